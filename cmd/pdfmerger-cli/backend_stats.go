@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// sizeBucketOrder 尺寸档的显示顺序
+var sizeBucketOrder = []string{pdf.SizeBucketSmall, pdf.SizeBucketMedium, pdf.SizeBucketLarge, pdf.SizeBucketHuge}
+
+// printBackendStats 输出各合并后端的统计
+func printBackendStats(w io.Writer, jsonOutput bool) error {
+	stats := pdf.BackendStats()
+
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "暂无后端统计")
+		return nil
+	}
+
+	if path := pdf.DefaultBackendStatsStore().Path(); path != "" {
+		fmt.Fprintf(w, "后端统计: %s\n", path)
+	}
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s: 尝试 %d，成功 %d (%.1f%%)\n",
+			stat.Backend, stat.Attempts, stat.Successes, stat.SuccessRate()*100)
+
+		classes := make([]string, 0, len(stat.Failures))
+		for class := range stat.Failures {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(w, "  失败 %-18s %d\n", class, stat.Failures[class])
+		}
+
+		for _, name := range sizeBucketOrder {
+			bucket, ok := stat.Buckets[name]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "  %-7s 尝试 %d，成功 %d，平均吞吐 %.2f MB/s\n",
+				name, bucket.Attempts, bucket.Successes, bucket.Throughput()/(1<<20))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// cleanupOptions 遗留文件清理的命令行选项
+type cleanupOptions struct {
+	action          string
+	includeNameOnly bool
+	assumeYes       bool
+	jsonOutput      bool
+}
+
+// splitList 按逗号拆分参数并去掉空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// cleanupLegacy 先输出遗留文件的扫描报告，确认后再删除或隔离
+func cleanupLegacy(dirs []string, options cleanupOptions, in io.Reader, out io.Writer) error {
+	var mode pdf.LegacyCleanupMode
+	switch options.action {
+	case "", "report":
+		mode = pdf.CleanupDryRun
+	case "delete":
+		mode = pdf.CleanupDelete
+	case "quarantine":
+		mode = pdf.CleanupQuarantine
+	default:
+		return fmt.Errorf("未知的处理方式: %s", options.action)
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("没有指定要扫描的目录")
+	}
+
+	report, err := pdf.CleanupLegacyArtifacts(dirs, pdf.LegacyCleanupOptions{Mode: pdf.CleanupDryRun})
+	if err != nil {
+		return err
+	}
+	if mode == pdf.CleanupDryRun || len(report.Actions) == 0 {
+		return writeCleanupReport(out, report, options.jsonOutput)
+	}
+
+	if !options.jsonOutput {
+		pdf.WriteLegacyReport(out, report)
+	}
+	if !options.assumeYes && !confirmCleanup(in, out, mode, options.includeNameOnly) {
+		fmt.Fprintln(out, "已取消，未修改任何文件")
+		return nil
+	}
+
+	log := out
+	if options.jsonOutput {
+		log = nil
+	}
+	report, err = pdf.CleanupLegacyArtifacts(dirs, pdf.LegacyCleanupOptions{
+		Mode:            mode,
+		IncludeNameOnly: options.includeNameOnly,
+		Log:             log,
+	})
+	if err != nil {
+		return err
+	}
+	if options.jsonOutput {
+		return writeCleanupReport(out, report, true)
+	}
+	return nil
+}
+
+// confirmCleanup 询问用户是否继续
+func confirmCleanup(in io.Reader, out io.Writer, mode pdf.LegacyCleanupMode, includeNameOnly bool) bool {
+	verb := "删除内容确认的遗留文件"
+	if mode == pdf.CleanupQuarantine {
+		verb = "将内容确认的遗留文件移入隔离目录"
+	}
+	if includeNameOnly {
+		verb += "，并隔离仅名称匹配的文件"
+	}
+	fmt.Fprintf(out, "即将%s，是否继续? [y/N] ", verb)
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// writeCleanupReport 输出文本或JSON格式的报告
+func writeCleanupReport(out io.Writer, report *pdf.LegacyCleanupReport, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	pdf.WriteLegacyReport(out, report)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/file"
+)
+
+// appConfig 启动时从配置文件和环境变量加载的配置，命令行参数在 main 中覆盖其中的值
+var appConfig = model.DefaultConfig()
+
+// loadAppConfig 按 -config、PDF_MERGER_CONFIG、默认路径的顺序找到配置文件并加载，
+// 再用环境变量覆盖。配置文件损坏或环境变量无效时向 warn 输出警告并继续使用其余配置。
+func loadAppConfig(flagPath string, warn io.Writer) *model.Config {
+	config := model.DefaultConfig()
+	path, err := model.ResolveConfigPath(flagPath, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(warn, "警告: 无法确定配置文件路径，使用默认配置: %v\n", err)
+	} else {
+		loaded, err := model.LoadConfig(path)
+		if err != nil {
+			fmt.Fprintf(warn, "警告: %v\n", err)
+		}
+		config = loaded
+	}
+
+	if err := model.ApplyEnv(config, os.Getenv); err != nil {
+		fmt.Fprintf(warn, "警告: %v\n", err)
+	}
+	return config
+}
+
+// newConfig 返回 appConfig 的副本，供每次合并创建控制器和合并器使用
+func newConfig() *model.Config {
+	config := *appConfig
+	config.CommonPasswords = append([]string(nil), appConfig.CommonPasswords...)
+	config.FilenameEncodings = append([]string(nil), appConfig.FilenameEncodings...)
+	return &config
+}
+
+// newFileManager 创建使用配置的临时目录和临时空间配额的文件管理器
+func newFileManager(config *model.Config) file.FileManager {
+	fileManager := file.NewFileManagerWithClock(config.TempDirectory, config.Clock)
+	fileManager.SetTempQuota(file.TempQuota{MaxBytes: config.MaxTempBytes, MaxFiles: config.MaxTempFiles})
+	return fileManager
+}
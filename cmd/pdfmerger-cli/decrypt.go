@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// runDecrypt 处理 -decrypt 模式：用 -password 移除输入文件的加密并写出到 -output，失败时退出。
+// vault 不为nil时，没有 -password 则使用保险库中保存的密码，解密成功的 -password 保存到保险库。
+// 输入未加密时直接复制。
+func runDecrypt(input, password, outputFile string, jsonOutput bool, vault pdf.PasswordVault) {
+	if _, err := os.Stat(input); os.IsNotExist(err) {
+		fmt.Printf("错误: 文件不存在: %s\n", input)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		fmt.Printf("错误: 无法创建输出目录: %v\n", err)
+		os.Exit(1)
+	}
+
+	serviceConfig := pdf.DefaultServiceConfig()
+	serviceConfig.PasswordVault = vault
+	err := pdf.NewPDFServiceWithConfig(serviceConfig).DecryptPDF(input, outputFile, password)
+	if jsonOutput {
+		printJSONResult(outputFile, nil, err)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Printf("解密失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已解密到: %s\n", outputFile)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// encryptionOptions 输出加密的命令行选项，两个密码都为空时不加密
+type encryptionOptions struct {
+	userPassword  string
+	ownerPassword string
+	permissions   *pdf.OutputPermissions
+}
+
+// parseEncryptionOptions 解析 -encrypt-user、-encrypt-owner 和 -permissions
+func parseEncryptionOptions(userPassword, ownerPassword, permissions string) (encryptionOptions, error) {
+	options := encryptionOptions{userPassword: userPassword, ownerPassword: ownerPassword}
+	if permissions == "" {
+		return options, nil
+	}
+	if userPassword == "" && ownerPassword == "" {
+		return options, fmt.Errorf("-permissions 需要与 -encrypt-user 或 -encrypt-owner 一起使用")
+	}
+	parsed, err := pdf.ParseOutputPermissions(permissions)
+	if err != nil {
+		return options, err
+	}
+	options.permissions = parsed
+	return options, nil
+}
+
+// applyTo 把加密选项写入合并选项
+func (e encryptionOptions) applyTo(options *pdf.MergeOptions) {
+	options.OutputUserPassword = e.userPassword
+	options.OutputOwnerPassword = e.ownerPassword
+	options.OutputPermissions = e.permissions
+}
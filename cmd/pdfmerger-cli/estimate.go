@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+	"os"
+	"time"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// progressEstimate 根据合并总进度和输入总字节数估计吞吐量和剩余时间，用于进度行末尾的提示。
+// 控制器只报告总进度，已处理的字节数按进度比例折算。
+type progressEstimate struct {
+	totalBytes int64
+	rate       *model.RateEstimator
+	progress   float64 // 已观察到的最大进度
+}
+
+// newProgressEstimate 按输入文件的总大小创建估计，无法读取的文件忽略
+func newProgressEstimate(files []string) *progressEstimate {
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return &progressEstimate{totalBytes: total, rate: model.NewRateEstimator()}
+}
+
+// observe 记录0.0-1.0的总进度，返回追加在进度行末尾的估计；还估计不出剩余时间时返回空字符串
+// 超过1.0的进度按1.0计算；比之前小的进度按之前的最大进度计算，避免估计被重置
+func (e *progressEstimate) observe(now time.Time, progress float64) string {
+	e.progress = math.Max(e.progress, math.Min(1, progress))
+	e.rate.Observe(now, int64(e.progress*float64(e.totalBytes)), e.totalBytes)
+	eta, ok := e.rate.ETA()
+	if !ok || e.progress >= 1 {
+		return ""
+	}
+	return i18n.T(msgProgressEstimate, e.rate.Rate()/(1024*1024), formatRemaining(eta))
+}
+
+// formatRemaining 把剩余时间取整到秒，一分钟以上取整到10秒，避免末尾数字频繁跳动
+func formatRemaining(d time.Duration) string {
+	if d >= time.Minute {
+		return d.Round(10 * time.Second).String()
+	}
+	return d.Round(time.Second).String()
+}
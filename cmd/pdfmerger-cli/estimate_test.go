@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+func TestProgressEstimate_ClampsAndIgnoresRegressions(t *testing.T) {
+	e := &progressEstimate{totalBytes: 1000, rate: model.NewRateEstimator()}
+	start := time.Unix(0, 0)
+
+	e.observe(start, 0.5)
+	// 乱序到达的较小进度不能让估计重新开始
+	e.observe(start.Add(time.Second), 0.3)
+	if done, _ := e.rate.Progress(); done != 500 {
+		t.Errorf("已处理 = %d，应保持 500", done)
+	}
+
+	// 超过100%的进度按100%计算，不再显示剩余时间
+	if hint := e.observe(start.Add(2*time.Second), 2.15); hint != "" {
+		t.Errorf("完成后仍显示估计 %q", hint)
+	}
+	if done, total := e.rate.Progress(); done != total {
+		t.Errorf("已处理 = %d，应为总量 %d", done, total)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// runExtract 处理 -extract 模式：从 -input 指定的单个文件中按页码范围提取页面，失败时退出
+func runExtract(input, ranges, outputFile string, jsonOutput bool) {
+	if strings.Contains(input, ",") {
+		fmt.Println("错误: -extract 只接受一个输入文件")
+		os.Exit(1)
+	}
+	input = strings.TrimSpace(input)
+	if _, err := os.Stat(input); os.IsNotExist(err) {
+		fmt.Printf("错误: 文件不存在: %s\n", input)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		fmt.Printf("错误: 无法创建输出目录: %v\n", err)
+		os.Exit(1)
+	}
+
+	err := pdf.NewPDFService().ExtractPageRanges(input, ranges, outputFile)
+	if jsonOutput {
+		printJSONResult(outputFile, nil, err)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Printf("提取失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已提取第 %s 页到: %s\n", ranges, outputFile)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractImages_ImagePagesFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "scan.pdf", 3)
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		output   string
+	}{
+		{"空格分隔", []string{"-image-pages", "2-3"}, 0, "已提取 0 个图像"},
+		{"等号形式", []string{"-image-pages=1-2,3"}, 0, "已提取 0 个图像"},
+		{"之后的选项照常解析", []string{"-image-pages", "1", "-out", "imgs"}, 0, "imgs"},
+		{"超出页数", []string{"-image-pages=4-"}, 1, "错误"},
+		{"无效范围", []string{"-image-pages=0-2"}, 1, "页码从1开始"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-input", "scan.pdf", "-extract-images"}, tt.args...)
+			stdout, stderr, code := runCLI(t, dir, nil, args...)
+			if code != tt.exitCode {
+				t.Fatalf("退出码应为 %d，实际 %d\n%s%s", tt.exitCode, code, stdout, stderr)
+			}
+			if !strings.Contains(stdout+stderr, tt.output) {
+				t.Errorf("输出应包含 %q，实际:\n%s%s", tt.output, stdout, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// checkExtractText 检查 -extract-text 能否与其他选项一起使用：加密的输出无法提取文字，
+// 标准输出已用于进度、JSON结果或 -output -，文字只能写到文件
+func checkExtractText(textPath string, encryption encryptionOptions) error {
+	if textPath == "" {
+		return nil
+	}
+	if encryption.userPassword != "" || encryption.ownerPassword != "" {
+		return errors.New("-extract-text 不能与 -encrypt-user、-encrypt-owner 同时使用")
+	}
+	if textPath == stdioPath {
+		return errors.New("-extract-text 需要指定文件路径，不支持写到标准输出")
+	}
+	return nil
+}
+
+// extractOutputText 处理 -extract-text：逐页提取合并输出的文字并写到textPath，每页之后写一个换页符。
+// 只有图像的页面写出空文字，警告由服务的日志输出；失败时删除写了一半的文字文件
+func extractOutputText(outputFile, textPath string) error {
+	service := pdf.NewPDFService()
+	if err := os.MkdirAll(filepath.Dir(textPath), 0755); err != nil {
+		return &ioError{fmt.Errorf("无法创建文字输出目录: %w", err)}
+	}
+	file, err := os.Create(textPath)
+	if err != nil {
+		return &ioError{fmt.Errorf("无法创建文字输出文件: %w", err)}
+	}
+	err = service.ExtractTextTo(outputFile, nil, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = &ioError{closeErr}
+	}
+	if err != nil {
+		os.Remove(textPath)
+	}
+	return err
+}
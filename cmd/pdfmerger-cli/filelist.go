@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// listFilePrefix -input 中以 @ 开头的参数是列表文件，例如 @files.txt；
+// 输入很多时用列表文件避开 Windows 命令行的长度限制
+const listFilePrefix = "@"
+
+// pdfHeaderWindow PDF文件头必须出现在文件的前1024字节内
+const pdfHeaderWindow = 1024
+
+// rangeSuffixPattern 行尾冒号之后像页码范围的部分（数字、连字符和逗号），
+// 用于区分 a.pdf:1-3 与 Windows 盘符 C:\a.pdf
+var rangeSuffixPattern = regexp.MustCompile(`^[\d\s,-]*\d[\d\s,-]*$`)
+
+// listEntry 列表文件中的一个输入，或 -input 中直接给出的一个路径
+type listEntry struct {
+	path   string          // 列表中的相对路径已按列表文件所在目录解析，可以是通配符或目录
+	ranges []pdf.PageRange // 行尾 :页码范围 指定的页面，为空时合并全部页面
+	source string          // 所在的列表文件，标准输入为 "-"，直接给出的路径为空
+	line   int             // 在列表中的行号，从1开始
+}
+
+// location 返回用于错误信息的位置，例如 "files.txt 第3行"
+func (e listEntry) location() string {
+	if e.source == stdioPath {
+		return fmt.Sprintf("标准输入第%d行", e.line)
+	}
+	return fmt.Sprintf("%s 第%d行", e.source, e.line)
+}
+
+// expandFileLists 把 -input 参数中的 @列表文件 替换为列表中的条目，其他参数原样保留；
+// "-" 在标准输入以PDF文件头开始时仍表示从标准输入读取PDF，否则从标准输入读取列表，
+// 列表中的相对路径按当前目录解析
+func expandFileLists(args []string, stdin *bufio.Reader) ([]listEntry, error) {
+	var entries []listEntry
+	for _, arg := range args {
+		switch {
+		case arg == stdioPath && !stdinIsPDF(stdin):
+			cwd, err := os.Getwd()
+			if err != nil {
+				return nil, err
+			}
+			list, err := readFileList(stdin, stdioPath, cwd)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, list...)
+		case strings.HasPrefix(arg, listFilePrefix):
+			list, err := openFileList(strings.TrimPrefix(arg, listFilePrefix))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, list...)
+		default:
+			entries = append(entries, listEntry{path: arg})
+		}
+	}
+	return entries, nil
+}
+
+// openFileList 读取列表文件，相对路径按列表文件所在目录解析
+func openFileList(path string) ([]listEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &ioError{fmt.Errorf("无法读取列表文件: %v", err)}
+	}
+	defer file.Close()
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return readFileList(file, path, dir)
+}
+
+// readFileList 解析列表：每行一个路径（UTF-8），忽略空行和以 # 开头的注释行；
+// 行尾可以用 -pages 的语法指定页码范围，例如 chapter1.pdf:1-3,5。错误信息包含出错的行号
+func readFileList(r io.Reader, source, baseDir string) ([]listEntry, error) {
+	var entries []listEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF") // 记事本保存的UTF-8带BOM
+		}
+		entry := listEntry{source: source, line: line}
+		if !utf8.ValidString(text) {
+			return nil, fmt.Errorf("%s: 不是有效的UTF-8文本", entry.location())
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		path := text
+		if idx := strings.LastIndex(text, ":"); idx > 0 && rangeSuffixPattern.MatchString(text[idx+1:]) {
+			path = strings.TrimSpace(text[:idx])
+			ranges, err := pdf.ParsePageRanges(path, text[idx+1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", entry.location(), err)
+			}
+			entry.ranges = ranges
+		}
+		if path == "" {
+			return nil, fmt.Errorf("%s: 缺少文件路径", entry.location())
+		}
+		if strings.HasPrefix(path, listFilePrefix) {
+			return nil, fmt.Errorf("%s: 列表文件中不能再引用列表文件 %s", entry.location(), path)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		entry.path = path
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &ioError{fmt.Errorf("无法读取列表 %s: %v", source, err)}
+	}
+	return entries, nil
+}
+
+// stdinIsPDF 标准输入的开头是否包含PDF文件头，只预读不消耗数据
+func stdinIsPDF(stdin *bufio.Reader) bool {
+	head, _ := stdin.Peek(pdfHeaderWindow)
+	return bytes.Contains(head, []byte("%PDF-"))
+}
+
+// entryPaths 返回条目的路径，供不按页码范围合并的模式使用；有条目指定了页码范围时返回错误
+func entryPaths(entries []listEntry) ([]string, error) {
+	paths := make([]string, len(entries))
+	for i, entry := range entries {
+		if len(entry.ranges) > 0 {
+			return nil, fmt.Errorf("%s: 指定页码范围需要同时使用 -pages", entry.location())
+		}
+		paths[i] = entry.path
+	}
+	return paths, nil
+}
+
+// expandEntries 由 expandInputs 逐个展开条目中的通配符和目录，展开得到的文件继承条目的页码范围和位置。
+// 不带页码范围的同一文件只保留第一次出现的位置；sortBy 不为空时按 name、mtime 或 size 对整个列表排序，
+// 同一文件的多个页码范围保持原来的先后顺序
+func expandEntries(entries []listEntry, recursive bool, sortBy string) ([]listEntry, error) {
+	if err := checkSortMode(sortBy); err != nil {
+		return nil, err
+	}
+
+	var expanded []listEntry
+	var files []string
+	seen := make(map[string]bool)  // 已出现的文件，用于排序
+	whole := make(map[string]bool) // 已出现的不带页码范围的文件
+	for _, entry := range entries {
+		matches, err := expandInputs([]string{entry.path}, recursive, "")
+		if err != nil {
+			if entry.source != "" {
+				return nil, fmt.Errorf("%s: %v", entry.location(), err)
+			}
+			return nil, err
+		}
+		for _, match := range matches {
+			if len(entry.ranges) == 0 {
+				if whole[match] {
+					continue
+				}
+				whole[match] = true
+			}
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+			file := entry
+			file.path = match
+			expanded = append(expanded, file)
+		}
+	}
+
+	if sortBy != "" {
+		if err := sortInputs(files, sortBy); err != nil {
+			return nil, err
+		}
+		rank := make(map[string]int, len(files))
+		for i, file := range files {
+			rank[file] = i
+		}
+		sort.SliceStable(expanded, func(i, j int) bool {
+			return rank[expanded[i].path] < rank[expanded[j].path]
+		})
+	}
+	return expanded, nil
+}
+
+// entryLocations 返回来自列表文件的输入在列表中的位置，同一文件出现多次时使用第一次的位置
+func entryLocations(entries []listEntry) map[string]string {
+	locations := make(map[string]string)
+	for _, entry := range entries {
+		if _, ok := locations[entry.path]; !ok && entry.source != "" {
+			locations[entry.path] = entry.location()
+		}
+	}
+	return locations
+}
+
+// describeInput 返回错误信息中的输入文件，来自列表文件时附带所在的行，例如 "a.pdf（files.txt 第3行）"
+func describeInput(file string, locations map[string]string) string {
+	if location, ok := locations[file]; ok {
+		return fmt.Sprintf("%s（%s）", file, location)
+	}
+	return file
+}
+
+// expandInputArgs 先展开参数中的列表文件，再由 expandEntries 展开通配符和目录并按 sortBy 排序
+func expandInputArgs(args []string, stdin *bufio.Reader, recursive bool, sortBy string) ([]string, error) {
+	entries, err := expandFileLists(args, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err = expandEntries(entries, recursive, sortBy); err != nil {
+		return nil, err
+	}
+	return entryPaths(entries)
+}
+
+// pageRangeEntries 解析 -pages 模式的 -input：@列表文件 和 "-" 按行读取列表（行尾可带页码范围），
+// 其余部分按 文件:页码范围 的语法解析；之后与其他模式一样由 expandEntries 展开通配符和目录并按 sortBy 排序
+func pageRangeEntries(input string, stdin *bufio.Reader, recursive bool, sortBy string) ([]listEntry, error) {
+	var entries []listEntry
+	var pending []string
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		specs, err := pdf.ParseFileRangeSpecs(strings.Join(pending, ","))
+		if err != nil {
+			return err
+		}
+		for _, spec := range specs {
+			entries = append(entries, listEntry{path: spec.File, ranges: spec.Ranges})
+		}
+		pending = nil
+		return nil
+	}
+
+	for _, token := range strings.Split(input, ",") {
+		arg := strings.TrimSpace(token)
+		if arg != stdioPath && !strings.HasPrefix(arg, listFilePrefix) {
+			pending = append(pending, token)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		list, err := expandFileLists([]string{arg}, stdin)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range list {
+			if entry.path == stdioPath {
+				return nil, errors.New("-pages 不支持从标准输入读取PDF")
+			}
+		}
+		entries = append(entries, list...)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, &pdf.PDFError{Type: pdf.ErrorInvalidInput, Message: "没有提供输入文件"}
+	}
+	return expandEntries(entries, recursive, sortBy)
+}
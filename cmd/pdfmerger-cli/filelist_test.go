@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeListFile 在dir中写出列表文件，返回文件路径
+func writeListFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// describeEntries 把条目写成 相对路径:页码范围#行号 的形式，便于比较
+func describeEntries(t *testing.T, dir string, entries []listEntry) []string {
+	t.Helper()
+	described := make([]string, len(entries))
+	for i, entry := range entries {
+		path := entry.path
+		if path != stdioPath {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path = filepath.ToSlash(rel)
+		}
+		ranges := make([]string, len(entry.ranges))
+		for j, r := range entry.ranges {
+			ranges[j] = r.String()
+		}
+		if len(ranges) > 0 {
+			path += ":" + strings.Join(ranges, ",")
+		}
+		described[i] = fmt.Sprintf("%s#%d", path, entry.line)
+	}
+	return described
+}
+
+func TestExpandFileLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		lists   map[string]string // 列表文件的相对路径和内容
+		args    []string          // @ 之后和普通参数是相对dir的路径
+		stdin   func(dir string) string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "忽略注释和空行",
+			lists: map[string]string{"list.txt": "# 第一章\n\n  a.pdf  \n\n# b.pdf\nb.pdf:2-3\n"},
+			args:  []string{"@list.txt"},
+			want:  []string{"a.pdf#3", "b.pdf:2-3#6"},
+		},
+		{
+			name:  "相对路径按列表文件所在目录解析",
+			lists: map[string]string{"sub/list.txt": "c.pdf\n../a.pdf:1\n"},
+			args:  []string{"a.pdf", "@sub/list.txt"},
+			want:  []string{"a.pdf#0", "sub/c.pdf#1", "a.pdf:1#2"},
+		},
+		{
+			name:    "列表中不能嵌套列表文件",
+			lists:   map[string]string{"list.txt": "a.pdf\n@other.txt\n", "other.txt": "b.pdf\n"},
+			args:    []string{"@list.txt"},
+			wantErr: "第2行: 列表文件中不能再引用列表文件 @other.txt",
+		},
+		{
+			name:    "列表文件不存在",
+			args:    []string{"@missing.txt"},
+			wantErr: "无法读取列表文件",
+		},
+		{
+			name:    "页码范围无效时报告行号",
+			lists:   map[string]string{"list.txt": "a.pdf\nb.pdf:0-2\n"},
+			args:    []string{"@list.txt"},
+			wantErr: "第2行",
+		},
+		{
+			name:  "标准输入是PDF",
+			args:  []string{"a.pdf", stdioPath},
+			stdin: func(string) string { return "%PDF-1.4\n%%EOF\n" },
+			want:  []string{"a.pdf#0", "-#0"},
+		},
+		{
+			name: "标准输入是列表",
+			args: []string{stdioPath},
+			stdin: func(dir string) string {
+				return "# 列表\n" + filepath.Join(dir, "a.pdf") + "\n\n" + filepath.Join(dir, "b.pdf") + ":1\n"
+			},
+			want: []string{"a.pdf#2", "b.pdf:1#4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.lists {
+				writeListFile(t, dir, name, content)
+			}
+			args := make([]string, len(tt.args))
+			for i, arg := range tt.args {
+				switch {
+				case arg == stdioPath:
+					args[i] = arg
+				case strings.HasPrefix(arg, listFilePrefix):
+					args[i] = listFilePrefix + filepath.Join(dir, strings.TrimPrefix(arg, listFilePrefix))
+				default:
+					args[i] = filepath.Join(dir, arg)
+				}
+			}
+			stdin := ""
+			if tt.stdin != nil {
+				stdin = tt.stdin(dir)
+			}
+
+			entries, err := expandFileLists(args, bufio.NewReader(strings.NewReader(stdin)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，应包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describeEntries(t, dir, entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("条目 = %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "sub/c.pdf", 3)
+	writeTestPDF(t, dir, "sub/d.pdf", 1)
+	writeTestPDF(t, dir, "sub/deep/e.pdf", 1)
+
+	tests := []struct {
+		name      string
+		list      string
+		recursive bool
+		sortBy    string
+		want      []string
+		wantErr   string
+	}{
+		{
+			name: "列表中的通配符",
+			list: "sub/*.pdf\n",
+			want: []string{"sub/c.pdf#1", "sub/d.pdf#1"},
+		},
+		{
+			name:      "列表中的目录",
+			list:      "a.pdf\nsub\n",
+			recursive: true,
+			want:      []string{"a.pdf#1", "sub/c.pdf#2", "sub/d.pdf#2", "sub/deep/e.pdf#2"},
+		},
+		{
+			name:   "按大小排序",
+			list:   "sub/c.pdf\nsub/d.pdf\na.pdf\n",
+			sortBy: "size",
+			want:   []string{"a.pdf#3", "sub/d.pdf#2", "sub/c.pdf#1"},
+		},
+		{
+			name:   "同一文件只保留一次，页码范围都保留",
+			list:   "sub/c.pdf:3\na.pdf\nsub/*.pdf\na.pdf\nsub/c.pdf:1\n",
+			sortBy: "name",
+			want:   []string{"a.pdf#2", "sub/c.pdf:3#1", "sub/c.pdf#3", "sub/c.pdf:1#5", "sub/d.pdf#3"},
+		},
+		{
+			name:    "通配符没有匹配时报告行号",
+			list:    "a.pdf\nmissing/*.pdf\n",
+			wantErr: "第2行: 没有匹配",
+		},
+		{
+			name:    "未知的排序方式",
+			list:    "a.pdf\n",
+			sortBy:  "pages",
+			wantErr: "未知的排序方式",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := readFileList(strings.NewReader(tt.list), "list.txt", dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err = expandEntries(entries, tt.recursive, tt.sortBy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，应包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describeEntries(t, dir, entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("条目 = %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageRangeEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "sub/c.pdf", 3)
+	writeTestPDF(t, dir, "sub/d.pdf", 2)
+	list := writeListFile(t, dir, "list.txt", "sub/*.pdf:2\n")
+
+	tests := []struct {
+		name    string
+		input   string
+		stdin   string
+		sortBy  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "直接给出的通配符带页码范围",
+			input: filepath.Join(dir, "sub", "*.pdf") + ":1," + filepath.Join(dir, "a.pdf"),
+			want:  []string{"sub/c.pdf:1#0", "sub/d.pdf:1#0", "a.pdf#0"},
+		},
+		{
+			name:   "列表和直接给出的输入一起排序",
+			input:  filepath.Join(dir, "sub", "c.pdf") + ":3,@" + list + "," + filepath.Join(dir, "a.pdf"),
+			sortBy: "name",
+			want:   []string{"a.pdf#0", "sub/c.pdf:3#0", "sub/c.pdf:2#1", "sub/d.pdf:2#1"},
+		},
+		{
+			name:    "标准输入是PDF",
+			input:   filepath.Join(dir, "a.pdf") + ",-",
+			stdin:   "%PDF-1.4\n%%EOF\n",
+			wantErr: "-pages 不支持从标准输入读取PDF",
+		},
+		{
+			name:  "标准输入是列表",
+			input: "-",
+			stdin: filepath.Join(dir, "a.pdf") + "\n" + filepath.Join(dir, "sub", "c.pdf") + ":2-3\n",
+			want:  []string{"a.pdf#1", "sub/c.pdf:2-3#2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := pageRangeEntries(tt.input, bufio.NewReader(strings.NewReader(tt.stdin)), false, tt.sortBy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，应包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describeEntries(t, dir, entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("条目 = %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileList_ErrorsIncludeListLocation(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "b.pdf", 1)
+	writeListFile(t, dir, "bad.pdf", "不是PDF")
+
+	tests := []struct {
+		name     string
+		list     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "验证失败",
+			list:     "a.pdf\nbad.pdf\nb.pdf\n",
+			args:     []string{"-strict"},
+			wantCode: exitValidationFailed,
+			want:     "bad.pdf（list.txt 第2行）",
+		},
+		{
+			name:     "文件不存在",
+			list:     "a.pdf\n\nmissing.pdf\n",
+			wantCode: exitIOFailed,
+			want:     "missing.pdf（list.txt 第3行）",
+		},
+		{
+			name:     "-pages 模式文件不存在",
+			list:     "a.pdf:1\nmissing.pdf:1\n",
+			args:     []string{"-pages"},
+			wantCode: 1,
+			want:     "missing.pdf（list.txt 第2行）",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeListFile(t, dir, "list.txt", tt.list)
+			args := append([]string{"-input", "@list.txt", "-output", "out.pdf"}, tt.args...)
+			stdout, stderr, code := runCLI(t, dir, nil, args...)
+			if code != tt.wantCode {
+				t.Fatalf("退出码 = %d，应为 %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout, stderr)
+			}
+			if !strings.Contains(stdout+stderr, tt.want) {
+				t.Errorf("输出中没有 %q\nstdout: %s\nstderr: %s", tt.want, stdout, stderr)
+			}
+		})
+	}
+}
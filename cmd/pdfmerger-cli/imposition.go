@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// parseImposition 解析 -nup、-booklet、-nup-margin 和 -nup-order，不拼版时返回nil。
+// 拼版后书签指向的页面不再在输出中，因此不能与 -bookmarks 同时使用
+func parseImposition(nup int, booklet bool, margin float64, order string, bookmarks bool) (*pdf.ImpositionOptions, error) {
+	options := &pdf.ImpositionOptions{NUp: nup, Booklet: booklet, Margin: margin, Order: pdf.NUpOrder(order)}
+	if !options.Enabled() {
+		return nil, nil
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if bookmarks {
+		return nil, errors.New("-nup 和 -booklet 不能与 -bookmarks 同时使用")
+	}
+	return options, nil
+}
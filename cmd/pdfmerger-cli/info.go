@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// infoMetadataKeys -info 输出的文档信息字段，按输出顺序排列
+var infoMetadataKeys = []string{"Title", "Author", "Subject", "Creator", "Producer", "Keywords"}
+
+// fileInfoReport -info 模式下单个文件的输出，JSON字段名是稳定接口，只增不改
+type fileInfoReport struct {
+	Path              string                `json:"path"`
+	Error             string                `json:"error,omitempty"`
+	FileSize          int64                 `json:"file_size"`
+	PageCount         int                   `json:"page_count"`
+	PageWidth         float64               `json:"page_width,omitempty"`  // 第一页显示时的宽度（点）
+	PageHeight        float64               `json:"page_height,omitempty"` // 第一页显示时的高度（点）
+	Version           string                `json:"version"`
+	Linearized        bool                  `json:"linearized"`
+	Conformance       string                `json:"conformance,omitempty"` // 声明的标准符合性，例如 PDF/A-2b，没有声明时为 none
+	Signatures        int                   `json:"signatures,omitempty"`  // 数字签名的数量
+	Signers           []string              `json:"signers,omitempty"`     // 可解析的签名者名称
+	Attachments       []pdf.AttachmentInfo  `json:"attachments,omitempty"` // 附件（嵌入文件）
+	FormFields        int                   `json:"form_fields,omitempty"` // 表单中的终端字段数量
+	Encryption        encryptionReport      `json:"encryption"`
+	Permissions       map[string]bool       `json:"permissions"`
+	PermissionSummary string                `json:"permission_summary"`
+	Metadata          map[string]string     `json:"metadata"`
+	PDFCPUVersion     string                `json:"pdfcpu_version,omitempty"`
+	Issues            []pdf.ValidationIssue `json:"issues,omitempty"` // 验证发现的问题，按严重程度排列
+}
+
+// encryptionReport 文件的加密信息
+type encryptionReport struct {
+	Encrypted     bool   `json:"encrypted"`
+	Method        string `json:"method,omitempty"`
+	KeyLength     int    `json:"key_length,omitempty"`
+	UserPassword  bool   `json:"user_password"`
+	OwnerPassword bool   `json:"owner_password"`
+}
+
+// runInfo 处理 -info 模式：输出每个文件的页数、版本、加密、权限和文档信息。
+// 单个文件以JSON对象输出，多个文件以数组输出；任何文件失败时以状态1退出。
+func runInfo(files []string, jsonOutput bool) {
+	service := pdf.NewPDFService()
+	reports := make([]fileInfoReport, 0, len(files))
+	failed := false
+	for _, file := range files {
+		report := collectFileInfo(service, file)
+		if report.Error != "" {
+			failed = true
+		}
+		reports = append(reports, report)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if len(reports) == 1 {
+			encoder.Encode(reports[0])
+		} else {
+			encoder.Encode(reports)
+		}
+	} else {
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			printFileInfo(os.Stdout, report)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// collectFileInfo 读取单个文件的信息，失败时只填写路径和错误
+func collectFileInfo(service pdf.PDFService, file string) fileInfoReport {
+	report := fileInfoReport{
+		Path:        file,
+		Permissions: map[string]bool{},
+		Metadata:    map[string]string{},
+	}
+
+	if validation, err := pdf.NewPDFValidator().GetValidationReport(file); err == nil {
+		report.Issues = pdf.TopIssues(validation.Issues, 0)
+	}
+
+	info, err := service.GetPDFInfo(file)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	report.FileSize = info.FileSize
+	report.PageCount = info.PageCount
+	report.PageWidth = info.PageWidth
+	report.PageHeight = info.PageHeight
+	report.Version = info.Version
+	report.Linearized = info.IsLinearized
+	report.Conformance = info.Conformance
+	report.Signatures = info.SignatureCount
+	report.Signers = info.Signers
+	if info.AttachmentCount > 0 {
+		report.Attachments, _ = service.ListAttachments(file)
+	}
+	report.FormFields = info.FormFieldCount
+	report.PDFCPUVersion = info.PDFCPUVersion
+	report.Encryption = encryptionReport{
+		Encrypted:     info.IsEncrypted,
+		Method:        info.EncryptionMethod,
+		KeyLength:     info.KeyLength,
+		UserPassword:  info.UserPassword,
+		OwnerPassword: info.OwnerPassword,
+	}
+	report.PermissionSummary = info.GetPermissionSummary()
+
+	// 未加密的文件没有权限限制，权限标志只在加密文件上有意义
+	report.Permissions = info.GetPermissionFlags()
+	if !info.IsEncrypted {
+		for name := range report.Permissions {
+			report.Permissions[name] = true
+		}
+	}
+
+	// 文档信息优先取自PDFInfo，缺少的字段用元数据补充
+	metadata := info.GetMetadataMap()
+	if extra, err := service.GetPDFMetadata(file); err == nil {
+		for _, key := range infoMetadataKeys {
+			if metadata[key] == "" && extra[key] != "" {
+				metadata[key] = extra[key]
+			}
+		}
+	}
+	for _, key := range infoMetadataKeys {
+		if metadata[key] != "" {
+			report.Metadata[key] = metadata[key]
+		}
+	}
+	return report
+}
+
+// printFileInfo 以文本形式输出单个文件的信息
+func printFileInfo(w io.Writer, report fileInfoReport) {
+	fmt.Fprintf(w, "文件: %s\n", report.Path)
+	if report.Error != "" {
+		fmt.Fprintf(w, "  错误: %s\n", report.Error)
+		printIssues(w, "  ", report.Issues)
+		return
+	}
+
+	fmt.Fprintf(w, "  大小: %s\n", (&pdf.PDFInfo{FileSize: report.FileSize}).GetFormattedSize())
+	fmt.Fprintf(w, "  页数: %d\n", report.PageCount)
+	if report.PageWidth > 0 {
+		fmt.Fprintf(w, "  页面尺寸: %.0f × %.0f 点\n", report.PageWidth, report.PageHeight)
+	}
+	if report.Version != "" {
+		fmt.Fprintf(w, "  版本: PDF %s\n", report.Version)
+	}
+	if report.Linearized {
+		fmt.Fprintln(w, "  线性化: 是")
+	}
+	if report.Conformance != "" {
+		fmt.Fprintf(w, "  标准: %s\n", report.Conformance)
+	}
+	if report.Signatures > 0 {
+		signers := ""
+		if len(report.Signers) > 0 {
+			signers = fmt.Sprintf(" (%s)", strings.Join(report.Signers, ", "))
+		}
+		fmt.Fprintf(w, "  数字签名: %d%s\n", report.Signatures, signers)
+	}
+	if len(report.Attachments) > 0 {
+		names := make([]string, len(report.Attachments))
+		for i, attachment := range report.Attachments {
+			names[i] = attachment.Name
+		}
+		fmt.Fprintf(w, "  附件: %d (%s)\n", len(report.Attachments), strings.Join(names, ", "))
+	}
+	if report.FormFields > 0 {
+		fmt.Fprintf(w, "  表单字段: %d\n", report.FormFields)
+	}
+
+	if report.Encryption.Encrypted {
+		details := []string{}
+		if report.Encryption.Method != "" {
+			details = append(details, report.Encryption.Method)
+		}
+		if report.Encryption.KeyLength > 0 {
+			details = append(details, fmt.Sprintf("%d位", report.Encryption.KeyLength))
+		}
+		if report.Encryption.UserPassword {
+			details = append(details, "需要用户密码")
+		}
+		if report.Encryption.OwnerPassword {
+			details = append(details, "设置了所有者密码")
+		}
+		fmt.Fprintf(w, "  加密: 是 %s\n", strings.Join(details, "，"))
+	} else {
+		fmt.Fprintln(w, "  加密: 否")
+	}
+
+	var allowed []string
+	for name, ok := range report.Permissions {
+		if ok {
+			allowed = append(allowed, name)
+		}
+	}
+	sort.Strings(allowed)
+	fmt.Fprintf(w, "  权限: %s", report.PermissionSummary)
+	if report.Encryption.Encrypted {
+		if len(allowed) == 0 {
+			fmt.Fprint(w, " (不允许任何操作)")
+		} else {
+			fmt.Fprintf(w, " (%s)", strings.Join(allowed, ", "))
+		}
+	}
+	fmt.Fprintln(w)
+
+	for _, key := range infoMetadataKeys {
+		if value, ok := report.Metadata[key]; ok {
+			fmt.Fprintf(w, "  %s: %s\n", key, value)
+		}
+	}
+	if report.PDFCPUVersion != "" {
+		fmt.Fprintf(w, "  pdfcpu版本: %s\n", report.PDFCPUVersion)
+	}
+	if len(report.Issues) > 0 {
+		fmt.Fprintln(w, "  诊断:")
+		printIssues(w, "    ", report.Issues)
+	}
+}
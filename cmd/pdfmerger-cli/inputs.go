@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// inputSortModes -sort 支持的排序方式
+var inputSortModes = map[string]bool{"": true, "name": true, "mtime": true, "size": true}
+
+// expandInputs 展开 -input 中的通配符和目录：通配符由程序自己匹配（Windows cmd 不会展开），
+// 目录收集其中的 *.pdf，recursive 时包含子目录。同一文件只保留第一次出现的位置。
+// sortBy 为空时保持参数顺序（通配符和目录内按路径排序），否则按 name、mtime 或 size 对整个列表排序。
+// 返回的路径都经过 model.NormalizePath 规范为绝对路径，Windows上的长路径和网络共享路径带有长路径前缀。
+func expandInputs(patterns []string, recursive bool, sortBy string) ([]string, error) {
+	if err := checkSortMode(sortBy); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		path = model.NormalizePath(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		// 长路径前缀 \\?\ 中的问号不是通配符
+		if strings.ContainsAny(model.TrimLongPathPrefix(pattern), "*?[") {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("无效的通配符 %s: %v", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("没有匹配 %s 的文件", pattern)
+			}
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					continue
+				}
+				add(match)
+			}
+			continue
+		}
+
+		path := model.NormalizePath(pattern)
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			// 不存在的路径保留，由后续检查报告
+			add(path)
+			continue
+		}
+		dirFiles, err := collectPDFs(path, recursive)
+		if err != nil {
+			return nil, err
+		}
+		if len(dirFiles) == 0 {
+			return nil, fmt.Errorf("目录中没有PDF文件: %s", pattern)
+		}
+		for _, file := range dirFiles {
+			add(file)
+		}
+	}
+
+	if sortBy != "" {
+		if err := sortInputs(files, sortBy); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// checkSortMode 检查 -sort 的值
+func checkSortMode(sortBy string) error {
+	if !inputSortModes[sortBy] {
+		return fmt.Errorf("未知的排序方式: %s（可用: name、mtime、size）", sortBy)
+	}
+	return nil
+}
+
+// collectPDFs 返回目录中扩展名为 .pdf（不区分大小写）的文件，按路径排序
+func collectPDFs(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.EqualFold(filepath.Ext(path), ".pdf") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无法读取目录 %s: %v", dir, err)
+	}
+	return files, nil
+}
+
+// sortInputs 按 name、mtime 或 size 排序，相同时按路径排序，保证结果可重现
+func sortInputs(files []string, sortBy string) error {
+	infos := make(map[string]os.FileInfo, len(files))
+	if sortBy != "name" {
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return fmt.Errorf("无法读取文件信息 %s: %v", file, err)
+			}
+			infos[file] = info
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		switch sortBy {
+		case "mtime":
+			if ta, tb := infos[a].ModTime(), infos[b].ModTime(); !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		case "size":
+			if sa, sb := infos[a].Size(), infos[b].Size(); sa != sb {
+				return sa < sb
+			}
+		}
+		return a < b
+	})
+	return nil
+}
+
+// filterValidInputs 用 validate 检查每个输入：有警告的文件输出警告后继续合并，验证失败的文件输出警告后跳过；
+// strict 时遇到第一个有警告或无效的文件就返回错误。来自列表文件的输入在信息中附带所在的行
+func filterValidInputs(files []string, validate func(string) controller.ValidationOutcome, strict bool, locations map[string]string, warn io.Writer) ([]string, error) {
+	valid := make([]string, 0, len(files))
+	for _, file := range files {
+		outcome := validate(file)
+		name := describeInput(file, locations)
+		if !outcome.Valid {
+			if strict {
+				return nil, &inputValidationError{fmt.Errorf("文件验证失败 %s: %v", name, outcome.Err)}
+			}
+			fmt.Fprintf(warn, "警告: 跳过无效文件 %s: %v\n", name, outcome.Err)
+			continue
+		}
+		if len(outcome.Warnings) > 0 && strict {
+			return nil, &inputValidationError{fmt.Errorf("文件验证有警告 %s: %s", name, strings.Join(outcome.Warnings, "; "))}
+		}
+		for _, warning := range outcome.Warnings {
+			fmt.Fprintf(warn, "警告: %s: %s\n", name, warning)
+		}
+		valid = append(valid, file)
+	}
+	return valid, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// runInterleave 处理 -mode interleave：交替合并两个输入的页面，失败时退出
+func runInterleave(files []string, outputFile string, reverseSecond, jsonOutput, linearize, adaptive, bookmarks, toc bool, stamps []*pdf.StampOptions, orientation orientationOptions, optimize optimizeOptions, encryption encryptionOptions) {
+	if len(files) != 2 {
+		fmt.Println("错误: 交替合并需要正好两个PDF文件（奇数页,偶数页）")
+		os.Exit(1)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			fmt.Printf("错误: 文件不存在: %s\n", file)
+			os.Exit(1)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		fmt.Printf("错误: 无法创建输出目录: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		err := mergeInterleaved(files[0], files[1], outputFile, reverseSecond, true, linearize, adaptive, bookmarks, toc, stamps, orientation, optimize, encryption)
+		printJSONResult(outputFile, nil, err)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("开始交替合并: %s + %s\n", files[0], files[1])
+	fmt.Printf("输出文件: %s\n", outputFile)
+	if err := mergeInterleaved(files[0], files[1], outputFile, reverseSecond, false, linearize, adaptive, bookmarks, toc, stamps, orientation, optimize, encryption); err != nil {
+		fmt.Printf("\n合并失败: %s\n", mergeErrorText(err))
+		if partial := pdf.PartialMergeResult(err); partial != nil {
+			printPartialResult(partial)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("✅ PDF合并完成！")
+}
+
+// mergeInterleaved 交替合并两个文件的页面，reverseSecond 时第二个文件从最后一页开始取
+func mergeInterleaved(fileA, fileB, outputFile string, reverseSecond, quiet, linearize, adaptive, bookmarks, toc bool, stamps []*pdf.StampOptions, orientation orientationOptions, optimize optimizeOptions, encryption encryptionOptions) error {
+	config := newConfig()
+	tempDir := config.TempDirectory
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+
+	options := &pdf.MergeOptions{
+		MaxMemoryUsage:     config.MaxMemoryUsage,
+		TempDirectory:      tempDir,
+		EnableGC:           true,
+		UseStreaming:       true,
+		OptimizeMemory:     true,
+		Linearize:          linearize,
+		AdaptiveBackends:   adaptive,
+		AddSourceBookmarks: bookmarks,
+		GenerateTOC:        toc,
+		Stamps:             stamps,
+	}
+	optimize.applyTo(options)
+	encryption.applyTo(options)
+	orientation.applyTo(options, []string{fileA, fileB})
+	merger := pdf.NewStreamingMerger(options)
+	defer merger.Close()
+
+	result, err := merger.MergeInterleaved(context.Background(), fileA, fileB, reverseSecond, outputFile, func(progress float64, message string) {
+		if !quiet {
+			fmt.Printf("\r进度: %d%% - %s", int(progress), message)
+		}
+	})
+	if err != nil {
+		if result != nil {
+			return &pdf.MergeError{Result: result, Err: err}
+		}
+		return err
+	}
+	if !quiet {
+		fmt.Printf("\n合并完成，共 %d 页，输出文件: %s\n", result.TotalPages, outputFile)
+		printOptimizeResult(result)
+	}
+	return nil
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"syscall"
+	"time"
 
 	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
 	"github.com/user/pdf-merger/internal/model"
-	"github.com/user/pdf-merger/pkg/file"
 	"github.com/user/pdf-merger/pkg/pdf"
 )
 
+// exitPartialMerge 合并成功但跳过了部分输入时的退出码，便于脚本区分完整合并和部分合并；
+// 与 exitValidationFailed 等失败的退出码不同，此时输出文件已写出
+const exitPartialMerge = 5
+
+// jobWatchdogInterval 等待合并结果时检查任务是否仍在运行的间隔
+const jobWatchdogInterval = 500 * time.Millisecond
+
 var (
 	Version   = "v1.0.0"
 	BuildTime = "unknown"
@@ -21,18 +33,315 @@ var (
 
 func main() {
 	var (
-		inputFiles  = flag.String("input", "", "输入PDF文件路径，用逗号分隔")
-		outputFile  = flag.String("output", "merged.pdf", "输出PDF文件路径")
+		inputFiles  = flag.String("input", "", "输入PDF文件路径，用逗号分隔；@files.txt 从列表文件读取，每行一个路径")
+		outputFile  = flag.String("output", "merged.pdf", "输出PDF文件路径 (未指定时写入配置的输出目录)")
+		configPath  = flag.String("config", "", "配置文件路径 (默认: 配置目录下的pdf-merger/config.json)")
+		profileName = flag.String("profile", "", "合并配置方案: 内置的 default、low-memory 或配置文件 Profiles 中的方案，命令行选项优先 (默认: default)")
+		maxMemoryMB = flag.Int64("max-memory", 0, "合并时的最大内存使用量，单位MB (默认: 配置文件中的值)")
 		showVersion = flag.Bool("version", false, "显示版本信息")
 		showHelp    = flag.Bool("help", false, "显示帮助信息")
+		jsonOutput  = flag.Bool("json", false, "以JSON格式输出结果")
+		verbose     = flag.Bool("verbose", false, "输出调试日志到标准错误")
+		vaultPath   = flag.String("vault", "", "密码保险库路径 (默认: 配置目录下的password_vault.json)")
+		vaultList   = flag.Bool("vault-list", false, "列出密码保险库中的条目")
+		vaultPurge  = flag.Bool("vault-purge", false, "清空密码保险库")
+		vaultRemove = flag.String("vault-remove", "", "按内容哈希删除密码保险库条目")
+		remoteURL   = flag.String("remote", "", "把输入上传到指定地址的合并服务并以NDJSON输出任务事件，完成后下载结果到 -output（需配合 -json）")
+		serveAddr   = flag.String("serve", "", "以HTTP服务运行并监听指定地址，例如 :8080")
+		linearize   = flag.Bool("linearize", false, "线性化输出文件（快速Web视图）")
+		bookmarks   = flag.Bool("bookmarks", false, "为每个输入文件添加指向其第一页的顶层书签")
+		toc         = flag.Bool("toc", false, "在输出开头插入列出各输入文件及起始页的目录页")
+		pageRanges  = flag.Bool("pages", false, "按 -input 中的 文件:页码范围 只合并指定页面，例如 a.pdf:1-3,b.pdf:5,7,9-")
+		extract     = flag.String("extract", "", "从 -input 指定的单个文件中提取页面，例如 1-5,8")
+		decrypt     = flag.String("decrypt", "", "移除指定PDF文件的加密，写出到 -output")
+		infoFiles   = flag.String("info", "", "显示PDF文件的页数、版本、加密、权限和文档信息，多个文件用逗号分隔")
+		validate    = flag.String("validate", "", "验证PDF文件并列出问题的严重程度、位置和修复建议，多个文件用逗号分隔")
+		workers     = flag.Int("workers", 0, "-validate 并行验证的工作协程数，指定后输出批量验证报告 (默认逐个验证)")
+		manifest    = flag.Bool("manifest", false, "在输出旁写出合并清单 (输出文件名.manifest.json)，再次合并到同一输出时报告与上次的差异")
+		verify      = flag.String("verify", "", "按合并时写出的 .manifest.json 清单校验输出文件的SHA-256，多个文件用逗号分隔")
+		password    = flag.String("password", "", "-decrypt 使用的用户密码或所有者密码 (未指定时使用密码保险库中保存的密码)")
+		mergeMode   = flag.String("mode", "", "合并模式: interleave 交替合并两个文件的页面（双面扫描）")
+		reverse2nd  = flag.Bool("reverse-second", false, "交替合并时第二个文件从最后一页开始取")
+		dryRun      = flag.Bool("dry-run", false, "只检查输入并输出合并预检报告，不写出文件")
+		statsFlag   = flag.Bool("backend-stats", false, "显示各合并后端的统计信息")
+		adaptive    = flag.Bool("adaptive-backends", false, "按历史统计选择合并后端顺序")
+		cleanupDirs = flag.String("cleanup-legacy", "", "扫描目录中旧版本遗留的 .fallback/.placeholder/临时文件，用逗号分隔")
+		cleanupMode = flag.String("cleanup-action", "report", "遗留文件的处理方式: report、delete 或 quarantine")
+		nameOnly    = flag.Bool("cleanup-name-only", false, "同时隔离仅文件名匹配、内容无法确认的遗留文件")
+		assumeYes   = flag.Bool("yes", false, "清理遗留文件时不再询问确认")
+		listSpaces  = flag.Bool("list-workspaces", false, "列出保留在磁盘上的任务工作区")
+		discardID   = flag.String("discard-workspace", "", "删除指定任务ID的工作区")
+		tempDir     = flag.String("temp-dir", "", "任务工作区所在的临时目录 (默认: 配置文件中的值或系统临时目录)")
+		recursive   = flag.Bool("recursive", false, "-input 中的目录包含子目录中的PDF文件")
+		sortBy      = flag.String("sort", "", "展开后的输入排序方式: name、mtime 或 size (默认保持参数顺序)")
+		strict      = flag.Bool("strict", false, "遇到无效或有验证警告的输入文件时中止，而不是跳过或继续")
+		timeout     = flag.Duration("timeout", 0, "合并的最长时间，例如 10m，超时后取消合并 (默认: 不限制)")
+		maxOutputMB = flag.Int64("max-output-size", 0, "输出文件的大小上限，单位MB，超出时中止合并 (默认: 不限制)")
+		maxPages    = flag.Int("max-output-pages", 0, "输出文件的页数上限，超出时在合并前中止 (默认: 不限制)")
+		encryptUser = flag.String("encrypt-user", "", "加密输出，打开文件需要的用户密码")
+		encryptOwn  = flag.String("encrypt-owner", "", "加密输出的所有者密码 (默认与用户密码相同)")
+		permissions = flag.String("permissions", "", "加密输出允许的操作，用逗号分隔，例如 print,copy (默认全部允许)")
+		watchDir    = flag.String("watch", "", "监视目录，按批次合并其中出现的PDF文件")
+		watchOutput = flag.String("output-dir", "", "-watch 和 -split 模式的输出目录 (-watch 默认: 配置的输出目录)")
+		batchWindow = flag.Duration("batch-window", 30*time.Second, "-watch 模式中目录安静多久后合并当前批次")
+		stableTime  = flag.Duration("stable-time", 2*time.Second, "-watch 模式中文件大小保持不变多久后才认为已写完")
+		stampText   = flag.String("stamp", "", "在每页底部居中添加页码，支持 {page}、{pages}、{filename}，例如 \"Page {page} of {pages}\"")
+		watermark   = flag.String("watermark", "", "在每页中心斜向添加半透明的水印文字，例如 DRAFT")
+		nup         = flag.Int("nup", 0, "把每2或4个页面缩小排列到一张输出页上，例如 -nup 2")
+		booklet     = flag.Bool("booklet", false, "按骑马钉印刷的顺序两两排列页面，页数补足到4的倍数")
+		nupMargin   = flag.Float64("nup-margin", 0, "-nup 和 -booklet 每个格子四周的边距（点）")
+		nupOrder    = flag.String("nup-order", string(pdf.NUpOrderAcross), "-nup 的排列顺序: across 先从左到右，down 先从上到下")
+		rotate      = flag.String("rotate", "", "按文件顺时针旋转页面，角度为 0、90、180、270，例如 scan.pdf=90,back.pdf=180")
+		normalize   = flag.Bool("normalize-orientation", false, "合并前把页面的 /Rotate 写入页面内容，使方向混杂的扫描件以正向合并")
+		optimize    = flag.Bool("optimize", false, "优化输出：合并相同的字体和图像，使用对象流和交叉引用流")
+		imageDPI    = flag.Int("image-dpi", 0, "配合 -optimize 把分辨率高于该值的图像降采样，例如 150 (默认不降采样)")
+		allowSigned = flag.Bool("allow-signed", false, "合并包含数字签名的输入时不输出警告（签名在输出中仍会失效）")
+		flatten     = flag.Bool("flatten-forms", false, "把表单字段展平到页面内容，而不是合并各输入的表单")
+		metaSource  = flag.String("metadata", pdf.MetadataSourceFirst, "输出元数据的来源: first 复制第一个输入的文档信息和XMP元数据，custom 只使用 -title 等指定的值，none 不设置")
+		metaTitle   = flag.String("title", "", "输出文档信息中的标题")
+		metaAuthor  = flag.String("author", "", "输出文档信息中的作者")
+		metaSubject = flag.String("subject", "", "输出文档信息中的主题")
+		metaKeyword = flag.String("keywords", "", "输出文档信息中的关键词")
+		maxParallel = flag.Int("max-concurrent", 0, "同时合并的分块数上限 (默认: CPU核数)")
+		chunkSize   = flag.Int("chunk-size", 0, "分块合并时每个分块的文件数，指定后不再自适应调整 (默认: 按文件大小和内存自动选择)")
+		memoryLimit = flag.Float64("memory-limit", 0, "内存使用达到 -max-memory 的该比例时暂停分块合并并清理内存，0到1之间，例如 0.8 (默认: 0.85)")
+		requireExt  = flag.Bool("require-pdf-ext", false, "只接受扩展名为 .pdf 的输入文件 (默认按文件头识别PDF，没有扩展名的文件也可以合并)")
+		split       = flag.String("split", "", "把指定PDF文件拆分为多个文件，写入 -output-dir (默认: 输入所在目录)")
+		splitEvery  = flag.Int("every", 0, "-split 按页数拆分时每个文件的页数")
+		splitBy     = flag.String("split-by", "pages", "-split 的拆分方式: pages 每 -every 页一个文件，bookmarks 在每个顶层书签处拆分")
+		extractText = flag.String("extract-text", "", "合并后把输出的文字逐页写到指定文件，页之间以换页符分隔")
+		extractImgs = flag.Bool("extract-images", false, "把 -input 指定的单个文件中的图像写到 -out，配合 -image-pages 1-10 只提取指定页面")
+		imagePages  = flag.String("image-pages", "", "-extract-images 只提取指定页面的图像，例如 1-10,15 (默认: 全部页面)")
+		imagesOut   = flag.String("out", "", "-extract-images 的输出目录 (默认: 输入所在目录)")
 	)
 
 	flag.Parse()
 
+	// 默认只输出警告和错误，-verbose 时输出合并过程的调试日志
+	if *verbose {
+		pdf.SetDefaultLogger(pdf.NewWriterLogger(os.Stderr, pdf.LogDebug))
+	}
+
+	// 配置文件和环境变量提供默认值，命令行参数优先
+	appConfig = loadAppConfig(*configPath, os.Stderr)
+	i18n.SetLocale(i18n.ResolveLocale(appConfig.Language, os.Getenv))
+	profile, err := appConfig.ResolveProfile(*profileName)
+	if err == nil {
+		err = applyProfileDefaults(profile)
+	}
+	var profileStreaming *pdf.StreamingConfig
+	if err == nil {
+		profileStreaming, err = pdf.ProfileStreamingConfig(profile)
+	}
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	if *tempDir != "" {
+		appConfig.TempDirectory = *tempDir
+	}
+	if *maxMemoryMB > 0 {
+		appConfig.MaxMemoryUsage = *maxMemoryMB * 1024 * 1024
+	}
+	if !flagSet("output") && appConfig.OutputDirectory != "" {
+		*outputFile = filepath.Join(appConfig.OutputDirectory, *outputFile)
+	}
+
+	if *vaultList || *vaultPurge || *vaultRemove != "" {
+		if err := manageVault(*vaultPath, *vaultList, *vaultPurge, *vaultRemove); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 设置了保险库主密码时，加密输入优先使用保险库中保存的密码，解密成功的密码保存到保险库
+	vault, err := openVault(*vaultPath)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *statsFlag {
+		if err := printBackendStats(os.Stdout, *jsonOutput); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *cleanupDirs != "" {
+		options := cleanupOptions{
+			action:          *cleanupMode,
+			includeNameOnly: *nameOnly,
+			assumeYes:       *assumeYes,
+			jsonOutput:      *jsonOutput,
+		}
+		if err := cleanupLegacy(splitList(*cleanupDirs), options, os.Stdin, os.Stdout); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *listSpaces || *discardID != "" {
+		ctrl := newWorkspaceController(appConfig.TempDirectory)
+		var err error
+		if *discardID != "" {
+			err = discardWorkspace(os.Stdout, ctrl, *discardID)
+		} else {
+			err = printWorkspaces(os.Stdout, ctrl, *jsonOutput)
+		}
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *remoteURL != "" && !*jsonOutput {
+		fmt.Println("错误: -remote 需要与 -json 一起使用")
+		os.Exit(1)
+	}
+
+	if *serveAddr != "" {
+		if err := runServe(*serveAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *showVersion {
-		fmt.Printf("PDF合并工具 (命令行版本) %s\n", Version)
-		fmt.Printf("构建时间: %s\n", BuildTime)
-		fmt.Printf("Git提交: %s\n", GitCommit)
+		fmt.Println(i18n.T(msgVersion, Version))
+		fmt.Println(i18n.T(msgBuildTime, BuildTime))
+		fmt.Println(i18n.T(msgGitCommit, GitCommit))
+		return
+	}
+
+	if *split != "" {
+		runSplit(*split, *splitBy, *splitEvery, *watchOutput, *jsonOutput)
+		return
+	}
+
+	if *decrypt != "" {
+		runDecrypt(*decrypt, *password, *outputFile, *jsonOutput, vault)
+		return
+	}
+
+	// -input - 和列表文件共用的标准输入，判断是PDF还是列表时预读的数据保留在缓冲中
+	stdin := bufio.NewReader(os.Stdin)
+
+	if *infoFiles != "" {
+		// 文件列表之后的参数也作为文件，支持 -info a.pdf b.pdf
+		files, err := expandInputArgs(append(splitList(*infoFiles), flag.Args()...), stdin, *recursive, *sortBy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		runInfo(files, *jsonOutput)
+		return
+	}
+
+	if *validate != "" {
+		files, err := expandInputArgs(append(splitList(*validate), flag.Args()...), stdin, *recursive, *sortBy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		if *workers > 0 {
+			runBatchValidate(files, *workers, *jsonOutput)
+			return
+		}
+		runValidate(files, *jsonOutput)
+		return
+	}
+
+	if *verify != "" {
+		files, err := expandInputArgs(append(splitList(*verify), flag.Args()...), stdin, *recursive, *sortBy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		runVerify(files, *jsonOutput)
+		return
+	}
+
+	if *watchDir != "" {
+		encryption, err := parseEncryptionOptions(*encryptUser, *encryptOwn, *permissions)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		stamps, err := parseStamps(*stampText, *watermark)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		imposition, err := parseImposition(*nup, *booklet, *nupMargin, *nupOrder, *bookmarks)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		orientation, err := parseOrientationOptions(*rotate, *normalize)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		optimization, err := parseOptimizeOptions(*optimize, *imageDPI)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		metadata, err := parseMetadataOptions(*metaSource, *metaTitle, *metaAuthor, *metaSubject, *metaKeyword)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		streaming, err := parseStreamingConfig(profileStreaming, *maxParallel, *chunkSize, *memoryLimit)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		if *watchOutput == "" {
+			*watchOutput = appConfig.OutputDirectory
+		}
+		if *watchOutput == "" {
+			fmt.Println("错误: -watch 需要 -output-dir 指定输出目录")
+			os.Exit(1)
+		}
+		options := watchOptions{
+			dir:         *watchDir,
+			outputDir:   *watchOutput,
+			batchWindow: *batchWindow,
+			stableTime:  *stableTime,
+			settings: mergeSettings{
+				linearize:      *linearize,
+				adaptive:       *adaptive,
+				bookmarks:      *bookmarks,
+				toc:            *toc,
+				strict:         *strict,
+				timeout:        *timeout,
+				limits:         outputLimits{maxBytes: *maxOutputMB * 1024 * 1024, maxPages: *maxPages},
+				encryption:     encryption,
+				stamps:         stamps,
+				imposition:     imposition,
+				orientation:    orientation,
+				optimize:       optimization,
+				allowSigned:    *allowSigned,
+				flattenForms:   *flatten,
+				requireExt:     *requireExt,
+				metadata:       metadata,
+				streaming:      streaming,
+				profile:        profile,
+				vault:          vault,
+				finishOnSignal: true,
+			},
+		}
+		if err := runWatch(options); err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -41,22 +350,153 @@ func main() {
 		return
 	}
 
-	// 解析输入文件
-	files := strings.Split(*inputFiles, ",")
-	for i, file := range files {
-		files[i] = strings.TrimSpace(file)
+	if *extract != "" {
+		runExtract(*inputFiles, *extract, *outputFile, *jsonOutput)
+		return
+	}
+
+	if *extractImgs {
+		runExtractImages(*inputFiles, *imagePages, *imagesOut, *jsonOutput)
+		return
+	}
+
+	encryption, err := parseEncryptionOptions(*encryptUser, *encryptOwn, *permissions)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	stamps, err := parseStamps(*stampText, *watermark)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	imposition, err := parseImposition(*nup, *booklet, *nupMargin, *nupOrder, *bookmarks)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	orientation, err := parseOrientationOptions(*rotate, *normalize)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	optimization, err := parseOptimizeOptions(*optimize, *imageDPI)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	metadata, err := parseMetadataOptions(*metaSource, *metaTitle, *metaAuthor, *metaSubject, *metaKeyword)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	streaming, err := parseStreamingConfig(profileStreaming, *maxParallel, *chunkSize, *memoryLimit)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *pageRanges {
+		if pdf.IsS3URI(*outputFile) {
+			fmt.Println("错误: -pages 不支持输出到对象存储")
+			os.Exit(1)
+		}
+		runPageRanges(*inputFiles, stdin, *recursive, *sortBy, *outputFile, *jsonOutput, *linearize, *adaptive, *bookmarks, *toc, *allowSigned, stamps, orientation, optimization, encryption)
+		return
+	}
+
+	// -input 中的 "-" 从标准输入读取，-output - 把结果写到标准输出，此时其他输出都写到标准错误
+	if *jsonOutput && *outputFile == stdioPath {
+		fmt.Println("错误: -output - 不能与 -json 同时使用")
+		os.Exit(1)
+	}
+	if err := checkExtractText(*extractText, encryption); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	// 先展开 @列表文件 和从标准输入读取的列表，通配符和目录在准备标准输入输出之后展开
+	entries, err := expandFileLists(splitList(*inputFiles), stdin)
+	var args []string
+	if err == nil {
+		args, err = entryPaths(entries)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		var cliIO *ioError
+		if errors.As(err, &cliIO) {
+			os.Exit(exitIOFailed)
+		}
+		os.Exit(1)
+	}
+	pipe, inputs, output, err := newPipeline(args, *outputFile, appConfig.TempDirectory, appConfig.MaxMemoryUsage, stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		var cliIO *ioError
+		if errors.As(err, &cliIO) {
+			os.Exit(exitIOFailed)
+		}
+		os.Exit(1)
+	}
+	location := pipe.location(*outputFile)
+	*outputFile = output
+
+	// 解析输入文件，展开通配符和目录；标准输入已由 newPipeline 保存为临时文件
+	for i := range entries {
+		entries[i].path = inputs[i]
+	}
+	entries, err = expandEntries(entries, *recursive, *sortBy)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		pipe.cleanup()
+		os.Exit(1)
+	}
+	files := make([]string, len(entries))
+	for i, entry := range entries {
+		files[i] = entry.path
+	}
+	locations := entryLocations(entries)
+
+	if *dryRun {
+		runDryRun(files, *jsonOutput)
+		pipe.cleanup()
+		return
+	}
+	if err := orientation.checkInputs(files); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		pipe.cleanup()
+		os.Exit(1)
+	}
+
+	switch *mergeMode {
+	case "":
+	case "interleave":
+		if !*allowSigned {
+			warnSignedInputs(os.Stderr, files)
+		}
+		runInterleave(files, *outputFile, *reverse2nd, *jsonOutput, *linearize, *adaptive, *bookmarks, *toc, stamps, orientation, optimization, encryption)
+		if err := pipe.finish(*outputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(exitIOFailed)
+		}
+		return
+	default:
+		fmt.Printf("错误: 未知的合并模式: %s\n", *mergeMode)
+		pipe.cleanup()
+		os.Exit(1)
 	}
 
 	if len(files) < 2 {
 		fmt.Println("错误: 至少需要两个PDF文件进行合并")
+		pipe.cleanup()
 		os.Exit(1)
 	}
 
 	// 验证输入文件
 	for _, file := range files {
 		if _, err := os.Stat(file); os.IsNotExist(err) {
-			fmt.Printf("错误: 文件不存在: %s\n", file)
-			os.Exit(1)
+			fmt.Printf("错误: 文件不存在: %s\n", describeInput(file, locations))
+			pipe.cleanup()
+			os.Exit(exitIOFailed)
 		}
 	}
 
@@ -64,57 +504,256 @@ func main() {
 	outputDir := filepath.Dir(*outputFile)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		fmt.Printf("错误: 无法创建输出目录: %v\n", err)
-		os.Exit(1)
+		pipe.cleanup()
+		os.Exit(exitIOFailed)
 	}
 
-	fmt.Printf("开始合并 %d 个PDF文件...\n", len(files))
-	fmt.Printf("输出文件: %s\n", *outputFile)
+	settings := mergeSettings{
+		quiet:        *jsonOutput,
+		linearize:    *linearize,
+		adaptive:     *adaptive,
+		bookmarks:    *bookmarks,
+		toc:          *toc,
+		strict:       *strict,
+		timeout:      *timeout,
+		limits:       outputLimits{maxBytes: *maxOutputMB * 1024 * 1024, maxPages: *maxPages},
+		encryption:   encryption,
+		stamps:       stamps,
+		imposition:   imposition,
+		orientation:  orientation,
+		optimize:     optimization,
+		allowSigned:  *allowSigned,
+		flattenForms: *flatten,
+		requireExt:   *requireExt,
+		metadata:     metadata,
+		streaming:    streaming,
+		profile:      profile,
+		vault:        vault,
+		manifest:     *manifest,
+		locations:    locations,
+	}
+	if *remoteURL != "" {
+		err := runRemote(newRemoteJob(*remoteURL, files, *outputFile, settings), os.Stdout)
+		if err == nil {
+			err = pipe.finish(*outputFile)
+		}
+		pipe.cleanup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			var cliIO *ioError
+			if errors.As(err, &cliIO) {
+				os.Exit(exitIOFailed)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if *jsonOutput {
+		skipped, err := mergePDFs(files, *outputFile, settings)
+		if err == nil && *extractText != "" {
+			err = extractOutputText(*outputFile, *extractText)
+		}
+		if err == nil {
+			err = pipe.finish(*outputFile)
+		}
+		pipe.cleanup()
+		printJSONResult(location, skipped, err)
+		if err != nil {
+			os.Exit(mergeExitCode(err))
+		}
+		if len(skipped) > 0 {
+			os.Exit(exitPartialMerge)
+		}
+		return
+	}
+
+	fmt.Println(i18n.T(msgMergeStart, len(files)))
+	fmt.Println(i18n.T(msgOutputFile, location))
 	fmt.Println()
 
 	// 执行合并
-	if err := mergePDFs(files, *outputFile); err != nil {
-		fmt.Printf("合并失败: %v\n", err)
-		os.Exit(1)
+	skipped, err := mergePDFs(files, *outputFile, settings)
+	if err == nil && *extractText != "" {
+		err = extractOutputText(*outputFile, *extractText)
+	}
+	if err == nil {
+		err = pipe.finish(*outputFile)
+	}
+	if err != nil {
+		fmt.Println(i18n.T(msgMergeFailed, mergeErrorText(err)))
+		if partial := pdf.PartialMergeResult(err); partial != nil {
+			printPartialResult(partial)
+		}
+		pipe.cleanup()
+		os.Exit(mergeExitCode(err))
 	}
 
-	fmt.Println("✅ PDF合并完成！")
+	if len(skipped) > 0 {
+		printSkippedFiles(skipped, locations)
+		fmt.Println(i18n.T(msgMergePartial))
+		os.Exit(exitPartialMerge)
+	}
+	fmt.Println(i18n.T(msgMergeComplete))
 }
 
+// jsonResult -json 模式下的输出对象
+type jsonResult struct {
+	Success       bool             `json:"success"`
+	OutputPath    string           `json:"output_path"`
+	Error         string           `json:"error,omitempty"`
+	SkippedFiles  []string         `json:"skipped_files,omitempty"`
+	PartialResult *pdf.MergeResult `json:"partial_result,omitempty"`
+}
+
+// printJSONResult 以JSON格式输出合并结果，包含跳过的输入，失败时包含部分结果
+func printJSONResult(outputPath string, skipped []string, err error) {
+	result := jsonResult{
+		Success:      err == nil,
+		OutputPath:   outputPath,
+		SkippedFiles: skipped,
+	}
+	if err != nil {
+		result.Error = mergeErrorText(err)
+		result.PartialResult = pdf.PartialMergeResult(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+}
+
+// mergeErrorText 返回合并失败时显示的错误。PDF处理后端不可用时直接显示该错误的消息和原因，
+// 不显示外层的包装
+func mergeErrorText(err error) string {
+	if unavailable := pdf.AdapterUnavailableError(err); unavailable != nil {
+		return fmt.Sprintf("%s: %v", unavailable.Message, unavailable.Cause)
+	}
+	return err.Error()
+}
+
+// printPartialResult 输出失败时已完成的部分
+func printPartialResult(partial *pdf.MergeResult) {
+	fmt.Println(i18n.T(msgFailedStage, partial.FailedStage))
+	fmt.Println(i18n.T(msgPartialFiles, len(partial.ValidatedFiles), len(partial.SkippedFiles)))
+	if partial.TotalChunks > 0 {
+		fmt.Println(i18n.T(msgCompletedChunks, partial.CompletedChunks, partial.TotalChunks))
+	}
+	for _, warning := range partial.Warnings {
+		fmt.Println(i18n.T(msgWarning, warning))
+	}
+}
+
+// printSkippedFiles 列出合并时跳过的输入文件，来自列表文件的输入附带所在的行
+func printSkippedFiles(skipped []string, locations map[string]string) {
+	fmt.Println(i18n.T(msgSkippedFiles, len(skipped)))
+	for _, file := range skipped {
+		fmt.Printf("  %s\n", describeInput(file, locations))
+	}
+}
+
+// showUsage 以当前语言输出帮助信息
 func showUsage() {
-	fmt.Println("PDF合并工具 (命令行版本)")
-	fmt.Println()
-	fmt.Println("用法:")
-	fmt.Println("  pdf-merger-cli -input file1.pdf,file2.pdf,file3.pdf -output merged.pdf")
-	fmt.Println()
-	fmt.Println("选项:")
-	fmt.Println("  -input   输入PDF文件路径，用逗号分隔 (必需)")
-	fmt.Println("  -output  输出PDF文件路径 (默认: merged.pdf)")
-	fmt.Println("  -version 显示版本信息")
-	fmt.Println("  -help    显示此帮助信息")
-	fmt.Println()
-	fmt.Println("示例:")
-	fmt.Println("  pdf-merger-cli -input doc1.pdf,doc2.pdf -output combined.pdf")
-	fmt.Println("  pdf-merger-cli -input *.pdf -output all.pdf")
-	fmt.Println("  pdf-merger-cli -version")
+	fmt.Print(i18n.T(msgUsage, vaultPassphraseEnv,
+		model.EnvConfigPath, model.EnvTempDir, model.EnvOutputDir, model.EnvMaxMemoryMB))
+}
+
+// outputLimits -max-output-size 和 -max-output-pages 指定的输出上限，0表示不限制
+type outputLimits struct {
+	maxBytes int64
+	maxPages int
 }
 
-func mergePDFs(inputFiles []string, outputFile string) error {
+// mergeSettings 合并输入文件时使用的命令行选项
+type mergeSettings struct {
+	quiet       bool
+	linearize   bool
+	adaptive    bool
+	bookmarks   bool
+	toc         bool
+	strict      bool
+	timeout     time.Duration // 大于0时限制合并的最长时间
+	limits      outputLimits
+	encryption  encryptionOptions
+	stamps      []*pdf.StampOptions    // 合并后添加的页码和水印
+	imposition  *pdf.ImpositionOptions // 印章之后的n-up或小册子拼版，nil时不拼版
+	orientation orientationOptions     // 按文件的旋转和页面方向规范
+	optimize    optimizeOptions        // 输出优化
+	allowSigned bool                   // 合并包含数字签名的输入时不输出警告
+	// flattenForms 把表单字段展平到页面内容，为false时合并各输入的表单
+	flattenForms bool
+	// requireExt 只接受 .pdf 扩展名的输入，为false时按文件头识别PDF
+	requireExt bool
+	// metadata 输出元数据的来源和覆盖的文档信息
+	metadata metadataOptions
+	// streaming 合并配置方案和 -max-concurrent、-chunk-size、-memory-limit 指定的流式合并配置，nil时使用默认配置
+	streaming *pdf.StreamingConfig
+	// profile -profile 选择的合并配置方案，先于命令行选项应用到服务配置
+	profile model.MergeProfile
+	// vault 查找和保存加密输入密码的保险库，nil时不使用
+	vault pdf.PasswordVault
+	// manifest 在输出旁写出合并清单，输出旁已有清单时报告与上次合并的差异
+	manifest bool
+	// finishOnSignal 收到 SIGINT/SIGTERM 时不取消任务，由调用方（-watch）等任务完成后再退出
+	finishOnSignal bool
+	// locations 来自列表文件的输入在列表中的位置，验证失败和跳过输入时显示
+	locations map[string]string
+}
+
+// mergePDFs 通过控制器合并输入文件，返回因无效而跳过的输入。收到 SIGINT/SIGTERM（未设置finishOnSignal时）、
+// 超过 timeout（大于0时）或任务结束却没有发出结果时取消任务并返回错误。
+// 超出 limits 时合并以 ErrorLimitExceeded 失败。
+func mergePDFs(inputFiles []string, outputFile string, settings mergeSettings) ([]string, error) {
+	quiet := settings.quiet
+
 	// 创建配置
-	config := model.DefaultConfig()
+	config := newConfig()
 
-	// 创建PDF服务
-	pdfService := pdf.NewPDFService()
+	// 创建PDF服务：先应用合并配置方案，再用命令行选项（未指定时已取方案中的值）覆盖
+	serviceConfig := pdf.DefaultServiceConfig()
+	if err := pdf.ApplyMergeProfile(serviceConfig, settings.profile); err != nil {
+		return nil, err
+	}
+	if flagSet("max-memory") {
+		serviceConfig.MaxMemoryUsage = config.MaxMemoryUsage
+	}
+	serviceConfig.Linearize = settings.linearize
+	serviceConfig.AdaptiveBackends = settings.adaptive
+	serviceConfig.SourceBookmarks = settings.bookmarks
+	serviceConfig.GenerateTOC = settings.toc
+	serviceConfig.MaxOutputSize = settings.limits.maxBytes
+	serviceConfig.MaxOutputPages = settings.limits.maxPages
+	serviceConfig.OutputUserPassword = settings.encryption.userPassword
+	serviceConfig.OutputOwnerPassword = settings.encryption.ownerPassword
+	serviceConfig.OutputPermissions = settings.encryption.permissions
+	serviceConfig.Stamps = settings.stamps
+	serviceConfig.Imposition = settings.imposition
+	serviceConfig.OptimizeOutput = settings.optimize.enabled
+	serviceConfig.OptimizeImagesDPI = settings.optimize.imageDPI
+	serviceConfig.FlattenForms = settings.flattenForms
+	serviceConfig.RequirePDFExtension = settings.requireExt
+	serviceConfig.MetadataSource = settings.metadata.source
+	serviceConfig.CustomMetadata = settings.metadata.custom
+	serviceConfig.StreamingConfig = settings.streaming
+	serviceConfig.PasswordVault = settings.vault
+	serviceConfig.WriteManifest = settings.manifest
+	serviceConfig.ToolVersion = Version
+	pdfService := pdf.NewPDFServiceWithConfig(serviceConfig)
 
 	// 创建文件管理器
-	fileManager := file.NewFileManager(config.TempDirectory)
+	fileManager := newFileManager(config)
 
 	// 创建控制器
 	ctrl := controller.NewController(pdfService, fileManager, config)
+	ctrl.SetPasswordVault(settings.vault)
 
-	// 设置进度回调
+	// 设置进度回调，进度行末尾附带吞吐量和剩余时间的估计
+	estimate := newProgressEstimate(inputFiles)
 	ctrl.SetProgressCallback(func(progress float64, status, detail string) {
+		if quiet {
+			return
+		}
 		percentage := int(progress * 100)
-		fmt.Printf("\r进度: %d%% - %s: %s", percentage, status, detail)
+		fmt.Print(i18n.T(msgProgress, percentage, status, detail) + estimate.observe(ctrl.Clock.Now(), progress))
 		if progress >= 1.0 {
 			fmt.Println()
 		}
@@ -132,27 +771,125 @@ func mergePDFs(inputFiles []string, outputFile string) error {
 		completionChan <- outputPath
 	})
 
-	// 验证文件
-	for _, file := range inputFiles {
-		if err := ctrl.ValidateFile(file); err != nil {
-			return fmt.Errorf("文件验证失败 %s: %v", file, err)
-		}
+	// 验证文件，无效文件按 -strict 中止或跳过，只有警告的文件在 -strict 时同样中止
+	validFiles, err := filterValidInputs(inputFiles, ctrl.ValidateFileOutcome, settings.strict, settings.locations, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(validFiles) < 2 {
+		return nil, &inputValidationError{errors.New("有效的PDF文件不足两个，无法合并")}
+	}
+	skipped := skippedInputs(inputFiles, validFiles)
+	if !settings.allowSigned {
+		warnSignedInputs(os.Stderr, validFiles)
 	}
 
+	// 在启动任务前注册信号，避免任务开始后收到的信号直接终止进程；
+	// finishOnSignal 时信号由调用方处理，nil通道不会收到信号
+	var signals chan os.Signal
+	if !settings.finishOnSignal {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+	}
+
+	// 按文件的旋转和方向规范在合并前应用到输入的临时副本
+	ctrl.Rotations = settings.orientation.forInputs(validFiles)
+	ctrl.NormalizeOrientation = settings.orientation.normalize
+
 	// 启动合并任务 (主文件 + 附加文件)
-	mainFile := inputFiles[0]
-	additionalFiles := inputFiles[1:]
+	mainFile := validFiles[0]
+	additionalFiles := validFiles[1:]
 
 	if err := ctrl.StartMergeJob(mainFile, additionalFiles, outputFile); err != nil {
-		return err
+		return nil, err
+	}
+
+	outputPath, err := waitForJob(ctrl, errorChan, completionChan, signals, settings.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !quiet {
+		fmt.Println(i18n.T(msgMergedTo, outputPath))
+		if result := ctrl.GetLastResult(); result != nil && result.Delta != nil && result.Delta.HasPrevious {
+			fmt.Println(i18n.T(msgDelta, result.Delta))
+		}
 	}
+	return skipped, nil
+}
 
-	// 等待结果
-	select {
-	case err := <-errorChan:
-		return err
-	case outputPath := <-completionChan:
-		fmt.Printf("合并完成，输出文件: %s\n", outputPath)
-		return nil
+// waitForJob 等待当前任务的错误或完成通知。收到信号或超时时取消任务；
+// 任务已经结束却没有发出任何通知时（例如执行中发生panic）返回错误，而不是一直等待。
+func waitForJob(ctrl *controller.Controller, errorChan <-chan error, completionChan <-chan string,
+	signals <-chan os.Signal, timeout time.Duration) (string, error) {
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
 	}
+	watchdog := time.NewTicker(jobWatchdogInterval)
+	defer watchdog.Stop()
+
+	for {
+		select {
+		case err := <-errorChan:
+			return "", err
+		case outputPath := <-completionChan:
+			return outputPath, nil
+		case sig := <-signals:
+			cancelJob(ctrl)
+			return "", fmt.Errorf("收到信号 %v，已取消合并", sig)
+		case <-deadline:
+			cancelJob(ctrl)
+			return "", fmt.Errorf("合并超过 %v 仍未完成，已取消", timeout)
+		case <-watchdog.C:
+			if ctrl.IsJobRunning() {
+				continue
+			}
+			// 回调在任务清除前发出，此时结果应已在通道中
+			select {
+			case err := <-errorChan:
+				return "", err
+			case outputPath := <-completionChan:
+				return outputPath, nil
+			default:
+				return "", errors.New("合并任务已结束，但没有收到完成或错误通知")
+			}
+		}
+	}
+}
+
+// cancelJob 取消当前任务，失败时只输出警告：调用方随后以错误退出
+func cancelJob(ctrl *controller.Controller) {
+	if err := ctrl.CancelCurrentJob(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 取消合并任务失败: %v\n", err)
+	}
+}
+
+// skippedInputs 返回 inputs 中不在 valid 里的文件，保持输入顺序
+func skippedInputs(inputs, valid []string) []string {
+	kept := make(map[string]bool, len(valid))
+	for _, file := range valid {
+		kept[file] = true
+	}
+	var skipped []string
+	for _, file := range inputs {
+		if !kept[file] {
+			skipped = append(skipped, file)
+		}
+	}
+	return skipped
+}
+
+// flagSet 判断命令行中是否显式指定了参数
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
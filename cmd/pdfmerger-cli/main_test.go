@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// cliMainEnv 设置时测试二进制直接作为命令行工具运行，命令行参数原样交给 main
+const cliMainEnv = "PDFMERGER_CLI_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(cliMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI 在dir中以子进程运行命令行工具，返回标准输出、标准错误和退出码
+func runCLI(t *testing.T, dir string, stdin io.Reader, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), cliMainEnv+"=1")
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("无法运行命令行工具: %v", err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// writeTestPDF 在dir中写出有pages页空白页面的PDF文件，返回文件路径
+func writeTestPDF(t *testing.T, dir, name string, pages int) string {
+	t.Helper()
+	kids := make([]string, pages)
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", i+3)
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages)
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestExitCodes_ValidationFailureVersusPartialMerge 退出码是脚本依赖的约定：验证失败 2、合并失败 3、
+// 读写失败 4，跳过无效输入后完成的合并 5，这里使用数值而不是常量
+func TestExitCodes_ValidationFailureVersusPartialMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "b.pdf", 2)
+	if err := os.WriteFile(filepath.Join(dir, "bad.pdf"), []byte("不是PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"跳过无效输入后完成合并", []string{"-input", "a.pdf,bad.pdf,b.pdf", "-output", "partial.pdf"}, 5},
+		{"-strict 时中止", []string{"-input", "a.pdf,bad.pdf,b.pdf", "-output", "strict.pdf", "-strict"}, 2},
+		{"有效输入不足两个", []string{"-input", "a.pdf,bad.pdf", "-output", "single.pdf"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runCLI(t, dir, nil, tt.args...)
+			if code != tt.wantCode {
+				t.Fatalf("退出码 = %d，应为 %d\nstdout: %s\nstderr: %s", code, tt.wantCode, stdout, stderr)
+			}
+		})
+	}
+}
+
+func TestStdoutOutput_IsExactlyMergedPDF(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "b.pdf", 2)
+	if err := os.WriteFile(filepath.Join(dir, "bad.pdf"), []byte("不是PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		others   string // 标准输入之后的输入
+		wantCode int
+	}{
+		{"完整合并", "b.pdf", 0},
+		{"跳过无效输入", "bad.pdf,b.pdf", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 同样的输入合并到文件作为对照，合并结果是确定的
+			if _, stderr, code := runCLI(t, dir, nil, "-input", "a.pdf,"+tt.others, "-output", "expected.pdf"); code != tt.wantCode {
+				t.Fatalf("合并到文件的退出码 = %d，应为 %d\nstderr: %s", code, tt.wantCode, stderr)
+			}
+			expected, err := os.ReadFile(filepath.Join(dir, "expected.pdf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdin, err := os.Open(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stdin.Close()
+			stdout, stderr, code := runCLI(t, dir, stdin, "-input", "-,"+tt.others, "-output", "-")
+			if code != tt.wantCode {
+				t.Fatalf("退出码 = %d，应为 %d\nstderr: %s", code, tt.wantCode, stderr)
+			}
+			if !bytes.Equal([]byte(stdout), expected) {
+				t.Errorf("标准输出与合并结果不同（%d 字节，应为 %d 字节），结尾: %q", len(stdout), len(expected), stdout[max(0, len(stdout)-80):])
+			}
+		})
+	}
+}
+
+// TestProgress_StaysWithinBounds 合并器逐行写出的说明不能被当作已完成的文件累加，
+// 进度不超过100%，也不倒退
+func TestProgress_StaysWithinBounds(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "b.pdf", 2)
+
+	stdout, stderr, code := runCLI(t, dir, nil, "-input", "a.pdf,b.pdf", "-output", "out.pdf")
+	if code != 0 {
+		t.Fatalf("退出码 = %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+	}
+	matches := regexp.MustCompile(`(?:进度|Progress): (\d+)%`).FindAllStringSubmatch(stdout+stderr, -1)
+	if len(matches) == 0 {
+		t.Fatalf("输出中没有进度行\nstdout: %s\nstderr: %s", stdout, stderr)
+	}
+	last := 0
+	for _, match := range matches {
+		percentage, _ := strconv.Atoi(match[1])
+		if percentage > 100 || percentage < last {
+			t.Fatalf("进度 %d%% 在 %d%% 之后\nstdout: %s", percentage, last, stdout)
+		}
+		last = percentage
+	}
+}
+
+// TestManifest_ReportsDeltaOnRemerge -manifest 写出的清单在再次合并到同一输出时用于报告差异
+func TestManifest_ReportsDeltaOnRemerge(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPDF(t, dir, "a.pdf", 1)
+	writeTestPDF(t, dir, "b.pdf", 2)
+
+	stdout, stderr, code := runCLI(t, dir, nil, "-input", "a.pdf,b.pdf", "-output", "out.pdf", "-manifest")
+	if code != 0 {
+		t.Fatalf("退出码 = %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.pdf.manifest.json")); err != nil {
+		t.Fatalf("应写出清单: %v", err)
+	}
+	if strings.Contains(stdout, "与上次合并相比") {
+		t.Errorf("首次合并不应报告差异\nstdout: %s", stdout)
+	}
+
+	writeTestPDF(t, dir, "c.pdf", 3)
+	stdout, stderr, code = runCLI(t, dir, nil, "-input", "a.pdf,b.pdf,c.pdf", "-output", "out.pdf", "-manifest")
+	if code != 0 {
+		t.Fatalf("退出码 = %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "与上次合并相比: 1 个新增") {
+		t.Errorf("摘要中应报告新增的输入\nstdout: %s", stdout)
+	}
+	if _, _, code := runCLI(t, dir, nil, "-verify", "out.pdf"); code != 0 {
+		t.Errorf("-verify 应通过再次合并后写出的清单，退出码 = %d", code)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "github.com/user/pdf-merger/internal/i18n"
+
+// 命令行帮助和合并进度的消息ID，翻译在 internal/i18n 的消息目录中
+const (
+	msgUsage            i18n.MessageID = "cli.usage"
+	msgVersion          i18n.MessageID = "cli.version"
+	msgBuildTime        i18n.MessageID = "cli.build_time"
+	msgGitCommit        i18n.MessageID = "cli.git_commit"
+	msgMergeStart       i18n.MessageID = "cli.merge_start"
+	msgOutputFile       i18n.MessageID = "cli.output_file"
+	msgProgress         i18n.MessageID = "cli.progress"
+	msgProgressEstimate i18n.MessageID = "cli.progress_estimate"
+	msgMergedTo         i18n.MessageID = "cli.merged_to"
+	msgDelta            i18n.MessageID = "cli.delta"
+	msgMergeFailed      i18n.MessageID = "cli.merge_failed"
+	msgFailedStage      i18n.MessageID = "cli.failed_stage"
+	msgPartialFiles     i18n.MessageID = "cli.partial_files"
+	msgCompletedChunks  i18n.MessageID = "cli.completed_chunks"
+	msgWarning          i18n.MessageID = "cli.warning"
+	msgSkippedFiles     i18n.MessageID = "cli.skipped_files"
+	msgMergePartial     i18n.MessageID = "cli.merge_partial"
+	msgMergeComplete    i18n.MessageID = "cli.merge_complete"
+	msgUploaded         i18n.MessageID = "cli.uploaded"
+)
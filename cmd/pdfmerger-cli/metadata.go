@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// metadataOptions 输出元数据的命令行选项
+type metadataOptions struct {
+	source string            // -metadata
+	custom map[string]string // -title、-author、-subject、-keywords 中非空的值
+}
+
+// parseMetadataOptions 解析 -metadata 和覆盖文档信息的选项
+func parseMetadataOptions(source, title, author, subject, keywords string) (metadataOptions, error) {
+	if !pdf.ValidMetadataSource(source) {
+		return metadataOptions{}, fmt.Errorf("-metadata 只能是 first、custom 或 none: %s", source)
+	}
+	custom := make(map[string]string)
+	for key, value := range map[string]string{"Title": title, "Author": author, "Subject": subject, "Keywords": keywords} {
+		if value != "" {
+			custom[key] = value
+		}
+	}
+	if len(custom) > 0 && source == pdf.MetadataSourceNone {
+		return metadataOptions{}, fmt.Errorf("-title、-author、-subject、-keywords 不能与 -metadata none 一起使用")
+	}
+	return metadataOptions{source: source, custom: custom}, nil
+}
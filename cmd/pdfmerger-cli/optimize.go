@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// optimizeOptions 输出优化的命令行选项
+type optimizeOptions struct {
+	enabled  bool // -optimize
+	imageDPI int  // -image-dpi，0时不降采样
+}
+
+// parseOptimizeOptions 解析 -optimize 和 -image-dpi
+func parseOptimizeOptions(optimize bool, imageDPI int) (optimizeOptions, error) {
+	if imageDPI < 0 {
+		return optimizeOptions{}, fmt.Errorf("-image-dpi 不能为负数: %d", imageDPI)
+	}
+	if imageDPI > 0 && !optimize {
+		return optimizeOptions{}, fmt.Errorf("-image-dpi 需要与 -optimize 一起使用")
+	}
+	return optimizeOptions{enabled: optimize, imageDPI: imageDPI}, nil
+}
+
+// applyTo 把优化选项写入合并选项
+func (o optimizeOptions) applyTo(options *pdf.MergeOptions) {
+	options.OptimizeOutput = o.enabled
+	options.OptimizeImagesDPI = o.imageDPI
+}
+
+// printOptimizeResult 输出优化前后的大小，未优化时不输出
+func printOptimizeResult(result *pdf.MergeResult) {
+	if result.OptimizedSize > 0 {
+		fmt.Printf("输出已优化: %d → %d 字节\n", result.OriginalSize, result.OptimizedSize)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// runPageRanges 处理 -pages 模式：解析 文件:页码范围 列表（可以包含 @列表文件），
+// 展开通配符和目录并按 sortBy 排序，检查文件后合并，失败时退出
+func runPageRanges(input string, stdin *bufio.Reader, recursive bool, sortBy, outputFile string, jsonOutput, linearize, adaptive, bookmarks, toc, allowSigned bool, stamps []*pdf.StampOptions, orientation orientationOptions, optimize optimizeOptions, encryption encryptionOptions) {
+	entries, err := pageRangeEntries(input, stdin, recursive, sortBy)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	locations := entryLocations(entries)
+	specs := make([]pdf.FileRangeSpec, len(entries))
+	inputs := make([]string, len(entries))
+	for i, entry := range entries {
+		if _, err := os.Stat(entry.path); os.IsNotExist(err) {
+			fmt.Printf("错误: 文件不存在: %s\n", describeInput(entry.path, locations))
+			os.Exit(1)
+		}
+		specs[i] = pdf.FileRangeSpec{File: entry.path, Ranges: entry.ranges}
+		inputs[i] = entry.path
+	}
+	if err := orientation.checkInputs(inputs); err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+	if !allowSigned {
+		warnSignedInputs(os.Stderr, inputs)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		fmt.Printf("错误: 无法创建输出目录: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		skipped, err := mergePageRanges(specs, outputFile, true, linearize, adaptive, bookmarks, toc, stamps, orientation, optimize, encryption)
+		printJSONResult(outputFile, skipped, err)
+		if err != nil {
+			os.Exit(1)
+		}
+		if len(skipped) > 0 {
+			os.Exit(exitPartialMerge)
+		}
+		return
+	}
+
+	fmt.Printf("开始从 %d 个PDF文件中提取页面并合并...\n", len(specs))
+	fmt.Printf("输出文件: %s\n", outputFile)
+	skipped, err := mergePageRanges(specs, outputFile, false, linearize, adaptive, bookmarks, toc, stamps, orientation, optimize, encryption)
+	if err != nil {
+		fmt.Printf("\n合并失败: %s\n", mergeErrorText(err))
+		if partial := pdf.PartialMergeResult(err); partial != nil {
+			printPartialResult(partial)
+		}
+		os.Exit(1)
+	}
+	if len(skipped) > 0 {
+		printSkippedFiles(skipped, locations)
+		fmt.Println("⚠️ PDF合并完成，但跳过了部分输入文件")
+		os.Exit(exitPartialMerge)
+	}
+	fmt.Println("✅ PDF合并完成！")
+}
+
+// mergePageRanges 按 -input 中每个文件的页码范围提取页面并合并，返回因无效而跳过的输入
+func mergePageRanges(specs []pdf.FileRangeSpec, outputFile string, quiet, linearize, adaptive, bookmarks, toc bool, stamps []*pdf.StampOptions, orientation orientationOptions, optimize optimizeOptions, encryption encryptionOptions) ([]string, error) {
+	config := newConfig()
+	tempDir := config.TempDirectory
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+
+	options := &pdf.MergeOptions{
+		MaxMemoryUsage:     config.MaxMemoryUsage,
+		TempDirectory:      tempDir,
+		EnableGC:           true,
+		UseStreaming:       true,
+		OptimizeMemory:     true,
+		Linearize:          linearize,
+		AdaptiveBackends:   adaptive,
+		AddSourceBookmarks: bookmarks,
+		GenerateTOC:        toc,
+		Stamps:             stamps,
+	}
+	optimize.applyTo(options)
+	encryption.applyTo(options)
+	inputs := make([]string, len(specs))
+	for i, spec := range specs {
+		inputs[i] = spec.File
+	}
+	orientation.applyTo(options, inputs)
+	merger := pdf.NewStreamingMerger(options)
+	defer merger.Close()
+
+	result, err := merger.MergeFilesWithPageRanges(specs, outputFile, func(progress float64, message string) {
+		if !quiet {
+			fmt.Printf("\r进度: %d%% - %s", int(progress), message)
+		}
+	})
+	if err != nil {
+		if result != nil {
+			return nil, &pdf.MergeError{Result: result, Err: err}
+		}
+		return nil, err
+	}
+	if !quiet {
+		fmt.Printf("\n合并完成，%d 个文件，共 %d 页，输出文件: %s\n", result.ProcessedFiles, result.TotalPages, outputFile)
+		printOptimizeResult(result)
+	}
+	return result.SkippedFiles, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// runDryRun 处理 -dry-run 模式：只做合并前的检查并输出预检报告，不写出任何文件
+func runDryRun(files []string, jsonOutput bool) {
+	config := newConfig()
+	tempDir := config.TempDirectory
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+
+	merger := pdf.NewStreamingMerger(&pdf.MergeOptions{
+		MaxMemoryUsage: config.MaxMemoryUsage,
+		TempDirectory:  tempDir,
+		UseStreaming:   true,
+		OptimizeMemory: true,
+	})
+	defer merger.Close()
+
+	report, err := merger.Preflight(context.Background(), files)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		printPreflightReport(os.Stdout, report)
+	}
+	if report.ValidFiles == 0 {
+		os.Exit(1)
+	}
+}
+
+// printPreflightReport 以文本形式输出预检报告
+func printPreflightReport(w io.Writer, report *pdf.PreflightReport) {
+	fmt.Fprintln(w, "合并预检（未写出任何文件）:")
+	for _, f := range report.Files {
+		status := "有效"
+		switch {
+		case f.Encrypted && !f.HasPassword:
+			status = "已加密，需要密码"
+		case !f.Valid:
+			status = "无效，将被跳过"
+		case f.Encrypted:
+			status = "已加密，将使用提供的密码解密"
+		}
+		pages := "页数未知"
+		if f.Pages > 0 {
+			pages = fmt.Sprintf("%d 页", f.Pages)
+		}
+		if f.PageSize != "" {
+			pages += " " + f.PageSize
+		}
+		fmt.Fprintf(w, "  %s  %.2f MB  %s  %s\n", f.File, float64(f.Size)/(1<<20), pages, status)
+		if f.Error != "" {
+			fmt.Fprintf(w, "      %s\n", f.Error)
+		}
+	}
+
+	fmt.Fprintf(w, "有效文件: %d/%d，加密文件: %d\n", report.ValidFiles, len(report.Files), report.EncryptedFiles)
+	if report.PagesComplete {
+		fmt.Fprintf(w, "总页数: %d\n", report.TotalPages)
+	} else {
+		fmt.Fprintf(w, "总页数: 至少 %d\n", report.TotalPages)
+	}
+	fmt.Fprintf(w, "预计输出大小: %.2f MB\n", float64(report.EstimatedOutputSize)/(1<<20))
+	if report.Strategy != "" {
+		fmt.Fprintf(w, "合并策略: %s\n", report.Strategy)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "警告: %s\n", warning)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// applyProfileDefaults 把方案中设置的选项作为命令行未指定的参数的值，命令行参数优先于方案。
+// 流式选项由 parseStreamingConfig 以方案的流式配置为基础处理；没有对应参数的选项在 mergePDFs 中应用
+func applyProfileDefaults(profile model.MergeProfile) error {
+	values := make(map[string]string)
+	setInt := func(name string, value int64) {
+		if value > 0 {
+			values[name] = strconv.FormatInt(value, 10)
+		}
+	}
+	setBool := func(name string, value bool) {
+		if value {
+			values[name] = "true"
+		}
+	}
+
+	merge := profile.Merge
+	setInt("max-memory", merge.MaxMemoryMB)
+	setBool("linearize", merge.Linearize)
+	setBool("bookmarks", merge.SourceBookmarks)
+	setBool("toc", merge.GenerateTOC)
+	setBool("flatten-forms", merge.FlattenForms)
+	setInt("max-output-size", merge.MaxOutputSizeMB)
+	setInt("max-output-pages", int64(merge.MaxOutputPages))
+	if merge.MetadataSource != "" {
+		values["metadata"] = merge.MetadataSource
+	}
+	setBool("optimize", profile.Writer.Optimize)
+	setInt("image-dpi", int64(profile.Writer.ImageDPI))
+
+	for name, value := range values {
+		if flagSet(name) {
+			continue
+		}
+		// 直接设置参数的值而不是调用 flag.Set，flagSet 仍只报告命令行中指定的参数
+		if err := flag.Lookup(name).Value.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
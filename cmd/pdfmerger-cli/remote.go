@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/internal/server"
+)
+
+// remoteJob -remote 模式的一次合并：上传到远程服务的输入和选项，以及下载结果的位置
+type remoteJob struct {
+	baseURL string              // 服务地址，例如 http://localhost:8080
+	token   string              // 访问令牌，为空时不发送 Authorization
+	files   []string            // 按合并顺序上传的输入
+	output  string              // 任务完成后下载输出的本地路径
+	options server.MergeOptions // 随上传发送的合并选项
+}
+
+// newRemoteJob 按命令行设置创建远程任务，访问令牌取自与 -serve 相同的环境变量
+func newRemoteJob(baseURL string, files []string, output string, settings mergeSettings) remoteJob {
+	job := remoteJob{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   os.Getenv(server.TokenEnv),
+		files:   files,
+		output:  output,
+		options: server.MergeOptions{
+			OutputName:           filepath.Base(output),
+			Strict:               settings.strict,
+			GenerateTOC:          settings.toc,
+			NormalizeOrientation: settings.orientation.normalize,
+		},
+	}
+	// 服务按上传的文件名指定旋转角度
+	for path, degrees := range settings.orientation.forInputs(files) {
+		if job.options.Rotations == nil {
+			job.options.Rotations = make(map[string]int)
+		}
+		job.options.Rotations[filepath.Base(path)] = degrees
+	}
+	return job
+}
+
+// runRemote 把输入上传到远程服务的 POST /merge，跟随任务的事件流（/jobs/{id}/events）逐行输出NDJSON，
+// 任务完成后把结果下载到 job.output。任务失败、被取消或流在终止事件之前中断时返回错误
+func runRemote(job remoteJob, out io.Writer) error {
+	status, err := job.submit()
+	if err != nil {
+		return err
+	}
+	if err := job.follow(status.ID, out); err != nil {
+		return err
+	}
+	return job.download(status.ID)
+}
+
+// newRequest 创建发往远程服务的请求，设置了访问令牌时附带 Authorization
+func (j remoteJob) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, j.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("无效的远程地址: %w", err)
+	}
+	if j.token != "" {
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+	return req, nil
+}
+
+// submit 以multipart流式上传选项和输入文件，不在内存中缓存文件内容，返回服务接受的任务状态
+func (j remoteJob) submit() (*server.JobStatus, error) {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(j.writeForm(form))
+	}()
+
+	req, err := j.newRequest(http.MethodPost, "/merge", body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("无法上传到远程服务: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, remoteStatusError(resp)
+	}
+
+	var status server.JobStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil || status.ID == "" {
+		return nil, fmt.Errorf("无法解析远程任务: %v", err)
+	}
+	return &status, nil
+}
+
+// writeForm 依次写入 options 字段和按合并顺序排列的 files 字段
+func (j remoteJob) writeForm(form *multipart.Writer) error {
+	options, err := form.CreateFormField("options")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(options).Encode(j.options); err != nil {
+		return err
+	}
+	for _, path := range j.files {
+		if err := copyFormFile(form, path); err != nil {
+			return err
+		}
+	}
+	return form.Close()
+}
+
+// copyFormFile 把path的内容写入新的 files 字段
+func copyFormFile(form *multipart.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ioError{fmt.Errorf("无法读取输入文件 %s: %w", path, err)}
+	}
+	defer f.Close()
+	part, err := form.CreateFormFile("files", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
+
+// follow 订阅任务的事件流，将事件逐行以NDJSON输出，直到终止事件
+func (j remoteJob) follow(jobID string, out io.Writer) error {
+	req, err := j.newRequest(http.MethodGet, "/jobs/"+jobID+"/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", server.ContentTypeNDJSON)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("无法连接远程服务: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return remoteStatusError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event model.ProgressEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("无法解析远程事件: %w", err)
+		}
+		fmt.Fprintf(out, "%s\n", scanner.Bytes())
+
+		if !event.Terminal {
+			continue
+		}
+		switch event.State {
+		case model.ProgressStateCompleted:
+			return nil
+		case model.ProgressStateFailed:
+			return fmt.Errorf("远程任务失败: %s", event.Error)
+		default:
+			return fmt.Errorf("远程任务已%s", event.State)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取远程事件失败: %w", err)
+	}
+	return fmt.Errorf("远程事件流在任务结束前中断")
+}
+
+// download 把任务的输出下载到 j.output：先写到同目录的临时文件，完整下载后再替换输出
+func (j remoteJob) download(jobID string) error {
+	req, err := j.newRequest(http.MethodGet, "/jobs/"+jobID+"/result", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("无法下载远程结果: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return remoteStatusError(resp)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(j.output), "."+filepath.Base(j.output)+".download-*")
+	if err != nil {
+		return &ioError{fmt.Errorf("无法创建输出文件: %w", err)}
+	}
+	_, err = io.Copy(temp, resp.Body)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), j.output)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return &ioError{fmt.Errorf("无法写出远程结果 %s: %w", j.output, err)}
+	}
+	return nil
+}
+
+// remoteStatusError 把远程服务的错误响应转换为错误，附带响应正文的第一行
+func remoteStatusError(resp *http.Response) error {
+	line, _ := bufio.NewReader(io.LimitReader(resp.Body, 1024)).ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return fmt.Errorf("远程服务返回状态 %s: %s", resp.Status, line)
+	}
+	return fmt.Errorf("远程服务返回状态 %s", resp.Status)
+}
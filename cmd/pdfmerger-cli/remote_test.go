@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/internal/server"
+	"github.com/user/pdf-merger/pkg/file"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// newRemoteServer 启动使用真实PDF服务、要求访问令牌的合并服务
+func newRemoteServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	config := model.DefaultConfig()
+	config.TempDirectory = dir
+	ctrl := controller.NewController(pdf.NewPDFService(), file.NewFileManager(dir), config)
+	srv := server.New(ctrl, server.Options{Token: token})
+	t.Cleanup(srv.Close)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestRunRemote_UploadsFollowsAndDownloads(t *testing.T) {
+	ts := newRemoteServer(t, "secret")
+	dir := t.TempDir()
+	files := []string{writeTestPDF(t, dir, "a.pdf", 1), writeTestPDF(t, dir, "b.pdf", 2)}
+	output := filepath.Join(dir, "out", "merged.pdf")
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	job := newRemoteJob(ts.URL+"/", files, output, mergeSettings{toc: true})
+	job.token = "secret"
+	var out bytes.Buffer
+	if err := runRemote(job, &out); err != nil {
+		t.Fatalf("远程合并失败: %v\n%s", err, out.String())
+	}
+
+	// 每行都是一个事件，最后一行是完成的终止事件
+	var events []model.ProgressEvent
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var event model.ProgressEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("输出不是NDJSON: %q", scanner.Text())
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		t.Fatal("没有输出任务事件")
+	}
+	last := events[len(events)-1]
+	if !last.Terminal || last.State != model.ProgressStateCompleted {
+		t.Errorf("最后一个事件应为完成的终止事件，实际 %+v", last)
+	}
+
+	// 结果下载到 -output，目录页加在两个输入的3页之前
+	pages, err := pdf.CountPagesInFile(output, nil)
+	if err != nil {
+		t.Fatalf("无法读取下载的结果: %v", err)
+	}
+	if pages <= 3 {
+		t.Errorf("下载的结果应包含目录页和3个输入页，实际 %d 页", pages)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(output), ".merged.pdf.download-*")); len(leftovers) > 0 {
+		t.Errorf("不应遗留下载临时文件: %v", leftovers)
+	}
+}
+
+func TestRunRemote_ReportsRejectedUpload(t *testing.T) {
+	ts := newRemoteServer(t, "secret")
+	dir := t.TempDir()
+	files := []string{writeTestPDF(t, dir, "a.pdf", 1), writeTestPDF(t, dir, "b.pdf", 1)}
+	output := filepath.Join(dir, "merged.pdf")
+
+	// 没有访问令牌时服务拒绝上传，不写出输出
+	job := newRemoteJob(ts.URL, files, output, mergeSettings{})
+	job.token = ""
+	err := runRemote(job, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("期望未授权错误，实际 %v", err)
+	}
+	if fileExists(output) {
+		t.Error("上传被拒绝时不应写出输出")
+	}
+}
+
+func TestNewRemoteJob_SendsRotationsByUploadName(t *testing.T) {
+	orientation, err := parseOrientationOptions("scans/back.pdf=180", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := newRemoteJob("http://localhost:8080", []string{"scans/front.pdf", "scans/back.pdf"}, "out/merged.pdf",
+		mergeSettings{strict: true, orientation: orientation})
+
+	want := server.MergeOptions{
+		OutputName:           "merged.pdf",
+		Strict:               true,
+		NormalizeOrientation: true,
+		Rotations:            map[string]int{"back.pdf": 180},
+	}
+	got, _ := json.Marshal(job.options)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(got, wantJSON) {
+		t.Errorf("上传的选项 = %s，应为 %s", got, wantJSON)
+	}
+}
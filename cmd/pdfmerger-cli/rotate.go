@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// orientationOptions 页面方向的命令行选项
+type orientationOptions struct {
+	rotations map[string]int // -rotate 中的 文件=角度，文件可以写路径或只写文件名
+	normalize bool           // -normalize-orientation
+}
+
+// parseOrientationOptions 解析 -rotate（例如 scan.pdf=90,back.pdf=180）和 -normalize-orientation
+func parseOrientationOptions(rotate string, normalize bool) (orientationOptions, error) {
+	options := orientationOptions{normalize: normalize}
+	for _, item := range splitList(rotate) {
+		idx := strings.LastIndex(item, "=")
+		if idx <= 0 {
+			return options, fmt.Errorf("无效的 -rotate 项 %q，格式为 文件=角度", item)
+		}
+		degrees, err := strconv.Atoi(strings.TrimSpace(item[idx+1:]))
+		if err != nil || pdf.ValidateRotation(degrees) != nil {
+			return options, fmt.Errorf("无效的 -rotate 项 %q，角度必须是 0、90、180 或 270", item)
+		}
+		if options.rotations == nil {
+			options.rotations = make(map[string]int)
+		}
+		options.rotations[strings.TrimSpace(item[:idx])] = degrees
+	}
+	return options, nil
+}
+
+// forInputs 返回按输入路径的旋转角度。-rotate 中的文件与输入路径相同或与输入的文件名相同时匹配
+func (o orientationOptions) forInputs(inputs []string) map[string]int {
+	if len(o.rotations) == 0 {
+		return nil
+	}
+	rotations := make(map[string]int)
+	for _, input := range inputs {
+		for file, degrees := range o.rotations {
+			if matchesInput(file, input) {
+				rotations[input] = degrees
+			}
+		}
+	}
+	return rotations
+}
+
+// checkInputs 检查 -rotate 中的每个文件都对应某个输入，避免拼写错误的文件名被静默忽略
+func (o orientationOptions) checkInputs(inputs []string) error {
+	for file := range o.rotations {
+		found := false
+		for _, input := range inputs {
+			if matchesInput(file, input) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("-rotate 指定的文件不在输入中: %s", file)
+		}
+	}
+	return nil
+}
+
+// applyTo 把方向选项写入合并选项
+func (o orientationOptions) applyTo(options *pdf.MergeOptions, inputs []string) {
+	options.Rotations = o.forInputs(inputs)
+	options.NormalizeOrientation = o.normalize
+}
+
+// matchesInput 判断 -rotate 中的文件是否指向该输入
+func matchesInput(file, input string) bool {
+	return filepath.Clean(file) == filepath.Clean(input) || file == filepath.Base(input)
+}
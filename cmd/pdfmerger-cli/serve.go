@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/server"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// shutdownTimeout 收到退出信号后等待进行中的请求结束的时长
+const shutdownTimeout = 30 * time.Second
+
+// runServe 以HTTP服务运行合并任务队列，收到 SIGINT/SIGTERM 时停止接受请求并取消未完成的任务
+func runServe(addr string) error {
+	config := newConfig()
+	ctrl := controller.NewController(
+		pdf.NewPDFServiceWithConfig(pdf.DefaultServiceConfig()),
+		newFileManager(config),
+		config,
+	)
+	// 服务长时间运行，定期删除过期的临时文件
+	defer ctrl.StartTempSweep(config.TempSweepAge)()
+
+	token := os.Getenv(server.TokenEnv)
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "警告: 未设置 %s，服务不验证访问令牌\n", server.TokenEnv)
+	}
+	srv := server.New(ctrl, server.Options{Token: token})
+	defer srv.Close()
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "合并服务监听 %s\n", addr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-shutdown
+	return nil
+}
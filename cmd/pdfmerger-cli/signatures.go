@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// warnSignedInputs 输入包含数字签名时输出醒目的警告：合并后的文件中这些签名都会失效。
+// 无法读取的输入不在这里报告，由之后的验证处理
+func warnSignedInputs(w io.Writer, files []string) {
+	var lines []string
+	for _, file := range files {
+		report, err := pdf.ReadSignatures(file)
+		if err != nil || report.Count == 0 {
+			continue
+		}
+		line := fmt.Sprintf("  %s: %d 个签名", file, report.Count)
+		if len(report.Signers) > 0 {
+			line += fmt.Sprintf("（%s）", strings.Join(report.Signers, "、"))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	banner := strings.Repeat("!", 60)
+	fmt.Fprintln(w, banner)
+	fmt.Fprintln(w, "⚠️  警告: 以下输入包含数字签名，合并后的文件中这些签名将失效")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "  如果这是预期行为，使用 -allow-signed 关闭此警告")
+	fmt.Fprintln(w, banner)
+}
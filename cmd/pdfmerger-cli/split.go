@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// splitJSONResult -split 模式的JSON输出，失败时包含已写出的文件
+type splitJSONResult struct {
+	Success     bool     `json:"success"`
+	OutputFiles []string `json:"output_files"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// runSplit 处理 -split 模式：按 -every 页或顶层书签把输入拆分到 -output-dir（默认为输入所在目录），失败时退出
+func runSplit(input, mode string, every int, outputDir string, jsonOutput bool) {
+	if _, err := os.Stat(input); os.IsNotExist(err) {
+		fmt.Printf("错误: 文件不存在: %s\n", input)
+		os.Exit(1)
+	}
+
+	opts := &pdf.SplitOptions{
+		Mode:       pdf.SplitMode(mode),
+		EveryPages: every,
+		OutputDir:  outputDir,
+	}
+	if !jsonOutput {
+		opts.Progress = func(progress float64, message string) {
+			fmt.Printf("[%3.0f%%] %s\n", progress*100, message)
+		}
+	}
+
+	files, err := pdf.NewPDFService().SplitPDF(input, opts)
+	if jsonOutput {
+		result := splitJSONResult{Success: err == nil, OutputFiles: files}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Printf("拆分失败: %v\n", err)
+		if len(files) > 0 {
+			fmt.Printf("已写出的 %d 个文件保留在输出目录中\n", len(files))
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已拆分为 %d 个文件\n", len(files))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// parseStamps 解析 -stamp 和 -watermark，返回按顺序添加的印章（先水印后页码，页码不被水印遮挡）
+func parseStamps(template, watermark string) ([]*pdf.StampOptions, error) {
+	var stamps []*pdf.StampOptions
+	if watermark != "" {
+		stamps = append(stamps, pdf.WatermarkStamp(watermark))
+	}
+	if template != "" {
+		stamps = append(stamps, pdf.PageNumberStamp(template))
+	}
+	for _, stamp := range stamps {
+		if err := stamp.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return stamps, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// 退出码，便于脚本区分失败的原因；参数错误等其他失败以 1 退出
+const (
+	// exitValidationFailed 输入未通过验证而没有合并：-strict 时遇到无效或有警告的输入，或有效输入不足两个
+	exitValidationFailed = 2
+	// exitMergeFailed 输入通过验证，但合并过程失败
+	exitMergeFailed = 3
+	// exitIOFailed 读取输入、创建输出目录或写出结果失败
+	exitIOFailed = 4
+)
+
+// stdioPath -input 中表示标准输入、-output 中表示标准输出的路径
+const stdioPath = "-"
+
+// defaultStdinLimit 没有配置 MaxMemoryUsage 时标准输入的大小上限
+const defaultStdinLimit = 100 * 1024 * 1024
+
+// inputValidationError 输入文件未通过验证导致合并无法进行
+type inputValidationError struct {
+	err error
+}
+
+func (e *inputValidationError) Error() string { return e.err.Error() }
+
+func (e *inputValidationError) Unwrap() error { return e.err }
+
+// ioError 读写命令行的输入输出失败
+type ioError struct {
+	err error
+}
+
+func (e *ioError) Error() string { return e.err.Error() }
+
+func (e *ioError) Unwrap() error { return e.err }
+
+// mergeExitCode 返回合并失败时的退出码
+func mergeExitCode(err error) int {
+	var validation *inputValidationError
+	if errors.As(err, &validation) {
+		return exitValidationFailed
+	}
+	var cliIO *ioError
+	var pathErr *fs.PathError
+	if errors.As(err, &cliIO) || errors.As(err, &pathErr) {
+		return exitIOFailed
+	}
+	var pdfErr *pdf.PDFError
+	if errors.As(err, &pdfErr) && (pdfErr.Type == pdf.ErrorIO || pdfErr.Type == pdf.ErrorPermission) {
+		return exitIOFailed
+	}
+	return exitMergeFailed
+}
+
+// pipeline 标准输入输出模式：-input 中的 "-" 从标准输入读取，-output 为 "-" 时把结果写到标准输出，
+// 为 s3://bucket/key 时上传到对象存储。合并只处理文件路径，因此标准输入先写入临时文件，
+// 输出先写入临时文件，合并完成后再复制到标准输出或上传
+type pipeline struct {
+	dir    string         // 保存标准输入和临时输出的目录，不需要时为空
+	stdout *os.File       // 写出结果的标准输出，不写到标准输出时为nil
+	remote *pdf.S3Backend // 上传结果的对象存储，不上传时为nil
+}
+
+// newPipeline 按输入和输出准备标准输入输出模式，返回替换 "-" 之后的输入和输出路径，标准输入从 stdin 读取。
+// 写到标准输出时把 os.Stdout 换成标准错误且不再恢复，使进度和日志不会混入结果；调用方结束时调用 finish 或 cleanup
+func newPipeline(inputs []string, output, tempDir string, stdinLimit int64, stdin io.Reader) (*pipeline, []string, string, error) {
+	stdinCount := 0
+	for _, input := range inputs {
+		if input == stdioPath {
+			stdinCount++
+		}
+	}
+	if stdinCount > 1 {
+		return nil, nil, "", errors.New("标准输入只能作为一个输入文件")
+	}
+	p := &pipeline{}
+	if pdf.IsS3URI(output) {
+		// 凭证和端点来自 AWS_* 环境变量，在合并前检查，避免合并完成后才发现无法上传
+		config, err := pdf.S3ConfigFromEnv(output, os.Getenv)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if p.remote, err = pdf.NewS3Backend(config); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	if stdinCount == 0 && output != stdioPath && p.remote == nil {
+		return p, inputs, output, nil
+	}
+
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, nil, "", &ioError{fmt.Errorf("无法创建临时目录: %v", err)}
+	}
+	dir, err := os.MkdirTemp(tempDir, "pdfmerger-pipe-")
+	if err != nil {
+		return nil, nil, "", &ioError{fmt.Errorf("无法创建临时目录: %v", err)}
+	}
+	p.dir = dir
+
+	resolved := append([]string(nil), inputs...)
+	for i, input := range resolved {
+		if input != stdioPath {
+			continue
+		}
+		path := filepath.Join(dir, "stdin.pdf")
+		if err := spoolStdin(stdin, path, stdinLimit); err != nil {
+			p.cleanup()
+			return nil, nil, "", err
+		}
+		resolved[i] = path
+	}
+
+	if output == stdioPath {
+		output = filepath.Join(dir, "output.pdf")
+		p.stdout = os.Stdout
+		os.Stdout = os.Stderr
+	} else if p.remote != nil {
+		// 合并和验证都在本地临时文件上进行，通过后才上传
+		output = filepath.Join(dir, "output.pdf")
+	}
+	return p, resolved, output, nil
+}
+
+// spoolStdin 把 r 写入 path，超过 limit 字节时失败
+func spoolStdin(r io.Reader, path string, limit int64) error {
+	if limit <= 0 {
+		limit = defaultStdinLimit
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return &ioError{fmt.Errorf("无法保存标准输入: %v", err)}
+	}
+	written, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return &ioError{fmt.Errorf("读取标准输入失败: %v", err)}
+	}
+	if written > limit {
+		return &ioError{fmt.Errorf("标准输入超过 %d 字节的上限", limit)}
+	}
+	if written == 0 {
+		return &ioError{errors.New("标准输入为空")}
+	}
+	return nil
+}
+
+// location 返回向用户报告的输出位置：上传时为对象存储URI，否则为 output
+func (p *pipeline) location(output string) string {
+	if p.remote != nil {
+		return p.remote.Location()
+	}
+	return output
+}
+
+// finish 合并成功后把输出复制到标准输出或上传到对象存储，并清理临时文件
+func (p *pipeline) finish(output string) error {
+	defer p.cleanup()
+	if p.remote != nil {
+		// 上传按重试策略重试，失败时删除未完成的分段上传
+		if err := p.remote.Promote(output, output); err != nil {
+			return &ioError{fmt.Errorf("上传合并结果失败: %w", err)}
+		}
+		fmt.Fprintln(os.Stderr, i18n.T(msgUploaded, p.remote.Location()))
+		return nil
+	}
+	if p.stdout == nil {
+		return nil
+	}
+	f, err := os.Open(output)
+	if err != nil {
+		return &ioError{fmt.Errorf("无法读取合并结果: %v", err)}
+	}
+	defer f.Close()
+	if _, err := io.Copy(p.stdout, f); err != nil {
+		return &ioError{fmt.Errorf("写出到标准输出失败: %v", err)}
+	}
+	return nil
+}
+
+// cleanup 删除临时文件。os.Stdout 保持为标准错误直到进程退出，
+// 合并结果之后输出的完成信息、跳过的输入等状态行不会追加到标准输出的结果中
+func (p *pipeline) cleanup() {
+	if p.dir != "" {
+		os.RemoveAll(p.dir)
+	}
+}
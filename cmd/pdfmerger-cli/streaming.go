@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// parseStreamingConfig 以base（合并配置方案的流式配置，nil时为默认配置）为基础解析 -max-concurrent、-chunk-size
+// 和 -memory-limit，都未指定时返回base。
+// -chunk-size 固定每个分块的文件数并关闭自适应分块；-memory-limit 是内存使用达到 -max-memory 的多大比例时
+// 暂停分块合并并清理内存，警告阈值不高于该值
+func parseStreamingConfig(base *pdf.StreamingConfig, maxConcurrent, chunkSize int, memoryLimit float64) (*pdf.StreamingConfig, error) {
+	if maxConcurrent < 0 {
+		return nil, fmt.Errorf("-max-concurrent 不能为负数: %d", maxConcurrent)
+	}
+	if chunkSize < 0 {
+		return nil, fmt.Errorf("-chunk-size 不能为负数: %d", chunkSize)
+	}
+	if memoryLimit < 0 || memoryLimit > 1 {
+		return nil, fmt.Errorf("-memory-limit 必须在0到1之间: %v", memoryLimit)
+	}
+	if maxConcurrent == 0 && chunkSize == 0 && memoryLimit == 0 {
+		return base, nil
+	}
+
+	config := pdf.DefaultStreamingConfig()
+	if base != nil {
+		copied := *base
+		config = &copied
+	}
+	if maxConcurrent > 0 {
+		config.MaxConcurrentChunks = maxConcurrent
+	}
+	if chunkSize > 0 {
+		config.MinChunkSize = chunkSize
+		config.MaxChunkSize = chunkSize
+		config.EnableAdaptiveChunking = false
+	}
+	if memoryLimit > 0 {
+		config.MemoryCriticalThreshold = memoryLimit
+		if config.MemoryWarningThreshold > memoryLimit {
+			config.MemoryWarningThreshold = memoryLimit
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
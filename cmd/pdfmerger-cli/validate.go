@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// validateReport -validate 模式下单个文件的输出，JSON字段名是稳定接口，只增不改
+type validateReport struct {
+	Path   string                `json:"path"`
+	Valid  bool                  `json:"valid"`
+	Error  string                `json:"error,omitempty"`
+	Issues []pdf.ValidationIssue `json:"issues"`
+}
+
+// runValidate 处理 -validate 模式：输出每个文件的结构化诊断（严重程度、类别、位置和修复建议）。
+// 单个文件以JSON对象输出，多个文件以数组输出；任何文件无效时以状态1退出。
+func runValidate(files []string, jsonOutput bool) {
+	validator := pdf.NewPDFValidator()
+	reports := make([]validateReport, 0, len(files))
+	failed := false
+	for _, file := range files {
+		report := validateReport{Path: file, Issues: []pdf.ValidationIssue{}}
+		if result, err := validator.GetValidationReport(file); err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Valid = result.IsValid
+			report.Issues = pdf.TopIssues(result.Issues, 0)
+		}
+		if !report.Valid {
+			failed = true
+		}
+		reports = append(reports, report)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if len(reports) == 1 {
+			encoder.Encode(reports[0])
+		} else {
+			encoder.Encode(reports)
+		}
+	} else {
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			printValidateReport(os.Stdout, report)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runBatchValidate 处理 -validate 配合 -workers 的模式：用workers个协程并行验证文件，
+// 输出每个文件的结果、耗时和汇总。中断时输出已完成部分的报告；任何文件无效或被跳过时以状态1退出。
+func runBatchValidate(files []string, workers int, jsonOutput bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config := pdf.DefaultServiceConfig()
+	config.TempDirectory = appConfig.TempDirectory
+	if !jsonOutput {
+		config.BatchProgress = func(done, total int, result pdf.BatchFileResult) {
+			fmt.Fprintf(os.Stderr, "\r已验证 %d/%d", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+	}
+	report, err := pdf.NewPDFServiceWithConfig(config).ValidateBatch(ctx, files, workers)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		printBatchValidateReport(os.Stdout, report)
+	}
+
+	if err != nil || report.Invalid > 0 || report.Skipped > 0 {
+		os.Exit(1)
+	}
+}
+
+// printBatchValidateReport 以文本形式输出批量验证报告，只列出无效的文件
+func printBatchValidateReport(w io.Writer, report *pdf.BatchValidationReport) {
+	for _, result := range report.Results {
+		if result.Valid || result.Skipped {
+			continue
+		}
+		fmt.Fprintf(w, "无效: %s (%v)\n", result.Path, result.Duration.Round(time.Millisecond))
+		fmt.Fprintf(w, "  %s\n", result.Error)
+	}
+	fmt.Fprintf(w, "共 %d 个文件: 有效 %d，无效 %d，跳过 %d，%d 个工作协程，耗时 %v\n",
+		report.Total, report.Valid, report.Invalid, report.Skipped, report.Workers, report.Duration.Round(time.Millisecond))
+	errorTypes := make([]string, 0, len(report.ErrorTypes))
+	for errorType := range report.ErrorTypes {
+		errorTypes = append(errorTypes, errorType)
+	}
+	sort.Strings(errorTypes)
+	for _, errorType := range errorTypes {
+		fmt.Fprintf(w, "  %s: %d\n", errorType, report.ErrorTypes[errorType])
+	}
+}
+
+// printValidateReport 以文本形式输出单个文件的验证结果，问题按严重程度排列
+func printValidateReport(w io.Writer, report validateReport) {
+	fmt.Fprintf(w, "文件: %s\n", report.Path)
+	if report.Error != "" {
+		fmt.Fprintf(w, "  错误: %s\n", report.Error)
+		return
+	}
+	if report.Valid {
+		fmt.Fprintln(w, "  结果: 有效")
+	} else {
+		fmt.Fprintln(w, "  结果: 无效")
+	}
+	printIssues(w, "  ", report.Issues)
+}
+
+// printIssues 每行输出一条诊断，行首加indent
+func printIssues(w io.Writer, indent string, issues []pdf.ValidationIssue) {
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%s%s\n", indent, issue)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// vaultPassphraseEnv 保险库主密码的环境变量名，避免主密码出现在命令行历史中
+const vaultPassphraseEnv = "PDF_MERGER_VAULT_PASSPHRASE"
+
+// resolveVaultPath 返回 -vault 指定的保险库路径，未指定时使用默认路径
+func resolveVaultPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	defaultPath, err := pdf.DefaultVaultPath()
+	if err != nil {
+		return "", fmt.Errorf("无法确定保险库路径: %v", err)
+	}
+	return defaultPath, nil
+}
+
+// openVault 在设置了保险库主密码的环境变量时打开保险库，合并和 -decrypt 用它查找并保存加密输入的密码；
+// 没有设置时返回nil，不使用保险库
+func openVault(path string) (pdf.PasswordVault, error) {
+	passphrase := os.Getenv(vaultPassphraseEnv)
+	if passphrase == "" {
+		return nil, nil
+	}
+	path, err := resolveVaultPath(path)
+	if err != nil {
+		return nil, err
+	}
+	vault, err := pdf.OpenFileVault(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return vault, nil
+}
+
+// manageVault 执行密码保险库管理命令
+func manageVault(path string, list, purge bool, removeHash string) error {
+	path, err := resolveVaultPath(path)
+	if err != nil {
+		return err
+	}
+
+	passphrase := os.Getenv(vaultPassphraseEnv)
+	if passphrase == "" {
+		return fmt.Errorf("请通过环境变量 %s 提供保险库主密码", vaultPassphraseEnv)
+	}
+
+	vault, err := pdf.OpenFileVault(path, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if removeHash != "" {
+		if err := vault.Remove(removeHash); err != nil {
+			return err
+		}
+		fmt.Printf("已删除条目: %s\n", removeHash)
+	}
+
+	if purge {
+		if err := vault.Purge(); err != nil {
+			return err
+		}
+		fmt.Println("密码保险库已清空")
+	}
+
+	if list {
+		entries, err := vault.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("密码保险库为空")
+			return nil
+		}
+		fmt.Printf("密码保险库: %s (%d 个条目)\n", vault.Path(), len(entries))
+		for _, entry := range entries {
+			fmt.Printf("  %s  %s  最后使用: %s\n", entry.ContentHash, entry.Label,
+				entry.LastUsed.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	return nil
+}
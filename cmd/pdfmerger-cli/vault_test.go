@@ -0,0 +1,30 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	t.Setenv(vaultPassphraseEnv, "")
+	vault, err := openVault(path)
+	if err != nil || vault != nil {
+		t.Fatalf("没有主密码时不应使用保险库，实际 %v, %v", vault, err)
+	}
+
+	t.Setenv(vaultPassphraseEnv, "master")
+	vault, err = openVault(path)
+	if err != nil || vault == nil {
+		t.Fatalf("设置了主密码时应打开保险库: %v", err)
+	}
+	if err := vault.Store("hash", "secret", "locked.pdf"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(vaultPassphraseEnv, "wrong")
+	if _, err := openVault(path); err == nil {
+		t.Error("主密码错误时应返回错误，而不是不使用保险库继续合并")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// verifyReport -verify 模式下单个文件的输出，JSON字段名是稳定接口，只增不改
+type verifyReport struct {
+	Path   string                    `json:"path"`
+	Match  bool                      `json:"match"`
+	Error  string                    `json:"error,omitempty"`
+	Result *pdf.ManifestVerification `json:"result,omitempty"`
+}
+
+// runVerify 处理 -verify 模式：重新计算输出文件的SHA-256，与合并时写出的旁路清单（.manifest.json）比对。
+// 单个文件以JSON对象输出，多个文件以数组输出；任何文件不一致或无法校验时以状态1退出。
+func runVerify(files []string, jsonOutput bool) {
+	reports := make([]verifyReport, 0, len(files))
+	failed := false
+	for _, file := range files {
+		report := verifyReport{Path: file}
+		if result, err := pdf.VerifyManifest(file); err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Match = result.Match
+			report.Result = result
+		}
+		if !report.Match {
+			failed = true
+		}
+		reports = append(reports, report)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if len(reports) == 1 {
+			encoder.Encode(reports[0])
+		} else {
+			encoder.Encode(reports)
+		}
+	} else {
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("文件: %s\n", report.Path)
+			switch {
+			case report.Error != "":
+				fmt.Printf("  错误: %s\n", report.Error)
+			case report.Match:
+				fmt.Printf("  结果: 与清单一致 (%d 个输入)\n", report.Result.Inputs)
+				fmt.Printf("  SHA-256: %s\n", report.Result.ActualSHA256)
+			default:
+				fmt.Println("  结果: 与清单不一致")
+				fmt.Printf("  清单SHA-256: %s (%d 字节)\n", report.Result.ExpectedSHA256, report.Result.ExpectedSize)
+				fmt.Printf("  实际SHA-256: %s (%d 字节)\n", report.Result.ActualSHA256, report.Result.ActualSize)
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// watchPollInterval -watch 模式检查目录和文件稳定性的间隔，fsnotify不可用时也是轮询间隔
+const watchPollInterval = time.Second
+
+// watchProcessedDir 合并完成的输入移入的子目录（位于监视目录内）
+const watchProcessedDir = "processed"
+
+// watchOptions -watch 模式的选项
+type watchOptions struct {
+	dir         string        // 监视的目录，只处理其中的顶层PDF文件
+	outputDir   string        // 合并输出和批次日志所在的目录
+	batchWindow time.Duration // 目录安静多久后合并当前批次
+	stableTime  time.Duration // 文件大小和修改时间保持不变多久后才认为已写完
+	settings    mergeSettings
+}
+
+// watchedFile 监视目录中一个文件的最近状态
+type watchedFile struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time // 最近一次观察到大小或修改时间变化的时间
+}
+
+// sameAs 判断文件自上次观察以来是否没有变化
+func (f watchedFile) sameAs(info os.FileInfo) bool {
+	return f.size == info.Size() && f.modTime.Equal(info.ModTime())
+}
+
+// folderWatcher 收集监视目录中出现的PDF文件，目录安静batchWindow且所有文件都已稳定后合并为一个批次
+type folderWatcher struct {
+	options    watchOptions
+	merge      func(files []string, outputPath string) ([]string, error)
+	pending    map[string]watchedFile // 等待合并的文件
+	failed     map[string]watchedFile // 合并失败或被跳过的文件，内容变化前不再处理
+	lastChange time.Time              // 目录中最近一次出现新文件或文件变化的时间
+	waitNoted  bool                   // 是否已提示只有一个文件、需要等待更多文件
+	clock      clock.Clock            // 判断安静和稳定时间的时间来源
+}
+
+// runWatch 监视目录并按批次合并其中出现的PDF文件，直到收到 SIGINT/SIGTERM。
+// 收到信号时正在进行的合并会完成后再退出，再次收到信号时立即退出。
+func runWatch(options watchOptions) error {
+	if err := checkWatchDirs(options.dir, options.outputDir); err != nil {
+		return err
+	}
+
+	w := &folderWatcher{
+		options: options,
+		pending: make(map[string]watchedFile),
+		failed:  make(map[string]watchedFile),
+		clock:   clock.System(),
+	}
+	w.merge = func(files []string, outputPath string) ([]string, error) {
+		return mergePDFs(files, outputPath, options.settings)
+	}
+
+	// fsnotify不可用（例如网络文件系统或达到监视数量上限）时只靠定时扫描
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if notifier, err := fsnotify.NewWatcher(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 无法监视目录变化，改为每 %v 轮询: %v\n", watchPollInterval, err)
+	} else if err := notifier.Add(options.dir); err != nil {
+		notifier.Close()
+		fmt.Fprintf(os.Stderr, "警告: 无法监视目录变化，改为每 %v 轮询: %v\n", watchPollInterval, err)
+	} else {
+		defer notifier.Close()
+		events, watchErrors = notifier.Events, notifier.Errors
+	}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	fmt.Printf("正在监视 %s，安静 %v 后合并到 %s（按 Ctrl+C 退出）\n", options.dir, options.batchWindow, options.outputDir)
+	w.scan(w.clock.Now())
+	for {
+		select {
+		case sig := <-signals:
+			fmt.Printf("收到信号 %v，停止监视\n", sig)
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if isPDFFile(event.Name) && filepath.Dir(event.Name) == filepath.Clean(options.dir) {
+				w.lastChange = w.clock.Now()
+				w.scan(w.lastChange)
+			}
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "警告: 监视目录出错: %v\n", err)
+		case <-ticker.C:
+			now := w.clock.Now()
+			w.scan(now)
+			if !w.ready(now) {
+				continue
+			}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				w.runBatch(now)
+			}()
+			if waitBatch(done, signals) {
+				return nil
+			}
+		}
+	}
+}
+
+// waitBatch 等待批次完成。期间收到信号时提示并在批次完成后返回true，再次收到信号时立即退出进程。
+func waitBatch(done <-chan struct{}, signals <-chan os.Signal) bool {
+	stopping := false
+	for {
+		select {
+		case <-done:
+			if stopping {
+				fmt.Println("当前批次已完成，停止监视")
+			}
+			return stopping
+		case sig := <-signals:
+			if stopping {
+				fmt.Fprintf(os.Stderr, "收到信号 %v，立即退出\n", sig)
+				os.Exit(1)
+			}
+			stopping = true
+			fmt.Printf("收到信号 %v，等待当前合并完成后退出（再次按 Ctrl+C 立即退出）\n", sig)
+		}
+	}
+}
+
+// checkWatchDirs 检查监视目录存在并创建输出目录。输出目录不能是监视目录，否则合并输出会被当作新的输入。
+func checkWatchDirs(dir, outputDir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("无法读取监视目录: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("监视路径不是目录: %s", dir)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	absOutput, err := filepath.Abs(outputDir)
+	if err != nil {
+		return err
+	}
+	if absDir == absOutput {
+		return errors.New("-output-dir 不能与 -watch 是同一个目录")
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %v", err)
+	}
+	return nil
+}
+
+// scan 读取监视目录中的顶层PDF文件，更新待合并文件的状态。新文件或大小、修改时间变化的文件
+// 重新开始计算稳定时间，并推迟批次；消失的文件从批次中移除。
+func (w *folderWatcher) scan(now time.Time) {
+	files, err := collectPDFs(w.options.dir, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		return
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		present[file] = true
+		if failed, ok := w.failed[file]; ok {
+			if failed.sameAs(info) {
+				continue
+			}
+			delete(w.failed, file)
+		}
+		if state, ok := w.pending[file]; ok && state.sameAs(info) {
+			continue
+		}
+		w.pending[file] = watchedFile{size: info.Size(), modTime: info.ModTime(), stableSince: now}
+		w.lastChange = now
+	}
+
+	for file := range w.pending {
+		if !present[file] {
+			delete(w.pending, file)
+		}
+	}
+	for file := range w.failed {
+		if !present[file] {
+			delete(w.failed, file)
+		}
+	}
+}
+
+// ready 判断是否可以合并当前批次：至少两个非空文件，目录已安静batchWindow，且每个文件都已稳定stableTime。
+// 只有一个文件时等待更多文件加入，而不是单独"合并"它。
+func (w *folderWatcher) ready(now time.Time) bool {
+	if len(w.pending) == 0 || now.Sub(w.lastChange) < w.options.batchWindow {
+		return false
+	}
+	for _, state := range w.pending {
+		if state.size == 0 || now.Sub(state.stableSince) < w.options.stableTime {
+			return false
+		}
+	}
+	if len(w.pending) < 2 {
+		if !w.waitNoted {
+			w.waitNoted = true
+			fmt.Println("目录中只有一个PDF文件，等待更多文件后再合并")
+		}
+		return false
+	}
+	w.waitNoted = false
+	return true
+}
+
+// runBatch 按修改时间顺序合并待合并的文件，输出名带时间戳。成功时把合并了的输入移入 processed/，
+// 失败或跳过的输入留在原处并写入与输出同名的 .log 文件，内容变化前不再处理。
+func (w *folderWatcher) runBatch(now time.Time) {
+	files := make([]string, 0, len(w.pending))
+	for file := range w.pending {
+		files = append(files, file)
+	}
+	if err := sortInputs(files, "mtime"); err != nil {
+		// 文件在扫描后被移走，下一轮扫描会重新整理批次
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		return
+	}
+	states := w.pending
+	w.pending = make(map[string]watchedFile)
+
+	outputPath := batchOutputPath(w.options.outputDir, now)
+	logPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".log"
+	fmt.Printf("合并 %d 个文件到 %s\n", len(files), outputPath)
+
+	var problems []string
+	skipped, mergeErr := w.merge(files, outputPath)
+	if mergeErr != nil {
+		problems = append(problems, fmt.Sprintf("合并失败: %s", mergeErrorText(mergeErr)))
+		skipped = files
+	}
+	for _, file := range skipped {
+		w.failed[file] = states[file]
+		if mergeErr == nil {
+			problems = append(problems, fmt.Sprintf("跳过无效文件: %s", file))
+		}
+	}
+
+	if mergeErr == nil {
+		isSkipped := make(map[string]bool, len(skipped))
+		for _, file := range skipped {
+			isSkipped[file] = true
+		}
+		for _, file := range files {
+			if isSkipped[file] {
+				continue
+			}
+			if target, err := moveToProcessed(w.options.dir, file); err != nil {
+				problems = append(problems, fmt.Sprintf("无法把 %s 移到 %s: %v", file, target, err))
+				w.failed[file] = states[file]
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf("✅ 批次完成: %s\n", outputPath)
+		return
+	}
+	if err := writeBatchLog(logPath, now, files, problems); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 无法写入批次日志 %s: %v\n", logPath, err)
+	}
+	if mergeErr != nil {
+		fmt.Printf("❌ 批次失败，详见 %s\n", logPath)
+	} else {
+		fmt.Printf("⚠️ 批次完成但有问题，详见 %s\n", logPath)
+	}
+}
+
+// batchOutputPath 返回批次的输出路径 merged_YYYYMMDD_HHMMSS.pdf，已存在时追加序号
+func batchOutputPath(outputDir string, now time.Time) string {
+	base := "merged_" + now.Format("20060102_150405")
+	path := filepath.Join(outputDir, base+".pdf")
+	for i := 2; fileExists(path); i++ {
+		path = filepath.Join(outputDir, fmt.Sprintf("%s_%d.pdf", base, i))
+	}
+	return path
+}
+
+// moveToProcessed 把输入移入监视目录下的 processed/，同名文件已存在时追加序号
+func moveToProcessed(dir, file string) (string, error) {
+	processed := filepath.Join(dir, watchProcessedDir)
+	target := filepath.Join(processed, filepath.Base(file))
+	if err := os.MkdirAll(processed, 0755); err != nil {
+		return target, err
+	}
+	ext := filepath.Ext(target)
+	stem := strings.TrimSuffix(target, ext)
+	for i := 2; fileExists(target); i++ {
+		target = fmt.Sprintf("%s_%d%s", stem, i, ext)
+	}
+	return target, os.Rename(file, target)
+}
+
+// writeBatchLog 写出批次的输入和问题
+func writeBatchLog(logPath string, now time.Time, files, problems []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "批次时间: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "输入文件 (%d):\n", len(files))
+	for _, file := range files {
+		fmt.Fprintf(&b, "  %s\n", file)
+	}
+	fmt.Fprintln(&b, "问题:")
+	for _, problem := range problems {
+		fmt.Fprintf(&b, "  %s\n", problem)
+	}
+	return os.WriteFile(logPath, []byte(b.String()), 0644)
+}
+
+// isPDFFile 判断路径的扩展名是否为 .pdf（不区分大小写）
+func isPDFFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// fileExists 判断路径是否存在
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/pkg/file"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// newWorkspaceController 创建只用于管理工作区的控制器
+func newWorkspaceController(tempDir string) *controller.Controller {
+	config := newConfig()
+	config.TempDirectory = tempDir
+	return controller.NewController(pdf.NewPDFService(), file.NewFileManagerWithClock(tempDir, config.Clock), config)
+}
+
+// printWorkspaces 输出保留在磁盘上的任务工作区及可回收的空间
+func printWorkspaces(w io.Writer, ctrl *controller.Controller, jsonOutput bool) error {
+	workspaces, err := ctrl.ListWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		if workspaces == nil {
+			workspaces = []controller.WorkspaceInfo{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(workspaces)
+	}
+
+	if len(workspaces) == 0 {
+		fmt.Fprintf(w, "没有保留的工作区 (%s)\n", ctrl.WorkspaceRoot())
+		return nil
+	}
+
+	var total int64
+	fmt.Fprintf(w, "工作区目录: %s\n", ctrl.WorkspaceRoot())
+	for _, ws := range workspaces {
+		resumable := ""
+		if ws.Resumable {
+			resumable = "  可恢复"
+		}
+		fmt.Fprintf(w, "  %s  %.2f MB  %s前%s\n",
+			ws.JobID, float64(ws.Size)/(1<<20), ws.Age.Round(time.Minute), resumable)
+		total += ws.Size
+	}
+	fmt.Fprintf(w, "共 %d 个工作区，可回收 %.2f MB\n", len(workspaces), float64(total)/(1<<20))
+	return nil
+}
+
+// discardWorkspace 删除指定任务的工作区
+func discardWorkspace(w io.Writer, ctrl *controller.Controller, jobID string) error {
+	if err := ctrl.DiscardWorkspace(jobID); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "已删除任务 %s 的工作区\n", jobID)
+	return nil
+}
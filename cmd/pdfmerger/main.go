@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"path/filepath"
@@ -8,7 +9,9 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
 	"github.com/user/pdf-merger/internal/model"
 	"github.com/user/pdf-merger/internal/ui"
 	"github.com/user/pdf-merger/pkg/file"
@@ -16,6 +19,16 @@ import (
 )
 
 func main() {
+	configFlag := flag.String("config", "", "配置文件路径 (默认: 配置目录下的pdf-merger/config.json)")
+	flag.Parse()
+
+	// 加载配置：配置文件提供默认值，环境变量覆盖文件中的值
+	configPath, settings := loadSettings(*configFlag)
+	config := effectiveConfig(settings)
+
+	// 界面语言按配置和环境变量选择，须在字体设置改写 LANG 之前确定
+	i18n.SetLocale(i18n.ResolveLocale(config.Language, os.Getenv))
+
 	// 创建应用程序实例
 	a := app.New()
 	a.SetIcon(nil) // 可以设置应用图标
@@ -23,29 +36,39 @@ func main() {
 	// 应用中文字体支持
 	ui.ApplyChineseTheme(a)
 
-	w := a.NewWindow("PDF Merger Tool")
-	w.Resize(fyne.NewSize(800, 600))
+	w := a.NewWindow(i18n.T(ui.WindowTitle))
+	w.Resize(fyne.NewSize(float32(config.WindowWidth), float32(config.WindowHeight)))
 	w.CenterOnScreen()
 
-	// 初始化服务
-	tempDir := createTempDir()
+	// 初始化服务，配置中没有临时目录时使用应用专用的系统临时子目录
+	tempDir := config.TempDirectory
+	if tempDir == "" {
+		tempDir = createTempDir()
+	} else if err := os.MkdirAll(tempDir, 0755); err != nil {
+		log.Fatalf("无法创建临时目录: %v", err)
+	}
 
 	// 创建服务实例
-	fileManager := createFileManager(tempDir)
+	fileManager := createFileManager(tempDir, config.Clock)
+	fileManager.SetTempFileMaxAge(config.TempFileMaxAge)
+	fileManager.SetTempQuota(file.TempQuota{MaxBytes: config.MaxTempBytes, MaxFiles: config.MaxTempFiles})
 	pdfService := createPDFService()
 
-	// 创建配置
-	config := model.DefaultConfig()
 	config.TempDirectory = tempDir
 
 	// 创建控制器
 	ctrl := controller.NewController(pdfService, fileManager, config)
+	stopTempSweep := ctrl.StartTempSweep(config.TempSweepAge)
 
 	// 创建事件处理器
 	eventHandler := controller.NewEventHandler(ctrl)
 
 	// 创建UI
 	userInterface := ui.NewUI(w, ctrl)
+	userInterface.SetSettings(configPath, settings)
+
+	// pdf包的警告和合并信息显示在日志视图中
+	pdf.SetDefaultLogger(userInterface.Logger())
 
 	// 连接事件处理器和UI
 	setupEventHandling(userInterface, eventHandler)
@@ -53,9 +76,21 @@ func main() {
 	// 设置主窗口内容
 	w.SetContent(userInterface.BuildUI())
 
+	// 上次运行时崩溃或被强制退出而没有完成的任务，提示重新执行或清理
+	userInterface.CheckInterruptedJobs()
+
+	// 询问是否恢复上次关闭窗口时的文件和输出路径
+	userInterface.OfferSessionRestore()
+
 	// 添加应用程序关闭时的清理操作
 	w.SetCloseIntercept(func() {
+		// 保存当前的文件和输出路径，下次启动时可以恢复
+		if err := userInterface.SaveSession(); err != nil {
+			log.Printf("保存会话时发生错误: %v", err)
+		}
+
 		// 清理临时文件
+		stopTempSweep()
 		if err := fileManager.CleanupTempFiles(); err != nil {
 			log.Printf("清理临时文件时发生错误: %v", err)
 		}
@@ -68,6 +103,32 @@ func main() {
 	w.ShowAndRun()
 }
 
+// loadSettings 返回配置文件路径和文件中的配置。
+// 文件不存在时使用默认配置；文件损坏时记录警告并使用默认配置。
+func loadSettings(flagPath string) (string, *model.Config) {
+	configPath, err := model.ResolveConfigPath(flagPath, os.Getenv)
+	if err != nil {
+		log.Printf("警告: 无法确定配置文件路径，使用默认配置: %v", err)
+		return "", model.DefaultConfig()
+	}
+	settings, err := model.LoadConfig(configPath)
+	if err != nil {
+		log.Printf("警告: %v", err)
+	}
+	return configPath, settings
+}
+
+// effectiveConfig 返回运行时使用的配置：文件中的配置加上环境变量覆盖
+func effectiveConfig(settings *model.Config) *model.Config {
+	config := *settings
+	config.CommonPasswords = append([]string(nil), settings.CommonPasswords...)
+	config.FilenameEncodings = append([]string(nil), settings.FilenameEncodings...)
+	if err := model.ApplyEnv(&config, os.Getenv); err != nil {
+		log.Printf("警告: %v", err)
+	}
+	return &config
+}
+
 // createTempDir 创建临时目录
 func createTempDir() string {
 	// 使用系统临时目录下的应用特定子目录
@@ -79,14 +140,18 @@ func createTempDir() string {
 	return tempDir
 }
 
-// createFileManager 创建文件管理器实例
-func createFileManager(tempDir string) file.FileManager {
-	return file.NewFileManager(tempDir)
+// createFileManager 创建使用时钟c的文件管理器实例
+func createFileManager(tempDir string, c clock.Clock) file.FileManager {
+	return file.NewFileManagerWithClock(tempDir, c)
 }
 
-// createPDFService 创建PDF服务实例
+// createPDFService 创建PDF服务实例。替换已存在的输出前保留备份，合并完成后可以撤销；
+// 读取每页的尺寸，用于在文件列表中显示页面尺寸
 func createPDFService() pdf.PDFService {
-	return pdf.NewPDFService()
+	config := pdf.DefaultServiceConfig()
+	config.BackupOutput = true
+	config.IncludePageGeometry = true
+	return pdf.NewPDFServiceWithConfig(config)
 }
 
 // setupEventHandling 设置事件处理
@@ -111,8 +176,8 @@ func setupEventHandling(ui *ui.UI, eventHandler *controller.EventHandler) {
 	})
 
 	// 设置完成回调
-	eventHandler.SetCompletionCallback(func(message string) {
-		ui.ShowCompletion(message)
+	eventHandler.SetCompletionCallback(func(result *pdf.MergeResult) {
+		ui.ShowMergeSummary(result)
 	})
 
 	// 设置UI的事件处理器
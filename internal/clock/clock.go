@@ -0,0 +1,106 @@
+package clock
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// Clock 时间与随机数来源。生产代码通过它获取当前时间、等待和生成随机ID，
+// 测试中替换为 Fake 即可得到确定的输出且无需真实等待。
+type Clock interface {
+	// Now 返回当前时间
+	Now() time.Time
+	// NewTimer 创建在 d 之后触发的定时器
+	NewTimer(d time.Duration) Timer
+	// Sleep 等待 d，ctx 取消时提前返回 ctx.Err()
+	Sleep(ctx context.Context, d time.Duration) error
+	// RandRead 用随机字节填充 p
+	RandRead(p []byte) (int, error)
+	// NewUUID 生成版本4 UUID
+	NewUUID() string
+}
+
+// Timer 定时器
+type Timer interface {
+	// C 返回定时器触发时接收时间的通道
+	C() <-chan time.Time
+	// Stop 停止定时器，已触发或已停止时返回false
+	Stop() bool
+}
+
+// systemClock 基于标准库的真实实现
+type systemClock struct{}
+
+var system Clock = systemClock{}
+
+// System 返回基于系统时间和 crypto/rand 的 Clock
+func System() Clock {
+	return system
+}
+
+// OrSystem 在 c 为nil时返回系统 Clock，用于配置项的默认值
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return system
+	}
+	return c
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (systemClock) RandRead(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+func (c systemClock) NewUUID() string {
+	return newUUID(c)
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// newUUID 用 c 的随机源生成版本4 UUID
+func newUUID(c Clock) string {
+	var b [16]byte
+	if _, err := c.RandRead(b[:]); err != nil {
+		// 随机源不可用时退化为基于时间的值，仍保持UUID格式
+		nanos := c.Now().UnixNano()
+		for i := 0; i < 8; i++ {
+			b[i] = byte(nanos >> (8 * i))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
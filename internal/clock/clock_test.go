@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestSystem_UUIDFormatAndUniqueness(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := System().NewUUID()
+		if !uuidPattern.MatchString(id) {
+			t.Fatalf("UUID格式不正确: %s", id)
+		}
+		if seen[id] {
+			t.Fatalf("UUID重复: %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestSystem_SleepHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := System().Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("期望context.Canceled，实际: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("取消后应立即返回")
+	}
+}
+
+func TestOrSystem(t *testing.T) {
+	if OrSystem(nil) != System() {
+		t.Error("nil应回退到系统时钟")
+	}
+	fake := NewFake(time.Unix(0, 0), 1)
+	if OrSystem(fake) != fake {
+		t.Error("非nil时应原样返回")
+	}
+}
+
+func TestFake_SleepAdvancesAndRecords(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start, 1)
+
+	if err := fake.Sleep(context.Background(), time.Minute); err != nil {
+		t.Fatalf("Sleep失败: %v", err)
+	}
+	fake.Sleep(context.Background(), 2*time.Minute)
+
+	if got := fake.Now(); !got.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("虚拟时间应推进3分钟，实际 %v", got)
+	}
+	sleeps := fake.Sleeps()
+	if len(sleeps) != 2 || sleeps[0] != time.Minute || sleeps[1] != 2*time.Minute {
+		t.Errorf("记录的等待时长不正确: %v", sleeps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fake.Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("已取消的ctx应返回context.Canceled，实际: %v", err)
+	}
+	if !fake.Now().Equal(start.Add(3 * time.Minute)) {
+		t.Error("已取消的Sleep不应推进时间")
+	}
+}
+
+func TestFake_TimerFiresOnAdvance(t *testing.T) {
+	fake := NewFake(time.Unix(0, 0), 1)
+	timer := fake.NewTimer(time.Second)
+
+	fake.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("定时器不应提前触发")
+	default:
+	}
+
+	fake.Advance(time.Millisecond)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("定时器应在到期时触发")
+	}
+	if timer.Stop() {
+		t.Error("已触发的定时器Stop应返回false")
+	}
+
+	stopped := fake.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("未触发的定时器Stop应返回true")
+	}
+	fake.Advance(time.Hour)
+	select {
+	case <-stopped.C():
+		t.Error("已停止的定时器不应触发")
+	default:
+	}
+}
+
+func TestFake_DeterministicEntropy(t *testing.T) {
+	a := NewFake(time.Unix(0, 0), 42)
+	b := NewFake(time.Unix(0, 0), 42)
+	for i := 0; i < 3; i++ {
+		idA, idB := a.NewUUID(), b.NewUUID()
+		if idA != idB {
+			t.Fatalf("相同种子应生成相同UUID: %s != %s", idA, idB)
+		}
+		if !uuidPattern.MatchString(idA) {
+			t.Fatalf("UUID格式不正确: %s", idA)
+		}
+	}
+	if a.NewUUID() == a.NewUUID() {
+		t.Error("同一时钟连续生成的UUID不应相同")
+	}
+	if NewFake(time.Unix(0, 0), 7).NewUUID() == NewFake(time.Unix(0, 0), 8).NewUUID() {
+		t.Error("不同种子应生成不同UUID")
+	}
+}
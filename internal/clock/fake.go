@@ -0,0 +1,142 @@
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Fake 测试用的虚拟时钟。Sleep 不真实等待，而是把虚拟时间向前推进并记录
+// 等待时长；定时器在虚拟时间到达时触发。随机字节由固定种子生成，
+// 相同种子的两个 Fake 产生相同的随机序列和UUID。
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	sleeps []time.Duration
+	state  uint64
+}
+
+// NewFake 创建从 start 开始、随机种子为 seed 的虚拟时钟
+func NewFake(start time.Time, seed uint64) *Fake {
+	return &Fake{now: start, state: seed}
+}
+
+// Now 返回虚拟时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance 推进虚拟时间并触发到期的定时器
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.advanceLocked(d)
+}
+
+// Sleep 记录等待时长并立即推进虚拟时间；ctx 已取消时返回 ctx.Err()
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.sleeps = append(f.sleeps, d)
+	if d > 0 {
+		f.advanceLocked(d)
+	}
+	f.mu.Unlock()
+	return ctx.Err()
+}
+
+// Sleeps 返回迄今所有 Sleep 调用的时长
+func (f *Fake) Sleeps() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.sleeps...)
+}
+
+// NewTimer 创建在虚拟时间推进 d 后触发的定时器
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.fire(f.now)
+		return t
+	}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+// RandRead 用确定的伪随机字节填充 p（splitmix64）
+func (f *Fake) RandRead(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buf [8]byte
+	for i := 0; i < len(p); i += 8 {
+		f.state += 0x9e3779b97f4a7c15
+		z := f.state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		binary.LittleEndian.PutUint64(buf[:], z^(z>>31))
+		copy(p[i:], buf[:])
+	}
+	return len(p), nil
+}
+
+// NewUUID 生成确定的版本4 UUID
+func (f *Fake) NewUUID() string {
+	return newUUID(f)
+}
+
+// advanceLocked 推进时间，调用方需持有锁
+func (f *Fake) advanceLocked(d time.Duration) {
+	f.now = f.now.Add(d)
+	remaining := f.timers[:0]
+	for _, t := range f.timers {
+		if !t.deadline.After(f.now) {
+			t.fire(f.now)
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	f.timers = remaining
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	ch       chan time.Time
+	done     bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop 从时钟中移除未触发的定时器
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t.done {
+		return false
+	}
+	t.done = true
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// fire 触发定时器，调用方需持有时钟的锁
+func (t *fakeTimer) fire(now time.Time) {
+	t.done = true
+	t.ch <- now
+}
@@ -0,0 +1,111 @@
+package clock
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// productionRoots 需要检查的生产代码目录（相对本包）
+var productionRoots = []string{"../../pkg", "../../internal", "../../cmd"}
+
+// sleepAllowedDirs 允许直接调用 time.Sleep 的目录：测试辅助代码用真实等待模拟慢操作
+var sleepAllowedDirs = []string{"internal/test_utils"}
+
+// nowAllowedDirs 允许直接调用 time.Now 的目录：本包的系统时钟实现和测试辅助代码
+var nowAllowedDirs = []string{"internal/clock", "internal/test_utils"}
+
+// TestNoDirectTimeSleepInProductionCode 生产代码应通过 Clock.Sleep 等待，
+// 这样测试可以用 Fake 跳过等待而不是真实睡眠。
+func TestNoDirectTimeSleepInProductionCode(t *testing.T) {
+	for _, v := range findTimeCalls(t, sleepAllowedDirs, "Sleep") {
+		t.Errorf("生产代码中直接调用了time.Sleep，请改用Clock.Sleep: %s", v)
+	}
+}
+
+// TestNoDirectTimeNowInProductionCode 生产代码应通过注入的 Clock 取得时间，
+// 直接调用 time.Now 时测试替换为 Fake 后仍会读到真实时间。
+func TestNoDirectTimeNowInProductionCode(t *testing.T) {
+	for _, v := range findTimeCalls(t, nowAllowedDirs, "Now", "Since") {
+		t.Errorf("生产代码中直接调用了time.Now或time.Since，请改用Clock.Now: %s", v)
+	}
+}
+
+// findTimeCalls 扫描 allowedDirs 以外的生产代码，返回直接调用 time 包中 names 函数的位置
+func findTimeCalls(t *testing.T, allowedDirs []string, names ...string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	var violations []string
+
+	for _, root := range productionRoots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			slashed := filepath.ToSlash(path)
+			if info.IsDir() {
+				for _, allowed := range allowedDirs {
+					if strings.HasSuffix(slashed, allowed) {
+						return filepath.SkipDir
+					}
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			timeName := importName(file, "time")
+			if timeName == "" {
+				return nil
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok || !containsName(names, sel.Sel.Name) {
+					return true
+				}
+				if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == timeName {
+					violations = append(violations, fset.Position(sel.Pos()).String())
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("扫描 %s 失败: %v", root, err)
+		}
+	}
+	return violations
+}
+
+// containsName 判断 name 是否在 names 中
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// importName 返回文件中导入 path 时使用的名称，未导入时返回空
+func importName(file *ast.File, path string) string {
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return filepath.Base(path)
+	}
+	return ""
+}
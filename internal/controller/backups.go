@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// outputRollback 返回输出所用的回滚管理器，备份位置和保留数与 pdf.DefaultRollbackConfig 一致
+func outputRollback(outputPath string) *pdf.RollbackManager {
+	return pdf.NewRollbackManagerWithConfig(filepath.Dir(outputPath), pdf.DefaultRollbackConfig())
+}
+
+// OutputBackups 列出合并前为输出保留的备份，最新的在前
+func (c *Controller) OutputBackups(outputPath string) ([]pdf.BackupInfo, error) {
+	return outputRollback(outputPath).ListBackups(outputPath)
+}
+
+// RestorePreviousOutput 用最新的备份替换输出，撤销最近一次合并。有任务正在运行时拒绝恢复；
+// 没有备份时返回的错误包装 pdf.ErrNoBackup
+func (c *Controller) RestorePreviousOutput(outputPath string) (*pdf.BackupInfo, error) {
+	if c.IsJobRunning() {
+		return nil, fmt.Errorf("已有合并任务正在运行，请等待完成后再恢复输出")
+	}
+	return outputRollback(outputPath).RestoreLatest(outputPath)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+func TestController_RestorePreviousOutput(t *testing.T) {
+	c := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	output := filepath.Join(t.TempDir(), "out.pdf")
+
+	if err := os.WriteFile(output, []byte("上一版"), 0644); err != nil {
+		t.Fatalf("写入输出失败: %v", err)
+	}
+	if _, err := c.RestorePreviousOutput(output); !errors.Is(err, pdf.ErrNoBackup) {
+		t.Fatalf("没有备份时应返回ErrNoBackup，实际: %v", err)
+	}
+
+	if _, err := pdf.NewRollbackManagerWithConfig(filepath.Dir(output), pdf.DefaultRollbackConfig()).BackupFile(output); err != nil {
+		t.Fatalf("备份失败: %v", err)
+	}
+	if err := os.WriteFile(output, []byte("合并结果"), 0644); err != nil {
+		t.Fatalf("写入输出失败: %v", err)
+	}
+
+	backups, err := c.OutputBackups(output)
+	if err != nil {
+		t.Fatalf("列出备份失败: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("应有1份备份，实际 %d", len(backups))
+	}
+
+	c.currentJob = model.NewMergeJob("a.pdf", nil, output)
+	if _, err := c.RestorePreviousOutput(output); err == nil {
+		t.Fatal("任务运行时应拒绝恢复")
+	}
+	c.currentJob = nil
+
+	restored, err := c.RestorePreviousOutput(output)
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if restored.Path != backups[0].Path {
+		t.Errorf("应使用最新的备份，期望: %s, 实际: %s", backups[0].Path, restored.Path)
+	}
+	data, _ := os.ReadFile(output)
+	if string(data) != "上一版" {
+		t.Errorf("输出应恢复为上一版，实际: %s", string(data))
+	}
+}
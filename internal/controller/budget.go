@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// 任务历史中的时间预算事件
+const (
+	HistoryEstimated      = "estimated"
+	HistoryDeferred       = "deferred"
+	HistoryBudgetExceeded = "budget_exceeded"
+	HistoryRolledBack     = "rolled_back"
+)
+
+var (
+	// ErrJobDeferred 任务因时间预算不足未启动
+	ErrJobDeferred = errors.New("任务因时间预算不足被推迟")
+	// ErrBudgetExceeded 任务运行超出时间预算后被取消
+	ErrBudgetExceeded = errors.New("任务超出时间预算")
+)
+
+// OverrunPolicy 预算到期时仍在运行的任务的处理方式
+type OverrunPolicy int
+
+const (
+	// OverrunCancel 取消任务并把输出恢复到任务开始前的状态
+	OverrunCancel OverrunPolicy = iota
+	// OverrunAllow 允许任务继续运行，只记录警告
+	OverrunAllow
+)
+
+// String 返回OverrunPolicy的字符串表示
+func (p OverrunPolicy) String() string {
+	switch p {
+	case OverrunCancel:
+		return "cancel"
+	case OverrunAllow:
+		return "allow"
+	default:
+		return "unknown"
+	}
+}
+
+// DurationEstimator 估算任务耗时
+type DurationEstimator interface {
+	// EstimateDuration 返回预计耗时；没有足够信息时返回false
+	EstimateDuration(job *model.MergeJob) (time.Duration, bool)
+}
+
+// ThroughputEstimator 按输入总大小和合并后端的历史吞吐量估算耗时
+type ThroughputEstimator struct {
+	// Stats 返回后端统计，nil时使用共享的统计存储
+	Stats func() []pdf.BackendStat
+	// SafetyFactor 估算值的放大系数，小于1时按1处理
+	SafetyFactor float64
+}
+
+// EstimateDuration 优先使用与输入大小同档的吞吐量，该档没有样本时使用所有档的总体吞吐量
+func (e *ThroughputEstimator) EstimateDuration(job *model.MergeJob) (time.Duration, bool) {
+	var total int64
+	for _, file := range append([]string{job.MainFile}, job.AdditionalFiles...) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return 0, false
+		}
+		total += info.Size()
+	}
+
+	statsFn := e.Stats
+	if statsFn == nil {
+		statsFn = pdf.BackendStats
+	}
+	bucket := pdf.SizeBucket(total)
+	var sameBucket, overall pdf.BucketStat
+	for _, stat := range statsFn() {
+		for name, b := range stat.Buckets {
+			overall.Bytes += b.Bytes
+			overall.Duration += b.Duration
+			if name == bucket {
+				sameBucket.Bytes += b.Bytes
+				sameBucket.Duration += b.Duration
+			}
+		}
+	}
+
+	throughput := sameBucket.Throughput()
+	if throughput <= 0 {
+		throughput = overall.Throughput()
+	}
+	if throughput <= 0 {
+		return 0, false
+	}
+
+	factor := e.SafetyFactor
+	if factor < 1 {
+		factor = 1
+	}
+	return time.Duration(float64(total) / throughput * factor * float64(time.Second)), true
+}
+
+// BudgetPolicy 队列级时间预算：截止时刻前无法完成的任务不启动，推迟并记录原因
+type BudgetPolicy struct {
+	// Deadline 队列中所有任务的截止时刻，零值表示只使用任务自身的 NotAfter
+	Deadline time.Time
+	// Estimator 估算任务耗时；无法估算时照常启动任务并记录警告
+	Estimator DurationEstimator
+	// Overrun 截止时刻到达时仍在运行的任务的处理方式
+	Overrun OverrunPolicy
+	// Clock 时间来源，nil时使用系统时钟
+	Clock clock.Clock
+	// Log 估算和调度决定的日志，nil时只记录在任务历史中
+	Log io.Writer
+}
+
+// DefaultBudgetPolicy 返回没有队列截止时刻、按历史吞吐量估算、超时取消的策略
+func DefaultBudgetPolicy() *BudgetPolicy {
+	return &BudgetPolicy{
+		Estimator: &ThroughputEstimator{SafetyFactor: 1.2},
+		Overrun:   OverrunCancel,
+	}
+}
+
+// deadlineFor 返回任务的有效截止时刻：队列截止时刻与任务 NotAfter 中较早者
+func (b *BudgetPolicy) deadlineFor(job *model.MergeJob) time.Time {
+	deadline := b.Deadline
+	if !job.NotAfter.IsZero() && (deadline.IsZero() || job.NotAfter.Before(deadline)) {
+		deadline = job.NotAfter
+	}
+	return deadline
+}
+
+// record 写入任务历史并输出日志，调用方需持有队列锁
+func (b *BudgetPolicy) record(qj *QueuedJob, event, detail string) {
+	qj.Job.AddHistory(event, detail)
+	if b.Log != nil {
+		fmt.Fprintf(b.Log, "%s 任务 %s %s: %s\n",
+			clock.OrSystem(b.Clock).Now().Format(time.RFC3339), qj.Job.ID, event, detail)
+	}
+}
+
+// SetBudget 设置队列的时间预算策略，需在 Start 之前调用；nil 恢复默认策略
+func (q *JobQueue) SetBudget(budget *BudgetPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if budget == nil {
+		budget = DefaultBudgetPolicy()
+	}
+	q.budget = budget
+}
+
+// Deferred 返回因时间预算被推迟的任务
+func (q *JobQueue) Deferred() []*QueuedJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*QueuedJob(nil), q.deferred...)
+}
+
+// admit 判断等待中的任务能否在截止时刻前完成，不能时推迟任务。调用方需持有锁
+func (q *JobQueue) admit(qj *QueuedJob) bool {
+	b := q.budget
+	qj.deadline = b.deadlineFor(qj.Job)
+	if qj.deadline.IsZero() {
+		return true
+	}
+
+	now := clock.OrSystem(b.Clock).Now()
+	remaining := qj.deadline.Sub(now)
+	if remaining <= 0 {
+		q.deferJob(qj, fmt.Sprintf("已过截止时刻 %s", qj.deadline.Format(time.RFC3339)))
+		return false
+	}
+
+	estimate, ok := time.Duration(0), false
+	if b.Estimator != nil {
+		estimate, ok = b.Estimator.EstimateDuration(qj.Job)
+	}
+	if !ok {
+		b.record(qj, HistoryEstimated, fmt.Sprintf("警告: 没有吞吐量统计，无法估算耗时，照常启动（截止 %s）",
+			qj.deadline.Format(time.RFC3339)))
+		return true
+	}
+
+	qj.estimate = estimate
+	b.record(qj, HistoryEstimated, fmt.Sprintf("预计耗时 %s，距截止时刻 %s 还有 %s",
+		estimate.Round(time.Second), qj.deadline.Format(time.RFC3339), remaining.Round(time.Second)))
+	if estimate > remaining {
+		q.deferJob(qj, fmt.Sprintf("预计耗时 %s 超过剩余预算 %s",
+			estimate.Round(time.Second), remaining.Round(time.Second)))
+		return false
+	}
+	return true
+}
+
+// deferJob 不启动任务，记录原因并结束等待。调用方需持有锁
+func (q *JobQueue) deferJob(qj *QueuedJob, reason string) {
+	q.pending = removeJob(q.pending, qj)
+	q.deferred = append(q.deferred, qj)
+	q.budget.record(qj, HistoryDeferred, reason)
+	qj.Job.Status = model.JobDeferred
+	qj.err = fmt.Errorf("%w: %s", ErrJobDeferred, reason)
+	close(qj.done)
+	q.active.Done()
+}
+
+// outputSnapshot 任务开始前的输出文件状态，用于超时取消后的回滚
+type outputSnapshot struct {
+	path    string
+	backup  string
+	existed bool
+}
+
+// snapshotOutput 备份已存在的输出文件
+func snapshotOutput(path string) (*outputSnapshot, error) {
+	snapshot := &outputSnapshot{path: path}
+	if path == "" {
+		return snapshot, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return snapshot, nil
+	}
+	backup, err := pdf.NewRollbackManager(filepath.Dir(path)).BackupFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.backup = backup
+	snapshot.existed = true
+	return snapshot, nil
+}
+
+// restore 恢复任务开始前的输出：原来存在则用备份覆盖，否则删除部分输出
+func (s *outputSnapshot) restore() error {
+	if s.path == "" {
+		return nil
+	}
+	if s.existed {
+		return pdf.NewRollbackManager(filepath.Dir(s.path)).RestoreFile(s.backup, s.path)
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// discard 删除备份
+func (s *outputSnapshot) discard() {
+	if s.backup != "" {
+		os.Remove(s.backup)
+	}
+}
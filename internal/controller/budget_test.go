@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// syntheticStats 返回中档（1MB-10MB）吞吐量为每10分钟1MB的后端统计
+func syntheticStats() func() []pdf.BackendStat {
+	store := pdf.NewBackendStatsStore()
+	store.Record(pdf.BackendOutcome{Backend: pdf.BackendPDFCPU, InputBytes: 2 << 20, Duration: 20 * time.Minute})
+	return store.Snapshot
+}
+
+// sizedFile 创建指定大小的稀疏文件
+func sizedFile(t *testing.T, dir, name string, size int64) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建文件失败: %v", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		t.Fatalf("设置文件大小失败: %v", err)
+	}
+	return path
+}
+
+// chanWriter 把每次写入的日志行发送到通道
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+// historyDetail 返回任务历史中第一个该事件的详情
+func historyDetail(job *model.MergeJob, event string) (string, bool) {
+	for _, entry := range job.History {
+		if entry.Event == event {
+			return entry.Detail, true
+		}
+	}
+	return "", false
+}
+
+func noopJob(ctx context.Context, job *QueuedJob) error { return nil }
+
+func TestJobQueue_DefersJobsThatWouldOverrunBudget(t *testing.T) {
+	dir := t.TempDir()
+	fake := clock.NewFake(time.Date(2024, 6, 1, 5, 0, 0, 0, time.UTC), 1)
+	var log strings.Builder
+
+	queue := NewJobQueue(1, nil)
+	queue.SetBudget(&BudgetPolicy{
+		Deadline:  fake.Now().Add(30 * time.Minute),
+		Estimator: &ThroughputEstimator{Stats: syntheticStats()},
+		Clock:     fake,
+		Log:       &log,
+	})
+
+	// 2MB：预计20分钟，可以完成
+	fits := model.NewMergeJob(sizedFile(t, dir, "fits.pdf", 2<<20), nil, "")
+	// 4MB：预计40分钟，超过30分钟预算
+	tooBig := model.NewMergeJob(sizedFile(t, dir, "big.pdf", 4<<20), nil, "")
+	// 任务自身的截止时刻早于队列截止时刻
+	ownDeadline := model.NewMergeJob(sizedFile(t, dir, "own.pdf", 2<<20), nil, "")
+	ownDeadline.NotAfter = fake.Now().Add(10 * time.Minute)
+	// 小文件档没有样本，使用总体吞吐量：预计5分钟
+	small := model.NewMergeJob(sizedFile(t, dir, "small.pdf", 512<<10), nil, "")
+
+	var ran []string
+	run := func(ctx context.Context, job *QueuedJob) error {
+		ran = append(ran, filepath.Base(job.Job.MainFile))
+		return nil
+	}
+	queued := make(map[*model.MergeJob]*QueuedJob)
+	for _, job := range []*model.MergeJob{fits, tooBig, ownDeadline, small} {
+		qj, err := queue.Enqueue(job, SourceScheduled, run)
+		if err != nil {
+			t.Fatalf("入队失败: %v", err)
+		}
+		queued[job] = qj
+	}
+	queue.Start(context.Background())
+	queue.Close()
+
+	if strings.Join(ran, ",") != "fits.pdf,small.pdf" {
+		t.Errorf("只应启动能在预算内完成的任务，实际: %v", ran)
+	}
+	for _, job := range []*model.MergeJob{tooBig, ownDeadline} {
+		err := queued[job].Wait()
+		if !errors.Is(err, ErrJobDeferred) {
+			t.Errorf("任务 %s 应被推迟，实际错误: %v", job.MainFile, err)
+		}
+		if job.Status != model.JobDeferred {
+			t.Errorf("推迟的任务状态应为JobDeferred，实际: %v", job.Status)
+		}
+		if detail, ok := historyDetail(job, HistoryDeferred); !ok || !strings.Contains(detail, "超过剩余预算") {
+			t.Errorf("历史中应记录推迟原因，实际: %q", detail)
+		}
+		if containsEvent(job, HistoryStarted) {
+			t.Error("推迟的任务不应启动")
+		}
+	}
+	if got := queued[small].Estimate(); got != 5*time.Minute {
+		t.Errorf("小文件应按总体吞吐量估算为5分钟，实际: %v", got)
+	}
+	if detail, _ := historyDetail(fits, HistoryEstimated); !strings.Contains(detail, "预计耗时 20m0s") {
+		t.Errorf("历史中应记录估算值，实际: %q", detail)
+	}
+	if len(queue.Deferred()) != 2 {
+		t.Errorf("应有2个推迟的任务，实际 %d", len(queue.Deferred()))
+	}
+	if !strings.Contains(log.String(), HistoryDeferred) || !strings.Contains(log.String(), HistoryEstimated) {
+		t.Errorf("估算和决定应写入日志:\n%s", log.String())
+	}
+}
+
+func TestJobQueue_PastDeadlineIsDeferred(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 6, 30, 0, 0, time.UTC), 1)
+	queue := NewJobQueue(1, nil)
+	queue.SetBudget(&BudgetPolicy{Deadline: fake.Now().Add(-30 * time.Minute), Clock: fake})
+
+	qj, _ := queue.Enqueue(model.NewMergeJob("missing.pdf", nil, ""), SourceScheduled, noopJob)
+	queue.Start(context.Background())
+	queue.Close()
+
+	if err := qj.Wait(); !errors.Is(err, ErrJobDeferred) {
+		t.Errorf("截止时刻之后不应启动任务，实际: %v", err)
+	}
+}
+
+func TestJobQueue_NoEstimateIsPermissiveWithWarning(t *testing.T) {
+	dir := t.TempDir()
+	fake := clock.NewFake(time.Date(2024, 6, 1, 5, 0, 0, 0, time.UTC), 1)
+	queue := NewJobQueue(1, nil)
+	queue.SetBudget(&BudgetPolicy{
+		Deadline:  fake.Now().Add(time.Minute),
+		Estimator: &ThroughputEstimator{Stats: pdf.NewBackendStatsStore().Snapshot},
+		Clock:     fake,
+	})
+
+	job := model.NewMergeJob(sizedFile(t, dir, "huge.pdf", 500<<20), nil, "")
+	qj, _ := queue.Enqueue(job, SourceScheduled, noopJob)
+	queue.Start(context.Background())
+	queue.Close()
+
+	if err := qj.Wait(); err != nil {
+		t.Fatalf("没有统计时应照常执行，实际: %v", err)
+	}
+	detail, ok := historyDetail(job, HistoryEstimated)
+	if !ok || !strings.Contains(detail, "警告") {
+		t.Errorf("无法估算时应记录警告，实际: %q", detail)
+	}
+}
+
+// runOverrunJob 启动一个写入部分输出后一直运行的任务，并把虚拟时间推进到截止时刻之后
+func runOverrunJob(t *testing.T, policy OverrunPolicy, output string, release <-chan struct{}) (*QueuedJob, chan string) {
+	t.Helper()
+	fake := clock.NewFake(time.Date(2024, 6, 1, 5, 50, 0, 0, time.UTC), 1)
+	logs := make(chan string, 16)
+	queue := NewJobQueue(1, nil)
+	queue.SetBudget(&BudgetPolicy{
+		Deadline:  fake.Now().Add(10 * time.Minute),
+		Estimator: &ThroughputEstimator{Stats: syntheticStats()},
+		Overrun:   policy,
+		Clock:     fake,
+		Log:       chanWriter(logs),
+	})
+	queue.Start(context.Background())
+
+	started := make(chan struct{})
+	job := model.NewMergeJob(sizedFile(t, t.TempDir(), "in.pdf", 512<<10), nil, output)
+	qj, err := queue.Enqueue(job, SourceScheduled, func(ctx context.Context, _ *QueuedJob) error {
+		if err := os.WriteFile(output, []byte("partial"), 0644); err != nil {
+			return err
+		}
+		close(started)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-release:
+			return nil
+		}
+	})
+	if err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	<-started
+	fake.Advance(15 * time.Minute)
+	return qj, logs
+}
+
+// waitForLog 等待包含指定事件的日志行
+func waitForLog(t *testing.T, logs chan string, event string) string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line := <-logs:
+			if strings.Contains(line, " "+event+":") {
+				return line
+			}
+		case <-timeout:
+			t.Fatalf("没有等到事件 %s", event)
+			return ""
+		}
+	}
+}
+
+func TestJobQueue_OverrunCancelRollsBackOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("existing output restored", func(t *testing.T) {
+		output := filepath.Join(dir, "existing.pdf")
+		os.WriteFile(output, []byte("previous result"), 0644)
+
+		qj, _ := runOverrunJob(t, OverrunCancel, output, nil)
+		err := qj.Wait()
+		if !errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("超时任务应返回ErrBudgetExceeded，实际: %v", err)
+		}
+		data, _ := os.ReadFile(output)
+		if string(data) != "previous result" {
+			t.Errorf("输出应恢复为任务开始前的内容，实际: %q", data)
+		}
+		if _, err := os.Stat(output + ".bak"); !os.IsNotExist(err) {
+			t.Error("回滚后应删除备份")
+		}
+		for _, event := range []string{HistoryBudgetExceeded, HistoryRolledBack} {
+			if !containsEvent(qj.Job, event) {
+				t.Errorf("历史中缺少事件 %s: %v", event, historyEvents(qj.Job))
+			}
+		}
+	})
+
+	t.Run("partial output removed", func(t *testing.T) {
+		output := filepath.Join(dir, "new.pdf")
+		qj, _ := runOverrunJob(t, OverrunCancel, output, nil)
+		if err := qj.Wait(); !errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("超时任务应返回ErrBudgetExceeded，实际: %v", err)
+		}
+		if _, err := os.Stat(output); !os.IsNotExist(err) {
+			t.Error("任务开始前不存在的输出应被删除")
+		}
+	})
+}
+
+func TestJobQueue_OverrunAllowContinuesWithWarning(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.pdf")
+	release := make(chan struct{})
+
+	qj, logs := runOverrunJob(t, OverrunAllow, output, release)
+	line := waitForLog(t, logs, HistoryBudgetExceeded)
+	if !strings.Contains(line, "警告") {
+		t.Errorf("允许超时时应记录警告，实际: %q", line)
+	}
+	close(release)
+
+	if err := qj.Wait(); err != nil {
+		t.Fatalf("允许超时的任务应正常完成，实际: %v", err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "partial" {
+		t.Errorf("允许超时时不应回滚输出，实际: %q", data)
+	}
+	if containsEvent(qj.Job, HistoryRolledBack) {
+		t.Error("允许超时时不应回滚")
+	}
+}
@@ -115,22 +115,30 @@ func (cm *CancellationManager) GracefulCancellation(jobID string, timeout time.D
 	}
 
 	// 等待任务完成清理
+	clk := cm.controller.Clock
+	timer := clk.NewTimer(timeout)
+	defer timer.Stop()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
 	done := make(chan bool, 1)
 	go func() {
-		// 等待任务状态变为非运行状态
+		// 等待任务状态变为非运行状态，超时后随 ctx 退出
 		for {
 			if !cm.controller.IsJobRunning() {
 				done <- true
 				return
 			}
-			time.Sleep(100 * time.Millisecond)
+			if clk.Sleep(ctx, 100*time.Millisecond) != nil {
+				return
+			}
 		}
 	}()
 
 	select {
 	case <-done:
 		return nil
-	case <-time.After(timeout):
+	case <-timer.C():
 		return fmt.Errorf("取消操作超时")
 	}
 }
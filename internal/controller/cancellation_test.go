@@ -6,9 +6,34 @@ import (
 	"testing"
 	"time"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/model"
 )
 
+func TestCancellationManager_GracefulCancellationTimesOutOnClock(t *testing.T) {
+	controller := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	controller.Clock = fake
+	cancelManager := NewCancellationManager(controller)
+
+	// 任务一直不退出
+	controller.currentJob = model.NewMergeJob("main.pdf", nil, "output.pdf")
+	_, cancel := context.WithCancel(context.Background())
+	cancelManager.RegisterCancellation("stuck", cancel)
+
+	start := time.Now()
+	err := cancelManager.GracefulCancellation("stuck", 5*time.Second)
+	if err == nil {
+		t.Fatal("任务未退出时应返回超时错误")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("使用虚拟时钟时不应真实等待5秒，耗时 %v", elapsed)
+	}
+	if fake.Now().Before(time.Unix(5, 0)) {
+		t.Errorf("超时前虚拟时间应至少推进5秒，实际 %v", fake.Now())
+	}
+}
+
 func TestCancellationManager_CancelAllJobs(t *testing.T) {
 	// 创建模拟服务
 	mockPDF := &mockPDFService{}
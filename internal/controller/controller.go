@@ -2,10 +2,13 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/model"
 	"github.com/user/pdf-merger/pkg/file"
 	"github.com/user/pdf-merger/pkg/pdf"
@@ -25,13 +28,42 @@ type Controller struct {
 	PDFService  pdf.PDFService
 	FileManager file.FileManager
 	Config      *model.Config
+	Clock       clock.Clock // 时间与随机源，默认取 Config.Clock，测试中可替换为 clock.Fake
+
+	// GenerateTOC 之后启动的任务是否在输出开头插入目录页，由GUI输出区域的复选框设置
+	GenerateTOC bool
+
+	// Rotations 之后启动的任务中按输入路径指定的顺时针旋转角度，由GUI文件列表的旋转按钮或CLI的 -rotate 设置
+	Rotations map[string]int
+
+	// NormalizeOrientation 之后启动的任务是否在合并前把页面的 /Rotate 写入页面内容
+	NormalizeOrientation bool
 
 	// 当前任务管理
 	currentJob          *model.MergeJob
 	jobMutex            sync.RWMutex
-	cancelFunc          context.CancelFunc
-	workflowManager     *WorkflowManager
 	cancellationManager *CancellationManager
+	lastPartialResult   *pdf.MergeResult // 最近一次失败任务的部分结果
+	lastResult          *pdf.MergeResult // 最近一次成功任务的合并结果
+	jobQueue            *JobQueue
+	jobs                []*model.MergeJob // 通过控制器入队的任务，按入队顺序
+
+	// 按任务订阅的回调
+	subscriptionMu  sync.Mutex
+	subscriptionSeq uint64
+	subscriptions   map[uint64]jobSubscription
+
+	// 工作区大小缓存
+	workspaceMu    sync.Mutex
+	workspaceSizes map[string]workspaceSize
+
+	// 运行中任务的断点最后写入的进度阶段
+	checkpointMu     sync.Mutex
+	checkpointStages map[string]string
+
+	// 密码保险库，nil时不使用
+	vaultMu sync.RWMutex
+	vault   pdf.PasswordVault
 
 	// 回调函数
 	progressCallback   ProgressCallback
@@ -49,10 +81,11 @@ func NewController(
 		PDFService:  pdfService,
 		FileManager: fileManager,
 		Config:      config,
+		Clock:       clock.System(),
+	}
+	if config != nil {
+		controller.Clock = clock.OrSystem(config.Clock)
 	}
-
-	// 创建工作流程管理器
-	controller.workflowManager = NewWorkflowManager(controller)
 
 	// 创建取消管理器
 	controller.cancellationManager = NewCancellationManager(controller)
@@ -75,7 +108,7 @@ func (c *Controller) SetCompletionCallback(callback CompletionCallback) {
 	c.completionCallback = callback
 }
 
-// ValidateFile 验证单个文件
+// ValidateFile 验证单个文件，任何问题都作为错误返回；需要区分警告和失败时使用ValidateFileOutcome
 func (c *Controller) ValidateFile(filePath string) error {
 	// 首先验证文件是否存在和可访问
 	if err := c.FileManager.ValidateFile(filePath); err != nil {
@@ -86,6 +119,45 @@ func (c *Controller) ValidateFile(filePath string) error {
 	return c.PDFService.ValidatePDF(filePath)
 }
 
+// ValidationOutcome 区分警告和失败的文件验证结果
+type ValidationOutcome struct {
+	Valid    bool     // 没有硬性失败，文件可以加入列表和合并
+	Warnings []string // 按Config.ValidationPolicy视为警告的问题
+	Err      error    // 硬性失败的原因，Valid为true时为nil
+}
+
+// ValidateFileOutcome 验证单个文件，并按Config.ValidationPolicy把PDF错误分为警告和失败。
+// 文件无法访问和不是PDFError的错误总是失败
+func (c *Controller) ValidateFileOutcome(filePath string) ValidationOutcome {
+	if err := c.FileManager.ValidateFile(filePath); err != nil {
+		return ValidationOutcome{Err: fmt.Errorf("文件访问失败: %v", err)}
+	}
+
+	err := c.PDFService.ValidatePDF(filePath)
+	if err == nil {
+		return ValidationOutcome{Valid: true}
+	}
+	var pdfErr *pdf.PDFError
+	if c.Config == nil || !errors.As(err, &pdfErr) ||
+		c.Config.ValidationSeverityOf(pdfErr.TypeName()) != model.ValidationWarning {
+		return ValidationOutcome{Err: err}
+	}
+	return ValidationOutcome{Valid: true, Warnings: validationWarnings(err)}
+}
+
+// validationWarnings 把视为警告的验证错误展开为逐条说明，没有诊断时使用错误本身
+func validationWarnings(err error) []string {
+	issues := pdf.ValidationIssues(err)
+	if len(issues) == 0 {
+		return []string{err.Error()}
+	}
+	warnings := make([]string, len(issues))
+	for i, issue := range issues {
+		warnings[i] = issue.String()
+	}
+	return warnings
+}
+
 // ValidateFiles 验证多个文件
 func (c *Controller) ValidateFiles(filePaths []string) map[string]error {
 	results := make(map[string]error)
@@ -100,11 +172,44 @@ func (c *Controller) ValidateFiles(filePaths []string) map[string]error {
 	return results
 }
 
+// DisplayName 返回文件名的显示形式：非UTF-8文件名按配置的回退编码解码，仅用于消息和界面
+func (c *Controller) DisplayName(filePath string) string {
+	encodings := model.DefaultFilenameEncodings
+	if c.Config != nil && len(c.Config.FilenameEncodings) > 0 {
+		encodings = c.Config.FilenameEncodings
+	}
+	return model.DisplayBase(filePath, encodings)
+}
+
 // GetPDFInfo 获取PDF文件信息
 func (c *Controller) GetPDFInfo(filePath string) (*pdf.PDFInfo, error) {
 	return c.PDFService.GetPDFInfo(filePath)
 }
 
+// InvalidatePDFInfo 丢弃服务缓存的文件信息，下次GetPDFInfo重新解析文件；服务不缓存信息时不做任何事
+func (c *Controller) InvalidatePDFInfo(filePath string) {
+	if invalidator, ok := c.PDFService.(pdf.InfoInvalidator); ok {
+		invalidator.InvalidateInfo(filePath)
+	}
+}
+
+// UseProfile 让之后开始的合并使用配置中名为name的合并配置方案，name为空时使用默认方案。
+// 方案不存在时返回列出可用方案的错误；服务不支持切换方案时只有默认方案可用
+func (c *Controller) UseProfile(name string) error {
+	profile, err := c.Config.ResolveProfile(name)
+	if err != nil {
+		return err
+	}
+	applier, ok := c.PDFService.(pdf.ProfileApplier)
+	if !ok {
+		if name == "" || name == model.DefaultProfileName {
+			return nil
+		}
+		return fmt.Errorf("PDF服务不支持合并配置方案: %s", name)
+	}
+	return applier.ApplyProfile(profile)
+}
+
 // GetCurrentJob 获取当前任务
 func (c *Controller) GetCurrentJob() *model.MergeJob {
 	c.jobMutex.RLock()
@@ -112,6 +217,21 @@ func (c *Controller) GetCurrentJob() *model.MergeJob {
 	return c.currentJob
 }
 
+// GetLastPartialResult 获取最近一次失败任务的部分合并结果，没有时返回nil
+func (c *Controller) GetLastPartialResult() *pdf.MergeResult {
+	c.jobMutex.RLock()
+	defer c.jobMutex.RUnlock()
+	return c.lastPartialResult
+}
+
+// GetLastResult 获取最近一次成功任务的合并结果，没有时返回nil。服务不提供合并结果时
+// （未实现 pdf.MergeResultProvider），结果只包含输出路径、处理时间和输出页数
+func (c *Controller) GetLastResult() *pdf.MergeResult {
+	c.jobMutex.RLock()
+	defer c.jobMutex.RUnlock()
+	return c.lastResult
+}
+
 // IsJobRunning 检查是否有任务正在运行
 func (c *Controller) IsJobRunning() bool {
 	c.jobMutex.RLock()
@@ -120,38 +240,71 @@ func (c *Controller) IsJobRunning() bool {
 	return c.currentJob != nil
 }
 
-// StartMergeJob 开始合并任务（异步）
+// StartMergeJob 开始合并任务（异步）。任务加入任务队列，与 EnqueueMergeJob 加入的任务依次执行；
+// 通过本方法启动的上一个任务尚未结束时仍然返回错误。
 func (c *Controller) StartMergeJob(mainFile string, additionalFiles []string, outputPath string) error {
+	return c.startMergeJob(model.NewMergeJobWithClock(c.Clock, mainFile, additionalFiles, outputPath))
+}
+
+// startMergeJob 在没有任务运行时把任务设为当前任务并加入任务队列
+func (c *Controller) startMergeJob(job *model.MergeJob) error {
 	// 检查是否已有任务在运行
 	if c.IsJobRunning() {
 		return fmt.Errorf("已有合并任务正在运行")
 	}
 
-	// 创建新任务
-	job := model.NewMergeJob(mainFile, additionalFiles, outputPath)
-
 	c.jobMutex.Lock()
 	c.currentJob = job
+	job.GenerateTOC = job.GenerateTOC || c.GenerateTOC
+	job.NormalizeOrientation = job.NormalizeOrientation || c.NormalizeOrientation
+	if len(c.Rotations) > 0 {
+		rotations := make(map[string]int, len(c.Rotations)+len(job.Rotations))
+		for path, degrees := range c.Rotations {
+			rotations[path] = degrees
+		}
+		// 任务自带的旋转优先
+		for path, degrees := range job.Rotations {
+			rotations[path] = degrees
+		}
+		job.Rotations = rotations
+	}
 	c.jobMutex.Unlock()
 
-	// 创建可取消的上下文
-	ctx, cancel := context.WithCancel(context.Background())
-	c.cancelFunc = cancel
-
 	// 注册取消操作
-	c.cancellationManager.RegisterCancellation(job.ID, cancel)
+	c.cancellationManager.RegisterCancellation(job.ID, func() { c.CancelJob(job.ID) })
 
 	// 添加清理任务
 	c.cancellationManager.AddCleanupTask(NewTempFileCleanupTask(c.FileManager))
 	c.cancellationManager.AddCleanupTask(NewMemoryCleanupTask())
 	c.cancellationManager.AddCleanupTask(NewJobStateCleanupTask(c))
 
-	// 异步执行合并
-	go c.executeMergeJob(ctx, job)
-
+	if err := c.enqueue(job); err != nil {
+		c.jobMutex.Lock()
+		c.currentJob = nil
+		c.jobMutex.Unlock()
+		return err
+	}
 	return nil
 }
 
+// mergeResult 取走服务为任务输出记录的合并结果；服务没有结果时按任务和输出文件构造
+func (c *Controller) mergeResult(job *model.MergeJob, started time.Time) *pdf.MergeResult {
+	if provider, ok := c.PDFService.(pdf.MergeResultProvider); ok {
+		if result := provider.TakeMergeResult(job.OutputPath); result != nil {
+			return result
+		}
+	}
+	result := &pdf.MergeResult{
+		OutputPath:     job.OutputPath,
+		ProcessedFiles: len(job.AdditionalFiles) + 1,
+		ProcessingTime: c.Clock.Now().Sub(started),
+	}
+	if info, err := c.PDFService.GetPDFInfo(job.OutputPath); err == nil {
+		result.TotalPages = info.PageCount
+	}
+	return result
+}
+
 // CancelCurrentJob 取消当前任务
 func (c *Controller) CancelCurrentJob() error {
 	c.jobMutex.RLock()
@@ -166,45 +319,64 @@ func (c *Controller) CancelCurrentJob() error {
 	return c.cancellationManager.GracefulCancellation(currentJob.ID, 5*time.Second)
 }
 
-// executeMergeJob 执行合并任务的内部方法
-func (c *Controller) executeMergeJob(ctx context.Context, job *model.MergeJob) {
+// executeMergeJob 执行合并任务的内部方法，作为任务队列的执行函数
+func (c *Controller) executeMergeJob(ctx context.Context, qj *QueuedJob) error {
+	job := qj.Job
+	// 任务运行期间其他任务触发的临时文件清理不会删除本任务的临时文件
+	releaseTempFiles := c.FileManager.HoldTempFiles()
 	defer func() {
+		releaseTempFiles()
+		// 正常结束时已在通知前删除，这里处理执行中途异常退出的情况
+		c.removeCheckpoint(job)
 		c.jobMutex.Lock()
-		// 只有在任务完成或失败时才清空当前任务
-		if c.currentJob != nil && (c.currentJob.Status == model.JobCompleted || c.currentJob.Status == model.JobFailed) {
+		// 任务结束后清空当前任务
+		if c.currentJob == job && job.Status != model.JobRunning {
 			c.currentJob = nil
 		}
-		c.cancelFunc = nil
 		c.jobMutex.Unlock()
+		c.pruneFinishedJobs()
 	}()
 
 	// 标记任务开始
 	c.jobMutex.Lock()
 	job.SetRunning()
+	c.lastPartialResult = nil
+	c.lastResult = nil
 	c.jobMutex.Unlock()
 
-	c.notifyProgress(0.0, "开始合并", "正在启动合并工作流程...")
+	started := c.Clock.Now()
+	c.notifyJobProgress(job, 0.0, "开始合并", "正在启动合并工作流程...")
 
-	// 使用工作流程管理器执行完整的合并流程
-	if err := c.workflowManager.ExecuteWorkflow(ctx, job); err != nil {
+	// 每个任务使用独立的工作流程管理器，多个任务可以同时运行
+	err := NewWorkflowManager(c).ExecuteWorkflow(ctx, job)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
 		c.jobMutex.Lock()
-		job.SetFailed(err)
+		if ctx.Err() != nil {
+			job.SetCancelled(err)
+		} else {
+			job.SetFailed(err)
+			c.lastPartialResult = pdf.PartialMergeResult(err)
+		}
 		c.jobMutex.Unlock()
-		c.notifyError(err)
-		return
-	}
-
-	// 检查取消
-	if ctx.Err() != nil {
-		return
+		// 先删除断点和工作区再发出结束事件，收到事件的一方不会看到已结束任务的工作区
+		c.removeCheckpoint(job)
+		c.notifyJobError(job, err)
+		return err
 	}
 
 	// 标记任务完成
+	result := c.mergeResult(job, started)
 	c.jobMutex.Lock()
 	job.SetCompleted()
+	c.lastResult = result
 	c.jobMutex.Unlock()
 
-	c.notifyCompletion(job.OutputPath)
+	c.removeCheckpoint(job)
+	c.notifyJobCompletion(job)
+	return nil
 }
 
 // validateJobFiles 验证任务中的所有文件
@@ -220,15 +392,17 @@ func (c *Controller) validateJobFiles(ctx context.Context, job *model.MergeJob)
 
 		// 更新进度
 		progress := 0.2 * float64(i) / float64(totalFiles)
-		c.notifyProgress(progress, "验证文件", fmt.Sprintf("正在验证: %s", filePath))
+		c.notifyProgress(progress, "验证文件", fmt.Sprintf("正在验证: %s", c.DisplayName(filePath)))
 
-		// 验证文件
-		if err := c.ValidateFile(filePath); err != nil {
-			return fmt.Errorf("文件验证失败 %s: %v", filePath, err)
+		// 验证文件，只有警告的文件继续合并
+		if outcome := c.ValidateFileOutcome(filePath); !outcome.Valid {
+			return fmt.Errorf("文件验证失败 %s: %v", c.DisplayName(filePath), outcome.Err)
 		}
 
 		// 减少模拟验证时间
-		time.Sleep(10 * time.Millisecond)
+		if err := c.Clock.Sleep(ctx, 10*time.Millisecond); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -246,54 +420,81 @@ func (c *Controller) performMerge(ctx context.Context, job *model.MergeJob) erro
 	// 执行合并
 	err := c.PDFService.MergePDFs(job.MainFile, job.AdditionalFiles, job.OutputPath, progressWriter)
 	if err != nil {
-		return fmt.Errorf("合并失败: %v", err)
+		return fmt.Errorf("合并失败: %w", err)
 	}
 
 	return nil
 }
 
-// notifyProgress 通知进度更新
+// notifyProgress 通知当前任务的进度更新
 func (c *Controller) notifyProgress(progress float64, status, detail string) {
+	c.notifyJobProgress(nil, progress, status, detail)
+}
+
+// notifyJobProgress 通知任务的进度更新：先调用全局回调，再调用订阅了该任务的回调。
+// job 为nil时更新当前任务。进度限制在0.0-1.0之间
+func (c *Controller) notifyJobProgress(job *model.MergeJob, progress float64, status, detail string) {
+	progress = math.Max(0, math.Min(1, progress))
 	if c.progressCallback != nil {
 		c.progressCallback(progress, status, detail)
 	}
 
 	// 更新任务进度
 	c.jobMutex.Lock()
-	if c.currentJob != nil {
-		c.currentJob.UpdateProgress(progress * 100)
+	if job == nil {
+		job = c.currentJob
+	}
+	if job != nil {
+		job.UpdateProgress(progress * 100)
 	}
 	c.jobMutex.Unlock()
+
+	if job == nil {
+		return
+	}
+	c.saveCheckpoint(job, status, detail)
+	for _, callbacks := range c.subscribers(job.ID) {
+		if callbacks.Progress != nil {
+			callbacks.Progress(job.ID, progress, status, detail)
+		}
+	}
 }
 
-// notifyError 通知错误
-func (c *Controller) notifyError(err error) {
+// notifyJobError 通知任务失败或被取消
+func (c *Controller) notifyJobError(job *model.MergeJob, err error) {
 	if c.errorCallback != nil {
 		c.errorCallback(err)
 	}
+	for _, callbacks := range c.subscribers(job.ID) {
+		if callbacks.Error != nil {
+			callbacks.Error(job.ID, err)
+		}
+	}
 }
 
-// notifyCompletion 通知完成
-func (c *Controller) notifyCompletion(outputPath string) {
+// notifyJobCompletion 通知任务完成
+func (c *Controller) notifyJobCompletion(job *model.MergeJob) {
 	if c.completionCallback != nil {
-		c.completionCallback(outputPath)
+		c.completionCallback(job.OutputPath)
+	}
+	for _, callbacks := range c.subscribers(job.ID) {
+		if callbacks.Completion != nil {
+			callbacks.Completion(job.ID, job.OutputPath)
+		}
 	}
 }
 
 // MergePDFs 执行PDF合并操作（同步版本，保持向后兼容）
 func (c *Controller) MergePDFs(mainFile string, additionalFiles []string, outputPath string) error {
 	// 验证主文件
-	if err := c.ValidateFile(mainFile); err != nil {
-		return fmt.Errorf("主文件验证失败: %v", err)
+	if outcome := c.ValidateFileOutcome(mainFile); !outcome.Valid {
+		return fmt.Errorf("主文件验证失败: %v", outcome.Err)
 	}
 
-	// 验证附加文件
-	validationResults := c.ValidateFiles(additionalFiles)
-
-	// 收集有效的文件
+	// 收集有效的附加文件，只有警告的文件也参与合并
 	validFiles := []string{mainFile}
 	for _, filePath := range additionalFiles {
-		if err, exists := validationResults[filePath]; !exists || err == nil {
+		if c.ValidateFileOutcome(filePath).Valid {
 			validFiles = append(validFiles, filePath)
 		}
 	}
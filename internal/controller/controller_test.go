@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -16,11 +20,15 @@ import (
 // mockPDFService 模拟PDF服务
 type mockPDFService struct {
 	validateError error
+	fileErrors    map[string]error // 按路径返回的验证错误，优先于validateError
 	mergeError    error
 	pdfInfo       *pdf.PDFInfo
 }
 
 func (m *mockPDFService) ValidatePDF(filePath string) error {
+	if err, ok := m.fileErrors[filePath]; ok {
+		return err
+	}
 	return m.validateError
 }
 
@@ -50,10 +58,71 @@ func (m *mockPDFService) MergePDFs(mainFile string, additionalFiles []string, ou
 	return m.mergeError
 }
 
+func (m *mockPDFService) ExtractPages(inputPath string, pages []int, outputPath string) error {
+	return nil
+}
+
+func (m *mockPDFService) ExtractPageRanges(inputPath string, ranges string, outputPath string) error {
+	return nil
+}
+
+func (m *mockPDFService) DecryptPDF(inputPath, outputPath, password string) error {
+	return nil
+}
+
+func (m *mockPDFService) RepairPDF(inputPath, outputPath string) (*pdf.RepairReport, error) {
+	return &pdf.RepairReport{InputPath: inputPath, OutputPath: outputPath}, nil
+}
+
+func (m *mockPDFService) ValidateConformance(filePath string) (*pdf.ConformanceReport, error) {
+	return &pdf.ConformanceReport{File: filePath, Conformance: pdf.ConformanceNone}, nil
+}
+
+func (m *mockPDFService) StampPDF(inputPath, outputPath string, opts *pdf.StampOptions) error {
+	return nil
+}
+
+func (m *mockPDFService) AddTableOfContents(outputPath string, sources []pdf.TOCSource) error {
+	return nil
+}
+
+func (m *mockPDFService) RotatePDF(inputPath, outputPath string, degrees int, normalize bool) ([]int, error) {
+	return nil, nil
+}
+
+func (m *mockPDFService) SplitPDF(inputPath string, opts *pdf.SplitOptions) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockPDFService) ListAttachments(filePath string) ([]pdf.AttachmentInfo, error) {
+	return nil, nil
+}
+
+func (m *mockPDFService) ValidateBatch(ctx context.Context, paths []string, workers int) (*pdf.BatchValidationReport, error) {
+	return &pdf.BatchValidationReport{Total: len(paths), Valid: len(paths)}, nil
+}
+
+func (m *mockPDFService) ExtractText(filePath string, pages []int) (map[int]string, error) {
+	return map[int]string{}, nil
+}
+
+func (m *mockPDFService) ExtractTextTo(filePath string, pages []int, w io.Writer) error {
+	return nil
+}
+
+func (m *mockPDFService) ExtractImages(filePath string, pages []int, outputDir string) ([]pdf.ExtractedImage, error) {
+	return nil, nil
+}
+
+func (m *mockPDFService) ExtractImagesContext(ctx context.Context, filePath string, pages []int, outputDir string) ([]pdf.ExtractedImage, error) {
+	return nil, nil
+}
+
 // mockFileManager 模拟文件管理器
 type mockFileManager struct {
 	validateError error
 	fileInfo      *file.FileInfo
+	sweeps        atomic.Int32 // CleanupTempFilesOlderThan的调用次数
 }
 
 func (m *mockFileManager) ValidateFile(filePath string) error {
@@ -80,6 +149,15 @@ func (m *mockFileManager) CleanupTempFiles() error {
 	return nil
 }
 
+func (m *mockFileManager) CleanupTempFilesOlderThan(maxAge time.Duration) (int, error) {
+	m.sweeps.Add(1)
+	return 0, nil
+}
+
+func (m *mockFileManager) HoldTempFiles() func() {
+	return func() {}
+}
+
 func (m *mockFileManager) RemoveTempFile(filePath string) error {
 	return nil
 }
@@ -107,6 +185,13 @@ func (m *mockFileManager) GetTempDir() string {
 func (m *mockFileManager) SetTempFileMaxAge(duration time.Duration) {
 }
 
+func (m *mockFileManager) SetTempQuota(quota file.TempQuota) {
+}
+
+func (m *mockFileManager) GetTempUsage() (int64, int) {
+	return 0, 0
+}
+
 func (m *mockFileManager) CopyFile(sourcePath, destPath string) error {
 	return nil
 }
@@ -140,6 +225,167 @@ func TestController_ValidateFile(t *testing.T) {
 	}
 }
 
+func TestController_ValidateFileOutcome(t *testing.T) {
+	structural := &pdf.PDFError{Type: pdf.ErrorValidation, Message: "PDF文件验证失败", File: "quirk.pdf"}
+	corrupted := &pdf.PDFError{Type: pdf.ErrorCorrupted, Message: "PDF文件可能已损坏", File: "broken.pdf"}
+	mockPDF := &mockPDFService{fileErrors: map[string]error{
+		"quirk.pdf":  structural,
+		"broken.pdf": corrupted,
+		"plain.pdf":  fmt.Errorf("unexpected"),
+	}}
+	mockFile := &mockFileManager{}
+	config := model.DefaultConfig()
+	controller := NewController(mockPDF, mockFile, config)
+
+	if outcome := controller.ValidateFileOutcome("ok.pdf"); !outcome.Valid || outcome.Err != nil || len(outcome.Warnings) != 0 {
+		t.Errorf("有效文件的结果不正确: %+v", outcome)
+	}
+
+	// 默认策略把结构检查错误视为警告
+	outcome := controller.ValidateFileOutcome("quirk.pdf")
+	if !outcome.Valid || outcome.Err != nil {
+		t.Errorf("只有警告的文件应有效: %+v", outcome)
+	}
+	if len(outcome.Warnings) != 1 || outcome.Warnings[0] != structural.Error() {
+		t.Errorf("警告不正确: %v", outcome.Warnings)
+	}
+	if controller.ValidateFile("quirk.pdf") == nil {
+		t.Error("ValidateFile应把警告也作为错误返回")
+	}
+
+	for _, path := range []string{"broken.pdf", "plain.pdf"} {
+		if outcome := controller.ValidateFileOutcome(path); outcome.Valid || outcome.Err == nil {
+			t.Errorf("%s 应为硬性失败: %+v", path, outcome)
+		}
+	}
+
+	// 策略可以把错误类型改为失败或警告
+	config.ValidationPolicy = map[string]model.ValidationSeverity{
+		"Validation Error": model.ValidationFailure,
+		"Corrupted File":   model.ValidationWarning,
+	}
+	if controller.ValidateFileOutcome("quirk.pdf").Valid {
+		t.Error("策略设为失败后结构检查错误应为硬性失败")
+	}
+	if !controller.ValidateFileOutcome("broken.pdf").Valid {
+		t.Error("策略设为警告后损坏错误应只产生警告")
+	}
+
+	// 文件无法访问总是失败
+	mockFile.validateError = fmt.Errorf("file not found")
+	if controller.ValidateFileOutcome("broken.pdf").Valid {
+		t.Error("无法访问的文件应为硬性失败")
+	}
+}
+
+// profileService 记录应用的合并配置方案的模拟服务
+type profileService struct {
+	mockPDFService
+	applied []model.MergeProfile
+}
+
+func (p *profileService) ApplyProfile(profile model.MergeProfile) error {
+	p.applied = append(p.applied, profile)
+	return nil
+}
+
+func TestController_UseProfile(t *testing.T) {
+	config := model.DefaultConfig()
+	config.Profiles = map[string]model.MergeProfile{
+		"archival": {Merge: model.ProfileMergeOptions{Linearize: true}},
+	}
+	service := &profileService{}
+	controller := NewController(service, &mockFileManager{}, config)
+
+	if err := controller.UseProfile("archival"); err != nil {
+		t.Fatalf("应用配置文件中的方案失败: %v", err)
+	}
+	if err := controller.UseProfile(""); err != nil {
+		t.Fatalf("空名称应使用默认方案: %v", err)
+	}
+	if len(service.applied) != 2 || !service.applied[0].Merge.Linearize || service.applied[1].Merge.Linearize {
+		t.Errorf("应用的方案不正确: %+v", service.applied)
+	}
+
+	err := controller.UseProfile("missing")
+	if !errors.Is(err, model.ErrUnknownProfile) {
+		t.Fatalf("未知方案应返回ErrUnknownProfile，实际 %v", err)
+	}
+	if !strings.Contains(err.Error(), "archival, default, low-memory") {
+		t.Errorf("错误应列出可用的方案: %v", err)
+	}
+	if len(service.applied) != 2 {
+		t.Error("未知方案不应应用到服务")
+	}
+
+	// 不支持方案的服务只接受默认方案
+	plain := NewController(&mockPDFService{}, &mockFileManager{}, config)
+	if err := plain.UseProfile(model.DefaultProfileName); err != nil {
+		t.Errorf("默认方案不应报错: %v", err)
+	}
+	if plain.UseProfile("archival") == nil {
+		t.Error("服务不支持方案时应返回错误")
+	}
+}
+
+func TestEventHandler_HandleMergeStartExcludesFailures(t *testing.T) {
+	mockPDF := &mockPDFService{fileErrors: map[string]error{
+		"quirk.pdf":  &pdf.PDFError{Type: pdf.ErrorValidation, Message: "PDF文件验证失败"},
+		"broken.pdf": &pdf.PDFError{Type: pdf.ErrorCorrupted, Message: "PDF文件可能已损坏"},
+	}}
+	controller := NewController(mockPDF, &mockFileManager{}, model.DefaultConfig())
+	handler := NewEventHandler(controller)
+
+	if err := handler.HandleMergeStart("broken.pdf", []string{"a.pdf"}, "out.pdf"); err == nil {
+		t.Error("主文件硬性失败时应拒绝开始合并")
+	}
+	if err := handler.HandleMergeStart("main.pdf", []string{"broken.pdf"}, "out.pdf"); err == nil {
+		t.Error("附加文件都硬性失败时应拒绝开始合并")
+	}
+
+	if err := handler.HandleMergeStart("main.pdf", []string{"quirk.pdf", "broken.pdf"}, "out.pdf"); err != nil {
+		t.Fatalf("有可合并的文件时应开始合并: %v", err)
+	}
+	job := controller.GetCurrentJob()
+	if job == nil {
+		t.Fatal("应已开始合并任务")
+	}
+	if len(job.AdditionalFiles) != 1 || job.AdditionalFiles[0] != "quirk.pdf" {
+		t.Errorf("合并任务应排除硬性失败的文件，实际 %v", job.AdditionalFiles)
+	}
+	time.Sleep(300 * time.Millisecond)
+}
+
+func TestEventHandler_HandleOutputPathChangedRejectsInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, []byte("%PDF-1.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.pdf")
+	if err := os.Symlink(input, link); err != nil {
+		t.Fatal(err)
+	}
+	controller := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	handler := NewEventHandler(controller)
+
+	if err := handler.HandleOutputPathChanged(filepath.Join(dir, "out.pdf"), input); err != nil {
+		t.Errorf("输出与输入不同时不应报错: %v", err)
+	}
+	err := handler.HandleOutputPathChanged(link, "other.pdf", input)
+	var pdfErr *pdf.PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != pdf.ErrorInvalidInput {
+		t.Fatalf("指向输入文件的符号链接应返回ErrorInvalidInput，实际 %v", err)
+	}
+
+	if _, err := controller.EnqueueMergeJob("other.pdf", []string{input}, link); !errors.As(err, &pdfErr) {
+		t.Errorf("输出是输入文件时应拒绝入队，实际 %v", err)
+	}
+	if len(controller.ListJobs()) != 0 {
+		t.Error("被拒绝的任务不应出现在任务列表中")
+	}
+}
+
 func TestController_StartMergeJob(t *testing.T) {
 	mockPDF := &mockPDFService{}
 	mockFile := &mockFileManager{}
@@ -182,6 +428,15 @@ func TestController_StartMergeJob(t *testing.T) {
 	if job != nil {
 		t.Error("Expected current job to be nil after completion")
 	}
+
+	// 服务不提供合并结果时由控制器补全摘要
+	result := controller.GetLastResult()
+	if result == nil {
+		t.Fatal("Expected last result after completion")
+	}
+	if result.OutputPath != "output.pdf" || result.ProcessedFiles != 3 || result.TotalPages != 10 {
+		t.Errorf("Unexpected last result: %+v", result)
+	}
 }
 
 func TestController_CancelCurrentJob(t *testing.T) {
@@ -17,7 +17,7 @@ type EventHandler struct {
 	onUIStateChanged func(enabled bool)
 	onProgressUpdate func(progress float64, status, detail string)
 	onError          func(err error)
-	onCompletion     func(message string)
+	onCompletion     func(result *pdf.MergeResult)
 }
 
 // NewEventHandler 创建新的事件处理器
@@ -49,26 +49,27 @@ func (eh *EventHandler) SetErrorCallback(callback func(err error)) {
 	eh.onError = callback
 }
 
-// SetCompletionCallback 设置完成回调
-func (eh *EventHandler) SetCompletionCallback(callback func(message string)) {
+// SetCompletionCallback 设置完成回调，回调收到的合并结果至少包含输出路径
+func (eh *EventHandler) SetCompletionCallback(callback func(result *pdf.MergeResult)) {
 	eh.onCompletion = callback
 }
 
 // HandleMainFileSelected 处理主文件选择事件
 func (eh *EventHandler) HandleMainFileSelected(filePath string) error {
-	// 验证文件
-	if err := eh.controller.ValidateFile(filePath); err != nil {
-		return fmt.Errorf("主文件无效: %v", err)
+	// 验证文件，只有警告的文件可以作为主文件
+	if outcome := eh.controller.ValidateFileOutcome(filePath); !outcome.Valid {
+		return fmt.Errorf("主文件无效: %v", outcome.Err)
 	}
 
 	return nil
 }
 
-// HandleAdditionalFileAdded 处理附加文件添加事件
+// HandleAdditionalFileAdded 处理附加文件添加事件，只有警告的文件照常加入，警告记录在条目的Warnings中
 func (eh *EventHandler) HandleAdditionalFileAdded(filePath string) (*model.FileEntry, error) {
 	// 验证文件
-	if err := eh.controller.ValidateFile(filePath); err != nil {
-		return nil, fmt.Errorf("文件无效: %v", err)
+	outcome := eh.controller.ValidateFileOutcome(filePath)
+	if !outcome.Valid {
+		return nil, fmt.Errorf("文件无效: %v", outcome.Err)
 	}
 
 	// 获取文件信息
@@ -80,15 +81,17 @@ func (eh *EventHandler) HandleAdditionalFileAdded(filePath string) (*model.FileE
 	// 创建文件条目
 	entry := &model.FileEntry{
 		Path:        filePath,
-		DisplayName: filepath.Base(filePath),
+		DisplayName: eh.controller.DisplayName(filePath),
 		Size:        fileInfo.Size,
 		IsValid:     true,
+		Warnings:    outcome.Warnings,
 	}
 
 	// 获取PDF信息
 	if pdfInfo, err := eh.controller.GetPDFInfo(filePath); err == nil {
 		entry.PageCount = pdfInfo.PageCount
 		entry.IsEncrypted = pdfInfo.IsEncrypted
+		entry.Signatures = pdfInfo.SignatureCount
 	} else {
 		entry.SetError(err.Error())
 	}
@@ -121,11 +124,25 @@ func (eh *EventHandler) HandleMergeStart(mainFile string, additionalFiles []stri
 		return fmt.Errorf("请选择输出文件路径")
 	}
 
+	// 文件加入列表后可能被修改或替换，开始前重新验证，排除硬性失败的附加文件
+	if outcome := eh.controller.ValidateFileOutcome(mainFile); !outcome.Valid {
+		return fmt.Errorf("主文件无效: %v", outcome.Err)
+	}
+	mergeable := make([]string, 0, len(additionalFiles))
+	for _, filePath := range additionalFiles {
+		if eh.controller.ValidateFileOutcome(filePath).Valid {
+			mergeable = append(mergeable, filePath)
+		}
+	}
+	if len(mergeable) == 0 {
+		return fmt.Errorf("附加文件都未通过验证，没有可合并的文件")
+	}
+
 	// 禁用UI
 	eh.notifyUIStateChanged(false)
 
 	// 开始异步合并任务
-	if err := eh.controller.StartMergeJob(mainFile, additionalFiles, outputPath); err != nil {
+	if err := eh.controller.StartMergeJob(mainFile, mergeable, outputPath); err != nil {
 		// 如果启动失败，重新启用UI
 		eh.notifyUIStateChanged(true)
 		return err
@@ -146,6 +163,33 @@ func (eh *EventHandler) HandleMergeCancel() error {
 	return nil
 }
 
+// HandleMergeEnqueue 处理批量合并事件：任务加入队列，已有任务运行时也不会拒绝。
+// 返回的任务ID可用于 SubscribeJob 和 HandleJobCancel。
+func (eh *EventHandler) HandleMergeEnqueue(mainFile string, additionalFiles []string, outputPath string) (string, error) {
+	if mainFile == "" {
+		return "", fmt.Errorf("请选择主PDF文件")
+	}
+	if outputPath == "" {
+		return "", fmt.Errorf("请选择输出文件路径")
+	}
+	return eh.controller.EnqueueMergeJob(mainFile, additionalFiles, outputPath)
+}
+
+// HandleJobCancel 处理取消指定任务的事件
+func (eh *EventHandler) HandleJobCancel(jobID string) error {
+	return eh.controller.CancelJob(jobID)
+}
+
+// SubscribeJob 订阅指定任务的回调，返回取消订阅的函数
+func (eh *EventHandler) SubscribeJob(jobID string, callbacks JobCallbacks) func() {
+	return eh.controller.SubscribeJob(jobID, callbacks)
+}
+
+// ListJobs 返回队列中的任务
+func (eh *EventHandler) ListJobs() []*model.MergeJob {
+	return eh.controller.ListJobs()
+}
+
 // HandleFileRemoval 处理文件移除事件
 func (eh *EventHandler) HandleFileRemoval(filePath string) error {
 	// 这里可以添加文件移除的相关逻辑
@@ -153,8 +197,12 @@ func (eh *EventHandler) HandleFileRemoval(filePath string) error {
 	return nil
 }
 
-// HandleOutputPathChanged 处理输出路径变更事件
-func (eh *EventHandler) HandleOutputPathChanged(outputPath string) error {
+// HandleOutputPathChanged 处理输出路径变更事件，inputFiles为已选择的输入文件，输出与其中之一相同时返回错误
+func (eh *EventHandler) HandleOutputPathChanged(outputPath string, inputFiles ...string) error {
+	if err := pdf.CheckOutputNotInput(outputPath, inputFiles); err != nil {
+		return err
+	}
+
 	// 验证输出路径的目录是否存在
 	dir := filepath.Dir(outputPath)
 	if err := eh.controller.FileManager.EnsureDirectoryExists(dir); err != nil {
@@ -208,10 +256,14 @@ func (eh *EventHandler) handleCompletion(outputPath string) {
 	// 重新启用UI
 	eh.notifyUIStateChanged(true)
 
-	message := fmt.Sprintf("PDF合并完成！\n输出文件: %s", outputPath)
-	if eh.onCompletion != nil {
-		eh.onCompletion(message)
+	if eh.onCompletion == nil {
+		return
+	}
+	result := eh.controller.GetLastResult()
+	if result == nil || result.OutputPath != outputPath {
+		result = &pdf.MergeResult{OutputPath: outputPath}
 	}
+	eh.onCompletion(result)
 }
 
 // notifyUIStateChanged 通知UI状态变更
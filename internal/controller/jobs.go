@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// maxFinishedJobs ListJobs 保留的已结束任务数，超出时丢弃最早结束的任务
+const maxFinishedJobs = 100
+
+// JobCallbacks 订阅任务的回调，回调的第一个参数为任务ID，字段为nil时忽略
+type JobCallbacks struct {
+	Progress   func(jobID string, progress float64, status, detail string)
+	Error      func(jobID string, err error)
+	Completion func(jobID string, outputPath string)
+}
+
+// jobSubscription 一个任务订阅，jobID 为空时订阅所有任务
+type jobSubscription struct {
+	jobID     string
+	callbacks JobCallbacks
+}
+
+// EnqueueMergeJob 将合并任务加入任务队列并立即返回任务ID。
+// 任务按入队顺序执行，同时运行的任务数由 Config.MaxConcurrentJobs 决定；
+// 与 StartMergeJob 不同，已有任务在运行时不会拒绝新任务。
+func (c *Controller) EnqueueMergeJob(mainFile string, additionalFiles []string, outputPath string) (string, error) {
+	job := model.NewMergeJobWithClock(c.Clock, mainFile, additionalFiles, outputPath)
+	if err := c.enqueue(job); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// EnqueueJob 与 EnqueueMergeJob 相同，但加入调用方创建的任务，任务自带的目录、旋转等选项保持不变。
+// 调用方可以在入队前用任务ID订阅回调，不会错过任务开始时的通知
+func (c *Controller) EnqueueJob(job *model.MergeJob) error {
+	return c.enqueue(job)
+}
+
+// ListJobs 返回通过控制器入队的任务（等待、运行中和最近结束的），按入队顺序排列
+func (c *Controller) ListJobs() []*model.MergeJob {
+	c.jobMutex.RLock()
+	defer c.jobMutex.RUnlock()
+	return append([]*model.MergeJob(nil), c.jobs...)
+}
+
+// CancelJob 取消指定任务：等待中的任务不再执行，运行中的任务在下一个取消检查点停止。
+// 两种情况都会以取消错误通知该任务的错误回调。
+func (c *Controller) CancelJob(jobID string) error {
+	c.jobMutex.RLock()
+	queue := c.jobQueue
+	c.jobMutex.RUnlock()
+	if queue == nil {
+		return fmt.Errorf("任务 %s 不存在或已完成", jobID)
+	}
+
+	started, err := queue.Cancel(jobID)
+	if err != nil {
+		return fmt.Errorf("任务 %s 不存在或已完成", jobID)
+	}
+	if started {
+		// 运行中的任务由 executeMergeJob 标记状态并通知
+		return nil
+	}
+
+	job := c.findJob(jobID)
+	if job == nil {
+		return nil
+	}
+	c.jobMutex.Lock()
+	job.SetCancelled(ErrJobCancelled)
+	if c.currentJob == job {
+		c.currentJob = nil
+	}
+	c.jobMutex.Unlock()
+	c.notifyJobError(job, ErrJobCancelled)
+	c.pruneFinishedJobs()
+	return nil
+}
+
+// SubscribeJob 订阅指定任务的进度、错误和完成回调，jobID 为空时订阅所有任务。
+// 返回的函数取消订阅。回调在执行任务的协程中调用，不应阻塞。
+func (c *Controller) SubscribeJob(jobID string, callbacks JobCallbacks) func() {
+	c.subscriptionMu.Lock()
+	defer c.subscriptionMu.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[uint64]jobSubscription)
+	}
+	c.subscriptionSeq++
+	id := c.subscriptionSeq
+	c.subscriptions[id] = jobSubscription{jobID: jobID, callbacks: callbacks}
+
+	return func() {
+		c.subscriptionMu.Lock()
+		defer c.subscriptionMu.Unlock()
+		delete(c.subscriptions, id)
+	}
+}
+
+// subscribers 返回订阅了指定任务的回调，按订阅顺序排列
+func (c *Controller) subscribers(jobID string) []JobCallbacks {
+	c.subscriptionMu.Lock()
+	defer c.subscriptionMu.Unlock()
+	var callbacks []JobCallbacks
+	for id := uint64(1); id <= c.subscriptionSeq; id++ {
+		sub, ok := c.subscriptions[id]
+		if ok && (sub.jobID == "" || sub.jobID == jobID) {
+			callbacks = append(callbacks, sub.callbacks)
+		}
+	}
+	return callbacks
+}
+
+// enqueue 记录任务并加入任务队列；输出路径与某个输入文件相同时拒绝入队
+func (c *Controller) enqueue(job *model.MergeJob) error {
+	if err := pdf.CheckOutputNotInput(job.OutputPath, append([]string{job.MainFile}, job.AdditionalFiles...)); err != nil {
+		return err
+	}
+	queue := c.ensureJobQueue()
+
+	c.jobMutex.Lock()
+	c.jobs = append(c.jobs, job)
+	c.jobMutex.Unlock()
+
+	if _, err := queue.Enqueue(job, SourceInteractive, c.executeMergeJob); err != nil {
+		c.jobMutex.Lock()
+		c.jobs = removeMergeJob(c.jobs, job)
+		c.jobMutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// ensureJobQueue 返回控制器的任务队列，没有时按 Config.MaxConcurrentJobs 创建并启动。
+// 通过 SetJobQueue 设置的队列由调用方负责启动。
+func (c *Controller) ensureJobQueue() *JobQueue {
+	c.jobMutex.Lock()
+	defer c.jobMutex.Unlock()
+	if c.jobQueue == nil {
+		workers := 1
+		if c.Config != nil && c.Config.MaxConcurrentJobs > 0 {
+			workers = c.Config.MaxConcurrentJobs
+		}
+		c.jobQueue = NewJobQueue(workers, nil)
+		c.jobQueue.Start(context.Background())
+	}
+	return c.jobQueue
+}
+
+// findJob 按ID查找通过控制器入队的任务
+func (c *Controller) findJob(jobID string) *model.MergeJob {
+	c.jobMutex.RLock()
+	defer c.jobMutex.RUnlock()
+	for _, job := range c.jobs {
+		if job.ID == jobID {
+			return job
+		}
+	}
+	return nil
+}
+
+// pruneFinishedJobs 已结束的任务超过 maxFinishedJobs 时丢弃最早入队的已结束任务
+func (c *Controller) pruneFinishedJobs() {
+	c.jobMutex.Lock()
+	defer c.jobMutex.Unlock()
+
+	finished := 0
+	for _, job := range c.jobs {
+		if jobFinished(job) {
+			finished++
+		}
+	}
+	kept := c.jobs[:0]
+	for _, job := range c.jobs {
+		if finished > maxFinishedJobs && jobFinished(job) {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	c.jobs = kept
+}
+
+// jobFinished 判断任务是否已经结束
+func jobFinished(job *model.MergeJob) bool {
+	return job.Status != model.JobPending && job.Status != model.JobRunning
+}
+
+// removeMergeJob 从切片中移除任务
+func removeMergeJob(jobs []*model.MergeJob, target *model.MergeJob) []*model.MergeJob {
+	for i, job := range jobs {
+		if job == target {
+			return append(jobs[:i], jobs[i+1:]...)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// gatedPDFService 合并时阻塞，直到测试放行
+type gatedPDFService struct {
+	mockPDFService
+	started chan string
+	release chan struct{}
+}
+
+func newGatedPDFService() *gatedPDFService {
+	return &gatedPDFService{
+		started: make(chan string, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *gatedPDFService) MergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) error {
+	g.started <- outputPath
+	<-g.release
+	return nil
+}
+
+// jobEvents 记录订阅收到的事件
+type jobEvents struct {
+	mu       sync.Mutex
+	progress map[string]int
+	errors   map[string]error
+	done     chan string
+}
+
+func subscribeEvents(c *Controller, jobID string) *jobEvents {
+	events := &jobEvents{
+		progress: make(map[string]int),
+		errors:   make(map[string]error),
+		done:     make(chan string, 10),
+	}
+	c.SubscribeJob(jobID, JobCallbacks{
+		Progress: func(id string, progress float64, status, detail string) {
+			events.mu.Lock()
+			events.progress[id]++
+			events.mu.Unlock()
+		},
+		Error: func(id string, err error) {
+			events.mu.Lock()
+			events.errors[id] = err
+			events.mu.Unlock()
+			events.done <- id
+		},
+		Completion: func(id string, outputPath string) {
+			events.done <- id
+		},
+	})
+	return events
+}
+
+func waitStarted(t *testing.T, service *gatedPDFService) string {
+	t.Helper()
+	select {
+	case output := <-service.started:
+		return output
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务没有开始合并")
+		return ""
+	}
+}
+
+func waitDone(t *testing.T, events *jobEvents) string {
+	t.Helper()
+	select {
+	case id := <-events.done:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务没有结束")
+		return ""
+	}
+}
+
+func TestController_EnqueueMergeJobRunsSequentially(t *testing.T) {
+	service := newGatedPDFService()
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+	events := subscribeEvents(controller, "")
+
+	first, err := controller.EnqueueMergeJob("a.pdf", []string{"b.pdf"}, "first.pdf")
+	if err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	if got := waitStarted(t, service); got != "first.pdf" {
+		t.Fatalf("期望先合并 first.pdf，实际 %s", got)
+	}
+
+	// 已有任务运行时仍然接受新任务
+	second, err := controller.EnqueueMergeJob("c.pdf", []string{"d.pdf"}, "second.pdf")
+	if err != nil {
+		t.Fatalf("已有任务运行时入队失败: %v", err)
+	}
+
+	jobs := controller.ListJobs()
+	if len(jobs) != 2 || jobs[0].ID != first || jobs[1].ID != second {
+		t.Fatalf("ListJobs 应按入队顺序返回两个任务，实际 %v", jobs)
+	}
+	if jobs[1].Status != model.JobPending {
+		t.Errorf("第二个任务应等待第一个任务，实际状态 %s", jobs[1].Status)
+	}
+
+	service.release <- struct{}{}
+	if id := waitDone(t, events); id != first {
+		t.Errorf("期望 %s 先完成，实际 %s", first, id)
+	}
+	if got := waitStarted(t, service); got != "second.pdf" {
+		t.Fatalf("期望随后合并 second.pdf，实际 %s", got)
+	}
+	service.release <- struct{}{}
+	if id := waitDone(t, events); id != second {
+		t.Errorf("期望 %s 随后完成，实际 %s", second, id)
+	}
+
+	for _, job := range controller.ListJobs() {
+		if job.Status != model.JobCompleted {
+			t.Errorf("任务 %s 应已完成，实际状态 %s", job.ID, job.Status)
+		}
+	}
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if events.progress[first] == 0 || events.progress[second] == 0 {
+		t.Errorf("每个任务都应收到带任务ID的进度通知: %v", events.progress)
+	}
+}
+
+func TestController_JobsUseConfigClock(t *testing.T) {
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start, 1)
+	config := model.DefaultConfig()
+	config.Clock = fake
+	service := newGatedPDFService()
+	controller := NewController(service, &mockFileManager{}, config)
+	events := subscribeEvents(controller, "")
+
+	id, err := controller.EnqueueMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf")
+	if err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	waitStarted(t, service)
+	fake.Advance(2 * time.Minute)
+	service.release <- struct{}{}
+	waitDone(t, events)
+
+	// 任务的创建和完成时间都取自配置中的时钟，而不是真实时间
+	job := controller.ListJobs()[0]
+	if job.ID != id || !job.CreatedAt.Equal(start) {
+		t.Errorf("任务创建时间 = %v，应为假时钟的 %v", job.CreatedAt, start)
+	}
+	if job.CompletedAt == nil || job.CompletedAt.Before(start.Add(2*time.Minute)) || job.CompletedAt.After(start.Add(time.Hour)) {
+		t.Errorf("任务完成时间 = %v，应取自假时钟", job.CompletedAt)
+	}
+}
+
+func TestController_CancelJob(t *testing.T) {
+	service := newGatedPDFService()
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	running, _ := controller.EnqueueMergeJob("a.pdf", []string{"b.pdf"}, "running.pdf")
+	waitStarted(t, service)
+	pending, _ := controller.EnqueueMergeJob("c.pdf", []string{"d.pdf"}, "pending.pdf")
+
+	pendingEvents := subscribeEvents(controller, pending)
+	runningEvents := subscribeEvents(controller, running)
+
+	if err := controller.CancelJob(pending); err != nil {
+		t.Fatalf("取消等待中的任务失败: %v", err)
+	}
+	if id := waitDone(t, pendingEvents); id != pending {
+		t.Fatalf("只应收到被订阅任务的事件，实际 %s", id)
+	}
+	pendingEvents.mu.Lock()
+	if !errors.Is(pendingEvents.errors[pending], ErrJobCancelled) {
+		t.Errorf("取消等待中的任务应返回 ErrJobCancelled，实际 %v", pendingEvents.errors[pending])
+	}
+	pendingEvents.mu.Unlock()
+
+	service.release <- struct{}{}
+	if id := waitDone(t, runningEvents); id != running {
+		t.Fatalf("期望 %s 完成，实际 %s", running, id)
+	}
+
+	statuses := make(map[string]model.JobStatus)
+	for _, job := range controller.ListJobs() {
+		statuses[job.ID] = job.Status
+	}
+	if statuses[running] != model.JobCompleted || statuses[pending] != model.JobCancelled {
+		t.Errorf("状态不正确: %v", statuses)
+	}
+	select {
+	case output := <-service.started:
+		t.Errorf("被取消的任务不应开始合并: %s", output)
+	default:
+	}
+
+	if err := controller.CancelJob(pending); err == nil {
+		t.Error("取消已结束的任务应返回错误")
+	}
+}
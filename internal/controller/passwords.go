@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// MaxPasswordAttempts 界面为每个加密文件询问密码的最大次数
+const MaxPasswordAttempts = 3
+
+// SetPasswordVault 设置密码保险库，nil表示不再使用。设置后加密输入优先使用按内容哈希保存的密码，
+// 验证通过或解密成功的密码保存到保险库
+func (c *Controller) SetPasswordVault(vault pdf.PasswordVault) {
+	c.vaultMu.Lock()
+	defer c.vaultMu.Unlock()
+	c.vault = vault
+}
+
+// PasswordVault 返回当前使用的密码保险库，没有时返回nil
+func (c *Controller) PasswordVault() pdf.PasswordVault {
+	c.vaultMu.RLock()
+	defer c.vaultMu.RUnlock()
+	return c.vault
+}
+
+// VerifyPassword 把加密文件解密到临时文件以确认密码正确，临时文件随即删除。
+// 密码正确时保存到密码保险库（已设置时）。密码错误时返回解密服务的错误，错误信息中不包含密码。
+func (c *Controller) VerifyPassword(filePath, password string) error {
+	if err := c.tryPassword(filePath, password); err != nil {
+		return err
+	}
+	pdf.RememberPassword(c.PasswordVault(), filePath, password)
+	return nil
+}
+
+// SavedPassword 返回密码保险库中为该文件内容保存、并且仍能解密文件的密码。
+// 没有设置保险库、没有条目或密码已失效时返回false，失效的条目被删除
+func (c *Controller) SavedPassword(filePath string) (string, bool) {
+	vault := c.PasswordVault()
+	password, ok := pdf.LookupVaultPassword(vault, filePath)
+	if !ok {
+		return "", false
+	}
+	if err := c.tryPassword(filePath, password); err != nil {
+		pdf.ForgetPassword(vault, filePath)
+		return "", false
+	}
+	return password, true
+}
+
+// tryPassword 把加密文件解密到临时文件以确认密码正确，临时文件随即删除
+func (c *Controller) tryPassword(filePath, password string) error {
+	tempPath, err := c.FileManager.CreateTempFile()
+	if err != nil {
+		return fmt.Errorf("无法创建临时文件: %v", err)
+	}
+	defer c.FileManager.RemoveTempFile(tempPath)
+
+	return c.PDFService.DecryptPDF(filePath, tempPath, password)
+}
+
+// StartMergeJobWithPasswords 与 StartMergeJob 相同，passwords 按输入路径提供加密文件的打开密码，
+// 工作流程在合并前用这些密码把加密文件解密到临时副本
+func (c *Controller) StartMergeJobWithPasswords(mainFile string, additionalFiles []string, outputPath string, passwords map[string]string) error {
+	job := model.NewMergeJobWithClock(c.Clock, mainFile, additionalFiles, outputPath)
+	if len(passwords) > 0 {
+		job.Passwords = make(map[string]string, len(passwords))
+		for path, password := range passwords {
+			job.Passwords[path] = password
+		}
+	}
+	return c.startMergeJob(job)
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+func TestController_VerifyPassword(t *testing.T) {
+	service := &decryptingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	if err := controller.VerifyPassword("locked.pdf", "secret"); err != nil {
+		t.Errorf("正确的密码应通过验证: %v", err)
+	}
+	if err := controller.VerifyPassword("locked.pdf", "guess"); err == nil {
+		t.Error("错误的密码应返回错误")
+	}
+}
+
+func TestController_StartMergeJobWithPasswordsCopiesPasswords(t *testing.T) {
+	service := newGatedPDFService()
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	passwords := map[string]string{"locked.pdf": "secret"}
+	if err := controller.StartMergeJobWithPasswords("main.pdf", []string{"locked.pdf"}, "out.pdf", passwords); err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	passwords["locked.pdf"] = "changed"
+
+	job := controller.GetCurrentJob()
+	if job == nil || job.Passwords["locked.pdf"] != "secret" {
+		t.Error("任务应保存调用时的密码副本")
+	}
+	if err := controller.StartMergeJobWithPasswords("a.pdf", nil, "b.pdf", nil); err == nil {
+		t.Error("已有任务运行时应返回错误")
+	}
+	close(service.release)
+}
+
+// openTestVault 在临时目录中创建密码保险库，并写出内容固定的 locked.pdf，返回保险库和文件路径
+func openTestVault(t *testing.T) (*pdf.FileVault, string) {
+	t.Helper()
+	dir := t.TempDir()
+	vault, err := pdf.OpenFileVault(filepath.Join(dir, "vault.json"), "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := filepath.Join(dir, "locked.pdf")
+	if err := os.WriteFile(locked, []byte("%PDF-1.4 encrypted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return vault, locked
+}
+
+func TestController_VerifyPasswordRemembersPassword(t *testing.T) {
+	vault, locked := openTestVault(t)
+	controller := NewController(&decryptingPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	controller.SetPasswordVault(vault)
+
+	if _, ok := controller.SavedPassword(locked); ok {
+		t.Fatal("保险库为空时不应返回密码")
+	}
+	if err := controller.VerifyPassword(locked, "guess"); err == nil {
+		t.Fatal("错误的密码应返回错误")
+	}
+	if entries, _ := vault.List(); len(entries) != 0 {
+		t.Fatalf("错误的密码不应保存，实际 %d 个条目", len(entries))
+	}
+	if err := controller.VerifyPassword(locked, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if password, ok := controller.SavedPassword(locked); !ok || password != "secret" {
+		t.Errorf("应返回保存的密码，实际 %q, %v", password, ok)
+	}
+}
+
+func TestController_SavedPasswordForgetsStalePassword(t *testing.T) {
+	vault, locked := openTestVault(t)
+	controller := NewController(&decryptingPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	controller.SetPasswordVault(vault)
+	if err := pdf.RememberPassword(vault, locked, "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := controller.SavedPassword(locked); ok {
+		t.Error("无法解密文件的保存密码不应返回")
+	}
+	if entries, _ := vault.List(); len(entries) != 0 {
+		t.Errorf("失效的条目应被删除，实际 %d 个条目", len(entries))
+	}
+}
+
+func TestWorkflowManager_UsesVaultPasswords(t *testing.T) {
+	vault, locked := openTestVault(t)
+	service := &decryptingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+	controller.SetPasswordVault(vault)
+	if err := pdf.RememberPassword(vault, locked, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	job := model.NewMergeJob("main.pdf", []string{locked}, "output.pdf")
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	if fmt.Sprint(service.decrypted) != fmt.Sprint([]string{locked}) {
+		t.Errorf("应使用保险库中的密码解密，实际 %v", service.decrypted)
+	}
+	if len(job.Passwords) != 0 {
+		t.Errorf("保险库中的密码不应写入任务，实际 %v", job.Passwords)
+	}
+}
+
+func TestWorkflowManager_RemembersAndForgetsVaultPasswords(t *testing.T) {
+	vault, locked := openTestVault(t)
+	service := &decryptingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+	controller.SetPasswordVault(vault)
+
+	// 任务中提供的正确密码在解密成功后保存
+	job := model.NewMergeJob("main.pdf", []string{locked}, "output.pdf")
+	job.Passwords = map[string]string{locked: "secret"}
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	if password, ok := pdf.LookupVaultPassword(vault, locked); !ok || password != "secret" {
+		t.Fatalf("解密成功的密码应保存，实际 %q, %v", password, ok)
+	}
+
+	// 失效的保存密码被删除，文件交给合并服务处理
+	if err := pdf.RememberPassword(vault, locked, "old"); err != nil {
+		t.Fatal(err)
+	}
+	service.decrypted = nil
+	job = model.NewMergeJob("main.pdf", []string{locked}, "output.pdf")
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	if len(service.decrypted) != 0 {
+		t.Errorf("失效的密码不应解密文件，实际 %v", service.decrypted)
+	}
+	if _, ok := pdf.LookupVaultPassword(vault, locked); ok {
+		t.Error("失效的保存密码应被删除")
+	}
+}
@@ -0,0 +1,479 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// JobSource 任务来源
+type JobSource int
+
+const (
+	// SourceInteractive 用户在界面中发起的任务
+	SourceInteractive JobSource = iota
+	// SourceScheduled 定时任务
+	SourceScheduled
+	// SourceWatch 监视文件夹触发的任务
+	SourceWatch
+)
+
+// String 返回JobSource的字符串表示
+func (s JobSource) String() string {
+	switch s {
+	case SourceInteractive:
+		return "interactive"
+	case SourceScheduled:
+		return "scheduled"
+	case SourceWatch:
+		return "watch"
+	default:
+		return "unknown"
+	}
+}
+
+// 任务历史中的队列事件
+const (
+	HistoryEnqueued  = "enqueued"
+	HistoryStarted   = "started"
+	HistoryPreempted = "preempted"
+	HistoryPreempter = "preempting"
+	HistoryResumed   = "resumed"
+	HistoryFinished  = "finished"
+	HistoryCancelled = "cancelled"
+)
+
+var (
+	// ErrQueueClosed 队列已关闭
+	ErrQueueClosed = errors.New("任务队列已关闭")
+	// ErrJobCancelled 任务在启动前被取消
+	ErrJobCancelled = errors.New("任务已取消")
+	// ErrJobNotQueued 任务不在队列中，或已经结束
+	ErrJobNotQueued = errors.New("任务不在队列中")
+)
+
+// PriorityPolicy 任务优先级与抢占策略
+type PriorityPolicy struct {
+	Priorities      map[JobSource]int // 各来源的优先级，数字越大优先级越高
+	AllowPreemption bool              // 是否允许高优先级任务抢占运行中的任务
+	MinPreemptGap   int               // 等待任务的优先级至少高出多少才触发抢占
+}
+
+// DefaultPriorityPolicy 返回默认策略：交互 > 定时 > 监视文件夹，允许抢占
+func DefaultPriorityPolicy() *PriorityPolicy {
+	return &PriorityPolicy{
+		Priorities: map[JobSource]int{
+			SourceInteractive: 20,
+			SourceScheduled:   10,
+			SourceWatch:       0,
+		},
+		AllowPreemption: true,
+		MinPreemptGap:   1,
+	}
+}
+
+// PriorityOf 返回来源对应的优先级
+func (p *PriorityPolicy) PriorityOf(source JobSource) int {
+	return p.Priorities[source]
+}
+
+// shouldPreempt 判断等待中的任务是否可以抢占运行中的任务
+func (p *PriorityPolicy) shouldPreempt(running, waiting int) bool {
+	gap := p.MinPreemptGap
+	if gap < 1 {
+		gap = 1
+	}
+	return p.AllowPreemption && waiting-running >= gap
+}
+
+// JobFunc 队列中任务的执行函数，应在分块边界调用 Checkpoint
+type JobFunc func(ctx context.Context, job *QueuedJob) error
+
+// QueuedJob 队列中的任务
+type QueuedJob struct {
+	Job      *model.MergeJob
+	Source   JobSource
+	Priority int
+
+	queue  *JobQueue
+	run    JobFunc
+	cancel context.CancelFunc // 取消运行中的任务，启动时设置
+	seq    uint64
+	resume chan struct{}
+	done   chan struct{}
+	err    error
+	pauses int
+
+	deadline time.Time     // 有效截止时刻，零值表示不限制
+	estimate time.Duration // 启动前的耗时估算，无法估算时为0
+	overran  bool          // 运行中到达截止时刻
+}
+
+// Estimate 返回启动前估算的耗时，无法估算或没有截止时刻时为0
+func (qj *QueuedJob) Estimate() time.Duration {
+	qj.queue.mu.Lock()
+	defer qj.queue.mu.Unlock()
+	return qj.estimate
+}
+
+// Wait 等待任务结束并返回执行结果
+func (qj *QueuedJob) Wait() error {
+	<-qj.done
+	return qj.err
+}
+
+// Done 返回任务结束时关闭的通道
+func (qj *QueuedJob) Done() <-chan struct{} {
+	return qj.done
+}
+
+// Preemptions 返回任务被抢占的次数
+func (qj *QueuedJob) Preemptions() int {
+	qj.queue.mu.Lock()
+	defer qj.queue.mu.Unlock()
+	return qj.pauses
+}
+
+// JobQueue 按优先级调度合并任务的队列，同优先级按先进先出
+type JobQueue struct {
+	mu       sync.Mutex
+	policy   *PriorityPolicy
+	ctx      context.Context
+	slots    int // 空闲的工作槽位
+	seq      uint64
+	pending  []*QueuedJob
+	paused   []*QueuedJob // 被抢占、等待恢复的任务
+	running  []*QueuedJob // 已启动、尚未结束的任务（含被抢占的任务）
+	budget   *BudgetPolicy
+	deferred []*QueuedJob // 因时间预算被推迟的任务
+	started  bool
+	closed   bool
+	active   sync.WaitGroup
+}
+
+// NewJobQueue 创建任务队列，workers 为同时运行的任务数
+func NewJobQueue(workers int, policy *PriorityPolicy) *JobQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if policy == nil {
+		policy = DefaultPriorityPolicy()
+	}
+	return &JobQueue{
+		policy: policy,
+		slots:  workers,
+		budget: DefaultBudgetPolicy(),
+	}
+}
+
+// Start 开始调度任务，ctx 取消时正在运行和等待恢复的任务随之取消
+func (q *JobQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.ctx = ctx
+	q.started = true
+	q.dispatch()
+}
+
+// Enqueue 将任务加入队列，优先级由策略按来源决定
+func (q *JobQueue) Enqueue(job *model.MergeJob, source JobSource, run JobFunc) (*QueuedJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+
+	q.seq++
+	qj := &QueuedJob{
+		Job:      job,
+		Source:   source,
+		Priority: q.policy.PriorityOf(source),
+		queue:    q,
+		run:      run,
+		seq:      q.seq,
+		resume:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	job.AddHistory(HistoryEnqueued, fmt.Sprintf("来源 %s，优先级 %d", source, qj.Priority))
+	q.pending = append(q.pending, qj)
+	q.active.Add(1)
+	q.dispatch()
+	return qj, nil
+}
+
+// Pending 返回等待调度的任务数（不含被抢占的任务）
+func (q *JobQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Contains 判断指定ID的任务是否在等待、运行或被抢占
+func (q *JobQueue) Contains(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, jobs := range [][]*QueuedJob{q.pending, q.running, q.paused} {
+		for _, qj := range jobs {
+			if qj.Job.ID == jobID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Cancel 取消指定任务。等待中的任务直接移出队列，以 ErrJobCancelled 结束；
+// 运行中或被抢占的任务取消其context，由执行函数返回后结束。返回任务是否已经启动。
+func (q *JobQueue) Cancel(jobID string) (bool, error) {
+	q.mu.Lock()
+	for _, qj := range q.pending {
+		if qj.Job.ID == jobID {
+			q.pending = removeJob(q.pending, qj)
+			qj.Job.AddHistory(HistoryCancelled, "启动前取消")
+			qj.Job.Status = model.JobCancelled
+			qj.err = ErrJobCancelled
+			close(qj.done)
+			q.active.Done()
+			q.mu.Unlock()
+			return false, nil
+		}
+	}
+	for _, qj := range q.running {
+		if qj.Job.ID == jobID {
+			qj.Job.AddHistory(HistoryCancelled, "")
+			cancel := qj.cancel
+			q.mu.Unlock()
+			cancel()
+			return true, nil
+		}
+	}
+	q.mu.Unlock()
+	return false, ErrJobNotQueued
+}
+
+// Close 停止接受新任务并等待已入队的任务结束，需在 Start 之后调用
+func (q *JobQueue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.active.Wait()
+}
+
+// dispatch 在有空闲槽位时按优先级启动或恢复任务，调用方需持有锁
+func (q *JobQueue) dispatch() {
+	for q.started && q.slots > 0 {
+		next, fromPaused := q.best()
+		if next == nil {
+			return
+		}
+		if !fromPaused && !q.admit(next) {
+			continue
+		}
+		q.slots--
+		if fromPaused {
+			q.paused = removeJob(q.paused, next)
+			next.Job.AddHistory(HistoryResumed, "")
+			next.resume <- struct{}{}
+			continue
+		}
+		q.pending = removeJob(q.pending, next)
+		q.running = append(q.running, next)
+		next.Job.AddHistory(HistoryStarted, "")
+		ctx, cancel := context.WithCancel(q.ctx)
+		next.cancel = cancel
+		go q.execute(ctx, next)
+	}
+}
+
+// best 返回优先级最高的候选任务；同优先级时入队早的优先，因此被抢占的任务先于后来者恢复
+func (q *JobQueue) best() (*QueuedJob, bool) {
+	var best *QueuedJob
+	fromPaused := false
+	consider := func(candidates []*QueuedJob, paused bool) {
+		for _, qj := range candidates {
+			if best == nil || qj.Priority > best.Priority ||
+				(qj.Priority == best.Priority && qj.seq < best.seq) {
+				best, fromPaused = qj, paused
+			}
+		}
+	}
+	consider(q.pending, false)
+	consider(q.paused, true)
+	return best, fromPaused
+}
+
+// highestPending 返回等待中优先级最高的任务
+func (q *JobQueue) highestPending() (*QueuedJob, bool) {
+	var best *QueuedJob
+	for _, qj := range q.pending {
+		if best == nil || qj.Priority > best.Priority ||
+			(qj.Priority == best.Priority && qj.seq < best.seq) {
+			best = qj
+		}
+	}
+	return best, best != nil
+}
+
+// execute 运行任务并在结束后释放槽位
+func (q *JobQueue) execute(ctx context.Context, qj *QueuedJob) {
+	cancel := qj.cancel
+	defer cancel()
+	ctx = context.WithValue(ctx, queuedJobKey{}, qj)
+
+	q.mu.Lock()
+	budget, deadline := q.budget, qj.deadline
+	q.mu.Unlock()
+
+	var snapshot *outputSnapshot
+	stopWatch := func() {}
+	if !deadline.IsZero() {
+		if budget.Overrun == OverrunCancel {
+			var err error
+			if snapshot, err = snapshotOutput(qj.Job.OutputPath); err != nil {
+				q.mu.Lock()
+				budget.record(qj, HistoryBudgetExceeded, fmt.Sprintf("警告: 无法备份输出，超时后不能回滚: %v", err))
+				q.mu.Unlock()
+			}
+		}
+		stopWatch = q.watchDeadline(qj, budget, deadline, cancel)
+	}
+
+	err := qj.run(ctx, qj)
+	stopWatch()
+
+	q.mu.Lock()
+	if qj.overran && budget.Overrun == OverrunCancel {
+		if snapshot != nil {
+			if restoreErr := snapshot.restore(); restoreErr != nil {
+				budget.record(qj, HistoryRolledBack, fmt.Sprintf("回滚失败: %v", restoreErr))
+			} else {
+				budget.record(qj, HistoryRolledBack, "输出已恢复到任务开始前的状态")
+			}
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		err = fmt.Errorf("%w: %v", ErrBudgetExceeded, err)
+	}
+	if snapshot != nil {
+		snapshot.discard()
+	}
+	qj.err = err
+	if err != nil {
+		qj.Job.AddHistory(HistoryFinished, err.Error())
+	} else {
+		qj.Job.AddHistory(HistoryFinished, "")
+	}
+	q.running = removeJob(q.running, qj)
+	q.slots++
+	q.dispatch()
+	q.mu.Unlock()
+
+	close(qj.done)
+	q.active.Done()
+}
+
+// watchDeadline 在截止时刻按策略处理仍在运行的任务。
+// 返回的函数停止监视，并等待监视协程退出，之后 overran 不会再改变。
+func (q *JobQueue) watchDeadline(qj *QueuedJob, budget *BudgetPolicy, deadline time.Time, cancel context.CancelFunc) func() {
+	clk := clock.OrSystem(budget.Clock)
+	timer := clk.NewTimer(deadline.Sub(clk.Now()))
+	stopped := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-timer.C():
+		case <-stopped:
+			timer.Stop()
+			return
+		}
+
+		q.mu.Lock()
+		qj.overran = true
+		if budget.Overrun == OverrunCancel {
+			budget.record(qj, HistoryBudgetExceeded, "到达截止时刻，取消任务并回滚输出")
+		} else {
+			budget.record(qj, HistoryBudgetExceeded, "警告: 到达截止时刻，按策略允许任务继续运行")
+		}
+		q.mu.Unlock()
+
+		if budget.Overrun == OverrunCancel {
+			cancel()
+		}
+	}()
+	return func() {
+		close(stopped)
+		<-exited
+	}
+}
+
+// Checkpoint 抢占点：有更高优先级的任务在等待且策略允许时暂停当前任务，
+// 直到高优先级任务完成后恢复。返回非nil表示任务已被取消。
+func (qj *QueuedJob) Checkpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	q := qj.queue
+	q.mu.Lock()
+	waiting, ok := q.highestPending()
+	if !ok || !q.policy.shouldPreempt(qj.Priority, waiting.Priority) {
+		q.mu.Unlock()
+		return nil
+	}
+
+	qj.pauses++
+	qj.Job.AddHistory(HistoryPreempted, fmt.Sprintf("被任务 %s 抢占", waiting.Job.ID))
+	waiting.Job.AddHistory(HistoryPreempter, fmt.Sprintf("抢占任务 %s", qj.Job.ID))
+	q.paused = append(q.paused, qj)
+	q.slots++
+	q.dispatch()
+	q.mu.Unlock()
+
+	select {
+	case <-qj.resume:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-qj.resume:
+			// 恢复与取消同时发生，已占用的槽位由 execute 释放
+		default:
+			// 收回让出的槽位，任务返回后由 execute 统一释放
+			q.paused = removeJob(q.paused, qj)
+			q.slots--
+		}
+		return ctx.Err()
+	}
+}
+
+// queuedJobKey 在context中保存当前队列任务
+type queuedJobKey struct{}
+
+// Checkpoint 在分块边界检查抢占信号。ctx 不属于队列任务时只检查取消。
+func Checkpoint(ctx context.Context) error {
+	if qj, ok := ctx.Value(queuedJobKey{}).(*QueuedJob); ok {
+		return qj.Checkpoint(ctx)
+	}
+	return ctx.Err()
+}
+
+// removeJob 从切片中移除任务
+func removeJob(jobs []*QueuedJob, target *QueuedJob) []*QueuedJob {
+	for i, qj := range jobs {
+		if qj == target {
+			return append(jobs[:i], jobs[i+1:]...)
+		}
+	}
+	return jobs
+}
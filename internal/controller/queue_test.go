@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// historyEvents 返回任务历史中的事件名
+func historyEvents(job *model.MergeJob) []string {
+	events := make([]string, 0, len(job.History))
+	for _, entry := range job.History {
+		events = append(events, entry.Event)
+	}
+	return events
+}
+
+func containsEvent(job *model.MergeJob, event string) bool {
+	for _, e := range historyEvents(job) {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func TestJobQueue_DispatchByPriorityThenFIFO(t *testing.T) {
+	queue := NewJobQueue(1, nil)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) JobFunc {
+		return func(ctx context.Context, job *QueuedJob) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	jobs := []struct {
+		name   string
+		source JobSource
+	}{
+		{"watch-1", SourceWatch},
+		{"nightly-1", SourceScheduled},
+		{"click", SourceInteractive},
+		{"nightly-2", SourceScheduled},
+		{"watch-2", SourceWatch},
+	}
+	for _, j := range jobs {
+		if _, err := queue.Enqueue(model.NewMergeJob(j.name, nil, ""), j.source, record(j.name)); err != nil {
+			t.Fatalf("入队失败: %v", err)
+		}
+	}
+
+	queue.Start(context.Background())
+	queue.Close()
+
+	expected := []string{"click", "nightly-1", "nightly-2", "watch-1", "watch-2"}
+	if len(order) != len(expected) {
+		t.Fatalf("期望执行 %d 个任务，实际 %v", len(expected), order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("调度顺序不正确，期望 %v，实际 %v", expected, order)
+		}
+	}
+
+	if _, err := queue.Enqueue(model.NewMergeJob("late", nil, ""), SourceWatch, record("late")); err != ErrQueueClosed {
+		t.Errorf("关闭后入队应返回ErrQueueClosed，实际: %v", err)
+	}
+}
+
+// preemptionFixture 两个输入文件与一个按4字节分块的流式合并器
+type preemptionFixture struct {
+	dir    string
+	first  string
+	second string
+	merger *StreamingMerger
+}
+
+func newPreemptionFixture(t *testing.T) *preemptionFixture {
+	t.Helper()
+	dir := t.TempDir()
+	f := &preemptionFixture{
+		dir:    dir,
+		first:  filepath.Join(dir, "first.bin"),
+		second: filepath.Join(dir, "second.bin"),
+		merger: &StreamingMerger{chunkSize: 4, maxMemory: 1 << 62},
+	}
+	os.WriteFile(f.first, []byte("first-input-0123456789"), 0644)
+	os.WriteFile(f.second, []byte("second-input-abcdefghij"), 0644)
+	return f
+}
+
+// lowPriorityJob 依次流式写入两个文件；两个文件之间调用 between
+func (f *preemptionFixture) lowPriorityJob(output string, between func()) JobFunc {
+	return func(ctx context.Context, job *QueuedJob) error {
+		out, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if err := f.merger.streamFile(ctx, f.first, out); err != nil {
+			return err
+		}
+		if between != nil {
+			between()
+		}
+		return f.merger.streamFile(ctx, f.second, out)
+	}
+}
+
+func TestJobQueue_PreemptsAtChunkBoundaryAndResumes(t *testing.T) {
+	f := newPreemptionFixture(t)
+
+	// 未被抢占时的参考输出
+	reference := filepath.Join(f.dir, "reference.bin")
+	refQueue := NewJobQueue(1, nil)
+	refQueue.Start(context.Background())
+	refJob, _ := refQueue.Enqueue(model.NewMergeJob(f.first, nil, reference), SourceScheduled, f.lowPriorityJob(reference, nil))
+	if err := refJob.Wait(); err != nil {
+		t.Fatalf("参考任务失败: %v", err)
+	}
+	refQueue.Close()
+
+	queue := NewJobQueue(1, nil)
+	queue.Start(context.Background())
+
+	output := filepath.Join(f.dir, "output.bin")
+	firstSize := int64(len("first-input-0123456789"))
+	var sizeSeenByInteractive int64 = -1
+	var interactive *QueuedJob
+
+	low, err := queue.Enqueue(model.NewMergeJob(f.first, []string{f.second}, output), SourceScheduled,
+		f.lowPriorityJob(output, func() {
+			// 第一个文件写完后，用户点击了合并
+			interactive, _ = queue.Enqueue(model.NewMergeJob("click.pdf", nil, ""), SourceInteractive,
+				func(ctx context.Context, job *QueuedJob) error {
+					if info, err := os.Stat(output); err == nil {
+						sizeSeenByInteractive = info.Size()
+					}
+					return nil
+				})
+		}))
+	if err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+
+	if err := low.Wait(); err != nil {
+		t.Fatalf("低优先级任务失败: %v", err)
+	}
+	interactive.Wait()
+	queue.Close()
+
+	if sizeSeenByInteractive != firstSize {
+		t.Errorf("抢占应发生在分块边界，交互任务运行时输出大小为 %d，期望 %d", sizeSeenByInteractive, firstSize)
+	}
+	if low.Preemptions() != 1 {
+		t.Errorf("期望被抢占1次，实际 %d", low.Preemptions())
+	}
+
+	// 两个任务的历史都记录了抢占
+	lowEvents := historyEvents(low.Job)
+	expectedLow := []string{HistoryEnqueued, HistoryStarted, HistoryPreempted, HistoryResumed, HistoryFinished}
+	if len(lowEvents) != len(expectedLow) {
+		t.Fatalf("低优先级任务历史不正确: %v", lowEvents)
+	}
+	for i := range expectedLow {
+		if lowEvents[i] != expectedLow[i] {
+			t.Fatalf("低优先级任务历史不正确，期望 %v，实际 %v", expectedLow, lowEvents)
+		}
+	}
+	if !containsEvent(interactive.Job, HistoryPreempter) {
+		t.Errorf("交互任务历史应记录抢占: %v", historyEvents(interactive.Job))
+	}
+
+	// 恢复后的输出与未被抢占时完全一致
+	got, _ := os.ReadFile(output)
+	want, _ := os.ReadFile(reference)
+	if !bytes.Equal(got, want) {
+		t.Errorf("恢复后的输出与参考输出不一致:\n%q\n%q", got, want)
+	}
+}
+
+func TestJobQueue_PolicyCanDisablePreemption(t *testing.T) {
+	f := newPreemptionFixture(t)
+	policy := DefaultPriorityPolicy()
+	policy.AllowPreemption = false
+
+	queue := NewJobQueue(1, policy)
+	queue.Start(context.Background())
+
+	output := filepath.Join(f.dir, "output.bin")
+	var sizeSeenByInteractive int64 = -1
+	var interactive *QueuedJob
+	low, _ := queue.Enqueue(model.NewMergeJob(f.first, nil, output), SourceWatch,
+		f.lowPriorityJob(output, func() {
+			interactive, _ = queue.Enqueue(model.NewMergeJob("click.pdf", nil, ""), SourceInteractive,
+				func(ctx context.Context, job *QueuedJob) error {
+					if info, err := os.Stat(output); err == nil {
+						sizeSeenByInteractive = info.Size()
+					}
+					return nil
+				})
+		}))
+
+	if err := low.Wait(); err != nil {
+		t.Fatalf("任务失败: %v", err)
+	}
+	interactive.Wait()
+	queue.Close()
+
+	fullSize := int64(len("first-input-0123456789") + len("second-input-abcdefghij"))
+	if sizeSeenByInteractive != fullSize {
+		t.Errorf("禁止抢占时交互任务应等低优先级任务写完，看到的输出大小 %d，期望 %d", sizeSeenByInteractive, fullSize)
+	}
+	if low.Preemptions() != 0 || containsEvent(low.Job, HistoryPreempted) {
+		t.Errorf("禁止抢占时不应记录抢占: %v", historyEvents(low.Job))
+	}
+}
+
+func TestJobQueue_CancelWhilePaused(t *testing.T) {
+	f := newPreemptionFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	queue := NewJobQueue(1, nil)
+	queue.Start(ctx)
+
+	output := filepath.Join(f.dir, "output.bin")
+	blocking := make(chan struct{})
+	low, _ := queue.Enqueue(model.NewMergeJob(f.first, nil, output), SourceScheduled,
+		f.lowPriorityJob(output, func() {
+			queue.Enqueue(model.NewMergeJob("click.pdf", nil, ""), SourceInteractive,
+				func(ctx context.Context, job *QueuedJob) error {
+					close(blocking)
+					<-ctx.Done()
+					return ctx.Err()
+				})
+		}))
+
+	<-blocking
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		queue.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后队列未能结束")
+	}
+	if err := low.Wait(); err != context.Canceled {
+		t.Errorf("被暂停的任务应返回context.Canceled，实际: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/file"
+)
+
+// ErrInputsMissing 中断的任务有输入文件已不存在，无法重新执行
+var ErrInputsMissing = errors.New("输入文件不存在")
+
+// processAlive 判断进程是否仍在运行，测试中可替换
+var processAlive = file.ProcessAlive
+
+// jobCheckpoint 运行中的任务保存在工作区中的断点（WorkspaceCheckpointFile）。
+// 任务开始时写入，每个进度阶段更新，任务结束后随工作区删除；程序崩溃后留下的断点用于发现中断的任务
+type jobCheckpoint struct {
+	Job       *model.MergeJob `json:"job"`
+	Stage     string          `json:"stage"`
+	Detail    string          `json:"detail,omitempty"`
+	PID       int             `json:"pid"`                // 执行任务的进程
+	TempDir   string          `json:"temp_dir,omitempty"` // 执行任务的进程的临时文件目录
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// InterruptedJob 上次运行时没有结束的任务，执行它的进程已经退出（例如程序崩溃）
+type InterruptedJob struct {
+	Job           *model.MergeJob // 断点中保存的任务，状态为中断时的状态
+	Stage         string          // 中断前的最后一个进度阶段
+	Detail        string
+	UpdatedAt     time.Time
+	Workspace     string   // 任务的工作区目录
+	TempDir       string   // 中断的进程留下的临时文件目录，可能已不存在
+	MissingInputs []string // 已不存在的输入文件，非空时无法重新执行
+}
+
+// Resumable 判断任务能否用相同的输入重新执行
+func (j InterruptedJob) Resumable() bool {
+	return len(j.MissingInputs) == 0
+}
+
+// saveCheckpoint 把任务的当前状态写入工作区的断点文件。阶段与上次写入时相同时不再写入。
+// 断点只用于崩溃后的恢复，写入失败不影响任务
+func (c *Controller) saveCheckpoint(job *model.MergeJob, stage, detail string) {
+	c.checkpointMu.Lock()
+	defer c.checkpointMu.Unlock()
+	if saved, ok := c.checkpointStages[job.ID]; ok && saved == stage {
+		return
+	}
+
+	checkpoint := jobCheckpoint{
+		Stage:     stage,
+		Detail:    detail,
+		PID:       os.Getpid(),
+		TempDir:   c.FileManager.GetTempDir(),
+		UpdatedAt: clock.OrSystem(c.Clock).Now(),
+	}
+	c.jobMutex.RLock()
+	if job.Status != model.JobRunning {
+		c.jobMutex.RUnlock()
+		return
+	}
+	checkpoint.Job = job
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	c.jobMutex.RUnlock()
+	if err != nil {
+		return
+	}
+
+	dir := c.WorkspaceDir(job.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	path := filepath.Join(dir, WorkspaceCheckpointFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	if c.checkpointStages == nil {
+		c.checkpointStages = make(map[string]string)
+	}
+	c.checkpointStages[job.ID] = stage
+}
+
+// removeCheckpoint 任务结束后删除其工作区和断点
+func (c *Controller) removeCheckpoint(job *model.MergeJob) {
+	c.checkpointMu.Lock()
+	defer c.checkpointMu.Unlock()
+	if _, ok := c.checkpointStages[job.ID]; !ok {
+		return
+	}
+	delete(c.checkpointStages, job.ID)
+	os.RemoveAll(c.WorkspaceDir(job.ID))
+}
+
+// InterruptedJobs 返回工作区中留有断点、执行它的进程已经退出的任务，按中断时间从旧到新排列。
+// 本进程中正在运行或排队的任务和其他仍在运行的进程中的任务不会返回
+func (c *Controller) InterruptedJobs() ([]InterruptedJob, error) {
+	workspaces, err := c.ListWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []InterruptedJob
+	for _, ws := range workspaces {
+		if !ws.Resumable || c.jobActive(ws.JobID) {
+			continue
+		}
+		checkpoint, err := readCheckpoint(ws.Path)
+		if err != nil || processAlive(checkpoint.PID) {
+			continue
+		}
+		jobs = append(jobs, interruptedJob(ws.Path, checkpoint))
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].UpdatedAt.Before(jobs[j].UpdatedAt)
+	})
+	return jobs, nil
+}
+
+// DiscardInterruptedJob 删除中断任务的工作区和它留下的临时文件
+func (c *Controller) DiscardInterruptedJob(jobID string) error {
+	job, err := c.interruptedJob(jobID)
+	if err != nil {
+		return err
+	}
+	if sessionTempDir(job.TempDir) && job.TempDir != c.FileManager.GetTempDir() {
+		if err := os.RemoveAll(job.TempDir); err != nil {
+			return fmt.Errorf("无法删除临时文件 %s: %w", job.TempDir, err)
+		}
+	}
+	return c.DiscardWorkspace(jobID)
+}
+
+// ResumeInterruptedJob 用中断任务的输入、输出和选项启动新任务（与 StartMergeJob 相同），
+// 并删除中断任务的工作区和临时文件，返回新任务的ID。加密输入的密码不保存在断点中，需要重新提供。
+// 有输入文件已不存在时返回 ErrInputsMissing
+func (c *Controller) ResumeInterruptedJob(jobID string) (string, error) {
+	interrupted, err := c.interruptedJob(jobID)
+	if err != nil {
+		return "", err
+	}
+	if !interrupted.Resumable() {
+		return "", fmt.Errorf("%w: %v", ErrInputsMissing, interrupted.MissingInputs)
+	}
+
+	old := interrupted.Job
+	job := model.NewMergeJobWithClock(c.Clock, old.MainFile, old.AdditionalFiles, old.OutputPath)
+	job.GenerateTOC = old.GenerateTOC
+	job.Rotations = old.Rotations
+	job.NormalizeOrientation = old.NormalizeOrientation
+	job.NotAfter = old.NotAfter
+	if err := c.startMergeJob(job); err != nil {
+		return "", err
+	}
+
+	if err := c.DiscardInterruptedJob(jobID); err != nil {
+		return job.ID, fmt.Errorf("任务已重新开始，但无法删除中断任务的工作区: %w", err)
+	}
+	return job.ID, nil
+}
+
+// interruptedJob 按ID查找中断的任务
+func (c *Controller) interruptedJob(jobID string) (InterruptedJob, error) {
+	if jobID == "" || jobID == "." || jobID == ".." || filepath.Base(jobID) != jobID {
+		return InterruptedJob{}, fmt.Errorf("无效的任务ID: %q", jobID)
+	}
+	if c.jobActive(jobID) {
+		return InterruptedJob{}, fmt.Errorf("%w: 任务 %s 正在运行或排队", ErrWorkspaceInUse, jobID)
+	}
+	path := c.WorkspaceDir(jobID)
+	checkpoint, err := readCheckpoint(path)
+	if err != nil {
+		return InterruptedJob{}, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, jobID)
+	}
+	if processAlive(checkpoint.PID) {
+		return InterruptedJob{}, fmt.Errorf("%w: 任务 %s 在进程 %d 中运行", ErrWorkspaceInUse, jobID, checkpoint.PID)
+	}
+	return interruptedJob(path, checkpoint), nil
+}
+
+// sessionTempDir 判断路径是否为文件管理器的会话临时目录，只删除这样的目录，避免断点内容被篡改时误删其他文件
+func sessionTempDir(path string) bool {
+	matched, _ := filepath.Match("session_*", filepath.Base(path))
+	return matched && filepath.Base(filepath.Dir(path)) == "pdf-merger-temp"
+}
+
+// readCheckpoint 读取工作区中的断点
+func readCheckpoint(workspace string) (*jobCheckpoint, error) {
+	data, err := os.ReadFile(filepath.Join(workspace, WorkspaceCheckpointFile))
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint jobCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("断点文件已损坏: %w", err)
+	}
+	if checkpoint.Job == nil {
+		return nil, errors.New("断点文件中没有任务")
+	}
+	return &checkpoint, nil
+}
+
+// interruptedJob 由断点生成中断任务的信息，检查输入文件是否仍然存在
+func interruptedJob(workspace string, checkpoint *jobCheckpoint) InterruptedJob {
+	job := InterruptedJob{
+		Job:       checkpoint.Job,
+		Stage:     checkpoint.Stage,
+		Detail:    checkpoint.Detail,
+		UpdatedAt: checkpoint.UpdatedAt,
+		Workspace: workspace,
+		TempDir:   checkpoint.TempDir,
+	}
+	for _, input := range append([]string{job.Job.MainFile}, job.Job.AdditionalFiles...) {
+		if !file.FileExists(input) {
+			job.MissingInputs = append(job.MissingInputs, input)
+		}
+	}
+	return job
+}
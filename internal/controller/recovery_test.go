@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// stubProcessAlive 在测试期间替换进程存活检查
+func stubProcessAlive(t *testing.T, alive func(pid int) bool) {
+	t.Helper()
+	original := processAlive
+	processAlive = alive
+	t.Cleanup(func() { processAlive = original })
+}
+
+// writeCheckpoint 在任务工作区中写入断点，模拟上次运行中断的任务
+func writeCheckpoint(t *testing.T, c *Controller, job *model.MergeJob, pid int, tempDir string, updatedAt time.Time) {
+	t.Helper()
+	data, err := json.Marshal(jobCheckpoint{
+		Job:       job,
+		Stage:     "合并文件",
+		PID:       pid,
+		TempDir:   tempDir,
+		UpdatedAt: updatedAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := c.WorkspaceDir(job.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, WorkspaceCheckpointFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touchFiles 创建空文件并返回它们的路径
+func touchFiles(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("%PDF-1.4"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestController_CheckpointWhileRunning(t *testing.T) {
+	service := newGatedPDFService()
+	config := model.DefaultConfig()
+	config.TempDirectory = t.TempDir()
+	c := NewController(service, &mockFileManager{}, config)
+
+	job := model.NewMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf")
+	job.GenerateTOC = true
+	job.Passwords = map[string]string{"a.pdf": "secret"}
+	events := subscribeEvents(c, job.ID)
+	if err := c.EnqueueJob(job); err != nil {
+		t.Fatalf("入队失败: %v", err)
+	}
+	waitStarted(t, service)
+
+	data, err := os.ReadFile(filepath.Join(c.WorkspaceDir(job.ID), WorkspaceCheckpointFile))
+	if err != nil {
+		t.Fatalf("运行中的任务应有断点: %v", err)
+	}
+	var checkpoint jobCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		t.Fatalf("断点不是有效的JSON: %v", err)
+	}
+	if checkpoint.Job.ID != job.ID || checkpoint.Job.Status != model.JobRunning || !checkpoint.Job.GenerateTOC {
+		t.Errorf("断点中的任务不正确: %+v", checkpoint.Job)
+	}
+	if checkpoint.PID != os.Getpid() || checkpoint.Stage == "" {
+		t.Errorf("断点应记录进程和进度阶段: %+v", checkpoint)
+	}
+	if checkpoint.Job.Passwords != nil || strings.Contains(string(data), "secret") {
+		t.Error("断点中不得保存密码")
+	}
+
+	// 同一进程中运行的任务不是中断的任务
+	interrupted, err := c.InterruptedJobs()
+	if err != nil || len(interrupted) != 0 {
+		t.Errorf("运行中的任务不应作为中断的任务返回: %v, %v", interrupted, err)
+	}
+
+	service.release <- struct{}{}
+	waitDone(t, events)
+	if _, err := os.Stat(c.WorkspaceDir(job.ID)); !os.IsNotExist(err) {
+		t.Errorf("任务结束后应删除工作区: %v", err)
+	}
+}
+
+func TestController_WorkspaceRemovedBeforeTerminalEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		mergeErr error
+	}{
+		{"完成", nil},
+		{"失败", errors.New("合并失败")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := model.DefaultConfig()
+			config.TempDirectory = t.TempDir()
+			c := NewController(&mockPDFService{mergeError: tt.mergeErr}, &mockFileManager{}, config)
+			job := model.NewMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf")
+
+			// 在结束事件的回调中检查工作区，收到事件时工作区必须已经删除
+			workspaceLeft := make(chan bool, 1)
+			check := func() {
+				_, err := os.Stat(c.WorkspaceDir(job.ID))
+				workspaceLeft <- !os.IsNotExist(err)
+			}
+			c.SubscribeJob(job.ID, JobCallbacks{
+				Error:      func(string, error) { check() },
+				Completion: func(string, string) { check() },
+			})
+			if err := c.EnqueueJob(job); err != nil {
+				t.Fatalf("入队失败: %v", err)
+			}
+			select {
+			case left := <-workspaceLeft:
+				if left {
+					t.Error("发出结束事件时任务的工作区仍然存在")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("任务没有结束")
+			}
+		})
+	}
+}
+
+func TestController_InterruptedJobs(t *testing.T) {
+	c, fake := newWorkspaceController(t)
+	stubProcessAlive(t, func(pid int) bool { return pid == 1 })
+	inputs := touchFiles(t, t.TempDir(), "a.pdf", "b.pdf")
+
+	older := model.NewMergeJob(inputs[0], []string{inputs[1]}, "older.pdf")
+	writeCheckpoint(t, c, older, 4242, "", fake.Now().Add(-2*time.Hour))
+	missing := model.NewMergeJob(inputs[0], []string{"/nonexistent/b.pdf"}, "missing.pdf")
+	writeCheckpoint(t, c, missing, 4243, "", fake.Now().Add(-time.Hour))
+	live := model.NewMergeJob(inputs[0], []string{inputs[1]}, "live.pdf")
+	writeCheckpoint(t, c, live, 1, "", fake.Now())
+	writeWorkspace(t, c, "no-checkpoint", map[string]int{"chunk.pdf": 10})
+
+	jobs, err := c.InterruptedJobs()
+	if err != nil {
+		t.Fatalf("InterruptedJobs 失败: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Job.ID != older.ID || jobs[1].Job.ID != missing.ID {
+		t.Fatalf("应按中断时间返回两个中断的任务，实际 %+v", jobs)
+	}
+	if !jobs[0].Resumable() || jobs[0].Stage != "合并文件" {
+		t.Errorf("输入都存在的任务应可以重新执行: %+v", jobs[0])
+	}
+	if jobs[1].Resumable() || len(jobs[1].MissingInputs) != 1 || jobs[1].MissingInputs[0] != "/nonexistent/b.pdf" {
+		t.Errorf("应报告不存在的输入: %+v", jobs[1])
+	}
+
+	if _, err := c.ResumeInterruptedJob(missing.ID); !errors.Is(err, ErrInputsMissing) {
+		t.Errorf("输入不存在时应返回 ErrInputsMissing，实际 %v", err)
+	}
+	if _, err := c.ResumeInterruptedJob(live.ID); !errors.Is(err, ErrWorkspaceInUse) {
+		t.Errorf("其他进程中运行的任务应返回 ErrWorkspaceInUse，实际 %v", err)
+	}
+}
+
+func TestController_DiscardInterruptedJob(t *testing.T) {
+	c, fake := newWorkspaceController(t)
+	stubProcessAlive(t, func(int) bool { return false })
+
+	sessionDir := filepath.Join(t.TempDir(), "pdf-merger-temp", "session_1")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	touchFiles(t, sessionDir, "chunk_1.pdf")
+	job := model.NewMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf")
+	writeCheckpoint(t, c, job, 4242, sessionDir, fake.Now())
+
+	if err := c.DiscardInterruptedJob(job.ID); err != nil {
+		t.Fatalf("清理中断的任务失败: %v", err)
+	}
+	if _, err := os.Stat(c.WorkspaceDir(job.ID)); !os.IsNotExist(err) {
+		t.Errorf("应删除工作区: %v", err)
+	}
+	if _, err := os.Stat(sessionDir); !os.IsNotExist(err) {
+		t.Errorf("应删除中断的进程留下的临时文件: %v", err)
+	}
+
+	// 断点中的临时目录不是会话目录时只删除工作区
+	other := t.TempDir()
+	job = model.NewMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf")
+	writeCheckpoint(t, c, job, 4242, other, fake.Now())
+	if err := c.DiscardInterruptedJob(job.ID); err != nil {
+		t.Fatalf("清理中断的任务失败: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("不应删除会话目录以外的目录: %v", err)
+	}
+}
+
+func TestController_ResumeInterruptedJob(t *testing.T) {
+	service := newGatedPDFService()
+	config := model.DefaultConfig()
+	config.TempDirectory = t.TempDir()
+	c := NewController(service, &mockFileManager{}, config)
+	stubProcessAlive(t, func(pid int) bool { return pid == os.Getpid() })
+	inputs := touchFiles(t, t.TempDir(), "a.pdf", "b.pdf")
+
+	old := model.NewMergeJob(inputs[0], []string{inputs[1]}, "resumed.pdf")
+	old.Status = model.JobRunning
+	old.Rotations = map[string]int{inputs[1]: 90}
+	writeCheckpoint(t, c, old, 4242, "", time.Now())
+
+	jobID, err := c.ResumeInterruptedJob(old.ID)
+	if err != nil {
+		t.Fatalf("重新执行中断的任务失败: %v", err)
+	}
+	if jobID == old.ID {
+		t.Error("重新执行应创建新任务")
+	}
+	if got := waitStarted(t, service); got != "resumed.pdf" {
+		t.Errorf("应合并到原来的输出，实际 %s", got)
+	}
+	if _, err := os.Stat(c.WorkspaceDir(old.ID)); !os.IsNotExist(err) {
+		t.Errorf("应删除中断任务的工作区: %v", err)
+	}
+	jobs := c.ListJobs()
+	if len(jobs) != 1 || jobs[0].ID != jobID || jobs[0].Rotations[inputs[1]] != 90 {
+		t.Errorf("新任务应保留原任务的选项: %+v", jobs)
+	}
+
+	events := subscribeEvents(c, jobID)
+	service.release <- struct{}{}
+	waitDone(t, events)
+}
@@ -5,23 +5,34 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 // StreamingMerger 流式PDF合并器
 type StreamingMerger struct {
 	controller *Controller
+	job        *model.MergeJob      // 正在合并的任务，进度通知发给该任务的订阅者
+	reporter   pdf.ProgressReporter // 进度写入器实现了该接口时，进度按比例交给它换算到工作流程的区间
 	chunkSize  int64
 	maxMemory  int64
 	tempFiles  []string
 	tempMutex  sync.Mutex
 }
 
+// clock 返回控制器的时钟，未关联控制器时使用系统时钟
+func (sm *StreamingMerger) clock() clock.Clock {
+	if sm.controller == nil {
+		return clock.System()
+	}
+	return clock.OrSystem(sm.controller.Clock)
+}
+
 // NewStreamingMerger 创建新的流式合并器
 func NewStreamingMerger(controller *Controller) *StreamingMerger {
 	return &StreamingMerger{
@@ -37,6 +48,8 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, job *model.MergeJ
 	progressWriter io.Writer) error {
 
 	defer sm.cleanup()
+	sm.job = job
+	sm.reporter, _ = progressWriter.(pdf.ProgressReporter)
 
 	// 检查内存使用情况
 	if !sm.shouldUseStreaming() {
@@ -69,20 +82,15 @@ func (sm *StreamingMerger) executeStreamingMerge(ctx context.Context, job *model
 
 		progress := 0.1 + (0.3 * float64(i) / float64(totalFiles))
 		sm.notifyProgress(progress, "预处理文件",
-			fmt.Sprintf("正在处理: %s (%d/%d)", filepath.Base(filePath), i+1, totalFiles))
+			fmt.Sprintf("正在处理: %s (%d/%d)", sm.controller.DisplayName(filePath), i+1, totalFiles))
 
 		// 预处理单个文件
 		processedFile, err := sm.preprocessFile(ctx, filePath)
 		if err != nil {
-			return fmt.Errorf("预处理文件 %s 失败: %v", filepath.Base(filePath), err)
+			return fmt.Errorf("预处理文件 %s 失败: %v", sm.controller.DisplayName(filePath), err)
 		}
 
 		processedFiles = append(processedFiles, processedFile)
-
-		// 写入进度
-		if progressWriter != nil {
-			progressWriter.Write([]byte(fmt.Sprintf("processed:%s\n", filePath)))
-		}
 	}
 
 	// 第二阶段：流式合并
@@ -90,7 +98,7 @@ func (sm *StreamingMerger) executeStreamingMerge(ctx context.Context, job *model
 
 	err := sm.performStreamingMerge(ctx, processedFiles, job.OutputPath, progressWriter)
 	if err != nil {
-		return fmt.Errorf("流式合并失败: %v", err)
+		return fmt.Errorf("流式合并失败: %w", err)
 	}
 
 	// 第三阶段：后处理和优化
@@ -158,16 +166,11 @@ func (sm *StreamingMerger) performStreamingMerge(ctx context.Context, files []st
 
 		progress := 0.4 + (0.5 * float64(i) / float64(totalFiles))
 		sm.notifyProgress(progress, "合并文件",
-			fmt.Sprintf("正在合并: %s (%d/%d)", filepath.Base(filePath), i+1, totalFiles))
+			fmt.Sprintf("正在合并: %s (%d/%d)", sm.controller.DisplayName(filePath), i+1, totalFiles))
 
 		// 流式处理单个文件
 		if err := sm.streamFile(ctx, filePath, outputFile); err != nil {
-			return fmt.Errorf("处理文件 %s 失败: %v", filepath.Base(filePath), err)
-		}
-
-		// 写入进度
-		if progressWriter != nil {
-			progressWriter.Write([]byte(fmt.Sprintf("merged:%s\n", filePath)))
+			return fmt.Errorf("处理文件 %s 失败: %v", sm.controller.DisplayName(filePath), err)
 		}
 
 		// 定期检查内存使用情况
@@ -198,9 +201,9 @@ func (sm *StreamingMerger) streamFile(ctx context.Context, filePath string,
 	buffer := make([]byte, sm.chunkSize)
 
 	for {
-		// 检查取消
-		if ctx.Err() != nil {
-			return ctx.Err()
+		// 检查取消，并在分块边界让出给更高优先级的任务
+		if err := Checkpoint(ctx); err != nil {
+			return err
 		}
 
 		// 读取数据块
@@ -220,7 +223,10 @@ func (sm *StreamingMerger) streamFile(ctx context.Context, filePath string,
 		// 检查内存使用情况
 		if sm.isMemoryHigh() {
 			runtime.GC()
-			time.Sleep(10 * time.Millisecond) // 短暂暂停以释放内存
+			// 短暂暂停以释放内存
+			if err := sm.clock().Sleep(ctx, 10*time.Millisecond); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -292,9 +298,13 @@ func (sm *StreamingMerger) cleanup() {
 	sm.tempFiles = sm.tempFiles[:0] // 清空切片
 }
 
-// notifyProgress 通知进度更新
+// notifyProgress 通知进度更新。工作流程的进度写入器按比例接收，否则直接作为任务进度通知
 func (sm *StreamingMerger) notifyProgress(progress float64, status, detail string) {
-	sm.controller.notifyProgress(progress, status, detail)
+	if sm.reporter != nil {
+		sm.reporter.ReportProgress(progress, status+": "+detail)
+		return
+	}
+	sm.controller.notifyJobProgress(sm.job, progress, status, detail)
 }
 
 // writePDFHeader 写入PDF头部
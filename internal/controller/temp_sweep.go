@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// tempSweepInterval 定期清理临时文件的间隔
+const tempSweepInterval = 10 * time.Minute
+
+// StartTempSweep 每隔tempSweepInterval删除修改时间早于maxAge之前的临时文件，直到调用返回的函数；
+// 返回的函数等待正在进行的清理结束。maxAge不大于0时不启动清理
+func (c *Controller) StartTempSweep(maxAge time.Duration) (stop func()) {
+	if maxAge <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	clk := clock.OrSystem(c.Clock)
+	go func() {
+		defer close(done)
+		for {
+			timer := clk.NewTimer(tempSweepInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+			if _, err := c.FileManager.CleanupTempFilesOlderThan(maxAge); err != nil {
+				fmt.Printf("定期清理临时文件失败: %v\n", err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
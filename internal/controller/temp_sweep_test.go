@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+func TestController_StartTempSweep(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 6, 1, 5, 0, 0, 0, time.UTC), 1)
+	mockFile := &mockFileManager{}
+	controller := NewController(&mockPDFService{}, mockFile, model.DefaultConfig())
+	controller.Clock = fake
+
+	stop := controller.StartTempSweep(time.Hour)
+	// 清理协程可能还没有创建计时器，反复推进虚拟时间直到执行清理
+	deadline := time.Now().Add(5 * time.Second)
+	for mockFile.sweeps.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("推进虚拟时间后没有执行定期清理")
+		}
+		fake.Advance(tempSweepInterval)
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+
+	swept := mockFile.sweeps.Load()
+	fake.Advance(tempSweepInterval)
+	time.Sleep(10 * time.Millisecond)
+	if got := mockFile.sweeps.Load(); got != swept {
+		t.Errorf("停止后不应再清理，清理次数从 %d 变为 %d", swept, got)
+	}
+}
+
+func TestController_StartTempSweepDisabled(t *testing.T) {
+	mockFile := &mockFileManager{}
+	controller := NewController(&mockPDFService{}, mockFile, model.DefaultConfig())
+
+	controller.StartTempSweep(0)()
+	if got := mockFile.sweeps.Load(); got != 0 {
+		t.Errorf("maxAge为0时不应清理，实际清理 %d 次", got)
+	}
+}
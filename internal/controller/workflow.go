@@ -3,12 +3,15 @@ package controller
 import (
 	"context"
 	"fmt"
+	"math"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 // WorkflowStep 定义工作流程步骤
@@ -46,12 +49,18 @@ func (ws WorkflowStep) String() string {
 // WorkflowManager 管理合并工作流程
 type WorkflowManager struct {
 	controller    *Controller
+	job           *model.MergeJob // 正在执行的任务，进度通知发给该任务的订阅者
 	currentStep   WorkflowStep
 	stepMutex     sync.RWMutex
 	retryCount    map[string]int
 	retryMutex    sync.RWMutex
 	maxRetries    int
 	memoryMonitor *MemoryMonitor
+
+	// decrypted 解密步骤为加密输入生成的临时副本（原路径 -> 副本路径），合并时替换原文件
+	decrypted map[string]string
+	// savedPasswords 本次运行中从密码保险库查到的密码（原路径 -> 密码，空串表示没有保存）
+	savedPasswords map[string]string
 }
 
 // NewWorkflowManager 创建新的工作流程管理器
@@ -69,10 +78,14 @@ func NewWorkflowManager(controller *Controller) *WorkflowManager {
 func (wm *WorkflowManager) ExecuteWorkflow(ctx context.Context, job *model.MergeJob) error {
 	// 重置状态
 	wm.resetWorkflow()
+	wm.stepMutex.Lock()
+	wm.job = job
+	wm.stepMutex.Unlock()
 
 	// 启动内存监控
 	wm.memoryMonitor.Start()
 	defer wm.memoryMonitor.Stop()
+	defer wm.removeDecryptedCopies()
 
 	// 执行各个步骤
 	steps := []struct {
@@ -97,18 +110,18 @@ func (wm *WorkflowManager) ExecuteWorkflow(ctx context.Context, job *model.Merge
 		wm.setCurrentStep(stepInfo.step)
 
 		// 更新进度
-		wm.controller.notifyProgress(stepInfo.progress, stepInfo.step.String(),
+		wm.notifyProgress(stepInfo.progress, stepInfo.step.String(),
 			fmt.Sprintf("正在执行: %s", stepInfo.step.String()))
 
 		// 执行步骤
 		if err := wm.executeStepWithRetry(ctx, job, stepInfo.handler); err != nil {
-			return fmt.Errorf("%s失败: %v", stepInfo.step.String(), err)
+			return fmt.Errorf("%s失败: %w", stepInfo.step.String(), err)
 		}
 	}
 
 	// 标记完成
 	wm.setCurrentStep(StepCompleted)
-	wm.controller.notifyProgress(1.0, StepCompleted.String(), "合并操作已完成")
+	wm.notifyProgress(1.0, StepCompleted.String(), "合并操作已完成")
 
 	return nil
 }
@@ -142,18 +155,15 @@ func (wm *WorkflowManager) executeStepWithRetry(ctx context.Context, job *model.
 		wm.incrementRetryCount(stepName)
 
 		// 通知重试
-		wm.controller.notifyProgress(
+		wm.notifyProgress(
 			wm.getStepProgress(),
 			fmt.Sprintf("%s (重试 %d/%d)", stepName, attempt+1, wm.maxRetries),
 			fmt.Sprintf("重试原因: %v", err),
 		)
 
 		// 等待后重试
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Duration(attempt+1) * time.Second):
-			// 继续重试
+		if err := wm.controller.Clock.Sleep(ctx, time.Duration(attempt+1)*time.Second); err != nil {
+			return err
 		}
 	}
 
@@ -173,16 +183,22 @@ func (wm *WorkflowManager) executeValidation(ctx context.Context, job *model.Mer
 
 		// 更新进度
 		progress := 0.2 * float64(i) / float64(totalFiles)
-		wm.controller.notifyProgress(progress, "验证文件",
-			fmt.Sprintf("正在验证: %s", filepath.Base(filePath)))
+		wm.notifyProgress(progress, "验证文件",
+			fmt.Sprintf("正在验证: %s", wm.controller.DisplayName(filePath)))
 
-		// 验证文件
-		if err := wm.controller.ValidateFile(filePath); err != nil {
-			return fmt.Errorf("文件验证失败 %s: %v", filepath.Base(filePath), err)
+		// 验证文件；提供了密码或保险库中保存了密码的加密文件在解密步骤中验证解密结果
+		if _, _, ok := wm.inputPassword(job, filePath); ok {
+			if err := wm.controller.FileManager.ValidateFile(filePath); err != nil {
+				return fmt.Errorf("文件验证失败 %s: %v", wm.controller.DisplayName(filePath), err)
+			}
+		} else if err := wm.controller.ValidateFile(filePath); err != nil {
+			return fmt.Errorf("文件验证失败 %s: %v", wm.controller.DisplayName(filePath), err)
 		}
 
 		// 模拟验证时间
-		time.Sleep(10 * time.Millisecond)
+		if err := wm.controller.Clock.Sleep(ctx, 10*time.Millisecond); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -198,7 +214,7 @@ func (wm *WorkflowManager) executePreparation(ctx context.Context, job *model.Me
 
 	// 检查内存使用情况
 	if wm.memoryMonitor.IsMemoryLow() {
-		wm.controller.notifyProgress(0.25, "内存优化", "内存使用较高，启用流式处理模式")
+		wm.notifyProgress(0.25, "内存优化", "内存使用较高，启用流式处理模式")
 		// 这里可以设置流式处理标志
 	}
 
@@ -210,7 +226,7 @@ func (wm *WorkflowManager) executePreparation(ctx context.Context, job *model.Me
 		}
 	}
 
-	wm.controller.notifyProgress(0.3, "准备合并",
+	wm.notifyProgress(0.3, "准备合并",
 		fmt.Sprintf("准备合并 %d 个文件，总大小: %.2f MB",
 			len(job.AdditionalFiles)+1, float64(totalSize)/(1024*1024)))
 
@@ -230,7 +246,7 @@ func (wm *WorkflowManager) executeDecryption(ctx context.Context, job *model.Mer
 	}
 
 	if len(encryptedFiles) == 0 {
-		wm.controller.notifyProgress(0.4, "跳过解密", "没有加密文件需要处理")
+		wm.notifyProgress(0.4, "跳过解密", "没有加密文件需要处理")
 		return nil
 	}
 
@@ -242,35 +258,191 @@ func (wm *WorkflowManager) executeDecryption(ctx context.Context, job *model.Mer
 		}
 
 		progress := 0.3 + (0.1 * float64(i) / float64(len(encryptedFiles)))
-		wm.controller.notifyProgress(progress, "处理加密文件",
-			fmt.Sprintf("正在处理: %s", filepath.Base(filePath)))
+		wm.notifyProgress(progress, "处理加密文件",
+			fmt.Sprintf("正在处理: %s", wm.controller.DisplayName(filePath)))
+
+		password, fromVault, ok := wm.inputPassword(job, filePath)
+		if !ok {
+			// 没有提供密码的文件交给合并服务处理
+			wm.notifyProgress(progress+0.01, "解密文件",
+				fmt.Sprintf("文件 %s 需要密码", wm.controller.DisplayName(filePath)))
+			continue
+		}
+		if _, done := wm.decrypted[filePath]; done {
+			continue
+		}
 
-		// 这里应该调用解密服务，但由于我们还没有实现完整的解密功能，
-		// 暂时跳过实际解密，只是记录需要处理的文件
-		wm.controller.notifyProgress(progress+0.01, "解密文件",
-			fmt.Sprintf("文件 %s 需要密码", filepath.Base(filePath)))
+		tempPath, err := wm.controller.FileManager.CreateTempFile()
+		if err != nil {
+			return fmt.Errorf("无法创建临时文件: %v", err)
+		}
+		if err := wm.controller.PDFService.DecryptPDF(filePath, tempPath, password); err != nil {
+			wm.controller.FileManager.RemoveTempFile(tempPath)
+			if fromVault {
+				// 保存的密码已失效：删除条目，文件按没有密码交给合并服务处理
+				pdf.ForgetPassword(wm.controller.PasswordVault(), filePath)
+				wm.savedPasswords[filePath] = ""
+				wm.notifyProgress(progress+0.01, "解密文件",
+					fmt.Sprintf("保存的密码无法解密 %s", wm.controller.DisplayName(filePath)))
+				continue
+			}
+			return fmt.Errorf("无法解密 %s: %w", wm.controller.DisplayName(filePath), err)
+		}
+		if !fromVault {
+			pdf.RememberPassword(wm.controller.PasswordVault(), filePath, password)
+		}
+		if wm.decrypted == nil {
+			wm.decrypted = make(map[string]string)
+		}
+		wm.decrypted[filePath] = tempPath
+		wm.notifyProgress(progress+0.01, "解密文件",
+			fmt.Sprintf("文件 %s 已解密", wm.controller.DisplayName(filePath)))
 	}
 
 	return nil
 }
 
+// inputPassword 返回解密输入使用的密码：任务中提供的密码优先，其次是密码保险库中为该文件内容保存的密码。
+// 保险库查询要读取整个文件计算哈希，结果在本次运行中缓存
+func (wm *WorkflowManager) inputPassword(job *model.MergeJob, filePath string) (password string, fromVault, ok bool) {
+	if password, ok := job.Passwords[filePath]; ok {
+		return password, false, true
+	}
+	vault := wm.controller.PasswordVault()
+	if vault == nil {
+		return "", false, false
+	}
+	if password, cached := wm.savedPasswords[filePath]; cached {
+		return password, true, password != ""
+	}
+	if encrypted, err := wm.controller.PDFService.IsPDFEncrypted(filePath); err != nil || !encrypted {
+		return "", false, false
+	}
+	password, _ = pdf.LookupVaultPassword(vault, filePath)
+	if wm.savedPasswords == nil {
+		wm.savedPasswords = make(map[string]string)
+	}
+	wm.savedPasswords[filePath] = password
+	return password, true, password != ""
+}
+
+// withDecryptedInputs 返回合并使用的任务：已解密的输入替换为临时副本，没有解密的输入时返回原任务
+func (wm *WorkflowManager) withDecryptedInputs(job *model.MergeJob) *model.MergeJob {
+	if len(wm.decrypted) == 0 {
+		return job
+	}
+	input := func(path string) string {
+		if copyPath, ok := wm.decrypted[path]; ok {
+			return copyPath
+		}
+		return path
+	}
+
+	merged := *job
+	merged.MainFile = input(job.MainFile)
+	merged.AdditionalFiles = make([]string, len(job.AdditionalFiles))
+	for i, path := range job.AdditionalFiles {
+		merged.AdditionalFiles[i] = input(path)
+	}
+	return &merged
+}
+
+// removeDecryptedCopies 删除解密步骤生成的临时副本
+func (wm *WorkflowManager) removeDecryptedCopies() {
+	for _, copyPath := range wm.decrypted {
+		wm.controller.FileManager.RemoveTempFile(copyPath)
+	}
+	wm.decrypted = nil
+}
+
 // executeMerging 执行合并步骤
 func (wm *WorkflowManager) executeMerging(ctx context.Context, job *model.MergeJob) error {
 	// 创建进度写入器
-	progressWriter := &WorkflowProgressWriter{
-		workflow:     wm,
-		baseProgress: 0.4,
-		maxProgress:  0.9,
-		totalFiles:   len(job.AdditionalFiles) + 1,
+	// 创建进度写入器，合并进度从开始合并时的0.5增长到0.85，之后是目录和完成步骤
+	progressWriter := newWorkflowProgressWriter(wm, 0.5, 0.85)
+
+	// 加密输入使用解密步骤生成的临时副本，指定了旋转的输入再换成旋转后的副本
+	merged, cleanup, err := wm.withRotatedInputs(job, wm.withDecryptedInputs(job))
+	if err != nil {
+		return err
 	}
+	defer cleanup()
 
 	// 检查内存使用情况，决定使用流式处理还是常规处理
 	if wm.memoryMonitor.IsMemoryLow() {
-		wm.controller.notifyProgress(0.5, "流式合并", "使用内存优化模式进行合并")
-		return wm.executeStreamingMerge(ctx, job, progressWriter)
+		wm.notifyProgress(0.5, "流式合并", "使用内存优化模式进行合并")
+		err = wm.executeStreamingMerge(ctx, merged, progressWriter)
 	} else {
-		wm.controller.notifyProgress(0.5, "标准合并", "使用标准模式进行合并")
-		return wm.executeStandardMerge(ctx, job, progressWriter)
+		wm.notifyProgress(0.5, "标准合并", "使用标准模式进行合并")
+		err = wm.executeStandardMerge(ctx, merged, progressWriter)
+	}
+	// 合并器的进度回调异步执行，合并返回后迟到的报告不能让进度倒退
+	progressWriter.close()
+	if err == nil && job.GenerateTOC {
+		wm.addTableOfContents(job, merged)
+	}
+	return err
+}
+
+// withRotatedInputs 为指定了旋转的输入（启用NormalizeOrientation时为全部输入）生成旋转后的临时副本，
+// 返回使用这些副本的任务和删除副本的清理函数。旋转按job中的原始路径查找，副本由merged中的文件
+// （加密输入为解密副本）生成。每次合并尝试重新生成，重试时不会叠加旋转。
+func (wm *WorkflowManager) withRotatedInputs(job, merged *model.MergeJob) (*model.MergeJob, func(), error) {
+	var temps []string
+	cleanup := func() {
+		for _, tempPath := range temps {
+			wm.controller.FileManager.RemoveTempFile(tempPath)
+		}
+	}
+	if len(job.Rotations) == 0 && !job.NormalizeOrientation {
+		return merged, cleanup, nil
+	}
+
+	originals := append([]string{job.MainFile}, job.AdditionalFiles...)
+	files := append([]string{merged.MainFile}, merged.AdditionalFiles...)
+	for i, original := range originals {
+		degrees := job.Rotations[original]
+		if degrees == 0 && !job.NormalizeOrientation {
+			continue
+		}
+		// 副本使用 .pdf 扩展名，合并前的验证按扩展名识别PDF文件
+		tempPath, tempFile, err := wm.controller.FileManager.CreateTempFileWithPrefix("rotated_", ".pdf")
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("无法创建临时文件: %v", err)
+		}
+		tempFile.Close()
+		temps = append(temps, tempPath)
+		kept, err := wm.controller.PDFService.RotatePDF(files[i], tempPath, degrees, job.NormalizeOrientation)
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("无法旋转 %s: %w", wm.controller.DisplayName(original), err)
+		}
+		if len(kept) > 0 {
+			wm.notifyProgress(0.45, "页面方向",
+				fmt.Sprintf("%s 中 %d 页有注释，保留原有的 /Rotate", wm.controller.DisplayName(original), len(kept)))
+		}
+		files[i] = tempPath
+	}
+
+	rotated := *merged
+	rotated.MainFile = files[0]
+	rotated.AdditionalFiles = files[1:]
+	return &rotated, cleanup, nil
+}
+
+// addTableOfContents 在合并输出开头插入目录页。标题从合并使用的文件（加密输入为解密副本）读取，
+// 没有标题时显示原始输入的文件名。目录是辅助信息，添加失败不影响合并结果，只报告警告。
+func (wm *WorkflowManager) addTableOfContents(job, merged *model.MergeJob) {
+	wm.notifyProgress(0.88, "生成目录", "正在插入目录页...")
+	originals := append([]string{job.MainFile}, job.AdditionalFiles...)
+	files := append([]string{merged.MainFile}, merged.AdditionalFiles...)
+	sources := make([]pdf.TOCSource, len(files))
+	for i, file := range files {
+		sources[i] = pdf.TOCSource{File: file, DisplayName: originals[i]}
+	}
+	if err := wm.controller.PDFService.AddTableOfContents(job.OutputPath, sources); err != nil {
+		wm.notifyProgress(0.89, "目录警告", fmt.Sprintf("添加目录页失败: %v", err))
 	}
 }
 
@@ -292,7 +464,7 @@ func (wm *WorkflowManager) executeStandardMerge(ctx context.Context, job *model.
 	// 执行合并
 	err := wm.controller.PDFService.MergePDFs(job.MainFile, job.AdditionalFiles, job.OutputPath, progressWriter)
 	if err != nil {
-		return fmt.Errorf("合并失败: %v", err)
+		return fmt.Errorf("合并失败: %w", err)
 	}
 
 	return nil
@@ -307,14 +479,14 @@ func (wm *WorkflowManager) executeFinalization(ctx context.Context, job *model.M
 
 	// 获取输出文件信息
 	if info, err := wm.controller.FileManager.GetFileInfo(job.OutputPath); err == nil {
-		wm.controller.notifyProgress(0.95, "验证输出",
+		wm.notifyProgress(0.95, "验证输出",
 			fmt.Sprintf("输出文件大小: %.2f MB", float64(info.Size)/(1024*1024)))
 	}
 
 	// 清理临时文件
 	if err := wm.controller.FileManager.CleanupTempFiles(); err != nil {
 		// 清理失败不应该导致整个操作失败，只记录警告
-		wm.controller.notifyProgress(0.98, "清理警告",
+		wm.notifyProgress(0.98, "清理警告",
 			fmt.Sprintf("临时文件清理失败: %v", err))
 	}
 
@@ -336,6 +508,7 @@ func (wm *WorkflowManager) shouldRetry(err error) bool {
 		"权限被拒绝",
 		"无效的PDF格式",
 		"用户取消",
+		"无法解密",
 	}
 
 	for _, nonRetryable := range nonRetryableErrors {
@@ -365,10 +538,19 @@ func (wm *WorkflowManager) shouldRetry(err error) bool {
 
 // 辅助方法
 
+// notifyProgress 通知正在执行的任务的进度
+func (wm *WorkflowManager) notifyProgress(progress float64, status, detail string) {
+	wm.stepMutex.RLock()
+	job := wm.job
+	wm.stepMutex.RUnlock()
+	wm.controller.notifyJobProgress(job, progress, status, detail)
+}
+
 func (wm *WorkflowManager) resetWorkflow() {
 	wm.stepMutex.Lock()
 	defer wm.stepMutex.Unlock()
 	wm.currentStep = StepValidation
+	wm.savedPasswords = nil
 
 	wm.retryMutex.Lock()
 	defer wm.retryMutex.Unlock()
@@ -419,27 +601,61 @@ func (wm *WorkflowManager) resetRetryCount(step string) {
 	delete(wm.retryCount, step)
 }
 
-// WorkflowProgressWriter 工作流程进度写入器
+// WorkflowProgressWriter 工作流程进度写入器。合并器通过 pdf.ProgressReporter 报告的0.0-1.0
+// 比例换算到合并步骤的[baseProgress, maxProgress]区间；写入的文本行只作为说明转发，不改变进度。
+// 合并器的进度回调可能乱序到达，进度只增不减，合并结束后调用close丢弃迟到的报告。
 type WorkflowProgressWriter struct {
 	workflow     *WorkflowManager
 	baseProgress float64
 	maxProgress  float64
-	totalFiles   int
-	currentFile  int
+
+	mu       sync.Mutex
+	progress float64
+	closed   bool
+}
+
+// newWorkflowProgressWriter 创建在[base, limit]区间内报告进度的写入器
+func newWorkflowProgressWriter(wm *WorkflowManager, base, limit float64) *WorkflowProgressWriter {
+	return &WorkflowProgressWriter{workflow: wm, baseProgress: base, maxProgress: limit, progress: base}
+}
+
+// ReportProgress 实现 pdf.ProgressReporter
+func (wpw *WorkflowProgressWriter) ReportProgress(fraction float64, message string) {
+	fraction = math.Max(0, math.Min(1, fraction))
+	progress := wpw.baseProgress + (wpw.maxProgress-wpw.baseProgress)*fraction
+
+	wpw.mu.Lock()
+	if wpw.closed || progress < wpw.progress {
+		wpw.mu.Unlock()
+		return
+	}
+	wpw.progress = progress
+	wpw.mu.Unlock()
+
+	wpw.workflow.notifyProgress(progress, "合并文件", message)
 }
 
+// Write 把合并器写出的文本作为说明按当前进度转发
 func (wpw *WorkflowProgressWriter) Write(p []byte) (n int, err error) {
-	// 更新进度
-	wpw.currentFile++
-	progress := wpw.baseProgress +
-		(wpw.maxProgress-wpw.baseProgress)*float64(wpw.currentFile)/float64(wpw.totalFiles)
+	detail := strings.TrimSpace(string(p))
 
-	wpw.workflow.controller.notifyProgress(progress, "合并文件",
-		fmt.Sprintf("正在处理第 %d/%d 个文件", wpw.currentFile, wpw.totalFiles))
+	wpw.mu.Lock()
+	closed, progress := wpw.closed, wpw.progress
+	wpw.mu.Unlock()
 
+	if !closed && detail != "" {
+		wpw.workflow.notifyProgress(progress, "合并文件", detail)
+	}
 	return len(p), nil
 }
 
+// close 停止转发进度，之后到达的报告都被忽略
+func (wpw *WorkflowProgressWriter) close() {
+	wpw.mu.Lock()
+	defer wpw.mu.Unlock()
+	wpw.closed = true
+}
+
 // MemoryMonitor 内存监控器
 type MemoryMonitor struct {
 	maxMemory     int64
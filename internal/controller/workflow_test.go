@@ -2,10 +2,17 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 func TestWorkflowManager_ExecuteWorkflow(t *testing.T) {
@@ -63,8 +70,10 @@ func TestWorkflowManager_CancellationSupport(t *testing.T) {
 	mockFile := &mockFileManager{}
 	config := model.DefaultConfig()
 
-	// 创建控制器
+	// 创建控制器，使用虚拟时钟避免真实等待
 	controller := NewController(mockPDF, mockFile, config)
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	controller.Clock = fake
 
 	// 创建工作流程管理器
 	workflowManager := NewWorkflowManager(controller)
@@ -74,12 +83,14 @@ func TestWorkflowManager_CancellationSupport(t *testing.T) {
 
 	// 创建可取消的上下文
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// 在短时间后取消
-	go func() {
-		time.Sleep(50 * time.Millisecond)
-		cancel()
-	}()
+	// 开始验证第一个文件时取消
+	controller.SetProgressCallback(func(progress float64, status, detail string) {
+		if status == "验证文件" {
+			cancel()
+		}
+	})
 
 	// 执行工作流程
 	err := workflowManager.ExecuteWorkflow(ctx, job)
@@ -89,9 +100,43 @@ func TestWorkflowManager_CancellationSupport(t *testing.T) {
 		t.Error("期望取消错误，但工作流程成功完成")
 	}
 
-	if err != context.Canceled {
+	if !errors.Is(err, context.Canceled) {
 		t.Errorf("期望取消错误，实际错误: %v", err)
 	}
+	if len(fake.Sleeps()) != 0 {
+		t.Errorf("取消后不应再等待: %v", fake.Sleeps())
+	}
+}
+
+func TestWorkflowManager_RetryBackoffUsesClock(t *testing.T) {
+	controller := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	controller.Clock = fake
+
+	workflowManager := NewWorkflowManager(controller)
+	job := model.NewMergeJob("main.pdf", nil, "output.pdf")
+
+	calls := 0
+	start := time.Now()
+	err := workflowManager.executeStepWithRetry(context.Background(), job, func(ctx context.Context, job *model.MergeJob) error {
+		calls++
+		return errors.New("IO错误")
+	})
+	if err == nil {
+		t.Fatal("步骤持续失败时应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("使用虚拟时钟时不应真实等待，耗时 %v", elapsed)
+	}
+	if calls != 4 {
+		t.Errorf("期望执行4次（1次+3次重试），实际 %d", calls)
+	}
+
+	// 每次重试前按 1s、2s、3s 递增等待
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(fake.Sleeps()) != fmt.Sprint(expected) {
+		t.Errorf("重试等待不正确，期望 %v，实际 %v", expected, fake.Sleeps())
+	}
 }
 
 func TestStreamingMerger_MergeStreaming(t *testing.T) {
@@ -266,3 +311,199 @@ func BenchmarkStreamingMerger_MergeStreaming(b *testing.B) {
 		}
 	}
 }
+
+// decryptingPDFService 把文件名为 locked.pdf 的文件报告为加密文件，只接受密码 secret，并记录合并的输入
+type decryptingPDFService struct {
+	mockPDFService
+	decrypted []string
+	merged    []string
+}
+
+func (d *decryptingPDFService) IsPDFEncrypted(filePath string) (bool, error) {
+	return filepath.Base(filePath) == "locked.pdf", nil
+}
+
+func (d *decryptingPDFService) DecryptPDF(inputPath, outputPath, password string) error {
+	if password != "secret" {
+		return errors.New("密码错误")
+	}
+	d.decrypted = append(d.decrypted, inputPath)
+	return nil
+}
+
+func (d *decryptingPDFService) MergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) error {
+	d.merged = append([]string{mainFile}, additionalFiles...)
+	return nil
+}
+
+func TestWorkflowManager_DecryptsInputsWithPasswords(t *testing.T) {
+	service := &decryptingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	job := model.NewMergeJob("main.pdf", []string{"locked.pdf", "plain.pdf"}, "output.pdf")
+	job.Passwords = map[string]string{"locked.pdf": "secret"}
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+
+	if fmt.Sprint(service.decrypted) != "[locked.pdf]" {
+		t.Errorf("只应解密提供了密码的加密文件，实际 %v", service.decrypted)
+	}
+	// mockFileManager 的临时文件固定为 /tmp/test.pdf
+	expected := []string{"main.pdf", "/tmp/test.pdf", "plain.pdf"}
+	if fmt.Sprint(service.merged) != fmt.Sprint(expected) {
+		t.Errorf("加密文件应以解密副本参与合并，期望 %v，实际 %v", expected, service.merged)
+	}
+	if job.AdditionalFiles[0] != "locked.pdf" {
+		t.Errorf("任务中的输入路径不应被修改，实际 %v", job.AdditionalFiles)
+	}
+}
+
+func TestWorkflowManager_WrongPasswordFailsWithoutRetry(t *testing.T) {
+	service := &decryptingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	controller.Clock = fake
+
+	job := model.NewMergeJob("main.pdf", []string{"locked.pdf"}, "output.pdf")
+	job.Passwords = map[string]string{"locked.pdf": "guess"}
+	err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job)
+	if err == nil {
+		t.Fatal("密码错误时工作流程应失败")
+	}
+	if strings.Contains(err.Error(), "guess") {
+		t.Errorf("错误信息不应包含密码: %v", err)
+	}
+	if service.merged != nil {
+		t.Error("解密失败时不应开始合并")
+	}
+	for _, d := range fake.Sleeps() {
+		if d >= time.Second {
+			t.Errorf("密码错误不应重试，实际等待 %v", fake.Sleeps())
+			break
+		}
+	}
+}
+
+// tocPDFService 记录插入目录页时的来源
+type tocPDFService struct {
+	decryptingPDFService
+	tocOutput  string
+	tocSources []pdf.TOCSource
+}
+
+func (d *tocPDFService) AddTableOfContents(outputPath string, sources []pdf.TOCSource) error {
+	d.tocOutput = outputPath
+	d.tocSources = sources
+	return nil
+}
+
+func TestWorkflowManager_AddsTableOfContents(t *testing.T) {
+	service := &tocPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	job := model.NewMergeJob("main.pdf", []string{"locked.pdf"}, "output.pdf")
+	job.Passwords = map[string]string{"locked.pdf": "secret"}
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	if service.tocSources != nil {
+		t.Fatal("未要求目录时不应插入目录页")
+	}
+
+	job = model.NewMergeJob("main.pdf", []string{"locked.pdf"}, "output.pdf")
+	job.Passwords = map[string]string{"locked.pdf": "secret"}
+	job.GenerateTOC = true
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	expected := []pdf.TOCSource{
+		{File: "main.pdf", DisplayName: "main.pdf"},
+		{File: "/tmp/test.pdf", DisplayName: "locked.pdf"},
+	}
+	if service.tocOutput != "output.pdf" || fmt.Sprint(service.tocSources) != fmt.Sprint(expected) {
+		t.Errorf("目录应从合并使用的文件读取并显示原始文件名，期望 %v，实际 %s %v",
+			expected, service.tocOutput, service.tocSources)
+	}
+}
+
+// rotatingPDFService 记录合并前的旋转调用
+type rotatingPDFService struct {
+	decryptingPDFService
+	rotated []string
+}
+
+func (d *rotatingPDFService) RotatePDF(inputPath, outputPath string, degrees int, normalize bool) ([]int, error) {
+	d.rotated = append(d.rotated, fmt.Sprintf("%s %d %v", inputPath, degrees, normalize))
+	return nil, nil
+}
+
+func TestWorkflowManager_RotatesInputs(t *testing.T) {
+	service := &rotatingPDFService{}
+	controller := NewController(service, &mockFileManager{}, model.DefaultConfig())
+
+	job := model.NewMergeJob("main.pdf", []string{"locked.pdf", "plain.pdf"}, "output.pdf")
+	job.Passwords = map[string]string{"locked.pdf": "secret"}
+	job.Rotations = map[string]int{"locked.pdf": 90}
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	// 旋转按原始路径查找，作用于解密副本
+	if fmt.Sprint(service.rotated) != "[/tmp/test.pdf 90 false]" {
+		t.Errorf("只应旋转指定了角度的输入，实际 %v", service.rotated)
+	}
+
+	service.rotated = nil
+	job = model.NewMergeJob("main.pdf", []string{"plain.pdf"}, "output.pdf")
+	job.NormalizeOrientation = true
+	if err := NewWorkflowManager(controller).ExecuteWorkflow(context.Background(), job); err != nil {
+		t.Fatalf("工作流程执行失败: %v", err)
+	}
+	if fmt.Sprint(service.rotated) != "[main.pdf 0 true plain.pdf 0 true]" {
+		t.Errorf("规范页面方向时应处理全部输入，实际 %v", service.rotated)
+	}
+}
+
+func TestWorkflowProgressWriter_MapsAndClampsProgress(t *testing.T) {
+	controller := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	var progresses []float64
+	var details []string
+	controller.SetProgressCallback(func(progress float64, status, detail string) {
+		progresses = append(progresses, progress)
+		details = append(details, detail)
+	})
+
+	writer := newWorkflowProgressWriter(NewWorkflowManager(controller), 0.5, 0.9)
+	writer.ReportProgress(0.5, "一半")
+	// 文本行只作为说明，不当作完成了一个文件
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(writer, "验证文件 %d/2\n", i+1)
+	}
+	writer.ReportProgress(0.25, "乱序到达")
+	writer.ReportProgress(3, "超出范围")
+	writer.close()
+	writer.ReportProgress(1, "合并结束后")
+	fmt.Fprintln(writer, "合并结束后")
+
+	want := []float64{0.7, 0.7, 0.7, 0.7, 0.7, 0.7, 0.9}
+	if fmt.Sprintf("%.2f", progresses) != fmt.Sprintf("%.2f", want) {
+		t.Errorf("进度 = %.2f，应为 %.2f", progresses, want)
+	}
+	if details[1] != "验证文件 1/2" || details[len(details)-1] != "超出范围" {
+		t.Errorf("说明不正确: %q", details)
+	}
+}
+
+func TestController_ClampsReportedProgress(t *testing.T) {
+	controller := NewController(&mockPDFService{}, &mockFileManager{}, model.DefaultConfig())
+	var progresses []float64
+	controller.SetProgressCallback(func(progress float64, status, detail string) {
+		progresses = append(progresses, progress)
+	})
+
+	controller.notifyProgress(2.15, "合并文件", "")
+	controller.notifyProgress(-0.1, "合并文件", "")
+	if fmt.Sprint(progresses) != fmt.Sprint([]float64{1, 0}) {
+		t.Errorf("进度 = %v，应限制在0.0-1.0之间", progresses)
+	}
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+const (
+	// WorkspaceDirName 临时目录下存放任务工作区的子目录，每个任务一个以任务ID命名的目录
+	WorkspaceDirName = "workspaces"
+	// WorkspaceCheckpointFile 工作区中的断点文件，存在时该工作区可以用于恢复任务
+	WorkspaceCheckpointFile = "checkpoint.json"
+)
+
+var (
+	// ErrWorkspaceInUse 工作区属于正在运行或排队的任务
+	ErrWorkspaceInUse = errors.New("工作区正在使用")
+	// ErrWorkspaceNotFound 指定任务没有工作区
+	ErrWorkspaceNotFound = errors.New("工作区不存在")
+)
+
+// WorkspaceInfo 保留在磁盘上的任务工作区
+type WorkspaceInfo struct {
+	JobID     string        `json:"job_id"`
+	Path      string        `json:"path"`
+	Size      int64         `json:"size"`
+	Age       time.Duration `json:"age"`
+	Resumable bool          `json:"resumable"`
+}
+
+// workspaceSize 缓存的工作区大小，目录修改时间变化后失效
+type workspaceSize struct {
+	modTime time.Time
+	size    int64
+}
+
+// SetJobQueue 设置任务队列，丢弃工作区时会拒绝队列中尚未结束的任务
+func (c *Controller) SetJobQueue(queue *JobQueue) {
+	c.jobMutex.Lock()
+	defer c.jobMutex.Unlock()
+	c.jobQueue = queue
+}
+
+// WorkspaceRoot 返回任务工作区的根目录
+func (c *Controller) WorkspaceRoot() string {
+	base := os.TempDir()
+	if c.Config != nil && c.Config.TempDirectory != "" {
+		base = c.Config.TempDirectory
+	}
+	return filepath.Join(base, WorkspaceDirName)
+}
+
+// WorkspaceDir 返回任务的工作区目录
+func (c *Controller) WorkspaceDir(jobID string) string {
+	return filepath.Join(c.WorkspaceRoot(), jobID)
+}
+
+// ListWorkspaces 列出保留在磁盘上的任务工作区，按创建时间从旧到新排列。
+// 大小在列出时才计算，并按目录缓存到目录内容发生变化。
+func (c *Controller) ListWorkspaces() ([]WorkspaceInfo, error) {
+	root := c.WorkspaceRoot()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("无法读取工作区目录 %s: %w", root, err)
+	}
+
+	now := clock.OrSystem(c.Clock).Now()
+	var workspaces []WorkspaceInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		size, err := c.workspaceSize(path, info.ModTime())
+		if err != nil {
+			return nil, fmt.Errorf("无法计算工作区 %s 的大小: %w", entry.Name(), err)
+		}
+		_, checkpointErr := os.Stat(filepath.Join(path, WorkspaceCheckpointFile))
+		workspaces = append(workspaces, WorkspaceInfo{
+			JobID:     entry.Name(),
+			Path:      path,
+			Size:      size,
+			Age:       now.Sub(info.ModTime()),
+			Resumable: checkpointErr == nil,
+		})
+	}
+
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		return workspaces[i].Age > workspaces[j].Age
+	})
+	return workspaces, nil
+}
+
+// workspaceSize 返回工作区占用的字节数，目录修改时间未变时使用缓存
+func (c *Controller) workspaceSize(path string, modTime time.Time) (int64, error) {
+	c.workspaceMu.Lock()
+	cached, ok := c.workspaceSizes[path]
+	c.workspaceMu.Unlock()
+	if ok && cached.modTime.Equal(modTime) {
+		return cached.size, nil
+	}
+
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c.workspaceMu.Lock()
+	if c.workspaceSizes == nil {
+		c.workspaceSizes = make(map[string]workspaceSize)
+	}
+	c.workspaceSizes[path] = workspaceSize{modTime: modTime, size: size}
+	c.workspaceMu.Unlock()
+	return size, nil
+}
+
+// DiscardWorkspace 删除任务的工作区。工作区属于正在运行或排队的任务时拒绝删除。
+func (c *Controller) DiscardWorkspace(jobID string) error {
+	if jobID == "" || jobID == "." || jobID == ".." || filepath.Base(jobID) != jobID {
+		return fmt.Errorf("无效的任务ID: %q", jobID)
+	}
+	if c.jobActive(jobID) {
+		return fmt.Errorf("%w: 任务 %s 正在运行或排队，请先取消任务再丢弃其工作区", ErrWorkspaceInUse, jobID)
+	}
+
+	path := c.WorkspaceDir(jobID)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, jobID)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("无法删除工作区 %s: %w", path, err)
+	}
+
+	c.workspaceMu.Lock()
+	delete(c.workspaceSizes, path)
+	c.workspaceMu.Unlock()
+	return nil
+}
+
+// jobActive 判断任务是否为当前任务或仍在队列中
+func (c *Controller) jobActive(jobID string) bool {
+	c.jobMutex.RLock()
+	current, queue := c.currentJob, c.jobQueue
+	c.jobMutex.RUnlock()
+
+	if current != nil && current.ID == jobID {
+		return true
+	}
+	return queue != nil && queue.Contains(jobID)
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// newWorkspaceController 创建使用独立临时目录和虚拟时钟的控制器
+func newWorkspaceController(t *testing.T) (*Controller, *clock.Fake) {
+	t.Helper()
+	config := model.DefaultConfig()
+	config.TempDirectory = t.TempDir()
+	c := NewController(&mockPDFService{}, &mockFileManager{}, config)
+	fake := clock.NewFake(time.Now().Add(time.Hour), 1)
+	c.Clock = fake
+	return c, fake
+}
+
+// writeWorkspace 在任务工作区中写入文件，返回写入的总字节数
+func writeWorkspace(t *testing.T, c *Controller, jobID string, files map[string]int) int64 {
+	t.Helper()
+	var total int64
+	for name, size := range files {
+		path := filepath.Join(c.WorkspaceDir(jobID), name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建工作区失败: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("写入工作区文件失败: %v", err)
+		}
+		total += int64(size)
+	}
+	return total
+}
+
+func TestController_ListWorkspaces(t *testing.T) {
+	c, _ := newWorkspaceController(t)
+
+	resumableSize := writeWorkspace(t, c, "job-resumable", map[string]int{
+		WorkspaceCheckpointFile: 100,
+		"chunks/chunk_001.pdf":  4096,
+		"chunks/chunk_002.pdf":  2048,
+	})
+	failedSize := writeWorkspace(t, c, "job-failed", map[string]int{"partial.pdf": 512})
+	// 根目录下的普通文件不是工作区
+	os.WriteFile(filepath.Join(c.WorkspaceRoot(), "stray.txt"), []byte("x"), 0644)
+
+	workspaces, err := c.ListWorkspaces()
+	if err != nil {
+		t.Fatalf("列出工作区失败: %v", err)
+	}
+	if len(workspaces) != 2 {
+		t.Fatalf("应有2个工作区，实际 %d: %+v", len(workspaces), workspaces)
+	}
+
+	byID := make(map[string]WorkspaceInfo)
+	for _, ws := range workspaces {
+		byID[ws.JobID] = ws
+		if ws.Age <= 0 {
+			t.Errorf("工作区 %s 的年龄应为正数，实际 %v", ws.JobID, ws.Age)
+		}
+	}
+	if ws := byID["job-resumable"]; !ws.Resumable || ws.Size != resumableSize {
+		t.Errorf("带断点的工作区应可恢复且大小为 %d，实际 %+v", resumableSize, ws)
+	}
+	if ws := byID["job-failed"]; ws.Resumable || ws.Size != failedSize {
+		t.Errorf("没有断点的工作区不可恢复且大小为 %d，实际 %+v", failedSize, ws)
+	}
+	if byID["job-failed"].Path != c.WorkspaceDir("job-failed") {
+		t.Errorf("工作区路径错误: %s", byID["job-failed"].Path)
+	}
+}
+
+func TestController_ListWorkspacesWithoutRoot(t *testing.T) {
+	c, _ := newWorkspaceController(t)
+	workspaces, err := c.ListWorkspaces()
+	if err != nil || len(workspaces) != 0 {
+		t.Errorf("没有工作区目录时应返回空列表，实际 %v, %v", workspaces, err)
+	}
+}
+
+func TestController_WorkspaceSizeIsCached(t *testing.T) {
+	c, _ := newWorkspaceController(t)
+	writeWorkspace(t, c, "job-1", map[string]int{"a.pdf": 1000})
+	path := c.WorkspaceDir("job-1")
+
+	if _, err := c.ListWorkspaces(); err != nil {
+		t.Fatalf("列出工作区失败: %v", err)
+	}
+	info, _ := os.Stat(path)
+
+	// 修改时间不变时使用缓存
+	c.workspaceMu.Lock()
+	c.workspaceSizes[path] = workspaceSize{modTime: info.ModTime(), size: 42}
+	c.workspaceMu.Unlock()
+	workspaces, _ := c.ListWorkspaces()
+	if workspaces[0].Size != 42 {
+		t.Errorf("目录未变化时应使用缓存的大小，实际 %d", workspaces[0].Size)
+	}
+
+	// 目录内容变化后重新计算
+	writeWorkspace(t, c, "job-1", map[string]int{"b.pdf": 500})
+	os.Chtimes(path, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second))
+	workspaces, _ = c.ListWorkspaces()
+	if workspaces[0].Size != 1500 {
+		t.Errorf("目录变化后应重新计算大小，实际 %d", workspaces[0].Size)
+	}
+}
+
+func TestController_DiscardWorkspace(t *testing.T) {
+	c, _ := newWorkspaceController(t)
+	writeWorkspace(t, c, "job-done", map[string]int{
+		WorkspaceCheckpointFile: 10,
+		"chunks/chunk_001.pdf":  100,
+	})
+	writeWorkspace(t, c, "job-keep", map[string]int{"a.pdf": 10})
+	c.ListWorkspaces()
+
+	if err := c.DiscardWorkspace("job-done"); err != nil {
+		t.Fatalf("丢弃工作区失败: %v", err)
+	}
+	if _, err := os.Stat(c.WorkspaceDir("job-done")); !os.IsNotExist(err) {
+		t.Error("工作区目录应被完全删除")
+	}
+	if _, cached := c.workspaceSizes[c.WorkspaceDir("job-done")]; cached {
+		t.Error("丢弃后应清除大小缓存")
+	}
+	workspaces, _ := c.ListWorkspaces()
+	if len(workspaces) != 1 || workspaces[0].JobID != "job-keep" {
+		t.Errorf("其他工作区应保留，实际 %+v", workspaces)
+	}
+
+	if err := c.DiscardWorkspace("job-done"); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("再次丢弃应返回ErrWorkspaceNotFound，实际 %v", err)
+	}
+	for _, id := range []string{"", "..", "../job-keep", "a/b"} {
+		if err := c.DiscardWorkspace(id); err == nil {
+			t.Errorf("无效的任务ID %q 应被拒绝", id)
+		}
+	}
+	if _, err := os.Stat(c.WorkspaceDir("job-keep")); err != nil {
+		t.Error("无效ID不应删除任何工作区")
+	}
+}
+
+func TestController_DiscardWorkspaceRefusesActiveJobs(t *testing.T) {
+	c, _ := newWorkspaceController(t)
+
+	// 控制器当前正在运行的任务
+	running := model.NewMergeJob("main.pdf", nil, "out.pdf")
+	c.jobMutex.Lock()
+	c.currentJob = running
+	c.jobMutex.Unlock()
+	writeWorkspace(t, c, running.ID, map[string]int{"chunk.pdf": 10})
+
+	// 队列中正在运行和排队的任务
+	queue := NewJobQueue(1, nil)
+	c.SetJobQueue(queue)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	queuedRunning := model.NewMergeJob("a.pdf", nil, "")
+	queuedWaiting := model.NewMergeJob("b.pdf", nil, "")
+	first, _ := queue.Enqueue(queuedRunning, SourceInteractive, func(ctx context.Context, _ *QueuedJob) error {
+		close(started)
+		<-release
+		return nil
+	})
+	second, _ := queue.Enqueue(queuedWaiting, SourceInteractive, noopJob)
+	queue.Start(context.Background())
+	<-started
+
+	for _, job := range []*model.MergeJob{running, queuedRunning, queuedWaiting} {
+		writeWorkspace(t, c, job.ID, map[string]int{"chunk.pdf": 10})
+		err := c.DiscardWorkspace(job.ID)
+		if !errors.Is(err, ErrWorkspaceInUse) {
+			t.Errorf("任务 %s 的工作区应拒绝丢弃，实际 %v", job.ID, err)
+		}
+		if _, statErr := os.Stat(c.WorkspaceDir(job.ID)); statErr != nil {
+			t.Errorf("拒绝丢弃时不应删除工作区 %s", job.ID)
+		}
+	}
+
+	close(release)
+	first.Wait()
+	second.Wait()
+	queue.Close()
+	if err := c.DiscardWorkspace(queuedRunning.ID); err != nil {
+		t.Errorf("任务结束后应允许丢弃工作区，实际 %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package i18n
+
+// enUS 英文消息目录
+var enUS = map[MessageID]string{
+	// 界面 (internal/ui)
+
+	// 窗口标题
+	"ui.window_title": "PDF Merger Tool",
+
+	// 按钮文本
+	"ui.browse_button":      "Browse...",
+	"ui.add_file_button":    "Add Files",
+	"ui.remove_file_button": "Remove Selected",
+	"ui.clear_files_button": "Clear All",
+	"ui.move_up_button":     "Move Up",
+	"ui.move_down_button":   "Move Down",
+	"ui.refresh_button":     "Refresh",
+	"ui.start_merge_button": "Start Merge",
+	"ui.cancel_button":      "Cancel",
+	"ui.close_button":       "Close",
+	"ui.maintenance_button": "Maintenance...",
+	"ui.settings_button":    "Settings...",
+	"ui.log_button":         "Log...",
+	"ui.save_button":        "Save",
+
+	// 标签文本
+	"ui.main_file_label":          "Main PDF File:",
+	"ui.additional_files_label":   "Additional PDF Files:",
+	"ui.output_path_label":        "Output Path:",
+	"ui.generate_toc_label":       "Insert table of contents page",
+	"ui.normalize_label":          "Bake page rotation into content (upright pages)",
+	"ui.profile_label":            "Profile:",
+	"ui.show_thumbnails_label":    "Show page thumbnails",
+	"ui.rotate_button_format":     "%d deg",
+	"ui.signature_badge":          "[Signed]",
+	"ui.warning_badge":            "[Warnings]",
+	"ui.page_size_format":         "%s %s, %d pages",
+	"ui.portrait":                 "portrait",
+	"ui.landscape":                "landscape",
+	"ui.mixed_page_sizes":         "Pages have different sizes",
+	"ui.no_files_label":           "No files",
+	"ui.progress_label":           "Progress:",
+	"ui.status_label":             "Status:",
+	"ui.main_file_heading":        "## Main PDF File",
+	"ui.additional_files_heading": "## Additional PDF Files",
+	"ui.output_heading":           "## Output File",
+	"ui.main_file_placeholder":    "Select the main PDF file...",
+	"ui.output_path_placeholder":  "Select the output file path...",
+	"ui.file_name_column":         "File Name",
+	"ui.file_size_column":         "Size",
+	"ui.file_status_column":       "Status",
+
+	// 状态消息
+	"ui.status_ready_text":      "Ready",
+	"ui.status_merging":         "Merging...",
+	"ui.status_completed_text":  "Completed",
+	"ui.status_cancelled_text":  "Cancelled",
+	"ui.status_error_text":      "Error",
+	"ui.status_processing_text": "Processing",
+	"ui.status_unknown_text":    "Unknown",
+	"ui.operation_failed_text":  "Operation failed",
+	"ui.cancelled_by_user_text": "Operation cancelled by user",
+	"ui.file_status_ok":         "OK",
+	"ui.file_status_error":      "Error",
+	"ui.file_status_invalid":    "Invalid",
+	"ui.file_status_encrypted":  "Encrypted",
+	"ui.file_status_unlocked":   "Unlocked",
+
+	// 对话框文本
+	"ui.select_main_file_title": "Select Main PDF File",
+	"ui.select_files_title":     "Select PDF Files",
+	"ui.select_output_title":    "Select Output Location",
+	"ui.error_dialog_title":     "Error",
+	"ui.info_dialog_title":      "Information",
+	"ui.success_dialog_title":   "Success",
+	"ui.select_cleanup_folder":  "Select Folder to Scan for Leftover Files",
+	"ui.cleanup_report_title":   "Leftover Files from Older Versions",
+	"ui.cleanup_none_found":     "No leftover files found.",
+	"ui.cleanup_confirm_button": "Quarantine",
+	"ui.cleanup_confirm_text":   "Move these files to the dated quarantine folder? Files whose content could not be verified are moved as well; nothing is deleted.",
+	"ui.cleanup_done_text":      "Moved %d file(s) to %s",
+
+	// 重复文件
+	"ui.duplicate_file_title":   "Duplicate File",
+	"ui.duplicate_file_confirm": "%s has the same content as %s, which is already in the list. Add it anyway?",
+
+	// 输出与输入相同
+	"ui.output_conflict_title":   "Output Is an Input File",
+	"ui.output_conflict_confirm": "The output path is the input file %s, which the merge would overwrite.\n\nSave to %s instead?",
+
+	// 加密文件
+	"ui.password_attempts_exceeded_text": "Wrong password (%d attempts)",
+
+	// 日志视图
+	"ui.log_title":      "Log",
+	"ui.log_empty_text": "No messages yet.",
+
+	// 维护面板
+	"ui.maintenance_title":           "Maintenance",
+	"ui.scan_legacy_button":          "Scan Folder for Leftover Files...",
+	"ui.workspaces_none_found":       "No job workspaces are retained on disk.",
+	"ui.workspaces_reclaimable_text": "%d job workspace(s), %s reclaimable",
+	"ui.workspace_resumable_text":    "(resumable)",
+	"ui.discard_workspace_button":    "Discard",
+	"ui.discard_workspace_confirm":   "Discard the workspace of job %s? It can no longer be resumed.",
+	"ui.temp_usage_text":             "Temporary files: %d, %s in total",
+	"ui.temp_quota_bytes_text":       ", limit %s",
+	"ui.temp_quota_files_text":       ", at most %d files",
+	"ui.vault_button":                "Password Vault...",
+
+	// 密码保险库
+	"ui.vault_title":            "Password Vault",
+	"ui.vault_passphrase_label": "Master passphrase",
+	"ui.vault_locked_text":      "Once unlocked, passwords of encrypted files are saved by file content and reused next time.",
+	"ui.vault_unlock_button":    "Unlock",
+	"ui.vault_empty_text":       "No passwords are saved in the vault.",
+	"ui.vault_entries_text":     "Passwords saved for %d file(s)",
+	"ui.vault_entry_text":       "%s  %s  last used %s",
+	"ui.vault_remove_button":    "Remove",
+	"ui.vault_purge_button":     "Purge Vault",
+	"ui.vault_purge_confirm":    "Delete all %d password(s) saved in the vault?",
+
+	// 上次运行中断的任务
+	"ui.interrupted_jobs_title":      "Interrupted Merges",
+	"ui.interrupted_jobs_text":       "%d merge(s) did not finish the last time the application ran.",
+	"ui.interrupted_job_text":        "%s (%d files), stopped at \"%s\" %s",
+	"ui.interrupted_missing_text":    "%d input file(s) no longer exist",
+	"ui.resume_interrupted_button":   "Resume",
+	"ui.clean_up_interrupted_button": "Clean Up",
+
+	// 会话恢复
+	"ui.restore_session_title":             "Restore Previous Session",
+	"ui.restore_session_text":              "Restore the files and output path from your last session?",
+	"ui.session_dropped_text":              "%d file(s) from the previous session no longer exist and were skipped:\n%s",
+	"ui.recent_output_folders_placeholder": "Recent output folders",
+
+	// 设置对话框
+	"ui.settings_title":              "Settings",
+	"ui.settings_unavailable":        "Settings are not available: no configuration file location.",
+	"ui.settings_temp_dir_label":     "Temporary Folder",
+	"ui.settings_output_dir_label":   "Default Output Folder",
+	"ui.settings_max_memory_label":   "Memory Limit (MB)",
+	"ui.settings_max_jobs_label":     "Concurrent Jobs",
+	"ui.settings_auto_decrypt_label": "Try Common Passwords",
+	"ui.settings_thumbnails_label":   "Show Page Thumbnails",
+	"ui.settings_system_default":     "System default",
+	"ui.settings_restart_hint":       "Takes effect after restart",
+	"ui.settings_invalid_number":     "%s must be a positive whole number",
+
+	// 文件过滤器
+	"ui.pdf_file_filter": "PDF Files (*.pdf)",
+
+	// 错误消息
+	"ui.error_no_main_file":   "Please select a main PDF file first",
+	"ui.error_no_files":       "Please add at least one PDF file",
+	"ui.error_invalid_file":   "Invalid PDF file",
+	"ui.error_merge_failed":   "Merge failed",
+	"ui.error_file_not_found": "File not found",
+
+	// 成功消息
+	"ui.success_merge_complete": "PDF files merged successfully!",
+
+	// 撤销合并
+	"ui.undo_merge_title":     "Undo merge (restore previous output)",
+	"ui.undo_merge_message":   "PDF files merged successfully!\n\nThe previous output was backed up (%s). Undo this merge and restore the previous output?",
+	"ui.undo_merge_done_text": "Restored the previous output: %s",
+
+	// 提示消息
+	"ui.hint_drop_files":       "Drag PDF files here or click Add Files button",
+	"ui.hint_select_main_file": "Please select a main PDF file as the base for merging",
+	"ui.hint_select_output":    "Please select the output file location",
+
+	// 拖放结果
+	"ui.drop_summary_text":    "Added %d, skipped %d non-PDF",
+	"ui.drop_duplicates_text": ", %d already in the list",
+
+	// 文件列表摘要
+	"ui.file_info_count_text":     "Files: %d",
+	"ui.file_info_valid_text":     " (valid: %d)",
+	"ui.file_info_encrypted_text": " (encrypted: %d)",
+	"ui.file_info_signed_text":    " (signed: %d, signatures become invalid after merging)",
+	"ui.file_info_pages_text":     ", total pages: %d",
+	"ui.file_info_size_text":      ", total size: %s",
+
+	// 合并进度
+	"ui.stage_validate_status":      "Validating",
+	"ui.stage_validate_detail":      "Validating PDF files...",
+	"ui.stage_prepare_status":       "Preparing",
+	"ui.stage_prepare_detail":       "Preparing the merge...",
+	"ui.stage_merge_status":         "Merging",
+	"ui.stage_merge_detail":         "Merging PDF files...",
+	"ui.stage_save_status":          "Saving",
+	"ui.stage_save_detail":          "Saving the merged file...",
+	"ui.stage_done_detail":          "Merge finished",
+	"ui.merge_complete_output_text": "PDF merge complete: %s",
+	"ui.elapsed_time_text":          "Elapsed: %s",
+	"ui.speed_text":                 "Speed: %.1f files/s",
+	"ui.throughput_text":            "Speed: %.1f MB/s",
+	"ui.remaining_time_text":        "About %s remaining",
+	"ui.processing_file_text":       "Processing: %s",
+	"ui.file_progress_text":         "Files: %d/%d",
+	"ui.completed_in_text":          "Done! Total time: %s",
+	"ui.duration_seconds_text":      "%.1fs",
+	"ui.duration_minutes_text":      "%.1fmin",
+	"ui.duration_hours_text":        "%.1fh",
+	"ui.age_days_text":              "%dd ago",
+	"ui.age_hours_text":             "%dh ago",
+	"ui.age_minutes_text":           "%dm ago",
+
+	// 合并失败时已完成的部分
+	"ui.failed_stage_text":     "Failed stage: %s",
+	"ui.partial_files_text":    "Validated files: %d, skipped files: %d",
+	"ui.completed_chunks_text": "Completed chunks: %d/%d",
+	"ui.warning_text":          "Warning: %s",
+
+	// 合并完成摘要
+	"ui.merge_summary_title":       "Merge complete",
+	"ui.summary_output_text":       "Output file: %s",
+	"ui.summary_pages_text":        "Total pages: %d",
+	"ui.summary_time_text":         "Processing time: %s",
+	"ui.summary_peak_memory_text":  "Peak memory: %s",
+	"ui.summary_not_measured_text": "not measured",
+	"ui.summary_skipped_heading":   "Skipped files (%d)",
+	"ui.summary_unknown_reason":    "Unknown reason",
+	"ui.summary_repaired_text":     "Merged after repair: %s",
+	"ui.summary_duplicate_text":    "%s has the same content as %s",
+	"ui.open_folder_button":        "Open containing folder",
+	"ui.copy_summary_button":       "Copy summary",
+	"ui.summary_copied_text":       "Copied",
+
+	// 遗留文件的可信度
+	"ui.legacy_verified_text":  "verified",
+	"ui.legacy_name_only_text": "name only",
+
+	// 错误提示 (pkg/pdf)
+	"pdf.error.invalid_file":        "The file format is invalid or the file is damaged",
+	"pdf.error.encrypted":           "The file is encrypted and requires a password",
+	"pdf.error.corrupted":           "The file is corrupted and cannot be processed",
+	"pdf.error.permission":          "No permission to access the file",
+	"pdf.error.memory":              "Out of memory; close other programs and try again",
+	"pdf.error.io":                  "File read/write error; check the available disk space",
+	"pdf.error.validation":          "PDF validation failed",
+	"pdf.error.processing":          "PDF processing failed",
+	"pdf.error.invalid_input":       "Invalid input parameters",
+	"pdf.error.limit_exceeded":      "Processing limit exceeded: the file may be damaged or maliciously crafted, or the merged result exceeds the size or page limit",
+	"pdf.error.checksum_mismatch":   "The file content does not match its checksum and may be damaged",
+	"pdf.error.adapter_unavailable": "The PDF processing backend is unavailable; these files cannot be merged",
+	"pdf.error.resource_limit":      "The file decompresses to too much data, nests objects too deeply or took too long to validate; it may be maliciously crafted",
+	"pdf.error.unknown":             "Unknown error",
+	"pdf.error.unknown_processing":  "An unknown error occurred during processing",
+	"pdf.error.with_file":           "%s (file: %s)",
+	"pdf.issues.more":               "%d more issue(s)",
+
+	// 命令行 (cmd/pdfmerger-cli)
+	"cli.version":           "PDF Merger Tool (command line) %s",
+	"cli.build_time":        "Build time: %s",
+	"cli.git_commit":        "Git commit: %s",
+	"cli.merge_start":       "Merging %d PDF files...",
+	"cli.output_file":       "Output file: %s",
+	"cli.progress":          "\rProgress: %d%% - %s: %s",
+	"cli.progress_estimate": " - %.1f MB/s - about %s remaining",
+	"cli.merged_to":         "Merge finished, output file: %s",
+	"cli.delta":             "Compared with the previous merge: %s",
+	"cli.merge_failed":      "Merge failed: %s",
+	"cli.failed_stage":      "Failed stage: %s",
+	"cli.partial_files":     "Validated files: %d, skipped files: %d",
+	"cli.completed_chunks":  "Completed chunks: %d/%d",
+	"cli.warning":           "Warning: %s",
+	"cli.skipped_files":     "Skipped files (%d):",
+	"cli.merge_partial":     "⚠️ PDF merge complete, but some input files were skipped",
+	"cli.merge_complete":    "✅ PDF merge complete!",
+	"cli.uploaded":          "Uploaded to %s",
+	"cli.usage": `PDF Merger Tool (command line)
+
+Usage:
+  pdf-merger-cli -input file1.pdf,file2.pdf,file3.pdf -output merged.pdf
+
+Options:
+  -input   Input PDF files, separated by commas (required); wildcards and directories are allowed, - reads from standard input (up to -max-memory)
+           @files.txt reads inputs from a list file: one path, wildcard or directory per line (UTF-8), blank lines and # comments
+           ignored, relative paths resolved against the list file's directory, optional page ranges in -pages syntax at the end
+           of a line, lists cannot reference other lists; when standard input is not a PDF, - reads the list from standard input
+  -recursive Include subdirectories of directory inputs
+  -sort    Sort expanded inputs by name, mtime or size (default: keep argument order)
+  -strict  Abort the merge with exit code 2 on invalid inputs or inputs with validation warnings (default: skip
+           invalid inputs with a warning and exit with code 5 after merging; inputs with only warnings are merged)
+  -timeout Maximum merge time, e.g. 30s or 10m; the merge is cancelled and exits non-zero when it expires
+  -max-output-size  Output size limit in MB; aborts when the inputs or the output being written exceed it
+  -max-output-pages Output page limit; aborts before merging when the inputs' pages add up to more
+  -output  Output PDF file (default: merged.pdf in the configured output folder); - writes to standard output, progress and logs then go to standard error;
+           s3://bucket/key validates locally, then uploads in parts to S3-compatible object storage, with credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN,
+           the region from AWS_REGION and the endpoint from AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL
+  -config  Configuration file (default: pdf-merger/config.json in the user configuration folder)
+  -profile Merge profile: the built-in default or low-memory, or one from Profiles in the configuration file (default: default);
+           a profile sets merge, streaming and writer options, and options given on the command line override its values
+  -max-memory Maximum memory used while merging, in MB
+  -max-concurrent Maximum number of chunks merged at the same time (default: number of CPUs)
+  -chunk-size Number of files per chunk in chunked merges; disables adaptive chunk sizing
+  -memory-limit Fraction of -max-memory (between 0 and 1) at which chunked merging pauses to free memory (default: 0.85)
+  -version Show version information
+  -help    Show this help
+  -json    Print the result as JSON (includes the partial result on failure)
+  -remote  Upload the inputs to the merge service at this address (POST /merge), print the job's events as NDJSON and download the result to -output (requires -json);
+           the access token is read from PDF_MERGER_SERVER_TOKEN
+  -serve   Run as an HTTP service: POST /merge uploads files, GET /jobs/<id> reports progress, GET /jobs/<id>/result downloads, DELETE /jobs/<id> cancels;
+           PDF_MERGER_SERVER_TOKEN sets the access token, uploads are limited by -max-memory, and the configured MaxConcurrentJobs jobs run at once
+  -linearize Linearize the output so web browsers can show it while downloading
+  -bookmarks Add a top-level bookmark for each input (titled with the document title, or the file name when there is none)
+  -toc       Insert a table of contents page listing each input's title and first page, with clickable entries; spans several pages for many inputs
+  -stamp     Add a page number centered at the bottom of every page; {page} is the page number, {pages} the page count, {filename} the source file name
+  -watermark Add semi-transparent diagonal watermark text in the center of every page; page numbers are drawn above the watermark
+  -nup      Scale every 2 or 4 pages down onto one output sheet without rasterizing; 2-up sheets swap width and height
+  -booklet  Arrange pages two per sheet in saddle-stitch order (last, first, second, second-to-last, ...), padding with blank pages to a multiple of 4
+  -nup-margin Margin around each cell when imposing, in points; -nup-order across (default) fills left to right, down fills top to bottom
+  -rotate   Rotate pages clockwise per file, e.g. scan.pdf=90,back.pdf=180; files can be given as paths or file names
+  -normalize-orientation Bake each page's /Rotate into its content and page boxes before merging, so output pages do not rely on /Rotate
+  -optimize  Optimize the output: share identical font programs and images, compress uncompressed streams, use object and cross-reference streams; skipped when inputs are digitally signed
+  -image-dpi Together with -optimize, downsample page images above this resolution to it
+  -extract-text After merging, write the output's text to this file page by page (pages separated by form feeds); image-only pages produce empty text and a warning
+  -flatten-forms Flatten form field appearances into page content and remove the forms; by default the inputs' forms are merged and clashing fields are renamed to name_2
+  -metadata Source of the output metadata: first (default) copies the first input's document info and XMP metadata, custom uses only the values given by -title etc., none leaves it unset
+  -title, -author, -subject, -keywords Override the corresponding output document info entries
+  -allow-signed Do not warn when merging digitally signed inputs; merging always invalidates input signatures
+  -encrypt-user  Encrypt the output; this password is required to open it
+  -encrypt-owner Owner password of the encrypted output (default: same as the user password)
+  -permissions   Operations allowed on the encrypted output: print,modify,copy,annotate,fill_forms,extract,assemble,print_high_quality or all/none
+  -pages   Merge only the given pages of each file, as file:ranges (N, N-M, N- separated by commas)
+  -extract Extract pages from a single input by page ranges (N, N-M, N- separated by commas)
+  -extract-images Write the images of a single input as JPEG or PNG files to -out (default: the input's folder); -image-pages 1-10 limits the pages,
+           soft masks become PNG transparency, small inline images (icons, rules) are skipped and images in unsupported formats are reported as warnings
+  -split   Split a file: -split-by pages (default) writes one file every -every pages, named name_001.pdf, name_002.pdf, ...;
+           -split-by bookmarks splits at each top-level bookmark and names files after the bookmark titles, pages before the first bookmark go to name_000.pdf;
+           files are written to -output-dir (default: the input's folder) and existing files are never overwritten
+  -decrypt Remove a file's encryption with -password and write it to -output (unencrypted files are copied as is)
+  -info    Show page count, version, encryption, permission summary, document info and size; with -json several files are printed as an array
+  -validate Validate files and list issues by severity with category, offset or object number and a suggested fix; exits with code 1 when a file is invalid
+  -workers With -validate, validate files in parallel with the given number of workers (e.g. many files from a folder or glob),
+           printing each file's result, error type and duration plus totals; on interrupt the finished part is printed, and the exit code is 1 when a file is invalid or was not validated
+  -manifest Write a merge manifest next to the output (output name.manifest.json) recording the tool version, options,
+           each input's SHA-256 and page count and the output hash; when a manifest from a previous merge exists, the summary
+           reports the inputs added, removed, changed or renamed since then and the change in page count and size
+  -verify  Recompute the output file's SHA-256 and compare it with the sidecar manifest written when merging (output name.manifest.json); exits with code 1 on a mismatch or a missing manifest
+  -mode interleave   Interleave the pages of two files (odd-pages file,even-pages file)
+  -reverse-second    Take the second file's pages in reverse when interleaving (for scanners that output back sides in reverse)
+  -watch   Watch a folder; once it has been quiet for -batch-window, merge its PDF files by modification time into -output-dir,
+           move merged inputs to the processed/ subfolder and write failed batches to a .log file named after the output
+  -output-dir   Output folder of -watch, with outputs named merged_date_time.pdf; also the output folder of -split
+  -batch-window How long the folder must be quiet before -watch merges (default: 30s)
+  -stable-time  How long a file's size must stay unchanged before -watch treats it as fully copied (default: 2s)
+  -dry-run Only check the inputs and report validity, encryption, page counts, estimated size and merge strategy
+  -backend-stats     Show success rates and throughput of each merge backend
+  -adaptive-backends Choose the backend order for each merge from the recorded statistics
+  -cleanup-legacy    Scan folders for files left behind by older versions; only reports by default
+  -cleanup-action    report (default), delete or quarantine
+  -cleanup-name-only Also quarantine files that only match by name (never deleted)
+  -yes               Do not ask for confirmation when cleaning up
+  -list-workspaces   List retained job workspaces and reclaimable space
+  -discard-workspace Delete a job's workspace (running or queued jobs are refused)
+  -temp-dir          Temporary folder containing the workspaces
+  -verbose           Write debug logs to standard error (default: only warnings and errors)
+  -vault        Password vault file
+  -vault-list   List password vault entries
+  -vault-purge  Empty the password vault
+  -vault-remove Remove a vault entry by content hash
+                (the master password is read from the %s environment variable; when it is set,
+                 merges and -decrypt use the passwords saved in the vault and save passwords that work)
+
+Configuration:
+  The temporary folder, output folder and memory limit are read from the configuration file at startup; a damaged file falls back to the defaults with a warning.
+  The environment variables %s, %s, %s and %s override the configuration file, and command line flags take precedence over both.
+  The language follows the Language setting in the configuration file, then LC_ALL, LC_MESSAGES and LANG (zh-CN or en-US).
+
+Exit codes:
+  0 merge complete; 1 invalid arguments; 2 inputs failed validation and nothing was merged (-strict, or fewer than
+  two valid inputs); 3 merge failed; 4 reading inputs, creating the output or writing the result failed;
+  5 merge completed after skipping inputs that failed validation
+
+Examples:
+  pdf-merger-cli -input doc1.pdf,doc2.pdf -output combined.pdf
+  pdf-merger-cli -input "*.pdf" -output all.pdf
+  cat a.pdf | pdf-merger-cli -input -,b.pdf -output - > merged.pdf
+  pdf-merger-cli -input scans -recursive -sort mtime -output all.pdf
+  pdf-merger-cli -input "*.pdf" -max-output-size 2048 -max-output-pages 10000 -output all.pdf
+  pdf-merger-cli -bookmarks -input contract_A.pdf,contract_B.pdf -output contracts.pdf
+  pdf-merger-cli -toc -input reports -sort name -output reports.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -stamp "Page {page} of {pages}" -watermark DRAFT -output review.pdf
+  pdf-merger-cli -input handout1.pdf,handout2.pdf -nup 2 -nup-margin 12 -output handouts.pdf
+  pdf-merger-cli -input scan1.pdf,scan2.pdf -rotate scan2.pdf=90 -normalize-orientation -output scans.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -optimize -image-dpi 150 -output small.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -extract-text merged.txt -output merged.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -encrypt-user secret -encrypt-owner admin -permissions print,copy -output locked.pdf
+  pdf-merger-cli -pages -input a.pdf:1-3,b.pdf:5,7,9- -output selected.pdf
+  pdf-merger-cli -input @chapters.txt -sort name -output book.pdf
+  find scans -name "*.pdf" | pdf-merger-cli -input - -output scans.pdf
+  pdf-merger-cli -input report.pdf -extract 1-5,8 -output excerpt.pdf
+  pdf-merger-cli -input scan.pdf -extract-images -image-pages 1-10 -out ./imgs
+  pdf-merger-cli -split big.pdf -every 50 -output-dir ./parts
+  pdf-merger-cli -split manual.pdf -split-by bookmarks -output-dir ./chapters
+  pdf-merger-cli -decrypt locked.pdf -password secret -output unlocked.pdf
+  pdf-merger-cli -json -info report.pdf,appendix.pdf
+  pdf-merger-cli -validate scans -recursive
+  pdf-merger-cli -validate "incoming/*.pdf" -workers 8 -json
+  pdf-merger-cli -dry-run -input doc1.pdf,doc2.pdf
+  pdf-merger-cli -watch ./inbox -output-dir ./merged -batch-window 30s
+  pdf-merger-cli -mode interleave -reverse-second -input odds.pdf,evens.pdf -output scan.pdf
+  pdf-merger-cli -version
+  pdf-merger-cli -json -remote http://localhost:8080 -input doc1.pdf,doc2.pdf -output merged.pdf
+  PDF_MERGER_SERVER_TOKEN=secret pdf-merger-cli -serve :8080
+  pdf-merger-cli -vault-list
+  pdf-merger-cli -backend-stats
+  pdf-merger-cli -cleanup-legacy ~/Documents -cleanup-action quarantine
+  pdf-merger-cli -discard-workspace <job-id>
+`,
+}
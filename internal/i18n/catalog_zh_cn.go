@@ -0,0 +1,411 @@
+package i18n
+
+// zhCN 简体中文消息目录
+var zhCN = map[MessageID]string{
+	// 界面 (internal/ui)
+
+	// 窗口标题
+	"ui.window_title": "PDF合并工具",
+
+	// 按钮文本
+	"ui.browse_button":      "浏览...",
+	"ui.add_file_button":    "添加文件",
+	"ui.remove_file_button": "移除选中",
+	"ui.clear_files_button": "清空",
+	"ui.move_up_button":     "上移",
+	"ui.move_down_button":   "下移",
+	"ui.refresh_button":     "刷新",
+	"ui.start_merge_button": "开始合并",
+	"ui.cancel_button":      "取消",
+	"ui.close_button":       "关闭",
+	"ui.maintenance_button": "维护...",
+	"ui.settings_button":    "设置...",
+	"ui.log_button":         "日志...",
+	"ui.save_button":        "保存",
+
+	// 标签文本
+	"ui.main_file_label":          "主PDF文件:",
+	"ui.additional_files_label":   "附加PDF文件:",
+	"ui.output_path_label":        "输出路径:",
+	"ui.generate_toc_label":       "插入目录页",
+	"ui.normalize_label":          "把页面旋转写入内容（页面保持正向）",
+	"ui.profile_label":            "合并方案:",
+	"ui.show_thumbnails_label":    "显示页面缩略图",
+	"ui.rotate_button_format":     "%d 度",
+	"ui.signature_badge":          "[已签名]",
+	"ui.warning_badge":            "[有警告]",
+	"ui.page_size_format":         "%s %s，%d 页",
+	"ui.portrait":                 "纵向",
+	"ui.landscape":                "横向",
+	"ui.mixed_page_sizes":         "包含不同尺寸的页面",
+	"ui.no_files_label":           "没有文件",
+	"ui.progress_label":           "进度:",
+	"ui.status_label":             "状态:",
+	"ui.main_file_heading":        "## 主PDF文件",
+	"ui.additional_files_heading": "## 附加PDF文件",
+	"ui.output_heading":           "## 输出文件",
+	"ui.main_file_placeholder":    "请选择主PDF文件...",
+	"ui.output_path_placeholder":  "请选择输出文件路径...",
+	"ui.file_name_column":         "文件名",
+	"ui.file_size_column":         "大小",
+	"ui.file_status_column":       "状态",
+
+	// 状态消息
+	"ui.status_ready_text":      "准备就绪",
+	"ui.status_merging":         "正在合并...",
+	"ui.status_completed_text":  "完成",
+	"ui.status_cancelled_text":  "已取消",
+	"ui.status_error_text":      "错误",
+	"ui.status_processing_text": "正在处理",
+	"ui.status_unknown_text":    "未知状态",
+	"ui.operation_failed_text":  "操作失败",
+	"ui.cancelled_by_user_text": "操作已被用户取消",
+	"ui.file_status_ok":         "正常",
+	"ui.file_status_error":      "错误",
+	"ui.file_status_invalid":    "无效",
+	"ui.file_status_encrypted":  "已加密",
+	"ui.file_status_unlocked":   "已解锁",
+
+	// 对话框文本
+	"ui.select_main_file_title": "选择主PDF文件",
+	"ui.select_files_title":     "选择PDF文件",
+	"ui.select_output_title":    "选择输出位置",
+	"ui.error_dialog_title":     "错误",
+	"ui.info_dialog_title":      "信息",
+	"ui.success_dialog_title":   "成功",
+	"ui.select_cleanup_folder":  "选择要扫描遗留文件的文件夹",
+	"ui.cleanup_report_title":   "旧版本遗留的文件",
+	"ui.cleanup_none_found":     "没有找到遗留文件。",
+	"ui.cleanup_confirm_button": "隔离",
+	"ui.cleanup_confirm_text":   "把这些文件移到按日期命名的隔离目录？无法确认内容的文件也会移动，不会删除任何文件。",
+	"ui.cleanup_done_text":      "已把 %d 个文件移到 %s",
+
+	// 重复文件
+	"ui.duplicate_file_title":   "重复文件",
+	"ui.duplicate_file_confirm": "%s 与列表中的 %s 内容相同，仍然添加吗？",
+
+	// 输出与输入相同
+	"ui.output_conflict_title":   "输出文件与输入相同",
+	"ui.output_conflict_confirm": "输出路径就是输入文件 %s，合并会覆盖该文件。\n\n改为保存到 %s 吗？",
+
+	// 加密文件
+	"ui.password_attempts_exceeded_text": "密码错误（已尝试 %d 次）",
+
+	// 日志视图
+	"ui.log_title":      "日志",
+	"ui.log_empty_text": "还没有消息。",
+
+	// 维护面板
+	"ui.maintenance_title":           "维护",
+	"ui.scan_legacy_button":          "扫描文件夹中的遗留文件...",
+	"ui.workspaces_none_found":       "磁盘上没有保留的任务工作区。",
+	"ui.workspaces_reclaimable_text": "%d 个任务工作区，可回收 %s",
+	"ui.workspace_resumable_text":    "（可恢复）",
+	"ui.discard_workspace_button":    "丢弃",
+	"ui.discard_workspace_confirm":   "丢弃任务 %s 的工作区？丢弃后无法再恢复该任务。",
+	"ui.temp_usage_text":             "临时文件: %d 个，共 %s",
+	"ui.temp_quota_bytes_text":       "，上限 %s",
+	"ui.temp_quota_files_text":       "，最多 %d 个",
+	"ui.vault_button":                "密码保险库...",
+
+	// 密码保险库
+	"ui.vault_title":            "密码保险库",
+	"ui.vault_passphrase_label": "主密码",
+	"ui.vault_locked_text":      "打开后，加密文件的密码按文件内容保存，下次自动使用。",
+	"ui.vault_unlock_button":    "打开",
+	"ui.vault_empty_text":       "保险库中没有保存的密码。",
+	"ui.vault_entries_text":     "保存了 %d 个文件的密码",
+	"ui.vault_entry_text":       "%s  %s  上次使用：%s",
+	"ui.vault_remove_button":    "删除",
+	"ui.vault_purge_button":     "清空保险库",
+	"ui.vault_purge_confirm":    "删除保险库中保存的全部 %d 个密码？",
+
+	// 上次运行中断的任务
+	"ui.interrupted_jobs_title":      "中断的合并",
+	"ui.interrupted_jobs_text":       "上次运行时有 %d 个合并没有完成。",
+	"ui.interrupted_job_text":        "%s（%d 个文件），停在“%s” %s",
+	"ui.interrupted_missing_text":    "%d 个输入文件已不存在",
+	"ui.resume_interrupted_button":   "恢复",
+	"ui.clean_up_interrupted_button": "清理",
+
+	// 会话恢复
+	"ui.restore_session_title":             "恢复上次的会话",
+	"ui.restore_session_text":              "恢复上次会话中的文件和输出路径吗？",
+	"ui.session_dropped_text":              "上次会话中有 %d 个文件已不存在，已跳过:\n%s",
+	"ui.recent_output_folders_placeholder": "最近的输出目录",
+
+	// 设置对话框
+	"ui.settings_title":              "设置",
+	"ui.settings_unavailable":        "设置不可用：无法确定配置文件位置。",
+	"ui.settings_temp_dir_label":     "临时目录",
+	"ui.settings_output_dir_label":   "默认输出目录",
+	"ui.settings_max_memory_label":   "内存上限 (MB)",
+	"ui.settings_max_jobs_label":     "同时运行的任务数",
+	"ui.settings_auto_decrypt_label": "尝试常用密码",
+	"ui.settings_thumbnails_label":   "显示页面缩略图",
+	"ui.settings_system_default":     "系统默认",
+	"ui.settings_restart_hint":       "重启后生效",
+	"ui.settings_invalid_number":     "%s 必须是正整数",
+
+	// 文件过滤器
+	"ui.pdf_file_filter": "PDF文件 (*.pdf)",
+
+	// 错误消息
+	"ui.error_no_main_file":   "请先选择主PDF文件",
+	"ui.error_no_files":       "请至少添加一个PDF文件",
+	"ui.error_invalid_file":   "无效的PDF文件",
+	"ui.error_merge_failed":   "合并失败",
+	"ui.error_file_not_found": "文件不存在",
+
+	// 成功消息
+	"ui.success_merge_complete": "PDF文件合并完成！",
+
+	// 撤销合并
+	"ui.undo_merge_title":     "撤销合并（恢复之前的输出）",
+	"ui.undo_merge_message":   "PDF文件合并完成！\n\n合并前的输出已备份（%s）。是否撤销本次合并并恢复之前的输出？",
+	"ui.undo_merge_done_text": "已恢复之前的输出: %s",
+
+	// 提示消息
+	"ui.hint_drop_files":       "把PDF文件拖到这里，或点击“添加文件”",
+	"ui.hint_select_main_file": "请选择作为合并基础的主PDF文件",
+	"ui.hint_select_output":    "请选择输出文件位置",
+
+	// 拖放结果
+	"ui.drop_summary_text":    "已添加 %d 个，跳过 %d 个非PDF文件",
+	"ui.drop_duplicates_text": "，%d 个已在列表中",
+
+	// 文件列表摘要
+	"ui.file_info_count_text":     "文件: %d个",
+	"ui.file_info_valid_text":     " (有效: %d个)",
+	"ui.file_info_encrypted_text": " (加密: %d个)",
+	"ui.file_info_signed_text":    " (签名: %d个，合并后失效)",
+	"ui.file_info_pages_text":     ", 总页数: %d页",
+	"ui.file_info_size_text":      ", 总大小: %s",
+
+	// 合并进度
+	"ui.stage_validate_status":      "验证文件",
+	"ui.stage_validate_detail":      "正在验证PDF文件...",
+	"ui.stage_prepare_status":       "准备合并",
+	"ui.stage_prepare_detail":       "正在准备合并操作...",
+	"ui.stage_merge_status":         "合并文件",
+	"ui.stage_merge_detail":         "正在合并PDF文件...",
+	"ui.stage_save_status":          "保存文件",
+	"ui.stage_save_detail":          "正在保存合并后的文件...",
+	"ui.stage_done_detail":          "合并操作已完成",
+	"ui.merge_complete_output_text": "PDF合并完成: %s",
+	"ui.elapsed_time_text":          "已用时: %s",
+	"ui.speed_text":                 "速度: %.1f 文件/秒",
+	"ui.throughput_text":            "速度: %.1f MB/秒",
+	"ui.remaining_time_text":        "预计剩余 %s",
+	"ui.processing_file_text":       "正在处理: %s",
+	"ui.file_progress_text":         "文件进度: %d/%d",
+	"ui.completed_in_text":          "完成！总用时: %s",
+	"ui.duration_seconds_text":      "%.1f秒",
+	"ui.duration_minutes_text":      "%.1f分钟",
+	"ui.duration_hours_text":        "%.1f小时",
+	"ui.age_days_text":              "%d天前",
+	"ui.age_hours_text":             "%d小时前",
+	"ui.age_minutes_text":           "%d分钟前",
+
+	// 合并失败时已完成的部分
+	"ui.failed_stage_text":     "失败阶段: %s",
+	"ui.partial_files_text":    "已验证文件: %d，跳过文件: %d",
+	"ui.completed_chunks_text": "已完成分块: %d/%d",
+	"ui.warning_text":          "警告: %s",
+
+	// 合并完成摘要
+	"ui.merge_summary_title":       "合并完成",
+	"ui.summary_output_text":       "输出文件: %s",
+	"ui.summary_pages_text":        "总页数: %d",
+	"ui.summary_time_text":         "处理时间: %s",
+	"ui.summary_peak_memory_text":  "峰值内存: %s",
+	"ui.summary_not_measured_text": "未测量",
+	"ui.summary_skipped_heading":   "跳过的文件 (%d)",
+	"ui.summary_unknown_reason":    "原因未知",
+	"ui.summary_repaired_text":     "已修复后合并: %s",
+	"ui.summary_duplicate_text":    "%s 与 %s 内容相同",
+	"ui.open_folder_button":        "打开所在文件夹",
+	"ui.copy_summary_button":       "复制摘要",
+	"ui.summary_copied_text":       "已复制",
+
+	// 遗留文件的可信度
+	"ui.legacy_verified_text":  "已确认",
+	"ui.legacy_name_only_text": "仅文件名",
+
+	// 错误提示 (pkg/pdf)
+	"pdf.error.invalid_file":        "文件格式无效或已损坏",
+	"pdf.error.encrypted":           "文件已加密，需要密码",
+	"pdf.error.corrupted":           "文件已损坏，无法处理",
+	"pdf.error.permission":          "没有访问文件的权限",
+	"pdf.error.memory":              "内存不足，请关闭其他程序后重试",
+	"pdf.error.io":                  "文件读写错误，请检查磁盘空间",
+	"pdf.error.validation":          "PDF文件验证失败",
+	"pdf.error.processing":          "PDF文件处理失败",
+	"pdf.error.invalid_input":       "输入参数无效",
+	"pdf.error.limit_exceeded":      "超出处理限制：文件可能已损坏或被恶意构造，或合并结果超出了大小、页数上限",
+	"pdf.error.checksum_mismatch":   "文件内容与校验和不一致，可能已损坏",
+	"pdf.error.adapter_unavailable": "PDF处理后端不可用，无法合并这些文件",
+	"pdf.error.resource_limit":      "文件解压后过大、对象嵌套过深或验证超时，可能被恶意构造",
+	"pdf.error.unknown":             "未知错误",
+	"pdf.error.unknown_processing":  "处理过程中发生未知错误",
+	"pdf.error.with_file":           "%s (文件: %s)",
+	"pdf.issues.more":               "另有 %d 个问题",
+
+	// 命令行 (cmd/pdfmerger-cli)
+	"cli.version":           "PDF合并工具 (命令行版本) %s",
+	"cli.build_time":        "构建时间: %s",
+	"cli.git_commit":        "Git提交: %s",
+	"cli.merge_start":       "开始合并 %d 个PDF文件...",
+	"cli.output_file":       "输出文件: %s",
+	"cli.progress":          "\r进度: %d%% - %s: %s",
+	"cli.progress_estimate": " - %.1f MB/秒 - 预计剩余 %s",
+	"cli.merged_to":         "合并完成，输出文件: %s",
+	"cli.delta":             "与上次合并相比: %s",
+	"cli.merge_failed":      "合并失败: %s",
+	"cli.failed_stage":      "失败阶段: %s",
+	"cli.partial_files":     "已验证文件: %d，跳过文件: %d",
+	"cli.completed_chunks":  "已完成分块: %d/%d",
+	"cli.warning":           "警告: %s",
+	"cli.skipped_files":     "跳过的文件 (%d):",
+	"cli.merge_partial":     "⚠️ PDF合并完成，但跳过了部分输入文件",
+	"cli.merge_complete":    "✅ PDF合并完成！",
+	"cli.uploaded":          "已上传到 %s",
+	"cli.usage": `PDF合并工具 (命令行版本)
+
+用法:
+  pdf-merger-cli -input file1.pdf,file2.pdf,file3.pdf -output merged.pdf
+
+选项:
+  -input   输入PDF文件路径，用逗号分隔 (必需)；支持通配符和目录，- 表示从标准输入读取 (大小上限为 -max-memory)
+           @files.txt 从列表文件读取输入：每行一个路径、通配符或目录 (UTF-8)，忽略空行和 # 注释，相对路径按列表文件所在目录解析，
+           行尾可用 -pages 的语法指定页码范围，列表中不能再引用列表文件；标准输入不是PDF时 - 从标准输入读取列表
+  -recursive 目录输入包含子目录
+  -sort    展开后的输入按 name、mtime 或 size 排序 (默认保持参数顺序)
+  -strict  遇到无效或有验证警告的输入时中止合并并以退出码 2 退出 (默认跳过无效输入并警告，
+           合并完成后以退出码 5 退出；只有警告的输入照常合并)
+  -timeout 合并的最长时间，例如 30s、10m，超时后取消合并并以非零退出码退出
+  -max-output-size  输出大小上限，单位MB；输入之和或合并中的输出超出时中止
+  -max-output-pages 输出页数上限；输入页数之和超出时在合并前中止
+  -output  输出PDF文件路径 (默认: 配置的输出目录下的 merged.pdf)；- 表示写到标准输出，此时进度和日志写到标准错误；
+           s3://bucket/key 在本地验证后分段上传到S3兼容的对象存储，凭证取 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、AWS_SESSION_TOKEN，
+           区域取 AWS_REGION，端点取 AWS_ENDPOINT_URL_S3 或 AWS_ENDPOINT_URL
+  -config  配置文件路径 (默认: 用户配置目录下的 pdf-merger/config.json)
+  -profile 合并配置方案：内置的 default、low-memory 或配置文件 Profiles 中的方案 (默认: default)；
+           方案设置合并、流式处理和写入选项，命令行中指定的选项优先于方案中的值
+  -max-memory 合并时的最大内存使用量，单位MB
+  -max-concurrent 同时合并的分块数上限 (默认: CPU核数)
+  -chunk-size 分块合并时每个分块的文件数，指定后不再按文件大小和内存自适应调整
+  -memory-limit 内存使用达到 -max-memory 的该比例（0到1之间）时暂停分块合并并清理内存 (默认: 0.85)
+  -version 显示版本信息
+  -help    显示此帮助信息
+  -json    以JSON格式输出结果（失败时包含部分结果）
+  -remote  把输入上传到指定地址的合并服务（POST /merge），以NDJSON输出任务事件，完成后下载结果到 -output（需配合 -json）；
+           访问令牌取自环境变量 PDF_MERGER_SERVER_TOKEN
+  -serve   以HTTP服务运行：POST /merge 上传文件，GET /jobs/<id> 查询进度，GET /jobs/<id>/result 下载，DELETE /jobs/<id> 取消；
+           环境变量 PDF_MERGER_SERVER_TOKEN 设置访问令牌，上传大小受 -max-memory 限制，同时运行的任务数取配置的 MaxConcurrentJobs
+  -linearize 线性化输出文件，便于网页边下载边显示
+  -bookmarks 为每个输入文件添加顶层书签（标题取文档标题，没有时使用文件名）
+  -toc       在输出开头插入目录页，列出各输入的标题和起始页，点击条目跳转；输入较多时分为多页
+  -stamp     在每页底部居中添加页码，{page} 为页码，{pages} 为总页数，{filename} 为该页来源文件名
+  -watermark 在每页中心斜向添加半透明水印文字；同时使用时页码位于水印之上
+  -nup      把每 2 或 4 个页面等比缩小排列到一张输出页上，不重新栅格化；2-up 输出页横竖对调
+  -booklet  按骑马钉印刷的顺序（最后、第一、第二、倒数第二……）两两排列页面，末尾补空白页到4的倍数
+  -nup-margin 拼版时每个格子四周的边距（点）；-nup-order across（默认）先从左到右，down 先从上到下
+  -rotate   按文件顺时针旋转页面，例如 scan.pdf=90,back.pdf=180；文件可写路径或文件名
+  -normalize-orientation 合并前把页面的 /Rotate 写入页面内容并调整页面框，输出页面不依赖 /Rotate
+  -optimize  优化输出：合并相同的字体程序和图像，压缩未压缩的流，使用对象流和交叉引用流；输入含数字签名时跳过
+  -image-dpi 配合 -optimize 把页面上分辨率高于该值的图像降采样到该值
+  -extract-text 合并后把输出的文字逐页写到指定文件（页之间以换页符分隔）；只有图像的页面写出空文字并输出警告
+  -flatten-forms 把表单字段的外观展平到页面内容并移除表单；默认合并各输入的表单，重名的字段改名为 name_2
+  -metadata 输出元数据的来源: first（默认）复制第一个输入的文档信息和XMP元数据，custom 只使用 -title 等指定的值，none 不设置
+  -title、-author、-subject、-keywords 覆盖输出文档信息中的对应项
+  -allow-signed 合并包含数字签名的输入时不输出警告；合并总会使输入的签名失效
+  -encrypt-user  加密输出，打开文件需要此密码
+  -encrypt-owner 加密输出的所有者密码（默认与用户密码相同）
+  -permissions   加密输出允许的操作: print,modify,copy,annotate,fill_forms,extract,assemble,print_high_quality 或 all/none
+  -pages   按 文件:页码范围 只合并每个文件的指定页面（N、N-M、N- 用逗号分隔）
+  -extract 从单个输入文件中按页码范围提取页面（N、N-M、N- 用逗号分隔）
+  -extract-images 把单个输入文件中的图像写为JPEG或PNG文件到 -out（默认为输入所在目录）；-image-pages 1-10 只提取指定页面，
+           软蒙版合成为PNG的透明通道，跳过图标、线条等小的内联图像，不支持格式的图像输出警告
+  -split   拆分文件: -split-by pages（默认）每 -every 页一个文件，命名为 文件名_001.pdf、文件名_002.pdf……；
+           -split-by bookmarks 在每个顶层书签处拆分并以书签标题命名，第一个书签之前的页面写入 文件名_000.pdf；
+           输出写入 -output-dir（默认为输入所在目录），不覆盖已有文件
+  -decrypt 用 -password 移除文件的加密并写出到 -output（未加密的文件直接复制）
+  -info    显示文件的页数、版本、加密、权限摘要、文档信息和大小；配合 -json 时多个文件输出为数组
+  -validate 验证文件，按严重程度列出问题的类别、偏移或对象编号以及修复建议；有无效文件时退出码为 1
+  -workers 配合 -validate 用指定数量的工作协程并行验证（例如目录或通配符下的大量文件），
+           输出每个文件的结果、错误类型和耗时以及汇总；中断时输出已完成部分，有无效或未验证的文件时退出码为 1
+  -manifest 在输出旁写出合并清单 (输出文件名.manifest.json)，记录工具版本、选项、各输入的SHA-256和页数以及输出的哈希；
+           输出旁已有清单时在摘要中报告与上次合并相比新增、移除、内容变更和改名的输入以及页数和大小的变化
+  -verify  重新计算输出文件的SHA-256，与合并时写出的旁路清单 (输出文件名.manifest.json) 比对；不一致或没有清单时退出码为 1
+  -mode interleave   交替合并两个文件的页面（奇数页文件,偶数页文件）
+  -reverse-second    交替合并时第二个文件倒序取页（扫描仪倒序输出背面时使用）
+  -watch   监视目录，目录安静 -batch-window 后按修改时间合并其中的PDF文件到 -output-dir，
+           合并了的输入移到 processed/ 子目录，失败的批次写入与输出同名的 .log 文件
+  -output-dir   -watch 模式的输出目录，输出名为 merged_日期_时间.pdf；也是 -split 的输出目录
+  -batch-window -watch 模式中目录安静多久后合并 (默认: 30s)
+  -stable-time  -watch 模式中文件大小不变多久后才认为已复制完成 (默认: 2s)
+  -dry-run 只检查输入文件，报告有效性、加密、页数、预计大小和合并策略
+  -backend-stats     显示各合并后端的成功率和吞吐量统计
+  -adaptive-backends 按历史统计为每次合并选择后端顺序
+  -cleanup-legacy    扫描目录中旧版本遗留的文件，默认只输出报告
+  -cleanup-action    report (默认)、delete 或 quarantine
+  -cleanup-name-only 同时隔离仅文件名匹配的文件（从不删除）
+  -yes               清理时不再询问确认
+  -list-workspaces   列出保留的任务工作区及可回收空间
+  -discard-workspace 删除指定任务的工作区（运行或排队中的任务会被拒绝）
+  -temp-dir          工作区所在的临时目录
+  -verbose           把调试日志输出到标准错误（默认只输出警告和错误）
+  -vault        密码保险库路径
+  -vault-list   列出密码保险库条目
+  -vault-purge  清空密码保险库
+  -vault-remove 按内容哈希删除保险库条目
+                (主密码通过环境变量 %s 提供；设置后合并和 -decrypt 使用保险库中保存的密码，
+                 解密成功的密码保存到保险库)
+
+配置:
+  启动时读取配置文件中的临时目录、输出目录和内存上限，文件损坏时使用默认配置并给出警告。
+  环境变量 %s、%s、%s、%s 覆盖配置文件，命令行参数优先于两者。
+  界面和输出的语言按配置文件中的 Language、LC_ALL、LC_MESSAGES、LANG 的顺序选择 (zh-CN 或 en-US)。
+
+退出码:
+  0 合并完成；1 参数错误；2 输入未通过验证，没有合并 (-strict 或有效输入不足两个)；3 合并失败；
+  4 读取输入、创建输出或写出结果失败；5 跳过未通过验证的输入后完成合并
+
+示例:
+  pdf-merger-cli -input doc1.pdf,doc2.pdf -output combined.pdf
+  pdf-merger-cli -input "*.pdf" -output all.pdf
+  cat a.pdf | pdf-merger-cli -input -,b.pdf -output - > merged.pdf
+  pdf-merger-cli -input scans -recursive -sort mtime -output all.pdf
+  pdf-merger-cli -input "*.pdf" -max-output-size 2048 -max-output-pages 10000 -output all.pdf
+  pdf-merger-cli -bookmarks -input contract_A.pdf,contract_B.pdf -output contracts.pdf
+  pdf-merger-cli -toc -input reports -sort name -output reports.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -stamp "Page {page} of {pages}" -watermark DRAFT -output review.pdf
+  pdf-merger-cli -input handout1.pdf,handout2.pdf -nup 2 -nup-margin 12 -output handouts.pdf
+  pdf-merger-cli -input scan1.pdf,scan2.pdf -rotate scan2.pdf=90 -normalize-orientation -output scans.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -optimize -image-dpi 150 -output small.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -extract-text merged.txt -output merged.pdf
+  pdf-merger-cli -input a.pdf,b.pdf -encrypt-user secret -encrypt-owner admin -permissions print,copy -output locked.pdf
+  pdf-merger-cli -pages -input a.pdf:1-3,b.pdf:5,7,9- -output selected.pdf
+  pdf-merger-cli -input @chapters.txt -sort name -output book.pdf
+  find scans -name "*.pdf" | pdf-merger-cli -input - -output scans.pdf
+  pdf-merger-cli -input report.pdf -extract 1-5,8 -output excerpt.pdf
+  pdf-merger-cli -input scan.pdf -extract-images -image-pages 1-10 -out ./imgs
+  pdf-merger-cli -split big.pdf -every 50 -output-dir ./parts
+  pdf-merger-cli -split manual.pdf -split-by bookmarks -output-dir ./chapters
+  pdf-merger-cli -decrypt locked.pdf -password secret -output unlocked.pdf
+  pdf-merger-cli -json -info report.pdf,appendix.pdf
+  pdf-merger-cli -validate scans -recursive
+  pdf-merger-cli -validate "incoming/*.pdf" -workers 8 -json
+  pdf-merger-cli -dry-run -input doc1.pdf,doc2.pdf
+  pdf-merger-cli -watch ./inbox -output-dir ./merged -batch-window 30s
+  pdf-merger-cli -mode interleave -reverse-second -input odds.pdf,evens.pdf -output scan.pdf
+  pdf-merger-cli -version
+  pdf-merger-cli -json -remote http://localhost:8080 -input doc1.pdf,doc2.pdf -output merged.pdf
+  PDF_MERGER_SERVER_TOKEN=secret pdf-merger-cli -serve :8080
+  pdf-merger-cli -vault-list
+  pdf-merger-cli -backend-stats
+  pdf-merger-cli -cleanup-legacy ~/Documents -cleanup-action quarantine
+  pdf-merger-cli -discard-workspace <任务ID>
+`,
+}
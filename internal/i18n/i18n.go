@@ -0,0 +1,117 @@
+// Package i18n 提供界面、错误提示和命令行输出使用的消息目录。
+// 消息按字符串ID查找，每种语言一份翻译；当前语言是进程级设置，启动时按配置或环境变量选择。
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Locale 语言标识，如 zh-CN、en-US
+type Locale string
+
+const (
+	// ZhCN 简体中文
+	ZhCN Locale = "zh-CN"
+	// EnUS 美国英语
+	EnUS Locale = "en-US"
+
+	// DefaultLocale 没有设置语言、或配置和环境变量都无法识别时使用的语言
+	DefaultLocale = ZhCN
+)
+
+// localeEnvVars 按优先级检查的语言环境变量
+var localeEnvVars = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+// MessageID 消息目录中的键，由使用消息的包定义，如 "ui.browse_button"
+type MessageID string
+
+// catalogs 每种语言的翻译
+var catalogs = map[Locale]map[MessageID]string{
+	ZhCN: zhCN,
+	EnUS: enUS,
+}
+
+var (
+	currentMu sync.RWMutex
+	current   = DefaultLocale
+)
+
+// SetLocale 设置之后查找消息使用的语言，不支持的语言被忽略
+func SetLocale(locale Locale) {
+	if _, ok := catalogs[locale]; !ok {
+		return
+	}
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = locale
+}
+
+// CurrentLocale 返回当前语言
+func CurrentLocale() Locale {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
+
+// T 返回当前语言的消息，有参数时按 fmt.Sprintf 格式化
+func T(id MessageID, args ...interface{}) string {
+	return Translate(CurrentLocale(), id, args...)
+}
+
+// Translate 返回指定语言的消息，有参数时按 fmt.Sprintf 格式化。
+// 该语言没有翻译时使用 DefaultLocale 的消息，都没有时返回ID本身
+func Translate(locale Locale, id MessageID, args ...interface{}) string {
+	message, ok := catalogs[locale][id]
+	if !ok {
+		if message, ok = catalogs[DefaultLocale][id]; !ok {
+			message = string(id)
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Has 判断语言的目录中是否有该消息
+func Has(locale Locale, id MessageID) bool {
+	_, ok := catalogs[locale][id]
+	return ok
+}
+
+// ParseLocale 识别配置或环境变量中的语言，如 zh-CN、zh_CN.UTF-8、en_US、C。
+// 中文和英文的各个地区分别使用 ZhCN 和 EnUS；C 和 POSIX 视为英文
+func ParseLocale(value string) (Locale, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	value = strings.ReplaceAll(value, "_", "-")
+
+	switch {
+	case value == "":
+		return "", false
+	case value == "zh" || strings.HasPrefix(value, "zh-"):
+		return ZhCN, true
+	case value == "en" || strings.HasPrefix(value, "en-"), value == "c", value == "posix":
+		return EnUS, true
+	default:
+		return "", false
+	}
+}
+
+// ResolveLocale 选择语言：优先使用配置的语言，其次是 LC_ALL、LC_MESSAGES、LANG 中第一个能识别的值，
+// 都没有时返回 DefaultLocale
+func ResolveLocale(configured string, getenv func(string) string) Locale {
+	if locale, ok := ParseLocale(configured); ok {
+		return locale
+	}
+	for _, name := range localeEnvVars {
+		if locale, ok := ParseLocale(getenv(name)); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+// formatVerbs 匹配消息中的格式化动词
+var formatVerbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogs_SameKeysAndVerbs(t *testing.T) {
+	for id, zh := range zhCN {
+		en, ok := enUS[id]
+		if !ok {
+			t.Errorf("Message %s has no en-US translation", id)
+			continue
+		}
+		zhVerbs := formatVerbs.FindAllString(zh, -1)
+		enVerbs := formatVerbs.FindAllString(en, -1)
+		if len(zhVerbs) != len(enVerbs) {
+			t.Errorf("Message %s: expected the same format verbs, got %v and %v", id, zhVerbs, enVerbs)
+			continue
+		}
+		for i := range zhVerbs {
+			if zhVerbs[i] != enVerbs[i] {
+				t.Errorf("Message %s: expected the same format verbs, got %v and %v", id, zhVerbs, enVerbs)
+				break
+			}
+		}
+	}
+	for id := range enUS {
+		if _, ok := zhCN[id]; !ok {
+			t.Errorf("Message %s has no zh-CN translation", id)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Translate(EnUS, "ui.browse_button"); got != "Browse..." {
+		t.Errorf("Expected en-US text, got %q", got)
+	}
+	if got := Translate(ZhCN, "ui.drop_summary_text", 2, 1); got != "已添加 2 个，跳过 1 个非PDF文件" {
+		t.Errorf("Expected formatted zh-CN text, got %q", got)
+	}
+	if got := Translate(EnUS, "no.such.message"); got != "no.such.message" {
+		t.Errorf("Expected unknown message to return its ID, got %q", got)
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	original := CurrentLocale()
+	t.Cleanup(func() { SetLocale(original) })
+
+	SetLocale(EnUS)
+	if got := T("ui.cancel_button"); got != "Cancel" {
+		t.Errorf("Expected en-US text after SetLocale, got %q", got)
+	}
+	SetLocale("fr-FR")
+	if CurrentLocale() != EnUS {
+		t.Errorf("Expected unsupported locale to be ignored, got %s", CurrentLocale())
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Locale
+		ok    bool
+	}{
+		{"zh-CN", ZhCN, true},
+		{"zh_CN.UTF-8", ZhCN, true},
+		{"zh_TW", ZhCN, true},
+		{"en_US.UTF-8", EnUS, true},
+		{"en-GB", EnUS, true},
+		{"C", EnUS, true},
+		{"POSIX", EnUS, true},
+		{"de_DE@euro", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLocale(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLocale(%q) = %s, %v; expected %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	if got := ResolveLocale("en-US", env(map[string]string{"LANG": "zh_CN.UTF-8"})); got != EnUS {
+		t.Errorf("Expected configured language to win, got %s", got)
+	}
+	if got := ResolveLocale("", env(map[string]string{"LC_ALL": "de_DE", "LANG": "en_US.UTF-8"})); got != EnUS {
+		t.Errorf("Expected first recognized environment value, got %s", got)
+	}
+	if got := ResolveLocale("", env(map[string]string{"LC_MESSAGES": "zh_CN", "LANG": "en_US"})); got != ZhCN {
+		t.Errorf("Expected LC_MESSAGES before LANG, got %s", got)
+	}
+	if got := ResolveLocale("", env(nil)); got != DefaultLocale {
+		t.Errorf("Expected default locale, got %s", got)
+	}
+}
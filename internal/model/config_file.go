@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// ConfigDirName 用户配置目录下存放本程序配置的子目录
+	ConfigDirName = "pdf-merger"
+	// ConfigFileName 配置文件名
+	ConfigFileName = "config.json"
+)
+
+// 覆盖配置文件的环境变量
+const (
+	EnvConfigPath  = "PDF_MERGER_CONFIG"        // 配置文件路径
+	EnvTempDir     = "PDF_MERGER_TEMP_DIR"      // Config.TempDirectory
+	EnvOutputDir   = "PDF_MERGER_OUTPUT_DIR"    // Config.OutputDirectory
+	EnvMaxMemoryMB = "PDF_MERGER_MAX_MEMORY_MB" // Config.MaxMemoryUsage，单位MB
+)
+
+// ResolveConfigPath 返回配置文件路径：flagValue 非空时使用它，其次是 PDF_MERGER_CONFIG，
+// 最后是用户配置目录下的默认路径
+func ResolveConfigPath(flagValue string, getenv func(string) string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if path := getenv(EnvConfigPath); path != "" {
+		return path, nil
+	}
+	return GetDefaultConfigPath()
+}
+
+// LoadConfig 从JSON文件加载配置。文件中没有的字段和未知字段分别使用默认值和被忽略；
+// 文件不存在时返回默认配置。文件无法读取或已损坏时同样返回默认配置，并返回说明原因的错误，
+// 调用方应把错误作为警告记录而不是退出。
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultConfig(), nil
+		}
+		return DefaultConfig(), fmt.Errorf("无法读取配置文件 %s，使用默认配置: %w", path, err)
+	}
+
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return DefaultConfig(), fmt.Errorf("配置文件 %s 已损坏，使用默认配置: %w", path, err)
+	}
+	mergeConfigDefaults(config)
+	return config, nil
+}
+
+// SaveConfig 把配置写入JSON文件，先写临时文件再替换，写入中断不会损坏原有配置
+func SaveConfig(path string, config *Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建配置目录: %w", err)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("无法写入配置文件: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("无法写入配置文件: %w", err)
+	}
+	return nil
+}
+
+// ApplyEnv 用环境变量覆盖配置中的临时目录、输出目录和内存上限
+func ApplyEnv(config *Config, getenv func(string) string) error {
+	if dir := getenv(EnvTempDir); dir != "" {
+		config.TempDirectory = dir
+	}
+	if dir := getenv(EnvOutputDir); dir != "" {
+		config.OutputDirectory = dir
+	}
+	if value := getenv(EnvMaxMemoryMB); value != "" {
+		mb, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || mb <= 0 {
+			return fmt.Errorf("%s 必须是正整数（MB）: %s", EnvMaxMemoryMB, value)
+		}
+		config.MaxMemoryUsage = mb * 1024 * 1024
+	}
+	return nil
+}
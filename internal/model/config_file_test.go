@@ -0,0 +1,222 @@
+package model
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_MissingFileUsesDefaults(t *testing.T) {
+	config, err := LoadConfig(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing config file, got %v", err)
+	}
+	if config.MaxMemoryUsage != DefaultConfig().MaxMemoryUsage {
+		t.Errorf("Expected default MaxMemoryUsage, got %d", config.MaxMemoryUsage)
+	}
+}
+
+func TestLoadConfig_CorruptFileFallsBackToDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err == nil {
+		t.Error("Expected a warning error for corrupt config file")
+	}
+	if config == nil || config.WindowWidth != DefaultConfig().WindowWidth {
+		t.Errorf("Expected default config for corrupt file, got %+v", config)
+	}
+}
+
+func TestLoadConfig_PartialFileKeepsDefaultsAndIgnoresUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"OutputDirectory": "/srv/out", "MaxMemoryUsage": 0, "FutureOption": true}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected unknown fields to be ignored, got %v", err)
+	}
+	if config.OutputDirectory != "/srv/out" {
+		t.Errorf("Expected OutputDirectory from file, got %s", config.OutputDirectory)
+	}
+	if config.MaxMemoryUsage != DefaultConfig().MaxMemoryUsage {
+		t.Errorf("Expected zero MaxMemoryUsage to fall back to default, got %d", config.MaxMemoryUsage)
+	}
+	if !config.EnableAutoDecrypt {
+		t.Error("Expected EnableAutoDecrypt missing from file to keep its default")
+	}
+}
+
+func TestSaveConfig_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+	config := DefaultConfig()
+	config.TempDirectory = "/var/tmp/pdf"
+	config.MaxConcurrentJobs = 3
+	config.EnableAutoDecrypt = false
+
+	if err := SaveConfig(path, config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected temporary file to be removed after save")
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if loaded.TempDirectory != "/var/tmp/pdf" || loaded.MaxConcurrentJobs != 3 || loaded.EnableAutoDecrypt {
+		t.Errorf("Loaded config does not match saved config: %+v", loaded)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		EnvTempDir:     "/env/tmp",
+		EnvMaxMemoryMB: "256",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	config := DefaultConfig()
+	config.OutputDirectory = "/file/out"
+	if err := ApplyEnv(config, getenv); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.TempDirectory != "/env/tmp" {
+		t.Errorf("Expected TempDirectory from env, got %s", config.TempDirectory)
+	}
+	if config.OutputDirectory != "/file/out" {
+		t.Errorf("Expected unset env var to keep file value, got %s", config.OutputDirectory)
+	}
+	if config.MaxMemoryUsage != 256*1024*1024 {
+		t.Errorf("Expected MaxMemoryUsage 256MB, got %d", config.MaxMemoryUsage)
+	}
+
+	env[EnvMaxMemoryMB] = "lots"
+	if err := ApplyEnv(DefaultConfig(), getenv); err == nil {
+		t.Error("Expected error for invalid memory value")
+	}
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	getenv := func(key string) string {
+		if key == EnvConfigPath {
+			return "/env/config.json"
+		}
+		return ""
+	}
+
+	if path, _ := ResolveConfigPath("/flag/config.json", getenv); path != "/flag/config.json" {
+		t.Errorf("Expected flag to take precedence, got %s", path)
+	}
+	if path, _ := ResolveConfigPath("", getenv); path != "/env/config.json" {
+		t.Errorf("Expected env path, got %s", path)
+	}
+}
+
+func TestLoadConfig_ValidationPolicyOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"ValidationPolicy": {"Validation Error": "failure", "Corrupted File": "warning"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := config.ValidationSeverityOf("Validation Error"); got != ValidationFailure {
+		t.Errorf("Expected Validation Error to be a failure, got %s", got)
+	}
+	if got := config.ValidationSeverityOf("Corrupted File"); got != ValidationWarning {
+		t.Errorf("Expected Corrupted File to be a warning, got %s", got)
+	}
+	if got := config.ValidationSeverityOf("Encrypted File"); got != ValidationFailure {
+		t.Errorf("Expected unlisted types to be failures, got %s", got)
+	}
+
+	missing, _ := LoadConfig(filepath.Join(t.TempDir(), "none.json"))
+	if got := missing.ValidationSeverityOf("Validation Error"); got != ValidationWarning {
+		t.Errorf("Expected default policy to treat Validation Error as a warning, got %s", got)
+	}
+}
+
+func TestSaveConfig_ProfilesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	config := DefaultConfig()
+	config.Profiles = map[string]MergeProfile{
+		"archival": {
+			Description: "归档",
+			Merge:       ProfileMergeOptions{Linearize: true, SourceBookmarks: true, MetadataSource: "first"},
+			Writer:      ProfileWriterOptions{BackupOutput: true, BackupRetention: 5},
+		},
+		"small-output": {
+			Merge:     ProfileMergeOptions{MaxOutputSizeMB: 20},
+			Streaming: ProfileStreamingOptions{ChunkSize: 4, MemoryLimit: 0.75},
+			Writer:    ProfileWriterOptions{Optimize: true, ImageDPI: 150},
+		},
+	}
+
+	if err := SaveConfig(path, config); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved config: %v", err)
+	}
+	if !maps.Equal(loaded.Profiles, config.Profiles) {
+		t.Errorf("Loaded profiles do not match saved profiles:\n got %+v\nwant %+v", loaded.Profiles, config.Profiles)
+	}
+
+	// 保存再加载后内置方案仍然可用，且不写入配置文件
+	if _, err := loaded.ResolveProfile(LowMemoryProfileName); err != nil {
+		t.Errorf("Expected built-in profile after round trip, got %v", err)
+	}
+	if _, ok := loaded.Profiles[LowMemoryProfileName]; ok {
+		t.Error("Expected built-in profiles not to be persisted")
+	}
+}
+
+func TestLoadConfig_ProfilesFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"Profiles": {
+		"fast": {"Streaming": {"MaxConcurrentChunks": 8}},
+		"default": {"Description": "团队默认", "Merge": {"GenerateTOC": true}}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	fast, err := config.ResolveProfile("fast")
+	if err != nil || fast.Streaming.MaxConcurrentChunks != 8 {
+		t.Errorf("Expected fast profile from file, got %+v (%v)", fast, err)
+	}
+	defaults, err := config.ResolveProfile("")
+	if err != nil || !defaults.Merge.GenerateTOC {
+		t.Errorf("Expected file entry to replace built-in default profile, got %+v (%v)", defaults, err)
+	}
+	if got := strings.Join(config.ProfileNames(), ","); got != "default,fast,low-memory" {
+		t.Errorf("Expected sorted profile names, got %s", got)
+	}
+
+	_, err = config.ResolveProfile("archival")
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("Expected ErrUnknownProfile, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "default, fast, low-memory") {
+		t.Errorf("Expected error to list available profiles, got %v", err)
+	}
+}
@@ -1,7 +1,7 @@
 package model
 
 import (
-	"encoding/json"
+	"maps"
 	"os"
 	"path/filepath"
 	"sync"
@@ -32,44 +32,19 @@ func NewConfigManager(configPath string) *ConfigManager {
 	}
 }
 
-// LoadConfig 从文件加载配置
+// LoadConfig 从文件加载配置，文件不存在时保留默认配置，文件损坏时返回错误并保留当前配置
 func (cm *ConfigManager) LoadConfig() error {
-	if _, err := os.Stat(cm.configPath); os.IsNotExist(err) {
-		// 配置文件不存在，使用默认配置
-		return nil
-	}
-
-	data, err := os.ReadFile(cm.configPath)
+	config, err := LoadConfig(cm.configPath)
 	if err != nil {
 		return err
 	}
-
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return err
-	}
-
-	// 合并默认配置和加载的配置
-	cm.mergeWithDefaults(&config)
-	cm.config = &config
-
+	cm.config = config
 	return nil
 }
 
 // SaveConfig 保存配置到文件
 func (cm *ConfigManager) SaveConfig() error {
-	// 确保配置目录存在
-	configDir := filepath.Dir(cm.configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(cm.config, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(cm.configPath, data, 0644)
+	return SaveConfig(cm.configPath, cm.config)
 }
 
 // GetConfig 获取当前配置
@@ -135,6 +110,11 @@ func (cm *ConfigManager) SetWindowSize(width, height int) {
 
 // mergeWithDefaults 将加载的配置与默认配置合并
 func (cm *ConfigManager) mergeWithDefaults(config *Config) {
+	mergeConfigDefaults(config)
+}
+
+// mergeConfigDefaults 把配置中为空或零值的字段设为默认值
+func mergeConfigDefaults(config *Config) {
 	defaults := DefaultConfig()
 
 	// 如果某些字段为空或零值，使用默认值
@@ -154,20 +134,25 @@ func (cm *ConfigManager) mergeWithDefaults(config *Config) {
 		config.CommonPasswords = defaults.CommonPasswords
 	}
 
-	// 注意：我们不覆盖EnableAutoDecrypt的值，因为布尔值没有明确的"未设置"状态
+	if len(config.FilenameEncodings) == 0 {
+		config.FilenameEncodings = defaults.FilenameEncodings
+	}
 
-	if config.WindowWidth <= 0 {
-		config.WindowWidth = defaults.WindowWidth
+	if config.TempFileMaxAge <= 0 {
+		config.TempFileMaxAge = defaults.TempFileMaxAge
 	}
 
-	if config.WindowHeight <= 0 {
-		config.WindowHeight = defaults.WindowHeight
+	// 没有验证策略时使用默认策略，空策略表示所有验证问题都是失败
+	if config.ValidationPolicy == nil {
+		config.ValidationPolicy = defaults.ValidationPolicy
 	}
 
-	if len(config.CommonPasswords) == 0 {
-		config.CommonPasswords = defaults.CommonPasswords
+	if config.MaxConcurrentJobs <= 0 {
+		config.MaxConcurrentJobs = defaults.MaxConcurrentJobs
 	}
 
+	// 注意：我们不覆盖EnableAutoDecrypt的值，因为布尔值没有明确的"未设置"状态
+
 	if config.WindowWidth <= 0 {
 		config.WindowWidth = defaults.WindowWidth
 	}
@@ -177,14 +162,14 @@ func (cm *ConfigManager) mergeWithDefaults(config *Config) {
 	}
 }
 
-// GetDefaultConfigPath 获取默认配置文件路径
+// GetDefaultConfigPath 获取默认配置文件路径：用户配置目录下的 pdf-merger/config.json
 func GetDefaultConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(homeDir, ".pdf-merger", "config.json"), nil
+	return filepath.Join(configDir, ConfigDirName, ConfigFileName), nil
 }
 
 // AddConfigChangeCallback 添加配置变更回调
@@ -318,7 +303,12 @@ func (cm *ConfigManager) configsEqual(config1, config2 *Config) bool {
 		config1.EnableAutoDecrypt == config2.EnableAutoDecrypt &&
 		config1.WindowWidth == config2.WindowWidth &&
 		config1.WindowHeight == config2.WindowHeight &&
-		cm.slicesEqual(config1.CommonPasswords, config2.CommonPasswords)
+		config1.MaxConcurrentJobs == config2.MaxConcurrentJobs &&
+		config1.ShowThumbnails == config2.ShowThumbnails &&
+		cm.slicesEqual(config1.CommonPasswords, config2.CommonPasswords) &&
+		cm.slicesEqual(config1.FilenameEncodings, config2.FilenameEncodings) &&
+		maps.Equal(config1.ValidationPolicy, config2.ValidationPolicy) &&
+		maps.Equal(config1.Profiles, config2.Profiles)
 }
 
 // slicesEqual 比较两个字符串切片是否相等
@@ -345,6 +335,8 @@ func (cm *ConfigManager) GetConfigSafely() *Config {
 	configCopy := *cm.config
 	configCopy.CommonPasswords = make([]string, len(cm.config.CommonPasswords))
 	copy(configCopy.CommonPasswords, cm.config.CommonPasswords)
+	configCopy.ValidationPolicy = maps.Clone(cm.config.ValidationPolicy)
+	configCopy.Profiles = maps.Clone(cm.config.Profiles)
 
 	return &configCopy
 }
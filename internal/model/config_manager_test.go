@@ -253,8 +253,8 @@ func TestGetDefaultConfigPath(t *testing.T) {
 	}
 
 	dir := filepath.Dir(path)
-	if filepath.Base(dir) != ".pdf-merger" {
-		t.Errorf("Expected config directory to be '.pdf-merger', got %s", filepath.Base(dir))
+	if filepath.Base(dir) != "pdf-merger" {
+		t.Errorf("Expected config directory to be 'pdf-merger', got %s", filepath.Base(dir))
 	}
 
 	if filepath.Base(path) != "config.json" {
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// DefaultFilenameEncodings 文件名不是合法UTF-8时依次尝试的编码。
+// Windows压缩包中的中文文件名多为GBK，其次是繁体Big5和日文Shift-JIS。
+var DefaultFilenameEncodings = []string{"gbk", "big5", "shift_jis"}
+
+// filenameDecoders 支持的回退编码，键为小写名称
+var filenameDecoders = map[string]encoding.Encoding{
+	"gbk":       simplifiedchinese.GBK,
+	"gb18030":   simplifiedchinese.GB18030,
+	"big5":      traditionalchinese.Big5,
+	"shift_jis": japanese.ShiftJIS,
+	"sjis":      japanese.ShiftJIS,
+	"euc-jp":    japanese.EUCJP,
+}
+
+// disambiguatorLength 冲突时附加的哈希前缀长度（十六进制字符数）
+const disambiguatorLength = 6
+
+// DisplayName 由原始文件名字节推导用于显示的名称。
+// 合法UTF-8原样返回；否则按 encodings 顺序尝试解码，取第一个没有替换字符和
+// 控制字符的结果；都失败时把非法字节替换为U+FFFD。
+// 结果只用于显示，文件系统操作必须继续使用原始路径。Windows长路径前缀不显示。
+func DisplayName(raw string, encodings []string) string {
+	raw = TrimLongPathPrefix(raw)
+	if utf8.ValidString(raw) {
+		return raw
+	}
+	for _, name := range encodings {
+		enc, ok := filenameDecoders[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		decoded, err := enc.NewDecoder().String(raw)
+		if err == nil && isCleanDecoding(decoded) {
+			return decoded
+		}
+	}
+	return strings.ToValidUTF8(raw, "�")
+}
+
+// DisplayBase 返回路径最后一个元素的显示名称
+func DisplayBase(path string, encodings []string) string {
+	return DisplayName(filepath.Base(path), encodings)
+}
+
+// isCleanDecoding 解码结果不含替换字符和控制字符时才认为解码成功
+func isCleanDecoding(s string) bool {
+	for _, r := range s {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// EscapeRawName 返回原始文件名的可逆转义形式：合法UTF-8字符保留，
+// 非法字节写成 \xNN，反斜杠写成 \\。用于清单等需要区分原始字节的场合。
+func EscapeRawName(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, raw[i])
+		case r == '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteString(raw[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// DisambiguateDisplayNames 推导一组原始名称的显示名称。
+// 原始字节不同但显示名称相同的条目都附加原始字节的短哈希，例如
+// "报告.pdf (1a2b3c)"，使列表中的条目可以区分；原始字节相同的条目不处理。
+func DisambiguateDisplayNames(raws []string, encodings []string) []string {
+	names := make([]string, len(raws))
+	distinctRaws := make(map[string]map[string]bool)
+	for i, raw := range raws {
+		names[i] = DisplayName(raw, encodings)
+		if distinctRaws[names[i]] == nil {
+			distinctRaws[names[i]] = make(map[string]bool)
+		}
+		distinctRaws[names[i]][raw] = true
+	}
+
+	for i, raw := range raws {
+		if len(distinctRaws[names[i]]) > 1 {
+			names[i] = fmt.Sprintf("%s (%s)", names[i], rawNameHash(raw))
+		}
+	}
+	return names
+}
+
+// rawNameHash 原始字节的短哈希
+func rawNameHash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])[:disambiguatorLength]
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// 各编码下的文件名字节夹具
+var (
+	gbkName      = "\xd6\xd0\xce\xc4\xb1\xa8\xb8\xe6.pdf" // 中文报告.pdf (GBK)
+	big5Name     = "\xa4\xa4\xa4\xe5.pdf"                 // 中文.pdf (Big5)
+	shiftJISName = "\x93\xfa\x96\x7b\x8c\xea.pdf"         // 日本語.pdf (Shift-JIS)
+)
+
+func TestDisplayName_ValidUTF8Unchanged(t *testing.T) {
+	for _, name := range []string{"report.pdf", "中文报告.pdf", "日本語.pdf", "a\\b.pdf", ""} {
+		if got := DisplayName(name, DefaultFilenameEncodings); got != name {
+			t.Errorf("Expected valid UTF-8 %q unchanged, got %q", name, got)
+		}
+	}
+}
+
+func TestDisplayName_FallbackEncodings(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		encodings []string
+		expected  string
+	}{
+		{"gbk", gbkName, []string{"gbk"}, "中文报告.pdf"},
+		{"gbk default order", gbkName, DefaultFilenameEncodings, "中文报告.pdf"},
+		{"gbk via gb18030", gbkName, []string{"GB18030"}, "中文报告.pdf"},
+		{"big5", big5Name, []string{"big5"}, "中文.pdf"},
+		{"shift_jis", shiftJISName, []string{"shift_jis"}, "日本語.pdf"},
+		{"sjis alias", shiftJISName, []string{"SJIS"}, "日本語.pdf"},
+		{"unknown encodings skipped", gbkName, []string{"klingon", "gbk"}, "中文报告.pdf"},
+		{"directory kept", "in/" + gbkName, []string{"gbk"}, "in/中文报告.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisplayName(tt.raw, tt.encodings); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDisplayName_SkipsEncodingsThatProduceReplacements(t *testing.T) {
+	// 0xff 在GBK和Big5中都不是合法字节，连续的非法字节替换为一个U+FFFD
+	raw := "\xa4\xff.pdf"
+	if got := DisplayName(raw, []string{"gbk", "big5"}); got != "�.pdf" {
+		t.Errorf("Expected replacement characters when no encoding fits, got %q", got)
+	}
+
+	// 第一个编码失败时继续尝试下一个
+	if got := DisplayName(big5Name, []string{"shift_jis", "big5"}); got != "中文.pdf" {
+		t.Errorf("Expected big5 after shift_jis fails, got %q", got)
+	}
+}
+
+func TestDisplayName_NoEncodings(t *testing.T) {
+	got := DisplayName(gbkName, nil)
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, ".pdf") || !strings.Contains(got, "�") {
+		t.Errorf("Expected invalid bytes to be replaced, got %q", got)
+	}
+}
+
+func TestDisplayName_IsPure(t *testing.T) {
+	encodings := []string{"gbk", "big5"}
+	first := DisplayName(gbkName, encodings)
+	for i := 0; i < 10; i++ {
+		if got := DisplayName(gbkName, encodings); got != first {
+			t.Fatalf("Expected identical results, got %q and %q", first, got)
+		}
+	}
+	if encodings[0] != "gbk" || encodings[1] != "big5" {
+		t.Errorf("Expected encodings slice to be left untouched, got %v", encodings)
+	}
+}
+
+func TestEscapeRawName(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"中文.pdf", "中文.pdf"},
+		{"\xd6\xd0.pdf", `\xd6\xd0.pdf`},
+		{`dir\name.pdf`, `dir\\name.pdf`},
+		{"\\xd6.pdf", `\\xd6.pdf`}, // 字面的反斜杠序列与原始字节可以区分
+	}
+	for _, tt := range tests {
+		if got := EscapeRawName(tt.raw); got != tt.expected {
+			t.Errorf("EscapeRawName(%q): expected %q, got %q", tt.raw, tt.expected, got)
+		}
+	}
+
+	// 转义结果是合法UTF-8，JSON往返不丢失信息
+	escaped := EscapeRawName(gbkName)
+	data, _ := json.Marshal(escaped)
+	var back string
+	json.Unmarshal(data, &back)
+	if back != escaped {
+		t.Errorf("Expected escaped name to survive JSON, got %q", back)
+	}
+}
+
+func TestDisambiguateDisplayNames_Collision(t *testing.T) {
+	// 两个不同的非法字节序列都显示为替换字符
+	first := "\xff\xfe.pdf"
+	second := "\xfe\xff.pdf"
+	names := DisambiguateDisplayNames([]string{first, second, "other.pdf"}, DefaultFilenameEncodings)
+
+	if names[0] == names[1] {
+		t.Fatalf("Expected colliding names to be disambiguated, got %q twice", names[0])
+	}
+	for _, name := range names[:2] {
+		if !strings.HasPrefix(name, "�.pdf (") || len(name) != len("�.pdf (")+disambiguatorLength+1 {
+			t.Errorf("Expected short hash suffix, got %q", name)
+		}
+	}
+	if names[2] != "other.pdf" {
+		t.Errorf("Expected non-colliding name unchanged, got %q", names[2])
+	}
+
+	// 后缀只取决于原始字节，与顺序和其他条目无关
+	again := DisambiguateDisplayNames([]string{second, "x.pdf", first}, DefaultFilenameEncodings)
+	if again[0] != names[1] || again[2] != names[0] {
+		t.Errorf("Expected stable suffixes, got %v and %v", names, again)
+	}
+}
+
+func TestDisambiguateDisplayNames_DecodedCollidesWithUTF8(t *testing.T) {
+	// GBK字节解码后与已有的UTF-8文件名相同
+	names := DisambiguateDisplayNames([]string{"中文报告.pdf", gbkName}, []string{"gbk"})
+	if names[0] == names[1] {
+		t.Fatalf("Expected disambiguation, got %q twice", names[0])
+	}
+	if !strings.HasPrefix(names[0], "中文报告.pdf (") || !strings.HasPrefix(names[1], "中文报告.pdf (") {
+		t.Errorf("Expected both to keep the readable name, got %v", names)
+	}
+}
+
+func TestDisambiguateDisplayNames_SameRawNotSuffixed(t *testing.T) {
+	names := DisambiguateDisplayNames([]string{"a.pdf", "a.pdf", gbkName}, []string{"gbk"})
+	if names[0] != "a.pdf" || names[1] != "a.pdf" {
+		t.Errorf("Expected identical raw names to stay unchanged, got %v", names)
+	}
+	if names[2] != "中文报告.pdf" {
+		t.Errorf("Expected decoded name, got %q", names[2])
+	}
+}
+
+func TestFileList_DisplayNamesKeepRawPath(t *testing.T) {
+	fl := NewFileList()
+	fl.SetMainFile("/in/\xff\xfe.pdf")
+	entry := fl.AddFile("/other/\xfe\xff.pdf")
+	gbk := fl.AddFile("/in/" + gbkName)
+
+	if entry.Path != "/other/\xfe\xff.pdf" {
+		t.Errorf("Expected raw path to be kept for filesystem use, got %q", entry.Path)
+	}
+	if fl.GetMainFile().DisplayName == entry.DisplayName {
+		t.Errorf("Expected colliding entries to be distinguishable, got %q", entry.DisplayName)
+	}
+	if gbk.DisplayName != "中文报告.pdf" {
+		t.Errorf("Expected GBK name to be decoded, got %q", gbk.DisplayName)
+	}
+
+	// 冲突消失后去掉后缀
+	fl.RemoveFile("/other/\xfe\xff.pdf")
+	if fl.GetMainFile().DisplayName != "�.pdf" {
+		t.Errorf("Expected suffix to be dropped once the collision is gone, got %q", fl.GetMainFile().DisplayName)
+	}
+
+	fl.SetFilenameEncodings(nil)
+	if gbk.DisplayName == "中文报告.pdf" {
+		t.Error("Expected display names to follow the configured encodings")
+	}
+}
@@ -1,30 +1,43 @@
 package model
 
 import (
+	"path/filepath"
 	"sort"
 	"sync"
 )
 
 // FileList 定义文件列表管理器
 type FileList struct {
-	mu       sync.RWMutex
-	files    []*FileEntry
-	mainFile *FileEntry
+	mu        sync.RWMutex
+	files     []*FileEntry
+	mainFile  *FileEntry
+	encodings []string // 推导显示名称时的回退编码
 }
 
 // NewFileList 创建一个新的文件列表
 func NewFileList() *FileList {
 	return &FileList{
-		files: make([]*FileEntry, 0),
+		files:     make([]*FileEntry, 0),
+		encodings: DefaultFilenameEncodings,
 	}
 }
 
+// SetFilenameEncodings 设置推导显示名称时的回退编码，并刷新已有条目
+func (fl *FileList) SetFilenameEncodings(encodings []string) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	fl.encodings = encodings
+	fl.refreshDisplayNames()
+}
+
 // SetMainFile 设置主文件
 func (fl *FileList) SetMainFile(path string) *FileEntry {
 	fl.mu.Lock()
 	defer fl.mu.Unlock()
 
 	fl.mainFile = NewFileEntry(path, 0)
+	fl.refreshDisplayNames()
 	return fl.mainFile
 }
 
@@ -52,6 +65,7 @@ func (fl *FileList) AddFile(path string) *FileEntry {
 	order := len(fl.files) + 1
 	fileEntry := NewFileEntry(path, order)
 	fl.files = append(fl.files, fileEntry)
+	fl.refreshDisplayNames()
 
 	return fileEntry
 }
@@ -68,6 +82,7 @@ func (fl *FileList) RemoveFile(path string) bool {
 
 			// 重新排序
 			fl.reorderFiles()
+			fl.refreshDisplayNames()
 			return true
 		}
 	}
@@ -233,6 +248,24 @@ func (fl *FileList) GetValidFiles() []*FileEntry {
 	return validFiles
 }
 
+// refreshDisplayNames 重新推导所有条目的显示名称，显示相同但原始字节不同的条目
+// 附加短哈希以便区分（内部方法，调用时需要已加锁）
+func (fl *FileList) refreshDisplayNames() {
+	entries := make([]*FileEntry, 0, len(fl.files)+1)
+	if fl.mainFile != nil {
+		entries = append(entries, fl.mainFile)
+	}
+	entries = append(entries, fl.files...)
+
+	raws := make([]string, len(entries))
+	for i, entry := range entries {
+		raws[i] = filepath.Base(entry.Path)
+	}
+	for i, name := range DisambiguateDisplayNames(raws, fl.encodings) {
+		entries[i].DisplayName = name
+	}
+}
+
 // reorderFiles 重新排序文件（内部方法，调用时需要已加锁）
 func (fl *FileList) reorderFiles() {
 	for i, file := range fl.files {
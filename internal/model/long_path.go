@@ -0,0 +1,67 @@
+package model
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// longPathThreshold 超过该长度的Windows路径加上 \\?\ 前缀。MAX_PATH为260，
+// 创建目录时还要为8.3文件名预留12个字符，因此从248开始加前缀
+const longPathThreshold = 248
+
+// Windows长路径前缀
+const (
+	longPathPrefix    = `\\?\`
+	longUNCPathPrefix = `\\?\UNC\`
+	devicePathPrefix  = `\\.\`
+)
+
+// NormalizePath 返回用于访问文件系统的路径：相对路径解析为绝对路径；在Windows上，
+// 超过MAX_PATH的路径加上 \\?\ 前缀（网络共享 \\server\share\... 加上 \\?\UNC\ 前缀），
+// 已带前缀的路径原样返回。其他系统上只解析为绝对路径。空路径原样返回
+func NormalizePath(path string) string {
+	if path == "" {
+		return path
+	}
+	if runtime.GOOS == "windows" && HasLongPathPrefix(path) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	if runtime.GOOS != "windows" {
+		return abs
+	}
+	return addLongPathPrefix(abs)
+}
+
+// addLongPathPrefix 为超过longPathThreshold的Windows绝对路径加上长路径前缀，
+// 驱动器路径（C:\...）加 \\?\，UNC路径（\\server\share\...）改写为 \\?\UNC\server\share\...
+func addLongPathPrefix(abs string) string {
+	if len(abs) < longPathThreshold || HasLongPathPrefix(abs) {
+		return abs
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return longUNCPathPrefix + abs[2:]
+	}
+	if len(abs) >= 3 && abs[1] == ':' && abs[2] == '\\' {
+		return longPathPrefix + abs
+	}
+	return abs
+}
+
+// HasLongPathPrefix 判断路径是否已带有 \\?\ 或 \\.\ 前缀
+func HasLongPathPrefix(path string) bool {
+	return strings.HasPrefix(path, longPathPrefix) || strings.HasPrefix(path, devicePathPrefix)
+}
+
+// TrimLongPathPrefix 去掉NormalizePath加上的长路径前缀，用于显示：
+// \\?\UNC\server\share 还原为 \\server\share，\\?\C:\... 还原为 C:\...
+func TrimLongPathPrefix(path string) string {
+	if strings.HasPrefix(path, longUNCPathPrefix) {
+		return `\\` + path[len(longUNCPathPrefix):]
+	}
+	return strings.TrimPrefix(path, longPathPrefix)
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// longTempDir 在临时目录下创建总长度超过260个字符的多级目录
+func longTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for len(dir) <= 300 {
+		dir = filepath.Join(dir, strings.Repeat("d", 40))
+	}
+	if err := os.MkdirAll(NormalizePath(dir), 0755); err != nil {
+		t.Fatalf("创建长路径目录失败: %v", err)
+	}
+	return dir
+}
+
+func TestAddLongPathPrefix(t *testing.T) {
+	long := strings.Repeat(`\segment`, 40)
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"短路径不变", `C:\docs\a.pdf`, `C:\docs\a.pdf`},
+		{"驱动器长路径", `C:` + long, `\\?\C:` + long},
+		{"网络共享长路径", `\\server\share` + long, `\\?\UNC\server\share` + long},
+		{"短网络共享路径不变", `\\server\share\a.pdf`, `\\server\share\a.pdf`},
+		{"已有前缀不变", `\\?\C:` + long, `\\?\C:` + long},
+		{"设备路径不变", `\\.\pipe` + long, `\\.\pipe` + long},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addLongPathPrefix(tt.path); got != tt.want {
+				t.Errorf("addLongPathPrefix(%q) = %q，期望 %q", tt.path, got, tt.want)
+			}
+			if got := TrimLongPathPrefix(addLongPathPrefix(tt.path)); !HasLongPathPrefix(tt.path) && got != tt.path {
+				t.Errorf("TrimLongPathPrefix 应还原 %q，实际 %q", tt.path, got)
+			}
+		})
+	}
+}
+
+func TestNormalizePath_RelativeBecomesAbsolute(t *testing.T) {
+	got := NormalizePath(filepath.Join("sub", "a.pdf"))
+	if !filepath.IsAbs(got) && !HasLongPathPrefix(got) {
+		t.Errorf("期望绝对路径，实际 %q", got)
+	}
+	if NormalizePath("") != "" {
+		t.Error("空路径应原样返回")
+	}
+}
+
+func TestNormalizePath_LongTempPath(t *testing.T) {
+	dir := longTempDir(t)
+	path := filepath.Join(dir, "input.pdf")
+	if len(path) <= 260 {
+		t.Fatalf("测试路径应超过260个字符，实际 %d", len(path))
+	}
+
+	normalized := NormalizePath(path)
+	if runtime.GOOS == "windows" && !HasLongPathPrefix(normalized) {
+		t.Errorf("Windows上的长路径应带有 \\\\?\\ 前缀，实际 %q", normalized)
+	}
+	if err := os.WriteFile(normalized, []byte("%PDF-1.4\n"), 0644); err != nil {
+		t.Fatalf("无法写入长路径文件: %v", err)
+	}
+	if _, err := os.Stat(normalized); err != nil {
+		t.Errorf("无法访问长路径文件: %v", err)
+	}
+	if display := DisplayName(normalized, nil); HasLongPathPrefix(display) {
+		t.Errorf("显示名称不应包含长路径前缀: %q", display)
+	}
+}
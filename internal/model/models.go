@@ -2,8 +2,9 @@ package model
 
 import (
 	"fmt"
-	"path/filepath"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // JobStatus 定义合并任务的状态
@@ -18,6 +19,10 @@ const (
 	JobCompleted
 	// JobFailed 表示任务失败
 	JobFailed
+	// JobDeferred 表示任务因时间预算不足被推迟，未执行
+	JobDeferred
+	// JobCancelled 表示任务在完成前被取消
+	JobCancelled
 )
 
 // String 返回JobStatus的字符串表示
@@ -31,42 +36,86 @@ func (js JobStatus) String() string {
 		return "已完成"
 	case JobFailed:
 		return "失败"
+	case JobDeferred:
+		return "已推迟"
+	case JobCancelled:
+		return "已取消"
 	default:
 		return "未知状态"
 	}
 }
 
-// MergeJob 定义PDF合并任务
+// MergeJob 定义PDF合并任务。任务可以序列化为JSON保存进度（见控制器的任务断点），
+// Error 和 Passwords 不会写出
 type MergeJob struct {
-	ID              string
-	MainFile        string
-	AdditionalFiles []string
-	OutputPath      string
-	Status          JobStatus
-	Progress        float64
-	Error           error
-	CreatedAt       time.Time
-	CompletedAt     *time.Time
-}
-
-// NewMergeJob 创建一个新的合并任务
+	ID              string            `json:"id"`
+	MainFile        string            `json:"main_file"`
+	AdditionalFiles []string          `json:"additional_files"`
+	OutputPath      string            `json:"output_path"`
+	Status          JobStatus         `json:"status"`
+	Progress        float64           `json:"progress"`
+	Error           error             `json:"-"`
+	CreatedAt       time.Time         `json:"created_at"`
+	CompletedAt     *time.Time        `json:"completed_at,omitempty"`
+	History         []JobHistoryEntry `json:"history,omitempty"`
+
+	// NotAfter 任务必须在此时刻前完成，零值表示不限制。队列据此决定是否启动任务
+	NotAfter time.Time `json:"not_after,omitempty"`
+
+	// Passwords 按输入路径提供的加密文件打开密码，只保存在内存中，不得写入日志或历史
+	Passwords map[string]string `json:"-"`
+
+	// GenerateTOC 合并后在输出开头插入列出各输入及起始页的目录页
+	GenerateTOC bool `json:"generate_toc,omitempty"`
+
+	// Rotations 按输入路径指定的顺时针旋转角度（0、90、180、270），合并前应用到输入的临时副本
+	Rotations map[string]int `json:"rotations,omitempty"`
+
+	// NormalizeOrientation 合并前把输入页面的 /Rotate 写入页面内容，使输出页面不依赖 /Rotate 显示为正向
+	NormalizeOrientation bool `json:"normalize_orientation,omitempty"`
+
+	// clock 任务ID、完成时间和历史记录使用的时钟，nil时使用系统时钟
+	clock clock.Clock
+}
+
+// JobHistoryEntry 任务历史记录中的一条事件
+type JobHistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// NewMergeJob 创建一个使用系统时钟的合并任务
 func NewMergeJob(mainFile string, additionalFiles []string, outputPath string) *MergeJob {
+	return NewMergeJobWithClock(nil, mainFile, additionalFiles, outputPath)
+}
+
+// NewMergeJobWithClock 创建一个新的合并任务，任务ID、创建/完成时间和历史记录取自c，nil时使用系统时钟。
+// 调用方通常传入 Config.Clock，测试中用于得到确定的ID和时间戳
+func NewMergeJobWithClock(c clock.Clock, mainFile string, additionalFiles []string, outputPath string) *MergeJob {
+	c = clock.OrSystem(c)
 	return &MergeJob{
-		ID:              generateJobID(),
+		ID:              generateJobID(c),
 		MainFile:        mainFile,
 		AdditionalFiles: additionalFiles,
 		OutputPath:      outputPath,
 		Status:          JobPending,
 		Progress:        0.0,
-		CreatedAt:       time.Now(),
+		CreatedAt:       c.Now(),
+		clock:           c,
 	}
 }
 
+// now 返回任务时钟的当前时间
+func (mj *MergeJob) now() time.Time {
+	return clock.OrSystem(mj.clock).Now()
+}
+
 // SetCompleted 标记任务为已完成
 func (mj *MergeJob) SetCompleted() {
 	mj.Status = JobCompleted
 	mj.Progress = 100.0
-	now := time.Now()
+	now := mj.now()
 	mj.CompletedAt = &now
 }
 
@@ -74,7 +123,15 @@ func (mj *MergeJob) SetCompleted() {
 func (mj *MergeJob) SetFailed(err error) {
 	mj.Status = JobFailed
 	mj.Error = err
-	now := time.Now()
+	now := mj.now()
+	mj.CompletedAt = &now
+}
+
+// SetCancelled 标记任务为已取消
+func (mj *MergeJob) SetCancelled(err error) {
+	mj.Status = JobCancelled
+	mj.Error = err
+	now := mj.now()
 	mj.CompletedAt = &now
 }
 
@@ -94,6 +151,15 @@ func (mj *MergeJob) UpdateProgress(progress float64) {
 	mj.Progress = progress
 }
 
+// AddHistory 追加一条任务历史记录
+func (mj *MergeJob) AddHistory(event, detail string) {
+	mj.History = append(mj.History, JobHistoryEntry{
+		Time:   mj.now(),
+		Event:  event,
+		Detail: detail,
+	})
+}
+
 // GetTotalFiles 获取总文件数
 func (mj *MergeJob) GetTotalFiles() int {
 	return 1 + len(mj.AdditionalFiles) // 主文件 + 附加文件
@@ -109,13 +175,24 @@ type FileEntry struct {
 	IsValid     bool
 	Order       int
 	Error       string // 文件处理错误信息
+	Password    string // 已验证的打开密码，只保存在内存中，不得写入日志
+	Rotation    int    // 合并时顺时针旋转的角度：0、90、180、270
+	Signatures  int    // 数字签名的数量，合并会使这些签名失效
+
+	// Warnings 验证时按策略视为警告的问题，文件仍然有效并参与合并
+	Warnings []string
+
+	// 页数最多的页面尺寸，未读取时PageSize为空
+	PageSize       string // 纸张名称或尺寸，例如 "A4"、"500×700 pt"
+	Landscape      bool   // 该尺寸的页面显示时是否为横向
+	MixedPageSizes bool   // 包含纸张尺寸不同的页面
 }
 
 // NewFileEntry 创建一个新的文件条目
 func NewFileEntry(path string, order int) *FileEntry {
 	return &FileEntry{
 		Path:        path,
-		DisplayName: filepath.Base(path),
+		DisplayName: DisplayBase(path, DefaultFilenameEncodings),
 		Order:       order,
 		IsValid:     true,
 	}
@@ -147,6 +224,57 @@ type Config struct {
 	EnableAutoDecrypt bool     // 是否启用自动解密
 	WindowWidth       int      // 窗口宽度
 	WindowHeight      int      // 窗口高度
+	FilenameEncodings []string // 文件名不是UTF-8时用于显示的回退编码，按顺序尝试
+	MaxConcurrentJobs int      // 任务队列同时运行的合并任务数
+	ShowThumbnails    bool     // 文件列表是否显示首页缩略图，文件很多时可以关闭
+	Language          string   // 界面和命令行输出的语言，如 zh-CN、en-US；为空时按 LC_ALL、LC_MESSAGES、LANG 选择
+
+	// TempFileMaxAge 临时文件的最长保留时间；其他会话遗留的临时文件在所属进程退出且超过该时长后清理
+	TempFileMaxAge time.Duration
+
+	// 临时目录配额：超出时创建临时文件立即失败，而不是在合并中途因磁盘写满失败；0时不限制
+	MaxTempBytes int64 // 临时文件的总字节数上限
+	MaxTempFiles int   // 临时文件数上限
+
+	// TempSweepAge 运行期间定期删除修改时间早于该时长之前的临时文件；0时不定期清理
+	TempSweepAge time.Duration
+
+	// ValidationPolicy 按PDF错误类型名称（PDFError.TypeName）决定验证问题是警告还是失败，
+	// 未列出的类型视为失败。只有警告的文件仍可加入列表并参与合并；配置文件中的条目覆盖默认策略的同名类型
+	ValidationPolicy map[string]ValidationSeverity
+
+	// Profiles 按名称保存的合并配置方案，与内置方案（default、low-memory）同名时替换内置方案
+	Profiles map[string]MergeProfile
+
+	// Clock 任务ID和时间戳、临时会话名称等使用的时间与随机源，nil时使用系统时钟；不写入配置文件，
+	// 测试中可替换为 clock.Fake
+	Clock clock.Clock `json:"-"`
+}
+
+// ValidationSeverity 验证问题的处理方式
+type ValidationSeverity string
+
+const (
+	// ValidationFailure 文件不能加入列表，合并时排除
+	ValidationFailure ValidationSeverity = "failure"
+	// ValidationWarning 文件可以加入列表和合并，界面显示警告
+	ValidationWarning ValidationSeverity = "warning"
+)
+
+// DefaultValidationPolicy 返回默认的验证策略：结构检查未通过（pdfcpu和读取器都拒绝，
+// 但文件头和大小正常）的文件视为警告，合并时仍会尝试修复或跳过；其他错误类型视为失败
+func DefaultValidationPolicy() map[string]ValidationSeverity {
+	return map[string]ValidationSeverity{
+		"Validation Error": ValidationWarning,
+	}
+}
+
+// ValidationSeverityOf 返回错误类型名称对应的处理方式，策略中没有的类型视为失败
+func (c *Config) ValidationSeverityOf(typeName string) ValidationSeverity {
+	if severity, ok := c.ValidationPolicy[typeName]; ok {
+		return severity
+	}
+	return ValidationFailure
 }
 
 // DefaultConfig 返回默认配置
@@ -159,6 +287,12 @@ func DefaultConfig() *Config {
 		EnableAutoDecrypt: true,
 		WindowWidth:       800,
 		WindowHeight:      600,
+		FilenameEncodings: append([]string(nil), DefaultFilenameEncodings...),
+		MaxConcurrentJobs: 1,
+		ShowThumbnails:    true,
+		TempFileMaxAge:    time.Hour,
+		TempSweepAge:      24 * time.Hour,
+		ValidationPolicy:  DefaultValidationPolicy(),
 	}
 }
 
@@ -184,7 +318,7 @@ func getDefaultPasswords() []string {
 	}
 }
 
-// generateJobID 生成唯一的任务ID
-func generateJobID() string {
-	return fmt.Sprintf("job_%d", time.Now().UnixNano())
+// generateJobID 用c的随机源生成唯一的任务ID。使用随机UUID而非时间戳，同一时刻创建的任务也不会冲突。
+func generateJobID(c clock.Clock) string {
+	return "job_" + c.NewUUID()
 }
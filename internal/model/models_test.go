@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestJobStatus_String(t *testing.T) {
@@ -15,6 +17,7 @@ func TestJobStatus_String(t *testing.T) {
 		{JobRunning, "执行中"},
 		{JobCompleted, "已完成"},
 		{JobFailed, "失败"},
+		{JobCancelled, "已取消"},
 		{JobStatus(999), "未知状态"},
 	}
 
@@ -207,9 +210,8 @@ func TestDefaultConfig(t *testing.T) {
 }
 
 func TestGenerateJobID(t *testing.T) {
-	id1 := generateJobID()
-	time.Sleep(1 * time.Millisecond) // 确保时间戳不同
-	id2 := generateJobID()
+	id1 := generateJobID(clock.System())
+	id2 := generateJobID(clock.System()) // 同一时刻生成也不应冲突
 
 	if id1 == id2 {
 		t.Error("Expected different job IDs")
@@ -219,3 +221,30 @@ func TestGenerateJobID(t *testing.T) {
 		t.Error("Expected non-empty job IDs")
 	}
 }
+
+func TestMergeJob_UsesInjectedClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start, 7)
+	job := NewMergeJobWithClock(fake, "a.pdf", nil, "out.pdf")
+	if !job.CreatedAt.Equal(start) {
+		t.Errorf("Expected CreatedAt %v, got %v", start, job.CreatedAt)
+	}
+
+	fake.Advance(time.Minute)
+	job.AddHistory("started", "")
+	if !job.History[0].Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected history time %v, got %v", start.Add(time.Minute), job.History[0].Time)
+	}
+
+	fake.Advance(time.Minute)
+	job.SetCompleted()
+	if !job.CompletedAt.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected CompletedAt %v, got %v", start.Add(2*time.Minute), *job.CompletedAt)
+	}
+
+	// 相同种子得到相同的任务ID
+	again := NewMergeJobWithClock(clock.NewFake(start, 7), "a.pdf", nil, "out.pdf")
+	if again.ID != job.ID {
+		t.Errorf("Expected deterministic job ID %s, got %s", job.ID, again.ID)
+	}
+}
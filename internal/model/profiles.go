@@ -0,0 +1,110 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// 内置的合并配置方案名称
+const (
+	// DefaultProfileName 不指定方案时使用的方案，所有选项都取默认值
+	DefaultProfileName = "default"
+	// LowMemoryProfileName 适合内存较小的机器：限制内存和并发，使用小分块并映射大文件
+	LowMemoryProfileName = "low-memory"
+)
+
+// ErrUnknownProfile 请求的合并配置方案既不是内置方案，也不在配置文件中
+var ErrUnknownProfile = errors.New("未知的合并配置方案")
+
+// MergeProfile 合并配置方案：按名称保存的一组合并、流式处理和写入选项。
+// 数值为0、字符串为空、布尔值为false的字段表示使用默认值
+type MergeProfile struct {
+	Description string // 方案说明，显示在 -help 和界面中
+	Merge       ProfileMergeOptions
+	Streaming   ProfileStreamingOptions
+	Writer      ProfileWriterOptions
+}
+
+// ProfileMergeOptions 方案中的合并选项，对应 MergeOptions 和服务配置的同名选项
+type ProfileMergeOptions struct {
+	MaxMemoryMB     int64  // 最大内存使用量（MB）
+	Linearize       bool   // 线性化输出（快速Web视图）
+	SourceBookmarks bool   // 为每个输入添加顶层书签
+	GenerateTOC     bool   // 在输出开头插入目录页
+	FlattenForms    bool   // 把表单字段展平到页面内容
+	DropAttachments bool   // 移除输出中的附件
+	AllowDuplicates bool   // 合并内容重复的输入
+	MaxOutputSizeMB int64  // 输出大小上限（MB）
+	MaxOutputPages  int    // 输出页数上限
+	MetadataSource  string // 输出元数据的来源：first、custom 或 none
+}
+
+// ProfileStreamingOptions 方案中的流式合并选项，对应 StreamingConfig
+type ProfileStreamingOptions struct {
+	MaxConcurrentChunks  int     // 同时合并的分块数上限
+	ChunkSize            int     // 固定的分块文件数，设置后不再自适应调整
+	MemoryLimit          float64 // 暂停分块合并并清理内存的内存使用比例，0到1之间
+	LargeFileThresholdMB int64   // 读取器和验证器映射输入的大小阈值（MB）
+	DisableMemoryMap     bool    // 不映射大文件，始终直接读取
+}
+
+// ProfileWriterOptions 方案中的写入选项，对应 WriterOptions 和服务配置的输出选项
+type ProfileWriterOptions struct {
+	Optimize        bool // 合并相同的字体和图像，写入对象流和交叉引用流
+	ImageDPI        int  // 配合Optimize把分辨率高于该值的图像降采样
+	BackupOutput    bool // 替换已存在的输出前保留备份
+	BackupRetention int  // 每个输出保留的备份数
+}
+
+// BuiltinProfiles 返回代码中定义的内置方案
+func BuiltinProfiles() map[string]MergeProfile {
+	return map[string]MergeProfile{
+		DefaultProfileName: {
+			Description: "默认选项",
+		},
+		LowMemoryProfileName: {
+			Description: "限制内存和并发，适合内存较小的机器",
+			Merge:       ProfileMergeOptions{MaxMemoryMB: 64},
+			Streaming: ProfileStreamingOptions{
+				MaxConcurrentChunks:  1,
+				ChunkSize:            2,
+				MemoryLimit:          0.6,
+				LargeFileThresholdMB: 1,
+			},
+		},
+	}
+}
+
+// AvailableProfiles 返回内置方案和配置文件中的方案，配置文件中的方案替换同名的内置方案
+func (c *Config) AvailableProfiles() map[string]MergeProfile {
+	profiles := BuiltinProfiles()
+	maps.Copy(profiles, c.Profiles)
+	return profiles
+}
+
+// ProfileNames 按名称排序返回所有可用的方案
+func (c *Config) ProfileNames() []string {
+	profiles := c.AvailableProfiles()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveProfile 返回名称对应的方案，名称为空时返回默认方案。
+// 方案不存在时返回包装ErrUnknownProfile的错误，并列出可用的方案
+func (c *Config) ResolveProfile(name string) (MergeProfile, error) {
+	if name == "" {
+		name = DefaultProfileName
+	}
+	if profile, ok := c.AvailableProfiles()[name]; ok {
+		return profile, nil
+	}
+	return MergeProfile{}, fmt.Errorf("%w: %s（可用的方案: %s）",
+		ErrUnknownProfile, name, strings.Join(c.ProfileNames(), ", "))
+}
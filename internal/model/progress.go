@@ -3,6 +3,8 @@ package model
 import (
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // ProgressTracker 定义进度跟踪器
@@ -16,7 +18,14 @@ type ProgressTracker struct {
 	lastUpdate   time.Time
 	isCompleted  bool
 	isCancelled  bool
+	isFailed     bool
+	errMessage   string
 	callbacks    []ProgressCallback
+	subscribers  map[int]chan ProgressInfo
+	nextSubID    int
+	clock        clock.Clock
+	bytes        *RateEstimator
+	pages        *RateEstimator
 }
 
 // ProgressCallback 定义进度回调函数类型
@@ -32,15 +41,97 @@ type ProgressInfo struct {
 	ElapsedTime   time.Duration
 	IsCompleted   bool
 	IsCancelled   bool
+	IsFailed      bool
+	Error         string
+	Stats         ProgressStats
+}
+
+// ProgressStats 已处理的字节数和页数，以及据此估计的吞吐量和剩余时间
+type ProgressStats struct {
+	BytesDone      int64
+	BytesTotal     int64
+	PagesDone      int
+	PagesTotal     int
+	Throughput     float64       // 平滑后的字节吞吐量（字节/秒），尚无样本时为0
+	PagesPerSecond float64       // 平滑后的页面吞吐量（页/秒），尚无样本时为0
+	ETA            time.Duration // 估计的剩余时间，HasETA为false时无意义
+	HasETA         bool
+}
+
+// IsTerminal 判断是否为终止状态（完成、取消或失败）
+func (pi ProgressInfo) IsTerminal() bool {
+	return pi.IsCompleted || pi.IsCancelled || pi.IsFailed
 }
 
 // NewProgressTracker 创建一个新的进度跟踪器
 func NewProgressTracker(totalSteps int) *ProgressTracker {
+	c := clock.System()
 	return &ProgressTracker{
 		totalSteps: totalSteps,
-		startTime:  time.Now(),
-		lastUpdate: time.Now(),
+		startTime:  c.Now(),
+		lastUpdate: c.Now(),
+		clock:      c,
+		bytes:      NewRateEstimator(),
+		pages:      NewRateEstimator(),
+	}
+}
+
+// SetClock 设置计时使用的时钟并从该时钟的当前时间重新计时，nil表示系统时钟
+func (pt *ProgressTracker) SetClock(c clock.Clock) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.clock = clock.OrSystem(c)
+	pt.startTime = pt.clock.Now()
+	pt.lastUpdate = pt.startTime
+}
+
+// RecordBytes 记录已处理的字节数和总字节数，用于估计吞吐量和剩余时间。
+// 只更新统计，不通知回调，调用方随后的进度更新会带上新的统计。
+func (pt *ProgressTracker) RecordBytes(done, total int64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.bytes.Observe(pt.clock.Now(), done, total)
+}
+
+// RecordPages 记录已处理的页数和总页数，没有字节数时按页数估计剩余时间
+func (pt *ProgressTracker) RecordPages(done, total int) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.pages.Observe(pt.clock.Now(), int64(done), int64(total))
+}
+
+// GetStats 返回吞吐量和剩余时间的快照，供界面定时轮询
+func (pt *ProgressTracker) GetStats() ProgressStats {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	return pt.statsUnsafe()
+}
+
+// statsUnsafe 计算统计快照（调用方需持有锁）。优先按字节估计剩余时间，没有字节数时按页数估计
+func (pt *ProgressTracker) statsUnsafe() ProgressStats {
+	bytesDone, bytesTotal := pt.bytes.Progress()
+	pagesDone, pagesTotal := pt.pages.Progress()
+	stats := ProgressStats{
+		BytesDone:      bytesDone,
+		BytesTotal:     bytesTotal,
+		PagesDone:      int(pagesDone),
+		PagesTotal:     int(pagesTotal),
+		Throughput:     pt.bytes.Rate(),
+		PagesPerSecond: pt.pages.Rate(),
+	}
+	if pt.isCompleted || pt.isCancelled || pt.isFailed {
+		return stats
+	}
+	if eta, ok := pt.bytes.ETA(); ok {
+		stats.ETA, stats.HasETA = eta, true
+	} else if eta, ok := pt.pages.ETA(); ok {
+		stats.ETA, stats.HasETA = eta, true
 	}
+	return stats
 }
 
 // SetCurrentStep 设置当前步骤
@@ -51,7 +142,7 @@ func (pt *ProgressTracker) SetCurrentStep(step int, message string) {
 	pt.currentStep = step
 	pt.stepProgress = 0
 	pt.message = message
-	pt.lastUpdate = time.Now()
+	pt.lastUpdate = pt.clock.Now()
 
 	pt.notifyCallbacks()
 }
@@ -72,7 +163,7 @@ func (pt *ProgressTracker) UpdateStepProgress(progress float64, message string)
 	if message != "" {
 		pt.message = message
 	}
-	pt.lastUpdate = time.Now()
+	pt.lastUpdate = pt.clock.Now()
 
 	pt.notifyCallbacks()
 }
@@ -88,7 +179,7 @@ func (pt *ProgressTracker) Complete(message string) {
 	if message != "" {
 		pt.message = message
 	}
-	pt.lastUpdate = time.Now()
+	pt.lastUpdate = pt.clock.Now()
 
 	pt.notifyCallbacks()
 }
@@ -102,7 +193,22 @@ func (pt *ProgressTracker) Cancel(message string) {
 	if message != "" {
 		pt.message = message
 	}
-	pt.lastUpdate = time.Now()
+	pt.lastUpdate = pt.clock.Now()
+
+	pt.notifyCallbacks()
+}
+
+// Fail 标记进度为失败
+func (pt *ProgressTracker) Fail(err error) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.isFailed = true
+	if err != nil {
+		pt.errMessage = err.Error()
+		pt.message = err.Error()
+	}
+	pt.lastUpdate = pt.clock.Now()
 
 	pt.notifyCallbacks()
 }
@@ -112,23 +218,53 @@ func (pt *ProgressTracker) GetProgress() ProgressInfo {
 	pt.mu.RLock()
 	defer pt.mu.RUnlock()
 
-	totalProgress := 0.0
-	if pt.totalSteps > 0 {
-		totalProgress = (float64(pt.currentStep-1) + pt.stepProgress/100.0) / float64(pt.totalSteps) * 100.0
+	return pt.getProgressUnsafe()
+}
+
+// Subscribe 订阅进度更新。与回调不同，订阅者按顺序收到每次更新；
+// 缓冲区满时丢弃最旧的更新，终止状态的更新总会送达，随后通道关闭。
+// 返回的取消函数可重复调用。
+func (pt *ProgressTracker) Subscribe(buffer int) (<-chan ProgressInfo, func()) {
+	if buffer < 1 {
+		buffer = 1
 	}
 
-	return ProgressInfo{
-		CurrentStep:   pt.currentStep,
-		TotalSteps:    pt.totalSteps,
-		StepProgress:  pt.stepProgress,
-		TotalProgress: totalProgress,
-		Message:       pt.message,
-		ElapsedTime:   time.Since(pt.startTime),
-		IsCompleted:   pt.isCompleted,
-		IsCancelled:   pt.isCancelled,
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	ch := make(chan ProgressInfo, buffer)
+	info := pt.getProgressUnsafe()
+	if info.IsTerminal() {
+		// 已经结束的任务只发送最终状态
+		ch <- info
+		close(ch)
+		return ch, func() {}
+	}
+
+	if pt.subscribers == nil {
+		pt.subscribers = make(map[int]chan ProgressInfo)
+	}
+	id := pt.nextSubID
+	pt.nextSubID++
+	pt.subscribers[id] = ch
+
+	return ch, func() {
+		pt.mu.Lock()
+		defer pt.mu.Unlock()
+		if sub, exists := pt.subscribers[id]; exists {
+			delete(pt.subscribers, id)
+			close(sub)
+		}
 	}
 }
 
+// SubscriberCount 返回当前订阅者数量
+func (pt *ProgressTracker) SubscriberCount() int {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+	return len(pt.subscribers)
+}
+
 // AddCallback 添加进度回调
 func (pt *ProgressTracker) AddCallback(callback ProgressCallback) {
 	pt.mu.Lock()
@@ -143,6 +279,28 @@ func (pt *ProgressTracker) notifyCallbacks() {
 	for _, callback := range pt.callbacks {
 		go callback(info.TotalProgress, info.Message)
 	}
+	pt.notifySubscribers(info)
+}
+
+// notifySubscribers 向订阅者发送更新，不阻塞（调用方需持有锁）
+func (pt *ProgressTracker) notifySubscribers(info ProgressInfo) {
+	for id, ch := range pt.subscribers {
+		select {
+		case ch <- info:
+		default:
+			// 缓冲区已满，丢弃最旧的更新
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- info
+		}
+
+		if info.IsTerminal() {
+			close(ch)
+			delete(pt.subscribers, id)
+		}
+	}
 }
 
 // getProgressUnsafe 获取进度信息（不加锁）
@@ -158,8 +316,91 @@ func (pt *ProgressTracker) getProgressUnsafe() ProgressInfo {
 		StepProgress:  pt.stepProgress,
 		TotalProgress: totalProgress,
 		Message:       pt.message,
-		ElapsedTime:   time.Since(pt.startTime),
+		ElapsedTime:   pt.clock.Now().Sub(pt.startTime),
 		IsCompleted:   pt.isCompleted,
 		IsCancelled:   pt.isCancelled,
+		IsFailed:      pt.isFailed,
+		Error:         pt.errMessage,
+		Stats:         pt.statsUnsafe(),
+	}
+}
+
+// ProgressEvent 对外发布的进度事件，CLI的JSON输出和HTTP事件流共用该格式
+type ProgressEvent struct {
+	Type          string    `json:"type"`
+	JobID         string    `json:"job_id,omitempty"`
+	State         string    `json:"state,omitempty"`
+	CurrentStep   int       `json:"current_step,omitempty"`
+	TotalSteps    int       `json:"total_steps,omitempty"`
+	StepProgress  float64   `json:"step_progress,omitempty"`
+	TotalProgress float64   `json:"total_progress,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Terminal      bool      `json:"terminal,omitempty"`
+	BytesDone     int64     `json:"bytes_done,omitempty"`
+	BytesTotal    int64     `json:"bytes_total,omitempty"`
+	Throughput    float64   `json:"throughput_bps,omitempty"`
+	ETASeconds    float64   `json:"eta_seconds,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// 进度事件类型
+const (
+	ProgressEventProgress  = "progress"
+	ProgressEventHeartbeat = "heartbeat"
+)
+
+// 进度事件中的任务状态
+const (
+	ProgressStateRunning   = "running"
+	ProgressStateCompleted = "completed"
+	ProgressStateCancelled = "cancelled"
+	ProgressStateFailed    = "failed"
+)
+
+// State 返回进度对应的任务状态
+func (pi ProgressInfo) State() string {
+	switch {
+	case pi.IsFailed:
+		return ProgressStateFailed
+	case pi.IsCancelled:
+		return ProgressStateCancelled
+	case pi.IsCompleted:
+		return ProgressStateCompleted
+	default:
+		return ProgressStateRunning
+	}
+}
+
+// NewProgressEvent 根据进度信息创建时间为now的进度事件
+func NewProgressEvent(jobID string, info ProgressInfo, now time.Time) ProgressEvent {
+	event := ProgressEvent{
+		Type:          ProgressEventProgress,
+		JobID:         jobID,
+		State:         info.State(),
+		CurrentStep:   info.CurrentStep,
+		TotalSteps:    info.TotalSteps,
+		StepProgress:  info.StepProgress,
+		TotalProgress: info.TotalProgress,
+		Message:       info.Message,
+		Error:         info.Error,
+		Terminal:      info.IsTerminal(),
+		BytesDone:     info.Stats.BytesDone,
+		BytesTotal:    info.Stats.BytesTotal,
+		Throughput:    info.Stats.Throughput,
+		Time:          now,
+	}
+	if info.Stats.HasETA {
+		event.ETASeconds = info.Stats.ETA.Seconds()
+	}
+	return event
+}
+
+// NewHeartbeatEvent 创建时间为now的心跳事件
+func NewHeartbeatEvent(jobID string, now time.Time) ProgressEvent {
+	return ProgressEvent{
+		Type:  ProgressEventHeartbeat,
+		JobID: jobID,
+		Time:  now,
 	}
 }
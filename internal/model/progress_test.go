@@ -1,10 +1,13 @@
 package model
 
 import (
+	"errors"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestNewProgressTracker(t *testing.T) {
@@ -186,3 +189,149 @@ func TestProgressTracker_ElapsedTime(t *testing.T) {
 		t.Errorf("Expected ElapsedTime >= 10ms, got %v", info.ElapsedTime)
 	}
 }
+
+func TestProgressTracker_SubscribeOrdering(t *testing.T) {
+	tracker := NewProgressTracker(2)
+	updates, unsubscribe := tracker.Subscribe(16)
+	defer unsubscribe()
+
+	tracker.SetCurrentStep(1, "step 1")
+	tracker.UpdateStepProgress(50, "halfway")
+	tracker.SetCurrentStep(2, "step 2")
+	tracker.Complete("done")
+
+	var received []ProgressInfo
+	for info := range updates {
+		received = append(received, info)
+	}
+
+	if len(received) != 4 {
+		t.Fatalf("Expected 4 updates, got %d", len(received))
+	}
+	for i := 1; i < len(received); i++ {
+		if received[i].TotalProgress < received[i-1].TotalProgress {
+			t.Errorf("Updates out of order at %d: %f < %f", i, received[i].TotalProgress, received[i-1].TotalProgress)
+		}
+	}
+	if last := received[len(received)-1]; !last.IsCompleted || !last.IsTerminal() {
+		t.Error("Expected final update to be terminal and completed")
+	}
+	if tracker.SubscriberCount() != 0 {
+		t.Errorf("Expected subscribers to be released after completion, got %d", tracker.SubscriberCount())
+	}
+}
+
+func TestProgressTracker_SubscribeSlowConsumer(t *testing.T) {
+	tracker := NewProgressTracker(100)
+	updates, unsubscribe := tracker.Subscribe(2)
+	defer unsubscribe()
+
+	// A subscriber that never reads must not block the tracker
+	for i := 1; i <= 100; i++ {
+		tracker.SetCurrentStep(i, "working")
+	}
+	tracker.Fail(errors.New("boom"))
+
+	var last ProgressInfo
+	for info := range updates {
+		last = info
+	}
+	if !last.IsFailed || last.Error != "boom" {
+		t.Errorf("Expected terminal failure to be delivered, got %+v", last)
+	}
+}
+
+func TestProgressTracker_Unsubscribe(t *testing.T) {
+	tracker := NewProgressTracker(1)
+	updates, unsubscribe := tracker.Subscribe(1)
+
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := <-updates; ok {
+		t.Error("Expected channel to be closed after unsubscribe")
+	}
+	if tracker.SubscriberCount() != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", tracker.SubscriberCount())
+	}
+
+	// Updates after unsubscribe must not panic on the closed channel
+	tracker.SetCurrentStep(1, "after unsubscribe")
+}
+
+func TestProgressTracker_SubscribeAfterCompletion(t *testing.T) {
+	tracker := NewProgressTracker(1)
+	tracker.Cancel("stopped")
+
+	updates, _ := tracker.Subscribe(1)
+	info, ok := <-updates
+	if !ok || !info.IsCancelled {
+		t.Errorf("Expected cancelled snapshot, got %+v", info)
+	}
+	if _, ok := <-updates; ok {
+		t.Error("Expected channel to be closed after terminal snapshot")
+	}
+}
+
+func TestProgressTracker_Stats(t *testing.T) {
+	tracker := NewProgressTracker(1)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	tracker.SetClock(fake)
+	tracker.SetCurrentStep(1, "merging")
+
+	if stats := tracker.GetStats(); stats.HasETA || stats.Throughput != 0 {
+		t.Errorf("Expected no estimate before any bytes, got %+v", stats)
+	}
+
+	tracker.RecordBytes(0, 10*1024*1024)
+	fake.Advance(time.Second)
+	tracker.RecordBytes(1024*1024, 10*1024*1024)
+
+	stats := tracker.GetStats()
+	if stats.Throughput != 1024*1024 {
+		t.Errorf("Expected throughput 1 MiB/s, got %f", stats.Throughput)
+	}
+	if !stats.HasETA || stats.ETA != 9*time.Second {
+		t.Errorf("Expected ETA 9s, got %v (%v)", stats.ETA, stats.HasETA)
+	}
+
+	// 订阅者收到的进度包含统计
+	updates, unsubscribe := tracker.Subscribe(1)
+	defer unsubscribe()
+	tracker.UpdateStepProgress(10, "")
+	info := <-updates
+	if info.Stats.BytesDone != 1024*1024 || !info.Stats.HasETA {
+		t.Errorf("Expected stats in progress info, got %+v", info.Stats)
+	}
+	if info.ElapsedTime != time.Second {
+		t.Errorf("Expected elapsed 1s from the tracker clock, got %v", info.ElapsedTime)
+	}
+
+	event := NewProgressEvent("job", info, fake.Now())
+	if event.ETASeconds != 9 || event.Throughput != 1024*1024 {
+		t.Errorf("Expected ETA and throughput in event, got %+v", event)
+	}
+
+	tracker.Complete("done")
+	if stats := tracker.GetStats(); stats.HasETA {
+		t.Error("Expected no ETA after completion")
+	}
+}
+
+func TestProgressTracker_PagesETA(t *testing.T) {
+	tracker := NewProgressTracker(1)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	tracker.SetClock(fake)
+
+	tracker.RecordPages(0, 100)
+	fake.Advance(2 * time.Second)
+	tracker.RecordPages(20, 100)
+
+	stats := tracker.GetStats()
+	if stats.PagesPerSecond != 10 {
+		t.Errorf("Expected 10 pages/s, got %f", stats.PagesPerSecond)
+	}
+	if !stats.HasETA || stats.ETA != 8*time.Second {
+		t.Errorf("Expected ETA 8s from pages, got %v (%v)", stats.ETA, stats.HasETA)
+	}
+}
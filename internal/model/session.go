@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// SessionFileName 保存上次会话的文件，与配置文件在同一目录
+	SessionFileName = "session.json"
+	// MaxRecentOutputDirs 最近使用的输出目录的最大数量
+	MaxRecentOutputDirs = 10
+)
+
+// Session 界面关闭时保存的会话：主文件、附加文件（按列表顺序）、输出路径和最近使用的输出目录
+type Session struct {
+	MainFile         string   `json:"main_file,omitempty"`
+	AdditionalFiles  []string `json:"additional_files,omitempty"`
+	OutputPath       string   `json:"output_path,omitempty"`
+	RecentOutputDirs []string `json:"recent_output_dirs,omitempty"` // 最近使用的在前
+}
+
+// SessionPath 返回与配置文件在同一目录的会话文件路径
+func SessionPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), SessionFileName)
+}
+
+// HasFiles 判断会话中是否有可以恢复的文件或输出路径
+func (s *Session) HasFiles() bool {
+	return s.MainFile != "" || len(s.AdditionalFiles) > 0 || s.OutputPath != ""
+}
+
+// AddRecentOutputDir 把目录移到最近使用的输出目录的最前面，超过 MaxRecentOutputDirs 的旧目录被丢弃
+func (s *Session) AddRecentOutputDir(dir string) {
+	if dir == "" {
+		return
+	}
+	dir = filepath.Clean(dir)
+	recent := []string{dir}
+	for _, existing := range s.RecentOutputDirs {
+		if existing != dir && len(recent) < MaxRecentOutputDirs {
+			recent = append(recent, existing)
+		}
+	}
+	s.RecentOutputDirs = recent
+}
+
+// LoadSession 从JSON文件加载会话，文件不存在时返回空会话。
+// 文件无法读取或已损坏时同样返回空会话，并返回说明原因的错误
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Session{}, nil
+		}
+		return &Session{}, fmt.Errorf("无法读取会话文件 %s: %w", path, err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return &Session{}, fmt.Errorf("会话文件 %s 已损坏: %w", path, err)
+	}
+	if len(session.RecentOutputDirs) > MaxRecentOutputDirs {
+		session.RecentOutputDirs = session.RecentOutputDirs[:MaxRecentOutputDirs]
+	}
+	return &session, nil
+}
+
+// SaveSession 把会话写入JSON文件，先写临时文件再替换
+func SaveSession(path string, session *Session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建配置目录: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("无法写入会话文件: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("无法写入会话文件: %w", err)
+	}
+	return nil
+}
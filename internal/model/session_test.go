@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSession_SaveAndLoad(t *testing.T) {
+	path := SessionPath(filepath.Join(t.TempDir(), "pdf-merger", ConfigFileName))
+	session := &Session{
+		MainFile:         "/docs/main.pdf",
+		AdditionalFiles:  []string{"/docs/b.pdf", "/docs/a.pdf"},
+		OutputPath:       "/out/merged.pdf",
+		RecentOutputDirs: []string{"/out"},
+	}
+	if err := SaveSession(path, session); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	if loaded.MainFile != session.MainFile || loaded.OutputPath != session.OutputPath {
+		t.Errorf("Expected %+v, got %+v", session, loaded)
+	}
+	if len(loaded.AdditionalFiles) != 2 || loaded.AdditionalFiles[0] != "/docs/b.pdf" {
+		t.Errorf("Expected additional files in list order, got %v", loaded.AdditionalFiles)
+	}
+	if !loaded.HasFiles() {
+		t.Error("Expected restored session to have files")
+	}
+}
+
+func TestLoadSession_MissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	session, err := LoadSession(filepath.Join(dir, SessionFileName))
+	if err != nil || session == nil || session.HasFiles() {
+		t.Errorf("Expected empty session for missing file, got %+v, %v", session, err)
+	}
+
+	path := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	session, err = LoadSession(path)
+	if err == nil {
+		t.Error("Expected a warning error for corrupt session file")
+	}
+	if session == nil || session.HasFiles() {
+		t.Errorf("Expected empty session for corrupt file, got %+v", session)
+	}
+}
+
+func TestSession_AddRecentOutputDir(t *testing.T) {
+	session := &Session{}
+	for i := 0; i < MaxRecentOutputDirs+3; i++ {
+		session.AddRecentOutputDir(fmt.Sprintf("/out/%d", i))
+	}
+	if len(session.RecentOutputDirs) != MaxRecentOutputDirs {
+		t.Fatalf("Expected %d recent dirs, got %d", MaxRecentOutputDirs, len(session.RecentOutputDirs))
+	}
+	if session.RecentOutputDirs[0] != fmt.Sprintf("/out/%d", MaxRecentOutputDirs+2) {
+		t.Errorf("Expected most recent dir first, got %v", session.RecentOutputDirs)
+	}
+
+	// 再次使用的目录移到最前面，不重复
+	session.AddRecentOutputDir("/out/5/")
+	if session.RecentOutputDirs[0] != "/out/5" || len(session.RecentOutputDirs) != MaxRecentOutputDirs {
+		t.Errorf("Expected reused dir moved to front without duplicates, got %v", session.RecentOutputDirs)
+	}
+	seen := make(map[string]bool)
+	for _, dir := range session.RecentOutputDirs {
+		if seen[dir] {
+			t.Errorf("Duplicate recent dir %s", dir)
+		}
+		seen[dir] = true
+	}
+}
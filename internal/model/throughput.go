@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// 吞吐量估计的默认参数
+const (
+	// DefaultRateSmoothing 指数加权移动平均中新样本的权重，越小越平滑
+	DefaultRateSmoothing = 0.3
+	// DefaultRateInterval 两次采样的最小间隔，间隔内的更新只累积进度，避免极短间隔放大速率
+	DefaultRateInterval = 500 * time.Millisecond
+)
+
+// RateEstimator 根据带时间戳的进度样本估计吞吐量和剩余时间。
+// 速率使用指数加权移动平均（EWMA）平滑，单次突发或停顿不会让剩余时间大幅跳动。
+// RateEstimator 不是并发安全的，由调用方加锁。
+type RateEstimator struct {
+	smoothing float64
+	interval  time.Duration
+
+	done     int64
+	total    int64
+	rate     float64
+	lastTime time.Time
+	lastDone int64
+	started  bool
+	sampled  bool
+}
+
+// NewRateEstimator 使用默认参数创建吞吐量估计器
+func NewRateEstimator() *RateEstimator {
+	return &RateEstimator{
+		smoothing: DefaultRateSmoothing,
+		interval:  DefaultRateInterval,
+	}
+}
+
+// Observe 记录now时刻已完成done、总量total。第一次调用只建立基准，
+// 距离上次采样不足最小间隔时只更新进度，不计算速率；done减少时视为重新开始。
+func (e *RateEstimator) Observe(now time.Time, done, total int64) {
+	e.done = done
+	e.total = total
+
+	if !e.started || done < e.lastDone {
+		e.started = true
+		e.sampled = false
+		e.rate = 0
+		e.lastTime = now
+		e.lastDone = done
+		return
+	}
+
+	elapsed := now.Sub(e.lastTime)
+	if elapsed < e.interval || elapsed <= 0 {
+		return
+	}
+
+	instant := float64(done-e.lastDone) / elapsed.Seconds()
+	if e.sampled {
+		e.rate = e.smoothing*instant + (1-e.smoothing)*e.rate
+	} else {
+		e.rate = instant
+		e.sampled = true
+	}
+	e.lastTime = now
+	e.lastDone = done
+}
+
+// Rate 返回平滑后的速率（单位/秒），尚无有效样本时返回0
+func (e *RateEstimator) Rate() float64 {
+	return e.rate
+}
+
+// ETA 返回按平滑速率估计的剩余时间；总量未知或速率为0时第二个返回值为false
+func (e *RateEstimator) ETA() (time.Duration, bool) {
+	if e.total <= 0 || !e.sampled || e.rate <= 0 {
+		return 0, false
+	}
+	remaining := e.total - e.done
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(remaining) / e.rate * float64(time.Second)), true
+}
+
+// Progress 返回最近一次记录的已完成量和总量
+func (e *RateEstimator) Progress() (done, total int64) {
+	return e.done, e.total
+}
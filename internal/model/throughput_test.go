@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateEstimator_NoSamples(t *testing.T) {
+	e := NewRateEstimator()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := e.ETA(); ok {
+		t.Error("Expected no ETA before any sample")
+	}
+
+	// 第一次记录只建立基准，同一时刻的第二次记录不应除以零
+	e.Observe(start, 0, 1000)
+	e.Observe(start, 100, 1000)
+	if e.Rate() != 0 {
+		t.Errorf("Expected rate 0 without elapsed time, got %f", e.Rate())
+	}
+	if _, ok := e.ETA(); ok {
+		t.Error("Expected no ETA without elapsed time")
+	}
+}
+
+func TestRateEstimator_ETA(t *testing.T) {
+	e := NewRateEstimator()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e.Observe(start, 0, 1000)
+	e.Observe(start.Add(time.Second), 100, 1000)
+
+	if e.Rate() != 100 {
+		t.Errorf("Expected rate 100, got %f", e.Rate())
+	}
+	eta, ok := e.ETA()
+	if !ok || eta != 9*time.Second {
+		t.Errorf("Expected ETA 9s, got %v (%v)", eta, ok)
+	}
+
+	// 间隔不足最小采样间隔时只更新进度
+	e.Observe(start.Add(time.Second+DefaultRateInterval/2), 500, 1000)
+	if e.Rate() != 100 {
+		t.Errorf("Expected rate unchanged within the sampling interval, got %f", e.Rate())
+	}
+	eta, _ = e.ETA()
+	if eta != 5*time.Second {
+		t.Errorf("Expected ETA 5s after progress update, got %v", eta)
+	}
+
+	e.Observe(start.Add(5*time.Second), 1000, 1000)
+	if eta, ok := e.ETA(); !ok || eta != 0 {
+		t.Errorf("Expected ETA 0 when done, got %v (%v)", eta, ok)
+	}
+}
+
+func TestRateEstimator_SmoothsBursts(t *testing.T) {
+	e := NewRateEstimator()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e.Observe(start, 0, 100000)
+	e.Observe(start.Add(time.Second), 100, 100000)
+	// 一秒内突然处理了10000字节，平滑后的速率不应直接跳到10000
+	e.Observe(start.Add(2*time.Second), 10100, 100000)
+
+	want := DefaultRateSmoothing*10000 + (1-DefaultRateSmoothing)*100
+	if e.Rate() != want {
+		t.Errorf("Expected smoothed rate %f, got %f", want, e.Rate())
+	}
+}
+
+func TestRateEstimator_RestartOnRegression(t *testing.T) {
+	e := NewRateEstimator()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	e.Observe(start, 0, 1000)
+	e.Observe(start.Add(time.Second), 500, 1000)
+	e.Observe(start.Add(2*time.Second), 100, 1000)
+
+	if e.Rate() != 0 {
+		t.Errorf("Expected rate reset after progress went backwards, got %f", e.Rate())
+	}
+	if done, total := e.Progress(); done != 100 || total != 1000 {
+		t.Errorf("Expected progress 100/1000, got %d/%d", done, total)
+	}
+}
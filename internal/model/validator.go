@@ -149,6 +149,18 @@ func (v *Validator) ValidateConfig(config *Config) error {
 		return &ValidationError{Field: "WindowHeight", Message: "must be between 300 and 3000"}
 	}
 
+	if config.MaxTempBytes < 0 {
+		return &ValidationError{Field: "MaxTempBytes", Message: "cannot be negative"}
+	}
+
+	if config.MaxTempFiles < 0 {
+		return &ValidationError{Field: "MaxTempFiles", Message: "cannot be negative"}
+	}
+
+	if config.MaxConcurrentJobs < 0 || config.MaxConcurrentJobs > 16 {
+		return &ValidationError{Field: "MaxConcurrentJobs", Message: "must be between 0 and 16"}
+	}
+
 	// 验证密码列表
 	for i, password := range config.CommonPasswords {
 		if len(password) > 100 {
@@ -159,6 +171,45 @@ func (v *Validator) ValidateConfig(config *Config) error {
 		}
 	}
 
+	// 验证合并配置方案
+	for name, profile := range config.Profiles {
+		if err := v.validateProfile(name, profile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateProfile 验证合并配置方案中的数值范围和元数据来源
+func (v *Validator) validateProfile(name string, profile MergeProfile) error {
+	field := func(f string) string { return fmt.Sprintf("Profiles[%s].%s", name, f) }
+
+	if strings.TrimSpace(name) == "" {
+		return &ValidationError{Field: "Profiles", Message: "profile name cannot be empty"}
+	}
+	for f, value := range map[string]int64{
+		"Merge.MaxMemoryMB":              profile.Merge.MaxMemoryMB,
+		"Merge.MaxOutputSizeMB":          profile.Merge.MaxOutputSizeMB,
+		"Merge.MaxOutputPages":           int64(profile.Merge.MaxOutputPages),
+		"Streaming.MaxConcurrentChunks":  int64(profile.Streaming.MaxConcurrentChunks),
+		"Streaming.ChunkSize":            int64(profile.Streaming.ChunkSize),
+		"Streaming.LargeFileThresholdMB": profile.Streaming.LargeFileThresholdMB,
+		"Writer.ImageDPI":                int64(profile.Writer.ImageDPI),
+		"Writer.BackupRetention":         int64(profile.Writer.BackupRetention),
+	} {
+		if value < 0 {
+			return &ValidationError{Field: field(f), Message: "cannot be negative"}
+		}
+	}
+	if profile.Streaming.MemoryLimit < 0 || profile.Streaming.MemoryLimit > 1 {
+		return &ValidationError{Field: field("Streaming.MemoryLimit"), Message: "must be between 0 and 1"}
+	}
+	switch profile.Merge.MetadataSource {
+	case "", "first", "custom", "none":
+	default:
+		return &ValidationError{Field: field("Merge.MetadataSource"), Message: "must be first, custom or none"}
+	}
 	return nil
 }
 
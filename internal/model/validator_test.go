@@ -1,6 +1,8 @@
 package model
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -165,6 +167,29 @@ func TestValidator_ValidateConfig(t *testing.T) {
 	}
 }
 
+func TestValidator_ValidateConfigProfiles(t *testing.T) {
+	validator := NewValidator()
+
+	config := DefaultConfig()
+	config.Profiles = map[string]MergeProfile{"fast": {Streaming: ProfileStreamingOptions{MaxConcurrentChunks: 8}}}
+	if err := validator.ValidateConfig(config); err != nil {
+		t.Errorf("Expected valid profile to pass validation, got error: %v", err)
+	}
+
+	for name, profile := range map[string]MergeProfile{
+		"negative":     {Merge: ProfileMergeOptions{MaxOutputPages: -1}},
+		"memory-limit": {Streaming: ProfileStreamingOptions{MemoryLimit: 1.5}},
+		"metadata":     {Merge: ProfileMergeOptions{MetadataSource: "last"}},
+	} {
+		config.Profiles = map[string]MergeProfile{name: profile}
+		err := validator.ValidateConfig(config)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || !strings.HasPrefix(validationErr.Field, "Profiles["+name+"]") {
+			t.Errorf("Expected validation error for profile %s, got %v", name, err)
+		}
+	}
+}
+
 func TestValidator_ValidateFileList(t *testing.T) {
 	validator := NewValidator()
 
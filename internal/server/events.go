@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// 事件流支持的内容类型
+const (
+	ContentTypeSSE    = "text/event-stream"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// DefaultHeartbeatInterval 默认心跳间隔，防止代理因空闲断开长连接
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// eventBufferSize 每个订阅者的事件缓冲区大小
+const eventBufferSize = 64
+
+// TrackerLookup 按任务ID查找进度跟踪器
+type TrackerLookup func(jobID string) (*model.ProgressTracker, bool)
+
+// EventsHandler 处理 GET /jobs/{id}/events，以SSE或NDJSON推送任务进度
+type EventsHandler struct {
+	lookup            TrackerLookup
+	heartbeatInterval time.Duration
+	clock             clock.Clock // 事件时间戳使用的时钟
+}
+
+// NewEventsHandler 创建事件流处理器，heartbeat<=0时使用默认心跳间隔
+func NewEventsHandler(lookup TrackerLookup, heartbeat time.Duration) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+	return &EventsHandler{
+		lookup:            lookup,
+		heartbeatInterval: heartbeat,
+		clock:             clock.System(),
+	}
+}
+
+// ServeHTTP 实现http.Handler
+func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobID, ok := parseEventsPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	tracker, exists := h.lookup(jobID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("job %s not found", jobID), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	writer := newEventWriter(w, negotiateContentType(r.Header.Get("Accept")))
+	w.Header().Set("Content-Type", writer.contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	updates, unsubscribe := tracker.Subscribe(eventBufferSize)
+	defer unsubscribe()
+
+	// 先发送当前快照，晚连接的客户端也能立即看到任务状态
+	snapshot := tracker.GetProgress()
+	if err := writer.write(model.NewProgressEvent(jobID, snapshot, h.clock.Now())); err != nil {
+		return
+	}
+	flusher.Flush()
+	if snapshot.IsTerminal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// 客户端断开，退出并通过defer取消订阅
+			return
+		case info, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err := writer.write(model.NewProgressEvent(jobID, info, h.clock.Now())); err != nil {
+				return
+			}
+			flusher.Flush()
+			if info.IsTerminal() {
+				return
+			}
+		case <-heartbeat.C:
+			if err := writer.heartbeat(jobID, h.clock.Now()); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseEventsPath 从 /jobs/{id}/events 中解析任务ID
+func parseEventsPath(path string) (string, bool) {
+	trimmed := strings.TrimPrefix(path, "/jobs/")
+	if trimmed == path {
+		return "", false
+	}
+	jobID := strings.TrimSuffix(trimmed, "/events")
+	if jobID == trimmed || jobID == "" || strings.Contains(jobID, "/") {
+		return "", false
+	}
+	return jobID, true
+}
+
+// negotiateContentType 根据Accept头选择事件格式，默认NDJSON
+func negotiateContentType(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		switch mediaType {
+		case ContentTypeSSE:
+			return ContentTypeSSE
+		case ContentTypeNDJSON:
+			return ContentTypeNDJSON
+		}
+	}
+	return ContentTypeNDJSON
+}
+
+// eventWriter 按协商的格式写出事件
+type eventWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	seq         int
+}
+
+func newEventWriter(w http.ResponseWriter, contentType string) *eventWriter {
+	return &eventWriter{w: w, contentType: contentType}
+}
+
+// write 写出一个进度事件
+func (ew *eventWriter) write(event model.ProgressEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if ew.contentType == ContentTypeSSE {
+		ew.seq++
+		_, err = fmt.Fprintf(ew.w, "id: %d\nevent: %s\ndata: %s\n\n", ew.seq, event.Type, data)
+		return err
+	}
+	_, err = fmt.Fprintf(ew.w, "%s\n", data)
+	return err
+}
+
+// heartbeat 写出时间为now的心跳。SSE使用注释行，不会触发客户端的事件回调
+func (ew *eventWriter) heartbeat(jobID string, now time.Time) error {
+	if ew.contentType == ContentTypeSSE {
+		_, err := fmt.Fprint(ew.w, ": heartbeat\n\n")
+		return err
+	}
+	return ew.write(model.NewHeartbeatEvent(jobID, now))
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// newTestServer 创建只包含一个任务的事件流测试服务器
+func newTestServer(t *testing.T, jobID string, tracker *model.ProgressTracker, heartbeat time.Duration) *httptest.Server {
+	t.Helper()
+	handler := NewEventsHandler(func(id string) (*model.ProgressTracker, bool) {
+		if id == jobID {
+			return tracker, true
+		}
+		return nil, false
+	}, heartbeat)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// openStream 发起事件流请求
+func openStream(t *testing.T, ctx context.Context, url, accept string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求事件流失败: %v", err)
+	}
+	return resp
+}
+
+// readNDJSON 读取NDJSON事件直到流结束
+func readNDJSON(t *testing.T, resp *http.Response) []model.ProgressEvent {
+	t.Helper()
+	var events []model.ProgressEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event model.ProgressEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("解析事件失败: %v (%s)", err, scanner.Text())
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// runMerge 模拟一次小型合并的进度变化
+func runMerge(tracker *model.ProgressTracker, subscribed func() bool) {
+	for !subscribed() {
+		time.Sleep(time.Millisecond)
+	}
+	tracker.SetCurrentStep(1, "验证文件")
+	tracker.UpdateStepProgress(100, "验证完成")
+	tracker.SetCurrentStep(2, "合并文件")
+	tracker.UpdateStepProgress(50, "合并中")
+	tracker.UpdateStepProgress(100, "合并完成")
+	tracker.Complete("完成")
+}
+
+func TestEventsHandler_NDJSONOrderingAndTerminal(t *testing.T) {
+	tracker := model.NewProgressTracker(2)
+	server := newTestServer(t, "job-1", tracker, time.Hour)
+
+	resp := openStream(t, context.Background(), server.URL+"/jobs/job-1/events", ContentTypeNDJSON)
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != ContentTypeNDJSON {
+		t.Fatalf("期望Content-Type %s，实际 %s", ContentTypeNDJSON, ct)
+	}
+
+	go runMerge(tracker, func() bool { return tracker.SubscriberCount() > 0 })
+	events := readNDJSON(t, resp)
+
+	if len(events) < 2 {
+		t.Fatalf("期望至少2个事件，实际 %d", len(events))
+	}
+	for i, event := range events {
+		if event.JobID != "job-1" {
+			t.Errorf("事件 %d 的任务ID错误: %s", i, event.JobID)
+		}
+		if i > 0 && event.TotalProgress < events[i-1].TotalProgress {
+			t.Errorf("事件 %d 进度倒退: %f < %f", i, event.TotalProgress, events[i-1].TotalProgress)
+		}
+		if event.Terminal && i != len(events)-1 {
+			t.Errorf("终止事件 %d 之后不应再有事件", i)
+		}
+	}
+	last := events[len(events)-1]
+	if !last.Terminal || last.State != model.ProgressStateCompleted {
+		t.Errorf("期望最后一个事件为completed终止事件，实际: %+v", last)
+	}
+}
+
+func TestEventsHandler_SSEFormat(t *testing.T) {
+	tracker := model.NewProgressTracker(1)
+	server := newTestServer(t, "job-sse", tracker, time.Hour)
+
+	resp := openStream(t, context.Background(), server.URL+"/jobs/job-sse/events", ContentTypeSSE)
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != ContentTypeSSE {
+		t.Fatalf("期望Content-Type %s，实际 %s", ContentTypeSSE, ct)
+	}
+
+	go func() {
+		for tracker.SubscriberCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		tracker.Fail(errors.New("输入文件损坏"))
+	}()
+
+	var dataLines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			dataLines = append(dataLines, data)
+		}
+	}
+
+	if len(dataLines) != 2 {
+		t.Fatalf("期望2个SSE事件（快照和失败），实际 %d", len(dataLines))
+	}
+	var last model.ProgressEvent
+	if err := json.Unmarshal([]byte(dataLines[1]), &last); err != nil {
+		t.Fatalf("解析SSE数据失败: %v", err)
+	}
+	if last.State != model.ProgressStateFailed || last.Error != "输入文件损坏" || !last.Terminal {
+		t.Errorf("期望失败终止事件，实际: %+v", last)
+	}
+}
+
+func TestEventsHandler_Heartbeat(t *testing.T) {
+	tracker := model.NewProgressTracker(1)
+	server := newTestServer(t, "job-hb", tracker, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp := openStream(t, ctx, server.URL+"/jobs/job-hb/events", ContentTypeNDJSON)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event model.ProgressEvent
+		json.Unmarshal(scanner.Bytes(), &event)
+		if event.Type == model.ProgressEventHeartbeat {
+			tracker.Complete("完成")
+			return
+		}
+	}
+	t.Error("空闲时未收到心跳事件")
+}
+
+func TestEventsHandler_ClientDisconnectReleasesSubscriber(t *testing.T) {
+	tracker := model.NewProgressTracker(3)
+	server := newTestServer(t, "job-2", tracker, time.Hour)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := openStream(t, ctx, server.URL+"/jobs/job-2/events", ContentTypeNDJSON)
+
+	// 读取初始快照后断开连接
+	reader := bufio.NewReader(resp.Body)
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("读取初始事件失败: %v", err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for tracker.SubscriberCount() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count := tracker.SubscriberCount(); count != 0 {
+		t.Fatalf("断开后订阅者未释放，剩余 %d", count)
+	}
+
+	// 任务继续推进不应阻塞
+	tracker.SetCurrentStep(1, "继续")
+
+	http.DefaultClient.CloseIdleConnections()
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > baseline {
+		t.Errorf("断开后存在goroutine泄漏: 基线 %d，当前 %d", baseline, after)
+	}
+}
+
+func TestEventsHandler_UnknownJob(t *testing.T) {
+	server := newTestServer(t, "job-1", model.NewProgressTracker(1), time.Hour)
+
+	for _, path := range []string{"/jobs/missing/events", "/jobs/job-1", "/other"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s 期望404，实际 %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	cases := map[string]string{
+		"":                                 ContentTypeNDJSON,
+		"*/*":                              ContentTypeNDJSON,
+		"text/event-stream":                ContentTypeSSE,
+		"application/x-ndjson;q=0.9":       ContentTypeNDJSON,
+		"text/html, text/event-stream;q=1": ContentTypeSSE,
+	}
+	for accept, expected := range cases {
+		if got := negotiateContentType(accept); got != expected {
+			t.Errorf("Accept %q: 期望 %s，实际 %s", accept, expected, got)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+const (
+	// TokenEnv 保存访问令牌的环境变量，非空时所有请求都需要 Authorization: Bearer <令牌>
+	TokenEnv = "PDF_MERGER_SERVER_TOKEN"
+	// DefaultResultTTL 任务结束后保留上传文件和输出的默认时长
+	DefaultResultTTL = time.Hour
+	// DefaultMaxUploadSize 没有配置上传大小上限且 Config.MaxMemoryUsage 为0时的上限
+	DefaultMaxUploadSize = 100 * 1024 * 1024
+	// JobsDirName 临时目录下存放服务任务文件的子目录，每个任务一个目录
+	JobsDirName = "server-jobs"
+)
+
+// maxOptionsSize options 字段JSON的最大字节数
+const maxOptionsSize = 64 * 1024
+
+// 任务状态，见 JobStatus.State
+const (
+	StatePending   = "pending"
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// MergeOptions POST /merge 中 options 字段的JSON
+type MergeOptions struct {
+	OutputName           string         `json:"output_name,omitempty"`           // 下载时的文件名，默认 merged.pdf
+	Strict               bool           `json:"strict,omitempty"`                // 有无效的上传文件时拒绝任务，而不是跳过
+	GenerateTOC          bool           `json:"generate_toc,omitempty"`          // 在输出开头插入目录页
+	NormalizeOrientation bool           `json:"normalize_orientation,omitempty"` // 合并前把 /Rotate 写入页面内容
+	Rotations            map[string]int `json:"rotations,omitempty"`             // 按上传文件名指定的顺时针旋转角度
+}
+
+// JobStatus GET /jobs/{id} 的响应
+type JobStatus struct {
+	ID           string     `json:"id"`
+	State        string     `json:"state"`
+	Progress     float64    `json:"progress"` // 百分比
+	Message      string     `json:"message,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Files        []string   `json:"files"`                   // 参与合并的上传文件名，按合并顺序
+	SkippedFiles []string   `json:"skipped_files,omitempty"` // 因无效而跳过的上传文件名
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	ResultURL    string     `json:"result_url,omitempty"` // 任务完成后下载输出的地址
+}
+
+// Options 合并服务的配置
+type Options struct {
+	Token         string        // 访问令牌，为空时不验证
+	MaxUploadSize int64         // 一次 POST /merge 的最大字节数，0时使用 Config.MaxMemoryUsage
+	ResultTTL     time.Duration // 任务结束后保留文件的时长，0时使用 DefaultResultTTL
+	Heartbeat     time.Duration // 事件流的心跳间隔，0时使用 DefaultHeartbeatInterval
+	Clock         clock.Clock   // nil时使用系统时钟
+}
+
+// Server 以HTTP提供合并服务：POST /merge 上传文件并创建任务，GET /jobs/{id} 查询进度，
+// GET /jobs/{id}/events 订阅进度事件，GET /jobs/{id}/result 下载输出，DELETE /jobs/{id} 取消或删除任务。
+// 任务通过控制器的任务队列执行，同时运行的任务数由 Config.MaxConcurrentJobs 决定
+type Server struct {
+	controller *controller.Controller
+	options    Options
+	clock      clock.Clock
+	root       string
+	events     *EventsHandler
+
+	mu   sync.Mutex
+	jobs map[string]*serverJob
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// serverJob 服务创建的一个任务，字段由 Server.mu 保护
+type serverJob struct {
+	job        *model.MergeJob
+	dir        string
+	outputName string
+	files      []string
+	skipped    []string
+	tracker    *model.ProgressTracker
+
+	state      string
+	message    string
+	err        string
+	finishedAt time.Time
+}
+
+// New 创建合并服务并启动过期任务的清理协程，使用完毕后调用 Close
+func New(ctrl *controller.Controller, options Options) *Server {
+	if options.ResultTTL <= 0 {
+		options.ResultTTL = DefaultResultTTL
+	}
+	if options.MaxUploadSize <= 0 {
+		options.MaxUploadSize = DefaultMaxUploadSize
+		if ctrl.Config != nil && ctrl.Config.MaxMemoryUsage > 0 {
+			options.MaxUploadSize = ctrl.Config.MaxMemoryUsage
+		}
+	}
+	base := os.TempDir()
+	if ctrl.Config != nil && ctrl.Config.TempDirectory != "" {
+		base = ctrl.Config.TempDirectory
+	}
+
+	s := &Server{
+		controller: ctrl,
+		options:    options,
+		clock:      clock.OrSystem(options.Clock),
+		root:       filepath.Join(base, JobsDirName),
+		jobs:       make(map[string]*serverJob),
+		stop:       make(chan struct{}),
+	}
+	s.events = NewEventsHandler(s.tracker, options.Heartbeat)
+	s.events.clock = s.clock
+	go s.cleanupLoop()
+	return s
+}
+
+// Close 停止清理协程，取消尚未结束的任务并删除已结束任务的文件
+func (s *Server) Close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	s.mu.Lock()
+	var unfinished []string
+	for id, sj := range s.jobs {
+		if sj.finishedAt.IsZero() {
+			unfinished = append(unfinished, id)
+			continue
+		}
+		os.RemoveAll(sj.dir)
+		delete(s.jobs, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range unfinished {
+		s.controller.CancelJob(id)
+	}
+}
+
+// ServeHTTP 实现http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pdf-merger"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == "/merge" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleMerge(w, r)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	if rest == r.URL.Path || rest == "" {
+		http.NotFound(w, r)
+		return
+	}
+	jobID, sub, _ := strings.Cut(rest, "/")
+	switch sub {
+	case "events":
+		s.events.ServeHTTP(w, r)
+	case "result":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleResult(w, r, jobID)
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			s.handleStatus(w, jobID)
+		case http.MethodDelete:
+			s.handleDelete(w, jobID)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorized 检查请求的访问令牌，没有配置令牌时总是通过
+func (s *Server) authorized(r *http.Request) bool {
+	if s.options.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.options.Token)) == 1
+}
+
+// handleMerge 处理 POST /merge：multipart 中的 files 字段为按顺序合并的PDF文件，options 字段为 MergeOptions。
+// 上传的文件直接写入任务目录，不在内存中缓存；请求体超过 MaxUploadSize 时返回413
+func (s *Server) handleMerge(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.options.MaxUploadSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	if err := os.MkdirAll(s.root, 0755); err != nil {
+		http.Error(w, "cannot create job directory", http.StatusInternalServerError)
+		return
+	}
+	dir, err := os.MkdirTemp(s.root, "job-")
+	if err != nil {
+		http.Error(w, "cannot create job directory", http.StatusInternalServerError)
+		return
+	}
+	accepted := false
+	defer func() {
+		if !accepted {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	var options MergeOptions
+	var names, paths []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			uploadError(w, err)
+			return
+		}
+		switch part.FormName() {
+		case "options":
+			if err := json.NewDecoder(io.LimitReader(part, maxOptionsSize)).Decode(&options); err != nil {
+				http.Error(w, fmt.Sprintf("invalid options: %v", err), http.StatusBadRequest)
+				return
+			}
+		case "files":
+			name := uploadName(part.FileName())
+			path := filepath.Join(dir, fmt.Sprintf("%03d_%s", len(paths)+1, name))
+			if err := saveUpload(path, part); err != nil {
+				uploadError(w, err)
+				return
+			}
+			names = append(names, name)
+			paths = append(paths, path)
+		}
+		part.Close()
+	}
+	if len(paths) < 2 {
+		http.Error(w, "at least two files are required", http.StatusBadRequest)
+		return
+	}
+
+	pathsByName := make(map[string]string, len(names))
+	for i, name := range names {
+		pathsByName[name] = paths[i]
+	}
+	rotations := make(map[string]int, len(options.Rotations))
+	for name, degrees := range options.Rotations {
+		path, ok := pathsByName[name]
+		if !ok {
+			http.Error(w, fmt.Sprintf("rotation for unknown file %s", name), http.StatusBadRequest)
+			return
+		}
+		if degrees%90 != 0 || degrees < 0 || degrees >= 360 {
+			http.Error(w, fmt.Sprintf("invalid rotation %d for %s", degrees, name), http.StatusBadRequest)
+			return
+		}
+		rotations[path] = degrees
+	}
+
+	// 与CLI相同：无效的文件按 strict 拒绝或跳过
+	var validNames, validPaths, skipped []string
+	for i, path := range paths {
+		if err := s.controller.ValidateFile(path); err != nil {
+			if options.Strict {
+				http.Error(w, fmt.Sprintf("invalid file %s: %v", names[i], err), http.StatusUnprocessableEntity)
+				return
+			}
+			skipped = append(skipped, names[i])
+			continue
+		}
+		validNames = append(validNames, names[i])
+		validPaths = append(validPaths, path)
+	}
+	if len(validPaths) < 2 {
+		http.Error(w, "fewer than two valid PDF files", http.StatusUnprocessableEntity)
+		return
+	}
+
+	job := model.NewMergeJobWithClock(s.clock, validPaths[0], validPaths[1:], filepath.Join(dir, "output.pdf"))
+	job.GenerateTOC = options.GenerateTOC
+	job.NormalizeOrientation = options.NormalizeOrientation
+	if len(rotations) > 0 {
+		job.Rotations = rotations
+	}
+
+	outputName := uploadName(options.OutputName)
+	if options.OutputName == "" {
+		outputName = "merged.pdf"
+	}
+	sj := &serverJob{
+		job:        job,
+		dir:        dir,
+		outputName: outputName,
+		files:      validNames,
+		skipped:    skipped,
+		tracker:    model.NewProgressTracker(1),
+		state:      StatePending,
+	}
+	sj.tracker.SetCurrentStep(1, "等待执行")
+
+	s.mu.Lock()
+	s.jobs[job.ID] = sj
+	s.mu.Unlock()
+	unsubscribe := s.controller.SubscribeJob(job.ID, s.jobCallbacks(sj))
+	if err := s.controller.EnqueueJob(job); err != nil {
+		unsubscribe()
+		s.mu.Lock()
+		delete(s.jobs, job.ID)
+		s.mu.Unlock()
+		http.Error(w, fmt.Sprintf("cannot enqueue job: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	accepted = true
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, s.status(sj))
+}
+
+// jobCallbacks 把控制器的任务回调转换为服务中的任务状态和进度事件
+func (s *Server) jobCallbacks(sj *serverJob) controller.JobCallbacks {
+	return controller.JobCallbacks{
+		Progress: func(jobID string, progress float64, status, detail string) {
+			s.mu.Lock()
+			if sj.finishedAt.IsZero() {
+				sj.state = StateRunning
+				sj.message = strings.TrimSuffix(status+": "+detail, ": ")
+			}
+			s.mu.Unlock()
+			sj.tracker.UpdateStepProgress(progress*100, detail)
+		},
+		Error: func(jobID string, err error) {
+			cancelled := errors.Is(err, context.Canceled) || errors.Is(err, controller.ErrJobCancelled)
+			s.mu.Lock()
+			sj.finishedAt = s.clock.Now()
+			sj.err = err.Error()
+			sj.state = StateFailed
+			if cancelled {
+				sj.state = StateCancelled
+			}
+			s.mu.Unlock()
+			if cancelled {
+				sj.tracker.Cancel(err.Error())
+			} else {
+				sj.tracker.Fail(err)
+			}
+		},
+		Completion: func(jobID string, outputPath string) {
+			s.mu.Lock()
+			sj.finishedAt = s.clock.Now()
+			sj.state = StateCompleted
+			sj.message = "合并完成"
+			s.mu.Unlock()
+			sj.tracker.Complete("合并完成")
+		},
+	}
+}
+
+// handleStatus 处理 GET /jobs/{id}
+func (s *Server) handleStatus(w http.ResponseWriter, jobID string) {
+	s.mu.Lock()
+	sj, ok := s.jobs[jobID]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %s not found", jobID), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status(sj))
+}
+
+// handleResult 处理 GET /jobs/{id}/result，任务尚未完成时返回409
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request, jobID string) {
+	s.mu.Lock()
+	sj, ok := s.jobs[jobID]
+	state := ""
+	if ok {
+		state = sj.state
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %s not found", jobID), http.StatusNotFound)
+		return
+	}
+	if state != StateCompleted {
+		http.Error(w, fmt.Sprintf("job %s is %s", jobID, state), http.StatusConflict)
+		return
+	}
+
+	f, err := os.Open(sj.job.OutputPath)
+	if err != nil {
+		http.Error(w, "output is no longer available", http.StatusGone)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "output is no longer available", http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sj.outputName))
+	http.ServeContent(w, r, sj.outputName, info.ModTime(), f)
+}
+
+// handleDelete 处理 DELETE /jobs/{id}：取消等待或运行中的任务（返回202），删除已结束的任务及其文件（返回204）
+func (s *Server) handleDelete(w http.ResponseWriter, jobID string) {
+	s.mu.Lock()
+	sj, ok := s.jobs[jobID]
+	finished := ok && !sj.finishedAt.IsZero()
+	if finished {
+		delete(s.jobs, jobID)
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %s not found", jobID), http.StatusNotFound)
+		return
+	}
+	if finished {
+		os.RemoveAll(sj.dir)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := s.controller.CancelJob(jobID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.status(sj))
+}
+
+// status 返回任务的当前状态
+func (s *Server) status(sj *serverJob) JobStatus {
+	progress := sj.tracker.GetProgress()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := JobStatus{
+		ID:           sj.job.ID,
+		State:        sj.state,
+		Progress:     progress.TotalProgress,
+		Message:      sj.message,
+		Error:        sj.err,
+		Files:        sj.files,
+		SkippedFiles: sj.skipped,
+		CreatedAt:    sj.job.CreatedAt,
+	}
+	if !sj.finishedAt.IsZero() {
+		finishedAt := sj.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	if sj.state == StateCompleted {
+		status.ResultURL = "/jobs/" + sj.job.ID + "/result"
+	}
+	return status
+}
+
+// tracker 按任务ID查找进度跟踪器，供事件流使用
+func (s *Server) tracker(jobID string) (*model.ProgressTracker, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sj, ok := s.jobs[jobID]
+	if !ok {
+		return nil, false
+	}
+	return sj.tracker, true
+}
+
+// cleanupLoop 定期删除过期的任务，直到 Close
+func (s *Server) cleanupLoop() {
+	interval := s.options.ResultTTL / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	for {
+		timer := s.clock.NewTimer(interval)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+			s.cleanupExpired()
+		}
+	}
+}
+
+// cleanupExpired 删除结束时间早于 ResultTTL 的任务及其上传文件和输出，返回删除的任务数
+func (s *Server) cleanupExpired() int {
+	now := s.clock.Now()
+	s.mu.Lock()
+	var expired []*serverJob
+	for id, sj := range s.jobs {
+		if !sj.finishedAt.IsZero() && !now.Before(sj.finishedAt.Add(s.options.ResultTTL)) {
+			expired = append(expired, sj)
+			delete(s.jobs, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sj := range expired {
+		os.RemoveAll(sj.dir)
+	}
+	return len(expired)
+}
+
+// uploadName 返回上传文件名中可用于本地文件的部分，无法使用时为 upload.pdf
+func uploadName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" || name == ".." || strings.TrimSpace(name) == "" {
+		return "upload.pdf"
+	}
+	return name
+}
+
+// saveUpload 把上传的内容流式写入path
+func saveUpload(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// uploadError 按读取上传内容时的错误返回413或400
+func uploadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("upload exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, fmt.Sprintf("cannot read upload: %v", err), http.StatusBadRequest)
+}
+
+// writeJSON 以JSON写出响应
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
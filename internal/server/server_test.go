@@ -0,0 +1,306 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/file"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// testPDF 生成包含pages个空白页面的PDF
+func testPDF(pages int) []byte {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	var kids strings.Builder
+	for i := 0; i < pages; i++ {
+		fmt.Fprintf(&kids, "%d 0 R ", i+3)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), pages))
+	for i := 0; i < pages; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+	return buf.Bytes()
+}
+
+// newMergeServer 创建使用真实PDF服务的合并服务，临时目录为测试目录
+func newMergeServer(t *testing.T, options Options) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	config := model.DefaultConfig()
+	config.TempDirectory = dir
+	ctrl := controller.NewController(pdf.NewPDFService(), file.NewFileManager(dir), config)
+	srv := New(ctrl, options)
+	t.Cleanup(srv.Close)
+	return srv, dir
+}
+
+// upload 上传的一个文件
+type upload struct {
+	name string
+	data []byte
+}
+
+// mergeRequest 构造 POST /merge 请求
+func mergeRequest(t *testing.T, options *MergeOptions, uploads ...upload) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if options != nil {
+		part, err := writer.CreateFormField("options")
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(part).Encode(options)
+	}
+	for _, u := range uploads {
+		part, err := writer.CreateFormFile("files", u.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(u.data)
+	}
+	writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/merge", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// decodeStatus 解析任务状态响应
+func decodeStatus(t *testing.T, rec *httptest.ResponseRecorder) JobStatus {
+	t.Helper()
+	var status JobStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("解析任务状态失败: %v (%s)", err, rec.Body.String())
+	}
+	return status
+}
+
+// waitFinished 轮询 GET /jobs/{id} 直到任务结束
+func waitFinished(t *testing.T, srv *Server, jobID string) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("查询任务返回 %d: %s", rec.Code, rec.Body.String())
+		}
+		status := decodeStatus(t, rec)
+		if status.FinishedAt != nil {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("任务 %s 没有结束", jobID)
+	return JobStatus{}
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	srv, _ := newMergeServer(t, Options{Token: "secret"})
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/x", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("没有令牌时应返回401，实际 %d", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("401响应应包含 WWW-Authenticate")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs/x", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("错误令牌应返回401，实际 %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs/x", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("正确令牌查询不存在的任务应返回404，实际 %d", rec.Code)
+	}
+}
+
+func TestServer_MergeAndDownload(t *testing.T) {
+	srv, dir := newMergeServer(t, Options{})
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, mergeRequest(t, &MergeOptions{OutputName: "report.pdf"},
+		upload{"a.pdf", testPDF(1)},
+		upload{"broken.pdf", []byte("not a pdf")},
+		upload{"b.pdf", testPDF(2)},
+	))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /merge 返回 %d: %s", rec.Code, rec.Body.String())
+	}
+	accepted := decodeStatus(t, rec)
+	if rec.Header().Get("Location") != "/jobs/"+accepted.ID {
+		t.Errorf("Location = %q", rec.Header().Get("Location"))
+	}
+	if strings.Join(accepted.Files, ",") != "a.pdf,b.pdf" {
+		t.Errorf("Files = %v", accepted.Files)
+	}
+	if strings.Join(accepted.SkippedFiles, ",") != "broken.pdf" {
+		t.Errorf("SkippedFiles = %v", accepted.SkippedFiles)
+	}
+
+	status := waitFinished(t, srv, accepted.ID)
+	if status.State != StateCompleted {
+		t.Fatalf("任务状态为 %s: %s", status.State, status.Error)
+	}
+	if status.Progress != 100 {
+		t.Errorf("完成后进度应为100，实际 %v", status.Progress)
+	}
+	if status.ResultURL != "/jobs/"+accepted.ID+"/result" {
+		t.Errorf("ResultURL = %q", status.ResultURL)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, status.ResultURL, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("下载结果返回 %d: %s", rec.Code, rec.Body.String())
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF")) {
+		t.Error("下载的结果不是PDF")
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, `"report.pdf"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/jobs/"+accepted.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("删除已完成的任务应返回204，实际 %d", rec.Code)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, JobsDirName))
+	if len(entries) != 0 {
+		t.Errorf("删除任务后应清除任务目录，剩余 %d 个", len(entries))
+	}
+}
+
+func TestServer_RejectsBadRequests(t *testing.T) {
+	srv, _ := newMergeServer(t, Options{})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"只有一个文件", mergeRequest(t, nil, upload{"a.pdf", testPDF(1)}), http.StatusBadRequest},
+		{"严格模式下有无效文件", mergeRequest(t, &MergeOptions{Strict: true},
+			upload{"a.pdf", testPDF(1)}, upload{"b.pdf", testPDF(1)}, upload{"c.pdf", []byte("x")}), http.StatusUnprocessableEntity},
+		{"有效文件少于两个", mergeRequest(t, nil, upload{"a.pdf", testPDF(1)}, upload{"c.pdf", []byte("x")}), http.StatusUnprocessableEntity},
+		{"无效的旋转角度", mergeRequest(t, &MergeOptions{Rotations: map[string]int{"a.pdf": 45}},
+			upload{"a.pdf", testPDF(1)}, upload{"b.pdf", testPDF(1)}), http.StatusBadRequest},
+		{"不是multipart", httptest.NewRequest(http.MethodPost, "/merge", strings.NewReader("{}")), http.StatusBadRequest},
+		{"GET /merge", httptest.NewRequest(http.MethodGet, "/merge", nil), http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, tt.req)
+			if rec.Code != tt.want {
+				t.Errorf("返回 %d，期望 %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_UploadTooLarge(t *testing.T) {
+	srv, dir := newMergeServer(t, Options{MaxUploadSize: 1024})
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, mergeRequest(t, nil,
+		upload{"a.pdf", testPDF(1)},
+		upload{"big.pdf", bytes.Repeat([]byte("x"), 4096)},
+	))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("超出上传大小应返回413，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, JobsDirName))
+	if len(entries) != 0 {
+		t.Errorf("被拒绝的上传应删除任务目录，剩余 %d 个", len(entries))
+	}
+}
+
+func TestServer_CleanupExpired(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	srv, _ := newMergeServer(t, Options{ResultTTL: time.Hour, Clock: fake})
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, mergeRequest(t, nil, upload{"a.pdf", testPDF(1)}, upload{"b.pdf", testPDF(1)}))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /merge 返回 %d: %s", rec.Code, rec.Body.String())
+	}
+	jobID := decodeStatus(t, rec).ID
+	waitFinished(t, srv, jobID)
+	jobDir := srv.jobs[jobID].dir
+
+	fake.Advance(30 * time.Minute)
+	if removed := srv.cleanupExpired(); removed != 0 {
+		t.Fatalf("未过期的任务不应删除，删除了 %d 个", removed)
+	}
+	fake.Advance(30 * time.Minute)
+	if removed := srv.cleanupExpired(); removed != 1 {
+		t.Fatalf("应删除1个过期任务，删除了 %d 个", removed)
+	}
+	if _, err := os.Stat(jobDir); !os.IsNotExist(err) {
+		t.Errorf("过期任务的目录应被删除: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID+"/result", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("过期任务应返回404，实际 %d", rec.Code)
+	}
+}
+
+func TestServer_ResultBeforeCompletion(t *testing.T) {
+	srv, _ := newMergeServer(t, Options{})
+	sj := &serverJob{
+		job:     model.NewMergeJob("a.pdf", []string{"b.pdf"}, "out.pdf"),
+		tracker: model.NewProgressTracker(1),
+		state:   StateRunning,
+	}
+	srv.jobs[sj.job.ID] = sj
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/"+sj.job.ID+"/result", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("未完成的任务下载结果应返回409，实际 %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), StateRunning) {
+		t.Errorf("错误信息应包含任务状态: %s", body)
+	}
+}
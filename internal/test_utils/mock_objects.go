@@ -275,6 +275,14 @@ func (m *MockFileManager) CleanupTempFiles() error {
 	return nil
 }
 
+// HoldTempFiles 模拟标记任务使用临时文件
+func (m *MockFileManager) HoldTempFiles() func() {
+	m.mutex.Lock()
+	m.callCounts["HoldTempFiles"]++
+	m.mutex.Unlock()
+	return func() {}
+}
+
 // RemoveTempFile 模拟删除临时文件
 func (m *MockFileManager) RemoveTempFile(filePath string) error {
 	m.mutex.Lock()
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/i18n"
+)
+
+// dropToastDuration 拖放结果提示的显示时间
+const dropToastDuration = 3 * time.Second
+
+// dropSummary 一次拖放的处理结果
+type dropSummary struct {
+	added      int // 加入列表或设为主文件的文件数
+	skipped    int // 不是PDF而被忽略的文件数
+	duplicates int // 已在列表中或已是主文件而被忽略的文件数
+}
+
+// String 以当前语言描述拖放结果
+func (s dropSummary) String() string {
+	text := i18n.T(DropSummaryText, s.added, s.skipped)
+	if s.duplicates > 0 {
+		text += i18n.T(DropDuplicatesText, s.duplicates)
+	}
+	return text
+}
+
+// collectDroppedPDFs 把拖放的路径展开为PDF文件列表：目录展开为其中的PDF文件（不含子目录，按名称排序），
+// 其他文件按扩展名过滤。返回PDF文件和被忽略的非PDF文件数。
+func collectDroppedPDFs(paths []string) ([]string, int) {
+	var pdfs []string
+	skipped := 0
+	add := func(path string) {
+		if strings.EqualFold(filepath.Ext(path), ".pdf") {
+			pdfs = append(pdfs, path)
+		} else {
+			skipped++
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			add(path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			skipped++
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				add(filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return pdfs, skipped
+}
+
+// onDropped 窗口拖放处理：把拖入的PDF文件和目录中的PDF文件加入附加文件列表，
+// 没有主文件时第一个文件成为主文件，然后显示处理结果
+func (u *UI) onDropped(_ fyne.Position, uris []fyne.URI) {
+	var paths []string
+	for _, uri := range uris {
+		if uri != nil && uri.Scheme() == "file" {
+			paths = append(paths, uri.Path())
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	summary := u.addDroppedFiles(paths)
+	u.showToast(summary.String())
+}
+
+// addDroppedFiles 按拖放顺序添加文件，跳过非PDF文件和重复文件。
+// 文件信息通过文件列表的 getFileInfo 回调获取，与“添加文件”按钮一致。
+func (u *UI) addDroppedFiles(paths []string) dropSummary {
+	pdfs, skipped := collectDroppedPDFs(paths)
+	summary := dropSummary{skipped: skipped}
+
+	for _, path := range pdfs {
+		if u.mainFilePath != "" && canonicalPath(path) == canonicalPath(u.mainFilePath) {
+			summary.duplicates++
+			continue
+		}
+		if u.mainFilePath == "" && u.fileListManager.indexOf(path) < 0 {
+			u.mainFilePath = path
+			if u.mainFileEntry != nil {
+				u.mainFileEntry.SetText(u.displayName(path))
+			}
+			summary.added++
+			continue
+		}
+		if err := u.fileListManager.AddFile(path); err != nil {
+			// 内容重复的文件等待用户在确认对话框中决定，不计入跳过
+			if !errors.Is(err, ErrDuplicateContent) {
+				summary.duplicates++
+			}
+			continue
+		}
+		summary.added++
+	}
+
+	if u.mergeButton != nil {
+		u.updateUI()
+	}
+	return summary
+}
+
+// showToast 在窗口底部短暂显示一条不需要确认的提示
+func (u *UI) showToast(message string) {
+	if u.window == nil {
+		return
+	}
+	canvas := u.window.Canvas()
+	popup := widget.NewPopUp(widget.NewLabel(message), canvas)
+	size := popup.MinSize()
+	popup.ShowAtPosition(fyne.NewPos((canvas.Size().Width-size.Width)/2, canvas.Size().Height-size.Height-20))
+	time.AfterFunc(dropToastDuration, popup.Hide)
+}
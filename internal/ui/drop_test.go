@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/file"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+func writeDropFiles(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("%PDF-1.4\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestCollectDroppedPDFs(t *testing.T) {
+	dir := t.TempDir()
+	files := writeDropFiles(t, dir, "a.pdf", "notes.txt")
+
+	folder := filepath.Join(dir, "scans")
+	if err := os.MkdirAll(filepath.Join(folder, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeDropFiles(t, folder, "2.PDF", "1.pdf", "image.png")
+	writeDropFiles(t, filepath.Join(folder, "nested"), "deep.pdf")
+
+	pdfs, skipped := collectDroppedPDFs(append(files, folder))
+
+	want := []string{files[0], filepath.Join(folder, "1.pdf"), filepath.Join(folder, "2.PDF")}
+	if len(pdfs) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, pdfs)
+	}
+	for i := range want {
+		if pdfs[i] != want[i] {
+			t.Errorf("Expected %s at %d, got %s", want[i], i, pdfs[i])
+		}
+	}
+	if skipped != 2 {
+		t.Errorf("Expected 2 skipped non-PDF files, got %d", skipped)
+	}
+}
+
+func TestUI_AddDroppedFiles(t *testing.T) {
+	app := test.NewApp()
+	window := app.NewWindow("Test")
+	ctrl := controller.NewController(pdf.NewPDFService(), file.NewFileManager("/tmp"), model.DefaultConfig())
+	ui := NewUI(window, ctrl)
+	ui.BuildUI()
+
+	dir := t.TempDir()
+	files := writeDropFiles(t, dir, "main.pdf", "b.pdf", "c.txt")
+
+	summary := ui.addDroppedFiles(files)
+	if ui.GetMainFilePath() != files[0] {
+		t.Errorf("Expected first dropped file to become main file, got %s", ui.GetMainFilePath())
+	}
+	if ui.fileListManager.GetFileCount() != 1 {
+		t.Errorf("Expected 1 additional file, got %d", ui.fileListManager.GetFileCount())
+	}
+	if summary.added != 2 || summary.skipped != 1 || summary.duplicates != 0 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	// 再次拖入同样的文件不会重复添加
+	summary = ui.addDroppedFiles(files[:2])
+	if summary.added != 0 || summary.duplicates != 2 {
+		t.Errorf("Expected duplicates to be ignored, got %+v", summary)
+	}
+	if ui.fileListManager.GetFileCount() != 1 {
+		t.Errorf("Expected file count to stay 1, got %d", ui.fileListManager.GetFileCount())
+	}
+}
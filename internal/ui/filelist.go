@@ -1,24 +1,44 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"image"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/user/pdf-merger/internal/i18n"
 	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
-// FileListManager 文件列表管理器
+// ErrDuplicateContent 要添加的文件与列表中的文件内容相同，已交给重复文件回调确认
+var ErrDuplicateContent = errors.New("文件内容与列表中的文件相同")
+
+// FileListManager 文件列表管理器。
+// 条目顺序由Order字段显式维护，刷新和重新验证不会改变顺序；
+// 选中状态按条目的规范路径保持，而不是按索引。
+// 点击行只选中该行；行首的复选框把条目加入或移出选择，实现多选。
+// 开启缩略图时每行显示文件首页的缩略图，在后台渲染，渲染完成前和失败时只显示文字。
 type FileListManager struct {
-	files         []model.FileEntry
-	list          *widget.List
-	selectedIndex int
-	onFileChanged func()
-	onFileInfo    func(string) (*model.FileEntry, error)
+	files          []model.FileEntry
+	list           *widget.List
+	selectedIndex  int             // 当前条目（最后点击的行），也属于选择
+	multiSelected  map[string]bool // 通过复选框额外选中的条目，按规范路径
+	onFileChanged  func()
+	onFileInfo     func(string) (*model.FileEntry, error)
+	onEncrypted    func(string)                                // 添加了加密文件，用于询问密码
+	onDuplicate    func(filePath, existing string, add func()) // 内容重复的文件，调用add确认添加
+	encodings      []string                                    // 推导显示名称时的回退编码
+	thumbnails     *thumbnailLoader
+	showThumbnails bool // 是否显示缩略图
 }
 
 // NewFileListManager 创建新的文件列表管理器
@@ -26,12 +46,39 @@ func NewFileListManager() *FileListManager {
 	flm := &FileListManager{
 		files:         make([]model.FileEntry, 0),
 		selectedIndex: -1,
+		multiSelected: make(map[string]bool),
+		encodings:     model.DefaultFilenameEncodings,
 	}
 
 	flm.createList()
+	flm.SetThumbnailCacheDir("")
 	return flm
 }
 
+// SetThumbnailCacheDir 设置缓存缩略图的临时目录，为空时使用系统临时目录
+func (flm *FileListManager) SetThumbnailCacheDir(dir string) {
+	flm.thumbnails = newThumbnailLoader(dir, nil)
+	flm.thumbnails.onReady = flm.list.Refresh
+	flm.list.Refresh()
+}
+
+// SetShowThumbnails 开启或关闭缩略图。文件很多时可以关闭，关闭后释放内存中的缩略图
+func (flm *FileListManager) SetShowThumbnails(show bool) {
+	if flm.showThumbnails == show {
+		return
+	}
+	flm.showThumbnails = show
+	if !show {
+		flm.thumbnails.Clear()
+	}
+	flm.list.Refresh()
+}
+
+// ThumbnailsShown 返回是否显示缩略图
+func (flm *FileListManager) ThumbnailsShown() bool {
+	return flm.showThumbnails
+}
+
 // createList 创建文件列表组件
 func (flm *FileListManager) createList() {
 	flm.list = widget.NewList(
@@ -46,9 +93,14 @@ func (flm *FileListManager) createList() {
 		},
 	)
 
-	// 设置列表选择处理
+	// 设置列表选择处理：点击其他行时只选中该行
 	flm.list.OnSelected = func(id widget.ListItemID) {
-		flm.selectedIndex = id
+		if id != flm.selectedIndex {
+			flm.multiSelected = make(map[string]bool)
+			flm.selectedIndex = id
+			flm.list.Refresh()
+			flm.notifyChanged()
+		}
 	}
 
 	flm.list.OnUnselected = func(id widget.ListItemID) {
@@ -61,17 +113,26 @@ func (flm *FileListManager) createList() {
 // createListItem 创建列表项模板
 func (flm *FileListManager) createListItem() fyne.CanvasObject {
 	// 简化的列表项，避免复杂的嵌套容器
+	selectCheck := widget.NewCheck("", nil)
+	thumbnail := canvas.NewImageFromImage(nil)
+	thumbnail.FillMode = canvas.ImageFillContain
+	thumbnail.SetMinSize(fyne.NewSize(thumbnailSize/2, thumbnailSize/2))
+	thumbnail.Hide()
 	fileIcon := widget.NewIcon(theme.DocumentIcon())
-	nameLabel := widget.NewLabel("文件名")
+	nameLabel := newTooltipLabel(i18n.T(FileNameColumn))
 	nameLabel.Truncation = fyne.TextTruncateEllipsis
-	sizeLabel := widget.NewLabel("大小")
-	statusLabel := widget.NewLabel("状态")
+	sizeLabel := widget.NewLabel(i18n.T(FileSizeColumn))
+	statusLabel := newTooltipLabel(i18n.T(FileStatusColumn))
+	rotateButton := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), nil)
 
 	return container.NewHBox(
+		selectCheck,
+		thumbnail,
 		fileIcon,
 		nameLabel,
 		sizeLabel,
 		statusLabel,
+		rotateButton,
 	)
 }
 
@@ -86,60 +147,192 @@ func (flm *FileListManager) updateListItem(id widget.ListItemID, obj fyne.Canvas
 	// 简化的列表项更新，避免复杂的容器结构
 	// 由于Fyne的List组件限制，我们使用简单的布局
 	container := obj.(*fyne.Container)
-	if len(container.Objects) < 4 {
+	if len(container.Objects) < 7 {
 		return
 	}
 
-	// 更新文件图标
-	if icon, ok := container.Objects[0].(*widget.Icon); ok {
-		if file.IsValid {
-			icon.SetResource(theme.DocumentIcon())
+	// 更新选择复选框，先解除回调避免SetChecked触发切换
+	if check, ok := container.Objects[0].(*widget.Check); ok {
+		path := file.Path
+		check.OnChanged = nil
+		check.SetChecked(flm.isSelected(id))
+		check.OnChanged = func(bool) {
+			flm.ToggleSelection(path)
+		}
+	}
+
+	// 更新缩略图，没有缩略图时隐藏，行只显示文字
+	if thumbnail, ok := container.Objects[1].(*canvas.Image); ok {
+		if img := flm.thumbnail(file); img != nil {
+			thumbnail.Image = img
+			thumbnail.Show()
 		} else {
+			thumbnail.Image = nil
+			thumbnail.Hide()
+		}
+		thumbnail.Refresh()
+	}
+
+	// 更新文件图标，只有警告的有效文件显示黄色警告图标
+	if icon, ok := container.Objects[2].(*widget.Icon); ok {
+		switch {
+		case !file.IsValid:
 			icon.SetResource(theme.ErrorIcon())
+		case len(file.Warnings) > 0:
+			icon.SetResource(theme.NewWarningThemedResource(theme.WarningIcon()))
+		default:
+			icon.SetResource(theme.DocumentIcon())
 		}
 	}
 
-	// 更新文件名
-	if nameLabel, ok := container.Objects[1].(*widget.Label); ok {
+	// 更新文件名，悬停时显示页面尺寸和页数
+	if nameLabel, ok := container.Objects[3].(*tooltipLabel); ok {
 		nameLabel.SetText(file.DisplayName)
+		nameLabel.SetTooltip(pageSizeTooltip(file))
 	}
 
 	// 更新文件大小
-	if sizeLabel, ok := container.Objects[2].(*widget.Label); ok {
+	if sizeLabel, ok := container.Objects[4].(*widget.Label); ok {
 		sizeLabel.SetText(file.GetSizeString())
 	}
 
-	// 更新状态
-	if statusLabel, ok := container.Objects[3].(*widget.Label); ok {
+	// 更新状态，悬停时显示验证警告
+	if statusLabel, ok := container.Objects[5].(*tooltipLabel); ok {
 		statusLabel.SetText(flm.getStatusText(file))
+		statusLabel.SetTooltip(warningTooltip(file))
+	}
+
+	// 更新旋转按钮，每次点击顺时针旋转90度
+	if rotateButton, ok := container.Objects[6].(*widget.Button); ok {
+		path := file.Path
+		rotateButton.SetText(i18n.T(RotateButtonFormat, file.Rotation))
+		rotateButton.OnTapped = func() {
+			flm.RotateFile(path)
+		}
 	}
 }
 
-// getStatusText 获取状态文本
+// thumbnail 返回条目的缩略图，未开启、尚未渲染或无法渲染时返回nil。
+// 无效和加密的文件不渲染
+func (flm *FileListManager) thumbnail(file model.FileEntry) image.Image {
+	if !flm.showThumbnails || !file.IsValid || file.IsEncrypted {
+		return nil
+	}
+	return flm.thumbnails.Lookup(file.Path)
+}
+
+// getStatusText 获取状态文本，有验证警告或数字签名的有效文件附加相应标记
 func (flm *FileListManager) getStatusText(file model.FileEntry) string {
+	status := flm.baseStatusText(file)
+	if !file.IsValid {
+		return status
+	}
+	if len(file.Warnings) > 0 {
+		status += " " + i18n.T(WarningBadge)
+	}
+	if file.Signatures > 0 {
+		status += " " + i18n.T(SignatureBadge)
+	}
+	return status
+}
+
+// warningTooltip 返回条目验证警告的悬停提示，每条警告一行；无效或没有警告的条目返回空字符串
+func warningTooltip(file model.FileEntry) string {
+	if !file.IsValid || len(file.Warnings) == 0 {
+		return ""
+	}
+	return strings.Join(file.Warnings, "\n")
+}
+
+// pageSizeTooltip 返回条目页面尺寸的悬停提示，例如 "A4 portrait, 12 pages"，有不同尺寸的页面时另起一行说明；
+// 尺寸未知时返回空字符串
+func pageSizeTooltip(file model.FileEntry) string {
+	if file.PageSize == "" {
+		return ""
+	}
+	orientation := i18n.T(PortraitLabel)
+	if file.Landscape {
+		orientation = i18n.T(LandscapeLabel)
+	}
+	tooltip := i18n.T(PageSizeFormat, file.PageSize, orientation, file.PageCount)
+	if file.MixedPageSizes {
+		tooltip += "\n" + i18n.T(MixedPageSizesLabel)
+	}
+	return tooltip
+}
+
+// baseStatusText 获取不含签名标记的状态文本
+func (flm *FileListManager) baseStatusText(file model.FileEntry) string {
 	if !file.IsValid {
+		if file.IsEncrypted && file.Error != "" {
+			// 密码相关的错误直接显示原因
+			return file.Error
+		}
 		if file.Error != "" {
-			return "错误"
+			return i18n.T(FileStatusError)
 		}
-		return "无效"
+		return i18n.T(FileStatusInvalid)
 	}
 
 	if file.IsEncrypted {
-		return "已加密"
+		if file.Password != "" {
+			return i18n.T(FileStatusUnlocked)
+		}
+		return i18n.T(FileStatusEncrypted)
 	}
 
-	return "正常"
+	return i18n.T(FileStatusOK)
 }
 
-// AddFile 添加文件到列表
+// AddFile 添加文件到列表末尾
 func (flm *FileListManager) AddFile(filePath string) error {
+	return flm.AddFileAt(filePath, -1)
+}
+
+// AddFileAt 在指定位置插入文件，index超出范围或为负数时追加到末尾。
+// 路径已在列表中时返回错误；内容与列表中的文件相同且设置了重复文件回调时，
+// 交给回调确认并返回ErrDuplicateContent，确认后再添加。
+func (flm *FileListManager) AddFileAt(filePath string, index int) error {
 	// 检查文件是否已存在
+	canonical := canonicalPath(filePath)
 	for _, file := range flm.files {
-		if file.Path == filePath {
+		if canonicalPath(file.Path) == canonical {
 			return fmt.Errorf("文件已存在于列表中")
 		}
 	}
 
+	if flm.onDuplicate != nil {
+		if existing := flm.findSameContent(filePath); existing != "" {
+			flm.onDuplicate(filePath, existing, func() {
+				if flm.indexOf(filePath) < 0 {
+					flm.insertFile(filePath, index)
+				}
+			})
+			return ErrDuplicateContent
+		}
+	}
+
+	flm.insertFile(filePath, index)
+	return nil
+}
+
+// findSameContent 返回列表中与文件内容相同的第一个条目的路径，没有或无法读取时返回空字符串。
+// 内容哈希按路径和修改时间缓存，重复添加时不会反复读取已有条目。
+func (flm *FileListManager) findSameContent(filePath string) string {
+	hash, err := pdf.FileContentHash(filePath)
+	if err != nil {
+		return ""
+	}
+	for _, file := range flm.files {
+		if other, err := pdf.FileContentHash(file.Path); err == nil && other == hash {
+			return file.Path
+		}
+	}
+	return ""
+}
+
+// insertFile 创建条目并插入到指定位置，不检查重复
+func (flm *FileListManager) insertFile(filePath string, index int) {
 	// 创建文件条目
 	fileEntry := model.NewFileEntry(filePath, len(flm.files))
 
@@ -149,127 +342,168 @@ func (flm *FileListManager) AddFile(filePath string) error {
 			fileEntry.Size = info.Size
 			fileEntry.PageCount = info.PageCount
 			fileEntry.IsEncrypted = info.IsEncrypted
+			fileEntry.Signatures = info.Signatures
 			fileEntry.IsValid = info.IsValid
 			fileEntry.Error = info.Error
+			fileEntry.Warnings = info.Warnings
+			fileEntry.PageSize = info.PageSize
+			fileEntry.Landscape = info.Landscape
+			fileEntry.MixedPageSizes = info.MixedPageSizes
 		}
 	}
 
-	// 添加到列表
-	flm.files = append(flm.files, *fileEntry)
+	// 插入到列表，保持选中条目不变
+	selected := flm.selectedPath()
+	if index < 0 || index >= len(flm.files) {
+		flm.files = append(flm.files, *fileEntry)
+	} else {
+		flm.files = append(flm.files[:index], append([]model.FileEntry{*fileEntry}, flm.files[index:]...)...)
+	}
+	flm.normalizeOrder()
+	flm.refreshDisplayNames()
+	flm.reselect(selected)
 	flm.list.Refresh()
 
 	if flm.onFileChanged != nil {
 		flm.onFileChanged()
 	}
+	if fileEntry.IsEncrypted && fileEntry.IsValid && flm.onEncrypted != nil {
+		flm.onEncrypted(filePath)
+	}
+}
 
-	return nil
+// SetFilenameEncodings 设置推导显示名称时的回退编码，并刷新已有条目
+func (flm *FileListManager) SetFilenameEncodings(encodings []string) {
+	flm.encodings = encodings
+	flm.refreshDisplayNames()
+	flm.list.Refresh()
 }
 
-// RemoveFile 移除指定索引的文件
-func (flm *FileListManager) removeFile(index int) {
-	if index < 0 || index >= len(flm.files) {
-		return
+// refreshDisplayNames 重新推导显示名称，显示相同但原始字节不同的条目附加短哈希
+func (flm *FileListManager) refreshDisplayNames() {
+	raws := make([]string, len(flm.files))
+	for i, file := range flm.files {
+		raws[i] = filepath.Base(file.Path)
+	}
+	for i, name := range model.DisambiguateDisplayNames(raws, flm.encodings) {
+		flm.files[i].DisplayName = name
 	}
+}
 
-	// 移除文件
-	flm.files = append(flm.files[:index], flm.files[index+1:]...)
+// removeFiles 移除指定索引的文件
+func (flm *FileListManager) removeFiles(indices []int) {
+	removed := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		if index >= 0 && index < len(flm.files) {
+			removed[index] = true
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
 
-	// 重新设置Order
-	for i := range flm.files {
-		flm.files[i].Order = i
+	// 移除后选中第一个被移除条目位置上的后继（没有后继时选中新的末尾）
+	successor := len(flm.files)
+	kept := flm.files[:0]
+	for i, file := range flm.files {
+		if removed[i] {
+			if i < successor {
+				successor = i
+			}
+			continue
+		}
+		kept = append(kept, file)
 	}
+	flm.files = kept
+	flm.normalizeOrder()
+	flm.refreshDisplayNames()
 
-	// 调整选中索引
-	if flm.selectedIndex == index {
-		flm.selectedIndex = -1
-	} else if flm.selectedIndex > index {
-		flm.selectedIndex--
+	if successor >= len(flm.files) {
+		successor = len(flm.files) - 1
 	}
+	flm.multiSelected = make(map[string]bool)
+	flm.selectIndex(successor)
 
 	flm.list.Refresh()
-
-	if flm.onFileChanged != nil {
-		flm.onFileChanged()
-	}
+	flm.notifyChanged()
 }
 
-// RemoveSelected 移除选中的文件
+// RemoveSelected 移除所有选中的文件
 func (flm *FileListManager) RemoveSelected() {
-	if flm.selectedIndex >= 0 {
-		flm.removeFile(flm.selectedIndex)
-	}
+	flm.removeFiles(flm.GetSelectedIndices())
 }
 
-// moveFileUp 向上移动文件
-func (flm *FileListManager) moveFileUp(index int) {
-	if index <= 0 || index >= len(flm.files) {
-		return
+// moveSelected 把选中的条目整体上移（delta为-1）或下移（delta为1）一位。
+// 选中条目之间的相对顺序保持不变，已经到达边界的连续选中条目不移动。
+func (flm *FileListManager) moveSelected(delta int) {
+	indices := flm.GetSelectedIndices()
+	if delta > 0 {
+		for i, j := 0, len(indices)-1; i < j; i, j = i+1, j-1 {
+			indices[i], indices[j] = indices[j], indices[i]
+		}
 	}
 
-	// 交换文件位置
-	flm.files[index], flm.files[index-1] = flm.files[index-1], flm.files[index]
-
-	// 更新Order
-	flm.files[index-1].Order = index - 1
-	flm.files[index].Order = index
-
-	// 更新选中索引
-	if flm.selectedIndex == index {
-		flm.selectedIndex = index - 1
-		flm.list.Select(flm.selectedIndex)
+	// 交换过程中索引会变化，按路径判断目标位置是否也被选中
+	selectedPaths := make(map[string]bool, len(indices))
+	for _, index := range indices {
+		selectedPaths[canonicalPath(flm.files[index].Path)] = true
 	}
 
-	flm.list.Refresh()
-
-	if flm.onFileChanged != nil {
-		flm.onFileChanged()
+	selected := flm.selectedPath()
+	moved := false
+	for _, index := range indices {
+		target := index + delta
+		if target < 0 || target >= len(flm.files) || selectedPaths[canonicalPath(flm.files[target].Path)] {
+			continue
+		}
+		flm.files[index], flm.files[target] = flm.files[target], flm.files[index]
+		moved = true
 	}
-}
-
-// moveFileDown 向下移动文件
-func (flm *FileListManager) moveFileDown(index int) {
-	if index < 0 || index >= len(flm.files)-1 {
+	if !moved {
 		return
 	}
 
-	// 交换文件位置
-	flm.files[index], flm.files[index+1] = flm.files[index+1], flm.files[index]
-
-	// 更新Order
-	flm.files[index].Order = index
-	flm.files[index+1].Order = index + 1
-
-	// 更新选中索引
-	if flm.selectedIndex == index {
-		flm.selectedIndex = index + 1
-		flm.list.Select(flm.selectedIndex)
-	}
-
+	flm.normalizeOrder()
+	flm.reselect(selected)
 	flm.list.Refresh()
-
-	if flm.onFileChanged != nil {
-		flm.onFileChanged()
-	}
+	flm.notifyChanged()
 }
 
 // MoveSelectedUp 向上移动选中的文件
 func (flm *FileListManager) MoveSelectedUp() {
-	if flm.selectedIndex > 0 {
-		flm.moveFileUp(flm.selectedIndex)
-	}
+	flm.moveSelected(-1)
 }
 
 // MoveSelectedDown 向下移动选中的文件
 func (flm *FileListManager) MoveSelectedDown() {
-	if flm.selectedIndex >= 0 && flm.selectedIndex < len(flm.files)-1 {
-		flm.moveFileDown(flm.selectedIndex)
+	flm.moveSelected(1)
+}
+
+// CanMoveSelectedUp 判断是否有选中的条目可以上移
+func (flm *FileListManager) CanMoveSelectedUp() bool {
+	for _, index := range flm.GetSelectedIndices() {
+		if index > 0 && !flm.isSelected(index-1) {
+			return true
+		}
 	}
+	return false
+}
+
+// CanMoveSelectedDown 判断是否有选中的条目可以下移
+func (flm *FileListManager) CanMoveSelectedDown() bool {
+	for _, index := range flm.GetSelectedIndices() {
+		if index < len(flm.files)-1 && !flm.isSelected(index+1) {
+			return true
+		}
+	}
+	return false
 }
 
 // Clear 清空文件列表
 func (flm *FileListManager) Clear() {
 	flm.files = make([]model.FileEntry, 0)
 	flm.selectedIndex = -1
+	flm.multiSelected = make(map[string]bool)
 	flm.list.Refresh()
 
 	if flm.onFileChanged != nil {
@@ -291,11 +525,57 @@ func (flm *FileListManager) GetFilePaths() []string {
 	return paths
 }
 
-// GetSelectedIndex 获取选中的索引
+// GetSelectedIndex 获取当前条目的索引。
+//
+// Deprecated: 多选时只返回最后点击的条目，请使用 GetSelectedIndices。
 func (flm *FileListManager) GetSelectedIndex() int {
 	return flm.selectedIndex
 }
 
+// GetSelectedIndices 按列表顺序返回所有选中条目的索引
+func (flm *FileListManager) GetSelectedIndices() []int {
+	var indices []int
+	for i := range flm.files {
+		if flm.isSelected(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// ToggleSelection 把文件加入或移出选择，文件不在列表中时返回false
+func (flm *FileListManager) ToggleSelection(filePath string) bool {
+	index := flm.indexOf(filePath)
+	if index < 0 {
+		return false
+	}
+
+	canonical := canonicalPath(filePath)
+	switch {
+	case index == flm.selectedIndex:
+		// 取消当前条目时由剩余选中条目中的第一个接替
+		flm.selectedIndex = -1
+		flm.list.UnselectAll()
+		for i := range flm.files {
+			if flm.multiSelected[canonicalPath(flm.files[i].Path)] {
+				delete(flm.multiSelected, canonicalPath(flm.files[i].Path))
+				flm.selectIndex(i)
+				break
+			}
+		}
+	case flm.multiSelected[canonical]:
+		delete(flm.multiSelected, canonical)
+	case flm.selectedIndex < 0:
+		flm.selectIndex(index)
+	default:
+		flm.multiSelected[canonical] = true
+	}
+
+	flm.list.Refresh()
+	flm.notifyChanged()
+	return true
+}
+
 // HasFiles 检查是否有文件
 func (flm *FileListManager) HasFiles() bool {
 	return len(flm.files) > 0
@@ -321,34 +601,206 @@ func (flm *FileListManager) SetOnFileInfo(callback func(string) (*model.FileEntr
 	flm.onFileInfo = callback
 }
 
-// RefreshFileInfo 刷新文件信息
+// SetOnEncryptedFile 设置添加加密文件后的回调
+func (flm *FileListManager) SetOnEncryptedFile(callback func(filePath string)) {
+	flm.onEncrypted = callback
+}
+
+// SetOnDuplicateFile 设置添加内容重复的文件时的确认回调，未设置时内容重复的文件直接添加
+func (flm *FileListManager) SetOnDuplicateFile(callback func(filePath, existing string, add func())) {
+	flm.onDuplicate = callback
+}
+
+// SetPassword 保存已验证的打开密码并清除条目的错误，条目不存在时返回false
+func (flm *FileListManager) SetPassword(filePath, password string) bool {
+	i := flm.indexOf(filePath)
+	if i < 0 {
+		return false
+	}
+	flm.files[i].Password = password
+	flm.files[i].IsValid = true
+	flm.files[i].Error = ""
+	flm.list.Refresh()
+	flm.notifyChanged()
+	return true
+}
+
+// MarkInvalid 把条目标记为无效并记录原因，原因显示在列表的状态列
+func (flm *FileListManager) MarkInvalid(filePath, reason string) bool {
+	i := flm.indexOf(filePath)
+	if i < 0 {
+		return false
+	}
+	flm.files[i].Password = ""
+	flm.files[i].SetError(reason)
+	flm.list.Refresh()
+	flm.notifyChanged()
+	return true
+}
+
+// GetPasswords 返回已提供密码的条目（路径 -> 密码），用于传给合并任务
+func (flm *FileListManager) GetPasswords() map[string]string {
+	passwords := make(map[string]string)
+	for _, file := range flm.files {
+		if file.Password != "" {
+			passwords[file.Path] = file.Password
+		}
+	}
+	return passwords
+}
+
+// RotateFile 把条目的合并旋转角度顺时针增加90度（270之后回到0），条目不存在时返回false
+func (flm *FileListManager) RotateFile(filePath string) bool {
+	i := flm.indexOf(filePath)
+	if i < 0 {
+		return false
+	}
+	flm.files[i].Rotation = (flm.files[i].Rotation + 90) % 360
+	flm.list.Refresh()
+	flm.notifyChanged()
+	return true
+}
+
+// GetRotations 返回指定了旋转的条目（路径 -> 顺时针角度），用于传给合并任务
+func (flm *FileListManager) GetRotations() map[string]int {
+	rotations := make(map[string]int)
+	for _, file := range flm.files {
+		if file.Rotation != 0 {
+			rotations[file.Path] = file.Rotation
+		}
+	}
+	return rotations
+}
+
+// RefreshFileInfo 刷新文件信息，只更新条目内容，顺序和选中条目保持不变
 func (flm *FileListManager) RefreshFileInfo() {
 	if flm.onFileInfo == nil {
 		return
 	}
 
+	selected := flm.selectedPath()
 	for i := range flm.files {
-		if info, err := flm.onFileInfo(flm.files[i].Path); err == nil {
-			flm.files[i].Size = info.Size
-			flm.files[i].PageCount = info.PageCount
-			flm.files[i].IsEncrypted = info.IsEncrypted
-			flm.files[i].IsValid = info.IsValid
-			flm.files[i].Error = info.Error
+		info, err := flm.onFileInfo(flm.files[i].Path)
+		if err != nil {
+			// 获取失败的条目原地标记为错误，不移动位置
+			flm.files[i].SetError(err.Error())
+			continue
 		}
-	}
+		flm.files[i].Size = info.Size
+		flm.files[i].PageCount = info.PageCount
+		flm.files[i].IsEncrypted = info.IsEncrypted
+		flm.files[i].Signatures = info.Signatures
+		flm.files[i].IsValid = info.IsValid
+		flm.files[i].Error = info.Error
+		flm.files[i].Warnings = info.Warnings
+		flm.files[i].PageSize = info.PageSize
+		flm.files[i].Landscape = info.Landscape
+		flm.files[i].MixedPageSizes = info.MixedPageSizes
+	}
+
+	sort.SliceStable(flm.files, func(i, j int) bool {
+		return flm.files[i].Order < flm.files[j].Order
+	})
+	flm.normalizeOrder()
+	flm.reselect(selected)
+	flm.list.Refresh()
+}
+
+// GetSelectedPath 获取选中文件的路径，未选中时返回空字符串
+func (flm *FileListManager) GetSelectedPath() string {
+	return flm.selectedPath()
+}
 
+// SelectFile 按路径只选中该文件，文件不在列表中时返回false
+func (flm *FileListManager) SelectFile(filePath string) bool {
+	index := flm.indexOf(filePath)
+	if index < 0 {
+		return false
+	}
+	flm.multiSelected = make(map[string]bool)
+	flm.selectIndex(index)
 	flm.list.Refresh()
+	return true
+}
+
+// isSelected 判断索引处的条目是否被选中
+func (flm *FileListManager) isSelected(index int) bool {
+	if index < 0 || index >= len(flm.files) {
+		return false
+	}
+	return index == flm.selectedIndex || flm.multiSelected[canonicalPath(flm.files[index].Path)]
+}
+
+// notifyChanged 通知文件列表或选择发生了变化
+func (flm *FileListManager) notifyChanged() {
+	if flm.onFileChanged != nil {
+		flm.onFileChanged()
+	}
+}
+
+// selectedPath 返回当前选中条目的路径
+func (flm *FileListManager) selectedPath() string {
+	if flm.selectedIndex < 0 || flm.selectedIndex >= len(flm.files) {
+		return ""
+	}
+	return flm.files[flm.selectedIndex].Path
+}
+
+// indexOf 按规范路径查找条目索引
+func (flm *FileListManager) indexOf(filePath string) int {
+	if filePath == "" {
+		return -1
+	}
+	canonical := canonicalPath(filePath)
+	for i, file := range flm.files {
+		if canonicalPath(file.Path) == canonical {
+			return i
+		}
+	}
+	return -1
+}
+
+// reselect 在列表变化后按路径恢复选中条目
+func (flm *FileListManager) reselect(filePath string) {
+	flm.selectIndex(flm.indexOf(filePath))
+}
+
+// selectIndex 设置选中索引并同步到列表组件
+func (flm *FileListManager) selectIndex(index int) {
+	if index < 0 || index >= len(flm.files) {
+		flm.selectedIndex = -1
+		flm.list.UnselectAll()
+		return
+	}
+	flm.selectedIndex = index
+	flm.list.Select(index)
+}
+
+// normalizeOrder 按当前位置重新编号Order
+func (flm *FileListManager) normalizeOrder() {
+	for i := range flm.files {
+		flm.files[i].Order = i
+	}
+}
+
+// canonicalPath 返回用于比较条目身份的规范路径
+func canonicalPath(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		return abs
+	}
+	return filepath.Clean(filePath)
 }
 
 // GetFileInfo 获取指定文件的信息摘要
 func (flm *FileListManager) GetFileInfo() string {
 	if len(flm.files) == 0 {
-		return "没有文件"
+		return i18n.T(NoFilesLabel)
 	}
 
 	totalFiles := len(flm.files)
 	validFiles := 0
 	encryptedFiles := 0
+	signedFiles := 0
 	totalPages := 0
 	totalSize := int64(0)
 
@@ -360,25 +812,32 @@ func (flm *FileListManager) GetFileInfo() string {
 		if file.IsEncrypted {
 			encryptedFiles++
 		}
+		if file.Signatures > 0 {
+			signedFiles++
+		}
 		totalSize += file.Size
 	}
 
 	var info strings.Builder
-	info.WriteString(fmt.Sprintf("文件: %d个", totalFiles))
+	info.WriteString(i18n.T(FileInfoCountText, totalFiles))
 
 	if validFiles != totalFiles {
-		info.WriteString(fmt.Sprintf(" (有效: %d个)", validFiles))
+		info.WriteString(i18n.T(FileInfoValidText, validFiles))
 	}
 
 	if encryptedFiles > 0 {
-		info.WriteString(fmt.Sprintf(" (加密: %d个)", encryptedFiles))
+		info.WriteString(i18n.T(FileInfoEncryptedText, encryptedFiles))
+	}
+
+	if signedFiles > 0 {
+		info.WriteString(i18n.T(FileInfoSignedText, signedFiles))
 	}
 
 	if totalPages > 0 {
-		info.WriteString(fmt.Sprintf(", 总页数: %d页", totalPages))
+		info.WriteString(i18n.T(FileInfoPagesText, totalPages))
 	}
 
-	info.WriteString(fmt.Sprintf(", 总大小: %s", formatFileSize(totalSize)))
+	info.WriteString(i18n.T(FileInfoSizeText, formatFileSize(totalSize)))
 
 	return info.String()
 }
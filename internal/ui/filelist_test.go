@@ -1,10 +1,18 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 
+	"github.com/user/pdf-merger/internal/i18n"
 	"github.com/user/pdf-merger/internal/model"
 )
 
@@ -209,6 +217,114 @@ func TestFileListManager_GetFileInfo(t *testing.T) {
 	}
 }
 
+func TestFileListManager_SignatureBadge(t *testing.T) {
+	flm := NewFileListManager()
+	flm.SetOnFileInfo(func(path string) (*model.FileEntry, error) {
+		return &model.FileEntry{Path: path, Size: 1024, PageCount: 1, IsValid: true, Signatures: 2}, nil
+	})
+
+	flm.AddFile("/test/signed.pdf")
+	if status := flm.getStatusText(flm.files[0]); status != "正常 "+i18n.T(SignatureBadge) {
+		t.Errorf("Expected the signature badge in the status, got %q", status)
+	}
+	if info := flm.GetFileInfo(); !contains(info, "签名: 1个") {
+		t.Errorf("Expected signed file count in info, got: %s", info)
+	}
+
+	flm.files[0].SetError("broken")
+	if status := flm.getStatusText(flm.files[0]); status != "错误" {
+		t.Errorf("Invalid files should not show the badge, got %q", status)
+	}
+}
+
+func TestFileListManager_WarningIndicator(t *testing.T) {
+	test.NewApp()
+	flm := NewFileListManager()
+	flm.SetOnFileInfo(func(path string) (*model.FileEntry, error) {
+		return &model.FileEntry{Path: path, Size: 1024, PageCount: 1, IsValid: true,
+			Warnings: []string{"缺少PDF结束标记", "交叉引用偏移不准确"}}, nil
+	})
+
+	flm.AddFile("/test/quirk.pdf")
+	file := flm.files[0]
+	if status := flm.getStatusText(file); status != "正常 "+i18n.T(WarningBadge) {
+		t.Errorf("Expected the warning badge in the status, got %q", status)
+	}
+	if tooltip := warningTooltip(file); tooltip != "缺少PDF结束标记\n交叉引用偏移不准确" {
+		t.Errorf("Expected one warning per tooltip line, got %q", tooltip)
+	}
+
+	row := flm.createListItem().(*fyne.Container)
+	flm.updateListItem(0, row)
+	if icon := row.Objects[2].(*widget.Icon); icon.Resource.Name() == theme.DocumentIcon().Name() {
+		t.Error("Files with warnings should show the warning icon")
+	}
+	if label := row.Objects[5].(*tooltipLabel); label.tooltip != warningTooltip(file) {
+		t.Errorf("Expected the status tooltip to list the warnings, got %q", label.tooltip)
+	}
+
+	flm.files[0].SetError("broken")
+	if status := flm.getStatusText(flm.files[0]); status != "错误" {
+		t.Errorf("Invalid files should not show the warning badge, got %q", status)
+	}
+	if tooltip := warningTooltip(flm.files[0]); tooltip != "" {
+		t.Errorf("Invalid files should not have a warning tooltip, got %q", tooltip)
+	}
+}
+
+func TestFileListManager_PageSizeTooltip(t *testing.T) {
+	test.NewApp()
+	flm := NewFileListManager()
+	flm.SetOnFileInfo(func(path string) (*model.FileEntry, error) {
+		return &model.FileEntry{Path: path, Size: 1024, PageCount: 12, IsValid: true, PageSize: "A4"}, nil
+	})
+
+	flm.AddFile("/test/report.pdf")
+	if tooltip := pageSizeTooltip(flm.files[0]); tooltip != i18n.T(PageSizeFormat, "A4", i18n.T(PortraitLabel), 12) {
+		t.Errorf("Expected the dominant page size and page count, got %q", tooltip)
+	}
+
+	row := flm.createListItem().(*fyne.Container)
+	flm.updateListItem(0, row)
+	if label := row.Objects[3].(*tooltipLabel); label.tooltip != pageSizeTooltip(flm.files[0]) {
+		t.Errorf("Expected the name tooltip to show the page size, got %q", label.tooltip)
+	}
+
+	flm.files[0].Landscape = true
+	flm.files[0].MixedPageSizes = true
+	want := i18n.T(PageSizeFormat, "A4", i18n.T(LandscapeLabel), 12) + "\n" + i18n.T(MixedPageSizesLabel)
+	if tooltip := pageSizeTooltip(flm.files[0]); tooltip != want {
+		t.Errorf("Expected the orientation and a mixed sizes note, got %q", tooltip)
+	}
+
+	flm.files[0].PageSize = ""
+	if tooltip := pageSizeTooltip(flm.files[0]); tooltip != "" {
+		t.Errorf("Files without page geometry should not have a tooltip, got %q", tooltip)
+	}
+}
+
+func TestTooltipLabel_ShowsOnHover(t *testing.T) {
+	test.NewApp()
+	label := newTooltipLabel("正常")
+	window := test.NewWindow(label)
+	defer window.Close()
+
+	label.MouseIn(nil)
+	if label.popUp != nil {
+		t.Error("Labels without a tooltip should not show a popup")
+	}
+
+	label.SetTooltip("缺少PDF结束标记")
+	label.MouseIn(nil)
+	if label.popUp == nil || !label.popUp.Visible() {
+		t.Fatal("Expected the tooltip to show on hover")
+	}
+	label.MouseOut()
+	if label.popUp != nil {
+		t.Error("Expected the tooltip to close when the mouse leaves")
+	}
+}
+
 func TestFileListManager_Callbacks(t *testing.T) {
 	flm := NewFileListManager()
 
@@ -242,6 +358,56 @@ func TestFileListManager_Callbacks(t *testing.T) {
 	}
 }
 
+func TestFileListManager_DuplicateContentNeedsConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, name := range []string{"a.pdf", "copy of a.pdf", "b.pdf"} {
+		paths[i] = filepath.Join(dir, name)
+		content := "%PDF-1.4\n"
+		if name == "b.pdf" {
+			content = "%PDF-1.7\n"
+		}
+		if err := os.WriteFile(paths[i], []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flm := NewFileListManager()
+	var asked, existing string
+	var confirm func()
+	flm.SetOnDuplicateFile(func(filePath, first string, add func()) {
+		asked, existing, confirm = filePath, first, add
+	})
+
+	if err := flm.AddFile(paths[0]); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if err := flm.AddFile(paths[1]); !errors.Is(err, ErrDuplicateContent) {
+		t.Fatalf("Expected ErrDuplicateContent, got %v", err)
+	}
+	if asked != paths[1] || existing != paths[0] {
+		t.Errorf("Expected confirmation for %s (same as %s), got %s (%s)", paths[1], paths[0], asked, existing)
+	}
+	if flm.GetFileCount() != 1 {
+		t.Fatalf("Expected duplicate to wait for confirmation, got %d files", flm.GetFileCount())
+	}
+
+	// 确认后添加，重复确认不会再次添加
+	confirm()
+	confirm()
+	if flm.GetFileCount() != 2 {
+		t.Errorf("Expected 2 files after confirmation, got %d", flm.GetFileCount())
+	}
+
+	// 内容不同的文件直接添加
+	if err := flm.AddFile(paths[2]); err != nil {
+		t.Errorf("AddFile failed: %v", err)
+	}
+	if flm.GetFileCount() != 3 {
+		t.Errorf("Expected 3 files, got %d", flm.GetFileCount())
+	}
+}
+
 func TestFileListManager_Widget(t *testing.T) {
 	flm := NewFileListManager()
 
@@ -261,6 +427,205 @@ func TestFileListManager_Widget(t *testing.T) {
 	// 这里我们无法直接测试widget的内容，但可以确保没有崩溃
 }
 
+func TestFileListManager_RefreshKeepsOrderAndSelection(t *testing.T) {
+	flm := NewFileListManager()
+	flm.AddFile("/test/a.pdf")
+	flm.AddFile("/test/b.pdf")
+	flm.AddFile("/test/c.pdf")
+	flm.AddFile("/test/d.pdf")
+
+	// 调整顺序：d 上移到第二位，并保持选中
+	flm.SelectFile("/test/d.pdf")
+	flm.MoveSelectedUp()
+	flm.MoveSelectedUp()
+	expected := []string{"/test/a.pdf", "/test/d.pdf", "/test/b.pdf", "/test/c.pdf"}
+
+	// 刷新时一个文件信息获取失败，另一个变为无效
+	flm.SetOnFileInfo(func(path string) (*model.FileEntry, error) {
+		switch path {
+		case "/test/b.pdf":
+			return nil, fmt.Errorf("读取失败")
+		case "/test/c.pdf":
+			return &model.FileEntry{Path: path, IsValid: false, Error: "损坏"}, nil
+		}
+		return &model.FileEntry{Path: path, Size: 2048, PageCount: 3, IsValid: true}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		flm.RefreshFileInfo()
+
+		paths := flm.GetFilePaths()
+		for j, path := range expected {
+			if paths[j] != path {
+				t.Fatalf("刷新后顺序改变: %v", paths)
+			}
+		}
+		for j, file := range flm.GetFiles() {
+			if file.Order != j {
+				t.Errorf("条目 %s 的Order应为 %d，实际 %d", file.Path, j, file.Order)
+			}
+		}
+		if flm.GetSelectedPath() != "/test/d.pdf" || flm.GetSelectedIndex() != 1 {
+			t.Errorf("刷新后选中条目改变: %s (%d)", flm.GetSelectedPath(), flm.GetSelectedIndex())
+		}
+	}
+
+	files := flm.GetFiles()
+	if files[2].IsValid || files[2].Error != "读取失败" {
+		t.Errorf("获取失败的条目应原地标记为错误: %+v", files[2])
+	}
+	if files[3].IsValid {
+		t.Errorf("无效条目应更新状态: %+v", files[3])
+	}
+}
+
+func TestFileListManager_RemoveSelectedSelectsSuccessor(t *testing.T) {
+	flm := NewFileListManager()
+	flm.AddFile("/test/a.pdf")
+	flm.AddFile("/test/b.pdf")
+	flm.AddFile("/test/c.pdf")
+
+	flm.SelectFile("/test/b.pdf")
+	flm.RemoveSelected()
+	if flm.GetSelectedPath() != "/test/c.pdf" {
+		t.Errorf("移除后应选中后继 c.pdf，实际 %q", flm.GetSelectedPath())
+	}
+
+	// 移除末尾条目时选中新的末尾
+	flm.RemoveSelected()
+	if flm.GetSelectedPath() != "/test/a.pdf" {
+		t.Errorf("移除末尾后应选中 a.pdf，实际 %q", flm.GetSelectedPath())
+	}
+
+	flm.RemoveSelected()
+	if flm.GetSelectedIndex() != -1 || flm.GetSelectedPath() != "" {
+		t.Errorf("列表为空时不应有选中条目，实际 %d", flm.GetSelectedIndex())
+	}
+}
+
+func TestFileListManager_AddFileAtKeepsSelection(t *testing.T) {
+	flm := NewFileListManager()
+	flm.AddFile("/test/a.pdf")
+	flm.AddFile("/test/b.pdf")
+	flm.SelectFile("/test/b.pdf")
+
+	// 默认追加到末尾
+	flm.AddFile("/test/c.pdf")
+	// 指定插入位置
+	if err := flm.AddFileAt("/test/x.pdf", 0); err != nil {
+		t.Fatalf("AddFileAt failed: %v", err)
+	}
+	// 规范路径相同视为重复
+	if err := flm.AddFileAt("/test/../test/a.pdf", 1); err == nil {
+		t.Error("Expected error when adding duplicate canonical path")
+	}
+
+	paths := flm.GetFilePaths()
+	expected := []string{"/test/x.pdf", "/test/a.pdf", "/test/b.pdf", "/test/c.pdf"}
+	for i, path := range expected {
+		if paths[i] != path {
+			t.Fatalf("Expected order %v, got %v", expected, paths)
+		}
+	}
+	if flm.GetSelectedPath() != "/test/b.pdf" || flm.GetSelectedIndex() != 2 {
+		t.Errorf("插入后选中条目应保持为 b.pdf，实际 %s (%d)", flm.GetSelectedPath(), flm.GetSelectedIndex())
+	}
+}
+
+func newMultiSelectList(paths ...string) *FileListManager {
+	flm := NewFileListManager()
+	for _, path := range paths {
+		flm.AddFile(path)
+	}
+	return flm
+}
+
+func expectPaths(t *testing.T, flm *FileListManager, expected ...string) {
+	t.Helper()
+	paths := flm.GetFilePaths()
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, paths)
+		}
+	}
+}
+
+func TestFileListManager_MultiSelectRemove(t *testing.T) {
+	flm := newMultiSelectList("/test/a.pdf", "/test/b.pdf", "/test/c.pdf", "/test/d.pdf", "/test/e.pdf")
+
+	flm.SelectFile("/test/b.pdf")
+	flm.ToggleSelection("/test/d.pdf")
+	flm.ToggleSelection("/test/e.pdf")
+
+	indices := flm.GetSelectedIndices()
+	if len(indices) != 3 || indices[0] != 1 || indices[1] != 3 || indices[2] != 4 {
+		t.Fatalf("Expected selected indices [1 3 4], got %v", indices)
+	}
+
+	flm.RemoveSelected()
+	expectPaths(t, flm, "/test/a.pdf", "/test/c.pdf")
+	if got := flm.GetSelectedIndices(); len(got) != 1 || flm.GetSelectedPath() != "/test/c.pdf" {
+		t.Errorf("批量移除后应只选中第一个被移除位置上的 c.pdf，实际 %v (%s)", got, flm.GetSelectedPath())
+	}
+}
+
+func TestFileListManager_MultiSelectMoveKeepsRelativeOrder(t *testing.T) {
+	flm := newMultiSelectList("/test/a.pdf", "/test/b.pdf", "/test/c.pdf", "/test/d.pdf", "/test/e.pdf")
+
+	flm.SelectFile("/test/d.pdf")
+	flm.ToggleSelection("/test/b.pdf")
+
+	flm.MoveSelectedUp()
+	expectPaths(t, flm, "/test/b.pdf", "/test/a.pdf", "/test/d.pdf", "/test/c.pdf", "/test/e.pdf")
+
+	// b 已在顶部不再移动，d 继续上移但不越过 b
+	if !flm.CanMoveSelectedUp() {
+		t.Fatal("d.pdf 仍可上移")
+	}
+	flm.MoveSelectedUp()
+	expectPaths(t, flm, "/test/b.pdf", "/test/d.pdf", "/test/a.pdf", "/test/c.pdf", "/test/e.pdf")
+	if flm.CanMoveSelectedUp() {
+		t.Error("选中条目整体位于顶部时不应能上移")
+	}
+
+	flm.MoveSelectedDown()
+	expectPaths(t, flm, "/test/a.pdf", "/test/b.pdf", "/test/d.pdf", "/test/c.pdf", "/test/e.pdf")
+	if flm.GetSelectedPath() != "/test/d.pdf" || len(flm.GetSelectedIndices()) != 2 {
+		t.Errorf("移动后选择应保持不变，实际 %v (%s)", flm.GetSelectedIndices(), flm.GetSelectedPath())
+	}
+}
+
+func TestFileListManager_ToggleSelection(t *testing.T) {
+	flm := newMultiSelectList("/test/a.pdf", "/test/b.pdf", "/test/c.pdf")
+
+	// 没有选中条目时勾选的条目成为当前条目
+	flm.ToggleSelection("/test/b.pdf")
+	if flm.GetSelectedPath() != "/test/b.pdf" {
+		t.Fatalf("Expected b.pdf to become current, got %q", flm.GetSelectedPath())
+	}
+	flm.ToggleSelection("/test/c.pdf")
+
+	// 取消当前条目时由剩余的选中条目接替
+	flm.ToggleSelection("/test/b.pdf")
+	if flm.GetSelectedPath() != "/test/c.pdf" || len(flm.GetSelectedIndices()) != 1 {
+		t.Errorf("Expected c.pdf to remain selected, got %v (%s)", flm.GetSelectedIndices(), flm.GetSelectedPath())
+	}
+
+	// 单选会清除其他选中条目
+	flm.ToggleSelection("/test/a.pdf")
+	flm.SelectFile("/test/b.pdf")
+	if got := flm.GetSelectedIndices(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("Expected only b.pdf selected, got %v", got)
+	}
+
+	if flm.ToggleSelection("/test/missing.pdf") {
+		t.Error("Expected false for file not in list")
+	}
+}
+
 // 辅助函数
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsSubstring(s, substr)))
@@ -274,3 +639,34 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFileListManager_RotateFile(t *testing.T) {
+	flm := NewFileListManager()
+	flm.AddFile("/test/a.pdf")
+	flm.AddFile("/test/b.pdf")
+
+	changed := 0
+	flm.SetOnFileChanged(func() { changed++ })
+
+	for i := 0; i < 3; i++ {
+		if !flm.RotateFile("/test/b.pdf") {
+			t.Fatal("RotateFile should find the entry")
+		}
+	}
+	rotations := flm.GetRotations()
+	if len(rotations) != 1 || rotations["/test/b.pdf"] != 270 {
+		t.Errorf("Expected only b.pdf rotated by 270, got %v", rotations)
+	}
+	if changed != 3 {
+		t.Errorf("Expected 3 change notifications, got %d", changed)
+	}
+
+	// 270之后回到0，不再出现在旋转列表中
+	flm.RotateFile("/test/b.pdf")
+	if len(flm.GetRotations()) != 0 {
+		t.Errorf("Expected rotation to wrap to 0, got %v", flm.GetRotations())
+	}
+	if flm.RotateFile("/test/missing.pdf") {
+		t.Error("RotateFile should return false for unknown files")
+	}
+}
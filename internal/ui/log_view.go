@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// maxLogLines 日志视图保留的行数，超出时丢弃最早的行
+const maxLogLines = 500
+
+// logBuffer 日志视图的有界行缓冲，可以在任意协程中追加
+type logBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	limit    int
+	onChange func(text string)
+}
+
+func newLogBuffer(limit int) *logBuffer {
+	return &logBuffer{limit: limit}
+}
+
+// Append 追加一行，超出上限时丢弃最早的行
+func (b *logBuffer) Append(line string) {
+	b.mu.Lock()
+	b.lines = append(b.lines, line)
+	if len(b.lines) > b.limit {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-b.limit:]...)
+	}
+	onChange := b.onChange
+	text := strings.Join(b.lines, "\n")
+	b.mu.Unlock()
+
+	if onChange != nil {
+		onChange(text)
+	}
+}
+
+// Text 返回当前保留的全部日志
+func (b *logBuffer) Text() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Join(b.lines, "\n")
+}
+
+// SetOnChange 设置追加日志后的回调，nil 表示不再通知
+func (b *logBuffer) SetOnChange(onChange func(text string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = onChange
+}
+
+// Logger 返回把Info及以上级别的日志追加到日志视图的Logger，
+// 可以通过 pdf.SetDefaultLogger 或 MergeOptions.Logger 挂接
+func (u *UI) Logger() pdf.Logger {
+	c := clock.System()
+	if u.controller != nil {
+		c = clock.OrSystem(u.controller.Clock)
+	}
+	return pdf.LoggerFunc(func(level pdf.LogLevel, message string) {
+		if level < pdf.LogInfo {
+			return
+		}
+		u.logs.Append(fmt.Sprintf("%s %-5s %s", c.Now().Format("15:04:05"), level, message))
+	})
+}
+
+// onShowLog 日志按钮点击处理：显示最近的日志，窗口打开期间实时更新
+func (u *UI) onShowLog() {
+	view := widget.NewMultiLineEntry()
+	view.Wrapping = fyne.TextWrapWord
+	view.SetText(u.logs.Text())
+	view.Disable()
+	if view.Text == "" {
+		view.SetPlaceHolder(i18n.T(LogEmptyText))
+	}
+
+	u.logs.SetOnChange(view.SetText)
+	panel := dialog.NewCustom(i18n.T(LogTitle), i18n.T(CloseButton), view, u.window)
+	panel.SetOnClosed(func() { u.logs.SetOnChange(nil) })
+	panel.Resize(fyne.NewSize(640, 400))
+	panel.Show()
+}
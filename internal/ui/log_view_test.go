@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestLogBuffer_KeepsNewestLines(t *testing.T) {
+	buffer := newLogBuffer(2)
+	var notified string
+	buffer.SetOnChange(func(text string) { notified = text })
+
+	buffer.Append("one")
+	buffer.Append("two")
+	buffer.Append("three")
+
+	if got := buffer.Text(); got != "two\nthree" {
+		t.Errorf("Expected the two newest lines, got %q", got)
+	}
+	if notified != buffer.Text() {
+		t.Errorf("Expected change callback with the buffer text, got %q", notified)
+	}
+}
+
+func TestUI_LoggerSkipsDebug(t *testing.T) {
+	app := test.NewApp()
+	ui := NewUI(app.NewWindow("Test"), nil)
+	logger := ui.Logger()
+
+	logger.Debug("adapter ready")
+	logger.Warn("could not remove %s", "tmp.pdf")
+
+	text := ui.logs.Text()
+	if strings.Contains(text, "adapter ready") {
+		t.Errorf("Debug messages should not reach the log view: %q", text)
+	}
+	if !strings.Contains(text, "WARN  could not remove tmp.pdf") {
+		t.Errorf("Expected the warning in the log view, got %q", text)
+	}
+}
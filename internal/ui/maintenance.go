@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// onMaintenance 维护按钮点击处理：显示临时文件使用量、保留的任务工作区、遗留文件扫描和密码保险库入口
+func (u *UI) onMaintenance() {
+	var panel dialog.Dialog
+	content := container.NewVBox()
+	var refresh func()
+	refresh = func() {
+		bytes, count := u.controller.FileManager.GetTempUsage()
+		content.Objects = []fyne.CanvasObject{
+			widget.NewLabel(tempUsageText(bytes, count, u.controller.Config)),
+			widget.NewSeparator(),
+			u.buildWorkspaceList(refresh),
+		}
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewButton(i18n.T(ScanLegacyButton), func() {
+			panel.Hide()
+			u.onScanLegacy()
+		}))
+		content.Add(widget.NewButton(i18n.T(VaultButton), func() {
+			panel.Hide()
+			u.onPasswordVault()
+		}))
+		content.Refresh()
+	}
+	refresh()
+
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(560, 300))
+	panel = dialog.NewCustom(i18n.T(MaintenanceTitle), i18n.T(CloseButton), scroll, u.window)
+	panel.Show()
+}
+
+// tempUsageText 临时目录的使用量，设置了配额时附带上限
+func tempUsageText(bytes int64, count int, config *model.Config) string {
+	text := i18n.T(TempUsageText, count, formatFileSize(bytes))
+	if config != nil && config.MaxTempBytes > 0 {
+		text += i18n.T(TempQuotaBytesText, formatFileSize(config.MaxTempBytes))
+	}
+	if config != nil && config.MaxTempFiles > 0 {
+		text += i18n.T(TempQuotaFilesText, config.MaxTempFiles)
+	}
+	return text
+}
+
+// buildWorkspaceList 列出保留的任务工作区及可回收空间，每个工作区带丢弃按钮；
+// 丢弃成功后调用 changed 重新构建列表
+func (u *UI) buildWorkspaceList(changed func()) fyne.CanvasObject {
+	workspaces, err := u.controller.ListWorkspaces()
+	if err != nil {
+		return widget.NewLabel(err.Error())
+	}
+	if len(workspaces) == 0 {
+		return widget.NewLabel(i18n.T(WorkspacesNoneFound))
+	}
+
+	var total int64
+	rows := container.NewVBox()
+	for _, ws := range workspaces {
+		ws := ws
+		total += ws.Size
+		text := fmt.Sprintf("%s  %s  %s", ws.JobID, formatFileSize(ws.Size), formatAge(ws.Age))
+		if ws.Resumable {
+			text += "  " + i18n.T(WorkspaceResumableText)
+		}
+		discard := widget.NewButton(i18n.T(DiscardWorkspaceButton), func() {
+			dialog.ShowConfirm(i18n.T(MaintenanceTitle), i18n.T(DiscardWorkspaceConfirm, ws.JobID), func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := u.controller.DiscardWorkspace(ws.JobID); err != nil {
+					dialog.ShowError(err, u.window)
+				}
+				changed()
+			}, u.window)
+		})
+		rows.Add(container.NewBorder(nil, nil, nil, discard, widget.NewLabel(text)))
+	}
+
+	header := widget.NewLabel(i18n.T(WorkspacesReclaimableText, len(workspaces), formatFileSize(total)))
+	return container.NewVBox(header, rows)
+}
+
+// CheckInterruptedJobs 在启动时检查上次运行中断的合并任务（例如程序崩溃），有时显示列表，
+// 每个任务可以用相同的输入重新执行或清理它留下的临时文件
+func (u *UI) CheckInterruptedJobs() {
+	if u.controller == nil {
+		return
+	}
+	jobs, err := u.controller.InterruptedJobs()
+	if err != nil {
+		log.Printf("警告: 无法检查中断的任务: %v", err)
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	var panel dialog.Dialog
+	rows := container.NewVBox(widget.NewLabel(i18n.T(InterruptedJobsText, len(jobs))))
+	remaining := len(jobs)
+	done := func(row fyne.CanvasObject) {
+		rows.Remove(row)
+		remaining--
+		if remaining == 0 {
+			panel.Hide()
+		}
+	}
+	now := clock.OrSystem(u.controller.Clock).Now()
+	for _, job := range jobs {
+		job := job
+		var row *fyne.Container
+		cleanUp := widget.NewButton(i18n.T(CleanUpInterruptedButton), func() {
+			if err := u.controller.DiscardInterruptedJob(job.Job.ID); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			done(row)
+		})
+		resume := widget.NewButton(i18n.T(ResumeInterruptedButton), func() {
+			panel.Hide()
+			u.resumeInterruptedJob(job)
+		})
+		if !job.Resumable() {
+			resume.Disable()
+		}
+		row = container.NewBorder(nil, nil, nil, container.NewHBox(resume, cleanUp),
+			widget.NewLabel(formatInterruptedJob(job, now)))
+		rows.Add(row)
+	}
+
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(560, 200))
+	panel = dialog.NewCustom(i18n.T(InterruptedJobsTitle), i18n.T(CloseButton), scroll, u.window)
+	panel.Show()
+}
+
+// resumeInterruptedJob 重新执行中断的任务，进度与正常合并一样显示在主窗口
+func (u *UI) resumeInterruptedJob(job controller.InterruptedJob) {
+	u.mergeButton.Hide()
+	u.cancelButton.Show()
+	u.disableInputControls()
+	u.progressManager.Start(5, len(job.Job.AdditionalFiles)+1)
+
+	jobID, err := u.controller.ResumeInterruptedJob(job.Job.ID)
+	if jobID == "" {
+		dialog.ShowError(err, u.window)
+		u.cancelAsyncMerge()
+		return
+	}
+	if err != nil {
+		log.Printf("警告: %v", err)
+	}
+}
+
+// formatInterruptedJob 以当前语言描述中断的任务：输出文件、输入数、中断时的阶段和时间
+func formatInterruptedJob(job controller.InterruptedJob, now time.Time) string {
+	text := i18n.T(InterruptedJobText, filepath.Base(job.Job.OutputPath),
+		len(job.Job.AdditionalFiles)+1, job.Stage, formatAge(now.Sub(job.UpdatedAt)))
+	if len(job.MissingInputs) > 0 {
+		text += "; " + i18n.T(InterruptedMissingText, len(job.MissingInputs))
+	}
+	return text
+}
+
+// formatAge 以当前语言显示工作区的年龄
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return i18n.T(AgeDaysText, int(age/(24*time.Hour)))
+	case age >= time.Hour:
+		return i18n.T(AgeHoursText, int(age/time.Hour))
+	default:
+		return i18n.T(AgeMinutesText, int(age/time.Minute))
+	}
+}
+
+// onScanLegacy 选择目录，扫描旧版本遗留的文件，确认后隔离
+func (u *UI) onScanLegacy() {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		u.showLegacyCleanup(uri.Path())
+	}, u.window)
+	folderDialog.SetDismissText(i18n.T(CancelButton))
+	folderDialog.Show()
+}
+
+// showLegacyCleanup 显示扫描报告，确认后把遗留文件移入隔离目录。
+// 界面只提供隔离，不提供删除，误判的文件可以从隔离目录找回。
+func (u *UI) showLegacyCleanup(dir string) {
+	report, err := pdf.CleanupLegacyArtifacts([]string{dir}, pdf.LegacyCleanupOptions{Mode: pdf.CleanupDryRun})
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	if len(report.Actions) == 0 {
+		dialog.ShowInformation(i18n.T(CleanupReportTitle), i18n.T(CleanupNoneFound), u.window)
+		return
+	}
+
+	reportText := widget.NewLabel(formatLegacyReport(dir, report))
+	scroll := container.NewVScroll(reportText)
+	scroll.SetMinSize(fyne.NewSize(560, 240))
+	content := container.NewBorder(nil, widget.NewLabel(i18n.T(CleanupConfirmText)), nil, nil, scroll)
+
+	dialog.ShowCustomConfirm(i18n.T(CleanupReportTitle), i18n.T(CleanupConfirmButton), i18n.T(CancelButton), content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		result, err := pdf.CleanupLegacyArtifacts([]string{dir}, pdf.LegacyCleanupOptions{
+			Mode:            pdf.CleanupQuarantine,
+			IncludeNameOnly: true,
+			Log:             log.Writer(),
+		})
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+
+		var moved int
+		var failed []string
+		for _, action := range result.Actions {
+			if action.Error != "" {
+				failed = append(failed, fmt.Sprintf("%s: %s", action.Artifact.Path, action.Error))
+			} else if action.Action == "quarantine" {
+				moved++
+			}
+		}
+		if len(failed) > 0 {
+			dialog.ShowError(fmt.Errorf("%s", strings.Join(failed, "\n")), u.window)
+			return
+		}
+		dialog.ShowInformation(i18n.T(CleanupReportTitle),
+			i18n.T(CleanupDoneText, moved, filepath.Join(dir, pdf.LegacyQuarantineDirName)), u.window)
+	}, u.window)
+}
+
+// formatLegacyReport 以当前语言列出扫描结果
+func formatLegacyReport(dir string, report *pdf.LegacyCleanupReport) string {
+	var b strings.Builder
+	for _, action := range report.Actions {
+		rel, err := filepath.Rel(dir, action.Artifact.Path)
+		if err != nil {
+			rel = action.Artifact.Path
+		}
+		confidence := i18n.T(LegacyVerifiedText)
+		if action.Artifact.Confidence == pdf.ConfidenceNameOnly {
+			confidence = i18n.T(LegacyNameOnlyText)
+		}
+		fmt.Fprintf(&b, "%s  (%s, %s)\n", rel, action.Artifact.Kind, confidence)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+func TestTempUsageText(t *testing.T) {
+	original := i18n.CurrentLocale()
+	i18n.SetLocale(i18n.ZhCN)
+	defer i18n.SetLocale(original)
+
+	if got, want := tempUsageText(2048, 3, nil), "临时文件: 3 个，共 2.0 KB"; got != want {
+		t.Errorf("没有配额时期望 %q，实际为 %q", want, got)
+	}
+
+	config := model.DefaultConfig()
+	config.MaxTempBytes = 1024 * 1024
+	config.MaxTempFiles = 10
+	if got, want := tempUsageText(2048, 3, config), "临时文件: 3 个，共 2.0 KB，上限 1.0 MB，最多 10 个"; got != want {
+		t.Errorf("有配额时期望 %q，实际为 %q", want, got)
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// ShowMergeSummary 合并完成后显示摘要对话框：输出、页数、耗时、峰值内存以及各输入的处理结果
+func (u *UI) ShowMergeSummary(result *pdf.MergeResult) {
+	u.progressManager.Complete(i18n.T(MergeCompleteOutputText, result.OutputPath))
+
+	content := container.NewVBox()
+	for _, line := range mergeSummaryOverview(result) {
+		label := widget.NewLabel(line)
+		label.Wrapping = fyne.TextWrapWord
+		content.Add(label)
+	}
+
+	if len(result.SkippedFiles) > 0 {
+		content.Add(widget.NewSeparator())
+		content.Add(widget.NewLabelWithStyle(i18n.T(SummarySkippedHeading, len(result.SkippedFiles)),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		table := container.NewGridWithColumns(2)
+		for _, file := range result.SkippedFiles {
+			name := widget.NewLabel(filepath.Base(file))
+			reason := widget.NewLabel(skipReason(result, file))
+			reason.Wrapping = fyne.TextWrapWord
+			table.Add(name)
+			table.Add(reason)
+		}
+		content.Add(table)
+	}
+
+	if notes := mergeSummaryNotes(result); len(notes) > 0 {
+		content.Add(widget.NewSeparator())
+		for _, note := range notes {
+			content.Add(widget.NewLabel(note))
+		}
+	}
+
+	openFolder := widget.NewButton(i18n.T(OpenFolderButton), func() {
+		folder := &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Dir(result.OutputPath))}
+		if err := fyne.CurrentApp().OpenURL(folder); err != nil {
+			dialog.ShowError(err, u.window)
+		}
+	})
+	var copySummary *widget.Button
+	copySummary = widget.NewButton(i18n.T(CopySummaryButton), func() {
+		u.window.Clipboard().SetContent(mergeSummaryText(result))
+		copySummary.SetText(i18n.T(SummaryCopiedText))
+	})
+	content.Add(container.NewHBox(openFolder, copySummary))
+
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(560, 320))
+	dialog.NewCustom(i18n.T(MergeSummaryTitle), i18n.T(CloseButton), scroll, u.window).Show()
+}
+
+// mergeSummaryOverview 摘要开头的输出路径、页数、耗时和峰值内存
+func mergeSummaryOverview(result *pdf.MergeResult) []string {
+	memory := i18n.T(SummaryNotMeasuredText)
+	if result.PeakMemory > 0 {
+		memory = formatFileSize(result.PeakMemory)
+	}
+	return []string{
+		i18n.T(SummaryOutputText, result.OutputPath),
+		i18n.T(SummaryPagesText, result.TotalPages),
+		i18n.T(SummaryTimeText, formatDuration(result.ProcessingTime)),
+		i18n.T(SummaryPeakMemoryText, memory),
+	}
+}
+
+// mergeSummaryNotes 修复后合并和内容重复的输入说明
+func mergeSummaryNotes(result *pdf.MergeResult) []string {
+	var notes []string
+	for _, file := range result.RepairedFiles {
+		notes = append(notes, i18n.T(SummaryRepairedText, filepath.Base(file)))
+	}
+	for _, duplicate := range result.Duplicates {
+		notes = append(notes, i18n.T(SummaryDuplicateText,
+			filepath.Base(duplicate.File), filepath.Base(duplicate.DuplicateOf)))
+	}
+	return notes
+}
+
+// skipReason 返回输入被跳过的原因，没有记录时返回"原因未知"
+func skipReason(result *pdf.MergeResult, file string) string {
+	if reason := result.SkipReasons[file]; reason != "" {
+		return reason
+	}
+	return i18n.T(SummaryUnknownReason)
+}
+
+// mergeSummaryText 生成"复制摘要"使用的纯文本，跳过的文件保留完整路径
+func mergeSummaryText(result *pdf.MergeResult) string {
+	var b strings.Builder
+	for _, line := range mergeSummaryOverview(result) {
+		b.WriteString(line + "\n")
+	}
+	if len(result.SkippedFiles) > 0 {
+		b.WriteString("\n" + i18n.T(SummarySkippedHeading, len(result.SkippedFiles)) + "\n")
+		for _, file := range result.SkippedFiles {
+			b.WriteString("- " + file + ": " + skipReason(result, file) + "\n")
+		}
+	}
+	if notes := mergeSummaryNotes(result); len(notes) > 0 {
+		b.WriteString("\n")
+		for _, note := range notes {
+			b.WriteString(note + "\n")
+		}
+	}
+	return b.String()
+}
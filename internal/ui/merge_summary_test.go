@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+func TestMergeSummaryText(t *testing.T) {
+	original := i18n.CurrentLocale()
+	i18n.SetLocale(i18n.ZhCN)
+	defer i18n.SetLocale(original)
+
+	result := &pdf.MergeResult{
+		OutputPath:     "/out/merged.pdf",
+		TotalPages:     12,
+		ProcessingTime: 1500 * time.Millisecond,
+		PeakMemory:     2 * 1024 * 1024,
+		SkippedFiles:   []string{"/in/broken.pdf", "/in/copy.pdf", "/in/other.pdf"},
+		SkipReasons: map[string]string{
+			"/in/broken.pdf": "文件头无效",
+			"/in/copy.pdf":   "与 /in/a.pdf 内容相同",
+		},
+		RepairedFiles: []string{"/in/fixed.pdf"},
+		Duplicates:    []pdf.DuplicateInput{{File: "/in/copy.pdf", DuplicateOf: "/in/a.pdf"}},
+	}
+
+	text := mergeSummaryText(result)
+	for _, want := range []string{
+		"输出文件: /out/merged.pdf",
+		"总页数: 12",
+		"跳过的文件 (3)",
+		"- /in/broken.pdf: 文件头无效",
+		"- /in/other.pdf: 原因未知",
+		"已修复后合并: fixed.pdf",
+		"copy.pdf 与 a.pdf 内容相同",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("摘要缺少 %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "未测量") {
+		t.Errorf("已测量峰值内存时不应显示未测量:\n%s", text)
+	}
+}
+
+func TestMergeSummaryText_Minimal(t *testing.T) {
+	original := i18n.CurrentLocale()
+	i18n.SetLocale(i18n.ZhCN)
+	defer i18n.SetLocale(original)
+
+	text := mergeSummaryText(&pdf.MergeResult{OutputPath: "/out/merged.pdf"})
+	if !strings.Contains(text, "峰值内存: 未测量") {
+		t.Errorf("未测量峰值内存时应说明:\n%s", text)
+	}
+	if strings.Contains(text, "跳过的文件") {
+		t.Errorf("没有跳过的文件时不应显示该部分:\n%s", text)
+	}
+}
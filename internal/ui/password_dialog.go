@@ -2,12 +2,13 @@ package ui
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/model"
 )
 
 // PasswordDialog 密码输入对话框
@@ -75,7 +76,7 @@ func (pd *PasswordDialog) createDialog(options *PasswordDialogOptions) {
 	content := container.NewVBox()
 
 	// 添加文件信息
-	fileName := filepath.Base(pd.filePath)
+	fileName := model.DisplayBase(pd.filePath, model.DefaultFilenameEncodings)
 	fileLabel := widget.NewLabel(fmt.Sprintf("文件: %s", fileName))
 	fileLabel.Wrapping = fyne.TextWrapWord
 	content.Add(fileLabel)
@@ -370,7 +371,7 @@ func (pcd *PasswordCacheDialog) createDialog() {
 
 			if id < len(pcd.cacheFiles) {
 				filePath := pcd.cacheFiles[id]
-				fileName := filepath.Base(filePath)
+				fileName := model.DisplayBase(filePath, model.DefaultFilenameEncodings)
 				label.SetText(fileName)
 
 				button.OnTapped = func() {
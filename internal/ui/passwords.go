@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"errors"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// passwordPromptFunc 询问加密文件的密码，第二个返回值为false表示用户取消。
+// 调用会阻塞到用户作出选择，不能在界面事件回调中直接调用。
+type passwordPromptFunc func(filePath string, attempt int, lastError error) (string, bool)
+
+// onEncryptedFileAdded 文件列表加入加密文件时在后台询问密码
+func (u *UI) onEncryptedFileAdded(filePath string) {
+	if u.controller == nil || u.passwordPrompt == nil {
+		return
+	}
+	go u.requestPassword(filePath)
+}
+
+// requestPassword 询问加密文件的密码并立即验证，最多询问 controller.MaxPasswordAttempts 次。
+// 密码保险库中保存了仍然有效的密码时不再询问。验证通过的密码保存在文件条目中，合并时传给任务，
+// 并由控制器保存到已打开的保险库；次数用尽时把条目标记为无效。
+// 同时加入多个加密文件时对话框依次显示。
+func (u *UI) requestPassword(filePath string) {
+	u.passwordMu.Lock()
+	defer u.passwordMu.Unlock()
+
+	if password, ok := u.controller.SavedPassword(filePath); ok {
+		u.fileListManager.SetPassword(filePath, password)
+		return
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= controller.MaxPasswordAttempts; attempt++ {
+		password, ok := u.passwordPrompt(filePath, attempt, lastErr)
+		if !ok {
+			return
+		}
+		err := u.controller.VerifyPassword(filePath, password)
+		if err == nil {
+			u.fileListManager.SetPassword(filePath, password)
+			return
+		}
+		lastErr = passwordError(err)
+	}
+	u.fileListManager.MarkInvalid(filePath, i18n.T(PasswordAttemptsExceededText, controller.MaxPasswordAttempts))
+}
+
+// passwordError 返回在密码对话框中显示的错误，只保留PDFError的消息而不展开底层原因
+func passwordError(err error) error {
+	var pdfErr *pdf.PDFError
+	if errors.As(err, &pdfErr) {
+		return errors.New(pdfErr.Message)
+	}
+	return err
+}
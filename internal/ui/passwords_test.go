@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/file"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// secretPDFService 只实现解密：密码为secret时成功
+type secretPDFService struct {
+	pdf.PDFService
+}
+
+func (s *secretPDFService) DecryptPDF(inputPath, outputPath, password string) error {
+	if password != "secret" {
+		return &pdf.PDFError{Type: pdf.ErrorEncrypted, Message: "wrong password", Cause: errors.New("detail")}
+	}
+	return nil
+}
+
+// newPasswordTestUI 创建带有一个加密条目的UI，prompt 依次返回 answers
+func newPasswordTestUI(t *testing.T, answers ...string) (*UI, *[]error) {
+	ctrl := controller.NewController(&secretPDFService{}, file.NewFileManager(t.TempDir()), model.DefaultConfig())
+	ui := &UI{controller: ctrl, fileListManager: NewFileListManager()}
+	ui.fileListManager.files = append(ui.fileListManager.files, model.FileEntry{
+		Path: "/test/locked.pdf", IsEncrypted: true, IsValid: true,
+	})
+
+	var shownErrors []error
+	ui.passwordPrompt = func(filePath string, attempt int, lastError error) (string, bool) {
+		shownErrors = append(shownErrors, lastError)
+		if len(answers) == 0 {
+			return "", false
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, true
+	}
+	return ui, &shownErrors
+}
+
+func TestUI_RequestPasswordStoresVerifiedPassword(t *testing.T) {
+	ui, shownErrors := newPasswordTestUI(t, "guess", "secret")
+	ui.requestPassword("/test/locked.pdf")
+
+	passwords := ui.fileListManager.GetPasswords()
+	if passwords["/test/locked.pdf"] != "secret" {
+		t.Errorf("Expected the verified password to be stored, got %v", len(passwords))
+	}
+	if len(*shownErrors) != 2 || (*shownErrors)[1] == nil || (*shownErrors)[1].Error() != "wrong password" {
+		t.Errorf("Expected the second prompt to show the previous error, got %v", *shownErrors)
+	}
+	if status := ui.fileListManager.getStatusText(ui.fileListManager.files[0]); status != "已解锁" {
+		t.Errorf("Expected unlocked status, got %q", status)
+	}
+}
+
+func TestUI_RequestPasswordMarksInvalidAfterMaxAttempts(t *testing.T) {
+	ui, shownErrors := newPasswordTestUI(t, "a", "b", "c", "secret")
+	ui.requestPassword("/test/locked.pdf")
+
+	if len(*shownErrors) != controller.MaxPasswordAttempts {
+		t.Errorf("Expected %d prompts, got %d", controller.MaxPasswordAttempts, len(*shownErrors))
+	}
+	entry := ui.fileListManager.files[0]
+	if entry.IsValid || entry.Password != "" {
+		t.Error("Entry should be invalid without a password after too many attempts")
+	}
+	if status := ui.fileListManager.getStatusText(entry); status != entry.Error || status == "" {
+		t.Errorf("Expected the error to be shown in the row, got %q", status)
+	}
+	if len(ui.fileListManager.GetPasswords()) != 0 {
+		t.Error("Invalid entries should not pass passwords to the merge")
+	}
+}
+
+func TestUI_RequestPasswordCancelled(t *testing.T) {
+	ui, _ := newPasswordTestUI(t)
+	ui.requestPassword("/test/locked.pdf")
+
+	entry := ui.fileListManager.files[0]
+	if !entry.IsValid || entry.Password != "" {
+		t.Error("Cancelling the prompt should leave the entry unchanged")
+	}
+}
+
+func TestUI_RequestPasswordUsesVault(t *testing.T) {
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked.pdf")
+	if err := os.WriteFile(locked, []byte("%PDF-1.4 encrypted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vault, err := pdf.OpenFileVault(filepath.Join(dir, "vault.json"), "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 验证通过的密码保存到保险库
+	ui, _ := newPasswordTestUI(t, "secret")
+	ui.controller.SetPasswordVault(vault)
+	ui.requestPassword(locked)
+	if password, ok := pdf.LookupVaultPassword(vault, locked); !ok || password != "secret" {
+		t.Fatalf("Expected the verified password to be saved in the vault, got %v", ok)
+	}
+
+	// 保险库中有有效密码时不再询问
+	ui, shownErrors := newPasswordTestUI(t)
+	ui.controller.SetPasswordVault(vault)
+	ui.fileListManager.files[0].Path = locked
+	ui.requestPassword(locked)
+	if len(*shownErrors) != 0 {
+		t.Errorf("Expected no prompt when the vault has the password, got %d", len(*shownErrors))
+	}
+	if ui.fileListManager.GetPasswords()[locked] != "secret" {
+		t.Error("Expected the saved password to be stored in the entry")
+	}
+}
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -9,12 +13,19 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 // ProgressManager 进度管理器
 type ProgressManager struct {
 	window      fyne.Window
 	progressBar *widget.ProgressBar
+	etaLabel    *widget.Label
 	statusLabel *widget.Label
 	detailLabel *widget.Label
 	timeLabel   *widget.Label
@@ -29,10 +40,22 @@ type ProgressManager struct {
 	currentFile    string
 	processedFiles int
 	totalFiles     int
+	throughput     float64
+	estimator      *model.RateEstimator
+	clock          clock.Clock // 计时和剩余时间估计的时间来源
 
 	// 回调函数
 	onCancel   func()
 	onComplete func()
+
+	// 跟随的任务
+	followMu sync.Mutex
+	unfollow func()
+}
+
+// jobSubscriber 可以按任务订阅回调的对象，Controller 和 EventHandler 都满足
+type jobSubscriber interface {
+	SubscribeJob(jobID string, callbacks controller.JobCallbacks) func()
 }
 
 // ProgressInfo 进度信息
@@ -45,12 +68,19 @@ type ProgressInfo struct {
 	TotalFiles     int
 	Step           int
 	TotalSteps     int
+	Throughput     float64       // 字节吞吐量（字节/秒），0表示未知
+	ETA            time.Duration // 剩余时间，0时按进度变化自行估计
 }
 
+// progressUnits 按进度估计剩余时间时把0.0-1.0的进度换算成的整数单位
+const progressUnits = 10000
+
 // NewProgressManager 创建新的进度管理器
 func NewProgressManager(window fyne.Window) *ProgressManager {
 	pm := &ProgressManager{
-		window: window,
+		window:    window,
+		estimator: model.NewRateEstimator(),
+		clock:     clock.System(),
 	}
 
 	pm.createComponents()
@@ -64,8 +94,13 @@ func (pm *ProgressManager) createComponents() {
 	pm.progressBar.SetValue(0)
 	pm.progressBar.Hide()
 
+	// 创建剩余时间标签，显示在进度条下方
+	pm.etaLabel = widget.NewLabel("")
+	pm.etaLabel.Alignment = fyne.TextAlignCenter
+	pm.etaLabel.Hide()
+
 	// 创建状态标签
-	pm.statusLabel = widget.NewLabel("准备就绪")
+	pm.statusLabel = widget.NewLabel(i18n.T(StatusReadyText))
 	pm.statusLabel.Alignment = fyne.TextAlignCenter
 	pm.statusLabel.TextStyle = fyne.TextStyle{Bold: true}
 
@@ -91,6 +126,7 @@ func (pm *ProgressManager) createComponents() {
 	// 创建主容器
 	pm.container = container.NewVBox(
 		pm.progressBar,
+		pm.etaLabel,
 		pm.statusLabel,
 		pm.detailLabel,
 		infoRow,
@@ -105,14 +141,18 @@ func (pm *ProgressManager) GetContainer() *fyne.Container {
 // Start 开始进度显示
 func (pm *ProgressManager) Start(totalSteps int, totalFiles int) {
 	pm.isActive = true
-	pm.startTime = time.Now()
+	pm.startTime = pm.clock.Now()
 	pm.totalSteps = totalSteps
 	pm.totalFiles = totalFiles
 	pm.currentStep = 0
 	pm.processedFiles = 0
+	pm.throughput = 0
+	pm.estimator = model.NewRateEstimator()
 
 	pm.progressBar.SetValue(0)
 	pm.progressBar.Show()
+	pm.etaLabel.SetText("")
+	pm.etaLabel.Show()
 	pm.detailLabel.Show()
 	pm.timeLabel.Show()
 	pm.speedLabel.Show()
@@ -127,11 +167,12 @@ func (pm *ProgressManager) Start(totalSteps int, totalFiles int) {
 func (pm *ProgressManager) Stop() {
 	pm.isActive = false
 	pm.progressBar.Hide()
+	pm.etaLabel.Hide()
 	pm.detailLabel.Hide()
 	pm.timeLabel.Hide()
 	pm.speedLabel.Hide()
 
-	pm.statusLabel.SetText("准备就绪")
+	pm.statusLabel.SetText(i18n.T(StatusReadyText))
 }
 
 // UpdateProgress 更新进度
@@ -142,6 +183,7 @@ func (pm *ProgressManager) UpdateProgress(info ProgressInfo) {
 
 	// 更新进度值
 	pm.progressBar.SetValue(info.Progress)
+	pm.updateETA(info)
 
 	// 更新状态信息
 	if info.Status != "" {
@@ -177,6 +219,25 @@ func (pm *ProgressManager) UpdateProgress(info ProgressInfo) {
 	pm.updateDisplay()
 }
 
+// updateETA 更新进度条下方的剩余时间。调用方没有给出剩余时间时按进度的平滑变化速度估计，
+// 估计不出时清空显示
+func (pm *ProgressManager) updateETA(info ProgressInfo) {
+	if info.Throughput > 0 {
+		pm.throughput = info.Throughput
+	}
+
+	pm.estimator.Observe(pm.clock.Now(), int64(info.Progress*progressUnits), progressUnits)
+	eta, ok := info.ETA, info.ETA > 0
+	if !ok {
+		eta, ok = pm.estimator.ETA()
+	}
+	if !ok || info.Progress >= 1 {
+		pm.etaLabel.SetText("")
+		return
+	}
+	pm.etaLabel.SetText(i18n.T(RemainingTimeText, formatDuration(eta)))
+}
+
 // SetStatus 设置状态文本
 func (pm *ProgressManager) SetStatus(status string) {
 	pm.statusLabel.SetText(status)
@@ -205,21 +266,23 @@ func (pm *ProgressManager) updateDisplay() {
 	}
 
 	// 更新时间信息
-	elapsed := time.Since(pm.startTime)
-	pm.timeLabel.SetText(fmt.Sprintf("已用时: %s", formatDuration(elapsed)))
+	elapsed := pm.clock.Now().Sub(pm.startTime)
+	pm.timeLabel.SetText(i18n.T(ElapsedTimeText, formatDuration(elapsed)))
 
-	// 更新速度信息
-	if pm.processedFiles > 0 && elapsed.Seconds() > 0 {
+	// 更新速度信息，有字节吞吐量时优先显示
+	if pm.throughput > 0 {
+		pm.speedLabel.SetText(i18n.T(ThroughputText, pm.throughput/(1024*1024)))
+	} else if pm.processedFiles > 0 && elapsed.Seconds() > 0 {
 		speed := float64(pm.processedFiles) / elapsed.Seconds()
-		pm.speedLabel.SetText(fmt.Sprintf("速度: %.1f 文件/秒", speed))
+		pm.speedLabel.SetText(i18n.T(SpeedText, speed))
 	}
 
 	// 更新详细信息（如果没有自定义详细信息）
 	if pm.detailLabel.Text == "" {
 		if pm.currentFile != "" {
-			pm.detailLabel.SetText(fmt.Sprintf("正在处理: %s", pm.currentFile))
+			pm.detailLabel.SetText(i18n.T(ProcessingFileText, pm.currentFile))
 		} else if pm.totalFiles > 0 {
-			pm.detailLabel.SetText(fmt.Sprintf("文件进度: %d/%d", pm.processedFiles, pm.totalFiles))
+			pm.detailLabel.SetText(i18n.T(FileProgressText, pm.processedFiles, pm.totalFiles))
 		}
 	}
 }
@@ -240,55 +303,95 @@ func (pm *ProgressManager) startTimer() {
 // Complete 完成进度
 func (pm *ProgressManager) Complete(message string) {
 	pm.progressBar.SetValue(1.0)
+	pm.etaLabel.SetText("")
 	pm.statusLabel.SetText(message)
 
-	elapsed := time.Since(pm.startTime)
-	pm.detailLabel.SetText(fmt.Sprintf("完成！总用时: %s", formatDuration(elapsed)))
+	elapsed := pm.clock.Now().Sub(pm.startTime)
+	pm.detailLabel.SetText(i18n.T(CompletedInText, formatDuration(elapsed)))
 
 	// 延迟隐藏进度信息
-	go func() {
-		time.Sleep(2 * time.Second)
+	time.AfterFunc(2*time.Second, func() {
 		if pm.onComplete != nil {
 			pm.onComplete()
 		}
 		pm.Stop()
-	}()
+	})
 }
 
 // Error 显示错误
 func (pm *ProgressManager) Error(err error) {
 	pm.isActive = false
 	pm.progressBar.Hide()
+	pm.etaLabel.Hide()
 
-	pm.statusLabel.SetText("操作失败")
+	pm.statusLabel.SetText(i18n.T(OperationFailedText))
 	pm.detailLabel.SetText(err.Error())
 	pm.detailLabel.Show()
 
 	// 显示错误对话框
-	pm.ShowErrorDialog("操作失败", err.Error())
+	pm.ShowErrorDialog(i18n.T(OperationFailedText), describeMergeFailure(err))
 
 	// 延迟重置状态
-	go func() {
-		time.Sleep(3 * time.Second)
-		pm.Stop()
-	}()
+	time.AfterFunc(3*time.Second, pm.Stop)
 }
 
 // Cancel 取消操作
 func (pm *ProgressManager) Cancel() {
-	pm.isActive = false
-	pm.statusLabel.SetText("已取消")
-	pm.detailLabel.SetText("操作已被用户取消")
+	pm.showCancelled()
 
 	if pm.onCancel != nil {
 		pm.onCancel()
 	}
+}
+
+// showCancelled 显示已取消状态并延迟重置
+func (pm *ProgressManager) showCancelled() {
+	pm.isActive = false
+	pm.statusLabel.SetText(i18n.T(StatusCancelledText))
+	pm.detailLabel.SetText(i18n.T(CancelledByUserText))
 
 	// 延迟重置状态
-	go func() {
-		time.Sleep(2 * time.Second)
-		pm.Stop()
-	}()
+	time.AfterFunc(2*time.Second, pm.Stop)
+}
+
+// FollowJob 只显示指定任务的进度：订阅该任务的回调，任务结束时显示结果并取消订阅。
+// 再次调用时改为跟随新的任务。
+func (pm *ProgressManager) FollowJob(source jobSubscriber, jobID string) {
+	pm.Unfollow()
+
+	unsubscribe := source.SubscribeJob(jobID, controller.JobCallbacks{
+		Progress: func(_ string, progress float64, status, detail string) {
+			pm.UpdateProgress(ProgressInfo{Progress: progress, Status: status, Detail: detail})
+		},
+		Error: func(_ string, err error) {
+			pm.Unfollow()
+			if errors.Is(err, context.Canceled) || errors.Is(err, controller.ErrJobCancelled) {
+				pm.showCancelled()
+				return
+			}
+			pm.Error(err)
+		},
+		Completion: func(_ string, outputPath string) {
+			pm.Unfollow()
+			pm.Complete(i18n.T(MergeCompleteOutputText, outputPath))
+		},
+	})
+
+	pm.followMu.Lock()
+	pm.unfollow = unsubscribe
+	pm.followMu.Unlock()
+}
+
+// Unfollow 停止跟随任务
+func (pm *ProgressManager) Unfollow() {
+	pm.followMu.Lock()
+	unsubscribe := pm.unfollow
+	pm.unfollow = nil
+	pm.followMu.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
+	}
 }
 
 // SetOnCancel 设置取消回调
@@ -314,11 +417,45 @@ func (pm *ProgressManager) GetProgress() float64 {
 // GetElapsedTime 获取已用时间
 func (pm *ProgressManager) GetElapsedTime() time.Duration {
 	if pm.isActive {
-		return time.Since(pm.startTime)
+		return pm.clock.Now().Sub(pm.startTime)
 	}
 	return 0
 }
 
+// maxDialogIssues 错误对话框中列出的验证诊断条数
+const maxDialogIssues = 5
+
+// describeMergeFailure 生成错误描述，包含合并失败时已完成的部分
+func describeMergeFailure(err error) string {
+	message := err.Error()
+	// 后端不可用时直接显示根本原因，而不是外层的合并失败信息
+	if unavailable := pdf.AdapterUnavailableError(err); unavailable != nil {
+		message = fmt.Sprintf("%s\n\n%v", unavailable.Message, unavailable.Cause)
+	}
+
+	// 验证失败时列出最严重的几条诊断及修复建议
+	if issues := pdf.ValidationIssues(err); len(issues) > 0 {
+		message = fmt.Sprintf("%s\n\n%s", message, pdf.SummarizeIssues(issues, maxDialogIssues))
+	}
+
+	partial := pdf.PartialMergeResult(err)
+	if partial == nil {
+		return message
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString("\n\n" + i18n.T(FailedStageText, partial.FailedStage))
+	b.WriteString("\n" + i18n.T(PartialFilesText, len(partial.ValidatedFiles), len(partial.SkippedFiles)))
+	if partial.TotalChunks > 0 {
+		b.WriteString("\n" + i18n.T(CompletedChunksText, partial.CompletedChunks, partial.TotalChunks))
+	}
+	for _, warning := range partial.Warnings {
+		b.WriteString("\n" + i18n.T(WarningText, warning))
+	}
+	return b.String()
+}
+
 // ShowErrorDialog 显示错误对话框
 func (pm *ProgressManager) ShowErrorDialog(title, message string) {
 	dialog.ShowError(fmt.Errorf("%s", message), pm.window)
@@ -340,7 +477,7 @@ func (pm *ProgressManager) ShowProgressDialog(title, message string, onCancel fu
 	progressBar := widget.NewProgressBar()
 	statusLabel := widget.NewLabel(message)
 
-	cancelBtn := widget.NewButton("取消", func() {
+	cancelBtn := widget.NewButton(i18n.T(CancelButton), func() {
 		if onCancel != nil {
 			onCancel()
 		}
@@ -353,7 +490,7 @@ func (pm *ProgressManager) ShowProgressDialog(title, message string, onCancel fu
 	)
 
 	// 创建对话框
-	progressDialog := dialog.NewCustom(title, "关闭", content, pm.window)
+	progressDialog := dialog.NewCustom(title, i18n.T(CloseButton), content, pm.window)
 	progressDialog.Show()
 
 	// 更新进度的函数
@@ -369,11 +506,11 @@ func (pm *ProgressManager) ShowProgressDialog(title, message string, onCancel fu
 // formatDuration 格式化时间间隔
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
-		return fmt.Sprintf("%.1f秒", d.Seconds())
+		return i18n.T(DurationSecondsText, d.Seconds())
 	} else if d < time.Hour {
-		return fmt.Sprintf("%.1f分钟", d.Minutes())
+		return i18n.T(DurationMinutesText, d.Minutes())
 	} else {
-		return fmt.Sprintf("%.1f小时", d.Hours())
+		return i18n.T(DurationHoursText, d.Hours())
 	}
 }
 
@@ -403,42 +540,42 @@ func GetStatusMessage(statusType StatusType, message string) StatusMessage {
 	case StatusReady:
 		return StatusMessage{
 			Type:    StatusReady,
-			Title:   "准备就绪",
+			Title:   i18n.T(StatusReadyText),
 			Message: message,
 			Icon:    theme.InfoIcon(),
 		}
 	case StatusProcessing:
 		return StatusMessage{
 			Type:    StatusProcessing,
-			Title:   "正在处理",
+			Title:   i18n.T(StatusProcessingText),
 			Message: message,
 			Icon:    theme.ComputerIcon(),
 		}
 	case StatusCompleted:
 		return StatusMessage{
 			Type:    StatusCompleted,
-			Title:   "完成",
+			Title:   i18n.T(StatusCompletedText),
 			Message: message,
 			Icon:    theme.ConfirmIcon(),
 		}
 	case StatusError:
 		return StatusMessage{
 			Type:    StatusError,
-			Title:   "错误",
+			Title:   i18n.T(StatusErrorText),
 			Message: message,
 			Icon:    theme.ErrorIcon(),
 		}
 	case StatusCancelled:
 		return StatusMessage{
 			Type:    StatusCancelled,
-			Title:   "已取消",
+			Title:   i18n.T(StatusCancelledText),
 			Message: message,
 			Icon:    theme.CancelIcon(),
 		}
 	default:
 		return StatusMessage{
 			Type:    StatusReady,
-			Title:   "未知状态",
+			Title:   i18n.T(StatusUnknownText),
 			Message: message,
 			Icon:    theme.QuestionIcon(),
 		}
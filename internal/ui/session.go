@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// defaultOutputName 没有输出文件名时，从最近使用的输出目录选择的文件名
+const defaultOutputName = "merged.pdf"
+
+// SaveSession 把主文件、附加文件（按列表顺序）、输出路径和最近使用的输出目录保存到配置目录。
+// 没有配置文件位置时不保存
+func (u *UI) SaveSession() error {
+	if u.configPath == "" {
+		return nil
+	}
+	session := &model.Session{
+		MainFile:         u.mainFilePath,
+		AdditionalFiles:  u.fileListManager.GetFilePaths(),
+		OutputPath:       u.outputPath,
+		RecentOutputDirs: u.recentOutputDirs,
+	}
+	return model.SaveSession(model.SessionPath(u.configPath), session)
+}
+
+// LoadSession 读取上次保存的会话，并恢复最近使用的输出目录。
+// 会话文件损坏时返回空会话和说明原因的错误
+func (u *UI) LoadSession() (*model.Session, error) {
+	if u.configPath == "" {
+		return &model.Session{}, nil
+	}
+	session, err := model.LoadSession(model.SessionPath(u.configPath))
+	u.setRecentOutputDirs(session.RecentOutputDirs)
+	return session, err
+}
+
+// RestoreSession 恢复会话中的文件和输出路径，跳过已不存在的文件，返回被跳过的路径。
+// 附加文件按保存时的顺序加入列表，不再询问内容重复的文件
+func (u *UI) RestoreSession(session *model.Session) []string {
+	var dropped []string
+	exists := func(path string) bool {
+		if _, err := os.Stat(path); err != nil {
+			dropped = append(dropped, path)
+			return false
+		}
+		return true
+	}
+
+	if session.MainFile != "" && exists(session.MainFile) {
+		u.mainFilePath = session.MainFile
+		if u.mainFileEntry != nil {
+			u.mainFileEntry.SetText(u.displayName(session.MainFile))
+		}
+	}
+	for _, path := range session.AdditionalFiles {
+		if exists(path) && u.fileListManager.indexOf(path) < 0 {
+			u.fileListManager.insertFile(path, -1)
+		}
+	}
+	if session.OutputPath != "" {
+		if _, err := os.Stat(filepath.Dir(session.OutputPath)); err == nil {
+			u.setOutputPath(session.OutputPath)
+		}
+	}
+
+	if u.mergeButton != nil {
+		u.updateUI()
+	}
+	return dropped
+}
+
+// OfferSessionRestore 启动时读取上次的会话；有可恢复的文件时询问是否恢复，并列出已不存在的文件
+func (u *UI) OfferSessionRestore() {
+	session, err := u.LoadSession()
+	if err != nil {
+		log.Printf("警告: %v", err)
+	}
+	if !session.HasFiles() || u.window == nil {
+		return
+	}
+
+	dialog.ShowConfirm(i18n.T(RestoreSessionTitle), i18n.T(RestoreSessionText), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if dropped := u.RestoreSession(session); len(dropped) > 0 {
+			dialog.ShowInformation(i18n.T(RestoreSessionTitle),
+				i18n.T(SessionDroppedText, len(dropped), strings.Join(dropped, "\n")), u.window)
+		}
+	}, u.window)
+}
+
+// setOutputPath 设置输出路径并同步输入框
+func (u *UI) setOutputPath(path string) {
+	u.outputPath = path
+	if u.outputPathEntry != nil {
+		u.outputPathEntry.SetText(path)
+	}
+}
+
+// rememberOutputDir 把输出目录记为最近使用，并刷新最近输出目录的下拉列表
+func (u *UI) rememberOutputDir(dir string) {
+	session := &model.Session{RecentOutputDirs: u.recentOutputDirs}
+	session.AddRecentOutputDir(dir)
+	u.setRecentOutputDirs(session.RecentOutputDirs)
+}
+
+// setRecentOutputDirs 设置最近使用的输出目录，最多保留 model.MaxRecentOutputDirs 个
+func (u *UI) setRecentOutputDirs(dirs []string) {
+	if len(dirs) > model.MaxRecentOutputDirs {
+		dirs = dirs[:model.MaxRecentOutputDirs]
+	}
+	u.recentOutputDirs = append([]string(nil), dirs...)
+	if u.recentOutputSelect != nil {
+		u.recentOutputSelect.Options = u.recentOutputDirs
+		u.recentOutputSelect.ClearSelected()
+		u.recentOutputSelect.Refresh()
+	}
+}
+
+// onRecentOutputDir 从最近输出目录的下拉列表选择目录：保留当前输出文件名，没有时使用默认文件名
+func (u *UI) onRecentOutputDir(dir string) {
+	if dir == "" {
+		return
+	}
+	name := defaultOutputName
+	if u.outputPath != "" {
+		name = filepath.Base(u.outputPath)
+	}
+	u.setOutputPath(filepath.Join(dir, name))
+	u.updateUI()
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// newSessionTestUI 创建使用临时配置目录的UI
+func newSessionTestUI(t *testing.T, configDir string) *UI {
+	t.Helper()
+	app := test.NewApp()
+	ui := NewUI(app.NewWindow("Test"), nil)
+	ui.SetSettings(filepath.Join(configDir, model.ConfigFileName), model.DefaultConfig())
+	ui.BuildUI()
+	return ui
+}
+
+// writeSessionFile 在目录中创建测试文件
+func writeSessionFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("%PDF-1.4 "+name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUI_SaveAndRestoreSession(t *testing.T) {
+	configDir := t.TempDir()
+	docs := t.TempDir()
+	main := writeSessionFile(t, docs, "main.pdf")
+	b := writeSessionFile(t, docs, "b.pdf")
+	a := writeSessionFile(t, docs, "a.pdf")
+	output := filepath.Join(docs, "out.pdf")
+
+	ui := newSessionTestUI(t, configDir)
+	restored := ui.RestoreSession(&model.Session{MainFile: main, AdditionalFiles: []string{b, a}, OutputPath: output})
+	if len(restored) != 0 {
+		t.Fatalf("Expected no dropped files, got %v", restored)
+	}
+	ui.rememberOutputDir(docs)
+	if err := ui.SaveSession(); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	other := newSessionTestUI(t, configDir)
+	session, err := other.LoadSession()
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	if dropped := other.RestoreSession(session); len(dropped) != 0 {
+		t.Errorf("Expected no dropped files, got %v", dropped)
+	}
+	if other.mainFilePath != main || other.outputPath != output || other.outputPathEntry.Text != output {
+		t.Errorf("Expected main %s and output %s, got %s and %s", main, output, other.mainFilePath, other.outputPath)
+	}
+	paths := other.fileListManager.GetFilePaths()
+	if len(paths) != 2 || paths[0] != b || paths[1] != a {
+		t.Errorf("Expected additional files in saved order, got %v", paths)
+	}
+	if len(other.recentOutputDirs) != 1 || other.recentOutputSelect.Options[0] != docs {
+		t.Errorf("Expected recent output dir %s, got %v", docs, other.recentOutputDirs)
+	}
+}
+
+func TestUI_RestoreSessionDropsMissingFiles(t *testing.T) {
+	docs := t.TempDir()
+	kept := writeSessionFile(t, docs, "kept.pdf")
+	missingMain := filepath.Join(docs, "gone-main.pdf")
+	missing := filepath.Join(docs, "gone.pdf")
+
+	ui := newSessionTestUI(t, t.TempDir())
+	dropped := ui.RestoreSession(&model.Session{
+		MainFile:        missingMain,
+		AdditionalFiles: []string{missing, kept},
+		OutputPath:      filepath.Join(docs, "no-such-dir", "out.pdf"),
+	})
+	if len(dropped) != 2 || dropped[0] != missingMain || dropped[1] != missing {
+		t.Errorf("Expected missing files to be dropped, got %v", dropped)
+	}
+	if ui.mainFilePath != "" {
+		t.Errorf("Expected missing main file to be skipped, got %s", ui.mainFilePath)
+	}
+	if paths := ui.fileListManager.GetFilePaths(); len(paths) != 1 || paths[0] != kept {
+		t.Errorf("Expected only existing file restored, got %v", paths)
+	}
+	if ui.outputPath != "" {
+		t.Errorf("Expected output path in missing folder to be skipped, got %s", ui.outputPath)
+	}
+}
+
+func TestUI_RecentOutputDirs(t *testing.T) {
+	ui := newSessionTestUI(t, t.TempDir())
+	for i := 0; i < model.MaxRecentOutputDirs+2; i++ {
+		ui.rememberOutputDir(fmt.Sprintf("/out/%d", i))
+	}
+	if len(ui.recentOutputSelect.Options) != model.MaxRecentOutputDirs {
+		t.Fatalf("Expected %d recent dirs, got %d", model.MaxRecentOutputDirs, len(ui.recentOutputSelect.Options))
+	}
+
+	// 选择最近的目录时保留当前的输出文件名
+	ui.setOutputPath("/elsewhere/report.pdf")
+	ui.onRecentOutputDir("/out/3")
+	if want := filepath.Join("/out/3", "report.pdf"); ui.outputPath != want {
+		t.Errorf("Expected output path %s, got %s", want, ui.outputPath)
+	}
+
+	ui.setOutputPath("")
+	ui.onRecentOutputDir("/out/4")
+	if want := filepath.Join("/out/4", defaultOutputName); ui.outputPath != want {
+		t.Errorf("Expected default output name, got %s", ui.outputPath)
+	}
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// SetSettings 设置设置对话框编辑的配置文件路径和文件中的配置。
+// settings 应是未经环境变量覆盖的配置，避免把临时覆盖写回文件。
+func (u *UI) SetSettings(configPath string, settings *model.Config) {
+	u.configPath = configPath
+	u.settings = settings
+}
+
+// onSettings 设置按钮点击处理：编辑并保存配置文件中的常用设置
+func (u *UI) onSettings() {
+	if u.configPath == "" || u.settings == nil {
+		dialog.ShowInformation(i18n.T(SettingsTitle), i18n.T(SettingsUnavailable), u.window)
+		return
+	}
+
+	tempDirEntry := widget.NewEntry()
+	tempDirEntry.SetText(u.settings.TempDirectory)
+	tempDirEntry.SetPlaceHolder(i18n.T(SettingsSystemDefault))
+	outputDirEntry := widget.NewEntry()
+	outputDirEntry.SetText(u.settings.OutputDirectory)
+	outputDirEntry.SetPlaceHolder(i18n.T(SettingsSystemDefault))
+	memoryEntry := widget.NewEntry()
+	memoryEntry.SetText(strconv.FormatInt(u.settings.MaxMemoryUsage/(1024*1024), 10))
+	jobsEntry := widget.NewEntry()
+	jobsEntry.SetText(strconv.Itoa(u.settings.MaxConcurrentJobs))
+	autoDecrypt := widget.NewCheck("", nil)
+	autoDecrypt.SetChecked(u.settings.EnableAutoDecrypt)
+	thumbnails := widget.NewCheck("", nil)
+	thumbnails.SetChecked(u.settings.ShowThumbnails)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T(SettingsTempDirLabel), tempDirEntry),
+		widget.NewFormItem(i18n.T(SettingsOutputDirLabel), outputDirEntry),
+		widget.NewFormItem(i18n.T(SettingsMaxMemoryLabel), memoryEntry),
+		widget.NewFormItem(i18n.T(SettingsMaxJobsLabel), jobsEntry),
+		widget.NewFormItem(i18n.T(SettingsAutoDecryptLabel), autoDecrypt),
+		widget.NewFormItem(i18n.T(SettingsThumbnailsLabel), thumbnails),
+	}
+	items[0].HintText = i18n.T(SettingsRestartHint)
+	items[3].HintText = i18n.T(SettingsRestartHint)
+
+	form := dialog.NewForm(i18n.T(SettingsTitle), i18n.T(SaveButton), i18n.T(CancelButton), items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		updated, err := u.settingsFromForm(tempDirEntry.Text, outputDirEntry.Text, memoryEntry.Text, jobsEntry.Text, autoDecrypt.Checked)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		updated.ShowThumbnails = thumbnails.Checked
+		if err := model.SaveConfig(u.configPath, updated); err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		u.settings = updated
+		u.applySettings(updated)
+	}, u.window)
+	form.Show()
+}
+
+// settingsFromForm 根据表单内容生成新的配置，数字字段无效或配置未通过验证时返回错误
+func (u *UI) settingsFromForm(tempDir, outputDir, memoryMB, jobs string, autoDecrypt bool) (*model.Config, error) {
+	updated := *u.settings
+	updated.TempDirectory = strings.TrimSpace(tempDir)
+	updated.OutputDirectory = strings.TrimSpace(outputDir)
+	updated.EnableAutoDecrypt = autoDecrypt
+
+	mb, err := strconv.ParseInt(strings.TrimSpace(memoryMB), 10, 64)
+	if err != nil || mb <= 0 {
+		return nil, fmt.Errorf(i18n.T(SettingsInvalidNumber), i18n.T(SettingsMaxMemoryLabel))
+	}
+	updated.MaxMemoryUsage = mb * 1024 * 1024
+
+	updated.MaxConcurrentJobs, err = strconv.Atoi(strings.TrimSpace(jobs))
+	if err != nil || updated.MaxConcurrentJobs <= 0 {
+		return nil, fmt.Errorf(i18n.T(SettingsInvalidNumber), i18n.T(SettingsMaxJobsLabel))
+	}
+
+	if err := model.NewValidator().ValidateConfig(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// applySettings 把立即生效的设置同步到控制器的配置和缩略图开关；
+// 临时目录和并发任务数在创建文件管理器和任务队列时使用，重启后生效
+func (u *UI) applySettings(settings *model.Config) {
+	if u.controller == nil || u.controller.Config == nil {
+		return
+	}
+	u.controller.Config.OutputDirectory = settings.OutputDirectory
+	u.controller.Config.MaxMemoryUsage = settings.MaxMemoryUsage
+	u.controller.Config.EnableAutoDecrypt = settings.EnableAutoDecrypt
+	u.controller.Config.ShowThumbnails = settings.ShowThumbnails
+	if u.thumbnailCheck != nil {
+		u.thumbnailCheck.SetChecked(settings.ShowThumbnails)
+	} else {
+		u.fileListManager.SetShowThumbnails(settings.ShowThumbnails)
+	}
+}
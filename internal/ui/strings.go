@@ -1,59 +1,234 @@
 package ui
 
-// 界面文本常量 - 使用英文避免字体问题
+import "github.com/user/pdf-merger/internal/i18n"
+
+// 界面文本的消息ID，翻译在 internal/i18n 的消息目录中，显示时通过 i18n.T 查找当前语言的文本
 const (
 	// 窗口标题
-	WindowTitle = "PDF Merger Tool"
+	WindowTitle i18n.MessageID = "ui.window_title"
 
 	// 按钮文本
-	BrowseButton     = "Browse..."
-	AddFileButton    = "Add Files"
-	RemoveFileButton = "Remove Selected"
-	ClearFilesButton = "Clear All"
-	MoveUpButton     = "Move Up"
-	MoveDownButton   = "Move Down"
-	RefreshButton    = "Refresh"
-	StartMergeButton = "Start Merge"
-	CancelButton     = "Cancel"
+	BrowseButton      i18n.MessageID = "ui.browse_button"
+	AddFileButton     i18n.MessageID = "ui.add_file_button"
+	RemoveFileButton  i18n.MessageID = "ui.remove_file_button"
+	ClearFilesButton  i18n.MessageID = "ui.clear_files_button"
+	MoveUpButton      i18n.MessageID = "ui.move_up_button"
+	MoveDownButton    i18n.MessageID = "ui.move_down_button"
+	RefreshButton     i18n.MessageID = "ui.refresh_button"
+	StartMergeButton  i18n.MessageID = "ui.start_merge_button"
+	CancelButton      i18n.MessageID = "ui.cancel_button"
+	CloseButton       i18n.MessageID = "ui.close_button"
+	MaintenanceButton i18n.MessageID = "ui.maintenance_button"
+	SettingsButton    i18n.MessageID = "ui.settings_button"
+	LogButton         i18n.MessageID = "ui.log_button"
+	SaveButton        i18n.MessageID = "ui.save_button"
 
 	// 标签文本
-	MainFileLabel        = "Main PDF File:"
-	AdditionalFilesLabel = "Additional PDF Files:"
-	OutputPathLabel      = "Output Path:"
-	NoFilesLabel         = "No files"
-	ProgressLabel        = "Progress:"
-	StatusLabel          = "Status:"
+	MainFileLabel          i18n.MessageID = "ui.main_file_label"
+	AdditionalFilesLabel   i18n.MessageID = "ui.additional_files_label"
+	OutputPathLabel        i18n.MessageID = "ui.output_path_label"
+	GenerateTOCLabel       i18n.MessageID = "ui.generate_toc_label"
+	NormalizeLabel         i18n.MessageID = "ui.normalize_label"
+	ProfileLabel           i18n.MessageID = "ui.profile_label"
+	ShowThumbnailsLabel    i18n.MessageID = "ui.show_thumbnails_label"
+	RotateButtonFormat     i18n.MessageID = "ui.rotate_button_format"
+	SignatureBadge         i18n.MessageID = "ui.signature_badge"
+	WarningBadge           i18n.MessageID = "ui.warning_badge"
+	PageSizeFormat         i18n.MessageID = "ui.page_size_format"
+	PortraitLabel          i18n.MessageID = "ui.portrait"
+	LandscapeLabel         i18n.MessageID = "ui.landscape"
+	MixedPageSizesLabel    i18n.MessageID = "ui.mixed_page_sizes"
+	NoFilesLabel           i18n.MessageID = "ui.no_files_label"
+	ProgressLabel          i18n.MessageID = "ui.progress_label"
+	StatusLabel            i18n.MessageID = "ui.status_label"
+	MainFileHeading        i18n.MessageID = "ui.main_file_heading"
+	AdditionalFilesHeading i18n.MessageID = "ui.additional_files_heading"
+	OutputHeading          i18n.MessageID = "ui.output_heading"
+	MainFilePlaceholder    i18n.MessageID = "ui.main_file_placeholder"
+	OutputPathPlaceholder  i18n.MessageID = "ui.output_path_placeholder"
+	FileNameColumn         i18n.MessageID = "ui.file_name_column"
+	FileSizeColumn         i18n.MessageID = "ui.file_size_column"
+	FileStatusColumn       i18n.MessageID = "ui.file_status_column"
 
 	// 状态消息
-	StatusReadyText     = "Ready"
-	StatusMerging       = "Merging..."
-	StatusCompletedText = "Completed"
-	StatusCancelledText = "Cancelled"
-	StatusErrorText     = "Error"
+	StatusReadyText      i18n.MessageID = "ui.status_ready_text"
+	StatusMerging        i18n.MessageID = "ui.status_merging"
+	StatusCompletedText  i18n.MessageID = "ui.status_completed_text"
+	StatusCancelledText  i18n.MessageID = "ui.status_cancelled_text"
+	StatusErrorText      i18n.MessageID = "ui.status_error_text"
+	StatusProcessingText i18n.MessageID = "ui.status_processing_text"
+	StatusUnknownText    i18n.MessageID = "ui.status_unknown_text"
+	OperationFailedText  i18n.MessageID = "ui.operation_failed_text"
+	CancelledByUserText  i18n.MessageID = "ui.cancelled_by_user_text"
+	FileStatusOK         i18n.MessageID = "ui.file_status_ok"
+	FileStatusError      i18n.MessageID = "ui.file_status_error"
+	FileStatusInvalid    i18n.MessageID = "ui.file_status_invalid"
+	FileStatusEncrypted  i18n.MessageID = "ui.file_status_encrypted"
+	FileStatusUnlocked   i18n.MessageID = "ui.file_status_unlocked"
 
 	// 对话框文本
-	SelectMainFileTitle = "Select Main PDF File"
-	SelectFilesTitle    = "Select PDF Files"
-	SelectOutputTitle   = "Select Output Location"
-	ErrorDialogTitle    = "Error"
-	InfoDialogTitle     = "Information"
-	SuccessDialogTitle  = "Success"
+	SelectMainFileTitle  i18n.MessageID = "ui.select_main_file_title"
+	SelectFilesTitle     i18n.MessageID = "ui.select_files_title"
+	SelectOutputTitle    i18n.MessageID = "ui.select_output_title"
+	ErrorDialogTitle     i18n.MessageID = "ui.error_dialog_title"
+	InfoDialogTitle      i18n.MessageID = "ui.info_dialog_title"
+	SuccessDialogTitle   i18n.MessageID = "ui.success_dialog_title"
+	SelectCleanupFolder  i18n.MessageID = "ui.select_cleanup_folder"
+	CleanupReportTitle   i18n.MessageID = "ui.cleanup_report_title"
+	CleanupNoneFound     i18n.MessageID = "ui.cleanup_none_found"
+	CleanupConfirmButton i18n.MessageID = "ui.cleanup_confirm_button"
+	CleanupConfirmText   i18n.MessageID = "ui.cleanup_confirm_text"
+	CleanupDoneText      i18n.MessageID = "ui.cleanup_done_text"
+
+	// 重复文件
+	DuplicateFileTitle   i18n.MessageID = "ui.duplicate_file_title"
+	DuplicateFileConfirm i18n.MessageID = "ui.duplicate_file_confirm"
+
+	// 输出与输入相同
+	OutputConflictTitle   i18n.MessageID = "ui.output_conflict_title"
+	OutputConflictConfirm i18n.MessageID = "ui.output_conflict_confirm"
+
+	// 加密文件
+	PasswordAttemptsExceededText i18n.MessageID = "ui.password_attempts_exceeded_text"
+
+	// 日志视图
+	LogTitle     i18n.MessageID = "ui.log_title"
+	LogEmptyText i18n.MessageID = "ui.log_empty_text"
+
+	// 维护面板
+	MaintenanceTitle          i18n.MessageID = "ui.maintenance_title"
+	ScanLegacyButton          i18n.MessageID = "ui.scan_legacy_button"
+	WorkspacesNoneFound       i18n.MessageID = "ui.workspaces_none_found"
+	WorkspacesReclaimableText i18n.MessageID = "ui.workspaces_reclaimable_text"
+	WorkspaceResumableText    i18n.MessageID = "ui.workspace_resumable_text"
+	DiscardWorkspaceButton    i18n.MessageID = "ui.discard_workspace_button"
+	DiscardWorkspaceConfirm   i18n.MessageID = "ui.discard_workspace_confirm"
+	TempUsageText             i18n.MessageID = "ui.temp_usage_text"
+	TempQuotaBytesText        i18n.MessageID = "ui.temp_quota_bytes_text"
+	TempQuotaFilesText        i18n.MessageID = "ui.temp_quota_files_text"
+	VaultButton               i18n.MessageID = "ui.vault_button"
+
+	// 密码保险库
+	VaultTitle           i18n.MessageID = "ui.vault_title"
+	VaultPassphraseLabel i18n.MessageID = "ui.vault_passphrase_label"
+	VaultLockedText      i18n.MessageID = "ui.vault_locked_text"
+	VaultUnlockButton    i18n.MessageID = "ui.vault_unlock_button"
+	VaultEmptyText       i18n.MessageID = "ui.vault_empty_text"
+	VaultEntriesText     i18n.MessageID = "ui.vault_entries_text"
+	VaultEntryText       i18n.MessageID = "ui.vault_entry_text"
+	VaultRemoveButton    i18n.MessageID = "ui.vault_remove_button"
+	VaultPurgeButton     i18n.MessageID = "ui.vault_purge_button"
+	VaultPurgeConfirm    i18n.MessageID = "ui.vault_purge_confirm"
+
+	// 上次运行中断的任务
+	InterruptedJobsTitle     i18n.MessageID = "ui.interrupted_jobs_title"
+	InterruptedJobsText      i18n.MessageID = "ui.interrupted_jobs_text"
+	InterruptedJobText       i18n.MessageID = "ui.interrupted_job_text"
+	InterruptedMissingText   i18n.MessageID = "ui.interrupted_missing_text"
+	ResumeInterruptedButton  i18n.MessageID = "ui.resume_interrupted_button"
+	CleanUpInterruptedButton i18n.MessageID = "ui.clean_up_interrupted_button"
+
+	// 会话恢复
+	RestoreSessionTitle            i18n.MessageID = "ui.restore_session_title"
+	RestoreSessionText             i18n.MessageID = "ui.restore_session_text"
+	SessionDroppedText             i18n.MessageID = "ui.session_dropped_text"
+	RecentOutputFoldersPlaceholder i18n.MessageID = "ui.recent_output_folders_placeholder"
+
+	// 设置对话框
+	SettingsTitle            i18n.MessageID = "ui.settings_title"
+	SettingsUnavailable      i18n.MessageID = "ui.settings_unavailable"
+	SettingsTempDirLabel     i18n.MessageID = "ui.settings_temp_dir_label"
+	SettingsOutputDirLabel   i18n.MessageID = "ui.settings_output_dir_label"
+	SettingsMaxMemoryLabel   i18n.MessageID = "ui.settings_max_memory_label"
+	SettingsMaxJobsLabel     i18n.MessageID = "ui.settings_max_jobs_label"
+	SettingsAutoDecryptLabel i18n.MessageID = "ui.settings_auto_decrypt_label"
+	SettingsThumbnailsLabel  i18n.MessageID = "ui.settings_thumbnails_label"
+	SettingsSystemDefault    i18n.MessageID = "ui.settings_system_default"
+	SettingsRestartHint      i18n.MessageID = "ui.settings_restart_hint"
+	SettingsInvalidNumber    i18n.MessageID = "ui.settings_invalid_number"
 
 	// 文件过滤器
-	PDFFileFilter = "PDF Files (*.pdf)"
+	PDFFileFilter i18n.MessageID = "ui.pdf_file_filter"
 
 	// 错误消息
-	ErrorNoMainFile   = "Please select a main PDF file first"
-	ErrorNoFiles      = "Please add at least one PDF file"
-	ErrorInvalidFile  = "Invalid PDF file"
-	ErrorMergeFailed  = "Merge failed"
-	ErrorFileNotFound = "File not found"
+	ErrorNoMainFile   i18n.MessageID = "ui.error_no_main_file"
+	ErrorNoFiles      i18n.MessageID = "ui.error_no_files"
+	ErrorInvalidFile  i18n.MessageID = "ui.error_invalid_file"
+	ErrorMergeFailed  i18n.MessageID = "ui.error_merge_failed"
+	ErrorFileNotFound i18n.MessageID = "ui.error_file_not_found"
 
 	// 成功消息
-	SuccessMergeComplete = "PDF files merged successfully!"
+	SuccessMergeComplete i18n.MessageID = "ui.success_merge_complete"
+
+	// 撤销合并
+	UndoMergeTitle    i18n.MessageID = "ui.undo_merge_title"
+	UndoMergeMessage  i18n.MessageID = "ui.undo_merge_message"
+	UndoMergeDoneText i18n.MessageID = "ui.undo_merge_done_text"
 
 	// 提示消息
-	HintDropFiles      = "Drag PDF files here or click Add Files button"
-	HintSelectMainFile = "Please select a main PDF file as the base for merging"
-	HintSelectOutput   = "Please select the output file location"
+	HintDropFiles      i18n.MessageID = "ui.hint_drop_files"
+	HintSelectMainFile i18n.MessageID = "ui.hint_select_main_file"
+	HintSelectOutput   i18n.MessageID = "ui.hint_select_output"
+
+	// 拖放结果
+	DropSummaryText    i18n.MessageID = "ui.drop_summary_text"
+	DropDuplicatesText i18n.MessageID = "ui.drop_duplicates_text"
+
+	// 文件列表摘要
+	FileInfoCountText     i18n.MessageID = "ui.file_info_count_text"
+	FileInfoValidText     i18n.MessageID = "ui.file_info_valid_text"
+	FileInfoEncryptedText i18n.MessageID = "ui.file_info_encrypted_text"
+	FileInfoSignedText    i18n.MessageID = "ui.file_info_signed_text"
+	FileInfoPagesText     i18n.MessageID = "ui.file_info_pages_text"
+	FileInfoSizeText      i18n.MessageID = "ui.file_info_size_text"
+
+	// 合并进度
+	StageValidateStatus     i18n.MessageID = "ui.stage_validate_status"
+	StageValidateDetail     i18n.MessageID = "ui.stage_validate_detail"
+	StagePrepareStatus      i18n.MessageID = "ui.stage_prepare_status"
+	StagePrepareDetail      i18n.MessageID = "ui.stage_prepare_detail"
+	StageMergeStatus        i18n.MessageID = "ui.stage_merge_status"
+	StageMergeDetail        i18n.MessageID = "ui.stage_merge_detail"
+	StageSaveStatus         i18n.MessageID = "ui.stage_save_status"
+	StageSaveDetail         i18n.MessageID = "ui.stage_save_detail"
+	StageDoneDetail         i18n.MessageID = "ui.stage_done_detail"
+	MergeCompleteOutputText i18n.MessageID = "ui.merge_complete_output_text"
+	ElapsedTimeText         i18n.MessageID = "ui.elapsed_time_text"
+	SpeedText               i18n.MessageID = "ui.speed_text"
+	ThroughputText          i18n.MessageID = "ui.throughput_text"
+	RemainingTimeText       i18n.MessageID = "ui.remaining_time_text"
+	ProcessingFileText      i18n.MessageID = "ui.processing_file_text"
+	FileProgressText        i18n.MessageID = "ui.file_progress_text"
+	CompletedInText         i18n.MessageID = "ui.completed_in_text"
+	DurationSecondsText     i18n.MessageID = "ui.duration_seconds_text"
+	DurationMinutesText     i18n.MessageID = "ui.duration_minutes_text"
+	DurationHoursText       i18n.MessageID = "ui.duration_hours_text"
+	AgeDaysText             i18n.MessageID = "ui.age_days_text"
+	AgeHoursText            i18n.MessageID = "ui.age_hours_text"
+	AgeMinutesText          i18n.MessageID = "ui.age_minutes_text"
+
+	// 合并失败时已完成的部分
+	FailedStageText     i18n.MessageID = "ui.failed_stage_text"
+	PartialFilesText    i18n.MessageID = "ui.partial_files_text"
+	CompletedChunksText i18n.MessageID = "ui.completed_chunks_text"
+	WarningText         i18n.MessageID = "ui.warning_text"
+
+	// 合并完成摘要
+	MergeSummaryTitle      i18n.MessageID = "ui.merge_summary_title"
+	SummaryOutputText      i18n.MessageID = "ui.summary_output_text"
+	SummaryPagesText       i18n.MessageID = "ui.summary_pages_text"
+	SummaryTimeText        i18n.MessageID = "ui.summary_time_text"
+	SummaryPeakMemoryText  i18n.MessageID = "ui.summary_peak_memory_text"
+	SummaryNotMeasuredText i18n.MessageID = "ui.summary_not_measured_text"
+	SummarySkippedHeading  i18n.MessageID = "ui.summary_skipped_heading"
+	SummaryUnknownReason   i18n.MessageID = "ui.summary_unknown_reason"
+	SummaryRepairedText    i18n.MessageID = "ui.summary_repaired_text"
+	SummaryDuplicateText   i18n.MessageID = "ui.summary_duplicate_text"
+	OpenFolderButton       i18n.MessageID = "ui.open_folder_button"
+	CopySummaryButton      i18n.MessageID = "ui.copy_summary_button"
+	SummaryCopiedText      i18n.MessageID = "ui.summary_copied_text"
+
+	// 遗留文件的可信度
+	LegacyVerifiedText i18n.MessageID = "ui.legacy_verified_text"
+	LegacyNameOnlyText i18n.MessageID = "ui.legacy_name_only_text"
 )
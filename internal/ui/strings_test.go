@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+func TestStrings_AllMessagesTranslated(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "strings.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, value := range spec.Values {
+			lit, ok := value.(*ast.BasicLit)
+			if !ok {
+				continue
+			}
+			id, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count++
+			for _, locale := range []i18n.Locale{i18n.ZhCN, i18n.EnUS} {
+				if !i18n.Has(locale, i18n.MessageID(id)) {
+					t.Errorf("Message %s (%s) has no %s translation", spec.Names[i].Name, id, locale)
+				}
+			}
+		}
+		return true
+	})
+	if count == 0 {
+		t.Error("Expected message IDs in strings.go")
+	}
+}
+
+func TestUI_EnglishLocale(t *testing.T) {
+	original := i18n.CurrentLocale()
+	i18n.SetLocale(i18n.EnUS)
+	defer i18n.SetLocale(original)
+
+	flm := NewFileListManager()
+	if info := flm.GetFileInfo(); info != "No files" {
+		t.Errorf("Expected English file info, got %q", info)
+	}
+	entry := model.NewFileEntry("/docs/a.pdf", 0)
+	entry.IsValid = true
+	if status := flm.getStatusText(*entry); status != "OK" {
+		t.Errorf("Expected English status, got %q", status)
+	}
+	if age := formatAge(2 * time.Hour); age != "2h ago" {
+		t.Errorf("Expected English age, got %q", age)
+	}
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/user/pdf-merger/pkg/thumbnail"
+)
+
+const (
+	// thumbnailSize 缩略图最长边的像素数，按高分辨率屏幕渲染，显示时缩小
+	thumbnailSize = 64
+	// thumbnailWorkers 后台渲染缩略图的goroutine数
+	thumbnailWorkers = 2
+	// thumbnailQueueSize 等待渲染的请求数上限，队列满时丢弃请求，下次刷新列表时重新请求
+	thumbnailQueueSize = 256
+	// thumbnailCacheDirName 临时目录下缓存缩略图的子目录
+	thumbnailCacheDirName = "pdf-merger-thumbnails"
+)
+
+// thumbnailRequest 等待渲染的缩略图
+type thumbnailRequest struct {
+	path string
+	key  string
+}
+
+// thumbnailLoader 在后台按需渲染文件首页的缩略图。
+// 渲染经过 thumbnail.Renderer，受超时、像素预算和按文件熔断的限制，光栅化器按可用能力选择。
+// 结果保存在内存中并以PNG缓存到磁盘，缓存键由规范路径、修改时间和大小生成，文件变化后自动重新渲染；
+// 渲染失败的文件不再重试，列表中显示为没有缩略图的行
+type thumbnailLoader struct {
+	cacheDir string
+	renderer *thumbnail.Renderer
+	onReady  func() // 有缩略图完成（或失败）时调用，在后台goroutine中
+
+	startOnce sync.Once
+	requests  chan thumbnailRequest
+
+	mutex   sync.Mutex
+	images  map[string]image.Image
+	pending map[string]bool
+	failed  map[string]bool
+}
+
+// newThumbnailLoader 创建缩略图加载器，cacheDir 为空时使用系统临时目录；
+// rasterizer 为nil时按可用能力选择默认光栅化器
+func newThumbnailLoader(cacheDir string, rasterizer thumbnail.Rasterizer) *thumbnailLoader {
+	if cacheDir == "" {
+		cacheDir = os.TempDir()
+	}
+	if rasterizer == nil {
+		rasterizer, _ = thumbnail.NewDefaultRasterizer()
+	}
+	return &thumbnailLoader{
+		cacheDir: filepath.Join(cacheDir, thumbnailCacheDirName),
+		renderer: thumbnail.NewRenderer(rasterizer, nil),
+		requests: make(chan thumbnailRequest, thumbnailQueueSize),
+		images:   make(map[string]image.Image),
+		pending:  make(map[string]bool),
+		failed:   make(map[string]bool),
+	}
+}
+
+// Lookup 返回文件的缩略图；尚未渲染时在后台排队并返回nil，完成后调用onReady。
+// 渲染失败或文件无法访问时返回nil
+func (l *thumbnailLoader) Lookup(filePath string) image.Image {
+	key, err := thumbnailKey(filePath)
+	if err != nil {
+		return nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if img, ok := l.images[key]; ok {
+		return img
+	}
+	if l.failed[key] || l.pending[key] {
+		return nil
+	}
+
+	l.startOnce.Do(l.start)
+	select {
+	case l.requests <- thumbnailRequest{path: filePath, key: key}:
+		l.pending[key] = true
+	default:
+	}
+	return nil
+}
+
+// Clear 丢弃内存中的缩略图和失败记录，磁盘缓存保留
+func (l *thumbnailLoader) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.images = make(map[string]image.Image)
+	l.failed = make(map[string]bool)
+}
+
+// start 启动后台渲染goroutine
+func (l *thumbnailLoader) start() {
+	for i := 0; i < thumbnailWorkers; i++ {
+		go func() {
+			for req := range l.requests {
+				l.process(req)
+			}
+		}()
+	}
+}
+
+// process 从磁盘缓存读取或渲染缩略图并记录结果
+func (l *thumbnailLoader) process(req thumbnailRequest) {
+	img, err := l.load(req)
+
+	l.mutex.Lock()
+	delete(l.pending, req.key)
+	if err != nil {
+		l.failed[req.key] = true
+	} else {
+		l.images[req.key] = img
+	}
+	onReady := l.onReady
+	l.mutex.Unlock()
+
+	if onReady != nil {
+		onReady()
+	}
+}
+
+// load 优先读取磁盘缓存，没有时渲染并写入缓存；写入缓存失败不影响结果
+func (l *thumbnailLoader) load(req thumbnailRequest) (image.Image, error) {
+	cached := filepath.Join(l.cacheDir, req.key+".png")
+	if f, err := os.Open(cached); err == nil {
+		img, err := png.Decode(f)
+		f.Close()
+		if err == nil {
+			return img, nil
+		}
+	}
+
+	img, err := l.renderer.Render(context.Background(), thumbnail.RenderRequest{
+		FilePath: req.path,
+		Page:     1,
+		Width:    thumbnailSize,
+		Height:   thumbnailSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(l.cacheDir, 0755); err == nil {
+		tmp, err := os.CreateTemp(l.cacheDir, req.key+"-*.tmp")
+		if err == nil {
+			encodeErr := png.Encode(tmp, img)
+			closeErr := tmp.Close()
+			if encodeErr != nil || closeErr != nil || os.Rename(tmp.Name(), cached) != nil {
+				os.Remove(tmp.Name())
+			}
+		}
+	}
+	return img, nil
+}
+
+// thumbnailKey 由规范路径、修改时间、大小和缩略图尺寸生成缓存键
+func thumbnailKey(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%d",
+		canonicalPath(filePath), info.ModTime().UnixNano(), info.Size(), thumbnailSize)))
+	return hex.EncodeToString(sum[:16]), nil
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/pkg/thumbnail"
+)
+
+// countingRasterizer 假光栅化器，记录渲染次数，可以模拟渲染失败
+type countingRasterizer struct {
+	renders int32
+	fail    bool
+}
+
+func (r *countingRasterizer) Render(ctx context.Context, req thumbnail.RenderRequest) (image.Image, error) {
+	atomic.AddInt32(&r.renders, 1)
+	if r.fail {
+		return nil, errors.New("render failed")
+	}
+	return image.NewRGBA(image.Rect(0, 0, req.Width*3/4, req.Height)), nil
+}
+
+// newTestThumbnailLoader 创建使用假光栅化器的加载器，返回渲染次数计数和完成通知
+func newTestThumbnailLoader(t *testing.T, cacheDir string, fail bool) (*thumbnailLoader, *int32, chan struct{}) {
+	t.Helper()
+	rasterizer := &countingRasterizer{fail: fail}
+	ready := make(chan struct{}, 16)
+	loader := newThumbnailLoader(cacheDir, rasterizer)
+	loader.onReady = func() { ready <- struct{}{} }
+	return loader, &rasterizer.renders, ready
+}
+
+// waitReady 等待加载器完成一个请求
+func waitReady(t *testing.T, ready chan struct{}) {
+	t.Helper()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("缩略图没有在后台完成")
+	}
+}
+
+func TestThumbnailLoader_RendersInBackgroundAndCaches(t *testing.T) {
+	cacheDir := t.TempDir()
+	input := filepath.Join(t.TempDir(), "a.pdf")
+	if err := os.WriteFile(input, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader, renders, ready := newTestThumbnailLoader(t, cacheDir, false)
+	if img := loader.Lookup(input); img != nil {
+		t.Error("第一次查找应在后台渲染并返回nil")
+	}
+	waitReady(t, ready)
+	img := loader.Lookup(input)
+	if img == nil || img.Bounds().Dy() != thumbnailSize {
+		t.Fatalf("渲染完成后应返回缩略图，实际 %v", img)
+	}
+
+	// 新的加载器从磁盘缓存读取，不再渲染
+	other, otherRenders, otherReady := newTestThumbnailLoader(t, cacheDir, false)
+	other.Lookup(input)
+	waitReady(t, otherReady)
+	if other.Lookup(input) == nil || atomic.LoadInt32(otherRenders) != 0 {
+		t.Errorf("应从磁盘缓存读取缩略图，渲染了 %d 次", atomic.LoadInt32(otherRenders))
+	}
+
+	// 文件修改后缓存键变化，重新渲染
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(input, later, later); err != nil {
+		t.Fatal(err)
+	}
+	loader.Lookup(input)
+	waitReady(t, ready)
+	if got := atomic.LoadInt32(renders); got != 2 {
+		t.Errorf("文件修改后应重新渲染，渲染了 %d 次", got)
+	}
+}
+
+func TestThumbnailLoader_FailureIsNotRetried(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "broken.pdf")
+	if err := os.WriteFile(input, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader, renders, ready := newTestThumbnailLoader(t, t.TempDir(), true)
+	loader.Lookup(input)
+	waitReady(t, ready)
+	for i := 0; i < 3; i++ {
+		if img := loader.Lookup(input); img != nil {
+			t.Fatal("渲染失败时应返回nil")
+		}
+	}
+	if got := atomic.LoadInt32(renders); got != 1 {
+		t.Errorf("渲染失败的文件不应重试，渲染了 %d 次", got)
+	}
+
+	// 不存在的文件直接返回nil，不排队
+	if img := loader.Lookup(filepath.Join(t.TempDir(), "missing.pdf")); img != nil {
+		t.Error("不存在的文件应返回nil")
+	}
+	if got := atomic.LoadInt32(renders); got != 1 {
+		t.Errorf("不存在的文件不应渲染，渲染了 %d 次", got)
+	}
+}
+
+func TestFileListManager_ThumbnailToggle(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "a.pdf")
+	if err := os.WriteFile(input, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flm := NewFileListManager()
+	loader, renders, _ := newTestThumbnailLoader(t, t.TempDir(), false)
+	flm.thumbnails = loader
+	if err := flm.AddFile(input); err != nil {
+		t.Fatal(err)
+	}
+	flm.files[0].IsValid = true
+
+	if flm.ThumbnailsShown() || flm.thumbnail(flm.files[0]) != nil {
+		t.Error("缩略图默认关闭")
+	}
+	if got := atomic.LoadInt32(renders); got != 0 {
+		t.Errorf("关闭时不应渲染缩略图，渲染了 %d 次", got)
+	}
+
+	flm.SetShowThumbnails(true)
+	flm.files[0].IsEncrypted = true
+	if flm.thumbnail(flm.files[0]) != nil {
+		t.Error("加密文件不应渲染缩略图")
+	}
+	if !flm.ThumbnailsShown() {
+		t.Error("开启后应显示缩略图")
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// tooltipLabel 鼠标悬停时在下方弹出提示的标签，提示为空时与普通标签相同
+type tooltipLabel struct {
+	widget.Label
+	tooltip string
+	popUp   *widget.PopUp
+}
+
+var _ desktop.Hoverable = (*tooltipLabel)(nil)
+
+// newTooltipLabel 创建没有提示的标签
+func newTooltipLabel(text string) *tooltipLabel {
+	label := &tooltipLabel{}
+	label.Text = text
+	label.ExtendBaseWidget(label)
+	return label
+}
+
+// SetTooltip 设置悬停提示，设为空时关闭已显示的提示
+func (l *tooltipLabel) SetTooltip(tooltip string) {
+	l.tooltip = tooltip
+	if tooltip == "" {
+		l.hideTooltip()
+	}
+}
+
+// MouseIn 鼠标进入时显示提示
+func (l *tooltipLabel) MouseIn(*desktop.MouseEvent) {
+	if l.tooltip == "" {
+		return
+	}
+	driver := fyne.CurrentApp().Driver()
+	canvas := driver.CanvasForObject(l)
+	if canvas == nil {
+		return
+	}
+	l.hideTooltip()
+	l.popUp = widget.NewPopUp(widget.NewLabel(l.tooltip), canvas)
+	l.popUp.ShowAtPosition(driver.AbsolutePositionForObject(l).AddXY(0, l.Size().Height))
+}
+
+// MouseMoved 实现desktop.Hoverable
+func (l *tooltipLabel) MouseMoved(*desktop.MouseEvent) {}
+
+// MouseOut 鼠标离开时关闭提示
+func (l *tooltipLabel) MouseOut() {
+	l.hideTooltip()
+}
+
+// hideTooltip 关闭已显示的提示
+func (l *tooltipLabel) hideTooltip() {
+	if l.popUp != nil {
+		l.popUp.Hide()
+		l.popUp = nil
+	}
+}
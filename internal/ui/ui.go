@@ -1,47 +1,77 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/user/pdf-merger/internal/clock"
 	"github.com/user/pdf-merger/internal/controller"
+	"github.com/user/pdf-merger/internal/i18n"
 	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 // UI 定义用户界面组件
 type UI struct {
-	window            fyne.Window
-	controller        *controller.Controller
-	eventHandler      interface{} // 将在后续更新中使用具体类型
-	mainFileEntry     *widget.Entry
-	mainFileBrowseBtn *widget.Button
-	fileListManager   *FileListManager
-	fileInfoLabel     *widget.Label
-	addFileBtn        *widget.Button
-	removeFileBtn     *widget.Button
-	clearFilesBtn     *widget.Button
-	moveUpBtn         *widget.Button
-	moveDownBtn       *widget.Button
-	refreshBtn        *widget.Button
-	outputPathEntry   *widget.Entry
-	outputBrowseBtn   *widget.Button
-	progressManager   *ProgressManager
-	mergeButton       *widget.Button
-	cancelButton      *widget.Button
+	window             fyne.Window
+	controller         *controller.Controller
+	eventHandler       interface{} // 将在后续更新中使用具体类型
+	mainFileEntry      *widget.Entry
+	mainFileBrowseBtn  *widget.Button
+	fileListManager    *FileListManager
+	fileInfoLabel      *widget.Label
+	addFileBtn         *widget.Button
+	removeFileBtn      *widget.Button
+	clearFilesBtn      *widget.Button
+	moveUpBtn          *widget.Button
+	moveDownBtn        *widget.Button
+	refreshBtn         *widget.Button
+	outputPathEntry    *widget.Entry
+	outputBrowseBtn    *widget.Button
+	recentOutputSelect *widget.Select
+	profileSelect      *widget.Select
+	tocCheck           *widget.Check
+	normalizeCheck     *widget.Check
+	thumbnailCheck     *widget.Check
+	progressManager    *ProgressManager
+	mergeButton        *widget.Button
+	cancelButton       *widget.Button
 
 	// 数据
 	mainFilePath string
 	outputPath   string
+
+	// 最近一次合并的输出和开始时间，合并完成后用于查找可撤销的备份
+	mergeOutput    string
+	mergeStartedAt time.Time
+
+	// 最近使用的输出目录，最近使用的在前，随会话保存
+	recentOutputDirs []string
+
+	// 设置对话框编辑的配置文件
+	configPath string
+	settings   *model.Config
+
+	// 日志视图显示的最近日志
+	logs *logBuffer
+
+	// 加密文件的密码询问，测试中可替换
+	passwordPrompt passwordPromptFunc
+	passwordMu     sync.Mutex
 }
 
 // NewUI 创建一个新的UI实例
@@ -49,13 +79,24 @@ func NewUI(window fyne.Window, controller *controller.Controller) *UI {
 	ui := &UI{
 		window:     window,
 		controller: controller,
+		logs:       newLogBuffer(maxLogLines),
 	}
 
 	// 创建文件列表管理器
 	ui.fileListManager = NewFileListManager()
+	if controller != nil && controller.Config != nil && len(controller.Config.FilenameEncodings) > 0 {
+		ui.fileListManager.SetFilenameEncodings(controller.Config.FilenameEncodings)
+	}
+	if controller != nil && controller.Config != nil {
+		ui.fileListManager.SetThumbnailCacheDir(controller.Config.TempDirectory)
+		ui.fileListManager.SetShowThumbnails(controller.Config.ShowThumbnails)
+	}
 
 	// 创建进度管理器
 	ui.progressManager = NewProgressManager(window)
+	if controller != nil {
+		ui.progressManager.clock = clock.OrSystem(controller.Clock)
+	}
 
 	// 设置回调
 	ui.fileListManager.SetOnFileChanged(ui.onFileListChanged)
@@ -63,6 +104,14 @@ func NewUI(window fyne.Window, controller *controller.Controller) *UI {
 	ui.progressManager.SetOnCancel(ui.onProgressCancel)
 	ui.progressManager.SetOnComplete(ui.onProgressComplete)
 
+	// 接受从系统文件管理器拖入的PDF文件和目录
+	if window != nil {
+		window.SetOnDropped(ui.onDropped)
+		ui.passwordPrompt = CreateGUIPasswordPrompt(window)
+	}
+	ui.fileListManager.SetOnEncryptedFile(ui.onEncryptedFileAdded)
+	ui.fileListManager.SetOnDuplicateFile(ui.confirmDuplicateFile)
+
 	return ui
 }
 
@@ -101,17 +150,17 @@ func (u *UI) BuildUI() fyne.CanvasObject {
 func (u *UI) createMainFileSection() *fyne.Container {
 	// 主文件输入框
 	u.mainFileEntry = widget.NewEntry()
-	u.mainFileEntry.SetPlaceHolder("请选择主PDF文件...")
+	u.mainFileEntry.SetPlaceHolder(i18n.T(MainFilePlaceholder))
 	u.mainFileEntry.Disable() // 只读，通过浏览按钮选择
 
 	// 主文件浏览按钮
-	u.mainFileBrowseBtn = widget.NewButton(BrowseButton, u.onMainFileBrowse)
+	u.mainFileBrowseBtn = widget.NewButton(i18n.T(BrowseButton), u.onMainFileBrowse)
 
 	// 布局
 	fileRow := container.NewBorder(nil, nil, nil, u.mainFileBrowseBtn, u.mainFileEntry)
 
 	return container.NewVBox(
-		widget.NewRichTextFromMarkdown("## 主PDF文件"),
+		widget.NewRichTextFromMarkdown(i18n.T(MainFileHeading)),
 		fileRow,
 	)
 }
@@ -119,18 +168,18 @@ func (u *UI) createMainFileSection() *fyne.Container {
 // createAdditionalFilesSection 创建附加文件列表区域
 func (u *UI) createAdditionalFilesSection() *fyne.Container {
 	// 文件信息标签
-	u.fileInfoLabel = widget.NewLabel(NoFilesLabel)
+	u.fileInfoLabel = widget.NewLabel(i18n.T(NoFilesLabel))
 	u.fileInfoLabel.TextStyle = fyne.TextStyle{Italic: true}
 
 	// 主要操作按钮
-	u.addFileBtn = widget.NewButtonWithIcon(AddFileButton, theme.ContentAddIcon(), u.onAddFiles)
-	u.removeFileBtn = widget.NewButtonWithIcon(RemoveFileButton, theme.DeleteIcon(), u.onRemoveSelected)
-	u.clearFilesBtn = widget.NewButtonWithIcon(ClearFilesButton, theme.ContentClearIcon(), u.onClearFiles)
+	u.addFileBtn = widget.NewButtonWithIcon(i18n.T(AddFileButton), theme.ContentAddIcon(), u.onAddFiles)
+	u.removeFileBtn = widget.NewButtonWithIcon(i18n.T(RemoveFileButton), theme.DeleteIcon(), u.onRemoveSelected)
+	u.clearFilesBtn = widget.NewButtonWithIcon(i18n.T(ClearFilesButton), theme.ContentClearIcon(), u.onClearFiles)
 
 	// 排序按钮
-	u.moveUpBtn = widget.NewButtonWithIcon(MoveUpButton, theme.MoveUpIcon(), u.onMoveUp)
-	u.moveDownBtn = widget.NewButtonWithIcon(MoveDownButton, theme.MoveDownIcon(), u.onMoveDown)
-	u.refreshBtn = widget.NewButtonWithIcon(RefreshButton, theme.ViewRefreshIcon(), u.onRefreshFiles)
+	u.moveUpBtn = widget.NewButtonWithIcon(i18n.T(MoveUpButton), theme.MoveUpIcon(), u.onMoveUp)
+	u.moveDownBtn = widget.NewButtonWithIcon(i18n.T(MoveDownButton), theme.MoveDownIcon(), u.onMoveDown)
+	u.refreshBtn = widget.NewButtonWithIcon(i18n.T(RefreshButton), theme.ViewRefreshIcon(), u.onRefreshFiles)
 
 	// 按钮行
 	mainButtonRow := container.NewHBox(
@@ -150,17 +199,22 @@ func (u *UI) createAdditionalFilesSection() *fyne.Container {
 		sortButtonRow,
 	)
 
+	// 缩略图开关，只对本次运行生效；默认值在设置中保存
+	u.thumbnailCheck = widget.NewCheck(i18n.T(ShowThumbnailsLabel), nil)
+	u.thumbnailCheck.SetChecked(u.fileListManager.ThumbnailsShown())
+	u.thumbnailCheck.OnChanged = u.fileListManager.SetShowThumbnails
+
 	// 文件列表容器
 	listWidget := u.fileListManager.GetWidget()
 	listContainer := container.NewBorder(
-		u.fileInfoLabel,
+		container.NewBorder(nil, nil, nil, u.thumbnailCheck, u.fileInfoLabel),
 		buttonContainer,
 		nil, nil,
 		listWidget,
 	)
 
 	return container.NewVBox(
-		widget.NewRichTextFromMarkdown("## 附加PDF文件"),
+		widget.NewRichTextFromMarkdown(i18n.T(AdditionalFilesHeading)),
 		listContainer,
 	)
 }
@@ -169,34 +223,82 @@ func (u *UI) createAdditionalFilesSection() *fyne.Container {
 func (u *UI) createOutputSection() *fyne.Container {
 	// 输出路径输入框
 	u.outputPathEntry = widget.NewEntry()
-	u.outputPathEntry.SetPlaceHolder("请选择输出文件路径...")
+	u.outputPathEntry.SetPlaceHolder(i18n.T(OutputPathPlaceholder))
 	u.outputPathEntry.OnChanged = func(text string) {
 		u.outputPath = text
 		u.updateUI()
 	}
 
 	// 输出路径浏览按钮
-	u.outputBrowseBtn = widget.NewButton(BrowseButton, u.onOutputBrowse)
+	u.outputBrowseBtn = widget.NewButton(i18n.T(BrowseButton), u.onOutputBrowse)
+
+	// 最近使用的输出目录，选择后保留当前的输出文件名
+	u.recentOutputSelect = widget.NewSelect(u.recentOutputDirs, u.onRecentOutputDir)
+	u.recentOutputSelect.PlaceHolder = i18n.T(RecentOutputFoldersPlaceholder)
+
+	// 合并配置方案，对之后启动的任务生效
+	profileNames := model.DefaultConfig().ProfileNames()
+	if u.controller != nil && u.controller.Config != nil {
+		profileNames = u.controller.Config.ProfileNames()
+	}
+	u.profileSelect = widget.NewSelect(profileNames, u.onProfileSelected)
+	u.profileSelect.SetSelected(model.DefaultProfileName)
+
+	// 目录页选项，对之后启动的任务生效
+	u.tocCheck = widget.NewCheck(i18n.T(GenerateTOCLabel), func(checked bool) {
+		if u.controller != nil {
+			u.controller.GenerateTOC = checked
+		}
+	})
+
+	// 页面方向规范选项，对之后启动的任务生效；单个文件的旋转由文件列表每行的旋转按钮设置
+	u.normalizeCheck = widget.NewCheck(i18n.T(NormalizeLabel), func(checked bool) {
+		if u.controller != nil {
+			u.controller.NormalizeOrientation = checked
+		}
+	})
 
 	// 布局
-	outputRow := container.NewBorder(nil, nil, nil, u.outputBrowseBtn, u.outputPathEntry)
+	outputRow := container.NewBorder(nil, nil, nil,
+		container.NewHBox(u.recentOutputSelect, u.outputBrowseBtn), u.outputPathEntry)
 
 	return container.NewVBox(
-		widget.NewRichTextFromMarkdown("## 输出文件"),
+		widget.NewRichTextFromMarkdown(i18n.T(OutputHeading)),
 		outputRow,
+		container.NewHBox(widget.NewLabel(i18n.T(ProfileLabel)), u.profileSelect),
+		u.tocCheck,
+		u.normalizeCheck,
 	)
 }
 
+// onProfileSelected 选择合并配置方案，无法应用时显示错误，服务继续使用之前应用的方案
+func (u *UI) onProfileSelected(name string) {
+	if u.controller == nil {
+		return
+	}
+	if err := u.controller.UseProfile(name); err != nil {
+		dialog.ShowError(err, u.window)
+	}
+}
+
 // createControlSection 创建进度和控制区域
 func (u *UI) createControlSection() *fyne.Container {
 	// 控制按钮
-	u.mergeButton = widget.NewButtonWithIcon(StartMergeButton, theme.MediaPlayIcon(), u.onMerge)
-	u.cancelButton = widget.NewButtonWithIcon(CancelButton, theme.CancelIcon(), u.onCancel)
+	u.mergeButton = widget.NewButtonWithIcon(i18n.T(StartMergeButton), theme.MediaPlayIcon(), u.onMerge)
+	u.cancelButton = widget.NewButtonWithIcon(i18n.T(CancelButton), theme.CancelIcon(), u.onCancel)
 	u.cancelButton.Hide() // 初始隐藏
 
+	maintenanceButton := widget.NewButtonWithIcon(i18n.T(MaintenanceButton), theme.SettingsIcon(), u.onMaintenance)
+	settingsButton := widget.NewButton(i18n.T(SettingsButton), u.onSettings)
+	logButton := widget.NewButton(i18n.T(LogButton), u.onShowLog)
+
 	buttonRow := container.NewHBox(
 		u.mergeButton,
 		u.cancelButton,
+		layout.NewSpacer(),
+		logButton,
+		settingsButton,
+		maintenanceButton,
 	)
 
 	// 获取进度管理器容器
@@ -228,13 +330,13 @@ func (u *UI) onMainFileBrowse() {
 		}
 
 		path := uri.Path()
-		if !strings.HasSuffix(strings.ToLower(path), ".pdf") {
+		if !pdf.IsPDFFile(path) {
 			dialog.ShowError(fmt.Errorf("请选择PDF文件"), u.window)
 			return
 		}
 
 		u.mainFilePath = path
-		u.mainFileEntry.SetText(filepath.Base(path))
+		u.mainFileEntry.SetText(u.displayName(path))
 		u.updateUI()
 
 	}, u.window)
@@ -262,14 +364,16 @@ func (u *UI) onAddFiles() {
 		}
 
 		path := uri.Path()
-		if !strings.HasSuffix(strings.ToLower(path), ".pdf") {
+		if !pdf.IsPDFFile(path) {
 			dialog.ShowError(fmt.Errorf("请选择PDF文件"), u.window)
 			return
 		}
 
-		// 添加到文件列表管理器
+		// 添加到文件列表管理器，内容重复的文件已弹出确认对话框
 		if err := u.fileListManager.AddFile(path); err != nil {
-			dialog.ShowError(err, u.window)
+			if !errors.Is(err, ErrDuplicateContent) {
+				dialog.ShowError(err, u.window)
+			}
 			return
 		}
 
@@ -279,6 +383,20 @@ func (u *UI) onAddFiles() {
 	fileDialog.Show()
 }
 
+// confirmDuplicateFile 询问是否添加与列表中文件内容相同的文件，确认后调用add。
+// 没有窗口时无法询问，不添加。
+func (u *UI) confirmDuplicateFile(filePath, existing string, add func()) {
+	if u.window == nil {
+		return
+	}
+	message := i18n.T(DuplicateFileConfirm, filepath.Base(filePath), filepath.Base(existing))
+	dialog.ShowConfirm(i18n.T(DuplicateFileTitle), message, func(confirmed bool) {
+		if confirmed {
+			add()
+		}
+	}, u.window)
+}
+
 // onRemoveSelected 移除选中文件按钮点击处理
 func (u *UI) onRemoveSelected() {
 	if !u.fileListManager.HasFiles() {
@@ -286,7 +404,7 @@ func (u *UI) onRemoveSelected() {
 		return
 	}
 
-	if u.fileListManager.GetSelectedIndex() < 0 {
+	if len(u.fileListManager.GetSelectedIndices()) == 0 {
 		dialog.ShowInformation("提示", "请先选择要移除的文件", u.window)
 		return
 	}
@@ -335,8 +453,13 @@ func (u *UI) onOutputBrowse() {
 
 	}, u.window)
 
-	fileDialog.SetFileName("merged.pdf")
+	fileDialog.SetFileName(defaultOutputName)
 	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".pdf"}))
+	if u.controller != nil && u.controller.Config != nil && u.controller.Config.OutputDirectory != "" {
+		if location, err := storage.ListerForURI(storage.NewFileURI(u.controller.Config.OutputDirectory)); err == nil {
+			fileDialog.SetLocation(location)
+		}
+	}
 	fileDialog.Show()
 }
 
@@ -363,6 +486,10 @@ func (u *UI) onMerge() {
 			return
 		}
 
+		if u.confirmOutputConflict(append([]string{u.mainFilePath}, additionalFiles...)) {
+			return
+		}
+
 		// 开始异步合并
 		u.startAsyncMerge()
 		return
@@ -385,10 +512,36 @@ func (u *UI) onMerge() {
 		return
 	}
 
+	if u.confirmOutputConflict(append([]string{u.mainFilePath}, u.fileListManager.GetFilePaths()...)) {
+		return
+	}
+
 	// 开始合并
 	u.startMerge()
 }
 
+// confirmOutputConflict 输出路径是某个输入文件时返回true，不开始合并，并询问是否改用同一目录中
+// 建议的新文件名；确认后只替换输出路径，由用户再次点击合并
+func (u *UI) confirmOutputConflict(inputs []string) bool {
+	err := pdf.CheckOutputNotInput(u.outputPath, inputs)
+	var pdfErr *pdf.PDFError
+	if !errors.As(err, &pdfErr) {
+		return false
+	}
+	if u.window == nil {
+		return true
+	}
+	suggested := pdf.SuggestOutputPath(u.outputPath, inputs)
+	message := i18n.T(OutputConflictConfirm, u.displayName(pdfErr.File), u.displayName(suggested))
+	dialog.ShowConfirm(i18n.T(OutputConflictTitle), message, func(confirmed bool) {
+		if confirmed {
+			u.setOutputPath(suggested)
+			u.updateUI()
+		}
+	}, u.window)
+	return true
+}
+
 // onCancel 取消按钮点击处理
 func (u *UI) onCancel() {
 	// 取消合并操作
@@ -407,6 +560,12 @@ func (u *UI) onMoveDown() {
 
 // onRefreshFiles 刷新文件信息按钮点击处理
 func (u *UI) onRefreshFiles() {
+	// 手动刷新时丢弃缓存的信息，重新读取每个文件
+	if u.controller != nil {
+		for _, path := range u.fileListManager.GetFilePaths() {
+			u.controller.InvalidatePDFInfo(path)
+		}
+	}
 	u.fileListManager.RefreshFileInfo()
 	u.updateFileInfo()
 }
@@ -422,7 +581,7 @@ func (u *UI) getFileInfo(filePath string) (*model.FileEntry, error) {
 	// 创建文件条目
 	fileEntry := &model.FileEntry{
 		Path:        filePath,
-		DisplayName: filepath.Base(filePath),
+		DisplayName: u.displayName(filePath),
 		IsValid:     true,
 	}
 
@@ -436,10 +595,25 @@ func (u *UI) getFileInfo(filePath string) (*model.FileEntry, error) {
 		if pdfInfo, err := u.controller.GetPDFInfo(filePath); err == nil {
 			fileEntry.PageCount = pdfInfo.PageCount
 			fileEntry.IsEncrypted = pdfInfo.IsEncrypted
+			fileEntry.Signatures = pdfInfo.SignatureCount
+			if dominant, count := pdf.DominantPageSize(pdfInfo.PageSizes); count > 0 {
+				fileEntry.PageSize = dominant.SizeName()
+				fileEntry.Landscape = dominant.Landscape()
+				fileEntry.MixedPageSizes = pdfInfo.MixedPageSizes
+			}
 		} else {
 			fileEntry.IsValid = false
 			fileEntry.Error = err.Error()
 		}
+		// 加密文件在输入密码前无法验证，只对能读取信息的未加密文件区分警告和失败
+		if fileEntry.IsValid && !fileEntry.IsEncrypted {
+			if outcome := u.controller.ValidateFileOutcome(filePath); outcome.Valid {
+				fileEntry.Warnings = outcome.Warnings
+			} else {
+				fileEntry.IsValid = false
+				fileEntry.Error = outcome.Err.Error()
+			}
+		}
 	}
 
 	return fileEntry, nil
@@ -462,6 +636,10 @@ func (u *UI) disableInputControls() {
 	u.moveDownBtn.Disable()
 	u.refreshBtn.Disable()
 	u.outputBrowseBtn.Disable()
+	u.recentOutputSelect.Disable()
+	u.profileSelect.Disable()
+	u.tocCheck.Disable()
+	u.normalizeCheck.Disable()
 }
 
 // enableInputControls 启用输入控件
@@ -474,6 +652,10 @@ func (u *UI) enableInputControls() {
 	u.moveDownBtn.Enable()
 	u.refreshBtn.Enable()
 	u.outputBrowseBtn.Enable()
+	u.recentOutputSelect.Enable()
+	u.profileSelect.Enable()
+	u.tocCheck.Enable()
+	u.normalizeCheck.Enable()
 
 	// 重新应用按钮状态逻辑
 	u.updateUI()
@@ -491,8 +673,8 @@ func (u *UI) performMerge() {
 	// 步骤1: 验证文件
 	u.progressManager.UpdateProgress(ProgressInfo{
 		Progress: 0.1,
-		Status:   "验证文件",
-		Detail:   "正在验证PDF文件...",
+		Status:   i18n.T(StageValidateStatus),
+		Detail:   i18n.T(StageValidateDetail),
 		Step:     1,
 	})
 
@@ -503,16 +685,16 @@ func (u *UI) performMerge() {
 	// 步骤2: 准备合并
 	u.progressManager.UpdateProgress(ProgressInfo{
 		Progress: 0.3,
-		Status:   "准备合并",
-		Detail:   "正在准备合并操作...",
+		Status:   i18n.T(StagePrepareStatus),
+		Detail:   i18n.T(StagePrepareDetail),
 		Step:     2,
 	})
 
 	// 步骤3: 执行合并
 	u.progressManager.UpdateProgress(ProgressInfo{
 		Progress: 0.5,
-		Status:   "合并文件",
-		Detail:   "正在合并PDF文件...",
+		Status:   i18n.T(StageMergeStatus),
+		Detail:   i18n.T(StageMergeDetail),
 		Step:     3,
 	})
 
@@ -523,27 +705,27 @@ func (u *UI) performMerge() {
 	// 步骤4: 保存文件
 	u.progressManager.UpdateProgress(ProgressInfo{
 		Progress: 0.8,
-		Status:   "保存文件",
-		Detail:   "正在保存合并后的文件...",
+		Status:   i18n.T(StageSaveStatus),
+		Detail:   i18n.T(StageSaveDetail),
 		Step:     4,
 	})
 
 	// 步骤5: 完成
 	u.progressManager.UpdateProgress(ProgressInfo{
 		Progress: 1.0,
-		Status:   "完成",
-		Detail:   "合并操作已完成",
+		Status:   i18n.T(StatusCompletedText),
+		Detail:   i18n.T(StageDoneDetail),
 		Step:     5,
 	})
 
-	u.progressManager.Complete("PDF合并完成！")
+	u.progressManager.Complete(i18n.T(SuccessMergeComplete))
 }
 
-// validateFiles 验证文件
+// validateFiles 验证文件，只有警告的文件照常合并
 func (u *UI) validateFiles() bool {
 	// 验证主文件
-	if err := u.controller.ValidateFile(u.mainFilePath); err != nil {
-		u.progressManager.Error(fmt.Errorf("主文件验证失败: %v", err))
+	if outcome := u.controller.ValidateFileOutcome(u.mainFilePath); !outcome.Valid {
+		u.progressManager.Error(fmt.Errorf("主文件验证失败: %v", outcome.Err))
 		return false
 	}
 
@@ -552,13 +734,13 @@ func (u *UI) validateFiles() bool {
 	for i, filePath := range additionalFiles {
 		u.progressManager.UpdateProgress(ProgressInfo{
 			Progress:       0.1 + (0.2 * float64(i) / float64(len(additionalFiles))),
-			CurrentFile:    filepath.Base(filePath),
+			CurrentFile:    u.displayName(filePath),
 			ProcessedFiles: i,
 			TotalFiles:     len(additionalFiles),
 		})
 
-		if err := u.controller.ValidateFile(filePath); err != nil {
-			u.progressManager.Error(fmt.Errorf("文件 %s 验证失败: %v", filepath.Base(filePath), err))
+		if outcome := u.controller.ValidateFileOutcome(filePath); !outcome.Valid {
+			u.progressManager.Error(fmt.Errorf("文件 %s 验证失败: %v", u.displayName(filePath), outcome.Err))
 			return false
 		}
 	}
@@ -566,6 +748,22 @@ func (u *UI) validateFiles() bool {
 	return true
 }
 
+// displayName 返回文件名的显示形式，没有控制器时使用默认回退编码
+func (u *UI) displayName(filePath string) string {
+	if u.controller == nil {
+		return model.DisplayBase(filePath, model.DefaultFilenameEncodings)
+	}
+	return u.controller.DisplayName(filePath)
+}
+
+// clock 返回控制器的时钟，未设置控制器时使用系统时钟
+func (u *UI) clock() clock.Clock {
+	if u.controller == nil {
+		return clock.System()
+	}
+	return clock.OrSystem(u.controller.Clock)
+}
+
 // executeMerge 执行合并
 func (u *UI) executeMerge() bool {
 	additionalFiles := u.fileListManager.GetFilePaths()
@@ -580,9 +778,9 @@ func (u *UI) executeMerge() bool {
 
 		var currentFile string
 		if i == 0 {
-			currentFile = filepath.Base(u.mainFilePath)
+			currentFile = u.displayName(u.mainFilePath)
 		} else {
-			currentFile = filepath.Base(additionalFiles[i-1])
+			currentFile = u.displayName(additionalFiles[i-1])
 		}
 
 		u.progressManager.UpdateProgress(ProgressInfo{
@@ -593,14 +791,14 @@ func (u *UI) executeMerge() bool {
 		})
 
 		// 模拟处理时间
-		time.Sleep(500 * time.Millisecond)
+		u.clock().Sleep(context.Background(), 500*time.Millisecond)
 	}
 
 	// 实际的合并逻辑
 	if u.controller != nil {
 		err := u.controller.MergePDFs(u.mainFilePath, additionalFiles, u.outputPath)
 		if err != nil {
-			u.progressManager.Error(fmt.Errorf("合并失败: %v", err))
+			u.progressManager.Error(fmt.Errorf("合并失败: %w", err))
 			return false
 		}
 	}
@@ -615,8 +813,35 @@ func (u *UI) onProgressCancel() {
 
 // onProgressComplete 进度完成回调
 func (u *UI) onProgressComplete() {
-	// 显示完成对话框
-	u.progressManager.ShowInfoDialog("完成", "PDF文件合并完成！")
+	// 本次合并替换了已存在的输出时提供撤销，否则只显示完成对话框
+	backup := u.mergeBackup()
+	if backup == nil {
+		u.progressManager.ShowInfoDialog(i18n.T(StatusCompletedText), i18n.T(SuccessMergeComplete))
+		return
+	}
+	output := u.mergeOutput
+	u.progressManager.ShowConfirmDialog(i18n.T(UndoMergeTitle), i18n.T(UndoMergeMessage, backup.Path), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if _, err := u.controller.RestorePreviousOutput(output); err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		u.progressManager.ShowInfoDialog(i18n.T(UndoMergeTitle), i18n.T(UndoMergeDoneText, output))
+	})
+}
+
+// mergeBackup 返回本次合并开始后为输出保留的备份，没有时返回nil
+func (u *UI) mergeBackup() *pdf.BackupInfo {
+	if u.controller == nil || u.mergeOutput == "" {
+		return nil
+	}
+	backups, err := u.controller.OutputBackups(u.mergeOutput)
+	if err != nil || len(backups) == 0 || backups[0].Time.Before(u.mergeStartedAt) {
+		return nil
+	}
+	return &backups[0]
 }
 
 // startMerge 开始合并操作
@@ -627,6 +852,8 @@ func (u *UI) startMerge() {
 
 	// 禁用输入控件
 	u.disableInputControls()
+	u.rememberOutputDir(filepath.Dir(u.outputPath))
+	u.mergeOutput, u.mergeStartedAt = u.outputPath, u.clock().Now()
 
 	// 获取文件信息
 	additionalFiles := u.fileListManager.GetFilePaths()
@@ -647,6 +874,8 @@ func (u *UI) startAsyncMerge() {
 
 	// 禁用输入控件
 	u.disableInputControls()
+	u.rememberOutputDir(filepath.Dir(u.outputPath))
+	u.mergeOutput, u.mergeStartedAt = u.outputPath, u.clock().Now()
 
 	// 获取文件信息
 	additionalFiles := u.fileListManager.GetFilePaths()
@@ -657,7 +886,9 @@ func (u *UI) startAsyncMerge() {
 
 	// 通过控制器开始异步合并
 	if u.controller != nil {
-		err := u.controller.StartMergeJob(u.mainFilePath, additionalFiles, u.outputPath)
+		u.controller.Rotations = u.fileListManager.GetRotations()
+		err := u.controller.StartMergeJobWithPasswords(u.mainFilePath, additionalFiles, u.outputPath,
+			u.fileListManager.GetPasswords())
 		if err != nil {
 			dialog.ShowError(err, u.window)
 			u.cancelAsyncMerge()
@@ -718,7 +949,7 @@ func (u *UI) updateUI() {
 
 	// 更新文件操作按钮状态
 	hasFiles := u.fileListManager.HasFiles()
-	hasSelection := u.fileListManager.GetSelectedIndex() >= 0
+	hasSelection := len(u.fileListManager.GetSelectedIndices()) > 0
 
 	if hasFiles {
 		u.clearFilesBtn.Enable()
@@ -728,11 +959,19 @@ func (u *UI) updateUI() {
 
 	if hasSelection {
 		u.removeFileBtn.Enable()
-		u.moveUpBtn.Enable()
-		u.moveDownBtn.Enable()
 	} else {
 		u.removeFileBtn.Disable()
+	}
+
+	// 选中的条目已整体位于顶部或底部时禁用对应的移动按钮
+	if u.fileListManager.CanMoveSelectedUp() {
+		u.moveUpBtn.Enable()
+	} else {
 		u.moveUpBtn.Disable()
+	}
+	if u.fileListManager.CanMoveSelectedDown() {
+		u.moveDownBtn.Enable()
+	} else {
 		u.moveDownBtn.Disable()
 	}
 
@@ -783,9 +1022,9 @@ func (u *UI) UpdateProgress(info ProgressInfo) {
 	u.progressManager.UpdateProgress(info)
 }
 
-// ShowError 显示错误对话框
+// ShowError 显示错误对话框，合并失败时附带已完成部分的信息
 func (u *UI) ShowError(err error) {
-	dialog.ShowError(err, u.window)
+	dialog.ShowError(fmt.Errorf("%s", describeMergeFailure(err)), u.window)
 }
 
 // ShowInfo 显示信息对话框
@@ -100,6 +100,14 @@ func TestUI_BuildUI(t *testing.T) {
 		t.Error("Output browse button not created")
 	}
 
+	if ui.tocCheck == nil {
+		t.Error("Table of contents check not created")
+	}
+
+	if ui.normalizeCheck == nil {
+		t.Error("Normalize orientation check not created")
+	}
+
 	if ui.progressManager == nil {
 		t.Error("Progress manager not created")
 	}
@@ -113,6 +121,44 @@ func TestUI_BuildUI(t *testing.T) {
 	}
 }
 
+func TestUI_ProfileSelect(t *testing.T) {
+	app := test.NewApp()
+	window := app.NewWindow("Test")
+
+	config := model.DefaultConfig()
+	config.Profiles = map[string]model.MergeProfile{
+		"archival": {Merge: model.ProfileMergeOptions{Linearize: true}},
+	}
+	ctrl := controller.NewController(pdf.NewPDFService(), file.NewFileManager("/tmp"), config)
+	ui := NewUI(window, ctrl)
+	ui.BuildUI()
+
+	if ui.profileSelect == nil {
+		t.Fatal("Profile select not created")
+	}
+	want := []string{"archival", model.DefaultProfileName, model.LowMemoryProfileName}
+	if len(ui.profileSelect.Options) != len(want) {
+		t.Fatalf("Expected profiles %v, got %v", want, ui.profileSelect.Options)
+	}
+	for i, name := range want {
+		if ui.profileSelect.Options[i] != name {
+			t.Errorf("Expected profiles %v, got %v", want, ui.profileSelect.Options)
+		}
+	}
+	if ui.profileSelect.Selected != model.DefaultProfileName {
+		t.Errorf("Expected default profile to be selected, got %s", ui.profileSelect.Selected)
+	}
+
+	ui.disableInputControls()
+	if !ui.profileSelect.Disabled() {
+		t.Error("Profile select should be disabled while merging")
+	}
+	ui.enableInputControls()
+	if ui.profileSelect.Disabled() {
+		t.Error("Profile select should be enabled after merging")
+	}
+}
+
 func TestUI_UpdateUI(t *testing.T) {
 	// 创建测试应用和窗口
 	app := test.NewApp()
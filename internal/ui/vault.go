@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// vaultHashPrefix 条目列表中显示的内容哈希长度
+const vaultHashPrefix = 12
+
+// onPasswordVault 显示密码保险库：未打开时询问主密码并打开默认位置的保险库，
+// 打开后列出保存的条目（不含密码），每个条目带删除按钮，并可清空整个保险库
+func (u *UI) onPasswordVault() {
+	if u.controller.PasswordVault() == nil {
+		u.unlockPasswordVault()
+		return
+	}
+
+	var panel dialog.Dialog
+	content := container.NewVBox()
+	var refresh func()
+	refresh = func() {
+		content.Objects = []fyne.CanvasObject{u.buildVaultList(refresh)}
+		content.Refresh()
+	}
+	refresh()
+
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(560, 260))
+	panel = dialog.NewCustom(i18n.T(VaultTitle), i18n.T(CloseButton), scroll, u.window)
+	panel.Show()
+}
+
+// unlockPasswordVault 询问主密码并打开默认位置的保险库，成功后交给控制器使用并显示条目列表
+func (u *UI) unlockPasswordVault() {
+	passphrase := widget.NewPasswordEntry()
+	items := []*widget.FormItem{widget.NewFormItem(i18n.T(VaultPassphraseLabel), passphrase)}
+	items[0].HintText = i18n.T(VaultLockedText)
+
+	form := dialog.NewForm(i18n.T(VaultTitle), i18n.T(VaultUnlockButton), i18n.T(CancelButton), items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		path, err := pdf.DefaultVaultPath()
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		vault, err := pdf.OpenFileVault(path, passphrase.Text)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		u.controller.SetPasswordVault(vault)
+		u.onPasswordVault()
+	}, u.window)
+	form.Show()
+}
+
+// buildVaultList 列出保险库条目，每个条目带删除按钮，列表末尾是清空按钮；
+// 删除或清空后调用 changed 重新构建列表
+func (u *UI) buildVaultList(changed func()) fyne.CanvasObject {
+	vault := u.controller.PasswordVault()
+	entries, err := vault.List()
+	if err != nil {
+		return widget.NewLabel(err.Error())
+	}
+	if len(entries) == 0 {
+		return widget.NewLabel(i18n.T(VaultEmptyText))
+	}
+
+	now := clock.OrSystem(u.controller.Clock).Now()
+	rows := container.NewVBox(widget.NewLabel(i18n.T(VaultEntriesText, len(entries))))
+	for _, entry := range entries {
+		entry := entry
+		remove := widget.NewButton(i18n.T(VaultRemoveButton), func() {
+			if err := vault.Remove(entry.ContentHash); err != nil {
+				dialog.ShowError(err, u.window)
+			}
+			changed()
+		})
+		rows.Add(container.NewBorder(nil, nil, nil, remove, widget.NewLabel(formatVaultEntry(entry, now))))
+	}
+
+	purge := widget.NewButton(i18n.T(VaultPurgeButton), func() {
+		dialog.ShowConfirm(i18n.T(VaultTitle), i18n.T(VaultPurgeConfirm, len(entries)), func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := vault.Purge(); err != nil {
+				dialog.ShowError(err, u.window)
+			}
+			changed()
+		}, u.window)
+	})
+	return container.NewVBox(rows, widget.NewSeparator(), purge)
+}
+
+// formatVaultEntry 以当前语言描述保险库条目：文件名、内容哈希前缀和上次使用时间
+func formatVaultEntry(entry pdf.VaultEntry, now time.Time) string {
+	hash := entry.ContentHash
+	if len(hash) > vaultHashPrefix {
+		hash = hash[:vaultHashPrefix]
+	}
+	return i18n.T(VaultEntryText, entry.Label, hash, formatAge(now.Sub(entry.LastUsed)))
+}
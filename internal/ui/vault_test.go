@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+func TestFormatVaultEntry(t *testing.T) {
+	original := i18n.CurrentLocale()
+	i18n.SetLocale(i18n.ZhCN)
+	defer i18n.SetLocale(original)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry := pdf.VaultEntry{
+		ContentHash: strings.Repeat("ab", 32),
+		Label:       "report.pdf",
+		LastUsed:    now.Add(-3 * time.Hour),
+	}
+	if got, want := formatVaultEntry(entry, now), "report.pdf  abababababab  上次使用：3小时前"; got != want {
+		t.Errorf("期望 %q，实际为 %q", want, got)
+	}
+}
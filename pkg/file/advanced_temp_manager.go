@@ -60,8 +60,8 @@ func (atm *AdvancedTempManager) CreateTempFileWithTags(prefix, suffix string, ta
 	info := &TempFileInfo{
 		Path:         filePath,
 		Size:         0,
-		CreatedAt:    time.Now(),
-		LastAccessed: time.Now(),
+		CreatedAt:    atm.clock.Now(),
+		LastAccessed: atm.clock.Now(),
 		Tags:         make([]string, len(tags)),
 	}
 	copy(info.Tags, tags)
@@ -147,7 +147,7 @@ func (atm *AdvancedTempManager) UpdateLastAccessed(filePath string) {
 	defer atm.infoMutex.Unlock()
 
 	if info, exists := atm.fileInfos[filePath]; exists {
-		info.LastAccessed = time.Now()
+		info.LastAccessed = atm.clock.Now()
 	}
 }
 
@@ -207,7 +207,7 @@ func (atm *AdvancedTempManager) CleanupOldFiles(maxAge time.Duration) int {
 	atm.infoMutex.Lock()
 	defer atm.infoMutex.Unlock()
 
-	now := time.Now()
+	now := atm.clock.Now()
 	var filesToRemove []string
 
 	for filePath, info := range atm.fileInfos {
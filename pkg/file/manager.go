@@ -30,9 +30,18 @@ type FileManager interface {
 	// CopyToTempFile 将源文件复制到临时文件
 	CopyToTempFile(sourcePath string, prefix string) (string, error)
 
-	// CleanupTempFiles 清理所有临时文件
+	// CleanupTempFiles 清理临时文件：没有任务持有时删除本会话的全部临时文件，否则只删除超过最长保留时间的文件；
+	// 同时删除其他会话遗留的、所属进程已经退出且超过最长保留时间的临时文件
 	CleanupTempFiles() error
 
+	// CleanupTempFilesOlderThan 删除本会话中修改时间早于maxAge之前的临时文件，不论是否有任务持有，
+	// 返回删除的文件数；供控制器定期清理长时间运行的会话中遗留的文件
+	CleanupTempFilesOlderThan(maxAge time.Duration) (int, error)
+
+	// HoldTempFiles 标记一个任务开始使用临时文件，任务结束时调用返回的函数。
+	// 持有期间 CleanupTempFiles 不会删除运行中任务的临时文件
+	HoldTempFiles() func()
+
 	// RemoveTempFile 删除指定的临时文件
 	RemoveTempFile(filePath string) error
 
@@ -48,6 +57,12 @@ type FileManager interface {
 	// SetTempFileMaxAge 设置临时文件的最大保留时间
 	SetTempFileMaxAge(duration time.Duration)
 
+	// SetTempQuota 设置临时目录的空间配额，超出时创建临时文件返回 *TempQuotaError
+	SetTempQuota(quota TempQuota)
+
+	// GetTempUsage 返回临时目录中文件的总字节数和文件数
+	GetTempUsage() (int64, int)
+
 	// CopyFile 复制文件
 	CopyFile(sourcePath, destPath string) error
 
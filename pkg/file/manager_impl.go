@@ -7,6 +7,10 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+	"github.com/user/pdf-merger/pkg/pdf"
 )
 
 // FileManagerImpl 实现FileManager接口
@@ -14,13 +18,18 @@ type FileManagerImpl struct {
 	tempManager *TempFileManager
 }
 
-// NewFileManager 创建一个新的文件管理器实例
+// NewFileManager 创建一个使用系统时钟的文件管理器实例
 func NewFileManager(tempDir string) FileManager {
+	return NewFileManagerWithClock(tempDir, nil)
+}
+
+// NewFileManagerWithClock 创建一个新的文件管理器实例，临时文件管理使用时钟c（通常为 Config.Clock），nil时使用系统时钟
+func NewFileManagerWithClock(tempDir string, c clock.Clock) FileManager {
 	// 创建临时文件管理器
-	tempManager, err := NewTempFileManager(tempDir)
+	tempManager, err := NewTempFileManagerWithClock(tempDir, c)
 	if err != nil {
 		// 如果创建失败，使用默认临时目录
-		tempManager, _ = NewTempFileManager("")
+		tempManager, _ = NewTempFileManagerWithClock("", c)
 	}
 
 	return &FileManagerImpl{
@@ -33,9 +42,11 @@ func (fm *FileManagerImpl) ValidateFile(filePath string) error {
 	if filePath == "" {
 		return fmt.Errorf("文件路径不能为空")
 	}
+	// 长路径和网络共享路径规范后再访问，错误中保留原始路径
+	path := model.NormalizePath(filePath)
 
 	// 检查文件是否存在
-	info, err := os.Stat(filePath)
+	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		return fmt.Errorf("文件不存在: %s", filePath)
 	}
@@ -48,10 +59,9 @@ func (fm *FileManagerImpl) ValidateFile(filePath string) error {
 		return fmt.Errorf("路径指向目录而不是文件: %s", filePath)
 	}
 
-	// 检查文件扩展名
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".pdf" {
-		return fmt.Errorf("不支持的文件格式: %s (仅支持PDF文件)", ext)
+	// 检查文件类型：没有 .pdf 扩展名但文件头正确的文件也接受
+	if !pdf.IsPDFFile(path) {
+		return fmt.Errorf("不支持的文件格式: %s (仅支持PDF文件)", strings.ToLower(filepath.Ext(filePath)))
 	}
 
 	// 检查文件大小
@@ -60,7 +70,7 @@ func (fm *FileManagerImpl) ValidateFile(filePath string) error {
 	}
 
 	// 检查文件是否可读
-	file, err := os.Open(filePath)
+	file, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("无法读取文件: %v", err)
 	}
@@ -90,12 +100,22 @@ func (fm *FileManagerImpl) CopyToTempFile(sourcePath string, prefix string) (str
 	return fm.tempManager.CopyToTempFile(sourcePath, prefix)
 }
 
-// CleanupTempFiles 清理所有临时文件
+// CleanupTempFiles 清理临时文件，保留运行中任务持有的文件
 func (fm *FileManagerImpl) CleanupTempFiles() error {
 	fm.tempManager.Cleanup()
 	return nil
 }
 
+// CleanupTempFilesOlderThan 删除修改时间早于maxAge之前的临时文件，返回删除的文件数
+func (fm *FileManagerImpl) CleanupTempFilesOlderThan(maxAge time.Duration) (int, error) {
+	return fm.tempManager.SweepOlderThan(maxAge), nil
+}
+
+// HoldTempFiles 标记一个任务开始使用临时文件
+func (fm *FileManagerImpl) HoldTempFiles() func() {
+	return fm.tempManager.Hold()
+}
+
 // RemoveTempFile 删除指定的临时文件
 func (fm *FileManagerImpl) RemoveTempFile(filePath string) error {
 	return fm.tempManager.RemoveFile(filePath)
@@ -156,6 +176,16 @@ func (fm *FileManagerImpl) SetTempFileMaxAge(duration time.Duration) {
 	fm.tempManager.SetMaxAge(duration)
 }
 
+// SetTempQuota 设置临时目录的空间配额
+func (fm *FileManagerImpl) SetTempQuota(quota TempQuota) {
+	fm.tempManager.SetQuota(quota)
+}
+
+// GetTempUsage 返回临时目录中文件的总字节数和文件数
+func (fm *FileManagerImpl) GetTempUsage() (int64, int) {
+	return fm.tempManager.Usage()
+}
+
 // CopyFile 复制文件
 func (fm *FileManagerImpl) CopyFile(sourcePath, destPath string) error {
 	// 打开源文件
@@ -3,9 +3,12 @@ package file
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/model"
 )
 
 func TestFileManagerImpl_ValidateFile(t *testing.T) {
@@ -75,6 +78,16 @@ func TestFileManagerImpl_ValidateFile(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "无扩展名但文件头正确的PDF文件",
+			setupFile: func() string {
+				file := filepath.Join(tempDir, "document")
+				content := "%PDF-1.4\n1 0 obj\n<<\n/Type /Catalog\n/Pages 2 0 R\n>>\nendobj\n%%EOF"
+				os.WriteFile(file, []byte(content), 0644)
+				return file
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -97,6 +110,25 @@ func TestFileManagerImpl_ValidateFile(t *testing.T) {
 	}
 }
 
+func TestFileManagerImpl_ValidateFileLongPath(t *testing.T) {
+	dir := t.TempDir()
+	for len(dir) <= 300 {
+		dir = filepath.Join(dir, strings.Repeat("d", 40))
+	}
+	if err := os.MkdirAll(model.NormalizePath(dir), 0755); err != nil {
+		t.Fatalf("创建长路径目录失败: %v", err)
+	}
+	path := filepath.Join(dir, "long.pdf")
+	if err := os.WriteFile(model.NormalizePath(path), []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := NewFileManager(t.TempDir())
+	if err := fm.ValidateFile(path); err != nil {
+		t.Errorf("超过260个字符的路径应通过验证（%s）: %v", runtime.GOOS, err)
+	}
+}
+
 func TestFileManagerImpl_CreateTempFile(t *testing.T) {
 	tempDir := t.TempDir()
 	fm := NewFileManager(tempDir)
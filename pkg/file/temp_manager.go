@@ -6,23 +6,38 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
+// SessionOwnerFile 会话目录中记录所属进程ID的文件，清理其他会话时据此跳过仍在运行的进程的目录
+const SessionOwnerFile = "owner.pid"
+
 // TempFileManager 专门负责临时文件的管理
 type TempFileManager struct {
 	baseDir      string
 	sessionDir   string
 	files        map[string]time.Time
 	maxAge       time.Duration
+	holds        int       // 正在使用临时文件的任务数，见 Hold
+	quota        TempQuota // 会话目录的空间配额，见 SetQuota
 	cleanupTimer *time.Timer
+	clock        clock.Clock // 会话目录名称和文件时间使用的时钟
 	mutex        sync.RWMutex
 }
 
-// NewTempFileManager 创建一个新的临时文件管理器
+// NewTempFileManager 创建一个使用系统时钟的临时文件管理器
 func NewTempFileManager(baseDir string) (*TempFileManager, error) {
+	return NewTempFileManagerWithClock(baseDir, nil)
+}
+
+// NewTempFileManagerWithClock 创建一个新的临时文件管理器，会话目录名称和临时文件的时间取自c，nil时使用系统时钟
+func NewTempFileManagerWithClock(baseDir string, c clock.Clock) (*TempFileManager, error) {
+	c = clock.OrSystem(c)
 	if baseDir == "" {
 		baseDir = os.TempDir()
 	}
@@ -31,18 +46,19 @@ func NewTempFileManager(baseDir string) (*TempFileManager, error) {
 	baseDir = filepath.Join(baseDir, "pdf-merger-temp")
 
 	// 创建会话特定的目录（使用时间戳确保唯一性）
-	sessionDir := filepath.Join(baseDir, fmt.Sprintf("session_%d", time.Now().UnixNano()))
-
-	// 确保目录存在
-	if err := os.MkdirAll(sessionDir, 0755); err != nil {
-		return nil, fmt.Errorf("无法创建临时目录: %v", err)
-	}
+	sessionDir := filepath.Join(baseDir, fmt.Sprintf("session_%d", c.Now().UnixNano()))
 
 	manager := &TempFileManager{
 		baseDir:    baseDir,
 		sessionDir: sessionDir,
 		files:      make(map[string]time.Time),
 		maxAge:     1 * time.Hour, // 默认临时文件最长保留1小时
+		clock:      c,
+	}
+
+	// 确保目录存在
+	if err := manager.ensureSessionDir(); err != nil {
+		return nil, err
 	}
 
 	// 设置清理定时器
@@ -56,8 +72,13 @@ func NewTempFileManager(baseDir string) (*TempFileManager, error) {
 	return manager, nil
 }
 
-// CreateTempFile 创建一个新的临时文件
+// CreateTempFile 创建一个新的临时文件，会话目录已达到配额时返回 *TempQuotaError
 func (tm *TempFileManager) CreateTempFile(prefix string, suffix string) (string, *os.File, error) {
+	return tm.createTempFile(prefix, suffix, 0)
+}
+
+// createTempFile 检查再写入size字节是否超出配额后创建临时文件
+func (tm *TempFileManager) createTempFile(prefix string, suffix string, size int64) (string, *os.File, error) {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
@@ -69,6 +90,14 @@ func (tm *TempFileManager) CreateTempFile(prefix string, suffix string) (string,
 		suffix = ".tmp"
 	}
 
+	// 清理后会话目录可能已被删除
+	if err := tm.ensureSessionDir(); err != nil {
+		return "", nil, err
+	}
+	if err := tm.checkQuota(size); err != nil {
+		return "", nil, err
+	}
+
 	// 创建临时文件
 	tempFile, err := os.CreateTemp(tm.sessionDir, prefix+"*"+suffix)
 	if err != nil {
@@ -76,14 +105,14 @@ func (tm *TempFileManager) CreateTempFile(prefix string, suffix string) (string,
 	}
 
 	// 记录文件创建时间
-	tm.files[tempFile.Name()] = time.Now()
+	tm.files[tempFile.Name()] = tm.clock.Now()
 
 	return tempFile.Name(), tempFile, nil
 }
 
 // CreateTempFileWithContent 创建一个带有指定内容的临时文件
 func (tm *TempFileManager) CreateTempFileWithContent(prefix string, suffix string, content []byte) (string, error) {
-	filePath, file, err := tm.CreateTempFile(prefix, suffix)
+	filePath, file, err := tm.createTempFile(prefix, suffix, int64(len(content)))
 	if err != nil {
 		return "", err
 	}
@@ -113,8 +142,12 @@ func (tm *TempFileManager) CopyToTempFile(sourcePath string, prefix string) (str
 		ext = ".tmp"
 	}
 
-	// 创建临时文件
-	destPath, destFile, err := tm.CreateTempFile(prefix, ext)
+	// 创建临时文件，按源文件大小检查配额
+	var size int64
+	if info, err := sourceFile.Stat(); err == nil {
+		size = info.Size()
+	}
+	destPath, destFile, err := tm.createTempFile(prefix, ext, size)
 	if err != nil {
 		return "", err
 	}
@@ -161,18 +194,35 @@ func (tm *TempFileManager) RemoveFile(filePath string) error {
 	return nil
 }
 
-// Cleanup 清理所有临时文件
+// Hold 标记一个任务开始使用临时文件，任务结束时调用返回的函数。
+// 有任务持有时 Cleanup 只删除过期的文件，不会删除运行中任务正在使用的文件
+func (tm *TempFileManager) Hold() func() {
+	tm.mutex.Lock()
+	tm.holds++
+	tm.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tm.mutex.Lock()
+			tm.holds--
+			tm.mutex.Unlock()
+		})
+	}
+}
+
+// Cleanup 清理临时文件：没有任务持有时删除整个会话目录，否则只删除过期的文件。
+// 同时删除其他会话中所属进程已经退出、超过最长保留时间的目录
 func (tm *TempFileManager) Cleanup() {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
-	// 删除会话目录中的所有文件
-	if err := os.RemoveAll(tm.sessionDir); err != nil {
-		fmt.Printf("警告: 无法删除临时目录 %s: %v\n", tm.sessionDir, err)
+	if tm.holds > 0 {
+		tm.removeExpiredFiles()
+	} else {
+		tm.removeSession()
 	}
-
-	// 清空文件记录
-	tm.files = make(map[string]time.Time)
+	tm.cleanupOldSessions()
 }
 
 // CleanupExpired 清理过期的临时文件
@@ -180,18 +230,33 @@ func (tm *TempFileManager) CleanupExpired() {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
-	now := time.Now()
+	tm.removeExpiredFiles()
+
+	// 清理其他会话的过期目录
+	tm.cleanupOldSessions()
+}
+
+// removeSession 删除会话目录中的所有文件，调用方持有锁
+func (tm *TempFileManager) removeSession() {
+	if err := os.RemoveAll(tm.sessionDir); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 无法删除临时目录 %s: %v\n", tm.sessionDir, err)
+	}
+
+	// 清空文件记录
+	tm.files = make(map[string]time.Time)
+}
+
+// removeExpiredFiles 删除超过最长保留时间的临时文件，调用方持有锁
+func (tm *TempFileManager) removeExpiredFiles() {
+	now := tm.clock.Now()
 	for filePath, creationTime := range tm.files {
 		if now.Sub(creationTime) > tm.maxAge {
 			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-				fmt.Printf("警告: 无法删除过期临时文件 %s: %v\n", filePath, err)
+				fmt.Fprintf(os.Stderr, "警告: 无法删除过期临时文件 %s: %v\n", filePath, err)
 			}
 			delete(tm.files, filePath)
 		}
 	}
-
-	// 清理其他会话的过期目录
-	tm.cleanupOldSessions()
 }
 
 // cleanupOldSessions 清理旧的会话目录
@@ -203,7 +268,7 @@ func (tm *TempFileManager) cleanupOldSessions() {
 	}
 
 	currentSession := filepath.Base(tm.sessionDir)
-	now := time.Now()
+	now := tm.clock.Now()
 
 	for _, entry := range entries {
 		// 跳过当前会话目录
@@ -217,6 +282,11 @@ func (tm *TempFileManager) cleanupOldSessions() {
 			continue
 		}
 
+		// 所属进程仍在运行的会话可能有正在执行的任务，不论多旧都保留
+		if sessionOwnerAlive(sessionPath) {
+			continue
+		}
+
 		// 如果目录超过最大年龄，则删除
 		if now.Sub(info.ModTime()) > tm.maxAge {
 			os.RemoveAll(sessionPath)
@@ -224,6 +294,34 @@ func (tm *TempFileManager) cleanupOldSessions() {
 	}
 }
 
+// ensureSessionDir 创建会话目录并写入所属进程ID，调用方持有锁或尚未共享管理器
+func (tm *TempFileManager) ensureSessionDir() error {
+	if DirExists(tm.sessionDir) {
+		return nil
+	}
+	if err := os.MkdirAll(tm.sessionDir, 0755); err != nil {
+		return fmt.Errorf("无法创建临时目录: %v", err)
+	}
+	owner := filepath.Join(tm.sessionDir, SessionOwnerFile)
+	if err := os.WriteFile(owner, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("无法创建临时目录: %v", err)
+	}
+	return nil
+}
+
+// sessionOwnerAlive 判断会话目录所属的进程是否仍在运行，没有记录进程ID的旧会话视为已退出
+func sessionOwnerAlive(sessionPath string) bool {
+	data, err := os.ReadFile(filepath.Join(sessionPath, SessionOwnerFile))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+	return ProcessAlive(pid)
+}
+
 // GetSessionDir 获取当前会话的临时目录
 func (tm *TempFileManager) GetSessionDir() string {
 	tm.mutex.RLock()
@@ -265,10 +363,12 @@ func (tm *TempFileManager) isOwnedFile(filePath string) bool {
 	return strings.HasPrefix(absPath, tm.sessionDir)
 }
 
-// Close 关闭临时文件管理器，清理所有资源
+// Close 关闭临时文件管理器，不论是否有任务持有都删除会话目录
 func (tm *TempFileManager) Close() {
 	if tm.cleanupTimer != nil {
 		tm.cleanupTimer.Stop()
 	}
-	tm.Cleanup()
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.removeSession()
 }
@@ -3,8 +3,11 @@ package file
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestNewTempFileManager(t *testing.T) {
@@ -237,3 +240,123 @@ func TestTempFileManager_CleanupExpired(t *testing.T) {
 		t.Errorf("期望文件计数为0，实际为: %d", count)
 	}
 }
+
+func TestTempFileManager_UsesInjectedClock(t *testing.T) {
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start, 1)
+	manager, err := NewTempFileManagerWithClock(t.TempDir(), fake)
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+
+	// 会话目录名称取自假时钟
+	want := "session_" + strconv.FormatInt(start.UnixNano(), 10)
+	if got := filepath.Base(manager.GetSessionDir()); got != want {
+		t.Errorf("会话目录名称 = %s，应为 %s", got, want)
+	}
+
+	manager.SetMaxAge(time.Hour)
+	oldPath, err := manager.CreateTempFileWithContent("old_", ".tmp", []byte("old"))
+	if err != nil {
+		t.Fatalf("创建临时文件失败: %v", err)
+	}
+	fake.Advance(45 * time.Minute)
+	newPath, err := manager.CreateTempFileWithContent("new_", ".tmp", []byte("new"))
+	if err != nil {
+		t.Fatalf("创建临时文件失败: %v", err)
+	}
+
+	// 只有按假时钟已超过保留时间的文件被删除，不需要真实等待
+	fake.Advance(30 * time.Minute)
+	manager.CleanupExpired()
+	if FileExists(oldPath) {
+		t.Errorf("过期的临时文件未被删除: %s", oldPath)
+	}
+	if !FileExists(newPath) {
+		t.Errorf("未过期的临时文件被删除: %s", newPath)
+	}
+}
+
+func TestTempFileManager_CleanupWhileHeld(t *testing.T) {
+	manager, err := NewTempFileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+
+	release := manager.Hold()
+	path, f, err := manager.CreateTempFile("held_", ".pdf")
+	if err != nil {
+		t.Fatalf("创建临时文件失败: %v", err)
+	}
+	f.Close()
+
+	// 有任务持有时只删除过期的文件
+	manager.Cleanup()
+	if !FileExists(path) {
+		t.Error("有任务持有时不应删除未过期的临时文件")
+	}
+
+	release()
+	release() // 重复调用不应多次释放
+	manager.Cleanup()
+	if FileExists(path) {
+		t.Error("没有任务持有时应删除临时文件")
+	}
+
+	// 清理后仍可以创建临时文件
+	path, f, err = manager.CreateTempFile("after_", ".pdf")
+	if err != nil {
+		t.Fatalf("清理后创建临时文件失败: %v", err)
+	}
+	f.Close()
+	if !FileExists(filepath.Join(manager.GetSessionDir(), SessionOwnerFile)) {
+		t.Error("重新创建的会话目录应记录所属进程")
+	}
+}
+
+func TestTempFileManager_CleanupOrphanedSessions(t *testing.T) {
+	manager, err := NewTempFileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+	manager.SetMaxAge(time.Minute)
+	baseDir := filepath.Dir(manager.GetSessionDir())
+
+	old := time.Now().Add(-time.Hour)
+	session := func(name, owner string) string {
+		dir := filepath.Join(baseDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if owner != "" {
+			if err := os.WriteFile(filepath.Join(dir, SessionOwnerFile), []byte(owner), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chtimes(dir, old, old); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	dead := session("session_1", "999999999")
+	live := session("session_2", strconv.Itoa(os.Getpid()))
+	legacy := session("session_3", "")
+
+	manager.CleanupExpired()
+
+	if DirExists(dead) {
+		t.Error("所属进程已退出的过期会话应被删除")
+	}
+	if !DirExists(live) {
+		t.Error("所属进程仍在运行的会话不应被删除")
+	}
+	if DirExists(legacy) {
+		t.Error("没有记录所属进程的过期会话应被删除")
+	}
+	if !DirExists(manager.GetSessionDir()) {
+		t.Error("不应删除当前会话目录")
+	}
+}
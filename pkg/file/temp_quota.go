@@ -0,0 +1,115 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrTempQuotaExceeded 创建临时文件会超出临时空间配额，errors.Is 可以识别 *TempQuotaError
+var ErrTempQuotaExceeded = errors.New("超出临时空间配额")
+
+// TempQuota 会话临时目录的空间配额，字段为0时不限制
+type TempQuota struct {
+	MaxBytes int64 // 临时文件的总字节数上限
+	MaxFiles int   // 临时文件数上限
+}
+
+// TempQuotaError 创建临时文件前发现会超出配额时返回，说明超出的是字节数还是文件数
+type TempQuotaError struct {
+	Dir       string // 会话临时目录
+	Files     bool   // 超出的是文件数，为false时是字节数
+	Used      int64  // 当前已使用的字节数或文件数
+	Requested int64  // 本次需要的字节数或文件数
+	Limit     int64  // 配额
+}
+
+func (e *TempQuotaError) Error() string {
+	if e.Files {
+		return fmt.Sprintf("%v: 临时目录 %s 已有 %d 个文件，上限为 %d 个", ErrTempQuotaExceeded, e.Dir, e.Used, e.Limit)
+	}
+	return fmt.Sprintf("%v: 临时目录 %s 已使用 %d 字节，再写入 %d 字节将超出上限 %d 字节",
+		ErrTempQuotaExceeded, e.Dir, e.Used, e.Requested, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrTempQuotaExceeded) 成立
+func (e *TempQuotaError) Is(target error) bool {
+	return target == ErrTempQuotaExceeded
+}
+
+// SetQuota 设置会话临时目录的配额，对之后创建的临时文件生效
+func (tm *TempFileManager) SetQuota(quota TempQuota) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.quota = quota
+}
+
+// Usage 返回会话临时目录中文件的总字节数和文件数，包括不是通过管理器创建的文件
+func (tm *TempFileManager) Usage() (int64, int) {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return tm.usage()
+}
+
+// usage 遍历会话目录统计文件大小和数量，不计会话所属进程的记录文件；调用方持有锁
+func (tm *TempFileManager) usage() (int64, int) {
+	var bytes int64
+	var count int
+	filepath.WalkDir(tm.sessionDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path == filepath.Join(tm.sessionDir, SessionOwnerFile) {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			bytes += info.Size()
+			count++
+		}
+		return nil
+	})
+	return bytes, count
+}
+
+// checkQuota 检查再创建一个size字节的临时文件是否会超出配额；调用方持有锁
+func (tm *TempFileManager) checkQuota(size int64) error {
+	if tm.quota.MaxBytes <= 0 && tm.quota.MaxFiles <= 0 {
+		return nil
+	}
+	bytes, count := tm.usage()
+	if tm.quota.MaxFiles > 0 && count+1 > tm.quota.MaxFiles {
+		return &TempQuotaError{Dir: tm.sessionDir, Files: true, Used: int64(count), Requested: 1, Limit: int64(tm.quota.MaxFiles)}
+	}
+	if tm.quota.MaxBytes > 0 && bytes+size > tm.quota.MaxBytes {
+		return &TempQuotaError{Dir: tm.sessionDir, Used: bytes, Requested: size, Limit: tm.quota.MaxBytes}
+	}
+	return nil
+}
+
+// SweepOlderThan 删除会话目录中修改时间早于maxAge之前的文件，返回删除的文件数。
+// 与 Cleanup 不同，不论是否有任务持有都执行：仍在写入的文件修改时间较新，不会被删除
+func (tm *TempFileManager) SweepOlderThan(maxAge time.Duration) int {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	cutoff := tm.clock.Now().Add(-maxAge)
+	owner := filepath.Join(tm.sessionDir, SessionOwnerFile)
+	removed := 0
+	filepath.WalkDir(tm.sessionDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path == owner {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "警告: 无法删除过期临时文件 %s: %v\n", path, err)
+			return nil
+		}
+		delete(tm.files, path)
+		removed++
+		return nil
+	})
+	return removed
+}
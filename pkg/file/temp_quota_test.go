@@ -0,0 +1,101 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTempFileManager_QuotaFiles(t *testing.T) {
+	manager, err := NewTempFileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+	manager.SetQuota(TempQuota{MaxFiles: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := manager.CreateTempFileWithContent("quota_", ".tmp", []byte("data")); err != nil {
+			t.Fatalf("配额内创建临时文件失败: %v", err)
+		}
+	}
+
+	_, _, err = manager.CreateTempFile("quota_", ".tmp")
+	if !errors.Is(err, ErrTempQuotaExceeded) {
+		t.Fatalf("期望超出文件数配额，实际为: %v", err)
+	}
+	var quotaErr *TempQuotaError
+	if !errors.As(err, &quotaErr) || !quotaErr.Files || quotaErr.Used != 2 || quotaErr.Limit != 2 {
+		t.Errorf("配额错误内容不正确: %+v", quotaErr)
+	}
+
+	if bytes, count := manager.Usage(); bytes != 8 || count != 2 {
+		t.Errorf("期望使用8字节、2个文件，实际为 %d 字节、%d 个文件", bytes, count)
+	}
+}
+
+func TestTempFileManager_QuotaBytes(t *testing.T) {
+	manager, err := NewTempFileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+	manager.SetQuota(TempQuota{MaxBytes: 10})
+
+	if _, err := manager.CreateTempFileWithContent("quota_", ".tmp", make([]byte, 6)); err != nil {
+		t.Fatalf("配额内创建临时文件失败: %v", err)
+	}
+
+	_, err = manager.CreateTempFileWithContent("quota_", ".tmp", make([]byte, 6))
+	var quotaErr *TempQuotaError
+	if !errors.As(err, &quotaErr) || quotaErr.Files || quotaErr.Used != 6 || quotaErr.Requested != 6 {
+		t.Fatalf("期望超出字节数配额，实际为: %v", err)
+	}
+
+	source := filepath.Join(t.TempDir(), "source.pdf")
+	if err := os.WriteFile(source, make([]byte, 5), 0644); err != nil {
+		t.Fatalf("创建源文件失败: %v", err)
+	}
+	if _, err := manager.CopyToTempFile(source, "copy_"); !errors.Is(err, ErrTempQuotaExceeded) {
+		t.Errorf("复制超出配额的文件应失败，实际为: %v", err)
+	}
+	if _, count := manager.Usage(); count != 1 {
+		t.Errorf("配额检查失败后不应留下文件，实际有 %d 个", count)
+	}
+}
+
+func TestTempFileManager_SweepOlderThan(t *testing.T) {
+	manager, err := NewTempFileManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建临时文件管理器失败: %v", err)
+	}
+	defer manager.Close()
+
+	oldPath, err := manager.CreateTempFileWithContent("old_", ".tmp", []byte("old"))
+	if err != nil {
+		t.Fatalf("创建临时文件失败: %v", err)
+	}
+	newPath, err := manager.CreateTempFileWithContent("new_", ".tmp", []byte("new"))
+	if err != nil {
+		t.Fatalf("创建临时文件失败: %v", err)
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(oldPath, past, past); err != nil {
+		t.Fatalf("修改文件时间失败: %v", err)
+	}
+
+	if removed := manager.SweepOlderThan(time.Hour); removed != 1 {
+		t.Errorf("期望删除1个文件，实际删除 %d 个", removed)
+	}
+	if FileExists(oldPath) {
+		t.Errorf("过期文件未删除: %s", oldPath)
+	}
+	if !FileExists(newPath) {
+		t.Errorf("未过期的文件被删除: %s", newPath)
+	}
+	if count := manager.GetFileCount(); count != 1 {
+		t.Errorf("期望文件计数为1，实际为: %d", count)
+	}
+}
@@ -1,8 +1,10 @@
 package file
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"syscall"
 )
 
 // GetDirectoryFromPath 从文件路径中提取目录部分
@@ -27,3 +29,19 @@ func DirExists(path string) bool {
 	}
 	return info.IsDir()
 }
+
+// ProcessAlive 判断指定ID的进程是否仍在运行。无法确定时（例如无权向该进程发送信号）视为仍在运行
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	if pid == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || !(errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH))
+}
@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // ABTestCase A/B测试用例
@@ -60,6 +62,7 @@ type ABTestManager struct {
 	framework *ABTestFramework
 	suites    map[string]*ABTestSuite
 	config    *PDFServiceConfig
+	clock     clock.Clock // 套件时间和报告生成时间使用的时钟
 }
 
 // NewABTestManager 创建A/B测试管理器
@@ -68,6 +71,7 @@ func NewABTestManager(config *PDFServiceConfig, framework *ABTestFramework) *ABT
 		framework: framework,
 		suites:    make(map[string]*ABTestSuite),
 		config:    config,
+		clock:     clock.System(),
 	}
 }
 
@@ -77,8 +81,8 @@ func (m *ABTestManager) CreateTestSuite(id, name string) *ABTestSuite {
 		ID:       id,
 		Name:     name,
 		Cases:    make([]ABTestCase, 0),
-		Created:  time.Now(),
-		Modified: time.Now(),
+		Created:  m.clock.Now(),
+		Modified: m.clock.Now(),
 	}
 	m.suites[id] = suite
 	return suite
@@ -92,7 +96,7 @@ func (m *ABTestManager) AddTestCase(suiteID string, testCase ABTestCase) error {
 	}
 
 	suite.Cases = append(suite.Cases, testCase)
-	suite.Modified = time.Now()
+	suite.Modified = m.clock.Now()
 	return nil
 }
 
@@ -128,8 +132,8 @@ func (m *ABTestManager) GenerateStatistics() *ABTestStatistics {
 		TotalTests:    len(results),
 		CategoryStats: make(map[string]CategoryStat),
 		TimeRange: TimeRange{
-			Start: time.Now(),
-			End:   time.Now(),
+			Start: m.clock.Now(),
+			End:   m.clock.Now(),
 		},
 	}
 
@@ -277,7 +281,7 @@ func (m *ABTestManager) GenerateDetailedReport() string {
 	results := m.framework.GetResults()
 
 	report := "# A/B测试详细报告\n\n"
-	report += fmt.Sprintf("生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	report += fmt.Sprintf("生成时间: %s\n", m.clock.Now().Format("2006-01-02 15:04:05"))
 	report += fmt.Sprintf("测试时间范围: %s - %s\n",
 		stats.TimeRange.Start.Format("2006-01-02 15:04:05"),
 		stats.TimeRange.End.Format("2006-01-02 15:04:05"))
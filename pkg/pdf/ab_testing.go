@@ -8,6 +8,8 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // ABTestResult A/B测试结果
@@ -44,6 +46,7 @@ type ABTestFramework struct {
 	results    map[string]*ABTestComparison
 	mutex      sync.RWMutex
 	outputPath string
+	clock      clock.Clock // 测试时间和报告生成时间使用的时钟
 }
 
 // NewABTestFramework 创建A/B测试框架
@@ -52,6 +55,7 @@ func NewABTestFramework(config *PDFServiceConfig, outputPath string) *ABTestFram
 		config:     config,
 		results:    make(map[string]*ABTestComparison),
 		outputPath: outputPath,
+		clock:      clock.System(),
 	}
 }
 
@@ -60,7 +64,7 @@ func (f *ABTestFramework) RunABTest(testID, testName string, testFunc func() err
 	comparison := &ABTestComparison{
 		TestID:      testID,
 		TestName:    testName,
-		GeneratedAt: time.Now(),
+		GeneratedAt: f.clock.Now(),
 	}
 
 	// 测试pdfcpu
@@ -98,9 +102,9 @@ func (f *ABTestFramework) RunABTest(testID, testName string, testFunc func() err
 // runSingleTest 运行单个测试
 func (f *ABTestFramework) runSingleTest(engine string, testFunc func() error) (*ABTestResult, error) {
 	result := &ABTestResult{
-		TestID:    fmt.Sprintf("%s_%d", engine, time.Now().Unix()),
+		TestID:    fmt.Sprintf("%s_%d", engine, f.clock.Now().Unix()),
 		TestName:  engine,
-		StartTime: time.Now(),
+		StartTime: f.clock.Now(),
 	}
 
 	// 记录开始时的内存
@@ -110,7 +114,7 @@ func (f *ABTestFramework) runSingleTest(engine string, testFunc func() error) (*
 
 	// 执行测试
 	err := testFunc()
-	result.EndTime = time.Now()
+	result.EndTime = f.clock.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	result.Success = err == nil
 	if err != nil {
@@ -225,7 +229,7 @@ func (f *ABTestFramework) GenerateReport() string {
 	results := f.GetResults()
 
 	report := "# A/B测试报告\n\n"
-	report += fmt.Sprintf("生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	report += fmt.Sprintf("生成时间: %s\n", f.clock.Now().Format("2006-01-02 15:04:05"))
 	report += fmt.Sprintf("测试总数: %d\n\n", len(results))
 
 	totalTests := len(results)
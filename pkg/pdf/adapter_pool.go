@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultAdapterPoolSize 适配器池默认保留的空闲适配器数
+const DefaultAdapterPoolSize = 4
+
+// AdapterPoolStats 适配器池的计数，用于观察复用效果
+type AdapterPoolStats struct {
+	Created   int // 创建的适配器数
+	Reused    int // 复用空闲适配器的次数
+	Discarded int // 因状态异常或池已满而关闭的适配器数
+	Idle      int // 当前空闲的适配器数
+}
+
+// AdapterPool 复用同一配置的pdfcpu适配器，避免每次操作都重新创建适配器、检测pdfcpu命令行工具。
+// 取出的适配器由调用方独占使用，用完后放回；池最多保留size个空闲适配器，超出的直接关闭，
+// 因此取出时不会阻塞。放回时调用返回内部状态错误（适配器已关闭、后端不可用）的适配器会被丢弃，
+// 下次取出时重新创建。AdapterPool 可被多个协程同时使用。
+type AdapterPool struct {
+	config *PDFCPUConfig
+	size   int
+
+	mu     sync.Mutex
+	idle   []*PDFCPUAdapter
+	stats  AdapterPoolStats
+	closer closeGuard
+}
+
+// NewAdapterPool 创建使用config创建适配器的池，size为0时使用DefaultAdapterPoolSize；config为nil时使用默认配置
+func NewAdapterPool(size int, config *PDFCPUConfig) *AdapterPool {
+	if size <= 0 {
+		size = DefaultAdapterPoolSize
+	}
+	if config == nil {
+		config = DefaultPDFCPUConfig()
+	}
+	return &AdapterPool{config: config, size: size}
+}
+
+// Get 取出一个空闲适配器，没有空闲适配器时新建。池关闭后返回ErrClosed
+func (p *AdapterPool) Get() (*PDFCPUAdapter, error) {
+	if err := p.closer.enter("适配器池", ""); err != nil {
+		return nil, err
+	}
+	defer p.closer.leave()
+
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		adapter := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if adapter.healthy() {
+			p.stats.Reused++
+			p.mu.Unlock()
+			return adapter, nil
+		}
+		p.stats.Discarded++
+		adapter.Close()
+	}
+	p.mu.Unlock()
+
+	adapter, err := NewPDFCPUAdapter(p.config)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.stats.Created++
+	p.mu.Unlock()
+	return adapter, nil
+}
+
+// Put 放回Get取出的适配器。callErr为最近一次调用的错误，属于内部状态错误时丢弃该适配器；
+// 池已关闭或空闲适配器已满时关闭适配器
+func (p *AdapterPool) Put(adapter *PDFCPUAdapter, callErr error) {
+	if adapter == nil {
+		return
+	}
+	if p.closer.enter("适配器池", "") != nil {
+		adapter.Close()
+		return
+	}
+	defer p.closer.leave()
+
+	p.mu.Lock()
+	if isAdapterStateError(callErr) || !adapter.healthy() || len(p.idle) >= p.size {
+		p.stats.Discarded++
+		p.mu.Unlock()
+		adapter.Close()
+		return
+	}
+	p.idle = append(p.idle, adapter)
+	p.mu.Unlock()
+}
+
+// Do 取出适配器执行fn后放回，fn返回的错误同时用于健康检查
+func (p *AdapterPool) Do(fn func(adapter *PDFCPUAdapter) error) error {
+	adapter, err := p.Get()
+	if err != nil {
+		return err
+	}
+	err = fn(adapter)
+	p.Put(adapter, err)
+	return err
+}
+
+// Stats 返回池的计数
+func (p *AdapterPool) Stats() AdapterPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Idle = len(p.idle)
+	return stats
+}
+
+// Close 关闭池中的空闲适配器，之后的Get返回ErrClosed，放回的适配器直接关闭。
+// 重复调用返回首次关闭的结果
+func (p *AdapterPool) Close() error {
+	return p.closer.close(func() error {
+		p.mu.Lock()
+		idle := p.idle
+		p.idle = nil
+		p.mu.Unlock()
+
+		var firstErr error
+		for _, adapter := range idle {
+			if err := adapter.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// isAdapterStateError 判断错误是否说明适配器本身已不可用，而不是输入文件的问题
+func isAdapterStateError(err error) bool {
+	return err != nil && (errors.Is(err, ErrClosed) || AdapterUnavailableError(err) != nil)
+}
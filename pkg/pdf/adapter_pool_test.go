@@ -0,0 +1,159 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapterPool_ReusesAdapters(t *testing.T) {
+	pool := NewAdapterPool(2, &PDFCPUConfig{TempDirectory: t.TempDir()})
+	defer pool.Close()
+
+	first, err := pool.Get()
+	require.NoError(t, err)
+	pool.Put(first, nil)
+
+	second, err := pool.Get()
+	require.NoError(t, err)
+	assert.Same(t, first, second, "放回的适配器应被复用")
+	pool.Put(second, nil)
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.Created)
+	assert.Equal(t, 1, stats.Reused)
+	assert.Equal(t, 1, stats.Idle)
+}
+
+func TestAdapterPool_KeepsAtMostSizeIdle(t *testing.T) {
+	pool := NewAdapterPool(1, &PDFCPUConfig{TempDirectory: t.TempDir()})
+	defer pool.Close()
+
+	first, err := pool.Get()
+	require.NoError(t, err)
+	second, err := pool.Get()
+	require.NoError(t, err)
+	assert.NotSame(t, first, second, "没有空闲适配器时应新建而不是阻塞")
+
+	pool.Put(first, nil)
+	pool.Put(second, nil)
+	assert.Equal(t, 1, pool.Stats().Idle)
+	assert.Equal(t, 1, pool.Stats().Discarded)
+	assert.True(t, second.closer.isClosed(), "超出容量的适配器应被关闭")
+}
+
+func TestAdapterPool_RecreatesAfterStateError(t *testing.T) {
+	pool := NewAdapterPool(2, &PDFCPUConfig{TempDirectory: t.TempDir()})
+	defer pool.Close()
+
+	broken, err := pool.Get()
+	require.NoError(t, err)
+	pool.Put(broken, newAdapterUnavailableError(errors.New("后端不可用"), nil))
+	assert.True(t, broken.closer.isClosed(), "返回内部状态错误的适配器应被丢弃")
+
+	// 输入文件本身的错误不影响复用
+	healthy, err := pool.Get()
+	require.NoError(t, err)
+	assert.NotSame(t, broken, healthy)
+	pool.Put(healthy, &PDFError{Type: ErrorCorrupted, Message: "输入损坏"})
+
+	// 在池外被关闭的空闲适配器在取出时重新创建
+	healthy.Close()
+	replacement, err := pool.Get()
+	require.NoError(t, err)
+	assert.NotSame(t, healthy, replacement)
+	pool.Put(replacement, nil)
+
+	stats := pool.Stats()
+	assert.Equal(t, 3, stats.Created)
+	assert.Equal(t, 2, stats.Discarded)
+}
+
+func TestAdapterPool_ConcurrentUse(t *testing.T) {
+	dir := t.TempDir()
+	file := createTestPDFFile(t, dir, "in.pdf")
+	pool := NewAdapterPool(2, &PDFCPUConfig{TempDirectory: t.TempDir()})
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				err := pool.Do(func(adapter *PDFCPUAdapter) error {
+					_, err := adapter.GetFileInfo(file)
+					return err
+				})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := pool.Stats()
+	assert.LessOrEqual(t, stats.Idle, 2)
+	assert.Equal(t, 40, stats.Created+stats.Reused)
+}
+
+func TestPDFService_ReusesPooledAdapter(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = t.TempDir()
+	config.InfoCacheSize = -1
+	service := NewPDFServiceWithConfig(config).(*PDFServiceImpl)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.GetPDFInfo(path)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, service.adapters.Stats().Created, "连续获取信息应复用同一个适配器")
+}
+
+func TestAdapterPool_DiscardKeepsSiblingTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := createTestPDFFile(t, dir, "in.pdf")
+	pool := NewAdapterPool(1, &PDFCPUConfig{TempDirectory: t.TempDir()})
+	defer pool.Close()
+
+	busy, err := pool.Get()
+	require.NoError(t, err)
+	discarded, err := pool.Get()
+	require.NoError(t, err)
+	require.NotEqual(t, busy.tempDir, discarded.tempDir, "每个适配器应使用自己的临时目录")
+
+	// busy 写出中间文件后等待另一个适配器被丢弃，再继续使用中间文件
+	written := make(chan string)
+	resume := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		intermediate, err := os.CreateTemp(busy.tempDir, "stamp-*.pdf")
+		if err != nil {
+			done <- err
+			return
+		}
+		intermediate.Close()
+		written <- intermediate.Name()
+		<-resume
+		if _, err := os.Stat(intermediate.Name()); err != nil {
+			done <- err
+			return
+		}
+		_, err = busy.GetFileInfo(file)
+		done <- err
+	}()
+
+	intermediate := <-written
+	pool.Put(discarded, newAdapterUnavailableError(errors.New("后端不可用"), nil))
+	assert.NoDirExists(t, discarded.tempDir, "丢弃的适配器应删除自己的临时目录")
+	close(resume)
+	require.NoError(t, <-done, "丢弃其他适配器不应删除仍在使用的中间文件")
+	assert.FileExists(t, intermediate)
+	pool.Put(busy, nil)
+}
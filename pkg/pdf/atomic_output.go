@@ -0,0 +1,133 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// stagingPath 返回与输出文件同目录的临时路径。合并结果先写到这里，验证通过后再
+// rename 到输出路径；同目录保证 rename 不跨文件系统。
+func stagingPath(outputPath string, clk clock.Clock) string {
+	return generateTempPath(outputPath, filepath.Dir(outputPath), clk)
+}
+
+// commitOutput 用已验证的临时文件原子地替换输出文件，失败时删除临时文件，原输出保持不变。
+// 后端没有生成临时文件时（占位实现只写出标记文件）没有可替换的内容，原输出同样保持不变。
+func commitOutput(staging, outputPath string) error {
+	if !fileExists(staging) {
+		return nil
+	}
+	if err := os.Rename(staging, outputPath); err != nil {
+		discardStaging(staging)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法移动临时文件到最终位置",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// commitMergedOutput 备份已存在的输出（启用BackupOutput时），再提交已验证的临时输出，
+// 并把MergeResult.OutputPath设为提交后的位置。返回之后统计页数应读取的本地文件；
+// 远程输出提交后本地没有输出文件，因此在提交前统计临时文件的页数并返回空字符串
+func (sm *StreamingMerger) commitMergedOutput(result *MergeResult, staging, outputPath string) (string, error) {
+	if sm.outputBackend == nil {
+		sm.backupExistingOutput(result, outputPath)
+		return outputPath, commitOutput(staging, outputPath)
+	}
+
+	_, remote := sm.outputBackend.(RemoteBackend)
+	if !remote {
+		sm.backupExistingOutput(result, outputPath)
+	}
+	if !fileExists(staging) {
+		// 占位实现没有生成临时文件，没有可提交的内容
+		return outputPath, nil
+	}
+	if remote {
+		sm.countOutputPages(result, staging)
+	}
+	if err := sm.outputBackend.Promote(staging, outputPath); err != nil {
+		discardStaging(staging)
+		return "", err
+	}
+	result.OutputPath = outputLocation(sm.outputBackend, outputPath)
+	if remote {
+		return "", nil
+	}
+	return outputPath, nil
+}
+
+// runInterruptible 执行无法中途中断的写入：work写入outputPath旁的临时文件，成功后才替换outputPath。
+// ctx取消时立即返回ctx.Err()而不等待work结束，work结束后删除它写出的临时文件，outputPath保持不变。
+// ctx永远不会取消时work直接写入outputPath。
+func runInterruptible(ctx context.Context, outputPath string, work func(tempPath string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return work(outputPath)
+	}
+
+	tempPath := generateTempPath(outputPath, filepath.Dir(outputPath), clock.OrSystem(nil))
+	done := make(chan error, 1)
+	go func() {
+		done <- work(tempPath)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			os.Remove(tempPath)
+			return err
+		}
+		if ctx.Err() != nil {
+			os.Remove(tempPath)
+			return ctx.Err()
+		}
+		if err := os.Rename(tempPath, outputPath); err != nil {
+			os.Remove(tempPath)
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: "无法移动临时文件到最终位置",
+				File:    outputPath,
+				Cause:   err,
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		// 放弃仍在进行的写入，结束后丢弃其结果
+		go func() {
+			<-done
+			os.Remove(tempPath)
+		}()
+		return ctx.Err()
+	}
+}
+
+// discardStaging 删除临时输出以及占位符后端在其旁边留下的文件
+func discardStaging(staging string) {
+	for _, path := range []string{staging, staging + ".fallback", staging + ".placeholder"} {
+		os.Remove(path)
+	}
+}
+
+// backupExistingOutput 在启用MergeOptions.BackupOutput且输出已存在时，于替换前在备份目录保留一份备份，
+// 并按保留数清理该输出较旧的备份；备份失败只记为警告
+func (sm *StreamingMerger) backupExistingOutput(result *MergeResult, outputPath string) {
+	if !sm.keepBackup || !fileExists(outputPath) {
+		return
+	}
+	backupPath, err := NewRollbackManagerWithConfig(filepath.Dir(outputPath), sm.backupConfig).BackupFile(outputPath)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("备份输出文件失败: %v", err))
+		return
+	}
+	result.BackupPath = backupPath
+}
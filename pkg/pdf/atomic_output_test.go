@@ -0,0 +1,187 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertNoStaging 检查输出目录中没有遗留的临时输出
+func assertNoStaging(t *testing.T, dir string) {
+	t.Helper()
+	leftovers, err := filepath.Glob(filepath.Join(dir, "out_temp_*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "不应遗留临时输出")
+}
+
+func TestMergeStreaming_FailureLeavesExistingOutput(t *testing.T) {
+	dir := t.TempDir()
+	first := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+	// 使用对象流的文件无法由内置合并处理
+	second := createTestFile(t, dir, "b.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /ObjStm /N 0 /First 0 /Length 0 >>\nstream\n\nendstream",
+	}))
+	output := createTestFile(t, dir, "out.pdf", []byte("previous result"))
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeStreaming(context.Background(), []string{first, second}, output, nil)
+	require.Error(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, MergeStageMerging, result.FailedStage)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous result", string(data), "失败时原输出应保持不变")
+	assertNoStaging(t, dir)
+	assert.False(t, fileExists(filepath.Join(dir, DefaultBackupDirectory)), "未启用BackupOutput时不应生成备份")
+}
+
+func TestMergeStreaming_ReplacesOutputWithoutBackup(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	output := createTestFile(t, dir, "out.pdf", []byte("previous result"))
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeStreaming(context.Background(), []string{input}, output, nil)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, buildFlatPDF(2), data)
+	assert.Empty(t, result.BackupPath)
+	assert.False(t, fileExists(filepath.Join(dir, DefaultBackupDirectory)))
+	assertNoStaging(t, dir)
+}
+
+func TestMergeFiles_BackupOutputOptIn(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+	output := createTestFile(t, dir, "out.pdf", []byte("previous result"))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: t.TempDir(),
+		BackendStats:  NewBackendStatsStore(),
+		BackupOutput:  true,
+	})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{input}, output, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, DefaultBackupDirectory), filepath.Dir(result.BackupPath), "备份应保存在输出旁的备份目录")
+	backup, err := os.ReadFile(result.BackupPath)
+	require.NoError(t, err)
+	assert.Equal(t, "previous result", string(backup), "备份应为被替换的上一版输出")
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, buildFlatPDF(1), data)
+	assertNoStaging(t, dir)
+}
+
+func TestCommitOutput_FailureDiscardsStaging(t *testing.T) {
+	dir := t.TempDir()
+	staging := createTestFile(t, dir, "out_temp_1_abcd.pdf", buildFlatPDF(1))
+	createTestFile(t, dir, "out_temp_1_abcd.pdf.fallback", []byte("placeholder"))
+
+	err := commitOutput(staging, filepath.Join(dir, "missing", "out.pdf"))
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorIO, pdfErr.Type)
+	assertNoStaging(t, dir)
+}
+
+func TestMergeStreaming_CancelDuringMergeLeavesOutputUntouched(t *testing.T) {
+	// 内置合并在取消后仍会运行完，模拟一个无法中断的大型合并
+	started := make(chan struct{}, 1)
+	finished := make(chan struct{}, 1)
+	original := concatenateInputs
+	concatenateInputs = func(files []string, outputPath string) error {
+		started <- struct{}{}
+		defer func() { finished <- struct{}{} }()
+		time.Sleep(1500 * time.Millisecond)
+		return original(files, outputPath)
+	}
+	defer func() { concatenateInputs = original }()
+
+	for _, existing := range []bool{true, false} {
+		dir := t.TempDir()
+		inputs := []string{
+			createTestFile(t, dir, "a.pdf", buildFlatPDF(2)),
+			createTestFile(t, dir, "b.pdf", buildFlatPDF(3)),
+		}
+		output := filepath.Join(dir, "out.pdf")
+		if existing {
+			createTestFile(t, dir, "out.pdf", []byte("previous result"))
+		}
+
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), BackendStats: NewBackendStatsStore()})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelledAt := make(chan time.Time, 1)
+		go func() {
+			// 合并开始200ms后取消
+			<-started
+			time.Sleep(200 * time.Millisecond)
+			cancelledAt <- time.Now()
+			cancel()
+		}()
+
+		_, err := merger.MergeStreaming(ctx, inputs, output, nil)
+		returned := time.Now()
+		cancel()
+		require.True(t, errors.Is(err, context.Canceled), "期望返回取消错误，实际 %v", err)
+		assert.Less(t, returned.Sub(<-cancelledAt), time.Second, "取消后应尽快返回")
+
+		// 等待被放弃的合并结束，它的结果应被丢弃
+		<-finished
+		time.Sleep(100 * time.Millisecond)
+		if existing {
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.Equal(t, "previous result", string(data), "取消后原输出应保持不变")
+		} else {
+			assert.NoFileExists(t, output, "取消后不应创建输出")
+		}
+		assertNoStaging(t, dir)
+		merger.Close()
+	}
+}
+
+func TestPDFService_BackupOutputCanBeRestored(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(2))
+	output := createTestFile(t, dir, "out.pdf", []byte("previous result"))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = t.TempDir()
+	config.BackupOutput = true
+	service := NewPDFServiceWithConfig(config)
+
+	var progress bytes.Buffer
+	require.NoError(t, service.MergePDFs(a, []string{b}, output, &progress))
+	assert.Contains(t, progress.String(), "已备份原输出")
+
+	rollback := NewRollbackManagerWithConfig(dir, DefaultRollbackConfig())
+	backups, err := rollback.ListBackups(output)
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, int64(len("previous result")), backups[0].Size)
+
+	restored, err := rollback.RestoreLatest(output)
+	require.NoError(t, err)
+	assert.Equal(t, backups[0].Path, restored.Path)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous result", string(data), "撤销合并应恢复上一版输出")
+}
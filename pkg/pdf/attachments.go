@@ -0,0 +1,441 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxNameTreeNodes 遍历名称树时访问的节点数上限，防止损坏或恶意的循环引用
+const maxNameTreeNodes = 10000
+
+var (
+	documentTypePattern = regexp.MustCompile(`/Type\s*/(Catalog|Pages)\b`)
+	fileSpecNamePattern = regexp.MustCompile(`/(UF|F)\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+	paramsSizePattern   = regexp.MustCompile(`/Size\s+(\d+)`)
+)
+
+// AttachmentInfo 文件 /Names /EmbeddedFiles 名称树中的一个附件
+type AttachmentInfo struct {
+	Name        string `json:"name"`                  // 名称树中的键
+	FileName    string `json:"file_name,omitempty"`   // 文件说明字典中的 /UF，没有时为 /F
+	Description string `json:"description,omitempty"` // 文件说明字典中的 /Desc
+	Size        int64  `json:"size"`                  // 嵌入文件 /Params 中的 /Size，没有时为流数据的长度
+}
+
+// AttachmentMergeReport 合并附件的结果
+type AttachmentMergeReport struct {
+	Count   int                // 输出中的附件数量
+	Renamed []AttachmentRename // 因重名而改名的附件，按合并顺序排列
+}
+
+// AttachmentRename 一个因与之前的附件重名而改名的附件
+type AttachmentRename struct {
+	File string // 附件所在的输入
+	From string // 原名称
+	To   string // 输出中的名称
+}
+
+// embeddedFile 名称树中的一项，ref 为文件说明字典的对象编号
+type embeddedFile struct {
+	name string
+	ref  int
+}
+
+// ListAttachments 不依赖pdfcpu读取文件的附件，包括对象流中的名称树和文件说明字典；
+// 页面上的文件附件注释不在名称树中，不会列出
+func ListAttachments(filePath string) ([]AttachmentInfo, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	bodies := objectBodies(data)
+	files := readEmbeddedFiles(data, bodies)
+	attachments := make([]AttachmentInfo, 0, len(files))
+	for _, file := range files {
+		attachments = append(attachments, describeAttachment(bodies, file))
+	}
+	return attachments, nil
+}
+
+// describeAttachment 读取文件说明字典中的文件名、说明和嵌入文件的大小
+func describeAttachment(bodies map[int][]byte, file embeddedFile) AttachmentInfo {
+	info := AttachmentInfo{Name: file.name}
+	spec, ok := bodies[file.ref]
+	if !ok {
+		return info
+	}
+	top := topLevelOnly(spec)
+	for _, m := range fileSpecNamePattern.FindAllSubmatch(top, -1) {
+		if name := decodePDFString(m[2]); name != "" && (info.FileName == "" || string(m[1]) == "UF") {
+			info.FileName = name
+		}
+	}
+	if idx := bytes.Index(top, []byte("/Desc")); idx >= 0 {
+		info.Description = strings.TrimSpace(decodePDFString(bytes.TrimLeft(top[idx+len("/Desc"):], " \t\r\n")))
+	}
+
+	lookup := func(num int) ([]byte, bool) {
+		body, ok := bodies[num]
+		return body, ok
+	}
+	ef := resolveDictWith(spec, "/EF", lookup)
+	stream := resolveDictWith(ef, "/F", lookup)
+	if stream == nil {
+		return info
+	}
+	dict, raw := stream, []byte(nil)
+	if loc := streamKeyword.FindIndex(stream); loc != nil {
+		dict, raw = stream[:loc[0]+2], stream[loc[1]:]
+		if end := bytes.LastIndex(raw, []byte("endstream")); end >= 0 {
+			raw = bytes.TrimRight(raw[:end], "\r\n")
+		}
+	}
+	if params := resolveDictWith(dict, "/Params", lookup); params != nil {
+		if m := paramsSizePattern.FindSubmatch(params); m != nil {
+			info.Size, _ = strconv.ParseInt(string(m[1]), 10, 64)
+			return info
+		}
+	}
+	if decoded, err := decodeStreamData(dict, raw); err == nil {
+		info.Size = int64(len(decoded))
+	}
+	return info
+}
+
+// readEmbeddedFiles 按名称树顺序返回目录 /Names /EmbeddedFiles 中的附件，没有附件或无法解析时返回nil
+func readEmbeddedFiles(data []byte, bodies map[int][]byte) []embeddedFile {
+	lookup := func(num int) ([]byte, bool) {
+		body, ok := bodies[num]
+		return body, ok
+	}
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return nil
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	catalog, ok := bodies[catalogNum]
+	if !ok {
+		return nil
+	}
+	names := resolveDictWith(topLevelDict(catalog), "/Names", lookup)
+	root := resolveDictWith(names, "/EmbeddedFiles", lookup)
+	if root == nil {
+		return nil
+	}
+
+	var files []embeddedFile
+	visited := make(map[int]bool)
+	var walk func(node []byte)
+	walk = func(node []byte) {
+		top := topLevelOnly(node)
+		if idx := bytes.Index(top, []byte("/Names")); idx >= 0 {
+			files = append(files, parseNameTreeLeaves(top[idx+len("/Names"):])...)
+		}
+		idx := bytes.Index(top, []byte("/Kids"))
+		if idx < 0 {
+			return
+		}
+		for _, kid := range parseArrayRefs(bytes.TrimLeft(top[idx+len("/Kids"):], " \t\r\n")) {
+			if visited[kid] || len(visited) >= maxNameTreeNodes {
+				continue
+			}
+			visited[kid] = true
+			if body, ok := bodies[kid]; ok {
+				walk(body)
+			}
+		}
+	}
+	walk(root)
+	return files
+}
+
+// topLevelDict 返回对象开头的字典（不含其后的流数据）
+func topLevelDict(body []byte) []byte {
+	start := bytes.Index(body, []byte("<<"))
+	if start < 0 {
+		return body
+	}
+	return body[start:skipDictionary(body, start)]
+}
+
+// parseNameTreeLeaves 解析名称树叶子节点 /Names 数组中的 键 值 对，只保留值为间接引用的项
+func parseNameTreeLeaves(raw []byte) []embeddedFile {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	if !bytes.HasPrefix(raw, []byte("[")) {
+		return nil
+	}
+
+	var files []embeddedFile
+	key, hasKey := "", false
+	for i := 1; i < len(raw); {
+		switch c := raw[i]; {
+		case c == ']':
+			return files
+		case c == '(':
+			end := skipLiteralString(raw, i)
+			key, hasKey = decodePDFString(raw[i:end]), true
+			i = end
+		case c == '<' && i+1 < len(raw) && raw[i+1] == '<':
+			i = skipDictionary(raw, i)
+			hasKey = false
+		case c == '<':
+			end := bytes.IndexByte(raw[i:], '>')
+			if end < 0 {
+				return files
+			}
+			key, hasKey = decodePDFString(raw[i:i+end+1]), true
+			i += end + 1
+		case c >= '0' && c <= '9':
+			if m := refPattern.FindSubmatch(raw[i:]); m != nil && hasKey {
+				num, _ := strconv.Atoi(string(m[1]))
+				files = append(files, embeddedFile{name: key, ref: num})
+				i += len(m[0])
+				hasKey = false
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return files
+}
+
+// MergeAttachments 以增量更新把inputs中的附件按输入顺序合并为outputPath的 /EmbeddedFiles 名称树，
+// 替换输出中已有的附件（合并后端可能只保留了部分输入的附件）。与之前的附件重名的附件改名，
+// 例如 file.txt → file_2.txt；附件引用的对象连同嵌入文件一起复制，指向页面或文档结构的引用改为null。
+// 加密的输入无法读取附件，被跳过（合并器传入的是解密副本）
+func MergeAttachments(outputPath string, inputs []string) (*AttachmentMergeReport, error) {
+	report := &AttachmentMergeReport{}
+	type copied struct {
+		name string
+		ref  int
+	}
+	var merged []copied
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	update := newIncrementalUpdate(data, indexObjects(data))
+
+	used := make(map[string]bool)
+	for _, input := range inputs {
+		inputData, err := os.ReadFile(input)
+		if err != nil {
+			return nil, &PDFError{
+				Type:    ErrorIO,
+				Message: "无法读取PDF文件",
+				File:    input,
+				Cause:   err,
+			}
+		}
+		if encrypted, _ := hasEncryptEntry(input); encrypted {
+			continue
+		}
+		bodies := objectBodies(inputData)
+		for _, file := range readEmbeddedFiles(inputData, bodies) {
+			if _, ok := bodies[file.ref]; !ok {
+				continue
+			}
+			name := uniqueAttachmentName(file.name, used)
+			ref := copyObjectGraph(update, bodies, file.ref)
+			if name != file.name {
+				report.Renamed = append(report.Renamed, AttachmentRename{File: input, From: file.name, To: name})
+				update.set(ref, renamedFileSpec(update.objects[ref], name))
+			}
+			merged = append(merged, copied{name: name, ref: ref})
+		}
+	}
+	report.Count = len(merged)
+
+	if len(merged) == 0 {
+		if _, err := setEmbeddedFiles(update, outputPath, ""); err != nil {
+			return nil, err
+		}
+		return report, writeIncrementalUpdate(update, outputPath, "无法写入附件")
+	}
+
+	// 名称树的键必须按字节顺序排列；键统一写为UTF-16BE文本字符串，按编码后的十六进制排序即为码元顺序
+	sort.SliceStable(merged, func(i, j int) bool { return pdfTextString(merged[i].name) < pdfTextString(merged[j].name) })
+	entries := make([]string, len(merged))
+	for i, item := range merged {
+		entries[i] = fmt.Sprintf("%s %d 0 R", pdfTextString(item.name), item.ref)
+	}
+	tree := update.add("<< /Names [" + strings.Join(entries, " ") + "] >>")
+	if _, err := setEmbeddedFiles(update, outputPath, fmt.Sprintf("%d 0 R", tree)); err != nil {
+		return nil, err
+	}
+	return report, writeIncrementalUpdate(update, outputPath, "无法写入附件")
+}
+
+// RemoveAttachments 以增量更新从filePath的 /Names 中移除 /EmbeddedFiles，返回移除的附件数量
+func RemoveAttachments(filePath string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	count := len(readEmbeddedFiles(data, objectBodies(data)))
+	if count == 0 {
+		return 0, nil
+	}
+	update := newIncrementalUpdate(data, indexObjects(data))
+	if _, err := setEmbeddedFiles(update, filePath, ""); err != nil {
+		return 0, err
+	}
+	return count, writeIncrementalUpdate(update, filePath, "无法移除附件")
+}
+
+// setEmbeddedFiles 把目录 /Names 中的 /EmbeddedFiles 设为value（间接引用），value为空时移除该项。
+// /Names 以新对象写入，其中的其他名称树保持不变；返回是否修改了目录
+func setEmbeddedFiles(update *incrementalUpdate, filePath, value string) (bool, error) {
+	data := update.data
+	bodies := objectBodies(data)
+	lookup := func(num int) ([]byte, bool) {
+		body, ok := bodies[num]
+		return body, ok
+	}
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return false, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "找不到文档目录",
+			File:    filePath,
+		}
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	body, ok := bodies[catalogNum]
+	if !ok {
+		return false, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("文档目录对象 %d 不存在", catalogNum),
+			File:    filePath,
+		}
+	}
+	catalog := string(bytes.TrimSpace(topLevelDict(body)))
+
+	names := string(bytes.TrimSpace(resolveDictWith([]byte(catalog), "/Names", lookup)))
+	if !strings.HasPrefix(names, "<<") {
+		names = "<< >>"
+	}
+	if value == "" {
+		if !strings.Contains(names, "/EmbeddedFiles") {
+			return false, nil
+		}
+		names = withoutEntry(names, "/EmbeddedFiles")
+	} else {
+		names = withEntry(names, "/EmbeddedFiles", value)
+	}
+	update.set(catalogNum, withEntry(catalog, "/Names", fmt.Sprintf("%d 0 R", update.add(names))))
+	return true, nil
+}
+
+// writeIncrementalUpdate 把增量更新写入临时文件后替换filePath，没有修改时不写入
+func writeIncrementalUpdate(update *incrementalUpdate, filePath, message string) error {
+	if len(update.objects) == 0 {
+		return nil
+	}
+	tempPath := filePath + ".attachments.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: message,
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// copyObjectGraph 把root及其引用的对象复制为update中的新对象，返回root的新编号。
+// 页面、页面树和目录不会被复制，指向它们以及不存在的对象的引用改为null
+func copyObjectGraph(update *incrementalUpdate, bodies map[int][]byte, root int) int {
+	renumbered := make(map[int]int)
+	queue := []int{root}
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		if _, ok := renumbered[num]; ok {
+			continue
+		}
+		body, ok := bodies[num]
+		if !ok {
+			continue
+		}
+		head := topLevelDict(body)
+		if num != root && (pageTypePattern.Match(head) || documentTypePattern.Match(head)) {
+			continue
+		}
+		renumbered[num] = update.add("")
+		for _, m := range anyRefPattern.FindAllSubmatch(head, -1) {
+			ref, _ := strconv.Atoi(string(m[1]))
+			queue = append(queue, ref)
+		}
+	}
+	for num, newNum := range renumbered {
+		update.set(newNum, renumberRefs(string(bytes.TrimSpace(bodies[num])), renumbered))
+	}
+	return renumbered[root]
+}
+
+// renamedFileSpec 把文件说明字典中的 /UF 和 /F 改为name
+func renamedFileSpec(spec, name string) string {
+	return fileSpecNamePattern.ReplaceAllStringFunc(spec, func(entry string) string {
+		key := fileSpecNamePattern.FindStringSubmatch(entry)[1]
+		return "/" + key + " " + pdfTextString(name)
+	})
+}
+
+// uniqueAttachmentName 返回尚未使用的附件名称：重名时在扩展名前依次加 _2、_3……，used 记录已使用的名称
+func uniqueAttachmentName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// withoutEntry 从字典中移除key及其值，key不存在时原样返回
+func withoutEntry(dict, key string) string {
+	idx := strings.Index(dict, key)
+	if idx < 0 {
+		return dict
+	}
+	existing := directValue([]byte(dict[idx:]), key)
+	end := idx + len(key) + strings.Index(dict[idx+len(key):], existing) + len(existing)
+	return dict[:idx] + strings.TrimLeft(dict[end:], " \t\r\n")
+}
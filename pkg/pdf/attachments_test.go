@@ -0,0 +1,150 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAttachmentPDF 写出单页测试文件，/Names /EmbeddedFiles 中依次包含names对应的附件，
+// 附件内容为 "content of <name>"
+func writeAttachmentPDF(t *testing.T, dir, fileName string, names ...string) string {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Names 4 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"",
+	}
+	entries := ""
+	for _, name := range names {
+		content := "content of " + name
+		specNum := len(objects) + 1
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Filespec /F (%s) /UF (%s) /Desc (about %s) /EF << /F %d 0 R >> >>", name, name, name, specNum+1),
+			fmt.Sprintf("<< /Type /EmbeddedFile /Params << /Size %d >> /Length %d >>\nstream\n%s\nendstream",
+				len(content), len(content), content))
+		entries += fmt.Sprintf(" (%s) %d 0 R", name, specNum)
+	}
+	objects[3] = "<< /EmbeddedFiles << /Names [" + entries + " ] >> >>"
+	return createTestFile(t, dir, fileName, buildPDF(objects))
+}
+
+func TestListAttachments(t *testing.T) {
+	dir := t.TempDir()
+	input := writeAttachmentPDF(t, dir, "a.pdf", "report.csv", "notes.txt")
+
+	attachments, err := ListAttachments(input)
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	assert.Equal(t, AttachmentInfo{
+		Name:        "report.csv",
+		FileName:    "report.csv",
+		Description: "about report.csv",
+		Size:        int64(len("content of report.csv")),
+	}, attachments[0])
+	assert.Equal(t, "notes.txt", attachments[1].Name)
+
+	// 测试套件中的附件夹具：没有 /Params 时大小取流数据的长度
+	fixture := createTestFile(t, dir, "fixture.pdf", []byte(createPDFWithAttachments("1.4")))
+	attachments, err = ListAttachments(fixture)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "attachment.txt", attachments[0].Name)
+	assert.Equal(t, "attachment.txt", attachments[0].FileName)
+	assert.Positive(t, attachments[0].Size)
+
+	attachments, err = ListAttachments(createTestFile(t, dir, "plain.pdf", buildFlatPDF(1)))
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestParseNameTreeLeaves(t *testing.T) {
+	leaves := parseNameTreeLeaves([]byte(`[(a\)b) 5 0 R <FEFF00E9> 6 0 R (inline) << /F (x) >> (c) 7 0 R]`))
+	assert.Equal(t, []embeddedFile{{name: "a)b", ref: 5}, {name: "é", ref: 6}, {name: "c", ref: 7}}, leaves)
+}
+
+func TestUniqueAttachmentName(t *testing.T) {
+	used := make(map[string]bool)
+	assert.Equal(t, "file.txt", uniqueAttachmentName("file.txt", used))
+	assert.Equal(t, "file_2.txt", uniqueAttachmentName("file.txt", used))
+	assert.Equal(t, "file_3.txt", uniqueAttachmentName("file.txt", used))
+	assert.Equal(t, "README", uniqueAttachmentName("README", used))
+	assert.Equal(t, "README_2", uniqueAttachmentName("README", used))
+}
+
+func TestMergeFiles_PreservesAttachments(t *testing.T) {
+	dir := t.TempDir()
+	a := writeAttachmentPDF(t, dir, "a.pdf", "file.txt", "a-only.txt")
+	b := writeAttachmentPDF(t, dir, "b.pdf", "file.txt")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Attachments)
+	assert.Contains(t, result.Warnings, fmt.Sprintf("输入 %s 的附件 file.txt 与之前的附件重名，已改名为 file_2.txt", b))
+
+	attachments, err := ListAttachments(output)
+	require.NoError(t, err)
+	require.Len(t, attachments, 3)
+	byName := make(map[string]AttachmentInfo)
+	for _, attachment := range attachments {
+		byName[attachment.Name] = attachment
+	}
+	assert.Equal(t, "file.txt", byName["file.txt"].FileName)
+	assert.Equal(t, "file_2.txt", byName["file_2.txt"].FileName, "改名后文件说明中的文件名也应更新")
+	assert.Equal(t, int64(len("content of file.txt")), byName["file_2.txt"].Size)
+	assert.Contains(t, byName, "a-only.txt")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	count, err := catalogPageCount(data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	info, err := NewPDFService().GetPDFInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.AttachmentCount)
+}
+
+func TestMergeFiles_DropAttachments(t *testing.T) {
+	dir := t.TempDir()
+	a := writeAttachmentPDF(t, dir, "a.pdf", "file.txt")
+	b := writeAttachmentPDF(t, dir, "b.pdf", "other.txt")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:   dir,
+		BackendStats:    NewBackendStatsStore(),
+		DropAttachments: true,
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Zero(t, result.Attachments)
+
+	attachments, err := ListAttachments(output)
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestRemoveAttachments(t *testing.T) {
+	dir := t.TempDir()
+	input := writeAttachmentPDF(t, dir, "a.pdf", "file.txt", "other.txt")
+
+	removed, err := RemoveAttachments(input)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	attachments, err := ListAttachments(input)
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+
+	pages, err := CountPagesInFile(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+}
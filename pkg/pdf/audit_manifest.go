@@ -0,0 +1,522 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// ManifestSuffix 合并清单旁路文件后缀，如 merged.pdf.manifest.json
+const ManifestSuffix = ".manifest.json"
+
+// ManifestAttachmentName 嵌入输出的合并清单附件名称，与已有附件重名时改名
+const ManifestAttachmentName = "merge-manifest.json"
+
+// manifestTool 清单中记录的工具名称
+const manifestTool = "pdf-merger"
+
+// AuditManifest 审计用的合并清单：工具版本、时间、合并选项，以及各输入和输出的大小、SHA-256和页数。
+// 嵌入输出的副本在计算输出摘要之前写入，因此没有Output；旁路文件包含最终输出的摘要
+type AuditManifest struct {
+	Tool        string          `json:"tool"`
+	ToolVersion string          `json:"tool_version"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Options     ManifestOptions `json:"options"`
+	Inputs      []ManifestInput `json:"inputs"`
+	Output      *ManifestOutput `json:"output,omitempty"`
+	// ReviewCopyPath 启用ReviewCopy时在输出旁生成的审阅副本，没有时为空
+	ReviewCopyPath string `json:"review_copy_path,omitempty"`
+}
+
+// ManifestInput 清单中的一个输入
+type ManifestInput struct {
+	Path        string `json:"path"`
+	RawPath     string `json:"raw_path"`     // 原始路径的转义形式，非UTF-8字节写作\xNN
+	DisplayPath string `json:"display_path"` // 用于显示的路径，非UTF-8文件名按回退编码解码
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Pages       int    `json:"pages"`
+	// PageOffset 输出中位于该输入之前的页数（包括目录页），该输入的第一页是输出的第 PageOffset+1 页
+	PageOffset int `json:"page_offset"`
+}
+
+// ManifestOutput 清单中的输出
+type ManifestOutput struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Pages  int    `json:"pages"`
+}
+
+// ManifestOptions 清单中记录的合并选项。只记录影响输出内容的选项，不记录密码
+type ManifestOptions struct {
+	VerifyChecksums      bool           `json:"verify_checksums,omitempty"`
+	TryRepair            bool           `json:"try_repair,omitempty"`
+	AllowDuplicates      bool           `json:"allow_duplicates,omitempty"`
+	Rotations            map[string]int `json:"rotations,omitempty"`
+	NormalizeOrientation bool           `json:"normalize_orientation,omitempty"`
+	DropAttachments      bool           `json:"drop_attachments,omitempty"`
+	FlattenForms         bool           `json:"flatten_forms,omitempty"`
+	SourceBookmarks      bool           `json:"source_bookmarks,omitempty"`
+	GenerateTOC          bool           `json:"generate_toc,omitempty"`
+	Stamps               []string       `json:"stamps,omitempty"` // 各印章的文字模板
+	NUp                  int            `json:"nup,omitempty"`
+	Booklet              bool           `json:"booklet,omitempty"`
+	Optimize             bool           `json:"optimize,omitempty"`
+	OptimizeImagesDPI    int            `json:"optimize_images_dpi,omitempty"`
+	Encrypted            bool           `json:"encrypted,omitempty"`
+	Linearize            bool           `json:"linearize,omitempty"`
+	MaxOutputSizeBytes   int64          `json:"max_output_size_bytes,omitempty"`
+	MaxOutputPages       int            `json:"max_output_pages,omitempty"`
+}
+
+// ManifestVerification 按旁路清单校验输出的结果
+type ManifestVerification struct {
+	OutputPath     string `json:"output_path"`
+	ManifestPath   string `json:"manifest_path"`
+	ExpectedSHA256 string `json:"expected_sha256"`
+	ActualSHA256   string `json:"actual_sha256"`
+	ExpectedSize   int64  `json:"expected_size"`
+	ActualSize     int64  `json:"actual_size"`
+	Match          bool   `json:"match"`
+	Inputs         int    `json:"inputs"` // 清单记录的输入数量
+}
+
+// ReadManifest 读取输出旁的合并清单旁路文件
+func ReadManifest(outputPath string) (*AuditManifest, error) {
+	manifestPath := outputPath + ManifestSuffix
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取合并清单",
+			File:    manifestPath,
+			Cause:   err,
+		}
+	}
+	var manifest AuditManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "合并清单格式无效",
+			File:    manifestPath,
+			Cause:   err,
+		}
+	}
+	return &manifest, nil
+}
+
+// VerifyManifest 重新计算outputPath的SHA-256，与旁路清单中记录的输出摘要比对。
+// 清单不存在、无法解析或没有输出摘要时返回错误；内容不一致时返回Match为false的结果
+func VerifyManifest(outputPath string) (*ManifestVerification, error) {
+	manifest, err := ReadManifest(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	manifestPath := outputPath + ManifestSuffix
+	if manifest.Output == nil || !isSHA256Hex(manifest.Output.SHA256) {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "合并清单中没有输出摘要",
+			File:    manifestPath,
+		}
+	}
+
+	// 校验时总是重新读取文件，不使用按修改时间缓存的摘要
+	actual, err := computeContentHash(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	expected := strings.ToLower(manifest.Output.SHA256)
+	return &ManifestVerification{
+		OutputPath:     outputPath,
+		ManifestPath:   manifestPath,
+		ExpectedSHA256: expected,
+		ActualSHA256:   actual.SHA256,
+		ExpectedSize:   manifest.Output.Size,
+		ActualSize:     actual.Size,
+		Match:          actual.SHA256 == expected && actual.Size == manifest.Output.Size,
+		Inputs:         len(manifest.Inputs),
+	}, nil
+}
+
+// withoutPaths 返回只保留文件名的副本，用于PrivacyMode下嵌入输出的清单
+func (m *AuditManifest) withoutPaths() *AuditManifest {
+	copied := *m
+	copied.Inputs = make([]ManifestInput, len(m.Inputs))
+	for i, input := range m.Inputs {
+		input.Path = filepath.Base(input.Path)
+		input.RawPath = filepath.Base(input.RawPath)
+		input.DisplayPath = filepath.Base(input.DisplayPath)
+		copied.Inputs[i] = input
+	}
+	if m.ReviewCopyPath != "" {
+		copied.ReviewCopyPath = filepath.Base(m.ReviewCopyPath)
+	}
+	if m.Options.Rotations != nil {
+		copied.Options.Rotations = make(map[string]int, len(m.Options.Rotations))
+		for file, degrees := range m.Options.Rotations {
+			copied.Options.Rotations[filepath.Base(file)] = degrees
+		}
+	}
+	if m.Output != nil {
+		output := *m.Output
+		output.Path = filepath.Base(output.Path)
+		copied.Output = &output
+	}
+	return &copied
+}
+
+// newAuditManifest 按合并结果生成清单，见buildAuditManifest
+func (sm *StreamingMerger) newAuditManifest(result *MergeResult) (*AuditManifest, error) {
+	return buildAuditManifest(result, sm.manifestOptions(), sm.toolVersion, sm.clock.Now().UTC())
+}
+
+// buildAuditManifest 按合并结果生成没有输出摘要的清单：输入为通过验证的原始输入，验证时已计算的摘要直接复用。
+// 页面偏移按各输入的页数累加，目录页排在所有输入之前
+func buildAuditManifest(result *MergeResult, options ManifestOptions, toolVersion string, createdAt time.Time) (*AuditManifest, error) {
+	manifest := &AuditManifest{
+		Tool:        manifestTool,
+		ToolVersion: toolVersion,
+		CreatedAt:   createdAt,
+		Options:     options,
+		Inputs:      make([]ManifestInput, 0, len(result.ValidatedFiles)),
+	}
+	if manifest.ToolVersion == "" {
+		manifest.ToolVersion = "unknown"
+	}
+
+	pages := make(map[string]int, len(result.InputPages))
+	for _, input := range result.InputPages {
+		pages[input.File] = input.Pages
+	}
+
+	offset := result.TOCPages
+	for _, file := range result.ValidatedFiles {
+		hash, err := FileContentHash(file)
+		if err != nil {
+			return nil, err
+		}
+		count, ok := pages[file]
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("合并清单中 %s 的页数未知，之后输入的页面偏移可能不准确", file))
+		}
+		path := absPath(file)
+		manifest.Inputs = append(manifest.Inputs, ManifestInput{
+			Path:        path,
+			RawPath:     model.EscapeRawName(path),
+			DisplayPath: model.DisplayName(path, model.DefaultFilenameEncodings),
+			Size:        hash.Size,
+			SHA256:      hash.SHA256,
+			Pages:       count,
+			PageOffset:  offset,
+		})
+		offset += count
+	}
+	if result.ReviewCopyPath != "" {
+		manifest.ReviewCopyPath = absPath(result.ReviewCopyPath)
+	}
+	return manifest, nil
+}
+
+// manifestOptions 返回清单中记录的合并选项
+func (sm *StreamingMerger) manifestOptions() ManifestOptions {
+	options := ManifestOptions{
+		VerifyChecksums:      sm.integrity,
+		TryRepair:            sm.tryRepair,
+		AllowDuplicates:      sm.allowDuplicates,
+		NormalizeOrientation: sm.normalize,
+		DropAttachments:      sm.dropAttachments,
+		FlattenForms:         sm.flattenForms,
+		SourceBookmarks:      sm.sourceBookmarks,
+		GenerateTOC:          sm.generateTOC,
+		NUp:                  sm.imposition.nup(),
+		Booklet:              sm.imposition != nil && sm.imposition.Booklet,
+		Optimize:             sm.optimize,
+		OptimizeImagesDPI:    sm.imageDPI,
+		Encrypted:            sm.encryption != nil,
+		Linearize:            sm.linearize,
+		MaxOutputSizeBytes:   sm.maxOutputBytes,
+		MaxOutputPages:       sm.maxOutputPages,
+	}
+	if len(sm.rotations) > 0 {
+		options.Rotations = make(map[string]int, len(sm.rotations))
+		for file, degrees := range sm.rotations {
+			options.Rotations[absPath(file)] = degrees
+		}
+	}
+	for _, stamp := range sm.stamps {
+		if stamp != nil {
+			options.Stamps = append(options.Stamps, stamp.Text)
+		}
+	}
+	return options
+}
+
+// prepareManifest 启用WriteManifest或EmbedManifest时生成清单，启用EmbedManifest时把清单作为附件
+// 写入staging。在印章之后、优化和加密之前调用，嵌入的清单随输出一起优化和加密；未启用时返回nil
+func (sm *StreamingMerger) prepareManifest(result *MergeResult, staging string) (*AuditManifest, error) {
+	if !sm.writeManifest && !sm.embedManifest {
+		return nil, nil
+	}
+	manifest, err := sm.newAuditManifest(result)
+	if err != nil {
+		return nil, err
+	}
+	result.Manifest = manifest
+	if !sm.embedManifest || !fileExists(staging) {
+		return manifest, nil
+	}
+
+	embedded := manifest
+	if sm.privacyMode {
+		embedded = manifest.withoutPaths()
+	}
+	if err := embedManifest(staging, embedded); err != nil {
+		return nil, err
+	}
+	result.Attachments++
+	return manifest, nil
+}
+
+// recordManifestOutput 在提交前记录最终输出的大小、SHA-256和页数。此后不再修改staging，
+// 远程输出提交后本地没有输出文件，因此在提交前计算
+func (sm *StreamingMerger) recordManifestOutput(result *MergeResult, manifest *AuditManifest, staging, outputPath string) error {
+	if manifest == nil || !fileExists(staging) {
+		return nil
+	}
+	hash, err := computeContentHash(staging)
+	if err != nil {
+		return err
+	}
+	pages, err := sm.countPages(staging)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("合并清单无法统计输出页数: %v", err))
+	}
+	manifest.Output = &ManifestOutput{
+		Path:   absPath(outputPath),
+		Size:   hash.Size,
+		SHA256: hash.SHA256,
+		Pages:  pages,
+	}
+	return nil
+}
+
+// writeManifestSidecar 启用WriteManifest时把清单写到提交后的输出旁（output.pdf.manifest.json）。
+// committed 为提交后的本地输出，远程输出没有本地文件，只在MergeResult.Manifest中返回清单。
+// 在生成审阅副本之后调用，清单中记录审阅副本的路径
+func (sm *StreamingMerger) writeManifestSidecar(result *MergeResult, manifest *AuditManifest, committed string) error {
+	if manifest == nil || !sm.writeManifest {
+		return nil
+	}
+	if manifest.Output == nil {
+		result.Warnings = append(result.Warnings, "没有输出文件，未写出合并清单")
+		return nil
+	}
+	if result.ReviewCopyPath != "" {
+		manifest.ReviewCopyPath = absPath(result.ReviewCopyPath)
+	}
+	if committed == "" {
+		manifest.Output.Path = result.OutputPath
+		result.Warnings = append(result.Warnings, "远程输出不写合并清单旁路文件，清单见合并结果")
+		return nil
+	}
+
+	manifestPath, err := writeManifestFile(manifest, committed)
+	if err != nil {
+		return err
+	}
+	result.ManifestPath = manifestPath
+	return nil
+}
+
+// writeManifestFile 把清单写到outputPath旁的旁路文件（先写临时文件再替换），返回旁路文件路径
+func writeManifestFile(manifest *AuditManifest, outputPath string) (string, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法生成合并清单",
+			Cause:   err,
+		}
+	}
+	manifestPath := outputPath + ManifestSuffix
+	tempPath := manifestPath + ".tmp"
+	if err := os.WriteFile(tempPath, append(data, '\n'), 0644); err != nil {
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入合并清单",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, manifestPath); err != nil {
+		os.Remove(tempPath)
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入合并清单",
+			File:    manifestPath,
+			Cause:   err,
+		}
+	}
+	return manifestPath, nil
+}
+
+// embedManifest 以增量更新把清单作为JSON附件加入filePath的 /EmbeddedFiles，保留已有的附件
+func embedManifest(filePath string, manifest *AuditManifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法生成合并清单",
+			Cause:   err,
+		}
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	bodies := objectBodies(data)
+	existing := readEmbeddedFiles(data, bodies)
+	update := newIncrementalUpdate(data, indexObjects(data))
+
+	used := make(map[string]bool, len(existing)+1)
+	for _, file := range existing {
+		used[file.name] = true
+	}
+	name := uniqueAttachmentName(ManifestAttachmentName, used)
+
+	stream := update.add(fmt.Sprintf(
+		"<< /Type /EmbeddedFile /Subtype /application#2Fjson /Params << /Size %d >> /Length %d >>\nstream\n%s\nendstream",
+		len(content), len(content), content))
+	spec := update.add(fmt.Sprintf(
+		"<< /Type /Filespec /F %s /UF %s /Desc %s /AFRelationship /Supplement /EF << /F %d 0 R /UF %d 0 R >> >>",
+		pdfTextString(name), pdfTextString(name), pdfTextString("合并清单"), stream, stream))
+
+	entries := append(existing, embeddedFile{name: name, ref: spec})
+	sort.SliceStable(entries, func(i, j int) bool { return pdfTextString(entries[i].name) < pdfTextString(entries[j].name) })
+	refs := make([]string, len(entries))
+	for i, entry := range entries {
+		refs[i] = fmt.Sprintf("%s %d 0 R", pdfTextString(entry.name), entry.ref)
+	}
+	tree := update.add("<< /Names [" + strings.Join(refs, " ") + "] >>")
+	if _, err := setEmbeddedFiles(update, filePath, fmt.Sprintf("%d 0 R", tree)); err != nil {
+		return err
+	}
+	return writeIncrementalUpdate(update, filePath, "无法嵌入合并清单")
+}
+
+// absPath 返回绝对路径，无法取得时原样返回
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// previousManifest 启用WriteManifest时读取输出旁上次写出的清单旁路文件，没有或无法解析时返回nil
+func (s *PDFServiceImpl) previousManifest(outputPath string) *AuditManifest {
+	if !s.config.Load().WriteManifest || !fileExists(outputPath+ManifestSuffix) {
+		return nil
+	}
+	manifest, err := ReadManifest(outputPath)
+	if err != nil {
+		return nil
+	}
+	return manifest
+}
+
+// recordManifest 在后处理全部完成后按最终输出生成清单：启用WriteManifest时写出旁路文件，
+// 有上次的清单时与之比较并设置MergeResult.Delta。两者都不需要时不做任何事
+func (s *PDFServiceImpl) recordManifest(result *MergeResult, previous *AuditManifest, outputPath string) error {
+	config := s.config.Load()
+	if !config.WriteManifest && previous == nil {
+		return nil
+	}
+
+	// pdfcpu合并不报告各输入的页数，按文件统计以便计算页面偏移
+	if len(result.InputPages) < len(result.ValidatedFiles) {
+		known := make(map[string]int, len(result.InputPages))
+		for _, input := range result.InputPages {
+			known[input.File] = input.Pages
+		}
+		inputPages := make([]InputPageCount, 0, len(result.ValidatedFiles))
+		for _, file := range result.ValidatedFiles {
+			pages, ok := known[file]
+			if !ok {
+				count, err := CountPagesInFile(file, nil)
+				if err != nil {
+					continue
+				}
+				pages = count
+			}
+			inputPages = append(inputPages, InputPageCount{File: file, Pages: pages})
+		}
+		result.InputPages = inputPages
+	}
+
+	manifest, err := buildAuditManifest(result, s.manifestOptions(), config.ToolVersion, clock.OrSystem(config.Clock).Now().UTC())
+	if err != nil {
+		return err
+	}
+	hash, err := computeContentHash(outputPath)
+	if err != nil {
+		return err
+	}
+	manifest.Output = &ManifestOutput{
+		Path:   absPath(outputPath),
+		Size:   hash.Size,
+		SHA256: hash.SHA256,
+		Pages:  result.TotalPages,
+	}
+
+	if config.WriteManifest {
+		manifestPath, err := writeManifestFile(manifest, outputPath)
+		if err != nil {
+			return err
+		}
+		result.Manifest = manifest
+		result.ManifestPath = manifestPath
+	}
+	result.Delta = CompareManifests(previous, manifest)
+	return nil
+}
+
+// manifestOptions 返回清单中记录的服务配置中的合并选项
+func (s *PDFServiceImpl) manifestOptions() ManifestOptions {
+	config := s.config.Load()
+	options := ManifestOptions{
+		VerifyChecksums:    config.VerifyChecksums,
+		AllowDuplicates:    config.AllowDuplicates,
+		DropAttachments:    config.DropAttachments,
+		FlattenForms:       config.FlattenForms,
+		SourceBookmarks:    config.SourceBookmarks,
+		GenerateTOC:        config.GenerateTOC,
+		NUp:                config.Imposition.nup(),
+		Booklet:            config.Imposition != nil && config.Imposition.Booklet,
+		Optimize:           config.OptimizeOutput,
+		OptimizeImagesDPI:  config.OptimizeImagesDPI,
+		Encrypted:          config.OutputUserPassword != "" || config.OutputOwnerPassword != "",
+		Linearize:          config.Linearize,
+		MaxOutputSizeBytes: config.MaxOutputSize,
+		MaxOutputPages:     config.MaxOutputPages,
+	}
+	for _, stamp := range config.Stamps {
+		if stamp != nil {
+			options.Stamps = append(options.Stamps, stamp.Text)
+		}
+	}
+	return options
+}
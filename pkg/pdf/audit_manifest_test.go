@@ -0,0 +1,157 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// mergeWithManifest 以原生合并两个输入（2页和3页），返回输出路径和合并结果
+func mergeWithManifest(t *testing.T, dir string, options *MergeOptions) (string, *MergeResult) {
+	t.Helper()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(3))
+
+	options.TempDirectory = dir
+	options.BackendStats = NewBackendStatsStore()
+	merger := NewStreamingMerger(options)
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	return output, result
+}
+
+func TestMergeFiles_WriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	output, result := mergeWithManifest(t, dir, &MergeOptions{
+		WriteManifest: true,
+		ToolVersion:   "v1.2.3",
+		Clock:         clock.NewFake(start, 1),
+	})
+
+	assert.Equal(t, output+ManifestSuffix, result.ManifestPath)
+	manifest, err := ReadManifest(output)
+	require.NoError(t, err)
+	assert.Equal(t, "pdf-merger", manifest.Tool)
+	assert.Equal(t, "v1.2.3", manifest.ToolVersion)
+	assert.True(t, manifest.CreatedAt.Equal(start))
+
+	require.Len(t, manifest.Inputs, 2)
+	a, b := manifest.Inputs[0], manifest.Inputs[1]
+	assert.Equal(t, filepath.Join(dir, "a.pdf"), a.Path)
+	assert.Equal(t, 2, a.Pages)
+	assert.Equal(t, 0, a.PageOffset)
+	assert.Equal(t, 3, b.Pages)
+	assert.Equal(t, 2, b.PageOffset)
+	hash, err := FileContentHash(filepath.Join(dir, "b.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, hash.SHA256, b.SHA256)
+	assert.Equal(t, hash.Size, b.Size)
+
+	require.NotNil(t, manifest.Output)
+	outputHash, err := computeContentHash(output)
+	require.NoError(t, err)
+	assert.Equal(t, outputHash.SHA256, manifest.Output.SHA256)
+	assert.Equal(t, 5, manifest.Output.Pages)
+
+	// 没有启用EmbedManifest时不添加附件
+	attachments, err := ListAttachments(output)
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestMergeFiles_ManifestRecordsRawPathsAndReviewCopy(t *testing.T) {
+	dir := t.TempDir()
+	gbkName := "\xd6\xd0\xce\xc4\xb1\xa8\xb8\xe6.pdf" // 中文报告.pdf (GBK)
+	a := createTestFile(t, dir, gbkName, buildFlatPDF(1))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(2))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		WriteManifest: true,
+		ReviewCopy:    true,
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ReviewCopyPath)
+
+	manifest, err := ReadManifest(output)
+	require.NoError(t, err)
+	assert.Equal(t, result.ReviewCopyPath, manifest.ReviewCopyPath)
+	require.Len(t, manifest.Inputs, 2)
+	input := manifest.Inputs[0]
+	assert.Equal(t, model.EscapeRawName(a), input.RawPath, "JSON中的路径不能保留非UTF-8字节，原始路径以转义形式记录")
+	assert.Equal(t, filepath.Join(dir, "中文报告.pdf"), input.DisplayPath)
+	assert.Equal(t, b, manifest.Inputs[1].RawPath)
+	assert.Equal(t, b, manifest.Inputs[1].DisplayPath)
+}
+
+func TestMergeFiles_EmbedManifest(t *testing.T) {
+	dir := t.TempDir()
+	output, result := mergeWithManifest(t, dir, &MergeOptions{WriteManifest: true, EmbedManifest: true})
+
+	attachments, err := ListAttachments(output)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, ManifestAttachmentName, attachments[0].Name)
+	assert.Equal(t, 1, result.Attachments)
+
+	// 旁路清单的输出摘要针对嵌入清单之后的最终输出
+	verification, err := VerifyManifest(output)
+	require.NoError(t, err)
+	assert.True(t, verification.Match)
+	assert.Equal(t, 2, verification.Inputs)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(data, []byte(filepath.Join(dir, "a.pdf"))), "未启用PrivacyMode时嵌入的清单包含绝对路径")
+}
+
+func TestMergeFiles_EmbedManifestPrivacyMode(t *testing.T) {
+	dir := t.TempDir()
+	output, result := mergeWithManifest(t, dir, &MergeOptions{EmbedManifest: true, PrivacyMode: true})
+
+	assert.Empty(t, result.ManifestPath, "未启用WriteManifest时不写旁路文件")
+	assert.False(t, fileExists(output+ManifestSuffix))
+	require.NotNil(t, result.Manifest)
+	assert.Equal(t, filepath.Join(dir, "a.pdf"), result.Manifest.Inputs[0].Path, "合并结果中的清单保留完整路径")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(data, []byte(`"path": "a.pdf"`)))
+	assert.False(t, bytes.Contains(data, []byte(dir)), "启用PrivacyMode时嵌入的清单不包含绝对路径")
+}
+
+func TestVerifyManifest_DetectsModifiedOutput(t *testing.T) {
+	dir := t.TempDir()
+	output, _ := mergeWithManifest(t, dir, &MergeOptions{WriteManifest: true})
+
+	f, err := os.OpenFile(output, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("%tampered\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	verification, err := VerifyManifest(output)
+	require.NoError(t, err)
+	assert.False(t, verification.Match)
+	assert.NotEqual(t, verification.ExpectedSHA256, verification.ActualSHA256)
+
+	_, err = VerifyManifest(filepath.Join(dir, "missing.pdf"))
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorIO, pdfErr.Type)
+}
@@ -0,0 +1,430 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// 合并后端名称
+const (
+	BackendPDFCPU   = "pdfcpu"   // pdfcpu库或命令行
+	BackendFallback = "fallback" // 纯Go回退实现
+)
+
+const (
+	backendStatsVersion  = 1
+	defaultStatsFileName = "backend_stats.json"
+
+	// minBucketSamples 同尺寸档样本少于该值时改用后端的总体成功率
+	minBucketSamples = 3
+)
+
+// 输入尺寸档，按合并输入的总字节数划分
+const (
+	SizeBucketSmall  = "small"  // < 1MB
+	SizeBucketMedium = "medium" // 1MB - 10MB
+	SizeBucketLarge  = "large"  // 10MB - 100MB
+	SizeBucketHuge   = "huge"   // >= 100MB
+)
+
+// failureClassNames 失败分类名称，写入统计文件
+var failureClassNames = map[ErrorType]string{
+	ErrorInvalidFile:        "invalid_file",
+	ErrorEncrypted:          "encrypted",
+	ErrorCorrupted:          "corrupted",
+	ErrorPermission:         "permission",
+	ErrorMemory:             "memory",
+	ErrorIO:                 "io",
+	ErrorValidation:         "validation",
+	ErrorProcessing:         "processing",
+	ErrorInvalidInput:       "invalid_input",
+	ErrorLimitExceeded:      "limit_exceeded",
+	ErrorChecksumMismatch:   "checksum_mismatch",
+	ErrorAdapterUnavailable: "adapter_unavailable",
+	ErrorResourceLimit:      "resource_limit",
+}
+
+// SizeBucket 返回输入总字节数所属的尺寸档
+func SizeBucket(inputBytes int64) string {
+	switch {
+	case inputBytes < 1<<20:
+		return SizeBucketSmall
+	case inputBytes < 10<<20:
+		return SizeBucketMedium
+	case inputBytes < 100<<20:
+		return SizeBucketLarge
+	default:
+		return SizeBucketHuge
+	}
+}
+
+// BucketStat 某个后端在一个尺寸档内的统计
+type BucketStat struct {
+	Attempts  int64         `json:"attempts"`
+	Successes int64         `json:"successes"`
+	Bytes     int64         `json:"bytes"`    // 成功合并的输入总字节数
+	Duration  time.Duration `json:"duration"` // 成功合并的总耗时
+}
+
+// Throughput 平均吞吐量（字节/秒），没有成功样本时为0
+func (b BucketStat) Throughput() float64 {
+	if b.Duration <= 0 {
+		return 0
+	}
+	return float64(b.Bytes) / b.Duration.Seconds()
+}
+
+// BackendStat 单个后端的累计统计
+type BackendStat struct {
+	Backend   string                `json:"backend"`
+	Attempts  int64                 `json:"attempts"`
+	Successes int64                 `json:"successes"`
+	Failures  map[string]int64      `json:"failures,omitempty"` // 按失败分类计数
+	Buckets   map[string]BucketStat `json:"buckets,omitempty"`  // 按尺寸档统计
+}
+
+// SuccessRate 成功率，没有尝试时为0
+func (s BackendStat) SuccessRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Attempts)
+}
+
+// BackendOutcome 一次后端合并尝试的结果
+type BackendOutcome struct {
+	Backend    string
+	InputBytes int64
+	Duration   time.Duration
+	Err        error
+}
+
+// statsFile 统计文件的磁盘格式
+type statsFile struct {
+	Version  int                     `json:"version"`
+	Backends map[string]*BackendStat `json:"backends"`
+}
+
+// BackendStatsStore 后端统计存储。记录只在内存中更新计数，持有锁的时间很短；
+// 写盘通过 Flush 完成，开启自动写盘时在后台进行，不阻塞合并。
+type BackendStatsStore struct {
+	mu        sync.Mutex
+	path      string // 为空时只保存在内存中
+	backends  map[string]*BackendStat
+	dirty     bool
+	autoFlush bool
+	flushing  int32 // 后台写盘进行中（原子访问）
+	clock     clock.Clock
+}
+
+// NewBackendStatsStore 创建只保存在内存中的统计存储
+func NewBackendStatsStore() *BackendStatsStore {
+	return &BackendStatsStore{
+		backends: make(map[string]*BackendStat),
+		clock:    clock.System(),
+	}
+}
+
+// OpenBackendStatsStore 打开 path 处的统计文件，文件不存在时从空统计开始。
+// 文件损坏或版本不符时将其改名为 .corrupt-<时间> 保留现场，并从空统计开始。
+func OpenBackendStatsStore(path string, clk clock.Clock) (*BackendStatsStore, error) {
+	store := NewBackendStatsStore()
+	store.path = path
+	store.clock = clock.OrSystem(clk)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, &PDFError{Type: ErrorIO, Message: "无法读取后端统计文件", File: path, Cause: err}
+	}
+
+	var file statsFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != backendStatsVersion {
+		if rotateErr := store.rotateCorrupt(); rotateErr != nil {
+			return nil, &PDFError{Type: ErrorIO, Message: "无法移走损坏的后端统计文件", File: path, Cause: rotateErr}
+		}
+		return store, nil
+	}
+
+	for name, stat := range file.Backends {
+		if stat == nil {
+			continue
+		}
+		stat.Backend = name
+		store.backends[name] = stat
+	}
+	return store, nil
+}
+
+// rotateCorrupt 把损坏的统计文件改名保留
+func (s *BackendStatsStore) rotateCorrupt() error {
+	rotated := fmt.Sprintf("%s.corrupt-%s", s.path, s.clock.Now().Format("20060102-150405"))
+	return os.Rename(s.path, rotated)
+}
+
+// Path 返回统计文件路径，内存存储为空
+func (s *BackendStatsStore) Path() string {
+	return s.path
+}
+
+// Record 记录一次后端合并尝试
+func (s *BackendStatsStore) Record(outcome BackendOutcome) {
+	bucket := SizeBucket(outcome.InputBytes)
+
+	s.mu.Lock()
+	stat := s.backends[outcome.Backend]
+	if stat == nil {
+		stat = &BackendStat{Backend: outcome.Backend}
+		s.backends[outcome.Backend] = stat
+	}
+	if stat.Buckets == nil {
+		stat.Buckets = make(map[string]BucketStat)
+	}
+	b := stat.Buckets[bucket]
+	stat.Attempts++
+	b.Attempts++
+	if outcome.Err == nil {
+		stat.Successes++
+		b.Successes++
+		b.Bytes += outcome.InputBytes
+		b.Duration += outcome.Duration
+	} else {
+		if stat.Failures == nil {
+			stat.Failures = make(map[string]int64)
+		}
+		stat.Failures[failureClass(outcome.Err)]++
+	}
+	stat.Buckets[bucket] = b
+	s.dirty = true
+	s.mu.Unlock()
+
+	if s.autoFlush {
+		s.flushInBackground()
+	}
+}
+
+// failureClass 返回错误的失败分类，非PDFError按pdfcpu错误信息归类
+func failureClass(err error) string {
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) {
+		pdfErr = mapPDFCPUError(err)
+	}
+	if name, ok := failureClassNames[pdfErr.Type]; ok {
+		return name
+	}
+	return "other"
+}
+
+// flushInBackground 启动后台写盘；已有写盘在进行时直接返回，由其完成后再检查
+func (s *BackendStatsStore) flushInBackground() {
+	if !atomic.CompareAndSwapInt32(&s.flushing, 0, 1) {
+		return
+	}
+	go func() {
+		for {
+			err := s.Flush()
+			atomic.StoreInt32(&s.flushing, 0)
+			// 写盘失败时等下一次记录再试，避免反复重试
+			if err != nil || !s.isDirty() || !atomic.CompareAndSwapInt32(&s.flushing, 0, 1) {
+				return
+			}
+		}
+	}()
+}
+
+// isDirty 是否有尚未写盘的记录
+func (s *BackendStatsStore) isDirty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dirty
+}
+
+// Flush 把有变化的统计写入文件（先写临时文件再改名）
+func (s *BackendStatsStore) Flush() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	file := statsFile{Version: backendStatsVersion, Backends: make(map[string]*BackendStat, len(s.backends))}
+	for name, stat := range s.backends {
+		copied := copyBackendStat(stat)
+		file.Backends[name] = &copied
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err == nil {
+		err = writeStatsFile(s.path, data)
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return &PDFError{Type: ErrorIO, Message: "无法写入后端统计文件", File: s.path, Cause: err}
+	}
+	return nil
+}
+
+// writeStatsFile 原子写入统计文件
+func writeStatsFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// Snapshot 返回按后端名称排序的统计副本
+func (s *BackendStatsStore) Snapshot() []BackendStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]BackendStat, 0, len(s.backends))
+	for _, stat := range s.backends {
+		stats = append(stats, copyBackendStat(stat))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Backend < stats[j].Backend })
+	return stats
+}
+
+// copyBackendStat 深拷贝统计，调用方需持有锁
+func copyBackendStat(stat *BackendStat) BackendStat {
+	copied := *stat
+	if stat.Failures != nil {
+		copied.Failures = make(map[string]int64, len(stat.Failures))
+		for k, v := range stat.Failures {
+			copied.Failures[k] = v
+		}
+	}
+	if stat.Buckets != nil {
+		copied.Buckets = make(map[string]BucketStat, len(stat.Buckets))
+		for k, v := range stat.Buckets {
+			copied.Buckets[k] = v
+		}
+	}
+	return copied
+}
+
+// Order 根据统计为输入大小为 inputBytes 的合并选择后端顺序。
+// 优先同尺寸档成功率高的后端（样本不足时用总体成功率），其次吞吐量高的后端；
+// 没有统计差异时保持 backends 的原有顺序。返回新的切片，包含全部后端。
+func (s *BackendStatsStore) Order(backends []string, inputBytes int64) []string {
+	bucket := SizeBucket(inputBytes)
+
+	type scored struct {
+		name       string
+		rate       float64
+		throughput float64
+	}
+	candidates := make([]scored, len(backends))
+
+	s.mu.Lock()
+	for i, name := range backends {
+		candidates[i] = scored{name: name, rate: 0.5}
+		stat := s.backends[name]
+		if stat == nil {
+			continue
+		}
+		b := stat.Buckets[bucket]
+		if b.Attempts >= minBucketSamples {
+			candidates[i].rate = smoothedRate(b.Successes, b.Attempts)
+		} else {
+			candidates[i].rate = smoothedRate(stat.Successes, stat.Attempts)
+		}
+		candidates[i].throughput = b.Throughput()
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].rate != candidates[j].rate {
+			return candidates[i].rate > candidates[j].rate
+		}
+		return candidates[i].throughput > candidates[j].throughput
+	})
+
+	ordered := make([]string, len(candidates))
+	for i, c := range candidates {
+		ordered[i] = c.name
+	}
+	return ordered
+}
+
+// smoothedRate 拉普拉斯平滑后的成功率，没有样本时为0.5
+func smoothedRate(successes, attempts int64) float64 {
+	return float64(successes+1) / float64(attempts+2)
+}
+
+// DefaultBackendStatsPath 返回配置目录下的默认统计文件路径
+func DefaultBackendStatsPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, defaultVaultDirName, defaultStatsFileName), nil
+}
+
+// backendStatsPath 默认统计文件路径，测试中可替换
+var backendStatsPath = DefaultBackendStatsPath
+
+var (
+	defaultStatsOnce  sync.Once
+	defaultStatsStore *BackendStatsStore
+)
+
+// DefaultBackendStatsStore 返回进程共享的统计存储，首次调用时从配置目录加载。
+// 无法确定或读取配置目录时退化为内存存储，不影响合并。
+func DefaultBackendStatsStore() *BackendStatsStore {
+	defaultStatsOnce.Do(func() {
+		defaultStatsStore = NewBackendStatsStore()
+		path, err := backendStatsPath()
+		if err != nil {
+			return
+		}
+		store, err := OpenBackendStatsStore(path, nil)
+		if err != nil {
+			return
+		}
+		store.autoFlush = true
+		defaultStatsStore = store
+	})
+	return defaultStatsStore
+}
+
+// BackendStats 返回各合并后端的累计统计
+func BackendStats() []BackendStat {
+	return DefaultBackendStatsStore().Snapshot()
+}
@@ -0,0 +1,221 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+func init() {
+	// 测试中的合并不写入用户配置目录
+	backendStatsPath = func() (string, error) {
+		dir, err := os.MkdirTemp("", "pdf-backend-stats")
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, defaultStatsFileName), nil
+	}
+}
+
+// recordOutcomes 记录 n 次相同结果
+func recordOutcomes(store *BackendStatsStore, backend string, inputBytes int64, n int, err error) {
+	for i := 0; i < n; i++ {
+		store.Record(BackendOutcome{Backend: backend, InputBytes: inputBytes, Duration: time.Second, Err: err})
+	}
+}
+
+func TestSizeBucket(t *testing.T) {
+	assert.Equal(t, SizeBucketSmall, SizeBucket(0))
+	assert.Equal(t, SizeBucketSmall, SizeBucket(1<<20-1))
+	assert.Equal(t, SizeBucketMedium, SizeBucket(1<<20))
+	assert.Equal(t, SizeBucketLarge, SizeBucket(10<<20))
+	assert.Equal(t, SizeBucketHuge, SizeBucket(100<<20))
+}
+
+func TestBackendStatsStore_RecordAndSnapshot(t *testing.T) {
+	store := NewBackendStatsStore()
+	store.Record(BackendOutcome{Backend: BackendPDFCPU, InputBytes: 2 << 20, Duration: time.Second})
+	store.Record(BackendOutcome{Backend: BackendPDFCPU, InputBytes: 2 << 20, Duration: time.Second,
+		Err: &PDFError{Type: ErrorCorrupted, Message: "损坏"}})
+	store.Record(BackendOutcome{Backend: BackendPDFCPU, InputBytes: 100, Err: errors.New("pdfcpu: validation error")})
+	store.Record(BackendOutcome{Backend: BackendFallback, InputBytes: 100, Duration: time.Millisecond})
+
+	stats := store.Snapshot()
+	require.Len(t, stats, 2)
+	assert.Equal(t, BackendFallback, stats[0].Backend, "快照应按后端名称排序")
+
+	pdfcpu := stats[1]
+	assert.Equal(t, int64(3), pdfcpu.Attempts)
+	assert.Equal(t, int64(1), pdfcpu.Successes)
+	assert.Equal(t, map[string]int64{"corrupted": 1, "validation": 1}, pdfcpu.Failures)
+	medium := pdfcpu.Buckets[SizeBucketMedium]
+	assert.Equal(t, int64(2), medium.Attempts)
+	assert.Equal(t, int64(1), medium.Successes)
+	assert.InDelta(t, float64(2<<20), medium.Throughput(), 1, "吞吐量只统计成功的合并")
+
+	// 快照是副本，修改不影响存储
+	pdfcpu.Failures["corrupted"] = 100
+	assert.Equal(t, int64(1), store.Snapshot()[1].Failures["corrupted"])
+}
+
+func TestBackendStatsStore_AdaptiveOrderFollowsOutcomes(t *testing.T) {
+	store := NewBackendStatsStore()
+	static := []string{BackendPDFCPU, BackendFallback}
+	const small = 100 << 10
+	const large = 50 << 20
+
+	assert.Equal(t, static, store.Order(static, small), "没有统计时保持静态顺序")
+
+	// pdfcpu在小文件上接连失败，回退后端成功
+	recordOutcomes(store, BackendPDFCPU, small, 4, errors.New("corrupt"))
+	recordOutcomes(store, BackendFallback, small, 4, nil)
+	assert.Equal(t, []string{BackendFallback, BackendPDFCPU}, store.Order(static, small))
+
+	// 大文件样本不足时参考总体成功率
+	assert.Equal(t, []string{BackendFallback, BackendPDFCPU}, store.Order(static, large))
+
+	// pdfcpu在大文件上积累了成功记录后，大文件重新优先pdfcpu，小文件不受影响
+	recordOutcomes(store, BackendPDFCPU, large, 5, nil)
+	recordOutcomes(store, BackendFallback, large, 3, errors.New("permission denied"))
+	assert.Equal(t, []string{BackendPDFCPU, BackendFallback}, store.Order(static, large))
+	assert.Equal(t, []string{BackendFallback, BackendPDFCPU}, store.Order(static, small))
+
+	assert.Equal(t, []string{BackendPDFCPU, BackendFallback}, static, "不应修改传入的切片")
+}
+
+func TestBackendStatsStore_OrderPrefersThroughputOnEqualRates(t *testing.T) {
+	store := NewBackendStatsStore()
+	store.Record(BackendOutcome{Backend: BackendPDFCPU, InputBytes: 1000, Duration: 2 * time.Second})
+	store.Record(BackendOutcome{Backend: BackendFallback, InputBytes: 1000, Duration: time.Second})
+
+	assert.Equal(t, []string{BackendFallback, BackendPDFCPU},
+		store.Order([]string{BackendPDFCPU, BackendFallback}, 1000))
+}
+
+func TestBackendStatsStore_FlushAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats", defaultStatsFileName)
+	store, err := OpenBackendStatsStore(path, nil)
+	require.NoError(t, err)
+	assert.Empty(t, store.Snapshot())
+
+	recordOutcomes(store, BackendPDFCPU, 10, 2, nil)
+	recordOutcomes(store, BackendPDFCPU, 10, 1, errors.New("password required"))
+	require.NoError(t, store.Flush())
+
+	reopened, err := OpenBackendStatsStore(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, store.Snapshot(), reopened.Snapshot())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "写盘后不应留下临时文件")
+}
+
+func TestBackendStatsStore_CorruptFileRotatedAside(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid json":    "{not json",
+		"unknown version": `{"version": 99, "backends": {}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, defaultStatsFileName)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+			fake := clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), 1)
+			store, err := OpenBackendStatsStore(path, fake)
+			require.NoError(t, err)
+			assert.Empty(t, store.Snapshot(), "损坏的统计应从空开始")
+
+			rotated := path + ".corrupt-20240506-070809"
+			data, err := os.ReadFile(rotated)
+			require.NoError(t, err, "损坏的文件应改名保留")
+			assert.Equal(t, content, string(data))
+			assert.False(t, fileExists(path))
+
+			// 新统计可以正常写入
+			recordOutcomes(store, BackendFallback, 1, 1, nil)
+			require.NoError(t, store.Flush())
+			reopened, err := OpenBackendStatsStore(path, fake)
+			require.NoError(t, err)
+			assert.Len(t, reopened.Snapshot(), 1)
+		})
+	}
+}
+
+func TestBackendStatsStore_AutoFlushInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), defaultStatsFileName)
+	store, err := OpenBackendStatsStore(path, nil)
+	require.NoError(t, err)
+	store.autoFlush = true
+
+	recordOutcomes(store, BackendPDFCPU, 1, 10, nil)
+	assert.Eventually(t, func() bool {
+		reopened, err := OpenBackendStatsStore(path, nil)
+		if err != nil {
+			return false
+		}
+		stats := reopened.Snapshot()
+		return len(stats) == 1 && stats[0].Attempts == 10
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamingMerger_RecordsBackendOutcomes(t *testing.T) {
+	tempDir := t.TempDir()
+	file := createTestFile(t, tempDir, "in.pdf", []byte("%PDF-1.4\n%%EOF"))
+	store := NewBackendStatsStore()
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: store})
+	merger.adapter = nil
+
+	require.NoError(t, merger.mergeWithBackends(context.Background(), []string{file}, filepath.Join(tempDir, "out.pdf")))
+	stats := store.Snapshot()
+	require.Len(t, stats, 1)
+	assert.Equal(t, BackendFallback, stats[0].Backend)
+	assert.Equal(t, int64(1), stats[0].Successes)
+}
+
+func TestStreamingMerger_BackendChain(t *testing.T) {
+	store := NewBackendStatsStore()
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), BackendStats: store})
+	if merger.adapter == nil {
+		t.Skip("pdfcpu适配器不可用")
+	}
+
+	assert.Equal(t, []string{BackendPDFCPU}, merger.backendChain(10), "静态模式只使用首选后端")
+
+	merger.adaptive = true
+	assert.Equal(t, []string{BackendPDFCPU, BackendFallback}, merger.backendChain(10))
+	recordOutcomes(store, BackendPDFCPU, 10, 3, errors.New("corrupt"))
+	assert.Equal(t, []string{BackendFallback, BackendPDFCPU}, merger.backendChain(10),
+		"自适应模式应随统计调整顺序并保留其余后端")
+}
+
+func TestStreamingMerger_AdaptiveFallsThroughChain(t *testing.T) {
+	tempDir := t.TempDir()
+	input := createTestFile(t, tempDir, "broken.pdf", []byte("not a pdf"))
+	store := NewBackendStatsStore()
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: store, AdaptiveBackends: true})
+	if merger.adapter == nil {
+		t.Skip("pdfcpu适配器不可用")
+	}
+
+	// pdfcpu无法处理该输入，链中的回退后端接手
+	require.NoError(t, merger.mergeWithBackends(context.Background(), []string{input}, filepath.Join(tempDir, "out.pdf")))
+	byName := make(map[string]BackendStat)
+	for _, stat := range store.Snapshot() {
+		byName[stat.Backend] = stat
+	}
+	assert.Equal(t, int64(0), byName[BackendPDFCPU].Successes)
+	assert.Equal(t, int64(1), byName[BackendPDFCPU].Attempts)
+	assert.Equal(t, int64(1), byName[BackendFallback].Successes)
+	assert.Equal(t, BackendFallback, merger.backendChain(1)[0], "下一次合并应优先成功过的后端")
+}
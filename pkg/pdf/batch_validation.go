@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// BatchFileResult 批量验证中单个文件的结果
+type BatchFileResult struct {
+	Path      string        `json:"path"`
+	Valid     bool          `json:"valid"`
+	Skipped   bool          `json:"skipped,omitempty"`    // 取消时尚未开始验证
+	ErrorType string        `json:"error_type,omitempty"` // PDFError的类型，其他错误为空
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// BatchValidationReport 批量验证的汇总报告，Results与输入顺序相同
+type BatchValidationReport struct {
+	Results    []BatchFileResult `json:"results"`
+	Total      int               `json:"total"`
+	Valid      int               `json:"valid"`
+	Invalid    int               `json:"invalid"`
+	Skipped    int               `json:"skipped"`
+	ErrorTypes map[string]int    `json:"error_types,omitempty"` // 各错误类型的文件数
+	Workers    int               `json:"workers"`
+	Duration   time.Duration     `json:"duration_ns"`
+}
+
+// BatchProgressFunc 批量验证每完成一个文件调用一次，done为已完成的文件数。
+// 调用是串行的，回调中不需要加锁，但应尽快返回
+type BatchProgressFunc func(done, total int, result BatchFileResult)
+
+// validateBatch 用workers个协程对paths逐个调用validate并汇总结果，workers不大于0时使用CPU核数，耗时按c计算。
+// ctx结束后不再开始新文件，已开始的文件验证完成后返回部分报告和ctx.Err()，未验证的文件标记为Skipped
+func validateBatch(ctx context.Context, c clock.Clock, paths []string, workers int, validate func(string) error, progress BatchProgressFunc) (*BatchValidationReport, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = max(1, min(workers, len(paths)))
+
+	start := c.Now()
+	results := make([]BatchFileResult, len(paths))
+	started := make([]bool, len(paths))
+	jobs := make(chan int)
+
+	var progressMu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				result := validateOne(c, paths[index], validate)
+				results[index] = result
+				if progress != nil {
+					progressMu.Lock()
+					done++
+					progress(done, len(paths), result)
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for index := range paths {
+		// 空闲协程和ctx结束同时就绪时select随机选择，先检查ctx使取消后不再开始新文件
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- index:
+			started[index] = true
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := &BatchValidationReport{
+		Results:    results,
+		Total:      len(paths),
+		ErrorTypes: make(map[string]int),
+		Workers:    workers,
+		Duration:   c.Now().Sub(start),
+	}
+	for index, path := range paths {
+		switch {
+		case !started[index]:
+			results[index] = BatchFileResult{Path: path, Skipped: true}
+			report.Skipped++
+		case results[index].Valid:
+			report.Valid++
+		default:
+			report.Invalid++
+			if errorType := results[index].ErrorType; errorType != "" {
+				report.ErrorTypes[errorType]++
+			}
+		}
+	}
+	return report, ctx.Err()
+}
+
+// validateOne 验证单个文件并记录耗时和错误类型
+func validateOne(c clock.Clock, path string, validate func(string) error) BatchFileResult {
+	start := c.Now()
+	err := validate(path)
+	result := BatchFileResult{Path: path, Valid: err == nil, Duration: c.Now().Sub(start)}
+	if err != nil {
+		result.Error = err.Error()
+		var pdfErr *PDFError
+		if errors.As(err, &pdfErr) {
+			result.ErrorType = pdfErr.typeString()
+		}
+	}
+	return result
+}
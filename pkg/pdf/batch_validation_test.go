@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+func TestPDFService_ValidateBatch(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 6; i++ {
+		paths = append(paths, createTestFile(t, dir, fmt.Sprintf("valid%d.pdf", i), buildFlatPDF(1)))
+	}
+	paths = append(paths,
+		createTestFile(t, dir, "garbage.pdf", []byte("not a pdf at all")),
+		dir+"/missing.pdf")
+
+	var calls []int
+	config := DefaultServiceConfig()
+	config.BatchProgress = func(done, total int, result BatchFileResult) {
+		assert.Equal(t, len(paths), total)
+		calls = append(calls, done)
+	}
+	service := NewPDFServiceWithConfig(config)
+
+	report, err := service.ValidateBatch(context.Background(), paths, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Total)
+	assert.Equal(t, 6, report.Valid)
+	assert.Equal(t, 2, report.Invalid)
+	assert.Zero(t, report.Skipped)
+	assert.Equal(t, 4, report.Workers)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, calls, "进度回调应串行调用且计数递增")
+
+	require.Len(t, report.Results, len(paths))
+	for i, result := range report.Results {
+		assert.Equal(t, paths[i], result.Path, "结果应与输入顺序相同")
+	}
+	assert.True(t, report.Results[0].Valid)
+	garbage := report.Results[6]
+	assert.False(t, garbage.Valid)
+	assert.NotEmpty(t, garbage.ErrorType)
+	assert.NotEmpty(t, garbage.Error)
+	assert.Positive(t, garbage.Duration)
+	assert.Equal(t, 2, sumCounts(report.ErrorTypes))
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+func TestValidateBatch_BoundedWorkers(t *testing.T) {
+	paths := make([]string, 20)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("file%d.pdf", i)
+	}
+
+	var running, peak int32
+	validate := func(string) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	report, err := validateBatch(context.Background(), clock.System(), paths, 3, validate, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20, report.Valid)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(3))
+	assert.Greater(t, atomic.LoadInt32(&peak), int32(1), "应并行验证")
+
+	// 工作协程数不超过文件数
+	report, err = validateBatch(context.Background(), clock.System(), paths[:2], 0, validate, nil)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, report.Workers, 2)
+}
+
+func TestValidateBatch_Cancel(t *testing.T) {
+	paths := make([]string, 10)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("file%d.pdf", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	validate := func(string) error {
+		once.Do(cancel)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	report, err := validateBatch(ctx, clock.System(), paths, 2, validate, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, report)
+	assert.Positive(t, report.Skipped)
+	assert.Equal(t, report.Total, report.Valid+report.Invalid+report.Skipped)
+	last := report.Results[len(paths)-1]
+	assert.True(t, last.Skipped)
+	assert.Equal(t, paths[len(paths)-1], last.Path)
+}
@@ -0,0 +1,347 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxOutlineItems 遍历已有书签时最多访问的顶层书签数，防止损坏文件中的循环链表
+const maxOutlineItems = 10000
+
+var (
+	outlinesRefPattern   = regexp.MustCompile(`/Outlines\s+(\d+)\s+\d+\s+R`)
+	outlineLinkPattern   = regexp.MustCompile(`^/(Parent|Prev|Next)\s+\d+\s+\d+\s+R`)
+	outlineDestPattern   = regexp.MustCompile(`/(?:Dest|D)\s*\[\s*(\d+)\s+\d+\s+R`)
+	outlineCountPattern  = regexp.MustCompile(`/Count\s+(-?\d+)`)
+	outlineFirstPattern  = regexp.MustCompile(`/First\s+(\d+)\s+\d+\s+R`)
+	outlineNextPattern   = regexp.MustCompile(`/Next\s+(\d+)\s+\d+\s+R`)
+	pageModePattern      = regexp.MustCompile(`/PageMode\s*/\w+`)
+	literalEscapeReplace = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\(`, "(", `\)`, ")", `\\`, `\`)
+)
+
+// SourceBookmark 合并输出中一个来源文件对应的顶层书签
+type SourceBookmark struct {
+	Title string // 书签文字
+	Page  int    // 来源在输出中的第一页（从1开始）
+	Pages int    // 来源的页数，用于把输出中已有的书签归到对应来源下
+}
+
+// AddSourceBookmarks 以增量更新为每个来源添加一个指向其第一页的顶层书签，输入与输出可以相同。
+// 输出中已有的顶层书签按目标页嵌套到对应来源的书签下（默认折叠），
+// 无法确定目标页的书签（如命名目标）归入前一个书签所在的来源。
+func AddSourceBookmarks(inputPath, outputPath string, sources []SourceBookmark) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法为加密文件添加书签",
+			File:    inputPath,
+		}
+	}
+
+	stats, err := WalkPageTree(inputPath, data, nil)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if source.Page < 1 || source.Page+max(source.Pages, 1)-1 > len(stats.Pages) {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("书签 %q 的页面超出文档页数 %d", source.Title, len(stats.Pages)),
+				File:    inputPath,
+			}
+		}
+	}
+
+	offsets := indexObjects(data)
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "找不到文档目录",
+			File:    inputPath,
+		}
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	catalog, ok := objectBody(data, offsets, catalogNum)
+	if !ok {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("文档目录对象 %d 不存在", catalogNum),
+			File:    inputPath,
+		}
+	}
+
+	update := newIncrementalUpdate(data, offsets)
+	children := groupExistingOutlines(data, offsets, catalog, stats.Pages, sources)
+
+	outlinesNum := update.add("")
+	items := make([]int, len(sources))
+	for i := range sources {
+		items[i] = update.add("")
+	}
+	for i, source := range sources {
+		entries := []string{
+			"/Title " + pdfTextString(source.Title),
+			fmt.Sprintf("/Parent %d 0 R", outlinesNum),
+			fmt.Sprintf("/Dest [%d 0 R /Fit]", stats.Pages[source.Page-1]),
+		}
+		if i > 0 {
+			entries = append(entries, fmt.Sprintf("/Prev %d 0 R", items[i-1]))
+		}
+		if i < len(items)-1 {
+			entries = append(entries, fmt.Sprintf("/Next %d 0 R", items[i+1]))
+		}
+		if kids := children[i]; len(kids) > 0 {
+			visible := 0
+			for j, kid := range kids {
+				body, _ := objectBody(data, offsets, kid)
+				visible += 1 + max(outlineCount(body), 0)
+				update.set(kid, relinkedOutlineItem(body, items[i], kids, j))
+			}
+			entries = append(entries,
+				fmt.Sprintf("/First %d 0 R /Last %d 0 R /Count %d", kids[0], kids[len(kids)-1], -visible))
+		}
+		update.set(items[i], "<< "+strings.Join(entries, " ")+" >>")
+	}
+
+	outlines := "<< /Type /Outlines /Count 0 >>"
+	if len(items) > 0 {
+		outlines = fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>",
+			items[0], items[len(items)-1], len(items))
+	}
+	update.set(outlinesNum, outlines)
+	update.set(catalogNum, withOutlines(catalog, outlinesNum))
+
+	tempPath := outputPath + ".bookmarks.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入书签",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// groupExistingOutlines 按目标页把目录中已有的顶层书签分到各来源下，返回与sources对应的书签对象编号
+func groupExistingOutlines(data []byte, offsets map[int]int, catalog []byte, pages []int, sources []SourceBookmark) [][]int {
+	groups := make([][]int, len(sources))
+	m := outlinesRefPattern.FindSubmatch(catalog)
+	if m == nil || len(sources) == 0 {
+		return groups
+	}
+	rootNum, _ := strconv.Atoi(string(m[1]))
+	root, ok := objectBody(data, offsets, rootNum)
+	if !ok {
+		return groups
+	}
+
+	pageIndex := make(map[int]int, len(pages))
+	for i, num := range pages {
+		pageIndex[num] = i + 1
+	}
+
+	current := 0
+	visited := make(map[int]bool)
+	next := outlineFirstPattern.FindSubmatch(root)
+	for next != nil && len(visited) < maxOutlineItems {
+		num, _ := strconv.Atoi(string(next[1]))
+		body, ok := objectBody(data, offsets, num)
+		if !ok || visited[num] {
+			break
+		}
+		visited[num] = true
+
+		if dest := outlineDestPattern.FindSubmatch(body); dest != nil {
+			pageNum, _ := strconv.Atoi(string(dest[1]))
+			if page, ok := pageIndex[pageNum]; ok {
+				current = sourceForPage(sources, page, current)
+			}
+		}
+		groups[current] = append(groups[current], num)
+		next = outlineNextPattern.FindSubmatch(topLevelOnly(body))
+	}
+	return groups
+}
+
+// sourceForPage 返回包含page的来源下标，不属于任何来源时返回fallback
+func sourceForPage(sources []SourceBookmark, page, fallback int) int {
+	for i, source := range sources {
+		if page >= source.Page && page < source.Page+max(source.Pages, 1) {
+			return i
+		}
+	}
+	return fallback
+}
+
+// relinkedOutlineItem 返回挂到新父书签下的书签内容，Prev/Next按kids中的顺序重新链接
+func relinkedOutlineItem(body []byte, parent int, kids []int, i int) string {
+	item := stripTopLevelLinks(string(bytes.TrimSpace(body)))
+	links := fmt.Sprintf("<< /Parent %d 0 R", parent)
+	if i > 0 {
+		links += fmt.Sprintf(" /Prev %d 0 R", kids[i-1])
+	}
+	if i < len(kids)-1 {
+		links += fmt.Sprintf(" /Next %d 0 R", kids[i+1])
+	}
+	return strings.Replace(item, "<<", links, 1)
+}
+
+// stripTopLevelLinks 删除书签字典最外层的 Parent/Prev/Next 条目，保留嵌套字典（如动作链的 /Next）
+func stripTopLevelLinks(item string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(item); i++ {
+		switch {
+		case strings.HasPrefix(item[i:], "<<"):
+			depth++
+			b.WriteString("<<")
+			i++
+			continue
+		case strings.HasPrefix(item[i:], ">>"):
+			depth--
+			b.WriteString(">>")
+			i++
+			continue
+		case depth == 1 && item[i] == '/':
+			if m := outlineLinkPattern.FindStringIndex(item[i:]); m != nil {
+				i += m[1] - 1
+				continue
+			}
+		}
+		b.WriteByte(item[i])
+	}
+	return b.String()
+}
+
+// topLevelOnly 返回去掉嵌套字典后的对象内容，用于读取最外层的条目
+func topLevelOnly(body []byte) []byte {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(body); i++ {
+		switch {
+		case i+1 < len(body) && body[i] == '<' && body[i+1] == '<':
+			depth++
+			i++
+		case i+1 < len(body) && body[i] == '>' && body[i+1] == '>':
+			depth--
+			i++
+		case depth <= 1:
+			out = append(out, body[i])
+		}
+	}
+	return out
+}
+
+// outlineCount 返回书签最外层的 /Count，不存在时为0
+func outlineCount(body []byte) int {
+	m := outlineCountPattern.FindSubmatch(topLevelOnly(body))
+	if m == nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(string(m[1]))
+	return count
+}
+
+// withOutlines 返回引用新书签树并在打开时显示书签面板的目录内容，替换原有的 /Outlines
+func withOutlines(catalog []byte, outlinesNum int) string {
+	body := outlinesRefPattern.ReplaceAllString(string(bytes.TrimSpace(catalog)), "")
+	entries := fmt.Sprintf("<< /Outlines %d 0 R", outlinesNum)
+	if !pageModePattern.MatchString(body) {
+		entries += " /PageMode /UseOutlines"
+	}
+	return strings.Replace(body, "<<", entries, 1)
+}
+
+// SourceBookmarkTitle 返回来源文件的书签文字：文档信息中的标题，没有标题时使用文件名
+func SourceBookmarkTitle(filePath, displayName string) string {
+	if data, err := os.ReadFile(filePath); err == nil {
+		if title := documentTitle(data); title != "" {
+			return title
+		}
+	}
+	return filepath.Base(displayName)
+}
+
+// documentTitle 读取trailer引用的文档信息字典中的 /Title，不存在或无法解析时返回空串
+func documentTitle(data []byte) string {
+	return strings.TrimSpace(readDocumentInfo(data, objectBodies(data))["Title"])
+}
+
+// decodePDFString 解码位于raw开头的字面字符串或十六进制字符串，支持UTF-16BE（带BOM）
+func decodePDFString(raw []byte) string {
+	var value []byte
+	switch {
+	case bytes.HasPrefix(raw, []byte("(")):
+		depth := 0
+		for i := 0; i < len(raw); i++ {
+			switch raw[i] {
+			case '\\':
+				i++
+				continue
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				value = []byte(literalEscapeReplace.Replace(string(raw[1:i])))
+				break
+			}
+		}
+	case bytes.HasPrefix(raw, []byte("<")) && !bytes.HasPrefix(raw, []byte("<<")):
+		end := bytes.IndexByte(raw, '>')
+		if end < 0 {
+			return ""
+		}
+		digits := strings.Join(strings.Fields(string(raw[1:end])), "")
+		if len(digits)%2 == 1 {
+			digits += "0"
+		}
+		decoded, err := hex.DecodeString(digits)
+		if err != nil {
+			return ""
+		}
+		value = decoded
+	}
+
+	if len(value) >= 2 && value[0] == 0xFE && value[1] == 0xFF {
+		units := make([]uint16, 0, len(value)/2-1)
+		for i := 2; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	// 其余按单字节编码处理，ASCII以外的字符近似为Latin-1
+	runes := make([]rune, len(value))
+	for i, c := range value {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outlineNode 测试中读回的书签
+type outlineNode struct {
+	Title    string
+	Page     int // 目标页（从1开始），无法确定时为0
+	Children []outlineNode
+}
+
+// readOutlines 读回文件目录中的书签树
+func readOutlines(t *testing.T, path string) []outlineNode {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	stats, err := WalkPageTree(path, data, nil)
+	require.NoError(t, err)
+	pageIndex := make(map[int]int, len(stats.Pages))
+	for i, num := range stats.Pages {
+		pageIndex[num] = i + 1
+	}
+
+	offsets := indexObjects(data)
+	roots := rootRefPattern.FindAllSubmatch(data, -1)
+	require.NotEmpty(t, roots)
+	catalogNum, _ := strconv.Atoi(string(roots[len(roots)-1][1]))
+	catalog, ok := objectBody(data, offsets, catalogNum)
+	require.True(t, ok)
+	m := outlinesRefPattern.FindSubmatch(catalog)
+	require.NotNil(t, m, "目录中应引用书签树")
+	outlinesNum, _ := strconv.Atoi(string(m[1]))
+
+	var walk func(parent int) []outlineNode
+	walk = func(parent int) []outlineNode {
+		body, ok := objectBody(data, offsets, parent)
+		require.True(t, ok)
+		var nodes []outlineNode
+		next := outlineFirstPattern.FindSubmatch(topLevelOnly(body))
+		for next != nil {
+			num, _ := strconv.Atoi(string(next[1]))
+			item, ok := objectBody(data, offsets, num)
+			require.True(t, ok)
+			node := outlineNode{Children: walk(num)}
+			if idx := bytes.Index(item, []byte("/Title")); idx >= 0 {
+				node.Title = decodePDFString(bytes.TrimLeft(item[idx+len("/Title"):], " "))
+			}
+			if dest := outlineDestPattern.FindSubmatch(item); dest != nil {
+				pageNum, _ := strconv.Atoi(string(dest[1]))
+				node.Page = pageIndex[pageNum]
+			}
+			nodes = append(nodes, node)
+			next = outlineNextPattern.FindSubmatch(topLevelOnly(item))
+		}
+		return nodes
+	}
+	return walk(outlinesNum)
+}
+
+func TestAddSourceBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(5))
+	output := filepath.Join(dir, "out.pdf")
+
+	require.NoError(t, AddSourceBookmarks(input, output, []SourceBookmark{
+		{Title: "contract_A.pdf", Page: 1, Pages: 2},
+		{Title: "合同 B", Page: 3, Pages: 3},
+	}))
+
+	assert.Equal(t, []outlineNode{
+		{Title: "contract_A.pdf", Page: 1},
+		{Title: "合同 B", Page: 3},
+	}, readOutlines(t, output))
+
+	stats, err := WalkPageTreeFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.PageCount)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/PageMode /UseOutlines")
+}
+
+func TestAddSourceBookmarks_NestsExistingOutlines(t *testing.T) {
+	dir := t.TempDir()
+	// 页面为对象3..6；已有书签8、9分别指向第1页和第4页，书签10使用命名目标
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 7 0 R /PageMode /UseNone >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R] /Count 4 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Outlines /First 8 0 R /Last 10 0 R /Count 3 >>",
+		"<< /Title (Intro) /Parent 7 0 R /Next 9 0 R /Dest [3 0 R /Fit] >>",
+		"<< /Title (Terms) /Parent 7 0 R /Prev 8 0 R /Next 10 0 R /A << /S /GoTo /D [6 0 R /Fit] >> >>",
+		"<< /Title (Appendix) /Parent 7 0 R /Prev 9 0 R /Dest (appendix) >>",
+	}
+	input := createTestFile(t, dir, "merged.pdf", buildPDF(objects))
+
+	require.NoError(t, AddSourceBookmarks(input, input, []SourceBookmark{
+		{Title: "a.pdf", Page: 1, Pages: 2},
+		{Title: "b.pdf", Page: 3, Pages: 2},
+	}))
+
+	assert.Equal(t, []outlineNode{
+		{Title: "a.pdf", Page: 1, Children: []outlineNode{{Title: "Intro", Page: 1}}},
+		{Title: "b.pdf", Page: 3, Children: []outlineNode{{Title: "Terms", Page: 4}, {Title: "Appendix"}}},
+	}, readOutlines(t, input))
+
+	data, err := os.ReadFile(input)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/UseOutlines", "已有的 /PageMode 应保留")
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestAddSourceBookmarks_RejectsPagesOutOfRange(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	err := AddSourceBookmarks(input, output, []SourceBookmark{{Title: "a.pdf", Page: 2, Pages: 3}})
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.False(t, fileExists(output))
+}
+
+func TestSourceBookmarkTitle(t *testing.T) {
+	dir := t.TempDir()
+	withInfo := func(info string) []byte {
+		data := buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+			info,
+		})
+		return bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Info 4 0 R"), 1)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"literal title", withInfo(`<< /Title (Master \(Signed\)) /Author (x) >>`), "Master (Signed)"},
+		{"utf-16 title", withInfo("<< /Title <FEFF5408540C> >>"), "合同"},
+		{"empty title", withInfo("<< /Title () >>"), "contract_A.pdf"},
+		{"no info", buildFlatPDF(1), "contract_A.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, "copy.pdf", tt.content)
+			assert.Equal(t, tt.want, SourceBookmarkTitle(path, filepath.Join("/docs", "contract_A.pdf")))
+		})
+	}
+}
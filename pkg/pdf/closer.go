@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed 对象关闭后再调用其方法时返回的错误。
+// 实际返回值是以它为Cause的PDFError，可用 errors.Is(err, ErrClosed) 判断。
+var ErrClosed = errors.New("对象已关闭")
+
+// closeGuard 实现包内持有资源的类型共同遵守的Close契约：
+//   - Close 幂等，可并发调用，之后的调用返回首次关闭的错误；
+//   - Close 先拒绝新的操作，再等待进行中的操作结束，最后才释放资源；
+//   - 关闭后调用的方法返回包装了 ErrClosed 的错误，而不是空指针panic。
+//
+// 需要取消而不是等待进行中操作的类型，可以用 context 派生在关闭时取消的上下文。
+// 零值可直接使用。
+type closeGuard struct {
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+	ops    sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// enter 登记一个进行中的操作；已关闭时返回ErrClosed错误。成功时调用方必须调用 leave
+func (g *closeGuard) enter(what, file string) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.closed {
+		return closedError(what, file)
+	}
+	g.ops.Add(1)
+	return nil
+}
+
+// leave 结束 enter 登记的操作
+func (g *closeGuard) leave() {
+	g.ops.Done()
+}
+
+// isClosed 是否已开始关闭
+func (g *closeGuard) isClosed() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.closed
+}
+
+// closing 返回开始关闭时关闭的通道
+func (g *closeGuard) closing() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.doneLocked()
+}
+
+// doneLocked 延迟创建关闭通道，调用方需持有写锁
+func (g *closeGuard) doneLocked() chan struct{} {
+	if g.done == nil {
+		g.done = make(chan struct{})
+	}
+	return g.done
+}
+
+// context 派生一个在开始关闭时取消的上下文
+func (g *closeGuard) context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	closing := g.closing()
+	go func() {
+		select {
+		case <-closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// close 执行一次关闭：拒绝新操作，等待进行中的操作结束后调用 release。
+// 并发或重复调用会等待首次关闭完成，并返回同一个错误。
+func (g *closeGuard) close(release func() error) error {
+	g.once.Do(func() {
+		g.mu.Lock()
+		g.closed = true
+		close(g.doneLocked())
+		g.mu.Unlock()
+
+		g.ops.Wait()
+		if release != nil {
+			g.err = release()
+		}
+	})
+	return g.err
+}
+
+// closedError 构造关闭后调用方法的错误
+func closedError(what, file string) error {
+	return &PDFError{
+		Type:    ErrorInvalidInput,
+		Message: what + "已关闭",
+		File:    file,
+		Cause:   ErrClosed,
+	}
+}
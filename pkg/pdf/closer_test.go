@@ -0,0 +1,314 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeContractCase 一个持有资源的类型的Close契约测试用例
+type closeContractCase struct {
+	open func(t *testing.T) io.Closer // 创建处于可用状态的实例
+	op   func(c io.Closer) error      // 有代表性的操作，关闭后应返回ErrClosed
+}
+
+// closeContractCases 包内所有带Close方法的导出类型。
+// 新增持有资源的类型时必须在此注册，TestCloseContract_AllTypesRegistered 会检查。
+func closeContractCases() []closeContractCase {
+	return []closeContractCase{
+		{
+			open: func(t *testing.T) io.Closer {
+				adapter, err := NewPDFCPUAdapter(&PDFCPUConfig{TempDirectory: t.TempDir()})
+				require.NoError(t, err)
+				return adapter
+			},
+			op: func(c io.Closer) error {
+				_, err := c.(*PDFCPUAdapter).IsEncrypted("missing.pdf")
+				return err
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				return NewAdapterPool(1, &PDFCPUConfig{TempDirectory: t.TempDir()})
+			},
+			op: func(c io.Closer) error {
+				adapter, err := c.(*AdapterPool).Get()
+				c.(*AdapterPool).Put(adapter, err)
+				return err
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				return &PDFCPUCLIAdapter{
+					cliPath: filepath.Join(t.TempDir(), "pdfcpu-missing"),
+					tempDir: t.TempDir(),
+					logger:  discardLogger{},
+				}
+			},
+			op: func(c io.Closer) error {
+				_, err := c.(*PDFCPUCLIAdapter).GetVersion()
+				return err
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				reader, err := NewPDFReader(createTestPDFFile(t, t.TempDir(), "reader.pdf"))
+				require.NoError(t, err)
+				return reader
+			},
+			op: func(c io.Closer) error {
+				_, err := c.(*PDFReader).GetPageCount()
+				return err
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				reader, err := NewEnhancedPDFReader(createTestPDFFile(t, t.TempDir(), "reader.pdf"), ValidationBasic)
+				require.NoError(t, err)
+				return reader
+			},
+			op: func(c io.Closer) error {
+				return c.(*EnhancedPDFReader).ValidateWithMode(ValidationBasic)
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				dir := t.TempDir()
+				writer, err := NewPDFWriter(filepath.Join(dir, "out.pdf"), &WriterOptions{TempDirectory: dir})
+				require.NoError(t, err)
+				require.NoError(t, writer.Open())
+				return writer
+			},
+			op: func(c io.Closer) error {
+				return c.(*PDFWriter).AddContent([]byte("%PDF-1.4\n"))
+			},
+		},
+		{
+			open: func(t *testing.T) io.Closer {
+				dir := t.TempDir()
+				merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+				merger.adapter = nil
+				return &mergerUnderTest{StreamingMerger: merger, input: createTestPDFFile(t, dir, "in.pdf"), dir: dir}
+			},
+			op: func(c io.Closer) error {
+				m := c.(*mergerUnderTest)
+				_, err := m.MergeStreaming(context.Background(), []string{m.input}, filepath.Join(m.dir, "out.pdf"), nil)
+				return err
+			},
+		},
+	}
+}
+
+// mergerUnderTest 为合并器附带测试输入
+type mergerUnderTest struct {
+	*StreamingMerger
+	input string
+	dir   string
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+// closerTypeName 返回实例的类型名
+func closerTypeName(c io.Closer) string {
+	if m, ok := c.(*mergerUnderTest); ok {
+		c = m.StreamingMerger
+	}
+	return reflect.TypeOf(c).Elem().Name()
+}
+
+// checkCloseContract 检查Close契约：幂等、关闭后返回ErrClosed，以及操作与Close并发时的安全性
+func checkCloseContract(t *testing.T, tc closeContractCase) {
+	t.Run("idempotent", func(t *testing.T) {
+		c := tc.open(t)
+		assert.False(t, errors.Is(tc.op(c), ErrClosed), "关闭前的操作不应返回ErrClosed")
+
+		first := c.Close()
+		assert.Equal(t, first, c.Close(), "重复关闭应返回首次关闭的结果")
+
+		err := tc.op(c)
+		assert.True(t, errors.Is(err, ErrClosed), "关闭后的操作应返回ErrClosed，实际: %v", err)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		for round := 0; round < 5; round++ {
+			c := tc.open(t)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !errors.Is(tc.op(c), ErrClosed) {
+				}
+			}()
+
+			closeErrs := make([]error, 2)
+			for i := range closeErrs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					closeErrs[i] = c.Close()
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, closeErrs[0], closeErrs[1], "并发关闭应返回相同结果")
+			assert.True(t, errors.Is(tc.op(c), ErrClosed))
+		}
+	})
+}
+
+func TestCloseContract(t *testing.T) {
+	for _, tc := range closeContractCases() {
+		tc := tc
+		c := tc.open(t)
+		name := closerTypeName(c)
+		c.Close()
+		t.Run(name, func(t *testing.T) {
+			checkCloseContract(t, tc)
+		})
+	}
+}
+
+// TestCloseContract_AllTypesRegistered 包内每个带Close方法的导出类型都必须登记在契约测试中
+func TestCloseContract_AllTypesRegistered(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	var withClose []string
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Name.Name != "Close" {
+					continue
+				}
+				recv := fn.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok && ast.IsExported(ident.Name) {
+					withClose = append(withClose, ident.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(withClose)
+
+	var registered []string
+	for _, tc := range closeContractCases() {
+		c := tc.open(t)
+		registered = append(registered, closerTypeName(c))
+		c.Close()
+	}
+	sort.Strings(registered)
+
+	assert.Equal(t, withClose, registered, "带Close方法的导出类型必须在closeContractCases中登记")
+}
+
+func TestCloseGuard_CloseWaitsForOperations(t *testing.T) {
+	var g closeGuard
+	require.NoError(t, g.enter("测试", ""))
+
+	released := make(chan struct{})
+	closed := make(chan error, 1)
+	go func() {
+		closed <- g.close(func() error {
+			close(released)
+			return errors.New("释放失败")
+		})
+	}()
+
+	// 关闭开始后拒绝新操作，但在进行中的操作结束前不释放资源
+	require.Eventually(t, g.isClosed, time.Second, time.Millisecond)
+	assert.True(t, errors.Is(g.enter("测试", ""), ErrClosed))
+	select {
+	case <-released:
+		t.Fatal("进行中的操作结束前不应释放资源")
+	default:
+	}
+
+	g.leave()
+	err := <-closed
+	assert.EqualError(t, err, "释放失败")
+	assert.Equal(t, err, g.close(func() error {
+		t.Error("资源只应释放一次")
+		return nil
+	}))
+}
+
+func TestCloseGuard_ContextCanceledOnClose(t *testing.T) {
+	var g closeGuard
+	require.NoError(t, g.enter("测试", ""))
+	ctx, cancel := g.context(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		g.leave()
+		done <- ctx.Err()
+	}()
+
+	require.NoError(t, g.close(nil))
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestPDFWriter_CloseWaitsForWrite(t *testing.T) {
+	dir := t.TempDir()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	backend := &fakeWriteBackend{writeTemp: func(int) error {
+		close(entered)
+		<-release
+		return &PDFError{Type: ErrorValidation, Message: "测试写入"}
+	}}
+
+	writer, err := NewPDFWriter(filepath.Join(dir, "out.pdf"), &WriterOptions{TempDirectory: dir, WriteBackend: backend})
+	require.NoError(t, err)
+	require.NoError(t, writer.Open())
+
+	writeDone := make(chan struct{})
+	go func() {
+		writer.Write(context.Background(), nil)
+		close(writeDone)
+	}()
+	<-entered
+
+	closeDone := make(chan error, 1)
+	go func() { closeDone <- writer.Close() }()
+
+	select {
+	case <-closeDone:
+		t.Fatal("Close应等待进行中的Write结束")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-closeDone)
+	select {
+	case <-writeDone:
+	default:
+		t.Fatal("Close返回前Write应已结束")
+	}
+	_, err = writer.Write(context.Background(), nil)
+	assert.True(t, errors.Is(err, ErrClosed))
+}
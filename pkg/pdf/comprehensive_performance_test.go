@@ -574,7 +574,8 @@ func BenchmarkStreamingMerger(b *testing.B) {
 	for i := 0; i < 10; i++ {
 		fileName := fmt.Sprintf("bench_test_%d.pdf", i)
 		filePath := filepath.Join(testDir, fileName)
-		content := createPerformanceTestPDFContent(20)
+		// 页数各不相同，避免作为重复输入被跳过
+		content := buildFlatPDF(20 + i)
 		err := ioutil.WriteFile(filePath, content, 0644)
 		if err != nil {
 			b.Fatalf("创建测试文件失败: %v", err)
@@ -584,29 +585,43 @@ func BenchmarkStreamingMerger(b *testing.B) {
 
 	config := func() *StreamingConfig { c := DefaultStreamingConfig(); c.MaxConcurrentChunks = 4; return c }()
 
-	b.ResetTimer()
-	b.ReportAllocs()
-
-	for i := 0; i < b.N; i++ {
-		options := &MergeOptions{
-			MaxMemoryUsage:    100 * 1024 * 1024, // 100MB
-			TempDirectory:     testDir,
-			EnableGC:          true,
-			ChunkSize:         10,
-			UseStreaming:      true,
-			OptimizeMemory:    true,
-			ConcurrentWorkers: config.MaxConcurrentChunks,
+	// 对比每次合并新建适配器与从适配器池取出适配器
+	for _, pooled := range []bool{false, true} {
+		name := "new_adapter"
+		var pool *AdapterPool
+		if pooled {
+			name = "pooled"
+			pool = NewAdapterPool(0, &PDFCPUConfig{TempDirectory: testDir})
 		}
-		merger := NewStreamingMerger(options)
-		outputPath := filepath.Join(testDir, fmt.Sprintf("bench_output_%d.pdf", i))
-
-		ctx := context.Background()
-		_, err := merger.MergeStreaming(ctx, testFiles, outputPath, nil)
-		if err != nil {
-			b.Fatalf("合并失败: %v", err)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				options := &MergeOptions{
+					MaxMemoryUsage:    100 * 1024 * 1024, // 100MB
+					TempDirectory:     testDir,
+					EnableGC:          true,
+					ChunkSize:         10,
+					UseStreaming:      true,
+					OptimizeMemory:    true,
+					ConcurrentWorkers: config.MaxConcurrentChunks,
+					AdapterPool:       pool,
+				}
+				merger := NewStreamingMerger(options)
+				outputPath := filepath.Join(testDir, fmt.Sprintf("bench_output_%d.pdf", i))
+
+				ctx := context.Background()
+				_, err := merger.MergeStreaming(ctx, testFiles, outputPath, nil)
+				merger.Close()
+				if err != nil {
+					b.Fatalf("合并失败: %v", err)
+				}
+
+				os.Remove(outputPath)
+			}
+		})
+		if pool != nil {
+			pool.Close()
 		}
-
-		os.Remove(outputPath)
 	}
 }
 
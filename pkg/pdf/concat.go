@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	objStmPattern = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	anyRefPattern = regexp.MustCompile(`\b(\d+)\s+\d+\s+R\b`)
+	streamKeyword = regexp.MustCompile(`>>\s*stream\r?\n`)
+)
+
+const (
+	concatRootNum  = 1 // 合并结果的页面树根
+	concatCatalog  = 2 // 合并结果的目录
+	concatFirstNum = 3 // 输入对象重新编号的起点
+)
+
+// concatenateInputs 内置合并的入口，可在测试中替换
+var concatenateInputs = concatenatePDFs
+
+// concatenatePDFs 不依赖外部后端的最小合并：把每个输入的对象重新编号后写入outputPath，
+// 并用新的页面树根按输入顺序挂接各输入的页面，页面从祖先节点继承的属性写到页面本身。
+// 大纲、表单等文档级结构不会保留；加密文件和使用对象流的文件无法处理，返回错误。
+func concatenatePDFs(files []string, outputPath string) error {
+	if len(files) == 0 {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "没有输入文件",
+		}
+	}
+
+	var objects []string // 下标i对应对象编号 concatFirstNum+i
+	var kids []int
+	for _, file := range files {
+		pages, err := appendConcatInput(file, &objects)
+		if err != nil {
+			return err
+		}
+		kids = append(kids, pages...)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, 0, len(objects)+2)
+	writeObject := func(num int, body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", num, body)
+	}
+	writeObject(concatRootNum, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", refList(kids), len(kids)))
+	writeObject(concatCatalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", concatRootNum))
+	for i, body := range objects {
+		writeObject(concatFirstNum+i, body)
+	}
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, concatCatalog, xrefOffset)
+
+	tempPath := outputPath + ".concat.tmp"
+	if err := os.WriteFile(tempPath, out.Bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入合并结果",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// appendConcatInput 把一个输入的全部对象重新编号后追加到objects，返回其页面的新编号（按页序）
+func appendConcatInput(file string, objects *[]string) ([]int, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    file,
+			Cause:   err,
+		}
+	}
+	if encrypted, _ := hasEncryptEntry(file); encrypted {
+		return nil, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "内置合并无法处理加密文件",
+			File:    file,
+		}
+	}
+	if objStmPattern.Match(data) {
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: "内置合并无法处理使用对象流的文件",
+			File:    file,
+		}
+	}
+
+	stats, err := WalkPageTree(file, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    file,
+			Cause:   err,
+		}
+	}
+
+	nums := make([]int, 0, len(offsets))
+	for num := range offsets {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	renumbered := make(map[int]int, len(nums))
+	for _, num := range nums {
+		renumbered[num] = concatFirstNum + len(*objects) + len(renumbered)
+	}
+
+	isPage := make(map[int]bool, len(stats.Pages))
+	for _, num := range stats.Pages {
+		isPage[num] = true
+	}
+	for _, num := range nums {
+		body, _ := objectBody(data, offsets, num)
+		object := string(bytes.TrimSpace(body))
+		if isPage[num] {
+			object = reparentedPage(data, offsets, body, rootNum)
+		}
+		object = renumberRefs(object, renumbered)
+		if isPage[num] {
+			object = parentRefPattern.ReplaceAllString(object, fmt.Sprintf("/Parent %d 0 R", concatRootNum))
+		}
+		*objects = append(*objects, object)
+	}
+
+	pages := make([]int, len(stats.Pages))
+	for i, num := range stats.Pages {
+		pages[i] = renumbered[num]
+	}
+	return pages, nil
+}
+
+// renumberRefs 按映射改写对象中（流数据之前）的间接引用，映射中没有的对象改为null
+func renumberRefs(object string, renumbered map[int]int) string {
+	head, stream := object, ""
+	if loc := streamKeyword.FindStringIndex(object); loc != nil {
+		head, stream = object[:loc[0]+2], object[loc[0]+2:]
+	}
+	head = anyRefPattern.ReplaceAllStringFunc(head, func(ref string) string {
+		num, _ := strconv.Atoi(anyRefPattern.FindStringSubmatch(ref)[1])
+		if newNum, ok := renumbered[num]; ok {
+			return fmt.Sprintf("%d 0 R", newNum)
+		}
+		return "null"
+	})
+	return head + stream
+}
@@ -0,0 +1,98 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeObjStmPDF 写出使用对象流的测试文件，内置合并无法处理
+func writeObjStmPDF(t *testing.T, dir, name string) string {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /ObjStm /N 0 /First 0 /Length 0 >>\nstream\n\nendstream",
+	})
+	return createTestFile(t, dir, name, data)
+}
+
+func TestConcatenatePDFs_KeepsPagesAndInheritedBoxes(t *testing.T) {
+	dir := t.TempDir()
+	boxed := writeBoxedPDF(t, dir, "boxed.pdf")
+	flat := createTestFile(t, dir, "flat.pdf", buildFlatPDF(3))
+	output := filepath.Join(dir, "out.pdf")
+
+	require.NoError(t, concatenatePDFs([]string{boxed, flat}, output))
+
+	count, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	boxes, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	require.Len(t, boxes, 5)
+	assert.Equal(t, [4]float64{0, 0, 600, 800}, boxes[0].MediaBox, "从页面树节点继承的MediaBox应写到页面本身")
+	assert.Equal(t, [4]float64{20, 20, 580, 780}, boxes[1].CropBox)
+	assert.Equal(t, [4]float64{0, 0, 612, 792}, boxes[4].MediaBox)
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestRenumberRefs(t *testing.T) {
+	object := "<< /Contents 4 0 R /Resources 9 0 R /Length 5 >>\nstream\n1 0 R\nendstream"
+	got := renumberRefs(object, map[int]int{4: 12})
+	assert.Equal(t, "<< /Contents 12 0 R /Resources null /Length 5 >>\nstream\n1 0 R\nendstream", got, "流数据不应被改写")
+}
+
+func TestFallbackMerge_UnsupportedInputReportsAdapterUnavailable(t *testing.T) {
+	dir := t.TempDir()
+	first := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+	second := writeObjStmPDF(t, dir, "b.pdf")
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir})
+	adapterErr := errors.New("backend missing")
+	merger.adapter = nil
+	merger.adapterErr = adapterErr
+
+	err := merger.fallbackMerge([]string{first, second}, output)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorAdapterUnavailable, pdfErr.Type)
+	assert.Equal(t, second, pdfErr.File)
+	assert.ErrorIs(t, err, adapterErr)
+	assert.NoFileExists(t, output, "合并失败时不应写出任何输出")
+
+	wrapped := fmt.Errorf("流式合并失败: %w", &PDFError{Type: ErrorProcessing, Message: "PDF processing failed", Cause: err})
+	assert.Same(t, pdfErr, AdapterUnavailableError(wrapped))
+	assert.Nil(t, AdapterUnavailableError(errors.New("other")))
+}
+
+func TestMergeStreaming_WithoutAdapterWritesValidPDF(t *testing.T) {
+	dir := t.TempDir()
+	inputs := []string{
+		createTestFile(t, dir, "a.pdf", buildFlatPDF(2)),
+		createTestFile(t, dir, "b.pdf", buildFlatPDF(1)),
+	}
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	_, err := merger.MergeStreaming(context.Background(), inputs, output, nil)
+	require.NoError(t, err)
+
+	count, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	_, err = os.Stat(output + ".fallback")
+	assert.True(t, os.IsNotExist(err), "不应再写出.fallback文本文件")
+}
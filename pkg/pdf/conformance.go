@@ -0,0 +1,280 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConformanceNone 文件没有声明任何标准符合性
+const ConformanceNone = "none"
+
+var (
+	metadataRefPattern     = regexp.MustCompile(`/Metadata\s+(\d+)\s+\d+\s+R`)
+	outputIntentsPattern   = regexp.MustCompile(`/OutputIntents\s*(\[(?:[^\[\]]|\[[^\]]*\])*\]|\d+\s+\d+\s+R)`)
+	outputIntentSPattern   = regexp.MustCompile(`/S\s*/(GTS_PDF[A-Z0-9]+|ISO_PDFE1)\b`)
+	pdfaPartPattern        = regexp.MustCompile(`pdfaid:part\s*(?:=\s*["']|>)\s*(\d)`)
+	pdfaConformancePattern = regexp.MustCompile(`pdfaid:conformance\s*(?:=\s*["']|>)\s*([A-Za-z])`)
+	pdfxVersionPattern     = regexp.MustCompile(`GTS_PDFXVersion\s*(?:=\s*["']|>|\()\s*(PDF/X-[^"'<)]+)`)
+)
+
+// ConformanceReport 文件声明的标准符合性（PDF/A、PDF/X）以及与声明不一致的明显问题。
+// 声明取自目录引用的XMP元数据和 /OutputIntents，PDF/X版本也可以来自文档信息字典。
+// 只检查声明和合并相关的结构，不是完整的PDF/A验证。
+type ConformanceReport struct {
+	File          string   `json:"file"`
+	Conformance   string   `json:"conformance"`              // 例如 "PDF/A-2b"、"PDF/X-4"，同时声明时用逗号分隔，没有声明时为 "none"
+	PDFAPart      int      `json:"pdfa_part,omitempty"`      // PDF/A 部分（1、2、3、4），0表示未声明
+	PDFALevel     string   `json:"pdfa_level,omitempty"`     // PDF/A 符合级别（a、b、u），PDF/A-4没有级别
+	PDFXVersion   string   `json:"pdfx_version,omitempty"`   // 例如 "PDF/X-4"、"PDF/X-1a:2001"
+	OutputIntents []string `json:"output_intents,omitempty"` // 输出意图的子类型，例如 GTS_PDFA1、GTS_PDFX
+	HasMetadata   bool     `json:"has_metadata"`             // 目录是否引用了XMP元数据流
+	Issues        []string `json:"issues,omitempty"`         // 与声明不一致的问题
+}
+
+// Declared 判断文件是否声明了任何标准符合性
+func (r *ConformanceReport) Declared() bool {
+	return r.PDFAPart > 0 || r.PDFXVersion != ""
+}
+
+// Compliant 判断文件声明了标准符合性且没有发现问题
+func (r *ConformanceReport) Compliant() bool {
+	return r.Declared() && len(r.Issues) == 0
+}
+
+// hasOutputIntent 判断是否有指定子类型的输出意图
+func (r *ConformanceReport) hasOutputIntent(subtype string) bool {
+	for _, intent := range r.OutputIntents {
+		if intent == subtype {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConformance 不依赖pdfcpu读取文件声明的PDF/A、PDF/X符合性并检查输出意图、加密等明显问题。
+// 文件无法读取或找不到文档目录时返回错误；没有声明时返回Conformance为 "none" 的报告。
+func ValidateConformance(filePath string) (*ConformanceReport, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	report, err := readConformance(data)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法读取文档目录",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	report.File = filePath
+	return report, nil
+}
+
+// readConformance ValidateConformance 的实现，data 为文件内容
+func readConformance(data []byte) (*ConformanceReport, error) {
+	index, catalog, err := readCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConformanceReport{}
+	if m := metadataRefPattern.FindSubmatch(catalog); m != nil {
+		num, _ := strconv.Atoi(string(m[1]))
+		if object, err := index.object(num); err == nil {
+			report.HasMetadata = true
+			if xmp, err := decodeStream(object); err == nil {
+				readXMPConformance(report, xmp)
+			}
+		}
+	}
+	if report.PDFXVersion == "" {
+		if info := documentInfo(data, index); info != nil {
+			if m := pdfxVersionPattern.FindSubmatch(info); m != nil {
+				report.PDFXVersion = strings.TrimSpace(string(m[1]))
+			}
+		}
+	}
+	report.OutputIntents = readOutputIntents(index, catalog)
+
+	report.Conformance = conformanceString(report)
+	report.Issues = conformanceIssues(report, data)
+	return report, nil
+}
+
+// readCatalog 返回对象索引和文档目录。优先沿交叉引用读取（可读取对象流中的目录），
+// 交叉引用无法解析时按对象头扫描并以最后一个 /Root 引用为准。
+func readCatalog(data []byte) (*xrefIndex, []byte, error) {
+	if index, err := readXRefIndex(data); err == nil {
+		for _, trailer := range index.trailers {
+			if m := rootRefPattern.FindSubmatch(trailer); m != nil {
+				num, _ := strconv.Atoi(string(m[1]))
+				if catalog, err := index.object(num); err == nil {
+					return index, catalog, nil
+				}
+				break
+			}
+		}
+	}
+
+	index := scanObjectIndex(data)
+	matches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return nil, nil, fmt.Errorf("未找到 /Root 引用")
+	}
+	num, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+	catalog, err := index.object(num)
+	if err != nil {
+		return nil, nil, err
+	}
+	return index, catalog, nil
+}
+
+// documentInfo 返回trailer引用的文档信息字典，不存在时返回nil
+func documentInfo(data []byte, index *xrefIndex) []byte {
+	matches := infoRefPattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	num, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+	info, err := index.object(num)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// readXMPConformance 从XMP元数据中读取 pdfaid 和 pdfxid 声明，支持属性和元素两种写法
+func readXMPConformance(report *ConformanceReport, xmp []byte) {
+	if m := pdfaPartPattern.FindSubmatch(xmp); m != nil {
+		report.PDFAPart, _ = strconv.Atoi(string(m[1]))
+		if m := pdfaConformancePattern.FindSubmatch(xmp); m != nil {
+			report.PDFALevel = strings.ToLower(string(m[1]))
+		}
+	}
+	if m := pdfxVersionPattern.FindSubmatch(xmp); m != nil {
+		report.PDFXVersion = strings.TrimSpace(string(m[1]))
+	}
+}
+
+// readOutputIntents 返回目录中各输出意图的子类型，输出意图数组和字典都可以是间接引用
+func readOutputIntents(index *xrefIndex, catalog []byte) []string {
+	m := outputIntentsPattern.FindSubmatch(catalog)
+	if m == nil {
+		return nil
+	}
+	array := m[1]
+	if !bytes.HasPrefix(array, []byte("[")) {
+		ref := indirectRefPattern.FindSubmatch(array)
+		num, _ := strconv.Atoi(string(ref[1]))
+		object, err := index.object(num)
+		if err != nil {
+			return nil
+		}
+		array = object
+	}
+
+	var intents []string
+	add := func(dict []byte) {
+		if s := outputIntentSPattern.FindSubmatch(dict); s != nil {
+			intents = append(intents, string(s[1]))
+		}
+	}
+	if dicts := bytes.Split(array, []byte("<<"))[1:]; len(dicts) > 0 {
+		// 直接写在数组中的输出意图字典，其中的引用是ICC配置文件而不是输出意图
+		for _, dict := range dicts {
+			add(dict)
+		}
+		return intents
+	}
+	for _, ref := range parseArrayRefs(bytes.TrimSpace(array)) {
+		if object, err := index.object(ref); err == nil {
+			add(object)
+		}
+	}
+	return intents
+}
+
+// conformanceString 把声明格式化为 "PDF/A-2b"、"PDF/X-4" 等，没有声明时返回 ConformanceNone
+func conformanceString(report *ConformanceReport) string {
+	var parts []string
+	if report.PDFAPart > 0 {
+		parts = append(parts, fmt.Sprintf("PDF/A-%d%s", report.PDFAPart, report.PDFALevel))
+	}
+	if report.PDFXVersion != "" {
+		parts = append(parts, report.PDFXVersion)
+	}
+	if len(parts) == 0 {
+		return ConformanceNone
+	}
+	return strings.Join(parts, ", ")
+}
+
+// conformanceIssues 检查声明与文件结构是否一致
+func conformanceIssues(report *ConformanceReport, data []byte) []string {
+	var issues []string
+	encrypted := encryptEntryPattern.Match(data)
+	if report.PDFAPart > 0 {
+		if !report.hasOutputIntent("GTS_PDFA1") {
+			issues = append(issues, "声明了PDF/A但缺少 GTS_PDFA1 输出意图")
+		}
+		if encrypted {
+			issues = append(issues, "PDF/A文件不允许加密")
+		}
+		if report.PDFAPart == 1 {
+			if m := headerVersionPattern.FindSubmatch(data); m != nil && string(m[1]) > "1.4" {
+				issues = append(issues, fmt.Sprintf("PDF/A-1要求PDF版本不高于1.4，文件版本为%s", m[1]))
+			}
+		}
+	}
+	if report.PDFXVersion != "" && !report.hasOutputIntent("GTS_PDFX") {
+		issues = append(issues, "声明了PDF/X但缺少 GTS_PDFX 输出意图")
+	}
+	if !report.HasMetadata && report.PDFAPart == 0 && report.hasOutputIntent("GTS_PDFA1") {
+		issues = append(issues, "有 GTS_PDFA1 输出意图但缺少XMP元数据，无法确认PDF/A声明")
+	}
+	return issues
+}
+
+// checkConformanceKept 合并成功后检查声明了PDF/A或PDF/X的输入，输出丢失了输出意图或符合性声明时记录警告
+func (sm *StreamingMerger) checkConformanceKept(result *MergeResult, files []string, outputPath string) {
+	var declared []*ConformanceReport
+	for _, file := range files {
+		if report, err := ValidateConformance(file); err == nil && report.Declared() {
+			declared = append(declared, report)
+		}
+	}
+	if len(declared) == 0 {
+		return
+	}
+
+	output, err := ValidateConformance(outputPath)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("无法检查输出的标准符合性: %v", err))
+		return
+	}
+	for _, input := range declared {
+		switch {
+		case len(output.OutputIntents) == 0:
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("输入 %s 声明为 %s，但合并输出没有保留输出意图，不再符合该标准", input.File, input.Conformance))
+		case output.Conformance != input.Conformance:
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("输入 %s 声明为 %s，合并输出的声明为 %s", input.File, input.Conformance, output.Conformance))
+		}
+	}
+	if output.Declared() && !output.Compliant() {
+		for _, issue := range output.Issues {
+			result.Warnings = append(result.Warnings, "合并输出: "+issue)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildConformancePDF 生成一页的测试文件，catalogExtra追加到目录字典，xmp非空时作为对象4的元数据流
+func buildConformancePDF(catalogExtra, xmp string, extra ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R " + catalogExtra + " >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+	}
+	return buildPDF(append(objects, extra...))
+}
+
+const pdfa2bXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="2" pdfaid:conformance="B"/>` +
+	`</rdf:RDF></x:xmpmeta>`
+
+const pdfx4XMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description rdf:about="" xmlns:pdfxid="http://www.npes.org/pdfx/ns/id/"><pdfxid:GTS_PDFXVersion>PDF/X-4</pdfxid:GTS_PDFXVersion></rdf:Description>` +
+	`</rdf:RDF></x:xmpmeta>`
+
+func TestValidateConformance(t *testing.T) {
+	dir := t.TempDir()
+	pdfaIntent := "<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB) >>"
+
+	tests := []struct {
+		name        string
+		data        []byte
+		conformance string
+		issues      int
+	}{
+		{"没有声明", buildFlatPDF(1), ConformanceNone, 0},
+		{"PDF/A-2b", buildConformancePDF("/Metadata 4 0 R /OutputIntents ["+pdfaIntent+"]", pdfa2bXMP), "PDF/A-2b", 0},
+		{"间接引用的输出意图", buildConformancePDF("/Metadata 4 0 R /OutputIntents [5 0 R]", pdfa2bXMP, pdfaIntent), "PDF/A-2b", 0},
+		{"PDF/A缺少输出意图", buildConformancePDF("/Metadata 4 0 R", pdfa2bXMP), "PDF/A-2b", 1},
+		{"PDF/X-4", buildConformancePDF("/Metadata 4 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFX >>]", pdfx4XMP), "PDF/X-4", 0},
+		{"信息字典中的PDF/X版本", withInfo(buildConformancePDF("/OutputIntents [<< /S /GTS_PDFX >>]", "",
+			"<< /GTS_PDFXVersion (PDF/X-1a:2001) >>"), 5), "PDF/X-1a:2001", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, strings.ReplaceAll(tt.name, "/", "_")+".pdf", tt.data)
+			report, err := ValidateConformance(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.conformance, report.Conformance)
+			assert.Len(t, report.Issues, tt.issues, "%v", report.Issues)
+			assert.Equal(t, tt.conformance != ConformanceNone && tt.issues == 0, report.Compliant())
+		})
+	}
+}
+
+// withInfo 在trailer中引用编号为info的文档信息字典
+func withInfo(data []byte, info int) []byte {
+	return []byte(strings.Replace(string(data), "/Root 1 0 R >>", fmt.Sprintf("/Root 1 0 R /Info %d 0 R >>", info), 1))
+}
+
+func TestValidateConformance_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := ValidateConformance(filepath.Join(dir, "missing.pdf"))
+	assert.Error(t, err)
+
+	_, err = ValidateConformance(createTestFile(t, dir, "garbage.pdf", []byte("%PDF-1.4\nnot a pdf\n")))
+	assert.Error(t, err)
+}
+
+func TestGetPDFInfo_Conformance(t *testing.T) {
+	dir := t.TempDir()
+	pdfa := createTestFile(t, dir, "pdfa.pdf",
+		buildConformancePDF("/Metadata 4 0 R /OutputIntents [<< /S /GTS_PDFA1 >>]", pdfa2bXMP))
+
+	service := NewPDFService()
+	info, err := service.GetPDFInfo(pdfa)
+	require.NoError(t, err)
+	assert.Equal(t, "PDF/A-2b", info.Conformance)
+}
+
+func TestMergeFiles_WarnsWhenConformanceDropped(t *testing.T) {
+	dir := t.TempDir()
+	pdfa := createTestFile(t, dir, "pdfa.pdf",
+		buildConformancePDF("/Metadata 4 0 R /OutputIntents [<< /S /GTS_PDFA1 >>]", pdfa2bXMP))
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(2))
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{pdfa, plain}, output, nil)
+	require.NoError(t, err)
+
+	report, err := ValidateConformance(output)
+	require.NoError(t, err)
+	// 合并后端不保留输入的输出意图
+	assert.Empty(t, report.OutputIntents)
+	assert.Contains(t, strings.Join(result.Warnings, "\n"), "PDF/A-2b")
+}
@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // PDFDecryptor 提供PDF文件解密功能
@@ -20,6 +23,8 @@ type PDFDecryptor struct {
 	mutex            sync.Mutex
 	progressCallback func(current, total int, password string)
 	adapter          *PDFCPUAdapter // 新增pdfcpu适配器
+	vault            PasswordVault  // 可选的密码保险库
+	clock            clock.Clock
 }
 
 // DecryptorOptions 解密器选项
@@ -29,6 +34,8 @@ type DecryptorOptions struct {
 	MaxAttempts      int                                       // 最大尝试次数
 	AttemptDelay     time.Duration                             // 尝试间隔
 	ProgressCallback func(current, total int, password string) // 进度回调
+	Vault            PasswordVault                             // 密码保险库，解密前优先查询
+	Clock            clock.Clock                               // 尝试间隔使用的时钟，nil时使用系统时钟
 }
 
 // DecryptResult 解密结果
@@ -39,6 +46,7 @@ type DecryptResult struct {
 	AttemptCount   int
 	ProcessingTime time.Duration
 	IsOriginalFile bool // 是否为原始文件（未加密）
+	FromVault      bool // 是否使用了保险库中保存的密码
 }
 
 // NewPDFDecryptor 创建一个新的PDF解密器
@@ -63,6 +71,8 @@ func NewPDFDecryptor(options *DecryptorOptions) *PDFDecryptor {
 		progressCallback: options.ProgressCallback,
 		tempFiles:        make([]string, 0),
 		adapter:          adapter,
+		vault:            options.Vault,
+		clock:            clock.OrSystem(options.Clock),
 	}
 
 	// 如果没有提供常用密码，使用默认列表
@@ -208,7 +218,7 @@ func (d *PDFDecryptor) DecryptPDF(filePath, password string) (string, error) {
 
 // AutoDecrypt 自动解密PDF文件
 func (d *PDFDecryptor) AutoDecrypt(filePath string) (*DecryptResult, error) {
-	startTime := time.Now()
+	startTime := d.clock.Now()
 	result := &DecryptResult{
 		Success:        false,
 		ProcessingTime: 0,
@@ -225,7 +235,18 @@ func (d *PDFDecryptor) AutoDecrypt(filePath string) (*DecryptResult, error) {
 		result.Success = true
 		result.DecryptedPath = filePath
 		result.IsOriginalFile = true
-		result.ProcessingTime = time.Since(startTime)
+		result.ProcessingTime = d.clock.Now().Sub(startTime)
+		return result, nil
+	}
+
+	// 优先使用保险库中保存的密码
+	if decryptedPath, password, ok := d.decryptWithVault(filePath); ok {
+		result.Success = true
+		result.DecryptedPath = decryptedPath
+		result.UsedPassword = password
+		result.FromVault = true
+		result.ProcessingTime = d.clock.Now().Sub(startTime)
+		d.addTempFile(decryptedPath)
 		return result, nil
 	}
 
@@ -254,10 +275,11 @@ func (d *PDFDecryptor) AutoDecrypt(filePath string) (*DecryptResult, error) {
 			result.Success = true
 			result.DecryptedPath = decryptedPath
 			result.UsedPassword = password
-			result.ProcessingTime = time.Since(startTime)
+			result.ProcessingTime = d.clock.Now().Sub(startTime)
 
 			// 记录临时文件以便后续清理
 			d.addTempFile(decryptedPath)
+			d.rememberPassword(filePath, password)
 			return result, nil
 		}
 
@@ -265,19 +287,19 @@ func (d *PDFDecryptor) AutoDecrypt(filePath string) (*DecryptResult, error) {
 		if pdfErr, ok := err.(*PDFError); ok {
 			if pdfErr.Type != ErrorEncrypted {
 				// 非密码错误，停止尝试
-				result.ProcessingTime = time.Since(startTime)
+				result.ProcessingTime = d.clock.Now().Sub(startTime)
 				return result, err
 			}
 		}
 
 		// 添加延迟避免过快尝试
 		if d.attemptDelay > 0 {
-			time.Sleep(d.attemptDelay)
+			d.clock.Sleep(context.Background(), d.attemptDelay)
 		}
 	}
 
 	// 所有密码都失败
-	result.ProcessingTime = time.Since(startTime)
+	result.ProcessingTime = d.clock.Now().Sub(startTime)
 	return result, &PDFError{
 		Type:    ErrorEncrypted,
 		Message: fmt.Sprintf("无法使用 %d 个常用密码解密文件", result.AttemptCount),
@@ -287,7 +309,7 @@ func (d *PDFDecryptor) AutoDecrypt(filePath string) (*DecryptResult, error) {
 
 // TryDecryptWithPasswords 尝试使用指定密码列表解密PDF文件
 func (d *PDFDecryptor) TryDecryptWithPasswords(filePath string, passwords []string) (*DecryptResult, error) {
-	startTime := time.Now()
+	startTime := d.clock.Now()
 	result := &DecryptResult{
 		Success:        false,
 		ProcessingTime: 0,
@@ -304,7 +326,18 @@ func (d *PDFDecryptor) TryDecryptWithPasswords(filePath string, passwords []stri
 		result.Success = true
 		result.DecryptedPath = filePath
 		result.IsOriginalFile = true
-		result.ProcessingTime = time.Since(startTime)
+		result.ProcessingTime = d.clock.Now().Sub(startTime)
+		return result, nil
+	}
+
+	// 优先使用保险库中保存的密码
+	if decryptedPath, password, ok := d.decryptWithVault(filePath); ok {
+		result.Success = true
+		result.DecryptedPath = decryptedPath
+		result.UsedPassword = password
+		result.FromVault = true
+		result.ProcessingTime = d.clock.Now().Sub(startTime)
+		d.addTempFile(decryptedPath)
 		return result, nil
 	}
 
@@ -324,10 +357,11 @@ func (d *PDFDecryptor) TryDecryptWithPasswords(filePath string, passwords []stri
 			result.Success = true
 			result.DecryptedPath = decryptedPath
 			result.UsedPassword = password
-			result.ProcessingTime = time.Since(startTime)
+			result.ProcessingTime = d.clock.Now().Sub(startTime)
 
 			// 记录临时文件以便后续清理
 			d.addTempFile(decryptedPath)
+			d.rememberPassword(filePath, password)
 			return result, nil
 		}
 
@@ -335,19 +369,19 @@ func (d *PDFDecryptor) TryDecryptWithPasswords(filePath string, passwords []stri
 		if pdfErr, ok := err.(*PDFError); ok {
 			if pdfErr.Type != ErrorEncrypted {
 				// 非密码错误，停止尝试
-				result.ProcessingTime = time.Since(startTime)
+				result.ProcessingTime = d.clock.Now().Sub(startTime)
 				return result, err
 			}
 		}
 
 		// 添加延迟
 		if d.attemptDelay > 0 {
-			time.Sleep(d.attemptDelay)
+			d.clock.Sleep(context.Background(), d.attemptDelay)
 		}
 	}
 
 	// 所有密码都失败
-	result.ProcessingTime = time.Since(startTime)
+	result.ProcessingTime = d.clock.Now().Sub(startTime)
 	return result, &PDFError{
 		Type:    ErrorEncrypted,
 		Message: fmt.Sprintf("无法使用提供的 %d 个密码解密文件", len(passwords)),
@@ -373,6 +407,32 @@ func (d *PDFDecryptor) TryDecryptPDF(filePath string, passwords []string) (strin
 	}
 }
 
+// decryptWithVault 尝试使用保险库中保存的密码解密
+func (d *PDFDecryptor) decryptWithVault(filePath string) (string, string, bool) {
+	password, ok := LookupVaultPassword(d.vault, filePath)
+	if !ok {
+		return "", "", false
+	}
+
+	decryptedPath, err := d.DecryptPDF(filePath, password)
+	if err != nil {
+		// 保存的密码已失效，移除条目
+		ForgetPassword(d.vault, filePath)
+		return "", "", false
+	}
+	return decryptedPath, password, true
+}
+
+// rememberPassword 将解密成功的密码保存到保险库
+func (d *PDFDecryptor) rememberPassword(filePath, password string) {
+	RememberPassword(d.vault, filePath, password)
+}
+
+// SetVault 设置密码保险库，nil表示禁用
+func (d *PDFDecryptor) SetVault(vault PasswordVault) {
+	d.vault = vault
+}
+
 // IsPDFEncrypted 检查PDF文件是否加密
 func (d *PDFDecryptor) IsPDFEncrypted(filePath string) (bool, error) {
 	// 打开文件
@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RenamedInput 内容相同但路径变化的输入
+type RenamedInput struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DeltaSummary 与上次运行相比的变化
+type DeltaSummary struct {
+	HasPrevious bool           `json:"has_previous"`
+	Added       []string       `json:"added,omitempty"`
+	Removed     []string       `json:"removed,omitempty"`
+	Changed     []string       `json:"changed,omitempty"`
+	Renamed     []RenamedInput `json:"renamed,omitempty"`
+	PagesBefore int            `json:"pages_before"`
+	PagesAfter  int            `json:"pages_after"`
+	SizeBefore  int64          `json:"size_before"`
+	SizeAfter   int64          `json:"size_after"`
+}
+
+// CompareManifests 比较两次运行的合并清单（见AuditManifest）。纯函数，不访问文件系统。
+// 路径相同但摘要不同视为内容变更；路径不同但摘要相同视为重命名；
+// 没有记录摘要的输入只按路径比较。页数和大小取自清单的输出，没有输出时为0。
+// previous为nil时返回HasPrevious=false。
+func CompareManifests(previous, current *AuditManifest) *DeltaSummary {
+	delta := &DeltaSummary{}
+	if current != nil && current.Output != nil {
+		delta.PagesAfter = current.Output.Pages
+		delta.SizeAfter = current.Output.Size
+	}
+	if previous == nil {
+		return delta
+	}
+	delta.HasPrevious = true
+	if previous.Output != nil {
+		delta.PagesBefore = previous.Output.Pages
+		delta.SizeBefore = previous.Output.Size
+	}
+
+	var currentInputs []ManifestInput
+	if current != nil {
+		currentInputs = current.Inputs
+	}
+
+	before := make(map[string]string, len(previous.Inputs))
+	for _, input := range previous.Inputs {
+		before[input.Path] = input.SHA256
+	}
+	after := make(map[string]string, len(currentInputs))
+	for _, input := range currentInputs {
+		after[input.Path] = input.SHA256
+	}
+
+	// 只在一侧出现的路径，先按内容匹配重命名
+	removedByHash := make(map[string][]string)
+	var removed []string
+	for _, input := range previous.Inputs {
+		if _, exists := after[input.Path]; exists {
+			continue
+		}
+		removed = append(removed, input.Path)
+		if input.SHA256 != "" {
+			removedByHash[input.SHA256] = append(removedByHash[input.SHA256], input.Path)
+		}
+	}
+
+	renamedFrom := make(map[string]bool)
+	for _, input := range currentInputs {
+		prevHash, exists := before[input.Path]
+		if exists {
+			if prevHash != "" && input.SHA256 != "" && prevHash != input.SHA256 {
+				delta.Changed = append(delta.Changed, input.Path)
+			}
+			continue
+		}
+
+		if candidates := removedByHash[input.SHA256]; input.SHA256 != "" && len(candidates) > 0 {
+			delta.Renamed = append(delta.Renamed, RenamedInput{From: candidates[0], To: input.Path})
+			renamedFrom[candidates[0]] = true
+			removedByHash[input.SHA256] = candidates[1:]
+			continue
+		}
+		delta.Added = append(delta.Added, input.Path)
+	}
+
+	for _, file := range removed {
+		if !renamedFrom[file] {
+			delta.Removed = append(delta.Removed, file)
+		}
+	}
+
+	sort.Strings(delta.Added)
+	sort.Strings(delta.Removed)
+	sort.Strings(delta.Changed)
+	sort.Slice(delta.Renamed, func(i, j int) bool {
+		return delta.Renamed[i].To < delta.Renamed[j].To
+	})
+	return delta
+}
+
+// HasChanges 判断输入或输出是否有变化
+func (d *DeltaSummary) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 || len(d.Renamed) > 0 ||
+		d.PagesBefore != d.PagesAfter || d.SizeBefore != d.SizeAfter
+}
+
+// String 返回简短的变化摘要，如 "3 个新增, 1 个移除, 2 个内容变更; 页数 412→436"
+func (d *DeltaSummary) String() string {
+	if d == nil || !d.HasPrevious {
+		return "没有上次运行记录"
+	}
+
+	parts := []string{
+		fmt.Sprintf("%d 个新增", len(d.Added)),
+		fmt.Sprintf("%d 个移除", len(d.Removed)),
+		fmt.Sprintf("%d 个内容变更", len(d.Changed)),
+	}
+	if len(d.Renamed) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 个重命名", len(d.Renamed)))
+	}
+	summary := strings.Join(parts, ", ")
+	summary += fmt.Sprintf("; 页数 %d→%d", d.PagesBefore, d.PagesAfter)
+	if d.SizeBefore != d.SizeAfter {
+		summary += fmt.Sprintf("; 大小 %.2f MB→%.2f MB",
+			float64(d.SizeBefore)/(1024*1024), float64(d.SizeAfter)/(1024*1024))
+	}
+	return summary
+}
@@ -0,0 +1,200 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func manifestOf(pages int, size int64, inputs ...string) *AuditManifest {
+	manifest := &AuditManifest{Output: &ManifestOutput{Pages: pages, Size: size}}
+	for i := 0; i+1 < len(inputs); i += 2 {
+		manifest.Inputs = append(manifest.Inputs, ManifestInput{Path: inputs[i], SHA256: inputs[i+1]})
+	}
+	return manifest
+}
+
+func TestCompareManifests(t *testing.T) {
+	previous := manifestOf(412, 1000,
+		"jan/a.pdf", "h-a",
+		"jan/b.pdf", "h-b",
+		"jan/c.pdf", "h-c",
+		"jan/old.pdf", "h-old",
+	)
+	current := manifestOf(436, 1200,
+		"jan/a.pdf", "h-a",
+		"jan/b.pdf", "h-b2",
+		"feb/c.pdf", "h-c",
+		"jan/new1.pdf", "h-n1",
+		"jan/new2.pdf", "h-n2",
+	)
+
+	delta := CompareManifests(previous, current)
+
+	if !delta.HasPrevious {
+		t.Fatal("期望HasPrevious为true")
+	}
+	if !reflect.DeepEqual(delta.Added, []string{"jan/new1.pdf", "jan/new2.pdf"}) {
+		t.Errorf("新增不正确: %v", delta.Added)
+	}
+	if !reflect.DeepEqual(delta.Removed, []string{"jan/old.pdf"}) {
+		t.Errorf("移除不正确: %v", delta.Removed)
+	}
+	if !reflect.DeepEqual(delta.Changed, []string{"jan/b.pdf"}) {
+		t.Errorf("内容变更不正确: %v", delta.Changed)
+	}
+	if !reflect.DeepEqual(delta.Renamed, []RenamedInput{{From: "jan/c.pdf", To: "feb/c.pdf"}}) {
+		t.Errorf("重命名不正确: %v", delta.Renamed)
+	}
+	if delta.PagesBefore != 412 || delta.PagesAfter != 436 {
+		t.Errorf("页数变化不正确: %d→%d", delta.PagesBefore, delta.PagesAfter)
+	}
+
+	summary := delta.String()
+	if !strings.HasPrefix(summary, "2 个新增, 1 个移除, 1 个内容变更, 1 个重命名; 页数 412→436") {
+		t.Errorf("摘要不正确: %s", summary)
+	}
+}
+
+func TestCompareManifests_NoChanges(t *testing.T) {
+	manifest := manifestOf(10, 500, "a.pdf", "h-a", "b.pdf", "h-b")
+	delta := CompareManifests(manifest, manifestOf(10, 500, "a.pdf", "h-a", "b.pdf", "h-b"))
+	if delta.HasChanges() {
+		t.Errorf("相同清单不应有变化: %+v", delta)
+	}
+	if delta.String() != "0 个新增, 0 个移除, 0 个内容变更; 页数 10→10" {
+		t.Errorf("摘要不正确: %s", delta.String())
+	}
+}
+
+func TestCompareManifests_NoPrevious(t *testing.T) {
+	delta := CompareManifests(nil, manifestOf(3, 100, "a.pdf", "h-a"))
+	if delta.HasPrevious || delta.String() != "没有上次运行记录" {
+		t.Errorf("没有上次清单时应降级为无记录: %+v", delta)
+	}
+	if (*DeltaSummary)(nil).String() != "没有上次运行记录" {
+		t.Error("nil摘要应返回无记录")
+	}
+}
+
+func TestCompareManifests_MissingDigests(t *testing.T) {
+	// 旧清单没有记录摘要：路径相同不视为变更，路径不同不视为重命名
+	previous := manifestOf(2, 0, "a.pdf", "", "b.pdf", "")
+	current := manifestOf(2, 0, "a.pdf", "h-a", "c.pdf", "")
+
+	delta := CompareManifests(previous, current)
+	if len(delta.Changed) != 0 || len(delta.Renamed) != 0 {
+		t.Errorf("缺少摘要时不应判断内容变化: %+v", delta)
+	}
+	if !reflect.DeepEqual(delta.Added, []string{"c.pdf"}) || !reflect.DeepEqual(delta.Removed, []string{"b.pdf"}) {
+		t.Errorf("新增/移除不正确: %+v", delta)
+	}
+}
+
+func TestStreamingMerger_AttachesDelta(t *testing.T) {
+	tempDir := t.TempDir()
+	file1 := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(2))
+	output := filepath.Join(tempDir, "out.pdf")
+
+	first := NewStreamingMerger(&MergeOptions{TempDirectory: os.TempDir(), VerifyChecksums: true})
+	first.adapter = nil
+	result, err := first.MergeFiles([]string{file1, file2}, output, nil)
+	if err != nil {
+		t.Fatalf("首次合并失败: %v", err)
+	}
+	if result.Delta == nil || result.Delta.HasPrevious {
+		t.Fatalf("首次运行应报告没有上次记录: %+v", result.Delta)
+	}
+
+	previous, err := first.newAuditManifest(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(file2, buildFlatPDF(3), 0644)
+	second := NewStreamingMerger(&MergeOptions{
+		TempDirectory:    os.TempDir(),
+		VerifyChecksums:  true,
+		PreviousManifest: previous,
+	})
+	second.adapter = nil
+	result, err = second.MergeFiles([]string{file1, file2}, output, nil)
+	if err != nil {
+		t.Fatalf("再次合并失败: %v", err)
+	}
+	if result.Delta == nil || !reflect.DeepEqual(result.Delta.Changed, []string{absPath(file2)}) {
+		t.Errorf("期望检测到 %s 内容变更，实际: %+v", file2, result.Delta)
+	}
+}
+
+func TestStreamingMerger_ComparesWithPreviousSidecar(t *testing.T) {
+	tempDir := t.TempDir()
+	file1 := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(2))
+	output := filepath.Join(tempDir, "out.pdf")
+	merge := func(files ...string) *MergeResult {
+		t.Helper()
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, WriteManifest: true})
+		merger.adapter = nil
+		result, err := merger.MergeFiles(files, output, nil)
+		if err != nil {
+			t.Fatalf("合并失败: %v", err)
+		}
+		return result
+	}
+
+	if result := merge(file1, file2); result.Delta == nil || result.Delta.HasPrevious {
+		t.Fatalf("首次运行应报告没有上次记录: %+v", result.Delta)
+	}
+
+	file3 := createTestFile(t, tempDir, "c.pdf", buildFlatPDF(4))
+	result := merge(file1, file2, file3)
+	if result.Delta == nil || !result.Delta.HasPrevious {
+		t.Fatalf("应读取上次写出的清单旁路文件: %+v", result.Delta)
+	}
+	if !reflect.DeepEqual(result.Delta.Added, []string{absPath(file3)}) {
+		t.Errorf("新增不正确: %v", result.Delta.Added)
+	}
+	if result.Delta.PagesBefore != 3 || result.Delta.PagesAfter != 7 {
+		t.Errorf("页数变化不正确: %d→%d", result.Delta.PagesBefore, result.Delta.PagesAfter)
+	}
+}
+
+func TestPDFServiceImpl_WritesManifestAndDelta(t *testing.T) {
+	tempDir := t.TempDir()
+	file1 := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(2))
+	output := filepath.Join(tempDir, "out.pdf")
+	service := NewPDFServiceWithConfig(&ServiceConfig{
+		TempDirectory: tempDir,
+		WriteManifest: true,
+		ToolVersion:   "1.2.3",
+	}).(*PDFServiceImpl)
+
+	if err := service.MergePDFs(file1, []string{file2}, output, nil); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	first := service.TakeMergeResult(output)
+	if first == nil || first.ManifestPath != output+ManifestSuffix {
+		t.Fatalf("应在最终输出旁写出清单: %+v", first)
+	}
+	verification, err := VerifyManifest(output)
+	if err != nil || !verification.Match {
+		t.Fatalf("清单应记录后处理之后的输出: %+v, %v", verification, err)
+	}
+
+	os.WriteFile(file2, buildFlatPDF(3), 0644)
+	var progress strings.Builder
+	if err := service.MergePDFs(file1, []string{file2}, output, &progress); err != nil {
+		t.Fatalf("再次合并失败: %v", err)
+	}
+	second := service.TakeMergeResult(output)
+	if second.Delta == nil || !reflect.DeepEqual(second.Delta.Changed, []string{absPath(file2)}) {
+		t.Errorf("期望检测到 %s 内容变更，实际: %+v", file2, second.Delta)
+	}
+	if !strings.Contains(progress.String(), "与上次相比: 0 个新增, 0 个移除, 1 个内容变更") {
+		t.Errorf("进度输出中应有变化摘要:\n%s", progress.String())
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"runtime"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // PDFDiagnosticReport PDF文件诊断报告
@@ -23,8 +25,8 @@ type PDFDiagnosticReport struct {
 	GeneratedAt     time.Time              // 诊断时间
 }
 
-// DiagnosePDF 对单个PDF文件进行诊断
-func DiagnosePDF(filePath string) *PDFDiagnosticReport {
+// DiagnosePDF 对单个PDF文件进行诊断，诊断时间取自 c，nil表示系统时钟
+func DiagnosePDF(filePath string, c clock.Clock) *PDFDiagnosticReport {
 	report := &PDFDiagnosticReport{
 		FilePath:    filePath,
 		Exists:      false,
@@ -33,7 +35,7 @@ func DiagnosePDF(filePath string) *PDFDiagnosticReport {
 		Permissions: "",
 		PageCount:   0,
 		Extra:       make(map[string]interface{}),
-		GeneratedAt: time.Now(),
+		GeneratedAt: clock.OrSystem(c).Now(),
 	}
 
 	info, err := os.Stat(filePath)
@@ -94,8 +96,8 @@ type SystemDiagnosticReport struct {
 	GeneratedAt   time.Time
 }
 
-// DiagnoseSystem 检查系统环境
-func DiagnoseSystem() *SystemDiagnosticReport {
+// DiagnoseSystem 检查系统环境，诊断时间取自 c，nil表示系统时钟
+func DiagnoseSystem(c clock.Clock) *SystemDiagnosticReport {
 	report := &SystemDiagnosticReport{
 		GoVersion:     runtime.Version(),
 		OS:            runtime.GOOS,
@@ -104,7 +106,7 @@ func DiagnoseSystem() *SystemDiagnosticReport {
 		PDFCPUPresent: true,      // 占位，实际可检测CLI或库
 		PDFCPUVersion: "v0.11.0", // 可集成真实版本检测
 		OtherChecks:   make(map[string]interface{}),
-		GeneratedAt:   time.Now(),
+		GeneratedAt:   clock.OrSystem(c).Now(),
 	}
 	// 可扩展更多依赖/环境检查
 	return report
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package pdf
+
+import "errors"
+
+// availableDiskSpace 当前平台无法查询可用空间，预检跳过临时空间检查
+func availableDiskSpace(dir string) (int64, error) {
+	return 0, errors.New("当前平台不支持查询可用磁盘空间")
+}
@@ -0,0 +1,138 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// contentHashCacheLimit 内容哈希缓存的条目上限，超出时清空后重新积累
+const contentHashCacheLimit = 4096
+
+// ContentHash 文件内容的标识，大小和SHA-256都相同的文件视为内容相同
+type ContentHash struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// DuplicateInput 与之前的输入内容相同的输入
+type DuplicateInput struct {
+	File        string `json:"file"`
+	DuplicateOf string `json:"duplicate_of"` // 内容相同的第一个输入
+}
+
+// cachedContentHash 缓存的哈希及计算时文件的修改时间
+type cachedContentHash struct {
+	modTime time.Time
+	hash    ContentHash
+}
+
+// contentHashCache 按路径缓存的内容哈希，文件大小或修改时间变化后失效
+var contentHashCache = struct {
+	sync.Mutex
+	entries map[string]cachedContentHash
+}{entries: make(map[string]cachedContentHash)}
+
+// FileContentHash 流式计算文件的大小和SHA-256，不把整个文件读入内存。
+// 结果按路径和修改时间缓存，重复验证同一文件时不再读取。
+func FileContentHash(filePath string) (ContentHash, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return ContentHash{}, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法获取文件信息",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	if hash, ok := lookupContentHash(filePath, info); ok {
+		return hash, nil
+	}
+	hash, err := computeContentHash(filePath)
+	if err != nil {
+		return ContentHash{}, err
+	}
+	rememberContentHash(filePath, info, hash)
+	return hash, nil
+}
+
+// computeContentHash 读取整个文件计算大小和SHA-256，不使用缓存
+func computeContentHash(filePath string) (ContentHash, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ContentHash{}, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法打开文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	size, err := io.Copy(digest, file)
+	if err != nil {
+		return ContentHash{}, &PDFError{
+			Type:    ErrorIO,
+			Message: "读取文件时发生IO错误",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return ContentHash{Size: size, SHA256: hex.EncodeToString(digest.Sum(nil))}, nil
+}
+
+// lookupContentHash 返回缓存中与文件当前大小和修改时间一致的哈希
+func lookupContentHash(filePath string, info os.FileInfo) (ContentHash, bool) {
+	contentHashCache.Lock()
+	defer contentHashCache.Unlock()
+	cached, ok := contentHashCache.entries[filepath.Clean(filePath)]
+	if !ok || cached.hash.Size != info.Size() || !cached.modTime.Equal(info.ModTime()) {
+		return ContentHash{}, false
+	}
+	return cached.hash, true
+}
+
+// rememberContentHash 缓存哈希。读取的字节数与stat得到的大小不同说明读取期间文件被修改，此时不缓存。
+func rememberContentHash(filePath string, info os.FileInfo, hash ContentHash) {
+	if hash.Size != info.Size() {
+		return
+	}
+	contentHashCache.Lock()
+	defer contentHashCache.Unlock()
+	if len(contentHashCache.entries) >= contentHashCacheLimit {
+		contentHashCache.entries = make(map[string]cachedContentHash)
+	}
+	contentHashCache.entries[filepath.Clean(filePath)] = cachedContentHash{modTime: info.ModTime(), hash: hash}
+}
+
+// inputHashes 一次合并中已接受的输入：内容哈希 -> 第一个具有该内容的输入
+type inputHashes map[ContentHash]string
+
+// skipDuplicate 检查输入是否与之前接受的输入内容相同，相同时记录到result.Duplicates。
+// 未启用AllowDuplicates时把输入记为跳过并返回true；无法计算哈希的输入不视为重复。
+func (sm *StreamingMerger) skipDuplicate(result *MergeResult, seen inputHashes, file string) bool {
+	hash, err := FileContentHash(file)
+	if err != nil {
+		return false
+	}
+	first, ok := seen[hash]
+	if !ok {
+		seen[hash] = file
+		return false
+	}
+
+	result.Duplicates = append(result.Duplicates, DuplicateInput{File: file, DuplicateOf: first})
+	if sm.allowDuplicates {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("文件 %s 与 %s 内容相同，按设置仍然合并", file, first))
+		return false
+	}
+	result.skipInput(file, fmt.Sprintf("与 %s 内容相同", first))
+	result.Warnings = append(result.Warnings, fmt.Sprintf("跳过重复文件 %s: 与 %s 内容相同", file, first))
+	return true
+}
@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileContentHash_CachedByModTime(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "a.pdf", buildFlatPDF(1))
+	first, err := FileContentHash(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(buildFlatPDF(1))), first.Size)
+
+	// 同样大小和修改时间的内容变化命中缓存，说明没有重新读取
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	changed := buildFlatPDF(1)
+	changed[len(changed)-2] = 'X'
+	require.NoError(t, os.WriteFile(path, changed, 0644))
+	require.NoError(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	cached, err := FileContentHash(path)
+	require.NoError(t, err)
+	assert.Equal(t, first, cached)
+
+	// 修改时间变化后重新计算
+	later := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	updated, err := FileContentHash(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.SHA256, updated.SHA256)
+}
+
+func TestVerifyInputIntegrity_PrimesContentHashCache(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "a.pdf", buildFlatPDF(2))
+	digest, err := verifyInputIntegrity(path, nil)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	hash, ok := lookupContentHash(path, info)
+	require.True(t, ok, "完整性校验计算的摘要应供重复检测复用")
+	assert.Equal(t, digest.SHA256, hash.SHA256)
+}
+
+func TestMergeStreaming_Duplicates(t *testing.T) {
+	dir := t.TempDir()
+	first := createTestFile(t, dir, "first.pdf", buildFlatPDF(1))
+	copied := createTestFile(t, dir, "copy.pdf", buildFlatPDF(1))
+	other := createTestFile(t, dir, "other.pdf", buildFlatPDF(2))
+	inputs := []string{first, other, copied, first}
+
+	t.Run("默认跳过", func(t *testing.T) {
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), inputs, filepath.Join(dir, "skip.pdf"), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{first, other}, result.ValidatedFiles)
+		assert.Equal(t, []string{copied, first}, result.SkippedFiles)
+		assert.Equal(t, []DuplicateInput{{File: copied, DuplicateOf: first}, {File: first, DuplicateOf: first}}, result.Duplicates)
+		assert.Contains(t, result.Warnings[0], "跳过重复文件")
+		assert.Equal(t, 3, result.TotalPages)
+	})
+
+	t.Run("允许重复", func(t *testing.T) {
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore(), AllowDuplicates: true})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), inputs, filepath.Join(dir, "all.pdf"), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, inputs, result.ValidatedFiles)
+		assert.Empty(t, result.SkippedFiles)
+		assert.Len(t, result.Duplicates, 2)
+		assert.Equal(t, 5, result.TotalPages)
+	})
+}
+
+func TestMergeFiles_SkipsDuplicatesAndInvalidInputs(t *testing.T) {
+	dir := t.TempDir()
+	good := createTestFile(t, dir, "good.pdf", buildFlatPDF(1))
+	other := createTestFile(t, dir, "other.pdf", buildFlatPDF(2))
+	empty := createTestFile(t, dir, "empty.pdf", nil)
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{good, empty, other, good}, output, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{empty, good}, result.SkippedFiles)
+	assert.Equal(t, 2, result.ProcessedFiles)
+	count, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "跳过的输入不应出现在输出中")
+}
@@ -30,6 +30,8 @@ type EnhancedPDFReader struct {
 	validationMode ValidationMode
 	cliAdapter     *PDFCPUCLIAdapter
 	useCLI         bool
+	pageTreeLimits *PageTreeLimits
+	closer         closeGuard // Close契约：等待进行中的读取结束后再释放资源
 }
 
 // NewEnhancedPDFReader 创建增强的PDF读取器
@@ -39,6 +41,7 @@ func NewEnhancedPDFReader(filePath string, mode ValidationMode) (*EnhancedPDFRea
 		isOpen:         false,
 		validationMode: mode,
 		useCLI:         false,
+		pageTreeLimits: DefaultPageTreeLimits(),
 	}
 
 	// 只在严格模式下使用CLI适配器
@@ -58,6 +61,11 @@ func NewEnhancedPDFReader(filePath string, mode ValidationMode) (*EnhancedPDFRea
 
 // Open 打开PDF文件
 func (r *EnhancedPDFReader) Open() error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	if r.isOpen {
 		return nil
 	}
@@ -169,6 +177,7 @@ func (r *EnhancedPDFReader) checkBasicStructure() error {
 	hasObjects := false
 	hasXref := false
 	hasTrailer := false
+	hasXRefStream := false
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -187,6 +196,11 @@ func (r *EnhancedPDFReader) checkBasicStructure() error {
 		if line == "trailer" {
 			hasTrailer = true
 		}
+
+		// 使用交叉引用流的文件没有xref和trailer关键字
+		if xrefStreamTypePattern.MatchString(line) {
+			hasXRefStream = true
+		}
 	}
 
 	if !hasObjects {
@@ -198,7 +212,7 @@ func (r *EnhancedPDFReader) checkBasicStructure() error {
 	}
 
 	// 宽松模式下，xref和trailer不是必需的
-	if r.validationMode == ValidationStrict && (!hasXref || !hasTrailer) {
+	if r.validationMode == ValidationStrict && (!hasXref || !hasTrailer) && !hasXRefStream {
 		return &PDFError{
 			Type:    ErrorCorrupted,
 			Message: "PDF文件结构不完整",
@@ -211,6 +225,11 @@ func (r *EnhancedPDFReader) checkBasicStructure() error {
 
 // GetInfo 获取PDF信息
 func (r *EnhancedPDFReader) GetInfo() (*PDFInfo, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -288,6 +307,8 @@ func (r *EnhancedPDFReader) parseBasicInfo() (*PDFInfo, error) {
 	// 计算页数
 	if pageCount, err := r.countPages(); err == nil {
 		info.PageCount = pageCount
+	} else if IsLimitExceededError(err) {
+		return nil, err
 	} else {
 		info.PageCount = 1 // 默认至少1页
 	}
@@ -344,6 +365,13 @@ func (r *EnhancedPDFReader) extractVersion() (string, error) {
 
 // countPages 计算页数
 func (r *EnhancedPDFReader) countPages() (int, error) {
+	// 优先遍历页面树；超出限制时直接拒绝，不回退到启发式计数
+	if count, err := CountPagesInFile(r.filePath, r.pageTreeLimits); err == nil && count > 0 {
+		return count, nil
+	} else if IsLimitExceededError(err) {
+		return 0, err
+	}
+
 	file, err := os.Open(r.filePath)
 	if err != nil {
 		return 0, err
@@ -445,19 +473,18 @@ func (r *EnhancedPDFReader) extractTitle() string {
 	return fileName
 }
 
-// Close 关闭读取器
+// Close 关闭读取器。与 PDFReader 相同，Close 等待进行中的读取结束，
+// 关闭后不能再打开，之后的方法调用返回 ErrClosed。
 func (r *EnhancedPDFReader) Close() error {
-	if !r.isOpen {
-		return nil
-	}
-
-	if r.cliAdapter != nil {
-		r.cliAdapter.Close()
-	}
+	return r.closer.close(func() error {
+		if r.cliAdapter != nil {
+			r.cliAdapter.Close()
+		}
 
-	r.info = nil
-	r.isOpen = false
-	return nil
+		r.info = nil
+		r.isOpen = false
+		return nil
+	})
 }
 
 // GetValidationMode 获取验证模式
@@ -465,6 +492,15 @@ func (r *EnhancedPDFReader) GetValidationMode() ValidationMode {
 	return r.validationMode
 }
 
+// SetPageTreeLimits 设置页面树遍历限制（nil表示使用默认限制）
+func (r *EnhancedPDFReader) SetPageTreeLimits(limits *PageTreeLimits) {
+	if limits == nil {
+		limits = DefaultPageTreeLimits()
+	}
+	r.pageTreeLimits = limits
+	r.info = nil
+}
+
 // SetValidationMode 设置验证模式
 func (r *EnhancedPDFReader) SetValidationMode(mode ValidationMode) {
 	r.validationMode = mode
@@ -482,6 +518,11 @@ func (r *EnhancedPDFReader) GetFilePath() string {
 
 // ValidateWithMode 使用指定模式验证文件
 func (r *EnhancedPDFReader) ValidateWithMode(mode ValidationMode) error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	oldMode := r.validationMode
 	r.validationMode = mode
 
@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -387,17 +388,18 @@ endobj
 		t.Error("Reader should be closed after Close()")
 	}
 
-	// 重新打开
+	// 关闭后不能重新打开
 	err = reader.Open()
-	if err != nil {
-		t.Errorf("Failed to reopen reader: %v", err)
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed when reopening a closed reader, got %v", err)
 	}
 
-	// 验证重新打开状态
-	if !reader.IsOpen() {
-		t.Error("Reader should be open after Open()")
+	if reader.IsOpen() {
+		t.Error("Reader should stay closed")
 	}
 
-	// 最终关闭
-	reader.Close()
+	// 重复关闭是安全的
+	if err := reader.Close(); err != nil {
+		t.Errorf("Expected repeated Close to succeed, got %v", err)
+	}
 }
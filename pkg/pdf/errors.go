@@ -1,8 +1,13 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+
+	"github.com/user/pdf-merger/internal/i18n"
+	"github.com/user/pdf-merger/internal/model"
 )
 
 // ErrorType 定义PDF处理中可能出现的错误类型
@@ -27,6 +32,14 @@ const (
 	ErrorProcessing
 	// ErrorInvalidInput 表示输入参数无效
 	ErrorInvalidInput
+	// ErrorLimitExceeded 表示文件超出了处理限制（如页面树深度），或合并输出超出了大小、页数上限
+	ErrorLimitExceeded
+	// ErrorChecksumMismatch 表示文件内容与预期校验和不一致
+	ErrorChecksumMismatch
+	// ErrorAdapterUnavailable 表示PDF处理后端不可用，内置合并也无法处理输入
+	ErrorAdapterUnavailable
+	// ErrorResourceLimit 表示验证或读取文件时超出了资源限制（解压字节数、对象嵌套深度或验证时间）
+	ErrorResourceLimit
 )
 
 // PDFError 定义PDF处理错误的结构
@@ -40,9 +53,19 @@ type PDFError struct {
 // Error 实现error接口
 func (e *PDFError) Error() string {
 	if e.Cause != nil {
-		return fmt.Sprintf("%s: %s (file: %s): %v", e.typeString(), e.Message, e.File, e.Cause)
+		return fmt.Sprintf("%s: %s (file: %s): %v", e.typeString(), e.Message, e.displayFile(), e.Cause)
 	}
-	return fmt.Sprintf("%s: %s (file: %s)", e.typeString(), e.Message, e.File)
+	return fmt.Sprintf("%s: %s (file: %s)", e.typeString(), e.Message, e.displayFile())
+}
+
+// displayFile 返回用于错误文本的文件路径；File 字段本身保留原始字节
+func (e *PDFError) displayFile() string {
+	return model.DisplayName(e.File, model.DefaultFilenameEncodings)
+}
+
+// TypeName 返回错误类型的名称，如 "Validation Error"，供配置按类型决定处理方式
+func (e *PDFError) TypeName() string {
+	return e.typeString()
 }
 
 // typeString 返回错误类型的字符串表示
@@ -66,24 +89,44 @@ func (e *PDFError) typeString() string {
 		return "Processing Error"
 	case ErrorInvalidInput:
 		return "Invalid Input"
+	case ErrorLimitExceeded:
+		return "Limit Exceeded"
+	case ErrorChecksumMismatch:
+		return "Checksum Mismatch"
+	case ErrorAdapterUnavailable:
+		return "Adapter Unavailable"
+	case ErrorResourceLimit:
+		return "Resource Limit"
 	default:
 		return "Unknown Error"
 	}
 }
 
-// ErrorMessages 定义用户友好的错误消息
-var ErrorMessages = map[ErrorType]string{
-	ErrorInvalidFile:  "文件格式无效或已损坏",
-	ErrorEncrypted:    "文件已加密，需要密码",
-	ErrorCorrupted:    "文件已损坏，无法处理",
-	ErrorPermission:   "没有访问文件的权限",
-	ErrorMemory:       "内存不足，请关闭其他程序后重试",
-	ErrorIO:           "文件读写错误，请检查磁盘空间",
-	ErrorValidation:   "PDF文件验证失败",
-	ErrorProcessing:   "PDF文件处理失败",
-	ErrorInvalidInput: "输入参数无效",
+// ErrorMessages 定义用户友好的错误消息在消息目录中的ID，显示时按当前语言翻译
+var ErrorMessages = map[ErrorType]i18n.MessageID{
+	ErrorInvalidFile:        "pdf.error.invalid_file",
+	ErrorEncrypted:          "pdf.error.encrypted",
+	ErrorCorrupted:          "pdf.error.corrupted",
+	ErrorPermission:         "pdf.error.permission",
+	ErrorMemory:             "pdf.error.memory",
+	ErrorIO:                 "pdf.error.io",
+	ErrorValidation:         "pdf.error.validation",
+	ErrorProcessing:         "pdf.error.processing",
+	ErrorInvalidInput:       "pdf.error.invalid_input",
+	ErrorLimitExceeded:      "pdf.error.limit_exceeded",
+	ErrorChecksumMismatch:   "pdf.error.checksum_mismatch",
+	ErrorAdapterUnavailable: "pdf.error.adapter_unavailable",
+	ErrorResourceLimit:      "pdf.error.resource_limit",
 }
 
+// 用户友好消息中的其他文本
+const (
+	msgUnknownError           i18n.MessageID = "pdf.error.unknown"
+	msgUnknownProcessingError i18n.MessageID = "pdf.error.unknown_processing"
+	msgErrorWithFile          i18n.MessageID = "pdf.error.with_file"
+	msgMoreIssues             i18n.MessageID = "pdf.issues.more"
+)
+
 // NewPDFError 创建一个新的PDFError
 func NewPDFError(errorType ErrorType, message, file string, cause error) *PDFError {
 	return &PDFError{
@@ -94,19 +137,48 @@ func NewPDFError(errorType ErrorType, message, file string, cause error) *PDFErr
 	}
 }
 
-// GetUserMessage 获取用户友好的错误消息
+// newAdapterUnavailableError 创建 ErrorAdapterUnavailable 错误，原因依次为创建后端失败的错误
+// （没有时忽略）和内置合并失败的错误
+func newAdapterUnavailableError(adapterErr, mergeErr error) *PDFError {
+	var file string
+	var pdfErr *PDFError
+	if errors.As(mergeErr, &pdfErr) {
+		file = pdfErr.File
+	}
+	return &PDFError{
+		Type:    ErrorAdapterUnavailable,
+		Message: "PDF处理后端不可用，内置合并也无法处理这些文件",
+		File:    file,
+		Cause:   errors.Join(adapterErr, mergeErr),
+	}
+}
+
+// AdapterUnavailableError 在错误链中查找 ErrorAdapterUnavailable，没有时返回nil。
+// 界面应直接显示它的消息和原因，而不是外层的合并失败信息。
+func AdapterUnavailableError(err error) *PDFError {
+	var pdfErr *PDFError
+	for errors.As(err, &pdfErr) {
+		if pdfErr.Type == ErrorAdapterUnavailable {
+			return pdfErr
+		}
+		err = pdfErr.Cause
+	}
+	return nil
+}
+
+// GetUserMessage 获取当前语言的用户友好错误消息
 func (e *PDFError) GetUserMessage() string {
-	if msg, exists := ErrorMessages[e.Type]; exists {
-		return msg
+	if id, exists := ErrorMessages[e.Type]; exists {
+		return i18n.T(id)
 	}
-	return "未知错误"
+	return i18n.T(msgUnknownError)
 }
 
 // GetDetailedMessage 获取详细的错误消息，包含文件信息
 func (e *PDFError) GetDetailedMessage() string {
 	userMsg := e.GetUserMessage()
 	if e.File != "" {
-		return fmt.Sprintf("%s (文件: %s)", userMsg, e.File)
+		return i18n.T(msgErrorWithFile, userMsg, e.displayFile())
 	}
 	return userMsg
 }
@@ -128,9 +200,9 @@ func (e *PDFError) IsRetryable() bool {
 // GetSeverity 获取错误严重程度
 func (e *PDFError) GetSeverity() string {
 	switch e.Type {
-	case ErrorMemory, ErrorIO:
+	case ErrorMemory, ErrorIO, ErrorAdapterUnavailable:
 		return "high"
-	case ErrorPermission, ErrorCorrupted:
+	case ErrorPermission, ErrorCorrupted, ErrorLimitExceeded, ErrorChecksumMismatch, ErrorResourceLimit:
 		return "medium"
 	case ErrorInvalidFile, ErrorEncrypted:
 		return "low"
@@ -149,17 +221,36 @@ type ErrorHandler interface {
 	HandleError(err error) error
 	ShouldRetry(err error) bool
 	GetUserFriendlyMessage(err error) string
+
+	// HandleOperationError 处理operation的一次失败并记录该操作的失败次数，
+	// 返回处理后的错误，以及按重试策略是否还应重试。不再重试时清除该操作的记录
+	HandleOperationError(operation string, err error) (error, bool)
+	// ResetAttempts 清除operation的失败次数，操作成功或被放弃时调用
+	ResetAttempts(operation string)
+	// RetryPolicy 返回处理器使用的重试策略
+	RetryPolicy() *RetryPolicy
 }
 
-// DefaultErrorHandler 默认错误处理器
+// DefaultErrorHandler 默认错误处理器，按操作分别记录失败次数，可被多个协程同时使用
 type DefaultErrorHandler struct {
-	maxRetries int
+	policy   *RetryPolicy
+	mu       sync.Mutex
+	attempts map[string]int
 }
 
-// NewDefaultErrorHandler 创建默认错误处理器
+// NewDefaultErrorHandler 创建默认错误处理器，IO和内存错误最多重试maxRetries次
 func NewDefaultErrorHandler(maxRetries int) *DefaultErrorHandler {
+	return NewErrorHandlerWithPolicy(NewRetryPolicy(maxRetries))
+}
+
+// NewErrorHandlerWithPolicy 创建使用指定重试策略的错误处理器，policy为nil时使用默认策略
+func NewErrorHandlerWithPolicy(policy *RetryPolicy) *DefaultErrorHandler {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
 	return &DefaultErrorHandler{
-		maxRetries: maxRetries,
+		policy:   policy,
+		attempts: make(map[string]int),
 	}
 }
 
@@ -177,20 +268,58 @@ func (h *DefaultErrorHandler) HandleError(err error) error {
 	return NewPDFError(ErrorIO, err.Error(), "", err)
 }
 
-// ShouldRetry 判断是否应该重试
+// ShouldRetry 判断错误类型在重试策略中是否可以重试
 func (h *DefaultErrorHandler) ShouldRetry(err error) bool {
-	if pdfErr, ok := err.(*PDFError); ok {
-		return pdfErr.IsRetryable()
+	return h.policy.RetriesFor(err) > 0
+}
+
+// HandleOperationError 处理operation的一次失败，失败次数未超过该错误类型的重试次数时返回true
+func (h *DefaultErrorHandler) HandleOperationError(operation string, err error) (error, bool) {
+	handledErr := h.HandleError(err)
+	if handledErr == nil {
+		return nil, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.attempts[operation]++
+	retry := h.attempts[operation] <= h.policy.RetriesFor(handledErr)
+	if !retry {
+		delete(h.attempts, operation)
 	}
-	return false
+	return handledErr, retry
 }
 
-// GetUserFriendlyMessage 获取用户友好的错误消息
+// ResetAttempts 清除operation的失败次数
+func (h *DefaultErrorHandler) ResetAttempts(operation string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.attempts, operation)
+}
+
+// Attempts 返回operation当前记录的失败次数
+func (h *DefaultErrorHandler) Attempts(operation string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.attempts[operation]
+}
+
+// RetryPolicy 返回处理器使用的重试策略
+func (h *DefaultErrorHandler) RetryPolicy() *RetryPolicy {
+	return h.policy
+}
+
+// GetUserFriendlyMessage 获取当前语言的用户友好错误消息，错误带有验证诊断时附上最严重的几条
 func (h *DefaultErrorHandler) GetUserFriendlyMessage(err error) string {
-	if pdfErr, ok := err.(*PDFError); ok {
-		return pdfErr.GetDetailedMessage()
+	pdfErr, ok := err.(*PDFError)
+	if !ok {
+		return i18n.T(msgUnknownProcessingError)
+	}
+	message := pdfErr.GetDetailedMessage()
+	if issues := ValidationIssues(err); len(issues) > 0 {
+		message += "\n" + SummarizeIssues(issues, maxSummaryIssues)
 	}
-	return "处理过程中发生未知错误"
+	return message
 }
 
 // ErrorCollector 错误收集器，用于收集批量处理中的错误
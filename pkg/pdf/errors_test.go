@@ -4,6 +4,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+
+	"github.com/user/pdf-merger/internal/i18n"
 )
 
 func TestPDFError_Error(t *testing.T) {
@@ -291,6 +293,18 @@ func TestDefaultErrorHandler_GetUserFriendlyMessage(t *testing.T) {
 			t.Errorf("Expected message '%s', got '%s'", expected, msg)
 		}
 	})
+
+	t.Run("english locale", func(t *testing.T) {
+		original := i18n.CurrentLocale()
+		i18n.SetLocale(i18n.EnUS)
+		defer i18n.SetLocale(original)
+
+		msg := handler.GetUserFriendlyMessage(NewPDFError(ErrorEncrypted, "test", "test.pdf", nil))
+		expected := "The file is encrypted and requires a password (file: test.pdf)"
+		if msg != expected {
+			t.Errorf("Expected message '%s', got '%s'", expected, msg)
+		}
+	})
 }
 
 func TestErrorCollector(t *testing.T) {
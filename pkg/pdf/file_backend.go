@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"errors"
+)
+
+// fileBackend 合并器和服务解密输入、加密输出和修复输入时使用的后端。
+// 默认实现调用pdfcpu适配器；测试可以在合并器或服务上换成假后端，而不是替换包级变量
+type fileBackend interface {
+	// DecryptFile 用password把加密的inputFile解密到outputFile
+	DecryptFile(inputFile, outputFile, password string) error
+	// EncryptFile 按encryption的设置把inputFile加密到outputFile
+	EncryptFile(inputFile, outputFile string, encryption *outputEncryption) error
+	// RepairFile 把未通过验证的inputFile修复到outputFile
+	RepairFile(inputFile, outputFile string) (*RepairReport, error)
+}
+
+// adapterFileBackend 默认后端：使用pdfcpu适配器，适配器不可用时解密和加密返回错误，修复改用内置修复
+type adapterFileBackend struct {
+	adapter *PDFCPUAdapter
+}
+
+// DecryptFile 使用适配器解密
+func (b adapterFileBackend) DecryptFile(inputFile, outputFile, password string) error {
+	if b.adapter == nil {
+		return errors.New("没有可用的解密后端")
+	}
+	return b.adapter.DecryptFile(inputFile, outputFile, password)
+}
+
+// EncryptFile 使用适配器加密
+func (b adapterFileBackend) EncryptFile(inputFile, outputFile string, encryption *outputEncryption) error {
+	if b.adapter == nil {
+		return errors.New("没有可用的加密后端")
+	}
+	return b.adapter.EncryptFile(inputFile, outputFile, encryption.userPassword, encryption.ownerPassword, encryption.permissions)
+}
+
+// RepairFile 使用适配器修复，没有适配器时使用内置修复
+func (b adapterFileBackend) RepairFile(inputFile, outputFile string) (*RepairReport, error) {
+	if b.adapter == nil {
+		return RepairPDF(inputFile, outputFile)
+	}
+	return b.adapter.RepairPDF(inputFile, outputFile)
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PDFHeaderSniffSize 识别PDF文件时读取的文件开头字节数。规范允许 %PDF- 之前有少量垃圾字节，
+// 与常见阅读器一样只在前1024字节中查找
+const PDFHeaderSniffSize = 1024
+
+// HasPDFExtension 判断路径是否以 .pdf 结尾（不区分大小写）
+func HasPDFExtension(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".pdf")
+}
+
+// HasPDFHeader 判断文件的前PDFHeaderSniffSize字节中是否包含 %PDF- 文件头，无法读取时返回false
+func HasPDFHeader(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, PDFHeaderSniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	return bytes.Contains(head[:n], []byte("%PDF-"))
+}
+
+// IsPDFFile 判断文件是否为PDF：以文件头为准，.pdf 扩展名只用于跳过读取文件。
+// 没有扩展名或扩展名不同（如下载工具保存的 document）但文件头正确的文件也是PDF；
+// 扩展名为 .pdf 但内容损坏的文件由后续的验证报告
+func IsPDFFile(filePath string) bool {
+	return HasPDFExtension(filePath) || HasPDFHeader(filePath)
+}
+
+// isAcceptedPDFPath 按是否要求扩展名判断输入是否可以作为PDF处理
+func isAcceptedPDFPath(filePath string, requireExtension bool) bool {
+	if requireExtension {
+		return HasPDFExtension(filePath)
+	}
+	return IsPDFFile(filePath)
+}
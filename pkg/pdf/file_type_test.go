@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsPDFFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"小写扩展名", write("a.pdf", "not checked"), true},
+		{"大写扩展名", write("REPORT.PDF", "not checked"), true},
+		{"无扩展名但文件头正确", write("document", "%PDF-1.7\n"), true},
+		{"文件头前有垃圾字节", write("download.bin", strings.Repeat("x", 500)+"%PDF-1.4\n"), true},
+		{"文件头超出读取范围", write("late.bin", strings.Repeat("x", PDFHeaderSniffSize)+"%PDF-1.4\n"), false},
+		{"文本文件", write("notes.txt", "hello"), false},
+		{"不存在的文件", filepath.Join(dir, "missing"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPDFFile(tt.path); got != tt.want {
+				t.Errorf("IsPDFFile(%s) = %v，期望 %v", filepath.Base(tt.path), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFService_AcceptsPDFWithoutExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "document")
+	if err := os.WriteFile(path, buildFlatPDF(2), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewPDFServiceWithConfig(DefaultServiceConfig())
+	if err := service.ValidatePDF(path); err != nil {
+		t.Errorf("文件头正确的无扩展名文件应通过验证: %v", err)
+	}
+	info, err := service.GetPDFInfo(path)
+	if err != nil {
+		t.Fatalf("获取无扩展名文件的信息失败: %v", err)
+	}
+	if info.PageCount != 2 {
+		t.Errorf("期望2页，实际 %d", info.PageCount)
+	}
+
+	// 严格模式恢复只接受 .pdf 扩展名的行为
+	config := DefaultServiceConfig()
+	config.RequirePDFExtension = true
+	err = NewPDFServiceWithConfig(config).ValidatePDF(path)
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorInvalidFile {
+		t.Errorf("严格模式下期望ErrorInvalidFile，实际: %v", err)
+	}
+}
+
+func TestStreamingMerger_MergesInputWithoutExtension(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.pdf")
+	second := filepath.Join(dir, "download")
+	for i, path := range []string{first, second} {
+		if err := os.WriteFile(path, buildFlatPDF(i+1), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir})
+	defer merger.Close()
+	if _, err := merger.MergeFiles([]string{first, second}, output, nil); err != nil {
+		t.Fatalf("合并无扩展名的输入失败: %v", err)
+	}
+	if pages, err := ReadPageCount(output, nil); err != nil || pages != 3 {
+		t.Errorf("期望输出3页，实际 %d（%v）", pages, err)
+	}
+
+	strict := NewStreamingMerger(&MergeOptions{TempDirectory: dir, RequirePDFExtension: true})
+	defer strict.Close()
+	if err := strict.basicValidation(second); err == nil {
+		t.Error("RequirePDFExtension时应拒绝没有 .pdf 扩展名的输入")
+	}
+}
@@ -0,0 +1,690 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxFormFields 遍历表单字段树时访问的字段数上限，防止损坏或恶意的循环引用
+const maxFormFields = 100000
+
+const (
+	flattenFormPrefix = "PDFMergerFlat"     // 展平时外观流XObject的资源名称前缀
+	flattenFontName   = "PDFMergerFlatFont" // 展平没有外观流的文本字段时使用的字体
+)
+
+var (
+	widgetSubtypePattern   = regexp.MustCompile(`/Subtype\s*/Widget\b`)
+	formSubtypePattern     = regexp.MustCompile(`/Subtype\s*/Form\b`)
+	fieldNamePattern       = regexp.MustCompile(`/T\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+	fieldValuePattern      = regexp.MustCompile(`/V\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+	annotFlagsPattern      = regexp.MustCompile(`/F\s+(\d+)`)
+	fontSizePattern        = regexp.MustCompile(`(\d+(?:\.\d+)?)\s+Tf\b`)
+	needAppearancesPattern = regexp.MustCompile(`/NeedAppearances\s+true\b`)
+)
+
+// FormMergeReport 合并表单的结果
+type FormMergeReport struct {
+	Fields  int           // 输出 /AcroForm /Fields 中的顶层字段数量
+	Renamed []FieldRename // 因重名而改名的字段，按合并顺序排列
+}
+
+// FieldRename 一个因与之前输入的字段重名而改名的顶层字段
+type FieldRename struct {
+	Source int    // 字段所在输入的下标
+	From   string // 原名称
+	To     string // 输出中的名称
+}
+
+// FlattenReport 展平表单的结果
+type FlattenReport struct {
+	Fields            int      // 从页面上移除的字段控件数量
+	WithoutAppearance []string // 没有外观流、展平后不显示内容的字段名称
+	// WithoutAppearancePages 与WithoutAppearance一一对应，字段控件所在的页码（从1开始）
+	WithoutAppearancePages []int
+}
+
+// CountFormFields 不依赖pdfcpu统计文件 /AcroForm 中的终端字段数量（包括对象流中的字段），
+// 没有表单时返回0
+func CountFormFields(filePath string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	bodies := objectBodies(data)
+	lookup := bodiesLookup(bodies)
+	form := acroFormDict(data, lookup)
+	if form == nil {
+		return 0, nil
+	}
+
+	count := 0
+	visited := make(map[int]bool)
+	var walk func(num int)
+	walk = func(num int) {
+		if visited[num] || len(visited) >= maxFormFields {
+			return
+		}
+		visited[num] = true
+		body, ok := bodies[num]
+		if !ok {
+			return
+		}
+		// 子节点都没有 /T 时它们只是该字段的控件，字段本身是终端字段
+		var kids []int
+		for _, kid := range refArray(topLevelOnly(body), "/Kids", lookup) {
+			if kidBody, ok := bodies[kid]; ok && fieldNamePattern.Match(topLevelOnly(kidBody)) {
+				kids = append(kids, kid)
+			}
+		}
+		if len(kids) == 0 {
+			count++
+			return
+		}
+		for _, kid := range kids {
+			walk(kid)
+		}
+	}
+	for _, field := range refArray(topLevelOnly(form), "/Fields", lookup) {
+		walk(field)
+	}
+	return count, nil
+}
+
+// MergeForms 以增量更新为outputPath重建 /AcroForm：sources 按输出顺序列出各输入（可读取的路径）及其页数，
+// 输出第1页起依次属于各输入。页面上的字段控件所属的顶层字段按输入归组，全限定名与之前输入的字段重名时
+// 顶层字段改名为 name_<输入序号>，字段值和外观流保持不变；各输入 /DR 中的字体合并（同名时保留先出现的），
+// /DA 取第一个有该项的输入。只存在于表单、没有放在任何页面上的字段不会保留。
+// 加密的输入无法读取 /DR，其表单被忽略；输入都没有表单时不修改输出
+func MergeForms(outputPath string, sources []InputPageCount) (*FormMergeReport, error) {
+	report := &FormMergeReport{}
+	forms := make([][]byte, len(sources))
+	inputBodies := make([]map[int][]byte, len(sources))
+	hasForms := false
+	total := 0
+	for i, source := range sources {
+		data, err := os.ReadFile(source.File)
+		if err != nil {
+			return nil, &PDFError{
+				Type:    ErrorIO,
+				Message: "无法读取PDF文件",
+				File:    source.File,
+				Cause:   err,
+			}
+		}
+		total += source.Pages
+		inputBodies[i] = objectBodies(data)
+		if encrypted, _ := hasEncryptEntry(source.File); encrypted {
+			continue
+		}
+		forms[i] = acroFormDict(data, bodiesLookup(inputBodies[i]))
+		hasForms = hasForms || forms[i] != nil
+	}
+	if !hasForms {
+		return report, nil
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	stats, err := WalkPageTree(outputPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.Pages) != total {
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: fmt.Sprintf("输出有 %d 页，输入共 %d 页，无法把表单字段对应到输入", len(stats.Pages), total),
+			File:    outputPath,
+		}
+	}
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	lookup := func(num int) ([]byte, bool) {
+		return objectBody(data, offsets, num)
+	}
+
+	var fields []int
+	used := make(map[string]bool)
+	seen := make(map[int]bool)
+	first := 0
+	for i, source := range sources {
+		for _, root := range pageFieldRoots(stats.Pages[first:first+source.Pages], lookup, seen) {
+			body, _ := lookup(root)
+			name := fieldName(body)
+			unique := name
+			if name != "" && used[name] {
+				unique = fmt.Sprintf("%s_%d", name, i+1)
+				for n := 2; used[unique]; n++ {
+					unique = fmt.Sprintf("%s_%d_%d", name, i+1, n)
+				}
+				report.Renamed = append(report.Renamed, FieldRename{Source: i, From: name, To: unique})
+				update.set(root, withFieldName(string(bytes.TrimSpace(body)), unique))
+			}
+			used[unique] = true
+			fields = append(fields, root)
+		}
+		first += source.Pages
+	}
+	report.Fields = len(fields)
+
+	fonts := make(map[string]bool)
+	var fontEntries []string
+	da, needAppearances := "", false
+	for i, form := range forms {
+		if form == nil {
+			continue
+		}
+		inputLookup := bodiesLookup(inputBodies[i])
+		top := topLevelOnly(form)
+		if da == "" {
+			da = defaultAppearance(top)
+		}
+		needAppearances = needAppearances || needAppearancesPattern.Match(top)
+
+		dr := resolveDictWith(topLevelDict(form), "/DR", inputLookup)
+		fontDict := resolveDictWith(topLevelDict(dr), "/Font", inputLookup)
+		for _, m := range xobjectRefPattern.FindAllSubmatch(topLevelOnly(topLevelDict(fontDict)), -1) {
+			name := string(m[1])
+			if fonts[name] {
+				continue
+			}
+			num, _ := strconv.Atoi(string(m[2]))
+			if _, ok := inputBodies[i][num]; !ok {
+				continue
+			}
+			fonts[name] = true
+			fontEntries = append(fontEntries, fmt.Sprintf("/%s %d 0 R", name, copyObjectGraph(update, inputBodies[i], num)))
+		}
+	}
+
+	entries := []string{"/Fields [" + refList(fields) + "]"}
+	if len(fontEntries) > 0 {
+		entries = append(entries, "/DR << /Font << "+strings.Join(fontEntries, " ")+" >> >>")
+	}
+	if da != "" {
+		entries = append(entries, "/DA "+da)
+	}
+	if needAppearances {
+		entries = append(entries, "/NeedAppearances true")
+	}
+	formNum := update.add("<< " + strings.Join(entries, " ") + " >>")
+
+	catalogNum, catalog, err := catalogObject(outputPath, data, lookup)
+	if err != nil {
+		return nil, err
+	}
+	update.set(catalogNum, withEntry(catalog, "/AcroForm", fmt.Sprintf("%d 0 R", formNum)))
+	return report, writeIncrementalUpdate(update, outputPath, "无法写入表单")
+}
+
+// FlattenForms 不依赖pdfcpu把表单字段展平到页面内容，以增量更新写入outputPath，输入与输出可以是同一路径。
+// 每个可见控件的外观流（复选框等按 /AS 选择状态）按 /Rect 绘制到页面上，没有外观流的文本字段用
+// Helvetica绘制 /V 的文字；控件从 /Annots 中移除，目录中的 /AcroForm 被删除。不支持加密文件
+func FlattenForms(inputPath, outputPath string) (*FlattenReport, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if encrypted, _ := hasEncryptEntry(inputPath); encrypted {
+		return nil, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法展平加密文件的表单",
+			File:    inputPath,
+		}
+	}
+	stats, err := WalkPageTree(inputPath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	lookup := func(num int) ([]byte, bool) {
+		return objectBody(data, offsets, num)
+	}
+	report := &FlattenReport{}
+	saveNum, fontNum := 0, 0
+
+	for pageIndex, pageNum := range stats.Pages {
+		body, ok := lookup(pageNum)
+		if !ok {
+			continue
+		}
+		var kept []int
+		var content bytes.Buffer
+		var forms []int
+		widgets := 0
+		for _, ref := range refArray(body, "/Annots", lookup) {
+			annot, ok := lookup(ref)
+			if !ok || !widgetSubtypePattern.Match(topLevelOnly(annot)) {
+				kept = append(kept, ref)
+				continue
+			}
+			widgets++
+			top := topLevelOnly(annot)
+			if m := annotFlagsPattern.FindSubmatch(top); m != nil {
+				// 隐藏（2）和不显示（32）的控件不绘制
+				if flags, _ := strconv.Atoi(string(m[1])); flags&(2|32) != 0 {
+					continue
+				}
+			}
+			values, ok := numberArray(top, "/Rect", 4)
+			if !ok {
+				continue
+			}
+			rect := [4]float64{
+				math.Min(values[0], values[2]), math.Min(values[1], values[3]),
+				math.Max(values[0], values[2]), math.Max(values[1], values[3]),
+			}
+
+			if ap, ok := appearanceStream(annot, lookup); ok {
+				stream, _ := lookup(ap)
+				dict := topLevelDict(stream)
+				placement, ok := formPlacement(topLevelOnly(dict), rect)
+				if !ok {
+					continue
+				}
+				if _, typed := update.objects[ap]; !typed && !formSubtypePattern.Match(topLevelOnly(dict)) {
+					update.set(ap, strings.Replace(string(bytes.TrimSpace(stream)), "<<", "<< /Type /XObject /Subtype /Form", 1))
+				}
+				fmt.Fprintf(&content, "q %s cm /%s%d Do Q\n", placement, flattenFormPrefix, ap)
+				forms = append(forms, ap)
+				continue
+			}
+
+			text, size, isText := textFieldValue(annot, lookup)
+			if !isText {
+				report.WithoutAppearance = append(report.WithoutAppearance, qualifiedFieldName(annot, lookup))
+				report.WithoutAppearancePages = append(report.WithoutAppearancePages, pageIndex+1)
+				continue
+			}
+			if text == "" {
+				continue
+			}
+			if fontNum == 0 {
+				fontNum = update.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+			}
+			content.WriteString(textFieldOperators(text, size, rect))
+		}
+		if widgets == 0 {
+			continue
+		}
+		report.Fields += widgets
+
+		page := string(bytes.TrimSpace(body))
+		if content.Len() > 0 {
+			if saveNum == 0 {
+				saveNum = update.add("<< /Length 2 >>\nstream\nq\nendstream")
+			}
+			contentNum := update.add(fmt.Sprintf("<< /Length %d >>\nstream\nQ\n%sendstream", content.Len()+2, content.String()))
+
+			resources := "<< >>"
+			if existing := inheritedResources(data, offsets, body); existing != nil {
+				resources = string(bytes.TrimSpace(existing))
+			}
+			added := make(map[int]bool)
+			for _, num := range forms {
+				if !added[num] {
+					added[num] = true
+					resources = withResourceEntry(data, offsets, resources, "/XObject", fmt.Sprintf("%s%d", flattenFormPrefix, num), num)
+				}
+			}
+			if fontNum != 0 {
+				resources = withResourceEntry(data, offsets, resources, "/Font", flattenFontName, fontNum)
+			}
+			page = withEntry(page, "/Contents", "["+strings.TrimSpace(fmt.Sprintf("%d 0 R %s %d 0 R",
+				saveNum, existingContents(data, offsets, body), contentNum))+"]")
+			page = withEntry(page, "/Resources", resources)
+		}
+		if len(kept) == 0 {
+			page = withoutEntry(page, "/Annots")
+		} else {
+			page = withEntry(page, "/Annots", "["+refList(kept)+"]")
+		}
+		update.set(pageNum, page)
+	}
+
+	catalogNum, catalog, err := catalogObject(inputPath, data, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(catalog, "/AcroForm") {
+		update.set(catalogNum, withoutEntry(catalog, "/AcroForm"))
+	}
+
+	tempPath := outputPath + ".flatten.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入展平的表单",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return report, nil
+}
+
+// bodiesLookup 返回从objectBodies的结果中读取对象的函数
+func bodiesLookup(bodies map[int][]byte) func(num int) ([]byte, bool) {
+	return func(num int) ([]byte, bool) {
+		body, ok := bodies[num]
+		return body, ok
+	}
+}
+
+// catalogObject 返回文档目录的对象编号和字典内容（不含首尾空白）
+func catalogObject(filePath string, data []byte, lookup func(num int) ([]byte, bool)) (int, string, error) {
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return 0, "", &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "找不到文档目录",
+			File:    filePath,
+		}
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	body, ok := lookup(catalogNum)
+	if !ok {
+		return 0, "", &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("文档目录对象 %d 不存在", catalogNum),
+			File:    filePath,
+		}
+	}
+	return catalogNum, string(bytes.TrimSpace(topLevelDict(body))), nil
+}
+
+// acroFormDict 返回目录中的 /AcroForm 字典，没有表单或 /Fields 为空时返回nil
+func acroFormDict(data []byte, lookup func(num int) ([]byte, bool)) []byte {
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return nil
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	catalog, ok := lookup(catalogNum)
+	if !ok {
+		return nil
+	}
+	form := resolveDictWith(topLevelDict(catalog), "/AcroForm", lookup)
+	if form == nil || len(refArray(topLevelOnly(form), "/Fields", lookup)) == 0 {
+		return nil
+	}
+	return form
+}
+
+// refArray 返回字典中key对应数组里的间接引用，支持内联数组和间接引用的数组
+func refArray(dict []byte, key string, lookup func(num int) ([]byte, bool)) []int {
+	value := directValue(dict, key)
+	if m := refPattern.FindStringSubmatch(value); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		obj, ok := lookup(num)
+		if !ok {
+			return nil
+		}
+		return parseArrayRefs(bytes.TrimSpace(obj))
+	}
+	return parseArrayRefs([]byte(value))
+}
+
+// pageFieldRoots 按页面和 /Annots 顺序返回控件所属的顶层字段，seen 中已有的字段被跳过
+func pageFieldRoots(pages []int, lookup func(num int) ([]byte, bool), seen map[int]bool) []int {
+	var roots []int
+	for _, pageNum := range pages {
+		page, ok := lookup(pageNum)
+		if !ok {
+			continue
+		}
+		for _, ref := range refArray(page, "/Annots", lookup) {
+			annot, ok := lookup(ref)
+			if !ok || !widgetSubtypePattern.Match(topLevelOnly(annot)) {
+				continue
+			}
+			root := ref
+			for hop, body := 0, annot; hop < maxParentHops; hop++ {
+				m := parentRefPattern.FindSubmatch(topLevelOnly(body))
+				if m == nil {
+					break
+				}
+				parent, _ := strconv.Atoi(string(m[1]))
+				if body, ok = lookup(parent); !ok {
+					break
+				}
+				root = parent
+			}
+			if !seen[root] {
+				seen[root] = true
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots
+}
+
+// fieldName 返回字段自身的 /T（部分名称），没有时为空
+func fieldName(body []byte) string {
+	m := fieldNamePattern.FindSubmatch(topLevelOnly(body))
+	if m == nil {
+		return ""
+	}
+	return decodePDFString(m[1])
+}
+
+// qualifiedFieldName 沿 /Parent 拼接字段的全限定名称
+func qualifiedFieldName(body []byte, lookup func(num int) ([]byte, bool)) string {
+	var parts []string
+	for hop := 0; body != nil && hop < maxParentHops; hop++ {
+		top := topLevelOnly(body)
+		if name := fieldName(body); name != "" {
+			parts = append([]string{name}, parts...)
+		}
+		m := parentRefPattern.FindSubmatch(top)
+		if m == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(m[1]))
+		body, _ = lookup(num)
+	}
+	return strings.Join(parts, ".")
+}
+
+// withFieldName 把字段字典最外层的 /T 改为name，嵌套字典（如 /MK、/AP）中的内容不变
+func withFieldName(dict, name string) string {
+	// 把嵌套字典替换为等长的空白后定位，保证找到的是最外层的 /T
+	masked := []byte(dict)
+	depth := 0
+	for i := 0; i < len(masked); i++ {
+		switch {
+		case i+1 < len(masked) && masked[i] == '<' && masked[i+1] == '<':
+			depth++
+			if depth > 1 {
+				masked[i], masked[i+1] = ' ', ' '
+			}
+			i++
+		case i+1 < len(masked) && masked[i] == '>' && masked[i+1] == '>':
+			if depth > 1 {
+				masked[i], masked[i+1] = ' ', ' '
+			}
+			depth--
+			i++
+		case depth > 1:
+			masked[i] = ' '
+		}
+	}
+	loc := fieldNamePattern.FindIndex(masked)
+	if loc == nil {
+		return strings.Replace(dict, "<<", "<< /T "+pdfTextString(name), 1)
+	}
+	return dict[:loc[0]] + "/T " + pdfTextString(name) + dict[loc[1]:]
+}
+
+// defaultAppearance 返回表单字典中 /DA 的原始字面字符串（含括号），没有时为空
+func defaultAppearance(top []byte) string {
+	idx := bytes.Index(top, []byte("/DA"))
+	if idx < 0 {
+		return ""
+	}
+	rest := bytes.TrimLeft(top[idx+len("/DA"):], " \t\r\n")
+	if !bytes.HasPrefix(rest, []byte("(")) {
+		return ""
+	}
+	return string(rest[:skipLiteralString(rest, 0)])
+}
+
+// appearanceStream 返回控件正常外观流的对象编号；外观为状态字典时按 /AS 选择
+func appearanceStream(annot []byte, lookup func(num int) ([]byte, bool)) (int, bool) {
+	ap := resolveDictWith(topLevelDict(annot), "/AP", lookup)
+	if ap == nil {
+		return 0, false
+	}
+	states := []byte(directValue(topLevelDict(ap), "/N"))
+	if m := refPattern.FindSubmatch(states); m != nil {
+		num, _ := strconv.Atoi(string(m[1]))
+		obj, ok := lookup(num)
+		if !ok {
+			return 0, false
+		}
+		if streamKeyword.Match(obj) {
+			return num, true
+		}
+		states = topLevelDict(obj)
+	}
+	state := entryValue(topLevelOnly(annot), "/AS")
+	if state == "" {
+		return 0, false
+	}
+	for _, m := range xobjectRefPattern.FindAllSubmatch(topLevelOnly(states), -1) {
+		if "/"+string(m[1]) == state {
+			num, _ := strconv.Atoi(string(m[2]))
+			if obj, ok := lookup(num); ok && streamKeyword.Match(obj) {
+				return num, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// formPlacement 计算把外观流（按其 /Matrix 变换后的 /BBox）映射到控件矩形的矩阵，
+// 即 Do 之前需要的 cm 操作数
+func formPlacement(dict []byte, rect [4]float64) (affine, bool) {
+	bbox, ok := numberArray(dict, "/BBox", 4)
+	if !ok {
+		return affine{}, false
+	}
+	matrix := affine{1, 0, 0, 1, 0, 0}
+	if values, ok := numberArray(dict, "/Matrix", 6); ok {
+		copy(matrix[:], values[:])
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{bbox[0], bbox[1]}, {bbox[2], bbox[1]}, {bbox[0], bbox[3]}, {bbox[2], bbox[3]}} {
+		x := corner[0]*matrix[0] + corner[1]*matrix[2] + matrix[4]
+		y := corner[0]*matrix[1] + corner[1]*matrix[3] + matrix[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	if maxX-minX < 1e-6 || maxY-minY < 1e-6 {
+		return affine{}, false
+	}
+	sx := (rect[2] - rect[0]) / (maxX - minX)
+	sy := (rect[3] - rect[1]) / (maxY - minY)
+	return affine{sx, 0, 0, sy, rect[0] - sx*minX, rect[1] - sy*minY}, true
+}
+
+// textFieldValue 沿 /Parent 读取继承的 /FT、/V 和 /DA，isText 表示字段为文本字段；
+// /DA 的字号为0（自动）时size为0
+func textFieldValue(annot []byte, lookup func(num int) ([]byte, bool)) (text string, size float64, isText bool) {
+	fieldType, hasValue, hasDA := "", false, false
+	for hop, body := 0, annot; body != nil && hop < maxParentHops; hop++ {
+		top := topLevelOnly(body)
+		if fieldType == "" {
+			fieldType = entryValue(top, "/FT")
+		}
+		if !hasValue {
+			if m := fieldValuePattern.FindSubmatch(top); m != nil {
+				text, hasValue = decodePDFString(m[1]), true
+			}
+		}
+		if !hasDA {
+			if da := defaultAppearance(top); da != "" {
+				if m := fontSizePattern.FindStringSubmatch(decodePDFString([]byte(da))); m != nil {
+					size, _ = strconv.ParseFloat(m[1], 64)
+				}
+				hasDA = true
+			}
+		}
+		m := parentRefPattern.FindSubmatch(top)
+		if m == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(m[1]))
+		body, _ = lookup(num)
+	}
+	return text, size, fieldType == "/Tx"
+}
+
+// textFieldOperators 返回在矩形中单行绘制文字的内容流操作符，超出矩形的部分被裁剪。
+// size为0时按矩形高度选择字号
+func textFieldOperators(text string, size float64, rect [4]float64) string {
+	width, height := rect[2]-rect[0], rect[3]-rect[1]
+	if size <= 0 {
+		size = math.Max(4, math.Min(12, height*0.7))
+	}
+	baseline := rect[1] + (height-size)/2 + size*0.22
+	return fmt.Sprintf("q %s %s %s %s re W n BT /%s %s Tf %s %s Td (%s) Tj ET Q\n",
+		formatNumber(rect[0]), formatNumber(rect[1]), formatNumber(width), formatNumber(height),
+		flattenFontName, formatNumber(size), formatNumber(rect[0]+2), formatNumber(baseline),
+		escapePDFLiteral(asciiOnly(text)))
+}
+
+// numberArray 读取字典中key对应的数字数组，要求恰好有n个数字
+func numberArray(dict []byte, key string, n int) ([]float64, bool) {
+	value := directValue(dict, key)
+	if !strings.HasPrefix(value, "[") {
+		return nil, false
+	}
+	fields := strings.Fields(strings.Trim(value, "[]"))
+	if len(fields) != n {
+		return nil, false
+	}
+	values := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
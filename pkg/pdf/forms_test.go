@@ -0,0 +1,252 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFormPDF 写出单页测试文件，页面上有一个文本字段field（控件与字段合并为对象4），值为value。
+// appearance 为true时控件带外观流（对象5，BBox为 0 0 200 20），/DR 中的字体名称为font
+func writeFormPDF(t *testing.T, dir, fileName, field, value, font string, appearance bool) string {
+	ap := ""
+	stream := "BT /" + font + " 12 Tf 2 5 Td (" + value + ") Tj ET"
+	if appearance {
+		ap = " /AP << /N 5 0 R >>"
+	}
+	content := "BT /F1 12 Tf 72 740 Td (" + fileName + ") Tj ET"
+	objects := []string{
+		fmt.Sprintf("<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /DR << /Font << /%s 6 0 R >> >> /DA (/%s 0 Tf 0 g) >> >>", font, font),
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << >> /Contents 7 0 R /Annots [4 0 R] >>",
+		fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Tx /T (%s) /V (%s) /F 4 /Rect [100 700 300 720] /P 3 0 R%s >>", field, value, ap),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 200 20] /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+	return createTestFile(t, dir, fileName, buildPDF(objects))
+}
+
+// outputFieldNames 返回 /AcroForm /Fields 中各顶层字段的名称（已排序）
+func outputFieldNames(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	bodies := objectBodies(data)
+	form := acroFormDict(data, bodiesLookup(bodies))
+	require.NotNil(t, form)
+	var names []string
+	for _, ref := range refArray(topLevelOnly(form), "/Fields", bodiesLookup(bodies)) {
+		names = append(names, fieldName(bodies[ref]))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCountFormFields(t *testing.T) {
+	dir := t.TempDir()
+	count, err := CountFormFields(writeFormPDF(t, dir, "form.pdf", "name", "Ada", "Helv", true))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// 非终端字段 person 有两个子字段；子字段 phone 的子节点只是控件
+	hierarchical := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm 4 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Fields [5 0 R] >>",
+		"<< /T (person) /Kids [6 0 R 7 0 R] >>",
+		"<< /T (name) /FT /Tx /Parent 5 0 R >>",
+		"<< /T (phone) /FT /Tx /Parent 5 0 R /Kids [8 0 R 9 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /Parent 7 0 R /Rect [0 0 10 10] >>",
+		"<< /Type /Annot /Subtype /Widget /Parent 7 0 R /Rect [0 20 10 30] >>",
+	})
+	count, err = CountFormFields(createTestFile(t, dir, "tree.pdf", hierarchical))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = CountFormFields(createTestFile(t, dir, "plain.pdf", buildFlatPDF(1)))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestWithFieldName(t *testing.T) {
+	dict := "<< /MK << /T (nested) >> /T (email) /V (x) >>"
+	assert.Equal(t, "<< /MK << /T (nested) >> /T <FEFF0065006D00610069006C005F0032> /V (x) >>", withFieldName(dict, "email_2"))
+	assert.Equal(t, "email_2", fieldName([]byte(withFieldName(dict, "email_2"))))
+	assert.Equal(t, "a", fieldName([]byte(withFieldName("<< /FT /Tx >>", "a"))), "没有 /T 时添加")
+}
+
+func TestFormPlacement(t *testing.T) {
+	placement, ok := formPlacement([]byte("/BBox [0 0 200 20]"), [4]float64{100, 700, 300, 720})
+	require.True(t, ok)
+	assert.Equal(t, "1 0 0 1 100 700", placement.String())
+
+	// 旋转90度的外观流：变换后的BBox为 [-20 0 0 200]
+	placement, ok = formPlacement([]byte("/BBox [0 0 200 20] /Matrix [0 1 -1 0 0 0]"), [4]float64{10, 10, 30, 110})
+	require.True(t, ok)
+	assert.Equal(t, "1 0 0 0.5 30 10", placement.String())
+
+	_, ok = formPlacement([]byte("/BBox [0 0 0 20]"), [4]float64{0, 0, 10, 10})
+	assert.False(t, ok)
+}
+
+func TestMergeFiles_MergesForms(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFormPDF(t, dir, "a.pdf", "email", "a@example.com", "Helv", true)
+	b := writeFormPDF(t, dir, "b.pdf", "email", "b@example.com", "Cour", true)
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.FormFields)
+	assert.Contains(t, result.Warnings, fmt.Sprintf("输入 %s 的表单字段 email 与之前的字段重名，已改名为 email_2", b))
+	assert.Equal(t, []string{"email", "email_2"}, outputFieldNames(t, output))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	bodies := objectBodies(data)
+	lookup := bodiesLookup(bodies)
+	form := acroFormDict(data, lookup)
+	assert.Equal(t, "(/Helv 0 Tf 0 g)", defaultAppearance(topLevelOnly(form)), "/DA 取第一个输入")
+	fonts := string(resolveDictWith(resolveDictWith(form, "/DR", lookup), "/Font", lookup))
+	assert.Contains(t, fonts, "/Helv")
+	assert.Contains(t, fonts, "/Cour")
+
+	for _, ref := range refArray(topLevelOnly(form), "/Fields", lookup) {
+		text, _, isText := textFieldValue(bodies[ref], lookup)
+		assert.True(t, isText)
+		want := "a@example.com"
+		if fieldName(bodies[ref]) == "email_2" {
+			want = "b@example.com"
+		}
+		assert.Equal(t, want, text, "字段值保持不变")
+		_, hasAppearance := appearanceStream(bodies[ref], lookup)
+		assert.True(t, hasAppearance, "外观流保持不变")
+	}
+
+	info, err := NewPDFService().GetPDFInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.FormFieldCount)
+}
+
+func TestMergeForms_PageCountMismatch(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFormPDF(t, dir, "a.pdf", "email", "x", "Helv", true)
+	output := createTestFile(t, dir, "out.pdf", buildFlatPDF(3))
+
+	_, err := MergeForms(output, []InputPageCount{{File: a, Pages: 1}})
+	assert.ErrorContains(t, err, "输出有 3 页，输入共 1 页")
+
+	// 输入没有表单时不检查页数，也不修改输出
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	report, err := MergeForms(output, []InputPageCount{{File: plain, Pages: 1}})
+	require.NoError(t, err)
+	assert.Zero(t, report.Fields)
+}
+
+func TestFlattenForms(t *testing.T) {
+	dir := t.TempDir()
+	input := writeFormPDF(t, dir, "form.pdf", "email", "a@example.com", "Helv", true)
+	output := filepath.Join(dir, "flat.pdf")
+
+	report, err := FlattenForms(input, output)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Fields)
+	assert.Empty(t, report.WithoutAppearance)
+
+	count, err := CountFormFields(output)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	offsets := indexObjects(data)
+	page, ok := objectBody(data, offsets, 3)
+	require.True(t, ok)
+	assert.NotContains(t, string(page), "/Annots", "控件从页面移除")
+	assert.Contains(t, string(page), "/PDFMergerFlat5 5 0 R")
+	assert.Contains(t, string(data), "q 1 0 0 1 100 700 cm /PDFMergerFlat5 Do Q")
+
+	pages, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+}
+
+func TestFlattenForms_TextWithoutAppearance(t *testing.T) {
+	dir := t.TempDir()
+	input := writeFormPDF(t, dir, "form.pdf", "email", "a@example.com", "Helv", false)
+
+	report, err := FlattenForms(input, input)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Fields)
+	assert.Empty(t, report.WithoutAppearance, "文本字段用 /V 绘制")
+
+	data, err := os.ReadFile(input)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/PDFMergerFlatFont 12 Tf 102 ")
+	assert.Contains(t, string(data), "(a@example.com) Tj")
+}
+
+func TestMergeFiles_FlattenForms(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFormPDF(t, dir, "a.pdf", "email", "a@example.com", "Helv", true)
+	b := writeFormPDF(t, dir, "b.pdf", "email", "b@example.com", "Cour", true)
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		FlattenForms:  true,
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Zero(t, result.FormFields)
+
+	count, err := CountFormFields(output)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	pages, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+}
+
+func TestMergeFiles_FlattenFormsAttributesDroppedFields(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFormPDF(t, dir, "a.pdf", "email", "a@example.com", "Helv", true)
+	b := writeFormPDF(t, dir, "b.pdf", "choice", "x", "Helv", false)
+	// 改为等长的选择字段类型，交叉引用偏移不变；没有外观流的选择字段展平后不显示
+	data, err := os.ReadFile(b)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(b, bytes.Replace(data, []byte("/FT /Tx"), []byte("/FT /Ch"), 1), 0644))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		FlattenForms:  true,
+		GenerateTOC:   true,
+		ReviewCopy:    true,
+	})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{a, b}, filepath.Join(dir, "merged.pdf"), nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ReviewCopyPath)
+
+	var dropped []PageWarning
+	for _, warning := range result.PageWarnings {
+		if warning.Kind == WarningDroppedAnnotation {
+			dropped = append(dropped, warning)
+		}
+	}
+	require.Len(t, dropped, 1)
+	assert.Equal(t, 2+result.TOCPages, dropped[0].Page, "页码应包含之后插入的目录页")
+	assert.Contains(t, dropped[0].Message, "choice")
+}
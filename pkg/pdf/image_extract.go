@@ -0,0 +1,448 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	// DefaultMinInlineImagePixels 提取图像时默认跳过的内联图像像素数（宽×高）下限，更小的内联图像通常是图标或装饰线
+	DefaultMinInlineImagePixels = 32 * 32
+	// maxExtractedImagePixels 提取的单个图像的最大像素数，防止解码时占用过多内存
+	maxExtractedImagePixels = 1 << 26
+)
+
+// 提取出的图像格式
+const (
+	ImageFormatJPEG = "jpeg"
+	ImageFormatPNG  = "png"
+)
+
+var (
+	inlineImageNamePattern = regexp.MustCompile(`/[A-Za-z0-9]+`)
+	invertedDecodePattern  = regexp.MustCompile(`/Decode\s*\[\s*1\b`)
+	// inlineImageAbbreviations 内联图像字典中的缩写键名和值，见PDF规范表92、93
+	inlineImageAbbreviations = map[string]string{
+		"/W": "/Width", "/H": "/Height", "/BPC": "/BitsPerComponent", "/CS": "/ColorSpace", "/F": "/Filter",
+		"/DP": "/DecodeParms", "/IM": "/ImageMask", "/D": "/Decode", "/I": "/Interpolate",
+		"/G": "/DeviceGray", "/RGB": "/DeviceRGB", "/CMYK": "/DeviceCMYK", "/Fl": "/FlateDecode", "/DCT": "/DCTDecode",
+		"/AHx": "/ASCIIHexDecode", "/A85": "/ASCII85Decode", "/LZW": "/LZWDecode", "/RL": "/RunLengthDecode",
+		"/CCF": "/CCITTFaxDecode",
+	}
+)
+
+// ExtractedImage 从页面中导出的一个图像
+type ExtractedImage struct {
+	Page     int    `json:"page"`                // 图像第一次出现的页码，从1开始
+	ObjectID int    `json:"object_id,omitempty"` // 图像XObject的对象编号，内联图像为0
+	Format   string `json:"format"`              // ImageFormatJPEG 或 ImageFormatPNG
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	HasAlpha bool   `json:"has_alpha,omitempty"` // /SMask 已合成为PNG的透明通道
+	Path     string `json:"path"`                // 写出的文件路径
+}
+
+// ImageExtractOptions 图像提取选项
+type ImageExtractOptions struct {
+	// MinInlinePixels 跳过像素数小于该值的内联图像，0时使用DefaultMinInlineImagePixels，负数时不跳过
+	MinInlinePixels int
+	// Warn 图像无法导出时调用，nil时忽略
+	Warn func(page int, message string)
+}
+
+// ExtractImages 不依赖pdfcpu按pages的顺序（为空时为全部页面）把页面及其表单XObject中的图像写到outputDir，
+// 返回写出的图像。DCTDecode图像原样写为JPEG，FlateDecode和未压缩的8位灰度、RGB、CMYK及1位灰度图像写为PNG；
+// 有 /SMask 的图像把软蒙版合成为PNG的透明通道。同一个图像XObject只在第一次出现的页面导出一次。
+// 不支持的过滤器或颜色空间通过opts.Warn报告后跳过；ctx结束时返回已写出的图像和ctx.Err()
+func ExtractImages(ctx context.Context, filePath string, pages []int, outputDir string, opts *ImageExtractOptions) ([]ExtractedImage, error) {
+	if opts == nil {
+		opts = &ImageExtractOptions{}
+	}
+	if encrypted, err := hasEncryptEntry(filePath); err == nil && encrypted {
+		return nil, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法从加密文件中提取图像",
+			File:    filePath,
+		}
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	pages, err = selectPages(filePath, pages, len(stats.Pages))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法创建图像输出目录",
+			File:    outputDir,
+			Cause:   err,
+		}
+	}
+
+	minInline := opts.MinInlinePixels
+	if minInline == 0 {
+		minInline = DefaultMinInlineImagePixels
+	}
+	extractor := &imageExtractor{
+		ctx:       ctx,
+		data:      data,
+		offsets:   indexObjects(data),
+		dir:       outputDir,
+		minInline: minInline,
+		warn:      opts.Warn,
+		seen:      make(map[int]bool),
+	}
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return extractor.images, err
+		}
+		body, _ := objectBody(data, extractor.offsets, stats.Pages[page-1])
+		content, err := pageContent(data, extractor.offsets, body)
+		if err != nil {
+			return extractor.images, &PDFError{
+				Type:    ErrorCorrupted,
+				Message: fmt.Sprintf("无法读取第%d页的内容流", page),
+				File:    filePath,
+				Cause:   err,
+			}
+		}
+		extractor.inline = 0
+		if err := extractor.run(page, content, inheritedResources(data, extractor.offsets, body), 0); err != nil {
+			return extractor.images, err
+		}
+	}
+	return extractor.images, nil
+}
+
+// imageExtractor 在一个文件的多个页面之间共享对象索引和已导出的图像
+type imageExtractor struct {
+	ctx       context.Context
+	data      []byte
+	offsets   map[int]int
+	dir       string
+	minInline int
+	warn      func(page int, message string)
+	seen      map[int]bool // 已处理的图像XObject对象编号
+	inline    int          // 当前页面已处理的内联图像数，用于文件名
+	images    []ExtractedImage
+}
+
+// lookup 按对象编号读取对象内容
+func (e *imageExtractor) lookup(num int) ([]byte, bool) {
+	return objectBody(e.data, e.offsets, num)
+}
+
+// run 导出内容流中绘制的图像，resources为内容流所在页面或表单XObject的资源字典；只有写出文件失败或ctx结束时返回错误
+func (e *imageExtractor) run(page int, content, resources []byte, depth int) error {
+	var xobjects []byte
+	if resources != nil {
+		xobjects = resolveDictWith(resources, "/XObject", e.lookup)
+	}
+
+	var runErr error
+	scanContentOperators(content, func(op string, operands []contentOperand) {
+		if runErr != nil {
+			return
+		}
+		switch op {
+		case "Do":
+			if len(operands) >= 1 && strings.HasPrefix(operands[0].token, "/") {
+				runErr = e.drawXObject(page, xobjects, operands[0].token[1:], resources, depth)
+			}
+		case "BI":
+			if len(operands) >= 1 {
+				runErr = e.inlineImage(page, operands[0].str)
+			}
+		}
+	})
+	return runErr
+}
+
+// drawXObject 处理 Do：导出未导出过的图像XObject，递归进入表单XObject
+func (e *imageExtractor) drawXObject(page int, xobjects []byte, name string, resources []byte, depth int) error {
+	num, ok := resourceRef(xobjects, name)
+	if !ok || e.seen[num] {
+		return nil
+	}
+	obj, ok := e.lookup(num)
+	if !ok {
+		return nil
+	}
+	if imageTypePattern.Match(obj) {
+		e.seen[num] = true
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+		dict, raw, err := splitStream(obj)
+		if err != nil {
+			e.warnf(page, "图像对象 %d 无法导出：%v", num, err)
+			return nil
+		}
+		return e.save(page, num, dict, raw, fmt.Sprintf("page-%03d-obj-%d", page, num))
+	}
+	if depth >= maxFormDepth || !formTypePattern.Match(obj) {
+		return nil
+	}
+	dict, _, err := splitStream(obj)
+	if err != nil {
+		return nil
+	}
+	content, err := decodeStream(obj)
+	if err != nil {
+		return nil
+	}
+	// 没有自己资源的表单使用所在页面的资源
+	formResources := resolveDictWith(dict, "/Resources", e.lookup)
+	if formResources == nil {
+		formResources = resources
+	}
+	return e.run(page, content, formResources, depth+1)
+}
+
+// inlineImage 导出内联图像，raw为BI之后到EI的原始字节；小于像素下限的图像被跳过
+func (e *imageExtractor) inlineImage(page int, raw []byte) error {
+	e.inline++
+	dict, data, ok := parseInlineImage(raw)
+	if !ok {
+		return nil
+	}
+	width, _ := strconv.Atoi(directValue(dict, "/Width"))
+	height, _ := strconv.Atoi(directValue(dict, "/Height"))
+	if width*height < e.minInline {
+		return nil
+	}
+	return e.save(page, 0, dict, data, fmt.Sprintf("page-%03d-inline-%d", page, e.inline))
+}
+
+// save 解码图像并写为 base.jpg 或 base.png，无法解码的图像记录警告后跳过
+func (e *imageExtractor) save(page, objectID int, dict, raw []byte, base string) error {
+	label := "内联图像"
+	if objectID > 0 {
+		label = fmt.Sprintf("图像对象 %d", objectID)
+	}
+	img, jpegData, err := decodeImageData(dict, raw, e.lookup)
+	if err != nil {
+		e.warnf(page, "%s 无法导出：%v", label, err)
+		return nil
+	}
+
+	extracted := ExtractedImage{Page: page, ObjectID: objectID}
+	if mask := e.softMask(page, label, dict); mask != nil {
+		if img == nil {
+			if img, err = jpeg.Decode(bytes.NewReader(jpegData)); err != nil {
+				e.warnf(page, "%s 无法导出：%v", label, err)
+				return nil
+			}
+		}
+		img = applySoftMask(img, mask)
+		jpegData = nil
+		extracted.HasAlpha = true
+	}
+
+	var encoded []byte
+	if jpegData != nil {
+		config, _ := jpeg.DecodeConfig(bytes.NewReader(jpegData))
+		extracted.Format, extracted.Width, extracted.Height = ImageFormatJPEG, config.Width, config.Height
+		extracted.Path = filepath.Join(e.dir, base+".jpg")
+		encoded = jpegData
+	} else {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			e.warnf(page, "%s 无法导出：%v", label, err)
+			return nil
+		}
+		bounds := img.Bounds()
+		extracted.Format, extracted.Width, extracted.Height = ImageFormatPNG, bounds.Dx(), bounds.Dy()
+		extracted.Path = filepath.Join(e.dir, base+".png")
+		encoded = b.Bytes()
+	}
+	if err := os.WriteFile(extracted.Path, encoded, 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写出图像文件",
+			File:    extracted.Path,
+			Cause:   err,
+		}
+	}
+	e.images = append(e.images, extracted)
+	return nil
+}
+
+// softMask 解码图像字典 /SMask 引用的软蒙版，没有或无法解码时返回nil
+func (e *imageExtractor) softMask(page int, label string, dict []byte) image.Image {
+	m := refPattern.FindStringSubmatch(directValue(dict, "/SMask"))
+	if m == nil {
+		return nil
+	}
+	num, _ := strconv.Atoi(m[1])
+	obj, ok := e.lookup(num)
+	if !ok {
+		return nil
+	}
+	maskDict, raw, err := splitStream(obj)
+	if err == nil {
+		var img image.Image
+		var jpegData []byte
+		if img, jpegData, err = decodeImageData(maskDict, raw, e.lookup); err == nil {
+			if img != nil {
+				return img
+			}
+			if img, err = jpeg.Decode(bytes.NewReader(jpegData)); err == nil {
+				return img
+			}
+		}
+	}
+	e.warnf(page, "%s 的软蒙版无法解码，导出时不含透明度：%v", label, err)
+	return nil
+}
+
+// warnf 通过选项中的Warn报告一条警告
+func (e *imageExtractor) warnf(page int, format string, args ...interface{}) {
+	if e.warn != nil {
+		e.warn(page, fmt.Sprintf(format, args...))
+	}
+}
+
+// parseInlineImage 把BI之后到EI的原始字节分为展开缩写后的图像字典和图像数据
+func parseInlineImage(raw []byte) ([]byte, []byte, bool) {
+	id := bytes.Index(raw, []byte("ID"))
+	if id < 0 {
+		return nil, nil, false
+	}
+	dict := inlineImageNamePattern.ReplaceAllFunc(raw[:id], func(name []byte) []byte {
+		if full, ok := inlineImageAbbreviations[string(name)]; ok {
+			return []byte(full)
+		}
+		return name
+	})
+	// ID 之后有一个空白字符，EI 之前的空白字符不属于图像数据
+	data := raw[id+2:]
+	if len(data) > 0 && isPDFWhitespace(data[0]) {
+		data = data[1:]
+	}
+	data = bytes.TrimSuffix(data, []byte("EI"))
+	if bytes.HasSuffix(data, []byte("\r\n")) {
+		data = data[:len(data)-2]
+	} else if len(data) > 0 && isPDFWhitespace(data[len(data)-1]) {
+		data = data[:len(data)-1]
+	}
+	return dict, data, true
+}
+
+// decodeImageData 按图像字典解码图像数据。最后一个过滤器为DCTDecode时不解码JPEG，返回可以原样写出的JPEG数据；
+// 否则返回解码后的图像
+func decodeImageData(dict, raw []byte, lookup func(num int) ([]byte, bool)) (image.Image, []byte, error) {
+	width, errW := strconv.Atoi(directValue(dict, "/Width"))
+	height, errH := strconv.Atoi(directValue(dict, "/Height"))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return nil, nil, fmt.Errorf("图像尺寸无效")
+	}
+	if int64(width)*int64(height) > maxExtractedImagePixels {
+		return nil, nil, fmt.Errorf("图像 %d×%d 超过 %d 像素", width, height, maxExtractedImagePixels)
+	}
+	if predictor := directValue(dict, "/Predictor"); predictor != "" && predictor != "1" {
+		return nil, nil, fmt.Errorf("不支持预测器 %s", predictor)
+	}
+
+	samples := raw
+	filters := strings.Fields(strings.ReplaceAll(strings.Trim(entryValue(dict, "/Filter"), "[] \t\r\n"), "/", " /"))
+	for i, filter := range filters {
+		switch {
+		case filter == "/FlateDecode":
+			decoded, err := inflate(samples, width*height*4)
+			if err != nil {
+				return nil, nil, err
+			}
+			samples = decoded
+		case filter == "/DCTDecode" && i == len(filters)-1:
+			config, err := jpeg.DecodeConfig(bytes.NewReader(samples))
+			if err != nil {
+				return nil, nil, err
+			}
+			if config.Width != width || config.Height != height {
+				return nil, nil, fmt.Errorf("JPEG尺寸 %d×%d 与图像字典不符", config.Width, config.Height)
+			}
+			return nil, samples, nil
+		default:
+			return nil, nil, fmt.Errorf("不支持的过滤器 %s", filter)
+		}
+	}
+
+	rect := image.Rect(0, 0, width, height)
+	if components, ok := imageComponentsWith(dict, lookup); ok {
+		size := width * height * components
+		if len(samples) < size {
+			return nil, nil, fmt.Errorf("图像数据不足")
+		}
+		if components == 4 {
+			return &image.CMYK{Pix: samples[:size], Stride: width * 4, Rect: rect}, nil, nil
+		}
+		return samplesImage(samples[:size], width, height, components), nil, nil
+	}
+
+	// 1位灰度图像和图像蒙版：默认0为黑色、1为白色，/Decode [1 0] 时相反
+	imageMask := strings.HasPrefix(directValue(dict, "/ImageMask"), "true")
+	if directValue(dict, "/BitsPerComponent") == "1" || imageMask {
+		if !imageMask && entryValue(dict, "/ColorSpace") != "/DeviceGray" {
+			return nil, nil, fmt.Errorf("不支持1位的颜色空间 %s", entryValue(dict, "/ColorSpace"))
+		}
+		stride := (width + 7) / 8
+		if len(samples) < stride*height {
+			return nil, nil, fmt.Errorf("图像数据不足")
+		}
+		invert := invertedDecodePattern.Match(dict)
+		img := image.NewGray(rect)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				bit := samples[y*stride+x/8]>>(7-uint(x%8))&1 == 1
+				if bit != invert {
+					img.Pix[y*img.Stride+x] = 0xff
+				}
+			}
+		}
+		return img, nil, nil
+	}
+	return nil, nil, fmt.Errorf("不支持的颜色空间或位深")
+}
+
+// applySoftMask 把软蒙版的灰度作为图像的透明通道，尺寸不同时先把蒙版缩放到图像的尺寸
+func applySoftMask(img, mask image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	alpha := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	xdraw.ApproxBiLinear.Scale(alpha, alpha.Bounds(), mask, mask.Bounds(), xdraw.Src, nil)
+
+	out := image.NewNRGBA(alpha.Bounds())
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			c.A = alpha.GrayAt(x, y).Y
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jpegImageObject 返回width×height的DCTDecode图像对象
+func jpegImageObject(t *testing.T, width, height int, dict string) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xC0
+	}
+	var b bytes.Buffer
+	require.NoError(t, jpeg.Encode(&b, img, nil))
+	return streamObject(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d "+
+		"/ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode %s", width, height, dict), b.String())
+}
+
+func TestExtractImages_XObjectsAndSoftMask(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R /Resources << /XObject << /Im1 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 8 0 R /Resources << /XObject << /Im1 6 0 R /Im2 7 0 R >> >> >>",
+		streamObject("", "q 100 0 0 100 0 0 cm /Im1 Do Q"),
+		jpegImageObject(t, 16, 12, ""),
+		strings.Replace(flateImageObject(color.RGBA{R: 200, G: 10, B: 10, A: 255}), "/Filter", "/SMask 9 0 R /Filter", 1),
+		streamObject("", "/Im1 Do /Im2 Do"),
+		// 2×2的软蒙版缩放到图像的4×4
+		streamObject("/Type /XObject /Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8", "\x80\x80\x80\x80"),
+	})
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "signed.pdf", data)
+	outDir := filepath.Join(dir, "imgs")
+
+	images, err := ExtractImages(context.Background(), input, nil, outDir, nil)
+	require.NoError(t, err)
+	require.Len(t, images, 2, "第2页重复引用的图像不应再次导出")
+
+	assert.Equal(t, ExtractedImage{Page: 1, ObjectID: 6, Format: ImageFormatJPEG, Width: 16, Height: 12,
+		Path: filepath.Join(outDir, "page-001-obj-6.jpg")}, images[0])
+	written, err := os.ReadFile(images[0].Path)
+	require.NoError(t, err)
+	_, err = jpeg.DecodeConfig(bytes.NewReader(written))
+	require.NoError(t, err)
+
+	assert.Equal(t, ExtractedImage{Page: 2, ObjectID: 7, Format: ImageFormatPNG, Width: 4, Height: 4, HasAlpha: true,
+		Path: filepath.Join(outDir, "page-002-obj-7.png")}, images[1])
+	file, err := os.Open(images[1].Path)
+	require.NoError(t, err)
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	require.NoError(t, err)
+	c := color.NRGBAModel.Convert(decoded.At(3, 3)).(color.NRGBA)
+	assert.Equal(t, color.NRGBA{R: 200, G: 10, B: 10, A: 0x80}, c)
+}
+
+func TestExtractImages_InlineThresholdAndWarnings(t *testing.T) {
+	large := strings.Repeat("\x40", 40*40)
+	content := "q BI /W 40 /H 40 /CS /G /BPC 8 ID " + large + " EI Q\n" +
+		"q BI /W 2 /H 2 /CS /G /BPC 8 ID \x00\xff\xff\x00 EI Q\n/Fax Do"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /XObject << /Fax 5 0 R >> >> >>",
+		streamObject("", content),
+		streamObject("/Type /XObject /Subtype /Image /Width 8 /Height 8 /ColorSpace /DeviceGray /BitsPerComponent 1 /Filter /CCITTFaxDecode", "\x00"),
+	})
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "inline.pdf", data)
+
+	var warnings []string
+	opts := &ImageExtractOptions{Warn: func(page int, message string) {
+		warnings = append(warnings, message)
+	}}
+	images, err := ExtractImages(context.Background(), input, []int{1}, filepath.Join(dir, "default"), opts)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "小于默认像素下限的内联图像应被跳过")
+	assert.Equal(t, ExtractedImage{Page: 1, Format: ImageFormatPNG, Width: 40, Height: 40,
+		Path: filepath.Join(dir, "default", "page-001-inline-1.png")}, images[0])
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "/CCITTFaxDecode")
+
+	images, err = ExtractImages(context.Background(), input, nil, filepath.Join(dir, "all"), &ImageExtractOptions{MinInlinePixels: -1})
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, filepath.Join(dir, "all", "page-001-inline-2.png"), images[1].Path)
+}
+
+func TestExtractImages_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "numbered.pdf", 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	images, err := ExtractImages(ctx, input, nil, filepath.Join(dir, "imgs"), nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, images)
+}
+
+func TestPDFServiceImpl_ExtractImages(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "numbered.pdf", 2)
+	service := NewPDFService()
+
+	images, err := service.ExtractImages(input, nil, filepath.Join(dir, "imgs"))
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	_, err = service.ExtractImages(input, []int{3}, filepath.Join(dir, "imgs"))
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+}
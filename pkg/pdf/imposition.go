@@ -0,0 +1,305 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NUpOrder n-up拼版时源页面在输出页上的排列顺序
+type NUpOrder string
+
+const (
+	NUpOrderAcross NUpOrder = "across" // 先从左到右，再从上到下
+	NUpOrderDown   NUpOrder = "down"   // 先从上到下，再从左到右
+)
+
+// ImpositionOptions 合并后的拼版选项：把多个源页面缩放排列到一张输出页上，或按骑马钉顺序排成小册子
+type ImpositionOptions struct {
+	// NUp 每张输出页上的源页面数，2或4；0且Booklet为false时不拼版，Booklet为true时0表示2
+	NUp int
+
+	// Booklet 按骑马钉印刷的顺序排列页面（最后一页、第一页、第二页、倒数第二页……），每张输出页两个源页面，
+	// 页数不是4的倍数时在末尾补空白页。双面打印后对折即为按顺序阅读的小册子
+	Booklet bool
+
+	// Margin 每个格子四周的边距（点），源页面在边距内等比缩放并居中
+	Margin float64
+
+	// Order n-up的排列顺序，空时为NUpOrderAcross；小册子总是先左后右
+	Order NUpOrder
+}
+
+// Enabled 报告是否需要拼版
+func (o *ImpositionOptions) Enabled() bool {
+	return o != nil && (o.NUp != 0 || o.Booklet)
+}
+
+// Validate 检查拼版选项是否有效
+func (o *ImpositionOptions) Validate() error {
+	invalid := func(message string) error {
+		return &PDFError{Type: ErrorInvalidInput, Message: message}
+	}
+	switch {
+	case o.NUp != 0 && o.NUp != 2 && o.NUp != 4:
+		return invalid(fmt.Sprintf("无效的n-up页数 %d，只支持 2、4", o.NUp))
+	case o.Booklet && o.NUp == 4:
+		return invalid("小册子每张输出页只能排列2个源页面")
+	case o.Margin < 0 || math.IsNaN(o.Margin) || math.IsInf(o.Margin, 0):
+		return invalid(fmt.Sprintf("无效的拼版边距 %v", o.Margin))
+	}
+	switch o.Order {
+	case "", NUpOrderAcross, NUpOrderDown:
+		return nil
+	}
+	return invalid(fmt.Sprintf("无效的n-up排列顺序 %q，只支持 %s、%s", o.Order, NUpOrderAcross, NUpOrderDown))
+}
+
+// perSheet 返回每张输出页上的源页面数
+func (o *ImpositionOptions) perSheet() int {
+	if o.NUp == 0 {
+		return 2
+	}
+	return o.NUp
+}
+
+// nup 返回合并清单中记录的每张输出页的源页面数，不拼版时为0
+func (o *ImpositionOptions) nup() int {
+	if !o.Enabled() {
+		return 0
+	}
+	return o.perSheet()
+}
+
+// imposition 返回MergeOptions中的拼版选项，不拼版时返回nil
+func (o *MergeOptions) imposition() *ImpositionOptions {
+	if o == nil || (o.NUp == 0 && !o.Booklet) {
+		return nil
+	}
+	return &ImpositionOptions{NUp: o.NUp, Booklet: o.Booklet, Margin: o.NUpMargin, Order: o.NUpOrder}
+}
+
+// ImposedPageCount 返回pages个源页面拼版后的输出页数
+func ImposedPageCount(pages int, options *ImpositionOptions) int {
+	if !options.Enabled() || pages <= 0 {
+		return pages
+	}
+	return len(impositionSlots(pages, options)) / options.perSheet()
+}
+
+// impositionSlots 返回输出页上各格子依次放置的源页面下标（从0开始），空白格子为-1
+func impositionSlots(pages int, options *ImpositionOptions) []int {
+	var slots []int
+	if options.Booklet {
+		total := (pages + 3) / 4 * 4
+		// 每张纸正面为 [最后, 第一]，背面为 [第二, 倒数第二]，依次向内
+		for i := 0; i < total/4; i++ {
+			slots = append(slots, total-1-2*i, 2*i, 2*i+1, total-2-2*i)
+		}
+	} else {
+		per := options.perSheet()
+		for i := 0; i < (pages+per-1)/per*per; i++ {
+			slots = append(slots, i)
+		}
+	}
+	for i, page := range slots {
+		if page >= pages {
+			slots[i] = -1
+		}
+	}
+	return slots
+}
+
+// displaySize 返回页面按 /Rotate 显示时可见区域（CropBox）的宽和高
+func displaySize(box PageBoxes, rotation int) (float64, float64) {
+	if rotation == 90 || rotation == 270 {
+		return box.Height(), box.Width()
+	}
+	return box.Width(), box.Height()
+}
+
+// ReadPageSize 返回第一页显示时的宽和高（点），已考虑 /Rotate
+func ReadPageSize(filePath string) (float64, float64, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	pages, boxes, err := readPageBoxes(filePath, data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(pages) == 0 {
+		return 0, 0, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "PDF文件没有页面",
+			File:    filePath,
+		}
+	}
+	offsets := indexObjects(data)
+	body, _ := objectBody(data, offsets, pages[0])
+	width, height := displaySize(boxes[0], pageRotation(data, offsets, body))
+	return width, height, nil
+}
+
+// impositionSheetSize 按第一个源页面的显示尺寸确定输出页尺寸和格子的行列数：
+// 2-up把输出页横竖对调，两个格子沿长边排列；4-up保持方向，排成2×2
+func impositionSheetSize(width, height float64, options *ImpositionOptions) (sheetWidth, sheetHeight float64, cols, rows int) {
+	if options.perSheet() == 4 {
+		return width, height, 2, 2
+	}
+	sheetWidth, sheetHeight = height, width
+	if sheetWidth >= sheetHeight {
+		return sheetWidth, sheetHeight, 2, 1
+	}
+	return sheetWidth, sheetHeight, 1, 2
+}
+
+// ImposePages 按options拼版并以增量更新写入outputPath，返回输出页数，输入与输出可以是同一路径。
+// 每个源页面成为一个表单XObject，在格子的边距内等比缩放并居中，不重新栅格化；输出页尺寸见impositionSheetSize。
+// 源页面的注释（链接、表单控件）不会带到输出页上，需要保留表单外观时先展平表单；
+// 书签和页面标签指向的页面不再在页面树中，因此从目录中移除。不支持加密文件和对象流中的页面对象。
+func ImposePages(inputPath, outputPath string, options *ImpositionOptions) (int, error) {
+	if !options.Enabled() {
+		return 0, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "没有指定拼版方式",
+			File:    inputPath,
+		}
+	}
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+	data, err := readTransformInput(inputPath, "无法为加密文件拼版")
+	if err != nil {
+		return 0, err
+	}
+
+	pages, boxes, err := readPageBoxes(inputPath, data)
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "PDF文件没有页面",
+			File:    inputPath,
+		}
+	}
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	rootBody, _ := objectBody(data, offsets, rootNum)
+
+	firstBody, _ := objectBody(data, offsets, pages[0])
+	width, height := displaySize(boxes[0], pageRotation(data, offsets, firstBody))
+	sheetWidth, sheetHeight, cols, rows := impositionSheetSize(width, height, options)
+	cellWidth, cellHeight := sheetWidth/float64(cols), sheetHeight/float64(rows)
+	if 2*options.Margin >= min(cellWidth, cellHeight) {
+		return 0, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("拼版边距 %s 点超出格子大小 %s×%s 点", formatNumber(options.Margin), formatNumber(cellWidth), formatNumber(cellHeight)),
+			File:    inputPath,
+		}
+	}
+
+	update := newIncrementalUpdate(data, offsets)
+	forms := make(map[int]int, len(pages))
+	formFor := func(index int) (int, error) {
+		if num, ok := forms[index]; ok {
+			return num, nil
+		}
+		body, _ := objectBody(data, offsets, pages[index])
+		content, err := pageContent(data, offsets, body)
+		if err != nil {
+			return 0, &PDFError{
+				Type:    ErrorCorrupted,
+				Message: fmt.Sprintf("无法读取第%d页的内容流", index+1),
+				File:    inputPath,
+				Cause:   err,
+			}
+		}
+		resources := inheritedValue(data, offsets, body, "/Resources")
+		if resources == "" {
+			resources = "<< >>"
+		}
+		stream := deflate(content)
+		crop := boxes[index].CropBox
+		num := update.add(fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [%s %s %s %s] /Resources %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			formatNumber(crop[0]), formatNumber(crop[1]), formatNumber(crop[2]), formatNumber(crop[3]), resources, len(stream), stream))
+		forms[index] = num
+		return num, nil
+	}
+
+	slots := impositionSlots(len(pages), options)
+	per := options.perSheet()
+	sheets := make([]int, 0, len(slots)/per)
+	for start := 0; start < len(slots); start += per {
+		var content bytes.Buffer
+		var xobjects []string
+		for cell, index := range slots[start : start+per] {
+			if index < 0 {
+				continue
+			}
+			formNum, err := formFor(index)
+			if err != nil {
+				return 0, err
+			}
+			row, col := cell/cols, cell%cols
+			if options.Order == NUpOrderDown && !options.Booklet {
+				row, col = cell%rows, cell/rows
+			}
+
+			body, _ := objectBody(data, offsets, pages[index])
+			rotation := pageRotation(data, offsets, body)
+			viewWidth, viewHeight := displaySize(boxes[index], rotation)
+			scale := min((cellWidth-2*options.Margin)/viewWidth, (cellHeight-2*options.Margin)/viewHeight)
+			x := float64(col)*cellWidth + (cellWidth-viewWidth*scale)/2
+			y := sheetHeight - float64(row+1)*cellHeight + (cellHeight-viewHeight*scale)/2
+			matrix := viewToUser(boxes[index].CropBox, rotation).inverse().then(affine{scale, 0, 0, scale, x, y})
+
+			name := "PDFMergerNUp" + strconv.Itoa(cell)
+			fmt.Fprintf(&content, "q %s cm /%s Do Q\n", matrix, name)
+			xobjects = append(xobjects, fmt.Sprintf("/%s %d 0 R", name, formNum))
+		}
+		contentNum := update.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+
+		// 显式设置可继承的属性，不受页面树根上的 /Rotate、/CropBox 影响
+		sheets = append(sheets, update.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /CropBox [0 0 %s %s] /Rotate 0 /Resources << /XObject << %s >> >> /Contents %d 0 R >>",
+			rootNum, formatNumber(sheetWidth), formatNumber(sheetHeight), formatNumber(sheetWidth), formatNumber(sheetHeight),
+			strings.Join(xobjects, " "), contentNum)))
+	}
+
+	root := withEntry(string(bytes.TrimSpace(rootBody)), "/Kids", "["+refList(sheets)+"]")
+	update.set(rootNum, withEntry(root, "/Count", strconv.Itoa(len(sheets))))
+
+	if matches := rootRefPattern.FindAllSubmatch(data, -1); len(matches) > 0 {
+		catalogNum, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+		if catalog, ok := objectBody(data, offsets, catalogNum); ok {
+			trimmed := withoutEntry(withoutEntry(string(bytes.TrimSpace(catalog)), "/Outlines"), "/PageLabels")
+			if trimmed != string(bytes.TrimSpace(catalog)) {
+				update.set(catalogNum, trimmed)
+			}
+		}
+	}
+
+	if err := writeTransformOutput(update, outputPath, ".impose.tmp", "无法写入拼版结果"); err != nil {
+		return 0, err
+	}
+	return len(sheets), nil
+}
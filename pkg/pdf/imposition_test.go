@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeNumberedPDF 写出pages页的美国信纸文件，每页内容流显示 "Page N"
+func writeNumberedPDF(t *testing.T, dir, name string, pages int) string {
+	t.Helper()
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R /Outlines 3 0 R >>", "", "<< /Type /Outlines /Count 0 >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"}
+	kids := make([]int, pages)
+	for i := 0; i < pages; i++ {
+		content := fmt.Sprintf("BT /F1 24 Tf 72 720 Td (Page %d) Tj ET", i+1)
+		kids[i] = len(objects) + 1
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents %d 0 R >>", len(objects)+2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", refList(kids), pages)
+	return createTestFile(t, dir, name, buildPDF(objects))
+}
+
+func TestImpositionSlots(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2, 3, 4, -1}, impositionSlots(5, &ImpositionOptions{NUp: 2}))
+	assert.Equal(t, []int{0, 1, 2, 3, 4, -1, -1, -1}, impositionSlots(5, &ImpositionOptions{NUp: 4}))
+	// 8页小册子：[8,1] [2,7] [6,3] [4,5]
+	assert.Equal(t, []int{7, 0, 1, 6, 5, 2, 3, 4}, impositionSlots(8, &ImpositionOptions{Booklet: true}))
+	// 5页补足到8页，空白页在末尾
+	assert.Equal(t, []int{-1, 0, 1, -1, -1, 2, 3, 4}, impositionSlots(5, &ImpositionOptions{Booklet: true}))
+
+	assert.Equal(t, 3, ImposedPageCount(5, &ImpositionOptions{NUp: 2}))
+	assert.Equal(t, 4, ImposedPageCount(5, &ImpositionOptions{Booklet: true}))
+	assert.Equal(t, 5, ImposedPageCount(5, nil))
+}
+
+func TestImpositionOptions_Validate(t *testing.T) {
+	for name, options := range map[string]*ImpositionOptions{
+		"nup":     {NUp: 3},
+		"booklet": {NUp: 4, Booklet: true},
+		"margin":  {NUp: 2, Margin: -1},
+		"order":   {NUp: 2, Order: "diagonal"},
+	} {
+		var pdfErr *PDFError
+		require.ErrorAs(t, options.Validate(), &pdfErr, name)
+		assert.Equal(t, ErrorInvalidInput, pdfErr.Type, name)
+	}
+	assert.NoError(t, (&ImpositionOptions{Booklet: true, Margin: 10}).Validate())
+}
+
+func TestImposePages_TwoUp(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "handout.pdf", 5)
+	output := filepath.Join(dir, "2up.pdf")
+
+	sheets, err := ImposePages(input, output, &ImpositionOptions{NUp: 2, Margin: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 3, sheets)
+
+	pages, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pages)
+	width, height, err := ReadPageSize(output)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{792, 612}, []float64{width, height}, "2-up输出页应横竖对调")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, 5, strings.Count(string(data), "/Subtype /Form"), "每个源页面应成为一个表单XObject")
+	offsets := indexObjects(data)
+	catalog, ok := objectBody(data, offsets, 1)
+	require.True(t, ok)
+	assert.NotContains(t, string(catalog), "/Outlines", "拼版后应移除书签")
+
+	geometry, err := ReadPageGeometry(output, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(geometry.Content), " Do "), "最后一张只有一个源页面")
+
+	count, err := CountPagesInFile(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count, "输入文件不应被修改")
+}
+
+func TestImposePages_FourUpOrder(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "slides.pdf", 4)
+
+	for order, want := range map[NUpOrder]string{
+		NUpOrderAcross: "0.5 0 0 0.5 306 396 cm /PDFMergerNUp1 Do",
+		NUpOrderDown:   "0.5 0 0 0.5 0 0 cm /PDFMergerNUp1 Do",
+	} {
+		output := filepath.Join(dir, string(order)+".pdf")
+		sheets, err := ImposePages(input, output, &ImpositionOptions{NUp: 4, Order: order})
+		require.NoError(t, err)
+		assert.Equal(t, 1, sheets)
+
+		geometry, err := ReadPageGeometry(output, 1)
+		require.NoError(t, err)
+		assert.Equal(t, [4]float64{0, 0, 612, 792}, geometry.MediaBox, "4-up输出页保持源页面的方向")
+		assert.Contains(t, string(geometry.Content), want, string(order))
+	}
+}
+
+func TestImposePages_Booklet(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "zine.pdf", 6)
+	output := filepath.Join(dir, "booklet.pdf")
+
+	sheets, err := ImposePages(input, output, &ImpositionOptions{Booklet: true})
+	require.NoError(t, err)
+	assert.Equal(t, 4, sheets, "6页补足到8页，每张输出页两页")
+
+	// 第一张为 [空白, 第1页]：只有右侧的格子
+	geometry, err := ReadPageGeometry(output, 1)
+	require.NoError(t, err)
+	assert.NotContains(t, string(geometry.Content), "/PDFMergerNUp0 Do")
+	assert.Contains(t, string(geometry.Content), "/PDFMergerNUp1 Do")
+}
+
+func TestImposePages_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "in.pdf", 2)
+	output := filepath.Join(dir, "out.pdf")
+
+	_, err := ImposePages(input, output, nil)
+	assert.Error(t, err)
+
+	_, err = ImposePages(input, output, &ImpositionOptions{NUp: 2, Margin: 400})
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.NoFileExists(t, output)
+}
+
+func TestMergeOptions_ValidateImposition(t *testing.T) {
+	assert.NoError(t, (&MergeOptions{NUp: 2, GenerateTOC: true}).Validate())
+	assert.Error(t, (&MergeOptions{NUp: 3}).Validate())
+	assert.Error(t, (&MergeOptions{Booklet: true, AddSourceBookmarks: true}).Validate())
+}
+
+func TestMergeFiles_NUp(t *testing.T) {
+	dir := t.TempDir()
+	a := writeNumberedPDF(t, dir, "a.pdf", 3)
+	b := writeNumberedPDF(t, dir, "b.pdf", 2)
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Stamps:        []*StampOptions{PageNumberStamp("{page}")},
+		NUp:           2,
+	})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalPages, "5个源页面2-up后为3页")
+
+	info, err := NewPDFService().GetPDFInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.PageCount)
+	assert.Equal(t, 792.0, info.PageWidth)
+	assert.Equal(t, 612.0, info.PageHeight)
+}
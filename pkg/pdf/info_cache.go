@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultInfoCacheSize PDF信息缓存默认保留的文件数
+const DefaultInfoCacheSize = 256
+
+// infoCacheEntry 一个文件的缓存信息，以及提取信息时文件的大小和修改时间
+type infoCacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+	info    *PDFInfo
+}
+
+// infoCache 按（绝对路径、大小、修改时间）缓存PDF信息的LRU缓存。
+// 文件大小或修改时间变化后缓存自动失效，命中只需要一次stat，不再解析文件。
+type infoCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // 最近使用的在前
+}
+
+// newInfoCache 创建容量为capacity的缓存，capacity为0时使用DefaultInfoCacheSize，负数时返回nil（不缓存）
+func newInfoCache(capacity int) *infoCache {
+	if capacity < 0 {
+		return nil
+	}
+	if capacity == 0 {
+		capacity = DefaultInfoCacheSize
+	}
+	return &infoCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get 返回文件当前大小和修改时间下缓存的信息副本，未命中时返回nil
+func (c *infoCache) get(filePath string, stat os.FileInfo) *PDFInfo {
+	if c == nil {
+		return nil
+	}
+	path := absPath(filePath)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*infoCacheEntry)
+	if entry.size != stat.Size() || !entry.modTime.Equal(stat.ModTime()) {
+		// 文件已修改，丢弃过期的信息
+		c.order.Remove(elem)
+		delete(c.entries, path)
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return entry.info.Clone()
+}
+
+// put 缓存文件在stat时刻的信息，超出容量时淘汰最久未使用的文件
+func (c *infoCache) put(filePath string, stat os.FileInfo, info *PDFInfo) {
+	if c == nil {
+		return
+	}
+	entry := &infoCacheEntry{
+		path:    absPath(filePath),
+		size:    stat.Size(),
+		modTime: stat.ModTime(),
+		info:    info.Clone(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.path]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.path] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*infoCacheEntry).path)
+	}
+}
+
+// invalidate 丢弃文件的缓存信息
+func (c *infoCache) invalidate(filePath string) {
+	if c == nil {
+		return
+	}
+	path := absPath(filePath)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, path)
+	}
+}
+
+// len 返回缓存的文件数
+func (c *infoCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfoCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache := newInfoCache(2)
+	paths := make([]string, 3)
+	for i := range paths {
+		paths[i] = createTestFile(t, dir, fmt.Sprintf("%d.pdf", i), buildFlatPDF(i+1))
+	}
+	stat := func(path string) os.FileInfo {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info
+	}
+
+	cache.put(paths[0], stat(paths[0]), &PDFInfo{PageCount: 1})
+	cache.put(paths[1], stat(paths[1]), &PDFInfo{PageCount: 2})
+	require.NotNil(t, cache.get(paths[0], stat(paths[0])), "访问后应成为最近使用")
+	cache.put(paths[2], stat(paths[2]), &PDFInfo{PageCount: 3})
+
+	assert.Equal(t, 2, cache.len())
+	assert.Nil(t, cache.get(paths[1], stat(paths[1])), "最久未使用的文件应被淘汰")
+	assert.NotNil(t, cache.get(paths[0], stat(paths[0])))
+	assert.NotNil(t, cache.get(paths[2], stat(paths[2])))
+}
+
+func TestInfoCache_DisabledWhenNegative(t *testing.T) {
+	cache := newInfoCache(-1)
+	assert.Nil(t, cache)
+	cache.put("a.pdf", nil, &PDFInfo{})
+	cache.invalidate("a.pdf")
+	assert.Equal(t, 0, cache.len())
+}
+
+func TestPDFService_GetPDFInfoUsesCache(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = t.TempDir()
+	service := NewPDFServiceWithConfig(config).(*PDFServiceImpl)
+
+	first, err := service.GetPDFInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, first.PageCount)
+	assert.Equal(t, 1, service.infoCache.len())
+
+	// 返回的是副本，修改不影响缓存
+	first.PageCount = 99
+	second, err := service.GetPDFInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.PageCount)
+
+	// 文件修改后缓存自动失效
+	require.NoError(t, os.WriteFile(path, buildFlatPDF(3), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	third, err := service.GetPDFInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, third.PageCount)
+}
+
+func TestPDFService_InvalidateInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestFile(t, dir, "a.pdf", buildFlatPDF(1))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = t.TempDir()
+	service := NewPDFServiceWithConfig(config).(*PDFServiceImpl)
+
+	_, err := service.GetPDFInfo(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, service.infoCache.len())
+
+	// 相对路径和绝对路径对应同一条缓存
+	relative, err := filepath.Rel(mustGetwd(t), path)
+	require.NoError(t, err)
+	service.InvalidateInfo(relative)
+	assert.Equal(t, 0, service.infoCache.len())
+}
+
+func mustGetwd(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	return wd
+}
+
+// benchmarkInfoFiles 创建100个用于信息基准测试的文件
+func benchmarkInfoFiles(b *testing.B) []string {
+	b.Helper()
+	dir := b.TempDir()
+	paths := make([]string, 100)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%03d.pdf", i))
+		if err := os.WriteFile(paths[i], buildFlatPDF(i%10+1), 0644); err != nil {
+			b.Fatalf("创建文件失败: %v", err)
+		}
+	}
+	return paths
+}
+
+// benchmarkGetPDFInfo 反复获取100个文件的信息，模拟界面刷新文件列表
+func benchmarkGetPDFInfo(b *testing.B, cacheSize int) {
+	paths := benchmarkInfoFiles(b)
+	config := DefaultServiceConfig()
+	config.TempDirectory = b.TempDir()
+	config.InfoCacheSize = cacheSize
+	service := NewPDFServiceWithConfig(config).(*PDFServiceImpl)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			if _, err := service.GetPDFInfo(path); err != nil {
+				b.Fatalf("获取信息失败: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkGetPDFInfo_Uncached 每次都完整解析文件
+func BenchmarkGetPDFInfo_Uncached(b *testing.B) {
+	benchmarkGetPDFInfo(b, -1)
+}
+
+// BenchmarkGetPDFInfo_Cached 文件未修改时每个文件只需一次stat
+func BenchmarkGetPDFInfo_Cached(b *testing.B) {
+	benchmarkGetPDFInfo(b, 0)
+}
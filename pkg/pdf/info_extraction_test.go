@@ -545,14 +545,26 @@ func BenchmarkPDFInfoExtraction(b *testing.B) {
 	tempDir := createTempDir(b, "benchmark_info_extraction")
 	testFile := createTestPDFFile(b, tempDir, "benchmark_test.pdf")
 
-	service := NewPDFService()
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, err := service.GetPDFInfo(testFile)
-		if err != nil {
-			b.Fatalf("Benchmark failed: %v", err)
+	// 关闭信息缓存，对比每次调用新建适配器与复用适配器池中的适配器
+	for _, poolSize := range []int{-1, 0} {
+		name := "new_adapter"
+		if poolSize >= 0 {
+			name = "pooled"
 		}
+		b.Run(name, func(b *testing.B) {
+			config := DefaultServiceConfig()
+			config.InfoCacheSize = -1
+			config.AdapterPoolSize = poolSize
+			service := NewPDFServiceWithConfig(config)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := service.GetPDFInfo(testFile)
+				if err != nil {
+					b.Fatalf("Benchmark failed: %v", err)
+				}
+			}
+		})
 	}
 }
 
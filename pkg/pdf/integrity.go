@@ -0,0 +1,183 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// ChecksumSidecarSuffix 校验和旁路文件后缀，如 input.pdf.sha256
+const ChecksumSidecarSuffix = ".sha256"
+
+// 预期校验和的来源
+const (
+	DigestSourceSidecar = "sidecar"
+	DigestSourceSpec    = "spec"
+)
+
+// InputDigest 输入文件的内容摘要，可保存下来供后续运行校验
+type InputDigest struct {
+	File     string `json:"file"`
+	RawFile  string `json:"raw_file"`     // 原始路径的转义形式，非UTF-8字节写作\xNN
+	Display  string `json:"display_file"` // 用于显示的路径，非UTF-8文件名按回退编码解码
+	SHA256   string `json:"sha256"`
+	Expected string `json:"expected,omitempty"` // 预期摘要，没有时为空
+	Source   string `json:"source,omitempty"`   // 预期摘要的来源（sidecar/spec）
+	Verified bool   `json:"verified"`           // 是否已与预期摘要比对一致
+}
+
+// ChecksumMismatchError 文件内容与预期校验和不一致
+type ChecksumMismatchError struct {
+	File     string
+	Expected string
+	Observed string
+	Source   string
+}
+
+// Error 实现error接口
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s (%s): expected sha256 %s, observed %s",
+		model.DisplayName(e.File, model.DefaultFilenameEncodings), e.Source, e.Expected, e.Observed)
+}
+
+// newChecksumMismatchError 创建校验和不一致错误，以PDFError包装以便统一处理
+func newChecksumMismatchError(filePath, expected, observed, source string) *PDFError {
+	return &PDFError{
+		Type:    ErrorChecksumMismatch,
+		Message: fmt.Sprintf("文件校验和不一致（预期 %s，实际 %s）", expected, observed),
+		File:    filePath,
+		Cause: &ChecksumMismatchError{
+			File:     filePath,
+			Expected: expected,
+			Observed: observed,
+			Source:   source,
+		},
+	}
+}
+
+// ReadChecksumSidecar 读取文件旁的 .sha256 校验和文件，兼容 sha256sum 输出格式。
+// 旁路文件不存在时返回false。
+func ReadChecksumSidecar(filePath string) (string, bool, error) {
+	sidecarPath := filePath + ChecksumSidecarSuffix
+	data, err := os.ReadFile(sidecarPath)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取校验和文件",
+			File:    sidecarPath,
+			Cause:   err,
+		}
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || !isSHA256Hex(fields[0]) {
+		return "", false, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "校验和文件格式无效",
+			File:    sidecarPath,
+		}
+	}
+	return strings.ToLower(fields[0]), true, nil
+}
+
+// isSHA256Hex 判断字符串是否为SHA-256十六进制摘要
+func isSHA256Hex(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// expectedDigest 返回输入文件的预期摘要，合并选项中指定的优先于旁路文件
+func expectedDigest(filePath string, expected map[string]string) (string, string, error) {
+	if digest, exists := expected[filePath]; exists && digest != "" {
+		if !isSHA256Hex(digest) {
+			return "", "", &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("无效的预期校验和: %s", digest),
+				File:    filePath,
+			}
+		}
+		return strings.ToLower(digest), DigestSourceSpec, nil
+	}
+
+	digest, exists, err := ReadChecksumSidecar(filePath)
+	if err != nil || !exists {
+		return "", "", err
+	}
+	return digest, DigestSourceSidecar, nil
+}
+
+// verifyInputIntegrity 在一次顺序读取中同时完成PDF头部检查和SHA-256计算，
+// 不额外增加读取遍数。存在预期摘要且不一致时返回ErrorChecksumMismatch。
+func verifyInputIntegrity(filePath string, expected map[string]string) (*InputDigest, error) {
+	want, source, err := expectedDigest(filePath, expected)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法打开文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	defer file.Close()
+
+	info, statErr := file.Stat()
+	hash := sha256.New()
+	reader := io.TeeReader(file, hash)
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil || !bytes.Equal(header, []byte("%PDF-")) {
+		return nil, &PDFError{
+			Type:    ErrorInvalidFile,
+			Message: "无效的PDF文件头",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	rest, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "读取文件时发生IO错误",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	digest := &InputDigest{
+		File:     filePath,
+		RawFile:  model.EscapeRawName(filePath),
+		Display:  model.DisplayName(filePath, model.DefaultFilenameEncodings),
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+		Expected: want,
+		Source:   source,
+	}
+	// 同一次读取得到的摘要供重复输入检测复用
+	if statErr == nil {
+		rememberContentHash(filePath, info, ContentHash{Size: int64(len(header)) + rest, SHA256: digest.SHA256})
+	}
+	if want == "" {
+		return digest, nil
+	}
+	if digest.SHA256 != want {
+		return digest, newChecksumMismatchError(filePath, want, digest.SHA256, source)
+	}
+	digest.Verified = true
+	return digest, nil
+}
@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSidecar 按 sha256sum 格式为文件写入 .sha256 旁路文件
+func writeSidecar(t *testing.T, filePath string) string {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	content := fmt.Sprintf("%s  %s\n", digest, filepath.Base(filePath))
+	if err := os.WriteFile(filePath+ChecksumSidecarSuffix, []byte(content), 0644); err != nil {
+		t.Fatalf("写入校验和文件失败: %v", err)
+	}
+	return digest
+}
+
+// corruptBlock 模拟坏扇区：将文件中间一段内容清零，长度不变
+func corruptBlock(t *testing.T, filePath string) {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	for i := len(data) / 3; i < len(data)/3+16 && i < len(data); i++ {
+		data[i] = 0
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+}
+
+func newIntegrityMerger(expected map[string]string) *StreamingMerger {
+	merger := NewStreamingMerger(&MergeOptions{
+		MaxMemoryUsage:    100 * 1024 * 1024,
+		TempDirectory:     os.TempDir(),
+		VerifyChecksums:   true,
+		ExpectedChecksums: expected,
+	})
+	merger.adapter = nil
+	return merger
+}
+
+func TestIntegrity_CorruptedInputRejected(t *testing.T) {
+	tempDir := t.TempDir()
+	good := createTestFile(t, tempDir, "good.pdf", buildFlatPDF(2))
+	bad := createTestFile(t, tempDir, "bad.pdf", buildFlatPDF(3))
+	writeSidecar(t, good)
+	expected := writeSidecar(t, bad)
+	corruptBlock(t, bad)
+
+	merger := newIntegrityMerger(nil)
+	result, err := merger.MergeFiles([]string{good, bad}, filepath.Join(tempDir, "out.pdf"), nil)
+
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorChecksumMismatch {
+		t.Fatalf("期望ErrorChecksumMismatch，实际: %v", err)
+	}
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("错误链中缺少ChecksumMismatchError: %v", err)
+	}
+	if mismatch.File != bad || mismatch.Expected != expected || mismatch.Observed == expected {
+		t.Errorf("错误信息不正确: %+v", mismatch)
+	}
+	if mismatch.Source != DigestSourceSidecar {
+		t.Errorf("期望来源为sidecar，实际 %s", mismatch.Source)
+	}
+
+	if result == nil || result.FailedStage != MergeStageValidation {
+		t.Fatalf("期望在验证阶段失败并返回部分结果，实际: %+v", result)
+	}
+	if len(result.InputDigests) != 2 || !result.InputDigests[0].Verified || result.InputDigests[1].Verified {
+		t.Errorf("部分结果中的摘要记录不正确: %+v", result.InputDigests)
+	}
+}
+
+func TestIntegrity_ExpectedChecksumFromOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	file1 := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(2))
+
+	wrong := hex.EncodeToString(make([]byte, sha256.Size))
+	merger := newIntegrityMerger(map[string]string{file2: wrong})
+	_, err := merger.MergeStreaming(context.Background(), []string{file1, file2}, filepath.Join(tempDir, "out.pdf"), nil)
+
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("期望ChecksumMismatchError，实际: %v", err)
+	}
+	if mismatch.Source != DigestSourceSpec || mismatch.Expected != wrong {
+		t.Errorf("期望使用选项中的预期摘要，实际: %+v", mismatch)
+	}
+}
+
+func TestIntegrity_NoSidecarRecordsDigests(t *testing.T) {
+	tempDir := t.TempDir()
+	content := buildFlatPDF(2)
+	file1 := createTestFile(t, tempDir, "a.pdf", content)
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(4))
+
+	merger := newIntegrityMerger(nil)
+	result, err := merger.MergeFiles([]string{file1, file2}, filepath.Join(tempDir, "out.pdf"), nil)
+	if err != nil {
+		t.Fatalf("没有旁路文件时不应失败: %v", err)
+	}
+
+	if len(result.InputDigests) != 2 {
+		t.Fatalf("期望记录2个摘要，实际 %d", len(result.InputDigests))
+	}
+	sum := sha256.Sum256(content)
+	digest := result.InputDigests[0]
+	if digest.File != file1 || digest.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("记录的摘要不正确: %+v", digest)
+	}
+	if digest.Expected != "" || digest.Verified {
+		t.Errorf("没有预期摘要时不应标记为已校验: %+v", digest)
+	}
+}
+
+func TestIntegrity_DisabledByDefault(t *testing.T) {
+	tempDir := t.TempDir()
+	file1 := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+	file2 := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(1))
+	writeSidecar(t, file2)
+	// 写入校验和之后文件被改动，但仍是有效的PDF
+	createTestFile(t, tempDir, "b.pdf", append(buildFlatPDF(1), "% edited\n"...))
+
+	merger := NewStreamingMerger(nil)
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{file1, file2}, filepath.Join(tempDir, "out.pdf"), nil)
+	if err != nil {
+		t.Fatalf("未启用完整性模式时不应校验: %v", err)
+	}
+	if len(result.InputDigests) != 0 {
+		t.Errorf("未启用完整性模式时不应记录摘要: %+v", result.InputDigests)
+	}
+}
+
+func TestReadChecksumSidecar(t *testing.T) {
+	tempDir := t.TempDir()
+	file := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1))
+
+	if _, exists, err := ReadChecksumSidecar(file); exists || err != nil {
+		t.Errorf("旁路文件不存在时应返回false，实际: %v, %v", exists, err)
+	}
+
+	os.WriteFile(file+ChecksumSidecarSuffix, []byte("not-a-digest\n"), 0644)
+	if _, _, err := ReadChecksumSidecar(file); err == nil {
+		t.Error("格式无效的旁路文件应返回错误")
+	}
+
+	expected := writeSidecar(t, file)
+	digest, exists, err := ReadChecksumSidecar(file)
+	if err != nil || !exists || digest != expected {
+		t.Errorf("期望读取到 %s，实际: %s, %v, %v", expected, digest, exists, err)
+	}
+}
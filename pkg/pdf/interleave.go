@@ -0,0 +1,141 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// interleaveOrder 返回交替合并后的页面顺序：先合并的文档中第一个输入占第1..countA页，
+// 第二个输入占其后的countB页。页数不等时多出的页面依次追加在末尾；
+// reverseSecond 为true时第二个输入按从后往前的顺序取页（扫描仪倒序输出背面）。
+func interleaveOrder(countA, countB int, reverseSecond bool) []int {
+	order := make([]int, 0, countA+countB)
+	for i := 0; i < max(countA, countB); i++ {
+		if i < countA {
+			order = append(order, i+1)
+		}
+		if i < countB {
+			page := i + 1
+			if reverseSecond {
+				page = countB - i
+			}
+			order = append(order, countA+page)
+		}
+	}
+	return order
+}
+
+// MergeInterleaved 交替合并两个输入的页面，用于双面扫描得到的奇数页和偶数页文件：
+// 输出依次为 fileA 第1页、fileB 第1页、fileA 第2页……，页数不等时剩余页面追加在末尾。
+// reverseSecond 为true时 fileB 从最后一页开始取。
+// 先按普通方式合并两个输入，再以增量更新重排合并结果的页面，返回的 MergeResult 描述最终输出。
+func (sm *StreamingMerger) MergeInterleaved(ctx context.Context, fileA, fileB string, reverseSecond bool, outputPath string,
+	progressCallback func(progress float64, message string)) (*MergeResult, error) {
+
+	if err := sm.closer.enter("合并器", ""); err != nil {
+		return nil, err
+	}
+	defer sm.closer.leave()
+
+	startTime := sm.clock.Now()
+	workDir, err := os.MkdirTemp(sm.tempDir, "pdf-interleave-")
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法创建交替合并目录",
+			File:    sm.tempDir,
+			Cause:   err,
+		}
+	}
+	defer os.RemoveAll(workDir)
+
+	// 交替排列会打散各输入的页面，不添加来源书签和目录页；印章的页码、优化和加密在重排之后处理
+	// 中间结果留在本地，只有重排后的最终输出交给输出后端
+	bookmarks, toc, stamps, optimize, encryption, backend := sm.sourceBookmarks, sm.generateTOC, sm.stamps, sm.optimize, sm.encryption, sm.outputBackend
+	sm.sourceBookmarks, sm.generateTOC, sm.stamps, sm.optimize, sm.encryption, sm.outputBackend = false, false, nil, false, nil, nil
+	// 交替排列后各输入的页面不再连续，清单无法记录页面偏移
+	writeManifest, embedManifest := sm.writeManifest, sm.embedManifest
+	sm.writeManifest, sm.embedManifest = false, false
+	merged := filepath.Join(workDir, "merged.pdf")
+	result, err := sm.MergeStreaming(ctx, []string{fileA, fileB}, merged, progressCallback)
+	sm.sourceBookmarks, sm.generateTOC, sm.stamps, sm.optimize, sm.encryption, sm.outputBackend = bookmarks, toc, stamps, optimize, encryption, backend
+	sm.writeManifest, sm.embedManifest = writeManifest, embedManifest
+	if result != nil && bookmarks {
+		result.Warnings = append(result.Warnings, "交替合并不添加来源书签")
+	}
+	if result != nil && toc {
+		result.Warnings = append(result.Warnings, "交替合并不添加目录页")
+	}
+	if result != nil && (writeManifest || embedManifest) {
+		result.Warnings = append(result.Warnings, "交替合并不生成合并清单")
+	}
+	if result != nil {
+		result.OutputPath = outputPath
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// 交替合并需要两个输入都有效，被跳过的输入会使页面无法对应
+	if len(result.SkippedFiles) > 0 {
+		return sm.failResult(result, MergeStageValidation, startTime), &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "交替合并需要两个有效的输入",
+			File:    result.SkippedFiles[0],
+		}
+	}
+
+	counts := make(map[string]int, len(result.InputPages))
+	for _, input := range result.InputPages {
+		counts[input.File] = input.Pages
+	}
+	countA, okA := counts[fileA]
+	countB, okB := counts[fileB]
+	if !okA || !okB || countA+countB != result.TotalPages {
+		return sm.failResult(result, MergeStageVerification, startTime), &PDFError{
+			Type:    ErrorProcessing,
+			Message: fmt.Sprintf("合并结果的页数 %d 与输入页数 %d+%d 不一致，无法交替排列", result.TotalPages, countA, countB),
+			File:    outputPath,
+		}
+	}
+
+	if progressCallback != nil {
+		progressCallback(100, "交替排列页面")
+	}
+	staging := sm.stagingPath(outputPath)
+	defer discardStaging(staging)
+	if err := ExtractPages(merged, staging, interleaveOrder(countA, countB, reverseSecond)); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+
+	// 重排以增量更新写入，需要在重排后添加印章、优化、加密、重新线性化，并针对最终输出重新生成审阅副本
+	if err := sm.stampOutput(result, staging, outputPath, nil); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	sm.optimizeOutput(result, staging, []string{fileA, fileB}, sm.reviewCopy)
+	if err := sm.encryptOutput(result, staging); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.linearizeOutput(staging); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.verifyLinearized(result, staging); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	committed, err := sm.commitMergedOutput(result, staging, outputPath)
+	if err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if committed != "" {
+		sm.countOutputPages(result, committed)
+	}
+	result.ProcessingTime = sm.clock.Now().Sub(startTime)
+	if sm.reviewCopy {
+		result.PageWarnings = nil
+		result.ReviewCopyPath = ""
+		sm.produceReviewCopy(result)
+	}
+	return result, nil
+}
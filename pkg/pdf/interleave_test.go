@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterleaveOrder(t *testing.T) {
+	tests := []struct {
+		name           string
+		countA, countB int
+		reverse        bool
+		want           []int
+	}{
+		{"equal", 3, 3, false, []int{1, 4, 2, 5, 3, 6}},
+		{"reverse second", 3, 3, true, []int{1, 6, 2, 5, 3, 4}},
+		{"first longer", 3, 1, false, []int{1, 4, 2, 3}},
+		{"second longer reversed", 1, 3, true, []int{1, 4, 3, 2}},
+		{"empty second", 2, 0, false, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, interleaveOrder(tt.countA, tt.countB, tt.reverse))
+		})
+	}
+}
+
+func TestInterleaveOrder_ReordersMergedDocument(t *testing.T) {
+	dir := t.TempDir()
+	// 模拟合并后的文档：第1..2页为奇数页扫描（第1、3页），第3..5页为倒序的偶数页扫描（第6、4、2页）
+	labels := []string{"1", "3", "6", "4", "2"}
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R 7 0 R] /Count 5 >>"}
+	for i := range labels {
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", 8+i))
+	}
+	for _, label := range labels {
+		content := "BT (page " + label + ") Tj ET"
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	merged := createTestFile(t, dir, "merged.pdf", buildPDF(objects))
+	output := filepath.Join(dir, "out.pdf")
+
+	require.NoError(t, ExtractPages(merged, output, interleaveOrder(2, 3, true)))
+
+	stats, err := WalkPageTreeFile(output, nil)
+	require.NoError(t, err)
+	var got []string
+	for _, pageNum := range stats.Pages {
+		got = append(got, labels[pageNum-3])
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4", "6"}, got, "应按双面扫描的原始顺序排列")
+}
+
+func TestMergeInterleaved_RequiresBothInputs(t *testing.T) {
+	dir := t.TempDir()
+	odds := createTestFile(t, dir, "odds.pdf", buildFlatPDF(3))
+	evens := createTestFile(t, dir, "evens.txt", []byte("not a pdf"))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeInterleaved(context.Background(), odds, evens, false, output, nil)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.Equal(t, evens, pdfErr.File)
+	require.NotNil(t, result)
+	assert.Equal(t, output, result.OutputPath)
+	assert.False(t, fileExists(output))
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "pdf-interleave-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestMergeInterleaved_DuplexScan(t *testing.T) {
+	dir := t.TempDir()
+	odds := createTestFile(t, dir, "odds.pdf", buildFlatPDF(3))
+	evens := createTestFile(t, dir, "evens.pdf", buildFlatPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	if merger.adapter == nil || !CheckPDFCPUAvailability().IsAvailable() {
+		t.Skip("pdfcpu不可用")
+	}
+	result, err := merger.MergeInterleaved(context.Background(), odds, evens, true, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.TotalPages)
+	assert.Equal(t, output, result.OutputPath)
+}
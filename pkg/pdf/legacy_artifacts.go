@@ -0,0 +1,354 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// LegacyArtifactKind 历史遗留文件的类别
+type LegacyArtifactKind string
+
+const (
+	// ArtifactFallback 旧回退合并写出的 output.pdf.fallback 文本文件
+	ArtifactFallback LegacyArtifactKind = "fallback"
+	// ArtifactPlaceholder pdfcpu不可用时写出的 .placeholder 文本文件
+	ArtifactPlaceholder LegacyArtifactKind = "placeholder"
+	// ArtifactTempChunk 中断的合并遗留的 *_temp_*.pdf 分块
+	ArtifactTempChunk LegacyArtifactKind = "temp_chunk"
+)
+
+// ArtifactConfidence 分类的可信程度
+type ArtifactConfidence string
+
+const (
+	// ConfidenceSignature 文件内容与本工具写出的格式一致，确定是遗留文件
+	ConfidenceSignature ArtifactConfidence = "signature"
+	// ConfidenceNameOnly 只有文件名符合历史模式，内容无法确认
+	ConfidenceNameOnly ArtifactConfidence = "name_only"
+)
+
+// LegacyCleanupMode 清理动作
+type LegacyCleanupMode string
+
+const (
+	CleanupDryRun     LegacyCleanupMode = "dry-run"    // 只报告，不修改文件
+	CleanupDelete     LegacyCleanupMode = "delete"     // 删除确定的遗留文件
+	CleanupQuarantine LegacyCleanupMode = "quarantine" // 移入带日期的隔离目录
+)
+
+// LegacyQuarantineDirName 扫描根目录下的隔离目录名，扫描时跳过
+const LegacyQuarantineDirName = ".pdf-merger-quarantine"
+
+// legacySignatureReadLimit 检查内容签名时读取的字节数
+const legacySignatureReadLimit = 256
+
+// 历史文件名模式：
+//   - 合并器中间文件 name_temp_20060102_150405[_hex8].pdf
+//   - 写入器临时文件 name_temp_<UnixNano>[_hex8].pdf
+var (
+	mergerTempNamePattern = regexp.MustCompile(`^.+_temp_(\d{8}_\d{6})(_[0-9a-f]{8})?\.pdf$`)
+	writerTempNamePattern = regexp.MustCompile(`^.+_temp_(\d{18,19})(_[0-9a-f]{8})?\.pdf$`)
+)
+
+// 本工具写出的文本占位文件的内容签名
+var (
+	fallbackSignature     = []byte("Fallback merge result\nFiles: [")
+	placeholderSignatures = [][]byte{
+		[]byte("Placeholder merge result for files: ["),
+		[]byte("Placeholder decrypt result\nInput: "),
+		[]byte("Placeholder optimize result\nInput: "),
+	}
+)
+
+// LegacyArtifact 扫描到的一个遗留文件
+type LegacyArtifact struct {
+	Path       string             `json:"path"`
+	Kind       LegacyArtifactKind `json:"kind"`
+	Confidence ArtifactConfidence `json:"confidence"`
+	Size       int64              `json:"size"`
+	ModTime    time.Time          `json:"mod_time"`
+}
+
+// ClassifyLegacyArtifact 判断文件是否为历史遗留文件。
+// 先按文件名匹配历史模式，只有名称匹配的文件才会读取内容检查签名，
+// 因此普通的 .pdf 文件不会被读取或归类。
+func ClassifyLegacyArtifact(path string) (*LegacyArtifact, bool, error) {
+	kind, ok := legacyKindByName(filepath.Base(path))
+	if !ok {
+		return nil, false, nil
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, false, nil
+	}
+
+	artifact := &LegacyArtifact{
+		Path:       path,
+		Kind:       kind,
+		Confidence: ConfidenceNameOnly,
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+	}
+
+	head, err := readHead(path, legacySignatureReadLimit)
+	if err != nil {
+		return nil, false, err
+	}
+	if hasLegacySignature(kind, head) {
+		artifact.Confidence = ConfidenceSignature
+	}
+	return artifact, true, nil
+}
+
+// legacyKindByName 按文件名判断可能的类别
+func legacyKindByName(name string) (LegacyArtifactKind, bool) {
+	switch {
+	case strings.HasSuffix(name, ".fallback") && len(name) > len(".fallback"):
+		return ArtifactFallback, true
+	case strings.HasSuffix(name, ".placeholder") && len(name) > len(".placeholder"):
+		return ArtifactPlaceholder, true
+	case isLegacyTempName(name):
+		return ArtifactTempChunk, true
+	}
+	return "", false
+}
+
+// isLegacyTempName 文件名是否符合临时分块的命名模式，且嵌入的时间戳有效
+func isLegacyTempName(name string) bool {
+	if m := mergerTempNamePattern.FindStringSubmatch(name); m != nil {
+		_, err := time.ParseInLocation("20060102_150405", m[1], time.Local)
+		return err == nil
+	}
+	return writerTempNamePattern.MatchString(name)
+}
+
+// hasLegacySignature 内容是否与该类别的写出格式一致
+func hasLegacySignature(kind LegacyArtifactKind, head []byte) bool {
+	switch kind {
+	case ArtifactFallback:
+		return bytes.HasPrefix(head, fallbackSignature)
+	case ArtifactPlaceholder:
+		for _, sig := range placeholderSignatures {
+			if bytes.HasPrefix(head, sig) {
+				return true
+			}
+		}
+	case ArtifactTempChunk:
+		// 中间文件在回退路径下也可能是文本占位内容
+		if bytes.HasPrefix(head, fallbackSignature) {
+			return true
+		}
+		for _, sig := range placeholderSignatures {
+			if bytes.HasPrefix(head, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readHead 读取文件开头最多 n 个字节
+func readHead(path string, n int) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// ScanLegacyArtifacts 递归扫描目录中的遗留文件，不跟随符号链接，跳过隔离目录
+func ScanLegacyArtifacts(dirs []string) ([]LegacyArtifact, error) {
+	var artifacts []LegacyArtifact
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == LegacyQuarantineDirName {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			artifact, ok, err := ClassifyLegacyArtifact(path)
+			if err != nil {
+				return err
+			}
+			if ok {
+				artifacts = append(artifacts, *artifact)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, &PDFError{Type: ErrorIO, Message: "扫描遗留文件失败", File: dir, Cause: err}
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts, nil
+}
+
+// LegacyCleanupOptions 遗留文件清理选项
+type LegacyCleanupOptions struct {
+	Mode LegacyCleanupMode
+
+	// IncludeNameOnly 同时隔离只有文件名匹配的文件。这类文件从不直接删除，
+	// 删除模式下也只会移入隔离目录。
+	IncludeNameOnly bool
+
+	// Log 每个动作写一行日志，nil时不记录
+	Log io.Writer
+
+	// Clock 用于隔离目录的日期和日志时间，nil时使用系统时钟
+	Clock clock.Clock
+}
+
+// LegacyCleanupAction 对一个遗留文件执行的动作
+type LegacyCleanupAction struct {
+	Artifact LegacyArtifact `json:"artifact"`
+	Action   string         `json:"action"` // delete、quarantine、skip 或 report
+	Target   string         `json:"target,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// LegacyCleanupReport 清理结果
+type LegacyCleanupReport struct {
+	Mode    LegacyCleanupMode     `json:"mode"`
+	Actions []LegacyCleanupAction `json:"actions"`
+}
+
+// CleanupLegacyArtifacts 扫描并按模式处理遗留文件。
+// 只有内容签名确认的文件会被删除；只有文件名匹配的文件默认跳过，
+// 开启 IncludeNameOnly 时移入隔离目录。单个文件处理失败不会中止其余文件。
+func CleanupLegacyArtifacts(dirs []string, options LegacyCleanupOptions) (*LegacyCleanupReport, error) {
+	if options.Mode == "" {
+		options.Mode = CleanupDryRun
+	}
+	switch options.Mode {
+	case CleanupDryRun, CleanupDelete, CleanupQuarantine:
+	default:
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: fmt.Sprintf("未知的清理模式: %s", options.Mode)}
+	}
+	clk := clock.OrSystem(options.Clock)
+
+	report := &LegacyCleanupReport{Mode: options.Mode}
+	for _, dir := range dirs {
+		artifacts, err := ScanLegacyArtifacts([]string{dir})
+		if err != nil {
+			return report, err
+		}
+		quarantineDir := filepath.Join(dir, LegacyQuarantineDirName, clk.Now().Format("20060102"))
+
+		for _, artifact := range artifacts {
+			action := planLegacyAction(artifact, options)
+			switch action.Action {
+			case "delete":
+				if err := os.Remove(artifact.Path); err != nil {
+					action.Error = err.Error()
+				}
+			case "quarantine":
+				target, err := quarantineArtifact(dir, quarantineDir, artifact.Path)
+				action.Target = target
+				if err != nil {
+					action.Error = err.Error()
+				}
+			}
+			logLegacyAction(options.Log, clk, action)
+			report.Actions = append(report.Actions, action)
+		}
+	}
+	return report, nil
+}
+
+// planLegacyAction 决定对文件执行的动作
+func planLegacyAction(artifact LegacyArtifact, options LegacyCleanupOptions) LegacyCleanupAction {
+	action := LegacyCleanupAction{Artifact: artifact}
+	verified := artifact.Confidence == ConfidenceSignature
+
+	switch {
+	case options.Mode == CleanupDryRun:
+		action.Action = "report"
+	case !verified && !options.IncludeNameOnly:
+		action.Action = "skip"
+	case !verified || options.Mode == CleanupQuarantine:
+		action.Action = "quarantine"
+	default:
+		action.Action = "delete"
+	}
+	return action
+}
+
+// quarantineArtifact 把文件移入隔离目录，保留相对于扫描根目录的路径
+func quarantineArtifact(root, quarantineDir, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	target := filepath.Join(quarantineDir, rel)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return target, err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return target, fmt.Errorf("隔离目录中已存在同名文件")
+	}
+	return target, os.Rename(path, target)
+}
+
+// logLegacyAction 记录一行动作日志
+func logLegacyAction(w io.Writer, clk clock.Clock, action LegacyCleanupAction) {
+	if w == nil {
+		return
+	}
+	line := fmt.Sprintf("%s %s %s [%s/%s]", clk.Now().Format(time.RFC3339), action.Action,
+		action.Artifact.Path, action.Artifact.Kind, action.Artifact.Confidence)
+	if action.Target != "" {
+		line += " -> " + action.Target
+	}
+	if action.Error != "" {
+		line += " 失败: " + action.Error
+	}
+	fmt.Fprintln(w, line)
+}
+
+// WriteLegacyReport 以文本形式输出清理报告
+func WriteLegacyReport(w io.Writer, report *LegacyCleanupReport) {
+	if len(report.Actions) == 0 {
+		fmt.Fprintln(w, "未发现遗留文件")
+		return
+	}
+
+	var verified, nameOnly int
+	for _, action := range report.Actions {
+		if action.Artifact.Confidence == ConfidenceSignature {
+			verified++
+		} else {
+			nameOnly++
+		}
+		fmt.Fprintf(w, "%-10s %-11s %-9s %8d  %s\n", action.Action, action.Artifact.Kind,
+			action.Artifact.Confidence, action.Artifact.Size, action.Artifact.Path)
+		if action.Error != "" {
+			fmt.Fprintf(w, "           失败: %s\n", action.Error)
+		}
+	}
+	fmt.Fprintf(w, "共 %d 个遗留文件：内容确认 %d 个，仅名称匹配 %d 个\n", verified+nameOnly, verified, nameOnly)
+}
@@ -0,0 +1,170 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// legacyFixture 用当前代码生成真实的遗留文件，并放入容易误判的用户文件
+type legacyFixture struct {
+	dir       string
+	genuine   map[string]ArtifactConfidence // 应识别的文件及其可信度
+	untouched []string                      // 任何模式下都不应改动的用户文件
+}
+
+func newLegacyFixture(t *testing.T) *legacyFixture {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "2023")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	f := &legacyFixture{dir: dir, genuine: make(map[string]ArtifactConfidence)}
+
+	// 旧版本回退合并写出的 .fallback（现在的回退合并直接生成PDF）
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	a := createTestPDFFile(t, dir, "a.pdf")
+	b := createTestPDFFile(t, dir, "b.pdf")
+	fallback := createTestFile(t, dir, "merged.pdf.fallback",
+		[]byte(fmt.Sprintf("Fallback merge result\nFiles: %v\nOutput: %s\n", []string{a, b}, filepath.Join(dir, "merged.pdf"))))
+	f.genuine[fallback] = ConfidenceSignature
+
+	// pdfcpu不可用时写出的 .placeholder，合并的占位文件来自旧版本
+	adapter := &PDFCPUAdapter{logger: NopLogger(), clock: clock.System()}
+	createTestFile(t, sub, "m.pdf.placeholder",
+		[]byte(fmt.Sprintf("Placeholder merge result for files: %v\nOutput: %s\n", []string{a}, filepath.Join(sub, "m.pdf"))))
+	require.NoError(t, adapter.createPlaceholderDecrypt(a, filepath.Join(sub, "d.pdf"), "secret"))
+	require.NoError(t, adapter.createPlaceholderOptimize(a, filepath.Join(sub, "o.pdf")))
+	for _, name := range []string{"m.pdf", "d.pdf", "o.pdf"} {
+		f.genuine[filepath.Join(sub, name+".placeholder")] = ConfidenceSignature
+	}
+
+	// 中断的合并遗留的中间文件，内容是真实PDF，只能按名称识别
+	chunk := merger.generateTempPath(filepath.Join(dir, "report.pdf"))
+	require.NoError(t, os.WriteFile(chunk, []byte("%PDF-1.4\n%%EOF"), 0644))
+	f.genuine[chunk] = ConfidenceNameOnly
+	writerTemp := generateTempPath(filepath.Join(dir, "out.pdf"), sub, clock.System())
+	require.NoError(t, os.WriteFile(writerTemp, []byte("%PDF-1.4\n%%EOF"), 0644))
+	f.genuine[writerTemp] = ConfidenceNameOnly
+	// 旧版本的中间文件没有随机后缀
+	oldChunk := filepath.Join(dir, "report_temp_20230105_101112.pdf")
+	require.NoError(t, os.WriteFile(oldChunk, []byte("%PDF-1.4\n%%EOF"), 0644))
+	f.genuine[oldChunk] = ConfidenceNameOnly
+
+	// 用户自己的 .fallback 文件，名称匹配但内容不符
+	userFallback := createTestFile(t, dir, "notes.pdf.fallback", []byte("my backup copy"))
+	f.genuine[userFallback] = ConfidenceNameOnly
+
+	// 近似但不匹配的用户文件
+	f.untouched = append(f.untouched,
+		a, b,
+		createTestFile(t, dir, "invoice_temp_2023.pdf", []byte("%PDF-1.4")),
+		createTestFile(t, dir, "scan_temp_20231301_999999.pdf", []byte("%PDF-1.4")),
+		createTestFile(t, dir, "scan_temp_2023010_101112.pdf", []byte("%PDF-1.4")),
+		createTestFile(t, dir, "report_temp_20230105_101112.PDF.bak", []byte("%PDF-1.4")),
+		createTestFile(t, sub, "copied.pdf", []byte("Fallback merge result\nFiles: [x.pdf]\n")),
+		createTestFile(t, sub, ".fallback", []byte("Fallback merge result\nFiles: [x.pdf]\n")),
+	)
+	return f
+}
+
+func (f *legacyFixture) assertUntouched(t *testing.T) {
+	for _, path := range f.untouched {
+		assert.True(t, fileExists(path), "用户文件不应被改动: %s", path)
+	}
+}
+
+func TestScanLegacyArtifacts_ClassifiesGenuineAndNearMiss(t *testing.T) {
+	f := newLegacyFixture(t)
+
+	artifacts, err := ScanLegacyArtifacts([]string{f.dir})
+	require.NoError(t, err)
+
+	found := make(map[string]ArtifactConfidence)
+	for _, artifact := range artifacts {
+		found[artifact.Path] = artifact.Confidence
+	}
+	assert.Equal(t, f.genuine, found)
+	f.assertUntouched(t)
+}
+
+func TestCleanupLegacyArtifacts_DryRunChangesNothing(t *testing.T) {
+	f := newLegacyFixture(t)
+	var logBuf bytes.Buffer
+
+	report, err := CleanupLegacyArtifacts([]string{f.dir}, LegacyCleanupOptions{Log: &logBuf})
+	require.NoError(t, err)
+	assert.Equal(t, CleanupDryRun, report.Mode)
+	assert.Len(t, report.Actions, len(f.genuine))
+	for _, action := range report.Actions {
+		assert.Equal(t, "report", action.Action)
+		assert.True(t, fileExists(action.Artifact.Path))
+	}
+	assert.Equal(t, len(f.genuine), bytes.Count(logBuf.Bytes(), []byte("\n")), "每个文件都应记录日志")
+	f.assertUntouched(t)
+}
+
+func TestCleanupLegacyArtifacts_DeleteOnlyVerified(t *testing.T) {
+	f := newLegacyFixture(t)
+
+	_, err := CleanupLegacyArtifacts([]string{f.dir}, LegacyCleanupOptions{Mode: CleanupDelete})
+	require.NoError(t, err)
+
+	for path, confidence := range f.genuine {
+		if confidence == ConfidenceSignature {
+			assert.False(t, fileExists(path), "内容确认的遗留文件应删除: %s", path)
+		} else {
+			assert.True(t, fileExists(path), "仅名称匹配的文件默认不处理: %s", path)
+		}
+	}
+	f.assertUntouched(t)
+}
+
+func TestCleanupLegacyArtifacts_QuarantineIntoDatedFolder(t *testing.T) {
+	f := newLegacyFixture(t)
+	fake := clock.NewFake(time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC), 1)
+	var logBuf bytes.Buffer
+
+	// 删除模式下，仅名称匹配的文件也只会隔离，不会删除
+	report, err := CleanupLegacyArtifacts([]string{f.dir}, LegacyCleanupOptions{
+		Mode:            CleanupDelete,
+		IncludeNameOnly: true,
+		Log:             &logBuf,
+		Clock:           fake,
+	})
+	require.NoError(t, err)
+
+	quarantine := filepath.Join(f.dir, LegacyQuarantineDirName, "20240304")
+	for _, action := range report.Actions {
+		path := action.Artifact.Path
+		assert.Empty(t, action.Error)
+		assert.False(t, fileExists(path))
+		if action.Artifact.Confidence == ConfidenceNameOnly {
+			require.Equal(t, "quarantine", action.Action, path)
+			rel, err := filepath.Rel(f.dir, path)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(quarantine, rel), action.Target)
+			assert.True(t, fileExists(action.Target), "隔离的文件应保留相对路径")
+		} else {
+			assert.Equal(t, "delete", action.Action, path)
+		}
+	}
+	assert.Contains(t, logBuf.String(), "2024-03-04T05:06:07Z quarantine ")
+	f.assertUntouched(t)
+
+	// 再次扫描时跳过隔离目录
+	artifacts, err := ScanLegacyArtifacts([]string{f.dir})
+	require.NoError(t, err)
+	assert.Empty(t, artifacts)
+}
+
+func TestCleanupLegacyArtifacts_UnknownMode(t *testing.T) {
+	_, err := CleanupLegacyArtifacts([]string{t.TempDir()}, LegacyCleanupOptions{Mode: "purge"})
+	assert.Error(t, err)
+}
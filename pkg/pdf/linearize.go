@@ -0,0 +1,696 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// linearizationHeaderWindow 线性化参数字典必须位于文件开头的1024字节内
+const linearizationHeaderWindow = 1024
+
+var (
+	linearizedPattern    = regexp.MustCompile(`/Linearized\s+[\d.]+`)
+	linLengthPattern     = regexp.MustCompile(`/L\s+(\d+)`)
+	linPagesPattern      = regexp.MustCompile(`/N\s+(\d+)`)
+	linObjectPattern     = regexp.MustCompile(`/O\s+(\d+)`)
+	linEndPattern        = regexp.MustCompile(`/E\s+(\d+)`)
+	linXRefPattern       = regexp.MustCompile(`/T\s+(\d+)`)
+	linHintPattern       = regexp.MustCompile(`/H\s*\[\s*(\d+)\s+(\d+)`)
+	indirectRefPattern   = regexp.MustCompile(`(\d+)\s+(\d+)\s+R\b`)
+	infoRefPattern       = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+	encryptRefPattern    = regexp.MustCompile(`/Encrypt\s+(\d+)\s+\d+\s+R`)
+	encryptVersion       = regexp.MustCompile(`/V\s+(\d+)`)
+	idArrayPattern       = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	streamLengthPattern  = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	objectStreamPattern  = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pagesNodePattern     = regexp.MustCompile(`/Type\s*/Pages\b`)
+	headerVersionPattern = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+)
+
+// LinearizationInfo 线性化参数字典中的信息
+type LinearizationInfo struct {
+	FileLength      int64 // /L 文件总长度
+	PageCount       int   // /N 页数
+	FirstPageObject int   // /O 首页页面对象编号
+	FirstPageEnd    int64 // /E 首页部分的结束偏移
+	MainXRefEntry   int64 // /T 主交叉引用表第一个条目之前空白字符的偏移
+	HintOffset      int64 // /H 主提示流偏移
+	HintLength      int64 // /H 主提示流长度
+}
+
+// IsLinearized 检查文件是否为有效的线性化PDF（快速Web视图）
+func IsLinearized(filePath string) (bool, error) {
+	info, err := ReadLinearizationInfo(filePath)
+	return info != nil, err
+}
+
+// ReadLinearizationInfo 读取线性化参数字典，文件未线性化时返回nil。
+// 线性化后又追加了增量更新的文件（/L 与文件长度不一致）视为未线性化。
+func ReadLinearizationInfo(filePath string) (*LinearizationInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法打开PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法获取文件信息",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	head := make([]byte, linearizationHeaderWindow)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return parseLinearizationDict(head[:n], stat.Size()), nil
+}
+
+// parseLinearizationDict 解析文件中的第一个对象，它必须是线性化参数字典
+func parseLinearizationDict(head []byte, fileSize int64) *LinearizationInfo {
+	loc := objHeaderPattern.FindIndex(head)
+	if loc == nil {
+		return nil
+	}
+	end := bytes.Index(head[loc[1]:], []byte("endobj"))
+	if end < 0 {
+		return nil
+	}
+	dict := head[loc[1] : loc[1]+end]
+	if !linearizedPattern.Match(dict) {
+		return nil
+	}
+
+	values := make([]int64, 0, 5)
+	for _, pattern := range []*regexp.Regexp{linLengthPattern, linPagesPattern, linObjectPattern, linEndPattern, linXRefPattern} {
+		m := pattern.FindSubmatch(dict)
+		if m == nil {
+			return nil
+		}
+		v, err := strconv.ParseInt(string(m[1]), 10, 64)
+		if err != nil {
+			return nil
+		}
+		values = append(values, v)
+	}
+	hint := linHintPattern.FindSubmatch(dict)
+	if hint == nil {
+		return nil
+	}
+	hintOffset, _ := strconv.ParseInt(string(hint[1]), 10, 64)
+	hintLength, _ := strconv.ParseInt(string(hint[2]), 10, 64)
+
+	info := &LinearizationInfo{
+		FileLength:      values[0],
+		PageCount:       int(values[1]),
+		FirstPageObject: int(values[2]),
+		FirstPageEnd:    values[3],
+		MainXRefEntry:   values[4],
+		HintOffset:      hintOffset,
+		HintLength:      hintLength,
+	}
+	if info.FileLength != fileSize {
+		return nil
+	}
+	return info
+}
+
+// LinearizeFile 将PDF重写为线性化结构（快速Web视图）：
+// 线性化参数字典、首页交叉引用表、目录、首页对象、主提示流，然后是其余页面与对象和主交叉引用表。
+// 输入与输出可以是同一路径。不支持对象流、交叉引用流和使用与对象编号相关密钥的加密文件。
+func LinearizeFile(inputPath, outputPath string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+
+	linearized, err := linearize(inputPath, data)
+	if err != nil {
+		return err
+	}
+
+	tempPath := outputPath + ".linearize.tmp"
+	if err := os.WriteFile(tempPath, linearized, 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入线性化输出",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// linearObject 重写时使用的对象：流之前的部分与原始流数据
+type linearObject struct {
+	dict   []byte
+	stream []byte // nil表示非流对象
+}
+
+// linearizer 保存一次线性化所需的状态
+type linearizer struct {
+	data     []byte
+	offsets  map[int]int
+	objects  map[int]*linearObject
+	assigned map[int]bool
+	pages    map[int]bool
+	newNum   map[int]int
+}
+
+// linearize 返回线性化后的文件内容
+func linearize(filePath string, data []byte) ([]byte, error) {
+	unsupported := func(message string) error {
+		return &PDFError{Type: ErrorProcessing, Message: message, File: filePath}
+	}
+
+	if !bytes.Contains(data, []byte("trailer")) {
+		return nil, unsupported("无法线性化：不支持使用交叉引用流的文件")
+	}
+	if objectStreamPattern.Match(data) {
+		return nil, unsupported("无法线性化：不支持包含对象流的文件")
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.Pages) == 0 {
+		return nil, unsupported("无法线性化：文件没有页面")
+	}
+
+	l := &linearizer{
+		data:     data,
+		offsets:  indexObjects(data),
+		objects:  make(map[int]*linearObject),
+		assigned: make(map[int]bool),
+		pages:    make(map[int]bool, len(stats.Pages)),
+		newNum:   make(map[int]int),
+	}
+	for _, page := range stats.Pages {
+		l.pages[page] = true
+	}
+
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	rootNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	catalog, ok := l.load(rootNum)
+	if !ok {
+		return nil, &PDFError{Type: ErrorCorrupted, Message: "目录对象不存在", File: filePath}
+	}
+
+	encryptNum := refNumber(encryptRefPattern, data)
+	if encryptNum > 0 {
+		// V5（AES-256）的密钥与对象编号无关，其他版本重新编号后将无法解密
+		encrypt, ok := l.load(encryptNum)
+		version := 0
+		if ok {
+			if m := encryptVersion.FindSubmatch(encrypt.dict); m != nil {
+				version, _ = strconv.Atoi(string(m[1]))
+			}
+		}
+		if version < 5 {
+			return nil, &PDFError{
+				Type:    ErrorEncrypted,
+				Message: "无法线性化：加密密钥与对象编号相关（仅支持AES-256加密）",
+				File:    filePath,
+			}
+		}
+	}
+	infoNum := refNumber(infoRefPattern, data)
+
+	// 首页部分：目录与首页独占的对象
+	l.assigned[rootNum] = true
+	firstPage := append([]int{rootNum}, l.collect(stats.Pages[0], true)...)
+
+	// 其余页面各自的对象，随后是页面树、文档信息等剩余对象
+	var mainOrder []int
+	pageGroups := make([][]int, len(stats.Pages))
+	pageGroups[0] = firstPage
+	for i, page := range stats.Pages[1:] {
+		pageGroups[i+1] = l.collect(page, true)
+		mainOrder = append(mainOrder, pageGroups[i+1]...)
+	}
+	for _, ref := range refsIn(catalog.dict) {
+		mainOrder = append(mainOrder, l.collect(ref, false)...)
+	}
+	for _, ref := range []int{infoNum, encryptNum} {
+		if ref > 0 {
+			mainOrder = append(mainOrder, l.collect(ref, false)...)
+		}
+	}
+
+	// 主部分从1开始编号，首页部分紧随其后，保证首页交叉引用表是连续的一段
+	for i, num := range mainOrder {
+		l.newNum[num] = i + 1
+	}
+	linNum := len(mainOrder) + 1
+	for i, num := range firstPage {
+		l.newNum[num] = linNum + 1 + i
+	}
+	hintNum := linNum + 1 + len(firstPage)
+	size := hintNum + 1
+
+	serialized := make(map[int]int64)
+	serialize := func(nums []int) [][]byte {
+		out := make([][]byte, len(nums))
+		for i, num := range nums {
+			out[i] = l.serialize(num)
+			serialized[num] = int64(len(out[i]))
+		}
+		return out
+	}
+	firstBytes := serialize(firstPage)
+	mainBytes := serialize(mainOrder)
+
+	version := "1.4"
+	if m := headerVersionPattern.FindSubmatch(data); m != nil {
+		version = string(m[1])
+	}
+	header := fmt.Sprintf("%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+
+	trailerExtras := ""
+	if infoNum > 0 {
+		if n, ok := l.newNum[infoNum]; ok {
+			trailerExtras += fmt.Sprintf(" /Info %d 0 R", n)
+		}
+	}
+	if encryptNum > 0 {
+		trailerExtras += fmt.Sprintf(" /Encrypt %d 0 R", l.newNum[encryptNum])
+	}
+	if ids := idArrayPattern.FindAll(data, -1); len(ids) > 0 {
+		trailerExtras += " " + string(ids[len(ids)-1])
+	}
+
+	firstXRef := func(offsets []int64, mainXRef int64) string {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "xref\n%d %d\n", linNum, len(offsets))
+		for _, off := range offsets {
+			fmt.Fprintf(&b, "%010d 00000 n \n", off)
+		}
+		fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R%s /Prev %010d >>\nstartxref\n0\n%%%%EOF\n",
+			size, l.newNum[rootNum], trailerExtras, mainXRef)
+		return b.String()
+	}
+	linDict := func(fileLength, hintOffset, hintLength, firstEnd, mainEntry int64) string {
+		return fmt.Sprintf("%d 0 obj\n<< /Linearized 1 /L %010d /H [ %010d %010d ] /O %d /E %010d /N %d /T %010d >>\nendobj\n",
+			linNum, fileLength, hintOffset, hintLength, l.newNum[stats.Pages[0]], firstEnd, len(stats.Pages), mainEntry)
+	}
+
+	// 各部分使用定宽数字，先按占位值计算布局
+	firstSectionCount := len(firstPage) + 2 // 参数字典 + 首页对象 + 提示流
+	pos := int64(len(header) + len(linDict(0, 0, 0, 0, 0)) + len(firstXRef(make([]int64, firstSectionCount), 0)))
+
+	firstOffsets := make([]int64, len(firstBytes))
+	for i, b := range firstBytes {
+		firstOffsets[i] = pos
+		pos += int64(len(b))
+	}
+	firstEnd := pos
+
+	groupLengths := make([]int64, len(pageGroups))
+	groupCounts := make([]int, len(pageGroups))
+	for i, group := range pageGroups {
+		groupCounts[i] = len(group)
+		for _, num := range group {
+			groupLengths[i] += serialized[num]
+		}
+	}
+	// 首页的提示信息不计目录
+	groupCounts[0]--
+	groupLengths[0] -= int64(len(firstBytes[0]))
+	firstPageObjectLengths := make([]int64, 0, len(firstBytes)-1)
+	for _, b := range firstBytes[1:] {
+		firstPageObjectLengths = append(firstPageObjectLengths, int64(len(b)))
+	}
+
+	hint := hintStreamObject(hintNum, encryptNum > 0, firstOffsets[1], groupCounts, groupLengths, firstPageObjectLengths)
+	hintOffset := pos
+	pos += int64(len(hint))
+
+	mainOffsets := make([]int64, len(mainBytes))
+	for i, b := range mainBytes {
+		mainOffsets[i] = pos
+		pos += int64(len(b))
+	}
+
+	mainXRef := pos
+	var tail bytes.Buffer
+	subsection := fmt.Sprintf("xref\n0 %d\n", len(mainOrder)+1)
+	tail.WriteString(subsection)
+	tail.WriteString("0000000000 65535 f \n")
+	for _, off := range mainOffsets {
+		fmt.Fprintf(&tail, "%010d 00000 n \n", off)
+	}
+	firstXRefOffset := int64(len(header) + len(linDict(0, 0, 0, 0, 0)))
+	fmt.Fprintf(&tail, "trailer\n<< /Size %d >>\nstartxref\n%d\n%%%%EOF\n", size, firstXRefOffset)
+	fileLength := pos + int64(tail.Len())
+	mainEntry := mainXRef + int64(len(subsection)) - 1
+
+	var out bytes.Buffer
+	out.Grow(int(fileLength))
+	out.WriteString(header)
+	out.WriteString(linDict(fileLength, hintOffset, int64(len(hint)), firstEnd, mainEntry))
+	xrefOffsets := append([]int64{int64(len(header))}, firstOffsets...)
+	xrefOffsets = append(xrefOffsets, hintOffset)
+	out.WriteString(firstXRef(xrefOffsets, mainXRef))
+	for _, b := range firstBytes {
+		out.Write(b)
+	}
+	out.Write(hint)
+	for _, b := range mainBytes {
+		out.Write(b)
+	}
+	out.Write(tail.Bytes())
+	return out.Bytes(), nil
+}
+
+// collect 从start开始按广度优先收集尚未分配的对象。
+// forPage为true时不跟随指向其他页面和页面树节点的引用，只收集该页独占的对象。
+func (l *linearizer) collect(start int, forPage bool) []int {
+	var order []int
+	queue := []int{start}
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		if l.assigned[num] {
+			continue
+		}
+		obj, ok := l.load(num)
+		if !ok {
+			continue
+		}
+		l.assigned[num] = true
+		order = append(order, num)
+
+		for _, ref := range refsIn(obj.dict) {
+			if l.assigned[ref] {
+				continue
+			}
+			if forPage && (l.pages[ref] || l.isPagesNode(ref)) {
+				continue
+			}
+			queue = append(queue, ref)
+		}
+	}
+	return order
+}
+
+// isPagesNode 判断对象是否为页面树的中间节点
+func (l *linearizer) isPagesNode(num int) bool {
+	obj, ok := l.load(num)
+	return ok && pagesNodePattern.Match(obj.dict)
+}
+
+// load 读取对象，流对象按 /Length 截取原始数据
+func (l *linearizer) load(num int) (*linearObject, bool) {
+	if obj, ok := l.objects[num]; ok {
+		return obj, true
+	}
+	start, ok := l.offsets[num]
+	if !ok {
+		return nil, false
+	}
+	obj := splitObject(l.data[start:], func(lengthNum int) ([]byte, bool) {
+		return objectBody(l.data, l.offsets, lengthNum)
+	})
+	l.objects[num] = obj
+	return obj, true
+}
+
+// splitObject 把对象内容（obj之后的数据）拆分为流之前的部分和原始流数据。
+// lookup 用于读取间接引用的 /Length 对象。
+func splitObject(rest []byte, lookup func(num int) ([]byte, bool)) *linearObject {
+	endobj := bytes.Index(rest, []byte("endobj"))
+	streamIdx := findStreamKeyword(rest)
+
+	obj := &linearObject{}
+	if streamIdx < 0 || (endobj >= 0 && endobj < streamIdx) {
+		if endobj < 0 {
+			endobj = len(rest)
+		}
+		obj.dict = rest[:endobj]
+		return obj
+	}
+	obj.dict = rest[:streamIdx]
+	dataStart := streamIdx + len("stream")
+	if dataStart < len(rest) && rest[dataStart] == '\r' {
+		dataStart++
+	}
+	if dataStart < len(rest) && rest[dataStart] == '\n' {
+		dataStart++
+	}
+	obj.stream = streamData(rest, obj.dict, dataStart, lookup)
+	return obj
+}
+
+// streamData 返回流数据：优先使用 /Length，与 endstream 位置不符时退回按 endstream 截取
+func streamData(rest, dict []byte, dataStart int, lookup func(num int) ([]byte, bool)) []byte {
+	if m := streamLengthPattern.FindSubmatch(dict); m != nil {
+		length, _ := strconv.Atoi(string(m[1]))
+		if len(m[2]) > 0 {
+			// 间接引用时 m[1] 是长度对象的编号
+			lengthNum := length
+			length = -1
+			if lengthObj, ok := lookup(lengthNum); ok {
+				if v, err := strconv.Atoi(string(bytes.TrimSpace(lengthObj))); err == nil {
+					length = v
+				}
+			}
+		}
+		if length >= 0 && dataStart+length <= len(rest) {
+			after := bytes.TrimLeft(rest[dataStart+length:], "\r\n ")
+			if bytes.HasPrefix(after, []byte("endstream")) {
+				return rest[dataStart : dataStart+length]
+			}
+		}
+	}
+
+	end := bytes.Index(rest[dataStart:], []byte("endstream"))
+	if end < 0 {
+		return rest[dataStart:]
+	}
+	raw := rest[dataStart : dataStart+end]
+	if bytes.HasSuffix(raw, []byte("\r\n")) {
+		return raw[:len(raw)-2]
+	}
+	if bytes.HasSuffix(raw, []byte("\n")) || bytes.HasSuffix(raw, []byte("\r")) {
+		return raw[:len(raw)-1]
+	}
+	return raw
+}
+
+// serialize 按新编号输出对象，引用改写为新编号，不存在的对象改为null
+func (l *linearizer) serialize(num int) []byte {
+	obj, _ := l.load(num)
+	dict := indirectRefPattern.ReplaceAllFunc(obj.dict, func(ref []byte) []byte {
+		m := indirectRefPattern.FindSubmatch(ref)
+		old, _ := strconv.Atoi(string(m[1]))
+		if n, ok := l.newNum[old]; ok {
+			return []byte(fmt.Sprintf("%d 0 R", n))
+		}
+		return []byte("null")
+	})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d 0 obj\n", l.newNum[num])
+	b.Write(bytes.TrimSpace(dict))
+	if obj.stream != nil {
+		b.WriteString("\nstream\n")
+		b.Write(obj.stream)
+		b.WriteString("\nendstream")
+	}
+	b.WriteString("\nendobj\n")
+	return b.Bytes()
+}
+
+// findStreamKeyword 查找紧跟在字典之后的 stream 关键字
+func findStreamKeyword(data []byte) int {
+	for from := 0; ; {
+		idx := bytes.Index(data[from:], []byte("stream"))
+		if idx < 0 {
+			return -1
+		}
+		pos := from + idx
+		from = pos + len("stream")
+
+		before := bytes.TrimRight(data[:pos], " \t\r\n\f")
+		if !bytes.HasSuffix(before, []byte(">>")) {
+			continue
+		}
+		if from < len(data) && (data[from] == '\r' || data[from] == '\n') {
+			return pos
+		}
+	}
+}
+
+// refsIn 返回内容中所有间接引用的对象编号
+func refsIn(dict []byte) []int {
+	matches := indirectRefPattern.FindAllSubmatch(dict, -1)
+	refs := make([]int, 0, len(matches))
+	for _, m := range matches {
+		if num, err := strconv.Atoi(string(m[1])); err == nil {
+			refs = append(refs, num)
+		}
+	}
+	return refs
+}
+
+// refNumber 返回模式最后一次匹配到的引用编号（增量更新时以最后的trailer为准），没有时返回0
+func refNumber(pattern *regexp.Regexp, data []byte) int {
+	matches := pattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	num, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+	return num
+}
+
+// hintStreamObject 生成主提示流：页面偏移提示表与共享对象提示表。
+// 首页以外不拆分共享对象，共享对象表只列出首页部分的对象。
+// 加密文件中提示流使用Identity加密过滤器保持明文。
+func hintStreamObject(num int, encrypted bool, firstPageOffset int64, counts []int, lengths []int64, firstPageObjects []int64) []byte {
+	minCount, maxCount := counts[0], counts[0]
+	minLength, maxLength := lengths[0], lengths[0]
+	for i := range counts {
+		minCount = min(minCount, counts[i])
+		maxCount = max(maxCount, counts[i])
+		minLength = min(minLength, lengths[i])
+		maxLength = max(maxLength, lengths[i])
+	}
+	countBits := bitsFor(uint64(maxCount - minCount))
+	lengthBits := bitsFor(uint64(maxLength - minLength))
+
+	w := &bitWriter{}
+	// 页面偏移提示表头
+	w.write(uint64(minCount), 32)
+	w.write(uint64(firstPageOffset), 32)
+	w.write(uint64(countBits), 16)
+	w.write(uint64(minLength), 32)
+	w.write(uint64(lengthBits), 16)
+	w.write(0, 32) // 内容流相对页面起点的最小偏移
+	w.write(0, 16)
+	w.write(uint64(minLength), 32) // 内容流长度按页面长度记录
+	w.write(uint64(lengthBits), 16)
+	w.write(0, 16) // 共享对象引用数的位数
+	w.write(0, 16)
+	w.write(0, 16)
+	w.write(0, 16)
+	// 每页条目：每一项对所有页面连续写出，项之间按字节对齐
+	for _, c := range counts {
+		w.write(uint64(c-minCount), countBits)
+	}
+	w.flush()
+	for _, length := range lengths {
+		w.write(uint64(length-minLength), lengthBits)
+	}
+	w.flush()
+	for _, length := range lengths {
+		w.write(uint64(length-minLength), lengthBits)
+	}
+	w.flush()
+
+	sharedOffset := w.buf.Len()
+	var minObj, maxObj int64
+	for i, length := range firstPageObjects {
+		if i == 0 || length < minObj {
+			minObj = length
+		}
+		maxObj = max(maxObj, length)
+	}
+	objBits := bitsFor(uint64(maxObj - minObj))
+	// 共享对象提示表头
+	w.write(0, 32) // 共享对象部分第一个对象的编号（无）
+	w.write(0, 32)
+	w.write(uint64(len(firstPageObjects)), 32)
+	w.write(uint64(len(firstPageObjects)), 32)
+	w.write(0, 16)
+	w.write(uint64(minObj), 32)
+	w.write(uint64(objBits), 16)
+	for _, length := range firstPageObjects {
+		w.write(uint64(length-minObj), objBits)
+	}
+	w.flush()
+	for range firstPageObjects {
+		w.write(0, 1) // 无MD5签名
+	}
+	w.flush()
+
+	filter := ""
+	if encrypted {
+		filter = " /Filter /Crypt /DecodeParms << /Name /Identity >>"
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d 0 obj\n<< /S %d /Length %d%s >>\nstream\n", num, sharedOffset, w.buf.Len(), filter)
+	b.Write(w.buf.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+	return b.Bytes()
+}
+
+// bitsFor 返回表示value所需的位数
+func bitsFor(value uint64) int {
+	bits := 0
+	for value > 0 {
+		bits++
+		value >>= 1
+	}
+	return bits
+}
+
+// bitWriter 按高位在前写入位序列
+type bitWriter struct {
+	buf   bytes.Buffer
+	cur   byte
+	count uint
+}
+
+// write 写入value的低bits位
+func (w *bitWriter) write(value uint64, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte(value>>uint(i)&1)
+		w.count++
+		if w.count == 8 {
+			w.buf.WriteByte(w.cur)
+			w.cur, w.count = 0, 0
+		}
+	}
+}
+
+// flush 补齐到字节边界
+func (w *bitWriter) flush() {
+	if w.count > 0 {
+		w.buf.WriteByte(w.cur << (8 - w.count))
+		w.cur, w.count = 0, 0
+	}
+}
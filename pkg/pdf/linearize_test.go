@@ -0,0 +1,198 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// linearizeContent 首页内容流，线性化前后应逐字节保留
+const linearizeContent = "BT /F1 12 Tf 72 720 Td (first page) Tj ET"
+
+// buildLinearizeFixture 三页文档：首页带内容流，第二、三页共享字体，带文档信息
+func buildLinearizeFixture() []byte {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 6 0 R /Resources << /Font << /F1 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 8 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [9 0 R] /Resources << /Font << /F1 8 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(linearizeContent), linearizeContent),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Times-Roman >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest [3 0 R /Fit] >>",
+		"<< /Title (linearize fixture) >>",
+	})
+	return bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Info 10 0 R"), 1)
+}
+
+func TestLinearizeFile_Structure(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildLinearizeFixture())
+	output := filepath.Join(dir, "linearized.pdf")
+
+	if err := LinearizeFile(input, output); err != nil {
+		t.Fatalf("线性化失败: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+
+	info, err := ReadLinearizationInfo(output)
+	if err != nil || info == nil {
+		t.Fatalf("未检测到线性化参数字典: %v", err)
+	}
+	if info.FileLength != int64(len(data)) || info.PageCount != 3 {
+		t.Errorf("参数字典不正确: %+v", info)
+	}
+
+	// 参数字典是文件中的第一个对象
+	first := objHeaderPattern.FindIndex(data)
+	if !bytes.Contains(data[first[0]:first[0]+200], []byte("/Linearized 1")) {
+		t.Error("第一个对象应为线性化参数字典")
+	}
+
+	// 首页页面对象与页面树一致，且位于首页部分内
+	stats, err := WalkPageTree(output, data, nil)
+	if err != nil {
+		t.Fatalf("遍历线性化输出失败: %v", err)
+	}
+	if len(stats.Pages) != 3 || stats.Pages[0] != info.FirstPageObject {
+		t.Errorf("首页对象应为 %d，页面树为 %v", info.FirstPageObject, stats.Pages)
+	}
+	offsets := indexObjects(data)
+	if int64(offsets[info.FirstPageObject]) > info.FirstPageEnd {
+		t.Error("首页页面对象应位于首页部分内")
+	}
+	if int64(offsets[stats.Pages[2]]) < info.HintOffset+info.HintLength {
+		t.Error("其余页面应位于提示流之后")
+	}
+
+	// 提示流
+	hint := data[info.HintOffset : info.HintOffset+info.HintLength]
+	if !objHeaderPattern.Match(hint[:16]) || !bytes.Contains(hint, []byte("/S ")) || !bytes.HasSuffix(hint, []byte("endobj\n")) {
+		t.Errorf("/H 未指向提示流对象: %q", hint[:min(len(hint), 60)])
+	}
+
+	// 首页交叉引用表通过 /Prev 指向主交叉引用表，/T 指向主表第一个条目之前的换行
+	if data[info.MainXRefEntry] != '\n' || !bytes.HasPrefix(data[info.MainXRefEntry+1:], []byte("0000000000 65535 f")) {
+		t.Errorf("/T 位置不正确: %q", data[info.MainXRefEntry:info.MainXRefEntry+20])
+	}
+	matches := startxrefPattern.FindAllSubmatch(data, -1)
+	last, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+	if !bytes.HasPrefix(data[last:], []byte("xref")) {
+		t.Error("最后的 startxref 应指向首页交叉引用表")
+	}
+	if !bytes.Contains(data, []byte("/Info ")) || !bytes.Contains(data, []byte("(linearize fixture)")) {
+		t.Error("文档信息应保留")
+	}
+
+	// 内容流原样保留
+	if !bytes.Contains(data, []byte("stream\n"+linearizeContent+"\nendstream")) {
+		t.Error("内容流应逐字节保留")
+	}
+}
+
+func TestIsLinearized_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildLinearizeFixture())
+
+	if linearized, err := IsLinearized(input); err != nil || linearized {
+		t.Fatalf("普通文件不应被识别为线性化: %v, %v", linearized, err)
+	}
+
+	if err := LinearizeFile(input, input); err != nil {
+		t.Fatalf("原地线性化失败: %v", err)
+	}
+	if linearized, _ := IsLinearized(input); !linearized {
+		t.Fatal("线性化后应被识别")
+	}
+
+	// 再次线性化仍然有效
+	if err := LinearizeFile(input, input); err != nil {
+		t.Fatalf("重复线性化失败: %v", err)
+	}
+	if linearized, _ := IsLinearized(input); !linearized {
+		t.Fatal("重复线性化后应被识别")
+	}
+
+	// 追加增量更新后线性化失效
+	reviewPath, err := WriteReviewCopy(input, []PageWarning{{Page: 1, Kind: WarningGeneral, Message: "check"}})
+	if err != nil {
+		t.Fatalf("生成审阅副本失败: %v", err)
+	}
+	if linearized, _ := IsLinearized(reviewPath); linearized {
+		t.Error("增量更新后的文件不应被识别为线性化")
+	}
+}
+
+func TestLinearizeFile_RejectsObjectNumberKeyedEncryption(t *testing.T) {
+	dir := t.TempDir()
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Filter /Standard /V 2 /R 3 /Length 128 >>",
+	})
+	data = bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 4 0 R"), 1)
+	input := createTestFile(t, dir, "rc4.pdf", data)
+
+	err := LinearizeFile(input, filepath.Join(dir, "out.pdf"))
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorEncrypted {
+		t.Errorf("期望加密错误，实际: %v", err)
+	}
+}
+
+func TestMergeOptions_LinearizeRejectsIncrementalUpdate(t *testing.T) {
+	options := &MergeOptions{Linearize: true, ReviewCopy: true}
+	err := options.Validate()
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorInvalidInput {
+		t.Fatalf("期望选项校验错误，实际: %v", err)
+	}
+
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildLinearizeFixture())
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, Linearize: true})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{input}, filepath.Join(dir, "out.pdf"), &MergeOptions{ReviewCopy: true})
+	if err == nil || result != nil {
+		t.Errorf("冲突的选项应在合并前被拒绝，实际: %v", err)
+	}
+	if fileExists(filepath.Join(dir, "out.pdf")) {
+		t.Error("被拒绝的合并不应产生输出")
+	}
+}
+
+func TestStreamingMerger_LinearizesOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildLinearizeFixture())
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, Linearize: true})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{input}, output, nil)
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if !result.Linearized {
+		t.Error("MergeResult应记录输出已线性化")
+	}
+	if linearized, _ := IsLinearized(output); !linearized {
+		t.Error("输出应已线性化")
+	}
+
+	// 未要求线性化时保持原样
+	plain := NewStreamingMerger(&MergeOptions{TempDirectory: dir})
+	plain.adapter = nil
+	result, err = plain.MergeFiles([]string{input}, filepath.Join(dir, "plain.pdf"), nil)
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if result.Linearized {
+		t.Error("未要求线性化时不应标记为线性化")
+	}
+}
@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota // 调试信息：每个操作的细节
+	LogInfo                  // 一般信息：后端选择、优化模式等
+	LogWarn                  // 警告：不影响结果的失败，如临时文件删除失败
+	LogError                 // 错误
+)
+
+// String 返回级别名称
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// Logger 分级日志接口。MergeOptions、ServiceConfig、WriterOptions 和 PDFCPUConfig
+// 都可以指定Logger，为nil时使用 SetDefaultLogger 设置的默认日志。实现必须可以并发调用。
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
+// LoggerFunc 把函数适配为Logger，每条日志以级别和格式化后的消息调用函数。
+// 可用于把日志转发到界面或在测试中捕获日志。
+type LoggerFunc func(level LogLevel, message string)
+
+// Debug 记录调试级别日志
+func (f LoggerFunc) Debug(format string, args ...interface{}) {
+	f(LogDebug, fmt.Sprintf(format, args...))
+}
+
+// Info 记录信息级别日志
+func (f LoggerFunc) Info(format string, args ...interface{}) {
+	f(LogInfo, fmt.Sprintf(format, args...))
+}
+
+// Warn 记录警告级别日志
+func (f LoggerFunc) Warn(format string, args ...interface{}) {
+	f(LogWarn, fmt.Sprintf(format, args...))
+}
+
+// Error 记录错误级别日志
+func (f LoggerFunc) Error(format string, args ...interface{}) {
+	f(LogError, fmt.Sprintf(format, args...))
+}
+
+// NopLogger 返回丢弃所有日志的Logger
+func NopLogger() Logger {
+	return LoggerFunc(func(LogLevel, string) {})
+}
+
+// NewWriterLogger 返回把不低于 minLevel 的日志逐行写入 w 的Logger，
+// 每行格式为“时间 级别 消息”
+func NewWriterLogger(w io.Writer, minLevel LogLevel) Logger {
+	return NewWriterLoggerWithClock(w, minLevel, nil)
+}
+
+// NewWriterLoggerWithClock 与 NewWriterLogger 相同，但每行的时间取自 c，nil表示系统时钟
+func NewWriterLoggerWithClock(w io.Writer, minLevel LogLevel, c clock.Clock) Logger {
+	c = clock.OrSystem(c)
+	var mu sync.Mutex
+	return LoggerFunc(func(level LogLevel, message string) {
+		if level < minLevel {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "%s %-5s %s\n", c.Now().Format("15:04:05"), level, message)
+	})
+}
+
+var (
+	defaultLoggerMu sync.RWMutex
+	defaultLogger   = NewWriterLogger(os.Stderr, LogWarn)
+)
+
+// SetDefaultLogger 设置没有指定Logger的组件使用的默认日志，nil表示丢弃所有日志。
+// 默认只把警告和错误写到标准错误，不会干扰标准输出上的进度和JSON结果。
+func SetDefaultLogger(logger Logger) {
+	if logger == nil {
+		logger = NopLogger()
+	}
+	defaultLoggerMu.Lock()
+	defer defaultLoggerMu.Unlock()
+	defaultLogger = logger
+}
+
+// DefaultLogger 返回当前的默认日志
+func DefaultLogger() Logger {
+	defaultLoggerMu.RLock()
+	defer defaultLoggerMu.RUnlock()
+	return defaultLogger
+}
+
+// loggerOrDefault 返回logger，为nil时返回默认日志
+func loggerOrDefault(logger Logger) Logger {
+	if logger != nil {
+		return logger
+	}
+	return DefaultLogger()
+}
+
+// printfLogger 把Logger适配为只有Printf的SimpleLogger，消息按调试级别记录；
+// logger为nil时使用记录时的默认日志
+type printfLogger struct {
+	logger Logger
+}
+
+func (p printfLogger) Printf(format string, v ...interface{}) {
+	loggerOrDefault(p.logger).Debug(format, v...)
+}
@@ -0,0 +1,91 @@
+package pdf
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedLog 一条被捕获的日志
+type capturedLog struct {
+	level   LogLevel
+	message string
+}
+
+// captureLogger 返回把日志记录到切片的Logger
+func captureLogger() (Logger, func() []capturedLog) {
+	var mu sync.Mutex
+	var entries []capturedLog
+	logger := LoggerFunc(func(level LogLevel, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, capturedLog{level, message})
+	})
+	return logger, func() []capturedLog {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedLog(nil), entries...)
+	}
+}
+
+// messagesAt 返回指定级别的日志消息
+func messagesAt(entries []capturedLog, level LogLevel) []string {
+	var messages []string
+	for _, entry := range entries {
+		if entry.level == level {
+			messages = append(messages, entry.message)
+		}
+	}
+	return messages
+}
+
+func TestNewWriterLogger_FiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWriterLogger(&buf, LogWarn)
+
+	logger.Debug("debug %d", 1)
+	logger.Info("info")
+	logger.Warn("disk %s", "full")
+	logger.Error("failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "WARN  disk full")
+	assert.Contains(t, lines[1], "ERROR failed")
+}
+
+func TestSetDefaultLogger(t *testing.T) {
+	original := DefaultLogger()
+	t.Cleanup(func() { SetDefaultLogger(original) })
+
+	logger, entries := captureLogger()
+	SetDefaultLogger(logger)
+	loggerOrDefault(nil).Info("hello")
+	assert.Equal(t, []capturedLog{{LogInfo, "hello"}}, entries())
+
+	// nil 表示丢弃日志而不是恢复标准错误输出
+	SetDefaultLogger(nil)
+	assert.NotPanics(t, func() { DefaultLogger().Warn("dropped") })
+	assert.Len(t, entries(), 1)
+}
+
+func TestStreamingMerger_LogsThroughOptionsLogger(t *testing.T) {
+	logger, entries := captureLogger()
+	dir := t.TempDir()
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, Logger: logger})
+	defer merger.Close()
+	merger.cleanupTempFiles([]string{filepath.Join(dir, "missing.tmp")})
+
+	warnings := messagesAt(entries(), LogWarn)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "missing.tmp")
+
+	// 合并器创建的pdfcpu适配器使用同一个日志
+	assert.NotEmpty(t, messagesAt(entries(), LogDebug))
+}
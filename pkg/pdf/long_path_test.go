@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// longPathDir 在临时目录下创建总长度超过260个字符的多级目录，返回未加前缀的路径
+func longPathDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for len(dir) <= 300 {
+		dir = filepath.Join(dir, strings.Repeat("长", 10)+strings.Repeat("d", 20))
+	}
+	if err := os.MkdirAll(model.NormalizePath(dir), 0755); err != nil {
+		t.Fatalf("创建长路径目录失败: %v", err)
+	}
+	return dir
+}
+
+func TestLongPaths_ValidateAndMerge(t *testing.T) {
+	dir := longPathDir(t)
+	first := filepath.Join(dir, "first.pdf")
+	second := filepath.Join(dir, "second.pdf")
+	for i, path := range []string{first, second} {
+		if err := os.WriteFile(model.NormalizePath(path), buildFlatPDF(i+1), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(first) <= 260 {
+		t.Fatalf("测试路径应超过260个字符，实际 %d", len(first))
+	}
+
+	service := NewPDFServiceWithConfig(DefaultServiceConfig())
+	if err := service.ValidatePDF(first); err != nil {
+		t.Errorf("长路径文件应通过验证: %v", err)
+	}
+
+	output := filepath.Join(dir, "merged.pdf")
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir()})
+	defer merger.Close()
+	if _, err := merger.MergeFiles([]string{first, second}, output, nil); err != nil {
+		t.Fatalf("合并长路径输入失败: %v", err)
+	}
+	if pages, err := ReadPageCount(model.NormalizePath(output), nil); err != nil || pages != 3 {
+		t.Errorf("期望输出3页，实际 %d（%v）", pages, err)
+	}
+}
+
+func TestLongPaths_WriterNormalizesOutputPath(t *testing.T) {
+	output := filepath.Join(longPathDir(t), "out.pdf")
+	writer, err := NewPDFWriter(output, nil)
+	if err != nil {
+		t.Fatalf("创建长路径输出的写入器失败: %v", err)
+	}
+	defer writer.Close()
+
+	got := writer.GetOutputPath()
+	if runtime.GOOS == "windows" {
+		if !model.HasLongPathPrefix(got) {
+			t.Errorf("Windows上的长输出路径应带有长路径前缀，实际 %q", got)
+		}
+	} else if got != output {
+		t.Errorf("期望输出路径 %q，实际 %q", output, got)
+	}
+}
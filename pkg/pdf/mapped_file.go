@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// errMmapUnsupported 当前平台或文件大小不支持内存映射
+var errMmapUnsupported = errors.New("不支持内存映射")
+
+// mappedFile 只读打开的PDF文件。大于阈值的文件在支持的平台上映射到内存，
+// 只有实际访问的页面会被读入，不占用堆内存；其他情况通过 *os.File 的 ReadAt 按需读取。
+// 映射期间文件被其他进程截断时，访问截断部分会使进程收到SIGBUS，调用方只应映射不会被改写的输入。
+// 读取可以并发，但Close不能与读取并发，持有者负责在读取结束后再关闭
+type mappedFile struct {
+	file *os.File
+	data []byte // 映射的文件内容，未映射时为nil
+	size int64
+
+	closeOnce sync.Once
+	closeErr  error
+	closed    bool
+}
+
+var _ io.ReaderAt = (*mappedFile)(nil)
+
+// openMappedFile 打开文件，大小超过threshold时尝试映射到内存；threshold为负数时不映射，
+// 映射失败时回退到直接读取文件
+func openMappedFile(path string, threshold int64) (*mappedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	f := &mappedFile{file: file, size: stat.Size()}
+	if threshold >= 0 && f.size > threshold {
+		if data, err := mmapFile(file, f.size); err == nil {
+			f.data = data
+		}
+	}
+	return f, nil
+}
+
+// Size 返回打开时的文件大小
+func (f *mappedFile) Size() int64 {
+	return f.size
+}
+
+// Mapped 文件内容是否已映射到内存
+func (f *mappedFile) Mapped() bool {
+	return f.data != nil
+}
+
+// ReadAt 实现io.ReaderAt，已映射时从映射复制，否则从文件读取；关闭后返回ErrClosed
+func (f *mappedFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.data == nil {
+		return f.file.ReadAt(p, off)
+	}
+	if off < 0 {
+		return 0, errors.New("负的读取偏移")
+	}
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Bytes 返回整个文件内容。已映射时直接返回映射，不复制，Close之后不能再访问；
+// 未映射时把文件读入内存
+func (f *mappedFile) Bytes() ([]byte, error) {
+	if f.closed {
+		return nil, ErrClosed
+	}
+	if f.data != nil {
+		return f.data, nil
+	}
+	data := make([]byte, f.size)
+	if _, err := f.file.ReadAt(data, 0); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Close 解除映射并关闭文件，重复调用返回首次关闭的结果。解除映射失败时仍会关闭文件
+func (f *mappedFile) Close() error {
+	f.closeOnce.Do(func() {
+		f.closed = true
+		if f.data != nil {
+			f.closeErr = munmapFile(f.data)
+			f.data = nil
+		}
+		if err := f.file.Close(); f.closeErr == nil {
+			f.closeErr = err
+		}
+	})
+	return f.closeErr
+}
+
+// readFileData 读取整个文件供解析：大于threshold的文件映射到内存而不是读入堆。
+// 返回的data只能在release之前使用
+func readFileData(path string, threshold int64) (data []byte, release func() error, err error) {
+	f, err := openMappedFile(path, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data, err = f.Bytes(); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return data, f.Close, nil
+}
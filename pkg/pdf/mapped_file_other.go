@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package pdf
+
+import "os"
+
+// mmapFile 当前平台不映射文件，openMappedFile回退到直接读取
+func mmapFile(file *os.File, size int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+// munmapFile 当前平台不会建立映射
+func munmapFile(data []byte) error {
+	return nil
+}
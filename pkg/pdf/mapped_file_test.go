@@ -0,0 +1,241 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMappedFile_ReadAt(t *testing.T) {
+	data := buildFlatPDF(2)
+	path := createTestFile(t, t.TempDir(), "a.pdf", data)
+
+	for name, threshold := range map[string]int64{"mapped": 0, "disabled": -1, "below-threshold": int64(len(data))} {
+		t.Run(name, func(t *testing.T) {
+			f, err := openMappedFile(path, threshold)
+			require.NoError(t, err)
+			defer f.Close()
+
+			assert.Equal(t, int64(len(data)), f.Size())
+			if threshold < 0 || int64(len(data)) <= threshold {
+				assert.False(t, f.Mapped(), "不超过阈值或禁用时不应映射")
+			}
+
+			head := make([]byte, 8)
+			n, err := f.ReadAt(head, 0)
+			require.NoError(t, err)
+			assert.Equal(t, "%PDF-1.4", string(head[:n]))
+
+			tail := make([]byte, 16)
+			n, err = f.ReadAt(tail, f.Size()-6)
+			assert.ErrorIs(t, err, io.EOF, "读到文件末尾时应返回io.EOF")
+			assert.Equal(t, "%%EOF\n", string(tail[:n]))
+
+			_, err = f.ReadAt(head, f.Size())
+			assert.ErrorIs(t, err, io.EOF)
+
+			all, err := f.Bytes()
+			require.NoError(t, err)
+			assert.Equal(t, data, all)
+		})
+	}
+}
+
+func TestMappedFile_Close(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "a.pdf", buildFlatPDF(1))
+
+	f, err := openMappedFile(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.NoError(t, f.Close(), "重复关闭应返回首次关闭的结果")
+	assert.False(t, f.Mapped(), "关闭后应已解除映射")
+
+	_, err = f.ReadAt(make([]byte, 4), 0)
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = f.Bytes()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestPDFReader_MemoryMappedLargeFile(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "large.pdf", buildFlatPDF(3))
+	config := DefaultStreamingConfig()
+	config.LargeFileThreshold = 0
+
+	reader, err := NewPDFReaderWithConfig(path, config)
+	require.NoError(t, err)
+	require.NotNil(t, reader.source)
+	mapped := reader.source
+
+	count, err := reader.readPageCount()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	require.NoError(t, reader.Close())
+	assert.Nil(t, reader.source)
+	assert.False(t, mapped.Mapped(), "Close应解除映射")
+	assert.NoError(t, reader.Close())
+
+	config.DisableMemoryMap = true
+	reader, err = NewPDFReaderWithConfig(path, config)
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.False(t, reader.source.Mapped(), "禁用映射时应直接读取文件")
+}
+
+func TestPDFValidator_MemoryMappedLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultStreamingConfig()
+	config.LargeFileThreshold = 0
+	validator := NewPDFValidatorWithConfig(config)
+
+	valid := createTestFile(t, dir, "valid.pdf", buildFlatPDF(2))
+	assert.NoError(t, validator.validateBasic(valid))
+	issues, err := validator.strictIssuesInFile(valid)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	// 映射后的检查结果与直接读取相同
+	for check, data := range strictFixtures() {
+		path := createTestFile(t, dir, check+".pdf", data)
+		issues, err := validator.strictIssuesInFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{check}, strictChecks(issues), check)
+	}
+
+	truncated := createTestFile(t, dir, "truncated.pdf", bytes.TrimSuffix(buildFlatPDF(2), []byte("%%EOF\n")))
+	var pdfErr *PDFError
+	require.True(t, errors.As(validator.validateBasic(truncated), &pdfErr))
+	assert.Equal(t, ErrorCorrupted, pdfErr.Type)
+}
+
+// writeLargePDFFixture 生成一页的PDF，内容流为size字节的填充数据，逐块写入文件而不在内存中构造
+func writeLargePDFFixture(path string, size int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriterSize(file, 1<<20)
+
+	var offset int64
+	write := func(s string) {
+		n, _ := w.WriteString(s)
+		offset += int64(n)
+	}
+	write("%PDF-1.7\n")
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+	}
+	offsets := make([]int64, 0, 4)
+	for i, obj := range objects {
+		offsets = append(offsets, offset)
+		write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, obj))
+	}
+
+	offsets = append(offsets, offset)
+	write(fmt.Sprintf("4 0 obj\n<< /Length %d >>\nstream\n", size))
+	chunk := []byte(strings.Repeat("0123456789abcdef", 1<<16))
+	for remaining := size; remaining > 0; {
+		n := min(remaining, int64(len(chunk)))
+		if _, err := w.Write(chunk[:n]); err != nil {
+			return err
+		}
+		offset += n
+		remaining -= n
+	}
+	write("\nendstream\nendobj\n")
+
+	xref := offset
+	write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1))
+	for _, o := range offsets {
+		write(fmt.Sprintf("%010d 00000 n \n", o))
+	}
+	write(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref))
+	return w.Flush()
+}
+
+// resetPeakRSS 重置进程的峰值常驻内存（Linux的 /proc/self/clear_refs），不支持时忽略
+func resetPeakRSS() {
+	os.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+}
+
+// peakRSS 返回进程的峰值常驻内存（字节），无法读取时返回0
+func peakRSS() int64 {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "VmHWM:" {
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// BenchmarkValidateLargeFile 对比映射和直接读取时验证大文件的耗时与峰值常驻内存。
+// 默认生成1GB的文件，可用 PDF_BENCH_FIXTURE_MB 调整，例如：
+//
+//	PDF_BENCH_FIXTURE_MB=256 go test ./pkg/pdf -run '^$' -bench ValidateLargeFile -benchtime 1x
+func BenchmarkValidateLargeFile(b *testing.B) {
+	sizeMB := int64(1024)
+	if value := os.Getenv("PDF_BENCH_FIXTURE_MB"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			b.Fatalf("PDF_BENCH_FIXTURE_MB 必须是正整数: %s", value)
+		}
+		sizeMB = parsed
+	}
+	path := filepath.Join(b.TempDir(), "large.pdf")
+	if err := writeLargePDFFixture(path, sizeMB<<20); err != nil {
+		b.Fatalf("生成测试文件失败: %v", err)
+	}
+
+	for _, disable := range []bool{true, false} {
+		name := "mmap"
+		if disable {
+			name = "read"
+		}
+		b.Run(name, func(b *testing.B) {
+			config := DefaultStreamingConfig()
+			config.DisableMemoryMap = disable
+			validator := NewPDFValidatorWithConfig(config)
+
+			b.ReportAllocs()
+			// 归还上一个子测试留下的堆内存，峰值只反映本子测试
+			debug.FreeOSMemory()
+			resetPeakRSS()
+			for i := 0; i < b.N; i++ {
+				if err := validator.validateBasic(path); err != nil {
+					b.Fatalf("基本验证失败: %v", err)
+				}
+				if _, err := validator.strictIssuesInFile(path); err != nil {
+					b.Fatalf("严格检查失败: %v", err)
+				}
+				reader, err := NewPDFReaderWithConfig(path, config)
+				if err != nil {
+					b.Fatalf("打开读取器失败: %v", err)
+				}
+				if count, err := reader.readPageCount(); err != nil || count != 1 {
+					b.Fatalf("页数应为1，实际 %d（%v）", count, err)
+				}
+				reader.Close()
+			}
+			b.ReportMetric(float64(peakRSS())/(1<<20), "peak-RSS-MB")
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package pdf
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile 把整个文件只读映射到内存
+func mmapFile(file *os.File, size int64) ([]byte, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, errMmapUnsupported
+	}
+	return syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// munmapFile 解除mmapFile建立的映射
+func munmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
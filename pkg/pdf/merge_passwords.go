@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"regexp"
+)
+
+// encryptTailWindow 无法解析trailer时查找加密字典读取的文件尾部字节数，覆盖最后的trailer或交叉引用流字典
+const encryptTailWindow = 64 * 1024
+
+// encryptEntryPattern trailer或交叉引用流字典中的加密字典条目
+var encryptEntryPattern = regexp.MustCompile(`/Encrypt\s*(?:\d+\s+\d+\s+R|<<)`)
+
+// hasEncryptEntry 检查trailer是否引用了加密字典：从startxref开始沿 /Prev 解析各交叉引用段
+// （包括交叉引用流和增量更新）的trailer，只有其中确实存在 /Encrypt 引用时才判定为加密。
+// 不会把带 /Filter 的普通文件或正文中提到 /Encrypt 的文件误判为加密。
+// 无法定位trailer（缺少startxref或偏移不准确）时退回到在文件尾部查找 /Encrypt 引用。
+func hasEncryptEntry(filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if dicts, err := readTrailerDicts(f, info.Size()); err == nil {
+		for _, dict := range dicts {
+			if encryptEntryPattern.Match(dict) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	offset := max(info.Size()-encryptTailWindow, 0)
+	return encryptEntryPattern.Match(readWindow(f, offset, info.Size(), encryptTailWindow)), nil
+}
+
+// isEncryptedInput 判断输入是否加密；无法读取的文件视为未加密，交给后续验证报告
+func (sm *StreamingMerger) isEncryptedInput(filePath string) bool {
+	if sm.adapter != nil && sm.adapter.useCLI && sm.adapter.cliAdapter != nil {
+		if encrypted, err := sm.adapter.cliAdapter.IsEncrypted(filePath); err == nil {
+			return encrypted
+		}
+	}
+	encrypted, err := hasEncryptEntry(filePath)
+	return err == nil && encrypted
+}
+
+// inputPassword 返回加密输入的打开密码：优先使用MergeOptions.Passwords，没有时按内容哈希查询保险库，
+// fromVault 表示密码来自保险库
+func (sm *StreamingMerger) inputPassword(filePath string) (password string, fromVault, ok bool) {
+	if password, ok := sm.passwords[filePath]; ok {
+		return password, false, true
+	}
+	if sm.vault == nil {
+		return "", false, false
+	}
+
+	sm.vaultMutex.Lock()
+	defer sm.vaultMutex.Unlock()
+	if password, ok := sm.vaultPasswords[filePath]; ok {
+		return password, true, true
+	}
+	password, ok = LookupVaultPassword(sm.vault, filePath)
+	if !ok {
+		return "", false, false
+	}
+	if sm.vaultPasswords == nil {
+		sm.vaultPasswords = make(map[string]string)
+	}
+	sm.vaultPasswords[filePath] = password
+	return password, true, true
+}
+
+// requirePassword 检查加密输入是否提供了密码（包括保险库中保存的密码），未提供时返回指明文件的ErrorEncrypted
+func (sm *StreamingMerger) requirePassword(filePath string) error {
+	if _, _, ok := sm.inputPassword(filePath); ok {
+		return nil
+	}
+	return &PDFError{
+		Type:    ErrorEncrypted,
+		Message: "文件已加密，需要提供密码",
+		File:    filePath,
+	}
+}
+
+// decryptInput 用MergeOptions.Passwords或保险库中的密码把加密输入解密到outputPath，
+// 密码缺失或错误时返回指明文件的ErrorEncrypted。指定的密码解密成功后保存到保险库，
+// 保险库中的密码失效时删除该条目
+func (sm *StreamingMerger) decryptInput(filePath, outputPath string) error {
+	password, fromVault, ok := sm.inputPassword(filePath)
+	if !ok {
+		return sm.requirePassword(filePath)
+	}
+
+	err := sm.files().DecryptFile(filePath, outputPath, password)
+	if err == nil && !fileExists(outputPath) {
+		err = errors.New("解密后没有生成文件")
+	}
+	if err == nil && sm.isEncryptedInput(outputPath) {
+		err = errors.New("解密后的文件仍然加密")
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		if fromVault {
+			ForgetPassword(sm.vault, filePath)
+		}
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法解密文件，密码错误或解密失败",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	if !fromVault {
+		RememberPassword(sm.vault, filePath, password)
+	}
+	return nil
+}
+
+// decryptInputs 为加密输入生成解密后的临时副本并验证，返回替换后的输入列表。
+// 返回的清理函数删除这些副本。
+func (sm *StreamingMerger) decryptInputs(files []string) ([]string, func(), error) {
+	var temps []string
+	cleanup := func() { sm.cleanupTempFiles(temps) }
+
+	prepared := make([]string, len(files))
+	for i, file := range files {
+		prepared[i] = file
+		if !sm.isEncryptedInput(file) {
+			continue
+		}
+		tempPath := sm.generateTempPath(file)
+		if err := sm.decryptInput(file, tempPath); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		temps = append(temps, tempPath)
+		if err := sm.validateInputFile(tempPath); err != nil {
+			cleanup()
+			return nil, func() {}, &PDFError{
+				Type:    ErrorCorrupted,
+				Message: "解密后的文件无效",
+				File:    file,
+				Cause:   err,
+			}
+		}
+		prepared[i] = tempPath
+	}
+	return prepared, cleanup, nil
+}
+
+// isEncryptionError 判断错误是否为需要密码的加密错误
+func isEncryptionError(err error) bool {
+	var pdfErr *PDFError
+	return errors.As(err, &pdfErr) && pdfErr.Type == ErrorEncrypted
+}
@@ -0,0 +1,352 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildEncryptedPDF 生成trailer中引用加密字典的测试文件
+func buildEncryptedPDF(pages int) []byte {
+	return bytes.Replace(buildFlatPDF(pages), []byte("/Root 1 0 R >>"), []byte("/Root 1 0 R /Encrypt 99 0 R >>"), 1)
+}
+
+// fakeFileBackend 测试用的文件后端，没有设置的操作返回错误，修复使用内置修复
+type fakeFileBackend struct {
+	decrypt func(inputFile, outputFile, password string) error
+	encrypt func(inputFile, outputFile string, encryption *outputEncryption) error
+}
+
+func (b *fakeFileBackend) DecryptFile(inputFile, outputFile, password string) error {
+	if b.decrypt == nil {
+		return errors.New("没有可用的解密后端")
+	}
+	return b.decrypt(inputFile, outputFile, password)
+}
+
+func (b *fakeFileBackend) EncryptFile(inputFile, outputFile string, encryption *outputEncryption) error {
+	if b.encrypt == nil {
+		return errors.New("没有可用的加密后端")
+	}
+	return b.encrypt(inputFile, outputFile, encryption)
+}
+
+func (b *fakeFileBackend) RepairFile(inputFile, outputFile string) (*RepairReport, error) {
+	return RepairPDF(inputFile, outputFile)
+}
+
+// fakeDecrypt 返回假的解密后端：密码为secret时写出pages页的未加密文件
+func fakeDecrypt(pages int) *fakeFileBackend {
+	return &fakeFileBackend{decrypt: func(inputFile, outputFile, password string) error {
+		if password != "secret" {
+			return errors.New("wrong password")
+		}
+		return os.WriteFile(outputFile, buildFlatPDF(pages), 0644)
+	}}
+}
+
+func TestHasEncryptEntry(t *testing.T) {
+	dir := t.TempDir()
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	filtered := createTestFile(t, dir, "filtered.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"<< /Length 0 /Filter /FlateDecode >>\nstream\n\nendstream",
+	}))
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(1))
+
+	for path, want := range map[string]bool{plain: false, filtered: false, encrypted: true} {
+		got, err := hasEncryptEntry(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, filepath.Base(path))
+	}
+}
+
+// appendIncrementalUpdate 在文件末尾追加一个只含trailer的增量更新，trailer通过 /Prev 指向原文件的交叉引用，
+// padding 字节的注释把原trailer推出文件尾部的扫描窗口
+func appendIncrementalUpdate(data []byte, padding int) []byte {
+	matches := startxrefPattern.FindAllSubmatch(data, -1)
+	prev := string(matches[len(matches)-1][1])
+
+	var buf bytes.Buffer
+	buf.Write(data)
+	fmt.Fprintf(&buf, "%%%s\n", strings.Repeat("x", padding))
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 1\n0000000000 65535 f \ntrailer\n<< /Size 4 /Root 1 0 R /Prev %s >>\nstartxref\n%d\n%%%%EOF\n", prev, xrefOffset)
+	return buf.Bytes()
+}
+
+func TestHasEncryptEntry_ParsesTrailers(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"encryption keywords in content", []byte(createPDFWithEncryptKeywords("1.4")), false},
+		{"encrypt reference outside trailer", buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+			"<< /Length 31 >>\nstream\nBT (/Encrypt 5 0 R /V 4) Tj ET\nendstream",
+		}), false},
+		{"xref stream without encrypt", buildXRefStreamPDF(xrefStreamFixture{compress: true}), false},
+		{"xref stream with encrypt", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /W [1 2 1] /Encrypt 9 0 R"}), true},
+		{"encrypt in earlier trailer", appendIncrementalUpdate(buildEncryptedPDF(1), encryptTailWindow), true},
+		{"incremental update without encrypt", appendIncrementalUpdate(buildFlatPDF(1), 16), false},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, fmt.Sprintf("case%d.pdf", i), tt.data)
+			got, err := hasEncryptEntry(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckEncryptionByContent_IgnoresKeywords(t *testing.T) {
+	dir := t.TempDir()
+	service := &PDFServiceImpl{}
+
+	keywords := createTestFile(t, dir, "keywords.pdf", []byte(createPDFWithEncryptKeywords("1.4")))
+	encrypted, err := service.checkEncryptionByContent(keywords)
+	require.NoError(t, err)
+	assert.False(t, encrypted, "正文中的 /Encrypt、/Filter 等关键字不代表文件已加密")
+
+	locked := createTestFile(t, dir, "locked.pdf", []byte(createMetadataEncryptedPDF("1.4")))
+	encrypted, err = service.checkEncryptionByContent(locked)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+}
+
+func TestMergeStreaming_EncryptedInputWithoutPassword(t *testing.T) {
+	dir := t.TempDir()
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeStreaming(context.Background(), []string{plain, encrypted}, output, nil)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorEncrypted, pdfErr.Type)
+	assert.Equal(t, encrypted, pdfErr.File, "错误应指明需要密码的文件")
+	require.NotNil(t, result)
+	assert.Equal(t, MergeStageValidation, result.FailedStage)
+	assert.NotContains(t, result.SkippedFiles, encrypted, "加密文件不应被静默跳过")
+	assert.False(t, fileExists(output))
+}
+
+func TestMergeStreaming_DecryptsWithPassword(t *testing.T) {
+	dir := t.TempDir()
+	tempDir := t.TempDir()
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(3))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: tempDir,
+		BackendStats:  NewBackendStatsStore(),
+		Passwords:     map[string]string{encrypted: "secret"},
+	})
+	merger.adapter = nil
+	merger.fileBackend = fakeDecrypt(3)
+	result, err := merger.MergeStreaming(context.Background(), []string{encrypted}, output, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{encrypted}, result.ValidatedFiles, "结果中应显示原始输入")
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/Encrypt", "应合并解密后的副本")
+
+	leftovers, err := filepath.Glob(filepath.Join(tempDir, "*_temp_*.pdf"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "解密副本应被清理")
+}
+
+func TestMergeStreaming_WrongPassword(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(1))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Passwords:     map[string]string{encrypted: "guess"},
+	})
+	merger.adapter = nil
+	merger.fileBackend = fakeDecrypt(1)
+	_, err := merger.MergeStreaming(context.Background(), []string{encrypted}, output, nil)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorEncrypted, pdfErr.Type)
+	assert.Equal(t, encrypted, pdfErr.File)
+	assert.ErrorContains(t, err, "wrong password")
+	assert.False(t, fileExists(output))
+}
+
+func TestMergeFilesWithPageRanges_EncryptedInput(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(4))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Passwords:     map[string]string{encrypted: "secret"},
+	})
+	merger.adapter = nil
+	merger.fileBackend = fakeDecrypt(4)
+	result, err := merger.MergeFilesWithPageRanges([]FileRangeSpec{
+		{File: encrypted, Ranges: []PageRange{{2, 3}}},
+	}, output, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.TotalPages)
+	assert.Equal(t, []string{encrypted}, result.ValidatedFiles)
+}
+
+func TestPDFServiceImpl_DecryptPDF(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "locked.pdf", buildEncryptedPDF(3))
+	output := filepath.Join(dir, "unlocked.pdf")
+
+	service := NewPDFService().(*PDFServiceImpl)
+	service.fileBackend = fakeDecrypt(3)
+	require.NoError(t, service.DecryptPDF(input, output, "secret"))
+
+	encrypted, err := hasEncryptEntry(output)
+	require.NoError(t, err)
+	assert.False(t, encrypted)
+	stats, err := WalkPageTreeFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.PageCount)
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*_temp_*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestPDFServiceImpl_DecryptPDF_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "locked.pdf", buildEncryptedPDF(1))
+	output := createTestFile(t, dir, "unlocked.pdf", []byte("previous"))
+	service := NewPDFService().(*PDFServiceImpl)
+
+	tests := []struct {
+		name    string
+		backend func(inputFile, outputFile, password string) error
+		want    ErrorType
+	}{
+		{"wrong password", func(_, _, _ string) error {
+			return errors.New("decryption failed: pdfcpu: please provide the correct password")
+		}, ErrorEncrypted},
+		{"corrupted", func(_, _, _ string) error {
+			return errors.New("decryption failed: pdfcpu: corrupt xref table")
+		}, ErrorCorrupted},
+		{"still encrypted", func(_, outputFile, _ string) error {
+			return os.WriteFile(outputFile, buildEncryptedPDF(1), 0644)
+		}, ErrorCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.fileBackend = &fakeFileBackend{decrypt: tt.backend}
+			err := service.DecryptPDF(input, output, "guess")
+
+			var pdfErr *PDFError
+			require.ErrorAs(t, err, &pdfErr)
+			assert.Equal(t, tt.want, pdfErr.Type)
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.Equal(t, "previous", string(data), "解密失败时应保留原有输出")
+		})
+	}
+}
+
+func TestPDFServiceImpl_DecryptPDF_NotEncryptedCopies(t *testing.T) {
+	service := NewPDFService().(*PDFServiceImpl)
+	service.fileBackend = &fakeFileBackend{decrypt: func(string, string, string) error {
+		t.Fatal("未加密的输入不应调用解密后端")
+		return nil
+	}}
+
+	dir := t.TempDir()
+	content := buildFlatPDF(2)
+	input := createTestFile(t, dir, "plain.pdf", content)
+	output := filepath.Join(dir, "out.pdf")
+
+	require.NoError(t, service.DecryptPDF(input, output, ""))
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestMergeStreaming_UsesVaultPasswords(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(2))
+	vault, err := OpenFileVault(filepath.Join(dir, "vault.json"), "master")
+	require.NoError(t, err)
+	newMerger := func(passwords map[string]string) *StreamingMerger {
+		merger := NewStreamingMerger(&MergeOptions{
+			TempDirectory: dir,
+			BackendStats:  NewBackendStatsStore(),
+			Passwords:     passwords,
+			Vault:         vault,
+		})
+		merger.adapter = nil
+		merger.fileBackend = fakeDecrypt(2)
+		return merger
+	}
+
+	// 指定的密码解密成功后保存到保险库
+	_, err = newMerger(map[string]string{encrypted: "secret"}).MergeStreaming(context.Background(), []string{encrypted}, filepath.Join(dir, "first.pdf"), nil)
+	require.NoError(t, err)
+	password, ok := LookupVaultPassword(vault, encrypted)
+	require.True(t, ok, "解密成功的密码应保存")
+	assert.Equal(t, "secret", password)
+
+	// 没有指定密码时使用保险库中的密码
+	result, err := newMerger(nil).MergeStreaming(context.Background(), []string{encrypted}, filepath.Join(dir, "second.pdf"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.TotalPages)
+
+	// 失效的保存密码被删除
+	require.NoError(t, RememberPassword(vault, encrypted, "old"))
+	_, err = newMerger(nil).MergeStreaming(context.Background(), []string{encrypted}, filepath.Join(dir, "third.pdf"), nil)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorEncrypted, pdfErr.Type)
+	_, ok = LookupVaultPassword(vault, encrypted)
+	assert.False(t, ok, "失效的保存密码应被删除")
+}
+
+func TestPDFServiceImpl_DecryptPDF_UsesVault(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "locked.pdf", buildEncryptedPDF(1))
+	output := filepath.Join(dir, "unlocked.pdf")
+	vault, err := OpenFileVault(filepath.Join(dir, "vault.json"), "master")
+	require.NoError(t, err)
+	service := NewPDFServiceWithConfig(&ServiceConfig{PasswordVault: vault}).(*PDFServiceImpl)
+	service.fileBackend = fakeDecrypt(1)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, service.DecryptPDF(input, output, ""), &pdfErr, "保险库为空时需要密码")
+	assert.Equal(t, ErrorEncrypted, pdfErr.Type)
+
+	require.NoError(t, service.DecryptPDF(input, output, "secret"))
+	require.NoError(t, os.Remove(output))
+	require.NoError(t, service.DecryptPDF(input, output, ""), "应使用保存的密码")
+	assert.True(t, fileExists(output))
+}
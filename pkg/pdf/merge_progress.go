@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// MergeProgressFunc 报告合并进度：已写入输出的输入字节数和输入总字节数
+type MergeProgressFunc func(doneBytes, totalBytes int64)
+
+// mergeProgressSteps 报告进度时输入最多分成的组数。每追加一组都要重写一次累积的输出，
+// 组数越多进度越细，总耗时也越长。
+const mergeProgressSteps = 8
+
+// groupInputsByBytes 按顺序把输入分成至多maxGroups个连续的组，每组的字节数接近总量的1/maxGroups，
+// 同时返回每组的字节数。无法读取大小的文件按0字节计算。
+func groupInputsByBytes(files []string, maxGroups int) ([][]string, []int64) {
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			sizes[i] = info.Size()
+		}
+		total += sizes[i]
+	}
+	target := total / int64(max(maxGroups, 1))
+
+	var groups [][]string
+	var groupBytes []int64
+	start, current := 0, int64(0)
+	for i := range files {
+		current += sizes[i]
+		last := i == len(files)-1
+		if last || (current >= target && len(groups) < maxGroups-1) {
+			groups = append(groups, files[start:i+1])
+			groupBytes = append(groupBytes, current)
+			start, current = i+1, 0
+		}
+	}
+	return groups, groupBytes
+}
+
+// mergeProgress 把后端报告的已处理字节数换算为合并步骤内的进度。
+// 分块和并发模式中多个后端调用共享同一实例，进度在[start, end]内按字节比例增长。
+type mergeProgress struct {
+	mu     sync.Mutex
+	report func(progress float64, message string)
+	record func(done, total int64) // 记录字节数用于估计吞吐量，可以为nil
+	start  float64
+	end    float64
+	total  int64
+	done   int64
+}
+
+// trackMergeProgress 让后续的后端调用按files的总字节数在[start, end]内报告进度
+func (sm *StreamingMerger) trackMergeProgress(files []string, start, end float64) {
+	sm.mergeProgress = &mergeProgress{
+		report: sm.updateProgress,
+		record: sm.recordProgressBytes,
+		start:  start,
+		end:    end,
+		total:  totalInputBytes(files),
+	}
+}
+
+// recordProgressBytes 把合并步骤已处理的字节数交给进度跟踪器，用于估计吞吐量和剩余时间
+func (sm *StreamingMerger) recordProgressBytes(done, total int64) {
+	if sm.progressTracker != nil {
+		sm.progressTracker.RecordBytes(done, total)
+	}
+}
+
+// backendCallback 为一次后端调用返回进度函数，只累加本次调用新报告的字节数；p为nil时返回nil
+func (p *mergeProgress) backendCallback() MergeProgressFunc {
+	if p == nil {
+		return nil
+	}
+	var reported int64
+	return func(doneBytes, _ int64) {
+		p.advance(doneBytes - reported)
+		reported = doneBytes
+	}
+}
+
+// advance 累加已处理的字节数并报告进度，超过总量时停在end
+func (p *mergeProgress) advance(n int64) {
+	if n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done = min(p.done+n, p.total)
+	if p.record != nil {
+		p.record(p.done, p.total)
+	}
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = float64(p.done) / float64(p.total)
+	}
+	p.report(p.start+(p.end-p.start)*fraction,
+		fmt.Sprintf("已合并 %.1f/%.1f MB", float64(p.done)/(1024*1024), float64(p.total)/(1024*1024)))
+}
+
+// ProgressReporter 由需要结构化进度的进度写入器实现。合并时如果传入的io.Writer实现了该接口，
+// 进度以0.0-1.0的比例报告给它，不再写成文本行。
+type ProgressReporter interface {
+	ReportProgress(fraction float64, message string)
+}
+
+// reporterProgress 返回把后端报告的字节数换算为比例交给reporter的进度函数，
+// progressWriter没有实现ProgressReporter时返回nil
+func reporterProgress(progressWriter io.Writer) MergeProgressFunc {
+	reporter, ok := progressWriter.(ProgressReporter)
+	if !ok {
+		return nil
+	}
+	return func(doneBytes, totalBytes int64) {
+		fraction := 1.0
+		if totalBytes > 0 {
+			fraction = float64(doneBytes) / float64(totalBytes)
+		}
+		reporter.ReportProgress(fraction,
+			fmt.Sprintf("已合并 %.1f/%.1f MB", float64(doneBytes)/(1024*1024), float64(totalBytes)/(1024*1024)))
+	}
+}
@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupInputsByBytes(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		createTestFile(t, dir, "a.pdf", make([]byte, 10)),
+		createTestFile(t, dir, "b.pdf", make([]byte, 10)),
+		createTestFile(t, dir, "c.pdf", make([]byte, 30)),
+		createTestFile(t, dir, "d.pdf", make([]byte, 10)),
+	}
+
+	groups, groupBytes := groupInputsByBytes(files, 2)
+	assert.Equal(t, [][]string{files[:3], files[3:]}, groups)
+	assert.Equal(t, []int64{50, 10}, groupBytes)
+
+	groups, groupBytes = groupInputsByBytes(files, 8)
+	assert.Len(t, groups, 4, "组数不应超过输入数")
+	assert.Equal(t, []int64{10, 10, 30, 10}, groupBytes)
+
+	groups, _ = groupInputsByBytes(files, 1)
+	assert.Equal(t, [][]string{files}, groups)
+
+	missing := filepath.Join(dir, "missing.pdf")
+	groups, groupBytes = groupInputsByBytes([]string{missing, files[0]}, 2)
+	assert.Equal(t, [][]string{{missing, files[0]}}, groups, "无法读取大小的文件按0字节计入下一组")
+	assert.Equal(t, []int64{10}, groupBytes)
+}
+
+func TestMergeProgress_SharedAcrossBackendCalls(t *testing.T) {
+	var mu sync.Mutex
+	var reported []float64
+	p := &mergeProgress{
+		report: func(progress float64, message string) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, progress)
+		},
+		start: 0,
+		end:   90,
+		total: 400,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			callback := p.backendCallback()
+			callback(50, 100)
+			callback(100, 100)
+			callback(100, 100) // 重复报告不应累加
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, reported, 8)
+	for i := 1; i < len(reported); i++ {
+		assert.GreaterOrEqual(t, reported[i], reported[i-1], "进度不应回退")
+	}
+	assert.Equal(t, 90.0, reported[len(reported)-1])
+
+	// 回退后端重复处理同一批输入时停在区间末尾
+	p.backendCallback()(100, 100)
+	assert.Equal(t, 90.0, reported[len(reported)-1])
+	assert.Nil(t, (*mergeProgress)(nil).backendCallback())
+}
+
+func TestMergeStreaming_ReportsMergeProgress(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildFlatPDF(3))
+	output := filepath.Join(dir, "out.pdf")
+
+	var mu sync.Mutex
+	var messages []string
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:  t.TempDir(),
+		BackendStats:   NewBackendStatsStore(),
+		MaxMemoryUsage: 1 << 30,
+	})
+	merger.adapter = nil
+	_, err := merger.MergeStreaming(context.Background(), []string{input}, output, func(progress float64, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, message)
+	})
+	require.NoError(t, err)
+
+	// 回调异步执行
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, message := range messages {
+			if strings.HasPrefix(message, "已合并") {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond, "合并步骤应按已合并的字节报告进度")
+	assert.Nil(t, merger.mergeProgress, "合并结束后不应保留进度跟踪")
+
+	// 合并步骤按实际字节数记录统计，完成后不再给出剩余时间
+	stats := merger.GetProgressTracker().GetStats()
+	assert.Equal(t, totalInputBytes([]string{input}), stats.BytesTotal)
+	assert.Equal(t, stats.BytesTotal, stats.BytesDone)
+	assert.False(t, stats.HasETA)
+}
+
+// recordingReporter 记录按比例报告的进度，同时记录写成文本的行
+type recordingReporter struct {
+	mu        sync.Mutex
+	fractions []float64
+	lines     []string
+}
+
+func (r *recordingReporter) ReportProgress(fraction float64, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fractions = append(r.fractions, fraction)
+}
+
+func (r *recordingReporter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, string(p))
+	return len(p), nil
+}
+
+func TestReporterProgress(t *testing.T) {
+	assert.Nil(t, reporterProgress(&strings.Builder{}), "普通写入器不接收字节进度")
+
+	reporter := &recordingReporter{}
+	progress := reporterProgress(reporter)
+	require.NotNil(t, progress)
+	progress(25, 100)
+	progress(0, 0)
+	assert.Equal(t, []float64{0.25, 1}, reporter.fractions)
+}
+
+func TestMergeFilesLegacy_ReportsFractionsToReporter(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+
+	reporter := &recordingReporter{}
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), BackendStats: NewBackendStatsStore()})
+	_, err := merger.MergeFilesLegacy(input, []string{input}, filepath.Join(dir, "out.pdf"), reporter)
+	require.NoError(t, err)
+
+	// 进度回调异步执行
+	require.Eventually(t, func() bool {
+		reporter.mu.Lock()
+		defer reporter.mu.Unlock()
+		return len(reporter.fractions) > 0
+	}, time.Second, 10*time.Millisecond)
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	for _, fraction := range reporter.fractions {
+		assert.True(t, fraction >= 0 && fraction <= 1, "进度 %v 应在0.0-1.0之间", fraction)
+	}
+	assert.Empty(t, reporter.lines, "实现了ProgressReporter时不应再写文本进度行")
+}
@@ -0,0 +1,53 @@
+package pdf
+
+import "sync"
+
+// mergeResultCapacity 服务保留的未取走合并结果数，超出时丢弃最早的结果
+const mergeResultCapacity = 32
+
+// mergeResultStore 按输出路径保存MergePDFs成功后的合并结果，供TakeMergeResult取走。
+// 结果只能取走一次，避免把之前写出到同一路径的结果当作本次的结果
+type mergeResultStore struct {
+	mu      sync.Mutex
+	results map[string]*MergeResult
+	order   []string // 保存顺序，最早的在前
+}
+
+// newMergeResultStore 创建空的结果存储
+func newMergeResultStore() *mergeResultStore {
+	return &mergeResultStore{results: make(map[string]*MergeResult)}
+}
+
+// put 保存输出路径的合并结果，替换该路径尚未取走的结果
+func (st *mergeResultStore) put(result *MergeResult) {
+	path := absPath(result.OutputPath)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.results[path]; !ok {
+		st.order = append(st.order, path)
+	}
+	st.results[path] = result
+	for len(st.order) > mergeResultCapacity {
+		delete(st.results, st.order[0])
+		st.order = st.order[1:]
+	}
+}
+
+// take 返回并移除输出路径的合并结果，没有时返回nil
+func (st *mergeResultStore) take(outputPath string) *MergeResult {
+	path := absPath(outputPath)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	result, ok := st.results[path]
+	if !ok {
+		return nil
+	}
+	delete(st.results, path)
+	for i, p := range st.order {
+		if p == path {
+			st.order = append(st.order[:i], st.order[i+1:]...)
+			break
+		}
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package pdf
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -9,23 +10,82 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/user/pdf-merger/internal/clock"
 	progressmodel "github.com/user/pdf-merger/internal/model"
 )
 
 // StreamingMerger 流式PDF合并器
 type StreamingMerger struct {
 	adapter         *PDFCPUAdapter
+	adapterErr      error        // 创建适配器失败的原因，回退合并无法处理输入时作为错误原因
+	adapterPool     *AdapterPool // 适配器取自该池时，Close把适配器放回池中而不是关闭
 	maxMemoryUsage  int64
 	tempDir         string
 	mutex           sync.Mutex
 	progressTracker *progressmodel.ProgressTracker
 	config          *PDFCPUConfig
 	streamingConfig *StreamingConfig
+	reviewCopy      bool
+	integrity       bool                          // 是否在验证时计算并校验输入摘要
+	expectedDigests map[string]string             // 按输入路径指定的预期SHA-256
+	previous        *AuditManifest                // 上次运行的清单，用于生成变化摘要
+	linearize       bool                          // 是否线性化输出
+	clock           clock.Clock                   // 时间与随机源
+	adaptive        bool                          // 是否按统计选择后端顺序
+	stats           *BackendStatsStore            // 后端结果统计，nil时使用共享存储
+	pageBoxes       map[string]*PageBoxAdjustment // 按输入路径指定的页面框调整
+	rotations       map[string]int                // 按输入路径指定的顺时针旋转角度
+	normalize       bool                          // 是否在合并前把 /Rotate 写入页面内容
+	passwords       map[string]string             // 按输入路径指定的打开密码
+	vault           PasswordVault                 // 没有指定密码的加密输入按内容哈希查询的保险库，可以为nil
+	vaultMutex      sync.Mutex                    // 保护vaultPasswords
+	vaultPasswords  map[string]string             // 本次合并中已从保险库取得的密码，避免重复计算哈希
+	tryRepair       bool                          // 是否尝试修复未通过验证的输入
+	allowDuplicates bool                          // 是否合并内容重复的输入
+	failOnSigned    bool                          // 输入包含数字签名时是否中止合并
+	dropAttachments bool                          // 是否移除输出中的附件而不是合并各输入的附件
+	flattenForms    bool                          // 是否把表单字段展平到页面内容而不是合并表单
+	maxOutputBytes  int64                         // 输出大小上限（字节），0时不限制
+	maxOutputPages  int                           // 输出页数上限，0时不限制
+	keepBackup      bool                          // 替换已存在的输出前是否保留备份
+	requireExt      bool                          // 是否只接受 .pdf 扩展名的输入
+	backupConfig    *RollbackConfig               // 备份的位置和保留策略
+	outputBackend   WriteBackend                  // 提交输出的后端，nil时在本地重命名
+	writeManifest   bool                          // 是否在输出旁写出合并清单
+	embedManifest   bool                          // 是否把合并清单作为附件嵌入输出
+	privacyMode     bool                          // 嵌入的清单是否只保留文件名
+	toolVersion     string                        // 清单中记录的工具版本
+	mergeProgress   *mergeProgress                // 合并步骤的字节进度，nil时后端不报告进度
+	sourceBookmarks bool                          // 是否为每个输入添加顶层书签
+	generateTOC     bool                          // 是否在输出开头插入目录页
+	metadataSource  string                        // 输出元数据的来源
+	customMetadata  map[string]string             // 覆盖输出文档信息的值
+	stamps          []*StampOptions               // 合并后添加到每一页的印章
+	imposition      *ImpositionOptions            // 印章之后的拼版，nil时不拼版
+	fileBackend     fileBackend                   // 解密、加密和修复单个文件的后端，nil时使用适配器
+	chunkMerger     chunkMergeFunc                // 合并单个分块的函数，nil时使用 mergeWithBackends
+	memoryReader    func() int64                  // 读取当前堆分配字节数的函数，nil时读取运行时统计
+	optimize        bool                          // 是否在加密前优化输出
+	imageDPI        int                           // 优化时图像降采样的目标分辨率，0时不降采样
+	encryption      *outputEncryption             // 输出加密设置，nil时不加密
+	log             Logger                        // 日志
+	totalChunks     int64                         // 当前合并的分块总数（原子访问）
+	completedChunks int64                         // 当前合并已完成的分块数（原子访问）
+	closer          closeGuard                    // Close契约：取消流式合并并等待合并结束后再释放资源
+	tempMu          sync.Mutex                    // 保护tempFiles和tempWriters
+	tempFiles       map[string]bool               // 已分配且尚未清理的临时文件，取消、关闭和流式合并结束时删除遗留
+	tempWriters     int                           // 仍可能写入临时文件的协程数（被取消而放弃等待的分块）
+	tempIdle        *sync.Cond                    // tempWriters归零时广播，延迟创建
+	runMu           sync.Mutex                    // 保护cancelRun
+	cancelRun       context.CancelFunc            // 取消进行中的流式合并，Cancel时调用
+	gcOnce          sync.Once                     // 每个合并器只启动一个渐进式GC协程
+	gcLoop          sync.WaitGroup                // 渐进式GC协程，Close时等待其退出
 }
 
 // StreamingConfig 流式合并配置
@@ -38,7 +98,8 @@ type StreamingConfig struct {
 	// 分块处理
 	MinChunkSize       int   // 最小分块大小
 	MaxChunkSize       int   // 最大分块大小
-	LargeFileThreshold int64 // 大文件阈值（字节）
+	LargeFileThreshold int64 // 大文件阈值（字节），读取器和验证器把超过阈值的输入映射到内存
+	DisableMemoryMap   bool  // 不映射大文件，始终直接读取
 
 	// 并发控制
 	MaxConcurrentChunks int           // 最大并发分块数
@@ -50,6 +111,17 @@ type StreamingConfig struct {
 	EnableProgressiveGC    bool // 启用渐进式GC
 }
 
+// memoryMapThreshold 返回映射输入的大小阈值，config为nil时使用默认配置，禁用映射时返回-1
+func (c *StreamingConfig) memoryMapThreshold() int64 {
+	if c == nil {
+		return DefaultStreamingConfig().LargeFileThreshold
+	}
+	if c.DisableMemoryMap {
+		return -1
+	}
+	return c.LargeFileThreshold
+}
+
 // DefaultStreamingConfig 默认流式合并配置
 func DefaultStreamingConfig() *StreamingConfig {
 	return &StreamingConfig{
@@ -70,6 +142,41 @@ func DefaultStreamingConfig() *StreamingConfig {
 	}
 }
 
+// Validate 检查配置的取值：分块大小和并发数至少为1且最小分块不大于最大分块，
+// 内存阈值在0到1之间（不含0）且警告阈值不高于严重阈值，时间和大小不为负。nil配置有效
+func (c *StreamingConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	invalid := func(format string, args ...interface{}) error {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "流式合并配置无效: " + fmt.Sprintf(format, args...),
+		}
+	}
+	switch {
+	case c.MinChunkSize < 1:
+		return invalid("最小分块大小必须至少为1，实际为 %d", c.MinChunkSize)
+	case c.MaxChunkSize < c.MinChunkSize:
+		return invalid("最小分块大小 %d 大于最大分块大小 %d", c.MinChunkSize, c.MaxChunkSize)
+	case c.MaxConcurrentChunks < 1:
+		return invalid("最大并发分块数必须至少为1，实际为 %d", c.MaxConcurrentChunks)
+	case c.MemoryWarningThreshold <= 0 || c.MemoryWarningThreshold > 1:
+		return invalid("内存警告阈值必须在0到1之间，实际为 %v", c.MemoryWarningThreshold)
+	case c.MemoryCriticalThreshold <= 0 || c.MemoryCriticalThreshold > 1:
+		return invalid("内存严重阈值必须在0到1之间，实际为 %v", c.MemoryCriticalThreshold)
+	case c.MemoryWarningThreshold > c.MemoryCriticalThreshold:
+		return invalid("内存警告阈值 %v 高于严重阈值 %v", c.MemoryWarningThreshold, c.MemoryCriticalThreshold)
+	case c.GCInterval < 0:
+		return invalid("GC间隔不能为负数: %v", c.GCInterval)
+	case c.ChunkProcessTimeout < 0:
+		return invalid("分块处理超时不能为负数: %v", c.ChunkProcessTimeout)
+	case c.LargeFileThreshold < 0:
+		return invalid("大文件阈值不能为负数: %d", c.LargeFileThreshold)
+	}
+	return nil
+}
+
 // MergeOptions 合并选项
 type MergeOptions struct {
 	MaxMemoryUsage    int64  // 最大内存使用量（字节）
@@ -78,17 +185,329 @@ type MergeOptions struct {
 	ChunkSize         int    // 每次处理的页面数量
 	UseStreaming      bool   // 是否使用流式处理
 	OptimizeMemory    bool   // 是否优化内存使用
-	ConcurrentWorkers int    // 并发工作线程数
+	ConcurrentWorkers int    // 同时合并的分块数上限，0时使用CPU核数；只限制本包的协程，不修改GOMAXPROCS
+	ReviewCopy        bool   // 是否在输出旁生成带警告注释的审阅副本（_review.pdf）
+	VerifyChecksums   bool   // 完整性模式：验证时计算输入摘要，并与.sha256旁路文件比对
+
+	// ExpectedChecksums 按输入路径指定的预期SHA-256，优先于旁路文件；非空时自动启用完整性模式
+	ExpectedChecksums map[string]string
+
+	// PreviousManifest 同一合并任务上次运行的清单，成功后据此生成MergeResult.Delta；
+	// 为nil且启用WriteManifest时读取输出旁上次写出的清单旁路文件
+	PreviousManifest *AuditManifest
+
+	// Linearize 将输出线性化（快速Web视图），作为优化和加密之后的最后一个写入步骤
+	Linearize bool
+
+	// Clock 时间与随机源，用于临时文件命名和内存压力下的等待；nil时使用系统时钟
+	Clock clock.Clock
+
+	// AdaptiveBackends 按历史统计为每次合并选择后端顺序，失败时依次尝试其余后端
+	AdaptiveBackends bool
+
+	// BackendStats 记录后端结果的统计存储；nil时使用配置目录下的共享存储
+	BackendStats *BackendStatsStore
+
+	// PageBoxes 按输入路径指定的页面框调整，在合并前的预处理中应用到该输入的副本
+	PageBoxes map[string]*PageBoxAdjustment
+
+	// Rotations 按输入路径指定的顺时针旋转角度（0、90、180、270），在合并前的预处理中叠加到该输入副本各页的 /Rotate 上
+	Rotations map[string]int
+
+	// NormalizeOrientation 合并前把各输入页面的 /Rotate 写入页面内容并相应调整MediaBox和CropBox，
+	// 输出中的页面不再依赖 /Rotate 显示为正向；在Rotations之后应用。有注释的页面保持不变并记录警告
+	NormalizeOrientation bool
+
+	// Passwords 按输入路径指定的打开密码；加密输入在合并前解密到临时副本
+	Passwords map[string]string
+
+	// Vault 密码保险库。没有在Passwords中指定密码的加密输入按内容哈希查询保险库，
+	// 用Passwords中的密码解密成功后保存到保险库，保存的密码失效时删除条目；nil时不使用保险库
+	Vault PasswordVault
+
+	// TryRepair 未通过验证的输入先尝试修复到临时副本（见RepairPDF），修复成功时合并副本而不是跳过该输入；
+	// 原始输入不会被修改，校验和不一致和缺少密码的输入不会尝试修复
+	TryRepair bool
+
+	// AllowDuplicates 合并与之前的输入内容相同（大小和SHA-256一致）的输入；
+	// 为false时跳过重复输入，两种情况都记录在MergeResult.Duplicates中
+	AllowDuplicates bool
+
+	// FailOnSignedInputs 输入包含数字签名时以ErrorInvalidInput中止合并；为false时合并并在Warnings中记录，
+	// 两种情况都记录在MergeResult.SignedFiles中。合并输出中不再有有效的签名
+	FailOnSignedInputs bool
+
+	// DropAttachments 移除输出中的附件（/Names /EmbeddedFiles）；为false时合并各输入的附件，
+	// 重名的附件改名（file.txt → file_2.txt）并记录在Warnings中
+	DropAttachments bool
+
+	// FlattenForms 把表单字段的外观展平到页面内容并移除 /AcroForm；为false时合并各输入的表单，
+	// 与之前的输入重名的顶层字段改名（name → name_2）并记录在Warnings中
+	FlattenForms bool
+
+	// MaxOutputSizeBytes 输出大小上限（字节），0时不限制。合并前有效输入的大小之和超出时立即失败，
+	// 合并期间写入的临时输出超出时中止，都返回ErrorLimitExceeded且不写出输出
+	MaxOutputSizeBytes int64
+
+	// MaxOutputPages 输出页数上限，0时不限制。合并前有效输入的页数之和超出时返回ErrorLimitExceeded
+	MaxOutputPages int
+
+	// AddSourceBookmarks 为每个输入添加指向其第一页的顶层书签，标题取文档信息中的Title，
+	// 没有时使用文件名；输入中已有的书签嵌套在对应输入的书签下
+	AddSourceBookmarks bool
+
+	// GenerateTOC 在输出开头插入列出各输入标题和起始页的目录页，每行链接到对应页面；
+	// 来源书签和印章的页码包含目录页
+	GenerateTOC bool
+
+	// Stamps 合并后按顺序添加到每一页的文字（页码、页脚、水印），在书签之后、加密和线性化之前应用；
+	// {filename} 为该页来源输入的文件名
+	Stamps []*StampOptions
+
+	// NUp 印章之后把每NUp个源页面（2或4）缩放排列到一张输出页上，0时不拼版；见ImposePages
+	NUp int
+
+	// Booklet 印章之后按骑马钉印刷的顺序两两排列页面，页数不是4的倍数时补空白页；NUp为0或2
+	Booklet bool
+
+	// NUpMargin 拼版时每个格子四周的边距（点）
+	NUpMargin float64
+
+	// NUpOrder n-up的排列顺序，空时为NUpOrderAcross
+	NUpOrder NUpOrder
+
+	// OptimizeOutput 在印章之后、加密之前优化输出：合并相同的字体程序和图像XObject，压缩未压缩的流，
+	// 并使用对象流和交叉引用流写出（线性化和审阅副本需要传统交叉引用表时不使用）。
+	// 输入包含数字签名或合并输出已加密时跳过并记录警告；优化失败时保留未优化的输出并记录警告
+	OptimizeOutput bool
+
+	// OptimizeImagesDPI 优化时把有效分辨率高于该值的页面图像降采样到该值，0时不降采样；需要启用OptimizeOutput
+	OptimizeImagesDPI int
+
+	// OutputUserPassword 打开输出所需的用户密码；与OutputOwnerPassword都为空时输出不加密
+	OutputUserPassword string
+
+	// OutputOwnerPassword 输出的所有者密码，为空时使用用户密码
+	OutputOwnerPassword string
+
+	// OutputPermissions 加密输出允许的操作，nil时允许全部操作；设置时必须提供密码
+	OutputPermissions *OutputPermissions
+
+	// BackupOutput 替换已存在的输出前保留一份备份（见BackupDirectory），备份路径记录在MergeResult.BackupPath。
+	// 输出总是先写入临时文件再替换，失败时原输出不受影响，备份只用于保留上一版结果。
+	BackupOutput bool
+
+	// BackupDirectory 备份目录，相对路径相对于输出所在的目录，为空时使用DefaultBackupDirectory
+	BackupDirectory string
+
+	// BackupRetention 每个输出保留的备份数，超出时删除最旧的备份；0使用DefaultBackupRetention，负数表示全部保留
+	BackupRetention int
+
+	// OutputBackend 提交已验证输出的后端，nil时把临时文件重命名为输出文件。
+	// 使用远程后端（如S3Backend）时输出路径只决定本地临时文件的位置，MergeResult.OutputPath为远程位置；
+	// 远程输出不能与审阅副本同时使用
+	OutputBackend WriteBackend
+
+	// WriteManifest 在输出旁写出合并清单（output.pdf.manifest.json，见AuditManifest）：工具版本、时间、选项，
+	// 各输入的路径、大小、SHA-256、页数和在输出中的页面偏移，以及输出的SHA-256和页数
+	WriteManifest bool
+
+	// EmbedManifest 把合并清单作为附件（merge-manifest.json）嵌入输出。嵌入在计算输出摘要之前进行，
+	// 因此嵌入的清单不包含输出摘要；启用PrivacyMode时其中的路径只保留文件名
+	EmbedManifest bool
+
+	// PrivacyMode 嵌入输出的合并清单不包含绝对路径，旁路清单不受影响
+	PrivacyMode bool
+
+	// ToolVersion 合并清单中记录的工具版本，为空时记录为 unknown
+	ToolVersion string
+
+	// Logger 合并过程的日志，同时传给合并器创建的pdfcpu适配器；nil时使用默认日志
+	Logger Logger
+
+	// AdapterPool 合并器从该池取出pdfcpu适配器，Close时放回，nil时合并器自行创建并关闭适配器。
+	// 池中的适配器使用池的配置（临时目录、日志、页面树限制）
+	AdapterPool *AdapterPool
+
+	// RequirePDFExtension 只接受 .pdf 扩展名的输入；为false时以文件头识别PDF
+	RequirePDFExtension bool
+
+	// StreamingConfig 分块大小、并发数、内存阈值和GC间隔等流式合并配置，合并器使用其副本；
+	// nil时使用DefaultStreamingConfig，并在MaxMemoryUsage小于50MB时降低内存阈值。
+	// ConcurrentWorkers大于0时优先于其中的MaxConcurrentChunks。取值无效时合并返回ErrorInvalidInput
+	StreamingConfig *StreamingConfig
+
+	// MetadataSource 输出元数据的来源：first复制主文件（第一个输入）的文档信息和XMP元数据，
+	// custom只应用CustomMetadata，空或none保留pdfcpu写出的元数据。在书签之后、印章和最终验证之前应用
+	MetadataSource string
+
+	// CustomMetadata 覆盖输出文档信息的值（Title、Author、Subject、Keywords），MetadataSource为first或custom时应用
+	CustomMetadata map[string]string
+}
+
+// Validate 检查选项组合是否有效
+func (o *MergeOptions) Validate() error {
+	if o.Linearize && o.ReviewCopy {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "线性化输出不能与审阅副本同时使用：审阅副本以增量更新方式写入，无法保持线性化结构",
+		}
+	}
+	if err := validateEncryptionOptions(o.OutputUserPassword, o.OutputOwnerPassword, o.OutputPermissions); err != nil {
+		return err
+	}
+	if err := validateStamps(o.Stamps); err != nil {
+		return err
+	}
+	if imposition := o.imposition(); imposition.Enabled() {
+		if err := imposition.Validate(); err != nil {
+			return err
+		}
+		if o.AddSourceBookmarks {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: "来源书签不能与拼版同时使用：拼版后书签指向的页面不再在输出中",
+			}
+		}
+	}
+	for file, degrees := range o.Rotations {
+		if err := validateRotation(file, degrees); err != nil {
+			return err
+		}
+	}
+	if o.OptimizeImagesDPI < 0 {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("图像降采样分辨率不能为负数: %d", o.OptimizeImagesDPI),
+		}
+	}
+	if o.OptimizeImagesDPI > 0 && !o.OptimizeOutput {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "图像降采样需要启用输出优化",
+		}
+	}
+	if _, remote := o.OutputBackend.(RemoteBackend); remote && o.ReviewCopy {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "远程输出不能与审阅副本同时使用：审阅副本写在本地输出文件旁",
+		}
+	}
+	if o.ReviewCopy && (o.OutputUserPassword != "" || o.OutputOwnerPassword != "") {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "加密输出不能与审阅副本同时使用：审阅副本以增量更新方式写入未加密的注释",
+		}
+	}
+	return nil
 }
 
-// MergeResult 合并结果
+// MergeResult 合并结果。合并失败时也可能返回部分结果，此时FailedStage非空且OutputPath不一定存在。
 type MergeResult struct {
-	OutputPath     string
-	TotalPages     int
-	ProcessedFiles int
-	SkippedFiles   []string
-	ProcessingTime time.Duration
-	MemoryUsage    int64
+	OutputPath      string        `json:"output_path"`
+	TotalPages      int           `json:"total_pages"`
+	ProcessedFiles  int           `json:"processed_files"`
+	SkippedFiles    []string      `json:"skipped_files"`
+	ProcessingTime  time.Duration `json:"processing_time_ns"`
+	MemoryUsage     int64         `json:"memory_usage"`
+	ValidatedFiles  []string      `json:"validated_files"`            // 通过验证的文件
+	TotalChunks     int           `json:"total_chunks"`               // 分块合并的分块总数
+	CompletedChunks int           `json:"completed_chunks"`           // 已完成的分块数
+	Warnings        []string      `json:"warnings,omitempty"`         // 处理过程中收集的警告
+	FailedStage     string        `json:"failed_stage,omitempty"`     // 失败阶段，成功时为空
+	PageWarnings    []PageWarning `json:"page_warnings,omitempty"`    // 可归属到页面的警告
+	ReviewCopyPath  string        `json:"review_copy_path,omitempty"` // 审阅副本路径
+	InputDigests    []InputDigest `json:"input_digests,omitempty"`    // 完整性模式下各输入的摘要
+	Delta           *DeltaSummary `json:"delta,omitempty"`            // 与上次运行相比的变化
+	Linearized      bool          `json:"linearized"`                 // 输出是否已线性化
+	Encrypted       bool          `json:"encrypted"`                  // 输出是否已加密
+	BackupPath      string        `json:"backup_path,omitempty"`      // 启用BackupOutput时被替换输出的备份
+
+	// PeakMemory 合并过程中内存监控读到的最大堆分配（字节）
+	PeakMemory int64 `json:"peak_memory"`
+	// MemoryPressureEvents 内存监控报告警告或严重压力的次数，大于0说明限流曾经生效
+	MemoryPressureEvents int `json:"memory_pressure_events"`
+
+	// InputPages 各有效输入的页数，按合并顺序排列；无法统计的输入不出现在列表中
+	InputPages []InputPageCount `json:"input_pages,omitempty"`
+
+	// TOCPages 启用GenerateTOC时插入在输出开头的目录页数，各输入的页面在输出中相应后移
+	TOCPages int `json:"toc_pages,omitempty"`
+
+	// RepairedFiles 启用TryRepair时经修复后合并的输入（原始路径），也出现在ValidatedFiles中
+	RepairedFiles []string `json:"repaired_files,omitempty"`
+
+	// SkipReasons SkippedFiles中各输入被跳过的原因，键为输入路径
+	SkipReasons map[string]string `json:"skip_reasons,omitempty"`
+
+	// Duplicates 与之前的输入内容相同的输入；未启用AllowDuplicates时它们也出现在SkippedFiles中
+	Duplicates []DuplicateInput `json:"duplicates,omitempty"`
+
+	// SignedFiles 包含数字签名的输入，合并输出中这些签名已失效
+	SignedFiles []string `json:"signed_files,omitempty"`
+
+	// Attachments 输出中的附件数量；启用DropAttachments时为0
+	Attachments int `json:"attachments,omitempty"`
+
+	// FormFields 输出 /AcroForm 中的顶层字段数量；启用FlattenForms时为0
+	FormFields int `json:"form_fields,omitempty"`
+
+	// OriginalSize 和 OptimizedSize 启用OptimizeOutput时优化前后的输出大小（字节），跳过或优化失败时为0
+	OriginalSize  int64 `json:"original_size,omitempty"`
+	OptimizedSize int64 `json:"optimized_size,omitempty"`
+
+	// Manifest 启用WriteManifest或EmbedManifest时生成的合并清单
+	Manifest *AuditManifest `json:"manifest,omitempty"`
+
+	// ManifestPath 启用WriteManifest时写出的清单旁路文件
+	ManifestPath string `json:"manifest_path,omitempty"`
+}
+
+// skipInput 把输入记为跳过并记录原因
+func (r *MergeResult) skipInput(file, reason string) {
+	r.SkippedFiles = append(r.SkippedFiles, file)
+	if r.SkipReasons == nil {
+		r.SkipReasons = make(map[string]string)
+	}
+	r.SkipReasons[file] = reason
+}
+
+// InputPageCount 单个输入文件的页数
+type InputPageCount struct {
+	File  string `json:"file"`
+	Pages int    `json:"pages"`
+}
+
+// 合并阶段名称，用于MergeResult.FailedStage
+const (
+	MergeStageValidation   = "validation"
+	MergeStageMerging      = "merging"
+	MergeStageVerification = "verification"
+)
+
+// MergeError 合并失败错误，携带失败时的部分结果，供只返回error的接口传递
+type MergeError struct {
+	Result *MergeResult
+	Err    error
+}
+
+// Error 实现error接口
+func (e *MergeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回底层错误
+func (e *MergeError) Unwrap() error {
+	return e.Err
+}
+
+// PartialMergeResult 从错误链中提取部分合并结果，没有时返回nil
+func PartialMergeResult(err error) *MergeResult {
+	var mergeErr *MergeError
+	if errors.As(err, &mergeErr) {
+		return mergeErr.Result
+	}
+	return nil
 }
 
 // NewStreamingMerger 创建新的流式合并器
@@ -105,30 +524,43 @@ func NewStreamingMerger(options *MergeOptions) *StreamingMerger {
 		}
 	}
 
+	logger := loggerOrDefault(options.Logger)
+
 	// 创建pdfcpu配置，优化内存使用
 	config := &PDFCPUConfig{
-		ValidationMode:    "relaxed",
-		WriteObjectStream: options.OptimizeMemory,
-		WriteXRefStream:   options.OptimizeMemory,
+		ValidationMode: "relaxed",
+		// 线性化只支持传统交叉引用表，此时不写对象流和交叉引用流
+		WriteObjectStream: options.OptimizeMemory && !options.Linearize,
+		WriteXRefStream:   options.OptimizeMemory && !options.Linearize,
 		EncryptUsingAES:   true,
 		EncryptKeyLength:  256,
 		TempDirectory:     options.TempDirectory,
+		Logger:            logger,
+		Clock:             options.Clock,
 	}
 
-	// 创建pdfcpu适配器
-	adapter, err := NewPDFCPUAdapter(config)
+	// 创建pdfcpu适配器，提供了适配器池时从池中取出
+	var adapter *PDFCPUAdapter
+	var err error
+	if options.AdapterPool != nil {
+		adapter, err = options.AdapterPool.Get()
+	} else {
+		adapter, err = NewPDFCPUAdapter(config)
+	}
 	if err != nil {
 		// 如果创建适配器失败，记录错误但继续创建合并器
-		fmt.Printf("Warning: Failed to create PDFCPUAdapter: %v\n", err)
+		logger.Warn("无法创建pdfcpu适配器: %v", err)
 	}
 
-	// 创建流式配置
-	streamingConfig := DefaultStreamingConfig()
-
-	// 根据选项调整流式配置
-	if options.MaxMemoryUsage > 0 {
+	// 创建流式配置，调用方提供的配置复制一份，大文件优化等调整不影响调用方
+	var streamingConfig *StreamingConfig
+	if options.StreamingConfig != nil {
+		copied := *options.StreamingConfig
+		streamingConfig = &copied
+	} else {
+		streamingConfig = DefaultStreamingConfig()
 		// 根据内存大小调整阈值
-		if options.MaxMemoryUsage < 50*1024*1024 { // 小于50MB
+		if options.MaxMemoryUsage > 0 && options.MaxMemoryUsage < 50*1024*1024 { // 小于50MB
 			streamingConfig.MemoryWarningThreshold = 0.60 // 更保守
 			streamingConfig.MemoryCriticalThreshold = 0.75
 		}
@@ -140,10 +572,50 @@ func NewStreamingMerger(options *MergeOptions) *StreamingMerger {
 
 	return &StreamingMerger{
 		adapter:         adapter,
+		adapterErr:      err,
+		adapterPool:     options.AdapterPool,
 		maxMemoryUsage:  options.MaxMemoryUsage,
 		tempDir:         options.TempDirectory,
 		config:          config,
 		streamingConfig: streamingConfig,
+		reviewCopy:      options.ReviewCopy,
+		integrity:       options.VerifyChecksums || len(options.ExpectedChecksums) > 0,
+		expectedDigests: options.ExpectedChecksums,
+		previous:        options.PreviousManifest,
+		linearize:       options.Linearize,
+		clock:           clock.OrSystem(options.Clock),
+		adaptive:        options.AdaptiveBackends,
+		stats:           options.BackendStats,
+		pageBoxes:       options.PageBoxes,
+		rotations:       options.Rotations,
+		normalize:       options.NormalizeOrientation,
+		passwords:       options.Passwords,
+		vault:           options.Vault,
+		tryRepair:       options.TryRepair,
+		allowDuplicates: options.AllowDuplicates,
+		failOnSigned:    options.FailOnSignedInputs,
+		dropAttachments: options.DropAttachments,
+		flattenForms:    options.FlattenForms,
+		maxOutputBytes:  options.MaxOutputSizeBytes,
+		maxOutputPages:  options.MaxOutputPages,
+		keepBackup:      options.BackupOutput,
+		requireExt:      options.RequirePDFExtension,
+		backupConfig:    backupConfig(options.BackupDirectory, options.BackupRetention, options.Clock),
+		outputBackend:   options.OutputBackend,
+		writeManifest:   options.WriteManifest,
+		embedManifest:   options.EmbedManifest,
+		privacyMode:     options.PrivacyMode,
+		toolVersion:     options.ToolVersion,
+		sourceBookmarks: options.AddSourceBookmarks,
+		generateTOC:     options.GenerateTOC,
+		metadataSource:  options.MetadataSource,
+		customMetadata:  options.CustomMetadata,
+		stamps:          options.Stamps,
+		imposition:      options.imposition(),
+		optimize:        options.OptimizeOutput,
+		imageDPI:        options.OptimizeImagesDPI,
+		encryption:      newOutputEncryption(options.OutputUserPassword, options.OutputOwnerPassword, options.OutputPermissions),
+		log:             logger,
 	}
 }
 
@@ -158,10 +630,19 @@ func NewStreamingMergerWithConfig(options *MergeOptions, streamingConfig *Stream
 
 // MergeFiles 使用pdfcpu合并多个PDF文件
 func (sm *StreamingMerger) MergeFiles(files []string, outputPath string, options *MergeOptions) (*MergeResult, error) {
+	if err := sm.closer.enter("合并器", ""); err != nil {
+		return nil, err
+	}
+	defer sm.closer.leave()
+
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
+	defer sm.sweepTempFiles()
 
-	startTime := time.Now()
+	atomic.StoreInt64(&sm.totalChunks, 0)
+	atomic.StoreInt64(&sm.completedChunks, 0)
+
+	startTime := sm.clock.Now()
 	result := &MergeResult{
 		OutputPath:     outputPath,
 		SkippedFiles:   make([]string, 0),
@@ -174,6 +655,18 @@ func (sm *StreamingMerger) MergeFiles(files []string, outputPath string, options
 			Message: "没有提供输入文件",
 		}
 	}
+	if err := CheckOutputNotInput(outputPath, files); err != nil {
+		return nil, err
+	}
+	// 上次运行的清单旁路文件在提交本次输出时被替换，先读取
+	previous := sm.previousManifest(outputPath)
+
+	if err := sm.checkOutputModes(sm.reviewCopy || (options != nil && options.ReviewCopy)); err != nil {
+		return nil, err
+	}
+	if err := sm.streamingConfig.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 新增：只读目录检测
 	dir := filepath.Dir(outputPath)
@@ -187,70 +680,163 @@ func (sm *StreamingMerger) MergeFiles(files []string, outputPath string, options
 	}
 
 	// 验证所有输入文件
+	repairs := make(inputRepairs)
+	defer sm.cleanupRepairs(repairs)
+	hashes := make(inputHashes)
 	for _, file := range files {
-		if err := sm.validateInputFile(file); err != nil {
-			result.SkippedFiles = append(result.SkippedFiles, file)
+		if err := sm.validateInput(result, file); err != nil {
+			if isChecksumMismatch(err) || isEncryptionError(err) {
+				return sm.failResult(result, MergeStageValidation, startTime), err
+			}
+			if !sm.repairInput(result, repairs, file) {
+				result.skipInput(file, err.Error())
+				result.Warnings = append(result.Warnings, fmt.Sprintf("跳过无效文件 %s: %v", file, err))
+				continue
+			}
+		}
+		if sm.skipDuplicate(result, hashes, file) {
 			continue
 		}
+		if err := sm.checkSignedInput(result, file); err != nil {
+			return sm.failResult(result, MergeStageValidation, startTime), err
+		}
+		result.ValidatedFiles = append(result.ValidatedFiles, file)
 	}
 
 	// 如果所有文件都无效，返回错误
-	validFiles := len(files) - len(result.SkippedFiles)
+	validFiles := len(result.ValidatedFiles)
 	if validFiles == 0 {
-		return nil, &PDFError{
+		return sm.failResult(result, MergeStageValidation, startTime), &PDFError{
 			Type:    ErrorInvalidInput,
 			Message: "没有有效的输入文件",
 		}
 	}
 
-	// 合并前备份输出文件
-	var backupPath string
-	var rollbackMgr *RollbackManager
-	if fileExists(outputPath) {
-		backupDir := filepath.Dir(outputPath)
-		rollbackMgr = NewRollbackManager(backupDir)
-		backupPath, _ = rollbackMgr.BackupFile(outputPath)
+	// 只合并通过验证的输入，跳过的无效文件和重复文件不参与合并
+	accepted := result.ValidatedFiles
+	decrypted, cleanupDecrypted, err := sm.decryptInputs(repairs.paths(accepted))
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+	defer cleanupDecrypted()
+	sm.countInputPages(result, accepted, decrypted)
+	if err := sm.checkInputBudget(result, decrypted); err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
 	}
 
-	// 使用pdfcpu适配器进行合并
-	var mergeErr error
-	if sm.adapter != nil {
-		mergeErr = sm.adapter.MergeFiles(files, outputPath)
-	} else {
-		mergeErr = sm.fallbackMerge(files, outputPath)
+	prepared, cleanup, err := sm.preparePageBoxes(decrypted, accepted)
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+	defer cleanup()
+	prepared, cleanupOrientation, err := sm.prepareOrientation(result, prepared, accepted)
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+	defer cleanupOrientation()
+
+	// 按后端链合并到临时文件，验证通过后才替换输出
+	staging := sm.stagingPath(outputPath)
+	defer discardStaging(staging)
+
+	mergeCtx, stopWatch := sm.watchOutputSize(context.Background(), staging)
+	mergeErr := sm.mergeWithBackends(mergeCtx, prepared, staging)
+	if err := stopWatch(); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
 	}
 	if mergeErr != nil {
-		if rollbackMgr != nil && backupPath != "" {
-			_ = rollbackMgr.RestoreFile(backupPath, outputPath)
-		}
-		return nil, mapPDFCPUError(mergeErr)
+		return sm.failResult(result, MergeStageMerging, startTime), mapPDFCPUError(mergeErr)
+	}
+	sm.mergeAttachments(result, staging, accepted, decrypted)
+	sm.mergeForms(result, staging, accepted, decrypted)
+	if sm.generateTOC {
+		sm.addTOC(result, staging, accepted, decrypted)
+	}
+	if sm.sourceBookmarks {
+		sm.addSourceBookmarks(result, staging, accepted, decrypted)
+	}
+	sm.applyMetadata(result, staging, decrypted)
+	if err := sm.stampOutput(result, staging, outputPath, accepted); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.imposeOutput(staging, outputPath); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	manifest, err := sm.prepareManifest(result, staging)
+	if err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	sm.optimizeOutput(result, staging, accepted, sm.reviewCopy || (options != nil && options.ReviewCopy))
+	if err := sm.encryptOutput(result, staging); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.linearizeOutput(staging); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.checkOutputSize(staging); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	if err := sm.verifyLinearized(result, staging); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	// 提交前在临时文件上检查，远程输出提交后本地没有输出文件
+	sm.checkConformanceKept(result, result.ValidatedFiles, staging)
+	if err := sm.recordManifestOutput(result, manifest, staging, outputPath); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	committed, err := sm.commitMergedOutput(result, staging, outputPath)
+	if err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
 	}
 
 	// 计算结果统计
 	result.ProcessedFiles = validFiles
-	result.ProcessingTime = time.Since(startTime)
+	result.ProcessingTime = sm.clock.Now().Sub(startTime)
 	result.MemoryUsage = sm.getCurrentMemoryUsage()
 
-	// 获取输出文件信息
-	if info, err := os.Stat(outputPath); err == nil {
-		// 估算页数（简单实现）
-		result.TotalPages = int(info.Size() / (1024 * 50)) // 假设每页约50KB
-		if result.TotalPages == 0 {
-			result.TotalPages = 1
-		}
+	if committed != "" {
+		sm.countOutputPages(result, committed)
+	}
+
+	if sm.reviewCopy || (options != nil && options.ReviewCopy) {
+		sm.produceReviewCopy(result)
+	}
+	// 审阅副本生成后再写清单旁路文件，清单中记录审阅副本路径
+	if err := sm.writeManifestSidecar(result, manifest, committed); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
 	}
+	sm.attachDelta(result, previous)
 
 	return result, nil
 }
 
-// MergeStreaming 执行流式合并，支持进度回调和取消
+// MergeStreaming 执行流式合并，支持进度回调和取消。
+// 只要开始了实际处理，失败时也会返回非nil的部分结果（FailedStage标明失败阶段），
+// 此时OutputPath可能不存在；仅检查错误的调用方不受影响。
 func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, outputPath string,
 	progressCallback func(progress float64, message string)) (*MergeResult, error) {
 
+	if err := sm.closer.enter("合并器", ""); err != nil {
+		return nil, err
+	}
+	defer sm.closer.leave()
+
+	// Close 时取消进行中的流式合并
+	ctx, cancel := sm.closer.context(ctx)
+	defer cancel()
+
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
-	startTime := time.Now()
+	// Cancel 时取消本次合并；返回时删除本次合并遗留的临时文件，包括被取消的分块仍在写入的文件
+	sm.setRunCancel(cancel)
+	defer sm.setRunCancel(nil)
+	defer sm.sweepTempFiles()
+
+	atomic.StoreInt64(&sm.totalChunks, 0)
+	atomic.StoreInt64(&sm.completedChunks, 0)
+
+	startTime := sm.clock.Now()
 	result := &MergeResult{
 		OutputPath:     outputPath,
 		SkippedFiles:   make([]string, 0),
@@ -263,6 +849,18 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 			Message: "没有提供输入文件",
 		}
 	}
+	if err := CheckOutputNotInput(outputPath, files); err != nil {
+		return nil, err
+	}
+	// 上次运行的清单旁路文件在提交本次输出时被替换，先读取
+	previous := sm.previousManifest(outputPath)
+
+	if err := sm.checkOutputModes(sm.reviewCopy); err != nil {
+		return nil, err
+	}
+	if err := sm.streamingConfig.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 新增：只读目录检测
 	dir := filepath.Dir(outputPath)
@@ -275,12 +873,16 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 		}
 	}
 
-	// 创建内存监控器
-	memoryMonitor := NewMemoryMonitor(sm.maxMemoryUsage)
+	// 创建内存监控器，返回前把读数写入结果（包括失败时的部分结果）
+	memoryMonitor := newMemoryMonitor(sm.maxMemoryUsage, sm.streamingConfig)
+	memoryMonitor.clock = sm.clock
+	memoryMonitor.readAlloc = sm.memoryReader
+	defer memoryMonitor.recordMemoryStats(result)
 
 	// 设置进度跟踪器
 	totalSteps := len(files) + 2 // 文件验证 + 合并 + 后处理
 	sm.progressTracker = progressmodel.NewProgressTracker(totalSteps)
+	sm.progressTracker.SetClock(sm.clock)
 
 	if progressCallback != nil {
 		sm.progressTracker.AddCallback(progressCallback)
@@ -289,11 +891,15 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 	// 第一步：验证所有输入文件
 	sm.progressTracker.SetCurrentStep(1, "验证输入文件")
 	validFiles := make([]string, 0, len(files))
+	repairs := make(inputRepairs)
+	defer sm.cleanupRepairs(repairs)
+	hashes := make(inputHashes)
 
 	for i, file := range files {
 		// 检查取消
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			result.ValidatedFiles = validFiles
+			return sm.failResult(result, MergeStageValidation, startTime), ctx.Err()
 		}
 
 		// 检查内存压力
@@ -304,28 +910,60 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 		progress := float64(i) / float64(len(files)) * 20 // 验证占20%
 		sm.progressTracker.UpdateStepProgress(progress, fmt.Sprintf("验证文件: %s", filepath.Base(file)))
 
-		if err := sm.validateInputFile(file); err != nil {
-			result.SkippedFiles = append(result.SkippedFiles, file)
+		if err := sm.validateInput(result, file); err != nil {
+			if isChecksumMismatch(err) || isEncryptionError(err) {
+				result.ValidatedFiles = validFiles
+				return sm.failResult(result, MergeStageValidation, startTime), err
+			}
+			if !sm.repairInput(result, repairs, file) {
+				result.skipInput(file, err.Error())
+				result.Warnings = append(result.Warnings, fmt.Sprintf("跳过无效文件 %s: %v", file, err))
+				continue
+			}
+		}
+		if sm.skipDuplicate(result, hashes, file) {
 			continue
 		}
+		if err := sm.checkSignedInput(result, file); err != nil {
+			result.ValidatedFiles = validFiles
+			return sm.failResult(result, MergeStageValidation, startTime), err
+		}
 		validFiles = append(validFiles, file)
 	}
+	result.ValidatedFiles = validFiles
 
 	if len(validFiles) == 0 {
-		return nil, &PDFError{
+		return sm.failResult(result, MergeStageValidation, startTime), &PDFError{
 			Type:    ErrorInvalidInput,
 			Message: "没有有效的输入文件",
 		}
 	}
 
-	// 合并前备份输出文件
-	var backupPath string
-	var rollbackMgr *RollbackManager
-	if fileExists(outputPath) {
-		backupDir := filepath.Dir(outputPath)
-		rollbackMgr = NewRollbackManager(backupDir)
-		backupPath, _ = rollbackMgr.BackupFile(outputPath)
+	// 预处理：已修复的输入换成修复副本，解密加密输入，再应用按输入指定的页面框调整、旋转和方向规范
+	decrypted, cleanupDecrypted, err := sm.decryptInputs(repairs.paths(validFiles))
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+	defer cleanupDecrypted()
+	sm.countInputPages(result, validFiles, decrypted)
+	if err := sm.checkInputBudget(result, decrypted); err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+
+	prepared, cleanup, err := sm.preparePageBoxes(decrypted, validFiles)
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
+	}
+	defer cleanup()
+	validFiles, cleanupOrientation, err := sm.prepareOrientation(result, prepared, validFiles)
+	if err != nil {
+		return sm.failResult(result, MergeStageValidation, startTime), err
 	}
+	defer cleanupOrientation()
+
+	// 合并结果先写入同目录的临时文件，验证通过后才替换输出，失败时原输出保持不变
+	staging := sm.stagingPath(outputPath)
+	defer discardStaging(staging)
 
 	// 第二步：执行智能合并策略选择
 	sm.progressTracker.SetCurrentStep(2, "合并PDF文件")
@@ -335,48 +973,108 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 	// 针对大文件进行优化
 	sm.optimizeForLargeFiles(validFiles)
 
+	// 后端按已合并的字节数推进本步骤进度，分块模式在内部重新划分进度区间
+	sm.trackMergeProgress(validFiles, 0, 100)
+	defer func() { sm.mergeProgress = nil }()
+
+	// 设置了输出大小上限时，临时输出超出上限即取消合并
+	mergeCtx, stopWatch := sm.watchOutputSize(ctx, staging)
+
 	// 根据文件特征选择合并策略
-	if sm.shouldUseConcurrentProcessing(validFiles) {
+	switch sm.selectStrategy(validFiles) {
+	case MergeStrategyConcurrent:
 		sm.progressTracker.UpdateStepProgress(0, "使用并发处理模式")
-		mergeErr = sm.processConcurrently(ctx, validFiles, outputPath)
-	} else if sm.shouldUseStreamingMode(validFiles) {
+		mergeErr = sm.processConcurrently(mergeCtx, validFiles, staging, memoryMonitor)
+	case MergeStrategyStreaming:
 		sm.progressTracker.UpdateStepProgress(0, "使用流式合并模式")
-		mergeErr = sm.performStreamingMergeWithChunking(ctx, validFiles, outputPath)
-	} else if sm.shouldUseMemoryOptimization(validFiles) {
+		mergeErr = sm.performStreamingMergeWithChunking(mergeCtx, validFiles, staging, memoryMonitor)
+	case MergeStrategyMemoryOptimized:
 		sm.progressTracker.UpdateStepProgress(0, "使用内存优化模式")
-		mergeErr = sm.performOptimizedMerge(ctx, validFiles, outputPath)
-	} else {
+		mergeErr = sm.performOptimizedMerge(mergeCtx, validFiles, staging, memoryMonitor)
+	default:
 		sm.progressTracker.UpdateStepProgress(0, "使用标准合并模式")
-		mergeErr = sm.performStreamingMerge(ctx, validFiles, outputPath)
+		mergeErr = sm.performStreamingMerge(mergeCtx, validFiles, staging, memoryMonitor)
+	}
+	if err := stopWatch(); err != nil {
+		mergeErr = err
 	}
 
+	if mergeErr == nil {
+		sm.mergeAttachments(result, staging, result.ValidatedFiles, decrypted)
+		sm.mergeForms(result, staging, result.ValidatedFiles, decrypted)
+	}
+	if mergeErr == nil && sm.generateTOC {
+		sm.addTOC(result, staging, result.ValidatedFiles, decrypted)
+	}
+	if mergeErr == nil && sm.sourceBookmarks {
+		sm.addSourceBookmarks(result, staging, result.ValidatedFiles, decrypted)
+	}
+	if mergeErr == nil {
+		sm.applyMetadata(result, staging, decrypted)
+	}
+	if mergeErr == nil {
+		mergeErr = sm.stampOutput(result, staging, outputPath, result.ValidatedFiles)
+	}
+	if mergeErr == nil {
+		mergeErr = sm.imposeOutput(staging, outputPath)
+	}
+	var manifest *AuditManifest
+	if mergeErr == nil {
+		manifest, mergeErr = sm.prepareManifest(result, staging)
+	}
+	if mergeErr == nil {
+		sm.optimizeOutput(result, staging, result.ValidatedFiles, sm.reviewCopy)
+	}
+	if mergeErr == nil {
+		mergeErr = sm.encryptOutput(result, staging)
+	}
+	if mergeErr == nil {
+		mergeErr = sm.linearizeOutput(staging)
+	}
+	if mergeErr == nil {
+		mergeErr = sm.checkOutputSize(staging)
+	}
 	if mergeErr != nil {
-		if rollbackMgr != nil && backupPath != "" {
-			_ = rollbackMgr.RestoreFile(backupPath, outputPath)
-		}
-		return nil, mergeErr
+		return sm.failResult(result, MergeStageMerging, startTime), mergeErr
 	}
 
 	// 第三步：后处理和验证
 	sm.progressTracker.SetCurrentStep(3, "验证输出文件")
+	result.ProcessedFiles = len(validFiles)
 
-	if err := sm.validateOutputFile(outputPath); err != nil {
-		if rollbackMgr != nil && backupPath != "" {
-			_ = rollbackMgr.RestoreFile(backupPath, outputPath)
-		}
-		return nil, err
+	if err := sm.validateOutputFile(staging); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	if err := sm.verifyLinearized(result, staging); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	// 提交前在临时文件上检查，远程输出提交后本地没有输出文件
+	sm.checkConformanceKept(result, result.ValidatedFiles, staging)
+	if err := sm.recordManifestOutput(result, manifest, staging, outputPath); err != nil {
+		return sm.failResult(result, MergeStageVerification, startTime), err
+	}
+	committed, err := sm.commitMergedOutput(result, staging, outputPath)
+	if err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
 	}
 
 	// 计算结果统计
-	result.ProcessedFiles = len(validFiles)
-	result.ProcessingTime = time.Since(startTime)
+	result.ProcessingTime = sm.clock.Now().Sub(startTime)
 	result.MemoryUsage = sm.getCurrentMemoryUsage()
+	sm.recordChunkStats(result)
 
-	// 获取输出文件信息
-	if info, err := os.Stat(outputPath); err == nil {
-		result.TotalPages = sm.estimatePageCount(info.Size())
+	if committed != "" {
+		sm.countOutputPages(result, committed)
 	}
 
+	if sm.reviewCopy {
+		sm.produceReviewCopy(result)
+	}
+	if err := sm.writeManifestSidecar(result, manifest, committed); err != nil {
+		return sm.failResult(result, MergeStageMerging, startTime), err
+	}
+	sm.attachDelta(result, previous)
+
 	// 最终内存清理
 	sm.optimizeMemoryUsage()
 
@@ -384,41 +1082,710 @@ func (sm *StreamingMerger) MergeStreaming(ctx context.Context, files []string, o
 	return result, nil
 }
 
-// MergeFilesLegacy 流式合并多个PDF文件（保留原有接口）
-func (sm *StreamingMerger) MergeFilesLegacy(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) (*MergeResult, error) {
-	// 将参数转换为新接口格式
-	allFiles := append([]string{mainFile}, additionalFiles...)
+// MergeFilesWithPageRanges 只合并每个输入的指定页面。先按范围把页面提取到临时副本，
+// 再以流式合并处理这些副本；未指定范围的输入合并全部页面。
+// 范围无效（页码为0、起始大于结束、超出文档页数）时在合并前返回包含文件和范围的PDFError。
+// MergeResult.InputPages 中的页数为每个输入实际提取的页数。
+func (sm *StreamingMerger) MergeFilesWithPageRanges(specs []FileRangeSpec, outputPath string,
+	progressCallback func(progress float64, message string)) (*MergeResult, error) {
 
-	// 创建进度回调函数
-	var progressCallback func(progress float64, message string)
-	if progressWriter != nil {
-		progressCallback = func(progress float64, message string) {
-			fmt.Fprintf(progressWriter, "进度: %.1f%% - %s\n", progress, message)
-		}
+	if err := sm.closer.enter("合并器", ""); err != nil {
+		return nil, err
 	}
+	defer sm.closer.leave()
 
-	// 使用新的流式合并方法
-	ctx := context.Background()
-	return sm.MergeStreaming(ctx, allFiles, outputPath, progressCallback)
-}
+	if len(specs) == 0 {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "没有提供输入文件",
+		}
+	}
+	inputs := make([]string, len(specs))
+	for i, spec := range specs {
+		inputs[i] = spec.File
+	}
+	if err := CheckOutputNotInput(outputPath, inputs); err != nil {
+		return nil, err
+	}
 
-// forceGC 强制垃圾回收
-func (sm *StreamingMerger) forceGC() {
-	runtime.GC()
-	runtime.GC() // 调用两次确保彻底清理
-}
+	workDir, err := os.MkdirTemp(sm.tempDir, "pdf-pages-")
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法创建页面提取目录",
+			File:    sm.tempDir,
+			Cause:   err,
+		}
+	}
+	defer os.RemoveAll(workDir)
 
-// getCurrentMemoryUsage 获取当前内存使用量
-func (sm *StreamingMerger) getCurrentMemoryUsage() int64 {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	return int64(m.Alloc)
-}
+	files := make([]string, len(specs))
+	origins := make(map[string]string, len(specs)) // 提取副本 -> 原始输入
+	var wholeRotations map[string]int              // 整文件输入在FileRangeSpec中指定的旋转
+	for i, spec := range specs {
+		if progressCallback != nil {
+			progressCallback(0, fmt.Sprintf("提取页面 (%d/%d): %s", i+1, len(specs), filepath.Base(spec.File)))
+		}
+		if err := validateRotation(spec.File, spec.Rotation); err != nil {
+			return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+		}
+
+		files[i] = spec.File
+		if len(spec.Ranges) == 0 {
+			// 整文件输入直接交给合并阶段验证和统计页数
+			if spec.Rotation != 0 {
+				if wholeRotations == nil {
+					wholeRotations = make(map[string]int)
+				}
+				wholeRotations[spec.File] = spec.Rotation
+			}
+			continue
+		}
+
+		// 每个输入使用单独的子目录，保留原文件名以便进度和结果中显示
+		inputDir := filepath.Join(workDir, strconv.Itoa(i))
+		source := spec.File
+		if sm.isEncryptedInput(spec.File) {
+			// 加密输入先解密再提取页面
+			if err := os.MkdirAll(inputDir, 0755); err != nil {
+				return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, &PDFError{
+					Type:    ErrorIO,
+					Message: "无法创建页面提取目录",
+					File:    inputDir,
+					Cause:   err,
+				}
+			}
+			source = filepath.Join(inputDir, "decrypted.pdf")
+			if err := sm.decryptInput(spec.File, source); err != nil {
+				return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+			}
+		}
+		pageCount, err := CountPagesInFile(source, nil)
+		if err != nil {
+			return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+		}
+		pages, err := spec.ResolvePages(pageCount)
+		if err != nil {
+			return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+		}
+
+		extracted := filepath.Join(inputDir, filepath.Base(spec.File))
+		if err := os.MkdirAll(filepath.Dir(extracted), 0755); err != nil {
+			return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, &PDFError{
+				Type:    ErrorIO,
+				Message: "无法创建页面提取目录",
+				File:    extracted,
+				Cause:   err,
+			}
+		}
+		if err := ExtractPages(source, extracted, pages); err != nil {
+			return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+		}
+		// 页面框调整和旋转按原始路径指定，提取后的副本不再经过 preparePageBoxes 和 prepareOrientation 匹配
+		if adjustment := sm.pageBoxes[spec.File]; !adjustment.IsZero() {
+			if err := SetPageBoxes(extracted, extracted, adjustment); err != nil {
+				return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+			}
+		}
+		if degrees := spec.rotation(sm.rotations); degrees != 0 {
+			if err := sm.rotateFile(extracted, extracted, degrees); err != nil {
+				return &MergeResult{OutputPath: outputPath, FailedStage: MergeStageValidation}, err
+			}
+		}
+		files[i] = extracted
+		origins[extracted] = spec.File
+	}
+
+	if len(wholeRotations) > 0 {
+		// 整文件输入的旋转在合并阶段按路径应用，FileRangeSpec中的值覆盖MergeOptions.Rotations
+		rotations := sm.rotations
+		merged := make(map[string]int, len(rotations)+len(wholeRotations))
+		for file, degrees := range rotations {
+			merged[file] = degrees
+		}
+		for file, degrees := range wholeRotations {
+			merged[file] = degrees
+		}
+		sm.rotations = merged
+		defer func() { sm.rotations = rotations }()
+	}
+
+	result, err := sm.MergeStreaming(context.Background(), files, outputPath, progressCallback)
+	if result == nil {
+		return nil, err
+	}
+
+	// 结果中显示原始输入路径，而不是已删除的提取副本
+	for _, list := range [][]string{result.ValidatedFiles, result.SkippedFiles, result.RepairedFiles} {
+		for i, file := range list {
+			if origin, ok := origins[file]; ok {
+				list[i] = origin
+			}
+		}
+	}
+	for i, input := range result.InputPages {
+		if origin, ok := origins[input.File]; ok {
+			result.InputPages[i].File = origin
+		}
+	}
+	return result, err
+}
+
+// preparePageBoxes 为指定了页面框调整的输入生成调整后的临时副本，返回替换后的输入列表。
+// originals 与files一一对应，是查找调整所用的原始输入路径（files可能已是解密副本）。
+// 返回的清理函数删除这些副本。
+func (sm *StreamingMerger) preparePageBoxes(files, originals []string) ([]string, func(), error) {
+	var temps []string
+	cleanup := func() { sm.cleanupTempFiles(temps) }
+	if len(sm.pageBoxes) == 0 {
+		return files, cleanup, nil
+	}
+
+	prepared := make([]string, len(files))
+	for i, file := range files {
+		prepared[i] = file
+		adjustment := sm.pageBoxes[originals[i]]
+		if adjustment.IsZero() {
+			continue
+		}
+		tempPath := sm.generateTempPath(file)
+		if err := SetPageBoxes(file, tempPath, adjustment); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		temps = append(temps, tempPath)
+		prepared[i] = tempPath
+	}
+	return prepared, cleanup, nil
+}
+
+// prepareOrientation 为指定了旋转的输入生成旋转后的临时副本，启用NormalizeOrientation时再把
+// /Rotate 写入副本的页面内容，返回替换后的输入列表。originals 与files一一对应，是查找旋转所用的原始输入路径。
+// 有注释而保持 /Rotate 的页面记录为警告。返回的清理函数删除这些副本。
+func (sm *StreamingMerger) prepareOrientation(result *MergeResult, files, originals []string) ([]string, func(), error) {
+	var temps []string
+	cleanup := func() { sm.cleanupTempFiles(temps) }
+	if len(sm.rotations) == 0 && !sm.normalize {
+		return files, cleanup, nil
+	}
+
+	prepared := make([]string, len(files))
+	for i, file := range files {
+		prepared[i] = file
+		degrees := sm.rotations[originals[i]]
+		if degrees == 0 && !sm.normalize {
+			continue
+		}
+		tempPath := sm.generateTempPath(file)
+		temps = append(temps, tempPath)
+		var err error
+		if degrees != 0 {
+			err = sm.rotateFile(file, tempPath, degrees)
+		} else {
+			err = copyFile(file, tempPath)
+		}
+		if err == nil && sm.normalize {
+			var kept []int
+			kept, err = sm.normalizeFile(tempPath)
+			for _, page := range kept {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("%s 第%d页有注释，保留 /Rotate 而不规范页面方向", filepath.Base(originals[i]), page))
+			}
+		}
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		prepared[i] = tempPath
+	}
+	return prepared, cleanup, nil
+}
+
+// rotateFile 使用适配器旋转页面，适配器不可用时使用内置实现
+func (sm *StreamingMerger) rotateFile(inputPath, outputPath string, degrees int) error {
+	if sm.adapter != nil {
+		return sm.adapter.RotateFile(inputPath, outputPath, degrees)
+	}
+	return RotatePages(inputPath, outputPath, degrees)
+}
+
+// normalizeFile 使用适配器在原处规范页面方向，适配器不可用时使用内置实现
+func (sm *StreamingMerger) normalizeFile(path string) ([]int, error) {
+	if sm.adapter != nil {
+		return sm.adapter.NormalizeOrientation(path, path)
+	}
+	return NormalizeOrientation(path, path)
+}
+
+// failResult 填充失败时已知的信息并返回部分结果
+func (sm *StreamingMerger) failResult(result *MergeResult, stage string, startTime time.Time) *MergeResult {
+	result.FailedStage = stage
+	result.ProcessingTime = sm.clock.Now().Sub(startTime)
+	result.MemoryUsage = sm.getCurrentMemoryUsage()
+	sm.recordChunkStats(result)
+	return result
+}
+
+// recordChunkStats 记录分块统计
+func (sm *StreamingMerger) recordChunkStats(result *MergeResult) {
+	result.TotalChunks = int(atomic.LoadInt64(&sm.totalChunks))
+	result.CompletedChunks = int(atomic.LoadInt64(&sm.completedChunks))
+}
+
+// produceReviewCopy 在主输出完成后生成审阅副本。失败只记录为警告，主输出不受影响。
+// 已归属到页面的警告不再作为一般警告重复放在摘要页中
+func (sm *StreamingMerger) produceReviewCopy(result *MergeResult) {
+	sm.placePageWarnings(result)
+	if detected, err := DetectPageWarnings(result.OutputPath); err == nil {
+		result.PageWarnings = append(result.PageWarnings, detected...)
+	}
+
+	warnings := append([]PageWarning(nil), result.PageWarnings...)
+	attributed := make(map[string]bool, len(result.PageWarnings))
+	for _, warning := range result.PageWarnings {
+		attributed[warning.Message] = true
+	}
+	for _, message := range result.Warnings {
+		if !attributed[message] {
+			warnings = append(warnings, PageWarning{Kind: WarningGeneral, Message: message})
+		}
+	}
+
+	reviewPath, err := WriteReviewCopy(result.OutputPath, warnings)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("生成审阅副本失败: %v", err))
+		return
+	}
+	result.ReviewCopyPath = reviewPath
+}
+
+// placePageWarnings 把合并过程中记录的页面警告换算为最终输出中的页码。记录时的页码按输入合并的顺序计，
+// 加上目录页数；只知道来源文件的警告（如修复的输入）归属到该输入在输出中的第一页。
+// 拼版后输出页与合并的页面不再一一对应，这些警告改为无法归属，放在审阅副本的摘要页中
+func (sm *StreamingMerger) placePageWarnings(result *MergeResult) {
+	starts := make(map[string]int, len(result.InputPages))
+	if len(result.InputPages) == len(result.ValidatedFiles) {
+		page := 1 + result.TOCPages
+		for _, input := range result.InputPages {
+			if _, seen := starts[input.File]; !seen {
+				starts[input.File] = page
+			}
+			page += input.Pages
+		}
+	}
+	for i := range result.PageWarnings {
+		warning := &result.PageWarnings[i]
+		switch {
+		case sm.imposition.Enabled():
+			warning.Page = 0
+		case warning.Page > 0:
+			warning.Page += result.TOCPages
+		default:
+			warning.Page = starts[warning.File]
+		}
+	}
+}
+
+// addSourceBookmarks 按各输入的页数为输出添加来源书签，书签顺序与合并顺序一致。
+// files 为原始输入路径，readable 与之一一对应，用于读取标题（加密输入为解密副本）。
+// 书签是辅助信息，无法添加时只记录警告。
+func (sm *StreamingMerger) addSourceBookmarks(result *MergeResult, outputPath string, files, readable []string) {
+	if len(result.InputPages) != len(files) {
+		result.Warnings = append(result.Warnings, "无法统计所有输入的页数，未添加来源书签")
+		return
+	}
+
+	sources := make([]SourceBookmark, len(files))
+	page := 1 + result.TOCPages
+	for i, input := range result.InputPages {
+		sources[i] = SourceBookmark{
+			Title: SourceBookmarkTitle(readable[i], input.File),
+			Page:  page,
+			Pages: input.Pages,
+		}
+		page += input.Pages
+	}
+	if err := AddSourceBookmarks(outputPath, outputPath, sources); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("添加来源书签失败: %v", err))
+	}
+}
+
+// applyMetadata 按MetadataSource设置输出的元数据，主文件为第一个输入的可读副本（readable[0]）。
+// 元数据不影响页面，失败时只记录警告
+func (sm *StreamingMerger) applyMetadata(result *MergeResult, outputPath string, readable []string) {
+	if sm.metadataSource == "" || sm.metadataSource == MetadataSourceNone || len(readable) == 0 {
+		return
+	}
+	if err := ApplyDocumentMetadata(outputPath, sm.metadataSource, readable[0], sm.customMetadata); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("设置输出元数据失败: %v", err))
+	}
+}
+
+// mergeAttachments 把各输入的附件合并到输出，启用DropAttachments时移除输出中的附件。
+// files 和 readable 的含义与 addSourceBookmarks 相同。附件不影响页面，失败时只记录警告
+func (sm *StreamingMerger) mergeAttachments(result *MergeResult, outputPath string, files, readable []string) {
+	if sm.dropAttachments {
+		if _, err := RemoveAttachments(outputPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("移除附件失败: %v", err))
+		}
+		return
+	}
+
+	report, err := MergeAttachments(outputPath, readable)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("合并附件失败: %v", err))
+		return
+	}
+	result.Attachments = report.Count
+
+	origins := make(map[string]string, len(readable))
+	for i, path := range readable {
+		if i < len(files) {
+			origins[path] = files[i]
+		}
+	}
+	for _, rename := range report.Renamed {
+		file := rename.File
+		if origin, ok := origins[file]; ok {
+			file = origin
+		}
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("输入 %s 的附件 %s 与之前的附件重名，已改名为 %s", file, rename.From, rename.To))
+	}
+}
+
+// mergeForms 合并各输入的表单字段，启用FlattenForms时把字段展平到页面内容。
+// files 和 readable 的含义与 addSourceBookmarks 相同；失败时只记录警告，输出仍可使用
+func (sm *StreamingMerger) mergeForms(result *MergeResult, outputPath string, files, readable []string) {
+	if sm.flattenForms {
+		report, err := FlattenForms(outputPath, outputPath)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("展平表单失败: %v", err))
+			return
+		}
+		for i, name := range report.WithoutAppearance {
+			message := fmt.Sprintf("表单字段 %s 没有外观，展平后不显示", name)
+			result.Warnings = append(result.Warnings, message)
+			result.PageWarnings = append(result.PageWarnings, PageWarning{
+				Page:    report.WithoutAppearancePages[i],
+				Kind:    WarningDroppedAnnotation,
+				Message: message,
+			})
+		}
+		return
+	}
+
+	// 页数未知的输入按0页计算，有表单时MergeForms会因页数不符而失败
+	sources := make([]InputPageCount, len(readable))
+	for i, path := range readable {
+		sources[i].File = path
+		if len(result.InputPages) == len(readable) {
+			sources[i].Pages = result.InputPages[i].Pages
+		}
+	}
+	report, err := MergeForms(outputPath, sources)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("合并表单失败: %v", err))
+		return
+	}
+	result.FormFields = report.Fields
+	for _, rename := range report.Renamed {
+		file := readable[rename.Source]
+		if rename.Source < len(files) {
+			file = files[rename.Source]
+		}
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("输入 %s 的表单字段 %s 与之前的字段重名，已改名为 %s", file, rename.From, rename.To))
+	}
+}
+
+// addTOC 按各输入的页数在输出开头插入目录页，条目顺序与合并顺序一致。
+// files 和 readable 的含义与 addSourceBookmarks 相同。目录是辅助信息，无法添加时只记录警告。
+func (sm *StreamingMerger) addTOC(result *MergeResult, outputPath string, files, readable []string) {
+	if len(result.InputPages) != len(files) {
+		result.Warnings = append(result.Warnings, "无法统计所有输入的页数，未添加目录页")
+		return
+	}
+
+	entries := make([]TOCEntry, len(files))
+	page := 1
+	for i, input := range result.InputPages {
+		entries[i] = TOCEntry{Title: SourceBookmarkTitle(readable[i], input.File), Page: page}
+		page += input.Pages
+	}
+	pages, err := AddTOCPages(outputPath, outputPath, entries)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("添加目录页失败: %v", err))
+		return
+	}
+	result.TOCPages = pages
+}
+
+// previousManifest 返回与本次合并比较的上次清单：优先使用MergeOptions.PreviousManifest，
+// 启用WriteManifest时读取输出旁上次写出的清单旁路文件；都没有或旁路文件无法解析时返回nil
+func (sm *StreamingMerger) previousManifest(outputPath string) *AuditManifest {
+	if sm.previous != nil {
+		return sm.previous
+	}
+	if !sm.writeManifest || !fileExists(outputPath+ManifestSuffix) {
+		return nil
+	}
+	manifest, err := ReadManifest(outputPath)
+	if err != nil {
+		sm.log.Warn("无法读取上次的合并清单，不与上次运行比较: %v", err)
+		return nil
+	}
+	return manifest
+}
+
+// attachDelta 与上次运行的清单比较并附加变化摘要。
+// 仅在有上次清单、生成了本次清单或记录了输入摘要时生成，没有上次记录时为"没有上次运行记录"。
+// 本次没有生成清单时按合并结果计算各输入的摘要
+func (sm *StreamingMerger) attachDelta(result *MergeResult, previous *AuditManifest) {
+	if previous == nil && result.Manifest == nil && !sm.integrity {
+		return
+	}
+	current := result.Manifest
+	if current == nil {
+		manifest, err := sm.newAuditManifest(result)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("无法与上次运行比较: %v", err))
+			return
+		}
+		manifest.Output = &ManifestOutput{Path: absPath(result.OutputPath), Pages: result.TotalPages}
+		if info, err := os.Stat(result.OutputPath); err == nil {
+			manifest.Output.Size = info.Size()
+		}
+		current = manifest
+	}
+	result.Delta = CompareManifests(previous, current)
+}
+
+// checkOutputModes 检查线性化是否与以增量更新方式写入的输出同时启用
+func (sm *StreamingMerger) checkOutputModes(reviewCopy bool) error {
+	options := &MergeOptions{
+		Linearize:         sm.linearize,
+		ReviewCopy:        reviewCopy,
+		Stamps:            sm.stamps,
+		OptimizeOutput:    sm.optimize,
+		OptimizeImagesDPI: sm.imageDPI,
+		OutputBackend:     sm.outputBackend,
+	}
+	if sm.encryption != nil {
+		options.OutputUserPassword = sm.encryption.userPassword
+		options.OutputOwnerPassword = sm.encryption.ownerPassword
+		options.OutputPermissions = sm.encryption.permissions
+	}
+	return options.Validate()
+}
+
+// encryptOutput 按输出加密设置加密输出。加密在书签之后、线性化之前进行，
+// 线性化支持AES-256加密的文件。
+func (sm *StreamingMerger) encryptOutput(result *MergeResult, outputPath string) error {
+	// 占位后端没有写出合并结果时没有可加密的内容，与commitOutput一致地保持原输出不变
+	if sm.encryption == nil || !fileExists(outputPath) {
+		return nil
+	}
+	if err := encryptInPlace(sm.files(), outputPath, sm.encryption); err != nil {
+		return err
+	}
+	result.Encrypted = true
+	return nil
+}
+
+// stampOutput 把印章添加到合并结果。files 为按顺序合并的输入，各输入的页数都已统计时
+// {filename} 为该页来源输入的文件名，否则（包括 files 为nil）为输出的文件名并记录警告。添加失败时合并失败，不写出缺少印章的输出。
+func (sm *StreamingMerger) stampOutput(result *MergeResult, staging, outputPath string, files []string) error {
+	if len(sm.stamps) == 0 || !fileExists(staging) {
+		return nil
+	}
+	sources := result.InputPages
+	if len(sources) == len(files) && result.TOCPages > 0 {
+		// 目录页的 {filename} 为输出的文件名
+		sources = append([]InputPageCount{{File: outputPath, Pages: result.TOCPages}}, sources...)
+	} else if len(sources) != len(files) {
+		sources = nil
+		for _, stamp := range sm.stamps {
+			if stamp.usesFilename() {
+				result.Warnings = append(result.Warnings, "无法确定各页的来源输入，印章中的 {filename} 使用输出文件名")
+				break
+			}
+		}
+		if pages, err := ReadPageCount(staging, nil); err == nil {
+			sources = []InputPageCount{{File: outputPath, Pages: pages}}
+		}
+	}
+
+	var err error
+	if sm.adapter != nil {
+		err = sm.adapter.StampFile(staging, staging, sm.stamps, sources)
+	} else {
+		err = stampPages(staging, staging, sm.stamps, sources)
+	}
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法为合并输出添加印章",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// imposeOutput 按拼版选项重新排列合并结果的页面。拼版失败时合并失败，不写出未拼版的输出
+func (sm *StreamingMerger) imposeOutput(staging, outputPath string) error {
+	if !sm.imposition.Enabled() || !fileExists(staging) {
+		return nil
+	}
+	var err error
+	if sm.adapter != nil {
+		_, err = sm.adapter.ImposeFile(staging, staging, sm.imposition)
+	} else {
+		_, err = ImposePages(staging, staging, sm.imposition)
+	}
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法为合并输出拼版",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// optimizeOutput 启用OptimizeOutput时优化合并结果。files 为合并的输入，其中任何一个包含数字签名时跳过；
+// reviewCopy 表示之后会以增量更新方式生成审阅副本。优化失败不影响合并，保留未优化的输出并记录警告
+func (sm *StreamingMerger) optimizeOutput(result *MergeResult, staging string, files []string, reviewCopy bool) {
+	if !sm.optimize || !fileExists(staging) {
+		return
+	}
+	for _, file := range files {
+		if signed, err := HasDigitalSignatures(file); err == nil && signed {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("输入 %s 包含数字签名，跳过输出优化", file))
+			return
+		}
+	}
+	if encrypted, err := hasEncryptEntry(staging); err == nil && encrypted {
+		result.Warnings = append(result.Warnings, "合并输出已加密，跳过输出优化")
+		return
+	}
+
+	// 线性化和审阅副本的增量更新都需要传统交叉引用表
+	options := &OptimizeOptions{ImageDPI: sm.imageDPI, ClassicXRef: sm.linearize || reviewCopy}
+	var report *OptimizeReport
+	var err error
+	if sm.adapter != nil {
+		report, err = sm.adapter.OptimizeOutput(staging, staging, options)
+	} else {
+		report, err = OptimizePDF(staging, staging, options)
+	}
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("无法优化合并输出: %v", err))
+		return
+	}
+	result.OriginalSize = report.OriginalSize
+	result.OptimizedSize = report.OptimizedSize
+}
+
+// linearizeOutput 线性化输出。它是合并后的最后一个写入步骤，之后不再修改输出。
+func (sm *StreamingMerger) linearizeOutput(outputPath string) error {
+	if !sm.linearize {
+		return nil
+	}
+	return LinearizeFile(outputPath, outputPath)
+}
+
+// verifyLinearized 在验证阶段确认输出确实已线性化
+func (sm *StreamingMerger) verifyLinearized(result *MergeResult, outputPath string) error {
+	if !sm.linearize {
+		return nil
+	}
+	linearized, err := IsLinearized(outputPath)
+	if err != nil {
+		return err
+	}
+	if !linearized {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "要求线性化的输出未线性化",
+			File:    outputPath,
+		}
+	}
+	result.Linearized = true
+	return nil
+}
+
+// chunkMergeFunc 把files合并到outputPath的函数
+type chunkMergeFunc func(ctx context.Context, files []string, outputPath string) error
+
+// mergeChunk 合并单个分块（或分批合并中待中间合并的临时文件）到临时文件
+func (sm *StreamingMerger) mergeChunk(ctx context.Context, files []string, outputPath string) error {
+	if sm.chunkMerger != nil {
+		return sm.chunkMerger(ctx, files, outputPath)
+	}
+	return sm.mergeWithBackends(ctx, files, outputPath)
+}
+
+// files 返回解密、加密和修复单个文件的后端
+func (sm *StreamingMerger) files() fileBackend {
+	if sm.fileBackend != nil {
+		return sm.fileBackend
+	}
+	return adapterFileBackend{adapter: sm.adapter}
+}
+
+// memoryAlloc 返回当前堆分配的字节数
+func (sm *StreamingMerger) memoryAlloc() int64 {
+	if sm.memoryReader != nil {
+		return sm.memoryReader()
+	}
+	return heapAlloc()
+}
+
+// MergeFilesLegacy 流式合并多个PDF文件（保留原有接口）
+func (sm *StreamingMerger) MergeFilesLegacy(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) (*MergeResult, error) {
+	// 将参数转换为新接口格式
+	allFiles := append([]string{mainFile}, additionalFiles...)
+
+	// 创建进度回调函数。合并器报告0-100的总进度，实现了ProgressReporter的写入器按比例接收
+	var progressCallback func(progress float64, message string)
+	if reporter, ok := progressWriter.(ProgressReporter); ok {
+		progressCallback = func(progress float64, message string) {
+			reporter.ReportProgress(progress/100, message)
+		}
+	} else if progressWriter != nil {
+		progressCallback = func(progress float64, message string) {
+			fmt.Fprintf(progressWriter, "进度: %.1f%% - %s\n", progress, message)
+		}
+	}
+
+	// 使用新的流式合并方法
+	ctx := context.Background()
+	return sm.MergeStreaming(ctx, allFiles, outputPath, progressCallback)
+}
+
+// forceGC 强制垃圾回收
+func (sm *StreamingMerger) forceGC() {
+	runtime.GC()
+	runtime.GC() // 调用两次确保彻底清理
+}
+
+// getCurrentMemoryUsage 获取当前内存使用量
+func (sm *StreamingMerger) getCurrentMemoryUsage() int64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.Alloc)
+}
 
 // validateInputFile 验证输入文件
 func (sm *StreamingMerger) validateInputFile(filePath string) error {
+	// 长路径和网络共享路径规范后再访问，错误中保留原始路径
+	path := progressmodel.NormalizePath(filePath)
+
 	// 检查文件是否存在
-	if _, err := os.Stat(filePath); err != nil {
+	if _, err := os.Stat(path); err != nil {
 		return &PDFError{
 			Type:    ErrorInvalidFile,
 			Message: "文件不存在",
@@ -429,11 +1796,53 @@ func (sm *StreamingMerger) validateInputFile(filePath string) error {
 
 	// 使用适配器验证文件
 	if sm.adapter != nil {
-		return sm.adapter.ValidateFile(filePath)
+		return sm.adapter.ValidateFile(path)
 	}
 
 	// 回退到基本验证
-	return sm.basicValidation(filePath)
+	return sm.basicValidation(path)
+}
+
+// validateInput 验证输入文件。完整性模式下读取一遍文件，同时完成头部检查和摘要计算，
+// 并将摘要记录到result中；校验和不一致时返回ErrorChecksumMismatch。
+// 加密输入未在MergeOptions.Passwords中提供密码、保险库中也没有时返回ErrorEncrypted。
+func (sm *StreamingMerger) validateInput(result *MergeResult, filePath string) error {
+	// 加密输入需要密码才能完整验证，这里只做基本检查，解密后的副本在 decryptInputs 中验证
+	encrypted := sm.isEncryptedInput(filePath)
+	if encrypted {
+		if err := sm.requirePassword(filePath); err != nil {
+			return err
+		}
+	}
+	if !sm.integrity {
+		if encrypted {
+			return sm.basicValidation(filePath)
+		}
+		return sm.validateInputFile(filePath)
+	}
+
+	if err := sm.basicValidation(filePath); err != nil {
+		return err
+	}
+	digest, err := verifyInputIntegrity(filePath, sm.expectedDigests)
+	if digest != nil {
+		result.InputDigests = append(result.InputDigests, *digest)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 外部CLI验证无法与摘要计算共用读取，仍单独执行
+	if !encrypted && sm.adapter != nil && sm.adapter.useCLI && sm.adapter.cliAdapter != nil {
+		return sm.adapter.cliAdapter.ValidateFile(filePath)
+	}
+	return nil
+}
+
+// isChecksumMismatch 判断错误是否为校验和不一致
+func isChecksumMismatch(err error) bool {
+	var pdfErr *PDFError
+	return errors.As(err, &pdfErr) && pdfErr.Type == ErrorChecksumMismatch
 }
 
 // validateOutputFile 验证输出文件
@@ -448,105 +1857,217 @@ func (sm *StreamingMerger) validateOutputFile(filePath string) error {
 		}
 	}
 
-	// 使用适配器验证文件
+	// 使用适配器验证文件，加密输出需要提供密码，否则会被误报为损坏
 	if sm.adapter != nil {
-		return sm.adapter.ValidateFile(filePath)
+		return sm.adapter.ValidateFileWithPassword(filePath, sm.encryption.validationPassword())
 	}
 
 	return nil
 }
 
 // performStreamingMerge 执行流式合并的核心逻辑
-func (sm *StreamingMerger) performStreamingMerge(ctx context.Context, files []string, outputPath string) error {
+func (sm *StreamingMerger) performStreamingMerge(ctx context.Context, files []string, outputPath string, monitor *MemoryMonitor) error {
 	// 检查是否应该使用内存优化模式
 	if sm.shouldUseMemoryOptimization(files) {
-		return sm.performOptimizedMerge(ctx, files, outputPath)
+		return sm.performOptimizedMerge(ctx, files, outputPath, monitor)
 	}
 
-	// 使用pdfcpu适配器进行标准合并
-	if sm.adapter != nil {
-		return sm.adapter.MergeFiles(files, outputPath)
+	// 标准合并
+	return sm.mergeWithBackends(ctx, files, outputPath)
+}
+
+// performStreamingMergeWithChunking 执行分块流式合并，按monitor报告的内存压力限制分块并发。
+// 任一分块失败时立即取消其余分块，返回所有失败分块的错误（见mergeChunksInOrder）
+func (sm *StreamingMerger) performStreamingMergeWithChunking(ctx context.Context, files []string, outputPath string, monitor *MemoryMonitor) error {
+	chunkSize := sm.calculateOptimalChunkSize(files)
+	if len(files) <= chunkSize {
+		return sm.performDirectMerge(ctx, files, outputPath)
+	}
+
+	// 分块合并按输入字节占0-90%，最终合并临时文件占其余部分
+	sm.trackMergeProgress(files, 0, 90)
+	tempFiles, err := sm.mergeChunksInOrder(ctx, splitChunks(files, chunkSize), outputPath, sm.streamingConfig.MaxConcurrentChunks, 0, monitor)
+	if err != nil {
+		return err
+	}
+	defer sm.cleanupTempFiles(tempFiles)
+
+	// 合并所有临时文件
+	sm.updateProgress(90, "合并最终结果")
+	sm.trackMergeProgress(tempFiles, 90, 100)
+	return sm.performDirectMerge(ctx, tempFiles, outputPath)
+}
+
+// splitChunks 按chunkSize把文件切分为连续的分块，保持输入顺序
+func splitChunks(files []string, chunkSize int) [][]string {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	chunks := make([][]string, 0, (len(files)+chunkSize-1)/chunkSize)
+	for i := 0; i < len(files); i += chunkSize {
+		end := i + chunkSize
+		if end > len(files) {
+			end = len(files)
+		}
+		chunks = append(chunks, files[i:end])
 	}
-
-	// 回退到基本合并
-	return sm.fallbackMerge(files, outputPath)
+	return chunks
 }
 
-// performStreamingMergeWithChunking 执行分块流式合并
-func (sm *StreamingMerger) performStreamingMergeWithChunking(ctx context.Context, files []string, outputPath string) error {
-	chunkSize := sm.calculateOptimalChunkSize(files)
-	if len(files) <= chunkSize {
-		return sm.performDirectMerge(ctx, files, outputPath)
-	}
-
-	tempFiles := make([]string, 0)
-	defer sm.cleanupTempFiles(tempFiles)
-
-	// 并发分块合并优化
-	maxConcurrent := sm.streamingConfig.MaxConcurrentChunks
+// mergeChunksInOrder 并发把每个分块合并到各自的临时文件，最多maxConcurrent个分块同时进行，
+// timeout大于0时限制单个分块的合并时间。返回的临时文件与chunks一一对应，保持输入顺序，
+// 与各分块完成的先后无关。任一分块失败时立即取消派生的上下文：尚未开始的分块不再开始，
+// 进行中的分块在下一个检查点放弃（ctx取消时不等待进行中的分块，见runChunk）；随后删除已生成的临时文件，并按分块顺序用errors.Join
+// 返回所有自身失败的分块的错误（因取消而放弃的分块不计入），使用户一次看到所有有问题的输入。
+// 每个分块开始前检查monitor的内存压力：警告时把并发上限减半，严重时等待进行中的分块结束、
+// 清理内存后逐个合并，压力恢复正常后恢复maxConcurrent。
+func (sm *StreamingMerger) mergeChunksInOrder(ctx context.Context, chunks [][]string, outputPath string, maxConcurrent int, timeout time.Duration, monitor *MemoryMonitor) ([]string, error) {
 	if maxConcurrent < 1 {
 		maxConcurrent = 1
 	}
-	sem := make(chan struct{}, maxConcurrent)
-	var wg sync.WaitGroup
-	var mergeErr atomic.Value
-
-	// 内存缓冲池（用于分块I/O）
-	_ = sync.Pool{
-		New: func() interface{} {
-			return make([]byte, 2*1024*1024) // 2MB缓冲区
-		},
+	tempFiles := make([]string, len(chunks))
+	for i := range chunks {
+		tempFiles[i] = sm.generateTempPath(outputPath)
 	}
 
-	for i := 0; i < len(files); i += chunkSize {
-		if ctx.Err() != nil {
-			return ctx.Err()
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	chunkErrs := make([]error, len(chunks)) // 各分块写入自己的位置，wg.Wait之后才读取
+
+	sem := make(chan struct{}, maxConcurrent)
+	limiter := &chunkLimiter{sem: sem}
+	atomic.StoreInt64(&sm.totalChunks, int64(len(chunks)))
+	for i, chunk := range chunks {
+		if err := sm.throttleChunks(chunkCtx, monitor, limiter); err != nil {
+			break
 		}
-		end := i + chunkSize
-		if end > len(files) {
-			end = len(files)
+		select {
+		case sem <- struct{}{}:
+		case <-chunkCtx.Done():
+		}
+		if chunkCtx.Err() != nil {
+			break
 		}
-		chunk := files[i:end]
-		tempFile := sm.generateTempPath(outputPath)
-		tempFiles = append(tempFiles, tempFile)
-
-		sem <- struct{}{}
 		wg.Add(1)
-		go func(idx, chunkIdx int, chunk []string, tempFile string) {
+		go func(index int, chunk []string) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			if mergeErr.Load() != nil {
+			if err := runChunk(ctx, chunkCtx, sm, chunk, tempFiles[index], timeout); err != nil {
+				if chunkCtx.Err() != nil && errors.Is(err, context.Canceled) {
+					// 其他分块失败或调用方取消后放弃的分块
+					return
+				}
+				sm.log.Info("分块 %d 合并失败: %v", index+1, err)
+				chunkErrs[index] = fmt.Errorf("分块 %d（%s）合并失败: %w", index+1, chunkFileNames(chunk), err)
+				cancel()
 				return
 			}
-			// 使用缓冲池进行I/O优化（如有自定义实现可在此处用bufPool）
-			err := sm.performDirectMerge(ctx, chunk, tempFile)
-			if err != nil {
-				mergeErr.Store(fmt.Errorf("分块 %d 合并失败: %v", chunkIdx+1, err))
-			}
+			atomic.AddInt64(&sm.completedChunks, 1)
 			// 内存优化
-			if (chunkIdx+1)%3 == 0 {
+			if (index+1)%3 == 0 {
 				sm.optimizeMemoryUsage()
 			}
-		}(i, i/chunkSize, chunk, tempFile)
-		// 更新进度
-		progress := float64(i)/float64(len(files))*80 + 10
-		sm.updateProgress(progress, fmt.Sprintf("处理分块 %d/%d", (i/chunkSize)+1, (len(files)+chunkSize-1)/chunkSize))
+		}(i, chunk)
 	}
 	wg.Wait()
-	if err := mergeErr.Load(); err != nil {
-		return err.(error)
+
+	err := errors.Join(chunkErrs...)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		sm.removeTempFiles(tempFiles)
+		return nil, err
+	}
+	return tempFiles, nil
+}
+
+// chunkFileNames 返回分块中各输入的文件名，用逗号分隔
+func chunkFileNames(chunk []string) string {
+	names := make([]string, len(chunk))
+	for i, file := range chunk {
+		names[i] = filepath.Base(file)
+	}
+	return strings.Join(names, ", ")
+}
+
+// throttleChunks 按当前内存压力调整分块并发上限，严重压力时先等待进行中的分块结束并清理内存
+func (sm *StreamingMerger) throttleChunks(ctx context.Context, monitor *MemoryMonitor, limiter *chunkLimiter) error {
+	capacity := cap(limiter.sem)
+	switch monitor.CheckMemoryPressure() {
+	case MemoryPressureWarning:
+		sm.log.Debug("内存压力警告，分块并发上限降为 %d", (capacity+1)/2)
+		return limiter.setLimit(ctx, (capacity+1)/2)
+	case MemoryPressureCritical:
+		sm.log.Info("内存压力严重，暂停分块合并并清理内存")
+		if err := limiter.setLimit(ctx, 0); err != nil {
+			return err
+		}
+		sm.handleMemoryPressure(MemoryPressureCritical)
+		return limiter.setLimit(ctx, 1)
+	default:
+		return limiter.setLimit(ctx, capacity)
+	}
+}
+
+// chunkLimiter 通过占用信号量的空闲槽位降低同时进行的分块数，不需要重建信号量
+type chunkLimiter struct {
+	sem      chan struct{}
+	reserved int // 调度方占用的槽位数
+}
+
+// setLimit 把并发上限调整为limit（0到信号量容量之间）。降低上限时等待进行中的分块释放槽位，
+// ctx取消时返回其错误
+func (l *chunkLimiter) setLimit(ctx context.Context, limit int) error {
+	want := cap(l.sem) - limit
+	for l.reserved > want {
+		<-l.sem
+		l.reserved--
+	}
+	for l.reserved < want {
+		select {
+		case l.sem <- struct{}{}:
+			l.reserved++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// runChunk 用chunkCtx合并单个分块，timeout大于0时超时即返回错误，ctx取消时返回ctx.Err()，都不再等待该分块。
+// 只取消chunkCtx（其他分块失败）时等待分块在下一个检查点自行返回，保留它在此之前遇到的错误。
+// 被放弃的分块登记为临时文件的写入者，退出后由sweepTempFiles删除它写出的文件
+func runChunk(ctx, chunkCtx context.Context, sm *StreamingMerger, chunk []string, tempFile string, timeout time.Duration) error {
+	if timeout <= 0 && ctx.Done() == nil {
+		return sm.mergeChunk(chunkCtx, chunk, tempFile)
+	}
+	done := make(chan error, 1)
+	sm.beginTempWrite()
+	go func() {
+		defer sm.endTempWrite()
+		done <- sm.mergeChunk(chunkCtx, chunk, tempFile)
+	}()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-expired:
+		return fmt.Errorf("处理超时（%v）", timeout)
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	// 合并所有临时文件
-	sm.updateProgress(90, "合并最终结果")
-	return sm.performDirectMerge(ctx, tempFiles, outputPath)
 }
 
 // performDirectMerge 执行直接合并
 func (sm *StreamingMerger) performDirectMerge(ctx context.Context, files []string, outputPath string) error {
-	if sm.adapter != nil {
-		return sm.adapter.MergeFiles(files, outputPath)
-	}
-	return sm.fallbackMerge(files, outputPath)
+	return sm.mergeWithBackends(ctx, files, outputPath)
 }
 
 // calculateOptimalChunkSize 计算最优分块大小
@@ -692,28 +2213,26 @@ func (sm *StreamingMerger) updateProgress(progress float64, message string) {
 }
 
 // performOptimizedMerge 执行内存优化的合并
-func (sm *StreamingMerger) performOptimizedMerge(ctx context.Context, files []string, outputPath string) error {
+func (sm *StreamingMerger) performOptimizedMerge(ctx context.Context, files []string, outputPath string, monitor *MemoryMonitor) error {
 	// 如果文件数量较多，分批处理
 	if len(files) > 10 {
-		return sm.performBatchMerge(ctx, files, outputPath)
+		return sm.performBatchMerge(ctx, files, outputPath, monitor)
 	}
 
 	// 直接合并
-	if sm.adapter != nil {
-		return sm.adapter.MergeFiles(files, outputPath)
-	}
-
-	return sm.fallbackMerge(files, outputPath)
+	return sm.mergeWithBackends(ctx, files, outputPath)
 }
 
-// performBatchMerge 执行分批合并 - 增强版本支持大文件处理
-func (sm *StreamingMerger) performBatchMerge(ctx context.Context, files []string, outputPath string) error {
+// performBatchMerge 执行分批合并 - 增强版本支持大文件处理。每个批次开始前检查monitor的内存压力
+func (sm *StreamingMerger) performBatchMerge(ctx context.Context, files []string, outputPath string, monitor *MemoryMonitor) error {
 	// 智能计算批次大小
 	batchSize := sm.calculateOptimalBatchSize(files)
 	tempFiles := make([]string, 0)
-	defer sm.cleanupTempFiles(tempFiles)
+	defer func() { sm.cleanupTempFiles(tempFiles) }()
 
-	sm.logger("开始分批合并，文件数: %d, 批次大小: %d", len(files), batchSize)
+	sm.log.Debug("开始分批合并，文件数: %d, 批次大小: %d", len(files), batchSize)
+	sm.trackMergeProgress(files, 0, 90)
+	atomic.StoreInt64(&sm.totalChunks, int64((len(files)+batchSize-1)/batchSize))
 
 	// 分批处理文件
 	for i := 0; i < len(files); i += batchSize {
@@ -731,39 +2250,30 @@ func (sm *StreamingMerger) performBatchMerge(ctx context.Context, files []string
 		batchNum := (i / batchSize) + 1
 		totalBatches := (len(files) + batchSize - 1) / batchSize
 
-		sm.logger("处理批次 %d/%d，文件数: %d", batchNum, totalBatches, len(batch))
+		sm.log.Debug("处理批次 %d/%d，文件数: %d", batchNum, totalBatches, len(batch))
 
-		// 检查内存压力并优化
-		if sm.shouldOptimizeMemoryForBatch(batch) {
-			sm.logger("检测到内存压力，执行优化")
+		// 检查内存压力并优化：严重时暂停并清理内存，警告时回收垃圾
+		if pressure := monitor.CheckMemoryPressure(); pressure != MemoryPressureNormal {
+			sm.log.Info("批次 %d 开始前检测到内存压力（级别 %d）", batchNum, pressure)
+			sm.handleMemoryPressure(pressure)
+		} else if sm.shouldOptimizeMemoryForBatch(batch) {
+			sm.log.Info("检测到内存压力，执行优化")
 			sm.optimizeMemoryUsage()
 		}
 
 		tempFile := sm.generateTempPath(outputPath)
 		tempFiles = append(tempFiles, tempFile)
 
-		// 更新进度
-		progress := float64(i)/float64(len(files))*70 + 20 // 合并占70%，从20%开始
-		sm.progressTracker.UpdateStepProgress(progress,
-			fmt.Sprintf("处理批次 %d/%d", batchNum, totalBatches))
-
 		// 合并当前批次
-		startTime := time.Now()
-		var err error
-
-		if sm.adapter != nil {
-			err = sm.adapter.MergeFiles(batch, tempFile)
-		} else {
-			err = sm.fallbackMerge(batch, tempFile)
-		}
-
-		if err != nil {
-			sm.logger("批次 %d 合并失败: %v", batchNum, err)
+		startTime := sm.clock.Now()
+		if err := sm.mergeChunk(ctx, batch, tempFile); err != nil {
+			sm.log.Info("批次 %d 合并失败: %v", batchNum, err)
 			return fmt.Errorf("批次 %d 合并失败: %w", batchNum, err)
 		}
+		atomic.AddInt64(&sm.completedChunks, 1)
 
-		processingTime := time.Since(startTime)
-		sm.logger("批次 %d 合并完成，耗时: %v", batchNum, processingTime)
+		processingTime := sm.clock.Now().Sub(startTime)
+		sm.log.Debug("批次 %d 合并完成，耗时: %v", batchNum, processingTime)
 
 		// 定期触发垃圾回收和内存优化
 		if batchNum%2 == 0 { // 每2个批次优化一次
@@ -772,25 +2282,21 @@ func (sm *StreamingMerger) performBatchMerge(ctx context.Context, files []string
 
 		// 检查临时文件大小，如果过大则进行中间合并
 		if len(tempFiles) >= 10 {
-			sm.logger("临时文件过多，执行中间合并")
-			if err := sm.performIntermediateMerge(ctx, tempFiles, outputPath); err != nil {
+			sm.log.Debug("临时文件过多，执行中间合并")
+			merged, err := sm.performIntermediateMerge(ctx, tempFiles, outputPath)
+			if err != nil {
 				return fmt.Errorf("中间合并失败: %w", err)
 			}
-			// 清理已合并的临时文件，保留最后一个
-			sm.cleanupTempFiles(tempFiles[:len(tempFiles)-1])
-			tempFiles = tempFiles[len(tempFiles)-1:]
+			tempFiles = merged
 		}
 	}
 
 	// 合并所有临时文件
-	sm.progressTracker.UpdateStepProgress(90, "合并最终结果")
-	sm.logger("开始最终合并，临时文件数: %d", len(tempFiles))
-
-	if sm.adapter != nil {
-		return sm.adapter.MergeFiles(tempFiles, outputPath)
-	}
+	sm.updateProgress(90, "合并最终结果")
+	sm.trackMergeProgress(tempFiles, 90, 100)
+	sm.log.Debug("开始最终合并，临时文件数: %d", len(tempFiles))
 
-	return sm.fallbackMerge(tempFiles, outputPath)
+	return sm.mergeWithBackends(ctx, tempFiles, outputPath)
 }
 
 // calculateOptimalBatchSize 计算最优批次大小
@@ -869,34 +2375,62 @@ func (sm *StreamingMerger) shouldOptimizeMemoryForBatch(batch []string) bool {
 	return false
 }
 
-// performIntermediateMerge 执行中间合并
-func (sm *StreamingMerger) performIntermediateMerge(ctx context.Context, tempFiles []string, outputPath string) error {
+// performIntermediateMerge 把临时文件合并为一个中间文件，返回替换后的临时文件列表（只含中间文件）。
+// 中间文件验证通过后才删除已合并的临时文件；失败时删除中间文件，原有临时文件保持不变。
+func (sm *StreamingMerger) performIntermediateMerge(ctx context.Context, tempFiles []string, outputPath string) ([]string, error) {
 	if len(tempFiles) <= 1 {
-		return nil
+		return tempFiles, nil
+	}
+	if ctx.Err() != nil {
+		return tempFiles, ctx.Err()
 	}
 
-	sm.logger("执行中间合并，文件数: %d", len(tempFiles))
+	sm.log.Debug("执行中间合并，文件数: %d", len(tempFiles))
 
 	// 创建中间合并文件
 	intermediateFile := sm.generateTempPath(outputPath)
 
-	// 合并临时文件
-	var err error
-	if sm.adapter != nil {
-		err = sm.adapter.MergeFiles(tempFiles, intermediateFile)
-	} else {
-		err = sm.fallbackMerge(tempFiles, intermediateFile)
-	}
+	// 合并临时文件。临时文件的内容已计入进度，中间合并不再报告
+	progress := sm.mergeProgress
+	sm.mergeProgress = nil
+	err := sm.mergeChunk(ctx, tempFiles, intermediateFile)
+	sm.mergeProgress = progress
 
+	if err == nil {
+		err = sm.validateInputFile(intermediateFile)
+	}
 	if err != nil {
-		return fmt.Errorf("中间合并失败: %w", err)
+		sm.removeTempFiles([]string{intermediateFile})
+		return tempFiles, err
 	}
 
-	// 用中间文件替换原有临时文件
-	tempFiles = []string{intermediateFile}
+	// 中间文件验证通过后才删除已合并的临时文件
+	sm.cleanupTempFiles(tempFiles)
 
-	sm.logger("中间合并完成")
-	return nil
+	sm.log.Debug("中间合并完成")
+	return []string{intermediateFile}, nil
+}
+
+// 合并策略名称，由 selectStrategy 根据文件特征选择
+const (
+	MergeStrategyConcurrent      = "concurrent"       // 并发处理分块
+	MergeStrategyStreaming       = "streaming"        // 分块流式合并
+	MergeStrategyMemoryOptimized = "memory_optimized" // 分批合并以降低内存占用
+	MergeStrategyStandard        = "standard"         // 一次性合并
+)
+
+// selectStrategy 按并发、流式、内存优化、标准的顺序选择第一个适用的合并策略
+func (sm *StreamingMerger) selectStrategy(files []string) string {
+	switch {
+	case sm.shouldUseConcurrentProcessing(files):
+		return MergeStrategyConcurrent
+	case sm.shouldUseStreamingMode(files):
+		return MergeStrategyStreaming
+	case sm.shouldUseMemoryOptimization(files):
+		return MergeStrategyMemoryOptimized
+	default:
+		return MergeStrategyStandard
+	}
 }
 
 // shouldUseMemoryOptimization 判断是否应该使用内存优化
@@ -965,8 +2499,8 @@ func (sm *StreamingMerger) shouldUseConcurrentProcessing(files []string) bool {
 	}
 
 	// 检查系统资源
-	if runtime.NumCPU() < 2 {
-		return false // 单核系统不使用并发
+	if runtime.NumCPU() < 2 || config.MaxConcurrentChunks < 2 {
+		return false // 单核系统或限制为单个分块时不使用并发
 	}
 
 	// 检查内存压力
@@ -1048,17 +2582,17 @@ func (sm *StreamingMerger) optimizeMemoryUsage() {
 	beforeGC := m.Alloc
 	beforeSys := m.Sys
 
-	sm.logger("开始内存优化，当前分配: %d MB, 系统内存: %d MB",
+	sm.log.Debug("开始内存优化，当前分配: %d MB, 系统内存: %d MB",
 		beforeGC/(1024*1024), beforeSys/(1024*1024))
 
 	// 第一阶段：标准垃圾回收
 	runtime.GC()
-	time.Sleep(10 * time.Millisecond)
+	sm.clock.Sleep(context.Background(), 10*time.Millisecond)
 
 	// 第二阶段：强制释放未使用的内存
 	runtime.GC()
 	debug.FreeOSMemory() // 释放操作系统内存
-	time.Sleep(50 * time.Millisecond)
+	sm.clock.Sleep(context.Background(), 50*time.Millisecond)
 
 	// 检查GC效果
 	runtime.ReadMemStats(&m)
@@ -1069,12 +2603,12 @@ func (sm *StreamingMerger) optimizeMemoryUsage() {
 	memoryReleased := beforeGC - afterGC
 	sysMemoryReleased := beforeSys - afterSys
 
-	sm.logger("内存优化完成，释放内存: %d MB, 系统内存释放: %d MB",
+	sm.log.Debug("内存优化完成，释放内存: %d MB, 系统内存释放: %d MB",
 		memoryReleased/(1024*1024), sysMemoryReleased/(1024*1024))
 
 	// 如果内存释放效果不佳，进行更激进的优化
 	if afterGC > beforeGC*75/100 {
-		sm.logger("内存释放效果不佳，进行激进优化")
+		sm.log.Info("内存释放效果不佳，进行激进优化")
 
 		// 设置更低的GC目标
 		originalGCPercent := debug.SetGCPercent(25) // 降低GC触发阈值到25%
@@ -1083,7 +2617,7 @@ func (sm *StreamingMerger) optimizeMemoryUsage() {
 		for i := 0; i < 3; i++ {
 			runtime.GC()
 			debug.FreeOSMemory()
-			time.Sleep(50 * time.Millisecond)
+			sm.clock.Sleep(context.Background(), 50*time.Millisecond)
 		}
 
 		// 恢复原始GC设置
@@ -1092,7 +2626,7 @@ func (sm *StreamingMerger) optimizeMemoryUsage() {
 		// 最终检查
 		runtime.ReadMemStats(&m)
 		finalAlloc := m.Alloc
-		sm.logger("激进优化后内存: %d MB", finalAlloc/(1024*1024))
+		sm.log.Debug("激进优化后内存: %d MB", finalAlloc/(1024*1024))
 	}
 
 	// 如果配置了渐进式GC，启用它
@@ -1101,78 +2635,155 @@ func (sm *StreamingMerger) optimizeMemoryUsage() {
 	}
 }
 
-// enableProgressiveGC 启用渐进式垃圾回收
+// enableProgressiveGC 启用渐进式垃圾回收。每个合并器只启动一个后台协程，
+// 协程在合并器关闭时退出；已关闭的合并器不再启动。
 func (sm *StreamingMerger) enableProgressiveGC() {
-	if sm.streamingConfig == nil {
+	if sm.streamingConfig == nil || sm.closer.isClosed() {
 		return
 	}
 
-	// 启动后台GC协程
-	go func() {
-		ticker := time.NewTicker(sm.streamingConfig.GCInterval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				// 检查内存压力
-				var m runtime.MemStats
-				runtime.ReadMemStats(&m)
-
-				currentMemory := int64(m.Alloc)
-				memoryPressure := float64(currentMemory) / float64(sm.maxMemoryUsage)
-
-				// 根据内存压力调整GC频率
-				if memoryPressure > sm.streamingConfig.MemoryCriticalThreshold {
-					runtime.GC()
-					debug.FreeOSMemory()
-				} else if memoryPressure > sm.streamingConfig.MemoryWarningThreshold {
-					runtime.GC()
-				}
+	sm.gcOnce.Do(func() {
+		ctx, cancel := sm.closer.context(context.Background())
+		sm.gcLoop.Add(1)
+		go func() {
+			defer sm.gcLoop.Done()
+			defer cancel()
+			sm.progressiveGC(ctx)
+		}()
+	})
+}
+
+// progressiveGC 按GCInterval检查内存压力并回收内存，直到ctx取消
+func (sm *StreamingMerger) progressiveGC(ctx context.Context) {
+	ticker := time.NewTicker(sm.streamingConfig.GCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 检查内存压力
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+
+			currentMemory := int64(m.Alloc)
+			memoryPressure := float64(currentMemory) / float64(sm.maxMemoryUsage)
+
+			// 根据内存压力调整GC频率
+			if memoryPressure > sm.streamingConfig.MemoryCriticalThreshold {
+				runtime.GC()
+				debug.FreeOSMemory()
+			} else if memoryPressure > sm.streamingConfig.MemoryWarningThreshold {
+				runtime.GC()
 			}
 		}
-	}()
+	}
+}
+
+// heapAlloc 返回运行时统计中当前堆分配的字节数
+func heapAlloc() int64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.Alloc)
 }
 
-// MemoryMonitor 内存监控器
+// MemoryMonitor 内存监控器，同时记录检查到的峰值内存和出现内存压力的次数。可并发使用，
+// nil监控器总是报告正常。
 type MemoryMonitor struct {
-	maxMemory     int64
-	warningLevel  int64
-	criticalLevel int64
-	lastCheck     time.Time
-	checkInterval time.Duration
+	mu             sync.Mutex
+	maxMemory      int64
+	warningLevel   int64
+	criticalLevel  int64
+	lastCheck      time.Time
+	lastLevel      MemoryPressureLevel
+	checkInterval  time.Duration
+	peakMemory     int64
+	pressureEvents int
+	clock          clock.Clock  // 检查间隔的时间来源
+	readAlloc      func() int64 // 读取当前堆分配字节数的函数，nil时读取运行时统计
 }
 
-// NewMemoryMonitor 创建内存监控器
+// NewMemoryMonitor 创建内存监控器，使用默认的警告（70%）和严重（85%）阈值
 func NewMemoryMonitor(maxMemory int64) *MemoryMonitor {
+	return newMemoryMonitor(maxMemory, DefaultStreamingConfig())
+}
+
+// newMemoryMonitor 按流式合并配置中的阈值创建内存监控器
+func newMemoryMonitor(maxMemory int64, config *StreamingConfig) *MemoryMonitor {
+	if config == nil {
+		config = DefaultStreamingConfig()
+	}
 	return &MemoryMonitor{
 		maxMemory:     maxMemory,
-		warningLevel:  maxMemory * 70 / 100, // 70%警告
-		criticalLevel: maxMemory * 85 / 100, // 85%严重
+		warningLevel:  int64(float64(maxMemory) * config.MemoryWarningThreshold),
+		criticalLevel: int64(float64(maxMemory) * config.MemoryCriticalThreshold),
 		checkInterval: 100 * time.Millisecond,
+		clock:         clock.System(),
 	}
 }
 
-// CheckMemoryPressure 检查内存压力
+// CheckMemoryPressure 检查内存压力。距上次检查不足检查间隔时不重新读取内存，返回上次的结果
 func (mm *MemoryMonitor) CheckMemoryPressure() MemoryPressureLevel {
-	now := time.Now()
-	if now.Sub(mm.lastCheck) < mm.checkInterval {
-		return MemoryPressureNormal // 避免频繁检查
+	if mm == nil {
+		return MemoryPressureNormal
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := mm.clock.Now()
+	if !mm.lastCheck.IsZero() && now.Sub(mm.lastCheck) < mm.checkInterval {
+		return mm.lastLevel // 避免频繁检查
 	}
 	mm.lastCheck = now
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+	readAlloc := mm.readAlloc
+	if readAlloc == nil {
+		readAlloc = heapAlloc
+	}
+	currentMemory := readAlloc()
+	if currentMemory > mm.peakMemory {
+		mm.peakMemory = currentMemory
+	}
 
-	currentMemory := int64(m.Alloc)
+	switch {
+	case currentMemory >= mm.criticalLevel:
+		mm.lastLevel = MemoryPressureCritical
+	case currentMemory >= mm.warningLevel:
+		mm.lastLevel = MemoryPressureWarning
+	default:
+		mm.lastLevel = MemoryPressureNormal
+	}
+	if mm.lastLevel != MemoryPressureNormal {
+		mm.pressureEvents++
+	}
+	return mm.lastLevel
+}
+
+// PeakMemory 返回检查过程中读到的最大堆分配字节数
+func (mm *MemoryMonitor) PeakMemory() int64 {
+	if mm == nil {
+		return 0
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.peakMemory
+}
 
-	if currentMemory >= mm.criticalLevel {
-		return MemoryPressureCritical
-	} else if currentMemory >= mm.warningLevel {
-		return MemoryPressureWarning
+// PressureEvents 返回检查结果为警告或严重的次数
+func (mm *MemoryMonitor) PressureEvents() int {
+	if mm == nil {
+		return 0
 	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.pressureEvents
+}
 
-	return MemoryPressureNormal
+// recordMemoryStats 把监控器的读数写入合并结果
+func (mm *MemoryMonitor) recordMemoryStats(result *MergeResult) {
+	result.PeakMemory = mm.PeakMemory()
+	result.MemoryPressureEvents = mm.PressureEvents()
 }
 
 // MemoryPressureLevel 内存压力级别
@@ -1196,19 +2807,105 @@ func (sm *StreamingMerger) handleMemoryPressure(level MemoryPressureLevel) {
 		sm.optimizeMemoryUsage()
 
 		// 如果仍然严重，暂停处理
-		var m runtime.MemStats
-		runtime.ReadMemStats(&m)
-		if int64(m.Alloc) > sm.maxMemoryUsage*80/100 {
-			time.Sleep(500 * time.Millisecond) // 暂停500ms
+		if sm.memoryAlloc() > sm.maxMemoryUsage*80/100 {
+			sm.clock.Sleep(context.Background(), 500*time.Millisecond) // 暂停500ms
 		}
 	}
 }
 
-// fallbackMerge 回退合并实现
-func (sm *StreamingMerger) fallbackMerge(files []string, outputPath string) error {
-	// 创建一个简单的占位符实现
-	// 在实际部署中，这里应该有一个可工作的PDF合并实现
+// availableBackends 返回静态顺序的可用后端
+func (sm *StreamingMerger) availableBackends() []string {
+	if sm.adapter != nil {
+		return []string{BackendPDFCPU, BackendFallback}
+	}
+	return []string{BackendFallback}
+}
+
+// backendChain 返回本次合并依次尝试的后端。静态模式只使用首选后端；
+// 自适应模式按统计排序，并保留其余后端作为回退。
+func (sm *StreamingMerger) backendChain(inputBytes int64) []string {
+	backends := sm.availableBackends()
+	if !sm.adaptive {
+		return backends[:1]
+	}
+	return sm.statsStore().Order(backends, inputBytes)
+}
+
+// statsStore 返回记录后端结果的统计存储
+func (sm *StreamingMerger) statsStore() *BackendStatsStore {
+	if sm.stats != nil {
+		return sm.stats
+	}
+	return DefaultBackendStatsStore()
+}
+
+// runBackend 使用指定后端合并，跟踪进度时在后端报告之外于成功后补齐本次调用的全部字节。
+// ctx取消时不等待后端结束，立即返回ctx.Err()，outputPath不会被写入。
+func (sm *StreamingMerger) runBackend(ctx context.Context, backend string, files []string, outputPath string) error {
+	progress := sm.mergeProgress.backendCallback()
+
+	var err error
+	if backend == BackendPDFCPU && sm.adapter != nil {
+		err = sm.adapter.MergeFilesContext(ctx, files, outputPath, progress)
+	} else {
+		err = runInterruptible(ctx, outputPath, func(tempPath string) error {
+			if tempPath != outputPath {
+				sm.trackTempFile(tempPath)
+			}
+			return sm.fallbackMerge(files, tempPath)
+		})
+	}
+	if err == nil && progress != nil {
+		total := totalInputBytes(files)
+		progress(total, total)
+	}
+	return err
+}
+
+// mergeWithBackends 按后端链合并并记录每个后端的结果，返回首个后端的错误。
+// ctx取消时返回ctx.Err()，不再尝试其余后端，也不记录被取消的结果。
+func (sm *StreamingMerger) mergeWithBackends(ctx context.Context, files []string, outputPath string) error {
+	inputBytes := totalInputBytes(files)
+	stats := sm.statsStore()
+
+	var firstErr error
+	for _, backend := range sm.backendChain(inputBytes) {
+		start := sm.clock.Now()
+		err := sm.runBackend(ctx, backend, files, outputPath)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		stats.Record(BackendOutcome{
+			Backend:    backend,
+			InputBytes: inputBytes,
+			Duration:   sm.clock.Now().Sub(start),
+			Err:        err,
+		})
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		sm.log.Info("后端 %s 合并失败: %v", backend, err)
+	}
+	return firstErr
+}
+
+// totalInputBytes 输入文件的总字节数，无法读取的文件忽略
+func totalInputBytes(files []string) int64 {
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
 
+// fallbackMerge 没有可用适配器时的回退合并：单个文件直接复制，多个文件使用内置合并。
+// 内置合并无法处理输入时返回 ErrorAdapterUnavailable，原因中包含创建适配器失败的错误。
+func (sm *StreamingMerger) fallbackMerge(files []string, outputPath string) error {
 	if len(files) == 0 {
 		return &PDFError{
 			Type:    ErrorInvalidInput,
@@ -1221,17 +2918,16 @@ func (sm *StreamingMerger) fallbackMerge(files []string, outputPath string) erro
 		return sm.copyFile(files[0], outputPath)
 	}
 
-	// 创建占位符合并结果
-	content := fmt.Sprintf("Fallback merge result\nFiles: %v\nOutput: %s\nTimestamp: %s\n",
-		files, outputPath, time.Now().Format(time.RFC3339))
-
-	return os.WriteFile(outputPath+".fallback", []byte(content), 0644)
+	if err := concatenateInputs(files, outputPath); err != nil {
+		return newAdapterUnavailableError(sm.adapterErr, err)
+	}
+	return nil
 }
 
 // basicValidation 基本文件验证
 func (sm *StreamingMerger) basicValidation(filePath string) error {
-	// 检查文件扩展名
-	if !strings.HasSuffix(strings.ToLower(filePath), ".pdf") {
+	// 检查文件类型：默认以文件头为准，RequirePDFExtension时要求 .pdf 扩展名
+	if !isAcceptedPDFPath(filePath, sm.requireExt) {
 		return &PDFError{
 			Type:    ErrorInvalidFile,
 			Message: "文件不是PDF格式",
@@ -1261,24 +2957,46 @@ func (sm *StreamingMerger) basicValidation(filePath string) error {
 	return nil
 }
 
-// estimatePageCount 估算页数
-func (sm *StreamingMerger) estimatePageCount(fileSize int64) int {
-	// 简单估算：假设每页约50KB
-	pages := int(fileSize / (1024 * 50))
-	if pages == 0 {
-		pages = 1
+// countPages 统计文件页数：CLI可用时使用pdfcpu的信息，否则或pdfcpu无法解析时使用内置读取器
+func (sm *StreamingMerger) countPages(filePath string) (int, error) {
+	if sm.adapter != nil && sm.adapter.useCLI && sm.adapter.cliAdapter != nil {
+		if info, err := sm.adapter.cliAdapter.GetFileInfo(filePath); err == nil && info.PageCount > 0 {
+			return info.PageCount, nil
+		}
+	}
+	return ReadPageCount(filePath, sm.config.PageTreeLimits)
+}
+
+// countInputPages 统计各有效输入的页数并记录到result中。
+// files 为原始输入路径，readable 与之一一对应，是实际读取的文件（加密输入为解密副本）。
+func (sm *StreamingMerger) countInputPages(result *MergeResult, files, readable []string) {
+	result.InputPages = result.InputPages[:0]
+	for i, file := range files {
+		pages, err := sm.countPages(readable[i])
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("无法统计 %s 的页数: %v", file, err))
+			continue
+		}
+		result.InputPages = append(result.InputPages, InputPageCount{File: file, Pages: pages})
 	}
-	return pages
 }
 
-// cleanupTempFiles 清理临时文件
-func (sm *StreamingMerger) cleanupTempFiles(tempFiles []string) {
-	for _, file := range tempFiles {
-		if err := os.Remove(file); err != nil {
-			// 记录错误但不中断程序
-			fmt.Printf("Warning: Failed to remove temp file %s: %v\n", file, err)
+// countOutputPages 设置 result.TotalPages：优先统计输出文件，失败时在所有输入都已统计的情况下
+// 使用输入页数之和，否则保持为0并记录警告
+func (sm *StreamingMerger) countOutputPages(result *MergeResult, outputPath string) {
+	if pages, err := sm.countPages(outputPath); err == nil {
+		result.TotalPages = pages
+		return
+	}
+	if len(result.InputPages) == result.ProcessedFiles {
+		total := 0
+		for _, input := range result.InputPages {
+			total += input.Pages
 		}
+		result.TotalPages = total
+		return
 	}
+	result.Warnings = append(result.Warnings, "无法统计输出文件的页数")
 }
 
 // copyFile 复制文件
@@ -1318,13 +3036,59 @@ func (sm *StreamingMerger) copyFile(src, dst string) error {
 	return nil
 }
 
-// generateTempPath 生成临时文件路径
+// tempPathRandomAttempts 随机后缀连续冲突多少次后改用进程ID与计数器生成后缀
+const tempPathRandomAttempts = 8
+
+// tempPathRegistry 进程内已分配且尚未清理的合并器临时路径，
+// 保证并发分配（包括多个合并器共用临时目录时）不会得到同一路径
+var tempPathRegistry = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// tempPathCounter 随机后缀不可用时生成后缀的计数器（原子访问）
+var tempPathCounter uint32
+
+// generateTempPath 分配一个唯一的临时文件路径并记录到合并器，文件名格式为
+// 名称_temp_时间戳_8位十六进制后缀.pdf（遗留文件扫描依赖此格式）。
+// 路径在进程内不会重复分配，也不会与磁盘上已有的文件重名。
 func (sm *StreamingMerger) generateTempPath(outputPath string) string {
 	fileName := filepath.Base(outputPath)
 	nameWithoutExt := strings.TrimSuffix(fileName, filepath.Ext(fileName))
-	timestamp := time.Now().Format("20060102_150405")
-	tempFileName := fmt.Sprintf("%s_temp_%s_%d.pdf", nameWithoutExt, timestamp, time.Now().UnixNano()%1000)
-	return filepath.Join(sm.tempDir, tempFileName)
+	timestamp := sm.clock.Now().Format("20060102_150405")
+	for attempt := 0; ; attempt++ {
+		suffix := randomSuffix(sm.clock)
+		if attempt >= tempPathRandomAttempts {
+			// 随机源反复给出相同的值时，进程ID与递增计数器保证最终得到新路径
+			suffix = fmt.Sprintf("%08x", uint32(os.Getpid())<<16^atomic.AddUint32(&tempPathCounter, 1))
+		}
+		path := filepath.Join(sm.tempDir, fmt.Sprintf("%s_temp_%s_%s.pdf", nameWithoutExt, timestamp, suffix))
+		if reserveTempPath(path) {
+			sm.trackTempFile(path)
+			return path
+		}
+	}
+}
+
+// reserveTempPath 路径未被分配且磁盘上不存在时占用它并返回true
+func reserveTempPath(path string) bool {
+	tempPathRegistry.Lock()
+	defer tempPathRegistry.Unlock()
+	if tempPathRegistry.paths[path] {
+		return false
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		return false
+	}
+	tempPathRegistry.paths[path] = true
+	return true
+}
+
+// releaseTempPath 释放已清理的临时路径
+func releaseTempPath(path string) {
+	tempPathRegistry.Lock()
+	delete(tempPathRegistry.paths, path)
+	tempPathRegistry.Unlock()
 }
 
 // fileExists 检查文件是否存在
@@ -1354,29 +3118,37 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-// logger 日志记录辅助方法
-func (sm *StreamingMerger) logger(format string, args ...interface{}) {
-	if sm.adapter != nil && sm.adapter.logger != nil {
-		sm.adapter.logger.Printf(format, args...)
-	} else {
-		fmt.Printf("[StreamingMerger] "+format+"\n", args...)
-	}
-}
-
 // GetProgressTracker 获取进度跟踪器
 func (sm *StreamingMerger) GetProgressTracker() *progressmodel.ProgressTracker {
 	return sm.progressTracker
 }
 
-// Cancel 取消合并操作
+// Cancel 取消进行中的流式合并并删除合并器登记的临时文件。
+// 仍在写入临时文件的分块协程退出后会再清理一次。
 func (sm *StreamingMerger) Cancel() {
+	sm.runMu.Lock()
+	cancel := sm.cancelRun
+	sm.runMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+
 	if sm.progressTracker != nil {
 		sm.progressTracker.Cancel("用户取消操作")
 	}
+	sm.sweepTempFiles()
 }
 
-// processConcurrently 并发处理多个文件
-func (sm *StreamingMerger) processConcurrently(ctx context.Context, files []string, outputPath string) error {
+// setRunCancel 设置Cancel时调用的取消函数，nil表示没有进行中的流式合并
+func (sm *StreamingMerger) setRunCancel(cancel context.CancelFunc) {
+	sm.runMu.Lock()
+	sm.cancelRun = cancel
+	sm.runMu.Unlock()
+}
+
+// processConcurrently 并发处理多个文件，按monitor报告的内存压力限制分块并发。
+// 任一分块失败时立即取消其余分块，返回所有失败分块的错误（见mergeChunksInOrder）
+func (sm *StreamingMerger) processConcurrently(ctx context.Context, files []string, outputPath string, monitor *MemoryMonitor) error {
 	config := sm.streamingConfig
 	if config == nil {
 		config = DefaultStreamingConfig()
@@ -1387,13 +3159,7 @@ func (sm *StreamingMerger) processConcurrently(ctx context.Context, files []stri
 		return sm.performDirectMerge(ctx, files, outputPath)
 	}
 
-	sm.logger("开始并发处理，文件数: %d, 最大并发数: %d", len(files), config.MaxConcurrentChunks)
-
-	// 创建工作池
-	semaphore := make(chan struct{}, config.MaxConcurrentChunks)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var processingErrors []error
+	sm.log.Debug("开始并发处理，文件数: %d, 最大并发数: %d", len(files), config.MaxConcurrentChunks)
 
 	// 分组处理文件
 	chunkSize := (len(files) + config.MaxConcurrentChunks - 1) / config.MaxConcurrentChunks
@@ -1401,106 +3167,20 @@ func (sm *StreamingMerger) processConcurrently(ctx context.Context, files []stri
 		chunkSize = 2
 	}
 
-	tempFiles := make([]string, 0)
-	defer sm.cleanupTempFiles(tempFiles)
-
-	// 并发处理每个分组
-	for i := 0; i < len(files); i += chunkSize {
-		end := i + chunkSize
-		if end > len(files) {
-			end = len(files)
-		}
-
-		chunk := files[i:end]
-		chunkIndex := i / chunkSize
-
-		wg.Add(1)
-		go func(chunk []string, index int) {
-			defer wg.Done()
-
-			// 获取信号量
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			// 检查取消
-			if ctx.Err() != nil {
-				mu.Lock()
-				processingErrors = append(processingErrors, ctx.Err())
-				mu.Unlock()
-				return
-			}
-
-			sm.logger("开始处理分组 %d，文件数: %d", index+1, len(chunk))
-
-			// 创建临时文件
-			tempFile := sm.generateTempPath(outputPath)
-
-			// 处理分组
-			startTime := time.Now()
-			var err error
-
-			// 添加超时控制
-			chunkCtx, cancel := context.WithTimeout(ctx, config.ChunkProcessTimeout)
-			defer cancel()
-
-			done := make(chan error, 1)
-			go func() {
-				if sm.adapter != nil {
-					done <- sm.adapter.MergeFiles(chunk, tempFile)
-				} else {
-					done <- sm.fallbackMerge(chunk, tempFile)
-				}
-			}()
-
-			select {
-			case err = <-done:
-				// 处理完成
-			case <-chunkCtx.Done():
-				err = fmt.Errorf("分组 %d 处理超时", index+1)
-			}
-
-			processingTime := time.Since(startTime)
-
-			if err != nil {
-				sm.logger("分组 %d 处理失败: %v", index+1, err)
-				mu.Lock()
-				processingErrors = append(processingErrors, fmt.Errorf("分组 %d 处理失败: %w", index+1, err))
-				mu.Unlock()
-				return
-			}
-
-			sm.logger("分组 %d 处理完成，耗时: %v", index+1, processingTime)
-
-			// 添加到临时文件列表
-			mu.Lock()
-			tempFiles = append(tempFiles, tempFile)
-			mu.Unlock()
-
-			// 更新进度
-			progress := float64(index+1)/float64((len(files)+chunkSize-1)/chunkSize)*80 + 10
-			sm.updateProgress(progress, fmt.Sprintf("完成分组 %d", index+1))
-
-		}(chunk, chunkIndex)
-	}
-
-	// 等待所有分组完成
-	wg.Wait()
-
-	// 检查处理错误
-	if len(processingErrors) > 0 {
-		return fmt.Errorf("并发处理失败: %v", processingErrors[0])
+	sm.trackMergeProgress(files, 0, 90)
+	tempFiles, err := sm.mergeChunksInOrder(ctx, splitChunks(files, chunkSize), outputPath, config.MaxConcurrentChunks, config.ChunkProcessTimeout, monitor)
+	if err != nil {
+		return fmt.Errorf("并发处理失败: %w", err)
 	}
+	defer sm.cleanupTempFiles(tempFiles)
 
-	sm.logger("所有分组处理完成，开始最终合并")
+	sm.log.Debug("所有分组处理完成，开始最终合并")
 
 	// 最终合并所有临时文件
 	sm.updateProgress(90, "合并最终结果")
+	sm.trackMergeProgress(tempFiles, 90, 100)
 
-	if sm.adapter != nil {
-		return sm.adapter.MergeFiles(tempFiles, outputPath)
-	}
-
-	return sm.fallbackMerge(tempFiles, outputPath)
+	return sm.mergeWithBackends(ctx, tempFiles, outputPath)
 }
 
 // configurePDFCPUForMinimalMemory 配置pdfcpu使用最小内存模式
@@ -1514,18 +3194,18 @@ func (sm *StreamingMerger) configurePDFCPUForMinimalMemory() {
 	sm.config.WriteXRefStream = true     // 启用交叉引用流
 	sm.config.ValidationMode = "relaxed" // 使用宽松验证模式减少内存使用
 
-	sm.logger("已配置pdfcpu最小内存模式")
+	sm.log.Debug("已配置pdfcpu最小内存模式")
 
-	// 如果有适配器，更新其配置
-	if sm.adapter != nil {
+	// 如果有适配器，更新其配置；池中的适配器由池的配置决定，不重新创建
+	if sm.adapter != nil && sm.adapterPool == nil {
 		// 重新创建适配器以应用新配置
 		if newAdapter, err := NewPDFCPUAdapter(sm.config); err == nil {
 			// 关闭旧适配器
 			sm.adapter.Close()
 			sm.adapter = newAdapter
-			sm.logger("已更新pdfcpu适配器配置")
+			sm.log.Debug("已更新pdfcpu适配器配置")
 		} else {
-			sm.logger("更新pdfcpu适配器配置失败: %v", err)
+			sm.log.Warn("更新pdfcpu适配器配置失败: %v", err)
 		}
 	}
 
@@ -1533,7 +3213,7 @@ func (sm *StreamingMerger) configurePDFCPUForMinimalMemory() {
 	debug.SetGCPercent(50)                  // 降低GC触发阈值
 	debug.SetMemoryLimit(sm.maxMemoryUsage) // 设置内存限制
 
-	sm.logger("已设置运行时内存优化参数")
+	sm.log.Debug("已设置运行时内存优化参数")
 }
 
 // optimizeForLargeFiles 针对大文件优化处理策略
@@ -1544,7 +3224,7 @@ func (sm *StreamingMerger) optimizeForLargeFiles(files []string) {
 		return
 	}
 
-	sm.logger("检测到大文件，启用大文件优化模式")
+	sm.log.Info("检测到大文件，启用大文件优化模式")
 
 	// 调整流式配置
 	if sm.streamingConfig == nil {
@@ -1565,25 +3245,37 @@ func (sm *StreamingMerger) optimizeForLargeFiles(files []string) {
 	// 配置pdfcpu最小内存模式
 	sm.configurePDFCPUForMinimalMemory()
 
-	sm.logger("大文件优化配置完成")
+	sm.log.Debug("大文件优化配置完成")
 }
 
-// Close 关闭合并器并清理资源
+// Close 关闭合并器并清理资源。进行中的 MergeStreaming 会被取消，MergeFiles
+// 没有上下文，Close 等待其完成；两者都返回后才关闭适配器。之后的合并返回
+// ErrClosed，重复调用返回首次关闭的结果。合并器分配但没有清理的临时文件在此删除。
 func (sm *StreamingMerger) Close() error {
-	sm.mutex.Lock()
-	defer sm.mutex.Unlock()
-
-	// 关闭pdfcpu适配器
-	if sm.adapter != nil {
-		if err := sm.adapter.Close(); err != nil {
-			return err
+	return sm.closer.close(func() error {
+		sm.mutex.Lock()
+		defer sm.mutex.Unlock()
+
+		// 开始关闭时渐进式GC协程的上下文已取消，等待其退出
+		sm.gcLoop.Wait()
+
+		// 删除合并中途退出时遗留的临时文件
+		sm.sweepTempFiles()
+
+		// 关闭pdfcpu适配器，取自适配器池的放回池中
+		if sm.adapter != nil && sm.adapterPool != nil {
+			sm.adapterPool.Put(sm.adapter, nil)
+		} else if sm.adapter != nil {
+			if err := sm.adapter.Close(); err != nil {
+				return err
+			}
 		}
-	}
 
-	// 取消进度跟踪器
-	if sm.progressTracker != nil {
-		sm.progressTracker.Cancel("合并器关闭")
-	}
+		// 取消进度跟踪器
+		if sm.progressTracker != nil {
+			sm.progressTracker.Cancel("合并器关闭")
+		}
 
-	return nil
+		return nil
+	})
 }
@@ -2,10 +2,19 @@ package pdf
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestNewStreamingMerger(t *testing.T) {
@@ -157,6 +166,112 @@ func TestMergeOptions_Validation(t *testing.T) {
 	}
 }
 
+func TestStreamingConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		modify  func(c *StreamingConfig)
+		wantErr string // 错误信息中应包含的内容，为空时期望有效
+	}{
+		{name: "默认配置", modify: func(c *StreamingConfig) {}},
+		{name: "固定分块大小", modify: func(c *StreamingConfig) { c.MinChunkSize, c.MaxChunkSize = 5, 5 }},
+		{name: "阈值为1", modify: func(c *StreamingConfig) { c.MemoryWarningThreshold, c.MemoryCriticalThreshold = 1, 1 }},
+		{name: "最小分块为0", modify: func(c *StreamingConfig) { c.MinChunkSize = 0 }, wantErr: "最小分块大小必须至少为1"},
+		{name: "最小分块大于最大分块", modify: func(c *StreamingConfig) { c.MinChunkSize, c.MaxChunkSize = 10, 4 }, wantErr: "最小分块大小 10 大于最大分块大小 4"},
+		{name: "并发数为0", modify: func(c *StreamingConfig) { c.MaxConcurrentChunks = 0 }, wantErr: "最大并发分块数"},
+		{name: "警告阈值为0", modify: func(c *StreamingConfig) { c.MemoryWarningThreshold = 0 }, wantErr: "内存警告阈值必须在0到1之间"},
+		{name: "严重阈值大于1", modify: func(c *StreamingConfig) { c.MemoryCriticalThreshold = 85 }, wantErr: "内存严重阈值必须在0到1之间"},
+		{name: "警告阈值高于严重阈值", modify: func(c *StreamingConfig) { c.MemoryWarningThreshold, c.MemoryCriticalThreshold = 0.9, 0.8 }, wantErr: "高于严重阈值"},
+		{name: "GC间隔为负", modify: func(c *StreamingConfig) { c.GCInterval = -time.Second }, wantErr: "GC间隔"},
+		{name: "分块超时为负", modify: func(c *StreamingConfig) { c.ChunkProcessTimeout = -time.Second }, wantErr: "分块处理超时"},
+		{name: "大文件阈值为负", modify: func(c *StreamingConfig) { c.LargeFileThreshold = -1 }, wantErr: "大文件阈值"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultStreamingConfig()
+			tc.modify(config)
+			err := config.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("期望配置有效，实际: %v", err)
+				}
+				return
+			}
+			var pdfErr *PDFError
+			if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorInvalidInput {
+				t.Fatalf("期望ErrorInvalidInput类型的错误，实际: %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("错误信息应包含 %q，实际: %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	var config *StreamingConfig
+	if err := config.Validate(); err != nil {
+		t.Errorf("nil配置应有效: %v", err)
+	}
+}
+
+func TestNewStreamingMerger_StreamingConfigOption(t *testing.T) {
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 3
+	config.MinChunkSize, config.MaxChunkSize = 4, 4
+	config.MemoryCriticalThreshold = 0.9
+
+	// 小内存时不再覆盖调用方指定的阈值
+	merger := NewStreamingMerger(&MergeOptions{MaxMemoryUsage: 10 * 1024 * 1024, StreamingConfig: config})
+	defer merger.Close()
+	if merger.streamingConfig == config {
+		t.Error("合并器应使用配置的副本")
+	}
+	if merger.streamingConfig.MaxConcurrentChunks != 3 || merger.streamingConfig.MinChunkSize != 4 {
+		t.Errorf("合并器未使用MergeOptions中的流式配置: %+v", merger.streamingConfig)
+	}
+	if merger.streamingConfig.MemoryCriticalThreshold != 0.9 {
+		t.Errorf("期望严重阈值0.9，实际 %v", merger.streamingConfig.MemoryCriticalThreshold)
+	}
+
+	// ConcurrentWorkers优先于MaxConcurrentChunks，且不修改调用方的配置
+	merger = NewStreamingMerger(&MergeOptions{ConcurrentWorkers: 6, StreamingConfig: config})
+	defer merger.Close()
+	if merger.streamingConfig.MaxConcurrentChunks != 6 {
+		t.Errorf("期望ConcurrentWorkers优先，实际并发数 %d", merger.streamingConfig.MaxConcurrentChunks)
+	}
+	if config.MaxConcurrentChunks != 3 {
+		t.Errorf("不应修改调用方的配置，实际并发数 %d", config.MaxConcurrentChunks)
+	}
+}
+
+func TestMergeFiles_InvalidStreamingConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{createTestPDFFile(t, tempDir, "a.pdf"), createTestPDFFile(t, tempDir, "b.pdf")}
+	config := DefaultStreamingConfig()
+	config.MinChunkSize, config.MaxChunkSize = 8, 2
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, StreamingConfig: config})
+	defer merger.Close()
+	output := filepath.Join(tempDir, "out.pdf")
+	for name, merge := range map[string]func() error{
+		"MergeFiles": func() error {
+			_, err := merger.MergeFiles(files, output, nil)
+			return err
+		},
+		"MergeStreaming": func() error {
+			_, err := merger.MergeStreaming(context.Background(), files, output, nil)
+			return err
+		},
+	} {
+		var pdfErr *PDFError
+		if err := merge(); !errors.As(err, &pdfErr) || pdfErr.Type != ErrorInvalidInput {
+			t.Errorf("%s: 期望ErrorInvalidInput，实际: %v", name, err)
+		}
+	}
+	if fileExists(output) {
+		t.Error("配置无效时不应写出输出")
+	}
+}
+
 func TestMergeResult_Structure(t *testing.T) {
 	// 测试合并结果结构
 	result := &MergeResult{
@@ -221,3 +336,743 @@ func TestStreamingMerger_ErrorHandling(t *testing.T) {
 		t.Logf("正确返回错误: %v", err)
 	}
 }
+
+func TestMergeStreaming_PartialResultOnValidationFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	merger := NewStreamingMerger(&MergeOptions{MaxMemoryUsage: 100 * 1024 * 1024, TempDirectory: tempDir})
+	defer merger.Close()
+
+	files := []string{
+		filepath.Join(tempDir, "missing1.pdf"),
+		filepath.Join(tempDir, "missing2.pdf"),
+	}
+	result, err := merger.MergeStreaming(context.Background(), files, filepath.Join(tempDir, "out.pdf"), nil)
+	if err == nil {
+		t.Fatal("期望验证失败")
+	}
+	if result == nil {
+		t.Fatal("失败时应返回部分结果")
+	}
+	if result.FailedStage != MergeStageValidation {
+		t.Errorf("期望失败阶段 %s，实际 %s", MergeStageValidation, result.FailedStage)
+	}
+	if len(result.SkippedFiles) != 2 || len(result.ValidatedFiles) != 0 {
+		t.Errorf("期望跳过2个文件、验证0个，实际跳过 %d、验证 %d", len(result.SkippedFiles), len(result.ValidatedFiles))
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("期望2条警告，实际 %d", len(result.Warnings))
+	}
+}
+
+func TestMergeStreaming_PartialResultOnChunkFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		files = append(files, createTestPDFFile(t, tempDir, fmt.Sprintf("file%d.pdf", i)))
+	}
+	badFile := files[len(files)-1]
+
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		for _, file := range chunk {
+			if file == badFile {
+				return fmt.Errorf("模拟分块失败")
+			}
+		}
+		return os.WriteFile(outputPath, []byte("chunk"), 0644)
+	}
+
+	// 固定分块大小为2，无论选择并发还是分块流式策略都会产生多个分块
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 4
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 2
+	config.MaxChunkSize = 2
+	// 测试文件内容相同，允许重复输入以便每个文件都参与分块
+	merger := NewStreamingMergerWithConfig(&MergeOptions{
+		MaxMemoryUsage:  100 * 1024 * 1024,
+		TempDirectory:   tempDir,
+		AllowDuplicates: true,
+	}, config)
+	defer merger.Close()
+	merger.chunkMerger = chunkMerger
+
+	result, err := merger.MergeStreaming(context.Background(), files, filepath.Join(tempDir, "out.pdf"), nil)
+	if err == nil {
+		t.Fatal("期望分块合并失败")
+	}
+	if result == nil {
+		t.Fatal("失败时应返回部分结果")
+	}
+	if result.FailedStage != MergeStageMerging {
+		t.Errorf("期望失败阶段 %s，实际 %s", MergeStageMerging, result.FailedStage)
+	}
+	if len(result.ValidatedFiles) != len(files) {
+		t.Errorf("期望 %d 个已验证文件，实际 %d", len(files), len(result.ValidatedFiles))
+	}
+	if result.TotalChunks < 2 {
+		t.Errorf("期望至少2个分块，实际 %d", result.TotalChunks)
+	}
+	if result.CompletedChunks >= result.TotalChunks {
+		t.Errorf("失败时已完成分块数 %d 应小于总数 %d", result.CompletedChunks, result.TotalChunks)
+	}
+}
+
+func TestMergeStreaming_CancelRemovesChunkOutputs(t *testing.T) {
+	inputDir := t.TempDir()
+	tempDir := t.TempDir()
+	files := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		files = append(files, createTestPDFFile(t, inputDir, fmt.Sprintf("file%d.pdf", i)))
+	}
+
+	// 分块先写出一部分，忽略取消继续写一段时间后才退出，模拟仍持有临时文件的后端
+	started := make(chan struct{})
+	var startOnce sync.Once
+	var running int64
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		if err := os.WriteFile(outputPath, []byte("%PDF-1.4 partial"), 0644); err != nil {
+			return err
+		}
+		startOnce.Do(func() { close(started) })
+		time.Sleep(300 * time.Millisecond)
+		f, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		f.WriteString(" more")
+		f.Close()
+		return ctx.Err()
+	}
+
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 4
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 2
+	config.MaxChunkSize = 2
+	merger := NewStreamingMergerWithConfig(&MergeOptions{
+		MaxMemoryUsage:  100 * 1024 * 1024,
+		TempDirectory:   tempDir,
+		AllowDuplicates: true,
+		BackendStats:    NewBackendStatsStore(),
+	}, config)
+	defer merger.Close()
+	merger.chunkMerger = chunkMerger
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := merger.MergeStreaming(context.Background(), files, filepath.Join(inputDir, "out.pdf"), nil)
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("分块合并没有开始")
+	}
+	merger.Cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("期望取消的合并返回错误")
+		}
+		if atomic.LoadInt64(&running) == 0 {
+			t.Error("取消后合并应直接返回，不等待仍在写入的分块")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("取消后合并应立即返回，不等待仍在写入的分块")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		// 适配器的工作目录随合并器关闭才删除，不属于合并遗留的文件
+		leftovers, err := filepath.Glob(filepath.Join(tempDir, "*.pdf"))
+		if err != nil {
+			t.Fatalf("读取临时目录失败: %v", err)
+		}
+		// 等被放弃的分块全部退出，确认它们在取消之后写出的文件也被删除
+		if len(leftovers) == 0 && atomic.LoadInt64(&running) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("取消后2秒内临时目录应为空，仍有 %d 个文件: %v", len(leftovers), leftovers)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(inputDir, "*_temp_*")); len(leftovers) > 0 {
+		t.Errorf("输出目录不应遗留临时输出: %v", leftovers)
+	}
+}
+
+func TestMergeStreaming_PartialResultOnVerificationFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{
+		createTestPDFFile(t, tempDir, "a.pdf"),
+		createTestPDFFile(t, tempDir, "b.pdf"),
+	}
+
+	// 加密后端写出了没有PDF头的文件，从而在验证阶段失败
+	merger := NewStreamingMerger(&MergeOptions{MaxMemoryUsage: 100 * 1024 * 1024, TempDirectory: tempDir,
+		OutputUserPassword: "secret", AllowDuplicates: true})
+	defer merger.Close()
+	merger.fileBackend = &fakeFileBackend{encrypt: func(inputFile, outputFile string, encryption *outputEncryption) error {
+		return os.WriteFile(outputFile, []byte("broken\ntrailer\n<< /Root 1 0 R /Encrypt 9 0 R >>\n"), 0644)
+	}}
+
+	outputPath := filepath.Join(tempDir, "out.pdf")
+	result, err := merger.MergeStreaming(context.Background(), files, outputPath, nil)
+	if err == nil {
+		t.Fatal("期望输出验证失败")
+	}
+	if result == nil {
+		t.Fatal("失败时应返回部分结果")
+	}
+	if result.FailedStage != MergeStageVerification {
+		t.Errorf("期望失败阶段 %s，实际 %s", MergeStageVerification, result.FailedStage)
+	}
+	if result.ProcessedFiles != 2 || len(result.ValidatedFiles) != 2 {
+		t.Errorf("期望处理2个文件，实际处理 %d、验证 %d", result.ProcessedFiles, len(result.ValidatedFiles))
+	}
+	if result.OutputPath != outputPath {
+		t.Errorf("期望输出路径 %s，实际 %s", outputPath, result.OutputPath)
+	}
+}
+
+func TestPartialMergeResult_FromServiceError(t *testing.T) {
+	tempDir := t.TempDir()
+	service := NewPDFService()
+
+	err := service.MergePDFs(filepath.Join(tempDir, "missing1.pdf"),
+		[]string{filepath.Join(tempDir, "missing2.pdf")}, filepath.Join(tempDir, "out.pdf"), nil)
+	if err == nil {
+		t.Fatal("期望合并失败")
+	}
+
+	partial := PartialMergeResult(fmt.Errorf("包装: %w", err))
+	if partial == nil {
+		t.Fatal("应能从错误链中提取部分结果")
+	}
+	if partial.FailedStage != MergeStageValidation || len(partial.SkippedFiles) != 2 {
+		t.Errorf("部分结果不符合预期: %+v", partial)
+	}
+	if PartialMergeResult(fmt.Errorf("普通错误")) != nil {
+		t.Error("普通错误不应包含部分结果")
+	}
+}
+
+func TestMergeStreaming_CountsRealPages(t *testing.T) {
+	dir := t.TempDir()
+	// 9页文件附带约600KB的内容流，按大小估算会得到十几页
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	var kids bytes.Buffer
+	for i := 0; i < 9; i++ {
+		fmt.Fprintf(&kids, "%d 0 R ", i+3)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count 9 >>", kids.String()))
+	for i := 0; i < 9; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 12 0 R >>")
+	}
+	image := bytes.Repeat([]byte("0"), 600*1024)
+	objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(image), image))
+	input := createTestFile(t, dir, "scan.pdf", buildPDF(objects))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeStreaming(context.Background(), []string{input}, output, nil)
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+
+	if result.TotalPages != 9 {
+		t.Errorf("期望总页数为 9，实际为 %d", result.TotalPages)
+	}
+	if len(result.InputPages) != 1 || result.InputPages[0] != (InputPageCount{File: input, Pages: 9}) {
+		t.Errorf("各输入页数不正确: %+v", result.InputPages)
+	}
+}
+
+func TestPerformStreamingMergeWithChunking_UniqueTempPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	const chunks = 64
+	files := make([]string, 0, chunks)
+	for i := 0; i < chunks; i++ {
+		files = append(files, filepath.Join(tempDir, fmt.Sprintf("in%d.pdf", i)))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		mu.Lock()
+		seen[outputPath]++
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		return os.WriteFile(outputPath, []byte("chunk"), 0644)
+	}
+
+	// 分块大小固定为1，64个输入产生64个并发分块
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 16
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 1
+	config.MaxChunkSize = 1
+	merger := NewStreamingMergerWithConfig(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore()}, config)
+	defer merger.Close()
+	merger.adapter = nil
+	merger.chunkMerger = chunkMerger
+
+	// 最终合并的结果与本测试无关，只检查分块的临时路径
+	_ = merger.performStreamingMergeWithChunking(context.Background(), files, filepath.Join(tempDir, "out.pdf"), nil)
+
+	if len(seen) != chunks {
+		t.Errorf("期望 %d 个不同的临时路径，实际 %d", chunks, len(seen))
+	}
+	for path, count := range seen {
+		if count != 1 {
+			t.Errorf("临时路径 %s 被使用了 %d 次", path, count)
+		}
+	}
+	leftovers, err := filepath.Glob(filepath.Join(tempDir, "*_temp_*.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("合并结束后不应遗留临时文件: %v", leftovers)
+	}
+}
+
+func TestStreamingMerger_CloseRemovesTrackedTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir})
+
+	leftover := merger.generateTempPath(filepath.Join(tempDir, "out.pdf"))
+	if err := os.WriteFile(leftover, []byte("chunk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cleaned := merger.generateTempPath(filepath.Join(tempDir, "out.pdf"))
+	if err := os.WriteFile(cleaned, []byte("chunk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	merger.cleanupTempFiles([]string{cleaned})
+
+	if err := merger.Close(); err != nil {
+		t.Fatalf("关闭合并器失败: %v", err)
+	}
+	if fileExists(leftover) {
+		t.Error("Close 应删除未清理的临时文件")
+	}
+	if len(merger.tempFiles) != 0 {
+		t.Errorf("Close 后不应再记录临时文件: %v", merger.tempFiles)
+	}
+}
+
+func TestConcurrentChunkMerge_PreservesInputOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	const inputs = 20
+	files := make([]string, 0, inputs)
+	for i := 0; i < inputs; i++ {
+		// 每个输入只有一页，页面宽度各不相同
+		files = append(files, createTestFile(t, tempDir, fmt.Sprintf("page%02d.pdf", i), buildPDF([]string{
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d 792] >>", 100+i),
+		})))
+	}
+
+	// 越靠前的分块完成得越晚，打乱完成顺序
+	delayedChunks := func(sm *StreamingMerger) chunkMergeFunc {
+		return func(ctx context.Context, chunk []string, outputPath string) error {
+			for i, file := range files {
+				if file == chunk[0] {
+					time.Sleep(time.Duration(inputs-i) * 100 * time.Microsecond)
+				}
+			}
+			return sm.mergeWithBackends(ctx, chunk, outputPath)
+		}
+	}
+
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 8
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 2
+	config.MaxChunkSize = 2
+
+	strategies := map[string]func(sm *StreamingMerger, output string) error{
+		"chunking": func(sm *StreamingMerger, output string) error {
+			return sm.performStreamingMergeWithChunking(context.Background(), files, output, nil)
+		},
+		"concurrent": func(sm *StreamingMerger, output string) error {
+			return sm.processConcurrently(context.Background(), files, output, nil)
+		},
+	}
+	for name, merge := range strategies {
+		t.Run(name, func(t *testing.T) {
+			for run := 0; run < 50; run++ {
+				// 虚拟时钟跳过分块之间内存优化的等待
+				merger := NewStreamingMergerWithConfig(&MergeOptions{
+					TempDirectory: tempDir,
+					BackendStats:  NewBackendStatsStore(),
+					Clock:         clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), uint64(run)),
+				}, config)
+				merger.adapter = nil
+				merger.chunkMerger = delayedChunks(merger)
+				output := filepath.Join(tempDir, fmt.Sprintf("%s%d.pdf", name, run))
+				if err := merge(merger, output); err != nil {
+					t.Fatalf("第 %d 次合并失败: %v", run+1, err)
+				}
+				merger.Close()
+
+				boxes, err := ReadPageBoxes(output)
+				if err != nil {
+					t.Fatalf("读取合并结果失败: %v", err)
+				}
+				if len(boxes) != inputs {
+					t.Fatalf("第 %d 次合并期望 %d 页，实际 %d", run+1, inputs, len(boxes))
+				}
+				for i, box := range boxes {
+					if box.Width() != float64(100+i) {
+						t.Fatalf("第 %d 次合并的第 %d 页来自错误的输入（宽度 %v）", run+1, i+1, box.Width())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentChunkMerge_FailureSkipsFinalMerge(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		files = append(files, createTestPDFFile(t, tempDir, fmt.Sprintf("file%d.pdf", i)))
+	}
+
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		if chunk[0] == files[0] {
+			return fmt.Errorf("模拟分块失败")
+		}
+		return os.WriteFile(outputPath, []byte("chunk"), 0644)
+	}
+
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 1
+	merger := NewStreamingMergerWithConfig(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore()}, config)
+	defer merger.Close()
+	merger.adapter = nil
+	merger.chunkMerger = chunkMerger
+
+	output := filepath.Join(tempDir, "out.pdf")
+	if err := merger.processConcurrently(context.Background(), files, output, nil); err == nil {
+		t.Fatal("期望分块失败时返回错误")
+	}
+	if fileExists(output) || fileExists(output+".fallback") {
+		t.Error("有分块失败时不应执行最终合并")
+	}
+	if completed := atomic.LoadInt64(&merger.completedChunks); completed != 0 {
+		t.Errorf("第一个分块失败后不应再合并其余分块，实际完成 %d 个", completed)
+	}
+	leftovers, err := filepath.Glob(filepath.Join(tempDir, "*_temp_*.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("分块失败后不应遗留临时文件: %v", leftovers)
+	}
+}
+
+func TestChunkMerge_EarlyFailureCancelsRunningChunks(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		files = append(files, createTestPDFFile(t, tempDir, fmt.Sprintf("file%d.pdf", i)))
+	}
+	badFile := files[2]
+
+	// 正常分块每10ms检查一次取消，完整合并一个分块需要2秒；含badFile的分块50ms后失败
+	const chunkDuration = 2 * time.Second
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		for _, file := range chunk {
+			if file == badFile {
+				time.Sleep(50 * time.Millisecond)
+				return fmt.Errorf("模拟损坏的输入")
+			}
+		}
+		for waited := time.Duration(0); waited < chunkDuration; waited += 10 * time.Millisecond {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return os.WriteFile(outputPath, []byte("chunk"), 0644)
+	}
+
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 2
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 2
+	config.MaxChunkSize = 2
+
+	strategies := map[string]func(sm *StreamingMerger, output string) error{
+		"chunking": func(sm *StreamingMerger, output string) error {
+			return sm.performStreamingMergeWithChunking(context.Background(), files, output, nil)
+		},
+		"concurrent": func(sm *StreamingMerger, output string) error {
+			return sm.processConcurrently(context.Background(), files, output, nil)
+		},
+	}
+	for name, merge := range strategies {
+		t.Run(name, func(t *testing.T) {
+			merger := NewStreamingMergerWithConfig(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore()}, config)
+			defer merger.Close()
+			merger.adapter = nil
+			merger.chunkMerger = chunkMerger
+
+			start := time.Now()
+			err := merge(merger, filepath.Join(tempDir, name+".pdf"))
+			elapsed := time.Since(start)
+			if err == nil {
+				t.Fatal("期望分块失败时返回错误")
+			}
+			if !strings.Contains(err.Error(), "file2.pdf") {
+				t.Errorf("错误应指出失败分块中的文件: %v", err)
+			}
+			if errors.Is(err, context.Canceled) {
+				t.Errorf("被取消的分块不应计入错误: %v", err)
+			}
+			// 不取消时至少要等一个完整分块（2秒）
+			if elapsed > chunkDuration/2 {
+				t.Errorf("分块失败后应尽快返回，实际耗时 %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestChunkMerge_JoinsAllChunkErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		files = append(files, createTestPDFFile(t, tempDir, fmt.Sprintf("file%d.pdf", i)))
+	}
+
+	// 前两个分块都失败：第二个分块先失败，第一个分块在其错误记录之后才失败，结果仍按分块顺序列出
+	secondFailed := make(chan struct{})
+	chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+		switch chunk[0] {
+		case files[0]:
+			<-secondFailed
+			time.Sleep(50 * time.Millisecond)
+			return fmt.Errorf("模拟损坏的输入 %s", filepath.Base(chunk[0]))
+		case files[2]:
+			defer close(secondFailed)
+			return fmt.Errorf("模拟损坏的输入 %s", filepath.Base(chunk[0]))
+		}
+		return os.WriteFile(outputPath, []byte("chunk"), 0644)
+	}
+
+	config := DefaultStreamingConfig()
+	config.MaxConcurrentChunks = 2
+	config.EnableAdaptiveChunking = false
+	config.MinChunkSize = 2
+	config.MaxChunkSize = 2
+	merger := NewStreamingMergerWithConfig(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore()}, config)
+	defer merger.Close()
+	merger.adapter = nil
+	merger.chunkMerger = chunkMerger
+
+	err := merger.performStreamingMergeWithChunking(context.Background(), files, filepath.Join(tempDir, "out.pdf"), nil)
+	if err == nil {
+		t.Fatal("期望分块失败时返回错误")
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("期望合并两个分块的错误，实际: %v", err)
+	}
+	message := err.Error()
+	first, second := strings.Index(message, "file0.pdf"), strings.Index(message, "file2.pdf")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("错误应按分块顺序列出所有失败的输入: %v", message)
+	}
+}
+
+func TestPerformBatchMerge_IntermediateMergeKeepsAllPages(t *testing.T) {
+	tempDir := t.TempDir()
+	files := make([]string, 0, 40)
+	wantPages := 0
+	for i := 0; i < 40; i++ {
+		pages := i%3 + 1
+		wantPages += pages
+		files = append(files, createTestFile(t, tempDir, fmt.Sprintf("in%02d.pdf", i), buildFlatPDF(pages)))
+	}
+
+	// 任何输入都超过大文件阈值，批次大小固定为3，14个批次会触发中间合并
+	config := DefaultStreamingConfig()
+	config.LargeFileThreshold = 1
+	merger := NewStreamingMergerWithConfig(&MergeOptions{
+		MaxMemoryUsage: 1024 * 1024 * 1024,
+		TempDirectory:  tempDir,
+		BackendStats:   NewBackendStatsStore(),
+		Clock:          clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1),
+	}, config)
+	defer merger.Close()
+	merger.adapter = nil
+	if size := merger.calculateOptimalBatchSize(files); size != 3 {
+		t.Fatalf("期望批次大小为 3，实际 %d", size)
+	}
+
+	output := filepath.Join(tempDir, "out.pdf")
+	if err := merger.performBatchMerge(context.Background(), files, output, nil); err != nil {
+		t.Fatalf("分批合并失败: %v", err)
+	}
+
+	gotPages, err := CountPagesInFile(output, nil)
+	if err != nil {
+		t.Fatalf("统计输出页数失败: %v", err)
+	}
+	if gotPages != wantPages {
+		t.Errorf("输出页数应等于输入页数之和 %d，实际 %d", wantPages, gotPages)
+	}
+
+	leftovers, err := filepath.Glob(filepath.Join(tempDir, "*_temp_*.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("分批合并结束后不应遗留临时文件: %v", leftovers)
+	}
+}
+
+// fixedMemoryAlloc 返回总是读到alloc字节堆分配的读取函数
+func fixedMemoryAlloc(alloc int64) func() int64 {
+	return func() int64 { return alloc }
+}
+
+func TestConcurrentChunkMerge_ThrottlesUnderMemoryPressure(t *testing.T) {
+	const maxMemory = 1024 * 1024 * 1024
+	tests := []struct {
+		name        string
+		alloc       int64
+		maxInFlight int64
+	}{
+		{"warning", maxMemory * 75 / 100, 2},
+		{"critical", maxMemory * 90 / 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := make([]string, 0, 16)
+			for i := 0; i < 16; i++ {
+				files = append(files, createTestFile(t, tempDir, fmt.Sprintf("in%02d.pdf", i), buildFlatPDF(1)))
+			}
+
+			var inFlight, peak int64
+			var merger *StreamingMerger
+			chunkMerger := func(ctx context.Context, chunk []string, outputPath string) error {
+				current := atomic.AddInt64(&inFlight, 1)
+				for {
+					seen := atomic.LoadInt64(&peak)
+					if current <= seen || atomic.CompareAndSwapInt64(&peak, seen, current) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt64(&inFlight, -1)
+				return merger.mergeWithBackends(ctx, chunk, outputPath)
+			}
+
+			config := DefaultStreamingConfig()
+			config.MaxConcurrentChunks = 4
+			merger = NewStreamingMergerWithConfig(&MergeOptions{
+				MaxMemoryUsage: maxMemory,
+				TempDirectory:  tempDir,
+				BackendStats:   NewBackendStatsStore(),
+				Clock:          clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1),
+			}, config)
+			defer merger.Close()
+			merger.adapter = nil
+			merger.chunkMerger = chunkMerger
+			merger.memoryReader = fixedMemoryAlloc(tt.alloc)
+
+			monitor := newMemoryMonitor(maxMemory, config)
+			monitor.readAlloc = merger.memoryReader
+			output := filepath.Join(tempDir, "out.pdf")
+			if err := merger.processConcurrently(context.Background(), files, output, monitor); err != nil {
+				t.Fatalf("并发合并失败: %v", err)
+			}
+			if got := atomic.LoadInt64(&peak); got > tt.maxInFlight {
+				t.Errorf("内存压力下最多应同时合并 %d 个分块，实际 %d", tt.maxInFlight, got)
+			}
+			if monitor.PressureEvents() == 0 {
+				t.Error("内存监控应记录压力事件")
+			}
+			if pages, err := CountPagesInFile(output, nil); err != nil || pages != len(files) {
+				t.Errorf("期望输出 %d 页，实际 %d (%v)", len(files), pages, err)
+			}
+		})
+	}
+}
+
+func TestMergeStreaming_ReportsMemoryMonitorReadings(t *testing.T) {
+	const maxMemory = 1024 * 1024 * 1024
+	tempDir := t.TempDir()
+	inputs := []string{
+		createTestFile(t, tempDir, "a.pdf", buildFlatPDF(1)),
+		createTestFile(t, tempDir, "b.pdf", buildFlatPDF(2)),
+	}
+
+	merger := NewStreamingMerger(&MergeOptions{
+		MaxMemoryUsage: maxMemory,
+		TempDirectory:  tempDir,
+		BackendStats:   NewBackendStatsStore(),
+		Clock:          clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1),
+	})
+	defer merger.Close()
+	merger.adapter = nil
+	merger.memoryReader = fixedMemoryAlloc(maxMemory * 90 / 100)
+
+	result, err := merger.MergeStreaming(context.Background(), inputs, filepath.Join(tempDir, "out.pdf"), nil)
+	if err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if result.PeakMemory != maxMemory*90/100 {
+		t.Errorf("期望峰值内存 %d，实际 %d", int64(maxMemory*90/100), result.PeakMemory)
+	}
+	if result.MemoryPressureEvents == 0 {
+		t.Error("严重内存压力应计入 MemoryPressureEvents")
+	}
+}
+
+func TestStreamingMerger_CloseStopsProgressiveGC(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	mergers := make([]*StreamingMerger, 100)
+	for i := range mergers {
+		config := DefaultStreamingConfig()
+		config.EnableProgressiveGC = true
+		config.GCInterval = time.Millisecond
+		mergers[i] = NewStreamingMergerWithConfig(&MergeOptions{TempDirectory: t.TempDir()}, config)
+		// 重复启用不应启动多个协程
+		mergers[i].enableProgressiveGC()
+		mergers[i].enableProgressiveGC()
+	}
+	if started := runtime.NumGoroutine() - before; started < len(mergers) {
+		t.Fatalf("期望每个合并器启动渐进式GC协程，实际只增加 %d 个协程", started)
+	}
+
+	for _, merger := range mergers {
+		if err := merger.Close(); err != nil {
+			t.Fatalf("关闭合并器失败: %v", err)
+		}
+		// 关闭后不再启动
+		merger.enableProgressiveGC()
+	}
+
+	// 关闭上下文的监听协程在Close返回后才退出，留出少量时间
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if leaked := runtime.NumGoroutine() - before; leaked > 0 {
+		t.Errorf("关闭全部合并器后仍有 %d 个协程未退出", leaked)
+	}
+}
@@ -0,0 +1,227 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 合并输出元数据的来源（MergeOptions.MetadataSource）
+const (
+	MetadataSourceNone   = "none"   // 保留pdfcpu写出的元数据
+	MetadataSourceFirst  = "first"  // 复制主文件（第一个输入）的文档信息和XMP元数据
+	MetadataSourceCustom = "custom" // 只应用CustomMetadata中的值
+)
+
+// DocumentInfoKeys 文档信息字典中读取和复制的键
+var DocumentInfoKeys = []string{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"}
+
+// CustomMetadataKeys CustomMetadata中可以覆盖的键
+var CustomMetadataKeys = []string{"Title", "Author", "Subject", "Keywords"}
+
+// ValidMetadataSource 判断元数据来源是否有效，空串等同于none
+func ValidMetadataSource(source string) bool {
+	switch source {
+	case "", MetadataSourceNone, MetadataSourceFirst, MetadataSourceCustom:
+		return true
+	}
+	return false
+}
+
+// ReadDocumentInfo 读取trailer引用的文档信息字典中DocumentInfoKeys列出的文本项，
+// 没有文档信息字典时返回空map
+func ReadDocumentInfo(filePath string) (map[string]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return readDocumentInfo(data, objectBodies(data)), nil
+}
+
+// readDocumentInfo 解析文档信息字典，值为间接引用时从bodies中读取
+func readDocumentInfo(data []byte, bodies map[int][]byte) map[string]string {
+	entries := make(map[string]string)
+	infoNum := refNumber(infoRefPattern, data)
+	if infoNum == 0 {
+		return entries
+	}
+	info, ok := bodies[infoNum]
+	if !ok {
+		return entries
+	}
+	info = topLevelDict(info)
+	for _, key := range DocumentInfoKeys {
+		raw, ok := dictEntry(info, "/"+key)
+		if !ok {
+			continue
+		}
+		if m := refPattern.FindSubmatch(raw); m != nil {
+			num, _ := strconv.Atoi(string(m[1]))
+			if raw, ok = bodies[num]; !ok {
+				continue
+			}
+			raw = bytes.TrimLeft(raw, " \t\r\n")
+		}
+		if bytes.HasPrefix(raw, []byte("(")) || bytes.HasPrefix(raw, []byte("<")) {
+			entries[key] = decodePDFString(raw)
+		}
+	}
+	return entries
+}
+
+// dictEntry 返回字典中key之后的内容（已去掉前导空白），key须以分隔符或空白结束，避免 /Title 匹配 /TitleX
+func dictEntry(dict []byte, key string) ([]byte, bool) {
+	for offset := 0; offset < len(dict); {
+		idx := bytes.Index(dict[offset:], []byte(key))
+		if idx < 0 {
+			return nil, false
+		}
+		end := offset + idx + len(key)
+		if end == len(dict) || isPDFWhitespace(dict[end]) || isPDFDelimiter(dict[end]) {
+			return bytes.TrimLeft(dict[end:], " \t\r\n"), true
+		}
+		offset = end
+	}
+	return nil, false
+}
+
+// ApplyDocumentMetadata 以增量更新设置outputPath的元数据。
+// source为first时复制mainFile的文档信息字典和目录中的XMP元数据流（/Metadata），再应用custom中的值；
+// source为custom时在输出已有的文档信息上应用custom中的值；source为空或none时不修改输出。
+// custom只能包含CustomMetadataKeys中的键，值为空的项被忽略。XMP元数据按原样复制，不随custom更新
+func ApplyDocumentMetadata(outputPath, source, mainFile string, custom map[string]string) error {
+	if !ValidMetadataSource(source) {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("无效的元数据来源: %s", source),
+			File:    outputPath,
+		}
+	}
+	for key := range custom {
+		if !slices.Contains(CustomMetadataKeys, key) {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("不支持自定义元数据项: %s", key),
+				File:    outputPath,
+			}
+		}
+	}
+	if source == "" || source == MetadataSourceNone {
+		return nil
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	bodies := objectBodies(data)
+	update := newIncrementalUpdate(data, indexObjects(data))
+
+	var entries map[string]string
+	if source == MetadataSourceFirst {
+		mainData, err := os.ReadFile(mainFile)
+		if err != nil {
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: "无法读取主文件",
+				File:    mainFile,
+				Cause:   err,
+			}
+		}
+		mainBodies := objectBodies(mainData)
+		entries = readDocumentInfo(mainData, mainBodies)
+		if err := copyXMPMetadata(update, bodies, mainData, mainBodies, outputPath); err != nil {
+			return err
+		}
+	} else {
+		entries = readDocumentInfo(data, bodies)
+	}
+	for key, value := range custom {
+		if value != "" {
+			entries[key] = value
+		}
+	}
+
+	if len(entries) > 0 {
+		update.info = update.add(documentInfoDict(entries))
+	}
+	return writeIncrementalUpdate(update, outputPath, "无法写入元数据")
+}
+
+// documentInfoDict 按DocumentInfoKeys的顺序生成文档信息字典
+func documentInfoDict(entries map[string]string) string {
+	var b strings.Builder
+	b.WriteString("<<")
+	for _, key := range DocumentInfoKeys {
+		if value, ok := entries[key]; ok {
+			fmt.Fprintf(&b, " /%s %s", key, infoString(value))
+		}
+	}
+	b.WriteString(" >>")
+	return b.String()
+}
+
+// infoString 把文本编码为PDF字符串：可打印ASCII使用字面字符串（日期等只能是ASCII），其余使用UTF-16BE
+func infoString(s string) string {
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			return pdfTextString(s)
+		}
+	}
+	return "(" + escapePDFLiteral(s) + ")"
+}
+
+// copyXMPMetadata 把主文件目录中的XMP元数据流复制为输出中的新对象，并替换输出目录的 /Metadata；
+// 主文件没有XMP元数据时不修改输出
+func copyXMPMetadata(update *incrementalUpdate, bodies map[int][]byte, mainData []byte, mainBodies map[int][]byte, outputPath string) error {
+	mainRoot := refNumber(rootRefPattern, mainData)
+	mainCatalog, ok := mainBodies[mainRoot]
+	if !ok {
+		return nil
+	}
+	m := refPattern.FindSubmatch([]byte(directValue(topLevelDict(mainCatalog), "/Metadata")))
+	if m == nil {
+		return nil
+	}
+	metadataNum, _ := strconv.Atoi(string(m[1]))
+	stream, ok := mainBodies[metadataNum]
+	if !ok {
+		return nil
+	}
+	dict, raw, err := splitStream(stream)
+	if err != nil {
+		return nil
+	}
+
+	header := fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d", len(raw))
+	if filter := entryValue(dict, "/Filter"); filter != "" {
+		header += " /Filter " + filter
+	}
+	metadataRef := update.add(header + " >>\nstream\n" + string(raw) + "\nendstream")
+
+	catalogNum := refNumber(rootRefPattern, update.data)
+	body, ok := bodies[catalogNum]
+	if !ok {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("文档目录对象 %d 不存在", catalogNum),
+			File:    outputPath,
+		}
+	}
+	catalog := string(bytes.TrimSpace(topLevelDict(body)))
+	update.set(catalogNum, withEntry(catalog, "/Metadata", fmt.Sprintf("%d 0 R", metadataRef)))
+	return nil
+}
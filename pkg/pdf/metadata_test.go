@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><dc:title>Main Document</dc:title></x:xmpmeta>`
+
+// buildInfoPDF 生成一页PDF，trailer引用包含info的文档信息字典，目录引用XMP元数据流
+func buildInfoPDF(info string) []byte {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 4 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(testXMP), testXMP),
+		info,
+	})
+	return bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Info 5 0 R"), 1)
+}
+
+// readCatalogMetadata 返回输出目录 /Metadata 引用的流数据
+func readCatalogMetadata(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	bodies := objectBodies(data)
+	catalog := bodies[refNumber(rootRefPattern, data)]
+	m := refPattern.FindSubmatch([]byte(directValue(topLevelDict(catalog), "/Metadata")))
+	require.NotNil(t, m, "目录中应有 /Metadata")
+	num := atoiOrZero(m[1])
+	_, raw, err := splitStream(bodies[num])
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func TestReadDocumentInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestFile(t, dir, "info.pdf", buildInfoPDF(
+		"<< /Title (Annual \\(2024\\) Report) /Author <FEFF5F204E09> /TitleX (ignored) /Producer (pdfcpu) >>"))
+
+	entries, err := ReadDocumentInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Annual (2024) Report", entries["Title"])
+	assert.Equal(t, "张三", entries["Author"])
+	assert.Equal(t, "pdfcpu", entries["Producer"])
+	assert.NotContains(t, entries, "Subject")
+
+	// 没有文档信息字典
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	entries, err = ReadDocumentInfo(plain)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestApplyDocumentMetadata_First(t *testing.T) {
+	dir := t.TempDir()
+	main := createTestFile(t, dir, "main.pdf", buildInfoPDF(
+		"<< /Title (Main Document) /Author <FEFF5F204E09> /CreationDate (D:20240101120000Z) >>"))
+	output := createTestFile(t, dir, "out.pdf", buildFlatPDF(2))
+
+	require.NoError(t, ApplyDocumentMetadata(output, MetadataSourceFirst, main, map[string]string{"Subject": "合并结果"}))
+
+	entries, err := ReadDocumentInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, "Main Document", entries["Title"])
+	assert.Equal(t, "张三", entries["Author"])
+	assert.Equal(t, "D:20240101120000Z", entries["CreationDate"])
+	assert.Equal(t, "合并结果", entries["Subject"])
+	assert.Equal(t, testXMP, readCatalogMetadata(t, output))
+
+	pages, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+}
+
+func TestApplyDocumentMetadata_Custom(t *testing.T) {
+	dir := t.TempDir()
+	main := createTestFile(t, dir, "main.pdf", buildInfoPDF("<< /Title (Main Document) >>"))
+	output := createTestFile(t, dir, "out.pdf", buildInfoPDF("<< /Title (Merged) /Producer (pdfcpu) >>"))
+
+	require.NoError(t, ApplyDocumentMetadata(output, MetadataSourceCustom, main, map[string]string{"Title": "Custom", "Keywords": "a, b"}))
+
+	entries, err := ReadDocumentInfo(output)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", entries["Title"])
+	assert.Equal(t, "a, b", entries["Keywords"])
+	assert.Equal(t, "pdfcpu", entries["Producer"], "custom应保留输出已有的文档信息")
+}
+
+func TestApplyDocumentMetadata_NoneAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	main := createTestFile(t, dir, "main.pdf", buildInfoPDF("<< /Title (Main Document) >>"))
+	original := buildFlatPDF(1)
+	output := createTestFile(t, dir, "out.pdf", original)
+
+	require.NoError(t, ApplyDocumentMetadata(output, MetadataSourceNone, main, nil))
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, original, data, "none时不应修改输出")
+
+	var pdfErr *PDFError
+	err = ApplyDocumentMetadata(output, "last", main, nil)
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+
+	err = ApplyDocumentMetadata(output, MetadataSourceCustom, main, map[string]string{"Producer": "x"})
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+}
+
+func TestMergeFiles_MetadataSourceFirst(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildInfoPDF("<< /Title (Main Document) /Author (Alice) >>"))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(2))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:  dir,
+		BackendStats:   NewBackendStatsStore(),
+		MetadataSource: MetadataSourceFirst,
+		CustomMetadata: map[string]string{"Keywords": "merged"},
+	})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalPages)
+	assert.Empty(t, result.Warnings)
+
+	metadata, err := NewPDFService().GetPDFMetadata(output)
+	require.NoError(t, err)
+	assert.Equal(t, "Main Document", metadata["Title"])
+	assert.Equal(t, "Alice", metadata["Author"])
+	assert.Equal(t, "merged", metadata["Keywords"])
+	assert.Equal(t, testXMP, readCatalogMetadata(t, output))
+}
+
+func TestPDFService_MergePDFsCustomMetadata(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildInfoPDF("<< /Title (Main Document) >>"))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(2))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = dir
+	config.MetadataSource = MetadataSourceCustom
+	config.CustomMetadata = map[string]string{"Title": "季度报告", "Author": "Bob"}
+	service := NewPDFServiceWithConfig(config)
+	output := filepath.Join(dir, "out.pdf")
+	require.NoError(t, service.MergePDFs(a, []string{b}, output, nil))
+
+	metadata, err := service.GetPDFMetadata(output)
+	require.NoError(t, err)
+	assert.Equal(t, "季度报告", metadata["Title"])
+	assert.Equal(t, "Bob", metadata["Author"])
+}
@@ -4,6 +4,8 @@ import (
 	"runtime"
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // MigrationMetrics 迁移/处理指标
@@ -17,14 +19,17 @@ type MigrationMetrics struct {
 	LastError      error                  // 最后一次错误
 	MemoryUsage    uint64                 // 峰值内存占用（字节）
 	Custom         map[string]interface{} // 其他自定义指标
+	clock          clock.Clock            // 开始、结束时间和时长使用的时钟
 	mutex          sync.Mutex
 }
 
 // NewMigrationMetrics 创建新指标对象
 func NewMigrationMetrics() *MigrationMetrics {
+	c := clock.System()
 	return &MigrationMetrics{
-		StartTime: time.Now(),
+		StartTime: c.Now(),
 		Custom:    make(map[string]interface{}),
+		clock:     c,
 	}
 }
 
@@ -32,7 +37,7 @@ func NewMigrationMetrics() *MigrationMetrics {
 func (m *MigrationMetrics) MarkEnd() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.EndTime = time.Now()
+	m.EndTime = clock.OrSystem(m.clock).Now()
 }
 
 // AddFile 增加处理文件数
@@ -85,7 +90,7 @@ func (m *MigrationMetrics) SetCustom(key string, value interface{}) {
 // GetDuration 获取处理总时长
 func (m *MigrationMetrics) GetDuration() time.Duration {
 	if m.EndTime.IsZero() {
-		return time.Since(m.StartTime)
+		return clock.OrSystem(m.clock).Now().Sub(m.StartTime)
 	}
 	return m.EndTime.Sub(m.StartTime)
 }
@@ -0,0 +1,476 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// optimizeObjectsPerStream 每个对象流最多容纳的对象数
+	optimizeObjectsPerStream = 100
+	// optimizeDedupePasses 去重的最大轮数：图像引用的软遮罩去重后，引用它们的图像才能在下一轮去重
+	optimizeDedupePasses = 4
+)
+
+var (
+	fontFileRefPattern  = regexp.MustCompile(`/FontFile[23]?\s+(\d+)\s+\d+\s+R`)
+	metadataTypePattern = regexp.MustCompile(`/Type\s*/Metadata\b`)
+	filterKeyPattern    = regexp.MustCompile(`/Filter\b`)
+)
+
+// OptimizeOptions 输出优化选项
+type OptimizeOptions struct {
+	// ImageDPI 把页面上有效分辨率高于此值的图像降采样到该分辨率，0时不降采样
+	ImageDPI int
+
+	// ClassicXRef 写出传统交叉引用表，不使用对象流和交叉引用流。
+	// 之后还要线性化或以增量更新修改的文件需要设置，这些步骤只支持传统结构
+	ClassicXRef bool
+}
+
+// OptimizeReport 一次优化的结果
+type OptimizeReport struct {
+	OriginalSize      int64 `json:"original_size"`      // 优化前的文件大小（字节）
+	OptimizedSize     int64 `json:"optimized_size"`     // 优化后的文件大小（字节）
+	DuplicateFonts    int   `json:"duplicate_fonts"`    // 去除的重复字体程序数
+	DuplicateImages   int   `json:"duplicate_images"`   // 去除的重复图像数
+	DownsampledImages int   `json:"downsampled_images"` // 降采样的图像数
+	CompressedStreams int   `json:"compressed_streams"` // 新压缩的未压缩流数
+	ObjectStreams     int   `json:"object_streams"`     // 写出的对象流数
+}
+
+// OptimizePDF 重写PDF以减小文件大小并写入outputPath，输入与输出可以是同一路径：
+// 合并内容相同的字体程序和图像XObject，以Flate压缩未压缩的流（XMP元数据除外），
+// 可选地降采样分辨率过高的图像（见OptimizeOptions.ImageDPI），丢弃不可达的对象，
+// 未设置ClassicXRef时把非流对象写入对象流并使用交叉引用流。
+// 结果不比输入小时写出原内容，此时报告中只有大小。不支持加密文件。
+func OptimizePDF(inputPath, outputPath string, options *OptimizeOptions) (*OptimizeReport, error) {
+	if options == nil {
+		options = &OptimizeOptions{}
+	}
+	if options.ImageDPI < 0 {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("无效的图像分辨率 %d", options.ImageDPI),
+			File:    inputPath,
+		}
+	}
+	data, err := readTransformInput(inputPath, "无法优化加密文件")
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptimizer(inputPath, data)
+	report := &OptimizeReport{OriginalSize: int64(len(data))}
+	optimized, err := o.optimize(options, report)
+	if err != nil {
+		return nil, err
+	}
+	if len(optimized) >= len(data) {
+		optimized = data
+		report = &OptimizeReport{OriginalSize: int64(len(data))}
+	}
+	report.OptimizedSize = int64(len(optimized))
+
+	if err := replaceFileContent(outputPath, optimized, ".optimize.tmp", "无法写入优化结果"); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// optimizer 保存一次优化所需的状态。对象优先按交叉引用读取（支持对象流），
+// 交叉引用无法解析时按对象头扫描
+type optimizer struct {
+	filePath string
+	data     []byte
+	index    *xrefIndex
+	offsets  map[int]int
+	objects  map[int]*linearObject
+	missing  map[int]bool
+}
+
+// newOptimizer 创建优化器
+func newOptimizer(filePath string, data []byte) *optimizer {
+	index, err := readXRefIndex(data)
+	if err != nil {
+		index = nil
+	}
+	return &optimizer{
+		filePath: filePath,
+		data:     data,
+		index:    index,
+		offsets:  indexObjects(data),
+		objects:  make(map[int]*linearObject),
+		missing:  make(map[int]bool),
+	}
+}
+
+// load 读取对象，流对象按 /Length 截取原始数据；修改过的对象返回修改后的内容
+func (o *optimizer) load(num int) (*linearObject, bool) {
+	if obj, ok := o.objects[num]; ok {
+		return obj, true
+	}
+	if o.missing[num] {
+		return nil, false
+	}
+	obj := o.read(num)
+	if obj == nil {
+		o.missing[num] = true
+		return nil, false
+	}
+	o.objects[num] = obj
+	return obj, true
+}
+
+// read 从文件中读取对象
+func (o *optimizer) read(num int) *linearObject {
+	if o.index != nil {
+		if entry, ok := o.index.entries[num]; ok {
+			if entry.stream != 0 {
+				if body, err := o.index.object(num); err == nil {
+					return &linearObject{dict: body}
+				}
+			} else if entry.offset >= 0 && entry.offset < int64(len(o.data)) {
+				rest := bytes.TrimLeft(o.data[entry.offset:], " \t\r\n\f\x00")
+				if m := objectStartPattern.FindSubmatch(rest); m != nil && string(m[1]) == strconv.Itoa(num) {
+					return splitObject(rest[len(m[0]):], o.body)
+				}
+			}
+		}
+	}
+	if start, ok := o.offsets[num]; ok {
+		return splitObject(o.data[start:], o.body)
+	}
+	return nil
+}
+
+// body 返回对象流之前的部分，用于解析间接引用的 /Length 和字典
+func (o *optimizer) body(num int) ([]byte, bool) {
+	obj, ok := o.load(num)
+	if !ok {
+		return nil, false
+	}
+	return obj.dict, true
+}
+
+// trailer 返回最新的trailer字典，没有时返回整个文件供按模式查找
+func (o *optimizer) trailer() []byte {
+	if o.index != nil && len(o.index.trailers) > 0 {
+		return o.index.trailers[0]
+	}
+	return o.data
+}
+
+// optimize 返回优化后的文件内容
+func (o *optimizer) optimize(options *OptimizeOptions, report *OptimizeReport) ([]byte, error) {
+	trailer := o.trailer()
+	rootMatches := rootRefPattern.FindAllSubmatch(trailer, -1)
+	if len(rootMatches) == 0 {
+		return nil, &PDFError{Type: ErrorCorrupted, Message: "trailer中没有 /Root 引用", File: o.filePath}
+	}
+	rootNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	if _, ok := o.load(rootNum); !ok {
+		return nil, &PDFError{Type: ErrorCorrupted, Message: "目录对象不存在", File: o.filePath}
+	}
+	starts := []int{rootNum}
+	infoNum := refNumber(infoRefPattern, trailer)
+	if infoNum > 0 {
+		starts = append(starts, infoNum)
+	}
+
+	order := o.reachable(starts, nil)
+	if options.ImageDPI > 0 {
+		o.downsampleImages(rootNum, order, options.ImageDPI, report)
+	}
+	canonical := o.deduplicate(order, report)
+	o.compressStreams(order, report)
+
+	// 去重后重新收集：被合并的对象及只被它们引用的对象不再写出
+	live := o.reachable(starts, canonical)
+	newNum := make(map[int]int, len(live))
+	for i, num := range live {
+		newNum[num] = i + 1
+	}
+
+	version := "1.4"
+	if m := headerVersionPattern.FindSubmatch(o.data); m != nil {
+		version = string(m[1])
+	}
+	extras := fmt.Sprintf(" /Root %d 0 R", newNum[rootNum])
+	if n, ok := newNum[infoNum]; ok && infoNum > 0 {
+		extras += fmt.Sprintf(" /Info %d 0 R", n)
+	}
+	if ids := idArrayPattern.FindAll(trailer, -1); len(ids) > 0 {
+		extras += " " + string(ids[len(ids)-1])
+	}
+
+	bodies := make([][]byte, len(live))
+	for i, num := range live {
+		bodies[i] = o.serialize(num, canonical, newNum)
+	}
+	if options.ClassicXRef {
+		return writeClassicFile(version, bodies, extras), nil
+	}
+	if version < "1.5" {
+		version = "1.5" // 对象流和交叉引用流需要PDF 1.5
+	}
+	out, streams := writeCompressedFile(version, bodies, o.isStreamBody(live), extras)
+	report.ObjectStreams = streams
+	return out, nil
+}
+
+// isStreamBody 返回各对象是否为流对象，流对象不能放入对象流
+func (o *optimizer) isStreamBody(live []int) []bool {
+	streams := make([]bool, len(live))
+	for i, num := range live {
+		obj, _ := o.load(num)
+		streams[i] = obj.stream != nil
+	}
+	return streams
+}
+
+// reachable 从starts开始按广度优先收集可达的对象。canonical非nil时引用先映射到保留的对象
+func (o *optimizer) reachable(starts []int, canonical map[int]int) []int {
+	var order []int
+	visited := make(map[int]bool)
+	queue := append([]int(nil), starts...)
+	for len(queue) > 0 {
+		num := queue[0]
+		queue = queue[1:]
+		if c, ok := canonical[num]; ok {
+			num = c
+		}
+		if visited[num] {
+			continue
+		}
+		visited[num] = true
+		obj, ok := o.load(num)
+		if !ok {
+			continue
+		}
+		order = append(order, num)
+		dict := obj.dict
+		if obj.stream != nil {
+			// 写出时 /Length 改为直接值，长度对象不再需要
+			dict = streamLengthPattern.ReplaceAll(dict, nil)
+		}
+		queue = append(queue, refsIn(dict)...)
+	}
+	return order
+}
+
+// deduplicate 找出内容相同的字体程序和图像XObject，返回重复对象到保留对象的映射。
+// 比较时忽略 /Length，字典中的引用按已找到的映射比较
+func (o *optimizer) deduplicate(order []int, report *OptimizeReport) map[int]int {
+	fontFiles := make(map[int]bool)
+	for _, num := range order {
+		obj, _ := o.load(num)
+		for _, m := range fontFileRefPattern.FindAllSubmatch(obj.dict, -1) {
+			ref, _ := strconv.Atoi(string(m[1]))
+			fontFiles[ref] = true
+		}
+	}
+
+	canonical := make(map[int]int)
+	for pass := 0; pass < optimizeDedupePasses; pass++ {
+		seen := make(map[[sha256.Size]byte]int)
+		changed := false
+		for _, num := range order {
+			if _, ok := canonical[num]; ok {
+				continue
+			}
+			obj, _ := o.load(num)
+			font := fontFiles[num]
+			if obj.stream == nil || !(font || imageTypePattern.Match(obj.dict)) {
+				continue
+			}
+			key := contentKey(obj, canonical)
+			first, ok := seen[key]
+			if !ok {
+				seen[key] = num
+				continue
+			}
+			canonical[num] = first
+			changed = true
+			if font {
+				report.DuplicateFonts++
+			} else {
+				report.DuplicateImages++
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return canonical
+}
+
+// contentKey 计算流对象的内容摘要：去掉 /Length 并按canonical改写引用后的字典，加上原始流数据
+func contentKey(obj *linearObject, canonical map[int]int) [sha256.Size]byte {
+	dict := streamLengthPattern.ReplaceAll(obj.dict, nil)
+	dict = indirectRefPattern.ReplaceAllFunc(dict, func(ref []byte) []byte {
+		m := indirectRefPattern.FindSubmatch(ref)
+		num, _ := strconv.Atoi(string(m[1]))
+		if c, ok := canonical[num]; ok {
+			num = c
+		}
+		return []byte(fmt.Sprintf("%d 0 R", num))
+	})
+	h := sha256.New()
+	h.Write(bytes.Join(bytes.Fields(dict), []byte(" ")))
+	h.Write([]byte{0})
+	h.Write(obj.stream)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// compressStreams 以Flate压缩没有过滤器的流，压缩后不变小的流和XMP元数据保持不变
+func (o *optimizer) compressStreams(order []int, report *OptimizeReport) {
+	for _, num := range order {
+		obj, _ := o.load(num)
+		if len(obj.stream) == 0 || filterKeyPattern.Match(obj.dict) || metadataTypePattern.Match(obj.dict) {
+			continue
+		}
+		compressed := deflate(obj.stream)
+		if len(compressed) >= len(obj.stream) {
+			continue
+		}
+		dict := withEntry(string(bytes.TrimSpace(obj.dict)), "/Filter", "/FlateDecode")
+		o.objects[num] = &linearObject{dict: []byte(dict), stream: compressed}
+		report.CompressedStreams++
+	}
+}
+
+// serialize 返回对象内容（不含对象头），引用按canonical和newNum改写，不存在的对象改为null，
+// 流的 /Length 改为直接值
+func (o *optimizer) serialize(num int, canonical, newNum map[int]int) []byte {
+	obj, _ := o.load(num)
+	dict := obj.dict
+	if obj.stream != nil {
+		dict = streamLengthPattern.ReplaceAll(dict, nil)
+	}
+	dict = indirectRefPattern.ReplaceAllFunc(dict, func(ref []byte) []byte {
+		m := indirectRefPattern.FindSubmatch(ref)
+		old, _ := strconv.Atoi(string(m[1]))
+		if c, ok := canonical[old]; ok {
+			old = c
+		}
+		if n, ok := newNum[old]; ok {
+			return []byte(fmt.Sprintf("%d 0 R", n))
+		}
+		return []byte("null")
+	})
+	dict = bytes.TrimSpace(dict)
+	if obj.stream == nil {
+		return dict
+	}
+
+	var b bytes.Buffer
+	b.Write(bytes.Replace(dict, []byte("<<"), []byte(fmt.Sprintf("<< /Length %d", len(obj.stream))), 1))
+	b.WriteString("\nstream\n")
+	b.Write(obj.stream)
+	b.WriteString("\nendstream")
+	return b.Bytes()
+}
+
+// writeClassicFile 按顺序写出对象（编号从1开始）和传统交叉引用表
+func writeClassicFile(version string, bodies [][]byte, trailerExtras string) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+	offsets := make([]int, len(bodies))
+	for i, body := range bodies {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(bodies)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d%s >>\nstartxref\n%d\n%%%%EOF\n", len(bodies)+1, trailerExtras, xref)
+	return out.Bytes()
+}
+
+// writeCompressedFile 写出流对象，把其余对象每optimizeObjectsPerStream个放入一个对象流，
+// 最后写出交叉引用流。对象编号从1开始，对象流和交叉引用流编号在其后。返回文件内容和对象流数
+func writeCompressedFile(version string, bodies [][]byte, streams []bool, trailerExtras string) ([]byte, int) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+
+	type xrefRow struct {
+		kind   byte
+		field2 int
+		field3 int
+	}
+	rows := make([]xrefRow, len(bodies)+1)
+	rows[0] = xrefRow{0, 0, 65535}
+
+	var packed []int
+	for i, body := range bodies {
+		if streams[i] {
+			rows[i+1] = xrefRow{1, out.Len(), 0}
+			fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, body)
+			continue
+		}
+		packed = append(packed, i)
+	}
+
+	objectStreams := 0
+	for start := 0; start < len(packed); start += optimizeObjectsPerStream {
+		chunk := packed[start:min(start+optimizeObjectsPerStream, len(packed))]
+		num := len(rows)
+		var header, content bytes.Buffer
+		for index, i := range chunk {
+			fmt.Fprintf(&header, "%d %d ", i+1, content.Len())
+			content.Write(bodies[i])
+			content.WriteByte('\n')
+			rows[i+1] = xrefRow{2, num, index}
+		}
+		data := deflate(append(header.Bytes(), content.Bytes()...))
+		rows = append(rows, xrefRow{1, out.Len(), 0})
+		fmt.Fprintf(&out, "%d 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n",
+			num, len(chunk), header.Len(), len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\nendobj\n")
+		objectStreams++
+	}
+
+	// 交叉引用流本身也在表中
+	xrefNum := len(rows)
+	xrefOffset := out.Len()
+	rows = append(rows, xrefRow{1, xrefOffset, 0})
+	width := 1
+	for limit := 256; xrefOffset >= limit || xrefNum >= limit; limit <<= 8 {
+		width++
+	}
+	var table bytes.Buffer
+	for _, row := range rows {
+		table.WriteByte(row.kind)
+		for shift := (width - 1) * 8; shift >= 0; shift -= 8 {
+			table.WriteByte(byte(row.field2 >> shift))
+		}
+		table.WriteByte(byte(row.field3 >> 8))
+		table.WriteByte(byte(row.field3))
+	}
+	data := deflate(table.Bytes())
+	fmt.Fprintf(&out, "%d 0 obj\n<< /Type /XRef /Size %d /W [1 %d 2]%s /Filter /FlateDecode /Length %d >>\nstream\n",
+		xrefNum, len(rows), width, trailerExtras, len(data))
+	out.Write(data)
+	fmt.Fprintf(&out, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes(), objectStreams
+}
+
+// deflate 以最高压缩率进行zlib压缩
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&b, zlib.BestCompression)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
@@ -0,0 +1,504 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxDownsampleImageSize 降采样时图像解码后的最大字节数，更大的图像保持不变
+	maxDownsampleImageSize = 256 * 1024 * 1024
+	// downsampleJPEGQuality 重新编码DCT图像时的JPEG质量
+	downsampleJPEGQuality = 85
+)
+
+var iccBasedPattern = regexp.MustCompile(`^\[\s*/ICCBased\s+(\d+)\s+\d+\s+R\s*\]$`)
+
+// imageUse 图像在页面上的最大显示尺寸（默认用户空间单位，1/72英寸）
+type imageUse struct {
+	width  float64
+	height float64
+}
+
+// downsampleImages 把页面上有效分辨率高于dpi的图像降采样到dpi。
+// 只处理8位、没有 /Mask 和 /DecodeParms、未压缩或使用FlateDecode、DCTDecode的图像；
+// 在表单XObject、图案等非页面资源中引用的图像以及所在页面内容流无法解码的图像无法确定显示尺寸，保持不变
+func (o *optimizer) downsampleImages(rootNum int, order []int, dpi int, report *OptimizeReport) {
+	uses, excluded := o.imagePlacements(rootNum, order)
+	for _, num := range order {
+		use, ok := uses[num]
+		if !ok || excluded[num] || use.width <= 0 || use.height <= 0 {
+			continue
+		}
+		obj, _ := o.load(num)
+		width, errW := strconv.Atoi(directValue(obj.dict, "/Width"))
+		height, errH := strconv.Atoi(directValue(obj.dict, "/Height"))
+		if errW != nil || errH != nil || width <= 0 || height <= 0 {
+			continue
+		}
+		// 按最大显示尺寸计算需要的像素数，只缩小不放大
+		newWidth := min(width, max(1, int(math.Round(use.width/72*float64(dpi)))))
+		newHeight := min(height, max(1, int(math.Round(use.height/72*float64(dpi)))))
+		if newWidth == width && newHeight == height {
+			continue
+		}
+		if resampled, ok := o.resampleImage(obj, width, height, newWidth, newHeight); ok && len(resampled.stream) < len(obj.stream) {
+			o.objects[num] = resampled
+			report.DownsampledImages++
+		}
+	}
+}
+
+// imagePlacements 扫描各页的内容流，返回直接绘制在页面上的图像的最大显示尺寸，
+// 以及无法确定显示尺寸的图像
+func (o *optimizer) imagePlacements(rootNum int, order []int) (map[int]imageUse, map[int]bool) {
+	uses := make(map[int]imageUse)
+	excluded := make(map[int]bool)
+
+	pageObjects := make(map[int]bool)
+	if catalog, ok := o.load(rootNum); ok {
+		if m := pagesRefPattern.FindSubmatch(catalog.dict); m != nil {
+			pagesNum, _ := strconv.Atoi(string(m[1]))
+			o.walkPages(pagesNum, nil, 0, pageObjects, func(page *linearObject, images map[string]int) {
+				content, ok := o.pageContent(page)
+				if !ok {
+					for _, num := range images {
+						excluded[num] = true
+					}
+					return
+				}
+				scanImageDraws(content, func(name string, ctm affine) {
+					num, ok := images[name]
+					if !ok {
+						return
+					}
+					use := uses[num]
+					use.width = max(use.width, math.Hypot(ctm[0], ctm[1]))
+					use.height = max(use.height, math.Hypot(ctm[2], ctm[3]))
+					uses[num] = use
+				})
+			})
+		}
+	}
+
+	// 表单XObject、图案、注释外观等在其他坐标系中绘制图像
+	for _, num := range order {
+		if pageObjects[num] {
+			continue
+		}
+		obj, _ := o.load(num)
+		for _, image := range o.imageResources(obj.dict) {
+			excluded[image] = true
+		}
+	}
+	return uses, excluded
+}
+
+// walkPages 遍历页面树，对每一页调用visit，传入页面和（可继承的）资源中图像XObject的名称到对象编号的映射
+func (o *optimizer) walkPages(num int, inherited map[string]int, depth int, visited map[int]bool,
+	visit func(page *linearObject, images map[string]int)) {
+	if depth > maxParentHops || visited[num] {
+		return
+	}
+	visited[num] = true
+	node, ok := o.load(num)
+	if !ok {
+		return
+	}
+	images := inherited
+	if resolveDictWith(node.dict, "/Resources", o.body) != nil {
+		images = o.imageResources(node.dict)
+	}
+	if !pagesNodePattern.Match(node.dict) {
+		visit(node, images)
+		return
+	}
+	if idx := bytes.Index(node.dict, []byte("/Kids")); idx >= 0 {
+		for _, kid := range parseArrayRefs(node.dict[idx+len("/Kids"):]) {
+			o.walkPages(kid, images, depth+1, visited, visit)
+		}
+	}
+}
+
+// imageResources 返回对象 /Resources 中图像XObject的名称到对象编号的映射
+func (o *optimizer) imageResources(dict []byte) map[string]int {
+	resources := resolveDictWith(dict, "/Resources", o.body)
+	if resources == nil {
+		return nil
+	}
+	xobjects := resolveDictWith(resources, "/XObject", o.body)
+	if xobjects == nil {
+		return nil
+	}
+	images := make(map[string]int)
+	for _, m := range xobjectRefPattern.FindAllSubmatch(xobjects, -1) {
+		num, _ := strconv.Atoi(string(m[2]))
+		if obj, ok := o.load(num); ok && imageTypePattern.Match(obj.dict) {
+			images[string(m[1])] = num
+		}
+	}
+	return images
+}
+
+// pageContent 读取并解码页面的内容流，只支持未压缩和FlateDecode的流
+func (o *optimizer) pageContent(page *linearObject) ([]byte, bool) {
+	idx := bytes.Index(page.dict, []byte("/Contents"))
+	if idx < 0 {
+		return nil, true
+	}
+	rest := page.dict[idx+len("/Contents"):]
+	var refs []int
+	if m := refPattern.FindSubmatch(rest); m != nil {
+		num, _ := strconv.Atoi(string(m[1]))
+		refs = []int{num}
+	} else {
+		refs = parseArrayRefs(rest)
+	}
+
+	var content bytes.Buffer
+	for _, num := range refs {
+		obj, ok := o.load(num)
+		if !ok || obj.stream == nil {
+			return nil, false
+		}
+		if filter := entryValue(obj.dict, "/Filter"); filter != "" && !flateFilterPattern.Match(obj.dict) {
+			return nil, false
+		}
+		decoded, err := decodeStreamData(obj.dict, obj.stream)
+		if err != nil || content.Len()+len(decoded) > maxDecodedContentSize {
+			return nil, false
+		}
+		content.Write(decoded)
+		content.WriteByte('\n')
+	}
+	return content.Bytes(), true
+}
+
+// scanImageDraws 按内容流中的 q、Q、cm 跟踪当前变换矩阵，对每个 Do 调用draw。
+// 字符串、字典和内联图像被跳过
+func scanImageDraws(content []byte, draw func(name string, ctm affine)) {
+	ctm := affine{1, 0, 0, 1, 0, 0}
+	var stack []affine
+	var operands []string
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			i = skipLiteralString(content, i)
+			operands = append(operands, "()")
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i = skipDictionary(content, i)
+			operands = append(operands, "<<>>")
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			i += end + 1
+			operands = append(operands, "<>")
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			i++
+		default:
+			start := i
+			i++
+			for i < len(content) && !isPDFWhitespace(content[i]) && !strings.ContainsRune("()<>[]{}/%", rune(content[i])) {
+				i++
+			}
+			token := string(content[start:i])
+			if c == '/' || c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') {
+				operands = append(operands, token)
+				continue
+			}
+			switch token {
+			case "q":
+				stack = append(stack, ctm)
+			case "Q":
+				if len(stack) > 0 {
+					ctm = stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+				}
+			case "cm":
+				if m, ok := parseMatrix(operands); ok {
+					ctm = m.then(ctm)
+				}
+			case "Do":
+				if n := len(operands); n > 0 && strings.HasPrefix(operands[n-1], "/") {
+					draw(operands[n-1][1:], ctm)
+				}
+			case "BI":
+				i = skipInlineImage(content, i)
+			}
+			operands = operands[:0]
+		}
+	}
+}
+
+// parseMatrix 把最后六个操作数解析为矩阵
+func parseMatrix(operands []string) (affine, bool) {
+	var m affine
+	if len(operands) < 6 {
+		return m, false
+	}
+	for i, operand := range operands[len(operands)-6:] {
+		v, err := strconv.ParseFloat(operand, 64)
+		if err != nil {
+			return m, false
+		}
+		m[i] = v
+	}
+	return m, true
+}
+
+// skipLiteralString 跳过从i开始的字面字符串（支持嵌套括号和转义），返回其后的位置
+func skipLiteralString(content []byte, i int) int {
+	depth := 0
+	for ; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(content)
+}
+
+// skipDictionary 跳过从i开始的字典（支持嵌套），返回其后的位置
+func skipDictionary(content []byte, i int) int {
+	depth := 0
+	for i+1 < len(content) {
+		switch {
+		case content[i] == '<' && content[i+1] == '<':
+			depth++
+			i += 2
+		case content[i] == '>' && content[i+1] == '>':
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(content)
+}
+
+// skipInlineImage 跳过 BI 之后的内联图像，返回 EI 之后的位置
+func skipInlineImage(content []byte, i int) int {
+	id := bytes.Index(content[i:], []byte("ID"))
+	if id < 0 {
+		return len(content)
+	}
+	for j := i + id + 2; j+2 <= len(content); j++ {
+		if content[j] == 'E' && content[j+1] == 'I' && isPDFWhitespace(content[j-1]) &&
+			(j+2 == len(content) || isPDFWhitespace(content[j+2])) {
+			return j + 2
+		}
+	}
+	return len(content)
+}
+
+// resampleImage 把图像按区域平均缩小到newWidth×newHeight，返回修改后的对象；不支持的图像返回false
+func (o *optimizer) resampleImage(obj *linearObject, width, height, newWidth, newHeight int) (*linearObject, bool) {
+	dict := obj.dict
+	components, ok := o.imageComponents(dict)
+	if !ok || width*height*components > maxDownsampleImageSize {
+		return nil, false
+	}
+	if directValue(dict, "/Mask") != "" || directValue(dict, "/DecodeParms") != "" {
+		return nil, false
+	}
+
+	filter := strings.Trim(entryValue(dict, "/Filter"), "[] \t\r\n")
+	var samples []byte
+	switch filter {
+	case "", "/FlateDecode":
+		decoded, err := inflateImage(filter, obj.stream)
+		if err != nil || len(decoded) < width*height*components {
+			return nil, false
+		}
+		samples = decoded[:width*height*components]
+	case "/DCTDecode":
+		decoded, err := jpeg.Decode(bytes.NewReader(obj.stream))
+		if err != nil {
+			return nil, false
+		}
+		if samples, ok = jpegSamples(decoded, width, height, components); !ok {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	resampled := resampleSamples(samples, width, height, components, newWidth, newHeight)
+	var stream []byte
+	if filter == "/DCTDecode" {
+		var b bytes.Buffer
+		if err := jpeg.Encode(&b, samplesImage(resampled, newWidth, newHeight, components), &jpeg.Options{Quality: downsampleJPEGQuality}); err != nil {
+			return nil, false
+		}
+		stream = b.Bytes()
+	} else {
+		stream = deflate(resampled)
+		filter = "/FlateDecode"
+	}
+
+	newDict := string(bytes.TrimSpace(streamLengthPattern.ReplaceAll(dict, nil)))
+	newDict = withEntry(newDict, "/Width", strconv.Itoa(newWidth))
+	newDict = withEntry(newDict, "/Height", strconv.Itoa(newHeight))
+	newDict = withEntry(newDict, "/Filter", filter)
+	return &linearObject{dict: []byte(newDict), stream: stream}, true
+}
+
+// imageComponents 返回8位图像每个像素的分量数，支持DeviceGray、DeviceRGB、DeviceCMYK和ICCBased颜色空间
+func (o *optimizer) imageComponents(dict []byte) (int, bool) {
+	return imageComponentsWith(dict, o.body)
+}
+
+// imageComponentsWith 与imageComponents相同，间接引用的颜色空间和ICC配置通过lookup读取
+func imageComponentsWith(dict []byte, lookup func(num int) ([]byte, bool)) (int, bool) {
+	if directValue(dict, "/BitsPerComponent") != "8" || strings.HasPrefix(directValue(dict, "/ImageMask"), "true") {
+		return 0, false
+	}
+	colorSpace := entryValue(dict, "/ColorSpace")
+	if m := refPattern.FindStringSubmatch(colorSpace); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		body, ok := lookup(num)
+		if !ok {
+			return 0, false
+		}
+		colorSpace = string(bytes.TrimSpace(body))
+	}
+	switch colorSpace {
+	case "/DeviceGray":
+		return 1, true
+	case "/DeviceRGB":
+		return 3, true
+	case "/DeviceCMYK":
+		return 4, true
+	}
+	if m := iccBasedPattern.FindStringSubmatch(colorSpace); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		if profile, ok := lookup(num); ok {
+			if n, err := strconv.Atoi(directValue(profile, "/N")); err == nil && (n == 1 || n == 3 || n == 4) {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// entryValue 与directValue相同，但值为名称时返回该名称
+func entryValue(dict []byte, key string) string {
+	idx := bytes.Index(dict, []byte(key))
+	if idx < 0 {
+		return ""
+	}
+	rest := bytes.TrimLeft(dict[idx+len(key):], " \t\r\n")
+	if !bytes.HasPrefix(rest, []byte("/")) {
+		return directValue(dict, key)
+	}
+	end := bytes.IndexAny(rest[1:], " \t\r\n/<>[]()")
+	if end < 0 {
+		return string(rest)
+	}
+	return string(rest[:end+1])
+}
+
+// inflateImage 解码未压缩或FlateDecode的图像数据
+func inflateImage(filter string, stream []byte) ([]byte, error) {
+	if filter == "" {
+		return stream, nil
+	}
+	return inflate(stream, maxDownsampleImageSize)
+}
+
+// jpegSamples 把解码后的JPEG转换为按行排列的灰度或RGB样本，尺寸或分量数与图像字典不符时返回false
+func jpegSamples(img image.Image, width, height, components int) ([]byte, bool) {
+	bounds := img.Bounds()
+	if bounds.Dx() != width || bounds.Dy() != height {
+		return nil, false
+	}
+	switch img.(type) {
+	case *image.Gray:
+		if components != 1 {
+			return nil, false
+		}
+	case *image.YCbCr:
+		if components != 3 {
+			return nil, false
+		}
+	default:
+		// CMYK JPEG无法重新编码
+		return nil, false
+	}
+
+	samples := make([]byte, 0, width*height*components)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if components == 1 {
+				samples = append(samples, byte(r>>8))
+				continue
+			}
+			samples = append(samples, byte(r>>8), byte(g>>8), byte(b>>8))
+		}
+	}
+	return samples, true
+}
+
+// samplesImage 把灰度或RGB样本转换为用于JPEG编码的图像
+func samplesImage(samples []byte, width, height, components int) image.Image {
+	rect := image.Rect(0, 0, width, height)
+	if components == 1 {
+		return &image.Gray{Pix: samples, Stride: width, Rect: rect}
+	}
+	img := image.NewRGBA(rect)
+	for i := 0; i < width*height; i++ {
+		copy(img.Pix[i*4:], samples[i*3:i*3+3])
+		img.Pix[i*4+3] = 0xff
+	}
+	return img
+}
+
+// resampleSamples 按区域平均把图像样本从width×height缩小到newWidth×newHeight
+func resampleSamples(samples []byte, width, height, components, newWidth, newHeight int) []byte {
+	out := make([]byte, newWidth*newHeight*components)
+	for y := 0; y < newHeight; y++ {
+		y0 := y * height / newHeight
+		y1 := max((y+1)*height/newHeight, y0+1)
+		for x := 0; x < newWidth; x++ {
+			x0 := x * width / newWidth
+			x1 := max((x+1)*width/newWidth, x0+1)
+			count := (y1 - y0) * (x1 - x0)
+			for c := 0; c < components; c++ {
+				sum := 0
+				for sy := y0; sy < y1; sy++ {
+					row := sy * width * components
+					for sx := x0; sx < x1; sx++ {
+						sum += int(samples[row+sx*components+c])
+					}
+				}
+				out[(y*newWidth+x)*components+c] = byte((sum + count/2) / count)
+			}
+		}
+	}
+	return out
+}
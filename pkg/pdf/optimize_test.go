@@ -0,0 +1,206 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeImagePDF 写出单页测试文件：页面把300×300的RGB图像绘制为1英寸见方，并使用嵌入字体。
+// title 使不同文件的内容不同，图像和字体程序在各文件中相同
+func writeImagePDF(t *testing.T, dir, name, title string) string {
+	pixels := make([]byte, 0, 300*300*3)
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 300; x++ {
+			pixels = append(pixels, byte(x), byte(y), byte(x^y))
+		}
+	}
+	image := deflate(pixels)
+	font := strings.Repeat("glyph outline data ", 200)
+	content := "q 72 0 0 72 100 600 cm /Im1 Do Q BT /F1 12 Tf 72 720 Td (" + title + ") Tj ET"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R " +
+			"/Resources << /XObject << /Im1 5 0 R >> /Font << /F1 6 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 300 /Height 300 /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", len(image), image),
+		"<< /Type /Font /Subtype /TrueType /BaseFont /Test /FontDescriptor 7 0 R >>",
+		"<< /Type /FontDescriptor /FontName /Test /FontFile2 8 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(font), font),
+	})
+	return createTestFile(t, dir, name, data)
+}
+
+// mergeForOptimize 用内置后端合并a和b，返回合并后未优化的输出
+func mergeForOptimize(t *testing.T, dir string, a, b string) string {
+	t.Helper()
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	output := filepath.Join(dir, "merged.pdf")
+	_, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	return output
+}
+
+func TestOptimizePDF_DeduplicatesAndUsesObjectStreams(t *testing.T) {
+	dir := t.TempDir()
+	merged := mergeForOptimize(t, dir, writeImagePDF(t, dir, "a.pdf", "A"), writeImagePDF(t, dir, "b.pdf", "B"))
+	output := filepath.Join(dir, "optimized.pdf")
+
+	report, err := OptimizePDF(merged, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.DuplicateImages)
+	assert.Equal(t, 1, report.DuplicateFonts)
+	assert.Positive(t, report.ObjectStreams)
+	assert.Less(t, report.OptimizedSize, report.OriginalSize)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	version := headerVersionPattern.FindSubmatch(data)
+	require.NotNil(t, version)
+	assert.GreaterOrEqual(t, string(version[1]), "1.5", "对象流需要PDF 1.5")
+	assert.Contains(t, string(data), "/Type /XRef")
+	assert.Equal(t, 1, len(imageTypePattern.FindAll(data, -1)), "相同的图像应只保留一份")
+
+	pages, err := ReadPageCount(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	count, err := catalogPageCount(data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestOptimizePDF_ClassicXRef(t *testing.T) {
+	dir := t.TempDir()
+	merged := mergeForOptimize(t, dir, writeImagePDF(t, dir, "a.pdf", "A"), writeImagePDF(t, dir, "b.pdf", "B"))
+	output := filepath.Join(dir, "optimized.pdf")
+
+	report, err := OptimizePDF(merged, output, &OptimizeOptions{ClassicXRef: true})
+	require.NoError(t, err)
+	assert.Zero(t, report.ObjectStreams)
+	assert.Equal(t, 1, report.DuplicateImages)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/ObjStm")
+	assert.Contains(t, string(data), "\nxref\n")
+
+	// 传统结构可以继续线性化
+	require.NoError(t, LinearizeFile(output, output))
+	linearized, err := IsLinearized(output)
+	require.NoError(t, err)
+	assert.True(t, linearized)
+}
+
+func TestOptimizePDF_DownsamplesImages(t *testing.T) {
+	dir := t.TempDir()
+	input := writeImagePDF(t, dir, "a.pdf", "A")
+	output := filepath.Join(dir, "optimized.pdf")
+
+	report, err := OptimizePDF(input, output, &OptimizeOptions{ImageDPI: 150, ClassicXRef: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.DownsampledImages)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.True(t, regexp.MustCompile(`/Width 150\b`).Match(data), "1英寸见方绘制的图像在150 DPI下应为150像素")
+	assert.True(t, regexp.MustCompile(`/Height 150\b`).Match(data))
+
+	// 显示分辨率已经低于阈值的图像保持不变
+	report, err = OptimizePDF(input, output, &OptimizeOptions{ImageDPI: 600})
+	require.NoError(t, err)
+	assert.Zero(t, report.DownsampledImages)
+
+	_, err = OptimizePDF(input, output, &OptimizeOptions{ImageDPI: -1})
+	assert.Error(t, err)
+}
+
+func TestScanImageDraws_TracksTransforms(t *testing.T) {
+	content := []byte("% comment /Im9 Do\nq 2 0 0 2 0 0 cm (a ) Do) Tj q 50 0 0 25 10 10 cm /Im1 Do Q /Im2 Do Q " +
+		"BI /W 1 /H 1 ID \x00\x01 EI /Im3 Do")
+	var draws []string
+	scanImageDraws(content, func(name string, ctm affine) {
+		draws = append(draws, fmt.Sprintf("%s %g %g", name, ctm[0], ctm[3]))
+	})
+	assert.Equal(t, []string{"Im1 100 50", "Im2 2 2", "Im3 1 1"}, draws)
+}
+
+func TestHasDigitalSignatures(t *testing.T) {
+	dir := t.TempDir()
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	signed := createTestFile(t, dir, "signed.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /SigFlags 3 >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R] >>",
+		"<< /FT /Sig /Type /Annot /Subtype /Widget /Rect [0 0 0 0] /V 5 0 R >>",
+		"<< /Type /Sig /Filter /Adobe.PPKLite /ByteRange [0 10 20 30] /Contents <00> >>",
+	}))
+
+	found, err := HasDigitalSignatures(plain)
+	require.NoError(t, err)
+	assert.False(t, found)
+	found, err = HasDigitalSignatures(signed)
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestMergeFiles_OptimizeOutput(t *testing.T) {
+	dir := t.TempDir()
+	a := writeImagePDF(t, dir, "a.pdf", "A")
+	b := writeImagePDF(t, dir, "b.pdf", "B")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:     dir,
+		BackendStats:      NewBackendStatsStore(),
+		OptimizeOutput:    true,
+		OptimizeImagesDPI: 150,
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, 2, result.TotalPages)
+	assert.Positive(t, result.OptimizedSize)
+	assert.Less(t, result.OptimizedSize, result.OriginalSize)
+
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	assert.Equal(t, result.OptimizedSize, info.Size())
+}
+
+func TestMergeFiles_OptimizeSkippedForSignedInputs(t *testing.T) {
+	dir := t.TempDir()
+	a := writeImagePDF(t, dir, "a.pdf", "A")
+	signed := createTestFile(t, dir, "signed.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Sig /ByteRange [0 10 20 30] /Contents <00> >>",
+	}))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:  dir,
+		BackendStats:   NewBackendStatsStore(),
+		OptimizeOutput: true,
+	})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{a, signed}, filepath.Join(dir, "out.pdf"), nil)
+	require.NoError(t, err)
+	assert.Zero(t, result.OptimizedSize)
+	assert.Contains(t, result.Warnings, fmt.Sprintf("输入 %s 包含数字签名，跳过输出优化", signed))
+}
+
+func TestMergeOptions_ValidateOptimize(t *testing.T) {
+	assert.NoError(t, (&MergeOptions{OptimizeOutput: true, OptimizeImagesDPI: 150}).Validate())
+	assert.Error(t, (&MergeOptions{OptimizeImagesDPI: 150}).Validate(), "降采样需要启用输出优化")
+	assert.Error(t, (&MergeOptions{OptimizeOutput: true, OptimizeImagesDPI: -1}).Validate())
+}
@@ -0,0 +1,174 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OutputPermissions 加密输出允许的操作，与PDFInfo中的权限标志一一对应
+type OutputPermissions struct {
+	Print            bool // 打印
+	Modify           bool // 修改内容
+	Copy             bool // 复制文本和图像
+	Annotate         bool // 添加注释
+	FillForms        bool // 填写表单
+	Extract          bool // 为辅助功能提取内容
+	Assemble         bool // 组装文档（插入、旋转、删除页面）
+	PrintHighQuality bool // 高质量打印
+}
+
+// AllOutputPermissions 返回允许全部操作的权限，未指定权限时加密输出使用此设置
+func AllOutputPermissions() *OutputPermissions {
+	return &OutputPermissions{
+		Print: true, Modify: true, Copy: true, Annotate: true,
+		FillForms: true, Extract: true, Assemble: true, PrintHighQuality: true,
+	}
+}
+
+// ParseOutputPermissions 解析逗号分隔的权限列表，名称与PDFInfo.GetPermissionFlags一致
+// （print、modify、copy、annotate、fill_forms、extract、assemble、print_high_quality），
+// 也接受 fill、print_high 简写以及 all、none。
+func ParseOutputPermissions(list string) (*OutputPermissions, error) {
+	permissions := &OutputPermissions{}
+	for _, name := range strings.Split(list, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "none":
+		case "all":
+			permissions = AllOutputPermissions()
+		case "print":
+			permissions.Print = true
+		case "modify":
+			permissions.Modify = true
+		case "copy":
+			permissions.Copy = true
+		case "annotate":
+			permissions.Annotate = true
+		case "fill", "fill_forms":
+			permissions.FillForms = true
+		case "extract":
+			permissions.Extract = true
+		case "assemble":
+			permissions.Assemble = true
+		case "print_high", "print_high_quality":
+			permissions.PrintHighQuality = true
+		default:
+			return nil, &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("未知的权限: %s", strings.TrimSpace(name)),
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// Flags 返回PDF标准安全处理程序 /P 条目中的权限位（第3-6、9-12位）
+func (p *OutputPermissions) Flags() int {
+	bits := []struct {
+		allowed bool
+		bit     uint
+	}{
+		{p.Print, 3}, {p.Modify, 4}, {p.Copy, 5}, {p.Annotate, 6},
+		{p.FillForms, 9}, {p.Extract, 10}, {p.Assemble, 11}, {p.PrintHighQuality, 12},
+	}
+	flags := 0
+	for _, b := range bits {
+		if b.allowed {
+			flags |= 1 << (b.bit - 1)
+		}
+	}
+	return flags
+}
+
+// cliValue 返回pdfcpu -perm 参数：none、all 或十六进制的权限位
+func (p *OutputPermissions) cliValue() string {
+	switch flags := p.Flags(); flags {
+	case 0:
+		return "none"
+	case AllOutputPermissions().Flags():
+		return "all"
+	default:
+		return fmt.Sprintf("0x%X", flags)
+	}
+}
+
+// outputEncryption 合并输出的加密设置
+type outputEncryption struct {
+	userPassword  string
+	ownerPassword string
+	permissions   *OutputPermissions
+}
+
+// newOutputEncryption 根据选项创建加密设置，两个密码都为空时返回nil（不加密）。
+// 未指定所有者密码时使用用户密码，未指定权限时允许全部操作。
+func newOutputEncryption(userPassword, ownerPassword string, permissions *OutputPermissions) *outputEncryption {
+	if userPassword == "" && ownerPassword == "" {
+		return nil
+	}
+	if ownerPassword == "" {
+		ownerPassword = userPassword
+	}
+	if permissions == nil {
+		permissions = AllOutputPermissions()
+	}
+	return &outputEncryption{
+		userPassword:  userPassword,
+		ownerPassword: ownerPassword,
+		permissions:   permissions,
+	}
+}
+
+// validationPassword 返回验证输出时使用的密码：所有者密码可以打开加密输出，不加密时为空
+func (e *outputEncryption) validationPassword() string {
+	if e == nil {
+		return ""
+	}
+	return e.ownerPassword
+}
+
+// validateEncryptionOptions 检查加密选项：设置了权限时必须提供密码
+func validateEncryptionOptions(userPassword, ownerPassword string, permissions *OutputPermissions) error {
+	if permissions != nil && userPassword == "" && ownerPassword == "" {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "设置输出权限需要提供用户密码或所有者密码",
+		}
+	}
+	return nil
+}
+
+// encryptInPlace 把filePath加密后替换原文件，并确认结果确实已加密。
+// 加密失败时原文件保持不变；加密后端不可用时返回错误而不是写出未加密的输出。
+func encryptInPlace(backend fileBackend, filePath string, encryption *outputEncryption) error {
+	encrypted := filePath + ".encrypt.tmp"
+	err := backend.EncryptFile(filePath, encrypted, encryption)
+	if err == nil && !fileExists(encrypted) {
+		err = errors.New("加密后没有生成文件")
+	}
+	if err == nil {
+		var ok bool
+		if ok, err = hasEncryptEntry(encrypted); err == nil && !ok {
+			err = errors.New("加密后的文件没有加密字典")
+		}
+	}
+	if err != nil {
+		os.Remove(encrypted)
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法加密输出文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(encrypted, filePath); err != nil {
+		os.Remove(encrypted)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换加密后的输出文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
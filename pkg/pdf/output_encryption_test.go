@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEncrypt 返回假的加密后端：在输入的trailer中加入加密字典引用，并记录收到的设置
+func fakeEncrypt(err error) (*fakeFileBackend, *outputEncryption) {
+	got := &outputEncryption{}
+	backend := &fakeFileBackend{encrypt: func(inputFile, outputFile string, encryption *outputEncryption) error {
+		*got = *encryption
+		if err != nil {
+			return err
+		}
+		data, readErr := os.ReadFile(inputFile)
+		if readErr != nil {
+			return readErr
+		}
+		return os.WriteFile(outputFile, bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 99 0 R"), 1), 0644)
+	}}
+	return backend, got
+}
+
+func TestParseOutputPermissions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  OutputPermissions
+		flags int
+	}{
+		{"print,copy", OutputPermissions{Print: true, Copy: true}, 0x14},
+		{" Print , fill ,print_high", OutputPermissions{Print: true, FillForms: true, PrintHighQuality: true}, 0x904},
+		{"none", OutputPermissions{}, 0},
+		{"all", *AllOutputPermissions(), 0xF3C},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOutputPermissions(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+			assert.Equal(t, tt.flags, got.Flags())
+		})
+	}
+
+	_, err := ParseOutputPermissions("print,rotate")
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.Contains(t, pdfErr.Message, "rotate")
+}
+
+func TestOutputPermissions_CLIValue(t *testing.T) {
+	assert.Equal(t, "none", (&OutputPermissions{}).cliValue())
+	assert.Equal(t, "all", AllOutputPermissions().cliValue())
+	assert.Equal(t, "0x14", (&OutputPermissions{Print: true, Copy: true}).cliValue())
+}
+
+func TestMergeOptions_ValidateEncryption(t *testing.T) {
+	options := &MergeOptions{OutputPermissions: &OutputPermissions{Print: true}}
+	assert.Error(t, options.Validate(), "只设置权限而没有密码时应拒绝")
+
+	options = &MergeOptions{OutputUserPassword: "secret", ReviewCopy: true}
+	assert.Error(t, options.Validate(), "加密输出不能生成审阅副本")
+
+	options = &MergeOptions{OutputOwnerPassword: "admin", OutputPermissions: &OutputPermissions{Print: true}, Linearize: true}
+	assert.NoError(t, options.Validate())
+}
+
+func TestMergeStreaming_EncryptsOutput(t *testing.T) {
+	backend, got := fakeEncrypt(nil)
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "in.pdf", buildFlatPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:      dir,
+		BackendStats:       NewBackendStatsStore(),
+		OutputUserPassword: "secret",
+		OutputPermissions:  &OutputPermissions{Print: true},
+	})
+	merger.adapter = nil
+	merger.fileBackend = backend
+	result, err := merger.MergeStreaming(context.Background(), []string{input}, output, nil)
+	require.NoError(t, err)
+
+	assert.True(t, result.Encrypted)
+	encrypted, err := hasEncryptEntry(output)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+	assert.Equal(t, outputEncryption{
+		userPassword:  "secret",
+		ownerPassword: "secret",
+		permissions:   &OutputPermissions{Print: true},
+	}, *got, "未指定所有者密码时应使用用户密码")
+}
+
+func TestMergeStreaming_EncryptionFailureKeepsPreviousOutput(t *testing.T) {
+	backend, _ := fakeEncrypt(errors.New("encryption failed"))
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "in.pdf", buildFlatPDF(1))
+	output := createTestFile(t, dir, "out.pdf", []byte("previous"))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:      dir,
+		BackendStats:       NewBackendStatsStore(),
+		OutputUserPassword: "secret",
+	})
+	merger.adapter = nil
+	merger.fileBackend = backend
+	result, err := merger.MergeStreaming(context.Background(), []string{input}, output, nil)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorProcessing, pdfErr.Type)
+	require.NotNil(t, result)
+	assert.Equal(t, MergeStageMerging, result.FailedStage)
+	assert.False(t, result.Encrypted)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data), "加密失败时不应写出未加密的输出")
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
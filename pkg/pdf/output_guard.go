@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// caseInsensitivePaths Windows和macOS的默认文件系统不区分文件名大小写
+var caseInsensitivePaths = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// resolvePath 返回解析符号链接后的绝对路径；文件不存在时只解析所在目录
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return filepath.Clean(path)
+}
+
+// SamePath 两个路径是否指向同一个文件：解析符号链接后比较，Windows和macOS上不区分大小写；
+// 两个文件都存在时还能识别硬链接
+func SamePath(a, b string) bool {
+	ra, rb := resolvePath(a), resolvePath(b)
+	if ra == rb || (caseInsensitivePaths && strings.EqualFold(ra, rb)) {
+		return true
+	}
+	if infoA, err := os.Stat(a); err == nil {
+		if infoB, err := os.Stat(b); err == nil {
+			return os.SameFile(infoA, infoB)
+		}
+	}
+	return false
+}
+
+// CheckOutputNotInput 确认输出路径不是任何一个输入文件。合并先备份再覆盖输出，
+// 输出就是输入时会读到写了一半的输入，因此在开始合并前以 ErrorInvalidInput 拒绝
+func CheckOutputNotInput(outputPath string, inputs []string) error {
+	for _, input := range inputs {
+		if SamePath(outputPath, input) {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: "输出路径不能与输入文件相同",
+				File:    input,
+			}
+		}
+	}
+	return nil
+}
+
+// SuggestOutputPath 在输出所在目录中返回一个不与输入文件重名且尚不存在的路径，
+// 例如 report.pdf 与输入相同时返回 report_merged.pdf，已存在时依次尝试 report_merged_2.pdf……
+func SuggestOutputPath(outputPath string, inputs []string) string {
+	used := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		used[strings.ToLower(input)] = true
+	}
+	dir := filepath.Dir(outputPath)
+	name := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	for {
+		candidate := uniqueOutputPath(dir, name+"_merged", used)
+		if CheckOutputNotInput(candidate, inputs) == nil {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamePath(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "input.pdf", 1)
+	link := filepath.Join(dir, "link.pdf")
+	require.NoError(t, os.Symlink(input, link))
+	linkedDir := filepath.Join(t.TempDir(), "linked")
+	require.NoError(t, os.Symlink(dir, linkedDir))
+
+	assert.True(t, SamePath(input, filepath.Join(dir, ".", "input.pdf")))
+	assert.True(t, SamePath(link, input), "符号链接指向输入文件")
+	assert.True(t, SamePath(filepath.Join(linkedDir, "input.pdf"), input), "所在目录是符号链接")
+	assert.True(t, SamePath(filepath.Join(linkedDir, "new.pdf"), filepath.Join(dir, "new.pdf")), "不存在的文件只解析目录")
+	assert.False(t, SamePath(filepath.Join(dir, "other.pdf"), input))
+
+	upper := filepath.Join(dir, "INPUT.pdf")
+	caseInsensitivePaths = true
+	defer func() { caseInsensitivePaths = false }()
+	assert.True(t, SamePath(upper, input), "不区分大小写的系统上只有大小写不同的路径相同")
+}
+
+func TestCheckOutputNotInput(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "input.pdf", 1)
+	link := filepath.Join(dir, "link.pdf")
+	require.NoError(t, os.Symlink(input, link))
+
+	assert.NoError(t, CheckOutputNotInput(filepath.Join(dir, "out.pdf"), []string{input}))
+
+	err := CheckOutputNotInput(link, []string{filepath.Join(dir, "other.pdf"), input})
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.Equal(t, input, pdfErr.File)
+
+	// 建议的路径不与输入或已有文件重名
+	writeNumberedPDF(t, dir, "input_merged.pdf", 1)
+	assert.Equal(t, filepath.Join(dir, "input_merged_2.pdf"), SuggestOutputPath(input, []string{input}))
+}
+
+func TestStreamingMerger_RejectsOutputThatIsInput(t *testing.T) {
+	dir := t.TempDir()
+	first := writeNumberedPDF(t, dir, "first.pdf", 1)
+	second := writeNumberedPDF(t, dir, "second.pdf", 1)
+	link := filepath.Join(dir, "link.pdf")
+	require.NoError(t, os.Symlink(second, link))
+	before, err := os.ReadFile(second)
+	require.NoError(t, err)
+
+	merger := NewStreamingMerger(nil)
+	defer merger.Close()
+
+	_, err = merger.MergeFiles([]string{first, second}, link, nil)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+
+	// 服务在备份输出之前拒绝，不会留下输入的备份
+	config := DefaultServiceConfig()
+	config.BackupOutput = true
+	config.BackupDirectory = filepath.Join(dir, "backups")
+	err = NewPDFServiceWithConfig(config).MergePDFs(first, []string{second}, link, nil)
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	assert.NoDirExists(t, config.BackupDirectory)
+
+	after, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "输入文件不应被改动")
+}
@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// outputSizePollInterval 合并期间检查临时输出大小的间隔
+const outputSizePollInterval = 200 * time.Millisecond
+
+// checkInputBudget 合并前检查有效输入的大小之和与页数之和是否超出输出上限。
+// files 为实际参与合并的文件（修复副本、解密副本），页数取自countInputPages记录的result.InputPages，
+// 无法统计页数的输入不计入。
+func (sm *StreamingMerger) checkInputBudget(result *MergeResult, files []string) error {
+	if sm.maxOutputBytes > 0 {
+		if total := totalInputBytes(files); total > sm.maxOutputBytes {
+			return &PDFError{
+				Type:    ErrorLimitExceeded,
+				Message: fmt.Sprintf("输入文件共 %d 字节，超出输出大小上限 %d 字节", total, sm.maxOutputBytes),
+			}
+		}
+	}
+	if sm.maxOutputPages > 0 {
+		total := 0
+		for _, input := range result.InputPages {
+			total += input.Pages
+		}
+		if total > sm.maxOutputPages {
+			return &PDFError{
+				Type:    ErrorLimitExceeded,
+				Message: fmt.Sprintf("输入文件共 %d 页，超出输出页数上限 %d 页", total, sm.maxOutputPages),
+			}
+		}
+	}
+	return nil
+}
+
+// checkOutputSize 检查写出的临时输出是否超出大小上限，文件不存在时不检查
+func (sm *StreamingMerger) checkOutputSize(staging string) error {
+	if sm.maxOutputBytes <= 0 {
+		return nil
+	}
+	if info, err := os.Stat(staging); err == nil && info.Size() > sm.maxOutputBytes {
+		return sm.outputSizeError(staging, info.Size())
+	}
+	return nil
+}
+
+// outputSizeError 临时输出超出大小上限的错误
+func (sm *StreamingMerger) outputSizeError(staging string, size int64) error {
+	return &PDFError{
+		Type:    ErrorLimitExceeded,
+		Message: fmt.Sprintf("合并输出已达到 %d 字节，超出输出大小上限 %d 字节", size, sm.maxOutputBytes),
+		File:    staging,
+	}
+}
+
+// watchOutputSize 在合并期间按outputSizePollInterval检查staging及后端在其旁边写入的临时文件，
+// 大小超出上限时取消返回的ctx。没有设置大小上限时原样返回ctx。
+// 合并结束后必须调用stop，它停止检查并在曾经超出上限时返回ErrorLimitExceeded错误。
+func (sm *StreamingMerger) watchOutputSize(ctx context.Context, staging string) (context.Context, func() error) {
+	if sm.maxOutputBytes <= 0 {
+		return ctx, func() error { return nil }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var exceeded atomic.Int64
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(outputSizePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if size := stagingSize(staging); size > sm.maxOutputBytes {
+					exceeded.Store(size)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, func() error {
+		close(done)
+		<-stopped
+		cancel()
+		if size := exceeded.Load(); size > 0 {
+			return sm.outputSizeError(staging, size)
+		}
+		return nil
+	}
+}
+
+// stagingSize 返回staging及以其文件名为前缀的临时文件中最大的大小。
+// 后端先写入staging旁的临时文件（见runInterruptible），完成后才改名为staging。
+func stagingSize(staging string) int64 {
+	dir := filepath.Dir(staging)
+	prefix := strings.TrimSuffix(filepath.Base(staging), filepath.Ext(staging))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var largest int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Size() > largest {
+			largest = info.Size()
+		}
+	}
+	return largest
+}
+
+// isLimitExceeded 判断错误链中是否有ErrorLimitExceeded
+func isLimitExceeded(err error) bool {
+	var pdfErr *PDFError
+	return errors.As(err, &pdfErr) && pdfErr.Type == ErrorLimitExceeded
+}
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireLimitExceeded(t *testing.T, err error) {
+	t.Helper()
+	var pdfErr *PDFError
+	require.True(t, errors.As(err, &pdfErr), "期望PDFError，得到 %v", err)
+	assert.Equal(t, ErrorLimitExceeded, pdfErr.Type)
+}
+
+func TestMergeStreaming_OutputBudget(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(3))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(4))
+	inputs := []string{a, b}
+
+	t.Run("页数超出上限", func(t *testing.T) {
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore(), MaxOutputPages: 6})
+		defer merger.Close()
+		output := filepath.Join(dir, "pages.pdf")
+		result, err := merger.MergeStreaming(context.Background(), inputs, output, nil)
+		requireLimitExceeded(t, err)
+		assert.Contains(t, err.Error(), "7 页")
+		assert.Equal(t, MergeStageValidation, result.FailedStage)
+		assert.NoFileExists(t, output)
+	})
+
+	t.Run("大小超出上限", func(t *testing.T) {
+		limit := int64(len(buildFlatPDF(3)) + len(buildFlatPDF(4)) - 1)
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore(), MaxOutputSizeBytes: limit})
+		defer merger.Close()
+		output := filepath.Join(dir, "size.pdf")
+		_, err := merger.MergeFiles(inputs, output, nil)
+		requireLimitExceeded(t, err)
+		assert.NoFileExists(t, output)
+	})
+
+	t.Run("未超出上限", func(t *testing.T) {
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore(),
+			MaxOutputPages: 7, MaxOutputSizeBytes: 1 << 20})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), inputs, filepath.Join(dir, "ok.pdf"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 7, result.TotalPages)
+	})
+}
+
+func TestWatchOutputSize_CancelsWhenTempOutputGrows(t *testing.T) {
+	dir := t.TempDir()
+	staging := filepath.Join(dir, "out_temp_1_ab.pdf")
+	merger := &StreamingMerger{maxOutputBytes: 100}
+
+	ctx, stop := merger.watchOutputSize(context.Background(), staging)
+	// 后端在staging旁写入的临时文件也计入
+	partial := strings.TrimSuffix(staging, ".pdf") + "_temp_2_cd.pdf"
+	require.NoError(t, os.WriteFile(partial, make([]byte, 101), 0644))
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("临时输出超出上限后应取消合并")
+	}
+	requireLimitExceeded(t, stop())
+}
+
+func TestWatchOutputSize_NoLimit(t *testing.T) {
+	merger := &StreamingMerger{}
+	ctx := context.Background()
+	watched, stop := merger.watchOutputSize(ctx, filepath.Join(t.TempDir(), "out.pdf"))
+	assert.Equal(t, ctx, watched)
+	assert.NoError(t, stop())
+}
+
+func TestPDFService_MergeRespectsOutputLimits(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(3))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(4))
+
+	config := DefaultServiceConfig()
+	config.TempDirectory = dir
+	config.MaxOutputPages = 5
+	service := NewPDFServiceWithConfig(config)
+
+	// 超出上限时不回退到不检查上限的其他合并方式
+	output := filepath.Join(dir, "out.pdf")
+	err := service.MergePDFs(a, []string{b}, output, nil)
+	requireLimitExceeded(t, err)
+	assert.NotNil(t, PartialMergeResult(err))
+	assert.NoFileExists(t, output)
+}
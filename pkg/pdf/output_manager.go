@@ -5,7 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // OutputManager 输出路径管理器
@@ -15,15 +16,17 @@ type OutputManager struct {
 	autoIncrement   bool
 	timestampSuffix bool
 	backupEnabled   bool
+	clock           clock.Clock
 }
 
 // OutputOptions 输出选项
 type OutputOptions struct {
-	BaseDirectory   string // 基础输出目录
-	DefaultFileName string // 默认文件名
-	AutoIncrement   bool   // 自动递增文件名
-	TimestampSuffix bool   // 添加时间戳后缀
-	BackupEnabled   bool   // 启用备份
+	BaseDirectory   string      // 基础输出目录
+	DefaultFileName string      // 默认文件名
+	AutoIncrement   bool        // 自动递增文件名
+	TimestampSuffix bool        // 添加时间戳后缀
+	BackupEnabled   bool        // 启用备份
+	Clock           clock.Clock // 时间戳后缀和备份文件名的时间来源，nil时使用系统时钟
 }
 
 // OutputInfo 输出信息
@@ -53,6 +56,7 @@ func NewOutputManager(options *OutputOptions) *OutputManager {
 		autoIncrement:   options.AutoIncrement,
 		timestampSuffix: options.TimestampSuffix,
 		backupEnabled:   options.BackupEnabled,
+		clock:           clock.OrSystem(options.Clock),
 	}
 }
 
@@ -129,7 +133,7 @@ func (om *OutputManager) validatePath(path string) error {
 func (om *OutputManager) addTimestampSuffix(path string) string {
 	ext := filepath.Ext(path)
 	nameWithoutExt := strings.TrimSuffix(path, ext)
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := om.clock.Now().Format("20060102_150405")
 	return fmt.Sprintf("%s_%s%s", nameWithoutExt, timestamp, ext)
 }
 
@@ -150,7 +154,7 @@ func (om *OutputManager) resolveAutoIncrement(path string) (string, bool) {
 	}
 
 	// 如果找不到可用的递增名称，使用时间戳
-	timestamp := time.Now().Format("20060102_150405_000")
+	timestamp := om.clock.Now().Format("20060102_150405_000")
 	return fmt.Sprintf("%s_%s%s", nameWithoutExt, timestamp, ext), true
 }
 
@@ -158,7 +162,7 @@ func (om *OutputManager) resolveAutoIncrement(path string) (string, bool) {
 func (om *OutputManager) generateBackupPath(path string) string {
 	ext := filepath.Ext(path)
 	nameWithoutExt := strings.TrimSuffix(path, ext)
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := om.clock.Now().Format("20060102_150405")
 	return fmt.Sprintf("%s_backup_%s%s", nameWithoutExt, timestamp, ext)
 }
 
@@ -170,7 +174,7 @@ func (om *OutputManager) ensureDirectoryWritable(dir string) error {
 	}
 
 	// 测试写入权限
-	testFile := filepath.Join(dir, ".write_test_"+time.Now().Format("20060102150405"))
+	testFile := filepath.Join(dir, ".write_test_"+om.clock.Now().Format("20060102150405"))
 	file, err := os.Create(testFile)
 	if err != nil {
 		return err
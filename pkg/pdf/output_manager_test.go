@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestNewOutputManager(t *testing.T) {
@@ -182,6 +185,23 @@ func TestOutputManager_TimestampSuffix(t *testing.T) {
 	}
 }
 
+func TestOutputManager_TimestampsFromClock(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewOutputManager(&OutputOptions{
+		BaseDirectory:   dir,
+		TimestampSuffix: true,
+		Clock:           clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), 1),
+	})
+
+	path := filepath.Join(dir, "report.pdf")
+	if got, want := manager.addTimestampSuffix(path), filepath.Join(dir, "report_20240506_070809.pdf"); got != want {
+		t.Errorf("时间戳路径应为 %s，实际: %s", want, got)
+	}
+	if got, want := manager.generateBackupPath(path), filepath.Join(dir, "report_backup_20240506_070809.pdf"); got != want {
+		t.Errorf("备份路径应为 %s，实际: %s", want, got)
+	}
+}
+
 func TestOutputManager_BackupOperations(t *testing.T) {
 	testDir := filepath.Join(os.TempDir(), "backup_test")
 	err := os.MkdirAll(testDir, 0755)
@@ -0,0 +1,313 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// boxTolerance 比较页面框坐标时允许的误差（点）
+const boxTolerance = 0.001
+
+var (
+	cropBoxPattern = regexp.MustCompile(`/CropBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	trimBoxPattern = regexp.MustCompile(`/TrimBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+
+	// pageBoxKeyPattern 匹配页面对象中要替换的页面框条目（内联数组或间接引用）
+	pageBoxKeyPattern = regexp.MustCompile(`/(MediaBox|CropBox|TrimBox)\s*(\[[^\]]*\]|\d+\s+\d+\s+R)`)
+)
+
+// PageBoxes 页面的有效页面框，坐标为 llx lly urx ury（点）
+type PageBoxes struct {
+	MediaBox [4]float64 `json:"media_box"`
+	CropBox  [4]float64 `json:"crop_box"` // 未设置时等于MediaBox
+	TrimBox  [4]float64 `json:"trim_box"` // 未设置时等于CropBox
+}
+
+// Width 返回页面的显示宽度（CropBox宽度）
+func (b PageBoxes) Width() float64 {
+	return b.CropBox[2] - b.CropBox[0]
+}
+
+// Height 返回页面的显示高度（CropBox高度）
+func (b PageBoxes) Height() float64 {
+	return b.CropBox[3] - b.CropBox[1]
+}
+
+// PageBoxAdjustment 单个输入的页面框调整，按以下顺序应用：
+//  1. CropToMedia：把CropBox（与MediaBox相交后）设为新的MediaBox；
+//  2. CropBox 设为显式值，或在当前CropBox基础上四边各向内收缩 CropMargin；
+//  3. TrimBox 设为显式值，或在调整后的CropBox基础上四边各向内收缩 TrimMargin。
+//
+// 调整后的CropBox和TrimBox必须面积为正且位于MediaBox之内。
+type PageBoxAdjustment struct {
+	CropBox     *[4]float64 `json:"crop_box,omitempty"`
+	CropMargin  float64     `json:"crop_margin,omitempty"`
+	TrimBox     *[4]float64 `json:"trim_box,omitempty"`
+	TrimMargin  float64     `json:"trim_margin,omitempty"`
+	CropToMedia bool        `json:"crop_to_media,omitempty"`
+}
+
+// IsZero 判断调整是否为空
+func (a *PageBoxAdjustment) IsZero() bool {
+	return a == nil || (a.CropBox == nil && a.CropMargin == 0 && a.TrimBox == nil && a.TrimMargin == 0 && !a.CropToMedia)
+}
+
+// Validate 检查调整参数本身是否有效，与具体页面无关
+func (a *PageBoxAdjustment) Validate() error {
+	if a == nil {
+		return nil
+	}
+	invalid := func(message string) error {
+		return &PDFError{Type: ErrorInvalidInput, Message: message}
+	}
+	if a.CropBox != nil && a.CropMargin != 0 {
+		return invalid("不能同时指定CropBox和裁切边距")
+	}
+	if a.TrimBox != nil && a.TrimMargin != 0 {
+		return invalid("不能同时指定TrimBox和成品边距")
+	}
+	if a.CropMargin < 0 || a.TrimMargin < 0 {
+		return invalid("页面框边距不能为负数")
+	}
+	return nil
+}
+
+// Apply 计算调整后的页面框。结果面积不为正或超出MediaBox时返回错误。
+func (a *PageBoxAdjustment) Apply(boxes PageBoxes) (PageBoxes, error) {
+	if err := a.Validate(); err != nil {
+		return boxes, err
+	}
+	if a.IsZero() {
+		return boxes, nil
+	}
+
+	if a.CropToMedia {
+		media, ok := intersectBox(boxes.CropBox, boxes.MediaBox)
+		if !ok {
+			return boxes, fmt.Errorf("CropBox与MediaBox不相交")
+		}
+		boxes.MediaBox = media
+		boxes.CropBox = media
+		if trim, ok := intersectBox(boxes.TrimBox, media); ok {
+			boxes.TrimBox = trim
+		} else {
+			boxes.TrimBox = media
+		}
+	}
+
+	switch {
+	case a.CropBox != nil:
+		boxes.CropBox = *a.CropBox
+	case a.CropMargin > 0:
+		boxes.CropBox = insetBox(boxes.CropBox, a.CropMargin)
+	}
+	if err := checkBoxWithin("CropBox", boxes.CropBox, boxes.MediaBox); err != nil {
+		return boxes, err
+	}
+
+	switch {
+	case a.TrimBox != nil:
+		boxes.TrimBox = *a.TrimBox
+	case a.TrimMargin > 0:
+		boxes.TrimBox = insetBox(boxes.CropBox, a.TrimMargin)
+	case a.CropBox != nil || a.CropMargin > 0:
+		// 成品框不应超出新的裁切框
+		if trim, ok := intersectBox(boxes.TrimBox, boxes.CropBox); ok {
+			boxes.TrimBox = trim
+		} else {
+			boxes.TrimBox = boxes.CropBox
+		}
+	}
+	if err := checkBoxWithin("TrimBox", boxes.TrimBox, boxes.MediaBox); err != nil {
+		return boxes, err
+	}
+	return boxes, nil
+}
+
+// insetBox 四边各向内收缩margin
+func insetBox(box [4]float64, margin float64) [4]float64 {
+	return [4]float64{box[0] + margin, box[1] + margin, box[2] - margin, box[3] - margin}
+}
+
+// intersectBox 返回两个框的交集，不相交时返回false
+func intersectBox(a, b [4]float64) ([4]float64, bool) {
+	box := [4]float64{
+		max(a[0], b[0]), max(a[1], b[1]),
+		min(a[2], b[2]), min(a[3], b[3]),
+	}
+	return box, box[2] > box[0] && box[3] > box[1]
+}
+
+// checkBoxWithin 检查页面框面积为正且位于MediaBox之内
+func checkBoxWithin(name string, box, media [4]float64) error {
+	if box[2]-box[0] <= boxTolerance || box[3]-box[1] <= boxTolerance {
+		return fmt.Errorf("%s %s 面积不为正", name, formatBox(box))
+	}
+	if box[0] < media[0]-boxTolerance || box[1] < media[1]-boxTolerance ||
+		box[2] > media[2]+boxTolerance || box[3] > media[3]+boxTolerance {
+		return fmt.Errorf("%s %s 超出MediaBox %s", name, formatBox(box), formatBox(media))
+	}
+	return nil
+}
+
+// formatBox 以PDF数组格式输出页面框
+func formatBox(box [4]float64) string {
+	parts := make([]string, 4)
+	for i, v := range box {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// ReadPageBoxes 读取每一页的有效MediaBox、CropBox和TrimBox。
+// 与 ReadPageGeometry 相同，不支持对象流中的页面对象。
+func ReadPageBoxes(filePath string) ([]PageBoxes, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	_, boxes, err := readPageBoxes(filePath, data)
+	return boxes, err
+}
+
+// readPageBoxes 返回页面对象编号及对应的有效页面框
+func readPageBoxes(filePath string, data []byte) ([]int, []PageBoxes, error) {
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offsets := indexObjects(data)
+	boxes := make([]PageBoxes, len(stats.Pages))
+	for i, objNum := range stats.Pages {
+		body, _ := objectBody(data, offsets, objNum)
+		b := PageBoxes{MediaBox: [4]float64{0, 0, 612, 792}}
+		if media, ok := inheritedMediaBox(data, offsets, body); ok {
+			b.MediaBox = media
+		}
+		// CropBox可继承，超出MediaBox的部分按规范裁掉
+		b.CropBox = b.MediaBox
+		if crop, ok := inheritedBox(data, offsets, body, cropBoxPattern); ok {
+			if crop, ok = intersectBox(crop, b.MediaBox); ok {
+				b.CropBox = crop
+			}
+		}
+		// TrimBox不可继承
+		b.TrimBox = b.CropBox
+		if m := trimBoxPattern.FindSubmatch(body); m != nil {
+			if trim, ok := parseBox(m); ok {
+				if trim, ok = intersectBox(trim, b.CropBox); ok {
+					b.TrimBox = trim
+				}
+			}
+		}
+		boxes[i] = b
+	}
+	return stats.Pages, boxes, nil
+}
+
+// PreviewPageBoxes 计算调整后每一页的页面框而不写入文件，结果与 SetPageBoxes 写出的一致
+func PreviewPageBoxes(filePath string, adjustment *PageBoxAdjustment) ([]PageBoxes, error) {
+	boxes, err := ReadPageBoxes(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return applyPageBoxes(filePath, boxes, adjustment)
+}
+
+// applyPageBoxes 对每一页应用调整，错误中包含页码
+func applyPageBoxes(filePath string, boxes []PageBoxes, adjustment *PageBoxAdjustment) ([]PageBoxes, error) {
+	if err := adjustment.Validate(); err != nil {
+		return nil, err
+	}
+	adjusted := make([]PageBoxes, len(boxes))
+	for i, b := range boxes {
+		result, err := adjustment.Apply(b)
+		if err != nil {
+			return nil, &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("第%d页的页面框调整无效", i+1),
+				File:    filePath,
+				Cause:   err,
+			}
+		}
+		adjusted[i] = result
+	}
+	return adjusted, nil
+}
+
+// SetPageBoxes 按调整设置每一页的MediaBox、CropBox和TrimBox，以增量更新写入outputPath。
+// 输入与输出可以是同一路径。不支持加密文件和对象流中的页面对象。
+func SetPageBoxes(inputPath, outputPath string, adjustment *PageBoxAdjustment) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法调整加密文件的页面框",
+			File:    inputPath,
+		}
+	}
+
+	pages, boxes, err := readPageBoxes(inputPath, data)
+	if err != nil {
+		return err
+	}
+	adjusted, err := applyPageBoxes(inputPath, boxes, adjustment)
+	if err != nil {
+		return err
+	}
+
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	if !adjustment.IsZero() {
+		for i, objNum := range pages {
+			body, _ := objectBody(data, offsets, objNum)
+			update.set(objNum, withPageBoxes(body, adjusted[i]))
+		}
+	}
+
+	tempPath := outputPath + ".boxes.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入页面框调整结果",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// withPageBoxes 返回写入了显式页面框的页面对象内容，替换页面上已有的页面框条目
+func withPageBoxes(body []byte, boxes PageBoxes) string {
+	page := pageBoxKeyPattern.ReplaceAllString(string(bytes.TrimSpace(body)), "")
+	entries := fmt.Sprintf("<< /MediaBox %s /CropBox %s /TrimBox %s",
+		formatBox(boxes.MediaBox), formatBox(boxes.CropBox), formatBox(boxes.TrimBox))
+	return strings.Replace(page, "<<", entries, 1)
+}
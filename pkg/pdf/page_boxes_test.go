@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBoxedPDF 写出两页测试文件：MediaBox继承自页面树节点，第二页带有CropBox和TrimBox
+func writeBoxedPDF(t *testing.T, dir, name string) string {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 600 800] >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /CropBox [20 20 580 780] /TrimBox [30 30 570 770] >>",
+	})
+	return createTestFile(t, dir, name, data)
+}
+
+func TestReadPageBoxes_InheritanceAndDefaults(t *testing.T) {
+	file := writeBoxedPDF(t, t.TempDir(), "boxed.pdf")
+
+	boxes, err := ReadPageBoxes(file)
+	require.NoError(t, err)
+	require.Len(t, boxes, 2)
+
+	full := [4]float64{0, 0, 600, 800}
+	assert.Equal(t, PageBoxes{MediaBox: full, CropBox: full, TrimBox: full}, boxes[0])
+	assert.Equal(t, [4]float64{20, 20, 580, 780}, boxes[1].CropBox)
+	assert.Equal(t, [4]float64{30, 30, 570, 770}, boxes[1].TrimBox)
+	assert.Equal(t, 560.0, boxes[1].Width(), "页面尺寸应以CropBox为准")
+}
+
+func TestPageBoxAdjustment_Apply(t *testing.T) {
+	page := PageBoxes{
+		MediaBox: [4]float64{0, 0, 600, 800},
+		CropBox:  [4]float64{20, 20, 580, 780},
+		TrimBox:  [4]float64{20, 20, 580, 780},
+	}
+
+	tests := []struct {
+		name       string
+		adjustment PageBoxAdjustment
+		want       PageBoxes
+		wantErr    bool
+	}{
+		{
+			name:       "symmetric crop margin",
+			adjustment: PageBoxAdjustment{CropMargin: 10},
+			want: PageBoxes{
+				MediaBox: page.MediaBox,
+				CropBox:  [4]float64{30, 30, 570, 770},
+				TrimBox:  [4]float64{30, 30, 570, 770},
+			},
+		},
+		{
+			name:       "explicit boxes",
+			adjustment: PageBoxAdjustment{CropBox: &[4]float64{0, 0, 300, 400}, TrimBox: &[4]float64{10, 10, 290, 390}},
+			want: PageBoxes{
+				MediaBox: page.MediaBox,
+				CropBox:  [4]float64{0, 0, 300, 400},
+				TrimBox:  [4]float64{10, 10, 290, 390},
+			},
+		},
+		{
+			name:       "crop box as media box",
+			adjustment: PageBoxAdjustment{CropToMedia: true, TrimMargin: 5},
+			want: PageBoxes{
+				MediaBox: [4]float64{20, 20, 580, 780},
+				CropBox:  [4]float64{20, 20, 580, 780},
+				TrimBox:  [4]float64{25, 25, 575, 775},
+			},
+		},
+		{name: "margin too large", adjustment: PageBoxAdjustment{CropMargin: 300}, wantErr: true},
+		{name: "outside media box", adjustment: PageBoxAdjustment{CropBox: &[4]float64{-10, 0, 300, 400}}, wantErr: true},
+		{name: "trim outside media box", adjustment: PageBoxAdjustment{TrimBox: &[4]float64{0, 0, 700, 800}}, wantErr: true},
+		{name: "negative margin", adjustment: PageBoxAdjustment{CropMargin: -1}, wantErr: true},
+		{name: "box and margin", adjustment: PageBoxAdjustment{CropMargin: 1, CropBox: &[4]float64{0, 0, 1, 1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.adjustment.Apply(page)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetPageBoxes_WritesBoxesMatchingPreview(t *testing.T) {
+	dir := t.TempDir()
+	input := writeBoxedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "cropped.pdf")
+	adjustment := &PageBoxAdjustment{CropMargin: 10}
+
+	preview, err := PreviewPageBoxes(input, adjustment)
+	require.NoError(t, err)
+	require.NoError(t, SetPageBoxes(input, output, adjustment))
+
+	written, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	assert.Equal(t, preview, written, "预览应与写出的页面框一致")
+	assert.Equal(t, [4]float64{10, 10, 590, 790}, written[0].CropBox)
+	assert.Equal(t, [4]float64{30, 30, 570, 770}, written[1].CropBox)
+
+	// 页面对象中写入的是显式页面框，而不是依赖继承
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/MediaBox [0 0 600 800] /CropBox [10 10 590 790] /TrimBox [10 10 590 790]")
+
+	original, err := ReadPageBoxes(input)
+	require.NoError(t, err)
+	assert.Equal(t, [4]float64{0, 0, 600, 800}, original[0].CropBox, "输入文件不应被修改")
+}
+
+func TestSetPageBoxes_InvalidAdjustmentLeavesNoOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := writeBoxedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "out.pdf")
+
+	err := SetPageBoxes(input, output, &PageBoxAdjustment{CropMargin: 290})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "第2页")
+	assert.False(t, fileExists(output))
+}
+
+func TestStreamingMerger_AppliesPageBoxesPerInput(t *testing.T) {
+	dir := t.TempDir()
+	input := writeBoxedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "out.pdf")
+	adjustment := &PageBoxAdjustment{CropToMedia: true}
+
+	preview, err := PreviewPageBoxes(input, adjustment)
+	require.NoError(t, err)
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		PageBoxes:     map[string]*PageBoxAdjustment{input: adjustment},
+	})
+	merger.adapter = nil
+	_, err = merger.MergeFiles([]string{input}, output, nil)
+	require.NoError(t, err)
+
+	written, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	assert.Equal(t, preview, written)
+	assert.Equal(t, [4]float64{20, 20, 580, 780}, written[1].MediaBox)
+
+	entries, err := filepath.Glob(filepath.Join(dir, "*_temp_*"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "预处理的临时副本应被清理")
+}
+
+func TestStreamingMerger_MergedOutputKeepsAdjustedBoxes(t *testing.T) {
+	dir := t.TempDir()
+	scan := writeBoxedPDF(t, dir, "scan.pdf")
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	adjustment := &PageBoxAdjustment{CropMargin: 10}
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		PageBoxes:     map[string]*PageBoxAdjustment{scan: adjustment},
+	})
+	if merger.adapter == nil || !CheckPDFCPUAvailability().IsAvailable() {
+		t.Skip("pdfcpu不可用")
+	}
+
+	output := filepath.Join(dir, "merged.pdf")
+	_, err := merger.MergeStreaming(context.Background(), []string{scan, plain}, output, nil)
+	require.NoError(t, err)
+
+	written, err := ReadPageBoxes(output)
+	if err != nil {
+		t.Skipf("输出使用了不支持的结构: %v", err)
+	}
+	preview, err := PreviewPageBoxes(scan, adjustment)
+	require.NoError(t, err)
+	require.Len(t, written, 3)
+	assert.Equal(t, preview, written[:2], "合并输出中调整过的页面应与预览一致")
+	assert.Equal(t, [4]float64{0, 0, 612, 792}, written[2].CropBox, "未指定调整的输入保持原样")
+}
+
+func TestStreamingMerger_InvalidPageBoxesFailValidation(t *testing.T) {
+	dir := t.TempDir()
+	input := writeBoxedPDF(t, dir, "scan.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		PageBoxes:     map[string]*PageBoxAdjustment{input: {CropMargin: 1000}},
+	})
+	merger.adapter = nil
+	result, err := merger.MergeFiles([]string{input}, filepath.Join(dir, "out.pdf"), nil)
+	require.Error(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, MergeStageValidation, result.FailedStage)
+}
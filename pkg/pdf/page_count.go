@@ -0,0 +1,504 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pagesCountPattern   = regexp.MustCompile(`/Count\s+(\d+)`)
+	objStmNPattern      = regexp.MustCompile(`/N\s+(\d+)`)
+	objStmFirstPattern  = regexp.MustCompile(`/First\s+(\d+)`)
+	predictorPattern    = regexp.MustCompile(`/Predictor\s+(\d+)`)
+	columnsPattern      = regexp.MustCompile(`/Columns\s+(\d+)`)
+	xrefTableRowPattern = regexp.MustCompile(`^(\d{1,10})\s+(\d{1,5})\s+([nf])$`)
+)
+
+// xrefEntry 交叉引用条目：未压缩对象记录偏移，对象流中的对象记录所在对象流编号和序号
+type xrefEntry struct {
+	offset int64
+	stream int // 所在对象流的编号，0表示未压缩
+	index  int // 在对象流中的序号
+}
+
+// xrefIndex 沿startxref与 /Prev 解析出的对象位置，较新的交叉引用段覆盖较早的段
+type xrefIndex struct {
+	data     []byte
+	entries  map[int]xrefEntry
+	trailers [][]byte               // 各段的trailer字典，最新的在前
+	covered  int                    // 各段条目（含空闲条目）覆盖的最大对象编号加1
+	streams  map[int]map[int][]byte // 已解码的对象流：对象流编号 -> 对象编号 -> 对象内容
+}
+
+// ReadPageCount 不依赖pdfcpu读取文件页数，依次尝试：
+//  1. 遍历页面树（按对象头扫描建立索引，增量更新以最后的定义为准）；
+//  2. 沿startxref与 /Prev 解析交叉引用表和交叉引用流，从 /Root 目录读取页面树根的 /Count，
+//     可读取位于对象流中的目录和页面树根；
+//  3. 交叉引用和目录都无法使用时，统计 /Type /Page 对象的数量。
+//
+// 页面树超出限制时直接返回该错误，不再尝试后续方法。
+func ReadPageCount(filePath string, limits *PageTreeLimits) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return readPageCount(filePath, data, limits)
+}
+
+// readPageCount ReadPageCount 的实现，data 为文件内容
+func readPageCount(filePath string, data []byte, limits *PageTreeLimits) (int, error) {
+	if limits == nil {
+		limits = DefaultPageTreeLimits()
+	}
+
+	stats, walkErr := WalkPageTree(filePath, data, limits)
+	if walkErr == nil && stats.PageCount > 0 {
+		return stats.PageCount, nil
+	}
+	if IsLimitExceededError(walkErr) {
+		return 0, walkErr
+	}
+
+	if count, err := catalogPageCount(data); err == nil {
+		if count > limits.MaxNodes {
+			return 0, newLimitExceededError(filePath, "MaxNodes", limits.MaxNodes, count)
+		}
+		return count, nil
+	}
+
+	if count := scanPageObjects(data); count > 0 {
+		return count, nil
+	}
+	if walkErr == nil {
+		walkErr = fmt.Errorf("页面树中没有页面")
+	}
+	return 0, &PDFError{
+		Type:    ErrorCorrupted,
+		Message: "无法读取页数",
+		File:    filePath,
+		Cause:   walkErr,
+	}
+}
+
+// catalogPageCount 通过交叉引用找到 /Root 目录和页面树根，返回根节点的 /Count
+func catalogPageCount(data []byte) (int, error) {
+	index, err := readXRefIndex(data)
+	if err != nil {
+		return 0, err
+	}
+
+	rootNum := 0
+	for _, trailer := range index.trailers {
+		if m := rootRefPattern.FindSubmatch(trailer); m != nil {
+			rootNum, _ = strconv.Atoi(string(m[1]))
+			break
+		}
+	}
+	if rootNum == 0 {
+		return 0, fmt.Errorf("trailer中没有 /Root 引用")
+	}
+
+	catalog, err := index.object(rootNum)
+	if err != nil {
+		return 0, fmt.Errorf("无法读取目录对象 %d: %w", rootNum, err)
+	}
+	m := pagesRefPattern.FindSubmatch(catalog)
+	if m == nil {
+		return 0, fmt.Errorf("目录对象缺少 /Pages 引用")
+	}
+	pagesNum, _ := strconv.Atoi(string(m[1]))
+	pages, err := index.object(pagesNum)
+	if err != nil {
+		return 0, fmt.Errorf("无法读取页面树根 %d: %w", pagesNum, err)
+	}
+	m = pagesCountPattern.FindSubmatch(pages)
+	if m == nil {
+		return 0, fmt.Errorf("页面树根缺少 /Count")
+	}
+	count, err := strconv.Atoi(string(m[1]))
+	if err != nil || count == 0 {
+		return 0, fmt.Errorf("页面树根的 /Count 无效: %s", m[1])
+	}
+	return count, nil
+}
+
+// scanPageObjects 统计文件中 /Type /Page 对象的数量，供交叉引用损坏时使用。
+// 增量更新中重新定义的对象只计一次；对象流中的页面不计入。
+func scanPageObjects(data []byte) int {
+	count := 0
+	offsets := indexObjects(data)
+	for num := range offsets {
+		body, _ := objectBody(data, offsets, num)
+		if pageTypePattern.Match(body) {
+			count++
+		}
+	}
+	return count
+}
+
+// readXRefIndex 从startxref开始沿 /Prev 解析各交叉引用段，包括混合引用文件的 /XRefStm。
+// 最后一个交叉引用段无法解析时返回错误；较早的段无法解析时停止并保留已读取的条目。
+func readXRefIndex(data []byte) (*xrefIndex, error) {
+	size := int64(len(data))
+	offset, found, err := lastStartXRef(bytes.NewReader(data), size)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("文件末尾缺少startxref")
+	}
+
+	index := &xrefIndex{
+		data:    data,
+		entries: make(map[int]xrefEntry),
+		streams: make(map[int]map[int][]byte),
+	}
+	visited := make(map[int64]bool)
+	for sections := 0; sections < xrefMaxSections && !visited[offset]; sections++ {
+		visited[offset] = true
+		trailer, err := index.readSection(offset)
+		if err != nil {
+			if len(index.trailers) == 0 {
+				return nil, err
+			}
+			break
+		}
+		index.trailers = append(index.trailers, trailer)
+
+		if m := xrefStmPattern.FindSubmatch(trailer); m != nil {
+			stmOffset, _ := strconv.ParseInt(string(m[1]), 10, 64)
+			index.readSection(stmOffset)
+		}
+
+		m := xrefPrevPattern.FindSubmatch(trailer)
+		if m == nil {
+			break
+		}
+		if offset, err = strconv.ParseInt(string(m[1]), 10, 64); err != nil {
+			break
+		}
+	}
+	return index, nil
+}
+
+// readSection 解析offset处的交叉引用表或交叉引用流，补充尚未记录的条目并返回trailer字典
+func (x *xrefIndex) readSection(offset int64) ([]byte, error) {
+	size := int64(len(x.data))
+	if offset < 0 || offset >= size {
+		return nil, fmt.Errorf("交叉引用偏移 %d 超出文件大小 %d", offset, size)
+	}
+	trimmed := bytes.TrimLeft(x.data[offset:], " \t\r\n\f\x00")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("xref")):
+		if err := x.readTable(offset); err != nil {
+			return nil, err
+		}
+		return readTrailerAfterTable(bytes.NewReader(x.data), offset, size)
+	case objectStartPattern.Match(trimmed):
+		object := trimmed
+		if end := bytes.Index(object, []byte("endobj")); end >= 0 {
+			object = object[:end]
+		}
+		dict := streamDict(object)
+		if !xrefStreamTypePattern.Match(dict) {
+			return nil, fmt.Errorf("偏移 %d 指向的对象不是交叉引用流", offset)
+		}
+		if err := x.readStream(object); err != nil {
+			return nil, fmt.Errorf("偏移 %d 处的交叉引用流无效: %w", offset, err)
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("偏移 %d 既不指向xref也不指向对象", offset)
+	}
+}
+
+// readTable 解析传统交叉引用表的条目，遇到trailer时停止
+func (x *xrefIndex) readTable(offset int64) error {
+	scanner := bufio.NewScanner(bytes.NewReader(x.data[offset:]))
+	scanner.Split(scanPDFLines)
+	started := false
+	next, remaining := 0, 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case !started:
+			if line != "xref" {
+				return fmt.Errorf("缺少xref关键字")
+			}
+			started = true
+		case strings.HasPrefix(line, "trailer"):
+			return nil
+		case remaining == 0:
+			m := xrefSubsectionPattern.FindStringSubmatch(line)
+			if m == nil {
+				return fmt.Errorf("无效的子段表头 %q", line)
+			}
+			next, _ = strconv.Atoi(m[1])
+			remaining, _ = strconv.Atoi(m[2])
+		default:
+			m := xrefTableRowPattern.FindStringSubmatch(line)
+			if m == nil {
+				return fmt.Errorf("无效的交叉引用条目 %q", line)
+			}
+			if _, ok := x.entries[next]; !ok && m[3] == "n" {
+				entryOffset, _ := strconv.ParseInt(m[1], 10, 64)
+				x.entries[next] = xrefEntry{offset: entryOffset}
+			}
+			next++
+			x.covered = max(x.covered, next)
+			remaining--
+		}
+	}
+	return fmt.Errorf("交叉引用表缺少trailer")
+}
+
+// readStream 解码交叉引用流并补充条目
+func (x *xrefIndex) readStream(object []byte) error {
+	dict := streamDict(object)
+	if err := checkXRefStreamDict(dict); err != nil {
+		return err
+	}
+	widths, _ := parseIntArray(xrefWidthsPattern.FindSubmatch(dict)[1])
+	rowWidth := widths[0] + widths[1] + widths[2]
+
+	rows, err := decodeStream(object)
+	if err != nil {
+		return err
+	}
+	if rows, err = undoPNGPredictor(dict, rows, rowWidth); err != nil {
+		return err
+	}
+
+	entries, _ := strconv.Atoi(string(sizePattern.FindSubmatch(dict)[1]))
+	subsections := []int{0, entries}
+	if m := xrefIndexPattern.FindSubmatch(dict); m != nil {
+		subsections, _ = parseIntArray(m[1])
+	}
+
+	row := 0
+	for i := 0; i+1 < len(subsections); i += 2 {
+		for num := subsections[i]; num < subsections[i]+subsections[i+1]; num++ {
+			if (row+1)*rowWidth > len(rows) {
+				return fmt.Errorf("交叉引用流数据不足 %d 行", row+1)
+			}
+			fields := rows[row*rowWidth:]
+			row++
+
+			kind := int64(1) // /W 第一个字段宽度为0时类型默认为1
+			if widths[0] > 0 {
+				kind = readXRefField(fields[:widths[0]])
+			}
+			second := readXRefField(fields[widths[0] : widths[0]+widths[1]])
+			third := readXRefField(fields[widths[0]+widths[1] : rowWidth])
+			x.covered = max(x.covered, num+1)
+			if _, ok := x.entries[num]; ok {
+				continue
+			}
+			switch kind {
+			case 1:
+				x.entries[num] = xrefEntry{offset: second}
+			case 2:
+				x.entries[num] = xrefEntry{stream: int(second), index: int(third)}
+			}
+		}
+	}
+	return nil
+}
+
+// readXRefField 按大端序读取交叉引用流中的一个字段
+func readXRefField(field []byte) int64 {
+	var v int64
+	for _, b := range field {
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// undoPNGPredictor 还原使用PNG预测器（/Predictor 10-15）编码的行数据，未使用预测器时原样返回
+func undoPNGPredictor(dict, data []byte, columns int) ([]byte, error) {
+	m := predictorPattern.FindSubmatch(dict)
+	if m == nil {
+		return data, nil
+	}
+	predictor, _ := strconv.Atoi(string(m[1]))
+	if predictor < 10 {
+		if predictor <= 1 {
+			return data, nil
+		}
+		return nil, fmt.Errorf("不支持的预测器 %d", predictor)
+	}
+	if m := columnsPattern.FindSubmatch(dict); m != nil {
+		columns, _ = strconv.Atoi(string(m[1]))
+	}
+	if columns <= 0 {
+		return nil, fmt.Errorf("预测器的 /Columns 无效")
+	}
+
+	stride := columns + 1
+	out := make([]byte, 0, len(data)/stride*columns)
+	prev := make([]byte, columns)
+	for start := 0; start+stride <= len(data); start += stride {
+		filter, row := data[start], append([]byte(nil), data[start+1:start+stride]...)
+		for i := range row {
+			var left, upLeft byte
+			if i > 0 {
+				left, upLeft = row[i-1], prev[i-1]
+			}
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += prev[i]
+			case 3:
+				row[i] += byte((int(left) + int(prev[i])) / 2)
+			case 4:
+				row[i] += paeth(left, prev[i], upLeft)
+			default:
+				return nil, fmt.Errorf("无效的PNG行过滤类型 %d", filter)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+// paeth PNG Paeth 预测函数
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+// abs 返回整数的绝对值
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// object 返回对象内容（obj与endobj之间，或对象流中的对象）
+func (x *xrefIndex) object(num int) ([]byte, error) {
+	entry, ok := x.entries[num]
+	if !ok {
+		return nil, fmt.Errorf("交叉引用中没有对象 %d", num)
+	}
+	if entry.stream != 0 {
+		objects, err := x.objectStream(entry.stream)
+		if err != nil {
+			return nil, err
+		}
+		body, ok := objects[num]
+		if !ok {
+			return nil, fmt.Errorf("对象流 %d 中没有对象 %d", entry.stream, num)
+		}
+		return body, nil
+	}
+	return x.objectAt(num, entry.offset)
+}
+
+// objectAt 读取offset处编号为num的未压缩对象
+func (x *xrefIndex) objectAt(num int, offset int64) ([]byte, error) {
+	if offset < 0 || offset >= int64(len(x.data)) {
+		return nil, fmt.Errorf("对象 %d 的偏移 %d 超出文件大小", num, offset)
+	}
+	object := bytes.TrimLeft(x.data[offset:], " \t\r\n\f\x00")
+	m := objectStartPattern.FindSubmatch(object)
+	if m == nil || string(m[1]) != strconv.Itoa(num) {
+		return nil, fmt.Errorf("对象 %d 的偏移 %d 不准确", num, offset)
+	}
+	object = object[len(m[0]):]
+	if end := bytes.Index(object, []byte("endobj")); end >= 0 {
+		object = object[:end]
+	}
+	return object, nil
+}
+
+// objectStream 解码对象流并按对象编号拆分其中的对象，结果会被缓存
+func (x *xrefIndex) objectStream(num int) (map[int][]byte, error) {
+	if objects, ok := x.streams[num]; ok {
+		return objects, nil
+	}
+	entry, ok := x.entries[num]
+	if !ok || entry.stream != 0 {
+		return nil, fmt.Errorf("对象流 %d 不存在", num)
+	}
+	object, err := x.objectAt(num, entry.offset)
+	if err != nil {
+		return nil, err
+	}
+	dict := streamDict(object)
+	nMatch, firstMatch := objStmNPattern.FindSubmatch(dict), objStmFirstPattern.FindSubmatch(dict)
+	if !objStmPattern.Match(dict) || nMatch == nil || firstMatch == nil {
+		return nil, fmt.Errorf("对象 %d 不是有效的对象流", num)
+	}
+	decoded, err := decodeStream(object)
+	if err != nil {
+		return nil, fmt.Errorf("无法解码对象流 %d: %w", num, err)
+	}
+
+	n, _ := strconv.Atoi(string(nMatch[1]))
+	first, _ := strconv.Atoi(string(firstMatch[1]))
+	if first > len(decoded) {
+		return nil, fmt.Errorf("对象流 %d 的 /First 超出数据长度", num)
+	}
+	header, err := parseIntArray(decoded[:first])
+	if err != nil || len(header) < 2*n {
+		return nil, fmt.Errorf("对象流 %d 的头部无效", num)
+	}
+
+	objects := make(map[int][]byte, n)
+	for i := 0; i < n; i++ {
+		start, end := first+header[2*i+1], len(decoded)
+		if i+1 < n {
+			end = first + header[2*i+3]
+		}
+		if start < first || end < start || end > len(decoded) {
+			return nil, fmt.Errorf("对象流 %d 中对象 %d 的偏移无效", num, header[2*i])
+		}
+		objects[header[2*i]] = decoded[start:end]
+	}
+	x.streams[num] = objects
+	return objects, nil
+}
+
+// scanPDFLines 按 \n、\r\n 或单独的 \r 切分行，交叉引用表可能使用任意一种行结束符
+func scanPDFLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		if data[i] == '\r' && i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,158 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildCompressedTreePDF 生成目录和页面树根都位于压缩对象流中的文件，
+// 交叉引用流使用 /Predictor 12 编码，页面对象本身未压缩
+func buildCompressedTreePDF(pages int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	pageOffsets := make([]int, pages)
+	var kids bytes.Buffer
+	for i := range pageOffsets {
+		pageOffsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>\nendobj\n", i+5)
+		fmt.Fprintf(&kids, "%d 0 R ", i+5)
+	}
+
+	catalog := "<< /Type /Catalog /Pages 2 0 R >>"
+	tree := fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), pages)
+	header := fmt.Sprintf("1 0 2 %d ", len(catalog)+1)
+	objStm := zlibCompress([]byte(header + catalog + " " + tree))
+	objStmOffset := buf.Len()
+	fmt.Fprintf(&buf, "3 0 obj\n<< /Type /ObjStm /N 2 /First %d /Length %d /Filter /FlateDecode >>\nstream\n",
+		len(header), len(objStm))
+	buf.Write(objStm)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	rows := [][]byte{xrefRow(0, 0, 255), xrefRow(2, 3, 0), xrefRow(2, 3, 1), xrefRow(1, objStmOffset, 0)}
+	xrefOffset := buf.Len()
+	rows = append(rows, xrefRow(1, xrefOffset, 0))
+	for _, offset := range pageOffsets {
+		rows = append(rows, xrefRow(1, offset, 0))
+	}
+	// PNG Up 预测：每行前加过滤类型2，数据为与上一行的差值
+	var predicted []byte
+	prev := make([]byte, 4)
+	for _, row := range rows {
+		predicted = append(predicted, 2)
+		for i, b := range row {
+			predicted = append(predicted, b-prev[i])
+		}
+		prev = row
+	}
+	data := zlibCompress(predicted)
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /XRef /Size %d /W [1 2 1] /Root 1 0 R /Length %d /Filter /FlateDecode "+
+		"/DecodeParms << /Predictor 12 /Columns 4 >> >>\nstream\n", len(rows), len(data))
+	buf.Write(data)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return buf.Bytes()
+}
+
+// zlibCompress 使用zlib压缩数据
+func zlibCompress(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// buildBrokenXRefPDF 生成trailer缺少 /Root、startxref指向无效位置的文件，只能通过扫描页面对象计数
+func buildBrokenXRefPDF(pages int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	buf.WriteString("2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n")
+	for i := 0; i < pages; i++ {
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Type /Page /MediaBox [0 0 612 792] >>\nendobj\n", i+3)
+	}
+	buf.WriteString("xref\n0 1\ngarbage\ntrailer\n<< /Size 3 >>\nstartxref\n999999\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestReadPageCount_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content []byte
+		want    int
+	}{
+		{"多页", []byte(createMultiPagePDF("1.4")), 2},
+		{"线性化", []byte(createLinearizedPDF("1.5")), 1},
+		{"平铺页面树", buildFlatPDF(7), 7},
+		{"交叉引用流中的对象流页面", buildXRefStreamPDF(xrefStreamFixture{compress: true}), 1},
+		{"混合引用", buildHybridPDF(-1), 1},
+		{"压缩的目录与预测器", buildCompressedTreePDF(4), 4},
+		{"损坏的交叉引用", buildBrokenXRefPDF(3), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, tt.name+".pdf", tt.content)
+			count, err := ReadPageCount(path, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, count)
+		})
+	}
+}
+
+func TestReadPageCount_IncrementalUpdateAddsPage(t *testing.T) {
+	base := buildFlatPDF(1)
+	var buf bytes.Buffer
+	buf.Write(base)
+	pageOffset := buf.Len()
+	buf.WriteString("4 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>\nendobj\n")
+	treeOffset := buf.Len()
+	buf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>\nendobj\n")
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n2 1\n%010d 00000 n \n4 1\n%010d 00000 n \n", treeOffset, pageOffset)
+	fmt.Fprintf(&buf, "trailer\n<< /Size 5 /Root 1 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n",
+		bytes.Index(base, []byte("\nxref\n"))+1, xrefOffset)
+
+	path := createTestFile(t, t.TempDir(), "updated.pdf", buf.Bytes())
+	count, err := ReadPageCount(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = catalogPageCount(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "沿 /Prev 解析时应以最新的页面树根为准")
+}
+
+func TestReadPageCount_LimitExceeded(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "deep.pdf", buildDeepPDF(20))
+	_, err := ReadPageCount(path, &PageTreeLimits{MaxDepth: 5, MaxNodes: 100, MaxKids: 100})
+	assert.True(t, IsLimitExceededError(err), "超出限制时不应回退到其他计数方法: %v", err)
+}
+
+func TestReadPageCount_NoPages(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "empty.pdf", []byte("%PDF-1.4\n%%EOF\n"))
+	_, err := ReadPageCount(path, nil)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorCorrupted, pdfErr.Type)
+}
+
+func TestGetBasicPDFInfo_UsesBuiltinPageCount(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestFile(t, dir, "compressed.pdf", buildCompressedTreePDF(3))
+	info, err := NewPDFValidator().GetBasicPDFInfo(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.PageCount)
+
+	reader, err := NewPDFReader(filepath.Join(dir, "compressed.pdf"))
+	require.NoError(t, err)
+	defer reader.Close()
+	count, err := reader.GetPageCount()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// pageSizeTolerance 比较页面尺寸时允许的误差（点），A4等纸张的尺寸常被写成小数
+const pageSizeTolerance = 3
+
+// paperSizes 常见纸张的纵向尺寸（点），按名称匹配时依次比较
+var paperSizes = []struct {
+	name          string
+	width, height float64
+}{
+	{"A3", 842, 1191},
+	{"A4", 595, 842},
+	{"A5", 420, 595},
+	{"B5", 499, 709},
+	{"Letter", 612, 792},
+	{"Legal", 612, 1008},
+	{"Tabloid", 792, 1224},
+}
+
+// PageDim 一页的尺寸和旋转
+type PageDim struct {
+	Width    float64 `json:"width"`    // 可见区域（CropBox）的宽（点），未考虑 /Rotate
+	Height   float64 `json:"height"`   // 可见区域的高（点）
+	Rotation int     `json:"rotation"` // 页面的 /Rotate：0、90、180、270
+}
+
+// DisplaySize 返回页面按 /Rotate 显示时的宽和高
+func (d PageDim) DisplaySize() (float64, float64) {
+	if d.Rotation == 90 || d.Rotation == 270 {
+		return d.Height, d.Width
+	}
+	return d.Width, d.Height
+}
+
+// Landscape 页面显示时是否为横向（宽大于高）
+func (d PageDim) Landscape() bool {
+	width, height := d.DisplaySize()
+	return width > height
+}
+
+// SameSize 两页的纸张尺寸是否相同，不区分横竖方向
+func (d PageDim) SameSize(other PageDim) bool {
+	a1, a2 := math.Min(d.Width, d.Height), math.Max(d.Width, d.Height)
+	b1, b2 := math.Min(other.Width, other.Height), math.Max(other.Width, other.Height)
+	return math.Abs(a1-b1) <= pageSizeTolerance && math.Abs(a2-b2) <= pageSizeTolerance
+}
+
+// PaperName 返回页面尺寸对应的常见纸张名称，例如 "A4"、"Letter"；不是常见纸张时返回空字符串
+func (d PageDim) PaperName() string {
+	for _, paper := range paperSizes {
+		if d.SameSize(PageDim{Width: paper.width, Height: paper.height}) {
+			return paper.name
+		}
+	}
+	return ""
+}
+
+// SizeName 返回纸张名称，不是常见纸张时返回显示尺寸，例如 "500×700 pt"
+func (d PageDim) SizeName() string {
+	if name := d.PaperName(); name != "" {
+		return name
+	}
+	width, height := d.DisplaySize()
+	return fmt.Sprintf("%.0f×%.0f pt", width, height)
+}
+
+// ReadPageDims 读取每一页的可见区域尺寸和 /Rotate。与 ReadPageBoxes 相同，不支持对象流中的页面对象
+func ReadPageDims(filePath string) ([]PageDim, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	pages, boxes, err := readPageBoxes(filePath, data)
+	if err != nil {
+		return nil, err
+	}
+	offsets := indexObjects(data)
+	dims := make([]PageDim, len(pages))
+	for i, num := range pages {
+		body, _ := objectBody(data, offsets, num)
+		dims[i] = PageDim{
+			Width:    boxes[i].Width(),
+			Height:   boxes[i].Height(),
+			Rotation: pageRotation(data, offsets, body),
+		}
+	}
+	return dims, nil
+}
+
+// HasMixedPageSizes 页面中是否有纸张尺寸不同的页面，只有方向不同的页面视为尺寸相同
+func HasMixedPageSizes(dims []PageDim) bool {
+	for _, d := range dims {
+		if !d.SameSize(dims[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DominantPageSize 返回页数最多的尺寸（尺寸相同时取其中第一页，保留其方向）及使用该尺寸的页数；
+// 页数相同时取先出现的尺寸，dims为空时返回零值
+func DominantPageSize(dims []PageDim) (PageDim, int) {
+	var groups []PageDim
+	var counts []int
+	for _, d := range dims {
+		found := false
+		for i, group := range groups {
+			if d.SameSize(group) {
+				counts[i]++
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, d)
+			counts = append(counts, 1)
+		}
+	}
+	best := -1
+	for i, count := range counts {
+		if best < 0 || count > counts[best] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return PageDim{}, 0
+	}
+	return groups[best], counts[best]
+}
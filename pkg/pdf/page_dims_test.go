@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSizedPDF 写出每页一个页面字典的文件，pages为各页的页面框和 /Rotate 等条目
+func writeSizedPDF(t *testing.T, dir, name string, pages ...string) string {
+	t.Helper()
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	kids := make([]int, len(pages))
+	for i, entries := range pages {
+		kids[i] = len(objects) + 1
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R %s >>", entries))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", refList(kids), len(pages))
+	return createTestFile(t, dir, name, buildPDF(objects))
+}
+
+func TestReadPageDims(t *testing.T) {
+	input := writeSizedPDF(t, t.TempDir(), "mixed.pdf",
+		"/MediaBox [0 0 595.28 841.89]",
+		"/MediaBox [0 0 595.28 841.89] /Rotate 90",
+		"",
+		"/MediaBox [0 0 600 900] /CropBox [50 50 550 750]")
+
+	dims, err := ReadPageDims(input)
+	require.NoError(t, err)
+	require.Len(t, dims, 4)
+	assert.Equal(t, PageDim{Width: 595.28, Height: 841.89, Rotation: 90}, dims[1])
+
+	assert.Equal(t, "A4", dims[0].PaperName())
+	assert.False(t, dims[0].Landscape())
+	assert.True(t, dims[1].Landscape(), "/Rotate 90 的纵向页面显示为横向")
+	assert.True(t, dims[0].SameSize(dims[1]), "只有方向不同的页面尺寸相同")
+	assert.Equal(t, "Letter", dims[2].SizeName(), "MediaBox从页面树继承")
+	assert.Equal(t, "500×700 pt", dims[3].SizeName(), "不是常见纸张时使用CropBox的尺寸")
+
+	dominant, count := DominantPageSize(dims)
+	assert.Equal(t, dims[0], dominant)
+	assert.Equal(t, 2, count)
+	assert.True(t, HasMixedPageSizes(dims))
+	assert.False(t, HasMixedPageSizes(dims[:2]))
+}
+
+func TestGetPDFInfo_IncludePageGeometry(t *testing.T) {
+	dir := t.TempDir()
+	input := writeSizedPDF(t, dir, "mixed.pdf", "/MediaBox [0 0 595 842]", "")
+
+	config := DefaultServiceConfig()
+	service := NewPDFServiceWithConfig(config)
+	info, err := service.GetPDFInfo(input)
+	require.NoError(t, err)
+	assert.Nil(t, info.PageSizes, "默认不读取每一页的尺寸")
+
+	// 开启后已缓存的信息重新读取
+	config.IncludePageGeometry = true
+	info, err = service.GetPDFInfo(input)
+	require.NoError(t, err)
+	assert.Equal(t, []PageDim{{Width: 595, Height: 842}, {Width: 612, Height: 792}}, info.PageSizes)
+	assert.True(t, info.MixedPageSizes)
+}
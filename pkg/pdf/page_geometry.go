@@ -0,0 +1,258 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// maxDecodedContentSize 单页内容流解码后的最大字节数，防止压缩炸弹
+const maxDecodedContentSize = 16 * 1024 * 1024
+
+// maxParentHops 查找继承属性时沿 /Parent 向上的最大层数
+const maxParentHops = 64
+
+var (
+	parentRefPattern   = regexp.MustCompile(`/Parent\s+(\d+)\s+\d+\s+R`)
+	xobjectRefPattern  = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	imageTypePattern   = regexp.MustCompile(`/Subtype\s*/Image\b`)
+	flateFilterPattern = regexp.MustCompile(`/Filter\s*\[?\s*/FlateDecode\b`)
+)
+
+// PageGeometry 页面的几何信息，供近似渲染使用
+type PageGeometry struct {
+	MediaBox      [4]float64      // llx lly urx ury
+	Content       []byte          // 解码后的内容流（多个流按顺序拼接）
+	ImageXObjects map[string]bool // 资源中图像XObject的名称
+}
+
+// Width 返回页面宽度
+func (g *PageGeometry) Width() float64 {
+	return g.MediaBox[2] - g.MediaBox[0]
+}
+
+// Height 返回页面高度
+func (g *PageGeometry) Height() float64 {
+	return g.MediaBox[3] - g.MediaBox[1]
+}
+
+// ReadPageGeometry 读取指定页（从1开始）的MediaBox、内容流和图像资源。
+// 只支持未压缩或FlateDecode的内容流，不支持对象流中的页面对象。
+func ReadPageGeometry(filePath string, page int) (*PageGeometry, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 || page > len(stats.Pages) {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("页码 %d 超出范围（共 %d 页）", page, len(stats.Pages)),
+			File:    filePath,
+		}
+	}
+
+	offsets := indexObjects(data)
+	body, _ := objectBody(data, offsets, stats.Pages[page-1])
+
+	geometry := &PageGeometry{
+		MediaBox:      [4]float64{0, 0, 612, 792},
+		ImageXObjects: make(map[string]bool),
+	}
+	if box, ok := inheritedMediaBox(data, offsets, body); ok {
+		geometry.MediaBox = box
+	}
+
+	content, err := pageContent(data, offsets, body)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("无法读取第%d页的内容流", page),
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	geometry.Content = content
+
+	if resources := inheritedResources(data, offsets, body); resources != nil {
+		if xobjects := resolveDict(data, offsets, resources, "/XObject"); xobjects != nil {
+			for _, m := range xobjectRefPattern.FindAllSubmatch(xobjects, -1) {
+				num, _ := strconv.Atoi(string(m[2]))
+				if obj, ok := objectBody(data, offsets, num); ok && imageTypePattern.Match(obj) {
+					geometry.ImageXObjects[string(m[1])] = true
+				}
+			}
+		}
+	}
+
+	return geometry, nil
+}
+
+// inheritedMediaBox 查找页面或其祖先节点上的MediaBox
+func inheritedMediaBox(data []byte, offsets map[int]int, body []byte) ([4]float64, bool) {
+	return inheritedBox(data, offsets, body, mediaBoxPattern)
+}
+
+// inheritedBox 查找页面或其祖先节点上由pattern匹配的页面框
+func inheritedBox(data []byte, offsets map[int]int, body []byte, pattern *regexp.Regexp) ([4]float64, bool) {
+	for hop := 0; body != nil && hop < maxParentHops; hop++ {
+		if m := pattern.FindSubmatch(body); m != nil {
+			return parseBox(m)
+		}
+		body = parentBody(data, offsets, body)
+	}
+	return [4]float64{}, false
+}
+
+// parseBox 解析页面框正则匹配到的四个坐标，要求面积为正
+func parseBox(m [][]byte) ([4]float64, bool) {
+	var box [4]float64
+	for i := 0; i < 4; i++ {
+		v, err := strconv.ParseFloat(string(m[i+1]), 64)
+		if err != nil {
+			return box, false
+		}
+		box[i] = v
+	}
+	return box, box[2] > box[0] && box[3] > box[1]
+}
+
+// inheritedResources 查找页面或其祖先节点上的Resources
+func inheritedResources(data []byte, offsets map[int]int, body []byte) []byte {
+	for hop := 0; body != nil && hop < maxParentHops; hop++ {
+		if resources := resolveDict(data, offsets, body, "/Resources"); resources != nil {
+			return resources
+		}
+		body = parentBody(data, offsets, body)
+	}
+	return nil
+}
+
+// parentBody 返回 /Parent 引用的对象内容
+func parentBody(data []byte, offsets map[int]int, body []byte) []byte {
+	m := parentRefPattern.FindSubmatch(body)
+	if m == nil {
+		return nil
+	}
+	num, _ := strconv.Atoi(string(m[1]))
+	parent, ok := objectBody(data, offsets, num)
+	if !ok {
+		return nil
+	}
+	return parent
+}
+
+// pageContent 读取并解码页面的 /Contents（单个引用或引用数组）
+func pageContent(data []byte, offsets map[int]int, body []byte) ([]byte, error) {
+	idx := bytes.Index(body, []byte("/Contents"))
+	if idx < 0 {
+		return nil, nil
+	}
+	rest := body[idx+len("/Contents"):]
+
+	var refs []int
+	if m := refPattern.FindSubmatch(rest); m != nil {
+		num, _ := strconv.Atoi(string(m[1]))
+		refs = []int{num}
+	} else {
+		refs = parseArrayRefs(rest)
+	}
+
+	var content bytes.Buffer
+	for _, num := range refs {
+		obj, ok := objectBody(data, offsets, num)
+		if !ok {
+			return nil, fmt.Errorf("内容流对象 %d 不存在", num)
+		}
+		stream, err := decodeStream(obj)
+		if err != nil {
+			return nil, err
+		}
+		if content.Len()+len(stream) > maxDecodedContentSize {
+			return nil, fmt.Errorf("内容流超过 %d 字节", maxDecodedContentSize)
+		}
+		content.Write(stream)
+		content.WriteByte('\n')
+	}
+	return content.Bytes(), nil
+}
+
+// decodeStream 提取对象中的流数据，必要时进行Flate解码
+func decodeStream(obj []byte) ([]byte, error) {
+	dict, raw, err := splitStream(obj)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStreamData(dict, raw)
+}
+
+// splitStream 把对象分为流字典和未解码的流数据
+func splitStream(obj []byte) ([]byte, []byte, error) {
+	start := bytes.Index(obj, []byte("stream"))
+	if start < 0 {
+		return nil, nil, fmt.Errorf("对象不包含流")
+	}
+	dict := obj[:start]
+	start += len("stream")
+	if start < len(obj) && obj[start] == '\r' {
+		start++
+	}
+	if start < len(obj) && obj[start] == '\n' {
+		start++
+	}
+	end := bytes.LastIndex(obj, []byte("endstream"))
+	if end < start {
+		return nil, nil, fmt.Errorf("流缺少 endstream")
+	}
+	// 只去掉 endstream 前的一个行结束符，避免截断以换行字节结尾的二进制数据
+	raw := obj[start:end]
+	if bytes.HasSuffix(raw, []byte("\r\n")) {
+		raw = raw[:len(raw)-2]
+	} else if bytes.HasSuffix(raw, []byte("\n")) || bytes.HasSuffix(raw, []byte("\r")) {
+		raw = raw[:len(raw)-1]
+	}
+	return dict, raw, nil
+}
+
+// decodeStreamData 按流字典对原始流数据进行Flate解码，没有Flate过滤器时原样返回
+func decodeStreamData(dict, raw []byte) ([]byte, error) {
+	if !flateFilterPattern.Match(dict) {
+		return raw, nil
+	}
+	decoded, err := inflate(raw, maxDecodedContentSize)
+	if err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// inflate 解码zlib数据，解码后超过limit字节时返回错误，防止压缩炸弹
+func inflate(raw []byte, limit int) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > limit {
+		return nil, fmt.Errorf("流解码后超过 %d 字节", limit)
+	}
+	return decoded, nil
+}
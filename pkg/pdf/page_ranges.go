@@ -0,0 +1,326 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// rangeTokenPattern 看起来像页码范围的片段（只含数字和连字符）
+	rangeTokenPattern = regexp.MustCompile(`^[\d-]+$`)
+	// pageRangePattern 页码范围：单页 "5"、闭区间 "1-3" 或到末页 "9-"
+	pageRangePattern = regexp.MustCompile(`^(\d+)(?:-(\d*))?$`)
+	rotatePattern    = regexp.MustCompile(`/Rotate\s+(-?\d+)`)
+)
+
+// PageRange 从1开始的页码范围，End 为0表示到最后一页
+type PageRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
+}
+
+// String 返回命令行语法形式的范围
+func (r PageRange) String() string {
+	switch {
+	case r.End == 0:
+		return fmt.Sprintf("%d-", r.Start)
+	case r.End == r.Start:
+		return strconv.Itoa(r.Start)
+	default:
+		return fmt.Sprintf("%d-%d", r.Start, r.End)
+	}
+}
+
+// FileRangeSpec 一个输入文件及要合并的页码范围，Ranges 为空表示全部页面。
+// Rotation 为这些页面顺时针旋转的角度（0、90、180、270），非0时覆盖MergeOptions.Rotations中该文件的值
+type FileRangeSpec struct {
+	File     string      `json:"file"`
+	Ranges   []PageRange `json:"ranges,omitempty"`
+	Rotation int         `json:"rotation,omitempty"`
+}
+
+// rotation 返回该输入的旋转角度，未指定时使用按路径指定的旋转
+func (s FileRangeSpec) rotation(rotations map[string]int) int {
+	if s.Rotation != 0 {
+		return s.Rotation
+	}
+	return rotations[s.File]
+}
+
+// rangeError 生成包含文件和范围的页码范围错误
+func rangeError(file, rng, reason string) error {
+	return &PDFError{
+		Type:    ErrorInvalidInput,
+		Message: fmt.Sprintf("文件 %s 的页码范围 %s 无效：%s", file, rng, reason),
+		File:    file,
+	}
+}
+
+// parsePageRange 解析单个页码范围，检查页码从1开始且起始不大于结束
+func parsePageRange(file, token string) (PageRange, error) {
+	m := pageRangePattern.FindStringSubmatch(token)
+	if m == nil {
+		return PageRange{}, rangeError(file, token, "格式应为 N、N-M 或 N-")
+	}
+	r := PageRange{}
+	r.Start, _ = strconv.Atoi(m[1])
+	switch {
+	case !strings.Contains(token, "-"):
+		r.End = r.Start
+	case m[2] != "":
+		r.End, _ = strconv.Atoi(m[2])
+	}
+	if r.Start == 0 || (strings.Contains(token, "-") && m[2] != "" && r.End == 0) {
+		return PageRange{}, rangeError(file, token, "页码从1开始")
+	}
+	if r.End != 0 && r.Start > r.End {
+		return PageRange{}, rangeError(file, token, "起始页大于结束页")
+	}
+	return r, nil
+}
+
+// ParsePageRanges 解析逗号分隔的页码范围，例如 "1-3,5,9-"
+func ParsePageRanges(file, spec string) ([]PageRange, error) {
+	var ranges []PageRange
+	for _, token := range strings.Split(spec, ",") {
+		r, err := parsePageRange(file, strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// ParseFileRangeSpecs 解析命令行输入列表，例如 "a.pdf:1-3,b.pdf:5,7,9-,c.pdf"。
+// 文件名后用最后一个冒号引出页码范围；紧跟在带范围的文件之后、只含数字和连字符的片段
+// 属于该文件的范围。没有范围的文件合并全部页面。
+func ParseFileRangeSpecs(input string) ([]FileRangeSpec, error) {
+	var specs []FileRangeSpec
+	for _, token := range strings.Split(input, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		if n := len(specs); n > 0 && len(specs[n-1].Ranges) > 0 && rangeTokenPattern.MatchString(token) {
+			r, err := parsePageRange(specs[n-1].File, token)
+			if err != nil {
+				return nil, err
+			}
+			specs[n-1].Ranges = append(specs[n-1].Ranges, r)
+			continue
+		}
+
+		spec := FileRangeSpec{File: token}
+		if idx := strings.LastIndex(token, ":"); idx > 0 && rangeTokenPattern.MatchString(token[idx+1:]) {
+			spec.File = token[:idx]
+			r, err := parsePageRange(spec.File, token[idx+1:])
+			if err != nil {
+				return nil, err
+			}
+			spec.Ranges = []PageRange{r}
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: "没有提供输入文件"}
+	}
+	return specs, nil
+}
+
+// ResolvePages 按范围顺序返回要提取的页码（从1开始），页码超出文档页数或重复时返回错误
+func (s FileRangeSpec) ResolvePages(pageCount int) ([]int, error) {
+	if len(s.Ranges) == 0 {
+		pages := make([]int, pageCount)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		return pages, nil
+	}
+
+	seen := make(map[int]bool)
+	var pages []int
+	for _, r := range s.Ranges {
+		end := r.End
+		if end == 0 {
+			end = pageCount
+		}
+		if r.Start > pageCount || end > pageCount {
+			return nil, rangeError(s.File, r.String(), fmt.Sprintf("超出文档页数 %d", pageCount))
+		}
+		for page := r.Start; page <= end; page++ {
+			if seen[page] {
+				return nil, rangeError(s.File, r.String(), fmt.Sprintf("第%d页重复", page))
+			}
+			seen[page] = true
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// ExtractPages 按给定顺序把页面（从1开始的页码）写入outputPath，以增量更新方式
+// 把页面树根改为只包含这些页面。页面从祖先节点继承的属性会写到页面本身。
+// 不支持加密文件和对象流中的页面对象。
+func ExtractPages(inputPath, outputPath string, pages []int) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法从加密文件中提取页面",
+			File:    inputPath,
+		}
+	}
+
+	stats, err := WalkPageTree(inputPath, data, nil)
+	if err != nil {
+		return err
+	}
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+
+	update := newIncrementalUpdate(data, offsets)
+	kids := make([]int, len(pages))
+	for i, page := range pages {
+		if page < 1 || page > len(stats.Pages) {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("第%d页超出文档页数 %d", page, len(stats.Pages)),
+				File:    inputPath,
+			}
+		}
+		objNum := stats.Pages[page-1]
+		body, _ := objectBody(data, offsets, objNum)
+		update.set(objNum, reparentedPage(data, offsets, body, rootNum))
+		kids[i] = objNum
+	}
+	update.set(rootNum, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", refList(kids), len(kids)))
+
+	tempPath := outputPath + ".pages.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入提取的页面",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// reparentedPage 返回直接挂在页面树根下的页面对象内容：
+// Parent 指向根节点，并写入原先从中间节点继承的 MediaBox、CropBox、Resources 和 Rotate
+func reparentedPage(data []byte, offsets map[int]int, body []byte, rootNum int) string {
+	page := string(bytes.TrimSpace(body))
+	var inherited []string
+	if !mediaBoxPattern.MatchString(page) {
+		media := [4]float64{0, 0, 612, 792}
+		if box, ok := inheritedMediaBox(data, offsets, body); ok {
+			media = box
+		}
+		inherited = append(inherited, "/MediaBox "+formatBox(media))
+	}
+	if !cropBoxPattern.MatchString(page) {
+		if box, ok := inheritedBox(data, offsets, body, cropBoxPattern); ok {
+			inherited = append(inherited, "/CropBox "+formatBox(box))
+		}
+	}
+	if !strings.Contains(page, "/Resources") {
+		if value := inheritedValue(data, offsets, parentBody(data, offsets, body), "/Resources"); value != "" {
+			inherited = append(inherited, "/Resources "+value)
+		}
+	}
+	if !rotatePattern.MatchString(page) {
+		if value := inheritedValue(data, offsets, parentBody(data, offsets, body), "/Rotate"); value != "" {
+			inherited = append(inherited, "/Rotate "+value)
+		}
+	}
+
+	page = parentRefPattern.ReplaceAllString(page, fmt.Sprintf("/Parent %d 0 R", rootNum))
+	if len(inherited) > 0 {
+		page = strings.Replace(page, "<<", "<< "+strings.Join(inherited, " "), 1)
+	}
+	return page
+}
+
+// inheritedValue 在节点及其祖先上查找key，返回原样的值（间接引用、字典、数组或单个记号）
+func inheritedValue(data []byte, offsets map[int]int, body []byte, key string) string {
+	for hop := 0; body != nil && hop < maxParentHops; hop++ {
+		if value := directValue(body, key); value != "" {
+			return value
+		}
+		body = parentBody(data, offsets, body)
+	}
+	return ""
+}
+
+// directValue 返回对象中key对应的原样值，不存在时返回空串
+func directValue(body []byte, key string) string {
+	idx := bytes.Index(body, []byte(key))
+	if idx < 0 {
+		return ""
+	}
+	rest := body[idx+len(key):]
+	if m := refPattern.Find(rest); m != nil {
+		return strings.TrimSpace(string(m))
+	}
+	rest = bytes.TrimLeft(rest, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(rest, []byte("<<")):
+		depth := 0
+		for i := 0; i+1 < len(rest); i++ {
+			switch {
+			case rest[i] == '<' && rest[i+1] == '<':
+				depth++
+				i++
+			case rest[i] == '>' && rest[i+1] == '>':
+				depth--
+				i++
+				if depth == 0 {
+					return string(rest[:i+1])
+				}
+			}
+		}
+	case bytes.HasPrefix(rest, []byte("[")):
+		if end := bytes.IndexByte(rest, ']'); end >= 0 {
+			return string(rest[:end+1])
+		}
+	default:
+		end := bytes.IndexAny(rest, " \t\r\n/<>[]")
+		if end < 0 {
+			end = len(rest)
+		}
+		return string(rest[:end])
+	}
+	return ""
+}
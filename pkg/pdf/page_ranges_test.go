@@ -0,0 +1,261 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeNestedPDF 写出5页测试文件：页面挂在中间节点下，MediaBox、Resources和Rotate从中间节点继承
+func writeNestedPDF(t *testing.T, dir, name string) string {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 5 /MediaBox [0 0 600 800] >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [5 0 R 6 0 R 7 0 R] /Count 3 /Resources << /Font << /F1 10 0 R >> >> /Rotate 90 >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [8 0 R 9 0 R] /Count 2 /MediaBox [0 0 300 400] >>",
+	}
+	for i := 0; i < 5; i++ {
+		parent := 3
+		if i >= 3 {
+			parent = 4
+		}
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents %d 0 R >>", parent, 11+i))
+	}
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	for i := 0; i < 5; i++ {
+		content := fmt.Sprintf("BT /F1 12 Tf (page %d) Tj ET", i+1)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	return createTestFile(t, dir, name, buildPDF(objects))
+}
+
+func TestParseFileRangeSpecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []FileRangeSpec
+	}{
+		{
+			name:  "ranges continue after comma",
+			input: "a.pdf:1-3,b.pdf:5,7,9-",
+			want: []FileRangeSpec{
+				{File: "a.pdf", Ranges: []PageRange{{1, 3}}},
+				{File: "b.pdf", Ranges: []PageRange{{5, 5}, {7, 7}, {9, 0}}},
+			},
+		},
+		{
+			name:  "whole file",
+			input: "a.pdf, b.pdf:2",
+			want: []FileRangeSpec{
+				{File: "a.pdf"},
+				{File: "b.pdf", Ranges: []PageRange{{2, 2}}},
+			},
+		},
+		{
+			name:  "windows path",
+			input: `C:\docs\a.pdf:1-2,C:\docs\b.pdf`,
+			want: []FileRangeSpec{
+				{File: `C:\docs\a.pdf`, Ranges: []PageRange{{1, 2}}},
+				{File: `C:\docs\b.pdf`},
+			},
+		},
+		{
+			name:  "numeric name after whole file",
+			input: "a.pdf,2024",
+			want:  []FileRangeSpec{{File: "a.pdf"}, {File: "2024"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := ParseFileRangeSpecs(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, specs)
+		})
+	}
+}
+
+func TestParseFileRangeSpecs_InvalidRanges(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"a.pdf:0", "a.pdf 的页码范围 0 无效"},
+		{"a.pdf:1-3,b.pdf:5-2", "b.pdf 的页码范围 5-2 无效"},
+		{"a.pdf:2,0-4", "a.pdf 的页码范围 0-4 无效"},
+		{"a.pdf:1--2", "a.pdf 的页码范围 1--2 无效"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseFileRangeSpecs(tt.input)
+			require.Error(t, err)
+			var pdfErr *PDFError
+			require.ErrorAs(t, err, &pdfErr)
+			assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+			assert.Contains(t, pdfErr.Message, tt.message)
+		})
+	}
+}
+
+func TestFileRangeSpec_ResolvePages(t *testing.T) {
+	spec := FileRangeSpec{File: "a.pdf", Ranges: []PageRange{{4, 5}, {1, 1}, {7, 0}}}
+	pages, err := spec.ResolvePages(8)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 1, 7, 8}, pages, "页面按范围顺序排列")
+
+	_, err = spec.ResolvePages(6)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.pdf 的页码范围 7- 无效：超出文档页数 6")
+
+	_, err = FileRangeSpec{File: "a.pdf", Ranges: []PageRange{{1, 3}, {2, 2}}}.ResolvePages(5)
+	assert.ErrorContains(t, err, "重复")
+
+	all, err := FileRangeSpec{File: "a.pdf"}.ResolvePages(3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, all)
+}
+
+func TestExtractPages_KeepsInheritedAttributes(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNestedPDF(t, dir, "nested.pdf")
+	output := filepath.Join(dir, "extracted.pdf")
+
+	require.NoError(t, ExtractPages(input, output, []int{5, 2}))
+
+	stats, err := WalkPageTreeFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{9, 6}, stats.Pages, "页面按指定顺序排列")
+
+	boxes, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	assert.Equal(t, [4]float64{0, 0, 300, 400}, boxes[0].MediaBox)
+	assert.Equal(t, [4]float64{0, 0, 600, 800}, boxes[1].MediaBox)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	offsets := indexObjects(data)
+	page, _ := objectBody(data, offsets, 6)
+	assert.Contains(t, string(page), "/Parent 2 0 R")
+	assert.Contains(t, string(page), "/Resources << /Font << /F1 10 0 R >> >>")
+	assert.Contains(t, string(page), "/Rotate 90")
+
+	check, err := checkXRefBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, XRefTable, check.Kind)
+}
+
+func TestMergeFilesWithPageRanges_InvalidRangeNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNestedPDF(t, dir, "nested.pdf")
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeFilesWithPageRanges([]FileRangeSpec{
+		{File: input, Ranges: []PageRange{{4, 6}}},
+	}, output, nil)
+
+	require.Error(t, err)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, input, pdfErr.File)
+	assert.Contains(t, pdfErr.Message, "4-6")
+	assert.Contains(t, pdfErr.Message, "超出文档页数 5")
+	require.NotNil(t, result)
+	assert.Equal(t, MergeStageValidation, result.FailedStage)
+	assert.False(t, fileExists(output))
+}
+
+func TestMergeFilesWithPageRanges_ReportsExtractedPages(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNestedPDF(t, dir, "nested.pdf")
+	output := filepath.Join(dir, "out.pdf")
+
+	var mu sync.Mutex
+	var messages []string
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	result, err := merger.MergeFilesWithPageRanges([]FileRangeSpec{
+		{File: input, Ranges: []PageRange{{1, 2}, {5, 0}}},
+	}, output, func(progress float64, message string) {
+		mu.Lock()
+		messages = append(messages, message)
+		mu.Unlock()
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.TotalPages, "总页数应为实际提取的页数")
+	assert.Equal(t, []string{input}, result.ValidatedFiles, "结果中应显示原始输入")
+	assert.Equal(t, []InputPageCount{{File: input, Pages: 3}}, result.InputPages)
+	count, err := CountPagesInFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	mu.Lock()
+	assert.Contains(t, strings.Join(messages, "\n"), "提取页面 (1/1): nested.pdf")
+	mu.Unlock()
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "pdf-pages-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "提取副本应被清理")
+}
+
+func TestMergeFilesWithPageRanges_MultipleInputs(t *testing.T) {
+	dir := t.TempDir()
+	nested := writeNestedPDF(t, dir, "nested.pdf")
+	flat := createTestFile(t, dir, "flat.pdf", buildFlatPDF(4))
+	output := filepath.Join(dir, "out.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	if merger.adapter == nil || !CheckPDFCPUAvailability().IsAvailable() {
+		t.Skip("pdfcpu不可用")
+	}
+	result, err := merger.MergeFilesWithPageRanges([]FileRangeSpec{
+		{File: nested, Ranges: []PageRange{{2, 3}}},
+		{File: flat},
+	}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 6, result.TotalPages)
+	assert.Equal(t, []string{nested, flat}, result.ValidatedFiles)
+}
+
+func TestPDFServiceImpl_ExtractPageRanges(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNestedPDF(t, dir, "nested.pdf")
+	output := filepath.Join(dir, "split.pdf")
+
+	service := NewPDFService()
+	require.NoError(t, service.ExtractPageRanges(input, "4-5,1", output))
+
+	stats, err := WalkPageTreeFile(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{8, 9, 5}, stats.Pages, "页面按范围顺序排列")
+}
+
+func TestPDFServiceImpl_ExtractPages_OutOfRange(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNestedPDF(t, dir, "nested.pdf")
+	output := filepath.Join(dir, "split.pdf")
+
+	service := NewPDFService()
+	for _, pages := range [][]int{{2, 6}, {0}, nil} {
+		err := service.ExtractPages(input, pages, output)
+		var pdfErr *PDFError
+		require.ErrorAs(t, err, &pdfErr)
+		assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	}
+	assert.False(t, fileExists(output), "页码无效时不应写出文件")
+
+	err := service.ExtractPageRanges(input, "3-7", output)
+	assert.ErrorContains(t, err, "超出文档页数 5")
+
+	err = service.ExtractPages(filepath.Join(dir, "missing.pdf"), []int{1}, output)
+	assert.Error(t, err)
+}
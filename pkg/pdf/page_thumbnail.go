@@ -0,0 +1,276 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// maxThumbnailSourcePixels 缩略图中绘制的单个图像解码后的最大像素数，更大的图像不绘制
+const maxThumbnailSourcePixels = 4096 * 4096
+
+// thumbnailBorderColor 缩略图页面轮廓的颜色，使白色页面在白色背景上可以分辨
+var thumbnailBorderColor = color.RGBA{0xC0, 0xC0, 0xC0, 0xFF}
+
+// RenderPageThumbnail 把指定页（从1开始）按页面比例渲染为不超过width*height像素的缩略图，按页面的 /Rotate 显示，
+// 同时返回绘制的图像数。不依赖外部工具：在白色页面上按内容流中的位置绘制页面直接引用的图像XObject
+// （8位DeviceGray、DeviceRGB、DeviceCMYK或ICCBased，未压缩、FlateDecode或DCTDecode）。
+// 文字、矢量图形和表单XObject中的图像不绘制，没有可绘制的图像时结果只有页面轮廓；
+// 无法解码的图像被跳过。checkPixels 在分配画布和解码每个图像前调用，返回错误时中止渲染，为nil时不检查。
+// 文件无法读取、页面无法解析或ctx取消时返回错误。
+// 该函数本身没有超时和熔断，界面中应通过 pkg/thumbnail 的 Renderer 调用
+func RenderPageThumbnail(ctx context.Context, filePath string, page, width, height int,
+	checkPixels func(width, height int) error) (image.Image, int, error) {
+	if width < 1 || height < 1 {
+		return nil, 0, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("缩略图尺寸 %dx%d 无效", width, height),
+			File:    filePath,
+		}
+	}
+	if checkPixels == nil {
+		checkPixels = func(int, int) error { return nil }
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 || page > len(stats.Pages) {
+		return nil, 0, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("页码 %d 超出范围（共 %d 页）", page, len(stats.Pages)),
+			File:    filePath,
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	offsets := indexObjects(data)
+	body, _ := objectBody(data, offsets, stats.Pages[page-1])
+	box := [4]float64{0, 0, 612, 792}
+	if mediaBox, ok := inheritedMediaBox(data, offsets, body); ok {
+		box = mediaBox
+	}
+	content, err := pageContent(data, offsets, body)
+	if err != nil {
+		return nil, 0, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: fmt.Sprintf("无法读取第%d页的内容流", page),
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	images := make(map[string]int)
+	if resources := inheritedResources(data, offsets, body); resources != nil {
+		if xobjects := resolveDict(data, offsets, resources, "/XObject"); xobjects != nil {
+			for _, m := range xobjectRefPattern.FindAllSubmatch(xobjects, -1) {
+				num, _ := strconv.Atoi(string(m[2]))
+				images[string(m[1])] = num
+			}
+		}
+	}
+
+	// 旋转90或270度的页面横向显示，按旋转后的尺寸放入width*height
+	rotation := pageRotation(data, offsets, body)
+	pageW, pageH := box[2]-box[0], box[3]-box[1]
+	shownW, shownH := pageW, pageH
+	if rotation == 90 || rotation == 270 {
+		shownW, shownH = pageH, pageW
+	}
+	scale := math.Min(float64(width)/shownW, float64(height)/shownH)
+	canvasW := max(1, int(math.Round(pageW*scale)))
+	canvasH := max(1, int(math.Round(pageH*scale)))
+	if err := checkPixels(canvasW, canvasH); err != nil {
+		return nil, 0, err
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, canvasW, canvasH))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// 同一图像可能绘制多次，只解码一次
+	decoded := make(map[int]image.Image)
+	drawn := 0
+	var renderErr error
+	scanImageDraws(content, func(name string, ctm affine) {
+		num, ok := images[name]
+		if !ok || renderErr != nil {
+			return
+		}
+		if renderErr = ctx.Err(); renderErr != nil {
+			return
+		}
+		img, seen := decoded[num]
+		if !seen {
+			img, renderErr = decodeThumbnailImage(data, offsets, num, checkPixels)
+			decoded[num] = img
+		}
+		if img != nil {
+			drawPlacedImage(canvas, img, ctm, box, scale)
+			drawn++
+		}
+	})
+	if renderErr != nil {
+		return nil, 0, renderErr
+	}
+
+	rotated := rotateClockwise(canvas, rotation)
+	strokeBorder(rotated, thumbnailBorderColor)
+	return rotated, drawn, nil
+}
+
+// decodeThumbnailImage 解码图像XObject，不支持的格式、超出像素上限或解码失败时返回nil；
+// checkPixels 拒绝图像尺寸时返回它的错误
+func decodeThumbnailImage(data []byte, offsets map[int]int, num int, checkPixels func(width, height int) error) (image.Image, error) {
+	obj, ok := objectBody(data, offsets, num)
+	if !ok || !imageTypePattern.Match(obj) {
+		return nil, nil
+	}
+	dict, raw, err := splitStream(obj)
+	if err != nil {
+		return nil, nil
+	}
+	width, errW := strconv.Atoi(directValue(dict, "/Width"))
+	height, errH := strconv.Atoi(directValue(dict, "/Height"))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return nil, nil
+	}
+	if err := checkPixels(width, height); err != nil {
+		return nil, err
+	}
+	if int64(width)*int64(height) > maxThumbnailSourcePixels {
+		return nil, nil
+	}
+
+	// 只有一个过滤器的数组与单个过滤器相同
+	filter := strings.TrimSpace(strings.Trim(entryValue(dict, "/Filter"), "[]"))
+	switch filter {
+	case "/DCTDecode":
+		config, err := jpeg.DecodeConfig(bytes.NewReader(raw))
+		if err != nil || config.Width != width || config.Height != height {
+			return nil, nil
+		}
+		img, err := jpeg.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return nil, nil
+		}
+		return img, nil
+	case "", "/FlateDecode":
+		if directValue(dict, "/DecodeParms") != "" {
+			return nil, nil
+		}
+		components, ok := imageComponentsWith(dict, func(num int) ([]byte, bool) {
+			return objectDict(data, offsets, num)
+		})
+		if !ok {
+			return nil, nil
+		}
+		size := width * height * components
+		samples := raw
+		if filter != "" {
+			if samples, err = inflate(raw, size); err != nil {
+				return nil, nil
+			}
+		}
+		if len(samples) < size {
+			return nil, nil
+		}
+		if components == 4 {
+			return &image.CMYK{Pix: samples[:size], Stride: width * 4, Rect: image.Rect(0, 0, width, height)}, nil
+		}
+		return samplesImage(samples[:size], width, height, components), nil
+	}
+	return nil, nil
+}
+
+// objectDict 返回对象的内容，流对象只返回流字典
+func objectDict(data []byte, offsets map[int]int, num int) ([]byte, bool) {
+	body, ok := objectBody(data, offsets, num)
+	if !ok {
+		return nil, false
+	}
+	if dict, _, err := splitStream(body); err == nil {
+		return dict, true
+	}
+	return body, true
+}
+
+// drawPlacedImage 把图像绘制到ctm变换后的单位正方形上（图像的第一行对应单位正方形的上边），
+// box 为页面的MediaBox，scale 为每个用户空间单位对应的像素数
+func drawPlacedImage(canvas *image.RGBA, img image.Image, ctm affine, box [4]float64, scale float64) {
+	a, b, c, d, e, f := ctm[0], ctm[1], ctm[2], ctm[3], ctm[4], ctm[5]
+	if math.Abs(a*d-b*c) < 1e-9 {
+		return
+	}
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	// 图像像素(u, v)对应单位正方形中的(u/w, 1-v/h)，变换到用户空间后再映射到画布（y轴向下）
+	s2d := f64.Aff3{
+		scale * a / w, -scale * c / h, scale * (c + e - box[0]),
+		-scale * b / w, scale * d / h, scale * (box[3] - d - f),
+	}
+	xdraw.ApproxBiLinear.Transform(canvas, s2d, img, bounds, xdraw.Over, nil)
+}
+
+// rotateClockwise 把图像顺时针旋转rotation度（0、90、180或270）
+func rotateClockwise(src *image.RGBA, rotation int) *image.RGBA {
+	if rotation != 90 && rotation != 180 && rotation != 270 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dstW, dstH := h, w
+	if rotation == 180 {
+		dstW, dstH = w, h
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch rotation {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			default:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(src.Bounds().Min.X+x, src.Bounds().Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// strokeBorder 在图像边缘绘制一像素的轮廓
+func strokeBorder(img *image.RGBA, c color.RGBA) {
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		img.SetRGBA(x, b.Min.Y, c)
+		img.SetRGBA(x, b.Max.Y-1, c)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		img.SetRGBA(b.Min.X, y, c)
+		img.SetRGBA(b.Max.X-1, y, c)
+	}
+}
@@ -0,0 +1,152 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeThumbnailPDF 写出单页测试文件：页面的上半部分绘制一个图像XObject，pageExtra 附加到页面字典
+func writeThumbnailPDF(t *testing.T, dir, name, pageExtra, imageObject string) string {
+	content := "q 612 0 0 396 0 396 cm /Im1 Do Q BT /F1 12 Tf 72 100 Td (text) Tj ET"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R " +
+			"/Resources << /XObject << /Im1 5 0 R >> >> " + pageExtra + ">>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		imageObject,
+	})
+	return createTestFile(t, dir, name, data)
+}
+
+// flateImageObject 返回纯色的FlateDecode RGB图像对象
+func flateImageObject(c color.RGBA) string {
+	pixels := bytes.Repeat([]byte{c.R, c.G, c.B}, 4*4)
+	stream := deflate(pixels)
+	return fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 4 /Height 4 /ColorSpace /DeviceRGB "+
+		"/BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", len(stream), stream)
+}
+
+// assertColorNear 断言像素颜色与期望值的各分量相差不超过tolerance
+func assertColorNear(t *testing.T, img image.Image, x, y int, want color.RGBA, tolerance int) {
+	t.Helper()
+	r, g, b, _ := img.At(x, y).RGBA()
+	got := [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+	for i, w := range [3]int{int(want.R), int(want.G), int(want.B)} {
+		if d := got[i] - w; d > tolerance || d < -tolerance {
+			t.Errorf("像素(%d, %d)为%v，期望接近%v", x, y, got, want)
+			return
+		}
+	}
+}
+
+func TestRenderPageThumbnail_DrawsImagesAtPlacement(t *testing.T) {
+	dir := t.TempDir()
+	red := color.RGBA{0xFF, 0, 0, 0xFF}
+	input := writeThumbnailPDF(t, dir, "image.pdf", "", flateImageObject(red))
+
+	img, _, err := RenderPageThumbnail(context.Background(), input, 1, 128, 128, nil)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 99, 128), img.Bounds(), "应保持页面比例放入给定尺寸")
+
+	// 图像绘制在页面的上半部分，下半部分的文字不绘制
+	assertColorNear(t, img, 50, 30, red, 8)
+	assertColorNear(t, img, 50, 100, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, 0)
+	assertColorNear(t, img, 0, 0, thumbnailBorderColor, 0)
+}
+
+func TestRenderPageThumbnail_DCTImage(t *testing.T) {
+	dir := t.TempDir()
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	blue := color.RGBA{0, 0, 0xFF, 0xFF}
+	for i := 0; i < len(src.Pix); i += 4 {
+		copy(src.Pix[i:], []byte{blue.R, blue.G, blue.B, blue.A})
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, src, &jpeg.Options{Quality: 95}))
+	object := fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 8 /Height 8 /ColorSpace /DeviceRGB "+
+		"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream", encoded.Len(), encoded.String())
+	input := writeThumbnailPDF(t, dir, "jpeg.pdf", "", object)
+
+	img, _, err := RenderPageThumbnail(context.Background(), input, 1, 64, 64, nil)
+	require.NoError(t, err)
+	assertColorNear(t, img, 25, 15, blue, 24)
+}
+
+func TestRenderPageThumbnail_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	red := color.RGBA{0xFF, 0, 0, 0xFF}
+	input := writeThumbnailPDF(t, dir, "rotated.pdf", "/Rotate 90 ", flateImageObject(red))
+
+	img, _, err := RenderPageThumbnail(context.Background(), input, 1, 128, 128, nil)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 99), img.Bounds(), "旋转90度的页面应横向显示")
+	// 页面的上半部分顺时针旋转后在右侧
+	assertColorNear(t, img, 100, 50, red, 8)
+	assertColorNear(t, img, 30, 50, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, 0)
+}
+
+func TestRenderPageThumbnail_UnsupportedImageLeavesBlankPage(t *testing.T) {
+	dir := t.TempDir()
+	object := "<< /Type /XObject /Subtype /Image /Width 4 /Height 4 /ColorSpace /DeviceRGB " +
+		"/BitsPerComponent 8 /Filter /JBIG2Decode /Length 4 >>\nstream\nxxxx\nendstream"
+	input := writeThumbnailPDF(t, dir, "jbig2.pdf", "", object)
+
+	img, _, err := RenderPageThumbnail(context.Background(), input, 1, 64, 64, nil)
+	require.NoError(t, err, "无法解码的图像应被跳过")
+	assertColorNear(t, img, 25, 15, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, 0)
+}
+
+func TestRenderPageThumbnail_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := writeThumbnailPDF(t, dir, "image.pdf", "", flateImageObject(color.RGBA{0xFF, 0, 0, 0xFF}))
+
+	var pdfErr *PDFError
+	_, _, err := RenderPageThumbnail(context.Background(), input, 1, 0, 64, nil)
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type, "尺寸无效应返回输入错误")
+
+	_, _, err = RenderPageThumbnail(context.Background(), input, 2, 64, 64, nil)
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type, "页码超出范围应返回输入错误")
+
+	_, _, err = RenderPageThumbnail(context.Background(), createTestFile(t, dir, "bad.pdf", []byte("not a pdf")), 1, 64, 64, nil)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = RenderPageThumbnail(ctx, input, 1, 64, 64, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRenderPageThumbnail_ChecksPixelsAndCountsImages(t *testing.T) {
+	dir := t.TempDir()
+	input := writeThumbnailPDF(t, dir, "image.pdf", "", flateImageObject(color.RGBA{0xFF, 0, 0, 0xFF}))
+
+	var checked [][2]int
+	_, drawn, err := RenderPageThumbnail(context.Background(), input, 1, 64, 64, func(width, height int) error {
+		checked = append(checked, [2]int{width, height})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, drawn)
+	assert.Equal(t, [][2]int{{49, 64}, {4, 4}}, checked, "画布和图像解码前都应检查像素数")
+
+	budget := errors.New("超出像素预算")
+	_, _, err = RenderPageThumbnail(context.Background(), input, 1, 64, 64, func(width, height int) error {
+		if width == 4 {
+			return budget
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, budget, "图像超出预算时应中止渲染")
+}
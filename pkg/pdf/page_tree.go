@@ -0,0 +1,326 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// PageTreeLimits 页面树遍历限制，防止畸形或恶意文件耗尽栈和内存
+type PageTreeLimits struct {
+	MaxDepth int // 页面树最大深度
+	MaxNodes int // 页面树对象（Pages与Page节点）总数上限
+	MaxKids  int // 单个节点Kids数组的最大条目数
+}
+
+// DefaultPageTreeLimits 返回默认的页面树限制
+func DefaultPageTreeLimits() *PageTreeLimits {
+	return &PageTreeLimits{
+		MaxDepth: 512,
+		MaxNodes: 1000000,
+		MaxKids:  200000,
+	}
+}
+
+// LimitExceededError 描述被触发的限制及观测到的值
+type LimitExceededError struct {
+	Limit    string // 限制名称，如 "MaxDepth"
+	Max      int    // 配置的上限
+	Observed int    // 实际观测值
+}
+
+// Error 实现error接口
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("limit %s exceeded: observed %d, max %d", e.Limit, e.Observed, e.Max)
+}
+
+// newLimitExceededError 创建包装了LimitExceededError的PDFError
+func newLimitExceededError(filePath, limit string, max, observed int) *PDFError {
+	return &PDFError{
+		Type:    ErrorLimitExceeded,
+		Message: fmt.Sprintf("页面树超出限制 %s（观测值 %d，上限 %d）", limit, observed, max),
+		File:    filePath,
+		Cause:   &LimitExceededError{Limit: limit, Max: max, Observed: observed},
+	}
+}
+
+var (
+	objHeaderPattern = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	rootRefPattern   = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	pagesRefPattern  = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R`)
+	pageTypePattern  = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// pageTreeNode 遍历栈中的条目
+type pageTreeNode struct {
+	objNum int
+	depth  int
+}
+
+// PageTreeStats 页面树遍历结果
+type PageTreeStats struct {
+	PageCount int   // 叶子页面数
+	NodeCount int   // 访问的节点总数
+	MaxDepth  int   // 实际最大深度
+	Pages     []int // 页面对象编号，按文档顺序
+}
+
+// CountPagesInFile 遍历文件的页面树并返回页数
+func CountPagesInFile(filePath string, limits *PageTreeLimits) (int, error) {
+	stats, err := WalkPageTreeFile(filePath, limits)
+	if err != nil {
+		return 0, err
+	}
+	return stats.PageCount, nil
+}
+
+// WalkPageTreeFile 读取文件并遍历其页面树
+func WalkPageTreeFile(filePath string, limits *PageTreeLimits) (*PageTreeStats, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return WalkPageTree(filePath, data, limits)
+}
+
+// WalkPageTree 使用显式栈迭代遍历页面树，在深度、节点数和Kids数量上强制限制。
+// 无法定位页面树（如对象位于对象流中）时返回ErrorCorrupted类型错误，调用方可回退到启发式方法。
+func WalkPageTree(filePath string, data []byte, limits *PageTreeLimits) (*PageTreeStats, error) {
+	if limits == nil {
+		limits = DefaultPageTreeLimits()
+	}
+
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats := &PageTreeStats{}
+	visited := make(map[int]bool)
+	stack := []pageTreeNode{{objNum: rootNum, depth: 1}}
+
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if node.depth > limits.MaxDepth {
+			return nil, newLimitExceededError(filePath, "MaxDepth", limits.MaxDepth, node.depth)
+		}
+		if visited[node.objNum] {
+			return nil, &PDFError{
+				Type:    ErrorCorrupted,
+				Message: fmt.Sprintf("页面树存在循环引用（对象 %d）", node.objNum),
+				File:    filePath,
+			}
+		}
+		visited[node.objNum] = true
+
+		stats.NodeCount++
+		if stats.NodeCount > limits.MaxNodes {
+			return nil, newLimitExceededError(filePath, "MaxNodes", limits.MaxNodes, stats.NodeCount)
+		}
+		if node.depth > stats.MaxDepth {
+			stats.MaxDepth = node.depth
+		}
+
+		body, ok := objectBody(data, offsets, node.objNum)
+		if !ok {
+			return nil, &PDFError{
+				Type:    ErrorCorrupted,
+				Message: fmt.Sprintf("页面树引用的对象 %d 不存在", node.objNum),
+				File:    filePath,
+			}
+		}
+
+		kidsStart := bytes.Index(body, []byte("/Kids"))
+		if kidsStart < 0 {
+			if pageTypePattern.Match(body) {
+				stats.PageCount++
+				stats.Pages = append(stats.Pages, node.objNum)
+			}
+			continue
+		}
+
+		// 先计数再分配，避免超大Kids数组引发巨量内存分配
+		kidsArray := body[kidsStart+len("/Kids"):]
+		if n := countArrayRefs(kidsArray); n > limits.MaxKids {
+			return nil, newLimitExceededError(filePath, "MaxKids", limits.MaxKids, n)
+		}
+
+		kids := parseArrayRefs(kidsArray)
+		// 逆序压栈以保持文档页面顺序
+		for i := len(kids) - 1; i >= 0; i-- {
+			stack = append(stack, pageTreeNode{objNum: kids[i], depth: node.depth + 1})
+		}
+	}
+
+	return stats, nil
+}
+
+// indexObjects 建立对象编号到对象内容起始偏移的索引，后出现的定义覆盖先前定义（增量更新）
+func indexObjects(data []byte) map[int]int {
+	offsets := make(map[int]int)
+	for _, m := range objHeaderPattern.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		offsets[num] = m[1]
+	}
+	return offsets
+}
+
+// objectBody 返回对象内容（obj与endobj之间）
+func objectBody(data []byte, offsets map[int]int, objNum int) ([]byte, bool) {
+	start, ok := offsets[objNum]
+	if !ok {
+		return nil, false
+	}
+	end := bytes.Index(data[start:], []byte("endobj"))
+	if end < 0 {
+		return data[start:], true
+	}
+	return data[start : start+end], true
+}
+
+// objectBodies 返回所有对象的内容：按对象头扫描得到未压缩的对象（增量更新以最后的定义为准），
+// 再用交叉引用中位于对象流的对象覆盖。无法读取的对象流被忽略
+func objectBodies(data []byte) map[int][]byte {
+	bodies := make(map[int][]byte)
+	offsets := indexObjects(data)
+	for num := range offsets {
+		if body, ok := objectBody(data, offsets, num); ok {
+			bodies[num] = body
+		}
+	}
+	if !objectStreamPattern.Match(data) {
+		return bodies
+	}
+	index, err := readXRefIndex(data)
+	if err != nil {
+		return bodies
+	}
+	for num, entry := range index.entries {
+		if entry.stream == 0 {
+			continue
+		}
+		if objects, err := index.objectStream(entry.stream); err == nil {
+			if body, ok := objects[num]; ok {
+				bodies[num] = body
+			}
+		}
+	}
+	return bodies
+}
+
+// findPageTreeRoot 通过 trailer 的 /Root 与目录的 /Pages 找到页面树根对象
+func findPageTreeRoot(data []byte, offsets map[int]int) (int, error) {
+	matches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("未找到 /Root 引用")
+	}
+	// 增量更新时以最后一个 trailer 为准
+	rootNum, err := strconv.Atoi(string(matches[len(matches)-1][1]))
+	if err != nil {
+		return 0, err
+	}
+
+	catalog, ok := objectBody(data, offsets, rootNum)
+	if !ok {
+		return 0, fmt.Errorf("目录对象 %d 不存在", rootNum)
+	}
+
+	m := pagesRefPattern.FindSubmatch(catalog)
+	if m == nil {
+		return 0, fmt.Errorf("目录对象缺少 /Pages 引用")
+	}
+	return strconv.Atoi(string(m[1]))
+}
+
+// scanArrayRefs 扫描以 [ 开始的数组中的间接引用（N G R），对每个引用调用fn
+func scanArrayRefs(data []byte, fn func(objNum int)) {
+	i := 0
+	for i < len(data) && isPDFWhitespace(data[i]) {
+		i++
+	}
+	if i >= len(data) || data[i] != '[' {
+		return
+	}
+	i++
+
+	var nums [2]int
+	count := 0
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case c == ']':
+			return
+		case isPDFWhitespace(c):
+			i++
+		case c >= '0' && c <= '9':
+			n := 0
+			for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+				n = n*10 + int(data[i]-'0')
+				i++
+			}
+			if count == 2 {
+				nums[0] = nums[1]
+				count = 1
+			}
+			nums[count] = n
+			count++
+		case c == 'R':
+			if count == 2 {
+				fn(nums[0])
+			}
+			count = 0
+			i++
+		default:
+			count = 0
+			i++
+		}
+	}
+}
+
+// countArrayRefs 统计数组中的引用数量而不分配内存
+func countArrayRefs(data []byte) int {
+	n := 0
+	scanArrayRefs(data, func(int) { n++ })
+	return n
+}
+
+// parseArrayRefs 解析数组中的引用对象编号
+func parseArrayRefs(data []byte) []int {
+	refs := make([]int, 0, countArrayRefs(data))
+	scanArrayRefs(data, func(objNum int) { refs = append(refs, objNum) })
+	return refs
+}
+
+// isPDFWhitespace 判断是否为PDF空白字符
+func isPDFWhitespace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+// IsLimitExceededError 判断错误链中是否包含限制超出错误
+func IsLimitExceededError(err error) bool {
+	var limitErr *LimitExceededError
+	return errors.As(err, &limitErr)
+}
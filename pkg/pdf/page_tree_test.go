@@ -0,0 +1,215 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// buildPDF 根据对象内容（按编号1..n排列）生成带交叉引用表的最小PDF，对象1为目录
+func buildPDF(objects []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+	return buf.Bytes()
+}
+
+// buildFlatPDF 生成单层页面树，包含pages个页面
+func buildFlatPDF(pages int) []byte {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	var kids bytes.Buffer
+	for i := 0; i < pages; i++ {
+		fmt.Fprintf(&kids, "%d 0 R ", i+3)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), pages))
+	for i := 0; i < pages; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+	return buildPDF(objects)
+}
+
+// buildDeepPDF 生成深度为depth的Pages链，末端挂一个页面
+func buildDeepPDF(depth int) []byte {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	for i := 0; i < depth; i++ {
+		objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", i+3))
+	}
+	objects = append(objects, "<< /Type /Page /MediaBox [0 0 612 792] >>")
+	return buildPDF(objects)
+}
+
+// buildWideKidsPDF 生成Kids数组包含n个引用的页面树（引用的对象并不存在）
+func buildWideKidsPDF(n int) []byte {
+	var kids bytes.Buffer
+	kids.Grow(n * 8)
+	for i := 0; i < n; i++ {
+		kids.WriteString("3 0 R ")
+	}
+	return buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), n),
+	})
+}
+
+// requireLimitError 断言错误为指定限制的LimitExceededError
+func requireLimitError(t *testing.T, err error, limit string, max int) *LimitExceededError {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("期望 %s 限制错误，实际为nil", limit)
+	}
+
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorLimitExceeded {
+		t.Fatalf("期望ErrorLimitExceeded类型的PDFError，实际: %v", err)
+	}
+
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("错误链中缺少LimitExceededError: %v", err)
+	}
+	if limitErr.Limit != limit {
+		t.Errorf("期望限制 %s，实际 %s", limit, limitErr.Limit)
+	}
+	if limitErr.Max != max {
+		t.Errorf("期望上限 %d，实际 %d", max, limitErr.Max)
+	}
+	if limitErr.Observed <= limitErr.Max {
+		t.Errorf("观测值 %d 应大于上限 %d", limitErr.Observed, limitErr.Max)
+	}
+	return limitErr
+}
+
+// hostileDepth、hostileKids 刚超过默认限制的深度和Kids数，用于确认在触发限制时立即停止
+var (
+	hostileDepth = DefaultPageTreeLimits().MaxDepth + 100
+	hostileKids  = DefaultPageTreeLimits().MaxKids + 1
+)
+
+func TestWalkPageTree_NormalDocument(t *testing.T) {
+	stats, err := WalkPageTree("flat.pdf", buildFlatPDF(5), nil)
+	if err != nil {
+		t.Fatalf("遍历正常文档失败: %v", err)
+	}
+	if stats.PageCount != 5 {
+		t.Errorf("期望5页，实际 %d", stats.PageCount)
+	}
+	if stats.NodeCount != 6 {
+		t.Errorf("期望6个节点，实际 %d", stats.NodeCount)
+	}
+	if stats.MaxDepth != 2 {
+		t.Errorf("期望深度2，实际 %d", stats.MaxDepth)
+	}
+}
+
+func TestWalkPageTree_DeepChain(t *testing.T) {
+	_, err := WalkPageTree("deep.pdf", buildDeepPDF(hostileDepth), nil)
+	limitErr := requireLimitError(t, err, "MaxDepth", DefaultPageTreeLimits().MaxDepth)
+	if limitErr.Observed != DefaultPageTreeLimits().MaxDepth+1 {
+		t.Errorf("应在首次越界时停止，实际观测值 %d", limitErr.Observed)
+	}
+}
+
+func TestWalkPageTree_DeepChainWithRaisedLimit(t *testing.T) {
+	limits := DefaultPageTreeLimits()
+	limits.MaxDepth = 2000
+
+	stats, err := WalkPageTree("deep.pdf", buildDeepPDF(1000), limits)
+	if err != nil {
+		t.Fatalf("提高限制后应能处理深层文档: %v", err)
+	}
+	if stats.PageCount != 1 {
+		t.Errorf("期望1页，实际 %d", stats.PageCount)
+	}
+}
+
+func TestWalkPageTree_HugeKidsArray(t *testing.T) {
+	_, err := WalkPageTree("wide.pdf", buildWideKidsPDF(hostileKids), nil)
+	limitErr := requireLimitError(t, err, "MaxKids", DefaultPageTreeLimits().MaxKids)
+	if limitErr.Observed != hostileKids {
+		t.Errorf("期望观测值%d，实际 %d", hostileKids, limitErr.Observed)
+	}
+}
+
+func TestWalkPageTree_MaxNodes(t *testing.T) {
+	limits := &PageTreeLimits{MaxDepth: 10, MaxNodes: 50, MaxKids: 1000}
+
+	_, err := WalkPageTree("many.pdf", buildFlatPDF(100), limits)
+	requireLimitError(t, err, "MaxNodes", 50)
+}
+
+func TestWalkPageTree_Cycle(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Pages /Kids [2 0 R] /Count 1 >>",
+	})
+
+	_, err := WalkPageTree("cycle.pdf", data, nil)
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorCorrupted {
+		t.Fatalf("期望循环引用返回ErrorCorrupted，实际: %v", err)
+	}
+	if IsLimitExceededError(err) {
+		t.Error("循环引用不应报告为限制错误")
+	}
+}
+
+func TestWalkPageTree_MissingRoot(t *testing.T) {
+	_, err := WalkPageTree("noroot.pdf", []byte("%PDF-1.4\n%%EOF\n"), nil)
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorCorrupted {
+		t.Fatalf("期望ErrorCorrupted，实际: %v", err)
+	}
+}
+
+func TestPageTreeLimits_ReadersRejectHostileFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	limits := DefaultPageTreeLimits()
+	files := []struct {
+		path  string
+		limit string
+		max   int
+	}{
+		{createTestFile(t, tempDir, "deep.pdf", buildDeepPDF(hostileDepth)), "MaxDepth", limits.MaxDepth},
+		{createTestFile(t, tempDir, "wide.pdf", buildWideKidsPDF(hostileKids)), "MaxKids", limits.MaxKids},
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file.path), func(t *testing.T) {
+			reader, err := NewEnhancedPDFReader(file.path, ValidationRelaxed)
+			if err != nil {
+				t.Fatalf("创建读取器失败: %v", err)
+			}
+			defer reader.Close()
+			_, err = reader.GetInfo()
+			requireLimitError(t, err, file.limit, file.max)
+
+			_, err = NewPDFService().GetPDFInfo(file.path)
+			requireLimitError(t, err, file.limit, file.max)
+		})
+	}
+}
+
+func TestPageTreeLimits_ServiceConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	file := createTestFile(t, tempDir, "pages.pdf", buildFlatPDF(20))
+
+	config := DefaultServiceConfig()
+	config.PageTreeLimits = &PageTreeLimits{MaxDepth: 10, MaxNodes: 10, MaxKids: 100}
+	service := NewPDFServiceWithConfig(config)
+
+	_, err := service.GetPDFInfo(file)
+	requireLimitError(t, err, "MaxNodes", 10)
+}
@@ -0,0 +1,443 @@
+package pdf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// PasswordVault 跨会话的密码保险库，以文件内容哈希为键保存密码。
+// 操作系统钥匙串等其他后端可通过实现该接口注入。
+type PasswordVault interface {
+	// Lookup 按内容哈希查找密码
+	Lookup(contentHash string) (string, bool, error)
+	// Store 保存密码，label仅用于展示（通常为文件名）
+	Store(contentHash, password, label string) error
+	// List 列出保险库条目（不包含密码）
+	List() ([]VaultEntry, error)
+	// Remove 删除指定条目
+	Remove(contentHash string) error
+	// Purge 清空保险库
+	Purge() error
+}
+
+// VaultEntry 保险库条目的公开信息，不包含密码
+type VaultEntry struct {
+	ContentHash string    `json:"content_hash"`
+	Label       string    `json:"label"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsed    time.Time `json:"last_used"`
+}
+
+// vaultRecord 加密保存的条目
+type vaultRecord struct {
+	VaultEntry
+	Password string `json:"password"`
+}
+
+// vaultFile 保险库文件格式，只有密文落盘
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+const (
+	vaultFileVersion    = 1
+	vaultKDFIterations  = 200000
+	vaultSaltSize       = 16
+	vaultKeySize        = 32
+	defaultVaultDirName = "pdf-merger"
+	defaultVaultName    = "password_vault.json"
+)
+
+// DefaultVaultPath 返回配置目录下的默认保险库路径
+func DefaultVaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, defaultVaultDirName, defaultVaultName), nil
+}
+
+// HashFileContent 计算文件内容的SHA-256哈希，作为保险库键。
+// 按内容而非路径索引，文件重命名或移动后仍能命中。
+func HashFileContent(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取文件以计算哈希",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "计算文件哈希失败",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// LookupVaultPassword 按文件内容哈希在保险库中查找密码；vault为nil、文件无法读取或没有条目时返回false
+func LookupVaultPassword(vault PasswordVault, filePath string) (string, bool) {
+	if vault == nil {
+		return "", false
+	}
+	contentHash, err := HashFileContent(filePath)
+	if err != nil {
+		return "", false
+	}
+	password, exists, err := vault.Lookup(contentHash)
+	if err != nil || !exists {
+		return "", false
+	}
+	return password, true
+}
+
+// RememberPassword 把解密成功的密码按文件内容哈希保存到保险库，条目标签为文件名；vault为nil时不做任何事
+func RememberPassword(vault PasswordVault, filePath, password string) error {
+	if vault == nil {
+		return nil
+	}
+	contentHash, err := HashFileContent(filePath)
+	if err != nil {
+		return err
+	}
+	return vault.Store(contentHash, password, filepath.Base(filePath))
+}
+
+// ForgetPassword 删除文件内容对应的保险库条目，用于保存的密码已失效时；vault为nil时不做任何事
+func ForgetPassword(vault PasswordVault, filePath string) error {
+	if vault == nil {
+		return nil
+	}
+	contentHash, err := HashFileContent(filePath)
+	if err != nil {
+		return err
+	}
+	return vault.Remove(contentHash)
+}
+
+// FileVault 基于文件的保险库，使用主密码派生的密钥以AES-GCM加密保存
+type FileVault struct {
+	path    string
+	key     []byte
+	salt    []byte
+	records map[string]*vaultRecord
+	mutex   sync.Mutex
+
+	// Clock 创建和最后使用时间的来源，nil时使用系统时钟
+	Clock clock.Clock
+}
+
+// OpenFileVault 打开或创建文件保险库。主密码错误或文件被篡改时返回错误。
+func OpenFileVault(path, passphrase string) (*FileVault, error) {
+	if passphrase == "" {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "保险库主密码不能为空",
+			File:    path,
+		}
+	}
+
+	vault := &FileVault{
+		path:    path,
+		records: make(map[string]*vaultRecord),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		vault.salt = make([]byte, vaultSaltSize)
+		if _, err := rand.Read(vault.salt); err != nil {
+			return nil, err
+		}
+		vault.key = deriveVaultKey(passphrase, vault.salt)
+		return vault, nil
+	}
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取密码保险库",
+			File:    path,
+			Cause:   err,
+		}
+	}
+
+	if err := vault.decode(data, passphrase); err != nil {
+		return nil, err
+	}
+	return vault, nil
+}
+
+// Lookup 按内容哈希查找密码，命中时更新最后使用时间
+func (v *FileVault) Lookup(contentHash string) (string, bool, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	record, exists := v.records[contentHash]
+	if !exists {
+		return "", false, nil
+	}
+	record.LastUsed = clock.OrSystem(v.Clock).Now()
+	return record.Password, true, v.save()
+}
+
+// Store 保存或更新密码
+func (v *FileVault) Store(contentHash, password, label string) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	now := clock.OrSystem(v.Clock).Now()
+	record, exists := v.records[contentHash]
+	if !exists {
+		record = &vaultRecord{VaultEntry: VaultEntry{ContentHash: contentHash, CreatedAt: now}}
+		v.records[contentHash] = record
+	}
+	record.Password = password
+	record.Label = label
+	record.LastUsed = now
+	return v.save()
+}
+
+// List 列出所有条目，按最后使用时间倒序
+func (v *FileVault) List() ([]VaultEntry, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	entries := make([]VaultEntry, 0, len(v.records))
+	for _, record := range v.records {
+		entries = append(entries, record.VaultEntry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Remove 删除指定条目
+func (v *FileVault) Remove(contentHash string) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if _, exists := v.records[contentHash]; !exists {
+		return nil
+	}
+	delete(v.records, contentHash)
+	return v.save()
+}
+
+// Purge 清空所有条目
+func (v *FileVault) Purge() error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.records = make(map[string]*vaultRecord)
+	return v.save()
+}
+
+// Path 返回保险库文件路径
+func (v *FileVault) Path() string {
+	return v.path
+}
+
+// String 避免在日志或诊断输出中泄露密码和密钥
+func (v *FileVault) String() string {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return fmt.Sprintf("FileVault{path: %s, entries: %d}", v.path, len(v.records))
+}
+
+// GoString 与String一致，防止%#v输出内部字段
+func (v *FileVault) GoString() string {
+	return v.String()
+}
+
+// decode 解密保险库文件内容
+func (v *FileVault) decode(data []byte, passphrase string) error {
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "密码保险库文件格式无效",
+			File:    v.path,
+			Cause:   err,
+		}
+	}
+	if file.Version != vaultFileVersion {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("不支持的密码保险库版本: %d", file.Version),
+			File:    v.path,
+		}
+	}
+
+	salt, err1 := hex.DecodeString(file.Salt)
+	nonce, err2 := hex.DecodeString(file.Nonce)
+	ciphertext, err3 := hex.DecodeString(file.Ciphertext)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "密码保险库文件已损坏",
+			File:    v.path,
+		}
+	}
+
+	v.salt = salt
+	v.key = deriveVaultKey(passphrase, salt)
+
+	gcm, err := newVaultCipher(v.key)
+	if err != nil {
+		return err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "密码保险库文件已损坏",
+			File:    v.path,
+		}
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		// 不区分主密码错误与篡改，避免提供额外信息
+		return &PDFError{
+			Type:    ErrorPermission,
+			Message: "无法解锁密码保险库：主密码错误或文件已被修改",
+			File:    v.path,
+		}
+	}
+
+	var records []*vaultRecord
+	if err := json.Unmarshal(plaintext, &records); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "密码保险库内容无效",
+			File:    v.path,
+		}
+	}
+	for _, record := range records {
+		v.records[record.ContentHash] = record
+	}
+	return nil
+}
+
+// save 加密并原子地写回保险库文件，调用方需持有锁
+func (v *FileVault) save() error {
+	records := make([]*vaultRecord, 0, len(v.records))
+	for _, record := range v.records {
+		records = append(records, record)
+	}
+	plaintext, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newVaultCipher(v.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(vaultFile{
+		Version:    vaultFileVersion,
+		Salt:       hex.EncodeToString(v.salt),
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0700); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法创建密码保险库目录",
+			File:    v.path,
+			Cause:   err,
+		}
+	}
+
+	tempPath := v.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入密码保险库",
+			File:    v.path,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, v.path); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入密码保险库",
+			File:    v.path,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// newVaultCipher 创建AES-GCM加密器
+func newVaultCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveVaultKey 使用PBKDF2-HMAC-SHA256从主密码派生密钥
+func deriveVaultKey(passphrase string, salt []byte) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	hashLen := prf.Size()
+	blocks := (vaultKeySize + hashLen - 1) / hashLen
+
+	key := make([]byte, 0, blocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for i := 1; i < vaultKDFIterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:vaultKeySize]
+}
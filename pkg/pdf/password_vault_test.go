@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+const testVaultSecret = "s3cr3t-Statement-Pass"
+
+func TestFileVault_EncryptionRoundTrip(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), "vault", "password_vault.json")
+
+	vault, err := OpenFileVault(vaultPath, "master")
+	if err != nil {
+		t.Fatalf("创建保险库失败: %v", err)
+	}
+	if err := vault.Store("abc123", testVaultSecret, "statement.pdf"); err != nil {
+		t.Fatalf("保存密码失败: %v", err)
+	}
+
+	// 落盘内容不应包含明文密码或文件名
+	data, err := os.ReadFile(vaultPath)
+	if err != nil {
+		t.Fatalf("读取保险库文件失败: %v", err)
+	}
+	if strings.Contains(string(data), testVaultSecret) || strings.Contains(string(data), "statement.pdf") {
+		t.Error("保险库文件包含明文内容")
+	}
+
+	reopened, err := OpenFileVault(vaultPath, "master")
+	if err != nil {
+		t.Fatalf("重新打开保险库失败: %v", err)
+	}
+	password, exists, err := reopened.Lookup("abc123")
+	if err != nil || !exists || password != testVaultSecret {
+		t.Errorf("期望取回保存的密码，实际: %q, %v, %v", password, exists, err)
+	}
+
+	// 错误的主密码应被拒绝
+	_, err = OpenFileVault(vaultPath, "wrong")
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorPermission {
+		t.Errorf("错误主密码应返回ErrorPermission，实际: %v", err)
+	}
+}
+
+func TestFileVault_LookupByContentAfterRename(t *testing.T) {
+	tempDir := t.TempDir()
+	original := createTestPDFFile(t, tempDir, "2026-09.pdf")
+
+	vault, err := OpenFileVault(filepath.Join(tempDir, "vault.json"), "master")
+	if err != nil {
+		t.Fatalf("创建保险库失败: %v", err)
+	}
+	hash, err := HashFileContent(original)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if err := vault.Store(hash, testVaultSecret, filepath.Base(original)); err != nil {
+		t.Fatalf("保存密码失败: %v", err)
+	}
+
+	renamed := filepath.Join(tempDir, "renamed", "statement.pdf")
+	os.MkdirAll(filepath.Dir(renamed), 0755)
+	if err := os.Rename(original, renamed); err != nil {
+		t.Fatalf("重命名文件失败: %v", err)
+	}
+
+	renamedHash, err := HashFileContent(renamed)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	password, exists, _ := vault.Lookup(renamedHash)
+	if !exists || password != testVaultSecret {
+		t.Error("重命名后应仍能按内容哈希找到密码")
+	}
+}
+
+func TestFileVault_RemoveAndPurge(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), "vault.json")
+	vault, err := OpenFileVault(vaultPath, "master")
+	if err != nil {
+		t.Fatalf("创建保险库失败: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		vault.Store(fmt.Sprintf("hash-%d", i), testVaultSecret, fmt.Sprintf("file-%d.pdf", i))
+	}
+
+	if err := vault.Remove("hash-0"); err != nil {
+		t.Fatalf("删除条目失败: %v", err)
+	}
+	if entries, _ := vault.List(); len(entries) != 2 {
+		t.Errorf("期望2个条目，实际 %d", len(entries))
+	}
+
+	if err := vault.Purge(); err != nil {
+		t.Fatalf("清空保险库失败: %v", err)
+	}
+	reopened, err := OpenFileVault(vaultPath, "master")
+	if err != nil {
+		t.Fatalf("重新打开保险库失败: %v", err)
+	}
+	if entries, _ := reopened.List(); len(entries) != 0 {
+		t.Errorf("清空后期望0个条目，实际 %d", len(entries))
+	}
+}
+
+func TestFileVault_SecretsRedacted(t *testing.T) {
+	vault, err := OpenFileVault(filepath.Join(t.TempDir(), "vault.json"), "master")
+	if err != nil {
+		t.Fatalf("创建保险库失败: %v", err)
+	}
+	vault.Store("abc123", testVaultSecret, "statement.pdf")
+
+	entries, _ := vault.List()
+	outputs := []string{
+		fmt.Sprintf("%v", vault),
+		fmt.Sprintf("%+v", vault),
+		fmt.Sprintf("%#v", vault),
+		fmt.Sprintf("%+v", entries),
+	}
+	for _, output := range outputs {
+		if strings.Contains(output, testVaultSecret) || strings.Contains(output, "master") {
+			t.Errorf("输出中泄露了密码: %s", output)
+		}
+	}
+}
+
+func TestOpenFileVault_EmptyPassphrase(t *testing.T) {
+	if _, err := OpenFileVault(filepath.Join(t.TempDir(), "vault.json"), ""); err == nil {
+		t.Error("空主密码应被拒绝")
+	}
+}
+
+func TestFileVault_TimestampsFromClock(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	fake := clock.NewFake(start, 1)
+	vault, err := OpenFileVault(filepath.Join(t.TempDir(), "vault.json"), "master")
+	if err != nil {
+		t.Fatalf("创建保险库失败: %v", err)
+	}
+	vault.Clock = fake
+
+	if err := vault.Store("abc123", testVaultSecret, "statement.pdf"); err != nil {
+		t.Fatalf("保存密码失败: %v", err)
+	}
+	fake.Advance(time.Hour)
+	if _, _, err := vault.Lookup("abc123"); err != nil {
+		t.Fatalf("查找密码失败: %v", err)
+	}
+
+	entries, err := vault.List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("期望1个条目，实际: %v, %v", entries, err)
+	}
+	if !entries[0].CreatedAt.Equal(start) {
+		t.Errorf("创建时间应为 %v，实际: %v", start, entries[0].CreatedAt)
+	}
+	if want := start.Add(time.Hour); !entries[0].LastUsed.Equal(want) {
+		t.Errorf("最后使用时间应为 %v，实际: %v", want, entries[0].LastUsed)
+	}
+}
@@ -1,25 +1,35 @@
 package pdf
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 	// TODO: 添加pdfcpu导入，当依赖可用时取消注释
 	// "github.com/pdfcpu/pdfcpu/pkg/api"
 	// "github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
 )
 
+// errPDFCPUUnavailable 没有pdfcpu命令行工具时内置合并失败的原因
+var errPDFCPUUnavailable = errors.New("未找到pdfcpu命令行工具")
+
 // PDFCPUAdapter 封装pdfcpu功能的适配器
 type PDFCPUAdapter struct {
 	// config *pdfcpu.Configuration // TODO: 当pdfcpu Go库可用时取消注释
-	logger     *log.Logger
+	logger     Logger
 	tempDir    string
 	cliAdapter *PDFCPUCLIAdapter // CLI适配器
 	useCLI     bool              // 是否使用CLI模式
+	limits     *PageTreeLimits   // 页面树遍历限制
+	closer     closeGuard        // Close契约：等待进行中的操作后再释放资源
+	clock      clock.Clock       // 时间来源
 }
 
 // PDFCPUConfig pdfcpu配置结构
@@ -30,29 +40,41 @@ type PDFCPUConfig struct {
 	EncryptUsingAES   bool
 	EncryptKeyLength  int
 	TempDirectory     string
+	PageTreeLimits    *PageTreeLimits // 页面树遍历限制，nil表示使用默认值
+	Logger            Logger          // 日志，nil时使用默认日志
+	Clock             clock.Clock     // 时间来源，nil时使用系统时钟
+}
+
+// DefaultPDFCPUConfig 返回默认的pdfcpu配置
+func DefaultPDFCPUConfig() *PDFCPUConfig {
+	return &PDFCPUConfig{
+		ValidationMode:    "relaxed",
+		WriteObjectStream: true,
+		WriteXRefStream:   true,
+		EncryptUsingAES:   true,
+		EncryptKeyLength:  256,
+		TempDirectory:     os.TempDir(),
+	}
 }
 
 // NewPDFCPUAdapter 创建新的pdfcpu适配器实例
 func NewPDFCPUAdapter(config *PDFCPUConfig) (*PDFCPUAdapter, error) {
 	if config == nil {
-		config = &PDFCPUConfig{
-			ValidationMode:    "relaxed",
-			WriteObjectStream: true,
-			WriteXRefStream:   true,
-			EncryptUsingAES:   true,
-			EncryptKeyLength:  256,
-			TempDirectory:     os.TempDir(),
-		}
+		config = DefaultPDFCPUConfig()
 	}
 
-	// 创建临时目录
-	tempDir := filepath.Join(config.TempDirectory, "pdfcpu-adapter")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
+	// 每个适配器使用自己的临时目录，Close 只删除该目录，不影响池中仍在使用的其他适配器
+	if config.TempDirectory != "" {
+		if err := os.MkdirAll(config.TempDirectory, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+	}
+	tempDir, err := os.MkdirTemp(config.TempDirectory, "pdfcpu-adapter-*")
+	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	// 创建日志记录器
-	logger := log.New(os.Stdout, "[PDFCPU] ", log.LstdFlags|log.Lshortfile)
+	logger := loggerOrDefault(config.Logger)
 
 	// 检查pdfcpu可用性
 	availability := CheckPDFCPUAvailability()
@@ -62,13 +84,19 @@ func NewPDFCPUAdapter(config *PDFCPUConfig) (*PDFCPUAdapter, error) {
 		logger:  logger,
 		tempDir: tempDir,
 		useCLI:  false,
+		limits:  config.PageTreeLimits,
+		clock:   clock.OrSystem(config.Clock),
+	}
+	if adapter.limits == nil {
+		adapter.limits = DefaultPageTreeLimits()
 	}
 
 	// 尝试初始化CLI适配器
 	if cliAdapter, err := NewPDFCPUCLIAdapter(); err == nil && cliAdapter.IsAvailable() {
+		cliAdapter.SetLogger(printfLogger{logger: logger})
 		adapter.cliAdapter = cliAdapter
 		adapter.useCLI = true
-		logger.Printf("Using pdfcpu CLI adapter")
+		logger.Debug("Using pdfcpu CLI adapter")
 	}
 
 	// TODO: 当pdfcpu Go库可用时，初始化pdfcpu配置
@@ -79,17 +107,30 @@ func NewPDFCPUAdapter(config *PDFCPUConfig) (*PDFCPUAdapter, error) {
 	//     adapter.config.WriteXRefStream = config.WriteXRefStream
 	// }
 
-	adapter.logger.Printf("PDFCPUAdapter initialized with temp dir: %s", tempDir)
+	adapter.logger.Debug("PDFCPUAdapter initialized with temp dir: %s", tempDir)
 	if fallbackMsg := availability.GetFallbackMessage(); fallbackMsg != "" && !adapter.useCLI {
-		adapter.logger.Printf("Warning: %s", fallbackMsg)
+		adapter.logger.Info("%s", fallbackMsg)
 	}
 
 	return adapter, nil
 }
 
+// healthy 判断适配器及其命令行适配器是否仍可使用，适配器池据此决定能否复用
+func (a *PDFCPUAdapter) healthy() bool {
+	if a.closer.isClosed() {
+		return false
+	}
+	return a.cliAdapter == nil || !a.cliAdapter.closer.isClosed()
+}
+
 // ValidateFile 验证PDF文件格式
 func (a *PDFCPUAdapter) ValidateFile(filePath string) error {
-	a.logger.Printf("Validating PDF file: %s", filePath)
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Validating PDF file: %s", filePath)
 
 	// 基本文件检查
 	if err := a.basicFileValidation(filePath); err != nil {
@@ -110,11 +151,22 @@ func (a *PDFCPUAdapter) ValidateFile(filePath string) error {
 
 // GetFileInfo 获取PDF文件信息
 func (a *PDFCPUAdapter) GetFileInfo(filePath string) (*PDFInfo, error) {
-	a.logger.Printf("Getting PDF file info: %s", filePath)
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
 
-	// 如果CLI可用，使用CLI获取信息
+	a.logger.Debug("Getting PDF file info: %s", filePath)
+
+	// 如果CLI可用，使用CLI获取信息；CLI的输出不包含页面尺寸
 	if a.useCLI && a.cliAdapter != nil {
-		return a.cliAdapter.GetFileInfo(filePath)
+		info, err := a.cliAdapter.GetFileInfo(filePath)
+		if err == nil && info.PageWidth == 0 {
+			if width, height, sizeErr := ReadPageSize(filePath); sizeErr == nil {
+				info.PageWidth, info.PageHeight = width, height
+			}
+		}
+		return info, err
 	}
 
 	// 基本文件信息
@@ -124,6 +176,7 @@ func (a *PDFCPUAdapter) GetFileInfo(filePath string) (*PDFInfo, error) {
 	}
 
 	pdfInfo := &PDFInfo{
+		FilePath: filePath,
 		FileSize: fileInfo.Size(),
 		// TODO: 当pdfcpu Go库可用时，获取更多信息
 		// PageCount:    getPageCount(filePath),
@@ -141,7 +194,26 @@ func (a *PDFCPUAdapter) GetFileInfo(filePath string) (*PDFInfo, error) {
 
 // MergeFiles 合并多个PDF文件
 func (a *PDFCPUAdapter) MergeFiles(inputFiles []string, outputFile string) error {
-	a.logger.Printf("Merging %d PDF files to: %s", len(inputFiles), outputFile)
+	return a.MergeFilesWithProgress(inputFiles, outputFile, nil)
+}
+
+// MergeFilesWithProgress 合并多个PDF文件并报告进度。progress非nil时输入按字节数分成
+// 至多mergeProgressSteps组，第一组创建输出，其余各组依次追加到输出，每组完成后报告一次；
+// progress为nil时一次合并全部输入。
+func (a *PDFCPUAdapter) MergeFilesWithProgress(inputFiles []string, outputFile string, progress MergeProgressFunc) error {
+	return a.MergeFilesContext(context.Background(), inputFiles, outputFile, progress)
+}
+
+// MergeFilesContext 与 MergeFilesWithProgress 相同，但可以通过ctx取消。
+// 取消时尽快返回ctx.Err()：pdfcpu进程被终止，无法中断的内置合并在后台结束后丢弃结果，
+// outputFile 不会被创建或修改。
+func (a *PDFCPUAdapter) MergeFilesContext(ctx context.Context, inputFiles []string, outputFile string, progress MergeProgressFunc) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Merging %d PDF files to: %s", len(inputFiles), outputFile)
 
 	if len(inputFiles) == 0 {
 		return fmt.Errorf("no input files provided")
@@ -149,28 +221,80 @@ func (a *PDFCPUAdapter) MergeFiles(inputFiles []string, outputFile string) error
 
 	// 验证所有输入文件
 	for _, file := range inputFiles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if err := a.ValidateFile(file); err != nil {
 			return fmt.Errorf("invalid input file %s: %w", file, err)
 		}
 	}
 
-	// 如果CLI可用，使用CLI合并
+	// 如果CLI可用，使用CLI合并。分组追加在同一个文件上进行，取消时不能留下只合并了一部分的输出
 	if a.useCLI && a.cliAdapter != nil {
-		return a.cliAdapter.MergeFiles(inputFiles, outputFile)
+		return runInterruptible(ctx, outputFile, func(tempPath string) error {
+			if progress == nil {
+				return a.cliAdapter.MergeFilesContext(ctx, inputFiles, tempPath)
+			}
+			return a.mergeGroupsWithCLI(ctx, inputFiles, tempPath, progress)
+		})
 	}
 
 	// TODO: 当pdfcpu Go库可用时，使用pdfcpu进行合并
 	// return api.MergeCreateFile(inputFiles, outputFile, a.config)
 
-	// 回退到占位符实现
-	return a.createPlaceholderMerge(inputFiles, outputFile)
+	// 回退到内置合并
+	err := runInterruptible(ctx, outputFile, func(tempPath string) error {
+		if err := concatenateInputs(inputFiles, tempPath); err != nil {
+			return newAdapterUnavailableError(errPDFCPUUnavailable, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		total := totalInputBytes(inputFiles)
+		progress(total, total)
+	}
+	return nil
+}
+
+// mergeGroupsWithCLI 按字节数分组，把各组依次合并到累积的输出文件中，每组完成后报告进度
+func (a *PDFCPUAdapter) mergeGroupsWithCLI(ctx context.Context, inputFiles []string, outputFile string, progress MergeProgressFunc) error {
+	groups, groupBytes := groupInputsByBytes(inputFiles, mergeProgressSteps)
+	total := totalInputBytes(inputFiles)
+
+	var done int64
+	for i, group := range groups {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		if i == 0 {
+			err = a.cliAdapter.MergeFilesContext(ctx, group, outputFile)
+		} else {
+			err = a.cliAdapter.AppendFilesContext(ctx, outputFile, group)
+		}
+		if err != nil {
+			return err
+		}
+		done += groupBytes[i]
+		progress(done, total)
+	}
+	return nil
 }
 
 // DecryptFile 解密PDF文件
 func (a *PDFCPUAdapter) DecryptFile(inputFile, outputFile, password string) error {
-	a.logger.Printf("Decrypting PDF file: %s -> %s", inputFile, outputFile)
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
 
-	if err := a.ValidateFile(inputFile); err != nil {
+	a.logger.Debug("Decrypting PDF file: %s -> %s", inputFile, outputFile)
+
+	// 设置了用户密码的文件不提供密码无法验证
+	if err := a.ValidateFileWithPassword(inputFile, password); err != nil {
 		return fmt.Errorf("invalid input file: %w", err)
 	}
 
@@ -186,9 +310,62 @@ func (a *PDFCPUAdapter) DecryptFile(inputFile, outputFile, password string) erro
 	return a.createPlaceholderDecrypt(inputFile, outputFile, password)
 }
 
+// EncryptFile 使用用户密码、所有者密码和权限加密PDF文件（AES-256）。
+// 没有可用的pdfcpu时返回错误，不会写出未加密的输出。
+func (a *PDFCPUAdapter) EncryptFile(inputFile, outputFile, userPassword, ownerPassword string, permissions *OutputPermissions) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Encrypting PDF file: %s -> %s", inputFile, outputFile)
+
+	if err := a.ValidateFile(inputFile); err != nil {
+		return fmt.Errorf("invalid input file: %w", err)
+	}
+	if permissions == nil {
+		permissions = AllOutputPermissions()
+	}
+
+	// 如果CLI可用，使用CLI加密
+	if a.useCLI && a.cliAdapter != nil {
+		return a.cliAdapter.EncryptFile(inputFile, outputFile, userPassword, ownerPassword, permissions.cliValue())
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu进行加密
+	// return api.EncryptFile(inputFile, outputFile, a.config)
+
+	return fmt.Errorf("encryption requires the pdfcpu CLI")
+}
+
+// ValidateFileWithPassword 使用密码验证加密的PDF文件，密码为空时等同于ValidateFile
+func (a *PDFCPUAdapter) ValidateFileWithPassword(filePath, password string) error {
+	if password == "" || !a.useCLI || a.cliAdapter == nil {
+		// 基本验证只检查文件结构，不需要解密
+		return a.ValidateFile(filePath)
+	}
+
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Validating encrypted PDF file: %s", filePath)
+
+	if err := a.basicFileValidation(filePath); err != nil {
+		return err
+	}
+	return a.cliAdapter.ValidateFileWithPassword(filePath, password)
+}
+
 // OptimizeFile 优化PDF文件
 func (a *PDFCPUAdapter) OptimizeFile(inputFile, outputFile string) error {
-	a.logger.Printf("Optimizing PDF file: %s -> %s", inputFile, outputFile)
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Optimizing PDF file: %s -> %s", inputFile, outputFile)
 
 	if err := a.ValidateFile(inputFile); err != nil {
 		return fmt.Errorf("invalid input file: %w", err)
@@ -206,26 +383,428 @@ func (a *PDFCPUAdapter) OptimizeFile(inputFile, outputFile string) error {
 	return a.createPlaceholderOptimize(inputFile, outputFile)
 }
 
-// Close 清理资源
-func (a *PDFCPUAdapter) Close() error {
-	a.logger.Printf("Closing PDFCPUAdapter")
+// RepairPDF 修复轻度损坏的PDF并写出到outputFile，输入文件不会被修改。
+// CLI可用时由pdfcpu以宽松模式读取并重写整个文件，失败时回退到内置修复（RepairPDF）。
+func (a *PDFCPUAdapter) RepairPDF(inputFile, outputFile string) (*RepairReport, error) {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Repairing PDF file: %s -> %s", inputFile, outputFile)
+
+	if err := checkRepairPaths(inputFile, outputFile); err != nil {
+		return nil, err
+	}
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return nil, err
+	}
+
+	// 如果CLI可用，使用CLI重写
+	if a.useCLI && a.cliAdapter != nil {
+		err := a.cliAdapter.OptimizeFile(inputFile, outputFile)
+		if err == nil {
+			err = a.cliAdapter.ValidateFile(outputFile)
+		}
+		if err == nil {
+			report := &RepairReport{InputPath: inputFile, OutputPath: outputFile, Backend: BackendPDFCPU}
+			if data, readErr := os.ReadFile(inputFile); readErr == nil {
+				_, report.Actions, _ = repairData(inputFile, data)
+			}
+			report.Pages, _ = ReadPageCount(outputFile, a.limits)
+			return report, nil
+		}
+		os.Remove(outputFile)
+		a.logger.Warn("pdfcpu修复失败，使用内置修复: %v", err)
+	}
 
-	// 关闭CLI适配器
-	if a.cliAdapter != nil {
-		a.cliAdapter.Close()
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu读取并重写
+	// ctx, err := api.ReadContextFile(inputFile); api.WriteContextFile(ctx, outputFile)
+
+	return RepairPDF(inputFile, outputFile)
+}
+
+// ExtractPages 按给定顺序把页面（从1开始的页码）提取到outputFile
+func (a *PDFCPUAdapter) ExtractPages(inputFile, outputFile string, pages []int) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Extracting %d pages: %s -> %s", len(pages), inputFile, outputFile)
+
+	if err := a.ValidateFile(inputFile); err != nil {
+		return fmt.Errorf("invalid input file: %w", err)
+	}
+
+	// 如果CLI可用，使用CLI提取
+	if a.useCLI && a.cliAdapter != nil {
+		selected := make([]string, len(pages))
+		for i, page := range pages {
+			selected[i] = strconv.Itoa(page)
+		}
+		return a.cliAdapter.ExtractPages(inputFile, outputFile, strings.Join(selected, ","))
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu进行提取
+	// return api.TrimFile(inputFile, outputFile, selectedPages, a.config)
+
+	// 回退到重写页面树的实现
+	return ExtractPages(inputFile, outputFile, pages)
+}
+
+// RotateFile 把每一页顺时针旋转degrees度（0、90、180、270）并写出到outputFile，输入与输出可以相同。
+// CLI可用时由pdfcpu旋转，失败时回退到内置实现（见RotatePages）。
+func (a *PDFCPUAdapter) RotateFile(inputFile, outputFile string, degrees int) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
 	}
+	defer a.closer.leave()
 
-	// 清理临时目录
-	if err := os.RemoveAll(a.tempDir); err != nil {
-		a.logger.Printf("Warning: failed to clean temp directory: %v", err)
+	a.logger.Debug("Rotating PDF file by %d degrees: %s -> %s", degrees, inputFile, outputFile)
+
+	if err := ValidateRotation(degrees); err != nil {
+		return err
+	}
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return err
 	}
 
+	// 如果CLI可用，使用CLI旋转
+	if a.useCLI && a.cliAdapter != nil && degrees != 0 {
+		err := a.cliAdapter.RotatePages(inputFile, outputFile, degrees)
+		if err == nil {
+			return nil
+		}
+		a.logger.Warn("pdfcpu旋转页面失败，使用内置实现: %v", err)
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu旋转
+	// return api.RotateFile(inputFile, outputFile, degrees, nil, a.config)
+
+	return RotatePages(inputFile, outputFile, degrees)
+}
+
+// NormalizeOrientation 把每一页的 /Rotate 写入页面内容并调整页面框，返回因有注释而保持不变的页码。
+// pdfcpu没有对应的操作，始终使用内置实现（见NormalizeOrientation）。
+func (a *PDFCPUAdapter) NormalizeOrientation(inputFile, outputFile string) ([]int, error) {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Normalizing page orientation: %s -> %s", inputFile, outputFile)
+
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return nil, err
+	}
+	return NormalizeOrientation(inputFile, outputFile)
+}
+
+// OptimizeOutput 优化合并输出（见OptimizePDF）。内置实现失败且不要求传统交叉引用表时，
+// 在CLI可用的情况下回退到pdfcpu优化，此时报告只包含优化前后的大小
+func (a *PDFCPUAdapter) OptimizeOutput(inputFile, outputFile string, options *OptimizeOptions) (*OptimizeReport, error) {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Optimizing merged output: %s -> %s", inputFile, outputFile)
+
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return nil, err
+	}
+	report, err := OptimizePDF(inputFile, outputFile, options)
+	if err == nil || !a.useCLI || a.cliAdapter == nil || (options != nil && options.ClassicXRef) {
+		return report, err
+	}
+	a.logger.Warn("内置优化失败，使用pdfcpu: %v", err)
+
+	before, statErr := os.Stat(inputFile)
+	if statErr != nil {
+		return nil, err
+	}
+	if cliErr := a.cliAdapter.OptimizeFile(inputFile, outputFile); cliErr != nil {
+		return nil, err
+	}
+	after, statErr := os.Stat(outputFile)
+	if statErr != nil {
+		return nil, statErr
+	}
+	return &OptimizeReport{OriginalSize: before.Size(), OptimizedSize: after.Size()}, nil
+}
+
+// StampFile 按顺序把印章文字添加到每一页并写出到outputFile。sources为输出各页的来源（按合并顺序），
+// 用于 {filename}，为空时使用inputFile的文件名。CLI可用时由pdfcpu添加，失败时回退到内置实现（见StampPDF）。
+func (a *PDFCPUAdapter) StampFile(inputFile, outputFile string, stamps []*StampOptions, sources []InputPageCount) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Stamping PDF file: %s -> %s", inputFile, outputFile)
+
+	if err := validateStamps(stamps); err != nil {
+		return err
+	}
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return err
+	}
+
+	// 如果CLI可用，使用CLI添加
+	if a.useCLI && a.cliAdapter != nil {
+		err := a.stampWithCLI(inputFile, outputFile, stamps, sources)
+		if err == nil {
+			return nil
+		}
+		a.logger.Warn("pdfcpu添加印章失败，使用内置实现: %v", err)
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu添加印章
+	// return api.AddTextWatermarksFile(inputFile, outputFile, nil, true, text, description, a.config)
+
+	return stampPages(inputFile, outputFile, stamps, sources)
+}
+
+// stampWithCLI 每个印章调用一次pdfcpu，模板引用 {filename} 时按来源分页范围分别调用。
+// 中间结果写在本适配器的临时目录，全部成功后才替换outputFile，因此输入与输出可以相同
+func (a *PDFCPUAdapter) stampWithCLI(inputFile, outputFile string, stamps []*StampOptions, sources []InputPageCount) error {
+	current := inputFile
+	for _, stamp := range stamps {
+		type group struct{ name, pages string }
+		groups := []group{{name: filepath.Base(inputFile)}}
+		if stamp.usesFilename() && len(sources) > 0 {
+			groups = groups[:0]
+			first := 1
+			for _, source := range sources {
+				if source.Pages <= 0 {
+					continue
+				}
+				groups = append(groups, group{filepath.Base(source.File), fmt.Sprintf("%d-%d", first, first+source.Pages-1)})
+				first += source.Pages
+			}
+		}
+		for _, g := range groups {
+			temp, err := os.CreateTemp(a.tempDir, "stamp-*.pdf")
+			if err != nil {
+				if current != inputFile {
+					os.Remove(current)
+				}
+				return err
+			}
+			temp.Close()
+			next := temp.Name()
+			text := strings.NewReplacer("{page}", "%p", "{pages}", "%P", "{filename}", g.name).Replace(stamp.Text)
+			err = a.cliAdapter.AddTextStamp(current, next, text, pdfcpuStampDescription(stamp), g.pages)
+			if current != inputFile {
+				os.Remove(current)
+			}
+			if err != nil {
+				os.Remove(next)
+				return err
+			}
+			current = next
+		}
+	}
+	if current == inputFile {
+		return copyFile(inputFile, outputFile)
+	}
+	if err := os.Rename(current, outputFile); err != nil {
+		os.Remove(current)
+		return err
+	}
 	return nil
 }
 
+// pdfcpuStampDescription 把印章选项转换为pdfcpu的文字印章描述，使用绝对字号以免按页宽缩放
+func pdfcpuStampDescription(stamp *StampOptions) string {
+	parts := []string{
+		"fontname:Helvetica",
+		"points:" + formatNumber(stamp.fontSize()),
+		"scale:1 abs",
+		"opacity:" + formatNumber(stamp.opacity()),
+		"fillcolor:#000000",
+	}
+	if stamp.Diagonal {
+		return strings.Join(append(parts, "position:c", "diagonal:1"), ", ")
+	}
+	anchors := map[StampPosition]string{
+		StampTopLeft: "tl", StampTopCenter: "tc", StampTopRight: "tr", StampCenter: "c",
+		StampBottomLeft: "bl", StampBottomCenter: "bc", StampBottomRight: "br",
+	}
+	position := stamp.Position
+	if position == "" {
+		position = StampBottomCenter
+	}
+	dx, dy := 0.0, 0.0
+	switch position {
+	case StampTopLeft, StampBottomLeft:
+		dx = stampMargin
+	case StampTopRight, StampBottomRight:
+		dx = -stampMargin
+	}
+	switch position {
+	case StampTopLeft, StampTopCenter, StampTopRight:
+		dy = -stampMargin
+	case StampBottomLeft, StampBottomCenter, StampBottomRight:
+		dy = stampMargin
+	}
+	return strings.Join(append(parts, "rotation:0", "position:"+anchors[position],
+		fmt.Sprintf("offset:%s %s", formatNumber(dx), formatNumber(dy))), ", ")
+}
+
+// ImposeFile 按options拼版并写出到outputFile，输入与输出可以相同，返回输出页数。
+// CLI可用时由pdfcpu拼版，输出页尺寸与内置实现相同；失败时回退到内置实现（见ImposePages）。
+func (a *PDFCPUAdapter) ImposeFile(inputFile, outputFile string, options *ImpositionOptions) (int, error) {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return 0, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Imposing PDF file: %s -> %s", inputFile, outputFile)
+
+	if !options.Enabled() {
+		return 0, &PDFError{Type: ErrorInvalidInput, Message: "没有指定拼版方式", File: inputFile}
+	}
+	if err := options.Validate(); err != nil {
+		return 0, err
+	}
+	if err := a.basicFileValidation(inputFile); err != nil {
+		return 0, err
+	}
+
+	// 如果CLI可用，使用CLI拼版
+	if a.useCLI && a.cliAdapter != nil {
+		pages, err := a.imposeWithCLI(inputFile, outputFile, options)
+		if err == nil {
+			return pages, nil
+		}
+		a.logger.Warn("pdfcpu拼版失败，使用内置实现: %v", err)
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu拼版
+	// return api.NUpFile(inFiles, outputFile, nil, nup, a.config)
+
+	return ImposePages(inputFile, outputFile, options)
+}
+
+// imposeWithCLI 由pdfcpu拼版到本适配器的临时目录，成功后替换outputFile，因此输入与输出可以相同
+func (a *PDFCPUAdapter) imposeWithCLI(inputFile, outputFile string, options *ImpositionOptions) (int, error) {
+	width, height, err := ReadPageSize(inputFile)
+	if err != nil {
+		return 0, err
+	}
+	sheetWidth, sheetHeight, _, _ := impositionSheetSize(width, height, options)
+	parts := []string{
+		fmt.Sprintf("dimensions:%s %s", formatNumber(sheetWidth), formatNumber(sheetHeight)),
+		"margin:" + formatNumber(options.Margin),
+		"border:off",
+	}
+	if options.Order == NUpOrderDown && !options.Booklet {
+		parts = append(parts, "order:downright")
+	}
+
+	file, err := os.CreateTemp(a.tempDir, "impose-*.pdf")
+	if err != nil {
+		return 0, err
+	}
+	file.Close()
+	temp := file.Name()
+	if err := a.cliAdapter.NUpPages(inputFile, temp, options.perSheet(), options.Booklet, strings.Join(parts, ", ")); err != nil {
+		os.Remove(temp)
+		return 0, err
+	}
+	pages, err := ReadPageCount(temp, a.limits)
+	if err != nil {
+		os.Remove(temp)
+		return 0, err
+	}
+	if err := os.Rename(temp, outputFile); err != nil {
+		os.Remove(temp)
+		return 0, err
+	}
+	return pages, nil
+}
+
+// ExtractText 按pages的顺序（为空时为全部页面）逐页提取文字并调用emit，没有可提取文字的页面记录警告。
+// pdfcpu只能导出未解码的内容流，文字由内置实现解码（见ExtractPageTexts）
+func (a *PDFCPUAdapter) ExtractText(filePath string, pages []int, emit TextPageFunc) error {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Extracting text: %s", filePath)
+
+	if err := a.basicFileValidation(filePath); err != nil {
+		return err
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu读取内容流
+	// return api.ExtractContentFile(filePath, outDir, selectedPages, a.config)
+
+	return ExtractPageTexts(filePath, pages, func(page PageText) error {
+		if page.Warning != "" {
+			a.logger.Warn("%s 第%d页: %s", filePath, page.Page, page.Warning)
+		}
+		return emit(page)
+	})
+}
+
+// ExtractImages 把pages中的图像写到outputDir，跳过像素数小于minInlinePixels的内联图像（含义见
+// ImageExtractOptions.MinInlinePixels），无法导出的图像记录警告。pdfcpu导出的是未解码的原始流，
+// 图像由内置实现解码（见包函数ExtractImages）
+func (a *PDFCPUAdapter) ExtractImages(ctx context.Context, filePath string, pages []int, outputDir string, minInlinePixels int) ([]ExtractedImage, error) {
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Extracting images: %s -> %s", filePath, outputDir)
+
+	if err := a.basicFileValidation(filePath); err != nil {
+		return nil, err
+	}
+
+	// TODO: 当pdfcpu Go库可用时，使用pdfcpu读取图像对象
+	// return api.ExtractImagesFile(filePath, outputDir, selectedPages, a.config)
+
+	return ExtractImages(ctx, filePath, pages, outputDir, &ImageExtractOptions{
+		MinInlinePixels: minInlinePixels,
+		Warn: func(page int, message string) {
+			a.logger.Warn("%s 第%d页: %s", filePath, page, message)
+		},
+	})
+}
+
+// Close 清理资源。Close 会等待进行中的操作结束后再删除本适配器的临时目录；
+// 重复调用只清理一次，之后的方法调用返回 ErrClosed。
+func (a *PDFCPUAdapter) Close() error {
+	return a.closer.close(func() error {
+		a.logger.Debug("Closing PDFCPUAdapter")
+
+		// 关闭CLI适配器
+		if a.cliAdapter != nil {
+			a.cliAdapter.Close()
+		}
+
+		// 清理临时目录
+		if err := os.RemoveAll(a.tempDir); err != nil {
+			a.logger.Warn("failed to clean temp directory: %v", err)
+		}
+
+		return nil
+	})
+}
+
 // IsEncrypted 检查PDF文件是否加密
 func (a *PDFCPUAdapter) IsEncrypted(filePath string) (bool, error) {
-	a.logger.Printf("Checking encryption status: %s", filePath)
+	if err := a.closer.enter("pdfcpu适配器", ""); err != nil {
+		return false, err
+	}
+	defer a.closer.leave()
+
+	a.logger.Debug("Checking encryption status: %s", filePath)
 
 	// 如果CLI可用，使用CLI检查
 	if a.useCLI && a.cliAdapter != nil {
@@ -284,8 +863,8 @@ func (a *PDFCPUAdapter) basicFileValidation(filePath string) error {
 		return fmt.Errorf("file not found: %w", err)
 	}
 
-	// 检查文件扩展名
-	if !strings.HasSuffix(strings.ToLower(filePath), ".pdf") {
+	// 检查文件类型，没有 .pdf 扩展名时以文件头为准
+	if !IsPDFFile(filePath) {
 		return fmt.Errorf("file is not a PDF: %s", filePath)
 	}
 
@@ -325,45 +904,50 @@ func (a *PDFCPUAdapter) basicPDFValidation(filePath string) error {
 
 // extractBasicInfo 提取基本PDF信息
 func (a *PDFCPUAdapter) extractBasicInfo(info *PDFInfo) error {
-	// 临时实现：设置默认值
-	info.PageCount = 1       // TODO: 实际计算页数
+	// 使用内置读取器计算页数，超出限制时拒绝该文件
+	info.PageCount = 1
+	if count, err := ReadPageCount(info.FilePath, a.limits); err == nil && count > 0 {
+		info.PageCount = count
+	} else if IsLimitExceededError(err) {
+		return err
+	}
+
+	if width, height, err := ReadPageSize(info.FilePath); err == nil {
+		info.PageWidth, info.PageHeight = width, height
+	}
+
 	info.IsEncrypted = false // TODO: 检查加密状态
 	info.Title = "Unknown"   // TODO: 读取实际标题
 
 	return nil
 }
 
-// createPlaceholderMerge 创建占位符合并实现
-func (a *PDFCPUAdapter) createPlaceholderMerge(inputFiles []string, outputFile string) error {
-	a.logger.Printf("Creating placeholder merge (pdfcpu not available yet)")
-
-	// 创建一个简单的占位符文件
-	content := fmt.Sprintf("Placeholder merge result for files: %v\nOutput: %s\nTimestamp: %s\n",
-		inputFiles, outputFile, time.Now().Format(time.RFC3339))
-
-	return os.WriteFile(outputFile+".placeholder", []byte(content), 0644)
-}
-
 // createPlaceholderDecrypt 创建占位符解密实现
 func (a *PDFCPUAdapter) createPlaceholderDecrypt(inputFile, outputFile, password string) error {
-	a.logger.Printf("Creating placeholder decrypt (pdfcpu not available yet)")
+	a.logger.Debug("Creating placeholder decrypt (pdfcpu not available yet)")
 
 	content := fmt.Sprintf("Placeholder decrypt result\nInput: %s\nOutput: %s\nPassword: %s\nTimestamp: %s\n",
-		inputFile, outputFile, password, time.Now().Format(time.RFC3339))
+		inputFile, outputFile, password, a.clock.Now().Format(time.RFC3339))
 
 	return os.WriteFile(outputFile+".placeholder", []byte(content), 0644)
 }
 
 // createPlaceholderOptimize 创建占位符优化实现
 func (a *PDFCPUAdapter) createPlaceholderOptimize(inputFile, outputFile string) error {
-	a.logger.Printf("Creating placeholder optimize (pdfcpu not available yet)")
+	a.logger.Debug("Creating placeholder optimize (pdfcpu not available yet)")
 
 	content := fmt.Sprintf("Placeholder optimize result\nInput: %s\nOutput: %s\nTimestamp: %s\n",
-		inputFile, outputFile, time.Now().Format(time.RFC3339))
+		inputFile, outputFile, a.clock.Now().Format(time.RFC3339))
 
 	return os.WriteFile(outputFile+".placeholder", []byte(content), 0644)
 }
 
+// isPasswordError 判断pdfcpu的错误是否由缺少密码或密码错误引起。
+// 命令行适配器的错误都带有 "decryption failed" 前缀，因此只按 password 判断。
+func isPasswordError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "password")
+}
+
 // mapPDFCPUError 将pdfcpu错误映射到现有错误类型
 func mapPDFCPUError(err error) *PDFError {
 	if err == nil {
@@ -2,7 +2,6 @@ package pdf
 
 import (
 	"fmt"
-	"log"
 )
 
 // PDFCPUAvailability 检查pdfcpu库的可用性
@@ -77,11 +76,11 @@ func (a *PDFCPUAvailability) GetError() error {
 }
 
 // LogStatus 记录pdfcpu状态
-func (a *PDFCPUAvailability) LogStatus(logger *log.Logger) {
+func (a *PDFCPUAvailability) LogStatus(logger Logger) {
 	if a.isAvailable {
-		logger.Printf("pdfcpu is available (version: %s)", a.version)
+		logger.Debug("pdfcpu is available (version: %s)", a.version)
 	} else {
-		logger.Printf("pdfcpu is not available: %v", a.error)
+		logger.Debug("pdfcpu is not available: %v", a.error)
 	}
 }
 
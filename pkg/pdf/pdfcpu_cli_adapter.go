@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,6 +16,7 @@ type PDFCPUCLIAdapter struct {
 	cliPath string
 	tempDir string
 	logger  SimpleLogger
+	closer  closeGuard // Close契约：等待进行中的命令结束后再释放资源
 }
 
 // SimpleLogger 简单的日志接口
@@ -38,25 +40,26 @@ func NewPDFCPUCLIAdapter() (*PDFCPUCLIAdapter, error) {
 	return &PDFCPUCLIAdapter{
 		cliPath: cliPath,
 		tempDir: tempDir,
-		logger:  &defaultLogger{},
+		logger:  printfLogger{},
 	}, nil
 }
 
-// defaultLogger 默认日志实现
-type defaultLogger struct{}
-
-func (l *defaultLogger) Printf(format string, v ...interface{}) {
-	fmt.Printf("[PDFCPU-CLI] "+format+"\n", v...)
-}
-
 // IsAvailable 检查pdfcpu CLI是否可用
 func (a *PDFCPUCLIAdapter) IsAvailable() bool {
+	if a.closer.isClosed() {
+		return false
+	}
 	cmd := exec.Command(a.cliPath, "version")
 	return cmd.Run() == nil
 }
 
 // GetVersion 获取pdfcpu版本
 func (a *PDFCPUCLIAdapter) GetVersion() (string, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return "", err
+	}
+	defer a.closer.leave()
+
 	cmd := exec.Command(a.cliPath, "version")
 	output, err := cmd.Output()
 	if err != nil {
@@ -79,6 +82,11 @@ func (a *PDFCPUCLIAdapter) GetVersion() (string, error) {
 
 // ValidateFile 验证PDF文件
 func (a *PDFCPUCLIAdapter) ValidateFile(filePath string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Validating PDF file using CLI: %s", filePath)
 
 	// 使用宽松模式验证，允许修复一些常见问题
@@ -104,6 +112,11 @@ func (a *PDFCPUCLIAdapter) ValidateFile(filePath string) error {
 
 // GetFileInfo 获取PDF文件信息
 func (a *PDFCPUCLIAdapter) GetFileInfo(filePath string) (*PDFInfo, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Getting PDF info using CLI: %s", filePath)
 
 	// 使用pdfcpu info命令，添加超时机制
@@ -139,6 +152,16 @@ func (a *PDFCPUCLIAdapter) GetFileInfo(filePath string) (*PDFInfo, error) {
 
 // MergeFiles 合并PDF文件
 func (a *PDFCPUCLIAdapter) MergeFiles(inputFiles []string, outputFile string) error {
+	return a.MergeFilesContext(context.Background(), inputFiles, outputFile)
+}
+
+// MergeFilesContext 合并PDF文件，ctx取消时终止pdfcpu进程并返回ctx.Err()
+func (a *PDFCPUCLIAdapter) MergeFilesContext(ctx context.Context, inputFiles []string, outputFile string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Merging %d PDF files using CLI to: %s", len(inputFiles), outputFile)
 
 	if len(inputFiles) == 0 {
@@ -150,13 +173,16 @@ func (a *PDFCPUCLIAdapter) MergeFiles(inputFiles []string, outputFile string) er
 	args = append(args, inputFiles...)
 
 	// 添加超时机制
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second) // 合并操作需要更长时间
+	cmdCtx, cancel := context.WithTimeout(ctx, 60*time.Second) // 合并操作需要更长时间
 	defer cancel()
-	cmd := exec.CommandContext(ctx, a.cliPath, args...)
+	cmd := exec.CommandContext(cmdCtx, a.cliPath, args...)
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if cmdCtx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("merge command timeout after 60 seconds")
 		}
 		return fmt.Errorf("merge failed: %s", string(output))
@@ -166,8 +192,54 @@ func (a *PDFCPUCLIAdapter) MergeFiles(inputFiles []string, outputFile string) er
 	return nil
 }
 
+// AppendFiles 把输入文件的页面追加到已存在的outputFile末尾
+func (a *PDFCPUCLIAdapter) AppendFiles(outputFile string, inputFiles []string) error {
+	return a.AppendFilesContext(context.Background(), outputFile, inputFiles)
+}
+
+// AppendFilesContext 把输入文件的页面追加到outputFile末尾，ctx取消时终止pdfcpu进程并返回ctx.Err()
+func (a *PDFCPUCLIAdapter) AppendFilesContext(ctx context.Context, outputFile string, inputFiles []string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Appending %d PDF files using CLI to: %s", len(inputFiles), outputFile)
+
+	if len(inputFiles) == 0 {
+		return fmt.Errorf("no input files provided")
+	}
+
+	// 构建命令参数: pdfcpu merge -mode append outFile inFile1 inFile2 ...
+	args := []string{"merge", "-mode", "append", outputFile}
+	args = append(args, inputFiles...)
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, a.cliPath, args...)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if cmdCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("append command timeout after 60 seconds")
+		}
+		return fmt.Errorf("append failed: %s", string(output))
+	}
+
+	a.logger.Printf("Append successful: %s", outputFile)
+	return nil
+}
+
 // DecryptFile 解密PDF文件
 func (a *PDFCPUCLIAdapter) DecryptFile(inputFile, outputFile, password string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Decrypting PDF file using CLI: %s -> %s", inputFile, outputFile)
 
 	cmd := exec.Command(a.cliPath, "decrypt", "-upw", password, inputFile, outputFile)
@@ -181,8 +253,64 @@ func (a *PDFCPUCLIAdapter) DecryptFile(inputFile, outputFile, password string) e
 	return nil
 }
 
+// EncryptFile 使用AES-256加密PDF文件，permissions 为pdfcpu -perm 参数（none、all 或十六进制权限位）
+func (a *PDFCPUCLIAdapter) EncryptFile(inputFile, outputFile, userPassword, ownerPassword, permissions string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Encrypting PDF file using CLI: %s -> %s", inputFile, outputFile)
+
+	args := []string{"encrypt", "-mode", "aes", "-key", "256", "-perm", permissions}
+	if userPassword != "" {
+		args = append(args, "-upw", userPassword)
+	}
+	args = append(args, "-opw", ownerPassword, inputFile, outputFile)
+	cmd := exec.Command(a.cliPath, args...)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("encryption failed: %s", string(output))
+	}
+
+	a.logger.Printf("Encryption successful: %s", outputFile)
+	return nil
+}
+
+// ValidateFileWithPassword 使用密码验证加密的PDF文件
+func (a *PDFCPUCLIAdapter) ValidateFileWithPassword(filePath, password string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Validating encrypted PDF file using CLI: %s", filePath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	// 同时作为用户密码和所有者密码传入，两者之一正确即可打开
+	cmd := exec.CommandContext(ctx, a.cliPath, "validate", "-mode=relaxed", "-upw", password, "-opw", password, filePath)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("validation timeout after 30 seconds")
+		}
+		return fmt.Errorf("validation failed: %s", string(output))
+	}
+
+	a.logger.Printf("Validation successful: %s", filePath)
+	return nil
+}
+
 // OptimizeFile 优化PDF文件
 func (a *PDFCPUCLIAdapter) OptimizeFile(inputFile, outputFile string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Optimizing PDF file using CLI: %s -> %s", inputFile, outputFile)
 
 	cmd := exec.Command(a.cliPath, "optimize", inputFile, outputFile)
@@ -198,6 +326,11 @@ func (a *PDFCPUCLIAdapter) OptimizeFile(inputFile, outputFile string) error {
 
 // SplitFile 分割PDF文件
 func (a *PDFCPUCLIAdapter) SplitFile(inputFile, outputDir string, pageRange string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Splitting PDF file using CLI: %s", inputFile)
 
 	args := []string{"split", inputFile, outputDir}
@@ -218,6 +351,11 @@ func (a *PDFCPUCLIAdapter) SplitFile(inputFile, outputDir string, pageRange stri
 
 // ExtractPages 提取页面
 func (a *PDFCPUCLIAdapter) ExtractPages(inputFile, outputFile string, pages string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Extracting pages from PDF using CLI: %s", inputFile)
 
 	cmd := exec.Command(a.cliPath, "trim", "-pages", pages, inputFile, outputFile)
@@ -231,18 +369,92 @@ func (a *PDFCPUCLIAdapter) ExtractPages(inputFile, outputFile string, pages stri
 	return nil
 }
 
-// Close 清理资源
-func (a *PDFCPUCLIAdapter) Close() error {
-	a.logger.Printf("Closing PDFCPUCLIAdapter")
+// RotatePages 把全部页面顺时针旋转degrees度（90的倍数）
+func (a *PDFCPUCLIAdapter) RotatePages(inputFile, outputFile string, degrees int) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Rotating PDF pages using CLI: %s -> %s", inputFile, outputFile)
+
+	cmd := exec.Command(a.cliPath, "rotate", "--", inputFile, strconv.Itoa(degrees), outputFile)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("rotation failed: %s", string(output))
+	}
+
+	a.logger.Printf("Rotation successful: %s", outputFile)
+	return nil
+}
+
+// AddTextStamp 在pages指定的页面（空时为全部页面）上添加文字印章。
+// text 中的 %p、%P 由pdfcpu替换为页码和总页数，description 为pdfcpu的印章描述，例如 "pos:bc, points:10"
+func (a *PDFCPUCLIAdapter) AddTextStamp(inputFile, outputFile, text, description, pages string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Stamping PDF file using CLI: %s -> %s", inputFile, outputFile)
+
+	args := []string{"stamp", "add", "-mode", "text"}
+	if pages != "" {
+		args = append(args, "-pages", pages)
+	}
+	args = append(args, "--", text, description, inputFile, outputFile)
+	cmd := exec.Command(a.cliPath, args...)
+	output, err := cmd.CombinedOutput()
 
-	// 清理临时目录
-	if err := os.RemoveAll(a.tempDir); err != nil {
-		a.logger.Printf("Warning: failed to clean temp directory: %v", err)
+	if err != nil {
+		return fmt.Errorf("stamp failed: %s", string(output))
+	}
+
+	a.logger.Printf("Stamp successful: %s", outputFile)
+	return nil
+}
+
+// NUpPages 把n个源页面排列到一张输出页上；booklet为true时按小册子顺序排列。
+// description 为pdfcpu的拼版描述，例如 "dimensions:842 595, margin:10, border:off"
+func (a *PDFCPUCLIAdapter) NUpPages(inputFile, outputFile string, n int, booklet bool, description string) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
+	a.logger.Printf("Imposing PDF pages using CLI: %s -> %s", inputFile, outputFile)
+
+	command := "nup"
+	if booklet {
+		command = "booklet"
+	}
+	cmd := exec.Command(a.cliPath, command, "--", description, outputFile, strconv.Itoa(n), inputFile)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", command, string(output))
 	}
 
+	a.logger.Printf("Imposition successful: %s", outputFile)
 	return nil
 }
 
+// Close 清理资源。Close 会等待进行中的命令结束后再删除临时目录；
+// 重复调用只清理一次，之后的方法调用返回 ErrClosed。
+func (a *PDFCPUCLIAdapter) Close() error {
+	return a.closer.close(func() error {
+		a.logger.Printf("Closing PDFCPUCLIAdapter")
+
+		// 清理临时目录
+		if err := os.RemoveAll(a.tempDir); err != nil {
+			a.logger.Printf("Warning: failed to clean temp directory: %v", err)
+		}
+
+		return nil
+	})
+}
+
 // SetLogger 设置日志记录器
 func (a *PDFCPUCLIAdapter) SetLogger(logger SimpleLogger) {
 	a.logger = logger
@@ -255,6 +467,11 @@ func (a *PDFCPUCLIAdapter) GetTempDir() string {
 
 // CreateTestPDF 创建测试PDF文件（用于测试）
 func (a *PDFCPUCLIAdapter) CreateTestPDF(outputFile string, pageCount int) error {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Creating test PDF with %d pages: %s", pageCount, outputFile)
 
 	// 创建JSON配置文件
@@ -306,6 +523,11 @@ func (a *PDFCPUCLIAdapter) CreateTestPDF(outputFile string, pageCount int) error
 
 // GetPermissions 获取PDF文件的详细权限信息
 func (a *PDFCPUCLIAdapter) GetPermissions(filePath string) (map[string]interface{}, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Getting PDF permissions using CLI: %s", filePath)
 
 	// 使用pdfcpu info命令获取详细信息
@@ -416,6 +638,11 @@ func (a *PDFCPUCLIAdapter) parsePermissionFlags(flags int) []string {
 
 // GetSecurityDetails 获取PDF安全详细信息
 func (a *PDFCPUCLIAdapter) GetSecurityDetails(filePath string) (map[string]interface{}, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return nil, err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Getting PDF security details using CLI: %s", filePath)
 
 	// 首先获取基本权限信息
@@ -465,6 +692,8 @@ func (a *PDFCPUCLIAdapter) GetCapabilities() []string {
 		"optimize",
 		"split",
 		"extract",
+		"nup",
+		"booklet",
 		"create",
 		"permissions",
 		"security",
@@ -473,6 +702,11 @@ func (a *PDFCPUCLIAdapter) GetCapabilities() []string {
 
 // ExecuteCommand 执行自定义pdfcpu命令
 func (a *PDFCPUCLIAdapter) ExecuteCommand(args ...string) (string, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return "", err
+	}
+	defer a.closer.leave()
+
 	cmd := exec.Command(a.cliPath, args...)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
@@ -480,6 +714,11 @@ func (a *PDFCPUCLIAdapter) ExecuteCommand(args ...string) (string, error) {
 
 // IsEncrypted 检查PDF文件是否加密
 func (a *PDFCPUCLIAdapter) IsEncrypted(filePath string) (bool, error) {
+	if err := a.closer.enter("pdfcpu命令行适配器", ""); err != nil {
+		return false, err
+	}
+	defer a.closer.leave()
+
 	a.logger.Printf("Checking encryption status using CLI: %s", filePath)
 
 	// 使用pdfcpu info命令检查加密状态
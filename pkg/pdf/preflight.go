@@ -0,0 +1,211 @@
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PreflightReport 合并前的预检报告，不生成任何输出文件
+type PreflightReport struct {
+	Files               []PreflightFile `json:"files"`
+	ValidFiles          int             `json:"valid_files"`
+	EncryptedFiles      int             `json:"encrypted_files"`
+	TotalPages          int             `json:"total_pages"`           // 有效输入的页数之和
+	PagesComplete       bool            `json:"pages_complete"`        // 是否统计到了所有有效输入的页数
+	TotalInputSize      int64           `json:"total_input_size"`      // 有效输入的大小之和（字节）
+	EstimatedOutputSize int64           `json:"estimated_output_size"` // 预计输出大小（字节）
+	Strategy            string          `json:"strategy,omitempty"`    // 合并时将选择的策略，没有有效输入时为空
+	HasLargeFiles       bool            `json:"has_large_files"`
+	EstimatedTempSpill  int64           `json:"estimated_temp_spill"`           // 分块合并预计写入临时目录的字节数
+	TempSpaceAvailable  int64           `json:"temp_space_available,omitempty"` // 临时目录所在文件系统的可用字节数，无法查询时为0
+	Warnings            []string        `json:"warnings,omitempty"`
+}
+
+// PreflightFile 单个输入的预检结果
+type PreflightFile struct {
+	File          string `json:"file"`
+	Size          int64  `json:"size"`
+	Valid         bool   `json:"valid"`
+	Encrypted     bool   `json:"encrypted"`
+	HasPassword   bool   `json:"has_password,omitempty"` // 加密输入是否在MergeOptions.Passwords或保险库中有密码
+	Pages         int    `json:"pages,omitempty"`        // 无法统计时为0
+	Error         string `json:"error,omitempty"`        // 无效或需要密码的原因
+	WillBeSkipped bool   `json:"will_be_skipped"`        // 合并时会被跳过；需要密码的输入会使合并失败而不是被跳过
+
+	// 页面尺寸，无法读取时为空
+	PageSize       string `json:"page_size,omitempty"`        // 页数最多的纸张尺寸，见PageDim.SizeName
+	MixedPageSizes bool   `json:"mixed_page_sizes,omitempty"` // 包含纸张尺寸不同的页面
+}
+
+// Preflight 执行合并前的全部检查而不写出文件：验证每个输入、检测加密、统计页数和页面尺寸，
+// 并报告 MergeStreaming 将选择的合并策略、预计的输出大小和分块写入临时目录的大小；
+// 输入的页面尺寸不一致、超出输出大小或页数上限、或临时目录可用空间不足时记录警告。
+// 只有在没有提供输入或ctx被取消时返回错误，单个输入的问题记录在报告中。
+func (sm *StreamingMerger) Preflight(ctx context.Context, files []string) (*PreflightReport, error) {
+	if err := sm.closer.enter("合并器", ""); err != nil {
+		return nil, err
+	}
+	defer sm.closer.leave()
+
+	if len(files) == 0 {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "没有提供输入文件",
+		}
+	}
+
+	report := &PreflightReport{
+		Files:         make([]PreflightFile, 0, len(files)),
+		PagesComplete: true,
+	}
+	var validFiles []string
+	var sizes []preflightPageSize
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entry, size := sm.preflightFile(file)
+		if entry.PageSize != "" {
+			sizes = append(sizes, preflightPageSize{file: file, dim: size})
+		}
+		if entry.MixedPageSizes {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s 包含不同尺寸的页面", file))
+		}
+		if entry.Valid {
+			validFiles = append(validFiles, file)
+			report.ValidFiles++
+			report.TotalInputSize += entry.Size
+			report.TotalPages += entry.Pages
+			if entry.Pages == 0 {
+				report.PagesComplete = false
+				report.Warnings = append(report.Warnings, fmt.Sprintf("无法统计 %s 的页数", file))
+			}
+		}
+		if entry.Encrypted {
+			report.EncryptedFiles++
+			if !entry.HasPassword {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s 已加密且没有提供密码，合并将失败", file))
+			}
+		}
+		report.Files = append(report.Files, entry)
+	}
+
+	if len(validFiles) == 0 {
+		report.PagesComplete = false
+		report.Warnings = append(report.Warnings, "没有有效的输入文件")
+		return report, nil
+	}
+
+	// 合并不重新压缩内容，输出大小约为输入之和
+	report.EstimatedOutputSize = report.TotalInputSize
+	report.HasLargeFiles = sm.analyzeFiles(validFiles).HasLargeFiles
+	report.Strategy = sm.selectStrategy(validFiles)
+	if sm.maxOutputBytes > 0 && report.TotalInputSize > sm.maxOutputBytes {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("输入文件共 %d 字节，超出输出大小上限 %d 字节，合并将失败",
+			report.TotalInputSize, sm.maxOutputBytes))
+	}
+	if sm.maxOutputPages > 0 && report.TotalPages > sm.maxOutputPages {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("输入文件共 %d 页，超出输出页数上限 %d 页，合并将失败",
+			report.TotalPages, sm.maxOutputPages))
+	}
+	sm.preflightTempSpace(report)
+	preflightPageSizes(report, sizes)
+	return report, nil
+}
+
+// preflightPageSize 一个输入页数最多的页面尺寸
+type preflightPageSize struct {
+	file string
+	dim  PageDim
+}
+
+// preflightPageSizes 各输入页数最多的纸张尺寸不同时记录警告，按尺寸列出输入，例如 "A4: a.pdf；Letter: b.pdf、c.pdf"
+func preflightPageSizes(report *PreflightReport, sizes []preflightPageSize) {
+	var groups []PageDim
+	files := make(map[int][]string)
+	for _, size := range sizes {
+		index := -1
+		for i, group := range groups {
+			if size.dim.SameSize(group) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = len(groups)
+			groups = append(groups, size.dim)
+		}
+		files[index] = append(files[index], size.file)
+	}
+	if len(groups) < 2 {
+		return
+	}
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = fmt.Sprintf("%s: %s", group.SizeName(), strings.Join(files[i], "、"))
+	}
+	report.Warnings = append(report.Warnings, "输入文件的页面尺寸不一致，合并后页面大小会不同（"+strings.Join(parts, "；")+"）")
+}
+
+// preflightTempSpace 估算分块合并写入临时目录的字节数，并与临时目录的可用空间比较
+func (sm *StreamingMerger) preflightTempSpace(report *PreflightReport) {
+	if report.Strategy == MergeStrategyStandard {
+		return
+	}
+	// 分块的中间结果不重新压缩内容，合计约为输入之和
+	report.EstimatedTempSpill = report.TotalInputSize
+
+	dir := sm.tempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	available, err := availableDiskSpace(dir)
+	if err != nil {
+		return
+	}
+	report.TempSpaceAvailable = available
+	if report.EstimatedTempSpill > available {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("分块合并预计写入临时目录 %d 字节，超出 %s 的可用空间 %d 字节",
+			report.EstimatedTempSpill, dir, available))
+	}
+}
+
+// preflightFile 按合并时的验证规则检查单个输入，同时返回有效输入页数最多的页面尺寸
+func (sm *StreamingMerger) preflightFile(file string) (PreflightFile, PageDim) {
+	entry := PreflightFile{File: file}
+	if info, err := os.Stat(file); err == nil {
+		entry.Size = info.Size()
+	}
+
+	entry.Encrypted = sm.isEncryptedInput(file)
+	var err error
+	if entry.Encrypted {
+		_, _, entry.HasPassword = sm.inputPassword(file)
+		if err = sm.requirePassword(file); err == nil {
+			err = sm.basicValidation(file)
+		}
+	} else {
+		err = sm.validateInputFile(file)
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+		entry.WillBeSkipped = !isEncryptionError(err)
+		return entry, PageDim{}
+	}
+
+	entry.Valid = true
+	if pages, err := sm.countPages(file); err == nil {
+		entry.Pages = pages
+	}
+	var dominant PageDim
+	if dims, err := ReadPageDims(file); err == nil && len(dims) > 0 {
+		dominant, _ = DominantPageSize(dims)
+		entry.PageSize = dominant.SizeName()
+		entry.MixedPageSizes = HasMixedPageSizes(dims)
+	}
+	return entry, dominant
+}
@@ -0,0 +1,133 @@
+package pdf
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflight_ReportsInputsWithoutWriting(t *testing.T) {
+	dir := t.TempDir()
+	nested := writeNestedPDF(t, dir, "nested.pdf")
+	flat := createTestFile(t, dir, "flat.pdf", buildFlatPDF(4))
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(2))
+	notPDF := createTestFile(t, dir, "notes.txt", []byte("not a pdf"))
+	before, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), MaxMemoryUsage: 1 << 30})
+	merger.adapter = nil
+	report, err := merger.Preflight(context.Background(), []string{nested, flat, encrypted, notPDF})
+	require.NoError(t, err)
+
+	require.Len(t, report.Files, 4)
+	assert.Equal(t, 5, report.Files[0].Pages)
+	assert.Equal(t, 4, report.Files[1].Pages)
+	assert.True(t, report.Files[2].Encrypted)
+	assert.False(t, report.Files[2].Valid)
+	assert.False(t, report.Files[2].WillBeSkipped, "缺少密码会使合并失败而不是跳过")
+	assert.True(t, report.Files[3].WillBeSkipped)
+	assert.NotEmpty(t, report.Files[3].Error)
+
+	assert.Equal(t, 2, report.ValidFiles)
+	assert.Equal(t, 1, report.EncryptedFiles)
+	assert.Equal(t, 9, report.TotalPages)
+	assert.True(t, report.PagesComplete)
+	assert.Equal(t, report.Files[0].Size+report.Files[1].Size, report.EstimatedOutputSize)
+	assert.Equal(t, merger.selectStrategy([]string{nested, flat}), report.Strategy)
+	assert.NotEmpty(t, report.Warnings)
+
+	after, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), len(after), "预检不应写出文件")
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	var decoded PreflightReport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *report, decoded)
+}
+
+func TestPreflight_EncryptedWithPassword(t *testing.T) {
+	dir := t.TempDir()
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(3))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:  t.TempDir(),
+		MaxMemoryUsage: 1 << 30,
+		Passwords:      map[string]string{encrypted: "secret"},
+	})
+	merger.adapter = nil
+	report, err := merger.Preflight(context.Background(), []string{encrypted})
+	require.NoError(t, err)
+
+	assert.True(t, report.Files[0].Valid)
+	assert.True(t, report.Files[0].HasPassword)
+	assert.Equal(t, 3, report.TotalPages, "页面树未加密时可以直接统计")
+	assert.Equal(t, MergeStrategyStandard, report.Strategy)
+}
+
+func TestPreflight_Errors(t *testing.T) {
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir()})
+	merger.adapter = nil
+
+	_, err := merger.Preflight(context.Background(), nil)
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = merger.Preflight(ctx, []string{filepath.Join(t.TempDir(), "a.pdf")})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPreflight_TempSpace(t *testing.T) {
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir()})
+
+	report := &PreflightReport{Strategy: MergeStrategyStandard, TotalInputSize: 1 << 20}
+	merger.preflightTempSpace(report)
+	assert.Zero(t, report.EstimatedTempSpill, "一次性合并不写入分块临时文件")
+
+	report = &PreflightReport{Strategy: MergeStrategyStreaming, TotalInputSize: 1 << 20}
+	merger.preflightTempSpace(report)
+	assert.Equal(t, int64(1<<20), report.EstimatedTempSpill)
+	if report.TempSpaceAvailable == 0 {
+		t.Skip("当前平台无法查询可用磁盘空间")
+	}
+	assert.Empty(t, report.Warnings)
+
+	report = &PreflightReport{Strategy: MergeStrategyStreaming, TotalInputSize: 1 << 62}
+	merger.preflightTempSpace(report)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0], "可用空间")
+}
+
+func TestPreflight_MixedPageSizes(t *testing.T) {
+	dir := t.TempDir()
+	a4 := writeSizedPDF(t, dir, "a4.pdf", "/MediaBox [0 0 595 842]", "/MediaBox [0 0 842 595]")
+	letter := writeSizedPDF(t, dir, "letter.pdf", "", "/MediaBox [0 0 612 1008]", "")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: t.TempDir(), MaxMemoryUsage: 1 << 30})
+	merger.adapter = nil
+	report, err := merger.Preflight(context.Background(), []string{a4, letter})
+	require.NoError(t, err)
+
+	assert.Equal(t, "A4", report.Files[0].PageSize)
+	assert.False(t, report.Files[0].MixedPageSizes, "横竖方向不同不算尺寸不同")
+	assert.Equal(t, "Letter", report.Files[1].PageSize)
+	assert.True(t, report.Files[1].MixedPageSizes)
+	assert.Contains(t, report.Warnings, letter+" 包含不同尺寸的页面")
+	assert.Contains(t, report.Warnings, "输入文件的页面尺寸不一致，合并后页面大小会不同（A4: "+a4+"；Letter: "+letter+"）")
+
+	report, err = merger.Preflight(context.Background(), []string{a4, a4})
+	require.NoError(t, err)
+	for _, warning := range report.Warnings {
+		assert.NotContains(t, warning, "页面尺寸不一致")
+	}
+}
@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"github.com/user/pdf-merger/internal/model"
+)
+
+// ApplyMergeProfile 把合并配置方案中设置的选项写入服务配置，方案中取默认值的字段保留config中的值。
+// 方案的流式选项替换config.StreamingConfig；选项无效时返回ErrorInvalidInput且不修改config
+func ApplyMergeProfile(config *ServiceConfig, profile model.MergeProfile) error {
+	streaming, err := ProfileStreamingConfig(profile)
+	if err != nil {
+		return err
+	}
+	switch profile.Merge.MetadataSource {
+	case "", MetadataSourceFirst, MetadataSourceCustom, MetadataSourceNone:
+	default:
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "未知的元数据来源: " + profile.Merge.MetadataSource,
+		}
+	}
+
+	merge := profile.Merge
+	if merge.MaxMemoryMB > 0 {
+		config.MaxMemoryUsage = merge.MaxMemoryMB * 1024 * 1024
+	}
+	config.Linearize = config.Linearize || merge.Linearize
+	config.SourceBookmarks = config.SourceBookmarks || merge.SourceBookmarks
+	config.GenerateTOC = config.GenerateTOC || merge.GenerateTOC
+	config.FlattenForms = config.FlattenForms || merge.FlattenForms
+	config.DropAttachments = config.DropAttachments || merge.DropAttachments
+	config.AllowDuplicates = config.AllowDuplicates || merge.AllowDuplicates
+	if merge.MaxOutputSizeMB > 0 {
+		config.MaxOutputSize = merge.MaxOutputSizeMB * 1024 * 1024
+	}
+	if merge.MaxOutputPages > 0 {
+		config.MaxOutputPages = merge.MaxOutputPages
+	}
+	if merge.MetadataSource != "" {
+		config.MetadataSource = merge.MetadataSource
+	}
+
+	if streaming != nil {
+		config.StreamingConfig = streaming
+	}
+
+	writer := profile.Writer
+	config.OptimizeOutput = config.OptimizeOutput || writer.Optimize
+	if writer.ImageDPI > 0 {
+		config.OptimizeImagesDPI = writer.ImageDPI
+	}
+	config.BackupOutput = config.BackupOutput || writer.BackupOutput
+	if writer.BackupRetention > 0 {
+		config.BackupRetention = writer.BackupRetention
+	}
+	return nil
+}
+
+// ProfileStreamingConfig 由方案的流式选项构造流式合并配置，没有设置任何流式选项时返回nil（使用默认配置）。
+// 固定分块大小时关闭自适应分块；内存上限同时限制警告阈值
+func ProfileStreamingConfig(profile model.MergeProfile) (*StreamingConfig, error) {
+	options := profile.Streaming
+	if options == (model.ProfileStreamingOptions{}) {
+		return nil, nil
+	}
+
+	config := DefaultStreamingConfig()
+	if options.MaxConcurrentChunks > 0 {
+		config.MaxConcurrentChunks = options.MaxConcurrentChunks
+	}
+	if options.ChunkSize > 0 {
+		config.MinChunkSize = options.ChunkSize
+		config.MaxChunkSize = options.ChunkSize
+		config.EnableAdaptiveChunking = false
+	}
+	if options.MemoryLimit > 0 {
+		config.MemoryCriticalThreshold = options.MemoryLimit
+		if config.MemoryWarningThreshold > options.MemoryLimit {
+			config.MemoryWarningThreshold = options.MemoryLimit
+		}
+	}
+	if options.LargeFileThresholdMB > 0 {
+		config.LargeFileThreshold = options.LargeFileThresholdMB * 1024 * 1024
+	}
+	config.DisableMemoryMap = options.DisableMemoryMap
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ApplyProfile 实现ProfileApplier：在创建服务时的配置副本上应用方案后替换当前配置。
+// 进行中的合并可能读到新旧两份配置，应只在没有合并进行时调用；验证器的映射阈值仍使用创建服务时的流式配置
+func (s *PDFServiceImpl) ApplyProfile(profile model.MergeProfile) error {
+	config := s.baseConfig
+	if err := ApplyMergeProfile(&config, profile); err != nil {
+		return err
+	}
+	s.config.Store(&config)
+	return nil
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/model"
+)
+
+func TestApplyMergeProfile(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.BackupOutput = true
+	profile := model.MergeProfile{
+		Merge: model.ProfileMergeOptions{
+			MaxMemoryMB:     32,
+			Linearize:       true,
+			MaxOutputSizeMB: 5,
+			MetadataSource:  MetadataSourceNone,
+		},
+		Streaming: model.ProfileStreamingOptions{ChunkSize: 4, MemoryLimit: 0.5, DisableMemoryMap: true},
+		Writer:    model.ProfileWriterOptions{Optimize: true, ImageDPI: 150},
+	}
+
+	require.NoError(t, ApplyMergeProfile(config, profile))
+	assert.Equal(t, int64(32*1024*1024), config.MaxMemoryUsage)
+	assert.True(t, config.Linearize)
+	assert.Equal(t, int64(5*1024*1024), config.MaxOutputSize)
+	assert.Equal(t, MetadataSourceNone, config.MetadataSource)
+	assert.True(t, config.OptimizeOutput)
+	assert.Equal(t, 150, config.OptimizeImagesDPI)
+	assert.True(t, config.BackupOutput, "方案中未设置的选项应保留原值")
+
+	streaming := config.StreamingConfig
+	require.NotNil(t, streaming)
+	assert.Equal(t, 4, streaming.MinChunkSize)
+	assert.Equal(t, 4, streaming.MaxChunkSize)
+	assert.False(t, streaming.EnableAdaptiveChunking, "固定分块大小时应关闭自适应分块")
+	assert.Equal(t, 0.5, streaming.MemoryCriticalThreshold)
+	assert.LessOrEqual(t, streaming.MemoryWarningThreshold, 0.5)
+	assert.True(t, streaming.DisableMemoryMap)
+}
+
+func TestApplyMergeProfile_Invalid(t *testing.T) {
+	for name, profile := range map[string]model.MergeProfile{
+		"memory-limit": {Streaming: model.ProfileStreamingOptions{MemoryLimit: 1.5}},
+		"metadata":     {Merge: model.ProfileMergeOptions{MetadataSource: "last"}},
+	} {
+		config := DefaultServiceConfig()
+		err := ApplyMergeProfile(config, profile)
+		var pdfErr *PDFError
+		require.ErrorAs(t, err, &pdfErr, name)
+		assert.Equal(t, ErrorInvalidInput, pdfErr.Type, name)
+		assert.Equal(t, DefaultServiceConfig().MaxMemoryUsage, config.MaxMemoryUsage, "无效方案不应修改配置")
+		assert.Nil(t, config.StreamingConfig, name)
+	}
+}
+
+func TestProfileStreamingConfig_BuiltinProfiles(t *testing.T) {
+	profiles := model.BuiltinProfiles()
+
+	streaming, err := ProfileStreamingConfig(profiles[model.DefaultProfileName])
+	require.NoError(t, err)
+	assert.Nil(t, streaming, "默认方案应使用默认流式配置")
+
+	streaming, err = ProfileStreamingConfig(profiles[model.LowMemoryProfileName])
+	require.NoError(t, err)
+	require.NotNil(t, streaming)
+	assert.Equal(t, 1, streaming.MaxConcurrentChunks)
+	assert.Equal(t, int64(1024*1024), streaming.LargeFileThreshold)
+}
+
+func TestPDFServiceImpl_ApplyProfile(t *testing.T) {
+	base := DefaultServiceConfig()
+	base.BackupOutput = true
+	service := NewPDFServiceWithConfig(base).(*PDFServiceImpl)
+	var applier ProfileApplier = service
+
+	require.NoError(t, applier.ApplyProfile(model.MergeProfile{Merge: model.ProfileMergeOptions{Linearize: true, GenerateTOC: true}}))
+	assert.True(t, service.config.Load().Linearize)
+	assert.True(t, service.config.Load().BackupOutput)
+	assert.False(t, base.Linearize, "应用方案不应修改调用方传入的配置")
+
+	// 再次应用时替换之前的方案，而不是在其基础上叠加
+	require.NoError(t, applier.ApplyProfile(model.BuiltinProfiles()[model.LowMemoryProfileName]))
+	current := service.config.Load()
+	assert.False(t, current.Linearize)
+	assert.False(t, current.GenerateTOC)
+	assert.Equal(t, int64(64*1024*1024), current.MaxMemoryUsage)
+	require.NotNil(t, current.StreamingConfig)
+
+	assert.Error(t, applier.ApplyProfile(model.MergeProfile{Merge: model.ProfileMergeOptions{MetadataSource: "last"}}))
+	assert.Same(t, current, service.config.Load(), "无效方案不应替换当前配置")
+}
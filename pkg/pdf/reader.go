@@ -1,7 +1,9 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -15,14 +17,27 @@ type PDFReader struct {
 	isOpen     bool
 	cliAdapter *PDFCPUCLIAdapter
 	useCLI     bool
+	limits     *PageTreeLimits
+	closer     closeGuard // Close契约：等待进行中的读取结束后再释放资源
+
+	source        *mappedFile // 打开的文件，大文件映射到内存，Close时解除映射
+	mmapThreshold int64       // 超过该大小的文件映射到内存，负数表示不映射
 }
 
-// NewPDFReader 创建一个新的PDF读取器
+// NewPDFReader 创建一个新的PDF读取器，使用默认的大文件阈值
 func NewPDFReader(filePath string) (*PDFReader, error) {
+	return NewPDFReaderWithConfig(filePath, nil)
+}
+
+// NewPDFReaderWithConfig 创建PDF读取器，大于config.LargeFileThreshold的文件映射到内存，
+// 读取文件头和页数时只读入用到的页面；config为nil时使用默认配置
+func NewPDFReaderWithConfig(filePath string, config *StreamingConfig) (*PDFReader, error) {
 	reader := &PDFReader{
-		filePath: filePath,
-		isOpen:   false,
-		useCLI:   false,
+		filePath:      filePath,
+		isOpen:        false,
+		useCLI:        false,
+		limits:        DefaultPageTreeLimits(),
+		mmapThreshold: config.memoryMapThreshold(),
 	}
 
 	// 尝试初始化CLI适配器
@@ -32,6 +47,7 @@ func NewPDFReader(filePath string) (*PDFReader, error) {
 	}
 
 	if err := reader.Open(); err != nil {
+		reader.Close()
 		return nil, err
 	}
 
@@ -40,6 +56,11 @@ func NewPDFReader(filePath string) (*PDFReader, error) {
 
 // Open 打开PDF文件进行读取
 func (r *PDFReader) Open() error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	if r.isOpen {
 		return nil
 	}
@@ -75,25 +96,35 @@ func (r *PDFReader) Open() error {
 	return nil
 }
 
-// Close 关闭PDF读取器并释放资源
+// Close 关闭PDF读取器并释放资源。Close 会等待进行中的读取结束；
+// 关闭后不能再打开，之后的方法调用返回 ErrClosed，重复调用返回首次关闭的结果。
 func (r *PDFReader) Close() error {
-	if !r.isOpen {
-		return nil
-	}
-
-	// 关闭CLI适配器
-	if r.cliAdapter != nil {
-		r.cliAdapter.Close()
-	}
+	return r.closer.close(func() error {
+		// 关闭CLI适配器
+		if r.cliAdapter != nil {
+			r.cliAdapter.Close()
+		}
 
-	r.info = nil
-	r.isOpen = false
+		r.info = nil
+		r.isOpen = false
 
-	return nil
+		// 解除映射并关闭文件；closeGuard保证此时没有进行中的读取
+		if r.source != nil {
+			err := r.source.Close()
+			r.source = nil
+			return err
+		}
+		return nil
+	})
 }
 
 // GetInfo 获取PDF文件的详细信息
 func (r *PDFReader) GetInfo() (*PDFInfo, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -106,19 +137,12 @@ func (r *PDFReader) GetInfo() (*PDFInfo, error) {
 		return r.info, nil
 	}
 
-	// 如果使用CLI，从CLI获取信息
+	// 如果使用CLI，从CLI获取信息；pdfcpu无法解析时回退到基本信息提取
 	if r.useCLI && r.cliAdapter != nil {
-		info, err := r.cliAdapter.GetFileInfo(r.filePath)
-		if err != nil {
-			return nil, &PDFError{
-				Type:    ErrorProcessing,
-				Message: "无法获取PDF信息",
-				File:    r.filePath,
-				Cause:   err,
-			}
+		if info, err := r.cliAdapter.GetFileInfo(r.filePath); err == nil {
+			r.info = info
+			return r.info, nil
 		}
-		r.info = info
-		return r.info, nil
 	}
 
 	// 回退到基本信息提取
@@ -132,9 +156,17 @@ func (r *PDFReader) GetInfo() (*PDFInfo, error) {
 		}
 	}
 
+	// 不依赖pdfcpu读取页数，超出限制时拒绝该文件
+	pageCount := 1
+	if count, err := r.readPageCount(); err == nil && count > 0 {
+		pageCount = count
+	} else if IsLimitExceededError(err) {
+		return nil, err
+	}
+
 	r.info = &PDFInfo{
 		FilePath:      r.filePath,
-		PageCount:     1,     // 默认值，实际需要解析PDF
+		PageCount:     pageCount,
 		IsEncrypted:   false, // 默认值，实际需要检查
 		FileSize:      fileInfo.Size(),
 		Title:         r.extractTitle(),
@@ -154,6 +186,11 @@ func (r *PDFReader) GetInfo() (*PDFInfo, error) {
 
 // GetPageCount 获取PDF页数
 func (r *PDFReader) GetPageCount() (int, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return 0, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return 0, &PDFError{
 			Type:    ErrorIO,
@@ -172,6 +209,11 @@ func (r *PDFReader) GetPageCount() (int, error) {
 
 // ValidatePage 验证指定页面是否存在
 func (r *PDFReader) ValidatePage(pageNum int) error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return &PDFError{
 			Type:    ErrorIO,
@@ -198,6 +240,11 @@ func (r *PDFReader) ValidatePage(pageNum int) error {
 
 // ValidateStructure 验证PDF文件结构完整性
 func (r *PDFReader) ValidateStructure() error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return &PDFError{
 			Type:    ErrorIO,
@@ -238,6 +285,11 @@ func (r *PDFReader) ValidateStructure() error {
 
 // IsEncrypted 检查PDF是否加密
 func (r *PDFReader) IsEncrypted() (bool, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return false, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return false, &PDFError{
 			Type:    ErrorIO,
@@ -254,6 +306,15 @@ func (r *PDFReader) IsEncrypted() (bool, error) {
 	return info.IsEncrypted, nil
 }
 
+// SetPageTreeLimits 设置页面树遍历限制（nil表示使用默认限制）
+func (r *PDFReader) SetPageTreeLimits(limits *PageTreeLimits) {
+	if limits == nil {
+		limits = DefaultPageTreeLimits()
+	}
+	r.limits = limits
+	r.info = nil
+}
+
 // GetFilePath 获取文件路径
 func (r *PDFReader) GetFilePath() string {
 	return r.filePath
@@ -277,6 +338,11 @@ func (r *PDFReader) extractTitle() string {
 
 // StreamPages 流式处理页面，避免一次性加载所有页面到内存
 func (r *PDFReader) StreamPages(processor func(pageNum int) error) error {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return &PDFError{
 			Type:    ErrorIO,
@@ -301,6 +367,11 @@ func (r *PDFReader) StreamPages(processor func(pageNum int) error) error {
 
 // GetMetadata 获取PDF元数据
 func (r *PDFReader) GetMetadata() (map[string]string, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -320,6 +391,14 @@ func (r *PDFReader) GetMetadata() (map[string]string, error) {
 		metadata["IsEncrypted"] = strconv.FormatBool(info.IsEncrypted)
 	}
 
+	// 文档信息字典中的文本项，Title优先于基本信息中以文件名代替的标题；加密文件中的字符串无法直接读取
+	encrypted := err == nil && info.IsEncrypted
+	if entries, err := ReadDocumentInfo(r.filePath); err == nil && !encrypted {
+		for key, value := range entries {
+			metadata[key] = value
+		}
+	}
+
 	// 如果使用CLI，可以尝试获取更多信息
 	if r.useCLI && r.cliAdapter != nil {
 		// CLI适配器目前不支持详细元数据提取
@@ -331,6 +410,11 @@ func (r *PDFReader) GetMetadata() (map[string]string, error) {
 
 // CheckPermissions 检查PDF权限设置
 func (r *PDFReader) CheckPermissions() ([]string, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -429,6 +513,11 @@ func (r *PDFReader) CanPrintHighQuality() (bool, error) {
 
 // GetSecurityInfo 获取PDF安全设置信息
 func (r *PDFReader) GetSecurityInfo() (map[string]interface{}, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -492,6 +581,11 @@ func (r *PDFReader) GetSecurityInfo() (map[string]interface{}, error) {
 
 // GetDetailedSecurityInfo 获取详细的安全信息，包括加密级别分析
 func (r *PDFReader) GetDetailedSecurityInfo() (map[string]interface{}, error) {
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return nil, err
+	}
+	defer r.closer.leave()
+
 	if !r.isOpen {
 		return nil, &PDFError{
 			Type:    ErrorIO,
@@ -622,9 +716,14 @@ func (r *PDFReader) containsPermission(permissions []string, permission string)
 
 // OpenWithPassword 使用密码打开加密的PDF文件
 func (r *PDFReader) OpenWithPassword(password string) error {
-	if r.isOpen {
-		r.Close()
+	if err := r.closer.enter("PDF读取器", r.filePath); err != nil {
+		return err
 	}
+	defer r.closer.leave()
+
+	// 重新打开时只丢弃缓存的信息，CLI适配器还要用于解密
+	r.info = nil
+	r.isOpen = false
 
 	// 验证文件是否存在
 	if _, err := os.Stat(r.filePath); err != nil {
@@ -665,22 +764,36 @@ func (r *PDFReader) OpenWithPassword(password string) error {
 	return nil
 }
 
-// basicPDFValidation 基本PDF文件验证
-func (r *PDFReader) basicPDFValidation() error {
-	file, err := os.Open(r.filePath)
+// readPageCount 不依赖pdfcpu读取页数，已映射的文件直接解析映射的内容
+func (r *PDFReader) readPageCount() (int, error) {
+	if r.source == nil || !r.source.Mapped() {
+		return ReadPageCount(r.filePath, r.limits)
+	}
+	data, err := r.source.Bytes()
 	if err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "无法打开PDF文件",
-			File:    r.filePath,
-			Cause:   err,
+		return 0, err
+	}
+	return readPageCount(r.filePath, data, r.limits)
+}
+
+// basicPDFValidation 基本PDF文件验证，打开的文件保留到Close
+func (r *PDFReader) basicPDFValidation() error {
+	if r.source == nil {
+		source, err := openMappedFile(r.filePath, r.mmapThreshold)
+		if err != nil {
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: "无法打开PDF文件",
+				File:    r.filePath,
+				Cause:   err,
+			}
 		}
+		r.source = source
 	}
-	defer file.Close()
 
 	// 检查PDF头部
 	header := make([]byte, 8)
-	if _, err := file.Read(header); err != nil {
+	if n, err := r.source.ReadAt(header, 0); err != nil && !(errors.Is(err, io.EOF) && n > 0) {
 		return &PDFError{
 			Type:    ErrorCorrupted,
 			Message: "无法读取文件头部",
@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -350,16 +351,17 @@ func TestPDFReader_CloseAndReopen(t *testing.T) {
 		t.Errorf("期望reader已关闭")
 	}
 
-	// 重新打开
+	// 关闭后不能重新打开
 	err = reader.Open()
-	if err != nil {
-		t.Logf("重新打开reader失败: %v", err)
-		return // 对于简单的测试PDF，这是预期的
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("期望关闭后打开返回ErrClosed，实际: %v", err)
 	}
 
-	if !reader.IsOpen() {
-		t.Errorf("期望reader已重新打开")
+	if reader.IsOpen() {
+		t.Errorf("期望reader保持关闭")
 	}
 
-	reader.Close()
+	if err := reader.Close(); err != nil {
+		t.Errorf("重复关闭应返回首次关闭的结果: %v", err)
+	}
 }
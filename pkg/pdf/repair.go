@@ -0,0 +1,344 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// repairHeaderWindow 查找PDF文件头的范围，文件头之前的数据（例如邮件网关添加的前缀）会被丢弃
+const repairHeaderWindow = 1024
+
+var (
+	catalogTypePattern = regexp.MustCompile(`/Type\s*/Catalog\b`)
+	headerPattern      = regexp.MustCompile(`%PDF-(\d\.\d)`)
+)
+
+// RepairAction 修复时发现并处理的问题
+type RepairAction string
+
+const (
+	// RepairLeadingData 丢弃文件头之前的数据
+	RepairLeadingData RepairAction = "leading_data"
+	// RepairXRef 交叉引用缺失或偏移不准确，按扫描到的对象重建
+	RepairXRef RepairAction = "xref"
+	// RepairEOF 补写缺失的 %%EOF 标记
+	RepairEOF RepairAction = "eof"
+	// RepairObjectStreams 把对象流中的对象重建为普通对象
+	RepairObjectStreams RepairAction = "object_streams"
+)
+
+// RepairReport 一次修复的结果
+type RepairReport struct {
+	InputPath  string         `json:"input_path"`
+	OutputPath string         `json:"output_path"`
+	Backend    string         `json:"backend"` // BackendPDFCPU 或 BackendFallback
+	Actions    []RepairAction `json:"actions"` // 为空表示没有发现结构问题，输出只是重写后的副本
+	Pages      int            `json:"pages"`   // 修复后的页数
+}
+
+// RepairPDF 不依赖pdfcpu修复轻度损坏的PDF并写出到outputPath：丢弃文件头之前的数据，
+// 合并交叉引用中可用的对象与按对象头扫描到的对象，展开对象流，然后重写交叉引用表、trailer和 %%EOF。
+// 输入文件不会被修改，outputPath 与输入相同时返回错误。加密文件和页面树无法恢复的文件返回错误。
+func RepairPDF(inputPath, outputPath string) (*RepairReport, error) {
+	if err := checkRepairPaths(inputPath, outputPath); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if encrypted, _ := hasEncryptEntry(inputPath); encrypted {
+		return nil, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法修复加密文件",
+			File:    inputPath,
+		}
+	}
+
+	repaired, actions, err := repairData(inputPath, data)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := WalkPageTree(inputPath, repaired, nil)
+	if err != nil || stats.PageCount == 0 {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "修复后仍无法读取页面树",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+
+	tempPath := outputPath + ".repair.tmp"
+	if err := os.WriteFile(tempPath, repaired, 0644); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入修复结果",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return &RepairReport{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Backend:    BackendFallback,
+		Actions:    actions,
+		Pages:      stats.PageCount,
+	}, nil
+}
+
+// checkRepairPaths 确认修复不会原地改写输入
+func checkRepairPaths(inputPath, outputPath string) error {
+	if SamePath(inputPath, outputPath) {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "修复结果不能写回原始文件",
+			File:    inputPath,
+		}
+	}
+	return nil
+}
+
+// repairData 返回重写后的文件内容和发现的问题
+func repairData(filePath string, data []byte) ([]byte, []RepairAction, error) {
+	var actions []RepairAction
+	head := data[:min(len(data), repairHeaderWindow)]
+	loc := headerPattern.FindSubmatchIndex(head)
+	if loc == nil {
+		return nil, nil, &PDFError{
+			Type:    ErrorInvalidFile,
+			Message: "无法修复：文件开头没有PDF文件头",
+			File:    filePath,
+		}
+	}
+	version := string(head[loc[2]:loc[3]])
+	if loc[0] > 0 {
+		data = data[loc[0]:]
+		actions = append(actions, RepairLeadingData)
+	}
+	if !bytes.Contains(data[max(len(data)-repairHeaderWindow, 0):], []byte("%%EOF")) {
+		actions = append(actions, RepairEOF)
+	}
+
+	objects, xrefValid, unpacked := collectRepairObjects(data)
+	if !xrefValid {
+		actions = append(actions, RepairXRef)
+	}
+	if unpacked {
+		actions = append(actions, RepairObjectStreams)
+	}
+
+	rootNum := repairRoot(data, objects)
+	if rootNum == 0 {
+		return nil, nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法修复：找不到文档目录",
+			File:    filePath,
+		}
+	}
+	trailer := fmt.Sprintf("/Root %d 0 R", rootNum)
+	if m := infoRefPattern.FindAllSubmatch(data, -1); len(m) > 0 {
+		if infoNum, _ := strconv.Atoi(string(m[len(m)-1][1])); objects[infoNum] != nil {
+			trailer += fmt.Sprintf(" /Info %d 0 R", infoNum)
+		}
+	}
+	return writeRepairedPDF(version, objects, trailer), actions, nil
+}
+
+// collectRepairObjects 收集文件中的对象：优先使用交叉引用中能读出的定义，
+// 再补充按对象头扫描到的对象和对象流中的对象；对象流与交叉引用流本身不再保留。
+// xrefValid 表示交叉引用可以解析且所有条目都指向正确的对象，unpacked 表示展开了对象流。
+func collectRepairObjects(data []byte) (objects map[int][]byte, xrefValid, unpacked bool) {
+	objects = make(map[int][]byte)
+	if index, err := readXRefIndex(data); err == nil {
+		xrefValid = true
+		for num := range index.entries {
+			if body, err := index.object(num); err == nil {
+				objects[num] = body
+			} else {
+				xrefValid = false
+			}
+		}
+	}
+
+	scanned := scanObjectIndex(data)
+	for num := range scanned.entries {
+		if _, ok := objects[num]; !ok {
+			objects[num], _ = scanned.object(num)
+		}
+	}
+	for num, body := range objects {
+		if !objStmPattern.Match(streamDict(body)) {
+			continue
+		}
+		unpacked = true
+		if compressed, err := scanned.objectStream(num); err == nil {
+			for n, obj := range compressed {
+				if _, ok := objects[n]; !ok {
+					objects[n] = obj
+				}
+			}
+		}
+	}
+
+	for num, body := range objects {
+		dict := streamDict(body)
+		if body == nil || objStmPattern.Match(dict) || xrefStreamTypePattern.Match(dict) {
+			delete(objects, num)
+		}
+	}
+	return objects, xrefValid, unpacked
+}
+
+// scanObjectIndex 按对象头扫描建立交叉引用索引，后出现的定义覆盖先前定义（增量更新）
+func scanObjectIndex(data []byte) *xrefIndex {
+	index := &xrefIndex{
+		data:    data,
+		entries: make(map[int]xrefEntry),
+		streams: make(map[int]map[int][]byte),
+	}
+	for _, m := range objHeaderPattern.FindAllSubmatchIndex(data, -1) {
+		if m[0] > 0 && !isPDFWhitespace(data[m[0]-1]) {
+			continue
+		}
+		if num, err := strconv.Atoi(string(data[m[2]:m[3]])); err == nil {
+			index.entries[num] = xrefEntry{offset: int64(m[0])}
+		}
+	}
+	return index
+}
+
+// repairRoot 返回文档目录的对象编号：以最后一个有效的 /Root 引用为准，
+// 引用缺失或指向的不是目录时使用编号最大的目录对象，没有时返回0
+func repairRoot(data []byte, objects map[int][]byte) int {
+	matches := rootRefPattern.FindAllSubmatch(data, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		num, _ := strconv.Atoi(string(matches[i][1]))
+		if body := objects[num]; body != nil && catalogTypePattern.Match(streamDict(body)) {
+			return num
+		}
+	}
+	root := 0
+	for num, body := range objects {
+		if num > root && catalogTypePattern.Match(streamDict(body)) {
+			root = num
+		}
+	}
+	return root
+}
+
+// writeRepairedPDF 按原编号输出对象，并写出完整的交叉引用表、trailer和 %%EOF
+func writeRepairedPDF(version string, objects map[int][]byte, trailer string) []byte {
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", version)
+	offsets := make(map[int]int, len(nums))
+	for _, num := range nums {
+		offsets[num] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", num)
+		out.Write(bytes.TrimSpace(objects[num]))
+		out.WriteString("\nendobj\n")
+	}
+
+	size := 1
+	if len(nums) > 0 {
+		size = nums[len(nums)-1] + 1
+	}
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for num := 1; num < size; num++ {
+		if offset, ok := offsets[num]; ok {
+			fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+		} else {
+			out.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", size, trailer, xrefOffset)
+	return out.Bytes()
+}
+
+// inputRepairs 一次合并中已修复的输入：原始路径 -> 修复后的临时副本
+type inputRepairs map[string]string
+
+// paths 返回把已修复的输入换成修复副本后的输入列表
+func (r inputRepairs) paths(files []string) []string {
+	if len(r) == 0 {
+		return files
+	}
+	replaced := make([]string, len(files))
+	for i, file := range files {
+		replaced[i] = file
+		if repaired, ok := r[file]; ok {
+			replaced[i] = repaired
+		}
+	}
+	return replaced
+}
+
+// repairInput 启用TryRepair时把未通过验证的输入修复到临时副本并重新验证副本。
+// 成功时记录到repairs和result.RepairedFiles并返回true；失败时删除副本、记录警告并返回false，
+// 由调用方按原来的验证错误跳过该输入。
+func (sm *StreamingMerger) repairInput(result *MergeResult, repairs inputRepairs, file string) bool {
+	if !sm.tryRepair {
+		return false
+	}
+	tempPath := sm.generateTempPath(file)
+	report, err := sm.files().RepairFile(file, tempPath)
+	if err == nil {
+		err = sm.validateInputFile(tempPath)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		sm.untrackTempFile(tempPath)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("无法修复文件 %s: %v", file, err))
+		return false
+	}
+
+	repairs[file] = tempPath
+	result.RepairedFiles = append(result.RepairedFiles, file)
+	summary := "未发现结构问题，已重写"
+	if len(report.Actions) > 0 {
+		actions := make([]string, len(report.Actions))
+		for i, action := range report.Actions {
+			actions[i] = string(action)
+		}
+		summary = strings.Join(actions, ", ")
+	}
+	message := fmt.Sprintf("已修复文件 %s（%s），合并修复后的副本", file, summary)
+	result.Warnings = append(result.Warnings, message)
+	result.PageWarnings = append(result.PageWarnings, PageWarning{Kind: WarningRepairedContent, Message: message, File: file})
+	return true
+}
+
+// cleanupRepairs 删除修复副本
+func (sm *StreamingMerger) cleanupRepairs(repairs inputRepairs) {
+	temps := make([]string, 0, len(repairs))
+	for _, temp := range repairs {
+		temps = append(temps, temp)
+	}
+	sm.cleanupTempFiles(temps)
+}
@@ -0,0 +1,191 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withStaleStartXRef 把startxref改为指向文件中间的无效偏移
+func withStaleStartXRef(data []byte) []byte {
+	i := bytes.LastIndex(data, []byte("startxref\n"))
+	return append(append([]byte(nil), data[:i]...), []byte("startxref\n17\n%%EOF\n")...)
+}
+
+func TestRepairPDF_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content []byte
+		pages   int
+		actions []RepairAction
+	}{
+		{"完好", buildFlatPDF(2), 2, nil},
+		{"缺少EOF", bytes.TrimSuffix(buildFlatPDF(2), []byte("%%EOF\n")), 2, []RepairAction{RepairEOF}},
+		{"startxref过期", withStaleStartXRef(buildFlatPDF(3)), 3, []RepairAction{RepairXRef}},
+		{"文件头前有数据", append([]byte("X-Mailer: scan\r\n"), buildFlatPDF(1)...), 1, []RepairAction{RepairLeadingData}},
+		{"对象流", buildCompressedTreePDF(4), 4, []RepairAction{RepairObjectStreams}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := createTestFile(t, dir, tt.name+".pdf", tt.content)
+			output := filepath.Join(dir, tt.name+"_repaired.pdf")
+
+			report, err := RepairPDF(input, output)
+			require.NoError(t, err)
+			assert.Equal(t, tt.actions, report.Actions)
+			assert.Equal(t, tt.pages, report.Pages)
+			assert.Equal(t, BackendFallback, report.Backend)
+
+			original, err := os.ReadFile(input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, original, "修复不应修改输入文件")
+
+			repaired, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(repaired, []byte("%PDF-")))
+			assert.True(t, bytes.HasSuffix(repaired, []byte("%%EOF\n")))
+			assert.NotContains(t, string(repaired), "/ObjStm")
+			index, err := readXRefIndex(repaired)
+			require.NoError(t, err)
+			for num := range index.entries {
+				_, err := index.object(num)
+				assert.NoError(t, err, "重建的交叉引用应指向正确的对象")
+			}
+			count, err := CountPagesInFile(output, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.pages, count)
+		})
+	}
+}
+
+func TestRepairPDF_Errors(t *testing.T) {
+	dir := t.TempDir()
+	plain := createTestFile(t, dir, "plain.pdf", buildFlatPDF(1))
+	encrypted := createTestFile(t, dir, "encrypted.pdf", buildEncryptedPDF(1))
+	noCatalog := createTestFile(t, dir, "nocatalog.pdf", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Page >>\nendobj\n"))
+	text := createTestFile(t, dir, "text.pdf", []byte("just text"))
+	alias := filepath.Join(dir, "alias.pdf")
+	require.NoError(t, os.Link(plain, alias))
+
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		want   ErrorType
+	}{
+		{"原地修复", plain, plain, ErrorInvalidInput},
+		{"指向输入的硬链接", plain, alias, ErrorInvalidInput},
+		{"加密文件", encrypted, filepath.Join(dir, "out1.pdf"), ErrorEncrypted},
+		{"没有目录", noCatalog, filepath.Join(dir, "out2.pdf"), ErrorCorrupted},
+		{"不是PDF", text, filepath.Join(dir, "out3.pdf"), ErrorInvalidFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := os.ReadFile(tt.input)
+			require.NoError(t, err)
+
+			_, err = RepairPDF(tt.input, tt.output)
+			var pdfErr *PDFError
+			require.ErrorAs(t, err, &pdfErr)
+			assert.Equal(t, tt.want, pdfErr.Type)
+
+			after, err := os.ReadFile(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+			if tt.want != ErrorInvalidInput {
+				assert.False(t, fileExists(tt.output), "失败时不应留下输出")
+			}
+		})
+	}
+}
+
+func TestPDFServiceImpl_RepairPDF(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "scan.pdf", bytes.TrimSuffix(buildFlatPDF(2), []byte("%%EOF\n")))
+	output := filepath.Join(dir, "fixed.pdf")
+
+	report, err := NewPDFService().RepairPDF(input, output)
+	require.NoError(t, err)
+	assert.Equal(t, output, report.OutputPath)
+	assert.Contains(t, report.Actions, RepairEOF)
+	assert.Equal(t, 2, report.Pages)
+	leftovers, err := filepath.Glob(filepath.Join(dir, "*_temp_*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+func TestMergeStreaming_TryRepair(t *testing.T) {
+	dir := t.TempDir()
+	tempDir := t.TempDir()
+	good := createTestFile(t, dir, "good.pdf", buildFlatPDF(1))
+	brokenContent := append([]byte("garbage before header\n"), buildFlatPDF(2)...)
+	broken := createTestFile(t, dir, "broken.pdf", brokenContent)
+
+	t.Run("关闭时跳过", func(t *testing.T) {
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore()})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), []string{good, broken}, filepath.Join(dir, "skip.pdf"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{broken}, result.SkippedFiles)
+		assert.Empty(t, result.RepairedFiles)
+	})
+
+	t.Run("开启时合并修复副本", func(t *testing.T) {
+		output := filepath.Join(dir, "repaired.pdf")
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore(), TryRepair: true})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), []string{good, broken}, output, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, result.SkippedFiles)
+		assert.Equal(t, []string{good, broken}, result.ValidatedFiles, "结果中应显示原始输入")
+		assert.Equal(t, []string{broken}, result.RepairedFiles)
+		assert.Equal(t, 3, result.TotalPages)
+
+		original, err := os.ReadFile(broken)
+		require.NoError(t, err)
+		assert.Equal(t, brokenContent, original, "原始输入不应被修改")
+		leftovers, err := filepath.Glob(filepath.Join(tempDir, "*_temp_*.pdf"))
+		require.NoError(t, err)
+		assert.Empty(t, leftovers, "修复副本应被清理")
+	})
+
+	t.Run("审阅副本中标注修复的输入", func(t *testing.T) {
+		output := filepath.Join(dir, "reviewed.pdf")
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore(), TryRepair: true, ReviewCopy: true})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), []string{good, broken}, output, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.ReviewCopyPath)
+
+		require.Len(t, result.PageWarnings, 1)
+		warning := result.PageWarnings[0]
+		assert.Equal(t, WarningRepairedContent, warning.Kind)
+		assert.Equal(t, broken, warning.File)
+		assert.Equal(t, 2, warning.Page, "应归属到修复的输入在输出中的第一页")
+
+		review, err := os.ReadFile(result.ReviewCopyPath)
+		require.NoError(t, err)
+		stats, err := WalkPageTree(result.ReviewCopyPath, review, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.PageCount, "修复警告已归属到页面，不应再出现在摘要页中")
+	})
+
+	t.Run("无法修复时跳过", func(t *testing.T) {
+		text := createTestFile(t, dir, "text.pdf", []byte("not a pdf at all"))
+		merger := NewStreamingMerger(&MergeOptions{TempDirectory: tempDir, BackendStats: NewBackendStatsStore(), TryRepair: true})
+		defer merger.Close()
+		result, err := merger.MergeStreaming(context.Background(), []string{good, text}, filepath.Join(dir, "partial.pdf"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{text}, result.SkippedFiles)
+		assert.Empty(t, result.RepairedFiles)
+		assert.Contains(t, result.Warnings[0], "无法修复文件")
+	})
+}
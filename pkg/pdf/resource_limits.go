@@ -0,0 +1,358 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// ResourceLimits 验证和读取文件时的资源限制，防止压缩炸弹或畸形对象图在内存检查生效前耗尽内存
+type ResourceLimits struct {
+	MaxDecodedBytes int64 // 文件中所有Flate流解码后的总字节数上限，0时不限制
+	MaxObjectDepth  int   // 从目录对象出发的引用链长度及对象内字典、数组嵌套层数的上限，0时不限制
+}
+
+// DefaultResourceLimits 返回默认的资源限制
+func DefaultResourceLimits() *ResourceLimits {
+	return &ResourceLimits{
+		MaxDecodedBytes: 1 << 30, // 1GB
+		MaxObjectDepth:  1024,
+	}
+}
+
+// backReferenceKeys 指向父节点或前一个兄弟节点的键，遍历对象图时不沿这些键前进
+var backReferenceKeys = map[string]bool{
+	"Parent": true,
+	"P":      true,
+	"Prev":   true,
+	"Last":   true,
+}
+
+// newResourceLimitError 创建包装了LimitExceededError的ErrorResourceLimit错误
+func newResourceLimitError(filePath, limit string, max, observed int64) *PDFError {
+	return &PDFError{
+		Type:    ErrorResourceLimit,
+		Message: fmt.Sprintf("文件超出资源限制 %s（观测值 %d，上限 %d）", limit, observed, max),
+		File:    filePath,
+		Cause:   &LimitExceededError{Limit: limit, Max: int(max), Observed: int(observed)},
+	}
+}
+
+// CheckResourceLimits 读取文件并检查其是否超出资源限制，limits为nil时使用默认值。
+// 超出限制时返回ErrorResourceLimit类型的PDFError；ctx结束时返回ctx的错误
+func CheckResourceLimits(ctx context.Context, filePath string, limits *ResourceLimits) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &PDFError{Type: ErrorIO, Message: "无法读取文件", File: filePath, Cause: err}
+	}
+	return checkResourceLimits(ctx, filePath, data, limits)
+}
+
+// checkResourceLimits 先统计所有Flate流解码后的总字节数（只计数，不保留解码结果），
+// 总量未超限后再从目录对象出发遍历对象图检查嵌套深度
+func checkResourceLimits(ctx context.Context, filePath string, data []byte, limits *ResourceLimits) error {
+	if limits == nil {
+		limits = DefaultResourceLimits()
+	}
+	offsets := indexObjects(data)
+	if limits.MaxDecodedBytes > 0 {
+		if err := checkDecodedBytes(ctx, filePath, data, offsets, limits.MaxDecodedBytes); err != nil {
+			return err
+		}
+	}
+	if limits.MaxObjectDepth > 0 {
+		if err := checkObjectDepth(ctx, filePath, data, offsets, limits.MaxObjectDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDecodedBytes 逐个解码文件中的Flate流，累计字节数超过max时立即停止。
+// 无法解码的流按已解码的部分计数，损坏由后续验证报告
+func checkDecodedBytes(ctx context.Context, filePath string, data []byte, offsets map[int]int, max int64) error {
+	counter := &decodedCounter{ctx: ctx, remaining: max}
+	for num := range offsets {
+		body, _ := objectBody(data, offsets, num)
+		if !bytes.Contains(body, []byte("stream")) {
+			continue
+		}
+		dict, raw, err := splitStream(body)
+		if err != nil || !flateFilterPattern.Match(dict) {
+			continue
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			continue
+		}
+		_, err = io.Copy(counter, reader)
+		reader.Close()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if counter.remaining < 0 {
+			return newResourceLimitError(filePath, "MaxDecodedBytes", max, max-counter.remaining)
+		}
+	}
+	return nil
+}
+
+// errDecodedBudget 解码字节数超出预算，用于中止io.Copy
+var errDecodedBudget = fmt.Errorf("解码字节数超出预算")
+
+// decodedCounter 丢弃写入的数据并从预算中扣除，预算用尽或ctx结束时返回错误
+type decodedCounter struct {
+	ctx       context.Context
+	remaining int64
+}
+
+// Write 实现io.Writer
+func (c *decodedCounter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	c.remaining -= int64(len(p))
+	if c.remaining < 0 {
+		return len(p), errDecodedBudget
+	}
+	return len(p), nil
+}
+
+// objectRef 对象内的一个间接引用及其所属的键
+type objectRef struct {
+	key    string
+	objNum int
+}
+
+// depthFrame 对象图遍历路径上的一个对象
+type depthFrame struct {
+	objNum int
+	depth  int
+	refs   []objectRef
+	next   int
+}
+
+// checkObjectDepth 从目录对象出发按深度优先遍历对象图。已遍历完的对象不再进入；
+// 指向路径上对象的引用通常是合法的回指（如链接注释指向所在页面），跳过，
+// 但经 /Kids 回到路径上的对象说明树结构成环，继续展开，使引用链不断加深直到超出max。
+// /Next 指向兄弟节点，不增加深度。对象流中的对象也参与遍历
+func checkObjectDepth(ctx context.Context, filePath string, data []byte, offsets map[int]int, max int) error {
+	matches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	rootNum := atoiOrZero(matches[len(matches)-1][1])
+	bodies := objectBodies(data)
+
+	onPath := make(map[int]int)
+	done := make(map[int]bool)
+	var stack []*depthFrame
+	push := func(objNum, depth int) error {
+		if depth > max {
+			return newResourceLimitError(filePath, "MaxObjectDepth", int64(max), int64(depth))
+		}
+		frame := &depthFrame{objNum: objNum, depth: depth}
+		if body, ok := bodies[objNum]; ok {
+			nesting := scanObjectRefs(beforeStream(body), func(key string, ref int) {
+				frame.refs = append(frame.refs, objectRef{key: key, objNum: ref})
+			})
+			if nesting > max {
+				return newResourceLimitError(filePath, "MaxObjectDepth", int64(max), int64(nesting))
+			}
+		}
+		onPath[objNum]++
+		stack = append(stack, frame)
+		return nil
+	}
+
+	if err := push(rootNum, 1); err != nil {
+		return err
+	}
+	for steps := 0; len(stack) > 0; steps++ {
+		if steps%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		top := stack[len(stack)-1]
+		if top.next >= len(top.refs) {
+			stack = stack[:len(stack)-1]
+			if onPath[top.objNum]--; onPath[top.objNum] == 0 {
+				done[top.objNum] = true
+			}
+			continue
+		}
+		ref := top.refs[top.next]
+		top.next++
+		if backReferenceKeys[ref.key] || done[ref.objNum] {
+			continue
+		}
+		if onPath[ref.objNum] > 0 && ref.key != "Kids" {
+			continue
+		}
+		depth := top.depth + 1
+		if ref.key == "Next" {
+			depth = top.depth
+		}
+		if err := push(ref.objNum, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// beforeStream 返回对象中流数据之前的部分，不含流的对象原样返回
+func beforeStream(body []byte) []byte {
+	if i := bytes.Index(body, []byte("stream")); i >= 0 {
+		return body[:i]
+	}
+	return body
+}
+
+// atoiOrZero 解析十进制数字，失败时返回0
+func atoiOrZero(digits []byte) int {
+	n := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+// scanObjectRefs 扫描对象内容中的间接引用（N G R），对每个引用以最近出现的名称作为键调用fn，
+// 返回字典与数组的最大嵌套层数。跳过字符串和注释中的内容
+func scanObjectRefs(data []byte, fn func(key string, objNum int)) int {
+	var nums [2]int
+	count := 0
+	key := ""
+	nesting, maxNesting := 0, 0
+	open := func() {
+		nesting++
+		if nesting > maxNesting {
+			maxNesting = nesting
+		}
+		count = 0
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			i = skipLiteralString(data, i)
+			count = 0
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			open()
+			i += 2
+		case c == '<':
+			for i < len(data) && data[i] != '>' {
+				i++
+			}
+			i++
+			count = 0
+		case c == '>' && i+1 < len(data) && data[i+1] == '>':
+			nesting--
+			count = 0
+			i += 2
+		case c == '[':
+			open()
+			i++
+		case c == ']':
+			nesting--
+			count = 0
+			i++
+		case c == '/':
+			start := i + 1
+			i = start
+			for i < len(data) && !isPDFWhitespace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+			key = string(data[start:i])
+			count = 0
+		case c >= '0' && c <= '9':
+			n := 0
+			for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+				n = n*10 + int(data[i]-'0')
+				i++
+			}
+			if i < len(data) && data[i] == '.' {
+				// 实数不可能是引用的一部分
+				for i < len(data) && !isPDFWhitespace(data[i]) && !isPDFDelimiter(data[i]) {
+					i++
+				}
+				count = 0
+				continue
+			}
+			if count == 2 {
+				nums[0] = nums[1]
+				count = 1
+			}
+			nums[count] = n
+			count++
+		case c == 'R' && (i+1 == len(data) || isPDFWhitespace(data[i+1]) || isPDFDelimiter(data[i+1])):
+			if count == 2 {
+				fn(key, nums[0])
+			}
+			count = 0
+			i++
+		case isPDFDelimiter(c):
+			i++
+			count = 0
+		default:
+			for i < len(data) && !isPDFWhitespace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+			count = 0
+		}
+	}
+	return maxNesting
+}
+
+// isPDFDelimiter 判断是否为PDF分隔符
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// runWithTimeout 在新协程中执行fn，超过timeout时返回ErrorResourceLimit错误，并通过ctx通知fn停止。
+// timeout不大于0时直接执行fn。fn中无法响应ctx的部分会在后台继续运行到结束，结果被丢弃
+func runWithTimeout(clk clock.Clock, filePath string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(context.Background())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	timer := clock.OrSystem(clk).NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C():
+		return &PDFError{
+			Type:    ErrorResourceLimit,
+			Message: fmt.Sprintf("验证超过 %v 仍未完成", timeout),
+			File:    filePath,
+			Cause:   &LimitExceededError{Limit: "ValidationTimeout", Max: int(timeout.Milliseconds()), Observed: int(timeout.Milliseconds())},
+		}
+	}
+}
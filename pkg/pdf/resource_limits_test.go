@@ -0,0 +1,206 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildZipBombPDF 生成一页PDF，其内容流是decoded字节的零压缩后的数据（压缩比约一千比一）
+func buildZipBombPDF(decoded int) []byte {
+	var compressed bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&compressed, zlib.BestCompression)
+	zeros := make([]byte, 1<<20)
+	for written := 0; written < decoded; written += len(zeros) {
+		w.Write(zeros[:min(len(zeros), decoded-written)])
+	}
+	w.Close()
+
+	return buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+	})
+}
+
+// circularKidsPDF 页面树的 /Kids 互相引用成环，与验证测试中的循环引用文件结构相同
+const circularKidsPDF = `%PDF-1.4
+1 0 obj
+<< /Type /Catalog /Pages 2 0 R >>
+endobj
+2 0 obj
+<< /Type /Pages /Kids [3 0 R] /Count 1 /Parent 3 0 R >>
+endobj
+3 0 obj
+<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Kids [2 0 R] >>
+endobj
+trailer
+<< /Size 4 /Root 1 0 R >>
+%%EOF`
+
+// writeResourceTestFile 把data写入临时目录中的文件并返回路径
+func writeResourceTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+// requireResourceLimit 断言err是指定限制的ErrorResourceLimit错误
+func requireResourceLimit(t *testing.T, err error, limit string) {
+	t.Helper()
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorResourceLimit {
+		t.Fatalf("期望ErrorResourceLimit类型的PDFError，实际: %v", err)
+	}
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != limit {
+		t.Fatalf("期望超出限制 %s，实际: %v", limit, err)
+	}
+}
+
+func TestCheckResourceLimits_ZipBomb(t *testing.T) {
+	data := buildZipBombPDF(64 << 20)
+	if len(data) > 1<<20 {
+		t.Fatalf("压缩炸弹文件应小于1MB，实际 %d 字节", len(data))
+	}
+	path := writeResourceTestFile(t, "bomb.pdf", data)
+
+	err := CheckResourceLimits(context.Background(), path, &ResourceLimits{MaxDecodedBytes: 16 << 20})
+	requireResourceLimit(t, err, "MaxDecodedBytes")
+
+	// 预算足够时同一文件通过检查
+	if err := CheckResourceLimits(context.Background(), path, &ResourceLimits{MaxDecodedBytes: 128 << 20}); err != nil {
+		t.Errorf("解码总量未超限时不应报错: %v", err)
+	}
+}
+
+func TestCheckResourceLimits_CircularKidsHitsDepthLimit(t *testing.T) {
+	path := writeResourceTestFile(t, "circular.pdf", []byte(circularKidsPDF))
+
+	err := CheckResourceLimits(context.Background(), path, &ResourceLimits{MaxObjectDepth: 64})
+	requireResourceLimit(t, err, "MaxObjectDepth")
+}
+
+func TestCheckResourceLimits_DeepInlineNesting(t *testing.T) {
+	nested := strings.Repeat("[", 100) + strings.Repeat("]", 100)
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Deep " + nested + " >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+	})
+	path := writeResourceTestFile(t, "nested.pdf", data)
+
+	err := CheckResourceLimits(context.Background(), path, &ResourceLimits{MaxObjectDepth: 50})
+	requireResourceLimit(t, err, "MaxObjectDepth")
+}
+
+func TestCheckResourceLimits_BackReferencesAreNotCycles(t *testing.T) {
+	// 页面通过 /Parent 回指页面树，注释通过 /P 回指页面，链接目标指向所在页面，书签之间以 /Next /Prev 相连
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 5 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R] >>",
+		"<< /Type /Annot /Subtype /Link /P 3 0 R /Rect [0 0 10 10] /Dest [3 0 R /Fit] >>",
+		"<< /Type /Outlines /First 6 0 R /Last 7 0 R /Count 2 >>",
+		"<< /Title (a 1 0 R) /Parent 5 0 R /Next 7 0 R /Dest [3 0 R /Fit] >>",
+		"<< /Title (b) /Parent 5 0 R /Prev 6 0 R /Dest [3 0 R /Fit] >>",
+	})
+	path := writeResourceTestFile(t, "backrefs.pdf", data)
+
+	if err := CheckResourceLimits(context.Background(), path, &ResourceLimits{MaxObjectDepth: 8}); err != nil {
+		t.Errorf("合法的回指不应超出深度限制: %v", err)
+	}
+}
+
+func TestCheckResourceLimits_LongOutlineSiblingChain(t *testing.T) {
+	// /Next 串起的兄弟书签不增加深度
+	const items = 2000
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 3 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		fmt.Sprintf("<< /Type /Outlines /First 4 0 R /Last %d 0 R /Count %d >>", items+3, items),
+	}
+	for i := 0; i < items; i++ {
+		next := ""
+		if i+1 < items {
+			next = fmt.Sprintf(" /Next %d 0 R", i+5)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Title (%d) /Parent 3 0 R%s >>", i, next))
+	}
+	path := writeResourceTestFile(t, "outline.pdf", buildPDF(objects))
+
+	if err := CheckResourceLimits(context.Background(), path, nil); err != nil {
+		t.Errorf("长书签链不应超出默认限制: %v", err)
+	}
+}
+
+func TestCheckResourceLimits_RegularPDF(t *testing.T) {
+	path := writeResourceTestFile(t, "flat.pdf", buildFlatPDF(50))
+	if err := CheckResourceLimits(context.Background(), path, nil); err != nil {
+		t.Errorf("普通文件不应超出默认限制: %v", err)
+	}
+}
+
+func TestPDFService_ResourceLimits(t *testing.T) {
+	config := DefaultServiceConfig()
+	config.ResourceLimits = &ResourceLimits{MaxDecodedBytes: 16 << 20, MaxObjectDepth: 64}
+	service := NewPDFServiceWithConfig(config)
+
+	bomb := writeResourceTestFile(t, "bomb.pdf", buildZipBombPDF(64<<20))
+	requireResourceLimit(t, service.ValidatePDF(bomb), "MaxDecodedBytes")
+	_, err := service.GetPDFInfo(bomb)
+	requireResourceLimit(t, err, "MaxDecodedBytes")
+
+	circular := writeResourceTestFile(t, "circular.pdf", []byte(circularKidsPDF))
+	requireResourceLimit(t, service.ValidatePDF(circular), "MaxObjectDepth")
+	_, err = service.GetPDFInfo(circular)
+	requireResourceLimit(t, err, "MaxObjectDepth")
+}
+
+func TestPDFService_ValidationTimeout(t *testing.T) {
+	config := DefaultServiceConfig()
+	// 解压预算足够大，让检查在解码压缩炸弹上花费远超超时的时间
+	config.ResourceLimits = &ResourceLimits{MaxDecodedBytes: 1 << 40, MaxObjectDepth: 64}
+	config.ValidationTimeout = time.Millisecond
+	service := NewPDFServiceWithConfig(config)
+	path := writeResourceTestFile(t, "bomb.pdf", buildZipBombPDF(64<<20))
+
+	start := time.Now()
+	err := service.ValidatePDF(path)
+	requireResourceLimit(t, err, "ValidationTimeout")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("超时后应尽快返回，实际耗时 %v", elapsed)
+	}
+}
+
+func TestRunWithTimeout_ReturnsResultBeforeTimeout(t *testing.T) {
+	want := errors.New("验证失败")
+	err := runWithTimeout(nil, "a.pdf", time.Minute, func(ctx context.Context) error { return want })
+	if err != want {
+		t.Errorf("期望返回fn的错误，实际: %v", err)
+	}
+
+	// fn响应ctx：超时后ctx结束
+	stopped := make(chan struct{})
+	err = runWithTimeout(nil, "a.pdf", time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	requireResourceLimit(t, err, "ValidationTimeout")
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("超时后fn的ctx应结束")
+	}
+}
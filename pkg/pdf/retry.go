@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // RetryConfig 重试配置
@@ -14,6 +17,8 @@ type RetryConfig struct {
 	MaxDelay      time.Duration // 最大延迟
 	BackoffFactor float64       // 退避因子
 	Timeout       time.Duration // 总超时时间
+	Clock         clock.Clock   // 等待重试使用的时钟，nil时使用系统时钟
+	Policy        *RetryPolicy  // 重试策略，设置时替代上面的次数和延迟字段；通常与错误处理器使用同一个策略
 }
 
 // DefaultRetryConfig 返回默认的重试配置
@@ -27,13 +32,30 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
+// retryPolicy 返回配置的重试策略；没有设置Policy时由次数和延迟字段构造，次数是所有错误类型的上限
+func (c *RetryConfig) retryPolicy() *RetryPolicy {
+	if c.Policy != nil {
+		return c.Policy
+	}
+	return &RetryPolicy{
+		DefaultMaxRetries: c.MaxRetries,
+		BackoffBase:       c.InitialDelay,
+		BackoffCap:        c.MaxDelay,
+		BackoffFactor:     c.BackoffFactor,
+	}
+}
+
 // RetryableOperation 可重试的操作函数类型
 type RetryableOperation func() error
 
-// RetryManager 重试管理器
+// RetryManager 重试管理器。是否重试由错误处理器按操作记录的失败次数决定，
+// 退避等待和次数上限来自配置的重试策略
 type RetryManager struct {
 	config       *RetryConfig
+	policy       *RetryPolicy
 	errorHandler ErrorHandler
+	clock        clock.Clock
+	operations   atomic.Int64 // 为每次执行生成操作名
 }
 
 // NewRetryManager 创建新的重试管理器
@@ -41,22 +63,34 @@ func NewRetryManager(config *RetryConfig, errorHandler ErrorHandler) *RetryManag
 	if config == nil {
 		config = DefaultRetryConfig()
 	}
+	policy := config.retryPolicy()
 	if errorHandler == nil {
-		errorHandler = NewDefaultErrorHandler(config.MaxRetries)
+		errorHandler = NewErrorHandlerWithPolicy(policy)
 	}
 
 	return &RetryManager{
 		config:       config,
+		policy:       policy,
 		errorHandler: errorHandler,
+		clock:        clock.OrSystem(config.Clock),
 	}
 }
 
+// newPolicyRetryManager 创建错误处理器和退避都使用policy的重试管理器，总超时与默认配置相同
+func newPolicyRetryManager(policy *RetryPolicy, clk clock.Clock) *RetryManager {
+	config := DefaultRetryConfig()
+	config.Policy = policy
+	config.Clock = clk
+	return NewRetryManager(config, NewErrorHandlerWithPolicy(policy))
+}
+
 // Execute 执行可重试的操作
 func (rm *RetryManager) Execute(operation RetryableOperation) error {
 	return rm.ExecuteWithContext(context.Background(), operation)
 }
 
-// ExecuteWithContext 带上下文的执行可重试操作
+// ExecuteWithContext 带上下文的执行可重试操作。每次执行单独记录失败次数，
+// 同时执行的操作互不影响；上下文在退避等待中取消时立即返回
 func (rm *RetryManager) ExecuteWithContext(ctx context.Context, operation RetryableOperation) error {
 	// 创建带超时的上下文
 	if rm.config.Timeout > 0 {
@@ -65,10 +99,10 @@ func (rm *RetryManager) ExecuteWithContext(ctx context.Context, operation Retrya
 		defer cancel()
 	}
 
-	var lastErr error
-	delay := rm.config.InitialDelay
+	name := fmt.Sprintf("retry-%d", rm.operations.Add(1))
+	defer rm.errorHandler.ResetAttempts(name)
 
-	for attempt := 0; attempt <= rm.config.MaxRetries; attempt++ {
+	for retry := 0; ; retry++ {
 		// 检查上下文是否已取消
 		select {
 		case <-ctx.Done():
@@ -82,35 +116,17 @@ func (rm *RetryManager) ExecuteWithContext(ctx context.Context, operation Retrya
 			return nil // 成功
 		}
 
-		// 处理错误
-		handledErr := rm.errorHandler.HandleError(err)
-		lastErr = handledErr
-
-		// 检查是否应该重试
-		if !rm.errorHandler.ShouldRetry(handledErr) {
+		// 处理错误并检查是否应该重试
+		handledErr, shouldRetry := rm.errorHandler.HandleOperationError(name, err)
+		if !shouldRetry || retry >= rm.policy.RetriesFor(handledErr) {
 			return handledErr
 		}
 
-		// 如果是最后一次尝试，不再延迟
-		if attempt == rm.config.MaxRetries {
-			break
-		}
-
-		// 等待重试延迟
-		select {
-		case <-ctx.Done():
-			return NewPDFError(ErrorIO, "操作超时或被取消", "", ctx.Err())
-		case <-time.After(delay):
-		}
-
-		// 计算下次延迟（指数退避）
-		delay = time.Duration(float64(delay) * rm.config.BackoffFactor)
-		if delay > rm.config.MaxDelay {
-			delay = rm.config.MaxDelay
+		// 等待重试延迟（指数退避）
+		if err := rm.policy.Wait(ctx, rm.clock, retry+1); err != nil {
+			return NewPDFError(ErrorIO, "操作超时或被取消", "", err)
 		}
 	}
-
-	return lastErr
 }
 
 // MemoryManager 内存管理器，用于处理内存不足的情况
@@ -189,10 +205,15 @@ type RecoveryManager struct {
 
 // NewRecoveryManager 创建新的恢复管理器
 func NewRecoveryManager(maxMemoryMB int64) *RecoveryManager {
+	return newRecoveryManager(maxMemoryMB, newPolicyRetryManager(DefaultRetryPolicy(), nil))
+}
+
+// newRecoveryManager 创建使用指定重试管理器的恢复管理器
+func newRecoveryManager(maxMemoryMB int64, retryManager *RetryManager) *RecoveryManager {
 	maxMemoryBytes := maxMemoryMB * 1024 * 1024
 
 	return &RecoveryManager{
-		retryManager:   NewRetryManager(DefaultRetryConfig(), NewDefaultErrorHandler(3)),
+		retryManager:   retryManager,
 		memoryManager:  NewMemoryManager(maxMemoryBytes),
 		errorCollector: NewErrorCollector(),
 	}
@@ -243,7 +264,7 @@ func (rm *RecoveryManager) tryMemoryRecovery() bool {
 	rm.memoryManager.ForceGC()
 
 	// 等待一小段时间让GC完成
-	time.Sleep(100 * time.Millisecond)
+	rm.retryManager.clock.Sleep(context.Background(), 100*time.Millisecond)
 
 	// 再次检查内存使用情况
 	err := rm.memoryManager.CheckMemoryUsage()
@@ -267,7 +288,7 @@ func (rm *RecoveryManager) tryErrorRecovery(err error) error {
 
 	case ErrorIO:
 		// IO错误：等待一段时间后重试
-		time.Sleep(500 * time.Millisecond)
+		rm.retryManager.clock.Sleep(context.Background(), 500*time.Millisecond)
 		return nil // 允许重试
 
 	case ErrorPermission:
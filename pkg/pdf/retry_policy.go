@@ -0,0 +1,84 @@
+package pdf
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// RetryPolicy 重试策略：每种错误类型最多重试几次，以及重试前的退避等待。
+// 错误处理器、重试管理器和写入器使用同一个策略，同一服务中各处的重试行为保持一致
+type RetryPolicy struct {
+	MaxRetries        map[ErrorType]int // 各错误类型的最大重试次数（不含第一次执行）
+	DefaultMaxRetries int               // 未在MaxRetries中列出的错误类型的最大重试次数
+	BackoffBase       time.Duration     // 第一次重试前的等待时间
+	BackoffCap        time.Duration     // 等待时间上限，0表示不限制
+	BackoffFactor     float64           // 每次重试后等待时间的倍数，不大于1时使用2
+	Jitter            bool              // 在等待时间的后一半内随机取值，避免多个操作同时重试
+}
+
+// DefaultRetryPolicy 返回默认的重试策略：IO和内存错误最多重试3次，其他错误不重试
+func DefaultRetryPolicy() *RetryPolicy {
+	return NewRetryPolicy(3)
+}
+
+// NewRetryPolicy 返回IO和内存错误最多重试maxRetries次的策略，退避从100ms开始翻倍，最长5s
+func NewRetryPolicy(maxRetries int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries: map[ErrorType]int{
+			ErrorIO:     maxRetries,
+			ErrorMemory: maxRetries,
+		},
+		BackoffBase:   100 * time.Millisecond,
+		BackoffCap:    5 * time.Second,
+		BackoffFactor: 2.0,
+	}
+}
+
+// RetriesFor 返回错误最多可重试的次数，不是PDFError的错误不重试
+func (p *RetryPolicy) RetriesFor(err error) int {
+	pdfErr, ok := err.(*PDFError)
+	if !ok {
+		return 0
+	}
+	if n, ok := p.MaxRetries[pdfErr.Type]; ok {
+		return n
+	}
+	return p.DefaultMaxRetries
+}
+
+// Backoff 返回第retry次重试（从1开始）前的等待时间。启用抖动时使用clk的随机源
+func (p *RetryPolicy) Backoff(retry int, clk clock.Clock) time.Duration {
+	factor := p.BackoffFactor
+	if factor <= 1.0 {
+		factor = 2.0
+	}
+
+	delay := p.BackoffBase
+	for i := 1; i < retry; i++ {
+		delay = time.Duration(float64(delay) * factor)
+		if p.BackoffCap > 0 && delay > p.BackoffCap {
+			delay = p.BackoffCap
+			break
+		}
+	}
+	if p.BackoffCap > 0 && delay > p.BackoffCap {
+		delay = p.BackoffCap
+	}
+
+	if p.Jitter && delay > 1 {
+		var buf [8]byte
+		if _, err := clock.OrSystem(clk).RandRead(buf[:]); err == nil {
+			half := delay / 2
+			delay = half + time.Duration(binary.BigEndian.Uint64(buf[:])%uint64(delay-half+1))
+		}
+	}
+	return delay
+}
+
+// Wait 等待第retry次重试前的退避时间；上下文在等待中取消时立即返回上下文的错误
+func (p *RetryPolicy) Wait(ctx context.Context, clk clock.Clock, retry int) error {
+	return clock.OrSystem(clk).Sleep(ctx, p.Backoff(retry, clk))
+}
@@ -0,0 +1,180 @@
+package pdf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{BackoffBase: 100 * time.Millisecond, BackoffCap: 300 * time.Millisecond, BackoffFactor: 2.0}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range expected {
+		if got := policy.Backoff(i+1, nil); got != want {
+			t.Errorf("Expected backoff %v before retry %d, got %v", want, i+1, got)
+		}
+	}
+
+	// 抖动落在等待时间的后一半内，相同种子的时钟得到相同的结果
+	policy.Jitter = true
+	first := policy.Backoff(2, clock.NewFake(time.Unix(0, 0), 7))
+	second := policy.Backoff(2, clock.NewFake(time.Unix(0, 0), 7))
+	if first < 100*time.Millisecond || first > 200*time.Millisecond {
+		t.Errorf("Expected jittered backoff between 100ms and 200ms, got %v", first)
+	}
+	if first != second {
+		t.Errorf("Expected the same jitter for the same seed, got %v and %v", first, second)
+	}
+}
+
+func TestRetryPolicy_RetriesFor(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: map[ErrorType]int{ErrorIO: 4}, DefaultMaxRetries: 1}
+
+	if n := policy.RetriesFor(NewPDFError(ErrorIO, "io", "", nil)); n != 4 {
+		t.Errorf("Expected 4 retries for IO errors, got %d", n)
+	}
+	if n := policy.RetriesFor(NewPDFError(ErrorProcessing, "processing", "", nil)); n != 1 {
+		t.Errorf("Expected default retries for unlisted types, got %d", n)
+	}
+	if n := policy.RetriesFor(errors.New("plain")); n != 0 {
+		t.Errorf("Expected no retries for non-PDF errors, got %d", n)
+	}
+}
+
+func TestDefaultErrorHandler_AttemptsPerOperation(t *testing.T) {
+	handler := NewErrorHandlerWithPolicy(&RetryPolicy{MaxRetries: map[ErrorType]int{ErrorIO: 2}})
+	ioErr := NewPDFError(ErrorIO, "io", "", nil)
+
+	for i := 0; i < 2; i++ {
+		if _, retry := handler.HandleOperationError("merge", ioErr); !retry {
+			t.Fatalf("Expected failure %d of merge to be retried", i+1)
+		}
+	}
+	// 另一个操作的失败次数单独计算
+	if _, retry := handler.HandleOperationError("validate", ioErr); !retry {
+		t.Error("Expected the first failure of another operation to be retried")
+	}
+	if _, retry := handler.HandleOperationError("merge", ioErr); retry {
+		t.Error("Expected merge to stop after 2 retries")
+	}
+	if n := handler.Attempts("merge"); n != 0 {
+		t.Errorf("Expected attempts to be cleared after giving up, got %d", n)
+	}
+
+	handler.ResetAttempts("validate")
+	if n := handler.Attempts("validate"); n != 0 {
+		t.Errorf("Expected attempts to be reset, got %d", n)
+	}
+}
+
+func TestRetryManager_Policy_PermissionErrorNotRetried(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	policy := DefaultRetryPolicy()
+	rm := newPolicyRetryManager(policy, fake)
+
+	calls := 0
+	err := rm.Execute(func() error {
+		calls++
+		return NewPDFError(ErrorPermission, "permission denied", "out.pdf", nil)
+	})
+
+	var pdfErr *PDFError
+	if !errors.As(err, &pdfErr) || pdfErr.Type != ErrorPermission {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected permission error not to be retried, got %d calls", calls)
+	}
+	if sleeps := fake.Sleeps(); len(sleeps) != 0 {
+		t.Errorf("Expected no backoff, got %v", sleeps)
+	}
+}
+
+func TestRetryManager_Policy_IOErrorRetriedWithBackoff(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	policy := &RetryPolicy{
+		MaxRetries:    map[ErrorType]int{ErrorIO: 3},
+		BackoffBase:   time.Second,
+		BackoffCap:    3 * time.Second,
+		BackoffFactor: 2.0,
+	}
+	rm := newPolicyRetryManager(policy, fake)
+
+	calls := 0
+	err := rm.Execute(func() error {
+		calls++
+		if calls < 4 {
+			return NewPDFError(ErrorIO, "temporary failure", "out.pdf", nil)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected operation to succeed on the last retry, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	sleeps := fake.Sleeps()
+	if len(sleeps) != len(expected) {
+		t.Fatalf("Expected backoff %v, got %v", expected, sleeps)
+	}
+	for i := range expected {
+		if sleeps[i] != expected[i] {
+			t.Fatalf("Expected backoff %v, got %v", expected, sleeps)
+		}
+	}
+}
+
+func TestRetryManager_Policy_CancelDuringBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: map[ErrorType]int{ErrorIO: 3}, BackoffBase: time.Hour}
+	rm := newPolicyRetryManager(policy, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- rm.ExecuteWithContext(ctx, func() error {
+			calls++
+			// 第一次失败后，在退避等待期间取消
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return NewPDFError(ErrorIO, "temporary failure", "out.pdf", nil)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected cancellation to interrupt the backoff")
+	}
+	if calls != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d calls", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to return soon after cancellation, took %v", elapsed)
+	}
+}
+
+func TestPDFServiceImpl_RetryPolicyFromConfig(t *testing.T) {
+	policy := &RetryPolicy{MaxRetries: map[ErrorType]int{ErrorIO: 5}}
+	service := NewPDFServiceWithConfig(&ServiceConfig{RetryPolicy: policy})
+
+	retrying := NewServiceWithRetry(service, 100)
+	if retrying.retryManager.policy != policy {
+		t.Error("Expected the retry service to use the policy from ServiceConfig")
+	}
+	if retrying.recoveryManager.retryManager != retrying.retryManager {
+		t.Error("Expected merge recovery to share the retry manager")
+	}
+}
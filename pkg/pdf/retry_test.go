@@ -6,6 +6,8 @@ import (
 	"runtime"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestDefaultRetryConfig(t *testing.T) {
@@ -131,38 +133,71 @@ func TestRetryManager_ExecuteWithContext_Timeout(t *testing.T) {
 }
 
 func TestRetryManager_ExecuteWithContext_Cancellation(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0), 1)
 	config := &RetryConfig{
 		MaxRetries:    5,
 		InitialDelay:  50 * time.Millisecond,
 		MaxDelay:      200 * time.Millisecond,
 		BackoffFactor: 2.0,
+		Clock:         fake,
 	}
 
 	rm := NewRetryManager(config, NewDefaultErrorHandler(5))
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// 100ms后取消上下文
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-		cancel()
-	}()
-
+	// 第二次失败后取消上下文
+	callCount := 0
 	operation := func() error {
+		callCount++
+		if callCount == 2 {
+			cancel()
+		}
 		return NewPDFError(ErrorIO, "operation", "test.pdf", nil)
 	}
 
-	start := time.Now()
 	err := rm.ExecuteWithContext(ctx, operation)
-	duration := time.Since(start)
-
 	if err == nil {
 		t.Error("Expected operation to fail due to cancellation")
 	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("Expected no retries after cancellation, got %d calls", callCount)
+	}
+	if sleeps := fake.Sleeps(); len(sleeps) != 1 || sleeps[0] != 50*time.Millisecond {
+		t.Errorf("Expected a single 50ms backoff before cancellation, got %v", sleeps)
+	}
+}
+
+func TestRetryManager_BackoffUsesClock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	config := &RetryConfig{
+		MaxRetries:    4,
+		InitialDelay:  time.Second,
+		MaxDelay:      3 * time.Second,
+		BackoffFactor: 2.0,
+		Clock:         fake,
+	}
 
-	// 应该在取消时间附近结束
-	if duration > 150*time.Millisecond {
-		t.Errorf("Operation took too long after cancellation: %v", duration)
+	rm := NewRetryManager(config, NewDefaultErrorHandler(4))
+	err := rm.Execute(func() error {
+		return NewPDFError(ErrorIO, "persistent failure", "test.pdf", nil)
+	})
+	if err == nil {
+		t.Fatal("Expected operation to fail after max retries")
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	sleeps := fake.Sleeps()
+	if len(sleeps) != len(expected) {
+		t.Fatalf("Expected backoff %v, got %v", expected, sleeps)
+	}
+	for i := range expected {
+		if sleeps[i] != expected[i] {
+			t.Fatalf("Expected backoff %v, got %v", expected, sleeps)
+		}
 	}
 }
 
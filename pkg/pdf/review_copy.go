@@ -0,0 +1,426 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// 页面警告类别
+const (
+	WarningRepairedContent   = "repaired-content"
+	WarningDroppedAnnotation = "dropped-annotation"
+	WarningImageOnlyPage     = "image-only-page"
+	WarningGeneral           = "general"
+)
+
+// ReviewCopySuffix 审阅副本文件名后缀
+const ReviewCopySuffix = "_review.pdf"
+
+// PageWarning 可归属到页面的合并警告
+type PageWarning struct {
+	Page    int    `json:"page"` // 合并输出中的页码（从1开始），0表示无法归属到具体页面
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"` // 来源文件
+}
+
+var (
+	sizePattern      = regexp.MustCompile(`/Size\s+(\d+)`)
+	startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)`)
+	countPattern     = regexp.MustCompile(`/Count\s+\d+`)
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	refPattern       = regexp.MustCompile(`^\s*(\d+)\s+\d+\s+R`)
+)
+
+// ReviewCopyPath 返回输出文件对应的审阅副本路径
+func ReviewCopyPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ReviewCopySuffix
+}
+
+// DetectPageWarnings 检查合并输出中值得审阅的页面（目前为仅含图像的页面）
+func DetectPageWarnings(filePath string) ([]PageWarning, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := indexObjects(data)
+	var warnings []PageWarning
+	for i, objNum := range stats.Pages {
+		body, _ := objectBody(data, offsets, objNum)
+		resources := resolveDict(data, offsets, body, "/Resources")
+		// 启发式：有XObject而没有字体的页面视为仅含图像（如扫描页）
+		if resources != nil && bytes.Contains(resources, []byte("/XObject")) && !bytes.Contains(resources, []byte("/Font")) {
+			warnings = append(warnings, PageWarning{
+				Page:    i + 1,
+				Kind:    WarningImageOnlyPage,
+				Message: "页面仅包含图像，没有可选择的文本",
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// WriteReviewCopy 在主输出旁生成审阅副本：对主输出做增量更新，为有警告的页面添加便签注释，
+// 无法归属页面的警告放在新增的首页摘要中。主输出文件不会被修改。
+// 存在摘要页时，原第N页在审阅副本中为第N+1页。
+func WriteReviewCopy(outputPath string, warnings []PageWarning) (string, error) {
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取合并输出",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法为加密的输出生成审阅副本",
+			File:    outputPath,
+		}
+	}
+
+	stats, err := WalkPageTree(outputPath, data, nil)
+	if err != nil {
+		return "", err
+	}
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return "", &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+
+	// 按页分组，超出范围的警告视为无法归属
+	byPage := make(map[int][]PageWarning)
+	var unattributed []PageWarning
+	for _, w := range warnings {
+		if w.Page >= 1 && w.Page <= len(stats.Pages) {
+			byPage[w.Page] = append(byPage[w.Page], w)
+		} else {
+			unattributed = append(unattributed, w)
+		}
+	}
+
+	update := newIncrementalUpdate(data, offsets)
+
+	pageNums := make([]int, 0, len(byPage))
+	for page := range byPage {
+		pageNums = append(pageNums, page)
+	}
+	sort.Ints(pageNums)
+
+	for _, page := range pageNums {
+		objNum := stats.Pages[page-1]
+		body, _ := objectBody(data, offsets, objNum)
+		annots := update.addNotes(objNum, pageHeight(body), byPage[page])
+		update.set(objNum, withAnnots(data, offsets, body, annots))
+	}
+
+	if len(unattributed) > 0 {
+		rootBody, _ := objectBody(data, offsets, rootNum)
+		summaryNum := update.addSummaryPage(rootNum, unattributed)
+		update.set(rootNum, withPrependedKid(rootBody, summaryNum, len(stats.Pages)+1))
+	}
+
+	reviewPath := ReviewCopyPath(outputPath)
+	if err := os.WriteFile(reviewPath, update.bytes(), 0644); err != nil {
+		return "", &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入审阅副本",
+			File:    reviewPath,
+			Cause:   err,
+		}
+	}
+	return reviewPath, nil
+}
+
+// incrementalUpdate 收集增量更新中新增或替换的对象
+type incrementalUpdate struct {
+	data    []byte
+	offsets map[int]int
+	nextNum int
+	objects map[int]string
+	info    int // 新的文档信息字典编号，0时沿用原trailer中的 /Info
+}
+
+// newIncrementalUpdate 创建增量更新，新对象编号从现有最大编号之后开始
+func newIncrementalUpdate(data []byte, offsets map[int]int) *incrementalUpdate {
+	next := 1
+	for num := range offsets {
+		if num >= next {
+			next = num + 1
+		}
+	}
+	if matches := sizePattern.FindAllSubmatch(data, -1); len(matches) > 0 {
+		if size, err := strconv.Atoi(string(matches[len(matches)-1][1])); err == nil && size > next {
+			next = size
+		}
+	}
+	return &incrementalUpdate{
+		data:    data,
+		offsets: offsets,
+		nextNum: next,
+		objects: make(map[int]string),
+	}
+}
+
+// add 添加新对象并返回其编号
+func (u *incrementalUpdate) add(body string) int {
+	num := u.nextNum
+	u.nextNum++
+	u.objects[num] = body
+	return num
+}
+
+// set 替换已有对象
+func (u *incrementalUpdate) set(num int, body string) {
+	u.objects[num] = body
+}
+
+// addNotes 为页面创建便签注释对象，从页面左上角向下排列
+func (u *incrementalUpdate) addNotes(pageNum int, height float64, warnings []PageWarning) []int {
+	refs := make([]int, 0, len(warnings))
+	for i, w := range warnings {
+		top := height - 36 - float64(i)*30
+		if top < 24 {
+			top = 24
+		}
+		refs = append(refs, u.add(fmt.Sprintf(
+			"<< /Type /Annot /Subtype /Text /Rect [12 %.0f 36 %.0f] /P %d 0 R /Name /Comment /Open false /T %s /Contents %s >>",
+			top-24, top, pageNum, pdfTextString("PDF Merger"), pdfTextString(describePageWarning(w)))))
+	}
+	return refs
+}
+
+// addSummaryPage 创建列出无法归属页面的警告的摘要页
+func (u *incrementalUpdate) addSummaryPage(parentNum int, warnings []PageWarning) int {
+	var content bytes.Buffer
+	content.WriteString("BT /F1 14 Tf 50 740 Td (Merge warnings without page attribution) Tj ET\n")
+	for i, w := range warnings {
+		line := fmt.Sprintf("%d. %s", i+1, describePageWarning(w))
+		fmt.Fprintf(&content, "BT /F1 10 Tf 50 %d Td (%s) Tj ET\n", 710-i*16, escapePDFLiteral(asciiOnly(line)))
+	}
+	contentNum := u.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	fontNum := u.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	// 先预留页面编号，注释需要通过 /P 引用页面
+	pageNum := u.add("")
+	annots := u.addNotes(pageNum, 792, warnings)
+	u.set(pageNum, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R /Annots [%s] >>",
+		parentNum, fontNum, contentNum, refList(annots)))
+	return pageNum
+}
+
+// bytes 返回原始内容加上增量更新部分（新对象、交叉引用表和trailer）
+func (u *incrementalUpdate) bytes() []byte {
+	if len(u.objects) == 0 {
+		return u.data
+	}
+
+	var out bytes.Buffer
+	out.Write(u.data)
+	if len(u.data) > 0 && u.data[len(u.data)-1] != '\n' {
+		out.WriteByte('\n')
+	}
+
+	nums := make([]int, 0, len(u.objects))
+	for num := range u.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	positions := make(map[int]int, len(nums))
+	for _, num := range nums {
+		positions[num] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", num, u.objects[num])
+	}
+
+	xrefOffset := out.Len()
+	out.WriteString("xref\n")
+	for _, num := range nums {
+		fmt.Fprintf(&out, "%d 1\n%010d 00000 n \n", num, positions[num])
+	}
+
+	rootMatches := rootRefPattern.FindAllSubmatch(u.data, -1)
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %s 0 R", u.nextNum, rootMatches[len(rootMatches)-1][1])
+	info := u.info
+	if info == 0 {
+		info = refNumber(infoRefPattern, u.data)
+	}
+	if info > 0 {
+		fmt.Fprintf(&out, " /Info %d 0 R", info)
+	}
+	if matches := startxrefPattern.FindAllSubmatch(u.data, -1); len(matches) > 0 {
+		fmt.Fprintf(&out, " /Prev %s", matches[len(matches)-1][1])
+	}
+	fmt.Fprintf(&out, " >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes()
+}
+
+// withAnnots 返回添加了注释引用的页面对象内容，保留已有注释
+func withAnnots(data []byte, offsets map[int]int, body []byte, annots []int) string {
+	refs := refList(annots)
+	page := string(bytes.TrimSpace(body))
+
+	idx := strings.Index(page, "/Annots")
+	if idx < 0 {
+		return strings.Replace(page, "<<", "<< /Annots ["+refs+"]", 1)
+	}
+
+	rest := page[idx+len("/Annots"):]
+	if m := refPattern.FindStringSubmatchIndex(rest); m != nil {
+		// 间接引用的注释数组：合并为内联数组
+		num, _ := strconv.Atoi(rest[m[2]:m[3]])
+		existing := ""
+		if arr, ok := objectBody(data, offsets, num); ok {
+			arrStr := strings.TrimSpace(string(arr))
+			existing = strings.TrimSuffix(strings.TrimPrefix(arrStr, "["), "]")
+		}
+		return page[:idx] + "/Annots [" + existing + " " + refs + "]" + rest[m[1]:]
+	}
+
+	end := strings.Index(rest, "]")
+	if end < 0 {
+		return page
+	}
+	return page[:idx+len("/Annots")] + rest[:end] + " " + refs + rest[end:]
+}
+
+// withPrependedKid 返回在Kids开头插入新页面并更新Count的页面树根内容
+func withPrependedKid(body []byte, kid, count int) string {
+	root := string(bytes.TrimSpace(body))
+	idx := strings.Index(root, "/Kids")
+	if idx >= 0 {
+		if open := strings.Index(root[idx:], "["); open >= 0 {
+			pos := idx + open + 1
+			root = root[:pos] + fmt.Sprintf("%d 0 R ", kid) + root[pos:]
+		}
+	}
+	return countPattern.ReplaceAllString(root, fmt.Sprintf("/Count %d", count))
+}
+
+// resolveDict 返回对象中key对应的字典内容，支持内联字典和间接引用
+func resolveDict(data []byte, offsets map[int]int, body []byte, key string) []byte {
+	return resolveDictWith(body, key, func(num int) ([]byte, bool) {
+		return objectBody(data, offsets, num)
+	})
+}
+
+// resolveDictWith 与 resolveDict 相同，间接引用的对象由lookup读取
+func resolveDictWith(body []byte, key string, lookup func(num int) ([]byte, bool)) []byte {
+	idx := bytes.Index(body, []byte(key))
+	if idx < 0 {
+		return nil
+	}
+	rest := body[idx+len(key):]
+	if m := refPattern.FindSubmatch(rest); m != nil {
+		num, _ := strconv.Atoi(string(m[1]))
+		obj, ok := lookup(num)
+		if !ok {
+			return nil
+		}
+		return obj
+	}
+
+	start := bytes.Index(rest, []byte("<<"))
+	if start < 0 {
+		return nil
+	}
+	depth := 0
+	for i := start; i+1 < len(rest); i++ {
+		switch {
+		case rest[i] == '<' && rest[i+1] == '<':
+			depth++
+			i++
+		case rest[i] == '>' && rest[i+1] == '>':
+			depth--
+			i++
+			if depth == 0 {
+				return rest[start : i+1]
+			}
+		}
+	}
+	return nil
+}
+
+// pageHeight 返回页面MediaBox高度，无法确定时使用Letter尺寸
+func pageHeight(body []byte) float64 {
+	m := mediaBoxPattern.FindSubmatch(body)
+	if m == nil {
+		return 792
+	}
+	lly, err1 := strconv.ParseFloat(string(m[2]), 64)
+	ury, err2 := strconv.ParseFloat(string(m[4]), 64)
+	if err1 != nil || err2 != nil || ury <= lly {
+		return 792
+	}
+	return ury - lly
+}
+
+// describePageWarning 生成注释文本
+func describePageWarning(w PageWarning) string {
+	if w.File != "" {
+		return fmt.Sprintf("[%s] %s (%s)", w.Kind, w.Message, filepath.Base(w.File))
+	}
+	return fmt.Sprintf("[%s] %s", w.Kind, w.Message)
+}
+
+// refList 生成间接引用列表
+func refList(nums []int) string {
+	refs := make([]string, len(nums))
+	for i, num := range nums {
+		refs[i] = fmt.Sprintf("%d 0 R", num)
+	}
+	return strings.Join(refs, " ")
+}
+
+// pdfTextString 将文本编码为UTF-16BE十六进制字符串，支持中文
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, c := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", c)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// escapePDFLiteral 转义PDF字面字符串中的特殊字符
+func escapePDFLiteral(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// asciiOnly 将非ASCII字符替换为问号，标准字体无法显示这些字符
+func asciiOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
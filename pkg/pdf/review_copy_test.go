@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildReviewFixture 生成三页PDF：第2页仅含图像，第3页已有一个注释
+func buildReviewFixture() []byte {
+	return buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /XObject << /Im1 8 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [6 0 R] /Resources << /Font << /F1 7 0 R >> >> >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /XObject /Subtype /Image /Width 1 /Height 1 /Length 0 >>",
+	})
+}
+
+// pageAnnotContents 返回审阅副本中指定页面（从1开始）所有文本注释的内容
+func pageAnnotContents(t *testing.T, data []byte, page int) (refs int, contents []string) {
+	t.Helper()
+	stats, err := WalkPageTree("review.pdf", data, nil)
+	if err != nil {
+		t.Fatalf("遍历审阅副本失败: %v", err)
+	}
+	offsets := indexObjects(data)
+	body, _ := objectBody(data, offsets, stats.Pages[page-1])
+	idx := bytes.Index(body, []byte("/Annots"))
+	if idx < 0 {
+		return 0, nil
+	}
+	for _, num := range parseArrayRefs(body[idx+len("/Annots"):]) {
+		refs++
+		annot, ok := objectBody(data, offsets, num)
+		if !ok {
+			t.Fatalf("注释对象 %d 不存在", num)
+		}
+		if bytes.Contains(annot, []byte("/Subtype /Text")) {
+			contents = append(contents, string(annot))
+		}
+	}
+	return refs, contents
+}
+
+func TestDetectPageWarnings_ImageOnlyPage(t *testing.T) {
+	file := createTestFile(t, t.TempDir(), "merged.pdf", buildReviewFixture())
+
+	warnings, err := DetectPageWarnings(file)
+	if err != nil {
+		t.Fatalf("检测页面警告失败: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Page != 2 || warnings[0].Kind != WarningImageOnlyPage {
+		t.Errorf("期望第2页的仅图像警告，实际: %+v", warnings)
+	}
+}
+
+func TestWriteReviewCopy_AnnotatesAttributedPages(t *testing.T) {
+	original := buildReviewFixture()
+	outputPath := createTestFile(t, t.TempDir(), "merged.pdf", original)
+
+	warnings := []PageWarning{
+		{Page: 2, Kind: WarningImageOnlyPage, Message: "页面仅包含图像"},
+		{Page: 3, Kind: WarningDroppedAnnotation, Message: "dropped widget", File: "/in/b.pdf"},
+	}
+	reviewPath, err := WriteReviewCopy(outputPath, warnings)
+	if err != nil {
+		t.Fatalf("生成审阅副本失败: %v", err)
+	}
+	if reviewPath != filepath.Join(filepath.Dir(outputPath), "merged_review.pdf") {
+		t.Errorf("审阅副本路径不符合预期: %s", reviewPath)
+	}
+
+	// 主输出保持不变
+	if current, _ := os.ReadFile(outputPath); !bytes.Equal(current, original) {
+		t.Error("主输出不应被修改")
+	}
+
+	review, err := os.ReadFile(reviewPath)
+	if err != nil {
+		t.Fatalf("读取审阅副本失败: %v", err)
+	}
+	if !bytes.HasPrefix(review, original) {
+		t.Error("审阅副本应为主输出的增量更新")
+	}
+
+	if refs, _ := pageAnnotContents(t, review, 1); refs != 0 {
+		t.Errorf("第1页不应有注释，实际 %d 个", refs)
+	}
+
+	_, notes := pageAnnotContents(t, review, 2)
+	if len(notes) != 1 || !strings.Contains(notes[0], pdfTextString("[image-only-page] 页面仅包含图像")) {
+		t.Errorf("第2页注释不符合预期: %v", notes)
+	}
+
+	refs, notes := pageAnnotContents(t, review, 3)
+	if refs != 2 || len(notes) != 1 {
+		t.Errorf("第3页应保留原注释并新增1个便签，实际引用 %d、便签 %d", refs, len(notes))
+	}
+	if len(notes) == 1 && !strings.Contains(notes[0], pdfTextString("[dropped-annotation] dropped widget (b.pdf)")) {
+		t.Errorf("第3页注释内容不符合预期: %s", notes[0])
+	}
+}
+
+func TestWriteReviewCopy_SummaryPageForUnattributedWarnings(t *testing.T) {
+	outputPath := createTestFile(t, t.TempDir(), "merged.pdf", buildReviewFixture())
+
+	reviewPath, err := WriteReviewCopy(outputPath, []PageWarning{
+		{Kind: WarningGeneral, Message: "跳过无效文件 c.pdf"},
+		{Page: 99, Kind: WarningRepairedContent, Message: "out of range"},
+		{Page: 1, Kind: WarningDroppedAnnotation, Message: "field dropped"},
+	})
+	if err != nil {
+		t.Fatalf("生成审阅副本失败: %v", err)
+	}
+	review, _ := os.ReadFile(reviewPath)
+
+	stats, err := WalkPageTree(reviewPath, review, nil)
+	if err != nil {
+		t.Fatalf("遍历审阅副本失败: %v", err)
+	}
+	if stats.PageCount != 4 {
+		t.Fatalf("期望摘要页加3页原内容，实际 %d 页", stats.PageCount)
+	}
+
+	if _, notes := pageAnnotContents(t, review, 1); len(notes) != 2 {
+		t.Errorf("摘要页应有2个便签，实际 %d", len(notes))
+	}
+	// 原第1页在审阅副本中为第2页
+	if _, notes := pageAnnotContents(t, review, 2); len(notes) != 1 {
+		t.Errorf("原第1页应有1个便签，实际 %d", len(notes))
+	}
+	if !bytes.Contains(review, []byte("/Count 4")) {
+		t.Error("页面树计数应更新为4")
+	}
+}
+
+func TestStreamingMerger_ProduceReviewCopy(t *testing.T) {
+	outputPath := createTestFile(t, t.TempDir(), "merged.pdf", buildReviewFixture())
+
+	merger := NewStreamingMerger(&MergeOptions{ReviewCopy: true, TempDirectory: t.TempDir()})
+	defer merger.Close()
+
+	result := &MergeResult{OutputPath: outputPath, Warnings: []string{"跳过无效文件 x.pdf"}}
+	merger.produceReviewCopy(result)
+
+	if result.ReviewCopyPath == "" {
+		t.Fatalf("应报告审阅副本路径，警告: %v", result.Warnings)
+	}
+	if len(result.PageWarnings) != 1 || result.PageWarnings[0].Page != 2 {
+		t.Errorf("应检测到第2页的仅图像警告，实际: %+v", result.PageWarnings)
+	}
+	if data, _ := os.ReadFile(outputPath); bytes.Contains(data, []byte("/Subtype /Text")) {
+		t.Error("主输出不应包含审阅注释")
+	}
+}
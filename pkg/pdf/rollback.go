@@ -1,46 +1,253 @@
 package pdf
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// 备份的默认位置和保留数量
+const (
+	// DefaultBackupDirectory 备份所在的子目录，相对于被备份文件所在的目录
+	DefaultBackupDirectory = ".pdf-merger-backups"
+	// DefaultBackupRetention 每个输出路径默认保留的备份数
+	DefaultBackupRetention = 5
 )
 
+// backupTimeLayout 备份文件名中的时间戳格式，按字典序排序即按时间排序
+const backupTimeLayout = "20060102T150405.000000000"
+
+// ErrNoBackup 输出没有可恢复的备份时 RestoreLatest 返回的错误，可用 errors.Is 判断
+var ErrNoBackup = errors.New("没有可恢复的备份")
+
+// RollbackConfig 备份的位置和保留策略
+type RollbackConfig struct {
+	// Directory 备份目录，相对路径相对于被备份文件所在的目录，为空时使用DefaultBackupDirectory
+	Directory string
+
+	// Keep 每个被备份路径保留的备份数，超出时删除最旧的备份；0或负数表示全部保留
+	Keep int
+
+	// Clock 备份时间戳和文件名后缀的来源，nil时使用系统时钟
+	Clock clock.Clock
+}
+
+// DefaultRollbackConfig 返回默认配置：备份保存在DefaultBackupDirectory子目录，每个路径保留DefaultBackupRetention份
+func DefaultRollbackConfig() *RollbackConfig {
+	return &RollbackConfig{
+		Directory: DefaultBackupDirectory,
+		Keep:      DefaultBackupRetention,
+	}
+}
+
+// backupConfig 按合并选项的约定构造备份配置：retention为0时使用默认保留数，负数表示全部保留
+func backupConfig(directory string, retention int, clk clock.Clock) *RollbackConfig {
+	config := DefaultRollbackConfig()
+	if directory != "" {
+		config.Directory = directory
+	}
+	switch {
+	case retention > 0:
+		config.Keep = retention
+	case retention < 0:
+		config.Keep = 0
+	}
+	config.Clock = clk
+	return config
+}
+
+// BackupInfo 一份备份的位置、创建时间和大小
+type BackupInfo struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
 // RollbackManager 回滚与恢复管理器
-// 支持文件级备份、恢复、回滚
+// 支持文件级备份、恢复、回滚。备份保存在专用目录中，文件名包含被备份路径的摘要和时间戳，
+// 每次备份的名称都不同，并发备份同一文件不会互相覆盖。
 
 type RollbackManager struct {
-	backupDir string
-	mutex     sync.Mutex
+	baseDir string
+	config  RollbackConfig
 }
 
-// NewRollbackManager 创建回滚管理器
+// backupDirLocks 各备份目录的锁，同一目录的备份、清理和恢复在进程内串行执行，
+// 多个管理器（例如并发的合并各自创建的管理器）共用同一把锁
+var backupDirLocks sync.Map
+
+// NewRollbackManager 创建回滚管理器，备份保存在backupDir下的DefaultBackupDirectory子目录，全部保留
 func NewRollbackManager(backupDir string) *RollbackManager {
-	return &RollbackManager{backupDir: backupDir}
+	return NewRollbackManagerWithConfig(backupDir, &RollbackConfig{Directory: DefaultBackupDirectory})
+}
+
+// NewRollbackManagerWithConfig 使用配置创建回滚管理器，相对的备份目录相对于baseDir；config为nil时使用默认配置
+func NewRollbackManagerWithConfig(baseDir string, config *RollbackConfig) *RollbackManager {
+	if config == nil {
+		config = DefaultRollbackConfig()
+	}
+	rm := &RollbackManager{baseDir: baseDir, config: *config}
+	if rm.config.Directory == "" {
+		rm.config.Directory = DefaultBackupDirectory
+	}
+	rm.config.Clock = clock.OrSystem(rm.config.Clock)
+	return rm
+}
+
+// BackupDir 返回备份所在的目录
+func (rm *RollbackManager) BackupDir() string {
+	if filepath.IsAbs(rm.config.Directory) {
+		return rm.config.Directory
+	}
+	return filepath.Join(rm.baseDir, rm.config.Directory)
 }
 
-// BackupFile 备份文件，返回备份路径
+// lock 锁定备份目录，返回解锁函数
+func (rm *RollbackManager) lock() func() {
+	value, _ := backupDirLocks.LoadOrStore(filepath.Clean(rm.BackupDir()), &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// BackupFile 备份文件，返回备份路径。备份后按保留数删除该文件较旧的备份
 func (rm *RollbackManager) BackupFile(filePath string) (string, error) {
-	rm.mutex.Lock()
-	defer rm.mutex.Unlock()
+	unlock := rm.lock()
+	defer unlock()
 
 	if _, err := os.Stat(filePath); err != nil {
 		return "", fmt.Errorf("待备份文件不存在: %s", filePath)
 	}
-	backupName := filepath.Base(filePath) + ".bak"
-	backupPath := filepath.Join(rm.backupDir, backupName)
+	if err := os.MkdirAll(rm.BackupDir(), 0755); err != nil {
+		return "", fmt.Errorf("备份失败: %v", err)
+	}
+
+	now := rm.config.Clock.Now()
+	prefix := backupPrefix(filePath)
+	var backupPath string
+	for {
+		backupName := prefix + now.UTC().Format(backupTimeLayout) + "-" + randomSuffix(rm.config.Clock) + ".bak"
+		backupPath = filepath.Join(rm.BackupDir(), backupName)
+		if !fileExists(backupPath) {
+			break
+		}
+	}
 	if err := copyFileForRollback(filePath, backupPath); err != nil {
+		os.Remove(backupPath)
 		return "", fmt.Errorf("备份失败: %v", err)
 	}
+
+	rm.prune(filePath)
 	return backupPath, nil
 }
 
+// ListBackups 返回filePath的全部备份，最新的在前；还没有备份时返回空列表
+func (rm *RollbackManager) ListBackups(filePath string) ([]BackupInfo, error) {
+	unlock := rm.lock()
+	defer unlock()
+	return rm.listBackups(filePath)
+}
+
+// listBackups 列出备份（调用方需持有目录锁）
+func (rm *RollbackManager) listBackups(filePath string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(rm.BackupDir())
+	if os.IsNotExist(err) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法读取备份目录: %v", err)
+	}
+
+	prefix := backupPrefix(filePath)
+	backups := make([]BackupInfo, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".bak") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".bak")
+		if i := strings.LastIndex(stamp, "-"); i >= 0 {
+			stamp = stamp[:i]
+		}
+		created, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{
+			Path: filepath.Join(rm.BackupDir(), name),
+			Time: created,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].Path > backups[j].Path
+	})
+	return backups, nil
+}
+
+// prune 删除超出保留数的旧备份（调用方需持有目录锁）
+func (rm *RollbackManager) prune(filePath string) {
+	if rm.config.Keep <= 0 {
+		return
+	}
+	backups, err := rm.listBackups(filePath)
+	if err != nil || len(backups) <= rm.config.Keep {
+		return
+	}
+	for _, backup := range backups[rm.config.Keep:] {
+		os.Remove(backup.Path)
+	}
+}
+
+// RestoreLatest 用最新的备份替换filePath并返回所用的备份，备份本身保留。
+// 没有备份时返回包装了ErrNoBackup的错误
+func (rm *RollbackManager) RestoreLatest(filePath string) (*BackupInfo, error) {
+	unlock := rm.lock()
+	defer unlock()
+
+	backups, err := rm.listBackups(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoBackup, filePath)
+	}
+
+	// 先复制到同目录的临时文件再替换，恢复中途失败时原文件保持不变
+	latest := backups[0]
+	staging := stagingPath(filePath, rm.config.Clock)
+	if err := copyFileForRollback(latest.Path, staging); err != nil {
+		os.Remove(staging)
+		return nil, fmt.Errorf("恢复失败: %v", err)
+	}
+	if err := os.Rename(staging, filePath); err != nil {
+		os.Remove(staging)
+		return nil, fmt.Errorf("恢复失败: %v", err)
+	}
+	return &latest, nil
+}
+
 // RestoreFile 恢复文件（用备份覆盖原文件）
 func (rm *RollbackManager) RestoreFile(backupPath, targetPath string) error {
-	rm.mutex.Lock()
-	defer rm.mutex.Unlock()
+	unlock := rm.lock()
+	defer unlock()
 	if _, err := os.Stat(backupPath); err != nil {
 		return fmt.Errorf("备份文件不存在: %s", backupPath)
 	}
@@ -61,6 +268,13 @@ func (rm *RollbackManager) RollbackIfFailed(targetPath string, op func() error)
 	return nil
 }
 
+// backupPrefix 返回filePath的备份文件名前缀：文件名加绝对路径摘要，
+// 不同目录下的同名文件共用备份目录时不会混在一起
+func backupPrefix(filePath string) string {
+	sum := sha256.Sum256([]byte(absPath(filePath)))
+	return filepath.Base(filePath) + "." + hex.EncodeToString(sum[:4]) + "."
+}
+
 // copyFileForRollback 回滚专用文件复制函数
 func copyFileForRollback(src, dst string) error {
 	srcFile, err := os.Open(src)
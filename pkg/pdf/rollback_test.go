@@ -2,12 +2,16 @@ package pdf
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 func TestRollbackManager_Basic(t *testing.T) {
@@ -372,9 +376,10 @@ startxref
 		t.Fatalf("创建PDF2失败: %v", err)
 	}
 
-	// 创建合并器
+	// 创建合并器，两个输入内容相同，需要允许重复输入
 	merger := NewStreamingMerger(&MergeOptions{
-		TempDirectory: tempDir,
+		TempDirectory:   tempDir,
+		AllowDuplicates: true,
 	})
 
 	// 测试合并成功
@@ -444,3 +449,180 @@ func TestRollbackManager_Performance(t *testing.T) {
 		t.Errorf("文件大小不匹配，期望: %d, 实际: %d", len(largeContent), len(restoredContent))
 	}
 }
+
+func TestRollbackManager_BackupsInSubdirectoryWithRetention(t *testing.T) {
+	tempDir := t.TempDir()
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1)
+	rollbackMgr := NewRollbackManagerWithConfig(tempDir, &RollbackConfig{Keep: 3, Clock: clk})
+
+	output := filepath.Join(tempDir, "out.pdf")
+	for i := 0; i < 5; i++ {
+		if err := ioutil.WriteFile(output, []byte(fmt.Sprintf("版本%d", i)), 0644); err != nil {
+			t.Fatalf("写入输出失败: %v", err)
+		}
+		backupPath, err := rollbackMgr.BackupFile(output)
+		if err != nil {
+			t.Fatalf("备份失败: %v", err)
+		}
+		if filepath.Dir(backupPath) != filepath.Join(tempDir, DefaultBackupDirectory) {
+			t.Errorf("备份应保存在备份目录，实际: %s", backupPath)
+		}
+		clk.Advance(time.Second)
+	}
+
+	siblings, _ := filepath.Glob(filepath.Join(tempDir, "*.bak"))
+	if len(siblings) != 0 {
+		t.Errorf("输出目录不应留下备份文件: %v", siblings)
+	}
+
+	backups, err := rollbackMgr.ListBackups(output)
+	if err != nil {
+		t.Fatalf("列出备份失败: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("期望保留3份备份，实际 %d 份", len(backups))
+	}
+	for i, backup := range backups {
+		data, err := ioutil.ReadFile(backup.Path)
+		if err != nil {
+			t.Fatalf("读取备份失败: %v", err)
+		}
+		want := fmt.Sprintf("版本%d", 4-i)
+		if string(data) != want {
+			t.Errorf("第%d份备份应为 %s，实际: %s", i, want, string(data))
+		}
+		if backup.Size != int64(len(want)) {
+			t.Errorf("备份大小不匹配，期望: %d, 实际: %d", len(want), backup.Size)
+		}
+		wantTime := time.Date(2024, 1, 2, 3, 4, 5+4-i, 0, time.UTC)
+		if !backup.Time.Equal(wantTime) {
+			t.Errorf("备份时间不匹配，期望: %v, 实际: %v", wantTime, backup.Time)
+		}
+	}
+}
+
+func TestRollbackManager_ListBackupsSeparatesOutputs(t *testing.T) {
+	tempDir := t.TempDir()
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	rollbackMgr := NewRollbackManagerWithConfig(tempDir, &RollbackConfig{Directory: backupDir})
+
+	first := filepath.Join(tempDir, "a", "out.pdf")
+	second := filepath.Join(tempDir, "b", "out.pdf")
+	for _, path := range []string{first, second} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatalf("写入输出失败: %v", err)
+		}
+		if _, err := rollbackMgr.BackupFile(path); err != nil {
+			t.Fatalf("备份失败: %v", err)
+		}
+	}
+
+	backups, err := rollbackMgr.ListBackups(first)
+	if err != nil {
+		t.Fatalf("列出备份失败: %v", err)
+	}
+	if len(backups) != 1 || filepath.Dir(backups[0].Path) != backupDir {
+		t.Fatalf("同名输出的备份应分开列出，实际: %+v", backups)
+	}
+
+	missing, err := rollbackMgr.ListBackups(filepath.Join(tempDir, "none.pdf"))
+	if err != nil {
+		t.Fatalf("没有备份时不应返回错误: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("期望空列表，实际: %+v", missing)
+	}
+}
+
+func TestRollbackManager_RestoreLatest(t *testing.T) {
+	tempDir := t.TempDir()
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1)
+	rollbackMgr := NewRollbackManagerWithConfig(tempDir, &RollbackConfig{Clock: clk})
+	output := filepath.Join(tempDir, "out.pdf")
+
+	if err := ioutil.WriteFile(output, []byte("当前内容"), 0644); err != nil {
+		t.Fatalf("写入输出失败: %v", err)
+	}
+	if _, err := rollbackMgr.RestoreLatest(output); !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("没有备份时应返回ErrNoBackup，实际: %v", err)
+	}
+
+	for _, content := range []string{"旧版本", "上一版"} {
+		if err := ioutil.WriteFile(output, []byte(content), 0644); err != nil {
+			t.Fatalf("写入输出失败: %v", err)
+		}
+		if _, err := rollbackMgr.BackupFile(output); err != nil {
+			t.Fatalf("备份失败: %v", err)
+		}
+		clk.Advance(time.Second)
+	}
+	if err := ioutil.WriteFile(output, []byte("合并结果"), 0644); err != nil {
+		t.Fatalf("写入输出失败: %v", err)
+	}
+
+	restored, err := rollbackMgr.RestoreLatest(output)
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("读取输出失败: %v", err)
+	}
+	if string(data) != "上一版" {
+		t.Errorf("应恢复最新的备份，实际: %s", string(data))
+	}
+	if _, err := os.Stat(restored.Path); err != nil {
+		t.Errorf("恢复后备份应保留: %v", err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(tempDir, "out_temp_*"))
+	if len(leftovers) != 0 {
+		t.Errorf("恢复不应留下临时文件: %v", leftovers)
+	}
+}
+
+func TestRollbackManager_ConcurrentBackupsUseUniqueNames(t *testing.T) {
+	tempDir := t.TempDir()
+	output := filepath.Join(tempDir, "out.pdf")
+	if err := ioutil.WriteFile(output, []byte("输出"), 0644); err != nil {
+		t.Fatalf("写入输出失败: %v", err)
+	}
+
+	// 固定时钟下所有备份的时间戳相同，名称只能靠后缀区分
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1)
+	const workers = 8
+	var wg sync.WaitGroup
+	paths := make([]string, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			// 每个合并各自创建管理器，共用备份目录的锁
+			rollbackMgr := NewRollbackManagerWithConfig(tempDir, &RollbackConfig{Keep: -1, Clock: clk})
+			backupPath, err := rollbackMgr.BackupFile(output)
+			if err != nil {
+				t.Errorf("并发备份 %d 失败: %v", id, err)
+				return
+			}
+			paths[id] = backupPath
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if seen[path] {
+			t.Errorf("备份名称重复: %s", path)
+		}
+		seen[path] = true
+	}
+	backups, err := NewRollbackManager(tempDir).ListBackups(output)
+	if err != nil {
+		t.Fatalf("列出备份失败: %v", err)
+	}
+	if len(backups) != workers {
+		t.Errorf("期望 %d 份备份，实际 %d 份", workers, len(backups))
+	}
+}
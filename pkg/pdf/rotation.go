@@ -0,0 +1,193 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// extraBoxKeyPattern 匹配页面上的 BleedBox 和 ArtBox，旋转写入内容后它们的坐标不再有效
+var extraBoxKeyPattern = regexp.MustCompile(`/(BleedBox|ArtBox)\s*(\[[^\]]*\]|\d+\s+\d+\s+R)`)
+
+// ValidateRotation 检查按文件指定的旋转角度，只接受 0、90、180、270（顺时针）
+func ValidateRotation(degrees int) error {
+	return validateRotation("", degrees)
+}
+
+// validateRotation 检查旋转角度，错误中包含为其指定该角度的文件
+func validateRotation(file string, degrees int) error {
+	switch degrees {
+	case 0, 90, 180, 270:
+		return nil
+	}
+	return &PDFError{
+		Type:    ErrorInvalidInput,
+		Message: fmt.Sprintf("无效的旋转角度 %d，只支持 0、90、180、270", degrees),
+		File:    file,
+	}
+}
+
+// RotatePages 把每一页的显示方向顺时针旋转degrees度（叠加到页面已有的 /Rotate 上），
+// 以增量更新写入outputPath。输入与输出可以是同一路径。不支持加密文件和对象流中的页面对象。
+func RotatePages(inputPath, outputPath string, degrees int) error {
+	if err := ValidateRotation(degrees); err != nil {
+		return err
+	}
+	data, err := readTransformInput(inputPath, "无法旋转加密文件的页面")
+	if err != nil {
+		return err
+	}
+
+	stats, err := WalkPageTree(inputPath, data, nil)
+	if err != nil {
+		return err
+	}
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	if degrees != 0 {
+		for _, pageNum := range stats.Pages {
+			body, _ := objectBody(data, offsets, pageNum)
+			rotation := (pageRotation(data, offsets, body) + degrees) % 360
+			// 显式写在页面上，覆盖从页面树继承的值
+			update.set(pageNum, withEntry(string(bytes.TrimSpace(body)), "/Rotate", fmt.Sprint(rotation)))
+		}
+	}
+	return writeTransformOutput(update, outputPath, ".rotate.tmp", "无法写入页面旋转结果")
+}
+
+// NormalizeOrientation 把每一页的 /Rotate 写入页面内容：内容按原来的显示方向变换，
+// MediaBox、CropBox 和 TrimBox 换成旋转后的尺寸，/Rotate 设为0。页面显示效果不变，
+// 但不再依赖查看器或合并后端处理 /Rotate（例如从页面树继承的值在合并时丢失）。
+// 有注释的页面保持不变，因为注释的外观和位置无法随内容一起旋转；返回这些页面的页码（从1开始）。
+// 以增量更新写入outputPath，输入与输出可以是同一路径。不支持加密文件和对象流中的页面对象。
+func NormalizeOrientation(inputPath, outputPath string) ([]int, error) {
+	data, err := readTransformInput(inputPath, "无法规范加密文件的页面方向")
+	if err != nil {
+		return nil, err
+	}
+
+	pages, boxes, err := readPageBoxes(inputPath, data)
+	if err != nil {
+		return nil, err
+	}
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	restoreNum := 0
+	var skipped []int
+	for i, pageNum := range pages {
+		body, _ := objectBody(data, offsets, pageNum)
+		rotation := pageRotation(data, offsets, body)
+		if rotation == 0 {
+			continue
+		}
+		if annots := strings.TrimSpace(directValue(body, "/Annots")); annots != "" && annots != "[]" {
+			skipped = append(skipped, i+1)
+			continue
+		}
+
+		// 用户空间到显示方向坐标（原点为MediaBox显示时的左下角）的变换
+		toView := viewToUser(boxes[i].MediaBox, rotation).inverse()
+		normalized := PageBoxes{
+			MediaBox: transformBox(toView, boxes[i].MediaBox),
+			CropBox:  transformBox(toView, boxes[i].CropBox),
+			TrimBox:  transformBox(toView, boxes[i].TrimBox),
+		}
+
+		page := extraBoxKeyPattern.ReplaceAllString(withPageBoxes(body, normalized), "")
+		page = withEntry(page, "/Rotate", "0")
+		if contents := strings.TrimSpace(existingContents(data, offsets, body)); contents != "" {
+			prefix := fmt.Sprintf("q %s cm\n", toView)
+			prefixNum := update.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(prefix), prefix))
+			if restoreNum == 0 {
+				restoreNum = update.add("<< /Length 2 >>\nstream\nQ\nendstream")
+			}
+			page = withEntry(page, "/Contents", fmt.Sprintf("[%d 0 R %s %d 0 R]", prefixNum, contents, restoreNum))
+		}
+		update.set(pageNum, page)
+	}
+
+	if err := writeTransformOutput(update, outputPath, ".orientation.tmp", "无法写入页面方向规范结果"); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// inverse 返回逆矩阵，页面变换矩阵总是可逆的
+func (m affine) inverse() affine {
+	det := m[0]*m[3] - m[1]*m[2]
+	return affine{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det, (m[1]*m[4] - m[0]*m[5]) / det,
+	}
+}
+
+// transformBox 返回页面框经m变换后的外接矩形
+func transformBox(m affine, box [4]float64) [4]float64 {
+	xs := [2]float64{box[0], box[2]}
+	ys := [2]float64{box[1], box[3]}
+	var result [4]float64
+	for i, x := range xs {
+		for j, y := range ys {
+			tx := m[0]*x + m[2]*y + m[4]
+			ty := m[1]*x + m[3]*y + m[5]
+			if i == 0 && j == 0 {
+				result = [4]float64{tx, ty, tx, ty}
+				continue
+			}
+			result = [4]float64{min(result[0], tx), min(result[1], ty), max(result[2], tx), max(result[3], ty)}
+		}
+	}
+	return result
+}
+
+// readTransformInput 读取要以增量更新修改页面的输入，加密文件返回encryptedMessage
+func readTransformInput(inputPath, encryptedMessage string) ([]byte, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: encryptedMessage,
+			File:    inputPath,
+		}
+	}
+	return data, nil
+}
+
+// writeTransformOutput 把增量更新写入临时文件后替换outputPath
+func writeTransformOutput(update *incrementalUpdate, outputPath, tempSuffix, writeMessage string) error {
+	return replaceFileContent(outputPath, update.bytes(), tempSuffix, writeMessage)
+}
+
+// replaceFileContent 把content写入临时文件后替换outputPath，失败时outputPath保持不变
+func replaceFileContent(outputPath string, content []byte, tempSuffix, writeMessage string) error {
+	tempPath := outputPath + tempSuffix
+	if err := os.WriteFile(tempPath, content, 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: writeMessage,
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
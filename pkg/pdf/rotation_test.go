@@ -0,0 +1,183 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRotatedPDF 写出三页测试文件：/Rotate 90 继承自页面树节点，第一页有内容流和CropBox，
+// 第二页显式为0，第三页为270且带有注释
+func writeRotatedPDF(t *testing.T, dir, name string) string {
+	content := "BT /F1 12 Tf 72 720 Td (Hello) Tj ET"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /Rotate 90 /MediaBox [0 0 600 800] >>",
+		"<< /Type /Page /Parent 2 0 R /CropBox [20 20 580 780] /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 0 >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 270 /Annots [7 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Annot /Subtype /Text /Rect [10 10 30 30] >>",
+	})
+	return createTestFile(t, dir, name, data)
+}
+
+// pageRotations 返回文件每一页生效的 /Rotate
+func pageRotations(t *testing.T, path string) []int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	pages, _, err := readPageBoxes(path, data)
+	require.NoError(t, err)
+	offsets := indexObjects(data)
+	rotations := make([]int, len(pages))
+	for i, num := range pages {
+		body, _ := objectBody(data, offsets, num)
+		rotations[i] = pageRotation(data, offsets, body)
+	}
+	return rotations
+}
+
+func TestRotatePages_AddsToExistingRotation(t *testing.T) {
+	dir := t.TempDir()
+	input := writeRotatedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "rotated.pdf")
+
+	require.NoError(t, RotatePages(input, output, 90))
+	assert.Equal(t, []int{180, 90, 0}, pageRotations(t, output))
+	assert.Equal(t, []int{90, 0, 270}, pageRotations(t, input), "输入文件不应被修改")
+
+	err := RotatePages(input, output, 45)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "45")
+}
+
+func TestViewTransform_MapsMediaBoxToOrigin(t *testing.T) {
+	box := [4]float64{10, 20, 610, 820}
+	for _, rotation := range []int{0, 90, 180, 270} {
+		toView := viewToUser(box, rotation).inverse()
+		want := [4]float64{0, 0, 600, 800}
+		if rotation == 90 || rotation == 270 {
+			want = [4]float64{0, 0, 800, 600}
+		}
+		assert.Equal(t, want, transformBox(toView, box), "rotation %d", rotation)
+		assert.Equal(t, affine{1, 0, 0, 1, 0, 0}, viewToUser(box, rotation).then(toView), "rotation %d", rotation)
+	}
+}
+
+func TestNormalizeOrientation_BakesRotationIntoContent(t *testing.T) {
+	dir := t.TempDir()
+	input := writeRotatedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "upright.pdf")
+
+	kept, err := NormalizeOrientation(input, output)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, kept, "有注释的页面应保持不变")
+	assert.Equal(t, []int{0, 0, 270}, pageRotations(t, output))
+
+	boxes, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	assert.Equal(t, [4]float64{0, 0, 800, 600}, boxes[0].MediaBox, "横向显示的页面应换成横向的MediaBox")
+	assert.Equal(t, [4]float64{20, 20, 780, 580}, boxes[0].CropBox)
+	assert.Equal(t, [4]float64{0, 0, 600, 800}, boxes[1].MediaBox)
+
+	// 原内容前后包上变换矩阵
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "q 0 -1 1 0 0 600 cm\n")
+	assert.Contains(t, string(data), "/Contents [8 0 R 6 0 R 9 0 R]")
+}
+
+func TestMergeFiles_RotationsAndNormalizeOrientation(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(1))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Rotations:     map[string]int{b: 90},
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "out.pdf")
+	_, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 0, 90}, pageRotations(t, output))
+
+	merger = NewStreamingMerger(&MergeOptions{
+		TempDirectory:        dir,
+		BackendStats:         NewBackendStatsStore(),
+		Rotations:            map[string]int{b: 90},
+		NormalizeOrientation: true,
+	})
+	merger.adapter = nil
+	normalized := filepath.Join(dir, "normalized.pdf")
+	_, err = merger.MergeFiles([]string{a, b}, normalized, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 0, 0}, pageRotations(t, normalized))
+	boxes, err := ReadPageBoxes(normalized)
+	require.NoError(t, err)
+	assert.Equal(t, [4]float64{0, 0, 792, 612}, boxes[2].MediaBox)
+
+	entries, err := filepath.Glob(filepath.Join(dir, "*_temp_*"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "预处理的临时副本应被清理")
+}
+
+func TestMergeFilesWithPageRanges_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(3))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(1))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Rotations:     map[string]int{a: 90, b: 90},
+	})
+	merger.adapter = nil
+	output := filepath.Join(dir, "out.pdf")
+	_, err := merger.MergeFilesWithPageRanges([]FileRangeSpec{
+		{File: a, Ranges: []PageRange{{2, 3}}},
+		{File: b, Rotation: 270},
+	}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{90, 90, 270}, pageRotations(t, output), "FileRangeSpec中的旋转应覆盖按路径指定的旋转")
+	assert.Equal(t, map[string]int{a: 90, b: 90}, merger.rotations, "合并后应恢复按路径指定的旋转")
+
+	_, err = merger.MergeFilesWithPageRanges([]FileRangeSpec{{File: a, Rotation: 45}}, output, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), a)
+}
+
+func TestMergeOptions_ValidateRotations(t *testing.T) {
+	assert.NoError(t, (&MergeOptions{Rotations: map[string]int{"a.pdf": 270}}).Validate())
+
+	err := (&MergeOptions{Rotations: map[string]int{"a.pdf": 45}}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.pdf")
+}
+
+func TestPDFService_RotatePDF(t *testing.T) {
+	dir := t.TempDir()
+	input := writeRotatedPDF(t, dir, "scan.pdf")
+	output := filepath.Join(dir, "out.pdf")
+
+	service := NewPDFService()
+	// 第三页旋转后为0，不需要规范，因此没有保持不变的页面
+	kept, err := service.RotatePDF(input, output, 90, true)
+	require.NoError(t, err)
+	assert.Empty(t, kept)
+	assert.Equal(t, []int{0, 0, 0}, pageRotations(t, output))
+
+	boxes, err := ReadPageBoxes(output)
+	require.NoError(t, err)
+	assert.Equal(t, [4]float64{0, 0, 600, 800}, boxes[0].MediaBox, "旋转180度后页面仍为纵向")
+	assert.Equal(t, [4]float64{0, 0, 800, 600}, boxes[1].MediaBox)
+
+	_, err = service.RotatePDF(input, output, 45, false)
+	assert.Error(t, err)
+}
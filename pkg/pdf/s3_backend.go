@@ -0,0 +1,397 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/user/pdf-merger/internal/clock"
+)
+
+// s3Scheme S3输出位置的URI前缀
+const s3Scheme = "s3://"
+
+// DefaultS3PartSize 分段上传的默认分段大小。S3要求除最后一段外每段至少5MiB
+const DefaultS3PartSize = 8 * 1024 * 1024
+
+// minS3PartSize S3允许的最小分段（最后一段除外）
+const minS3PartSize = 5 * 1024 * 1024
+
+// S3Config S3兼容对象存储的输出位置和凭证
+type S3Config struct {
+	Endpoint        string       // 服务地址，如 https://s3.us-east-1.amazonaws.com 或 http://localhost:9000
+	Region          string       // 签名使用的区域
+	Bucket          string       // 存储桶
+	Key             string       // 对象键
+	AccessKeyID     string       // 访问密钥ID
+	SecretAccessKey string       // 访问密钥
+	SessionToken    string       // 临时凭证的会话令牌，可为空
+	PartSize        int64        // 分段大小，0时使用DefaultS3PartSize，小于5MiB时使用5MiB
+	RetryPolicy     *RetryPolicy // 单个请求失败时的重试策略，nil时使用默认策略
+	HTTPClient      *http.Client // nil时使用 http.DefaultClient
+	Clock           clock.Clock  // 签名时间和重试等待，nil时使用系统时钟
+}
+
+// IsS3URI 判断路径是否为 s3://bucket/key 形式的输出位置
+func IsS3URI(path string) bool {
+	return strings.HasPrefix(path, s3Scheme)
+}
+
+// ParseS3URI 解析 s3://bucket/key，存储桶或对象键为空时返回错误
+func ParseS3URI(uri string) (bucket, key string, err error) {
+	if !IsS3URI(uri) {
+		return "", "", &PDFError{Type: ErrorInvalidInput, Message: "不是 s3:// 位置", File: uri}
+	}
+	rest := strings.TrimPrefix(uri, s3Scheme)
+	slash := strings.Index(rest, "/")
+	if slash <= 0 || slash == len(rest)-1 {
+		return "", "", &PDFError{Type: ErrorInvalidInput, Message: "S3位置应为 s3://bucket/key", File: uri}
+	}
+	return rest[:slash], rest[slash+1:], nil
+}
+
+// S3ConfigFromEnv 按 s3://bucket/key 和环境变量创建配置。凭证来自 AWS_ACCESS_KEY_ID、
+// AWS_SECRET_ACCESS_KEY 和 AWS_SESSION_TOKEN；区域来自 AWS_REGION 或 AWS_DEFAULT_REGION（默认 us-east-1）；
+// 服务地址来自 AWS_ENDPOINT_URL_S3 或 AWS_ENDPOINT_URL，都没有时使用该区域的AWS地址
+func S3ConfigFromEnv(uri string, getenv func(string) string) (*S3Config, error) {
+	bucket, key, err := ParseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	config := &S3Config{
+		Bucket:          bucket,
+		Key:             key,
+		AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		Region:          firstNonEmpty(getenv("AWS_REGION"), getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		Endpoint:        firstNonEmpty(getenv("AWS_ENDPOINT_URL_S3"), getenv("AWS_ENDPOINT_URL")),
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "上传到S3需要设置 AWS_ACCESS_KEY_ID 和 AWS_SECRET_ACCESS_KEY",
+			File:    uri,
+		}
+	}
+	return config, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// S3Backend 把输出上传到S3兼容对象存储的写入后端。暂存文件仍写在本地并在本地验证，
+// Promote 以分段上传逐段读取暂存文件，上传完成后删除它；上传失败时中止分段上传，不留下不完整的对象。
+// 对象位置由配置决定，Promote 的 outputPath 参数只用于错误信息
+type S3Backend struct {
+	config   S3Config
+	endpoint *url.URL
+	files    fileWriteBackend
+	client   *http.Client
+	policy   *RetryPolicy
+	clock    clock.Clock
+}
+
+// NewS3Backend 创建S3写入后端，配置缺少存储桶、对象键、凭证或服务地址无效时返回错误
+func NewS3Backend(config *S3Config) (*S3Backend, error) {
+	if config == nil || config.Bucket == "" || config.Key == "" {
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: "S3输出需要存储桶和对象键"}
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: "S3输出需要访问密钥", File: config.location()}
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: "S3服务地址无效", File: config.Endpoint, Cause: err}
+	}
+
+	b := &S3Backend{
+		config:   *config,
+		endpoint: endpoint,
+		client:   config.HTTPClient,
+		policy:   config.RetryPolicy,
+		clock:    clock.OrSystem(config.Clock),
+	}
+	if b.client == nil {
+		b.client = http.DefaultClient
+	}
+	if b.policy == nil {
+		b.policy = DefaultRetryPolicy()
+	}
+	if b.config.PartSize == 0 {
+		b.config.PartSize = DefaultS3PartSize
+	} else if b.config.PartSize < minS3PartSize {
+		b.config.PartSize = minS3PartSize
+	}
+	if b.config.Region == "" {
+		b.config.Region = "us-east-1"
+	}
+	return b, nil
+}
+
+// location 返回 s3://bucket/key
+func (c *S3Config) location() string {
+	return s3Scheme + c.Bucket + "/" + c.Key
+}
+
+// Location 返回上传后的输出位置 s3://bucket/key
+func (b *S3Backend) Location() string {
+	return b.config.location()
+}
+
+// WriteTemp 把内容写到本地暂存文件
+func (b *S3Backend) WriteTemp(tempPath string, content []byte) error {
+	return b.files.WriteTemp(tempPath, content)
+}
+
+// Promote 上传暂存文件，成功后删除它
+func (b *S3Backend) Promote(tempPath, outputPath string) error {
+	if err := b.Upload(context.Background(), tempPath); err != nil {
+		return err
+	}
+	return b.files.Cleanup(tempPath)
+}
+
+// Cleanup 删除本地暂存文件
+func (b *S3Backend) Cleanup(tempPath string) error {
+	return b.files.Cleanup(tempPath)
+}
+
+// Upload 以分段上传把本地文件上传到配置的对象，每次只读取一个分段。
+// 单个请求的临时失败（网络错误、5xx、429）按重试策略重试；上传失败或ctx取消时中止分段上传
+func (b *S3Backend) Upload(ctx context.Context, localPath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return &PDFError{Type: ErrorIO, Message: "无法读取要上传的文件", File: localPath, Cause: err}
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return &PDFError{Type: ErrorIO, Message: "无法读取要上传的文件", File: localPath, Cause: err}
+	}
+
+	uploadID, err := b.createUpload(ctx)
+	if err != nil {
+		return err
+	}
+
+	parts, err := b.uploadParts(ctx, file, info.Size(), uploadID)
+	if err == nil {
+		err = b.completeUpload(ctx, uploadID, parts)
+	}
+	if err != nil {
+		// 中止使用独立的上下文，ctx已取消时也能清理不完整的上传
+		if abortErr := b.abortUpload(context.Background(), uploadID); abortErr != nil {
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: fmt.Sprintf("上传失败，且无法中止分段上传 %s: %v", uploadID, abortErr),
+				File:    b.Location(),
+				Cause:   err,
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// s3Part 已上传的分段
+type s3Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// createUpload 开始分段上传，返回UploadId
+func (b *S3Backend) createUpload(ctx context.Context) (string, error) {
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err := b.do(ctx, http.MethodPost, url.Values{"uploads": {""}}, nil, func(resp *http.Response) error {
+		if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+			return &PDFError{Type: ErrorIO, Message: "无法解析分段上传的响应", File: b.Location(), Cause: err}
+		}
+		return nil
+	})
+	return result.UploadID, err
+}
+
+// uploadParts 按分段大小依次上传文件内容
+func (b *S3Backend) uploadParts(ctx context.Context, file *os.File, size int64, uploadID string) ([]s3Part, error) {
+	var parts []s3Part
+	for offset, number := int64(0), 1; offset < size || number == 1; offset, number = offset+b.config.PartSize, number+1 {
+		length := b.config.PartSize
+		if offset+length > size {
+			length = size - offset
+		}
+		section := io.NewSectionReader(file, offset, length)
+
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		err := b.do(ctx, http.MethodPut, query, section, func(resp *http.Response) error {
+			etag := resp.Header.Get("ETag")
+			if etag == "" {
+				return &PDFError{Type: ErrorIO, Message: "分段上传的响应没有ETag", File: b.Location()}
+			}
+			parts = append(parts, s3Part{PartNumber: number, ETag: etag})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// completeUpload 按分段列表完成上传。S3可能以200返回错误文档，此时同样视为失败
+func (b *S3Backend) completeUpload(ctx context.Context, uploadID string, parts []s3Part) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	return b.do(ctx, http.MethodPost, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), func(resp *http.Response) error {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return &PDFError{Type: ErrorIO, Message: "读取完成上传的响应失败", File: b.Location(), Cause: err}
+		}
+		if bytes.Contains(data, []byte("<Error>")) {
+			return &PDFError{Type: ErrorIO, Message: "完成分段上传失败: " + s3ErrorMessage(data), File: b.Location()}
+		}
+		return nil
+	})
+}
+
+// abortUpload 中止分段上传并释放已上传的分段
+func (b *S3Backend) abortUpload(ctx context.Context, uploadID string) error {
+	return b.do(ctx, http.MethodDelete, url.Values{"uploadId": {uploadID}}, nil, nil)
+}
+
+// do 签名并发送对象上的请求，按重试策略重试临时失败。body每次重试前回到开头；
+// 请求成功时把响应交给handle处理
+func (b *S3Backend) do(ctx context.Context, method string, query url.Values, body io.ReadSeeker,
+	handle func(*http.Response) error) error {
+
+	for retry := 0; ; retry++ {
+		err := b.send(ctx, method, query, body, handle)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return NewPDFError(ErrorIO, "上传被取消", b.Location(), ctx.Err())
+		}
+		if retry >= b.policy.RetriesFor(err) {
+			return err
+		}
+		if err := b.policy.Wait(ctx, b.clock, retry+1); err != nil {
+			return NewPDFError(ErrorIO, "上传被取消", b.Location(), err)
+		}
+	}
+}
+
+// send 发送一次请求
+func (b *S3Backend) send(ctx context.Context, method string, query url.Values, body io.ReadSeeker,
+	handle func(*http.Response) error) error {
+
+	payloadHash := emptyPayloadHash
+	var length int64
+	if body != nil {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		hash := sha256.New()
+		n, err := io.Copy(hash, body)
+		if err != nil {
+			return &PDFError{Type: ErrorIO, Message: "读取上传内容失败", File: b.Location(), Cause: err}
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		payloadHash, length = hex.EncodeToString(hash.Sum(nil)), n
+	}
+
+	target := *b.endpoint
+	target.Path = strings.TrimSuffix(b.endpoint.Path, "/") + "/" + b.config.Bucket + "/" + b.config.Key
+	target.RawPath = s3CanonicalURI(target.Path)
+	target.RawQuery = s3CanonicalQuery(query)
+
+	// 长度为0的请求体按未知长度处理会改用分块编码，因此空内容发送 NoBody
+	var reader io.Reader = http.NoBody
+	if body != nil && length > 0 {
+		reader = io.NopCloser(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return &PDFError{Type: ErrorInvalidInput, Message: "无法创建S3请求", File: b.Location(), Cause: err}
+	}
+	req.ContentLength = length
+	signS3Request(req, s3Credentials{
+		AccessKeyID:     b.config.AccessKeyID,
+		SecretAccessKey: b.config.SecretAccessKey,
+		SessionToken:    b.config.SessionToken,
+	}, b.config.Region, payloadHash, b.clock.Now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return &PDFError{Type: ErrorIO, Message: "S3请求失败", File: b.Location(), Cause: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return &PDFError{
+			Type:    s3StatusErrorType(resp.StatusCode),
+			Message: fmt.Sprintf("S3返回 %s: %s", resp.Status, s3ErrorMessage(data)),
+			File:    b.Location(),
+		}
+	}
+	if handle != nil {
+		return handle(resp)
+	}
+	return nil
+}
+
+// s3StatusErrorType 按HTTP状态选择错误类型：服务端错误、限流和超时可以重试，认证失败和其他客户端错误不重试
+func s3StatusErrorType(status int) ErrorType {
+	switch {
+	case status >= 500, status == http.StatusTooManyRequests, status == http.StatusRequestTimeout:
+		return ErrorIO
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrorPermission
+	default:
+		return ErrorInvalidInput
+	}
+}
+
+// s3ErrorMessage 从S3错误文档中取出错误码和说明，无法解析时返回原文的开头
+func s3ErrorMessage(data []byte) string {
+	var doc struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(data, &doc); err == nil && doc.Code != "" {
+		return strings.TrimSpace(doc.Code + " " + doc.Message)
+	}
+	text := strings.TrimSpace(string(data))
+	if len(text) > 200 {
+		text = text[:200]
+	}
+	return text
+}
@@ -1,11 +1,14 @@
 package pdf
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/user/pdf-merger/internal/model"
 )
 
 // PDFInfo 定义PDF文件信息（保持向后兼容）
@@ -19,6 +22,15 @@ type PDFInfo struct {
 	// 扩展信息
 	FilePath     string
 	Version      string
+	IsLinearized bool    // 是否为线性化（快速Web视图）文件
+	Conformance  string  // 声明的标准符合性，例如 "PDF/A-2b"、"PDF/X-4"，没有声明时为 "none"
+	PageWidth    float64 // 第一页显示时的宽度（点，已考虑 /Rotate），无法读取时为0
+	PageHeight   float64 // 第一页显示时的高度（点）
+
+	// 每一页的尺寸和旋转，只在ServiceConfig.IncludePageGeometry为true时由GetPDFInfo填写，见ReadPageDims
+	PageSizes      []PageDim
+	MixedPageSizes bool // PageSizes 中有纸张尺寸不同的页面（只有方向不同的页面视为相同）
+
 	Author       string
 	Subject      string
 	Creator      string
@@ -26,6 +38,17 @@ type PDFInfo struct {
 	CreationDate time.Time
 	ModDate      time.Time
 
+	// 数字签名，见ReadSignatures；合并后这些签名失效
+	HasSignatures  bool
+	SignatureCount int
+	Signers        []string // 签名字典 /Name 中的签名者，无法解析时为空
+
+	// AttachmentCount 附件（/Names /EmbeddedFiles 中的嵌入文件）数量，见ListAttachments
+	AttachmentCount int
+
+	// FormFieldCount 表单（/AcroForm）中的终端字段数量，见CountFormFields；合并时各输入的表单会合并
+	FormFieldCount int
+
 	// pdfcpu特有信息
 	PDFCPUVersion string
 	Permissions   []string
@@ -63,6 +86,70 @@ type PDFService interface {
 
 	// MergePDFs 将多个PDF文件合并为一个
 	MergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) error
+
+	// ExtractPages 按给定顺序把页面（从1开始的页码）提取为新的PDF文件
+	ExtractPages(inputPath string, pages []int, outputPath string) error
+
+	// ExtractPageRanges 按页码范围字符串（例如 "1-5,8"）提取页面
+	ExtractPageRanges(inputPath string, ranges string, outputPath string) error
+
+	// DecryptPDF 使用密码移除PDF的加密并写出到outputPath，输入未加密时直接复制
+	DecryptPDF(inputPath, outputPath, password string) error
+
+	// RepairPDF 修复轻度损坏的PDF（交叉引用、%%EOF标记、对象流）并写出到outputPath，不修改输入文件
+	RepairPDF(inputPath, outputPath string) (*RepairReport, error)
+
+	// ValidateConformance 读取文件声明的PDF/A、PDF/X符合性并检查输出意图等明显问题
+	ValidateConformance(filePath string) (*ConformanceReport, error)
+
+	// StampPDF 把页码（{page}、{pages}、{filename}）或水印文字添加到每一页并写出到outputPath
+	StampPDF(inputPath, outputPath string, opts *StampOptions) error
+
+	// AddTableOfContents 在已合并的outputPath开头插入列出各来源及起始页的目录页
+	AddTableOfContents(outputPath string, sources []TOCSource) error
+
+	// RotatePDF 把每一页顺时针旋转degrees度（0、90、180、270）并写出到outputPath；normalize为true时
+	// 再把 /Rotate 写入页面内容，返回因有注释而保持 /Rotate 的页码
+	RotatePDF(inputPath, outputPath string, degrees int, normalize bool) ([]int, error)
+
+	// SplitPDF 把文件按固定页数或顶层书签拆分为多个文件，返回按顺序写出的文件路径
+	SplitPDF(inputPath string, opts *SplitOptions) ([]string, error)
+
+	// ListAttachments 列出文件中的附件（嵌入文件），合并时各输入的附件会合并到输出
+	ListAttachments(filePath string) ([]AttachmentInfo, error)
+
+	// ValidateBatch 用workers个协程并行验证多个文件，返回每个文件的结果和汇总；ctx结束时返回部分报告
+	ValidateBatch(ctx context.Context, paths []string, workers int) (*BatchValidationReport, error)
+
+	// ExtractText 提取pages（为空时为全部页面）的文字，返回页码到文字的映射；只有图像的页面为空字符串并记录警告
+	ExtractText(filePath string, pages []int) (map[int]string, error)
+
+	// ExtractTextTo 与ExtractText相同，但逐页写到w（每页之后写换页符），不在内存中保留全部文字
+	ExtractTextTo(filePath string, pages []int, w io.Writer) error
+
+	// ExtractImages 把pages（为空时为全部页面）中的图像写为JPEG或PNG文件到outputDir，返回写出的图像
+	ExtractImages(filePath string, pages []int, outputDir string) ([]ExtractedImage, error)
+
+	// ExtractImagesContext 与ExtractImages相同，ctx结束时停止并返回已写出的图像和ctx.Err()
+	ExtractImagesContext(ctx context.Context, filePath string, pages []int, outputDir string) ([]ExtractedImage, error)
+}
+
+// InfoInvalidator 由缓存PDF信息的服务实现，用于强制下次GetPDFInfo重新解析文件
+type InfoInvalidator interface {
+	// InvalidateInfo 丢弃文件的缓存信息
+	InvalidateInfo(filePath string)
+}
+
+// MergeResultProvider 由能提供合并结果的服务实现，用于在MergePDFs成功后显示合并摘要
+type MergeResultProvider interface {
+	// TakeMergeResult 返回并移除最近一次成功写出到outputPath的合并结果，没有时返回nil
+	TakeMergeResult(outputPath string) *MergeResult
+}
+
+// ProfileApplier 由能切换合并配置方案的服务实现，用于GUI在两次合并之间选择方案
+type ProfileApplier interface {
+	// ApplyProfile 在创建服务时的配置上应用方案，替换之前应用的方案；对之后开始的合并生效
+	ApplyProfile(profile model.MergeProfile) error
 }
 
 // mapPDFInfo 将基本PDF信息映射到扩展的PDFInfo结构
@@ -1,44 +1,127 @@
 package pdf
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
-)
-
-// 初始化unidoc库
-func init() {
-	// 设置处理器数量，避免过度使用CPU
-	runtime.GOMAXPROCS(runtime.NumCPU() / 2)
-	if runtime.GOMAXPROCS(0) < 1 {
-		runtime.GOMAXPROCS(1)
-	}
 
-	// 设置unidoc日志级别 - 暂时跳过以避免网络问题
-	// log.SetLogger(nil)
-}
+	"github.com/user/pdf-merger/internal/clock"
+)
 
-// PDFServiceImpl 实现PDFService接口
+// PDFServiceImpl 实现PDFService接口，可被多个协程同时使用。
+// 服务持有只由ApplyProfile整体替换的配置、加锁的信息缓存和适配器池，每个操作独占从池中取出的适配器并使用各自的临时文件，
+// 因此不同文件上的合并、验证和信息查询可以并行执行；同时写同一个输出路径的结果由最后完成的操作决定。
 type PDFServiceImpl struct {
 	validator    *PDFValidator
 	errorHandler ErrorHandler
-	mutex        sync.Mutex
-	config       *ServiceConfig
+	config       atomic.Pointer[ServiceConfig] // 当前配置，ApplyProfile替换为应用方案后的副本
+	baseConfig   ServiceConfig                 // 创建服务时的配置，ApplyProfile在其基础上应用方案
+
+	infoCache *infoCache        // GetPDFInfo的结果缓存，nil时不缓存
+	adapters  *AdapterPool      // 各操作复用的pdfcpu适配器，nil时每次操作新建适配器
+	results   *mergeResultStore // MergePDFs成功后尚未被TakeMergeResult取走的合并结果
+
+	fileBackend fileBackend // 解密和加密单个文件的后端，nil时使用取出的适配器
 }
 
 // ServiceConfig PDF服务配置
 type ServiceConfig struct {
 	MaxRetries       int
 	RetryDelay       time.Duration
+	RetryPolicy      *RetryPolicy // 各错误类型的重试次数与退避，nil时IO和内存错误最多重试MaxRetries次
 	EnableStrictMode bool
 	PreferPDFCPU     bool
 	TempDirectory    string
 	MaxMemoryUsage   int64
+	InfoCacheSize    int                // GetPDFInfo缓存的文件数，0时使用DefaultInfoCacheSize，负数时不缓存
+	AdapterPoolSize  int                // 适配器池保留的空闲pdfcpu适配器数，0时使用DefaultAdapterPoolSize，负数时每次操作新建适配器
+	PageTreeLimits   *PageTreeLimits    // 页面树遍历限制，nil表示使用默认值
+	ResourceLimits   *ResourceLimits    // 验证和获取信息时的解压字节数与对象嵌套深度限制，nil表示使用默认值
+	VerifyChecksums  bool               // 合并前校验输入文件的.sha256旁路文件
+	Linearize        bool               // 合并成功后线性化输出（快速Web视图）
+	Clock            clock.Clock        // 时间与随机源，传递给合并器；nil时使用系统时钟
+	AdaptiveBackends bool               // 按历史统计选择合并后端顺序
+	SourceBookmarks  bool               // 合并后为每个输入添加顶层书签
+	GenerateTOC      bool               // 合并后在输出开头插入目录页
+	Logger           Logger             // 传给合并器和pdfcpu适配器的日志，nil时使用默认日志
+	MaxWorkers       int                // 合并时同时处理的分块数上限，0时使用CPU核数；不修改GOMAXPROCS
+	AllowDuplicates  bool               // 合并内容重复的输入，为false时跳过重复输入
+	FailOnSigned     bool               // 输入包含数字签名时中止合并，为false时合并并记录警告
+	DropAttachments  bool               // 移除输出中的附件，为false时合并各输入的附件
+	FlattenForms     bool               // 把表单字段展平到页面内容，为false时合并各输入的表单
+	MaxOutputSize    int64              // 输出大小上限（字节），0时不限制
+	MaxOutputPages   int                // 输出页数上限，0时不限制
+	Stamps           []*StampOptions    // 合并后按顺序添加到每一页的页码或水印，在加密之前添加
+	Imposition       *ImpositionOptions // 印章之后的n-up或小册子拼版，nil时不拼版
+
+	// MinInlineImagePixels ExtractImages跳过像素数小于该值的内联图像，0时使用DefaultMinInlineImagePixels，负数时不跳过
+	MinInlineImagePixels int
+
+	// 输出优化：在印章之后、加密之前执行，含义与MergeOptions中的同名字段相同
+	OptimizeOutput    bool
+	OptimizeImagesDPI int
+
+	// 输出加密：两个密码都为空时不加密，含义与MergeOptions中的同名字段相同
+	OutputUserPassword  string
+	OutputOwnerPassword string
+	OutputPermissions   *OutputPermissions
+
+	// 合并清单：含义与MergeOptions中的同名字段相同。清单在目录、印章、加密等后处理之后按最终输出生成，
+	// 再次合并到同一输出时与上次的清单比较，变化摘要见MergeResult.Delta
+	WriteManifest bool
+	ToolVersion   string
+
+	// PasswordVault 密码保险库，含义与MergeOptions.Vault相同；DecryptPDF在没有给出密码时也按内容哈希查询，nil时不使用保险库
+	PasswordVault PasswordVault
+
+	// 流式合并配置：含义与MergeOptions.StreamingConfig相同，MaxWorkers大于0时优先于其中的MaxConcurrentChunks
+	StreamingConfig *StreamingConfig
+
+	// 输出元数据：在来源书签之后、印章之前设置，含义与MergeOptions中的同名字段相同
+	MetadataSource string
+	CustomMetadata map[string]string
+
+	// 输出备份：替换已存在的输出前保留一份备份，含义与MergeOptions中的同名字段相同
+	BackupOutput    bool
+	BackupDirectory string
+	BackupRetention int
+
+	// 输入检查
+	// ValidationTimeout 单个文件的ValidatePDF和GetPDFInfo的最长时间，超时返回ErrorResourceLimit；0时不限制
+	ValidationTimeout time.Duration
+	// RequirePDFExtension 只接受 .pdf 扩展名的输入；为false时以文件头识别PDF，没有扩展名的文件也可以处理
+	RequirePDFExtension bool
+	// BatchProgress ValidateBatch每验证完一个文件调用一次，nil时不报告进度
+	BatchProgress BatchProgressFunc
+	// IncludePageGeometry GetPDFInfo填写每一页的尺寸（PDFInfo.PageSizes），需要读取所有页面对象，默认不填写
+	IncludePageGeometry bool
+}
+
+// DefaultServiceConfig 返回默认的服务配置
+func DefaultServiceConfig() *ServiceConfig {
+	return &ServiceConfig{
+		MaxRetries:        3,
+		RetryDelay:        time.Second * 2,
+		EnableStrictMode:  false,
+		PreferPDFCPU:      true,
+		TempDirectory:     os.TempDir(),
+		MaxMemoryUsage:    100 * 1024 * 1024, // 100MB
+		ValidationTimeout: 2 * time.Minute,
+	}
+}
+
+// retryPolicy 返回配置的重试策略，没有设置RetryPolicy时按MaxRetries构造
+func (c *ServiceConfig) retryPolicy() *RetryPolicy {
+	if c.RetryPolicy != nil {
+		return c.RetryPolicy
+	}
+	return NewRetryPolicy(c.MaxRetries)
 }
 
 // NewPDFService 创建一个新的PDF服务实例
@@ -49,28 +132,40 @@ func NewPDFService() PDFService {
 // NewPDFServiceWithConfig 使用配置创建PDF服务实例
 func NewPDFServiceWithConfig(config *ServiceConfig) PDFService {
 	if config == nil {
-		config = &ServiceConfig{
-			MaxRetries:       3,
-			RetryDelay:       time.Second * 2,
-			EnableStrictMode: false,
-			PreferPDFCPU:     true,
-			TempDirectory:    os.TempDir(),
-			MaxMemoryUsage:   100 * 1024 * 1024, // 100MB
-		}
+		config = DefaultServiceConfig()
 	}
 
-	return &PDFServiceImpl{
-		validator:    NewPDFValidator(),
-		errorHandler: NewDefaultErrorHandler(config.MaxRetries),
-		config:       config,
+	service := &PDFServiceImpl{
+		validator:    NewPDFValidatorWithConfig(config.StreamingConfig),
+		errorHandler: NewErrorHandlerWithPolicy(config.retryPolicy()),
+		baseConfig:   *config,
+		infoCache:    newInfoCache(config.InfoCacheSize),
+		results:      newMergeResultStore(),
 	}
+	service.config.Store(config)
+	if config.AdapterPoolSize >= 0 {
+		service.adapters = NewAdapterPool(config.AdapterPoolSize, service.adapterConfig())
+	}
+	return service
 }
 
 // ValidatePDF 验证PDF文件格式是否有效
 func (s *PDFServiceImpl) ValidatePDF(filePath string) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	return runWithTimeout(s.config.Load().Clock, filePath, s.config.Load().ValidationTimeout, func(ctx context.Context) error {
+		return s.validatePDF(ctx, filePath)
+	})
+}
+
+// ValidateBatch 用workers个协程并行验证paths（workers不大于0时使用CPU核数），每个文件的验证与ValidatePDF相同。
+// 服务不持有全局锁，各协程共享适配器池；进度通过ServiceConfig.BatchProgress报告。
+// ctx结束后返回已完成部分的报告和ctx.Err()
+func (s *PDFServiceImpl) ValidateBatch(ctx context.Context, paths []string, workers int) (*BatchValidationReport, error) {
+	config := s.config.Load()
+	return validateBatch(ctx, clock.OrSystem(config.Clock), paths, workers, s.ValidatePDF, config.BatchProgress)
+}
 
+// validatePDF 执行ValidatePDF的验证，ctx在超时后结束
+func (s *PDFServiceImpl) validatePDF(ctx context.Context, filePath string) error {
 	// 使用错误收集器收集验证过程中的错误
 	errorCollector := NewErrorCollector()
 
@@ -79,8 +174,13 @@ func (s *PDFServiceImpl) ValidatePDF(filePath string) error {
 		return s.errorHandler.HandleError(err)
 	}
 
+	// 解析文件之前检查页面树、解压字节数和对象嵌套深度，超出限制的文件不交给pdfcpu或读取器
+	if err := s.checkInputLimits(ctx, filePath); err != nil {
+		return err
+	}
+
 	// 第二步：优先使用pdfcpu进行验证（如果配置启用）
-	if s.config.PreferPDFCPU {
+	if s.config.Load().PreferPDFCPU {
 		if err := s.validateWithPDFCPU(filePath); err == nil {
 			return nil // pdfcpu验证成功
 		} else {
@@ -108,23 +208,63 @@ func (s *PDFServiceImpl) ValidatePDF(filePath string) error {
 	return nil
 }
 
+// checkInputLimits 先按PageTreeLimits遍历页面树，再检查解压字节数和对象嵌套深度。
+// 页面树遍历在触发限制时立即停止，恶意构造的页面树不必等整个对象图扫描完成就被拒绝；
+// 遍历发现的其他问题（如损坏的页面树）由之后的验证报告
+func (s *PDFServiceImpl) checkInputLimits(ctx context.Context, filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &PDFError{Type: ErrorIO, Message: "无法读取文件", File: filePath, Cause: err}
+	}
+	if _, err := WalkPageTree(filePath, data, s.config.Load().PageTreeLimits); IsLimitExceededError(err) {
+		return err
+	}
+	return checkResourceLimits(ctx, filePath, data, s.config.Load().ResourceLimits)
+}
+
 // GetPDFInfo 获取PDF文件的基本信息
 func (s *PDFServiceImpl) GetPDFInfo(filePath string) (*PDFInfo, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	var info *PDFInfo
+	err := runWithTimeout(s.config.Load().Clock, filePath, s.config.Load().ValidationTimeout, func(ctx context.Context) error {
+		var err error
+		info, err = s.getPDFInfo(ctx, filePath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
 
+// getPDFInfo 执行GetPDFInfo的信息提取，ctx在超时后结束
+func (s *PDFServiceImpl) getPDFInfo(ctx context.Context, filePath string) (*PDFInfo, error) {
 	// 首先进行基本验证
 	if err := s.basicFileValidation(filePath); err != nil {
 		return nil, s.errorHandler.HandleError(err)
 	}
 
+	// 文件未修改时直接使用缓存的信息；开启IncludePageGeometry之前缓存的信息没有页面尺寸，需要重新读取
+	stat, statErr := os.Stat(filePath)
+	if statErr == nil {
+		if cached := s.infoCache.get(filePath, stat); cached != nil && (!s.config.Load().IncludePageGeometry || cached.PageSizes != nil) {
+			return cached, nil
+		}
+	}
+
+	if err := s.checkInputLimits(ctx, filePath); err != nil {
+		return nil, err
+	}
+
 	var info *PDFInfo
 	var lastError error
 
 	// 方法1：优先使用pdfcpu适配器获取详细信息
-	if s.config.PreferPDFCPU {
+	if s.config.Load().PreferPDFCPU {
 		if pdfcpuInfo, err := s.getInfoWithPDFCPU(filePath); err == nil {
 			info = pdfcpuInfo
+		} else if IsLimitExceededError(err) {
+			// 超出限制的文件不再尝试其他方法
+			return nil, err
 		} else {
 			lastError = err
 		}
@@ -134,6 +274,8 @@ func (s *PDFServiceImpl) GetPDFInfo(filePath string) (*PDFInfo, error) {
 	if info == nil {
 		if readerInfo, err := s.getInfoWithEnhancedReader(filePath); err == nil {
 			info = readerInfo
+		} else if IsLimitExceededError(err) {
+			return nil, err
 		} else {
 			lastError = err
 		}
@@ -174,29 +316,47 @@ func (s *PDFServiceImpl) GetPDFInfo(filePath string) (*PDFInfo, error) {
 		}
 	}
 
+	// 页面尺寸读取失败不影响其他信息
+	if s.config.Load().IncludePageGeometry {
+		if dims, err := ReadPageDims(filePath); err == nil {
+			info.PageSizes = dims
+			info.MixedPageSizes = HasMixedPageSizes(dims)
+		}
+	}
+
+	if statErr == nil {
+		s.infoCache.put(filePath, stat, info)
+	}
 	return info, nil
 }
 
+// InvalidateInfo 丢弃文件的缓存信息，下次GetPDFInfo重新解析文件。
+// 文件大小或修改时间变化时缓存会自动失效，只在需要强制刷新时调用
+func (s *PDFServiceImpl) InvalidateInfo(filePath string) {
+	s.infoCache.invalidate(filePath)
+}
+
 // 新增的信息获取方法
 
 // getInfoWithPDFCPU 使用pdfcpu获取PDF信息
 func (s *PDFServiceImpl) getInfoWithPDFCPU(filePath string) (*PDFInfo, error) {
-	adapter, err := NewPDFCPUAdapter(nil)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
 		return nil, err
 	}
-	defer adapter.Close()
-
-	return adapter.GetFileInfo(filePath)
+	info, err := adapter.GetFileInfo(filePath)
+	s.releaseAdapter(adapter, err)
+	return info, err
 }
 
 // getInfoWithEnhancedReader 使用增强读取器获取PDF信息
 func (s *PDFServiceImpl) getInfoWithEnhancedReader(filePath string) (*PDFInfo, error) {
-	reader, err := NewPDFReader(filePath)
+	reader, err := NewPDFReaderWithConfig(filePath, s.config.Load().StreamingConfig)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
+	reader.SetPageTreeLimits(s.config.Load().PageTreeLimits)
 
 	return reader.GetInfo()
 }
@@ -228,6 +388,35 @@ func (s *PDFServiceImpl) enrichInfoWithFileSystemData(info *PDFInfo, filePath st
 		info.Title = getFileNameWithoutExt(filePath)
 	}
 
+	if linearized, err := IsLinearized(filePath); err == nil {
+		info.IsLinearized = linearized
+	}
+
+	if info.PageWidth == 0 {
+		if width, height, err := ReadPageSize(filePath); err == nil {
+			info.PageWidth, info.PageHeight = width, height
+		}
+	}
+
+	info.Conformance = ConformanceNone
+	if report, err := ValidateConformance(filePath); err == nil {
+		info.Conformance = report.Conformance
+	}
+
+	if report, err := ReadSignatures(filePath); err == nil {
+		info.HasSignatures = report.Count > 0
+		info.SignatureCount = report.Count
+		info.Signers = report.Signers
+	}
+
+	if attachments, err := ListAttachments(filePath); err == nil {
+		info.AttachmentCount = len(attachments)
+	}
+
+	if count, err := CountFormFields(filePath); err == nil {
+		info.FormFieldCount = count
+	}
+
 	return nil
 }
 
@@ -258,253 +447,1142 @@ func (s *PDFServiceImpl) validatePDFInfo(info *PDFInfo) error {
 // getBasicPDFInfo 获取基本PDF信息（回退方法）
 func (s *PDFServiceImpl) getBasicPDFInfo(filePath string) (*PDFInfo, error) {
 	// 使用pdfcpu适配器获取信息
-	adapter, err := NewPDFCPUAdapter(nil)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
 		return nil, fmt.Errorf("pdfcpu不可用: %w", err)
 	}
-	defer adapter.Close()
 
-	// 获取文件信息
-	info, err := adapter.GetFileInfo(filePath)
-	if err != nil {
+	// 获取文件信息
+	info, err := adapter.GetFileInfo(filePath)
+	s.releaseAdapter(adapter, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取文件大小
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法获取文件信息",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	// 检查是否加密
+	isEncrypted, err := s.IsPDFEncrypted(filePath)
+	if err != nil {
+		// 如果无法确定加密状态，假设未加密
+		isEncrypted = false
+	}
+
+	// 补充信息
+	info.FileSize = fileInfo.Size()
+	info.IsEncrypted = isEncrypted
+	info.CreationDate = fileInfo.ModTime()
+	info.ModDate = fileInfo.ModTime()
+
+	// 如果没有标题，使用文件名
+	if info.Title == "" {
+		info.Title = getFileNameWithoutExt(filePath)
+	}
+
+	return info, nil
+}
+
+// IsPDFEncrypted 检查PDF文件是否加密
+func (s *PDFServiceImpl) IsPDFEncrypted(filePath string) (bool, error) {
+	// 首先进行基本验证
+	if err := s.basicFileValidation(filePath); err != nil {
+		return false, s.errorHandler.HandleError(err)
+	}
+
+	// 方法1：优先使用pdfcpu检查加密状态
+	if s.config.Load().PreferPDFCPU {
+		if encrypted, err := s.checkEncryptionWithPDFCPU(filePath); err == nil {
+			return encrypted, nil
+		}
+	}
+
+	// 方法2：使用增强读取器检查
+	if encrypted, err := s.checkEncryptionWithEnhancedReader(filePath); err == nil {
+		return encrypted, nil
+	}
+
+	// 方法3：使用基本内容检查（最后的回退）
+	return s.checkEncryptionByContent(filePath)
+}
+
+// 新增的加密检查方法
+
+// checkEncryptionWithPDFCPU 使用pdfcpu检查加密状态
+func (s *PDFServiceImpl) checkEncryptionWithPDFCPU(filePath string) (bool, error) {
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return false, err
+	}
+	info, err := adapter.GetFileInfo(filePath)
+	s.releaseAdapter(adapter, err)
+	if err != nil {
+		return false, err
+	}
+
+	return info.IsEncrypted, nil
+}
+
+// checkEncryptionWithEnhancedReader 使用增强读取器检查加密状态
+func (s *PDFServiceImpl) checkEncryptionWithEnhancedReader(filePath string) (bool, error) {
+	reader, err := NewPDFReaderWithConfig(filePath, s.config.Load().StreamingConfig)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	return reader.IsEncrypted()
+}
+
+// checkEncryptionByContent 通过文件内容检查加密状态（最后的回退方法）：
+// 解析trailer中的 /Encrypt 引用，而不是在文件中查找 /Filter 等几乎所有PDF都有的关键字
+func (s *PDFServiceImpl) checkEncryptionByContent(filePath string) (bool, error) {
+	return hasEncryptEntry(filePath)
+}
+
+// ValidatePDFStructure 验证PDF文件结构完整性
+func (s *PDFServiceImpl) ValidatePDFStructure(filePath string) error {
+	// 首先进行基本验证
+	if err := s.ValidatePDF(filePath); err != nil {
+		return err
+	}
+
+	// 使用增强的PDF读取器进行结构验证
+	reader, err := NewPDFReaderWithConfig(filePath, s.config.Load().StreamingConfig)
+	if err != nil {
+		// 如果无法使用增强读取器，进行基本结构检查
+		return s.validateBasicStructure(filePath)
+	}
+	defer reader.Close()
+
+	// 使用增强读取器验证结构
+	return reader.ValidateStructure()
+}
+
+// GetPDFMetadata 获取PDF文件元数据
+func (s *PDFServiceImpl) GetPDFMetadata(filePath string) (map[string]string, error) {
+	// 使用增强的PDF读取器获取元数据
+	reader, err := NewPDFReaderWithConfig(filePath, s.config.Load().StreamingConfig)
+	if err != nil {
+		// 如果无法使用增强读取器，返回基本元数据
+		return s.getBasicMetadata(filePath)
+	}
+	defer reader.Close()
+
+	return reader.GetMetadata()
+}
+
+// validateBasicStructure 基本结构验证（回退方法）
+func (s *PDFServiceImpl) validateBasicStructure(filePath string) error {
+	// 使用pdfcpu适配器进行基本验证
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return fmt.Errorf("pdfcpu不可用: %w", err)
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	// 验证文件
+	if err := adapter.ValidateFile(filePath); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "PDF结构损坏",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	// 获取文件信息以检查页数
+	info, err := adapter.GetFileInfo(filePath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法获取PDF信息",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	if info.PageCount <= 0 {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "PDF文件没有有效页面",
+			File:    filePath,
+		}
+	}
+
+	return nil
+}
+
+// getBasicMetadata 获取基本元数据（回退方法）
+func (s *PDFServiceImpl) getBasicMetadata(filePath string) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	// 获取文件信息
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法获取文件信息",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	// 添加基本文件信息
+	metadata["FileName"] = getFileNameWithoutExt(filePath)
+	metadata["FileSize"] = fmt.Sprintf("%d", fileInfo.Size())
+	metadata["ModificationDate"] = fileInfo.ModTime().Format("2006-01-02 15:04:05")
+
+	// 尝试获取PDF信息
+	if info, err := s.GetPDFInfo(filePath); err == nil {
+		metadata["PageCount"] = fmt.Sprintf("%d", info.PageCount)
+		metadata["IsEncrypted"] = fmt.Sprintf("%t", info.IsEncrypted)
+		if info.Title != "" {
+			metadata["Title"] = info.Title
+		}
+	}
+
+	return metadata, nil
+}
+
+// MergePDFs 将多个PDF文件合并为一个（使用流式处理）
+func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) error {
+	// 备份和覆盖输出之前确认输出不是某个输入
+	if err := CheckOutputNotInput(outputPath, append([]string{mainFile}, additionalFiles...)); err != nil {
+		return err
+	}
+	// 上次运行的清单旁路文件在写出本次清单时被替换，先读取
+	previous := s.previousManifest(outputPath)
+	s.backupOutput(outputPath, progressWriter)
+	result, err := s.mergePDFs(mainFile, additionalFiles, outputPath, progressWriter)
+	if err != nil {
+		return err
+	}
+	files := append([]string{mainFile}, additionalFiles...)
+	s.mergeAttachments(files, outputPath, progressWriter)
+	s.mergeForms(files, outputPath, progressWriter)
+	tocPages := 0
+	if s.config.Load().GenerateTOC {
+		tocPages = s.addTOC(files, outputPath, progressWriter)
+	}
+	if s.config.Load().SourceBookmarks {
+		s.addSourceBookmarks(files, outputPath, tocPages, progressWriter)
+	}
+	s.applyMetadata(mainFile, outputPath, progressWriter)
+	if err := s.stampOutput(files, outputPath, tocPages, progressWriter); err != nil {
+		return err
+	}
+	if err := s.imposeOutput(outputPath, progressWriter); err != nil {
+		return err
+	}
+	if s.config.Load().OptimizeOutput {
+		s.optimizeOutput(files, outputPath, progressWriter)
+	}
+	if err := s.encryptOutput(outputPath, progressWriter); err != nil {
+		return err
+	}
+	if s.config.Load().Linearize {
+		if err := s.linearizeOutput(outputPath, progressWriter); err != nil {
+			return err
+		}
+		result.Linearized = true
+	}
+
+	// 目录页、印章和拼版等步骤之后重新统计输出页数
+	result.OutputPath = outputPath
+	result.TOCPages = tocPages
+	if pages, err := CountPagesInFile(outputPath, nil); err == nil {
+		result.TotalPages = pages
+	}
+	if err := s.recordManifest(result, previous, outputPath); err != nil {
+		return err
+	}
+	if result.Delta != nil && progressWriter != nil {
+		fmt.Fprintf(progressWriter, "与上次相比: %s\n", result.Delta)
+	}
+	s.results.put(result)
+	return nil
+}
+
+// TakeMergeResult 返回并移除最近一次MergePDFs成功写出到outputPath的合并结果，没有时返回nil
+func (s *PDFServiceImpl) TakeMergeResult(outputPath string) *MergeResult {
+	return s.results.take(outputPath)
+}
+
+// backupOutput 启用BackupOutput且输出已存在时，在任何合并策略替换输出之前备份上一版输出，
+// 可用RollbackManager.RestoreLatest撤销本次合并；备份失败只提示，不影响合并
+func (s *PDFServiceImpl) backupOutput(outputPath string, progressWriter io.Writer) {
+	if !s.config.Load().BackupOutput || !fileExists(outputPath) {
+		return
+	}
+	config := backupConfig(s.config.Load().BackupDirectory, s.config.Load().BackupRetention, s.config.Load().Clock)
+	backupPath, err := NewRollbackManagerWithConfig(filepath.Dir(outputPath), config).BackupFile(outputPath)
+	if progressWriter == nil {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(progressWriter, "警告: 备份输出文件失败: %v\n", err)
+		return
+	}
+	fmt.Fprintf(progressWriter, "已备份原输出: %s\n", backupPath)
+}
+
+// linearizeOutput 无论使用哪种合并策略，都在最后线性化输出并确认结果
+func (s *PDFServiceImpl) linearizeOutput(outputPath string, progressWriter io.Writer) error {
+	if err := LinearizeFile(outputPath, outputPath); err != nil {
+		return err
+	}
+	linearized, err := IsLinearized(outputPath)
+	if err != nil {
+		return err
+	}
+	if !linearized {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "要求线性化的输出未线性化",
+			File:    outputPath,
+		}
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "输出已线性化（快速Web视图）\n")
+	}
+	return nil
+}
+
+// addTOC 在输出开头插入目录页并返回目录页数。目录是辅助信息，无法统计页数或添加失败时只输出警告并返回0。
+func (s *PDFServiceImpl) addTOC(files []string, outputPath string, progressWriter io.Writer) int {
+	sources := make([]TOCSource, len(files))
+	for i, file := range files {
+		sources[i] = TOCSource{File: file}
+	}
+	pages, err := s.insertTOC(outputPath, sources)
+	if err != nil {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: 添加目录页失败: %v\n", err)
+		}
+		return 0
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "已添加 %d 页目录\n", pages)
+	}
+	return pages
+}
+
+// insertTOC 统计各来源的页数并在outputPath开头插入目录页，返回目录页数
+func (s *PDFServiceImpl) insertTOC(outputPath string, sources []TOCSource) (int, error) {
+	entries, err := tocEntries(sources, s.config.Load().PageTreeLimits)
+	if err != nil {
+		return 0, err
+	}
+	return AddTOCPages(outputPath, outputPath, entries)
+}
+
+// mergeAttachments 把各输入的附件合并到输出，配置了DropAttachments时移除输出中的附件。
+// 不是所有合并策略都保留附件，因此在合并之后统一处理；附件是辅助信息，失败时只输出警告。
+func (s *PDFServiceImpl) mergeAttachments(files []string, outputPath string, progressWriter io.Writer) {
+	if s.config.Load().DropAttachments {
+		removed, err := RemoveAttachments(outputPath)
+		if progressWriter != nil && err != nil {
+			fmt.Fprintf(progressWriter, "警告: 移除附件失败: %v\n", err)
+		} else if progressWriter != nil && removed > 0 {
+			fmt.Fprintf(progressWriter, "已移除 %d 个附件\n", removed)
+		}
+		return
+	}
+
+	report, err := MergeAttachments(outputPath, files)
+	if err != nil {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: 合并附件失败: %v\n", err)
+		}
+		return
+	}
+	if progressWriter == nil {
+		return
+	}
+	for _, rename := range report.Renamed {
+		fmt.Fprintf(progressWriter, "警告: %s 的附件 %s 与之前的附件重名，已改名为 %s\n",
+			filepath.Base(rename.File), rename.From, rename.To)
+	}
+	if report.Count > 0 {
+		fmt.Fprintf(progressWriter, "已合并 %d 个附件\n", report.Count)
+	}
+}
+
+// mergeForms 合并各输入的表单字段，配置了FlattenForms时把字段展平到页面内容。
+// 与附件相同，在合并之后统一处理；失败时只输出警告。
+func (s *PDFServiceImpl) mergeForms(files []string, outputPath string, progressWriter io.Writer) {
+	if s.config.Load().FlattenForms {
+		report, err := FlattenForms(outputPath, outputPath)
+		if progressWriter == nil {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(progressWriter, "警告: 展平表单失败: %v\n", err)
+			return
+		}
+		for _, name := range report.WithoutAppearance {
+			fmt.Fprintf(progressWriter, "警告: 表单字段 %s 没有外观，展平后不显示\n", name)
+		}
+		if report.Fields > 0 {
+			fmt.Fprintf(progressWriter, "已展平 %d 个表单控件\n", report.Fields)
+		}
+		return
+	}
+
+	sources := make([]InputPageCount, len(files))
+	for i, file := range files {
+		pages, err := CountPagesInFile(file, s.config.Load().PageTreeLimits)
+		if err != nil {
+			if progressWriter != nil {
+				fmt.Fprintf(progressWriter, "警告: 无法统计 %s 的页数，未合并表单\n", filepath.Base(file))
+			}
+			return
+		}
+		sources[i] = InputPageCount{File: file, Pages: pages}
+	}
+	report, err := MergeForms(outputPath, sources)
+	if err != nil {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: 合并表单失败: %v\n", err)
+		}
+		return
+	}
+	if progressWriter == nil {
+		return
+	}
+	for _, rename := range report.Renamed {
+		fmt.Fprintf(progressWriter, "警告: %s 的表单字段 %s 与之前的字段重名，已改名为 %s\n",
+			filepath.Base(files[rename.Source]), rename.From, rename.To)
+	}
+	if report.Fields > 0 {
+		fmt.Fprintf(progressWriter, "已合并 %d 个表单字段\n", report.Fields)
+	}
+}
+
+// addSourceBookmarks 按各输入的页数为输出添加来源书签，在线性化之前执行。tocPages 为输出开头的目录页数。
+// 书签是辅助信息，无法统计页数或添加失败时只输出警告。
+func (s *PDFServiceImpl) addSourceBookmarks(files []string, outputPath string, tocPages int, progressWriter io.Writer) {
+	sources := make([]SourceBookmark, len(files))
+	page := 1 + tocPages
+	for i, file := range files {
+		pages, err := CountPagesInFile(file, s.config.Load().PageTreeLimits)
+		if err != nil {
+			if progressWriter != nil {
+				fmt.Fprintf(progressWriter, "警告: 无法统计 %s 的页数，未添加来源书签\n", filepath.Base(file))
+			}
+			return
+		}
+		sources[i] = SourceBookmark{Title: SourceBookmarkTitle(file, file), Page: page, Pages: pages}
+		page += pages
+	}
+	if err := AddSourceBookmarks(outputPath, outputPath, sources); err != nil {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: 添加来源书签失败: %v\n", err)
+		}
+		return
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "已添加 %d 个来源书签\n", len(sources))
+	}
+}
+
+// applyMetadata 按服务配置设置输出的元数据，元数据来源为空或none时不做任何事；失败时只提示
+func (s *PDFServiceImpl) applyMetadata(mainFile, outputPath string, progressWriter io.Writer) {
+	source := s.config.Load().MetadataSource
+	if source == "" || source == MetadataSourceNone {
+		return
+	}
+	if err := ApplyDocumentMetadata(outputPath, source, mainFile, s.config.Load().CustomMetadata); err != nil {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: 设置输出元数据失败: %v\n", err)
+		}
+		return
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "已设置输出元数据（来源: %s）\n", source)
+	}
+}
+
+// stampOutput 按服务配置为输出添加印章，未配置印章时不做任何事。
+// 印章是要求的输出内容，添加失败时删除输出并返回错误。
+func (s *PDFServiceImpl) stampOutput(files []string, outputPath string, tocPages int, progressWriter io.Writer) error {
+	if len(s.config.Load().Stamps) == 0 {
+		return nil
+	}
+	if err := validateStamps(s.config.Load().Stamps); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+
+	// 来源页数只用于 {filename}，无法统计时使用输出的文件名；目录页也使用输出的文件名
+	sources := make([]InputPageCount, 0, len(files)+1)
+	if tocPages > 0 {
+		sources = append(sources, InputPageCount{File: outputPath, Pages: tocPages})
+	}
+	for _, file := range files {
+		pages, err := CountPagesInFile(file, s.config.Load().PageTreeLimits)
+		if err != nil {
+			sources = nil
+			break
+		}
+		sources = append(sources, InputPageCount{File: file, Pages: pages})
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		os.Remove(outputPath)
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建印章后端",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	if err := adapter.StampFile(outputPath, outputPath, s.config.Load().Stamps, sources); err != nil {
+		os.Remove(outputPath)
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法为合并输出添加印章",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "已添加 %d 个印章\n", len(s.config.Load().Stamps))
+	}
+	return nil
+}
+
+// imposeOutput 按配置对输出拼版。拼版失败时删除输出并返回错误，不保留未拼版的输出
+func (s *PDFServiceImpl) imposeOutput(outputPath string, progressWriter io.Writer) error {
+	imposition := s.config.Load().Imposition
+	if !imposition.Enabled() {
+		return nil
+	}
+	if err := imposition.Validate(); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	if s.config.Load().SourceBookmarks && progressWriter != nil {
+		fmt.Fprintf(progressWriter, "警告: 拼版后书签指向的页面不再在输出中，来源书签将被移除\n")
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		os.Remove(outputPath)
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建拼版后端",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	pages, err := adapter.ImposeFile(outputPath, outputPath, imposition)
+	if err != nil {
+		os.Remove(outputPath)
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法为合并输出拼版",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "已拼版为 %d 页\n", pages)
+	}
+	return nil
+}
+
+// optimizeOutput 优化输出。输入包含数字签名或输出已加密时跳过；优化失败时保留未优化的输出，只输出警告。
+func (s *PDFServiceImpl) optimizeOutput(files []string, outputPath string, progressWriter io.Writer) {
+	warn := func(format string, args ...interface{}) {
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "警告: "+format+"\n", args...)
+		}
+	}
+	for _, file := range files {
+		if signed, err := HasDigitalSignatures(file); err == nil && signed {
+			warn("%s 包含数字签名，跳过输出优化", filepath.Base(file))
+			return
+		}
+	}
+	if encrypted, err := hasEncryptEntry(outputPath); err == nil && encrypted {
+		warn("合并输出已加密，跳过输出优化")
+		return
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		warn("无法创建优化后端: %v", err)
+		return
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	options := &OptimizeOptions{ImageDPI: s.config.Load().OptimizeImagesDPI, ClassicXRef: s.config.Load().Linearize}
+	report, err := adapter.OptimizeOutput(outputPath, outputPath, options)
+	if err != nil {
+		warn("优化输出失败: %v", err)
+		return
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "输出已优化: %d → %d 字节\n", report.OriginalSize, report.OptimizedSize)
+	}
+}
+
+// encryptOutput 按服务配置加密输出并使用密码重新验证，未配置密码时不做任何事
+func (s *PDFServiceImpl) encryptOutput(outputPath string, progressWriter io.Writer) error {
+	if err := validateEncryptionOptions(s.config.Load().OutputUserPassword, s.config.Load().OutputOwnerPassword, s.config.Load().OutputPermissions); err != nil {
+		return err
+	}
+	encryption := newOutputEncryption(s.config.Load().OutputUserPassword, s.config.Load().OutputOwnerPassword, s.config.Load().OutputPermissions)
+	if encryption == nil {
+		return nil
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建加密后端",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	// 要求加密时不保留未加密的输出
+	if err := encryptInPlace(s.files(adapter), outputPath, encryption); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	if err := adapter.ValidateFileWithPassword(outputPath, encryption.validationPassword()); err != nil {
+		os.Remove(outputPath)
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "加密后的PDF文件无效",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	if progressWriter != nil {
+		fmt.Fprintf(progressWriter, "输出已加密\n")
+	}
+	return nil
+}
+
+// SetPageBoxes 调整文件每一页的CropBox/TrimBox并写入outputPath，输入与输出可以相同
+func (s *PDFServiceImpl) SetPageBoxes(inputPath, outputPath string, adjustment *PageBoxAdjustment) error {
+	if err := s.basicFileValidation(inputPath); err != nil {
+		return err
+	}
+	return SetPageBoxes(inputPath, outputPath, adjustment)
+}
+
+// PreviewPageBoxes 返回调整后每一页的页面框，不修改文件；adjustment为nil时返回当前的页面框
+func (s *PDFServiceImpl) PreviewPageBoxes(filePath string, adjustment *PageBoxAdjustment) ([]PageBoxes, error) {
+	if err := s.basicFileValidation(filePath); err != nil {
+		return nil, err
+	}
+	return PreviewPageBoxes(filePath, adjustment)
+}
+
+// ExtractPages 验证输入后按给定顺序提取页面，并确认输出文件有效
+func (s *PDFServiceImpl) ExtractPages(inputPath string, pages []int, outputPath string) error {
+	if err := s.ValidatePDF(inputPath); err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "没有指定要提取的页面",
+			File:    inputPath,
+		}
+	}
+
+	pageCount, err := CountPagesInFile(inputPath, s.config.Load().PageTreeLimits)
+	if err != nil {
+		return err
+	}
+	for _, page := range pages {
+		if page < 1 || page > pageCount {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("第%d页超出文档页数 %d", page, pageCount),
+				File:    inputPath,
+			}
+		}
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	if err := adapter.ExtractPages(inputPath, outputPath, pages); err != nil {
+		return err
+	}
+
+	if err := s.validateOutputFile(outputPath); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "提取页面后的PDF文件无效",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// ExtractPageRanges 解析页码范围字符串（例如 "1-5,8"、"9-"）后提取页面
+func (s *PDFServiceImpl) ExtractPageRanges(inputPath string, ranges string, outputPath string) error {
+	parsed, err := ParsePageRanges(inputPath, ranges)
+	if err != nil {
+		return err
+	}
+	pageCount, err := CountPagesInFile(inputPath, s.config.Load().PageTreeLimits)
+	if err != nil {
+		return err
+	}
+	pages, err := FileRangeSpec{File: inputPath, Ranges: parsed}.ResolvePages(pageCount)
+	if err != nil {
+		return err
+	}
+	return s.ExtractPages(inputPath, pages, outputPath)
+}
+
+// DecryptPDF 使用密码移除PDF的加密并写出到outputPath。配置了PasswordVault时，
+// password为空则使用保险库中按内容哈希保存的密码，给出的密码解密成功后保存到保险库。
+// 输入未加密时直接复制；密码错误返回ErrorEncrypted，文件损坏或解密结果无效返回ErrorCorrupted。
+// 结果先写到临时文件，验证通过后才替换outputPath，失败时不会留下未完成的输出。
+func (s *PDFServiceImpl) DecryptPDF(inputPath, outputPath, password string) error {
+	if err := s.basicFileValidation(inputPath); err != nil {
+		return err
+	}
+
+	encrypted, err := hasEncryptEntry(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+
+	if !encrypted {
+		if err := s.copyFile(inputPath, staging); err != nil {
+			return err
+		}
+	} else if err := s.decryptWithVault(inputPath, staging, password); err != nil {
+		return err
+	}
+
+	if err := s.validateOutputFile(staging); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "解密后的PDF文件无效",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+
+	return commitOutput(staging, outputPath)
+}
+
+// decryptWithVault 解密加密输入。password为空时先尝试保险库中保存的密码，失效的条目被删除；
+// 给出的密码解密成功后保存到保险库
+func (s *PDFServiceImpl) decryptWithVault(inputPath, outputPath, password string) error {
+	vault := s.config.Load().PasswordVault
+	if password == "" {
+		if saved, ok := LookupVaultPassword(vault, inputPath); ok {
+			if err := s.decryptToFile(inputPath, outputPath, saved); err == nil {
+				return nil
+			}
+			ForgetPassword(vault, inputPath)
+		}
+	}
+	if err := s.decryptToFile(inputPath, outputPath, password); err != nil {
+		return err
+	}
+	if password != "" {
+		RememberPassword(vault, inputPath, password)
+	}
+	return nil
+}
+
+// RepairPDF 修复轻度损坏的PDF并写出到outputPath，输入文件不会被修改。
+// 结果先写到临时文件，确认有效后才替换outputPath；无法修复时返回错误且不留下输出。
+func (s *PDFServiceImpl) RepairPDF(inputPath, outputPath string) (*RepairReport, error) {
+	if err := checkRepairPaths(inputPath, outputPath); err != nil {
+		return nil, err
+	}
+	if err := s.basicFileValidation(inputPath); err != nil {
+		return nil, err
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建修复后端",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+
+	report, err := adapter.RepairPDF(inputPath, staging)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateOutputFile(staging); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "修复后的PDF文件无效",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if err := commitOutput(staging, outputPath); err != nil {
+		return nil, err
+	}
+	report.OutputPath = outputPath
+	return report, nil
+}
+
+// StampPDF 把页码或水印添加到每一页并写出到outputPath，输入文件不会被修改（除非两者相同）。
+// 结果先写到临时文件，确认有效后才替换outputPath。
+func (s *PDFServiceImpl) StampPDF(inputPath, outputPath string, opts *StampOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := s.basicFileValidation(inputPath); err != nil {
+		return err
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建印章后端",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+
+	if err := adapter.StampFile(inputPath, staging, []*StampOptions{opts}, nil); err != nil {
+		return err
+	}
+	if err := s.validateOutputFile(staging); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "添加印章后的PDF文件无效",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	return commitOutput(staging, outputPath)
+}
+
+// AddTableOfContents 在已合并的outputPath开头插入目录页，sources为按合并顺序排列的来源。
+// 用于按任务选择是否生成目录的调用方（如GUI），服务配置了GenerateTOC时MergePDFs已添加目录。
+func (s *PDFServiceImpl) AddTableOfContents(outputPath string, sources []TOCSource) error {
+	if err := s.basicFileValidation(outputPath); err != nil {
+		return err
+	}
+	_, err := s.insertTOC(outputPath, sources)
+	return err
+}
+
+// RotatePDF 旋转每一页并可选地规范页面方向（见RotatePages和NormalizeOrientation），
+// 结果先写入临时文件，验证通过后才替换outputPath
+func (s *PDFServiceImpl) RotatePDF(inputPath, outputPath string, degrees int, normalize bool) ([]int, error) {
+	if err := validateRotation(inputPath, degrees); err != nil {
+		return nil, err
+	}
+	if err := s.basicFileValidation(inputPath); err != nil {
+		return nil, err
+	}
+
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建页面旋转后端",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	defer s.releaseAdapter(adapter, nil)
+
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+
+	if err := adapter.RotateFile(inputPath, staging, degrees); err != nil {
+		return nil, err
+	}
+	var kept []int
+	if normalize {
+		if kept, err = adapter.NormalizeOrientation(staging, staging); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateOutputFile(staging); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "旋转后的PDF文件无效",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if err := commitOutput(staging, outputPath); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// SplitPDF 按opts把inputPath拆分为多个文件并写入输出目录（默认为输入文件所在目录）。
+// 每个文件先写入临时文件，验证通过后才移动到不与已有文件重名的最终路径；出错时返回已写出的文件和错误
+func (s *PDFServiceImpl) SplitPDF(inputPath string, opts *SplitOptions) ([]string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.ValidatePDF(inputPath); err != nil {
 		return nil, err
 	}
 
-	// 获取文件大小
-	fileInfo, err := os.Stat(filePath)
+	data, err := os.ReadFile(inputPath)
 	if err != nil {
 		return nil, &PDFError{
 			Type:    ErrorIO,
-			Message: "无法获取文件信息",
-			File:    filePath,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
 			Cause:   err,
 		}
 	}
-
-	// 检查是否加密
-	isEncrypted, err := s.IsPDFEncrypted(filePath)
+	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	parts, err := planSplit(inputPath, data, base, opts, s.config.Load().PageTreeLimits)
 	if err != nil {
-		// 如果无法确定加密状态，假设未加密
-		isEncrypted = false
+		return nil, err
 	}
 
-	// 补充信息
-	info.FileSize = fileInfo.Size()
-	info.IsEncrypted = isEncrypted
-	info.CreationDate = fileInfo.ModTime()
-	info.ModDate = fileInfo.ModTime()
-
-	// 如果没有标题，使用文件名
-	if info.Title == "" {
-		info.Title = getFileNameWithoutExt(filePath)
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(inputPath)
 	}
-
-	return info, nil
-}
-
-// IsPDFEncrypted 检查PDF文件是否加密
-func (s *PDFServiceImpl) IsPDFEncrypted(filePath string) (bool, error) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// 首先进行基本验证
-	if err := s.basicFileValidation(filePath); err != nil {
-		return false, s.errorHandler.HandleError(err)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, &PDFError{
+			Type:    ErrorPermission,
+			Message: "无法创建输出目录",
+			File:    outputDir,
+			Cause:   err,
+		}
 	}
 
-	// 方法1：优先使用pdfcpu检查加密状态
-	if s.config.PreferPDFCPU {
-		if encrypted, err := s.checkEncryptionWithPDFCPU(filePath); err == nil {
-			return encrypted, nil
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建拆分后端",
+			File:    inputPath,
+			Cause:   err,
 		}
 	}
+	defer s.releaseAdapter(adapter, nil)
 
-	// 方法2：使用增强读取器检查
-	if encrypted, err := s.checkEncryptionWithEnhancedReader(filePath); err == nil {
-		return encrypted, nil
+	used := make(map[string]bool)
+	written := make([]string, 0, len(parts))
+	for i, part := range parts {
+		outputPath := uniqueOutputPath(outputDir, part.Name, used)
+		if err := s.writeSplitPart(adapter, inputPath, outputPath, part.Pages); err != nil {
+			return written, err
+		}
+		written = append(written, outputPath)
+		if opts.Progress != nil {
+			opts.Progress(float64(i+1)/float64(len(parts)),
+				fmt.Sprintf("已写出 %s（%d 页，%d/%d）", filepath.Base(outputPath), len(part.Pages), i+1, len(parts)))
+		}
 	}
-
-	// 方法3：使用基本内容检查（最后的回退）
-	return s.checkEncryptionByContent(filePath)
+	return written, nil
 }
 
-// 新增的加密检查方法
+// writeSplitPart 把pages提取到临时文件，验证通过后移动到outputPath
+func (s *PDFServiceImpl) writeSplitPart(adapter *PDFCPUAdapter, inputPath, outputPath string, pages []int) error {
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
 
-// checkEncryptionWithPDFCPU 使用pdfcpu检查加密状态
-func (s *PDFServiceImpl) checkEncryptionWithPDFCPU(filePath string) (bool, error) {
-	adapter, err := NewPDFCPUAdapter(nil)
-	if err != nil {
-		return false, err
+	if err := adapter.ExtractPages(inputPath, staging, pages); err != nil {
+		return err
 	}
-	defer adapter.Close()
-
-	info, err := adapter.GetFileInfo(filePath)
-	if err != nil {
-		return false, err
+	if err := s.validateOutputFile(staging); err != nil {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "拆分出的PDF文件无效",
+			File:    outputPath,
+			Cause:   err,
+		}
 	}
-
-	return info.IsEncrypted, nil
+	return commitOutput(staging, outputPath)
 }
 
-// checkEncryptionWithEnhancedReader 使用增强读取器检查加密状态
-func (s *PDFServiceImpl) checkEncryptionWithEnhancedReader(filePath string) (bool, error) {
-	reader, err := NewPDFReader(filePath)
-	if err != nil {
-		return false, err
+// ListAttachments 列出文件中的附件，见包函数ListAttachments
+func (s *PDFServiceImpl) ListAttachments(filePath string) ([]AttachmentInfo, error) {
+	if err := s.basicFileValidation(filePath); err != nil {
+		return nil, err
 	}
-	defer reader.Close()
-
-	return reader.IsEncrypted()
+	return ListAttachments(filePath)
 }
 
-// checkEncryptionByContent 通过文件内容检查加密状态（最后的回退方法）
-func (s *PDFServiceImpl) checkEncryptionByContent(filePath string) (bool, error) {
-	file, err := os.Open(filePath)
+// ExtractText 提取pages（为空时为全部页面）的文字，见包函数ExtractPageTexts
+func (s *PDFServiceImpl) ExtractText(filePath string, pages []int) (map[int]string, error) {
+	texts := make(map[int]string)
+	err := s.extractText(filePath, pages, func(page PageText) error {
+		texts[page.Page] = page.Text
+		return nil
+	})
 	if err != nil {
-		return false, err
-	}
-	defer file.Close()
-
-	// 读取文件的前4KB内容
-	buffer := make([]byte, 4096)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
-		return false, err
+		return nil, err
 	}
+	return texts, nil
+}
 
-	content := string(buffer[:n])
+// ExtractTextTo 逐页提取文字并写到w，每页之后写一个换页符，见WritePageText
+func (s *PDFServiceImpl) ExtractTextTo(filePath string, pages []int, w io.Writer) error {
+	return s.extractText(filePath, pages, func(page PageText) error {
+		return WritePageText(w, page)
+	})
+}
 
-	// 查找加密相关的关键字
-	encryptionKeywords := []string{
-		"/Encrypt",
-		"/Filter",
-		"/V ",
-		"/R ",
-		"/O ",
-		"/U ",
-		"/P ",
-		"Standard",
-		"Security",
+// extractText 由适配器逐页提取文字，警告写入适配器的日志
+func (s *PDFServiceImpl) extractText(filePath string, pages []int, emit TextPageFunc) error {
+	if err := s.basicFileValidation(filePath); err != nil {
+		return err
 	}
 
-	for _, keyword := range encryptionKeywords {
-		if strings.Contains(content, keyword) {
-			return true, nil
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建文字提取后端",
+			File:    filePath,
+			Cause:   err,
 		}
 	}
+	err = adapter.ExtractText(filePath, pages, emit)
+	s.releaseAdapter(adapter, err)
+	return err
+}
 
-	return false, nil
+// ExtractImages 把pages（为空时为全部页面）中的图像写到outputDir，见包函数ExtractImages
+func (s *PDFServiceImpl) ExtractImages(filePath string, pages []int, outputDir string) ([]ExtractedImage, error) {
+	return s.ExtractImagesContext(context.Background(), filePath, pages, outputDir)
 }
 
-// ValidatePDFStructure 验证PDF文件结构完整性
-func (s *PDFServiceImpl) ValidatePDFStructure(filePath string) error {
-	// 首先进行基本验证
-	if err := s.ValidatePDF(filePath); err != nil {
-		return err
+// ExtractImagesContext 与ExtractImages相同，ctx结束时返回已写出的图像和ctx.Err()；无法导出的图像写入适配器的日志
+func (s *PDFServiceImpl) ExtractImagesContext(ctx context.Context, filePath string, pages []int, outputDir string) ([]ExtractedImage, error) {
+	if err := s.basicFileValidation(filePath); err != nil {
+		return nil, err
 	}
 
-	// 使用增强的PDF读取器进行结构验证
-	reader, err := NewPDFReader(filePath)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
-		// 如果无法使用增强读取器，进行基本结构检查
-		return s.validateBasicStructure(filePath)
+		return nil, &PDFError{
+			Type:    ErrorProcessing,
+			Message: "无法创建图像提取后端",
+			File:    filePath,
+			Cause:   err,
+		}
 	}
-	defer reader.Close()
-
-	// 使用增强读取器验证结构
-	return reader.ValidateStructure()
+	images, err := adapter.ExtractImages(ctx, filePath, pages, outputDir, s.config.Load().MinInlineImagePixels)
+	s.releaseAdapter(adapter, err)
+	return images, err
 }
 
-// GetPDFMetadata 获取PDF文件元数据
-func (s *PDFServiceImpl) GetPDFMetadata(filePath string) (map[string]string, error) {
-	// 使用增强的PDF读取器获取元数据
-	reader, err := NewPDFReader(filePath)
-	if err != nil {
-		// 如果无法使用增强读取器，返回基本元数据
-		return s.getBasicMetadata(filePath)
+// ValidateConformance 读取文件声明的PDF/A、PDF/X符合性，见包函数ValidateConformance
+func (s *PDFServiceImpl) ValidateConformance(filePath string) (*ConformanceReport, error) {
+	if err := s.basicFileValidation(filePath); err != nil {
+		return nil, err
 	}
-	defer reader.Close()
-
-	return reader.GetMetadata()
+	return ValidateConformance(filePath)
 }
 
-// validateBasicStructure 基本结构验证（回退方法）
-func (s *PDFServiceImpl) validateBasicStructure(filePath string) error {
-	// 使用pdfcpu适配器进行基本验证
-	adapter, err := NewPDFCPUAdapter(nil)
+// decryptToFile 解密inputPath到outputPath，并确认结果确实已不再加密
+func (s *PDFServiceImpl) decryptToFile(inputPath, outputPath, password string) error {
+	adapter, err := s.acquireAdapter()
 	if err != nil {
-		return fmt.Errorf("pdfcpu不可用: %w", err)
-	}
-	defer adapter.Close()
-
-	// 验证文件
-	if err := adapter.ValidateFile(filePath); err != nil {
 		return &PDFError{
-			Type:    ErrorCorrupted,
-			Message: "PDF结构损坏",
-			File:    filePath,
+			Type:    ErrorProcessing,
+			Message: "无法创建解密后端",
+			File:    inputPath,
 			Cause:   err,
 		}
 	}
+	defer s.releaseAdapter(adapter, nil)
 
-	// 获取文件信息以检查页数
-	info, err := adapter.GetFileInfo(filePath)
-	if err != nil {
+	if err := s.files(adapter).DecryptFile(inputPath, outputPath, password); err != nil {
+		if isPasswordError(err) {
+			return &PDFError{
+				Type:    ErrorEncrypted,
+				Message: "密码错误，无法解密文件",
+				File:    inputPath,
+				Cause:   err,
+			}
+		}
 		return &PDFError{
 			Type:    ErrorCorrupted,
-			Message: "无法获取PDF信息",
-			File:    filePath,
+			Message: "无法解密文件，文件可能已损坏",
+			File:    inputPath,
 			Cause:   err,
 		}
 	}
 
-	if info.PageCount <= 0 {
+	if !fileExists(outputPath) {
 		return &PDFError{
-			Type:    ErrorCorrupted,
-			Message: "PDF文件没有有效页面",
-			File:    filePath,
+			Type:    ErrorProcessing,
+			Message: "解密后没有生成文件",
+			File:    inputPath,
 		}
 	}
-
-	return nil
-}
-
-// getBasicMetadata 获取基本元数据（回退方法）
-func (s *PDFServiceImpl) getBasicMetadata(filePath string) (map[string]string, error) {
-	metadata := make(map[string]string)
-
-	// 获取文件信息
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		return nil, &PDFError{
-			Type:    ErrorIO,
-			Message: "无法获取文件信息",
-			File:    filePath,
+	if stillEncrypted, err := hasEncryptEntry(outputPath); err != nil || stillEncrypted {
+		return &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "解密后的文件仍然加密",
+			File:    inputPath,
 			Cause:   err,
 		}
 	}
-
-	// 添加基本文件信息
-	metadata["FileName"] = getFileNameWithoutExt(filePath)
-	metadata["FileSize"] = fmt.Sprintf("%d", fileInfo.Size())
-	metadata["ModificationDate"] = fileInfo.ModTime().Format("2006-01-02 15:04:05")
-
-	// 尝试获取PDF信息
-	if info, err := s.GetPDFInfo(filePath); err == nil {
-		metadata["PageCount"] = fmt.Sprintf("%d", info.PageCount)
-		metadata["IsEncrypted"] = fmt.Sprintf("%t", info.IsEncrypted)
-		if info.Title != "" {
-			metadata["Title"] = info.Title
-		}
-	}
-
-	return metadata, nil
+	return nil
 }
 
-// MergePDFs 将多个PDF文件合并为一个（使用流式处理）
-func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// mergePDFs 按策略依次尝试合并，成功时返回合并结果
+func (s *PDFServiceImpl) mergePDFs(mainFile string, additionalFiles []string, outputPath string, progressWriter io.Writer) (*MergeResult, error) {
+	clk := clock.OrSystem(s.config.Load().Clock)
+	startTime := clk.Now()
+	startMemory := heapAlloc()
 
 	// 预处理：验证所有输入文件
 	allFiles := []string{mainFile}
@@ -514,10 +1592,10 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 		fmt.Fprintf(progressWriter, "开始合并 %d 个PDF文件...\n", len(allFiles))
 	}
 
-	// 验证所有输入文件 - 在验证期间释放锁以避免死锁
-	s.mutex.Unlock()
+	// 验证所有输入文件
 	errorCollector := NewErrorCollector()
 	validFiles := make([]string, 0, len(allFiles))
+	skipReasons := make(map[string]string)
 
 	for i, file := range allFiles {
 		if progressWriter != nil {
@@ -526,6 +1604,7 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 
 		if err := s.ValidatePDF(file); err != nil {
 			errorCollector.Add(fmt.Errorf("文件 %s 验证失败: %w", file, err))
+			skipReasons[file] = err.Error()
 			if progressWriter != nil {
 				fmt.Fprintf(progressWriter, "警告: 跳过无效文件 %s: %v\n", file, err)
 			}
@@ -533,30 +1612,58 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 			validFiles = append(validFiles, file)
 		}
 	}
-	s.mutex.Lock() // 重新获取锁
+
+	// 部分结果，失败时随错误返回
+	partial := &MergeResult{
+		OutputPath:     outputPath,
+		ValidatedFiles: validFiles,
+		SkippedFiles:   make([]string, 0),
+	}
+	valid := make(map[string]bool, len(validFiles))
+	for _, file := range validFiles {
+		valid[file] = true
+	}
+	for _, file := range allFiles {
+		if !valid[file] {
+			partial.skipInput(file, skipReasons[file])
+		}
+	}
+	for _, err := range errorCollector.GetErrors() {
+		partial.Warnings = append(partial.Warnings, err.Error())
+	}
 
 	// 检查是否有足够的有效文件进行合并
 	if len(validFiles) == 0 {
-		return &PDFError{
+		partial.FailedStage = MergeStageValidation
+		return nil, &PDFError{
 			Type:    ErrorInvalidFile,
 			Message: "没有有效的PDF文件可以合并",
 			File:    "",
-			Cause:   fmt.Errorf("validation errors: %s", errorCollector.GetSummary()),
+			Cause: &MergeError{
+				Result: partial,
+				Err:    fmt.Errorf("validation errors: %s", errorCollector.GetSummary()),
+			},
 		}
 	}
 
-	if len(validFiles) == 1 {
+	// 输出上限只由流式合并器检查，设置了上限时不使用其他合并方式
+	limited := s.config.Load().MaxOutputSize > 0 || s.config.Load().MaxOutputPages > 0
+
+	if len(validFiles) == 1 && !limited {
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "只有一个有效文件，直接复制到输出位置\n")
 		}
-		return s.copyFile(validFiles[0], outputPath)
+		if err := s.copyFile(validFiles[0], outputPath); err != nil {
+			return nil, err
+		}
+		return completedResult(partial, validFiles, clk.Now().Sub(startTime), startMemory), nil
 	}
 
 	// 尝试不同的合并策略
 	var mergeError error
 
 	// 策略1：优先使用pdfcpu合并（如果配置启用）
-	if s.config.PreferPDFCPU {
+	if s.config.Load().PreferPDFCPU && !limited {
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "尝试使用pdfcpu进行合并...\n")
 		}
@@ -565,7 +1672,7 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 			if progressWriter != nil {
 				fmt.Fprintf(progressWriter, "pdfcpu合并成功完成\n")
 			}
-			return nil
+			return completedResult(partial, validFiles, clk.Now().Sub(startTime), startMemory), nil
 		} else {
 			mergeError = err
 			if progressWriter != nil {
@@ -579,16 +1686,35 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 		fmt.Fprintf(progressWriter, "使用流式合并器进行合并...\n")
 	}
 
-	if err := s.mergeWithStreamingMerger(validFiles, outputPath, progressWriter); err == nil {
+	if result, err := s.mergeWithStreamingMerger(validFiles, outputPath, progressWriter); err == nil {
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "流式合并成功完成\n")
 		}
-		return nil
+		return withValidationSkips(result, partial), nil
 	} else {
 		mergeError = err
+		if streamingPartial := PartialMergeResult(err); streamingPartial != nil {
+			// 流式合并器给出的部分结果更详细
+			streamingPartial.Warnings = append(partial.Warnings, streamingPartial.Warnings...)
+			partial = streamingPartial
+		}
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "流式合并失败: %v\n", err)
 		}
+		// 超出输出上限时其他合并方式的结果同样超出，不再回退
+		if isLimitExceeded(err) {
+			if partial.FailedStage == "" {
+				partial.FailedStage = MergeStageValidation
+			}
+			var pdfErr *PDFError
+			errors.As(err, &pdfErr)
+			return nil, &PDFError{
+				Type:    ErrorLimitExceeded,
+				Message: pdfErr.Message,
+				File:    outputPath,
+				Cause:   &MergeError{Result: partial, Err: err},
+			}
+		}
 	}
 
 	// 策略3：基本合并（最后的回退）
@@ -600,36 +1726,65 @@ func (s *PDFServiceImpl) MergePDFs(mainFile string, additionalFiles []string, ou
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "基本合并成功完成\n")
 		}
-		return nil
+		return completedResult(partial, validFiles, clk.Now().Sub(startTime), startMemory), nil
 	} else {
 		mergeError = err
 	}
 
 	// 所有合并策略都失败
-	return &PDFError{
+	if partial.FailedStage == "" {
+		partial.FailedStage = MergeStageMerging
+	}
+	return nil, &PDFError{
 		Type:    ErrorProcessing,
 		Message: "所有合并策略都失败",
 		File:    outputPath,
-		Cause:   mergeError,
+		Cause:   &MergeError{Result: partial, Err: mergeError},
+	}
+}
+
+// completedResult 补全pdfcpu合并、基本合并或直接复制成功后的合并结果。这些方式没有内存监控，
+// PeakMemory取合并前后读到的较大堆分配
+func completedResult(partial *MergeResult, validFiles []string, elapsed time.Duration, startMemory int64) *MergeResult {
+	partial.ProcessedFiles = len(validFiles)
+	partial.ProcessingTime = elapsed
+	partial.PeakMemory = max(startMemory, heapAlloc())
+	return partial
+}
+
+// withValidationSkips 在流式合并器的结果中补上合并前验证跳过的输入及其警告
+func withValidationSkips(result, partial *MergeResult) *MergeResult {
+	result.SkippedFiles = append(append([]string(nil), partial.SkippedFiles...), result.SkippedFiles...)
+	if len(partial.SkipReasons) > 0 && result.SkipReasons == nil {
+		result.SkipReasons = make(map[string]string, len(partial.SkipReasons))
 	}
+	for file, reason := range partial.SkipReasons {
+		result.SkipReasons[file] = reason
+	}
+	result.Warnings = append(partial.Warnings, result.Warnings...)
+	return result
 }
 
 // 新增的合并方法
 
 // mergeWithPDFCPU 使用pdfcpu进行合并
 func (s *PDFServiceImpl) mergeWithPDFCPU(files []string, outputPath string, progressWriter io.Writer) error {
-	adapter, err := NewPDFCPUAdapter(nil)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
 		return err
 	}
-	defer adapter.Close()
+	defer s.releaseAdapter(adapter, nil)
 
-	if err := adapter.MergeFiles(files, outputPath); err != nil {
+	// 先写入同目录的临时文件，验证通过后才替换输出，失败时原输出保持不变
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+	// 进度写入器实现了ProgressReporter时按字节数报告合并进度
+	if err := adapter.MergeFilesWithProgress(files, staging, reporterProgress(progressWriter)); err != nil {
 		return err
 	}
 
 	// 验证输出文件 - 使用独立的验证方法避免死锁
-	if err := s.validateOutputFile(outputPath); err != nil {
+	if err := s.validateOutputFile(staging); err != nil {
 		return &PDFError{
 			Type:    ErrorCorrupted,
 			Message: "合并后的PDF文件无效",
@@ -637,6 +1792,9 @@ func (s *PDFServiceImpl) mergeWithPDFCPU(files []string, outputPath string, prog
 			Cause:   err,
 		}
 	}
+	if err := commitOutput(staging, outputPath); err != nil {
+		return err
+	}
 
 	// 输出统计信息
 	if progressWriter != nil {
@@ -649,30 +1807,49 @@ func (s *PDFServiceImpl) mergeWithPDFCPU(files []string, outputPath string, prog
 	return nil
 }
 
-// mergeWithStreamingMerger 使用流式合并器进行合并
-func (s *PDFServiceImpl) mergeWithStreamingMerger(files []string, outputPath string, progressWriter io.Writer) error {
+// mergeWithStreamingMerger 使用流式合并器进行合并，成功时返回合并器的结果
+func (s *PDFServiceImpl) mergeWithStreamingMerger(files []string, outputPath string, progressWriter io.Writer) (*MergeResult, error) {
 	if len(files) == 0 {
-		return fmt.Errorf("没有文件需要合并")
+		return nil, fmt.Errorf("没有文件需要合并")
 	}
 
 	mainFile := files[0]
 	additionalFiles := files[1:]
 
 	merger := NewStreamingMerger(&MergeOptions{
-		MaxMemoryUsage: s.config.MaxMemoryUsage,
-		TempDirectory:  s.config.TempDirectory,
-		EnableGC:       true,
-		ChunkSize:      10,
+		MaxMemoryUsage:      s.config.Load().MaxMemoryUsage,
+		TempDirectory:       s.config.Load().TempDirectory,
+		EnableGC:            true,
+		ChunkSize:           10,
+		VerifyChecksums:     s.config.Load().VerifyChecksums,
+		Clock:               s.config.Load().Clock,
+		AdaptiveBackends:    s.config.Load().AdaptiveBackends,
+		Logger:              s.config.Load().Logger,
+		ConcurrentWorkers:   s.config.Load().MaxWorkers,
+		StreamingConfig:     s.config.Load().StreamingConfig,
+		AllowDuplicates:     s.config.Load().AllowDuplicates,
+		FailOnSignedInputs:  s.config.Load().FailOnSigned,
+		DropAttachments:     s.config.Load().DropAttachments,
+		FlattenForms:        s.config.Load().FlattenForms,
+		MaxOutputSizeBytes:  s.config.Load().MaxOutputSize,
+		MaxOutputPages:      s.config.Load().MaxOutputPages,
+		AdapterPool:         s.adapters,
+		RequirePDFExtension: s.config.Load().RequirePDFExtension,
+		Vault:               s.config.Load().PasswordVault,
 	})
+	defer merger.Close()
 
 	result, err := merger.MergeFilesLegacy(mainFile, additionalFiles, outputPath, progressWriter)
 	if err != nil {
-		return err
+		if result != nil {
+			return nil, &MergeError{Result: result, Err: err}
+		}
+		return nil, err
 	}
 
 	// 验证输出文件
 	if err := s.validateOutputFile(outputPath); err != nil {
-		return &PDFError{
+		return nil, &PDFError{
 			Type:    ErrorCorrupted,
 			Message: "合并后的PDF文件无效",
 			File:    outputPath,
@@ -684,13 +1861,19 @@ func (s *PDFServiceImpl) mergeWithStreamingMerger(files []string, outputPath str
 	if progressWriter != nil {
 		fmt.Fprintf(progressWriter, "流式合并统计:\n")
 		fmt.Fprintf(progressWriter, "  总页数: %d\n", result.TotalPages)
+		for _, input := range result.InputPages {
+			fmt.Fprintf(progressWriter, "    %s: %d 页\n", filepath.Base(input.File), input.Pages)
+		}
 		fmt.Fprintf(progressWriter, "  处理文件数: %d\n", result.ProcessedFiles)
 		fmt.Fprintf(progressWriter, "  跳过文件数: %d\n", len(result.SkippedFiles))
+		if len(result.SignedFiles) > 0 {
+			fmt.Fprintf(progressWriter, "  签名已失效的输入数: %d\n", len(result.SignedFiles))
+		}
 		fmt.Fprintf(progressWriter, "  处理时间: %v\n", result.ProcessingTime)
 		fmt.Fprintf(progressWriter, "  内存使用: %.2f MB\n", float64(result.MemoryUsage)/(1024*1024))
 	}
 
-	return nil
+	return result, nil
 }
 
 // mergeWithBasicMethod 使用基本方法进行合并
@@ -710,25 +1893,25 @@ func (s *PDFServiceImpl) mergeWithBasicMethod(files []string, outputPath string,
 		}
 	}
 
-	// 创建PDF写入器 - 使用pdfcpu替代UniPDF
-	// 由于已完全迁移到pdfcpu，不再需要UniPDF的PDFWriter
-	totalPages := 0
-
-	// 逐个处理文件
+	// 逐个统计输入页数，直接遍历页面树而不调用GetPDFInfo
+	totalPages, counted := 0, true
 	for i, file := range files {
 		if progressWriter != nil {
 			fmt.Fprintf(progressWriter, "处理文件 %d/%d: %s\n", i+1, len(files), file)
 		}
 
-		// 获取页数（简单实现）
-		if info, err := s.GetPDFInfo(file); err == nil {
-			totalPages += info.PageCount
-		} else {
-			totalPages += 1 // 假设至少有一页
+		pages, err := CountPagesInFile(file, s.config.Load().PageTreeLimits)
+		if err != nil {
+			if IsLimitExceededError(err) {
+				return err
+			}
+			counted = false
+			continue
 		}
+		totalPages += pages
 	}
 
-	if totalPages == 0 {
+	if counted && totalPages == 0 {
 		return &PDFError{
 			Type:    ErrorProcessing,
 			Message: "没有成功处理任何页面",
@@ -737,18 +1920,20 @@ func (s *PDFServiceImpl) mergeWithBasicMethod(files []string, outputPath string,
 	}
 
 	// 使用pdfcpu进行合并
-	adapter, err := NewPDFCPUAdapter(nil)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
 		return fmt.Errorf("pdfcpu不可用: %w", err)
 	}
-	defer adapter.Close()
+	defer s.releaseAdapter(adapter, nil)
 
-	if err := adapter.MergeFiles(files, outputPath); err != nil {
+	staging := stagingPath(outputPath, clock.OrSystem(s.config.Load().Clock))
+	defer discardStaging(staging)
+	if err := adapter.MergeFiles(files, staging); err != nil {
 		return fmt.Errorf("pdfcpu合并失败: %w", err)
 	}
 
 	// 验证输出文件
-	if err := s.validateOutputFile(outputPath); err != nil {
+	if err := s.validateOutputFile(staging); err != nil {
 		return &PDFError{
 			Type:    ErrorCorrupted,
 			Message: "合并后的PDF文件无效",
@@ -756,9 +1941,20 @@ func (s *PDFServiceImpl) mergeWithBasicMethod(files []string, outputPath string,
 			Cause:   err,
 		}
 	}
+	if err := commitOutput(staging, outputPath); err != nil {
+		return err
+	}
 
+	// 以输出文件的实际页数为准，无法统计时使用输入页数之和
+	if pages, err := CountPagesInFile(outputPath, s.config.Load().PageTreeLimits); err == nil {
+		totalPages, counted = pages, true
+	}
 	if progressWriter != nil {
-		fmt.Fprintf(progressWriter, "基本合并完成 - 总页数: %d\n", totalPages)
+		if counted {
+			fmt.Fprintf(progressWriter, "基本合并完成 - 总页数: %d\n", totalPages)
+		} else {
+			fmt.Fprintf(progressWriter, "基本合并完成 - 无法统计总页数\n")
+		}
 	}
 
 	return nil
@@ -842,8 +2038,8 @@ func (s *PDFServiceImpl) basicFileValidation(filePath string) error {
 		}
 	}
 
-	// 检查文件扩展名
-	if !strings.HasSuffix(strings.ToLower(filePath), ".pdf") {
+	// 检查文件类型：默认以文件头为准，严格模式下要求 .pdf 扩展名
+	if !isAcceptedPDFPath(filePath, s.config.Load().RequirePDFExtension) {
 		return &PDFError{
 			Type:    ErrorInvalidFile,
 			Message: "文件不是PDF格式",
@@ -883,27 +2079,28 @@ func (s *PDFServiceImpl) basicFileValidation(filePath string) error {
 
 // validateWithPDFCPU 使用pdfcpu进行验证
 func (s *PDFServiceImpl) validateWithPDFCPU(filePath string) error {
-	adapter, err := NewPDFCPUAdapter(nil)
-	if err != nil {
-		return err // pdfcpu不可用
-	}
-	defer adapter.Close()
-
-	if s.config.EnableStrictMode {
+	if s.config.Load().EnableStrictMode {
 		// 使用严格模式验证
 		return s.validator.ValidateWithStrictMode(filePath)
 	}
 
-	return adapter.ValidateFile(filePath)
+	adapter, err := s.acquireAdapter()
+	if err != nil {
+		return err // pdfcpu不可用
+	}
+	err = adapter.ValidateFile(filePath)
+	s.releaseAdapter(adapter, err)
+	return err
 }
 
 // validateWithEnhancedReader 使用增强的PDF读取器进行验证
 func (s *PDFServiceImpl) validateWithEnhancedReader(filePath string) error {
-	reader, err := NewPDFReader(filePath)
+	reader, err := NewPDFReaderWithConfig(filePath, s.config.Load().StreamingConfig)
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
+	reader.SetPageTreeLimits(s.config.Load().PageTreeLimits)
 
 	// 验证PDF结构
 	if err := reader.ValidateStructure(); err != nil {
@@ -962,13 +2159,47 @@ func (s *PDFServiceImpl) validateOutputFile(filePath string) error {
 	}
 
 	// 使用pdfcpu进行快速验证（不依赖全局锁）
-	adapter, err := NewPDFCPUAdapter(nil)
+	adapter, err := s.acquireAdapter()
 	if err != nil {
 		return err // pdfcpu不可用，跳过验证
 	}
-	defer adapter.Close()
+	err = adapter.ValidateFile(filePath)
+	s.releaseAdapter(adapter, err)
+	return err
+}
+
+// adapterConfig 返回应用了服务级页面树限制和日志的pdfcpu配置
+func (s *PDFServiceImpl) adapterConfig() *PDFCPUConfig {
+	config := DefaultPDFCPUConfig()
+	config.PageTreeLimits = s.config.Load().PageTreeLimits
+	config.Logger = s.config.Load().Logger
+	config.Clock = s.config.Load().Clock
+	return config
+}
+
+// files 返回解密和加密单个文件的后端，没有替换后端时使用adapter
+func (s *PDFServiceImpl) files(adapter *PDFCPUAdapter) fileBackend {
+	if s.fileBackend != nil {
+		return s.fileBackend
+	}
+	return adapterFileBackend{adapter: adapter}
+}
+
+// acquireAdapter 从适配器池取出适配器，没有池时新建；用完后必须调用releaseAdapter
+func (s *PDFServiceImpl) acquireAdapter() (*PDFCPUAdapter, error) {
+	if s.adapters == nil {
+		return NewPDFCPUAdapter(s.adapterConfig())
+	}
+	return s.adapters.Get()
+}
 
-	return adapter.ValidateFile(filePath)
+// releaseAdapter 把适配器放回池中，callErr是最近一次调用的错误，内部状态错误时池会丢弃该适配器；没有池时关闭适配器
+func (s *PDFServiceImpl) releaseAdapter(adapter *PDFCPUAdapter, callErr error) {
+	if s.adapters == nil {
+		adapter.Close()
+		return
+	}
+	s.adapters.Put(adapter, callErr)
 }
 
 // getFileNameWithoutExt 获取不带扩展名的文件名
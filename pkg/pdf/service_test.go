@@ -2,8 +2,13 @@ package pdf
 
 import (
 	"bytes"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -209,3 +214,113 @@ func TestPDFServiceImpl_GetPDFInfo(t *testing.T) {
 		t.Logf("文件被标记为加密")
 	}
 }
+
+// TestImportDoesNotChangeGOMAXPROCS 在子进程中以固定的GOMAXPROCS启动测试程序，
+// 包初始化后读到的值应与环境变量一致
+func TestImportDoesNotChangeGOMAXPROCS(t *testing.T) {
+	if os.Getenv("PDF_MERGER_REPORT_GOMAXPROCS") != "" {
+		fmt.Printf("GOMAXPROCS=%d\n", runtime.GOMAXPROCS(0))
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestImportDoesNotChangeGOMAXPROCS$")
+	cmd.Env = append(os.Environ(), "PDF_MERGER_REPORT_GOMAXPROCS=1", "GOMAXPROCS=4")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("子进程运行失败: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "GOMAXPROCS=4\n") {
+		t.Errorf("导入pkg/pdf后GOMAXPROCS应保持为4，子进程输出:\n%s", output)
+	}
+}
+
+// TestPDFServiceImpl_ConcurrentMergeAndInfo 多个协程同时合并不同的文件并查询信息，
+// 应配合-race运行
+func TestPDFServiceImpl_ConcurrentMergeAndInfo(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultServiceConfig()
+	config.TempDirectory = tempDir
+	service := NewPDFServiceWithConfig(config)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		first := createTestFile(t, tempDir, fmt.Sprintf("a%d.pdf", i), buildFlatPDF(i+1))
+		second := createTestFile(t, tempDir, fmt.Sprintf("b%d.pdf", i), buildFlatPDF(2))
+		output := filepath.Join(tempDir, fmt.Sprintf("out%d.pdf", i))
+
+		wg.Add(2)
+		go func(want int) {
+			defer wg.Done()
+			if err := service.MergePDFs(first, []string{second}, output, nil); err != nil {
+				errs <- fmt.Errorf("合并 %s 失败: %w", output, err)
+				return
+			}
+			count, err := CountPagesInFile(output, nil)
+			if err != nil || count != want {
+				errs <- fmt.Errorf("%s 期望 %d 页，实际 %d（%v）", output, want, count, err)
+			}
+		}(i + 3)
+		go func(want int) {
+			defer wg.Done()
+			info, err := service.GetPDFInfo(first)
+			if err != nil {
+				errs <- fmt.Errorf("获取 %s 信息失败: %w", first, err)
+				return
+			}
+			if info.PageCount != want {
+				errs <- fmt.Errorf("%s 期望 %d 页，实际 %d", first, want, info.PageCount)
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestPDFServiceImpl_TakeMergeResult(t *testing.T) {
+	tempDir := t.TempDir()
+	config := DefaultServiceConfig()
+	config.TempDirectory = tempDir
+	service := NewPDFServiceWithConfig(config)
+	provider, ok := service.(MergeResultProvider)
+	if !ok {
+		t.Fatal("PDFServiceImpl应实现MergeResultProvider")
+	}
+
+	first := createTestFile(t, tempDir, "a.pdf", buildFlatPDF(2))
+	second := createTestFile(t, tempDir, "b.pdf", buildFlatPDF(3))
+	broken := createTestFile(t, tempDir, "broken.pdf", []byte("not a pdf"))
+	output := filepath.Join(tempDir, "out.pdf")
+
+	if err := service.MergePDFs(first, []string{second, broken}, output, nil); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+
+	result := provider.TakeMergeResult(output)
+	if result == nil {
+		t.Fatal("合并成功后应能取到合并结果")
+	}
+	if result.OutputPath != output {
+		t.Errorf("输出路径期望 %s，实际 %s", output, result.OutputPath)
+	}
+	if result.TotalPages != 5 {
+		t.Errorf("总页数期望 5，实际 %d", result.TotalPages)
+	}
+	if result.ProcessingTime <= 0 {
+		t.Error("应记录处理时间")
+	}
+	if len(result.SkippedFiles) != 1 || result.SkippedFiles[0] != broken {
+		t.Errorf("跳过的文件期望 [%s]，实际 %v", broken, result.SkippedFiles)
+	}
+	if result.SkipReasons[broken] == "" {
+		t.Error("跳过的文件应记录原因")
+	}
+
+	if again := provider.TakeMergeResult(output); again != nil {
+		t.Error("合并结果只能取走一次")
+	}
+}
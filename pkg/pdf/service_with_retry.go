@@ -17,10 +17,23 @@ type ServiceWithRetry struct {
 	retryManager    *RetryManager
 }
 
-// NewServiceWithRetry 创建带重试功能的PDF服务
+// NewServiceWithRetry 创建带重试功能的PDF服务。baseService由NewPDFServiceWithConfig创建时
+// 沿用其配置的重试策略，否则使用默认策略
 func NewServiceWithRetry(baseService PDFService, maxMemoryMB int64) *ServiceWithRetry {
-	recoveryManager := NewRecoveryManager(maxMemoryMB)
-	retryManager := NewRetryManager(DefaultRetryConfig(), NewDefaultErrorHandler(3))
+	policy := DefaultRetryPolicy()
+	if impl, ok := baseService.(*PDFServiceImpl); ok {
+		policy = impl.errorHandler.RetryPolicy()
+	}
+	return NewServiceWithRetryPolicy(baseService, maxMemoryMB, policy)
+}
+
+// NewServiceWithRetryPolicy 创建带重试功能的PDF服务，合并、验证和信息查询都按policy重试
+func NewServiceWithRetryPolicy(baseService PDFService, maxMemoryMB int64, policy *RetryPolicy) *ServiceWithRetry {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+	retryManager := newPolicyRetryManager(policy, nil)
+	recoveryManager := newRecoveryManager(maxMemoryMB, retryManager)
 
 	return &ServiceWithRetry{
 		baseService:     baseService,
@@ -66,6 +66,66 @@ func (m *MockPDFService) IsPDFEncrypted(filePath string) (bool, error) {
 	return false, nil
 }
 
+func (m *MockPDFService) ExtractPages(inputPath string, pages []int, outputPath string) error {
+	return nil
+}
+
+func (m *MockPDFService) ExtractPageRanges(inputPath string, ranges string, outputPath string) error {
+	return nil
+}
+
+func (m *MockPDFService) DecryptPDF(inputPath, outputPath, password string) error {
+	return nil
+}
+
+func (m *MockPDFService) RepairPDF(inputPath, outputPath string) (*RepairReport, error) {
+	return &RepairReport{InputPath: inputPath, OutputPath: outputPath}, nil
+}
+
+func (m *MockPDFService) ValidateConformance(filePath string) (*ConformanceReport, error) {
+	return &ConformanceReport{File: filePath, Conformance: ConformanceNone}, nil
+}
+
+func (m *MockPDFService) StampPDF(inputPath, outputPath string, opts *StampOptions) error {
+	return nil
+}
+
+func (m *MockPDFService) AddTableOfContents(outputPath string, sources []TOCSource) error {
+	return nil
+}
+
+func (m *MockPDFService) RotatePDF(inputPath, outputPath string, degrees int, normalize bool) ([]int, error) {
+	return nil, nil
+}
+
+func (m *MockPDFService) SplitPDF(inputPath string, opts *SplitOptions) ([]string, error) {
+	return nil, nil
+}
+
+func (m *MockPDFService) ListAttachments(filePath string) ([]AttachmentInfo, error) {
+	return nil, nil
+}
+
+func (m *MockPDFService) ValidateBatch(ctx context.Context, paths []string, workers int) (*BatchValidationReport, error) {
+	return &BatchValidationReport{Total: len(paths), Valid: len(paths)}, nil
+}
+
+func (m *MockPDFService) ExtractText(filePath string, pages []int) (map[int]string, error) {
+	return map[int]string{}, nil
+}
+
+func (m *MockPDFService) ExtractTextTo(filePath string, pages []int, w io.Writer) error {
+	return nil
+}
+
+func (m *MockPDFService) ExtractImages(filePath string, pages []int, outputDir string) ([]ExtractedImage, error) {
+	return nil, nil
+}
+
+func (m *MockPDFService) ExtractImagesContext(ctx context.Context, filePath string, pages []int, outputDir string) ([]ExtractedImage, error) {
+	return nil, nil
+}
+
 func TestNewServiceWithRetry(t *testing.T) {
 	mockService := &MockPDFService{}
 	service := NewServiceWithRetry(mockService, 100)
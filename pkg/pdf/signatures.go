@@ -0,0 +1,129 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[`)
+
+// SignatureReport 文件中的数字签名
+type SignatureReport struct {
+	File    string   `json:"file"`
+	Count   int      `json:"count"`             // 签名字典（带 /ByteRange）的数量，包括文档时间戳
+	Signers []string `json:"signers,omitempty"` // 签名字典 /Name 中的签名者，按出现顺序去重；没有 /Name 的签名不出现
+}
+
+// ReadSignatures 不依赖pdfcpu读取文件中的数字签名，包括对象流中的签名字典；增量更新中被替换的对象不计入。
+// 合并、优化等重写文件的操作都会使这些签名失效。
+func ReadSignatures(filePath string) (*SignatureReport, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+	report := readSignatures(data)
+	report.File = filePath
+	return report, nil
+}
+
+// HasDigitalSignatures 判断文件是否包含数字签名，见ReadSignatures
+func HasDigitalSignatures(filePath string) (bool, error) {
+	report, err := ReadSignatures(filePath)
+	if err != nil {
+		return false, err
+	}
+	return report.Count > 0, nil
+}
+
+// readSignatures ReadSignatures 的实现，data 为文件内容
+func readSignatures(data []byte) *SignatureReport {
+	report := &SignatureReport{}
+	if !byteRangePattern.Match(data) && !objectStreamPattern.Match(data) {
+		return report
+	}
+
+	bodies := objectBodies(data)
+
+	nums := make([]int, 0, len(bodies))
+	for num := range bodies {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	seen := make(map[string]bool)
+	for _, num := range nums {
+		body := bodies[num]
+		if idx := bytes.Index(body, []byte("stream")); idx >= 0 {
+			body = body[:idx]
+		}
+		for _, loc := range byteRangePattern.FindAllIndex(body, -1) {
+			report.Count++
+			dict := enclosingDict(body, loc[0])
+			idx := bytes.Index(dict, []byte("/Name"))
+			if idx < 0 {
+				continue
+			}
+			value := bytes.TrimLeft(dict[idx+len("/Name"):], " \t\r\n")
+			signer := strings.TrimSpace(decodePDFString(value))
+			if signer != "" && !seen[signer] {
+				seen[signer] = true
+				report.Signers = append(report.Signers, signer)
+			}
+		}
+	}
+	return report
+}
+
+// enclosingDict 返回包含位置pos的最内层字典，找不到时返回从pos开始的内容
+func enclosingDict(body []byte, pos int) []byte {
+	depth := 0
+	for i := pos - 1; i > 0; i-- {
+		switch {
+		case body[i] == '>' && body[i-1] == '>':
+			depth++
+			i--
+		case body[i] == '<' && body[i-1] == '<':
+			if depth == 0 {
+				start := i - 1
+				return body[start:skipDictionary(body, start)]
+			}
+			depth--
+			i--
+		}
+	}
+	return body[pos:]
+}
+
+// checkSignedInput 输入包含数字签名时记录警告，启用FailOnSignedInputs时返回错误。
+// 合并会重写文档结构，输出中不再有有效的签名
+func (sm *StreamingMerger) checkSignedInput(result *MergeResult, filePath string) error {
+	report, err := ReadSignatures(filePath)
+	if err != nil || report.Count == 0 {
+		return nil
+	}
+	result.SignedFiles = append(result.SignedFiles, filePath)
+
+	signers := ""
+	if len(report.Signers) > 0 {
+		signers = fmt.Sprintf("（签名者: %s）", strings.Join(report.Signers, "、"))
+	}
+	if sm.failOnSigned {
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("输入包含 %d 个数字签名%s，合并会使签名失效", report.Count, signers),
+			File:    filePath,
+		}
+	}
+	result.Warnings = append(result.Warnings,
+		fmt.Sprintf("输入 %s 包含 %d 个数字签名%s，合并后签名将失效", filePath, report.Count, signers))
+	return nil
+}
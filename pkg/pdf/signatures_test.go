@@ -0,0 +1,105 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSignedPDF 写出单页测试文件，签名字段的值为带 /Name 的签名字典，另有一个直接写在字段中的签名
+func writeSignedPDF(t *testing.T, dir, name string) string {
+	return createTestFile(t, dir, name, buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R 6 0 R] /SigFlags 3 >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R 6 0 R] >>",
+		"<< /FT /Sig /Type /Annot /Subtype /Widget /Rect [0 0 0 0] /V 5 0 R >>",
+		"<< /Type /Sig /Filter /Adobe.PPKLite /Name (Alice \\(Legal\\)) /ByteRange [0 10 20 30] /Contents <00> >>",
+		"<< /FT /Sig /Type /Annot /Subtype /Widget /Rect [0 0 0 0] " +
+			"/V << /Type /Sig /Name <FEFF0042006F0062> /ByteRange [0 10 20 30] /Contents <00> >> >>",
+	}))
+}
+
+func TestReadSignatures(t *testing.T) {
+	dir := t.TempDir()
+	signed := writeSignedPDF(t, dir, "signed.pdf")
+
+	report, err := ReadSignatures(signed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Count)
+	assert.Equal(t, []string{"Alice (Legal)", "Bob"}, report.Signers)
+
+	report, err = ReadSignatures(createTestFile(t, dir, "plain.pdf", buildFlatPDF(1)))
+	require.NoError(t, err)
+	assert.Zero(t, report.Count)
+
+	_, err = ReadSignatures(filepath.Join(dir, "missing.pdf"))
+	assert.Error(t, err)
+}
+
+func TestReadSignatures_ObjectStreamsAndIncrementalUpdates(t *testing.T) {
+	dir := t.TempDir()
+	signed := writeSignedPDF(t, dir, "signed.pdf")
+
+	// 对象流中的签名字典
+	compressed := filepath.Join(dir, "compressed.pdf")
+	_, err := OptimizePDF(signed, compressed, nil)
+	require.NoError(t, err)
+	data, err := os.ReadFile(compressed)
+	require.NoError(t, err)
+	require.True(t, objectStreamPattern.Match(data))
+	report, err := ReadSignatures(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Count)
+
+	// 增量更新替换的签名字典不再计入
+	data, err = os.ReadFile(signed)
+	require.NoError(t, err)
+	data = append(data, []byte("5 0 obj\n<< /Type /Annot /Subtype /Text /Rect [0 0 0 0] >>\nendobj\n")...)
+	report = readSignatures(data)
+	assert.Equal(t, 1, report.Count)
+	assert.Equal(t, []string{"Bob"}, report.Signers)
+}
+
+func TestMergeFiles_SignedInputs(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	signed := writeSignedPDF(t, dir, "signed.pdf")
+
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	merger.adapter = nil
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, signed}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{signed}, result.SignedFiles)
+	assert.Contains(t, result.Warnings,
+		fmt.Sprintf("输入 %s 包含 2 个数字签名（签名者: Alice (Legal)、Bob），合并后签名将失效", signed))
+
+	merger = NewStreamingMerger(&MergeOptions{
+		TempDirectory:      dir,
+		BackendStats:       NewBackendStatsStore(),
+		FailOnSignedInputs: true,
+	})
+	merger.adapter = nil
+	strict := filepath.Join(dir, "strict.pdf")
+	result, err = merger.MergeFiles([]string{a, signed}, strict, nil)
+	require.Error(t, err)
+	assert.Equal(t, ErrorInvalidInput, err.(*PDFError).Type)
+	assert.Equal(t, signed, err.(*PDFError).File)
+	assert.Equal(t, MergeStageValidation, result.FailedStage)
+	assert.NoFileExists(t, strict)
+}
+
+func TestPDFService_GetPDFInfoSignatures(t *testing.T) {
+	dir := t.TempDir()
+	signed := writeSignedPDF(t, dir, "signed.pdf")
+
+	info, err := NewPDFService().GetPDFInfo(signed)
+	require.NoError(t, err)
+	assert.True(t, info.HasSignatures)
+	assert.Equal(t, 2, info.SignatureCount)
+	assert.Equal(t, []string{"Alice (Legal)", "Bob"}, info.Signers)
+}
@@ -0,0 +1,249 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSplitNameLength 由书签标题生成的文件名（不含扩展名）的最大字符数
+const maxSplitNameLength = 100
+
+// SplitMode 拆分方式
+type SplitMode string
+
+const (
+	SplitByPages     SplitMode = "pages"     // 每EveryPages页一个文件
+	SplitByBookmarks SplitMode = "bookmarks" // 在每个顶层书签处拆分
+)
+
+// SplitOptions 拆分选项
+type SplitOptions struct {
+	Mode       SplitMode // 为空时按页数拆分
+	EveryPages int       // 按页数拆分时每个文件的页数
+	OutputDir  string    // 输出目录，为空时使用输入文件所在目录
+
+	// Progress 每写出一个文件后调用，progress 为已完成的比例
+	Progress func(progress float64, message string)
+}
+
+// Validate 检查拆分选项
+func (o *SplitOptions) Validate() error {
+	if o == nil {
+		return &PDFError{Type: ErrorInvalidInput, Message: "没有指定拆分选项"}
+	}
+	switch o.Mode {
+	case "", SplitByPages:
+		if o.EveryPages < 1 {
+			return &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("每个文件的页数必须大于0: %d", o.EveryPages),
+			}
+		}
+	case SplitByBookmarks:
+	default:
+		return &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: fmt.Sprintf("未知的拆分方式: %s（可用: pages、bookmarks）", o.Mode),
+		}
+	}
+	return nil
+}
+
+// splitPart 拆分出的一个文件
+type splitPart struct {
+	Name  string // 不含目录和扩展名的文件名
+	Pages []int  // 从1开始的页码
+}
+
+// splitBookmark 顶层书签及其目标页
+type splitBookmark struct {
+	Title string
+	Page  int // 从1开始的页码
+}
+
+// planSplit 计算拆分出的文件，base 为输入文件名（不含扩展名）
+func planSplit(filePath string, data []byte, base string, opts *SplitOptions, limits *PageTreeLimits) ([]splitPart, error) {
+	stats, err := WalkPageTree(filePath, data, limits)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.Pages) == 0 {
+		return nil, &PDFError{Type: ErrorInvalidInput, Message: "文档没有页面", File: filePath}
+	}
+
+	if opts.Mode == SplitByBookmarks {
+		bookmarks := readTopLevelBookmarks(data, stats.Pages)
+		if len(bookmarks) == 0 {
+			return nil, &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: "文档没有指向页面的顶层书签，无法按书签拆分",
+				File:    filePath,
+			}
+		}
+		return splitByBookmarks(base, len(stats.Pages), bookmarks), nil
+	}
+	return splitByPages(base, len(stats.Pages), opts.EveryPages), nil
+}
+
+// splitByPages 把pageCount页按每every页拆分，文件名为 base_001、base_002……
+func splitByPages(base string, pageCount, every int) []splitPart {
+	count := (pageCount + every - 1) / every
+	width := max(3, len(strconv.Itoa(count)))
+	parts := make([]splitPart, 0, count)
+	for start := 1; start <= pageCount; start += every {
+		part := splitPart{Name: fmt.Sprintf("%s_%0*d", base, width, len(parts)+1)}
+		for page := start; page < start+every && page <= pageCount; page++ {
+			part.Pages = append(part.Pages, page)
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// splitByBookmarks 在每个顶层书签的目标页处拆分，文件名为清理后的书签标题。
+// 第一个书签之前的页面放入 base_000；指向同一页的书签只保留第一个，书签按目标页排序
+func splitByBookmarks(base string, pageCount int, bookmarks []splitBookmark) []splitPart {
+	sorted := append([]splitBookmark(nil), bookmarks...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Page < sorted[j].Page })
+
+	var starts []splitBookmark
+	for _, bookmark := range sorted {
+		if len(starts) == 0 || starts[len(starts)-1].Page != bookmark.Page {
+			starts = append(starts, bookmark)
+		}
+	}
+
+	var parts []splitPart
+	if starts[0].Page > 1 {
+		parts = append(parts, splitPart{Name: base + "_000", Pages: pageSequence(1, starts[0].Page-1)})
+	}
+	for i, start := range starts {
+		end := pageCount
+		if i+1 < len(starts) {
+			end = starts[i+1].Page - 1
+		}
+		name := sanitizeFileName(start.Title)
+		if name == "" {
+			name = fmt.Sprintf("%s_%03d", base, i+1)
+		}
+		parts = append(parts, splitPart{Name: name, Pages: pageSequence(start.Page, end)})
+	}
+	return parts
+}
+
+// pageSequence 返回 first 到 last（含）的页码
+func pageSequence(first, last int) []int {
+	pages := make([]int, 0, last-first+1)
+	for page := first; page <= last; page++ {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// readTopLevelBookmarks 按顺序读取指向页面的顶层书签，pages 为按文档顺序的页面对象编号。
+// 使用命名目标或指向不存在页面的书签被忽略
+func readTopLevelBookmarks(data []byte, pages []int) []splitBookmark {
+	offsets := indexObjects(data)
+	rootMatches := rootRefPattern.FindAllSubmatch(data, -1)
+	if len(rootMatches) == 0 {
+		return nil
+	}
+	catalogNum, _ := strconv.Atoi(string(rootMatches[len(rootMatches)-1][1]))
+	catalog, ok := objectBody(data, offsets, catalogNum)
+	if !ok {
+		return nil
+	}
+	m := outlinesRefPattern.FindSubmatch(catalog)
+	if m == nil {
+		return nil
+	}
+	rootNum, _ := strconv.Atoi(string(m[1]))
+	root, ok := objectBody(data, offsets, rootNum)
+	if !ok {
+		return nil
+	}
+
+	pageIndex := make(map[int]int, len(pages))
+	for i, num := range pages {
+		pageIndex[num] = i + 1
+	}
+
+	var bookmarks []splitBookmark
+	visited := make(map[int]bool)
+	next := outlineFirstPattern.FindSubmatch(root)
+	for next != nil && len(visited) < maxOutlineItems {
+		num, _ := strconv.Atoi(string(next[1]))
+		body, ok := objectBody(data, offsets, num)
+		if !ok || visited[num] {
+			break
+		}
+		visited[num] = true
+
+		if dest := outlineDestPattern.FindSubmatch(body); dest != nil {
+			pageNum, _ := strconv.Atoi(string(dest[1]))
+			if page, ok := pageIndex[pageNum]; ok {
+				bookmarks = append(bookmarks, splitBookmark{Title: outlineTitle(body), Page: page})
+			}
+		}
+		next = outlineNextPattern.FindSubmatch(topLevelOnly(body))
+	}
+	return bookmarks
+}
+
+// outlineTitle 返回书签的 /Title，不存在时为空
+func outlineTitle(body []byte) string {
+	top := topLevelOnly(body)
+	idx := bytes.Index(top, []byte("/Title"))
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(decodePDFString(bytes.TrimLeft(top[idx+len("/Title"):], " \t\r\n")))
+}
+
+// sanitizeFileName 把书签标题转换为可用作文件名的字符串：替换路径分隔符、Windows保留字符和控制字符，
+// 合并连续空白，去掉首尾的空格和点，并限制长度
+func sanitizeFileName(title string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range title {
+		switch {
+		case unicode.IsSpace(r):
+			if space {
+				continue
+			}
+			r = ' '
+		case strings.ContainsRune(`/\:*?"<>|`, r) || unicode.IsControl(r):
+			r = '_'
+		}
+		space = r == ' '
+		b.WriteRune(r)
+	}
+
+	name := []rune(strings.Trim(b.String(), " ."))
+	if len(name) > maxSplitNameLength {
+		name = []rune(strings.TrimRight(string(name[:maxSplitNameLength]), " ."))
+	}
+	return string(name)
+}
+
+// uniqueOutputPath 返回 dir 中尚未被使用的 name.pdf 路径：与本次已分配的路径或已存在的文件重名时
+// 依次尝试 name_2.pdf、name_3.pdf……；used 记录已分配的路径（不区分大小写）
+func uniqueOutputPath(dir, name string, used map[string]bool) string {
+	candidate := filepath.Join(dir, name+".pdf")
+	for i := 2; ; i++ {
+		key := strings.ToLower(candidate)
+		if !used[key] {
+			if _, err := os.Stat(candidate); os.IsNotExist(err) {
+				used[key] = true
+				return candidate
+			}
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_%d.pdf", name, i))
+	}
+}
@@ -0,0 +1,157 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOutlinedPDF 写出5页的测试文件（页面对象3-7），顶层书签依次指向第3、2、5页，
+// 第一个书签之前还有第1页；另有一个使用命名目标的书签
+func writeOutlinedPDF(t *testing.T, dir, name string) string {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 8 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R 7 0 R] /Count 5 >>",
+	}
+	for i := 0; i < 5; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>")
+	}
+	objects = append(objects,
+		"<< /Type /Outlines /First 9 0 R /Last 12 0 R /Count 4 >>",
+		"<< /Title (Part 2: Methods) /Parent 8 0 R /Next 10 0 R /Dest [5 0 R /Fit] >>",
+		"<< /Title <FEFF0049006E00740072006F> /Parent 8 0 R /Prev 9 0 R /Next 11 0 R "+
+			"/A << /S /GoTo /D [4 0 R /XYZ 0 792 0] >> >>",
+		"<< /Title (Named) /Parent 8 0 R /Prev 10 0 R /Next 12 0 R /Dest (chapter) >>",
+		"<< /Title (  Results / Data?  ) /Parent 8 0 R /Prev 11 0 R /Dest [7 0 R /Fit] >>",
+	)
+	return createTestFile(t, dir, name, buildPDF(objects))
+}
+
+func TestSplitByPages(t *testing.T) {
+	parts := splitByPages("big", 5, 2)
+	require.Len(t, parts, 3)
+	assert.Equal(t, splitPart{Name: "big_001", Pages: []int{1, 2}}, parts[0])
+	assert.Equal(t, splitPart{Name: "big_003", Pages: []int{5}}, parts[2])
+
+	parts = splitByPages("big", 1200, 1)
+	assert.Equal(t, "big_0001", parts[0].Name, "编号宽度随文件数增加")
+}
+
+func TestSplitByBookmarks_PrefixPagesAndDuplicates(t *testing.T) {
+	parts := splitByBookmarks("doc", 6, []splitBookmark{
+		{Title: "B", Page: 4},
+		{Title: "A", Page: 2},
+		{Title: "A again", Page: 2},
+		{Title: "", Page: 6},
+	})
+	assert.Equal(t, []splitPart{
+		{Name: "doc_000", Pages: []int{1}},
+		{Name: "A", Pages: []int{2, 3}},
+		{Name: "B", Pages: []int{4, 5}},
+		{Name: "doc_003", Pages: []int{6}},
+	}, parts)
+
+	parts = splitByBookmarks("doc", 2, []splitBookmark{{Title: "All", Page: 1}})
+	assert.Equal(t, []splitPart{{Name: "All", Pages: []int{1, 2}}}, parts, "第一个书签位于第1页时没有前置部分")
+}
+
+func TestReadTopLevelBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	input := writeOutlinedPDF(t, dir, "doc.pdf")
+	stats, err := WalkPageTreeFile(input, nil)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(input)
+	require.NoError(t, err)
+	bookmarks := readTopLevelBookmarks(data, stats.Pages)
+	assert.Equal(t, []splitBookmark{
+		{Title: "Part 2: Methods", Page: 3},
+		{Title: "Intro", Page: 2},
+		{Title: "Results / Data?", Page: 5},
+	}, bookmarks, "命名目标的书签被忽略")
+
+	assert.Empty(t, readTopLevelBookmarks(buildFlatPDF(2), []int{3, 4}))
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "Results _ Data_", sanitizeFileName("  Results / Data?  "))
+	assert.Equal(t, "a b c..._d", sanitizeFileName("a\tb\n\n c...\x00d..."))
+	assert.Equal(t, "", sanitizeFileName(" .. "))
+	assert.Len(t, []rune(sanitizeFileName(string(make([]rune, 300)))), maxSplitNameLength)
+}
+
+func TestUniqueOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "part.pdf", buildFlatPDF(1))
+	used := make(map[string]bool)
+
+	assert.Equal(t, filepath.Join(dir, "part_2.pdf"), uniqueOutputPath(dir, "part", used), "不覆盖已有文件")
+	assert.Equal(t, filepath.Join(dir, "part_3.pdf"), uniqueOutputPath(dir, "part", used), "同一次拆分中的重名")
+	assert.Equal(t, filepath.Join(dir, "other.pdf"), uniqueOutputPath(dir, "other", used))
+}
+
+func TestPDFServiceImpl_SplitPDF_EveryPages(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "big.pdf", buildFlatPDF(5))
+	outputDir := filepath.Join(dir, "parts")
+
+	var progress []float64
+	files, err := NewPDFService().SplitPDF(input, &SplitOptions{
+		EveryPages: 2,
+		OutputDir:  outputDir,
+		Progress:   func(p float64, message string) { progress = append(progress, p) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(outputDir, "big_001.pdf"),
+		filepath.Join(outputDir, "big_002.pdf"),
+		filepath.Join(outputDir, "big_003.pdf"),
+	}, files)
+	assert.Len(t, progress, 3)
+	assert.Equal(t, 1.0, progress[2])
+
+	for i, want := range []int{2, 2, 1} {
+		count, err := CountPagesInFile(files[i], nil)
+		require.NoError(t, err)
+		assert.Equal(t, want, count)
+	}
+}
+
+func TestPDFServiceImpl_SplitPDF_Bookmarks(t *testing.T) {
+	dir := t.TempDir()
+	input := writeOutlinedPDF(t, dir, "doc.pdf")
+
+	files, err := NewPDFService().SplitPDF(input, &SplitOptions{Mode: SplitByBookmarks})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "doc_000.pdf"),
+		filepath.Join(dir, "Intro.pdf"),
+		filepath.Join(dir, "Part 2_ Methods.pdf"),
+		filepath.Join(dir, "Results _ Data_.pdf"),
+	}, files)
+
+	for i, want := range []int{1, 1, 2, 1} {
+		count, err := CountPagesInFile(files[i], nil)
+		require.NoError(t, err)
+		assert.Equal(t, want, count, files[i])
+	}
+}
+
+func TestPDFServiceImpl_SplitPDF_InvalidOptions(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "flat.pdf", buildFlatPDF(2))
+	service := NewPDFService()
+
+	for _, opts := range []*SplitOptions{nil, {EveryPages: 0}, {Mode: "chapters", EveryPages: 1}} {
+		_, err := service.SplitPDF(input, opts)
+		var pdfErr *PDFError
+		require.ErrorAs(t, err, &pdfErr)
+		assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+	}
+
+	_, err := service.SplitPDF(input, &SplitOptions{Mode: SplitByBookmarks})
+	assert.ErrorContains(t, err, "没有指向页面的顶层书签")
+}
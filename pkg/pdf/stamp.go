@@ -0,0 +1,404 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StampPosition 印章文字在页面上的位置，按页面显示方向（考虑 /Rotate）计算
+type StampPosition string
+
+const (
+	StampTopLeft      StampPosition = "top-left"
+	StampTopCenter    StampPosition = "top-center"
+	StampTopRight     StampPosition = "top-right"
+	StampCenter       StampPosition = "center"
+	StampBottomLeft   StampPosition = "bottom-left"
+	StampBottomCenter StampPosition = "bottom-center"
+	StampBottomRight  StampPosition = "bottom-right"
+)
+
+// stampMargin 印章文字与可见区域（CropBox）边缘的距离（磅）
+const stampMargin = 24.0
+
+// 内置实现添加到页面资源中的名称，带前缀以免与页面已有的资源重名
+const (
+	stampFontName  = "PDFMergerStampFont"
+	stampStateName = "PDFMergerStampGS"
+)
+
+// helveticaWidths Helvetica中ASCII 32到126各字符的宽度（千分之一字号），用于对齐和居中
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// StampOptions 添加到每一页的文字（页码、页脚或水印）
+type StampOptions struct {
+	// Text 文字模板：{page} 为页码，{pages} 为总页数，{filename} 为该页来源文件的文件名。
+	// 使用标准字体Helvetica，非ASCII字符显示为问号
+	Text     string
+	Position StampPosition // 位置，空时为底部居中；Diagonal为true时忽略
+	FontSize float64       // 字号（磅），0时为10；斜向水印超出页面对角线时自动缩小
+	Opacity  float64       // 不透明度（0到1），0时为1（不透明）
+	Diagonal bool          // 从左下到右上斜向穿过页面中心，用于水印
+}
+
+// PageNumberStamp 返回底部居中、10磅的页码印章，例如 PageNumberStamp("Page {page} of {pages}")
+func PageNumberStamp(template string) *StampOptions {
+	return &StampOptions{Text: template, Position: StampBottomCenter, FontSize: 10}
+}
+
+// WatermarkStamp 返回斜向穿过页面中心、半透明的水印，例如 WatermarkStamp("DRAFT")
+func WatermarkStamp(text string) *StampOptions {
+	return &StampOptions{Text: text, Position: StampCenter, FontSize: 72, Opacity: 0.3, Diagonal: true}
+}
+
+// Validate 检查印章选项是否有效
+func (o *StampOptions) Validate() error {
+	invalid := func(message string) error {
+		return &PDFError{Type: ErrorInvalidInput, Message: message}
+	}
+	if o == nil || strings.TrimSpace(o.Text) == "" {
+		return invalid("印章文字不能为空")
+	}
+	switch o.Position {
+	case "", StampTopLeft, StampTopCenter, StampTopRight, StampCenter, StampBottomLeft, StampBottomCenter, StampBottomRight:
+	default:
+		return invalid(fmt.Sprintf("未知的印章位置: %s", o.Position))
+	}
+	if o.FontSize < 0 || o.FontSize > 500 {
+		return invalid(fmt.Sprintf("印章字号必须在0到500之间: %g", o.FontSize))
+	}
+	if o.Opacity < 0 || o.Opacity > 1 {
+		return invalid(fmt.Sprintf("印章不透明度必须在0到1之间: %g", o.Opacity))
+	}
+	return nil
+}
+
+// validateStamps 检查每个印章
+func validateStamps(stamps []*StampOptions) error {
+	for _, stamp := range stamps {
+		if err := stamp.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand 替换模板中的占位符
+func (o *StampOptions) expand(page, pages int, filename string) string {
+	return strings.NewReplacer(
+		"{page}", strconv.Itoa(page),
+		"{pages}", strconv.Itoa(pages),
+		"{filename}", filename,
+	).Replace(o.Text)
+}
+
+// usesFilename 判断模板中是否引用了来源文件名
+func (o *StampOptions) usesFilename() bool {
+	return strings.Contains(o.Text, "{filename}")
+}
+
+func (o *StampOptions) fontSize() float64 {
+	if o.FontSize == 0 {
+		return 10
+	}
+	return o.FontSize
+}
+
+func (o *StampOptions) opacity() float64 {
+	if o.Opacity == 0 {
+		return 1
+	}
+	return o.Opacity
+}
+
+// StampPDF 不依赖pdfcpu把印章文字添加到每一页，以增量更新写入outputPath，输入与输出可以是同一路径。
+// {filename} 为输入的文件名。不支持加密文件和对象流中的页面对象。
+func StampPDF(inputPath, outputPath string, opts *StampOptions) error {
+	return stampPages(inputPath, outputPath, []*StampOptions{opts}, nil)
+}
+
+// pageSourceNames 返回每一页来源文件的文件名。sources按顺序覆盖输出的页面，
+// 没有覆盖到的页面（包括sources为空时）使用inputPath的文件名。
+func pageSourceNames(inputPath string, pages int, sources []InputPageCount) []string {
+	names := make([]string, 0, pages)
+	for _, source := range sources {
+		for i := 0; i < source.Pages && len(names) < pages; i++ {
+			names = append(names, filepath.Base(source.File))
+		}
+	}
+	for len(names) < pages {
+		names = append(names, filepath.Base(inputPath))
+	}
+	return names
+}
+
+// stampPages 内置的印章实现：每页在原内容之后追加一个内容流，原内容用 q/Q 包围，
+// 不受原内容遗留的图形状态影响。位置按每页自己的CropBox和 /Rotate 计算，不同尺寸的页面互不影响。
+func stampPages(inputPath, outputPath string, stamps []*StampOptions, sources []InputPageCount) error {
+	if err := validateStamps(stamps); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法为加密文件添加印章",
+			File:    inputPath,
+		}
+	}
+
+	pages, boxes, err := readPageBoxes(inputPath, data)
+	if err != nil {
+		return err
+	}
+	names := pageSourceNames(inputPath, len(pages), sources)
+
+	offsets := indexObjects(data)
+	update := newIncrementalUpdate(data, offsets)
+	fontNum := update.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	saveNum := update.add("<< /Length 2 >>\nstream\nq\nendstream")
+	// 每种不透明度一个图形状态，按印章顺序编号，输出可重现
+	states := make(map[float64]int)
+	var stateNums []int
+	for _, stamp := range stamps {
+		if _, ok := states[stamp.opacity()]; !ok {
+			states[stamp.opacity()] = update.add(fmt.Sprintf("<< /Type /ExtGState /ca %s /CA %s >>",
+				formatNumber(stamp.opacity()), formatNumber(stamp.opacity())))
+			stateNums = append(stateNums, states[stamp.opacity()])
+		}
+	}
+
+	for i, pageNum := range pages {
+		body, _ := objectBody(data, offsets, pageNum)
+		rotation := pageRotation(data, offsets, body)
+
+		var content bytes.Buffer
+		content.WriteString("Q\n")
+		for _, stamp := range stamps {
+			text := stamp.expand(i+1, len(pages), names[i])
+			state := fmt.Sprintf("%s%d", stampStateName, states[stamp.opacity()])
+			content.WriteString(stampOperators(stamp, text, boxes[i].CropBox, rotation, state))
+		}
+		contentNum := update.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+
+		resources := "<< >>"
+		if existing := inheritedResources(data, offsets, body); existing != nil {
+			resources = string(bytes.TrimSpace(existing))
+		}
+		resources = withResourceEntry(data, offsets, resources, "/Font", stampFontName, fontNum)
+		for _, num := range stateNums {
+			resources = withResourceEntry(data, offsets, resources, "/ExtGState", fmt.Sprintf("%s%d", stampStateName, num), num)
+		}
+
+		page := string(bytes.TrimSpace(body))
+		page = withEntry(page, "/Contents", "["+strings.TrimSpace(fmt.Sprintf("%d 0 R %s %d 0 R",
+			saveNum, existingContents(data, offsets, body), contentNum))+"]")
+		page = withEntry(page, "/Resources", resources)
+		update.set(pageNum, page)
+	}
+
+	tempPath := outputPath + ".stamp.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入印章",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return nil
+}
+
+// stampOperators 生成在box（用户空间中的可见区域）上按页面显示方向绘制文字的内容流片段
+func stampOperators(stamp *StampOptions, text string, box [4]float64, rotation int, state string) string {
+	text = asciiOnly(text)
+	size := stamp.fontSize()
+	width := textWidth(text) * size / 1000
+
+	// 先在显示方向的坐标系中排版，原点为显示时的左下角
+	viewW, viewH := box[2]-box[0], box[3]-box[1]
+	if rotation == 90 || rotation == 270 {
+		viewW, viewH = viewH, viewW
+	}
+
+	var placement affine
+	if stamp.Diagonal {
+		angle := math.Atan2(viewH, viewW)
+		if limit := math.Hypot(viewW, viewH) * 0.8; width > limit {
+			size *= limit / width
+			width = limit
+		}
+		cos, sin := math.Cos(angle), math.Sin(angle)
+		// 文字中心对齐页面中心，0.35倍字号近似大写字母高度的一半
+		x := viewW/2 - cos*width/2 + sin*size*0.35
+		y := viewH/2 - sin*width/2 - cos*size*0.35
+		placement = affine{cos, sin, -sin, cos, x, y}
+	} else {
+		position := stamp.Position
+		if position == "" {
+			position = StampBottomCenter
+		}
+		x, y := (viewW-width)/2, (viewH-size)/2
+		switch position {
+		case StampTopLeft, StampBottomLeft:
+			x = stampMargin
+		case StampTopRight, StampBottomRight:
+			x = viewW - stampMargin - width
+		}
+		switch position {
+		case StampTopLeft, StampTopCenter, StampTopRight:
+			y = viewH - stampMargin - size
+		case StampBottomLeft, StampBottomCenter, StampBottomRight:
+			y = stampMargin
+		}
+		placement = affine{1, 0, 0, 1, x, y}
+	}
+
+	matrix := placement.then(viewToUser(box, rotation))
+	return fmt.Sprintf("q /%s gs BT /%s %s Tf 0 g %s Tm (%s) Tj ET Q\n",
+		state, stampFontName, formatNumber(size), matrix, escapePDFLiteral(text))
+}
+
+// affine PDF仿射矩阵 [a b c d e f]，按行向量约定变换点
+type affine [6]float64
+
+// then 返回先应用m再应用n的矩阵
+func (m affine) then(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// String 以内容流中的操作数格式输出
+func (m affine) String() string {
+	parts := make([]string, len(m))
+	for i, v := range m {
+		parts[i] = formatNumber(v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// viewToUser 把显示方向坐标（原点为显示时可见区域的左下角）映射到用户空间。
+// /Rotate 为页面显示时顺时针旋转的角度。
+func viewToUser(box [4]float64, rotation int) affine {
+	llx, lly, urx, ury := box[0], box[1], box[2], box[3]
+	switch rotation {
+	case 90:
+		return affine{0, 1, -1, 0, urx, lly}
+	case 180:
+		return affine{-1, 0, 0, -1, urx, ury}
+	case 270:
+		return affine{0, -1, 1, 0, llx, ury}
+	default:
+		return affine{1, 0, 0, 1, llx, lly}
+	}
+}
+
+// pageRotation 返回页面（含继承）的 /Rotate，规范化为0、90、180或270
+func pageRotation(data []byte, offsets map[int]int, body []byte) int {
+	rotation, err := strconv.Atoi(inheritedValue(data, offsets, body, "/Rotate"))
+	if err != nil || rotation%90 != 0 {
+		return 0
+	}
+	return (rotation%360 + 360) % 360
+}
+
+// textWidth 返回ASCII文字在Helvetica中的宽度（千分之一字号）
+func textWidth(text string) float64 {
+	width := 0
+	for _, c := range text {
+		if c >= 32 && c <= 126 {
+			width += helveticaWidths[c-32]
+		} else {
+			width += helveticaWidths['?'-32]
+		}
+	}
+	return float64(width)
+}
+
+// formatNumber 以最多三位小数输出数值
+func formatNumber(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		v = 0 // 不输出 -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// existingContents 返回页面原有内容流的引用列表（不含方括号），支持单个引用、内联数组和间接引用的数组
+func existingContents(data []byte, offsets map[int]int, body []byte) string {
+	value := directValue(body, "/Contents")
+	if m := refPattern.FindStringSubmatch(value); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		if obj, ok := objectBody(data, offsets, num); ok && bytes.HasPrefix(bytes.TrimSpace(obj), []byte("[")) {
+			value = string(bytes.TrimSpace(obj))
+		}
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+}
+
+// withResourceEntry 在资源字典的category子字典（/Font、/ExtGState等）中加入 /name num 0 R。
+// 间接引用的子字典复制为内联字典，原对象不变，其他页面不受影响。
+func withResourceEntry(data []byte, offsets map[int]int, resources, category, name string, num int) string {
+	entry := fmt.Sprintf("/%s %d 0 R", name, num)
+	value := directValue([]byte(resources), category)
+	switch {
+	case value == "":
+		return strings.Replace(resources, "<<", fmt.Sprintf("<< %s << %s >>", category, entry), 1)
+	case refPattern.MatchString(value):
+		m := refPattern.FindStringSubmatch(value)
+		sub, _ := strconv.Atoi(m[1])
+		dict := "<< >>"
+		if obj, ok := objectBody(data, offsets, sub); ok {
+			dict = string(bytes.TrimSpace(obj))
+		}
+		return withEntry(resources, category, strings.Replace(dict, "<<", "<< "+entry, 1))
+	default:
+		return withEntry(resources, category, strings.Replace(value, "<<", "<< "+entry, 1))
+	}
+}
+
+// withEntry 把字典中key的值替换为value，key不存在时添加
+func withEntry(dict, key, value string) string {
+	idx := strings.Index(dict, key)
+	if idx < 0 {
+		return strings.Replace(dict, "<<", "<< "+key+" "+value, 1)
+	}
+	existing := directValue([]byte(dict[idx:]), key)
+	start := idx + len(key) + strings.Index(dict[idx+len(key):], existing)
+	return dict[:idx+len(key)] + " " + value + dict[start+len(existing):]
+}
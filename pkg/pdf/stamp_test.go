@@ -0,0 +1,181 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stampContentsPattern = regexp.MustCompile(`/Contents\s*\[([^\]]*)\]`)
+
+// stampStreams 返回文件每一页最后一个内容流（印章添加的内容流）的内容
+func stampStreams(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	pages, _, err := readPageBoxes(path, data)
+	require.NoError(t, err)
+	index := scanObjectIndex(data)
+
+	streams := make([]string, len(pages))
+	for i, num := range pages {
+		page, err := index.object(num)
+		require.NoError(t, err)
+		m := stampContentsPattern.FindSubmatch(page)
+		require.NotNil(t, m, "第%d页没有内容数组", i+1)
+		refs := parseArrayRefs(append(append([]byte("["), m[1]...), ']'))
+		require.GreaterOrEqual(t, len(refs), 2)
+		stream, err := index.object(refs[len(refs)-1])
+		require.NoError(t, err)
+		content, err := decodeStream(stream)
+		require.NoError(t, err)
+		streams[i] = string(content)
+	}
+	return streams
+}
+
+func TestStampPDF_PageNumbers(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "in.pdf", buildFlatPDF(3))
+	output := filepath.Join(dir, "out.pdf")
+
+	require.NoError(t, StampPDF(input, output, PageNumberStamp("Page {page} of {pages}")))
+
+	streams := stampStreams(t, output)
+	require.Len(t, streams, 3)
+	for i, stream := range streams {
+		assert.True(t, strings.HasPrefix(stream, "Q\n"), "印章内容流先恢复原内容之前的图形状态")
+		assert.Contains(t, stream, fmt.Sprintf("(Page %d of 3)", i+1))
+		assert.Contains(t, stream, "/"+stampFontName+" 10 Tf")
+	}
+	pages, err := ReadPageCount(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pages)
+
+	// 输入不变
+	original, err := os.ReadFile(input)
+	require.NoError(t, err)
+	assert.Equal(t, buildFlatPDF(3), original)
+}
+
+func TestStampPDF_PositionFollowsEachPage(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "mixed.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 1224 792] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Rotate 90 >>",
+	}))
+	output := filepath.Join(dir, "out.pdf")
+	stamp := &StampOptions{Text: "X", Position: StampTopLeft, FontSize: 10}
+	require.NoError(t, StampPDF(input, output, stamp))
+
+	streams := stampStreams(t, output)
+	require.Len(t, streams, 3)
+	// 左上角：距左边和上边各 stampMargin，基线在字号之下
+	assert.Contains(t, streams[0], "1 0 0 1 24 758 Tm")
+	assert.Contains(t, streams[1], "1 0 0 1 24 758 Tm", "宽页面的左上角位置不受宽度影响")
+	// 顺时针旋转90度的页面显示时左上角对应用户空间的左下角，文字方向随显示方向旋转
+	assert.Contains(t, streams[2], "0 1 -1 0 34 24 Tm")
+}
+
+func TestStampPDF_DiagonalWatermark(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "in.pdf", buildFlatPDF(1))
+	output := filepath.Join(dir, "out.pdf")
+	require.NoError(t, StampPDF(input, output, WatermarkStamp("DRAFT")))
+
+	stream := stampStreams(t, output)[0]
+	assert.Contains(t, stream, "(DRAFT)")
+	assert.Contains(t, stream, "72 Tf")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/ca 0.3 /CA 0.3")
+}
+
+func TestStampPages_FilenamePerSource(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(3))
+	output := filepath.Join(dir, "out.pdf")
+	sources := []InputPageCount{{File: "/in/a.pdf", Pages: 2}, {File: "/in/b.pdf", Pages: 1}}
+	require.NoError(t, stampPages(input, output, []*StampOptions{PageNumberStamp("{filename} {page}")}, sources))
+
+	streams := stampStreams(t, output)
+	assert.Contains(t, streams[0], "(a.pdf 1)")
+	assert.Contains(t, streams[1], "(a.pdf 2)")
+	assert.Contains(t, streams[2], "(b.pdf 3)")
+}
+
+func TestStampOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		stamp *StampOptions
+		valid bool
+	}{
+		{"页码", PageNumberStamp("{page}"), true},
+		{"水印", WatermarkStamp("DRAFT"), true},
+		{"nil", nil, false},
+		{"空文字", &StampOptions{Text: "  "}, false},
+		{"未知位置", &StampOptions{Text: "x", Position: "middle"}, false},
+		{"字号为负", &StampOptions{Text: "x", FontSize: -1}, false},
+		{"不透明度过大", &StampOptions{Text: "x", Opacity: 1.5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.stamp.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	options := &MergeOptions{Stamps: []*StampOptions{{Text: ""}}}
+	assert.Error(t, options.Validate())
+}
+
+func TestMergeFiles_Stamps(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(1))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory: dir,
+		BackendStats:  NewBackendStatsStore(),
+		Stamps:        []*StampOptions{WatermarkStamp("DRAFT"), PageNumberStamp("{filename} {page}/{pages}")},
+	})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalPages)
+
+	streams := stampStreams(t, output)
+	require.Len(t, streams, 3)
+	assert.Contains(t, streams[0], "(a.pdf 1/3)")
+	assert.Contains(t, streams[2], "(b.pdf 3/3)")
+	// 先绘制水印，页码在水印之上
+	assert.Less(t, strings.Index(streams[2], "(DRAFT)"), strings.Index(streams[2], "(b.pdf 3/3)"))
+}
+
+func TestPDFService_StampPDF(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "in.pdf", buildFlatPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	service := NewPDFService()
+	require.NoError(t, service.StampPDF(input, output, PageNumberStamp("{page}")))
+	assert.Contains(t, stampStreams(t, output)[1], "(2)")
+
+	assert.Error(t, service.StampPDF(input, output, &StampOptions{}))
+	assert.Error(t, service.StampPDF(filepath.Join(dir, "missing.pdf"), output, PageNumberStamp("{page}")))
+}
@@ -191,7 +191,7 @@ func TestStreamingOptimization(t *testing.T) {
 		concurrentOutputFile := filepath.Join(testDir, "concurrent_output.pdf")
 
 		// 强制使用并发处理（通过修改文件数量判断逻辑）
-		err := merger.processConcurrently(ctx, testFiles, concurrentOutputFile)
+		err := merger.processConcurrently(ctx, testFiles, concurrentOutputFile, nil)
 
 		if err != nil {
 			// 并发处理可能因为各种原因失败，记录但不强制要求成功
@@ -0,0 +1,237 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// 严格模式检查项，写入ValidationIssue.Check
+const (
+	StrictCheckXRefOffsets  = "xref-offsets"  // 交叉引用表中的偏移指向声明的对象
+	StrictCheckEOFPosition  = "eof-position"  // %%EOF 位于文件最后1024字节内
+	StrictCheckStreamLength = "stream-length" // 流的 /Length 与实际数据长度一致
+	StrictCheckFilterNames  = "filter-names"  // 流只使用标准过滤器
+	StrictCheckTrailerSize  = "trailer-size"  // trailer的 /Size 与交叉引用覆盖的对象数一致
+)
+
+// strictEOFWindow %%EOF 必须出现在文件末尾的字节数
+const strictEOFWindow = 1024
+
+// strictMaxIssuesPerCheck 每个检查项最多逐个列出的对象，其余合并为一条诊断
+const strictMaxIssuesPerCheck = 10
+
+// standardFilters PDF规范定义的流过滤器
+var standardFilters = []string{
+	"ASCIIHexDecode", "ASCII85Decode", "LZWDecode", "FlateDecode", "RunLengthDecode",
+	"CCITTFaxDecode", "JBIG2Decode", "DCTDecode", "JPXDecode", "Crypt",
+}
+
+var filterNamePattern = regexp.MustCompile(`/([^\s/\[\]<>()]+)`)
+
+// strictIssues 执行严格模式的结构检查，返回的每条诊断都设置了Check。
+// 宽松验证（基本检查和pdfcpu宽松模式）能容忍这些问题，严格模式把它们视为错误
+func strictIssues(data []byte) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(check string, issue ValidationIssue) {
+		issue.Check = check
+		issues = append(issues, issue)
+	}
+
+	eofInWindow := bytes.Contains(data[max(0, len(data)-strictEOFWindow):], []byte("%%EOF"))
+	if !eofInWindow {
+		add(StrictCheckEOFPosition, newIssue(SeverityError, CategoryTrailer,
+			fmt.Sprintf("最后%d字节内没有%%%%EOF标记", strictEOFWindow),
+			"删除%%EOF之后的多余数据，或另存为新文件"))
+	}
+
+	if index, err := readXRefIndex(data); err != nil {
+		// startxref与%%EOF相邻，末尾有多余数据时找不到startxref已由eof-position报告
+		if eofInWindow {
+			add(StrictCheckXRefOffsets, newIssue(SeverityError, CategoryXRef,
+				fmt.Sprintf("无法解析交叉引用: %v", err), remedyResave))
+		}
+	} else {
+		for _, issue := range checkXRefOffsets(index) {
+			add(StrictCheckXRefOffsets, issue)
+		}
+		if issue, ok := checkTrailerSize(index); !ok {
+			add(StrictCheckTrailerSize, issue)
+		}
+	}
+
+	lengths, filters := checkStreams(data)
+	for _, issue := range lengths {
+		add(StrictCheckStreamLength, issue)
+	}
+	for _, issue := range filters {
+		add(StrictCheckFilterNames, issue)
+	}
+	return issues
+}
+
+// limitIssues 只保留前strictMaxIssuesPerCheck条诊断，其余合并为一条
+func limitIssues(issues []ValidationIssue, what string) []ValidationIssue {
+	if len(issues) <= strictMaxIssuesPerCheck {
+		return issues
+	}
+	rest := len(issues) - strictMaxIssuesPerCheck
+	summary := issues[strictMaxIssuesPerCheck]
+	summary.Message = fmt.Sprintf("另有 %d 个对象%s", rest, what)
+	summary.Object = 0
+	summary.Offset = -1
+	return append(issues[:strictMaxIssuesPerCheck], summary)
+}
+
+// checkXRefOffsets 检查各未压缩对象的交叉引用偏移是否指向该对象
+func checkXRefOffsets(index *xrefIndex) []ValidationIssue {
+	nums := make([]int, 0, len(index.entries))
+	for num, entry := range index.entries {
+		if entry.stream == 0 {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+
+	var issues []ValidationIssue
+	for _, num := range nums {
+		offset := index.entries[num].offset
+		if _, err := index.objectAt(num, offset); err != nil {
+			issue := newIssue(SeverityError, CategoryXRef, err.Error(), remedyResave)
+			issue.Offset = offset
+			issue.Object = num
+			issues = append(issues, issue)
+		}
+	}
+	return limitIssues(issues, "的交叉引用偏移不准确")
+}
+
+// checkTrailerSize 检查最新trailer的 /Size 是否等于交叉引用各段覆盖的对象编号上限
+func checkTrailerSize(index *xrefIndex) (ValidationIssue, bool) {
+	m := sizePattern.FindSubmatch(index.trailers[0])
+	if m == nil {
+		return newIssue(SeverityError, CategoryTrailer, "trailer缺少 /Size", remedyResave), false
+	}
+	size := atoiOrZero(m[1])
+	if size != index.covered {
+		return newIssue(SeverityError, CategoryTrailer,
+			fmt.Sprintf("trailer的 /Size 为 %d，交叉引用覆盖 %d 个对象", size, index.covered), remedyResave), false
+	}
+	return ValidationIssue{}, true
+}
+
+// checkStreams 检查每个未压缩流对象的 /Length 和 /Filter，分别返回长度和过滤器的诊断
+func checkStreams(data []byte) (lengths, filters []ValidationIssue) {
+	offsets := indexObjects(data)
+	nums := make([]int, 0, len(offsets))
+	for num := range offsets {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var bodies map[int][]byte // 间接的 /Length 和 /Filter 需要时才建立
+	resolve := func(raw []byte) []byte {
+		m := refPattern.FindSubmatch(raw)
+		if m == nil {
+			return raw
+		}
+		if bodies == nil {
+			bodies = objectBodies(data)
+		}
+		return bytes.TrimLeft(bodies[atoiOrZero(m[1])], " \t\r\n")
+	}
+
+	for _, num := range nums {
+		body, _ := objectBody(data, offsets, num)
+		start := bytes.Index(body, []byte("<<"))
+		if start < 0 {
+			continue
+		}
+		end := skipDictionary(body, start)
+		rest := bytes.TrimLeft(body[end:], " \t\r\n")
+		if !bytes.HasPrefix(rest, []byte("stream")) {
+			continue
+		}
+		dict := body[start:end]
+		streamData := rest[len("stream"):]
+		if bytes.HasPrefix(streamData, []byte("\r\n")) {
+			streamData = streamData[2:]
+		} else if len(streamData) > 0 && (streamData[0] == '\n' || streamData[0] == '\r') {
+			streamData = streamData[1:]
+		}
+		offset := int64(offsets[num] + len(body) - len(streamData))
+
+		if issue, ok := checkStreamLength(dict, streamData, resolve); !ok {
+			issue.Object, issue.Offset = num, offset
+			lengths = append(lengths, issue)
+		}
+		if raw, ok := dictEntry(dict, "/Filter"); ok {
+			for _, name := range filterNames(resolve(raw)) {
+				if !slices.Contains(standardFilters, name) {
+					issue := newIssue(SeverityError, CategoryStream,
+						fmt.Sprintf("对象 %d 使用了未知的过滤器 /%s", num, name),
+						"用支持标准过滤器的工具重新生成文件")
+					issue.Object, issue.Offset = num, offset
+					filters = append(filters, issue)
+				}
+			}
+		}
+	}
+	return limitIssues(lengths, "的流长度不正确"), limitIssues(filters, "使用了未知的过滤器")
+}
+
+// checkStreamLength 检查声明的 /Length 之后（允许行尾和空白）紧跟 endstream
+func checkStreamLength(dict, streamData []byte, resolve func([]byte) []byte) (ValidationIssue, bool) {
+	raw, ok := dictEntry(dict, "/Length")
+	if !ok {
+		return newIssue(SeverityError, CategoryStream, "流缺少 /Length", remedyResave), false
+	}
+	length, err := strconv.Atoi(string(leadingToken(resolve(raw))))
+	if err != nil || length < 0 {
+		return newIssue(SeverityError, CategoryStream, "流的 /Length 无效", remedyResave), false
+	}
+	if length <= len(streamData) &&
+		bytes.HasPrefix(bytes.TrimLeft(streamData[length:], " \t\r\n\f\x00"), []byte("endstream")) {
+		return ValidationIssue{}, true
+	}
+
+	actual := bytes.Index(streamData, []byte("endstream"))
+	if actual < 0 {
+		return newIssue(SeverityError, CategoryStream,
+			fmt.Sprintf("流的 /Length 为 %d，但找不到 endstream", length), remedyReacquire), false
+	}
+	actual = len(bytes.TrimRight(streamData[:actual], "\r\n"))
+	return newIssue(SeverityError, CategoryStream,
+		fmt.Sprintf("流的 /Length 为 %d，实际数据长度为 %d", length, actual), remedyResave), false
+}
+
+// leadingToken 返回raw开头到第一个空白或分隔符之前的内容
+func leadingToken(raw []byte) []byte {
+	for i, c := range raw {
+		if isPDFWhitespace(c) || isPDFDelimiter(c) {
+			return raw[:i]
+		}
+	}
+	return raw
+}
+
+// filterNames 解析 /Filter 的值（单个名称或名称数组）
+func filterNames(raw []byte) []string {
+	if bytes.HasPrefix(raw, []byte("[")) {
+		if end := bytes.IndexByte(raw, ']'); end >= 0 {
+			raw = raw[:end]
+		}
+	} else if bytes.HasPrefix(raw, []byte("/")) {
+		raw = append([]byte("/"), leadingToken(raw[1:])...)
+	} else {
+		return nil
+	}
+	var names []string
+	for _, m := range filterNamePattern.FindAllSubmatch(raw, -1) {
+		names = append(names, string(m[1]))
+	}
+	return names
+}
@@ -0,0 +1,143 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildStreamPDF 生成一页PDF，内容流的字典为dict，数据为content
+func buildStreamPDF(dict, content string) []byte {
+	return buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		fmt.Sprintf("%s\nstream\n%s\nendstream", dict, content),
+	})
+}
+
+const strictTestContent = "BT /F1 12 Tf 72 720 Td (Hello) Tj ET"
+
+// strictFixtures 宽松模式能接受、严格模式应以对应检查项拒绝的文件
+func strictFixtures() map[string][]byte {
+	flat := buildFlatPDF(1)
+	objOffset := bytes.Index(flat, []byte("2 0 obj"))
+	badOffset := bytes.Replace(flat, []byte(fmt.Sprintf("%010d 00000 n", objOffset)), []byte("0000000005 00000 n"), 1)
+
+	return map[string][]byte{
+		StrictCheckXRefOffsets: badOffset,
+		StrictCheckEOFPosition: append(buildFlatPDF(1), strings.Repeat("% padding comment line\n", 100)...),
+		StrictCheckStreamLength: buildStreamPDF(
+			fmt.Sprintf("<< /Length %d >>", len(strictTestContent)+7), strictTestContent),
+		StrictCheckFilterNames: buildStreamPDF(
+			fmt.Sprintf("<< /Length %d /Filter /FooDecode >>", len(strictTestContent)), strictTestContent),
+		StrictCheckTrailerSize: bytes.Replace(buildFlatPDF(1), []byte("/Size 4"), []byte("/Size 9"), 1),
+	}
+}
+
+// readStrictTestFile 读取测试文件内容
+func readStrictTestFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+// strictChecks 返回诊断中出现的检查项
+func strictChecks(issues []ValidationIssue) []string {
+	var checks []string
+	for _, issue := range issues {
+		if issue.Check != "" {
+			checks = append(checks, issue.Check)
+		}
+	}
+	return checks
+}
+
+func TestValidateWithStrictMode_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	validator := NewPDFValidator()
+
+	for check, data := range strictFixtures() {
+		t.Run(check, func(t *testing.T) {
+			path := createTestFile(t, dir, check+".pdf", data)
+
+			assert.NoError(t, validator.ValidatePDFFile(path), "宽松模式应接受该文件")
+
+			err := validator.ValidateWithStrictMode(path)
+			var pdfErr *PDFError
+			require.ErrorAs(t, err, &pdfErr, "严格模式应拒绝该文件")
+			assert.Equal(t, ErrorValidation, pdfErr.Type)
+			assert.Equal(t, []string{check}, strictChecks(ValidationIssues(err)))
+		})
+	}
+}
+
+func TestValidateWithStrictMode_AcceptsWellFormedFiles(t *testing.T) {
+	dir := t.TempDir()
+	validator := NewPDFValidator()
+
+	flat := createTestFile(t, dir, "flat.pdf", buildFlatPDF(3))
+	assert.NoError(t, validator.ValidateWithStrictMode(flat))
+
+	stream := createTestFile(t, dir, "stream.pdf", buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R >>",
+		"<< /Length 5 0 R /Filter [/ASCIIHexDecode] >>\nstream\n48656C6C6F>\nendstream",
+		"11",
+	}))
+	assert.Empty(t, strictIssues(readStrictTestFile(t, stream)), "间接 /Length 和过滤器数组应通过检查")
+
+	// 合并输出同样满足严格检查
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(1))
+	merger := NewStreamingMerger(&MergeOptions{TempDirectory: dir, BackendStats: NewBackendStatsStore()})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	_, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Empty(t, strictIssues(readStrictTestFile(t, output)))
+}
+
+func TestGetStrictValidationReport(t *testing.T) {
+	dir := t.TempDir()
+	validator := NewPDFValidator()
+	fixtures := strictFixtures()
+	path := createTestFile(t, dir, "size.pdf", fixtures[StrictCheckTrailerSize])
+
+	relaxed, err := validator.GetValidationReport(path)
+	require.NoError(t, err)
+	assert.True(t, relaxed.IsValid)
+
+	report, err := validator.GetStrictValidationReport(path)
+	require.NoError(t, err)
+	assert.False(t, report.IsValid)
+	assert.Equal(t, []string{StrictCheckTrailerSize}, strictChecks(report.Issues))
+	assert.Len(t, report.Errors, 1)
+}
+
+func TestStrictIssues_LimitsPerObjectEntries(t *testing.T) {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+	}
+	for i := 0; i < 15; i++ {
+		objects = append(objects, "<< /Length 1 /Filter /FooDecode >>\nstream\nx\nendstream")
+	}
+	issues := strictIssues(buildPDF(objects))
+
+	require.Len(t, issues, strictMaxIssuesPerCheck+1)
+	assert.Equal(t, 3, issues[0].Object)
+	assert.Positive(t, issues[0].Offset)
+	last := issues[strictMaxIssuesPerCheck]
+	assert.Equal(t, StrictCheckFilterNames, last.Check)
+	assert.Contains(t, last.Message, "另有 5 个对象")
+	assert.Zero(t, last.Object)
+}
@@ -0,0 +1,142 @@
+package pdf
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// 删除临时文件失败时的重试参数。被取消的分块可能仍打开着文件，
+// 在不允许删除已打开文件的系统上需要等它关闭后再删除
+const (
+	tempRemoveAttempts = 5
+	tempRemoveDelay    = 50 * time.Millisecond
+)
+
+// stagingPath 返回与输出同目录的临时输出路径，并把它和占位符后端在其旁边写出的文件登记为临时文件
+func (sm *StreamingMerger) stagingPath(outputPath string) string {
+	staging := stagingPath(outputPath, sm.clock)
+	for _, path := range []string{staging, staging + ".fallback", staging + ".placeholder"} {
+		sm.trackTempFile(path)
+	}
+	return staging
+}
+
+// cleanupTempFiles 清理临时文件
+func (sm *StreamingMerger) cleanupTempFiles(tempFiles []string) {
+	for _, file := range tempFiles {
+		if err := sm.removeTempFile(file); err != nil {
+			// 记录错误但不中断程序
+			sm.log.Warn("无法删除临时文件 %s: %v", file, err)
+		}
+		sm.untrackTempFile(file)
+	}
+}
+
+// sweepTempFiles 删除合并器登记的全部临时文件。仍有被放弃的分块协程在写入时，先删除一次，
+// 等这些协程全部退出后再删除并释放路径，避免它们在本次清理之后写出的文件遗留在临时目录
+func (sm *StreamingMerger) sweepTempFiles() {
+	files := sm.trackedTempFiles()
+	if !sm.hasTempWriters() {
+		sm.removeTempFiles(files)
+		return
+	}
+
+	for _, file := range files {
+		sm.removeTempFile(file)
+	}
+	go func() {
+		sm.waitTempWriters()
+		sm.removeTempFiles(files)
+	}()
+}
+
+// trackedTempFiles 返回已登记且尚未清理的临时文件
+func (sm *StreamingMerger) trackedTempFiles() []string {
+	sm.tempMu.Lock()
+	defer sm.tempMu.Unlock()
+	files := make([]string, 0, len(sm.tempFiles))
+	for file := range sm.tempFiles {
+		files = append(files, file)
+	}
+	return files
+}
+
+// removeTempFiles 删除临时文件并移除记录，忽略尚未生成的文件
+func (sm *StreamingMerger) removeTempFiles(files []string) {
+	for _, file := range files {
+		if err := sm.removeTempFile(file); err != nil && !os.IsNotExist(err) {
+			sm.log.Warn("无法删除临时文件 %s: %v", file, err)
+		}
+		sm.untrackTempFile(file)
+	}
+}
+
+// removeTempFile 删除一个临时文件，删除失败时短暂重试；文件不存在时直接返回该错误
+func (sm *StreamingMerger) removeTempFile(path string) error {
+	var err error
+	for attempt := 0; attempt < tempRemoveAttempts; attempt++ {
+		if attempt > 0 {
+			sm.clock.Sleep(context.Background(), tempRemoveDelay)
+		}
+		if err = os.Remove(path); err == nil || os.IsNotExist(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// trackTempFile 记录已分配的临时文件
+func (sm *StreamingMerger) trackTempFile(path string) {
+	sm.tempMu.Lock()
+	defer sm.tempMu.Unlock()
+	if sm.tempFiles == nil {
+		sm.tempFiles = make(map[string]bool)
+	}
+	sm.tempFiles[path] = true
+}
+
+// untrackTempFile 在临时文件清理后移除记录，并释放路径供以后分配
+func (sm *StreamingMerger) untrackTempFile(path string) {
+	sm.tempMu.Lock()
+	delete(sm.tempFiles, path)
+	sm.tempMu.Unlock()
+	releaseTempPath(path)
+}
+
+// beginTempWrite 登记一个合并器不再等待、但仍可能写入临时文件的协程，结束时调用 endTempWrite
+func (sm *StreamingMerger) beginTempWrite() {
+	sm.tempMu.Lock()
+	sm.tempWriters++
+	sm.tempMu.Unlock()
+}
+
+// endTempWrite 结束 beginTempWrite 登记的写入
+func (sm *StreamingMerger) endTempWrite() {
+	sm.tempMu.Lock()
+	defer sm.tempMu.Unlock()
+	sm.tempWriters--
+	if sm.tempWriters == 0 && sm.tempIdle != nil {
+		sm.tempIdle.Broadcast()
+	}
+}
+
+// hasTempWriters 是否还有登记的写入协程
+func (sm *StreamingMerger) hasTempWriters() bool {
+	sm.tempMu.Lock()
+	defer sm.tempMu.Unlock()
+	return sm.tempWriters > 0
+}
+
+// waitTempWriters 等待登记的写入协程全部退出
+func (sm *StreamingMerger) waitTempWriters() {
+	sm.tempMu.Lock()
+	defer sm.tempMu.Unlock()
+	if sm.tempIdle == nil {
+		sm.tempIdle = sync.NewCond(&sm.tempMu)
+	}
+	for sm.tempWriters > 0 {
+		sm.tempIdle.Wait()
+	}
+}
@@ -0,0 +1,115 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// 简单字体的内置编码，见PDF规范附录D
+var (
+	standardEncoding = buildStandardEncoding()
+	winAnsiEncoding  = buildCharmapEncoding(charmap.Windows1252)
+	macRomanEncoding = buildCharmapEncoding(charmap.Macintosh)
+	glyphNameToRune  = buildGlyphNames()
+	asciiGlyphNames  = strings.Fields(`space exclam quotedbl numbersign dollar percent ampersand quotesingle parenleft parenright asterisk plus comma hyphen period slash zero one two three four five six seven eight nine colon semicolon less equal greater question at A B C D E F G H I J K L M N O P Q R S T U V W X Y Z bracketleft backslash bracketright asciicircum underscore grave a b c d e f g h i j k l m n o p q r s t u v w x y z braceleft bar braceright asciitilde`)
+	latin1GlyphNames = strings.Fields(`space exclamdown cent sterling currency yen brokenbar section dieresis copyright ordfeminine guillemotleft logicalnot hyphen registered macron degree plusminus twosuperior threesuperior acute mu paragraph periodcentered cedilla onesuperior ordmasculine guillemotright onequarter onehalf threequarters questiondown Agrave Aacute Acircumflex Atilde Adieresis Aring AE Ccedilla Egrave Eacute Ecircumflex Edieresis Igrave Iacute Icircumflex Idieresis Eth Ntilde Ograve Oacute Ocircumflex Otilde Odieresis multiply Oslash Ugrave Uacute Ucircumflex Udieresis Yacute Thorn germandbls agrave aacute acircumflex atilde adieresis aring ae ccedilla egrave eacute ecircumflex edieresis igrave iacute icircumflex idieresis eth ntilde ograve oacute ocircumflex otilde odieresis divide oslash ugrave uacute ucircumflex udieresis yacute thorn ydieresis`)
+	extraGlyphRunes  = map[string]rune{
+		"Euro": '€', "quotesinglbase": '‚', "florin": 'ƒ', "quotedblbase": '„', "ellipsis": '…', "dagger": '†',
+		"daggerdbl": '‡', "circumflex": 'ˆ', "perthousand": '‰', "Scaron": 'Š', "guilsinglleft": '‹', "OE": 'Œ',
+		"Zcaron": 'Ž', "quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”', "bullet": '•',
+		"endash": '–', "emdash": '—', "tilde": '˜', "trademark": '™', "scaron": 'š', "guilsinglright": '›', "oe": 'œ',
+		"zcaron": 'ž', "Ydieresis": 'Ÿ', "fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ', "fraction": '⁄',
+		"dotlessi": 'ı', "Lslash": 'Ł', "lslash": 'ł', "breve": '˘', "dotaccent": '˙', "ring": '˚', "hungarumlaut": '˝',
+		"ogonek": '˛', "caron": 'ˇ', "minus": '−', "nbspace": ' ', "sfthyphen": '­',
+	}
+	// standardHighGlyphs StandardEncoding 中0x80以上的编码对应的字形名
+	standardHighGlyphs = map[byte]string{
+		0xA1: "exclamdown", 0xA2: "cent", 0xA3: "sterling", 0xA4: "fraction", 0xA5: "yen", 0xA6: "florin",
+		0xA7: "section", 0xA8: "currency", 0xA9: "quotesingle", 0xAA: "quotedblleft", 0xAB: "guillemotleft",
+		0xAC: "guilsinglleft", 0xAD: "guilsinglright", 0xAE: "fi", 0xAF: "fl", 0xB1: "endash", 0xB2: "dagger",
+		0xB3: "daggerdbl", 0xB4: "periodcentered", 0xB6: "paragraph", 0xB7: "bullet", 0xB8: "quotesinglbase",
+		0xB9: "quotedblbase", 0xBA: "quotedblright", 0xBB: "guillemotright", 0xBC: "ellipsis", 0xBD: "perthousand",
+		0xBF: "questiondown", 0xC1: "grave", 0xC2: "acute", 0xC3: "circumflex", 0xC4: "tilde", 0xC5: "macron",
+		0xC6: "breve", 0xC7: "dotaccent", 0xC8: "dieresis", 0xCA: "ring", 0xCB: "cedilla", 0xCD: "hungarumlaut",
+		0xCE: "ogonek", 0xCF: "caron", 0xD0: "emdash", 0xE1: "AE", 0xE3: "ordfeminine", 0xE8: "Lslash",
+		0xE9: "Oslash", 0xEA: "OE", 0xEB: "ordmasculine", 0xF1: "ae", 0xF5: "dotlessi", 0xF8: "lslash",
+		0xF9: "oslash", 0xFA: "oe", 0xFB: "germandbls",
+	}
+)
+
+// buildGlyphNames 建立常用字形名到Unicode字符的映射：ASCII、Latin-1和WinAnsiEncoding中的字形
+func buildGlyphNames() map[string]rune {
+	names := make(map[string]rune, len(asciiGlyphNames)+len(latin1GlyphNames)+len(extraGlyphRunes))
+	for i, name := range asciiGlyphNames {
+		names[name] = rune(0x20 + i)
+	}
+	// 0xA0 的字形名与0x20相同，不能覆盖空格
+	for i, name := range latin1GlyphNames[1:] {
+		names[name] = rune(0xA1 + i)
+	}
+	for name, r := range extraGlyphRunes {
+		names[name] = r
+	}
+	return names
+}
+
+// buildStandardEncoding 返回StandardEncoding：ASCII中的两个引号为弯引号，0x80以上按字形表映射
+func buildStandardEncoding() *[256]rune {
+	var table [256]rune
+	for c := 0x20; c < 0x7F; c++ {
+		table[c] = rune(c)
+	}
+	table['\''] = '’'
+	table['`'] = '‘'
+	for c, name := range standardHighGlyphs {
+		table[c] = glyphRune(name)
+	}
+	return &table
+}
+
+// buildCharmapEncoding 按单字节字符集建立编码表，控制字符和未定义的编码为0
+func buildCharmapEncoding(cm *charmap.Charmap) *[256]rune {
+	var table [256]rune
+	for c := 0x20; c < 0x100; c++ {
+		if r := cm.DecodeByte(byte(c)); r != '�' && r != 0x7F && (r < 0x80 || r > 0x9F) {
+			table[c] = r
+		}
+	}
+	return &table
+}
+
+// glyphRune 返回字形名对应的字符，支持 uniXXXX、uXXXX 形式和 .sc 等后缀；未知的字形名返回0
+func glyphRune(name string) rune {
+	if r, ok := glyphNameToRune[name]; ok {
+		return r
+	}
+	if base, _, found := strings.Cut(name, "."); found && base != "" {
+		return glyphRune(base)
+	}
+	for _, prefix := range []string{"uni", "u"} {
+		if hex := strings.TrimPrefix(name, prefix); hex != name && len(hex) >= 4 && len(hex) <= 6 {
+			if v, err := strconv.ParseUint(hex[:4], 16, 32); err == nil && prefix == "uni" {
+				return rune(v)
+			}
+			if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				return rune(v)
+			}
+		}
+	}
+	return 0
+}
+
+// baseEncoding 返回编码名对应的编码表，未知的名称返回nil
+func baseEncoding(name string) *[256]rune {
+	switch name {
+	case "/StandardEncoding":
+		return standardEncoding
+	case "/WinAnsiEncoding":
+		return winAnsiEncoding
+	case "/MacRomanEncoding":
+		return macRomanEncoding
+	}
+	return nil
+}
@@ -0,0 +1,768 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// maxFormDepth 提取文字时进入嵌套表单XObject的最大层数
+	maxFormDepth = 8
+	// textSpaceThreshold TJ 数组中大于该值（千分之一文字空间单位）的负偏移被视为单词间的空格
+	textSpaceThreshold = 200
+)
+
+var (
+	type0FontPattern   = regexp.MustCompile(`/Subtype\s*/Type0\b`)
+	formTypePattern    = regexp.MustCompile(`/Subtype\s*/Form\b`)
+	cmapHexPattern     = regexp.MustCompile(`<([0-9A-Fa-f\s]*)>`)
+	cmapSectionPattern = regexp.MustCompile(`(?s)begin(codespacerange|bfchar|bfrange)(.*?)end(codespacerange|bfchar|bfrange)`)
+)
+
+// PageText 一页提取出的文字
+type PageText struct {
+	Page    int    // 页码，从1开始
+	Text    string // 页面文字，行之间以换行分隔；没有可提取的文字时为空
+	Warning string // 无法提取全部文字时的说明，例如只有图像的扫描页
+}
+
+// TextPageFunc 每提取完一页调用一次，返回错误时停止提取并返回该错误
+type TextPageFunc func(page PageText) error
+
+// ExtractPageTexts 不依赖pdfcpu按pages的顺序（为空时为全部页面）逐页提取文字并调用emit，同一时间只保留一页的文字。
+// 字符串按字体的 /ToUnicode CMap 解码，没有CMap时按 /Encoding（StandardEncoding、WinAnsiEncoding、
+// MacRomanEncoding 及 /Differences）解码；页面引用的表单XObject中的文字也会提取。
+// 没有ToUnicode的复合字体（Type0）无法还原字符，只有图像的页面得到空字符串，两者都通过PageText.Warning说明
+func ExtractPageTexts(filePath string, pages []int, emit TextPageFunc) error {
+	if encrypted, err := hasEncryptEntry(filePath); err == nil && encrypted {
+		return &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法从加密文件中提取文字",
+			File:    filePath,
+		}
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	stats, err := WalkPageTree(filePath, data, nil)
+	if err != nil {
+		return err
+	}
+	pages, err = selectPages(filePath, pages, len(stats.Pages))
+	if err != nil {
+		return err
+	}
+
+	extractor := &textExtractor{data: data, offsets: indexObjects(data), fonts: make(map[int]*textFont)}
+	for _, page := range pages {
+		text, err := extractor.page(stats.Pages[page-1])
+		if err != nil {
+			return &PDFError{
+				Type:    ErrorCorrupted,
+				Message: fmt.Sprintf("无法读取第%d页的内容流", page),
+				File:    filePath,
+				Cause:   err,
+			}
+		}
+		text.Page = page
+		if err := emit(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractText 提取pages（为空时为全部页面）的文字，返回页码到文字的映射，见ExtractPageTexts
+func ExtractText(filePath string, pages []int) (map[int]string, error) {
+	texts := make(map[int]string)
+	err := ExtractPageTexts(filePath, pages, func(page PageText) error {
+		texts[page.Page] = page.Text
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return texts, nil
+}
+
+// selectPages 检查页码都在1到count之间，pages为空时返回全部页码
+func selectPages(filePath string, pages []int, count int) ([]int, error) {
+	if len(pages) == 0 {
+		pages = make([]int, count)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		return pages, nil
+	}
+	for _, page := range pages {
+		if page < 1 || page > count {
+			return nil, &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("页码 %d 超出范围（共 %d 页）", page, count),
+				File:    filePath,
+			}
+		}
+	}
+	return pages, nil
+}
+
+// WritePageText 把一页的文字写到w，每页之后写一个换页符（\f），与常见的文字提取工具相同
+func WritePageText(w io.Writer, page PageText) error {
+	if _, err := io.WriteString(w, page.Text); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\f")
+	return err
+}
+
+// textExtractor 在一个文件的多个页面之间共享对象索引和已解析的字体
+type textExtractor struct {
+	data    []byte
+	offsets map[int]int
+	fonts   map[int]*textFont // 按字体对象编号缓存
+}
+
+// textState 提取一页文字时的状态
+type textState struct {
+	out       strings.Builder
+	font      *textFont
+	fontName  string
+	matrixY   float64 // 文字行矩阵的y坐标
+	lineY     float64 // 输出中当前行的y坐标
+	hasImages bool
+	undecoded map[string]bool // 无法解码的字体名
+}
+
+// page 提取一个页面对象的文字
+func (e *textExtractor) page(num int) (PageText, error) {
+	body, _ := objectBody(e.data, e.offsets, num)
+	content, err := pageContent(e.data, e.offsets, body)
+	if err != nil {
+		return PageText{}, err
+	}
+	state := &textState{undecoded: make(map[string]bool)}
+	e.run(state, content, inheritedResources(e.data, e.offsets, body), 0)
+
+	text := PageText{Text: strings.TrimSpace(state.out.String())}
+	switch {
+	case len(state.undecoded) > 0:
+		names := make([]string, 0, len(state.undecoded))
+		for name := range state.undecoded {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		text.Warning = fmt.Sprintf("字体 %s 没有 /ToUnicode，部分文字无法提取", strings.Join(names, "、"))
+	case text.Text == "" && state.hasImages:
+		text.Warning = "页面只有图像，没有可提取的文字"
+	}
+	return text, nil
+}
+
+// run 执行内容流中的文字操作，resources为内容流所在页面或表单XObject的资源字典
+func (e *textExtractor) run(state *textState, content, resources []byte, depth int) {
+	lookup := func(num int) ([]byte, bool) {
+		return objectBody(e.data, e.offsets, num)
+	}
+	var fonts, xobjects []byte
+	if resources != nil {
+		fonts = resolveDictWith(resources, "/Font", lookup)
+		xobjects = resolveDictWith(resources, "/XObject", lookup)
+	}
+
+	scanContentOperators(content, func(op string, operands []contentOperand) {
+		switch op {
+		case "BT":
+			state.matrixY = 0
+		case "Tf":
+			if len(operands) >= 2 && strings.HasPrefix(operands[0].token, "/") {
+				state.fontName = operands[0].token
+				state.font = e.font(fonts, operands[0].token[1:])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty, _ := strconv.ParseFloat(operands[1].token, 64)
+				state.moveTo(state.matrixY + ty)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := strconv.ParseFloat(operands[5].token, 64)
+				state.moveTo(y)
+			}
+		case "T*":
+			state.newLine()
+		case "Tj":
+			if len(operands) >= 1 {
+				state.show(operands[0])
+			}
+		case "'", "\"":
+			state.newLine()
+			if n := len(operands); n >= 1 {
+				state.show(operands[n-1])
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				for _, item := range operands[0].array {
+					if item.isString {
+						state.show(item)
+					} else if v, err := strconv.ParseFloat(item.token, 64); err == nil && v < -textSpaceThreshold {
+						state.space()
+					}
+				}
+			}
+		case "BI":
+			state.hasImages = true
+		case "Do":
+			if len(operands) >= 1 && strings.HasPrefix(operands[0].token, "/") {
+				e.drawXObject(state, xobjects, operands[0].token[1:], resources, depth)
+			}
+		}
+	})
+}
+
+// drawXObject 处理 Do：图像XObject记为页面有图像，表单XObject递归提取其中的文字
+func (e *textExtractor) drawXObject(state *textState, xobjects []byte, name string, resources []byte, depth int) {
+	num, ok := resourceRef(xobjects, name)
+	if !ok {
+		return
+	}
+	obj, ok := objectBody(e.data, e.offsets, num)
+	if !ok {
+		return
+	}
+	if imageTypePattern.Match(obj) {
+		state.hasImages = true
+		return
+	}
+	if depth >= maxFormDepth || !formTypePattern.Match(obj) {
+		return
+	}
+	dict, _, err := splitStream(obj)
+	if err != nil {
+		return
+	}
+	content, err := decodeStream(obj)
+	if err != nil {
+		return
+	}
+	// 没有自己资源的表单使用所在页面的资源
+	formResources := resolveDictWith(dict, "/Resources", func(num int) ([]byte, bool) {
+		return objectBody(e.data, e.offsets, num)
+	})
+	if formResources == nil {
+		formResources = resources
+	}
+	// 表单有自己的坐标系，其中的文字总是另起一行，之后的文字也不与表单中的文字同行
+	state.newLine()
+	state.lineY = math.Inf(1)
+	e.run(state, content, formResources, depth+1)
+	state.newLine()
+	state.lineY = math.Inf(1)
+}
+
+// font 返回资源字典中名为name的字体，按对象编号缓存；内联或无法解析的字体按StandardEncoding解码
+func (e *textExtractor) font(fonts []byte, name string) *textFont {
+	num, ok := resourceRef(fonts, name)
+	if !ok {
+		return &textFont{encoding: standardEncoding}
+	}
+	if font, ok := e.fonts[num]; ok {
+		return font
+	}
+	font := &textFont{encoding: standardEncoding}
+	if dict, ok := objectDict(e.data, e.offsets, num); ok {
+		font = e.parseFont(dict)
+	}
+	e.fonts[num] = font
+	return font
+}
+
+// parseFont 解析字体字典的 /ToUnicode 和 /Encoding
+func (e *textExtractor) parseFont(dict []byte) *textFont {
+	font := &textFont{composite: type0FontPattern.Match(dict), encoding: standardEncoding}
+	if m := refPattern.FindStringSubmatch(directValue(dict, "/ToUnicode")); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		if obj, ok := objectBody(e.data, e.offsets, num); ok {
+			if stream, err := decodeStream(obj); err == nil {
+				font.toUnicode = parseToUnicodeCMap(stream)
+			}
+		}
+	}
+	if font.composite {
+		return font
+	}
+
+	lookup := func(num int) ([]byte, bool) {
+		return objectBody(e.data, e.offsets, num)
+	}
+	if encoding := resolveDictWith(dict, "/Encoding", lookup); encoding != nil {
+		table := *standardEncoding
+		if base := baseEncoding(entryValue(encoding, "/BaseEncoding")); base != nil {
+			table = *base
+		}
+		applyDifferences(&table, encoding)
+		font.encoding = &table
+	} else if base := baseEncoding(entryValue(dict, "/Encoding")); base != nil {
+		font.encoding = base
+	}
+	return font
+}
+
+// resourceRef 返回资源字典中名为name的条目引用的对象编号
+func resourceRef(dict []byte, name string) (int, bool) {
+	for _, m := range xobjectRefPattern.FindAllSubmatch(dict, -1) {
+		if string(m[1]) == name {
+			num, err := strconv.Atoi(string(m[2]))
+			return num, err == nil
+		}
+	}
+	return 0, false
+}
+
+// applyDifferences 按编码字典的 /Differences 数组修改编码表
+func applyDifferences(table *[256]rune, encoding []byte) {
+	idx := bytes.Index(encoding, []byte("/Differences"))
+	if idx < 0 {
+		return
+	}
+	rest := encoding[idx+len("/Differences"):]
+	start := bytes.IndexByte(rest, '[')
+	end := bytes.IndexByte(rest, ']')
+	if start < 0 || end < start {
+		return
+	}
+	code := 0
+	for _, field := range strings.Fields(strings.ReplaceAll(string(rest[start+1:end]), "/", " /")) {
+		if strings.HasPrefix(field, "/") {
+			if code >= 0 && code < 256 {
+				table[code] = glyphRune(field[1:])
+			}
+			code++
+			continue
+		}
+		if v, err := strconv.Atoi(field); err == nil {
+			code = v
+		}
+	}
+}
+
+// newLine 在已有文字之后开始新的一行，去掉行尾的空格
+func (s *textState) newLine() {
+	text := s.out.String()
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return
+	}
+	if trimmed := strings.TrimRight(text, " "); len(trimmed) != len(text) {
+		s.out.Reset()
+		s.out.WriteString(trimmed)
+	}
+	s.out.WriteByte('\n')
+}
+
+// moveTo 把文字行矩阵移到y：与当前行的y不同时换行，相同时视为同一行中的下一个单词
+func (s *textState) moveTo(y float64) {
+	s.matrixY = y
+	if math.Abs(y-s.lineY) > 0.5 {
+		s.lineY = y
+		s.newLine()
+		return
+	}
+	s.space()
+}
+
+// space 在单词之间补一个空格
+func (s *textState) space() {
+	if s.out.Len() == 0 {
+		return
+	}
+	text := s.out.String()
+	if !strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\n") {
+		s.out.WriteByte(' ')
+	}
+}
+
+// show 用当前字体解码字符串操作数并追加到输出
+func (s *textState) show(operand contentOperand) {
+	if !operand.isString {
+		return
+	}
+	font := s.font
+	if font == nil {
+		font = &textFont{encoding: standardEncoding}
+	}
+	text, ok := font.decode(operand.str)
+	if !ok {
+		s.undecoded[s.fontName] = true
+	}
+	s.out.WriteString(text)
+}
+
+// textFont 把字符串中的字符编码解码为Unicode文字
+type textFont struct {
+	toUnicode *toUnicodeCMap // 字体的 /ToUnicode，没有时为nil
+	encoding  *[256]rune     // 简单字体的编码，ToUnicode中没有的编码按此解码
+	composite bool           // Type0 复合字体，没有ToUnicode时无法解码
+}
+
+// decode 解码字符串，有无法解码的字符时第二个返回值为false
+func (f *textFont) decode(str []byte) (string, bool) {
+	if f.toUnicode != nil {
+		return f.toUnicode.decode(str, f.encoding, f.composite)
+	}
+	if f.composite {
+		return "", false
+	}
+	var b strings.Builder
+	for _, c := range str {
+		if r := f.encoding[c]; r != 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), true
+}
+
+// toUnicodeCMap /ToUnicode CMap 中的码空间和映射
+type toUnicodeCMap struct {
+	lengths []int             // 码空间中出现的编码字节数，升序
+	chars   map[string]string // 编码（原始字节）到文字
+	ranges  []cmapRange
+}
+
+// cmapRange bfrange 中的一段连续编码
+type cmapRange struct {
+	length int
+	lo, hi uint32
+	base   []rune   // 目标为单个字符串时，lo对应的文字，之后的编码依次递增最后一个字符
+	list   []string // 目标为数组时，按顺序对应lo到hi的文字
+}
+
+// parseToUnicodeCMap 解析CMap中的 codespacerange、bfchar 和 bfrange
+func parseToUnicodeCMap(stream []byte) *toUnicodeCMap {
+	cmap := &toUnicodeCMap{chars: make(map[string]string)}
+	seen := make(map[int]bool)
+	for _, section := range cmapSectionPattern.FindAllSubmatch(stream, -1) {
+		kind, body := string(section[1]), section[2]
+		switch kind {
+		case "codespacerange":
+			codes := hexTokens(body)
+			for i := 0; i+1 < len(codes); i += 2 {
+				seen[len(codes[i])] = true
+			}
+		case "bfchar":
+			codes := hexTokens(body)
+			for i := 0; i+1 < len(codes); i += 2 {
+				cmap.chars[string(codes[i])] = utf16BEString(codes[i+1])
+				seen[len(codes[i])] = true
+			}
+		case "bfrange":
+			cmap.ranges = append(cmap.ranges, parseBFRanges(body)...)
+		}
+	}
+	for _, r := range cmap.ranges {
+		seen[r.length] = true
+	}
+	for length := range seen {
+		if length > 0 && length <= 4 {
+			cmap.lengths = append(cmap.lengths, length)
+		}
+	}
+	sort.Ints(cmap.lengths)
+	return cmap
+}
+
+// parseBFRanges 解析 bfrange 段中的各行：<lo> <hi> <dst> 或 <lo> <hi> [<dst1> <dst2> ...]
+func parseBFRanges(body []byte) []cmapRange {
+	var ranges []cmapRange
+	for len(body) > 0 {
+		codes := cmapHexPattern.FindAllSubmatchIndex(body, 2)
+		if len(codes) < 2 {
+			break
+		}
+		lo := hexBytes(body[codes[0][2]:codes[0][3]])
+		hi := hexBytes(body[codes[1][2]:codes[1][3]])
+		rest := bytes.TrimLeft(body[codes[1][1]:], " \t\r\n")
+		r := cmapRange{length: len(lo), lo: codeValue(lo), hi: codeValue(hi)}
+		switch {
+		case bytes.HasPrefix(rest, []byte("[")):
+			end := bytes.IndexByte(rest, ']')
+			if end < 0 {
+				return ranges
+			}
+			for _, dst := range hexTokens(rest[:end]) {
+				r.list = append(r.list, utf16BEString(dst))
+			}
+			body = rest[end+1:]
+		case bytes.HasPrefix(rest, []byte("<")):
+			end := bytes.IndexByte(rest, '>')
+			if end < 0 {
+				return ranges
+			}
+			r.base = []rune(utf16BEString(hexBytes(rest[1:end])))
+			body = rest[end+1:]
+		default:
+			return ranges
+		}
+		if len(lo) > 0 && r.hi >= r.lo {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// decode 按码空间逐个编码解码字符串。CMap中没有的单字节编码按encoding解码（只用于简单字体）
+func (c *toUnicodeCMap) decode(str []byte, encoding *[256]rune, composite bool) (string, bool) {
+	lengths := c.lengths
+	if len(lengths) == 0 {
+		lengths = []int{1}
+		if composite {
+			lengths = []int{2}
+		}
+	}
+	var b strings.Builder
+	complete := true
+	for i := 0; i < len(str); {
+		matched := false
+		for _, length := range lengths {
+			if i+length > len(str) {
+				break
+			}
+			if text, ok := c.lookup(str[i : i+length]); ok {
+				b.WriteString(text)
+				i += length
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if !composite && encoding != nil {
+			if r := encoding[str[i]]; r != 0 {
+				b.WriteRune(r)
+			}
+		} else {
+			complete = false
+		}
+		i += lengths[0]
+	}
+	return b.String(), complete
+}
+
+// lookup 返回编码对应的文字
+func (c *toUnicodeCMap) lookup(code []byte) (string, bool) {
+	if text, ok := c.chars[string(code)]; ok {
+		return text, true
+	}
+	value := codeValue(code)
+	for _, r := range c.ranges {
+		if r.length != len(code) || value < r.lo || value > r.hi {
+			continue
+		}
+		offset := int(value - r.lo)
+		if r.list != nil {
+			if offset < len(r.list) {
+				return r.list[offset], true
+			}
+			return "", false
+		}
+		if len(r.base) == 0 {
+			return "", false
+		}
+		runes := append([]rune(nil), r.base...)
+		runes[len(runes)-1] += rune(offset)
+		return string(runes), true
+	}
+	return "", false
+}
+
+// hexTokens 返回数据中所有十六进制字符串解码后的字节
+func hexTokens(data []byte) [][]byte {
+	var tokens [][]byte
+	for _, m := range cmapHexPattern.FindAllSubmatch(data, -1) {
+		tokens = append(tokens, hexBytes(m[1]))
+	}
+	return tokens
+}
+
+// hexBytes 解码十六进制数字，忽略空白，奇数个数字时在末尾补0
+func hexBytes(digits []byte) []byte {
+	clean := strings.Join(strings.Fields(string(digits)), "")
+	if len(clean)%2 == 1 {
+		clean += "0"
+	}
+	decoded, err := hex.DecodeString(clean)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// codeValue 把最多4个字节的大端编码转为整数
+func codeValue(code []byte) uint32 {
+	var v uint32
+	for _, c := range code {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+// utf16BEString 把UTF-16BE字节解码为字符串
+func utf16BEString(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// contentOperand 内容流中的一个操作数
+type contentOperand struct {
+	token    string           // 数字、名称等记号
+	str      []byte           // 字符串操作数解码后的字节，isString为true时有效
+	isString bool             // 是否为字面或十六进制字符串
+	array    []contentOperand // 数组操作数的元素
+}
+
+// scanContentOperators 按顺序对内容流中的每个操作符调用fn，操作数中的字符串已解码为字节。
+// 字典操作数被跳过；内联图像以操作符 "BI" 报告，唯一的操作数的str为BI之后到EI（含）的原始字节
+func scanContentOperators(content []byte, fn func(op string, operands []contentOperand)) {
+	var operands []contentOperand
+	var arrays [][]contentOperand // 未闭合的数组，最内层在最后
+	push := func(operand contentOperand) {
+		if n := len(arrays); n > 0 {
+			arrays[n-1] = append(arrays[n-1], operand)
+			return
+		}
+		operands = append(operands, operand)
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isPDFWhitespace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			var str []byte
+			str, i = parseLiteralString(content, i)
+			push(contentOperand{str: str, isString: true})
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i = skipDictionary(content, i)
+			push(contentOperand{token: "<<>>"})
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			push(contentOperand{str: hexBytes(content[i+1 : i+end]), isString: true})
+			i += end + 1
+		case c == '[':
+			arrays = append(arrays, nil)
+			i++
+		case c == ']':
+			if n := len(arrays); n > 0 {
+				array := arrays[n-1]
+				arrays = arrays[:n-1]
+				push(contentOperand{array: array})
+			}
+			i++
+		case c == '{' || c == '}' || c == ')' || c == '>':
+			i++
+		default:
+			start := i
+			i++
+			for i < len(content) && !isPDFWhitespace(content[i]) && !strings.ContainsRune("()<>[]{}/%", rune(content[i])) {
+				i++
+			}
+			token := string(content[start:i])
+			if c == '/' || c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') || len(arrays) > 0 {
+				push(contentOperand{token: token})
+				continue
+			}
+			if token == "BI" {
+				start := i
+				i = skipInlineImage(content, i)
+				operands = append(operands[:0], contentOperand{str: content[start:i]})
+			}
+			fn(token, operands)
+			operands = operands[:0]
+		}
+	}
+}
+
+// parseLiteralString 解码从i开始的字面字符串（支持嵌套括号、转义和八进制转义），返回字节和其后的位置
+func parseLiteralString(content []byte, i int) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(content) {
+				return out, i
+			}
+			switch e := content[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// 行连接：反斜杠后的行结束符被忽略
+				if i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					for n := 0; n < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; n++ {
+						v = v*8 + int(content[i]-'0')
+						i++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, len(content)
+}
@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTextPDF 写出单页测试文件，content 为页面内容流，fonts 为资源中 /Font 的条目，extra 为之后的对象（从5开始编号）
+func writeTextPDF(t *testing.T, dir, name, content, fonts string, extra ...string) string {
+	t.Helper()
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << " + fonts + " >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+	return createTestFile(t, dir, name, buildPDF(append(objects, extra...)))
+}
+
+// streamObject 返回未压缩的流对象
+func streamObject(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func TestExtractText_NumberedPages(t *testing.T) {
+	input := writeNumberedPDF(t, t.TempDir(), "numbered.pdf", 3)
+
+	texts, err := ExtractText(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]string{1: "Page 1", 2: "Page 2", 3: "Page 3"}, texts)
+
+	texts, err = ExtractText(input, []int{3, 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]string{1: "Page 1", 3: "Page 3"}, texts)
+
+	_, err = ExtractText(input, []int{4})
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidInput, pdfErr.Type)
+}
+
+func TestExtractText_EncodingsAndLayout(t *testing.T) {
+	// WinAnsi的0xE9为é，/Differences把0x80映射为欧元符号；TJ中的大偏移为空格，Td换行
+	content := "BT /F1 12 Tf 72 720 Td (Caf\\351 \\200) Tj 0 -14 Td [(Hello) -300 (World)] TJ ET\n" +
+		"BT /F1 12 Tf 1 0 0 1 72 692 Tm (same) Tj 1 0 0 1 200 692 Tm (line) Tj ET"
+	input := writeTextPDF(t, t.TempDir(), "winansi.pdf", content, "/F1 5 0 R",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding 6 0 R >>",
+		"<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [128 /Euro] >>")
+
+	texts, err := ExtractText(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Café €\nHello World\nsame line", texts[1])
+}
+
+func TestExtractText_ToUnicodeCMap(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"2 beginbfchar <0001> <4F60> <0002> <597D> endbfchar\n" +
+		"2 beginbfrange <0010> <0012> <0041> <0020> <0021> [<0078> <0079>] endbfrange\n" +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	content := "BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -14 Td <0010001100120020 0021> Tj ET\n" +
+		"BT /F2 12 Tf 72 600 Td <0001> Tj ET"
+	input := writeTextPDF(t, t.TempDir(), "cjk.pdf", content, "/F1 5 0 R /F2 7 0 R",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /Encoding /Identity-H /ToUnicode 6 0 R >>",
+		streamObject("", cmap),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /NoMap /Encoding /Identity-H >>")
+
+	var pages []PageText
+	err := ExtractPageTexts(input, nil, func(page PageText) error {
+		pages = append(pages, page)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.Equal(t, "你好\nABCxy", pages[0].Text, "没有ToUnicode的复合字体不应输出乱码")
+	assert.Contains(t, pages[0].Warning, "/F2")
+}
+
+func TestExtractText_ImageOnlyPageWarns(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /XObject << /Im1 5 0 R >> >> >>",
+		streamObject("", "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+		flateImageObject(thumbnailBorderColor),
+	})
+	input := createTestFile(t, t.TempDir(), "scan.pdf", data)
+
+	var pages []PageText
+	require.NoError(t, ExtractPageTexts(input, nil, func(page PageText) error {
+		pages = append(pages, page)
+		return nil
+	}))
+	require.Len(t, pages, 1)
+	assert.Empty(t, pages[0].Text)
+	assert.NotEmpty(t, pages[0].Warning)
+}
+
+func TestExtractText_ImposedFormXObjects(t *testing.T) {
+	dir := t.TempDir()
+	input := writeNumberedPDF(t, dir, "handout.pdf", 4)
+	output := filepath.Join(dir, "2up.pdf")
+	_, err := ImposePages(input, output, &ImpositionOptions{NUp: 2})
+	require.NoError(t, err)
+
+	texts, err := ExtractText(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]string{1: "Page 1\nPage 2", 2: "Page 3\nPage 4"}, texts, "应提取表单XObject中的文字")
+}
+
+func TestPDFServiceImpl_ExtractTextTo(t *testing.T) {
+	input := writeNumberedPDF(t, t.TempDir(), "numbered.pdf", 3)
+	service := NewPDFService()
+
+	var buf bytes.Buffer
+	require.NoError(t, service.ExtractTextTo(input, []int{2, 3}, &buf))
+	assert.Equal(t, "Page 2\fPage 3\f", buf.String())
+
+	texts, err := service.ExtractText(input, nil)
+	require.NoError(t, err)
+	assert.Len(t, texts, 3)
+}
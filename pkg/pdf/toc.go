@@ -0,0 +1,206 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 目录页版式（美国信纸，单位为点）
+const (
+	tocPageWidth      = 612
+	tocPageHeight     = 792
+	tocMargin         = 72
+	tocHeadingSize    = 18
+	tocFontSize       = 11
+	tocLineHeight     = 18
+	tocEntriesPerPage = 33 // 首行基线666到下边距72之间，每行18
+	tocFontName       = "PDFMergerTOCFont"
+)
+
+// TOCEntry 目录中的一行
+type TOCEntry struct {
+	Title string // 来源的标题（文档标题，没有时为文件名）
+	Page  int    // 来源在插入目录页之前的输出中的第一页（从1开始）
+}
+
+// TOCSource 生成目录的一个来源文件
+type TOCSource struct {
+	File        string // 用于统计页数和读取标题的文件，加密输入为解密副本
+	DisplayName string // 没有文档标题时显示的文件名，为空时使用File
+}
+
+// TOCPageCount 返回列出entries个来源需要的目录页数
+func TOCPageCount(entries int) int {
+	return (entries + tocEntriesPerPage - 1) / tocEntriesPerPage
+}
+
+// AddTOCPages 以增量更新在文档开头插入目录页并写入outputPath，输入与输出可以相同，返回插入的页数。
+// 每行显示来源标题、点引导线和插入目录页后的起始页码，整行是跳转到该页的链接。
+// 来源较多时目录分为多页。书签和链接通过页面对象引用目标，插入目录页后仍指向原来的页面。
+// 使用标准字体Helvetica，标题中ASCII以外的字符显示为问号。
+func AddTOCPages(inputPath, outputPath string, entries []TOCEntry) (int, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取PDF文件",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return 0, &PDFError{
+			Type:    ErrorEncrypted,
+			Message: "无法为加密文件添加目录页",
+			File:    inputPath,
+		}
+	}
+	if len(entries) == 0 {
+		return 0, &PDFError{
+			Type:    ErrorInvalidInput,
+			Message: "目录没有条目",
+			File:    inputPath,
+		}
+	}
+
+	stats, err := WalkPageTree(inputPath, data, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		if entry.Page < 1 || entry.Page > len(stats.Pages) {
+			return 0, &PDFError{
+				Type:    ErrorInvalidInput,
+				Message: fmt.Sprintf("目录条目 %q 的页码 %d 超出文档页数 %d", entry.Title, entry.Page, len(stats.Pages)),
+				File:    inputPath,
+			}
+		}
+	}
+
+	offsets := indexObjects(data)
+	rootNum, err := findPageTreeRoot(data, offsets)
+	if err != nil {
+		return 0, &PDFError{
+			Type:    ErrorCorrupted,
+			Message: "无法定位页面树",
+			File:    inputPath,
+			Cause:   err,
+		}
+	}
+	rootBody, _ := objectBody(data, offsets, rootNum)
+
+	update := newIncrementalUpdate(data, offsets)
+	fontNum := update.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	count := TOCPageCount(len(entries))
+	pageNums := make([]int, count)
+	for i := range pageNums {
+		pageNums[i] = update.add("")
+	}
+
+	for i, pageNum := range pageNums {
+		end := min((i+1)*tocEntriesPerPage, len(entries))
+		lines := entries[i*tocEntriesPerPage : end]
+
+		var content bytes.Buffer
+		heading := "Contents"
+		if i > 0 {
+			heading = "Contents (continued)"
+		}
+		fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n",
+			tocFontName, tocHeadingSize, tocMargin, tocPageHeight-tocMargin-tocHeadingSize, escapePDFLiteral(heading))
+
+		annots := make([]int, len(lines))
+		for j, entry := range lines {
+			y := float64(tocPageHeight - tocMargin - tocHeadingSize - 36 - j*tocLineHeight)
+			content.WriteString(tocLine(entry.Title, strconv.Itoa(entry.Page+count), y))
+			// 链接覆盖整行，边框不可见
+			annots[j] = update.add(fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%d %s %d %s] /Border [0 0 0] /Dest [%d 0 R /Fit] >>",
+				tocMargin, formatNumber(y-4), tocPageWidth-tocMargin, formatNumber(y+tocFontSize),
+				stats.Pages[entry.Page-1]))
+		}
+		contentNum := update.add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+
+		// 显式设置可继承的属性，不受页面树根上的 /Rotate、/CropBox 影响
+		update.set(pageNum, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /CropBox [0 0 %d %d] /Rotate 0 /Resources << /Font << /%s %d 0 R >> >> /Contents %d 0 R /Annots [%s] >>",
+			rootNum, tocPageWidth, tocPageHeight, tocPageWidth, tocPageHeight, tocFontName, fontNum, contentNum, refList(annots)))
+	}
+
+	root := rootBody
+	for i := len(pageNums) - 1; i >= 0; i-- {
+		root = []byte(withPrependedKid(root, pageNums[i], len(stats.Pages)+count))
+	}
+	update.set(rootNum, string(root))
+
+	tempPath := outputPath + ".toc.tmp"
+	if err := os.WriteFile(tempPath, update.bytes(), 0644); err != nil {
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法写入目录页",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return 0, &PDFError{
+			Type:    ErrorIO,
+			Message: "无法替换输出文件",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+	return count, nil
+}
+
+// tocLine 生成目录中一行的内容流片段：左对齐的标题、点引导线和右对齐的页码，标题过长时截断
+func tocLine(title, page string, y float64) string {
+	scale := float64(tocFontSize) / 1000
+	right := float64(tocPageWidth - tocMargin)
+	pageWidth := textWidth(page) * scale
+	dotWidth := textWidth(".") * scale
+	gap := 2 * dotWidth
+
+	title = asciiOnly(title)
+	maxTitle := right - tocMargin - pageWidth - 4*gap
+	if textWidth(title)*scale > maxTitle {
+		for len(title) > 0 && (textWidth(title)+textWidth("..."))*scale > maxTitle {
+			title = title[:len(title)-1]
+		}
+		title += "..."
+	}
+	titleEnd := tocMargin + textWidth(title)*scale
+
+	dots := int(math.Max(0, math.Floor((right-pageWidth-gap-titleEnd-gap)/dotWidth)))
+	leader := strings.Repeat(".", dots)
+	leaderStart := right - pageWidth - gap - float64(dots)*dotWidth
+
+	font := fmt.Sprintf("/%s %d Tf", tocFontName, tocFontSize)
+	return fmt.Sprintf("BT %s %d %s Td (%s) Tj ET\nBT %s %s %s Td (%s) Tj ET\nBT %s %s %s Td (%s) Tj ET\n",
+		font, tocMargin, formatNumber(y), escapePDFLiteral(title),
+		font, formatNumber(leaderStart), formatNumber(y), leader,
+		font, formatNumber(right-pageWidth), formatNumber(y), page)
+}
+
+// tocEntries 按顺序统计各来源的页数，返回目录条目（页码为插入目录页之前的页码）
+func tocEntries(sources []TOCSource, limits *PageTreeLimits) ([]TOCEntry, error) {
+	entries := make([]TOCEntry, len(sources))
+	page := 1
+	for i, source := range sources {
+		pages, err := CountPagesInFile(source.File, limits)
+		if err != nil {
+			return nil, err
+		}
+		display := source.DisplayName
+		if display == "" {
+			display = source.File
+		}
+		entries[i] = TOCEntry{Title: SourceBookmarkTitle(source.File, display), Page: page}
+		page += pages
+	}
+	return entries, nil
+}
@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tocContentsPattern = regexp.MustCompile(`/Contents\s+(\d+)\s+\d+\s+R`)
+
+// tocPage 读回目录页的内容流和链接目标（目标页在文件中的页码）
+type tocPage struct {
+	content string
+	links   []int
+}
+
+// readTOCPages 读回文件前count页的内容和链接
+func readTOCPages(t *testing.T, path string, count int) []tocPage {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	stats, err := WalkPageTree(path, data, nil)
+	require.NoError(t, err)
+	index := scanObjectIndex(data)
+	pageIndex := make(map[int]int, len(stats.Pages))
+	for i, num := range stats.Pages {
+		pageIndex[num] = i + 1
+	}
+
+	pages := make([]tocPage, count)
+	for i := range pages {
+		body, err := index.object(stats.Pages[i])
+		require.NoError(t, err)
+		m := tocContentsPattern.FindSubmatch(body)
+		require.NotNil(t, m)
+		num, _ := strconv.Atoi(string(m[1]))
+		stream, err := index.object(num)
+		require.NoError(t, err)
+		content, err := decodeStream(stream)
+		require.NoError(t, err)
+		pages[i].content = string(content)
+
+		annots := annotsArrayPattern.FindSubmatch(body)
+		require.NotNil(t, annots)
+		for _, ref := range parseArrayRefs(annots[0][len("/Annots"):]) {
+			annot, err := index.object(ref)
+			require.NoError(t, err)
+			dest := outlineDestPattern.FindSubmatch(annot)
+			require.NotNil(t, dest)
+			target, _ := strconv.Atoi(string(dest[1]))
+			pages[i].links = append(pages[i].links, pageIndex[target])
+		}
+	}
+	return pages
+}
+
+var annotsArrayPattern = regexp.MustCompile(`/Annots\s*\[[^\]]*\]`)
+
+func TestAddTOCPages(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(5))
+	output := filepath.Join(dir, "out.pdf")
+
+	count, err := AddTOCPages(input, output, []TOCEntry{{Title: "Annual report", Page: 1}, {Title: "Appendix", Page: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	pages, err := ReadPageCount(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 6, pages)
+
+	toc := readTOCPages(t, output, 1)[0]
+	assert.Contains(t, toc.content, "(Contents)")
+	assert.Contains(t, toc.content, "(Annual report)")
+	assert.Contains(t, toc.content, "(Appendix)")
+	// 页码包含目录页本身
+	assert.Contains(t, toc.content, "(2) Tj")
+	assert.Contains(t, toc.content, "(5) Tj")
+	assert.Regexp(t, `\(\.{10,}\) Tj`, toc.content, "标题和页码之间有点引导线")
+	assert.Equal(t, []int{2, 5}, toc.links)
+}
+
+func TestAddTOCPages_Paginates(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(40))
+	output := filepath.Join(dir, "out.pdf")
+
+	entries := make([]TOCEntry, 40)
+	for i := range entries {
+		entries[i] = TOCEntry{Title: fmt.Sprintf("report_%02d.pdf", i+1), Page: i + 1}
+	}
+	count, err := AddTOCPages(input, output, entries)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	pages, err := ReadPageCount(output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 42, pages)
+
+	toc := readTOCPages(t, output, 2)
+	assert.Len(t, toc[0].links, tocEntriesPerPage)
+	assert.Len(t, toc[1].links, 40-tocEntriesPerPage)
+	assert.Contains(t, toc[1].content, "(Contents \\(continued\\))")
+	assert.Contains(t, toc[1].content, "(report_40.pdf)")
+	assert.Equal(t, 3, toc[0].links[0])
+	assert.Equal(t, 42, toc[1].links[len(toc[1].links)-1])
+}
+
+func TestAddTOCPages_TruncatesLongTitles(t *testing.T) {
+	line := tocLine(strings.Repeat("Quarterly results ", 20), "12", 600)
+	assert.Contains(t, line, "...) Tj")
+	assert.NotContains(t, line, strings.Repeat("Quarterly results ", 6))
+}
+
+func TestAddTOCPages_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := createTestFile(t, dir, "merged.pdf", buildFlatPDF(2))
+	output := filepath.Join(dir, "out.pdf")
+
+	_, err := AddTOCPages(input, output, nil)
+	assert.Error(t, err)
+	_, err = AddTOCPages(input, output, []TOCEntry{{Title: "x", Page: 3}})
+	assert.Error(t, err)
+	assert.NoFileExists(t, output)
+}
+
+func TestMergeFiles_GenerateTOC(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(2))
+	b := createTestFile(t, dir, "b.pdf", buildFlatPDF(1))
+
+	merger := NewStreamingMerger(&MergeOptions{
+		TempDirectory:      dir,
+		BackendStats:       NewBackendStatsStore(),
+		GenerateTOC:        true,
+		AddSourceBookmarks: true,
+	})
+	defer merger.Close()
+	output := filepath.Join(dir, "out.pdf")
+	result, err := merger.MergeFiles([]string{a, b}, output, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TOCPages)
+	assert.Equal(t, 4, result.TotalPages)
+
+	toc := readTOCPages(t, output, 1)[0]
+	assert.Contains(t, toc.content, "(a.pdf)")
+	assert.Contains(t, toc.content, "(b.pdf)")
+	assert.Equal(t, []int{2, 4}, toc.links)
+
+	// 来源书签随目录页后移
+	outlines := readOutlines(t, output)
+	require.Len(t, outlines, 2)
+	assert.Equal(t, 2, outlines[0].Page)
+	assert.Equal(t, 4, outlines[1].Page)
+}
+
+func TestPDFService_AddTableOfContents(t *testing.T) {
+	dir := t.TempDir()
+	a := createTestFile(t, dir, "a.pdf", buildFlatPDF(3))
+	b := createTestFile(t, dir, "copy.pdf", buildFlatPDF(2))
+	merged := createTestFile(t, dir, "merged.pdf", buildFlatPDF(5))
+
+	service := NewPDFService()
+	require.NoError(t, service.AddTableOfContents(merged, []TOCSource{{File: a}, {File: b, DisplayName: "/in/locked.pdf"}}))
+
+	toc := readTOCPages(t, merged, 1)[0]
+	assert.Contains(t, toc.content, "(a.pdf)")
+	assert.Contains(t, toc.content, "(locked.pdf)")
+	assert.Equal(t, []int{2, 5}, toc.links)
+}
@@ -0,0 +1,188 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/user/pdf-merger/internal/i18n"
+)
+
+// IssueSeverity 验证问题的严重程度
+type IssueSeverity string
+
+const (
+	// SeverityError 文件无法按PDF处理
+	SeverityError IssueSeverity = "error"
+	// SeverityWarning 文件可以处理，但结构不规范，部分阅读器可能拒绝
+	SeverityWarning IssueSeverity = "warning"
+	// SeverityInfo 仅供参考的信息
+	SeverityInfo IssueSeverity = "info"
+)
+
+// IssueCategory 验证问题所在的文件结构
+type IssueCategory string
+
+const (
+	CategoryHeader     IssueCategory = "header"     // 文件头与版本
+	CategoryXRef       IssueCategory = "xref"       // 交叉引用表或交叉引用流
+	CategoryTrailer    IssueCategory = "trailer"    // trailer、startxref与 %%EOF
+	CategoryObject     IssueCategory = "object"     // 间接对象与页面树
+	CategoryStream     IssueCategory = "stream"     // 流数据
+	CategoryEncryption IssueCategory = "encryption" // 加密字典
+)
+
+// maxSummaryIssues GetUserFriendlyMessage 中列出的问题数
+const maxSummaryIssues = 3
+
+// backendObjectPattern 后端错误文本中的对象编号，例如 "obj#12" 或 "object 12"
+var backendObjectPattern = regexp.MustCompile(`(?i)\bobj(?:ect)?\s*#?\s*(\d+)`)
+
+// ValidationIssue 验证报告中的一条诊断
+type ValidationIssue struct {
+	Severity    IssueSeverity `json:"severity"`
+	Category    IssueCategory `json:"category"`
+	Message     string        `json:"message"`
+	Offset      int64         `json:"offset"`           // 发现问题的字节偏移，-1表示未知
+	Object      int           `json:"object,omitempty"` // 相关的对象编号，0表示不适用
+	Remediation string        `json:"remediation,omitempty"`
+	Check       string        `json:"check,omitempty"` // 产生该诊断的严格模式检查项（StrictCheck*），其他诊断为空
+}
+
+// String 返回单行描述，例如 "[error] xref 偏移 1024: startxref偏移超出文件大小（建议: ...）"
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", i.Severity, i.Category)
+	if i.Object > 0 {
+		fmt.Fprintf(&b, " 对象 %d", i.Object)
+	}
+	if i.Offset >= 0 {
+		fmt.Fprintf(&b, " 偏移 %d", i.Offset)
+	}
+	fmt.Fprintf(&b, ": %s", i.Message)
+	if i.Remediation != "" {
+		fmt.Fprintf(&b, "（建议: %s）", i.Remediation)
+	}
+	return b.String()
+}
+
+// ValidationIssuesError 携带验证诊断的错误，作为验证失败时PDFError的Cause
+type ValidationIssuesError struct {
+	Issues []ValidationIssue
+	Err    error
+}
+
+// Error 实现error接口
+func (e *ValidationIssuesError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	var messages []string
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap 返回底层错误
+func (e *ValidationIssuesError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationIssues 从错误链中提取验证诊断，没有时返回nil
+func ValidationIssues(err error) []ValidationIssue {
+	var issuesErr *ValidationIssuesError
+	if errors.As(err, &issuesErr) {
+		return issuesErr.Issues
+	}
+	return nil
+}
+
+// TopIssues 按严重程度排序（同级保持发现顺序）后返回前n条，n<=0时返回全部
+func TopIssues(issues []ValidationIssue, n int) []ValidationIssue {
+	sorted := append([]ValidationIssue(nil), issues...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return severityRank(sorted[a].Severity) < severityRank(sorted[b].Severity)
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// SummarizeIssues 每行一条列出最严重的n条问题，其余的以数量说明
+func SummarizeIssues(issues []ValidationIssue, n int) string {
+	top := TopIssues(issues, n)
+	lines := make([]string, 0, len(top)+1)
+	for _, issue := range top {
+		lines = append(lines, issue.String())
+	}
+	if rest := len(issues) - len(top); rest > 0 {
+		lines = append(lines, i18n.T(msgMoreIssues, rest))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// severityRank 严重程度的排序键，越严重越小
+func severityRank(severity IssueSeverity) int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// addIssue 记录一条诊断，并同步到旧的 Errors/Warnings 字段
+func (r *ValidationReport) addIssue(issue ValidationIssue) {
+	r.Issues = append(r.Issues, issue)
+	switch issue.Severity {
+	case SeverityError:
+		r.Errors = append(r.Errors, issue.Message)
+	case SeverityWarning:
+		r.Warnings = append(r.Warnings, issue.Message)
+	}
+}
+
+// newIssue 创建不带位置信息的诊断
+func newIssue(severity IssueSeverity, category IssueCategory, message, remediation string) ValidationIssue {
+	return ValidationIssue{
+		Severity:    severity,
+		Category:    category,
+		Message:     message,
+		Offset:      -1,
+		Remediation: remediation,
+	}
+}
+
+// backendIssue 把后端（pdfcpu）的验证错误转换为诊断，按错误文本推断类别和对象编号
+func backendIssue(err error) ValidationIssue {
+	message := err.Error()
+	lower := strings.ToLower(message)
+	category, remediation := CategoryObject, remedyResave
+	switch {
+	case strings.Contains(lower, "xref"):
+		category = CategoryXRef
+	case strings.Contains(lower, "trailer"), strings.Contains(lower, "eof"):
+		category = CategoryTrailer
+	case strings.Contains(lower, "encrypt"), strings.Contains(lower, "password"):
+		category, remediation = CategoryEncryption, "提供正确的密码后重试"
+	case strings.Contains(lower, "stream"):
+		category = CategoryStream
+	case strings.Contains(lower, "header"), strings.Contains(lower, "version"):
+		category, remediation = CategoryHeader, remedyConvert
+	}
+
+	issue := newIssue(SeverityError, category, message, remediation)
+	if m := backendObjectPattern.FindStringSubmatch(message); m != nil {
+		issue.Object, _ = strconv.Atoi(m[1])
+	}
+	return issue
+}
@@ -0,0 +1,116 @@
+package pdf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetValidationReport_StructuredIssues(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  []byte
+		severity IssueSeverity
+		category IssueCategory
+		offset   int64
+	}{
+		{"文件头", []byte("GIF89a" + strings.Repeat(" ", 200)), SeverityError, CategoryHeader, 0},
+		{"版本", []byte("%PDF-9.9\n" + strings.Repeat(" ", 200) + "\n%%EOF\n"), SeverityError, CategoryHeader, 4},
+		{"缺少EOF", append(buildFlatPDF(1)[:150], strings.Repeat(" ", 10)...), SeverityError, CategoryTrailer, 160},
+		{"缺少startxref", []byte("%PDF-1.4\n" + strings.Repeat("%", 200) + "\n%%EOF\n"), SeverityWarning, CategoryXRef, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createTestFile(t, dir, tt.name+".pdf", tt.content)
+			report, err := NewPDFValidator().GetValidationReport(path)
+			require.NoError(t, err)
+			require.NotEmpty(t, report.Issues)
+
+			issue := report.Issues[0]
+			assert.Equal(t, tt.severity, issue.Severity)
+			assert.Equal(t, tt.category, issue.Category)
+			assert.Equal(t, tt.offset, issue.Offset)
+			assert.NotEmpty(t, issue.Remediation, "每条诊断都应给出修复建议")
+			if tt.severity == SeverityError {
+				assert.False(t, report.IsValid)
+				assert.Equal(t, []string{issue.Message}, report.Errors, "Errors 应与错误级别的诊断一致")
+			} else {
+				assert.Contains(t, report.Warnings, issue.Message)
+			}
+		})
+	}
+}
+
+func TestGetValidationReport_XRefErrorCarriesOffset(t *testing.T) {
+	data := buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /W [1 2]"})
+	path := createTestFile(t, t.TempDir(), "bad_w.pdf", data)
+
+	report, err := NewPDFValidator().GetValidationReport(path)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, CategoryXRef, report.Issues[0].Category)
+	assert.Greater(t, report.Issues[0].Offset, int64(0), "交叉引用错误应指出startxref指向的偏移")
+}
+
+func TestGetValidationReport_EncryptedIsInfo(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "encrypted.pdf", []byte(createPDFContent("1.4", true, false)))
+	report, err := NewPDFValidator().GetValidationReport(path)
+	require.NoError(t, err)
+
+	var found bool
+	for _, issue := range report.Issues {
+		if issue.Category == CategoryEncryption {
+			found = true
+			assert.Equal(t, SeverityInfo, issue.Severity)
+		}
+	}
+	assert.True(t, found, "加密文件应有加密类别的诊断: %v", report.Issues)
+}
+
+func TestValidatePDFFile_ErrorCarriesIssues(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "text.pdf", []byte("not a pdf"+strings.Repeat(" ", 200)))
+	err := NewPDFValidator().ValidatePDFFile(path)
+
+	var pdfErr *PDFError
+	require.ErrorAs(t, err, &pdfErr)
+	assert.Equal(t, ErrorInvalidFile, pdfErr.Type)
+	issues := ValidationIssues(err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, CategoryHeader, issues[0].Category)
+
+	message := NewDefaultErrorHandler(3).GetUserFriendlyMessage(err)
+	assert.Contains(t, message, issues[0].String(), "友好消息应包含诊断摘要")
+}
+
+func TestSummarizeIssues_OrdersBySeverity(t *testing.T) {
+	issues := []ValidationIssue{
+		newIssue(SeverityInfo, CategoryEncryption, "信息", ""),
+		newIssue(SeverityWarning, CategoryXRef, "警告一", ""),
+		newIssue(SeverityError, CategoryObject, "错误", ""),
+		newIssue(SeverityWarning, CategoryXRef, "警告二", ""),
+	}
+
+	top := TopIssues(issues, 2)
+	require.Len(t, top, 2)
+	assert.Equal(t, "错误", top[0].Message)
+	assert.Equal(t, "警告一", top[1].Message, "同级诊断应保持发现顺序")
+
+	summary := SummarizeIssues(issues, 2)
+	assert.Equal(t, 3, strings.Count(summary, "\n")+1)
+	assert.Contains(t, summary, "另有 2 个问题")
+}
+
+func TestBackendIssue_Categorizes(t *testing.T) {
+	issue := backendIssue(errors.New("validation failed: invalid xref entry for obj#12"))
+	assert.Equal(t, CategoryXRef, issue.Category)
+	assert.Equal(t, 12, issue.Object)
+
+	issue = backendIssue(errors.New("stream length mismatch"))
+	assert.Equal(t, CategoryStream, issue.Category)
+	assert.Equal(t, 0, issue.Object)
+}
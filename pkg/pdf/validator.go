@@ -1,17 +1,27 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
 // PDFValidator 提供PDF文件验证功能
-type PDFValidator struct{}
+type PDFValidator struct {
+	mmapThreshold int64 // 超过该大小的文件映射到内存后检查，负数表示不映射
+}
 
-// NewPDFValidator 创建一个新的PDF验证器
+// NewPDFValidator 创建一个新的PDF验证器，使用默认的大文件阈值
 func NewPDFValidator() *PDFValidator {
-	return &PDFValidator{}
+	return NewPDFValidatorWithConfig(nil)
+}
+
+// NewPDFValidatorWithConfig 创建PDF验证器，大于config.LargeFileThreshold的文件映射到内存，
+// 文件头、trailer和交叉引用检查只读入用到的页面；config为nil时使用默认配置
+func NewPDFValidatorWithConfig(config *StreamingConfig) *PDFValidator {
+	return &PDFValidator{mmapThreshold: config.memoryMapThreshold()}
 }
 
 // ValidatePDFFile 验证PDF文件格式
@@ -39,71 +49,106 @@ func (v *PDFValidator) validateWithPDFCPU(filePath string) error {
 
 // validateBasic 基本验证方法（回退）
 func (v *PDFValidator) validateBasic(filePath string) error {
-	// 打开文件
-	file, err := os.Open(filePath)
-	if err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "无法打开文件",
+	_, _, err := v.checkBasic(filePath)
+	return err
+}
+
+// 常见问题的修复建议
+const (
+	remedyReacquire = "文件可能下载或复制不完整，请重新获取原始文件"
+	remedyResave    = "用PDF阅读器或编辑工具另存为新文件，可重建交叉引用和trailer"
+	remedyConvert   = "确认文件确实是PDF；其他格式需先转换为PDF"
+	remedyAccess    = "检查文件路径是否正确以及是否有读取权限"
+)
+
+// checkBasic 执行基本验证，并返回交叉引用检查结果和发现的诊断供验证报告使用。
+// 验证失败时返回的PDFError的Cause为ValidationIssuesError，其中包含此前的警告和导致失败的错误。
+func (v *PDFValidator) checkBasic(filePath string) (*XRefCheck, []ValidationIssue, error) {
+	var issues []ValidationIssue
+	fail := func(errType ErrorType, message string, cause error, issue ValidationIssue) ([]ValidationIssue, error) {
+		issues = append(issues, issue)
+		return issues, &PDFError{
+			Type:    errType,
+			Message: message,
 			File:    filePath,
-			Cause:   err,
+			Cause:   &ValidationIssuesError{Issues: issues, Err: cause},
 		}
 	}
+	headerIssue := func(message, remediation string) ValidationIssue {
+		issue := newIssue(SeverityError, CategoryHeader, message, remediation)
+		issue.Offset = 0
+		return issue
+	}
+
+	// 打开文件，大文件映射到内存
+	file, err := openMappedFile(filePath, v.mmapThreshold)
+	if err != nil {
+		issues, err := fail(ErrorIO, "无法打开文件", err,
+			newIssue(SeverityError, CategoryHeader, "无法打开文件", remedyAccess))
+		return nil, issues, err
+	}
 	defer file.Close()
 
 	// 读取文件头部
 	header := make([]byte, 8)
-	n, err := file.Read(header)
-	if err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "无法读取文件头部",
-			File:    filePath,
-			Cause:   err,
-		}
+	n, err := file.ReadAt(header, 0)
+	if err != nil && !(errors.Is(err, io.EOF) && n > 0) {
+		issues, err := fail(ErrorIO, "无法读取文件头部", err, headerIssue("无法读取文件头部", remedyAccess))
+		return nil, issues, err
 	}
 
 	if n < 4 {
-		return &PDFError{
-			Type:    ErrorInvalidFile,
-			Message: "文件太小，不是有效的PDF文件",
-			File:    filePath,
-		}
+		issues, err := fail(ErrorInvalidFile, "文件太小，不是有效的PDF文件", nil,
+			headerIssue(fmt.Sprintf("文件只有 %d 字节", n), remedyReacquire))
+		return nil, issues, err
 	}
 
 	// 检查PDF文件签名
 	headerStr := string(header[:4])
 	if headerStr != "%PDF" {
-		return &PDFError{
-			Type:    ErrorInvalidFile,
-			Message: "文件不是有效的PDF格式",
-			File:    filePath,
-		}
+		issues, err := fail(ErrorInvalidFile, "文件不是有效的PDF格式", nil,
+			headerIssue(fmt.Sprintf("文件头为 %q，不是 %%PDF", headerStr), remedyConvert))
+		return nil, issues, err
 	}
 
 	// 检查PDF版本
 	if n >= 8 {
 		versionStr := string(header[4:8])
 		if !v.isValidPDFVersion(versionStr) {
-			return &PDFError{
-				Type:    ErrorInvalidFile,
-				Message: fmt.Sprintf("不支持的PDF版本: %s", versionStr),
-				File:    filePath,
-			}
+			issue := headerIssue(fmt.Sprintf("不支持的PDF版本 %q", versionStr), "用PDF工具另存为1.0至2.0之间的版本")
+			issue.Offset = 4
+			issues, err := fail(ErrorInvalidFile, fmt.Sprintf("不支持的PDF版本: %s", versionStr), nil, issue)
+			return nil, issues, err
 		}
 	}
 
 	// 检查文件是否完整（查找EOF标记）
-	if err := v.checkPDFIntegrity(file); err != nil {
-		return &PDFError{
-			Type:    ErrorCorrupted,
-			Message: "PDF文件可能已损坏",
-			File:    filePath,
-			Cause:   err,
+	if err := v.checkPDFIntegrity(file, file.Size()); err != nil {
+		issue := newIssue(SeverityError, CategoryTrailer, err.Error(), remedyReacquire)
+		issue.Offset = file.Size()
+		issues, err := fail(ErrorCorrupted, "PDF文件可能已损坏", err, issue)
+		return nil, issues, err
+	}
+
+	// 检查startxref指向的交叉引用表或交叉引用流
+	xref, err := checkCrossReference(file, file.Size())
+	if err != nil {
+		issue := newIssue(SeverityError, CategoryXRef, err.Error(), remedyResave)
+		if xref != nil {
+			issue.Offset = xref.Offset
+		}
+		issues, err := fail(ErrorCorrupted, "PDF交叉引用无效", err, issue)
+		return nil, issues, err
+	}
+	for _, warning := range xref.Warnings {
+		issue := newIssue(SeverityWarning, CategoryXRef, warning, remedyResave)
+		if xref.Kind != XRefUnknown || xref.Offset > 0 {
+			issue.Offset = xref.Offset
 		}
+		issues = append(issues, issue)
 	}
 
-	return nil
+	return xref, issues, nil
 }
 
 // isValidPDFVersion 检查PDF版本是否有效
@@ -117,15 +162,8 @@ func (v *PDFValidator) isValidPDFVersion(version string) bool {
 	return false
 }
 
-// checkPDFIntegrity 检查PDF文件完整性
-func (v *PDFValidator) checkPDFIntegrity(file *os.File) error {
-	// 获取文件大小
-	stat, err := file.Stat()
-	if err != nil {
-		return err
-	}
-
-	fileSize := stat.Size()
+// checkPDFIntegrity 检查PDF文件完整性，只读取文件末尾
+func (v *PDFValidator) checkPDFIntegrity(file io.ReaderAt, fileSize int64) error {
 	if fileSize < 100 { // PDF文件至少应该有100字节
 		return fmt.Errorf("文件太小")
 	}
@@ -138,8 +176,7 @@ func (v *PDFValidator) checkPDFIntegrity(file *os.File) error {
 	}
 
 	buffer := make([]byte, bufferSize)
-	_, err = file.ReadAt(buffer, fileSize-bufferSize)
-	if err != nil {
+	if _, err := file.ReadAt(buffer, fileSize-bufferSize); err != nil && !errors.Is(err, io.EOF) {
 		return err
 	}
 
@@ -188,8 +225,14 @@ func (v *PDFValidator) GetBasicPDFInfo(filePath string) (*PDFInfo, error) {
 		isEncrypted = false
 	}
 
+	// pdfcpu无法解析时使用内置读取器获取页数，仍然失败时为-1
+	pageCount, err := ReadPageCount(filePath, nil)
+	if err != nil {
+		pageCount = -1
+	}
+
 	return &PDFInfo{
-		PageCount:   -1, // 需要PDF库才能准确获取页数
+		PageCount:   pageCount,
 		IsEncrypted: isEncrypted,
 		FileSize:    stat.Size(),
 		Title:       "", // 需要PDF库才能获取标题
@@ -224,45 +267,76 @@ func (v *PDFValidator) isPDFEncrypted(filePath string) (bool, error) {
 	return false, nil
 }
 
-// ValidateWithStrictMode 使用严格模式验证PDF文件
+// ValidateWithStrictMode 使用严格模式验证PDF文件。在pdfcpu严格验证（不可用时为基本验证）之外，
+// 还执行宽松模式容忍的结构检查，每项失败对应一条设置了Check的诊断：
+//   - StrictCheckXRefOffsets：交叉引用表中每个未压缩对象的偏移都指向该对象
+//   - StrictCheckEOFPosition：%%EOF 位于文件最后1024字节内
+//   - StrictCheckStreamLength：流的 /Length 之后紧跟 endstream
+//   - StrictCheckFilterNames：流只使用PDF规范定义的过滤器
+//   - StrictCheckTrailerSize：trailer的 /Size 等于交叉引用覆盖的对象数
+//
+// 任何检查失败时返回ErrorValidation类型的PDFError，其Cause为包含全部诊断的ValidationIssuesError
 func (v *PDFValidator) ValidateWithStrictMode(filePath string) error {
-	// 尝试使用pdfcpu的严格验证模式
+	issues, err := v.strictIssuesInFile(filePath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法读取文件",
+			File:    filePath,
+			Cause:   err,
+		}
+	}
+
+	var validateErr error
 	adapter, err := NewPDFCPUAdapter(&PDFCPUConfig{
 		ValidationMode: "strict",
 	})
 	if err == nil {
 		defer adapter.Close()
-		if err := adapter.ValidateFile(filePath); err == nil {
-			return nil
-		}
-		// 严格验证失败，返回错误
-		return &PDFError{
-			Type:    ErrorValidation,
-			Message: "PDF文件未通过严格验证",
-			File:    filePath,
-			Cause:   err,
+		if validateErr = adapter.ValidateFile(filePath); validateErr != nil {
+			issues = append(issues, backendIssue(validateErr))
 		}
+	} else if validateErr = v.validateBasic(filePath); validateErr != nil {
+		// pdfcpu不可用，回退到基本验证
+		issues = append(issues, ValidationIssues(validateErr)...)
 	}
 
-	// pdfcpu不可用，回退到基本验证
-	return v.validateBasic(filePath)
+	if len(issues) == 0 {
+		return nil
+	}
+	return &PDFError{
+		Type:    ErrorValidation,
+		Message: "PDF文件未通过严格验证",
+		File:    filePath,
+		Cause:   &ValidationIssuesError{Issues: issues, Err: validateErr},
+	}
 }
 
-// GetValidationReport 获取详细的验证报告
+// GetValidationReport 获取详细的验证报告。Issues 按发现顺序列出每条诊断，
+// Errors 和 Warnings 是其中错误和警告的文本，保留给只读取这两个字段的调用方。
 func (v *PDFValidator) GetValidationReport(filePath string) (*ValidationReport, error) {
 	report := &ValidationReport{
 		FilePath: filePath,
 		IsValid:  false,
 		Errors:   []string{},
 		Warnings: []string{},
+		Issues:   []ValidationIssue{},
 		Details:  make(map[string]interface{}),
 	}
 
 	// 基本文件检查
-	if err := v.validateBasic(filePath); err != nil {
-		report.Errors = append(report.Errors, err.Error())
+	xref, issues, err := v.checkBasic(filePath)
+	for _, issue := range issues {
+		report.addIssue(issue)
+	}
+	if err != nil {
 		return report, nil
 	}
+	report.Details["xrefType"] = string(xref.Kind)
+
+	if encrypted, err := hasEncryptEntry(filePath); err == nil && encrypted {
+		report.addIssue(newIssue(SeverityInfo, CategoryEncryption, "文件已加密", "合并或提取页面前需要提供密码"))
+	}
 
 	// 尝试使用pdfcpu获取详细信息
 	adapter, err := NewPDFCPUAdapter(nil)
@@ -271,7 +345,9 @@ func (v *PDFValidator) GetValidationReport(filePath string) (*ValidationReport,
 
 		// 验证文件
 		if err := adapter.ValidateFile(filePath); err != nil {
-			report.Errors = append(report.Errors, "pdfcpu验证失败: "+err.Error())
+			issue := backendIssue(err)
+			issue.Message = "pdfcpu验证失败: " + issue.Message
+			report.addIssue(issue)
 		} else {
 			report.IsValid = true
 		}
@@ -284,6 +360,7 @@ func (v *PDFValidator) GetValidationReport(filePath string) (*ValidationReport,
 			report.Details["title"] = info.Title
 		}
 	} else {
+		// 后端不可用不是文件本身的问题，不作为诊断记录
 		report.Warnings = append(report.Warnings, "pdfcpu不可用，使用基本验证")
 		report.IsValid = true // 基本验证已通过
 	}
@@ -291,6 +368,35 @@ func (v *PDFValidator) GetValidationReport(filePath string) (*ValidationReport,
 	return report, nil
 }
 
+// GetStrictValidationReport 在GetValidationReport的基础上加入ValidateWithStrictMode的结构检查，
+// 每项失败的检查作为一条设置了Check的错误诊断，存在这些诊断时IsValid为false
+func (v *PDFValidator) GetStrictValidationReport(filePath string) (*ValidationReport, error) {
+	report, err := v.GetValidationReport(filePath)
+	if err != nil {
+		return report, err
+	}
+	issues, err := v.strictIssuesInFile(filePath)
+	if err != nil {
+		return report, nil
+	}
+	report.Details["strict"] = true
+	for _, issue := range issues {
+		report.addIssue(issue)
+		report.IsValid = false
+	}
+	return report, nil
+}
+
+// strictIssuesInFile 对文件执行strictIssues，大文件映射到内存而不是读入堆
+func (v *PDFValidator) strictIssuesInFile(filePath string) ([]ValidationIssue, error) {
+	data, release, err := readFileData(filePath, v.mmapThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return strictIssues(data), nil
+}
+
 // CheckPermissions 检查PDF文件权限
 func (v *PDFValidator) CheckPermissions(filePath string) (*PDFPermissions, error) {
 	// 尝试使用pdfcpu获取权限信息
@@ -337,6 +443,7 @@ type ValidationReport struct {
 	IsValid  bool                   `json:"isValid"`
 	Errors   []string               `json:"errors"`
 	Warnings []string               `json:"warnings"`
+	Issues   []ValidationIssue      `json:"issues"`
 	Details  map[string]interface{} `json:"details"`
 }
 
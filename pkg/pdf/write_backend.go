@@ -0,0 +1,182 @@
+package pdf
+
+import (
+	"os"
+)
+
+// WriteBackend PDF写入器的存储后端。写入器先把内容写到暂存位置，加密和验证通过后提交到输出位置；
+// 加密和验证直接读取暂存路径，因此 WriteTemp 返回后暂存文件必须能在本地按该路径读取
+type WriteBackend interface {
+	// WriteTemp 把content写到tempPath，content为空时写入只有一页的基本PDF
+	WriteTemp(tempPath string, content []byte) error
+	// Promote 用tempPath的文件替换outputPath
+	Promote(tempPath, outputPath string) error
+	// Cleanup 删除暂存文件，文件不存在时不报错
+	Cleanup(tempPath string) error
+}
+
+// RemoteBackend 把输出提交到本地文件系统以外的后端，如对象存储。
+// 使用这类后端时输出路径只决定本地暂存文件的位置，提交后的输出位置由 Location 返回
+type RemoteBackend interface {
+	WriteBackend
+	// Location 返回提交后的输出位置，如 s3://bucket/key
+	Location() string
+}
+
+// outputLocation 返回提交后的输出位置：远程后端返回其位置，否则返回本地输出路径
+func outputLocation(backend WriteBackend, outputPath string) string {
+	if remote, ok := backend.(RemoteBackend); ok {
+		return remote.Location()
+	}
+	return outputPath
+}
+
+// fileWriteBackend 默认后端：写本地临时文件，再重命名到输出位置
+type fileWriteBackend struct {
+	adapter *PDFCPUAdapter // 内容为空时用于生成基本PDF，nil时写入内置的基本PDF
+}
+
+// WriteTemp 写入内容到临时文件
+func (b *fileWriteBackend) WriteTemp(tempPath string, content []byte) error {
+	// 创建临时文件
+	tempFile, err := os.Create(tempPath)
+	if err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法创建临时文件",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+	defer tempFile.Close()
+
+	if len(content) == 0 {
+		// 如果没有内容，创建一个空的PDF
+		return b.createBasicPDF(tempFile, tempPath)
+	}
+
+	// 写入内容
+	if _, err := tempFile.Write(content); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "写入临时文件失败",
+			File:    tempPath,
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// Promote 用临时文件替换输出文件
+func (b *fileWriteBackend) Promote(tempPath, outputPath string) error {
+	// 在Windows上，如果目标文件存在，需要先删除
+	if fileExists(outputPath) {
+		if err := os.Remove(outputPath); err != nil {
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: "无法删除现有输出文件",
+				File:    outputPath,
+				Cause:   err,
+			}
+		}
+	}
+
+	// 移动临时文件到最终位置
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		return &PDFError{
+			Type:    ErrorIO,
+			Message: "无法移动临时文件到最终位置",
+			File:    outputPath,
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// createBasicPDF 创建基本的PDF文件
+func (b *fileWriteBackend) createBasicPDF(file *os.File, tempPath string) error {
+	// 使用pdfcpu创建基本PDF
+	if b.adapter != nil && b.adapter.cliAdapter != nil {
+		// 使用pdfcpu create命令创建基本PDF
+		if err := b.adapter.cliAdapter.CreateTestPDF(tempPath, 1); err != nil {
+			return &PDFError{
+				Type:    ErrorProcessing,
+				Message: "无法创建基本PDF文件",
+				File:    tempPath,
+				Cause:   err,
+			}
+		}
+	} else {
+		// 回退到创建简单的PDF内容
+		basicPDF := `%PDF-1.4
+1 0 obj
+<<
+/Type /Catalog
+/Pages 2 0 R
+>>
+endobj
+2 0 obj
+<<
+/Type /Pages
+/Kids [3 0 R]
+/Count 1
+>>
+endobj
+3 0 obj
+<<
+/Type /Page
+/Parent 2 0 R
+/MediaBox [0 0 612 792]
+/Contents 4 0 R
+>>
+endobj
+4 0 obj
+<<
+/Length 44
+>>
+stream
+BT
+/F1 12 Tf
+72 720 Td
+(Generated PDF) Tj
+ET
+endstream
+endobj
+xref
+0 5
+0000000000 65535 f 
+0000000010 00000 n 
+0000000079 00000 n 
+0000000173 00000 n 
+0000000300 00000 n 
+trailer
+<<
+/Size 5
+/Root 1 0 R
+>>
+startxref
+400
+%%EOF`
+
+		if _, err := file.Write([]byte(basicPDF)); err != nil {
+			return &PDFError{
+				Type:    ErrorIO,
+				Message: "无法写入基本PDF内容",
+				File:    tempPath,
+				Cause:   err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Cleanup 删除临时文件
+func (b *fileWriteBackend) Cleanup(tempPath string) error {
+	if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
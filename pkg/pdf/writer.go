@@ -9,24 +9,29 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/internal/model"
 )
 
 // PDFWriter 提供增强的PDF写入功能，使用pdfcpu
 type PDFWriter struct {
-	outputPath        string
-	tempPath          string
-	isOpen            bool
-	mutex             sync.Mutex
-	retryCount        int
-	maxRetries        int
-	retryDelay        time.Duration
-	initialRetryDelay time.Duration
-	maxRetryDelay     time.Duration
-	backoffFactor     float64
-	backupEnabled     bool
-	adapter           *PDFCPUAdapter
-	config            *PDFCPUConfig
-	content           []byte // 存储要写入的内容
+	outputPath    string
+	tempPath      string
+	isOpen        bool
+	mutex         sync.Mutex
+	retryCount    int
+	outputSize    int64 // 最近一次提交的输出大小
+	retryPolicy   *RetryPolicy
+	backend       WriteBackend
+	backupEnabled bool
+	adapter       *PDFCPUAdapter
+	config        *PDFCPUConfig
+	content       []byte // 存储要写入的内容
+	clock         clock.Clock
+	encryption    *outputEncryption // 输出加密设置，nil时不加密
+	log           Logger            // 日志
+	closer        closeGuard        // Close契约：等待进行中的写入结束后再释放资源
 }
 
 // WriterOptions PDF写入器选项
@@ -36,6 +41,8 @@ type WriterOptions struct {
 	InitialRetryDelay time.Duration // 初始重试延迟
 	MaxRetryDelay     time.Duration // 最大重试延迟
 	BackoffFactor     float64       // 指数退避因子
+	RetryPolicy       *RetryPolicy  // 重试策略，设置时替代上面的次数和延迟字段
+	WriteBackend      WriteBackend  // 暂存与提交输出的后端，nil时写本地临时文件再重命名
 	BackupEnabled     bool          // 是否启用备份
 	TempDirectory     string        // 临时文件目录
 	ValidationMode    string        // pdfcpu验证模式
@@ -43,6 +50,15 @@ type WriterOptions struct {
 	WriteXRefStream   bool          // 是否写入交叉引用流
 	EncryptUsingAES   bool          // 是否使用AES加密
 	EncryptKeyLength  int           // 加密密钥长度
+	Clock             clock.Clock   // 时间与随机源，nil时使用系统时钟
+	Logger            Logger        // 日志，nil时使用默认日志
+
+	// OutputUserPassword 打开输出所需的用户密码；与OutputOwnerPassword都为空时输出不加密
+	OutputUserPassword string
+	// OutputOwnerPassword 输出的所有者密码，为空时使用用户密码
+	OutputOwnerPassword string
+	// OutputPermissions 加密输出允许的操作，nil时允许全部操作；设置时必须提供密码
+	OutputPermissions *OutputPermissions
 }
 
 // WriteResult 写入结果
@@ -57,6 +73,33 @@ type WriteResult struct {
 	ValidationTime time.Duration
 }
 
+// retryPolicy 返回写入使用的重试策略。没有设置RetryPolicy时由次数和延迟字段构造：
+// IO和处理错误最多重试MaxRetries次，退避默认从100ms开始翻倍，最长5s
+func (o *WriterOptions) retryPolicy() *RetryPolicy {
+	if o.RetryPolicy != nil {
+		return o.RetryPolicy
+	}
+	policy := &RetryPolicy{
+		MaxRetries: map[ErrorType]int{
+			ErrorIO:         o.MaxRetries,
+			ErrorProcessing: o.MaxRetries,
+		},
+		BackoffBase:   o.InitialRetryDelay,
+		BackoffCap:    o.MaxRetryDelay,
+		BackoffFactor: o.BackoffFactor,
+	}
+	if policy.BackoffBase == 0 {
+		policy.BackoffBase = o.RetryDelay
+	}
+	if policy.BackoffBase == 0 {
+		policy.BackoffBase = time.Millisecond * 100
+	}
+	if policy.BackoffCap == 0 {
+		policy.BackoffCap = time.Second * 5
+	}
+	return policy
+}
+
 // NewPDFWriter 创建新的PDF写入器
 func NewPDFWriter(outputPath string, options *WriterOptions) (*PDFWriter, error) {
 	if options == nil {
@@ -73,13 +116,24 @@ func NewPDFWriter(outputPath string, options *WriterOptions) (*PDFWriter, error)
 		}
 	}
 
+	// 写到本地文件时规范输出路径，使长路径和网络共享路径也能访问
+	if options.WriteBackend == nil {
+		outputPath = model.NormalizePath(outputPath)
+	}
+
 	// 验证输出路径
 	if err := validateOutputPath(outputPath); err != nil {
 		return nil, err
 	}
+	if err := validateEncryptionOptions(options.OutputUserPassword, options.OutputOwnerPassword, options.OutputPermissions); err != nil {
+		return nil, err
+	}
 
 	// 生成临时文件路径
-	tempPath := generateTempPath(outputPath, options.TempDirectory)
+	clk := clock.OrSystem(options.Clock)
+	tempPath := model.NormalizePath(generateTempPath(outputPath, options.TempDirectory, clk))
+
+	logger := loggerOrDefault(options.Logger)
 
 	// 创建pdfcpu配置
 	config := &PDFCPUConfig{
@@ -89,6 +143,7 @@ func NewPDFWriter(outputPath string, options *WriterOptions) (*PDFWriter, error)
 		EncryptUsingAES:   options.EncryptUsingAES,
 		EncryptKeyLength:  options.EncryptKeyLength,
 		TempDirectory:     options.TempDirectory,
+		Logger:            logger,
 	}
 
 	// 创建pdfcpu适配器
@@ -102,19 +157,24 @@ func NewPDFWriter(outputPath string, options *WriterOptions) (*PDFWriter, error)
 		}
 	}
 
+	backend := options.WriteBackend
+	if backend == nil {
+		backend = &fileWriteBackend{adapter: adapter}
+	}
+
 	writer := &PDFWriter{
-		outputPath:        outputPath,
-		tempPath:          tempPath,
-		isOpen:            false,
-		maxRetries:        options.MaxRetries,
-		retryDelay:        options.RetryDelay,
-		initialRetryDelay: options.InitialRetryDelay,
-		maxRetryDelay:     options.MaxRetryDelay,
-		backoffFactor:     options.BackoffFactor,
-		backupEnabled:     options.BackupEnabled,
-		adapter:           adapter,
-		config:            config,
-		content:           make([]byte, 0),
+		outputPath:    outputPath,
+		tempPath:      tempPath,
+		isOpen:        false,
+		retryPolicy:   options.retryPolicy(),
+		backend:       backend,
+		backupEnabled: options.BackupEnabled,
+		adapter:       adapter,
+		config:        config,
+		content:       make([]byte, 0),
+		clock:         clk,
+		encryption:    newOutputEncryption(options.OutputUserPassword, options.OutputOwnerPassword, options.OutputPermissions),
+		log:           logger,
 	}
 
 	return writer, nil
@@ -122,6 +182,11 @@ func NewPDFWriter(outputPath string, options *WriterOptions) (*PDFWriter, error)
 
 // Open 打开PDF写入器
 func (w *PDFWriter) Open() error {
+	if err := w.closer.enter("PDF写入器", w.outputPath); err != nil {
+		return err
+	}
+	defer w.closer.leave()
+
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
@@ -155,33 +220,37 @@ func (w *PDFWriter) Open() error {
 	return nil
 }
 
-// Close 关闭PDF写入器
+// Close 关闭PDF写入器。进行中的 Write 会先完成（包括重试），Close 再释放
+// 适配器和临时文件；之后的方法调用返回 ErrClosed，重复调用返回首次关闭的结果。
 func (w *PDFWriter) Close() error {
-	w.mutex.Lock()
-	defer w.mutex.Unlock()
+	return w.closer.close(func() error {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
 
-	if !w.isOpen {
-		return nil
-	}
-
-	// 关闭pdfcpu适配器
-	if w.adapter != nil {
-		w.adapter.Close()
-	}
+		// 关闭pdfcpu适配器
+		if w.adapter != nil {
+			w.adapter.Close()
+		}
 
-	w.isOpen = false
-	w.content = nil
+		w.isOpen = false
+		w.content = nil
 
-	// 清理临时文件
-	if w.tempPath != "" && fileExists(w.tempPath) {
-		os.Remove(w.tempPath)
-	}
+		// 清理临时文件
+		if w.tempPath != "" {
+			w.backend.Cleanup(w.tempPath)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // AddContent 添加内容到PDF写入器
 func (w *PDFWriter) AddContent(content []byte) error {
+	if err := w.closer.enter("PDF写入器", w.outputPath); err != nil {
+		return err
+	}
+	defer w.closer.leave()
+
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
@@ -200,6 +269,11 @@ func (w *PDFWriter) AddContent(content []byte) error {
 
 // Write 写入PDF文件（支持上下文取消和指数退避）
 func (w *PDFWriter) Write(ctx context.Context, progressWriter io.Writer) (*WriteResult, error) {
+	if err := w.closer.enter("PDF写入器", w.outputPath); err != nil {
+		return nil, err
+	}
+	defer w.closer.leave()
+
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
@@ -211,9 +285,9 @@ func (w *PDFWriter) Write(ctx context.Context, progressWriter io.Writer) (*Write
 		}
 	}
 
-	startTime := time.Now()
+	startTime := w.clock.Now()
 	result := &WriteResult{
-		OutputPath: w.outputPath,
+		OutputPath: outputLocation(w.backend, w.outputPath),
 		TempPath:   w.tempPath,
 	}
 
@@ -222,7 +296,7 @@ func (w *PDFWriter) Write(ctx context.Context, progressWriter io.Writer) (*Write
 	var rollbackMgr *RollbackManager
 	if w.backupEnabled && fileExists(w.outputPath) {
 		backupDir := filepath.Dir(w.outputPath)
-		rollbackMgr = NewRollbackManager(backupDir)
+		rollbackMgr = NewRollbackManagerWithConfig(backupDir, &RollbackConfig{Directory: DefaultBackupDirectory, Clock: w.clock})
 		backupPath, _ = rollbackMgr.BackupFile(w.outputPath)
 		result.BackupPath = backupPath
 		if progressWriter != nil && backupPath != "" {
@@ -230,72 +304,42 @@ func (w *PDFWriter) Write(ctx context.Context, progressWriter io.Writer) (*Write
 		}
 	}
 
-	// 尝试写入文件（带重试机制，支持指数退避和取消）
+	// 尝试写入文件（按重试策略退避重试，等待中可取消）
 	var writeErr error
-	delay := w.initialRetryDelay
-	if delay == 0 {
-		delay = w.retryDelay
-	}
-	if delay == 0 {
-		delay = time.Millisecond * 100
-	}
-	maxDelay := w.maxRetryDelay
-	if maxDelay == 0 {
-		maxDelay = time.Second * 5
-	}
-	factor := w.backoffFactor
-	if factor <= 1.0 {
-		factor = 2.0
-	}
+	for retry := 0; ; retry++ {
+		w.retryCount = retry
 
-	for attempt := 0; attempt <= w.maxRetries; attempt++ {
-		w.retryCount = attempt
-
-		if progressWriter != nil && attempt > 0 {
-			fmt.Fprintf(progressWriter, "重试写入文件 (第 %d/%d 次, 延迟: %v)...\n", attempt, w.maxRetries, delay)
+		if progressWriter != nil && retry > 0 {
+			fmt.Fprintf(progressWriter, "重试写入文件 (第 %d/%d 次)...\n", retry, w.retryPolicy.RetriesFor(writeErr))
 		}
 
 		writeErr = w.attemptWrite(progressWriter)
-		if writeErr == nil {
+		if writeErr == nil || retry >= w.retryPolicy.RetriesFor(writeErr) {
 			break
 		}
 
-		// 只对可恢复错误重试
-		pdfErr, ok := writeErr.(*PDFError)
-		if !ok || (pdfErr.Type != ErrorIO && pdfErr.Type != ErrorProcessing) {
-			break
+		delay := w.retryPolicy.Backoff(retry+1, w.clock)
+		if progressWriter != nil {
+			fmt.Fprintf(progressWriter, "写入失败，%v 后重试: %v\n", delay, writeErr)
 		}
-
-		// 如果不是最后一次尝试，等待后重试
-		if attempt < w.maxRetries {
+		if err := w.clock.Sleep(ctx, delay); err != nil {
 			if progressWriter != nil {
-				fmt.Fprintf(progressWriter, "写入失败，%v 后重试: %v\n", delay, writeErr)
-			}
-			select {
-			case <-ctx.Done():
-				if progressWriter != nil {
-					fmt.Fprintf(progressWriter, "写入操作被取消: %v\n", ctx.Err())
-				}
-				result.RetryCount = w.retryCount
-				result.WriteTime = time.Since(startTime)
-				result.Success = false
-				if rollbackMgr != nil && backupPath != "" {
-					_ = rollbackMgr.RestoreFile(backupPath, w.outputPath)
-				} else if backupPath != "" {
-					w.restoreBackup(backupPath)
-				}
-				return result, ctx.Err()
-			case <-time.After(delay):
+				fmt.Fprintf(progressWriter, "写入操作被取消: %v\n", err)
 			}
-			delay = time.Duration(float64(delay) * factor)
-			if delay > maxDelay {
-				delay = maxDelay
+			result.RetryCount = w.retryCount
+			result.WriteTime = w.clock.Now().Sub(startTime)
+			result.Success = false
+			if rollbackMgr != nil && backupPath != "" {
+				_ = rollbackMgr.RestoreFile(backupPath, w.outputPath)
+			} else if backupPath != "" {
+				w.restoreBackup(backupPath)
 			}
+			return result, err
 		}
 	}
 
 	result.RetryCount = w.retryCount
-	result.WriteTime = time.Since(startTime)
+	result.WriteTime = w.clock.Now().Sub(startTime)
 
 	if writeErr != nil {
 		result.Success = false
@@ -308,151 +352,48 @@ func (w *PDFWriter) Write(ctx context.Context, progressWriter io.Writer) (*Write
 		return result, writeErr
 	}
 
-	// 获取文件大小
-	if fileInfo, err := os.Stat(w.outputPath); err == nil {
-		result.FileSize = fileInfo.Size()
-	}
+	result.FileSize = w.outputSize
 
 	result.Success = true
 
 	if progressWriter != nil {
 		fmt.Fprintf(progressWriter, "PDF文件写入成功: %s (大小: %.2f MB, 用时: %v)\n",
-			w.outputPath, float64(result.FileSize)/(1024*1024), result.WriteTime)
+			result.OutputPath, float64(result.FileSize)/(1024*1024), result.WriteTime)
 	}
 
 	return result, nil
 }
 
-// attemptWrite 尝试写入文件
+// attemptWrite 尝试写入文件：写入暂存文件，需要时加密，验证后提交到输出位置
 func (w *PDFWriter) attemptWrite(progressWriter io.Writer) error {
 	// 首先写入临时文件
-	if err := writeToTempFile(w); err != nil {
+	if err := w.backend.WriteTemp(w.tempPath, w.content); err != nil {
 		return err
 	}
 
-	// 验证临时文件
-	if err := w.validateTempFile(); err != nil {
-		os.Remove(w.tempPath)
-		return err
-	}
-
-	// 原子性地移动临时文件到最终位置
-	if err := w.atomicMove(); err != nil {
-		os.Remove(w.tempPath)
-		return err
-	}
-
-	return nil
-}
-
-// 包级可替换的写入临时文件函数
-var writeToTempFile func(*PDFWriter) error = realWriteToTempFile
-
-// realWriteToTempFile 写入内容到临时文件（原 writeToTempFile 实现）
-func realWriteToTempFile(w *PDFWriter) error {
-	// 创建临时文件
-	tempFile, err := os.Create(w.tempPath)
-	if err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "无法创建临时文件",
-			File:    w.tempPath,
-			Cause:   err,
+	// 需要时加密临时文件，验证时提供密码
+	if w.encryption != nil {
+		if err := encryptInPlace(adapterFileBackend{adapter: w.adapter}, w.tempPath, w.encryption); err != nil {
+			w.backend.Cleanup(w.tempPath)
+			return err
 		}
 	}
-	defer tempFile.Close()
 
-	if len(w.content) == 0 {
-		// 如果没有内容，创建一个空的PDF
-		return w.createBasicPDF(tempFile)
+	// 验证临时文件
+	if err := w.validateTempFile(); err != nil {
+		w.backend.Cleanup(w.tempPath)
+		return err
 	}
 
-	// 写入内容
-	if _, err := tempFile.Write(w.content); err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "写入临时文件失败",
-			File:    w.tempPath,
-			Cause:   err,
-		}
+	// 提交前记录大小，远程后端提交后本地没有输出文件
+	if info, err := os.Stat(w.tempPath); err == nil {
+		w.outputSize = info.Size()
 	}
 
-	return nil
-}
-
-// createBasicPDF 创建基本的PDF文件
-func (w *PDFWriter) createBasicPDF(file *os.File) error {
-	// 使用pdfcpu创建基本PDF
-	if w.adapter != nil && w.adapter.cliAdapter != nil {
-		// 使用pdfcpu create命令创建基本PDF
-		if err := w.adapter.cliAdapter.CreateTestPDF(w.tempPath, 1); err != nil {
-			return &PDFError{
-				Type:    ErrorProcessing,
-				Message: "无法创建基本PDF文件",
-				File:    w.tempPath,
-				Cause:   err,
-			}
-		}
-	} else {
-		// 回退到创建简单的PDF内容
-		basicPDF := `%PDF-1.4
-1 0 obj
-<<
-/Type /Catalog
-/Pages 2 0 R
->>
-endobj
-2 0 obj
-<<
-/Type /Pages
-/Kids [3 0 R]
-/Count 1
->>
-endobj
-3 0 obj
-<<
-/Type /Page
-/Parent 2 0 R
-/MediaBox [0 0 612 792]
-/Contents 4 0 R
->>
-endobj
-4 0 obj
-<<
-/Length 44
->>
-stream
-BT
-/F1 12 Tf
-72 720 Td
-(Generated PDF) Tj
-ET
-endstream
-endobj
-xref
-0 5
-0000000000 65535 f 
-0000000010 00000 n 
-0000000079 00000 n 
-0000000173 00000 n 
-0000000300 00000 n 
-trailer
-<<
-/Size 5
-/Root 1 0 R
->>
-startxref
-400
-%%EOF`
-
-		if _, err := file.Write([]byte(basicPDF)); err != nil {
-			return &PDFError{
-				Type:    ErrorIO,
-				Message: "无法写入基本PDF内容",
-				File:    w.tempPath,
-				Cause:   err,
-			}
-		}
+	// 原子性地移动临时文件到最终位置
+	if err := w.backend.Promote(w.tempPath, w.outputPath); err != nil {
+		w.backend.Cleanup(w.tempPath)
+		return err
 	}
 
 	return nil
@@ -496,7 +437,7 @@ func (w *PDFWriter) validateTempFile() error {
 
 	// 使用pdfcpu验证PDF格式
 	if w.adapter != nil {
-		if err := w.adapter.ValidateFile(w.tempPath); err != nil {
+		if err := w.adapter.ValidateFileWithPassword(w.tempPath, w.encryption.validationPassword()); err != nil {
 			return &PDFError{
 				Type:    ErrorCorrupted,
 				Message: "生成的PDF文件格式无效",
@@ -555,43 +496,16 @@ func (w *PDFWriter) basicPDFValidation(filePath string) error {
 	return nil
 }
 
-// atomicMove 原子性地移动文件
-func (w *PDFWriter) atomicMove() error {
-	// 在Windows上，如果目标文件存在，需要先删除
-	if fileExists(w.outputPath) {
-		if err := os.Remove(w.outputPath); err != nil {
-			return &PDFError{
-				Type:    ErrorIO,
-				Message: "无法删除现有输出文件",
-				File:    w.outputPath,
-				Cause:   err,
-			}
-		}
-	}
-
-	// 移动临时文件到最终位置
-	if err := os.Rename(w.tempPath, w.outputPath); err != nil {
-		return &PDFError{
-			Type:    ErrorIO,
-			Message: "无法移动临时文件到最终位置",
-			File:    w.outputPath,
-			Cause:   err,
-		}
-	}
-
-	return nil
-}
-
 // createBackup 创建备份文件
 func (w *PDFWriter) createBackup() string {
 	if !fileExists(w.outputPath) {
 		return ""
 	}
 
-	backupPath := w.outputPath + ".backup." + time.Now().Format("20060102-150405")
+	backupPath := w.outputPath + ".backup." + w.clock.Now().Format("20060102-150405")
 	if err := copyFile(w.outputPath, backupPath); err != nil {
 		// 备份失败不是致命错误，只记录
-		fmt.Printf("Warning: 备份文件创建失败: %v\n", err)
+		w.log.Warn("备份文件创建失败: %v", err)
 		return ""
 	}
 
@@ -685,12 +599,19 @@ func checkDirectoryWritable(dir string) error {
 	return nil
 }
 
-// generateTempPath 生成临时文件路径
-func generateTempPath(outputPath, tempDir string) string {
+// generateTempPath 生成临时文件路径，时间戳后附加随机后缀以避免同一时刻的冲突
+func generateTempPath(outputPath, tempDir string, clk clock.Clock) string {
 	baseName := filepath.Base(outputPath)
 	ext := filepath.Ext(baseName)
 	name := strings.TrimSuffix(baseName, ext)
 
-	return filepath.Join(tempDir, fmt.Sprintf("%s_temp_%d%s",
-		name, time.Now().UnixNano(), ext))
+	return filepath.Join(tempDir, fmt.Sprintf("%s_temp_%d_%s%s",
+		name, clk.Now().UnixNano(), randomSuffix(clk), ext))
+}
+
+// randomSuffix 返回8位十六进制随机后缀
+func randomSuffix(clk clock.Clock) string {
+	var b [4]byte
+	clk.RandRead(b[:])
+	return fmt.Sprintf("%x", b)
 }
@@ -14,6 +14,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/user/pdf-merger/internal/clock"
 )
 
 // TestPDFWriterBasic 测试PDF写入器基本功能
@@ -332,6 +334,29 @@ func TestPDFWriterBackup(t *testing.T) {
 	})
 }
 
+func TestPDFWriterBackup_TimesFromClock(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "report.pdf")
+	require.NoError(t, os.WriteFile(outputPath, createWriterTestPDFContent("Initial content"), 0644))
+
+	writer, err := NewPDFWriter(outputPath, &WriterOptions{
+		BackupEnabled: true,
+		TempDirectory: dir,
+		Clock:         clock.NewFake(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), 1),
+	})
+	require.NoError(t, err)
+	defer writer.Close()
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.AddContent(createWriterTestPDFContent("New content")))
+
+	result, err := writer.Write(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, filepath.Base(result.BackupPath), ".20240506T070809")
+	assert.FileExists(t, result.BackupPath)
+	// 伪时钟不前进，写入耗时为0
+	assert.Zero(t, result.WriteTime)
+}
+
 // TestPDFWriterRetry 测试PDF写入器重试机制
 func TestPDFWriterRetry(t *testing.T) {
 	testDir := filepath.Join(os.TempDir(), "writer_retry_test")
@@ -975,6 +1000,40 @@ func BenchmarkPDFWriterLarge(b *testing.B) {
 	}
 }
 
+// fakeWriteBackend 测试用的写入后端：每次 WriteTemp 先调用 writeTemp，返回nil时由文件后端真正写入
+type fakeWriteBackend struct {
+	writeTemp func(attempt int) error // attempt 从1开始
+	files     fileWriteBackend
+	mu        sync.Mutex
+	attempts  int
+}
+
+func (b *fakeWriteBackend) WriteTemp(tempPath string, content []byte) error {
+	b.mu.Lock()
+	b.attempts++
+	attempt := b.attempts
+	b.mu.Unlock()
+	if err := b.writeTemp(attempt); err != nil {
+		return err
+	}
+	return b.files.WriteTemp(tempPath, content)
+}
+
+func (b *fakeWriteBackend) Promote(tempPath, outputPath string) error {
+	return b.files.Promote(tempPath, outputPath)
+}
+
+func (b *fakeWriteBackend) Cleanup(tempPath string) error {
+	return b.files.Cleanup(tempPath)
+}
+
+// Attempts 返回 WriteTemp 被调用的次数
+func (b *fakeWriteBackend) Attempts() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempts
+}
+
 // TestPDFWriterRetry_ExponentialBackoff 测试指数退避重试机制
 func TestPDFWriterRetry_ExponentialBackoff(t *testing.T) {
 	testDir := filepath.Join(os.TempDir(), "writer_retry_backoff_test")
@@ -983,22 +1042,15 @@ func TestPDFWriterRetry_ExponentialBackoff(t *testing.T) {
 
 	outputPath := filepath.Join(testDir, "backoff_test.pdf")
 
-	failCount := 2
-	var mu sync.Mutex
-
-	// hook: 模拟前两次写入失败
-	origWriteToTempFile := writeToTempFile
-	writeToTempFile = func(w *PDFWriter) error {
-		mu.Lock()
-		defer mu.Unlock()
-		if failCount > 0 {
-			failCount--
+	// 模拟前两次写入失败
+	backend := &fakeWriteBackend{writeTemp: func(attempt int) error {
+		if attempt <= 2 {
 			return &PDFError{Type: ErrorIO, Message: "模拟IO错误"}
 		}
-		return origWriteToTempFile(w)
-	}
-	defer func() { writeToTempFile = origWriteToTempFile }()
+		return nil
+	}}
 
+	fake := clock.NewFake(time.Unix(0, 0), 1)
 	options := &WriterOptions{
 		MaxRetries:        3,
 		InitialRetryDelay: time.Millisecond * 100,
@@ -1006,6 +1058,8 @@ func TestPDFWriterRetry_ExponentialBackoff(t *testing.T) {
 		BackoffFactor:     2.0,
 		BackupEnabled:     false,
 		TempDirectory:     testDir,
+		Clock:             fake,
+		WriteBackend:      backend,
 	}
 
 	writer, err := NewPDFWriter(outputPath, options)
@@ -1015,13 +1069,12 @@ func TestPDFWriterRetry_ExponentialBackoff(t *testing.T) {
 	require.NoError(t, writer.AddContent(createWriterTestPDFContent("backoff test")))
 
 	ctx := context.Background()
-	start := time.Now()
 	result, err := writer.Write(ctx, nil)
-	dur := time.Since(start)
 	assert.NoError(t, err)
 	assert.True(t, result.Success)
 	assert.Equal(t, 2, result.RetryCount)
-	assert.GreaterOrEqual(t, dur, time.Millisecond*100+time.Millisecond*200) // 至少两次递增延迟
+	// 两次递增的退避延迟，由虚拟时钟记录而不真实等待
+	assert.Equal(t, []time.Duration{time.Millisecond * 100, time.Millisecond * 200}, fake.Sleeps())
 }
 
 // TestPDFWriterRetry_ContextCancel 测试写入过程中取消
@@ -1032,19 +1085,17 @@ func TestPDFWriterRetry_ContextCancel(t *testing.T) {
 
 	outputPath := filepath.Join(testDir, "cancel_test.pdf")
 
-	failCount := 5
-	var mu sync.Mutex
-	origWriteToTempFile := writeToTempFile
-	writeToTempFile = func(w *PDFWriter) error {
-		mu.Lock()
-		defer mu.Unlock()
-		if failCount > 0 {
-			failCount--
-			return &PDFError{Type: ErrorIO, Message: "模拟IO错误"}
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 第二次写入失败时取消，之后不应再重试
+	backend := &fakeWriteBackend{writeTemp: func(attempt int) error {
+		if attempt == 2 {
+			cancel()
 		}
-		return origWriteToTempFile(w)
-	}
-	defer func() { writeToTempFile = origWriteToTempFile }()
+		return &PDFError{Type: ErrorIO, Message: "模拟IO错误"}
+	}}
 
 	options := &WriterOptions{
 		MaxRetries:        10,
@@ -1053,6 +1104,8 @@ func TestPDFWriterRetry_ContextCancel(t *testing.T) {
 		BackoffFactor:     2.0,
 		BackupEnabled:     false,
 		TempDirectory:     testDir,
+		Clock:             fake,
+		WriteBackend:      backend,
 	}
 
 	writer, err := NewPDFWriter(outputPath, options)
@@ -1061,15 +1114,11 @@ func TestPDFWriterRetry_ContextCancel(t *testing.T) {
 	require.NoError(t, writer.Open())
 	require.NoError(t, writer.AddContent(createWriterTestPDFContent("cancel test")))
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*250)
-	defer cancel()
-	start := time.Now()
 	result, err := writer.Write(ctx, nil)
-	dur := time.Since(start)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "context deadline")
+	assert.ErrorIs(t, err, context.Canceled)
 	assert.False(t, result.Success)
-	assert.GreaterOrEqual(t, dur, time.Millisecond*100) // 至少有一次重试
+	assert.Equal(t, 2, backend.Attempts())
+	assert.Equal(t, []time.Duration{time.Millisecond * 100}, fake.Sleeps()) // 取消前有一次重试
 }
 
 // TestPDFWriterRetry_ErrorType 测试不可恢复错误不重试
@@ -1080,12 +1129,10 @@ func TestPDFWriterRetry_ErrorType(t *testing.T) {
 
 	outputPath := filepath.Join(testDir, "errtype_test.pdf")
 
-	// hook: 第一次返回权限错误
-	origWriteToTempFile := writeToTempFile
-	writeToTempFile = func(w *PDFWriter) error {
+	// 第一次返回权限错误
+	backend := &fakeWriteBackend{writeTemp: func(int) error {
 		return &PDFError{Type: ErrorPermission, Message: "模拟权限错误"}
-	}
-	defer func() { writeToTempFile = origWriteToTempFile }()
+	}}
 
 	options := &WriterOptions{
 		MaxRetries:        3,
@@ -1094,13 +1141,13 @@ func TestPDFWriterRetry_ErrorType(t *testing.T) {
 		BackoffFactor:     2.0,
 		BackupEnabled:     false,
 		TempDirectory:     testDir,
+		WriteBackend:      backend,
 	}
 
 	writer, err := NewPDFWriter(outputPath, options)
 	require.NoError(t, err)
 	defer writer.Close()
 	require.NoError(t, writer.Open())
-	defer writer.Close()
 	_ = writer.AddContent(createWriterTestPDFContent("errtype test"))
 
 	ctx := context.Background()
@@ -1110,3 +1157,75 @@ func TestPDFWriterRetry_ErrorType(t *testing.T) {
 	assert.False(t, result.Success)
 	assert.Equal(t, 0, result.RetryCount) // 不应重试
 }
+
+// TestGenerateTempPath_UniqueAtSameInstant 同一时刻生成的临时路径不应冲突，且可由时钟复现
+func TestGenerateTempPath_UniqueAtSameInstant(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 3)
+	first := generateTempPath("/out/merged.pdf", "/tmp", fake)
+	second := generateTempPath("/out/merged.pdf", "/tmp", fake)
+	assert.NotEqual(t, first, second)
+	assert.True(t, strings.HasPrefix(filepath.Base(first), "merged_temp_"))
+	assert.Equal(t, ".pdf", filepath.Ext(first))
+
+	replay := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 3)
+	assert.Equal(t, first, generateTempPath("/out/merged.pdf", "/tmp", replay))
+}
+
+// TestPDFWriterRetry_Policy 设置重试策略时按策略的次数和退避重试
+func TestPDFWriterRetry_Policy(t *testing.T) {
+	testDir := filepath.Join(os.TempDir(), "writer_retry_policy_test")
+	require.NoError(t, os.MkdirAll(testDir, 0755))
+	defer os.RemoveAll(testDir)
+
+	outputPath := filepath.Join(testDir, "policy_test.pdf")
+
+	backend := &fakeWriteBackend{writeTemp: func(int) error {
+		return &PDFError{Type: ErrorIO, Message: "模拟IO错误"}
+	}}
+
+	fake := clock.NewFake(time.Unix(0, 0), 1)
+	options := &WriterOptions{
+		MaxRetries: 10, // 被策略替代
+		RetryPolicy: &RetryPolicy{
+			MaxRetries:  map[ErrorType]int{ErrorIO: 2},
+			BackoffBase: time.Millisecond * 50,
+		},
+		BackupEnabled: false,
+		TempDirectory: testDir,
+		Clock:         fake,
+		WriteBackend:  backend,
+	}
+
+	writer, err := NewPDFWriter(outputPath, options)
+	require.NoError(t, err)
+	defer writer.Close()
+	require.NoError(t, writer.Open())
+	require.NoError(t, writer.AddContent(createWriterTestPDFContent("policy test")))
+
+	result, err := writer.Write(context.Background(), nil)
+	assert.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, 3, backend.Attempts())
+	assert.Equal(t, 2, result.RetryCount)
+	assert.Equal(t, []time.Duration{time.Millisecond * 50, time.Millisecond * 100}, fake.Sleeps())
+}
+
+// TestFileWriteBackend 默认后端写入临时文件后替换已有的输出文件
+func TestFileWriteBackend(t *testing.T) {
+	dir := t.TempDir()
+	tempPath := filepath.Join(dir, "out.pdf.tmp")
+	outputPath := filepath.Join(dir, "out.pdf")
+	require.NoError(t, os.WriteFile(outputPath, []byte("old"), 0644))
+
+	backend := &fileWriteBackend{}
+	require.NoError(t, backend.WriteTemp(tempPath, []byte("new")))
+	require.NoError(t, backend.Promote(tempPath, outputPath))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	assert.False(t, fileExists(tempPath))
+
+	// 暂存文件不存在时清理不报错
+	assert.NoError(t, backend.Cleanup(tempPath))
+}
@@ -0,0 +1,343 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// XRefKind 文件最后一个交叉引用段的类型
+type XRefKind string
+
+const (
+	// XRefUnknown 无法确定交叉引用类型（缺少startxref或偏移不指向交叉引用）
+	XRefUnknown XRefKind = "unknown"
+	// XRefTable 传统交叉引用表（xref ... trailer）
+	XRefTable XRefKind = "table"
+	// XRefStream 交叉引用流（/Type /XRef 对象，PDF 1.5+）
+	XRefStream XRefKind = "stream"
+	// XRefHybrid 传统交叉引用表，trailer中通过 /XRefStm 指向补充的交叉引用流
+	XRefHybrid XRefKind = "hybrid"
+)
+
+const (
+	// xrefTailSize 查找startxref时读取的文件末尾字节数，与 %%EOF 检查一致
+	xrefTailSize = 1024
+	// xrefDictWindow 读取交叉引用流字典时的最大字节数
+	xrefDictWindow = 4096
+	// xrefTrailerWindow 读取trailer字典时的最大字节数
+	xrefTrailerWindow = 2048
+	// xrefMaxFieldWidth 交叉引用流单个字段的最大字节宽度
+	xrefMaxFieldWidth = 8
+	// xrefMaxSections 沿 /Prev 读取的交叉引用段的最大数量，防止循环或异常长的链
+	xrefMaxSections = 256
+)
+
+var (
+	xrefStreamTypePattern = regexp.MustCompile(`/Type\s*/XRef\b`)
+	xrefWidthsPattern     = regexp.MustCompile(`/W\s*\[([^\]]*)\]`)
+	xrefIndexPattern      = regexp.MustCompile(`/Index\s*\[([^\]]*)\]`)
+	xrefStmPattern        = regexp.MustCompile(`/XRefStm\s+(\d+)`)
+	xrefPrevPattern       = regexp.MustCompile(`/Prev\s+(\d+)`)
+	filterPattern         = regexp.MustCompile(`/Filter\b`)
+	xrefSubsectionPattern = regexp.MustCompile(`^(\d+)\s+(\d+)$`)
+	objectStartPattern    = regexp.MustCompile(`^(\d+)\s+(\d+)\s+obj\b`)
+)
+
+// XRefCheck 交叉引用的廉价检查结果。只解析字典和表头，不解压交叉引用流。
+type XRefCheck struct {
+	Kind     XRefKind
+	Offset   int64    // startxref 指向的偏移
+	Warnings []string // 不影响通过的可疑之处，例如手工构造文件中不准确的偏移
+}
+
+// checkCrossReference 检查startxref指向的交叉引用段。
+// 只有确定的损坏才返回错误：偏移超出文件范围、交叉引用流字典无效、/XRefStm 指向无效位置；
+// 偏移不准确等常见于手工生成文件的问题只记录为警告。
+// 已经找到startxref时，返回错误的同时也返回记录了偏移的检查结果。
+func checkCrossReference(r io.ReaderAt, size int64) (*XRefCheck, error) {
+	check := &XRefCheck{Kind: XRefUnknown}
+
+	offset, found, err := lastStartXRef(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		check.Warnings = append(check.Warnings, "文件末尾缺少startxref")
+		return check, nil
+	}
+	check.Offset = offset
+
+	window := readWindow(r, offset, size, xrefDictWindow)
+	trimmed := bytes.TrimLeft(window, " \t\r\n\f\x00")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("xref")):
+		check.Kind = XRefTable
+		trailer, err := readTrailerAfterTable(r, offset, size)
+		if err != nil {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("无法读取交叉引用表之后的trailer: %v", err))
+			return check, nil
+		}
+		if m := xrefStmPattern.FindSubmatch(trailer); m != nil {
+			stmOffset, _ := strconv.ParseInt(string(m[1]), 10, 64)
+			if err := checkXRefStreamAt(r, stmOffset, size); err != nil {
+				return check, fmt.Errorf("/XRefStm %d 无效: %w", stmOffset, err)
+			}
+			check.Kind = XRefHybrid
+		}
+	case objectStartPattern.Match(trimmed):
+		dict := streamDict(trimmed)
+		if !xrefStreamTypePattern.Match(dict) {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("startxref偏移 %d 指向的对象不是交叉引用流", offset))
+			return check, nil
+		}
+		if err := checkXRefStreamDict(dict); err != nil {
+			return check, fmt.Errorf("偏移 %d 处的交叉引用流无效: %w", offset, err)
+		}
+		check.Kind = XRefStream
+	default:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("startxref偏移 %d 既不指向xref也不指向对象", offset))
+	}
+	return check, nil
+}
+
+// lastStartXRef 返回文件末尾最后一个startxref的偏移，没有startxref时found为false
+func lastStartXRef(r io.ReaderAt, size int64) (offset int64, found bool, err error) {
+	tailSize := min(int64(xrefTailSize), size)
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil && err != io.EOF {
+		return 0, false, err
+	}
+	matches := startxrefPattern.FindAllSubmatch(tail, -1)
+	if len(matches) == 0 {
+		return 0, false, nil
+	}
+	value := matches[len(matches)-1][1]
+	offset, err = strconv.ParseInt(string(value), 10, 64)
+	if err != nil || offset >= size {
+		return 0, true, fmt.Errorf("startxref偏移 %s 超出文件大小 %d", value, size)
+	}
+	return offset, true, nil
+}
+
+// readTrailerDicts 从startxref开始沿 /Prev 读取各交叉引用段的trailer字典，最新的在前。
+// 交叉引用流的流字典即其trailer；混合引用文件还包含 /XRefStm 指向的流字典。
+// 最后一个交叉引用段无法读取时返回错误；较早的段无法读取时停止并返回已读取的字典。
+func readTrailerDicts(r io.ReaderAt, size int64) ([][]byte, error) {
+	offset, found, err := lastStartXRef(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("文件末尾缺少startxref")
+	}
+
+	var dicts [][]byte
+	visited := make(map[int64]bool)
+	for sections := 0; sections < xrefMaxSections && !visited[offset]; sections++ {
+		visited[offset] = true
+		dict, err := readSectionTrailer(r, offset, size)
+		if err != nil {
+			if len(dicts) == 0 {
+				return nil, err
+			}
+			break
+		}
+		dicts = append(dicts, dict)
+
+		if m := xrefStmPattern.FindSubmatch(dict); m != nil {
+			stmOffset, _ := strconv.ParseInt(string(m[1]), 10, 64)
+			if stm, err := readSectionTrailer(r, stmOffset, size); err == nil {
+				dicts = append(dicts, stm)
+			}
+		}
+
+		m := xrefPrevPattern.FindSubmatch(dict)
+		if m == nil {
+			break
+		}
+		if offset, err = strconv.ParseInt(string(m[1]), 10, 64); err != nil {
+			break
+		}
+	}
+	return dicts, nil
+}
+
+// readSectionTrailer 返回offset处交叉引用段的trailer字典：
+// 传统交叉引用表之后的trailer，或交叉引用流对象的流字典
+func readSectionTrailer(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	if offset < 0 || offset >= size {
+		return nil, fmt.Errorf("交叉引用偏移 %d 超出文件大小 %d", offset, size)
+	}
+	trimmed := bytes.TrimLeft(readWindow(r, offset, size, xrefDictWindow), " \t\r\n\f\x00")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("xref")):
+		return readTrailerAfterTable(r, offset, size)
+	case objectStartPattern.Match(trimmed):
+		dict := streamDict(trimmed)
+		if !xrefStreamTypePattern.Match(dict) {
+			return nil, fmt.Errorf("偏移 %d 指向的对象不是交叉引用流", offset)
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("偏移 %d 既不指向xref也不指向对象", offset)
+	}
+}
+
+// readWindow 读取从offset开始最多limit字节
+func readWindow(r io.ReaderAt, offset, size int64, limit int) []byte {
+	buf := make([]byte, min(int64(limit), size-offset))
+	n, _ := r.ReadAt(buf, offset)
+	return buf[:n]
+}
+
+// streamDict 返回对象头之后、stream关键字之前的字典内容
+func streamDict(object []byte) []byte {
+	if i := bytes.Index(object, []byte("stream")); i >= 0 {
+		return object[:i]
+	}
+	return object
+}
+
+// checkXRefStreamAt 检查offset处是否为有效的交叉引用流对象
+func checkXRefStreamAt(r io.ReaderAt, offset, size int64) error {
+	if offset >= size {
+		return fmt.Errorf("偏移超出文件大小 %d", size)
+	}
+	object := bytes.TrimLeft(readWindow(r, offset, size, xrefDictWindow), " \t\r\n\f\x00")
+	if !objectStartPattern.Match(object) {
+		return fmt.Errorf("偏移处不是对象")
+	}
+	dict := streamDict(object)
+	if !xrefStreamTypePattern.Match(dict) {
+		return fmt.Errorf("对象不是交叉引用流")
+	}
+	return checkXRefStreamDict(dict)
+}
+
+// checkXRefStreamDict 检查交叉引用流字典的 /W、/Size 和 /Index，
+// 未压缩时再检查 /Length 是否等于条目数乘以每行宽度
+func checkXRefStreamDict(dict []byte) error {
+	wMatch := xrefWidthsPattern.FindSubmatch(dict)
+	if wMatch == nil {
+		return fmt.Errorf("缺少 /W")
+	}
+	widths, err := parseIntArray(wMatch[1])
+	if err != nil || len(widths) != 3 {
+		return fmt.Errorf("/W [%s] 必须是3个整数", strings.TrimSpace(string(wMatch[1])))
+	}
+	rowWidth := 0
+	for _, w := range widths {
+		if w < 0 || w > xrefMaxFieldWidth {
+			return fmt.Errorf("/W 字段宽度 %d 无效", w)
+		}
+		rowWidth += w
+	}
+	if rowWidth == 0 {
+		return fmt.Errorf("/W 字段宽度之和为0")
+	}
+
+	sizeMatch := sizePattern.FindSubmatch(dict)
+	if sizeMatch == nil {
+		return fmt.Errorf("缺少 /Size")
+	}
+	entries, _ := strconv.Atoi(string(sizeMatch[1]))
+	total := entries
+
+	if m := xrefIndexPattern.FindSubmatch(dict); m != nil {
+		index, err := parseIntArray(m[1])
+		if err != nil || len(index) == 0 || len(index)%2 != 0 {
+			return fmt.Errorf("/Index [%s] 必须是成对的整数", strings.TrimSpace(string(m[1])))
+		}
+		total = 0
+		for i := 0; i < len(index); i += 2 {
+			start, count := index[i], index[i+1]
+			if start < 0 || count < 0 || start+count > entries {
+				return fmt.Errorf("/Index 子段 %d %d 超出 /Size %d", start, count, entries)
+			}
+			total += count
+		}
+	}
+
+	// 压缩的流需要解码才能核对长度，这里只核对未压缩的直接长度
+	if !filterPattern.Match(dict) {
+		if m := streamLengthPattern.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+			length, _ := strconv.Atoi(string(m[1]))
+			if length != total*rowWidth {
+				return fmt.Errorf("/Length %d 与 %d 个条目×每行 %d 字节不符", length, total, rowWidth)
+			}
+		}
+	}
+	return nil
+}
+
+// parseIntArray 解析数组中的整数
+func parseIntArray(s []byte) ([]int, error) {
+	fields := strings.Fields(string(s))
+	values := make([]int, len(fields))
+	for i, f := range fields {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// readTrailerAfterTable 按子段表头跳过传统交叉引用表的条目，返回其后的trailer字典
+func readTrailerAfterTable(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, offset, size-offset))
+	readLine := func() (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	// 跳过xref关键字前的空白行
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "xref" {
+			break
+		}
+		if line != "" {
+			return nil, fmt.Errorf("缺少xref关键字")
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "trailer") {
+			rest := []byte(strings.TrimPrefix(line, "trailer"))
+			more := make([]byte, xrefTrailerWindow)
+			n, _ := io.ReadFull(reader, more)
+			trailer := append(rest, more[:n]...)
+			if i := bytes.Index(trailer, []byte("startxref")); i >= 0 {
+				trailer = trailer[:i]
+			}
+			return trailer, nil
+		}
+		m := xrefSubsectionPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("无效的子段表头 %q", line)
+		}
+		count, _ := strconv.Atoi(m[2])
+		for i := 0; i < count; i++ {
+			if _, err := readLine(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xrefStreamFixture 描述只使用交叉引用流的测试文件，零值生成有效文件
+type xrefStreamFixture struct {
+	dict      string // 替换默认的 /Size 6 /W [1 2 1]
+	length    int    // 非零时替换 /Length
+	compress  bool
+	startxref int // 非零时替换 startxref
+}
+
+// objStmPage 对象流中保存的第3号页面对象
+const objStmPage = "3 0 << /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>"
+
+// xrefRow 生成 /W [1 2 1] 格式的交叉引用流条目
+func xrefRow(kind, field2, field3 int) []byte {
+	return []byte{byte(kind), byte(field2 >> 8), byte(field2), byte(field3)}
+}
+
+// writeCommonObjects 写出目录、页面树和包含页面对象的对象流（4号），返回各对象偏移
+func writeCommonObjects(buf *bytes.Buffer, header string) map[int]int {
+	offsets := make(map[int]int)
+	buf.WriteString(header)
+	offsets[1] = buf.Len()
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	offsets[2] = buf.Len()
+	buf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	offsets[4] = buf.Len()
+	fmt.Fprintf(buf, "4 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+		len(objStmPage), objStmPage)
+	return offsets
+}
+
+// buildXRefStreamPDF 生成只使用交叉引用流的文件：3号页面对象位于对象流中（类型2条目）
+func buildXRefStreamPDF(f xrefStreamFixture) []byte {
+	var buf bytes.Buffer
+	offsets := writeCommonObjects(&buf, "%PDF-1.5\n")
+	offsets[5] = buf.Len()
+
+	var rows []byte
+	rows = append(rows, xrefRow(0, 0, 255)...)
+	rows = append(rows, xrefRow(1, offsets[1], 0)...)
+	rows = append(rows, xrefRow(1, offsets[2], 0)...)
+	rows = append(rows, xrefRow(2, 4, 0)...)
+	rows = append(rows, xrefRow(1, offsets[4], 0)...)
+	rows = append(rows, xrefRow(1, offsets[5], 0)...)
+
+	dict := f.dict
+	if dict == "" {
+		dict = "/Size 6 /W [1 2 1]"
+	}
+	filter := ""
+	if f.compress {
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		w.Write(rows)
+		w.Close()
+		rows = compressed.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	length := len(rows)
+	if f.length != 0 {
+		length = f.length
+	}
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /XRef %s /Root 1 0 R /Length %d%s >>\nstream\n", dict, length, filter)
+	buf.Write(rows)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	startxref := offsets[5]
+	if f.startxref != 0 {
+		startxref = f.startxref
+	}
+	fmt.Fprintf(&buf, "startxref\n%d\n%%%%EOF\n", startxref)
+	return buf.Bytes()
+}
+
+// buildHybridPDF 生成混合引用文件：传统交叉引用表中对象3为空闲，
+// 其真实位置（对象流中）只记录在 /XRefStm 指向的交叉引用流中。
+// xrefStm 为负数时使用交叉引用流的真实偏移。
+func buildHybridPDF(xrefStm int) []byte {
+	var buf bytes.Buffer
+	offsets := writeCommonObjects(&buf, "%PDF-1.5\n")
+	offsets[5] = buf.Len()
+	row := xrefRow(2, 4, 0)
+	fmt.Fprintf(&buf, "5 0 obj\n<< /Type /XRef /Size 6 /Index [3 1] /W [1 2 1] /Length %d >>\nstream\n", len(row))
+	buf.Write(row)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	if xrefStm < 0 {
+		xrefStm = offsets[5]
+	}
+	xrefOffset := buf.Len()
+	buf.WriteString("xref\n0 6\n0000000000 65535 f \n")
+	fmt.Fprintf(&buf, "%010d 00000 n \n%010d 00000 n \n", offsets[1], offsets[2])
+	buf.WriteString("0000000000 00000 f \n")
+	fmt.Fprintf(&buf, "%010d 00000 n \n%010d 00000 n \n", offsets[4], offsets[5])
+	fmt.Fprintf(&buf, "trailer\n<< /Size 6 /Root 1 0 R /XRefStm %d >>\nstartxref\n%d\n%%%%EOF\n", xrefStm, xrefOffset)
+	return buf.Bytes()
+}
+
+// checkXRefBytes 对内存中的文件执行交叉引用检查
+func checkXRefBytes(data []byte) (*XRefCheck, error) {
+	return checkCrossReference(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestCheckCrossReference_ValidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		kind XRefKind
+	}{
+		{"classic table", buildFlatPDF(2), XRefTable},
+		{"xref stream only", buildXRefStreamPDF(xrefStreamFixture{}), XRefStream},
+		{"compressed xref stream", buildXRefStreamPDF(xrefStreamFixture{compress: true}), XRefStream},
+		{"xref stream with index", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /Index [0 6] /W [1 2 1]"}), XRefStream},
+		{"hybrid", buildHybridPDF(-1), XRefHybrid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := checkXRefBytes(tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, check.Kind)
+			assert.Empty(t, check.Warnings)
+
+			file := createTestFile(t, t.TempDir(), "valid.pdf", tt.data)
+			assert.NoError(t, NewPDFValidator().validateBasic(file), "有效文件应通过基本验证")
+		})
+	}
+}
+
+func TestCheckCrossReference_CorruptedFiles(t *testing.T) {
+	valid := buildXRefStreamPDF(xrefStreamFixture{})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		message string
+	}{
+		{"startxref beyond EOF", buildXRefStreamPDF(xrefStreamFixture{startxref: len(valid) + 100}), "超出文件大小"},
+		{"W with two fields", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /W [1 2]"}), "/W"},
+		{"W all zero", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /W [0 0 0]"}), "宽度之和为0"},
+		{"W negative", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /W [1 -2 1]"}), "字段宽度"},
+		{"missing Size", buildXRefStreamPDF(xrefStreamFixture{dict: "/W [1 2 1]"}), "/Size"},
+		{"index beyond Size", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /Index [0 9] /W [1 2 1]"}), "超出 /Size"},
+		{"index odd count", buildXRefStreamPDF(xrefStreamFixture{dict: "/Size 6 /Index [0 6 1] /W [1 2 1]"}), "/Index"},
+		{"length mismatch", buildXRefStreamPDF(xrefStreamFixture{length: 20}), "/Length 20"},
+		{"XRefStm beyond EOF", buildHybridPDF(1 << 20), "/XRefStm"},
+		{"XRefStm not an xref stream", buildHybridPDF(9), "不是交叉引用流"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := checkXRefBytes(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+
+			file := createTestFile(t, t.TempDir(), "corrupted.pdf", tt.data)
+			err = NewPDFValidator().validateBasic(file)
+			require.Error(t, err)
+			pdfErr, ok := err.(*PDFError)
+			require.True(t, ok)
+			assert.Equal(t, ErrorCorrupted, pdfErr.Type)
+		})
+	}
+}
+
+func TestCheckCrossReference_CompressedLengthNotChecked(t *testing.T) {
+	// 压缩的交叉引用流不解码，长度无法核对
+	data := buildXRefStreamPDF(xrefStreamFixture{compress: true, length: 7})
+	check, err := checkXRefBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, XRefStream, check.Kind)
+}
+
+func TestCheckCrossReference_InaccurateOffsetIsWarning(t *testing.T) {
+	data := buildFlatPDF(1)
+	data = startxrefPattern.ReplaceAll(data, []byte("startxref\n12"))
+
+	check, err := checkXRefBytes(data)
+	require.NoError(t, err, "偏移不准确但在文件范围内时不应判为损坏")
+	assert.Equal(t, XRefUnknown, check.Kind)
+	assert.NotEmpty(t, check.Warnings)
+}
+
+func TestPDFValidator_ExistingFixturesKeepClassification(t *testing.T) {
+	dir := t.TempDir()
+	validator := NewPDFValidator()
+
+	assert.NoError(t, validator.validateBasic(createTestPDFFile(t, dir, "valid.pdf")))
+
+	err := validator.validateBasic(createCorruptedPDFFile(t, dir, "corrupted.pdf"))
+	require.Error(t, err)
+	pdfErr, ok := err.(*PDFError)
+	require.True(t, ok)
+	assert.Equal(t, ErrorCorrupted, pdfErr.Type)
+}
+
+func TestPDFValidator_ReportIncludesXRefType(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "stream.pdf")
+	require.NoError(t, os.WriteFile(file, buildXRefStreamPDF(xrefStreamFixture{}), 0644))
+
+	report, err := NewPDFValidator().GetValidationReport(file)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, string(XRefStream), report.Details["xrefType"])
+}
@@ -0,0 +1,156 @@
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // 正常渲染
+	BreakerOpen     BreakerState = "open"      // 已熔断，直接显示通用图标
+	BreakerHalfOpen BreakerState = "half-open" // 冷却结束，允许一次试探渲染
+)
+
+// breaker 单个文件的熔断状态
+type breaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	lastErr  string
+	probing  bool // 半开状态下是否已有试探渲染在进行
+}
+
+// BreakerStats 熔断器状态快照，供指标展示
+type BreakerStats struct {
+	File                string       `json:"file"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	OpenedAt            time.Time    `json:"opened_at,omitempty"`
+}
+
+// allow 判断是否允许渲染该文件，冷却结束时转为半开并放行一次试探
+func (r *Renderer) allow(filePath string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, exists := r.breakers[filePath]
+	if !exists {
+		return true
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		if r.clock.Now().Sub(b.openedAt) < r.limits.BreakerCooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		r.logger.Printf("Thumbnail breaker half-open for %s, trying one render", filePath)
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordFailure 记录失败，连续失败达到阈值或半开试探失败时熔断
+func (r *Renderer) recordFailure(filePath string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.metrics.Failures++
+	if pdf.IsLimitExceededError(err) {
+		r.metrics.LimitRejections++
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		r.metrics.Timeouts++
+	}
+
+	b, exists := r.breakers[filePath]
+	if !exists {
+		b = &breaker{state: BreakerClosed}
+		r.breakers[filePath] = b
+	}
+	b.failures++
+	b.lastErr = err.Error()
+	b.probing = false
+
+	threshold := r.limits.FailureThreshold
+	if b.state == BreakerHalfOpen || (threshold > 0 && b.failures >= threshold) {
+		if b.state != BreakerOpen {
+			r.logger.Printf("Thumbnail breaker open for %s after %d consecutive failures: %v",
+				filePath, b.failures, err)
+		}
+		b.state = BreakerOpen
+		b.openedAt = r.clock.Now()
+	}
+}
+
+// abortProbe 试探渲染被调用方取消时，允许下一次请求重新试探
+func (r *Renderer) abortProbe(filePath string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if b, exists := r.breakers[filePath]; exists {
+		b.probing = false
+	}
+}
+
+// recordSuccess 记录成功，关闭熔断器
+func (r *Renderer) recordSuccess(filePath string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.metrics.Renders++
+	if b, exists := r.breakers[filePath]; exists {
+		if b.state != BreakerClosed {
+			r.logger.Printf("Thumbnail breaker closed for %s", filePath)
+		}
+		delete(r.breakers, filePath)
+	}
+}
+
+// BreakerState 返回文件当前的熔断状态
+func (r *Renderer) BreakerState(filePath string) BreakerState {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, exists := r.breakers[filePath]
+	if !exists {
+		return BreakerClosed
+	}
+	if b.state == BreakerOpen && r.clock.Now().Sub(b.openedAt) >= r.limits.BreakerCooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Breakers 返回所有存在失败记录的文件的熔断状态，按文件名排序
+func (r *Renderer) Breakers() []BreakerStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats := make([]BreakerStats, 0, len(r.breakers))
+	for file, b := range r.breakers {
+		stats = append(stats, BreakerStats{
+			File:                file,
+			State:               b.state,
+			ConsecutiveFailures: b.failures,
+			LastError:           b.lastErr,
+			OpenedAt:            b.openedAt,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].File < stats[j].File })
+	return stats
+}
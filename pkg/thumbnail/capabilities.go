@@ -0,0 +1,79 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os/exec"
+	"strconv"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// primaryRasterizerCommand 主光栅化器使用的外部命令（poppler-utils）
+const primaryRasterizerCommand = "pdftoppm"
+
+// lookPath 查找外部命令，可在测试中替换
+var lookPath = exec.LookPath
+
+// Capabilities 缩略图渲染能力
+type Capabilities struct {
+	Primary     bool   // 主光栅化器是否可用
+	PrimaryPath string // 主光栅化器路径
+	Approximate bool   // 是否使用近似渲染
+}
+
+// DetectCapabilities 检测可用的光栅化器
+func DetectCapabilities() Capabilities {
+	if path, err := lookPath(primaryRasterizerCommand); err == nil {
+		return Capabilities{Primary: true, PrimaryPath: path}
+	}
+	return Capabilities{Approximate: true}
+}
+
+// NewDefaultRasterizer 按能力选择光栅化器：主光栅化器不可用时绘制页面中的图像，
+// 没有可绘制图像的页面自动使用近似渲染
+func NewDefaultRasterizer() (Rasterizer, Capabilities) {
+	capabilities := DetectCapabilities()
+	if capabilities.Primary {
+		return &commandRasterizer{path: capabilities.PrimaryPath}, capabilities
+	}
+	return NewPageImageRasterizer(NewGeometryRasterizer()), capabilities
+}
+
+// commandRasterizer 调用 pdftoppm 渲染页面
+type commandRasterizer struct {
+	path string
+}
+
+// Render 实现Rasterizer接口，ctx取消时终止子进程
+func (c *commandRasterizer) Render(ctx context.Context, req RenderRequest) (image.Image, error) {
+	page := strconv.Itoa(req.Page)
+	cmd := exec.CommandContext(ctx, c.path,
+		"-f", page, "-l", page,
+		"-png", "-singlefile",
+		"-scale-to-x", strconv.Itoa(req.Width),
+		"-scale-to-y", strconv.Itoa(req.Height),
+		req.FilePath)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, pdf.NewPDFError(pdf.ErrorProcessing, "pdftoppm渲染失败: "+stderr.String(), req.FilePath, err)
+	}
+
+	// 解码前检查图像尺寸，避免外部工具输出超出像素预算的图像
+	config, err := png.DecodeConfig(bytes.NewReader(stdout.Bytes()))
+	if err != nil {
+		return nil, pdf.NewPDFError(pdf.ErrorProcessing, "无法解析pdftoppm输出", req.FilePath, err)
+	}
+	if err := req.CheckPixels(config.Width, config.Height); err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(stdout.Bytes()))
+}
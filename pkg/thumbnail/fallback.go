@@ -0,0 +1,511 @@
+package thumbnail
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// ApproximationLabel 近似渲染的水印文字
+const ApproximationLabel = "APPROX"
+
+// 近似渲染使用的颜色
+var (
+	pageColor      = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	backdropColor  = color.RGBA{0xE0, 0xE0, 0xE0, 0xFF}
+	outlineColor   = color.RGBA{0x60, 0x60, 0x60, 0xFF}
+	textBarColor   = color.RGBA{0xA0, 0xA0, 0xA0, 0xFF}
+	imageBoxColor  = color.RGBA{0x70, 0x90, 0xB0, 0xFF}
+	watermarkColor = color.RGBA{0xC0, 0x30, 0x30, 0xFF}
+)
+
+// GeometryStats 近似渲染绘制的元素数量
+type GeometryStats struct {
+	TextRuns int // 绘制的文本条数
+	Images   int // 绘制的图像占位框数
+}
+
+// GeometryRasterizer 纯Go的近似光栅化器，只绘制页面几何：
+// MediaBox轮廓、按Td/TJ等定位的灰色文本条和带叉的图像占位框，并带有近似水印。
+// 主光栅化器不可用时使用。
+type GeometryRasterizer struct{}
+
+// NewGeometryRasterizer 创建近似光栅化器
+func NewGeometryRasterizer() *GeometryRasterizer {
+	return &GeometryRasterizer{}
+}
+
+// Render 实现Rasterizer接口
+func (g *GeometryRasterizer) Render(ctx context.Context, req RenderRequest) (img image.Image, err error) {
+	// 近似渲染不能比通用图标更糟，任何意外都转为错误
+	defer func() {
+		if r := recover(); r != nil {
+			img, err = nil, pdf.NewPDFError(pdf.ErrorProcessing, fmt.Sprintf("近似渲染失败: %v", r), req.FilePath, nil)
+		}
+	}()
+
+	geometry, err := pdf.ReadPageGeometry(req.FilePath, req.Page)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rendered, _ := RenderGeometry(geometry, req.Width, req.Height)
+	return rendered, nil
+}
+
+// RenderGeometry 将页面几何绘制为width*height的图像，页面按比例居中
+func RenderGeometry(geometry *pdf.PageGeometry, width, height int) (*image.RGBA, GeometryStats) {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{backdropColor}, image.Point{}, draw.Src)
+
+	pageW, pageH := geometry.Width(), geometry.Height()
+	scale := math.Min(float64(width)/pageW, float64(height)/pageH)
+	offsetX := (float64(width) - pageW*scale) / 2
+	offsetY := (float64(height) - pageH*scale) / 2
+
+	p := &geometryPainter{
+		canvas:  canvas,
+		scale:   scale,
+		originX: offsetX - geometry.MediaBox[0]*scale,
+		// PDF坐标y轴向上，图像坐标y轴向下
+		originY: offsetY + geometry.MediaBox[3]*scale,
+	}
+
+	pageRect := image.Rect(int(offsetX), int(offsetY), int(offsetX+pageW*scale), int(offsetY+pageH*scale))
+	draw.Draw(canvas, pageRect, &image.Uniform{pageColor}, image.Point{}, draw.Src)
+	strokeRect(canvas, pageRect, outlineColor)
+
+	interpretContent(geometry, p)
+	drawWatermark(canvas, pageRect)
+
+	return canvas, p.stats
+}
+
+// matrix PDF变换矩阵 [a b c d e f]
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply 返回 m × n
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// apply 变换点
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// geometryPainter 在画布上绘制PDF用户空间中的元素
+type geometryPainter struct {
+	canvas  *image.RGBA
+	scale   float64
+	originX float64
+	originY float64
+	stats   GeometryStats
+}
+
+// toDevice 将PDF用户空间坐标转换为像素坐标
+func (p *geometryPainter) toDevice(x, y float64) (int, int) {
+	return int(p.originX + x*p.scale), int(p.originY - y*p.scale)
+}
+
+// textRun 绘制一段文本的灰色条，宽度按字符数估算
+func (p *geometryPainter) textRun(tm, ctm matrix, fontSize float64, chars int) {
+	if chars == 0 {
+		return
+	}
+	m := tm.multiply(ctm)
+	width := float64(chars) * fontSize * 0.5
+	x0, y0 := p.toDevice(m.apply(0, 0))
+	x1, y1 := p.toDevice(m.apply(width, fontSize*0.7))
+	fillRect(p.canvas, image.Rect(min(x0, x1), min(y0, y1), max(x0, x1)+1, max(y0, y1)+1), textBarColor)
+	p.stats.TextRuns++
+}
+
+// imageBox 绘制图像占位框：图像占据当前变换下的单位正方形
+func (p *geometryPainter) imageBox(ctm matrix) {
+	x0, y0 := p.toDevice(ctm.apply(0, 0))
+	x1, y1 := p.toDevice(ctm.apply(1, 1))
+	rect := image.Rect(min(x0, x1), min(y0, y1), max(x0, x1), max(y0, y1))
+	strokeRect(p.canvas, rect, imageBoxColor)
+	drawLine(p.canvas, rect.Min.X, rect.Min.Y, rect.Max.X-1, rect.Max.Y-1, imageBoxColor)
+	drawLine(p.canvas, rect.Min.X, rect.Max.Y-1, rect.Max.X-1, rect.Min.Y, imageBoxColor)
+	p.stats.Images++
+}
+
+// interpretContent 解释内容流中与几何相关的操作符，其余操作符忽略
+func interpretContent(geometry *pdf.PageGeometry, p *geometryPainter) {
+	var (
+		operands []contentToken
+		ctm      = identity
+		stack    []matrix
+		tm, tlm  = identity, identity
+		fontSize = 12.0
+		leading  = 0.0
+	)
+
+	nextLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+	number := func(i int) float64 {
+		if i < len(operands) {
+			return operands[i].number
+		}
+		return 0
+	}
+	// advance 文本绘制后按估算宽度移动文本矩阵
+	advance := func(chars int) {
+		tm = matrix{1, 0, 0, 1, float64(chars) * fontSize * 0.5, 0}.multiply(tm)
+	}
+
+	scanContent(geometry.Content, func(tok contentToken) {
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			return
+		}
+
+		switch tok.text {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(operands) >= 6 {
+				ctm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}.multiply(ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) >= 2 && operands[1].number > 0 {
+				fontSize = operands[1].number
+			}
+		case "TL":
+			leading = number(0)
+		case "Td":
+			nextLine(number(0), number(1))
+		case "TD":
+			leading = -number(1)
+			nextLine(number(0), number(1))
+		case "Tm":
+			if len(operands) >= 6 {
+				tlm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}
+				tm = tlm
+			}
+		case "T*":
+			nextLine(0, -leading)
+		case "Tj", "'", "\"":
+			if tok.text != "Tj" {
+				nextLine(0, -leading)
+			}
+			if len(operands) > 0 {
+				chars := operands[len(operands)-1].chars
+				p.textRun(tm, ctm, fontSize, chars)
+				advance(chars)
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				chars := operands[len(operands)-1].chars
+				p.textRun(tm, ctm, fontSize, chars)
+				advance(chars)
+			}
+		case "Do":
+			if len(operands) > 0 && geometry.ImageXObjects[operands[0].text] {
+				p.imageBox(ctm)
+			}
+		}
+		operands = operands[:0]
+	})
+}
+
+// 内容流记号类型
+const (
+	tokenNumber = iota
+	tokenString
+	tokenName
+	tokenArray
+	tokenOperator
+	tokenOther
+)
+
+// contentToken 内容流记号，数组记号只保留其中字符串的字符总数
+type contentToken struct {
+	kind   int
+	text   string
+	number float64
+	chars  int
+}
+
+// scanContent 扫描内容流记号。字典和内联图像按整体跳过。
+func scanContent(data []byte, emit func(contentToken)) {
+	i := 0
+	arrayDepth := 0
+	arrayChars := 0
+
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			n, next := scanLiteralString(data, i)
+			i = next
+			if arrayDepth > 0 {
+				arrayChars += n
+			} else {
+				emit(contentToken{kind: tokenString, chars: n})
+			}
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			i = skipDict(data, i)
+			if arrayDepth == 0 {
+				emit(contentToken{kind: tokenOther})
+			}
+		case c == '<':
+			start := i + 1
+			for i < len(data) && data[i] != '>' {
+				i++
+			}
+			n := (countHexDigits(data[start:min(i, len(data))]) + 1) / 2
+			i++
+			if arrayDepth > 0 {
+				arrayChars += n
+			} else {
+				emit(contentToken{kind: tokenString, chars: n})
+			}
+		case c == '[':
+			if arrayDepth == 0 {
+				arrayChars = 0
+			}
+			arrayDepth++
+			i++
+		case c == ']':
+			i++
+			if arrayDepth > 0 {
+				arrayDepth--
+				if arrayDepth == 0 {
+					emit(contentToken{kind: tokenArray, chars: arrayChars})
+				}
+			}
+		case c == '/':
+			start := i + 1
+			i++
+			for i < len(data) && !isSpace(data[i]) && !isDelimiter(data[i]) {
+				i++
+			}
+			if arrayDepth == 0 {
+				emit(contentToken{kind: tokenName, text: string(data[start:i])})
+			}
+		default:
+			start := i
+			for i < len(data) && !isSpace(data[i]) && !isDelimiter(data[i]) {
+				i++
+			}
+			if i == start {
+				i++
+				continue
+			}
+			word := string(data[start:i])
+			if arrayDepth > 0 {
+				continue
+			}
+			if v, err := strconv.ParseFloat(word, 64); err == nil {
+				emit(contentToken{kind: tokenNumber, number: v})
+				continue
+			}
+			if word == "BI" {
+				// 跳过内联图像数据直到 EI
+				i = skipInlineImage(data, i)
+				continue
+			}
+			emit(contentToken{kind: tokenOperator, text: word})
+		}
+	}
+}
+
+// scanLiteralString 扫描 (...) 字符串，返回字符数和结束位置
+func scanLiteralString(data []byte, i int) (int, int) {
+	depth := 0
+	chars := 0
+	for i < len(data) {
+		switch data[i] {
+		case '\\':
+			i += 2
+			chars++
+			continue
+		case '(':
+			depth++
+			if depth > 1 {
+				chars++
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return chars, i + 1
+			}
+			chars++
+		default:
+			chars++
+		}
+		i++
+	}
+	return chars, i
+}
+
+// skipDict 跳过 << ... >>
+func skipDict(data []byte, i int) int {
+	depth := 0
+	for i+1 < len(data) {
+		switch {
+		case data[i] == '<' && data[i+1] == '<':
+			depth++
+			i += 2
+		case data[i] == '>' && data[i+1] == '>':
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(data)
+}
+
+// skipInlineImage 跳过内联图像直到独立的 EI 操作符
+func skipInlineImage(data []byte, i int) int {
+	for i+2 < len(data) {
+		if isSpace(data[i]) && data[i+1] == 'E' && data[i+2] == 'I' && (i+3 == len(data) || isSpace(data[i+3])) {
+			return i + 3
+		}
+		i++
+	}
+	return len(data)
+}
+
+func countHexDigits(data []byte) int {
+	n := 0
+	for _, c := range data {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			n++
+		}
+	}
+	return n
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// fillRect 填充矩形（裁剪到画布）
+func fillRect(canvas *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	draw.Draw(canvas, rect.Intersect(canvas.Bounds()), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// strokeRect 绘制矩形边框
+func strokeRect(canvas *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	if rect.Empty() {
+		return
+	}
+	fillRect(canvas, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1), c)
+	fillRect(canvas, image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y), c)
+	fillRect(canvas, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y), c)
+	fillRect(canvas, image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y), c)
+}
+
+// drawLine 使用Bresenham算法画线
+func drawLine(canvas *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	bounds := canvas.Bounds()
+	for steps := 0; steps <= dx-dy; steps++ {
+		if image.Pt(x0, y0).In(bounds) {
+			canvas.SetRGBA(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawWatermark 在页面左上角标注近似渲染，并以斜纹标记页面角落
+func drawWatermark(canvas *image.RGBA, page image.Rectangle) {
+	label := &font.Drawer{
+		Dst:  canvas,
+		Src:  &image.Uniform{watermarkColor},
+		Face: basicfont.Face7x13,
+	}
+	textWidth := label.MeasureString(ApproximationLabel).Ceil()
+	box := image.Rect(page.Min.X+2, page.Min.Y+2, page.Min.X+textWidth+6, page.Min.Y+17).Intersect(page)
+	fillRect(canvas, box, pageColor)
+	strokeRect(canvas, box, watermarkColor)
+	label.Dot = fixed.P(box.Min.X+3, box.Min.Y+12)
+	label.DrawString(ApproximationLabel)
+
+	for i := 0; i < 3; i++ {
+		offset := 6 + i*4
+		drawLine(canvas, page.Max.X-offset, page.Max.Y-1, page.Max.X-1, page.Max.Y-offset, watermarkColor)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,198 @@
+package thumbnail
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// writeFixture 生成单页PDF夹具：两段文本和一个图像，内容流可选Flate压缩
+func writeFixture(t *testing.T, compress bool) string {
+	t.Helper()
+	content := []byte("BT /F1 12 Tf 72 720 Td (Hello World) Tj 0 -14 Td [(abc) -20 (def)] TJ ET\n" +
+		"q 200 0 0 100 100 300 cm /Im1 Do Q\n")
+
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(content)
+		w.Close()
+		content = buf.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /Im1 6 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(content), filter, content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray /BitsPerComponent 8 /Length 1 >>\nstream\n\x80\nendstream",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\n%%%%EOF\n", len(objects)+1)
+
+	path := filepath.Join(t.TempDir(), "fixture.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("写入夹具失败: %v", err)
+	}
+	return path
+}
+
+// withoutPrimaryRasterizer 模拟没有安装主光栅化器的环境
+func withoutPrimaryRasterizer(t *testing.T) {
+	t.Helper()
+	original := lookPath
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	t.Cleanup(func() { lookPath = original })
+}
+
+func TestNewDefaultRasterizer_FallbackWhenPrimaryMissing(t *testing.T) {
+	withoutPrimaryRasterizer(t)
+
+	rasterizer, capabilities := NewDefaultRasterizer()
+	if capabilities.Primary || !capabilities.Approximate {
+		t.Errorf("主光栅化器缺失时应选择近似渲染: %+v", capabilities)
+	}
+	if _, ok := rasterizer.(*PageImageRasterizer); !ok {
+		t.Fatalf("期望PageImageRasterizer，实际 %T", rasterizer)
+	}
+
+	renderer := NewRenderer(rasterizer, nil)
+	img, err := renderer.Render(context.Background(), RenderRequest{
+		FilePath: writeFixture(t, false), Page: 1, Width: 153, Height: 198,
+	})
+	if err != nil {
+		t.Fatalf("近似渲染失败: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 153, 198) {
+		t.Errorf("输出尺寸不正确: %v", img.Bounds())
+	}
+}
+
+func TestNewDefaultRasterizer_PrimaryWhenAvailable(t *testing.T) {
+	original := lookPath
+	lookPath = func(string) (string, error) { return "/usr/bin/pdftoppm", nil }
+	t.Cleanup(func() { lookPath = original })
+
+	rasterizer, capabilities := NewDefaultRasterizer()
+	if !capabilities.Primary || capabilities.Approximate {
+		t.Errorf("主光栅化器可用时不应使用近似渲染: %+v", capabilities)
+	}
+	if _, ok := rasterizer.(*commandRasterizer); !ok {
+		t.Errorf("期望commandRasterizer，实际 %T", rasterizer)
+	}
+}
+
+func TestGeometryRasterizer_PlaceholderCounts(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			path := writeFixture(t, compress)
+
+			img, err := NewGeometryRasterizer().Render(context.Background(), RenderRequest{FilePath: path, Page: 1, Width: 306, Height: 396})
+			if err != nil {
+				t.Fatalf("渲染失败: %v", err)
+			}
+			rgba := img.(*image.RGBA)
+
+			// 重新绘制以取得统计信息
+			_, stats := renderFixtureStats(t, path, 306, 396)
+			if stats.TextRuns != 2 || stats.Images != 1 {
+				t.Errorf("期望2条文本和1个图像，实际 %+v", stats)
+			}
+
+			// 图像位于 (100,300)-(300,400)，缩放0.5后占据像素 (50,196)-(150,246)
+			if rgba.RGBAAt(50, 245) != imageBoxColor {
+				t.Errorf("图像占位框位置不正确，像素颜色 %v", rgba.RGBAAt(50, 245))
+			}
+			// 第一段文本从 (72,720) 开始
+			if rgba.RGBAAt(37, 35) != textBarColor {
+				t.Errorf("文本条位置不正确，像素颜色 %v", rgba.RGBAAt(37, 35))
+			}
+			if !hasColor(rgba, watermarkColor) {
+				t.Error("近似渲染应带有水印")
+			}
+		})
+	}
+}
+
+func TestGeometryRasterizer_Fast(t *testing.T) {
+	path := writeFixture(t, true)
+	rasterizer := NewGeometryRasterizer()
+
+	best := time.Hour
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if _, err := rasterizer.Render(context.Background(), RenderRequest{FilePath: path, Page: 1, Width: 200, Height: 260}); err != nil {
+			t.Fatalf("渲染失败: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < best {
+			best = elapsed
+		}
+	}
+	if best > 50*time.Millisecond {
+		t.Errorf("近似渲染耗时 %v，超过50ms", best)
+	}
+}
+
+func TestGeometryRasterizer_BrokenFileFallsBackToIcon(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.pdf")
+	os.WriteFile(path, []byte("%PDF-1.4\ngarbage"), 0644)
+
+	renderer := NewRenderer(NewGeometryRasterizer(), nil)
+	if _, err := renderer.Render(context.Background(), RenderRequest{FilePath: path, Page: 1, Width: 64, Height: 80}); err == nil {
+		t.Error("损坏的文件应返回错误")
+	}
+	icon := renderer.Thumbnail(context.Background(), RenderRequest{FilePath: path, Page: 1, Width: 64, Height: 80})
+	if icon == nil || icon.Bounds().Dx() != 64 {
+		t.Error("失败时应返回通用图标")
+	}
+}
+
+func TestGeometryRasterizer_RespectsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewGeometryRasterizer().Render(ctx, RenderRequest{FilePath: writeFixture(t, false), Page: 1, Width: 10, Height: 10})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("期望context.Canceled，实际: %v", err)
+	}
+}
+
+// renderFixtureStats 直接调用RenderGeometry获取绘制统计
+func renderFixtureStats(t *testing.T, path string, width, height int) (*image.RGBA, GeometryStats) {
+	t.Helper()
+	geometry, err := pdf.ReadPageGeometry(path, 1)
+	if err != nil {
+		t.Fatalf("读取页面几何失败: %v", err)
+	}
+	return RenderGeometry(geometry, width, height)
+}
+
+func hasColor(img *image.RGBA, c color.RGBA) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.RGBAAt(x, y) == c {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package thumbnail
+
+import (
+	"context"
+	"image"
+
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// PageImageRasterizer 纯Go光栅化器，通过 pdf.RenderPageThumbnail 绘制页面直接引用的图像，
+// 适合扫描件等以图像为主的页面；页面没有可绘制的图像时交给fallback（通常是近似渲染）。
+// 输出按页面比例放入请求的尺寸，解码图像前检查请求的像素预算。
+type PageImageRasterizer struct {
+	fallback Rasterizer
+}
+
+// NewPageImageRasterizer 创建图像光栅化器，fallback为nil时没有图像的页面只绘制页面轮廓
+func NewPageImageRasterizer(fallback Rasterizer) *PageImageRasterizer {
+	return &PageImageRasterizer{fallback: fallback}
+}
+
+// Render 实现Rasterizer接口
+func (p *PageImageRasterizer) Render(ctx context.Context, req RenderRequest) (image.Image, error) {
+	img, drawn, err := pdf.RenderPageThumbnail(ctx, req.FilePath, req.Page, req.Width, req.Height, req.CheckPixels)
+	if err != nil {
+		return nil, err
+	}
+	if drawn == 0 && p.fallback != nil {
+		return p.fallback.Render(ctx, req)
+	}
+	return img, nil
+}
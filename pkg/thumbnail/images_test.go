@@ -0,0 +1,82 @@
+package thumbnail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTextFixture 生成只有文本、没有图像的单页PDF夹具
+func writeTextFixture(t *testing.T) string {
+	t.Helper()
+	content := "BT /F1 12 Tf 72 720 Td (Hello World) Tj ET\n"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\n%%%%EOF\n", len(objects)+1)
+
+	path := filepath.Join(t.TempDir(), "text.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("写入夹具失败: %v", err)
+	}
+	return path
+}
+
+func TestPageImageRasterizer_DrawsImagesOrFallsBack(t *testing.T) {
+	rasterizer := NewPageImageRasterizer(NewGeometryRasterizer())
+	req := RenderRequest{Page: 1, Width: 300, Height: 300, MaxPixels: DefaultLimits().MaxPixels}
+
+	// 有图像的页面按页面比例绘制，不带近似水印
+	req.FilePath = writeFixture(t, false)
+	img, err := rasterizer.Render(context.Background(), req)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 232, 300) {
+		t.Errorf("应按页面比例放入请求的尺寸，实际 %v", img.Bounds())
+	}
+	if rgba, ok := img.(*image.RGBA); !ok || hasColor(rgba, watermarkColor) {
+		t.Error("绘制了图像的页面不应使用近似渲染")
+	}
+
+	// 没有图像的页面交给近似渲染
+	req.FilePath = writeTextFixture(t)
+	img, err = rasterizer.Render(context.Background(), req)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if rgba, ok := img.(*image.RGBA); !ok || img.Bounds() != image.Rect(0, 0, 300, 300) || !hasColor(rgba, watermarkColor) {
+		t.Errorf("没有图像的页面应使用近似渲染，实际 %T %v", img, img.Bounds())
+	}
+}
+
+func TestPageImageRasterizer_EnforcesPixelBudget(t *testing.T) {
+	limits := DefaultLimits()
+	limits.MaxPixels = 100 * 100
+	renderer := NewRenderer(NewPageImageRasterizer(nil), limits)
+
+	// 输出尺寸在预算内，页面按比例缩放后的画布也在预算内
+	if _, err := renderer.Render(context.Background(), RenderRequest{FilePath: writeFixture(t, false), Page: 1, Width: 64, Height: 64}); err != nil {
+		t.Fatalf("预算内的渲染失败: %v", err)
+	}
+	if _, err := renderer.Render(context.Background(), RenderRequest{FilePath: writeFixture(t, false), Page: 1, Width: 200, Height: 200}); err == nil {
+		t.Fatal("超出像素预算时应返回错误")
+	}
+	if metrics := renderer.Metrics(); metrics.LimitRejections != 1 || metrics.Renders != 1 {
+		t.Errorf("指标不正确: %+v", metrics)
+	}
+}
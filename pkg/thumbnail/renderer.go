@@ -0,0 +1,230 @@
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// RenderRequest 缩略图渲染请求
+type RenderRequest struct {
+	FilePath string
+	Page     int // 页码，从1开始
+	Width    int // 输出宽度（像素）
+	Height   int // 输出高度（像素）
+
+	// MaxDepth 内容流递归（嵌套表单XObject）的最大深度，由Renderer填充
+	MaxDepth int
+	// MaxPixels 单次分配允许的最大像素数，由Renderer填充，光栅化器处理内嵌图像/软蒙版时也应遵守
+	MaxPixels int64
+}
+
+// EnterForm 光栅化器进入第depth层嵌套内容流前调用，超过深度上限时返回限制错误
+func (req RenderRequest) EnterForm(depth int) error {
+	if req.MaxDepth > 0 && depth > req.MaxDepth {
+		return limitError(req.FilePath, "MaxRecursionDepth", req.MaxDepth, depth)
+	}
+	return nil
+}
+
+// CheckPixels 光栅化器分配width*height的缓冲区前调用，超过像素预算时返回限制错误
+func (req RenderRequest) CheckPixels(width, height int) error {
+	pixels := int64(width) * int64(height)
+	if width < 0 || height < 0 || (req.MaxPixels > 0 && pixels > req.MaxPixels) {
+		return limitError(req.FilePath, "MaxPixels", int(req.MaxPixels), int(pixels))
+	}
+	return nil
+}
+
+// Rasterizer 页面光栅化器，实现需在ctx取消后尽快返回
+type Rasterizer interface {
+	Render(ctx context.Context, req RenderRequest) (image.Image, error)
+}
+
+// Limits 渲染限制
+type Limits struct {
+	Timeout           time.Duration // 单次渲染超时
+	MaxPixels         int64         // 单次分配的最大像素数（宽*高）
+	MaxRecursionDepth int           // 内容流递归的最大深度
+	FailureThreshold  int           // 同一文件连续失败多少次后熔断
+	BreakerCooldown   time.Duration // 熔断后多久允许一次试探渲染
+}
+
+// DefaultLimits 返回默认渲染限制
+func DefaultLimits() *Limits {
+	return &Limits{
+		Timeout:           5 * time.Second,
+		MaxPixels:         4096 * 4096,
+		MaxRecursionDepth: 32,
+		FailureThreshold:  3,
+		BreakerCooldown:   5 * time.Minute,
+	}
+}
+
+// ErrCircuitOpen 文件的缩略图渲染已被熔断
+var ErrCircuitOpen = errors.New("thumbnail rendering disabled for file after repeated failures")
+
+// Renderer 为光栅化器增加超时、像素预算、递归深度限制和按文件熔断，
+// 避免病态页面长时间占用CPU影响合并任务
+type Renderer struct {
+	rasterizer Rasterizer
+	limits     *Limits
+	logger     *log.Logger
+	clock      clock.Clock // 熔断冷却计时的时间来源
+
+	mutex    sync.Mutex
+	breakers map[string]*breaker
+	metrics  RenderMetrics
+}
+
+// RenderMetrics 渲染指标
+type RenderMetrics struct {
+	Renders           int64 // 成功渲染次数
+	Failures          int64 // 渲染失败次数（含超时和限制）
+	Timeouts          int64 // 超时次数
+	LimitRejections   int64 // 因像素或递归限制被拒绝的次数
+	BreakerRejections int64 // 因熔断被拒绝的次数
+	OpenBreakers      int   // 当前处于熔断（含半开）状态的文件数
+}
+
+// NewRenderer 创建渲染器，limits为nil时使用默认限制
+func NewRenderer(rasterizer Rasterizer, limits *Limits) *Renderer {
+	if limits == nil {
+		limits = DefaultLimits()
+	}
+	return &Renderer{
+		rasterizer: rasterizer,
+		limits:     limits,
+		logger:     log.New(os.Stdout, "[THUMBNAIL] ", log.LstdFlags),
+		breakers:   make(map[string]*breaker),
+		clock:      clock.System(),
+	}
+}
+
+// Render 在限制内渲染页面，失败时返回错误
+func (r *Renderer) Render(ctx context.Context, req RenderRequest) (image.Image, error) {
+	if r.rasterizer == nil {
+		return nil, pdf.NewPDFError(pdf.ErrorProcessing, "没有可用的光栅化器", req.FilePath, nil)
+	}
+
+	if !r.allow(req.FilePath) {
+		r.mutex.Lock()
+		r.metrics.BreakerRejections++
+		r.mutex.Unlock()
+		return nil, pdf.NewPDFError(pdf.ErrorProcessing, "缩略图渲染已熔断", req.FilePath, ErrCircuitOpen)
+	}
+
+	req.MaxDepth = r.limits.MaxRecursionDepth
+	req.MaxPixels = r.limits.MaxPixels
+
+	// 在调用光栅化器之前检查输出尺寸，避免分配超大缓冲区
+	if err := req.CheckPixels(req.Width, req.Height); err != nil {
+		r.recordFailure(req.FilePath, err)
+		return nil, err
+	}
+
+	img, err := r.renderWithTimeout(ctx, req)
+	if errors.Is(err, context.Canceled) {
+		// 调用方取消不计为文件的失败
+		r.abortProbe(req.FilePath)
+		return nil, err
+	}
+	if err != nil {
+		r.recordFailure(req.FilePath, err)
+		return nil, err
+	}
+	r.recordSuccess(req.FilePath)
+	return img, nil
+}
+
+// Thumbnail 渲染缩略图，任何失败都返回通用图标
+func (r *Renderer) Thumbnail(ctx context.Context, req RenderRequest) image.Image {
+	img, err := r.Render(ctx, req)
+	if err != nil {
+		return FallbackIcon(req.Width, req.Height)
+	}
+	return img
+}
+
+// renderWithTimeout 在单独的goroutine中渲染，超时后立即返回
+func (r *Renderer) renderWithTimeout(ctx context.Context, req RenderRequest) (image.Image, error) {
+	if r.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.limits.Timeout)
+		defer cancel()
+	}
+
+	type renderResult struct {
+		img image.Image
+		err error
+	}
+	done := make(chan renderResult, 1) // 带缓冲，超时后光栅化器返回时不会阻塞
+
+	go func() {
+		img, err := r.rasterizer.Render(ctx, req)
+		done <- renderResult{img: img, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err == nil && result.img == nil {
+			return nil, pdf.NewPDFError(pdf.ErrorProcessing, "光栅化器未返回图像", req.FilePath, nil)
+		}
+		return result.img, result.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, pdf.NewPDFError(pdf.ErrorProcessing,
+				fmt.Sprintf("渲染第%d页超时（%v）", req.Page, r.limits.Timeout), req.FilePath, ctx.Err())
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Metrics 返回渲染指标
+func (r *Renderer) Metrics() RenderMetrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	metrics := r.metrics
+	for _, b := range r.breakers {
+		if b.state != BreakerClosed {
+			metrics.OpenBreakers++
+		}
+	}
+	return metrics
+}
+
+// FallbackIcon 生成通用的文档图标，渲染失败时使用
+func FallbackIcon(width, height int) image.Image {
+	if width <= 0 || height <= 0 || int64(width)*int64(height) > 1024*1024 {
+		width, height = 64, 80
+	}
+	icon := image.NewGray(image.Rect(0, 0, width, height))
+	border := color.Gray{Y: 0x90}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x == 0 || y == 0 || x == width-1 || y == height-1 {
+				icon.SetGray(x, y, border)
+			} else {
+				icon.SetGray(x, y, color.Gray{Y: 0xF0})
+			}
+		}
+	}
+	return icon
+}
+
+// limitError 创建与页面树限制一致的限制错误
+func limitError(filePath, limit string, max, observed int) error {
+	return pdf.NewPDFError(pdf.ErrorLimitExceeded,
+		fmt.Sprintf("渲染超出限制 %s（上限 %d，实际 %d）", limit, max, observed), filePath,
+		&pdf.LimitExceededError{Limit: limit, Max: max, Observed: observed})
+}
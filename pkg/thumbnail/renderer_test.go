@@ -0,0 +1,183 @@
+package thumbnail
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/user/pdf-merger/internal/clock"
+	"github.com/user/pdf-merger/pkg/pdf"
+)
+
+// fakeRasterizer 可控的光栅化器，用于构造病态页面
+type fakeRasterizer struct {
+	calls     int32
+	block     bool // 一直阻塞直到ctx取消，模拟卡死的页面
+	nesting   int  // 页面中表单XObject的嵌套层数
+	imageSize int  // 页面内嵌图像（如软蒙版）的边长
+	fail      bool
+}
+
+func (f *fakeRasterizer) Render(ctx context.Context, req RenderRequest) (image.Image, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.fail {
+		return nil, errors.New("broken content stream")
+	}
+	for depth := 1; depth <= f.nesting; depth++ {
+		if err := req.EnterForm(depth); err != nil {
+			return nil, err
+		}
+	}
+	if f.imageSize > 0 {
+		if err := req.CheckPixels(f.imageSize, f.imageSize); err != nil {
+			return nil, err
+		}
+	}
+	return image.NewGray(image.Rect(0, 0, req.Width, req.Height)), nil
+}
+
+// withClock 让渲染器使用可推进的假时钟，返回推进时间的函数
+func withClock(renderer *Renderer) func(time.Duration) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	renderer.clock = fake
+	return fake.Advance
+}
+
+func TestRenderer_TimeoutFires(t *testing.T) {
+	limits := DefaultLimits()
+	limits.Timeout = 20 * time.Millisecond
+	renderer := NewRenderer(&fakeRasterizer{block: true}, limits)
+
+	start := time.Now()
+	_, err := renderer.Render(context.Background(), RenderRequest{FilePath: "slow.pdf", Page: 1, Width: 100, Height: 100})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望超时错误，实际: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超时未及时生效，耗时 %v", elapsed)
+	}
+	if metrics := renderer.Metrics(); metrics.Timeouts != 1 || metrics.Failures != 1 {
+		t.Errorf("超时指标不正确: %+v", metrics)
+	}
+}
+
+func TestRenderer_PixelCapRejectsBeforeRasterizing(t *testing.T) {
+	limits := DefaultLimits()
+	limits.MaxPixels = 1000 * 1000
+	rasterizer := &fakeRasterizer{}
+	renderer := NewRenderer(rasterizer, limits)
+
+	_, err := renderer.Render(context.Background(), RenderRequest{FilePath: "huge.pdf", Page: 1, Width: 20000, Height: 20000})
+	var limitErr *pdf.LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxPixels" {
+		t.Fatalf("期望MaxPixels限制错误，实际: %v", err)
+	}
+	if atomic.LoadInt32(&rasterizer.calls) != 0 {
+		t.Error("超出像素预算时不应调用光栅化器")
+	}
+
+	// 内嵌的超大软蒙版由光栅化器在分配前检查
+	rasterizer.imageSize = 50000
+	_, err = renderer.Render(context.Background(), RenderRequest{FilePath: "smask.pdf", Page: 1, Width: 100, Height: 100})
+	if !pdf.IsLimitExceededError(err) {
+		t.Errorf("期望软蒙版被像素预算拒绝，实际: %v", err)
+	}
+	if metrics := renderer.Metrics(); metrics.LimitRejections != 2 {
+		t.Errorf("期望2次限制拒绝，实际 %d", metrics.LimitRejections)
+	}
+}
+
+func TestRenderer_RecursionDepthCap(t *testing.T) {
+	limits := DefaultLimits()
+	limits.MaxRecursionDepth = 8
+	renderer := NewRenderer(&fakeRasterizer{nesting: 1000}, limits)
+
+	_, err := renderer.Render(context.Background(), RenderRequest{FilePath: "nested.pdf", Page: 1, Width: 100, Height: 100})
+	var limitErr *pdf.LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxRecursionDepth" || limitErr.Observed != 9 {
+		t.Fatalf("期望在第9层停止，实际: %v", err)
+	}
+}
+
+func TestRenderer_BreakerOpensAndHalfOpens(t *testing.T) {
+	limits := DefaultLimits()
+	limits.FailureThreshold = 3
+	limits.BreakerCooldown = time.Minute
+	rasterizer := &fakeRasterizer{fail: true}
+	renderer := NewRenderer(rasterizer, limits)
+	advance := withClock(renderer)
+	req := RenderRequest{FilePath: "bad.pdf", Page: 1, Width: 64, Height: 80}
+
+	for i := 0; i < 3; i++ {
+		if img := renderer.Thumbnail(context.Background(), req); img.Bounds().Dx() != 64 {
+			t.Fatalf("失败时应返回通用图标")
+		}
+	}
+	if state := renderer.BreakerState("bad.pdf"); state != BreakerOpen {
+		t.Fatalf("连续失败3次后应熔断，实际 %s", state)
+	}
+
+	// 熔断期间不再调用光栅化器
+	_, err := renderer.Render(context.Background(), req)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("期望ErrCircuitOpen，实际: %v", err)
+	}
+	if calls := atomic.LoadInt32(&rasterizer.calls); calls != 3 {
+		t.Errorf("熔断后不应调用光栅化器，调用次数 %d", calls)
+	}
+	metrics := renderer.Metrics()
+	if metrics.BreakerRejections != 1 || metrics.OpenBreakers != 1 {
+		t.Errorf("熔断指标不正确: %+v", metrics)
+	}
+
+	// 其他文件不受影响
+	if state := renderer.BreakerState("other.pdf"); state != BreakerClosed {
+		t.Errorf("其他文件不应熔断，实际 %s", state)
+	}
+
+	// 冷却结束后半开，试探失败重新熔断
+	advance(time.Minute)
+	if state := renderer.BreakerState("bad.pdf"); state != BreakerHalfOpen {
+		t.Fatalf("冷却后应半开，实际 %s", state)
+	}
+	renderer.Render(context.Background(), req)
+	if state := renderer.BreakerState("bad.pdf"); state != BreakerOpen {
+		t.Fatalf("试探失败后应重新熔断，实际 %s", state)
+	}
+
+	// 再次冷却后试探成功则关闭
+	advance(time.Minute)
+	rasterizer.fail = false
+	if _, err := renderer.Render(context.Background(), req); err != nil {
+		t.Fatalf("试探渲染失败: %v", err)
+	}
+	if state := renderer.BreakerState("bad.pdf"); state != BreakerClosed {
+		t.Errorf("试探成功后应关闭，实际 %s", state)
+	}
+	if len(renderer.Breakers()) != 0 {
+		t.Errorf("关闭后不应保留熔断记录: %+v", renderer.Breakers())
+	}
+}
+
+func TestRenderer_CallerCancelDoesNotTripBreaker(t *testing.T) {
+	limits := DefaultLimits()
+	limits.FailureThreshold = 1
+	renderer := NewRenderer(&fakeRasterizer{block: true}, limits)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := renderer.Render(ctx, RenderRequest{FilePath: "a.pdf", Page: 1, Width: 10, Height: 10})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望context.Canceled，实际: %v", err)
+	}
+	if state := renderer.BreakerState("a.pdf"); state != BreakerClosed {
+		t.Errorf("调用方取消不应触发熔断，实际 %s", state)
+	}
+}
@@ -57,7 +57,7 @@ func TestE2E_CompleteWorkflow(t *testing.T) {
 	// 设置回调以跟踪进度
 	var progressUpdates []string
 	var errorOccurred error
-	var completionMessage string
+	var completionResult *pdf.MergeResult
 
 	eventHandler.SetProgressUpdateCallback(func(progress float64, status, detail string) {
 		progressUpdates = append(progressUpdates, status)
@@ -69,9 +69,9 @@ func TestE2E_CompleteWorkflow(t *testing.T) {
 		t.Logf("错误发生: %v", err)
 	})
 
-	eventHandler.SetCompletionCallback(func(message string) {
-		completionMessage = message
-		t.Logf("完成: %s", message)
+	eventHandler.SetCompletionCallback(func(result *pdf.MergeResult) {
+		completionResult = result
+		t.Logf("完成: %s", result.OutputPath)
 	})
 
 	// 构建UI
@@ -142,8 +142,8 @@ cleanup:
 		t.Logf("测试过程中发生错误（可能是预期的）: %v", errorOccurred)
 	}
 
-	if completionMessage != "" {
-		t.Logf("收到完成消息: %s", completionMessage)
+	if completionResult != nil {
+		t.Logf("收到完成结果: %s，共 %d 页", completionResult.OutputPath, completionResult.TotalPages)
 	}
 
 	// 清理